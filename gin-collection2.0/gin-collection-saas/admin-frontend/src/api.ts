@@ -48,6 +48,15 @@ export const adminApi = {
 
   getHealth: () => api.get('/health'),
 
+  // Payment webhooks
+  getWebhookEvents: (page = 1, limit = 50, status = '') =>
+    api.get(`/webhooks?page=${page}&limit=${limit}${status ? `&status=${status}` : ''}`),
+
+  getWebhookEvent: (id: number) => api.get(`/webhooks/${id}`),
+
+  replayWebhookEvent: (id: number) =>
+    api.post(`/webhooks/${id}/replay`),
+
   // Server Management
   getServerStatus: () => api.get('/server/status'),
 
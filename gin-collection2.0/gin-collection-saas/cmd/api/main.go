@@ -1,28 +1,43 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"log"
 	"os"
+	"time"
 
 	"github.com/yourusername/gin-collection-saas/internal/delivery/http/handler"
 	adminHandler "github.com/yourusername/gin-collection-saas/internal/delivery/http/handler/admin"
 	"github.com/yourusername/gin-collection-saas/internal/delivery/http/middleware"
 	"github.com/yourusername/gin-collection-saas/internal/delivery/http/router"
+	"github.com/yourusername/gin-collection-saas/internal/domain/models"
+	"github.com/yourusername/gin-collection-saas/internal/infrastructure/billing"
 	"github.com/yourusername/gin-collection-saas/internal/infrastructure/cache"
 	"github.com/yourusername/gin-collection-saas/internal/infrastructure/database"
 	"github.com/yourusername/gin-collection-saas/internal/infrastructure/external"
 	"github.com/yourusername/gin-collection-saas/internal/infrastructure/storage"
 	"github.com/yourusername/gin-collection-saas/internal/repository/mysql"
 	adminUsecase "github.com/yourusername/gin-collection-saas/internal/usecase/admin"
+	apiKeyUsecase "github.com/yourusername/gin-collection-saas/internal/usecase/apikey"
 	"github.com/yourusername/gin-collection-saas/internal/usecase/auth"
 	botanicalUsecase "github.com/yourusername/gin-collection-saas/internal/usecase/botanical"
 	cocktailUsecase "github.com/yourusername/gin-collection-saas/internal/usecase/cocktail"
+	emailVerificationUsecase "github.com/yourusername/gin-collection-saas/internal/usecase/emailverification"
+	entitlementUsecase "github.com/yourusername/gin-collection-saas/internal/usecase/entitlement"
 	ginUsecase "github.com/yourusername/gin-collection-saas/internal/usecase/gin"
+	labelScanUsecase "github.com/yourusername/gin-collection-saas/internal/usecase/labelscan"
 	photoUsecase "github.com/yourusername/gin-collection-saas/internal/usecase/photo"
+	roleUsecase "github.com/yourusername/gin-collection-saas/internal/usecase/role"
+	scimUsecase "github.com/yourusername/gin-collection-saas/internal/usecase/scim"
+	ssoUsecase "github.com/yourusername/gin-collection-saas/internal/usecase/sso"
+	storageSyncUsecase "github.com/yourusername/gin-collection-saas/internal/usecase/storagesync"
 	subscriptionUsecase "github.com/yourusername/gin-collection-saas/internal/usecase/subscription"
 	tastingUsecase "github.com/yourusername/gin-collection-saas/internal/usecase/tasting"
+	twoFactorUsecase "github.com/yourusername/gin-collection-saas/internal/usecase/twofactor"
+	usageUsecase "github.com/yourusername/gin-collection-saas/internal/usecase/usage"
 	userUsecase "github.com/yourusername/gin-collection-saas/internal/usecase/user"
+	webAuthnUsecase "github.com/yourusername/gin-collection-saas/internal/usecase/webauthn"
 	"github.com/yourusername/gin-collection-saas/pkg/config"
 	"github.com/yourusername/gin-collection-saas/pkg/logger"
 	"github.com/yourusername/gin-collection-saas/pkg/utils"
@@ -86,6 +101,23 @@ func main() {
 	tastingRepo := mysql.NewTastingSessionRepository(db)
 	passwordResetRepo := mysql.NewPasswordResetRepository(db)
 	passwordHistoryRepo := mysql.NewPasswordHistoryRepository(db)
+	uploadSessionRepo := mysql.NewPhotoUploadSessionRepository(db)
+	storageUsageRepo := mysql.NewStorageUsageRepository(db)
+	inviteTokenRepo := mysql.NewInviteTokenRepository(db)
+	twoFactorRepo := mysql.NewTwoFactorRepository(db)
+	webAuthnRepo := mysql.NewWebAuthnRepository(db)
+	ssoRepo := mysql.NewSSORepository(db)
+	scimRepo := mysql.NewSCIMRepository(db)
+	sessionRepo := mysql.NewSessionRepository(db)
+	apiKeyRepo := mysql.NewAPIKeyRepository(db)
+	roleRepo := mysql.NewRoleRepository(db)
+	emailVerificationRepo := mysql.NewEmailVerificationRepository(db)
+	loginDeviceRepo := mysql.NewLoginDeviceRepository(db)
+	webhookEventRepo := mysql.NewWebhookEventRepository(db)
+	couponRepo := mysql.NewCouponRepository(db)
+	lockRepo := mysql.NewLockRepository(db)
+	invoiceRepo := mysql.NewInvoiceRepository(db)
+	planRepo := mysql.NewPlanRepository(db)
 
 	logger.Info("Repositories initialized")
 
@@ -94,20 +126,62 @@ func main() {
 		ClientID:     cfg.PayPal.ClientID,
 		ClientSecret: cfg.PayPal.ClientSecret,
 		Mode:         cfg.PayPal.Mode,
+		WebhookID:    cfg.PayPal.WebhookID,
 	})
+	if cfg.PayPal.WebhookID == "" {
+		logger.Warn("PAYPAL_WEBHOOK_ID is not set, PayPal webhooks will be rejected")
+	}
+
+	// Load the plan catalog
+	planCatalog := subscriptionUsecase.NewCatalog(planRepo)
+	if err := planCatalog.Load(context.Background()); err != nil {
+		log.Fatalf("Failed to load plan catalog: %v", err)
+	}
+	logger.Info("Plan catalog loaded", "plans", len(planCatalog.Plans()))
+
+	// Entitlements decide what tenants may use, upgrade hints come from the catalog
+	entitlementService := entitlementUsecase.NewService(storageUsageRepo)
+	entitlementService.SetPlanCatalog(planCatalog)
+
+	// Initialize billing providers, Stripe is optional. Plans are mapped with
+	// the provider plans of the catalog, the configured ones are the fallback.
+	paypalProvider := billing.NewPayPalProvider(paypalClient, billing.Plans(cfg.PayPal.PlanIDs))
+	paypalProvider.SetCatalog(planCatalog)
+	billingProviders := []billing.BillingProvider{paypalProvider}
+	if cfg.Stripe.SecretKey != "" {
+		stripeClient := external.NewStripeClient(&external.StripeConfig{
+			SecretKey:     cfg.Stripe.SecretKey,
+			WebhookSecret: cfg.Stripe.WebhookSecret,
+		})
+		stripeProvider := billing.NewStripeProvider(stripeClient, billing.Plans(cfg.Stripe.PriceIDs))
+		stripeProvider.SetCatalog(planCatalog)
+		billingProviders = append(billingProviders, stripeProvider)
+		if cfg.Stripe.WebhookSecret == "" {
+			logger.Warn("STRIPE_WEBHOOK_SECRET is not set, Stripe webhooks will be rejected")
+		}
+	}
+	billingRegistry := billing.NewRegistry(cfg.Billing.DefaultProvider, billingProviders...)
+	if _, err := billingRegistry.Get(cfg.Billing.DefaultProvider); err != nil {
+		log.Fatalf("Default billing provider %q is not configured", cfg.Billing.DefaultProvider)
+	}
+	logger.Info("Billing providers initialized", "providers", billingRegistry.Names(), "default", billingRegistry.Default())
 
 	// Initialize storage client (S3 or Local fallback)
 	var storageClient storage.Storage
+	storageBackend := "local"
+	storageBaseURL := cfg.Storage.BaseURL
+	if storageBaseURL == "" {
+		storageBaseURL = cfg.App.BaseURL + "/uploads"
+	}
+	localStorageConfig := &storage.LocalStorageConfig{
+		BasePath:      cfg.Storage.BasePath,
+		BaseURL:       storageBaseURL,
+		UploadURL:     cfg.App.BaseURL + "/api/v1/uploads",
+		SigningSecret: cfg.Storage.UploadSecret,
+	}
 	if cfg.S3.AccessKeyID == "" || cfg.S3.SecretAccessKey == "" {
 		// Use local storage when S3 is not configured
-		storageBaseURL := cfg.Storage.BaseURL
-		if storageBaseURL == "" {
-			storageBaseURL = cfg.App.BaseURL + "/uploads"
-		}
-		storageClient, err = storage.NewLocalStorage(&storage.LocalStorageConfig{
-			BasePath: cfg.Storage.BasePath,
-			BaseURL:  storageBaseURL,
-		})
+		storageClient, err = storage.NewLocalStorage(localStorageConfig)
 		if err != nil {
 			logger.Error("Failed to initialize local storage", "error", err.Error())
 			log.Fatalf("Failed to initialize local storage: %v", err)
@@ -115,6 +189,7 @@ func main() {
 		logger.Info("Using local file storage", "path", cfg.Storage.BasePath, "url", storageBaseURL)
 	} else {
 		// Use S3 storage
+		storageBackend = "s3"
 		storageClient, err = storage.NewS3Client(&storage.S3Config{
 			Bucket:          cfg.S3.Bucket,
 			Region:          cfg.S3.Region,
@@ -166,7 +241,24 @@ func main() {
 		logger.Warn("Token blacklist disabled - Redis not available")
 	}
 
+	// Failed logins per account, kept in memory without Redis
+	loginAttempts := utils.NewLoginAttemptTracker(redisClient, utils.LockoutPolicy{
+		Threshold:    int64(cfg.LoginProtection.LockoutThreshold),
+		BaseDuration: cfg.LoginProtection.LockoutDuration,
+		MaxDuration:  cfg.LoginProtection.LockoutMaxDuration,
+		Window:       utils.DefaultLockoutPolicy.Window,
+	})
+
 	// Initialize use cases
+	twoFactorService := twoFactorUsecase.NewService(twoFactorRepo, cfg.App.Name)
+	webAuthnService := webAuthnUsecase.NewService(webAuthnRepo, utils.WebAuthnRelyingParty{
+		ID:      cfg.WebAuthn.RPID,
+		Name:    cfg.WebAuthn.RPName,
+		Origins: cfg.WebAuthn.Origins,
+	})
+	emailVerificationService := emailVerificationUsecase.NewService(emailVerificationRepo, userRepo, emailClient, cfg.App.BaseURL)
+	ssoService := ssoUsecase.NewService(tenantRepo, userRepo, ssoRepo, entitlementService, external.NewOIDCClient(nil), cfg.App.BaseURL)
+
 	authService := auth.NewService(
 		userRepo,
 		tenantRepo,
@@ -179,6 +271,17 @@ func main() {
 	authService.SetBaseURL(cfg.App.BaseURL)
 	authService.SetTokenBlacklist(tokenBlacklist)
 	authService.SetPasswordHistoryRepo(passwordHistoryRepo)
+	authService.SetInviteTokenRepo(inviteTokenRepo)
+	authService.SetTwoFactorService(twoFactorService)
+	authService.SetWebAuthnService(webAuthnService)
+	authService.SetSSOService(ssoService)
+	authService.SetSessionRepo(sessionRepo)
+	authService.SetEmailVerificationService(emailVerificationService)
+	authService.SetLoginAttemptTracker(loginAttempts)
+	authService.SetAuditLogRepo(auditLogRepo)
+	if cfg.LoginProtection.NotifyNewDevices {
+		authService.SetLoginDeviceRepo(loginDeviceRepo)
+	}
 
 	ginService := ginUsecase.NewService(
 		ginRepo,
@@ -188,9 +291,40 @@ func main() {
 	subscriptionService := subscriptionUsecase.NewService(
 		subscriptionRepo,
 		tenantRepo,
-		paypalClient,
+		ginRepo,
+		photoRepo,
+		storageUsageRepo,
+		billingRegistry,
 		cfg.App.BaseURL,
 	)
+	subscriptionService.SetCatalog(planCatalog)
+	subscriptionService.SetWebhookEventRepo(webhookEventRepo)
+	subscriptionService.SetCouponRepo(couponRepo)
+	subscriptionService.SetLockRepo(lockRepo)
+	subscriptionService.SetTrialDays(cfg.Billing.TrialDays)
+	subscriptionService.SetTrialReminders(cfg.Billing.TrialReminderBefore)
+	subscriptionService.SetNotifications(userRepo, emailClient)
+	subscriptionService.SetAuditLogRepo(auditLogRepo)
+	subscriptionService.SetDunning(&models.DunningPolicy{
+		GracePeriod:  cfg.Billing.Dunning.GracePeriod,
+		ReminderDays: cfg.Billing.Dunning.ReminderDays,
+		FinalAfter:   cfg.Billing.Dunning.FinalAfter,
+		FinalAction:  cfg.Billing.Dunning.FinalAction,
+	})
+	subscriptionService.SetInvoicing(invoiceRepo, &subscriptionUsecase.InvoiceSettings{
+		Seller: models.BillingDetails{
+			Name:         cfg.Invoice.SellerName,
+			Email:        cfg.Invoice.SellerEmail,
+			AddressLine1: cfg.Invoice.SellerAddressLine1,
+			AddressLine2: cfg.Invoice.SellerAddressLine2,
+			PostalCode:   cfg.Invoice.SellerPostalCode,
+			City:         cfg.Invoice.SellerCity,
+			Country:      cfg.Invoice.SellerCountry,
+			VATID:        cfg.Invoice.SellerVATID,
+		},
+		VATRate:      cfg.Invoice.VATRate,
+		NumberPrefix: cfg.Invoice.NumberPrefix,
+	})
 
 	botanicalService := botanicalUsecase.NewService(
 		botanicalRepo,
@@ -205,33 +339,88 @@ func main() {
 	photoService := photoUsecase.NewService(
 		photoRepo,
 		ginRepo,
-		usageMetricsRepo,
+		storageUsageRepo,
 		tenantRepo,
+		entitlementService,
 		storageClient,
 	)
+	photoService.SetUploadSessionRepo(uploadSessionRepo)
+	photoService.SetActiveBackend(storageBackend)
 
 	userService := userUsecase.NewService(
 		userRepo,
 		tenantRepo,
 		auditLogRepo,
+		inviteTokenRepo,
+		entitlementService,
 		emailClient,
 		cfg.App.BaseURL,
 	)
+	userService.SetEmailVerificationService(emailVerificationService)
+	userService.SetLoginAttemptTracker(loginAttempts)
+
+	// SCIM provisioning manages users through the user service
+	scimService := scimUsecase.NewService(scimRepo, userRepo, userService, ssoService, entitlementService, cfg.App.BaseURL)
+
+	apiKeyService := apiKeyUsecase.NewService(apiKeyRepo, userRepo, tenantRepo, auditLogRepo, entitlementService)
+	roleService := roleUsecase.NewService(roleRepo, userRepo, tenantRepo, auditLogRepo, entitlementService)
 
 	tastingService := tastingUsecase.NewService(
 		tastingRepo,
 		ginRepo,
 	)
 
+	// Label scanning reads labels with the AI provider (disabled without AI)
+	var labelRecognizer labelScanUsecase.LabelRecognizer
+	if aiClient.IsEnabled() {
+		labelRecognizer = labelScanUsecase.NewAIRecognizer(aiClient)
+	}
+	labelScanService := labelScanUsecase.NewService(labelRecognizer, ginReferenceRepo)
+
+	// Initialize storage sync (migration between backends, reconciliation)
+	storageSyncService := storageSyncUsecase.NewService(
+		photoRepo,
+		tenantRepo,
+		storageUsageRepo,
+	)
+	storageSyncService.SetActiveBackend(storageBackend)
+	storageSyncService.RegisterBackend(storageBackend, storageClient)
+	if storageBackend == "s3" {
+		// Local storage stays available as migration source and target, photos
+		// still stored there are deleted from it
+		if localStorage, err := storage.NewLocalStorage(localStorageConfig); err == nil {
+			storageSyncService.RegisterBackend("local", localStorage)
+			photoService.RegisterBackend("local", localStorage)
+		} else {
+			logger.Warn("Local storage unavailable for migrations", "error", err.Error())
+		}
+	}
+
+	// Initialize Usage Service (metering per billing period, limit overrides)
+	usageService := usageUsecase.NewService(usageMetricsRepo, tenantRepo, subscriptionRepo, storageUsageRepo)
+	usageService.SetAuditLogRepository(auditLogRepo)
+
 	// Initialize Platform Admin Service
 	adminService := adminUsecase.NewService(
 		platformAdminRepo,
 		db,
 		cfg.JWT.Secret,
 	)
+	adminService.SetTwoFactorService(twoFactorService)
+	adminService.SetTwoFactorAttemptTracker(utils.NewAttemptTracker(redisClient, adminUsecase.TwoFactorLockoutPolicy, "admin_2fa"))
+	adminService.SetWebAuthnService(webAuthnService)
 
 	logger.Info("Services initialized")
 
+	// Start background jobs
+	photoService.StartUploadSweeper(context.Background(), 15*time.Minute)
+	storageSyncService.StartUsageReconciler(context.Background(), storageBackend, 24*time.Hour)
+	subscriptionService.StartPlanChangeScheduler(context.Background(), 15*time.Minute)
+	subscriptionService.StartTrialScheduler(context.Background(), time.Hour)
+	subscriptionService.StartDunningScheduler(context.Background(), time.Hour)
+	planCatalog.StartRefresh(context.Background(), 5*time.Minute)
+	usageService.StartStorageScheduler(context.Background(), time.Hour)
+
 	// Initialize HTTP handlers
 	cookieConfig := &utils.CookieConfig{
 		Domain:   cfg.Cookie.Domain,
@@ -242,20 +431,32 @@ func main() {
 	ginHandler := handler.NewGinHandler(ginService)
 	ginReferenceHandler := handler.NewGinReferenceHandler(ginReferenceRepo)
 	subscriptionHandler := handler.NewSubscriptionHandler(subscriptionService)
+	billingHandler := handler.NewBillingHandler(subscriptionService)
 	webhookHandler := handler.NewWebhookHandler(subscriptionService)
 	botanicalHandler := handler.NewBotanicalHandler(botanicalService)
 	cocktailHandler := handler.NewCocktailHandler(cocktailService)
 	photoHandler := handler.NewPhotoHandler(photoService)
 	userHandler := handler.NewUserHandler(userService)
-	tenantHandler := handler.NewTenantHandler(tenantRepo, usageMetricsRepo)
+	tenantHandler := handler.NewTenantHandler(tenantRepo, usageMetricsRepo, storageUsageRepo)
 	aiHandler := handler.NewAIHandler(aiClient)
+	labelScanHandler := handler.NewLabelScanHandler(labelScanService)
 	tastingHandler := handler.NewTastingHandler(tastingService)
+	ssoHandler := handler.NewSSOHandler(ssoService, authService, cookieConfig, cfg.JWT.Expiration, cfg.App.BaseURL)
+	scimHandler := handler.NewSCIMHandler(scimService)
+	apiKeyHandler := handler.NewAPIKeyHandler(apiKeyService)
+	roleHandler := handler.NewRoleHandler(roleService)
+	usageHandler := handler.NewUsageHandler(usageService)
 
 	// Initialize middleware
 	authMiddleware := middleware.NewAuthMiddleware(cfg.JWT.Secret, userRepo, tokenBlacklist)
 	tenantMiddleware := middleware.NewTenantMiddleware(tenantRepo)
-	tierEnforcement := middleware.NewTierEnforcementMiddleware(usageMetricsRepo, ginRepo)
+	tierEnforcement := middleware.NewTierEnforcementMiddleware(entitlementService, ginRepo)
 	platformAdminMiddleware := middleware.NewPlatformAdminMiddleware(adminService)
+	scimAuthMiddleware := middleware.NewSCIMAuthMiddleware(scimRepo, tenantRepo, entitlementService)
+	apiKeyAuthMiddleware := middleware.NewAPIKeyAuthMiddleware(apiKeyRepo, userRepo, tenantRepo, entitlementService)
+	permissionMiddleware := middleware.NewPermissionMiddleware(userRepo, roleRepo)
+	emailVerificationMiddleware := middleware.NewEmailVerificationMiddleware(userRepo, cfg.Email.RequiredTiers)
+	usageMetering := middleware.NewUsageMeteringMiddleware(usageService, entitlementService)
 
 	// Initialize rate limiting middleware (optional - requires Redis)
 	var rateLimitMiddleware *middleware.RateLimitMiddleware
@@ -273,6 +474,11 @@ func main() {
 
 	// Initialize Admin handlers
 	platformAdminHandler := adminHandler.NewHandler(adminService)
+	storageAdminHandler := adminHandler.NewStorageHandler(storageSyncService)
+	webhookAdminHandler := adminHandler.NewWebhookHandler(subscriptionService)
+	couponAdminHandler := adminHandler.NewCouponHandler(subscriptionService)
+	planAdminHandler := adminHandler.NewPlanHandler(planCatalog)
+	usageAdminHandler := adminHandler.NewUsageHandler(usageService)
 
 	// Initialize Server handler for deployment management
 	// Only enable in production when PROJECT_PATH is set
@@ -286,24 +492,36 @@ func main() {
 
 	// Setup router
 	routerCfg := &router.RouterConfig{
-		AuthHandler:         authHandler,
-		GinHandler:          ginHandler,
-		GinReferenceHandler: ginReferenceHandler,
-		SubscriptionHandler: subscriptionHandler,
-		WebhookHandler:      webhookHandler,
-		BotanicalHandler:    botanicalHandler,
-		CocktailHandler:     cocktailHandler,
-		PhotoHandler:        photoHandler,
-		UserHandler:         userHandler,
-		TenantHandler:       tenantHandler,
-		AIHandler:           aiHandler,
-		TastingHandler:      tastingHandler,
-		AuthMiddleware:      authMiddleware,
-		TenantMiddleware:    tenantMiddleware,
-		TierEnforcement:     tierEnforcement,
-		RateLimitMiddleware: rateLimitMiddleware,
-		CSRFMiddleware:      csrfMiddleware,
-		AllowedOrigins:      cfg.App.AllowedOrigins,
+		AuthHandler:          authHandler,
+		GinHandler:           ginHandler,
+		GinReferenceHandler:  ginReferenceHandler,
+		SubscriptionHandler:  subscriptionHandler,
+		BillingHandler:       billingHandler,
+		WebhookHandler:       webhookHandler,
+		BotanicalHandler:     botanicalHandler,
+		CocktailHandler:      cocktailHandler,
+		PhotoHandler:         photoHandler,
+		UserHandler:          userHandler,
+		TenantHandler:        tenantHandler,
+		AIHandler:            aiHandler,
+		LabelScanHandler:     labelScanHandler,
+		TastingHandler:       tastingHandler,
+		SSOHandler:           ssoHandler,
+		SCIMHandler:          scimHandler,
+		APIKeyHandler:        apiKeyHandler,
+		RoleHandler:          roleHandler,
+		UsageHandler:         usageHandler,
+		AuthMiddleware:       authMiddleware,
+		TenantMiddleware:     tenantMiddleware,
+		TierEnforcement:      tierEnforcement,
+		RateLimitMiddleware:  rateLimitMiddleware,
+		CSRFMiddleware:       csrfMiddleware,
+		SCIMAuthMiddleware:   scimAuthMiddleware,
+		APIKeyAuthMiddleware: apiKeyAuthMiddleware,
+		PermissionMiddleware: permissionMiddleware,
+		EmailVerification:    emailVerificationMiddleware,
+		UsageMetering:        usageMetering,
+		AllowedOrigins:       cfg.App.AllowedOrigins,
 	}
 
 	r := router.Setup(routerCfg)
@@ -318,7 +536,13 @@ func main() {
 	adminRouterCfg := &router.AdminRouterConfig{
 		AdminHandler:        platformAdminHandler,
 		ServerHandler:       serverHandler,
+		StorageHandler:      storageAdminHandler,
+		WebhookHandler:      webhookAdminHandler,
+		CouponHandler:       couponAdminHandler,
+		PlanHandler:         planAdminHandler,
+		UsageHandler:        usageAdminHandler,
 		PlatformAdminMiddle: platformAdminMiddleware,
+		RateLimitMiddleware: rateLimitMiddleware,
 		AllowedOrigins:      cfg.App.AllowedOrigins,
 	}
 	router.SetupAdminRoutes(r, adminRouterCfg)
@@ -0,0 +1,122 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/yourusername/gin-collection-saas/internal/infrastructure/database"
+	"github.com/yourusername/gin-collection-saas/internal/infrastructure/storage"
+	"github.com/yourusername/gin-collection-saas/internal/repository/mysql"
+	storageSyncUsecase "github.com/yourusername/gin-collection-saas/internal/usecase/storagesync"
+	"github.com/yourusername/gin-collection-saas/pkg/config"
+	"github.com/yourusername/gin-collection-saas/pkg/logger"
+)
+
+func main() {
+	// Parse flags
+	var (
+		command       = flag.String("command", "reconcile", "Command: backends, migrate, reconcile")
+		tenantID      = flag.Int64("tenant", 0, "Tenant ID (0 = all tenants)")
+		from          = flag.String("from", "local", "Source backend for migrate: local, s3")
+		to            = flag.String("to", "s3", "Target backend for migrate: local, s3")
+		backend       = flag.String("backend", "", "Backend to reconcile: local, s3 (default: active backend)")
+		deleteSource  = flag.Bool("delete-source", false, "Delete source objects after a verified copy")
+		deleteOrphans = flag.Bool("delete-orphans", false, "Delete orphaned objects during reconcile")
+		dryRun        = flag.Bool("dry-run", false, "Report what would be migrated without writing")
+	)
+
+	flag.Parse()
+
+	cfg, err := config.Load()
+	if err != nil {
+		log.Fatalf("Failed to load configuration: %v", err)
+	}
+
+	logger.Init(cfg.App.LogLevel)
+
+	db, err := database.NewMySQL(cfg.Database.DSN(), cfg.Database.MaxConns, cfg.Database.MaxIdle)
+	if err != nil {
+		log.Fatalf("Failed to connect to database: %v", err)
+	}
+	defer db.Close()
+
+	service := storageSyncUsecase.NewService(
+		mysql.NewPhotoRepository(db),
+		mysql.NewTenantRepository(db),
+		mysql.NewStorageUsageRepository(db),
+	)
+
+	// Register every backend that is configured
+	activeBackend := "local"
+	storageBaseURL := cfg.Storage.BaseURL
+	if storageBaseURL == "" {
+		storageBaseURL = cfg.App.BaseURL + "/uploads"
+	}
+	if localStorage, err := storage.NewLocalStorage(&storage.LocalStorageConfig{
+		BasePath: cfg.Storage.BasePath,
+		BaseURL:  storageBaseURL,
+	}); err == nil {
+		service.RegisterBackend("local", localStorage)
+	} else {
+		logger.Warn("Local storage unavailable", "error", err.Error())
+	}
+
+	if cfg.S3.AccessKeyID != "" && cfg.S3.SecretAccessKey != "" {
+		s3Client, err := storage.NewS3Client(&storage.S3Config{
+			Bucket:          cfg.S3.Bucket,
+			Region:          cfg.S3.Region,
+			Endpoint:        cfg.S3.Endpoint,
+			AccessKeyID:     cfg.S3.AccessKeyID,
+			SecretAccessKey: cfg.S3.SecretAccessKey,
+			PublicURL:       cfg.S3.PublicURL,
+		})
+		if err != nil {
+			log.Fatalf("Failed to initialize S3 client: %v", err)
+		}
+		service.RegisterBackend("s3", s3Client)
+		activeBackend = "s3"
+	}
+
+	service.SetActiveBackend(activeBackend)
+
+	ctx := context.Background()
+
+	var result interface{}
+	switch *command {
+	case "backends":
+		result = map[string]interface{}{"backends": service.Backends(), "active": activeBackend}
+	case "migrate":
+		result, err = service.Migrate(ctx, &storageSyncUsecase.MigrateOptions{
+			TenantID:     *tenantID,
+			From:         *from,
+			To:           *to,
+			DeleteSource: *deleteSource,
+			DryRun:       *dryRun,
+		})
+	case "reconcile":
+		if *backend == "" {
+			*backend = activeBackend
+		}
+		result, err = service.Reconcile(ctx, &storageSyncUsecase.ReconcileOptions{
+			TenantID:      *tenantID,
+			Backend:       *backend,
+			DeleteOrphans: *deleteOrphans,
+		})
+	default:
+		log.Fatalf("Unknown command: %s", *command)
+	}
+
+	if result != nil {
+		output, _ := json.MarshalIndent(result, "", "  ")
+		fmt.Println(string(output))
+	}
+
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Command failed: %v\n", err)
+		os.Exit(1)
+	}
+}
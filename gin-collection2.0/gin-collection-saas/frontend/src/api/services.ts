@@ -1,6 +1,10 @@
 import apiClient from './client';
 import type {
   AuthResponse,
+  APIKey,
+  EmailVerification,
+  PermissionInfo,
+  Role,
   User,
   Tenant,
   Gin,
@@ -10,6 +14,16 @@ import type {
   GinPhoto,
   Subscription,
   SubscriptionPlan,
+  BillingCycle,
+  BillingProvider,
+  BillingProviders,
+  BillingDetails,
+  Invoice,
+  UpcomingCharges,
+  UsageReport,
+  CouponPreview,
+  PlanChange,
+  PlanChangePreview,
   Botanical,
   GinBotanical,
   Cocktail,
@@ -55,6 +69,34 @@ export const authAPI = {
       current_password: currentPassword,
       new_password: newPassword,
     }),
+
+  // Invitations
+  acceptInvite: (data: {
+    token: string;
+    password: string;
+    first_name?: string;
+    last_name?: string;
+  }) => apiClient.post<AuthResponse>('/auth/accept-invite', data),
+
+  // Email verification and email change
+  verifyEmail: (token: string) =>
+    apiClient.post<{ purpose: 'verify' | 'change'; completed: boolean; message: string }>(
+      '/auth/verify-email',
+      { token }
+    ),
+
+  resendVerification: () => apiClient.post('/auth/verify-email/resend'),
+
+  getEmailChange: () =>
+    apiClient.get<{ pending: EmailVerification | null }>('/auth/email-change'),
+
+  requestEmailChange: (newEmail: string, password: string) =>
+    apiClient.post<{ pending: EmailVerification }>('/auth/email-change', {
+      new_email: newEmail,
+      password,
+    }),
+
+  cancelEmailChange: () => apiClient.delete('/auth/email-change'),
 };
 
 // ============================================================================
@@ -96,8 +138,14 @@ export const tenantAPI = {
         import: boolean;
         multi_user: boolean;
         api_access: boolean;
+        sso: boolean;
+        scim: boolean;
+        dedicated_database: boolean;
       };
     }>('/tenants/usage'),
+
+  getUsageDashboard: (periods?: number) =>
+    apiClient.get<UsageReport>('/tenants/usage/dashboard', { params: { periods } }),
 };
 
 // ============================================================================
@@ -174,18 +222,74 @@ export const photoAPI = {
 export const subscriptionAPI = {
   getCurrent: () => apiClient.get<Subscription>('/subscriptions/current'),
 
-  getPlans: () => apiClient.get<{ plans: SubscriptionPlan[] }>('/subscriptions/plans'),
+  getPlans: (lang?: string) =>
+    apiClient.get<{ plans: SubscriptionPlan[] }>('/subscriptions/plans', { params: { lang } }),
 
-  upgrade: (planId: string, billingCycle: 'monthly' | 'yearly') =>
-    apiClient.post<{ approval_url: string; subscription_id: string }>(
+  upgrade: (planId: string, billingCycle: 'monthly' | 'yearly', provider?: BillingProvider, couponCode?: string) =>
+    apiClient.post<{ approval_url: string; subscription_id: number; provider: BillingProvider; checkout_id: string; discount: number }>(
       '/subscriptions/upgrade',
-      { plan_id: planId, billing_cycle: billingCycle }
+      { plan_id: planId, billing_cycle: billingCycle, provider, coupon_code: couponCode }
     ),
 
-  activate: (subscriptionId: string) =>
-    apiClient.post('/subscriptions/activate', { subscription_id: subscriptionId }),
+  startTrial: (planId: string) => apiClient.post<Subscription>('/subscriptions/trial', { plan_id: planId }),
+
+  previewCoupon: (code: string, planId: string, billingCycle: 'monthly' | 'yearly') =>
+    apiClient.get<CouponPreview>(`/subscriptions/coupons/${encodeURIComponent(code)}`, {
+      params: { plan_id: planId, billing_cycle: billingCycle },
+    }),
 
-  cancel: () => apiClient.post('/subscriptions/cancel'),
+  activate: (provider: BillingProvider, checkoutId: string) =>
+    apiClient.post('/subscriptions/activate', { provider, checkout_id: checkoutId }),
+
+  changePlan: (planId: string) =>
+    apiClient.post<PlanChange>('/subscriptions/change-plan', { plan_id: planId }),
+
+  previewPlanChange: (planId: string) =>
+    apiClient.get<PlanChangePreview>('/subscriptions/change-plan/preview', { params: { plan_id: planId } }),
+
+  cancelScheduledChange: () => apiClient.delete<PlanChange>('/subscriptions/scheduled-change'),
+
+  getPortal: () => apiClient.get<{ url: string }>('/subscriptions/portal'),
+
+  getProviders: () => apiClient.get<BillingProviders>('/subscriptions/providers'),
+
+  selectProvider: (provider: BillingProvider | '') =>
+    apiClient.put<BillingProviders>('/subscriptions/providers', { provider }),
+
+  cancel: (reason?: string) =>
+    apiClient.post<{ message: string; subscription: Subscription }>('/subscriptions/cancel', { reason }),
+
+  pause: (reason?: string) =>
+    apiClient.post<{ message: string; subscription: Subscription }>('/subscriptions/pause', { reason }),
+
+  resume: () => apiClient.post<{ message: string; subscription: Subscription }>('/subscriptions/resume'),
+
+  changeBillingCycle: (billingCycle: BillingCycle) =>
+    apiClient.post<PlanChange>('/subscriptions/billing-cycle', { billing_cycle: billingCycle }),
+};
+
+// ============================================================================
+// Billing API
+// ============================================================================
+
+export const billingAPI = {
+  listInvoices: (page = 1, limit = 20) =>
+    apiClient.get<{ invoices: Invoice[]; total: number; page: number; limit: number }>('/billing/invoices', {
+      params: { page, limit },
+    }),
+
+  getInvoice: (id: number) => apiClient.get<Invoice>(`/billing/invoices/${id}`),
+
+  downloadInvoice: (id: number) =>
+    apiClient.get(`/billing/invoices/${id}/pdf`, { responseType: 'blob' }),
+
+  getDetails: () => apiClient.get<{ details: BillingDetails | null }>('/billing/details'),
+
+  updateDetails: (details: BillingDetails) =>
+    apiClient.put<{ message: string; details: BillingDetails }>('/billing/details', details),
+
+  getUpcomingCharges: (count = 3) =>
+    apiClient.get<UpcomingCharges>('/billing/upcoming', { params: { count } }),
 };
 
 // ============================================================================
@@ -250,10 +354,51 @@ export const userAPI = {
 
   delete: (id: number) => apiClient.delete(`/users/${id}`),
 
-  generateAPIKey: (id: number) =>
-    apiClient.post<{ api_key: string; message: string }>(`/users/${id}/api-key`),
+  // Lift a lockout after failed logins
+  unlock: (id: number) => apiClient.post(`/users/${id}/unlock`),
+
+  listAPIKeys: (id: number) =>
+    apiClient.get<{ api_keys: APIKey[]; scopes: string[] }>(`/users/${id}/api-keys`),
+
+  revokeAPIKey: (id: number, keyId: string) =>
+    apiClient.delete(`/users/${id}/api-keys/${keyId}`),
+};
+
+// ============================================================================
+// API Key API (keys of the current user, Pro and Enterprise)
+// ============================================================================
+
+export const apiKeyAPI = {
+  list: () => apiClient.get<{ api_keys: APIKey[]; scopes: string[] }>('/api-keys'),
+
+  create: (data: {
+    name: string;
+    scopes: string[];
+    expires_at?: string;
+    rate_limit?: number;
+  }) => apiClient.post<{ api_key: APIKey; key: string; message: string }>('/api-keys', data),
+
+  revoke: (id: string) => apiClient.delete(`/api-keys/${id}`),
+};
+
+// ============================================================================
+// Role API (built-in roles, custom roles are Enterprise only)
+// ============================================================================
+
+export const roleAPI = {
+  list: () => apiClient.get<{ roles: Role[]; permissions: PermissionInfo[] }>('/roles'),
+
+  create: (data: { name: string; description?: string; permissions: string[] }) =>
+    apiClient.post<{ role: Role }>('/roles', data),
+
+  update: (id: number, data: { name: string; description?: string; permissions: string[] }) =>
+    apiClient.put<{ role: Role }>(`/roles/${id}`, data),
+
+  delete: (id: number) => apiClient.delete(`/roles/${id}`),
+
+  assignUser: (id: number, userId: number) => apiClient.put(`/roles/${id}/members/${userId}`),
 
-  revokeAPIKey: (id: number) => apiClient.delete(`/users/${id}/api-key`),
+  unassignUser: (id: number, userId: number) => apiClient.delete(`/roles/${id}/members/${userId}`),
 };
 
 // ============================================================================
@@ -0,0 +1,395 @@
+import { useState } from 'react';
+import { useNavigate, useSearchParams, Link } from 'react-router-dom';
+import { motion } from 'framer-motion';
+import axios from 'axios';
+import { getErrorMessage } from '../api/client';
+import { useAuthStore } from '../stores/authStore';
+import {
+  UserPlus,
+  User,
+  Lock,
+  Eye,
+  EyeOff,
+  AlertCircle,
+  ArrowRight,
+  CheckCircle,
+  XCircle
+} from 'lucide-react';
+import './Login.css';
+
+// Matches the minimum length of the accept-invite endpoint
+const MIN_PASSWORD_LENGTH = 12;
+
+// Unknown, used and expired invitation tokens
+const isInvalidLink = (err: unknown) =>
+  axios.isAxiosError(err) &&
+  (err.response?.status === 401 || err.response?.data?.error === 'token has expired');
+
+const AcceptInvite = () => {
+  const navigate = useNavigate();
+  const [searchParams] = useSearchParams();
+  const token = searchParams.get('token');
+  const acceptInvite = useAuthStore((state) => state.acceptInvite);
+
+  const [firstName, setFirstName] = useState('');
+  const [lastName, setLastName] = useState('');
+  const [password, setPassword] = useState('');
+  const [confirmPassword, setConfirmPassword] = useState('');
+  const [showPassword, setShowPassword] = useState(false);
+  const [error, setError] = useState('');
+  const [isLoading, setIsLoading] = useState(false);
+  const [isTokenValid, setIsTokenValid] = useState(!!token);
+  const [needsLogin, setNeedsLogin] = useState(false);
+
+  const handleSubmit = async (e: React.FormEvent) => {
+    e.preventDefault();
+    setError('');
+
+    // Validate passwords match
+    if (password !== confirmPassword) {
+      setError('Die Passwörter stimmen nicht überein.');
+      return;
+    }
+
+    // Validate password length
+    if (password.length < MIN_PASSWORD_LENGTH) {
+      setError(`Das Passwort muss mindestens ${MIN_PASSWORD_LENGTH} Zeichen lang sein.`);
+      return;
+    }
+
+    setIsLoading(true);
+
+    try {
+      const loggedIn = await acceptInvite({
+        token: token as string,
+        password,
+        first_name: firstName.trim() || undefined,
+        last_name: lastName.trim() || undefined,
+      });
+
+      if (loggedIn) {
+        navigate('/dashboard');
+      } else {
+        // The tenant requires a second factor, it is set up at the first login
+        setNeedsLogin(true);
+      }
+    } catch (err) {
+      if (isInvalidLink(err)) {
+        setIsTokenValid(false);
+      } else {
+        setError(getErrorMessage(err));
+      }
+    } finally {
+      setIsLoading(false);
+    }
+  };
+
+  // Missing, invalid or expired token
+  if (!isTokenValid) {
+    return (
+      <div className="login-page">
+        <div className="login-page__ambient">
+          <div className="login-ambient-orb login-ambient-orb--1" />
+          <div className="login-ambient-orb login-ambient-orb--2" />
+        </div>
+        <div className="login-page__decor">
+          <div className="decor-line decor-line--1" />
+          <div className="decor-line decor-line--2" />
+        </div>
+        <motion.div
+          className="login-card"
+          initial={{ opacity: 0, y: 30 }}
+          animate={{ opacity: 1, y: 0 }}
+        >
+          <div style={{ textAlign: 'center' }}>
+            <div
+              style={{
+                width: '80px',
+                height: '80px',
+                margin: '0 auto 24px',
+                borderRadius: '50%',
+                background: 'rgba(220, 38, 38, 0.15)',
+                display: 'flex',
+                alignItems: 'center',
+                justifyContent: 'center',
+              }}
+            >
+              <XCircle size={40} style={{ color: '#F87171' }} />
+            </div>
+            <h1 className="login-title" style={{ fontSize: '1.5rem', marginBottom: '12px' }}>
+              Einladung ungültig
+            </h1>
+            <p style={{ color: 'var(--text-muted)', marginBottom: '32px' }}>
+              Dieser Einladungslink ist ungültig, abgelaufen oder wurde bereits verwendet.
+              Bitte den Administrator, dir eine neue Einladung zu senden.
+            </p>
+            <Link to="/login">
+              <motion.button
+                className="login-submit"
+                whileHover={{ scale: 1.02 }}
+                whileTap={{ scale: 0.98 }}
+              >
+                <span className="login-submit__content">
+                  <span>Zum Login</span>
+                  <ArrowRight size={18} />
+                </span>
+              </motion.button>
+            </Link>
+          </div>
+        </motion.div>
+      </div>
+    );
+  }
+
+  return (
+    <div className="login-page">
+      {/* Ambient Background */}
+      <div className="login-page__ambient">
+        <div className="login-ambient-orb login-ambient-orb--1" />
+        <div className="login-ambient-orb login-ambient-orb--2" />
+        <div className="login-ambient-orb login-ambient-orb--3" />
+      </div>
+
+      {/* Decorative Lines */}
+      <div className="login-page__decor">
+        <div className="decor-line decor-line--1" />
+        <div className="decor-line decor-line--2" />
+        <div className="decor-line decor-line--3" />
+        <div className="decor-line decor-line--4" />
+      </div>
+
+      {/* Card */}
+      <motion.div
+        className="login-card"
+        initial={{ opacity: 0, y: 30, scale: 0.95 }}
+        animate={{ opacity: 1, y: 0, scale: 1 }}
+        transition={{ duration: 0.6, ease: [0.22, 1, 0.36, 1] }}
+      >
+        {/* Header */}
+        <motion.div
+          className="login-header"
+          initial={{ opacity: 0, y: 20 }}
+          animate={{ opacity: 1, y: 0 }}
+          transition={{ duration: 0.6, delay: 0.1 }}
+        >
+          <motion.div
+            className="login-logo"
+            initial={{ scale: 0 }}
+            animate={{ scale: 1 }}
+            transition={{
+              type: 'spring',
+              stiffness: 200,
+              damping: 15,
+              delay: 0.2
+            }}
+          >
+            <UserPlus size={36} />
+          </motion.div>
+          <h1 className="login-title">
+            {needsLogin ? 'Willkommen!' : 'Einladung annehmen'}
+          </h1>
+          <p className="login-subtitle">
+            {needsLogin
+              ? 'Dein Konto ist eingerichtet'
+              : 'Wähle ein Passwort für dein Konto'}
+          </p>
+        </motion.div>
+
+        {needsLogin ? (
+          /* Success State, login with second factor required */
+          <motion.div
+            initial={{ opacity: 0, scale: 0.9 }}
+            animate={{ opacity: 1, scale: 1 }}
+            transition={{ duration: 0.4 }}
+          >
+            <div
+              style={{
+                display: 'flex',
+                flexDirection: 'column',
+                alignItems: 'center',
+                gap: '20px',
+                padding: '20px 0',
+              }}
+            >
+              <div
+                style={{
+                  width: '64px',
+                  height: '64px',
+                  borderRadius: '50%',
+                  background: 'rgba(126, 205, 160, 0.15)',
+                  display: 'flex',
+                  alignItems: 'center',
+                  justifyContent: 'center',
+                }}
+              >
+                <CheckCircle size={32} style={{ color: 'var(--mint)' }} />
+              </div>
+              <p style={{ color: 'var(--text-muted)', fontSize: '0.9rem', textAlign: 'center' }}>
+                Dein Team verlangt eine Zwei-Faktor-Authentifizierung.
+                Melde dich mit deinem neuen Passwort an, um sie einzurichten.
+              </p>
+            </div>
+
+            <Link to="/login">
+              <motion.button
+                type="button"
+                className="login-submit"
+                style={{ marginTop: '24px' }}
+                whileHover={{ scale: 1.02 }}
+                whileTap={{ scale: 0.98 }}
+              >
+                <span className="login-submit__content">
+                  <span>Jetzt anmelden</span>
+                  <ArrowRight size={18} />
+                </span>
+              </motion.button>
+            </Link>
+          </motion.div>
+        ) : (
+          /* Form State */
+          <>
+            {/* Error Message */}
+            {error && (
+              <motion.div
+                className="login-error"
+                initial={{ opacity: 0, x: -20 }}
+                animate={{ opacity: 1, x: 0 }}
+                transition={{ duration: 0.3 }}
+              >
+                <AlertCircle size={18} />
+                <span>{error}</span>
+              </motion.div>
+            )}
+
+            {/* Form */}
+            <motion.form
+              className="login-form"
+              onSubmit={handleSubmit}
+              initial={{ opacity: 0 }}
+              animate={{ opacity: 1 }}
+              transition={{ duration: 0.6, delay: 0.2 }}
+            >
+              {/* Name Fields (optional, prefilled by the inviter) */}
+              <div className="form-group">
+                <label className="form-label" htmlFor="firstName">
+                  Vorname (optional)
+                </label>
+                <div className="form-input-wrapper">
+                  <input
+                    id="firstName"
+                    type="text"
+                    value={firstName}
+                    onChange={(e) => setFirstName(e.target.value)}
+                    className="form-input"
+                    placeholder="Vorname"
+                    disabled={isLoading}
+                    autoComplete="given-name"
+                  />
+                  <User size={18} className="form-input-icon" />
+                </div>
+              </div>
+
+              <div className="form-group">
+                <label className="form-label" htmlFor="lastName">
+                  Nachname (optional)
+                </label>
+                <div className="form-input-wrapper">
+                  <input
+                    id="lastName"
+                    type="text"
+                    value={lastName}
+                    onChange={(e) => setLastName(e.target.value)}
+                    className="form-input"
+                    placeholder="Nachname"
+                    disabled={isLoading}
+                    autoComplete="family-name"
+                  />
+                  <User size={18} className="form-input-icon" />
+                </div>
+              </div>
+
+              {/* Password Field */}
+              <div className="form-group">
+                <label className="form-label" htmlFor="password">
+                  Passwort
+                </label>
+                <div className="form-input-wrapper">
+                  <input
+                    id="password"
+                    type={showPassword ? 'text' : 'password'}
+                    value={password}
+                    onChange={(e) => setPassword(e.target.value)}
+                    className="form-input"
+                    placeholder={`Mindestens ${MIN_PASSWORD_LENGTH} Zeichen`}
+                    required
+                    disabled={isLoading}
+                    autoComplete="new-password"
+                    minLength={MIN_PASSWORD_LENGTH}
+                    style={{ paddingRight: '48px' }}
+                    autoFocus
+                  />
+                  <Lock size={18} className="form-input-icon" />
+                  <button
+                    type="button"
+                    className="password-toggle"
+                    onClick={() => setShowPassword(!showPassword)}
+                    tabIndex={-1}
+                  >
+                    {showPassword ? <EyeOff size={18} /> : <Eye size={18} />}
+                  </button>
+                </div>
+              </div>
+
+              {/* Confirm Password Field */}
+              <div className="form-group">
+                <label className="form-label" htmlFor="confirmPassword">
+                  Passwort bestätigen
+                </label>
+                <div className="form-input-wrapper">
+                  <input
+                    id="confirmPassword"
+                    type={showPassword ? 'text' : 'password'}
+                    value={confirmPassword}
+                    onChange={(e) => setConfirmPassword(e.target.value)}
+                    className="form-input"
+                    placeholder="Passwort wiederholen"
+                    required
+                    disabled={isLoading}
+                    autoComplete="new-password"
+                    minLength={MIN_PASSWORD_LENGTH}
+                  />
+                  <Lock size={18} className="form-input-icon" />
+                </div>
+              </div>
+
+              {/* Submit Button */}
+              <motion.button
+                type="submit"
+                className="login-submit"
+                disabled={isLoading || password.length < MIN_PASSWORD_LENGTH || password !== confirmPassword}
+                whileHover={{ scale: isLoading ? 1 : 1.02 }}
+                whileTap={{ scale: isLoading ? 1 : 0.98 }}
+              >
+                <span className="login-submit__content">
+                  {isLoading ? (
+                    <>
+                      <span className="login-spinner" />
+                      <span>Konto wird eingerichtet...</span>
+                    </>
+                  ) : (
+                    <>
+                      <span>Einladung annehmen</span>
+                      <ArrowRight size={18} />
+                    </>
+                  )}
+                </span>
+              </motion.button>
+            </motion.form>
+          </>
+        )}
+      </motion.div>
+    </div>
+  );
+};
+
+export default AcceptInvite;
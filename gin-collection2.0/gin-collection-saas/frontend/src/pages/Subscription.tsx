@@ -121,6 +121,7 @@ const Subscription = () => {
   const [selectedPlan, setSelectedPlan] = useState<string | null>(null);
   const [isUpgrading, setIsUpgrading] = useState(false);
   const [isCancelling, setIsCancelling] = useState(false);
+  const [isResuming, setIsResuming] = useState(false);
   const [showConfirmModal, setShowConfirmModal] = useState(false);
   const [showCancelModal, setShowCancelModal] = useState(false);
   const [currentSubscription, setCurrentSubscription] = useState<SubscriptionType | null>(null);
@@ -162,13 +163,13 @@ const Subscription = () => {
       const apiResponse = response.data as unknown as {
         success: boolean;
         data: {
-          paypal_approval_url: string;
+          approval_url: string;
           subscription_id: number;
         }
       };
 
-      if (apiResponse.success && apiResponse.data?.paypal_approval_url) {
-        window.location.href = apiResponse.data.paypal_approval_url;
+      if (apiResponse.success && apiResponse.data?.approval_url) {
+        window.location.href = apiResponse.data.approval_url;
       } else {
         setUpgradeError('Upgrade konnte nicht gestartet werden. Bitte versuche es erneut.');
       }
@@ -181,19 +182,30 @@ const Subscription = () => {
     }
   };
 
+  const refreshTenant = async () => {
+    const tenantResponse = await tenantAPI.getCurrent();
+    const tenantData = tenantResponse.data as unknown as { success: boolean; data: { tenant: typeof tenant } };
+    if (tenantData.success && tenantData.data?.tenant) {
+      setTenant(tenantData.data.tenant);
+    }
+  };
+
   const handleCancelSubscription = async () => {
     setIsCancelling(true);
     try {
-      await subscriptionAPI.cancel();
+      // Paid subscriptions end with the billing period, trials right away
+      const response = await subscriptionAPI.cancel(cancelReason.trim() || undefined);
+      const apiResponse = response.data as unknown as { success: boolean; data: { subscription: SubscriptionType } };
       setShowCancelModal(false);
+      setCancelReason('');
 
-      const tenantResponse = await tenantAPI.getCurrent();
-      const tenantData = tenantResponse.data as unknown as { success: boolean; data: { tenant: typeof tenant } };
-      if (tenantData.success && tenantData.data?.tenant) {
-        setTenant(tenantData.data.tenant);
+      const subscription = apiResponse.data?.subscription;
+      if (subscription && subscription.status !== 'cancelled') {
+        setCurrentSubscription(subscription);
+      } else {
+        setCurrentSubscription(null);
+        await refreshTenant();
       }
-
-      setCurrentSubscription(null);
     } catch (err) {
       console.error('Cancel failed:', err);
     } finally {
@@ -201,6 +213,21 @@ const Subscription = () => {
     }
   };
 
+  const handleResumeSubscription = async () => {
+    setIsResuming(true);
+    try {
+      const response = await subscriptionAPI.resume();
+      const apiResponse = response.data as unknown as { success: boolean; data: { subscription: SubscriptionType } };
+      if (apiResponse.success && apiResponse.data?.subscription) {
+        setCurrentSubscription(apiResponse.data.subscription);
+      }
+    } catch (err) {
+      console.error('Resume failed:', err);
+    } finally {
+      setIsResuming(false);
+    }
+  };
+
   const mapPlanIdToBackend = (planId: string, cycle: BillingCycle): string => {
     const mapping: Record<string, Record<BillingCycle, string>> = {
       basic: { monthly: 'PLAN_BASIC_MONTHLY', yearly: 'PLAN_BASIC_YEARLY' },
@@ -710,19 +737,38 @@ const Subscription = () => {
               <div className="subscription-cancel-icon">
                 <AlertTriangle size={22} />
               </div>
-              <div className="subscription-cancel-info">
-                <h3>Abonnement kundigen</h3>
-                <p>
-                  Wenn du dein Abonnement kundigst, wird es am Ende des aktuellen Abrechnungszeitraums beendet.
-                  Du behaltst den Zugang bis dahin.
-                </p>
-                <button
-                  className="subscription-cancel-btn"
-                  onClick={() => setShowCancelModal(true)}
-                >
-                  Abonnement kundigen
-                </button>
-              </div>
+              {currentSubscription?.cancel_at_period_end ? (
+                <div className="subscription-cancel-info">
+                  <h3>Abonnement gekundigt</h3>
+                  <p>
+                    {currentSubscription.current_period_end
+                      ? `Dein Abonnement endet am ${new Date(currentSubscription.current_period_end).toLocaleDateString('de-DE')}.`
+                      : 'Dein Abonnement endet am Ende des aktuellen Abrechnungszeitraums.'}
+                    {' '}Bis dahin behaltst du den Zugang und kannst die Kundigung zurucknehmen.
+                  </p>
+                  <button
+                    className="subscription-cancel-btn"
+                    onClick={handleResumeSubscription}
+                    disabled={isResuming}
+                  >
+                    {isResuming ? 'Wird fortgesetzt...' : 'Kundigung zurucknehmen'}
+                  </button>
+                </div>
+              ) : (
+                <div className="subscription-cancel-info">
+                  <h3>Abonnement kundigen</h3>
+                  <p>
+                    Wenn du dein Abonnement kundigst, wird es am Ende des aktuellen Abrechnungszeitraums beendet.
+                    Du behaltst den Zugang bis dahin.
+                  </p>
+                  <button
+                    className="subscription-cancel-btn"
+                    onClick={() => setShowCancelModal(true)}
+                  >
+                    Abonnement kundigen
+                  </button>
+                </div>
+              )}
             </div>
           </motion.div>
         )}
@@ -768,7 +814,7 @@ const Subscription = () => {
                 <div className="subscription-modal__alert subscription-modal__alert--warning">
                   <AlertTriangle size={16} />
                   <span>
-                    Nach der Kundigung wirst du zum kostenlosen Plan herabgestuft und verlierst Zugang zu Premium-Funktionen.
+                    Am Ende des Abrechnungszeitraums wirst du zum kostenlosen Plan herabgestuft und verlierst Zugang zu Premium-Funktionen.
                   </span>
                 </div>
               </div>
@@ -858,7 +904,7 @@ const Subscription = () => {
 
                       <div className="subscription-modal__alert subscription-modal__alert--info">
                         <ExternalLink size={16} />
-                        <span>Du wirst zu PayPal weitergeleitet, um die Zahlung abzuschliessen.</span>
+                        <span>Du wirst zum Zahlungsanbieter weitergeleitet, um die Zahlung abzuschliessen.</span>
                       </div>
                     </>
                   );
@@ -889,7 +935,7 @@ const Subscription = () => {
                   ) : (
                     <>
                       <ExternalLink size={16} />
-                      Zu PayPal
+                      Zur Zahlung
                     </>
                   )}
                 </button>
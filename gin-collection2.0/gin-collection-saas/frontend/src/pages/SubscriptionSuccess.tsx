@@ -26,16 +26,18 @@ const SubscriptionSuccess = () => {
 
   useEffect(() => {
     const activateSubscription = async () => {
-      const subscriptionId = searchParams.get('subscription_id');
+      // PayPal returns the subscription ID, Stripe the Checkout session ID
+      const provider = searchParams.get('provider') === 'stripe' ? 'stripe' : 'paypal';
+      const checkoutId = provider === 'stripe' ? searchParams.get('session_id') : searchParams.get('subscription_id');
 
-      if (!subscriptionId) {
+      if (!checkoutId) {
         setStatus('error');
         setErrorMessage('Keine Abonnement-ID gefunden. Bitte kontaktiere den Support.');
         return;
       }
 
       try {
-        await subscriptionAPI.activate(subscriptionId);
+        await subscriptionAPI.activate(provider, checkoutId);
 
         const tenantResponse = await tenantAPI.getCurrent();
         const tenantData = tenantResponse.data as unknown as { success: boolean; data: { tenant: ReturnType<typeof useAuthStore.getState>['tenant'] } };
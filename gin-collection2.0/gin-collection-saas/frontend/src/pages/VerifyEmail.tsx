@@ -0,0 +1,137 @@
+import { useState, useEffect, useRef } from 'react';
+import { useSearchParams, Link } from 'react-router-dom';
+import { motion } from 'framer-motion';
+import { authAPI } from '../api/services';
+import { getErrorMessage } from '../api/client';
+import { useAuthStore } from '../stores/authStore';
+import { ArrowRight, CheckCircle, XCircle, Loader2 } from 'lucide-react';
+import './Login.css';
+
+const VerifyEmail = () => {
+  const [searchParams] = useSearchParams();
+  const token = searchParams.get('token');
+  const isAuthenticated = useAuthStore((state) => state.isAuthenticated);
+  const checkAuth = useAuthStore((state) => state.checkAuth);
+
+  const [isVerifying, setIsVerifying] = useState(!!token);
+  const [message, setMessage] = useState('');
+  const [error, setError] = useState(token ? '' : 'Der Bestätigungslink ist unvollständig.');
+
+  // Tokens are single-use, the request must not be repeated on re-render
+  const requested = useRef(false);
+
+  useEffect(() => {
+    if (!token || requested.current) {
+      return;
+    }
+    requested.current = true;
+
+    const verify = async () => {
+      try {
+        const response = await authAPI.verifyEmail(token);
+        // API returns { success: true, data: { purpose, completed, message } }
+        const apiResponse = response.data as unknown as {
+          success: boolean;
+          data: { purpose: 'verify' | 'change'; completed: boolean; message: string };
+        };
+        setMessage(apiResponse.data.message);
+
+        // Refresh the stored user so the verification banner disappears
+        if (isAuthenticated) {
+          checkAuth();
+        }
+      } catch (err) {
+        setError(getErrorMessage(err));
+      } finally {
+        setIsVerifying(false);
+      }
+    };
+
+    verify();
+  }, [token, isAuthenticated, checkAuth]);
+
+  const target = isAuthenticated ? '/dashboard' : '/login';
+  const targetLabel = isAuthenticated ? 'Zum Dashboard' : 'Zum Login';
+
+  // Loading state while the token is redeemed
+  if (isVerifying) {
+    return (
+      <div className="login-page">
+        <div className="login-page__ambient">
+          <div className="login-ambient-orb login-ambient-orb--1" />
+          <div className="login-ambient-orb login-ambient-orb--2" />
+        </div>
+        <motion.div
+          className="login-card"
+          initial={{ opacity: 0, y: 30 }}
+          animate={{ opacity: 1, y: 0 }}
+          style={{ textAlign: 'center', padding: '60px 40px' }}
+        >
+          <Loader2 size={48} className="login-spinner" style={{ margin: '0 auto 20px', width: '48px', height: '48px' }} />
+          <p style={{ color: 'var(--text-muted)' }}>E-Mail-Adresse wird bestätigt...</p>
+        </motion.div>
+      </div>
+    );
+  }
+
+  return (
+    <div className="login-page">
+      <div className="login-page__ambient">
+        <div className="login-ambient-orb login-ambient-orb--1" />
+        <div className="login-ambient-orb login-ambient-orb--2" />
+      </div>
+      <div className="login-page__decor">
+        <div className="decor-line decor-line--1" />
+        <div className="decor-line decor-line--2" />
+      </div>
+      <motion.div
+        className="login-card"
+        initial={{ opacity: 0, y: 30 }}
+        animate={{ opacity: 1, y: 0 }}
+      >
+        <div style={{ textAlign: 'center' }}>
+          <div
+            style={{
+              width: '80px',
+              height: '80px',
+              margin: '0 auto 24px',
+              borderRadius: '50%',
+              background: error ? 'rgba(220, 38, 38, 0.15)' : 'rgba(126, 205, 160, 0.15)',
+              display: 'flex',
+              alignItems: 'center',
+              justifyContent: 'center',
+            }}
+          >
+            {error ? (
+              <XCircle size={40} style={{ color: '#F87171' }} />
+            ) : (
+              <CheckCircle size={40} style={{ color: 'var(--mint)' }} />
+            )}
+          </div>
+          <h1 className="login-title" style={{ fontSize: '1.5rem', marginBottom: '12px' }}>
+            {error ? 'Link ungültig' : 'Geschafft!'}
+          </h1>
+          <p style={{ color: 'var(--text-muted)', marginBottom: '32px' }}>
+            {error
+              ? `${error} Du kannst in den Einstellungen einen neuen Link anfordern.`
+              : message}
+          </p>
+          <Link to={target}>
+            <motion.button
+              className="login-submit"
+              whileHover={{ scale: 1.02 }}
+              whileTap={{ scale: 0.98 }}
+            >
+              <span className="login-submit__content">
+                <span>{targetLabel}</span>
+                <ArrowRight size={18} />
+              </span>
+            </motion.button>
+          </Link>
+        </div>
+      </motion.div>
+    </div>
+  );
+};
+
+export default VerifyEmail;
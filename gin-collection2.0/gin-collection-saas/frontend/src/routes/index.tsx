@@ -9,6 +9,8 @@ const Login = lazy(() => import('../pages/Login'));
 const Register = lazy(() => import('../pages/Register'));
 const ForgotPassword = lazy(() => import('../pages/ForgotPassword'));
 const ResetPassword = lazy(() => import('../pages/ResetPassword'));
+const AcceptInvite = lazy(() => import('../pages/AcceptInvite'));
+const VerifyEmail = lazy(() => import('../pages/VerifyEmail'));
 const Dashboard = lazy(() => import('../pages/Dashboard'));
 const GinList = lazy(() => import('../pages/GinList'));
 const GinDetail = lazy(() => import('../pages/GinDetail'));
@@ -64,6 +66,22 @@ export const router = createBrowserRouter([
       </LazyPage>
     ),
   },
+  {
+    path: '/accept-invite',
+    element: (
+      <LazyPage>
+        <AcceptInvite />
+      </LazyPage>
+    ),
+  },
+  {
+    path: '/verify-email',
+    element: (
+      <LazyPage>
+        <VerifyEmail />
+      </LazyPage>
+    ),
+  },
   {
     path: '/subscription/success',
     element: (
@@ -21,6 +21,12 @@ interface AuthState {
     first_name?: string;
     last_name?: string;
   }) => Promise<void>;
+  acceptInvite: (data: {
+    token: string;
+    password: string;
+    first_name?: string;
+    last_name?: string;
+  }) => Promise<boolean>;
   setUser: (user: User) => void;
   setTenant: (tenant: Tenant) => void;
   initializeAuth: () => Promise<void>;
@@ -100,13 +106,47 @@ export const useAuthStore = create<AuthState>()(
         }
       },
 
+      // Returns false when the tenant requires a second factor, the user then
+      // signs in with the new password
+      acceptInvite: async (data) => {
+        set({ isLoading: true });
+        try {
+          const response = await authAPI.acceptInvite(data);
+          // API returns { success: true, data: { user, tenant } } or a 2FA challenge
+          const apiResponse = response.data as unknown as {
+            success: boolean;
+            data: { user?: User; tenant?: Tenant; two_factor_required?: boolean };
+          };
+          const { user, tenant, two_factor_required } = apiResponse.data;
+
+          if (two_factor_required || !user || !tenant) {
+            set({ isLoading: false });
+            return false;
+          }
+
+          set({
+            user,
+            tenant,
+            isAuthenticated: true,
+            isLoading: false,
+          });
+
+          // Fetch CSRF token after accepting the invitation
+          fetchCSRFToken();
+          return true;
+        } catch (error) {
+          set({ isLoading: false });
+          throw error;
+        }
+      },
+
       setUser: (user: User) => set({ user }),
       setTenant: (tenant: Tenant) => set({ tenant }),
 
       // Check if user is authenticated by calling /auth/me
       checkAuth: async () => {
         try {
-          const response = await authAPI.me();
+          const response = await authAPI.getMe();
           const apiResponse = response.data as unknown as { success: boolean; data: User };
           if (apiResponse.success && apiResponse.data) {
             set({ user: apiResponse.data, isAuthenticated: true });
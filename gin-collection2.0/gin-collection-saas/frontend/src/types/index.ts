@@ -7,13 +7,57 @@ export interface User {
   first_name?: string;
   last_name?: string;
   role: UserRole;
+  custom_role_id?: number;
   is_active: boolean;
+  email_verified_at?: string;
+  last_login_at?: string;
+  locked_until?: string; // locked after failed logins
   created_at: string;
   updated_at: string;
 }
 
+// Pending email verification or email change
+export interface EmailVerification {
+  user_id: number;
+  purpose: 'verify' | 'change';
+  email: string;
+  confirmed_at?: string;
+  old_confirmed_at?: string;
+  expires_at: string;
+  completed_at?: string;
+  created_at: string;
+}
+
 export type UserRole = 'owner' | 'admin' | 'member' | 'viewer';
 
+export interface PermissionInfo {
+  name: string;
+  description: string;
+  assignable: boolean;
+}
+
+export interface Role {
+  id?: number;
+  name: string;
+  description?: string;
+  built_in: boolean;
+  permissions: string[];
+  user_count?: number;
+}
+
+export interface APIKey {
+  id: string;
+  user_id: number;
+  name: string;
+  prefix: string;
+  scopes: string[];
+  rate_limit?: number;
+  expires_at?: string;
+  last_used_at?: string;
+  last_used_ip?: string;
+  created_at: string;
+}
+
 export interface AuthResponse {
   token: string;
   refresh_token?: string;
@@ -29,6 +73,8 @@ export interface Tenant {
   subdomain: string;
   tier: TenantTier;
   status: TenantStatus;
+  billing_restricted: boolean;
+  limit_overrides?: LimitOverrides;
   branding?: TenantBranding;
   created_at: string;
   updated_at: string;
@@ -146,29 +192,227 @@ export interface Subscription {
   id: number;
   tenant_id: number;
   plan_id: string;
+  plan_version: number;
   status: SubscriptionStatus;
   billing_cycle: BillingCycle;
+  provider: BillingProvider;
   amount: number;
   currency: string;
   current_period_start?: string;
   current_period_end?: string;
   next_billing_date?: string;
+  cancel_at_period_end: boolean;
+  scheduled_plan_id?: string;
+  scheduled_change_at?: string;
+  trial_ends_at?: string;
+  paused_at?: string;
+  past_due_since?: string;
+  dunning_stage?: DunningStage;
+  coupon_id?: number;
+  discount: number;
+  discount_ends_at?: string;
   created_at: string;
   updated_at: string;
 }
 
-export type SubscriptionStatus = 'active' | 'pending' | 'cancelled' | 'suspended' | 'expired';
+export type SubscriptionStatus = 'active' | 'pending' | 'trialing' | 'past_due' | 'paused' | 'cancelled' | 'suspended' | 'expired';
 export type BillingCycle = 'monthly' | 'yearly';
+export type DunningStage = 'grace' | 'restricted' | 'downgraded' | 'suspended';
+export type BillingProvider = 'paypal' | 'stripe';
+
+export interface BillingProviders {
+  available: BillingProvider[];
+  default: BillingProvider;
+  selected: BillingProvider;
+}
+
+export interface BillingDetails {
+  name: string;
+  email?: string;
+  address_line1: string;
+  address_line2?: string;
+  postal_code: string;
+  city: string;
+  country: string;
+  vat_id?: string;
+}
+
+export interface Invoice {
+  id: number;
+  tenant_id: number;
+  subscription_id?: number;
+  number: string;
+  provider: BillingProvider;
+  provider_payment_id: string;
+  plan_id: string;
+  description: string;
+  period_start?: string;
+  period_end?: string;
+  currency: string;
+  net_amount: number;
+  tax_rate: number;
+  tax_amount: number;
+  total_amount: number;
+  reverse_charge: boolean;
+  customer: BillingDetails | null;
+  seller: BillingDetails | null;
+  issued_at: string;
+  created_at: string;
+}
+
+export interface UpcomingCharge {
+  date: string;
+  plan_id: string;
+  plan_name: string;
+  billing_cycle: BillingCycle;
+  price: number;
+  discount: number;
+  total_amount: number;
+  net_amount: number;
+  tax_rate: number;
+  tax_amount: number;
+  reverse_charge: boolean;
+  currency: string;
+  scheduled: boolean;
+}
+
+export interface UpcomingCharges {
+  subscription_id: number;
+  status: SubscriptionStatus;
+  cancel_at_period_end: boolean;
+  ends_at?: string;
+  charges: UpcomingCharge[];
+}
+
+export interface Proration {
+  credit: number;
+  charge: number;
+  amount_due: number;
+  carried_credit: number;
+  currency: string;
+  remaining_days: number;
+  period_days: number;
+  invoiced: boolean;
+}
+
+export interface DowngradeImpact {
+  from_tier: TenantTier;
+  to_tier: TenantTier;
+  gin_count: number;
+  gin_limit: number | null;
+  gins_over_limit: number;
+  photo_limit: number;
+  photos_over_limit: number;
+  gins_with_photos_over_limit: number;
+  storage_mb: number;
+  storage_limit_mb: number | null;
+  storage_over_limit: boolean;
+  features_lost: string[];
+  read_only: boolean;
+}
+
+export interface PlanChangePreview {
+  current_plan_id: string;
+  target_plan: SubscriptionPlan;
+  downgrade: boolean;
+  effective_at: string;
+  proration?: Proration;
+  impact?: DowngradeImpact;
+}
+
+export interface PlanChange {
+  approval_url?: string;
+  subscription: Subscription;
+  plan: SubscriptionPlan;
+  tier: TenantTier;
+  scheduled: boolean;
+  effective_at: string;
+  proration?: Proration;
+  impact?: DowngradeImpact;
+}
 
 export interface SubscriptionPlan {
   id: string;
   name: string;
   tier: TenantTier;
+  billing_cycle: BillingCycle | ''; // empty = both cycles
   description: string;
   price_monthly: number;
   price_yearly: number;
   features: string[];
-  limits: TenantLimits;
+  limits: PlanLimits;
+  trial_days: number;
+  version: number;
+  active: boolean;
+  sort_order: number;
+}
+
+export interface PlanLimits {
+  max_gins: number | null; // null = unlimited
+  max_photos_per_gin: number; // -1 = unlimited
+  has_botanicals: boolean;
+  has_cocktails: boolean;
+  has_ai_suggestions: boolean;
+  has_export: boolean;
+  has_import: boolean;
+  has_multi_user: boolean;
+  has_api_access: boolean;
+  api_rate_limit: number;
+  storage_limit_mb: number | null; // null = unlimited
+  has_sso: boolean;
+  has_scim: boolean;
+  max_api_calls_per_period: number | null; // null = unlimited
+  max_ai_suggestions_per_period: number | null; // null = unlimited
+  has_dedicated_database: boolean;
+}
+
+// Limits set by platform admins for a single tenant, -1 = unlimited
+export interface LimitOverrides {
+  max_gins?: number;
+  max_photos_per_gin?: number;
+  storage_limit_mb?: number;
+  api_rate_limit?: number;
+  max_api_calls_per_period?: number;
+  max_ai_suggestions_per_period?: number;
+}
+
+export type UsageMetric = 'api_calls' | 'ai_suggestions' | 'storage_mb';
+
+export interface UsageMeter {
+  metric: UsageMetric;
+  current: number;
+  limit: number | null; // null = unlimited
+  percentage: number;
+  reached: boolean;
+  overridden?: boolean;
+}
+
+export interface UsagePeriod {
+  period_start: string;
+  period_end: string;
+  meters: UsageMeter[];
+}
+
+export interface UsageReport {
+  current: UsagePeriod;
+  history: UsagePeriod[]; // newest first
+  limits: PlanLimits;
+  overrides?: LimitOverrides;
+}
+
+export type CouponDuration = 'once' | 'repeating' | 'forever';
+
+export interface CouponPreview {
+  code: string;
+  description?: string;
+  plan_id: string;
+  billing_cycle: BillingCycle;
+  price: number;
+  discount: number;
+  discounted_price: number;
+  currency: string;
+  duration: CouponDuration;
+  duration_months?: number;
 }
 
 // Botanical Types
@@ -205,10 +449,26 @@ export interface CocktailIngredient {
 }
 
 // API Error Types
+export type DenialReason =
+  | 'feature_not_in_plan'
+  | 'limit_reached'
+  | 'over_limit'
+  | 'payment_overdue'
+  | 'unknown_feature';
+
 export interface APIError {
   error: string;
   upgrade_required?: boolean;
   code?: string;
+  // Set when the plan denies a feature or limit
+  reason?: DenialReason;
+  feature?: string;
+  current_tier?: string;
+  required_tier?: string;
+  upgrade_plan_id?: string;
+  limit?: number;
+  current_count?: number;
+  payment_required?: boolean;
 }
 
 // Search & Filter Types
@@ -0,0 +1,183 @@
+package admin
+
+import (
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/yourusername/gin-collection-saas/internal/delivery/http/middleware"
+	domainErrors "github.com/yourusername/gin-collection-saas/internal/domain/errors"
+	"github.com/yourusername/gin-collection-saas/internal/domain/models"
+	"github.com/yourusername/gin-collection-saas/internal/usecase/subscription"
+	"github.com/yourusername/gin-collection-saas/pkg/logger"
+)
+
+// CouponHandler lets platform admins manage coupon codes
+type CouponHandler struct {
+	subscriptionService *subscription.Service
+}
+
+// NewCouponHandler creates a new coupon handler
+func NewCouponHandler(subscriptionService *subscription.Service) *CouponHandler {
+	return &CouponHandler{
+		subscriptionService: subscriptionService,
+	}
+}
+
+// couponRequest is the body of coupon create and update requests
+type couponRequest struct {
+	Code           string                    `json:"code"` // ignored on update
+	Description    string                    `json:"description"`
+	DiscountType   models.CouponDiscountType `json:"discount_type" binding:"required"`
+	DiscountValue  float64                   `json:"discount_value" binding:"required"`
+	Currency       string                    `json:"currency"`
+	Duration       models.CouponDuration     `json:"duration" binding:"required"`
+	DurationMonths *int                      `json:"duration_months"`
+	MaxRedemptions *int                      `json:"max_redemptions"`
+	ExpiresAt      *time.Time                `json:"expires_at"`
+	PlanIDs        []string                  `json:"plan_ids"`
+	Active         *bool                     `json:"active"` // defaults to true
+}
+
+// coupon converts the request to a coupon
+func (r *couponRequest) coupon() *models.Coupon {
+	active := true
+	if r.Active != nil {
+		active = *r.Active
+	}
+	return &models.Coupon{
+		Code:           r.Code,
+		Description:    r.Description,
+		DiscountType:   r.DiscountType,
+		DiscountValue:  r.DiscountValue,
+		Currency:       r.Currency,
+		Duration:       r.Duration,
+		DurationMonths: r.DurationMonths,
+		MaxRedemptions: r.MaxRedemptions,
+		ExpiresAt:      r.ExpiresAt,
+		PlanIDs:        r.PlanIDs,
+		Active:         active,
+	}
+}
+
+// ListCoupons handles GET /admin/api/v1/coupons
+func (h *CouponHandler) ListCoupons(c *gin.Context) {
+	coupons, err := h.subscriptionService.ListCoupons(c.Request.Context())
+	if err != nil {
+		logger.Error("Failed to list coupons", "error", err.Error())
+		c.JSON(500, gin.H{"error": "Failed to list coupons"})
+		return
+	}
+
+	c.JSON(200, gin.H{
+		"coupons": coupons,
+		"total":   len(coupons),
+	})
+}
+
+// GetCoupon handles GET /admin/api/v1/coupons/:id
+func (h *CouponHandler) GetCoupon(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(400, gin.H{"error": "Invalid coupon ID"})
+		return
+	}
+
+	coupon, redemptions, err := h.subscriptionService.GetCoupon(c.Request.Context(), id)
+	if err == domainErrors.ErrNotFound {
+		c.JSON(404, gin.H{"error": "Coupon not found"})
+		return
+	}
+	if err != nil {
+		logger.Error("Failed to get coupon", "id", id, "error", err.Error())
+		c.JSON(500, gin.H{"error": "Failed to get coupon"})
+		return
+	}
+
+	c.JSON(200, gin.H{
+		"coupon":      coupon,
+		"redemptions": redemptions,
+	})
+}
+
+// CreateCoupon handles POST /admin/api/v1/coupons
+func (h *CouponHandler) CreateCoupon(c *gin.Context) {
+	var req couponRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(400, gin.H{"error": "Invalid request: " + err.Error()})
+		return
+	}
+
+	coupon := req.coupon()
+	adminID, ok := middleware.GetAdminID(c)
+	if ok {
+		coupon.CreatedBy = &adminID
+	}
+
+	if err := h.subscriptionService.CreateCoupon(c.Request.Context(), coupon); err != nil {
+		h.handleError(c, err, "Failed to create coupon")
+		return
+	}
+
+	logger.Info("Coupon created by platform admin", "coupon_id", coupon.ID, "code", coupon.Code, "admin_id", adminID)
+	c.JSON(201, coupon)
+}
+
+// UpdateCoupon handles PUT /admin/api/v1/coupons/:id
+func (h *CouponHandler) UpdateCoupon(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(400, gin.H{"error": "Invalid coupon ID"})
+		return
+	}
+
+	var req couponRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(400, gin.H{"error": "Invalid request: " + err.Error()})
+		return
+	}
+
+	coupon, err := h.subscriptionService.UpdateCoupon(c.Request.Context(), id, req.coupon())
+	if err != nil {
+		h.handleError(c, err, "Failed to update coupon")
+		return
+	}
+
+	adminID, _ := middleware.GetAdminID(c)
+	logger.Info("Coupon updated by platform admin", "coupon_id", id, "admin_id", adminID)
+	c.JSON(200, coupon)
+}
+
+// DeleteCoupon handles DELETE /admin/api/v1/coupons/:id
+func (h *CouponHandler) DeleteCoupon(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(400, gin.H{"error": "Invalid coupon ID"})
+		return
+	}
+
+	if err := h.subscriptionService.DeleteCoupon(c.Request.Context(), id); err != nil {
+		h.handleError(c, err, "Failed to delete coupon")
+		return
+	}
+
+	adminID, _ := middleware.GetAdminID(c)
+	logger.Info("Coupon deleted by platform admin", "coupon_id", id, "admin_id", adminID)
+	c.JSON(200, gin.H{"message": "Coupon deleted"})
+}
+
+// handleError responds with the status of a coupon error
+func (h *CouponHandler) handleError(c *gin.Context, err error, message string) {
+	switch {
+	case err == domainErrors.ErrNotFound:
+		c.JSON(404, gin.H{"error": "Coupon not found"})
+	case err == domainErrors.ErrCouponCodeTaken:
+		c.JSON(409, gin.H{"error": err.Error()})
+	case errors.Is(err, domainErrors.ErrInvalidInput):
+		c.JSON(400, gin.H{"error": err.Error()})
+	default:
+		logger.Error(message, "error", err.Error())
+		c.JSON(500, gin.H{"error": message})
+	}
+}
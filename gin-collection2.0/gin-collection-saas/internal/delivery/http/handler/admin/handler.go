@@ -5,6 +5,7 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"github.com/yourusername/gin-collection-saas/internal/delivery/http/middleware"
+	domainErrors "github.com/yourusername/gin-collection-saas/internal/domain/errors"
 	"github.com/yourusername/gin-collection-saas/internal/domain/models"
 	adminUsecase "github.com/yourusername/gin-collection-saas/internal/usecase/admin"
 	"github.com/yourusername/gin-collection-saas/pkg/logger"
@@ -26,8 +27,9 @@ func NewHandler(adminService *adminUsecase.Service) *Handler {
 
 // LoginRequest represents admin login request
 type LoginRequest struct {
-	Email    string `json:"email" binding:"required,email"`
-	Password string `json:"password" binding:"required,min=6"`
+	Email       string `json:"email" binding:"required,email"`
+	Password    string `json:"password" binding:"required,min=6"`
+	DeviceToken string `json:"device_token"` // trusted device, skips the second factor
 }
 
 // Login handles POST /admin/api/v1/auth/login
@@ -38,7 +40,7 @@ func (h *Handler) Login(c *gin.Context) {
 		return
 	}
 
-	response, err := h.adminService.Login(c.Request.Context(), req.Email, req.Password)
+	response, err := h.adminService.Login(c.Request.Context(), req.Email, req.Password, req.DeviceToken)
 	if err != nil {
 		logger.Warn("Admin login failed", "email", req.Email, "error", err.Error())
 		c.JSON(401, gin.H{"error": "Invalid credentials"})
@@ -94,6 +96,241 @@ func (h *Handler) ChangePassword(c *gin.Context) {
 	c.JSON(200, gin.H{"message": "Password changed successfully"})
 }
 
+// VerifyTwoFactor handles POST /admin/api/v1/auth/2fa/verify
+func (h *Handler) VerifyTwoFactor(c *gin.Context) {
+	var req models.TwoFactorVerifyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(400, gin.H{"error": "Invalid request: " + err.Error()})
+		return
+	}
+
+	response, err := h.adminService.VerifyTwoFactor(c.Request.Context(), &req, c.Request.UserAgent())
+	if err != nil {
+		respondTwoFactorError(c, err)
+		return
+	}
+
+	c.JSON(200, response)
+}
+
+// SetupTwoFactor handles POST /admin/api/v1/auth/2fa/enroll
+func (h *Handler) SetupTwoFactor(c *gin.Context) {
+	var req models.TwoFactorSetupRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(400, gin.H{"error": "Invalid request: " + err.Error()})
+		return
+	}
+
+	enrollment, err := h.adminService.BeginTwoFactorEnrollment(c.Request.Context(), req.ChallengeToken)
+	if err != nil {
+		respondTwoFactorError(c, err)
+		return
+	}
+
+	c.JSON(200, enrollment)
+}
+
+// GetTwoFactor handles GET /admin/api/v1/auth/2fa
+func (h *Handler) GetTwoFactor(c *gin.Context) {
+	adminID, ok := middleware.GetAdminID(c)
+	if !ok {
+		c.JSON(401, gin.H{"error": "Not authenticated"})
+		return
+	}
+
+	status, err := h.adminService.GetTwoFactorStatus(c.Request.Context(), adminID)
+	if err != nil {
+		logger.Error("Failed to get two-factor status", "error", err.Error())
+		c.JSON(500, gin.H{"error": "Failed to get two-factor status"})
+		return
+	}
+
+	c.JSON(200, status)
+}
+
+// RegenerateRecoveryCodes handles POST /admin/api/v1/auth/2fa/recovery-codes
+func (h *Handler) RegenerateRecoveryCodes(c *gin.Context) {
+	adminID, ok := middleware.GetAdminID(c)
+	if !ok {
+		c.JSON(401, gin.H{"error": "Not authenticated"})
+		return
+	}
+
+	var req models.TwoFactorCodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(400, gin.H{"error": "Invalid request: " + err.Error()})
+		return
+	}
+
+	codes, err := h.adminService.RegenerateRecoveryCodes(c.Request.Context(), adminID, req.Code)
+	if err != nil {
+		respondTwoFactorError(c, err)
+		return
+	}
+
+	c.JSON(200, gin.H{"recovery_codes": codes})
+}
+
+// respondTwoFactorError maps two-factor errors to status codes
+func respondTwoFactorError(c *gin.Context, err error) {
+	switch err {
+	case adminUsecase.ErrInvalidChallenge, domainErrors.ErrInvalidTwoFactorCode:
+		c.JSON(401, gin.H{"error": err.Error()})
+	case adminUsecase.ErrAdminNotActive:
+		c.JSON(403, gin.H{"error": err.Error()})
+	case domainErrors.ErrTwoFactorAlreadyEnabled:
+		c.JSON(409, gin.H{"error": err.Error()})
+	case domainErrors.ErrTwoFactorNotEnabled:
+		c.JSON(400, gin.H{"error": err.Error()})
+	default:
+		logger.Error("Admin two-factor request failed", "error", err.Error())
+		c.JSON(500, gin.H{"error": "Two-factor verification failed"})
+	}
+}
+
+// PasskeyLoginBeginRequest optionally names the admin logging in
+type PasskeyLoginBeginRequest struct {
+	Email string `json:"email" binding:"omitempty,email"`
+}
+
+// BeginPasskeyLogin handles POST /admin/api/v1/auth/passkeys/login/begin
+func (h *Handler) BeginPasskeyLogin(c *gin.Context) {
+	var req PasskeyLoginBeginRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(400, gin.H{"error": "Invalid request: " + err.Error()})
+		return
+	}
+
+	options, err := h.adminService.BeginPasskeyLogin(c.Request.Context(), req.Email)
+	if err != nil {
+		logger.Error("Failed to start admin passkey login", "error", err.Error())
+		c.JSON(500, gin.H{"error": "Failed to start passkey login"})
+		return
+	}
+
+	c.JSON(200, options)
+}
+
+// FinishPasskeyLogin handles POST /admin/api/v1/auth/passkeys/login/finish
+func (h *Handler) FinishPasskeyLogin(c *gin.Context) {
+	var req models.WebAuthnLoginRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(400, gin.H{"error": "Invalid request: " + err.Error()})
+		return
+	}
+
+	response, err := h.adminService.FinishPasskeyLogin(c.Request.Context(), &req)
+	if err != nil {
+		respondPasskeyError(c, err)
+		return
+	}
+
+	c.JSON(200, response)
+}
+
+// ListPasskeys handles GET /admin/api/v1/auth/passkeys
+func (h *Handler) ListPasskeys(c *gin.Context) {
+	adminID, ok := middleware.GetAdminID(c)
+	if !ok {
+		c.JSON(401, gin.H{"error": "Not authenticated"})
+		return
+	}
+
+	passkeys, err := h.adminService.ListPasskeys(c.Request.Context(), adminID)
+	if err != nil {
+		logger.Error("Failed to list passkeys", "error", err.Error())
+		c.JSON(500, gin.H{"error": "Failed to list passkeys"})
+		return
+	}
+
+	c.JSON(200, gin.H{"passkeys": passkeys})
+}
+
+// BeginPasskeyRegistration handles POST /admin/api/v1/auth/passkeys/register/begin
+func (h *Handler) BeginPasskeyRegistration(c *gin.Context) {
+	adminID, ok := middleware.GetAdminID(c)
+	if !ok {
+		c.JSON(401, gin.H{"error": "Not authenticated"})
+		return
+	}
+
+	options, err := h.adminService.BeginPasskeyRegistration(c.Request.Context(), adminID)
+	if err != nil {
+		logger.Error("Failed to start passkey registration", "error", err.Error())
+		c.JSON(500, gin.H{"error": "Failed to start passkey registration"})
+		return
+	}
+
+	c.JSON(200, options)
+}
+
+// FinishPasskeyRegistration handles POST /admin/api/v1/auth/passkeys/register/finish
+func (h *Handler) FinishPasskeyRegistration(c *gin.Context) {
+	adminID, ok := middleware.GetAdminID(c)
+	if !ok {
+		c.JSON(401, gin.H{"error": "Not authenticated"})
+		return
+	}
+
+	var req models.WebAuthnRegistrationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(400, gin.H{"error": "Invalid request: " + err.Error()})
+		return
+	}
+
+	passkey, err := h.adminService.FinishPasskeyRegistration(c.Request.Context(), adminID, &req)
+	if err != nil {
+		respondPasskeyError(c, err)
+		return
+	}
+
+	c.JSON(201, passkey)
+}
+
+// DeletePasskey handles DELETE /admin/api/v1/auth/passkeys/:id
+func (h *Handler) DeletePasskey(c *gin.Context) {
+	adminID, ok := middleware.GetAdminID(c)
+	if !ok {
+		c.JSON(401, gin.H{"error": "Not authenticated"})
+		return
+	}
+
+	passkeyID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(400, gin.H{"error": "Invalid passkey ID"})
+		return
+	}
+
+	if err := h.adminService.DeletePasskey(c.Request.Context(), adminID, passkeyID); err != nil {
+		if err == domainErrors.ErrNotFound {
+			c.JSON(404, gin.H{"error": "Passkey not found"})
+			return
+		}
+		logger.Error("Failed to delete passkey", "error", err.Error())
+		c.JSON(500, gin.H{"error": "Failed to delete passkey"})
+		return
+	}
+
+	c.JSON(200, gin.H{"message": "Passkey removed"})
+}
+
+// respondPasskeyError maps passkey ceremony errors to status codes
+func respondPasskeyError(c *gin.Context, err error) {
+	switch err {
+	case domainErrors.ErrPasskeyVerification, domainErrors.ErrInvalidToken, adminUsecase.ErrInvalidCredentials:
+		c.JSON(401, gin.H{"error": err.Error()})
+	case adminUsecase.ErrAdminNotActive:
+		c.JSON(403, gin.H{"error": err.Error()})
+	case domainErrors.ErrConflict:
+		c.JSON(409, gin.H{"error": "Passkey already registered"})
+	case domainErrors.ErrInvalidInput:
+		c.JSON(400, gin.H{"error": err.Error()})
+	default:
+		logger.Error("Admin passkey request failed", "error", err.Error())
+		c.JSON(500, gin.H{"error": "Passkey verification failed"})
+	}
+}
+
 // ==================== STATS ====================
 
 // GetStats handles GET /admin/api/v1/stats/overview
@@ -0,0 +1,153 @@
+package admin
+
+import (
+	"errors"
+
+	"github.com/gin-gonic/gin"
+	"github.com/yourusername/gin-collection-saas/internal/delivery/http/middleware"
+	domainErrors "github.com/yourusername/gin-collection-saas/internal/domain/errors"
+	"github.com/yourusername/gin-collection-saas/internal/domain/models"
+	"github.com/yourusername/gin-collection-saas/internal/usecase/subscription"
+	"github.com/yourusername/gin-collection-saas/pkg/logger"
+)
+
+// PlanHandler lets platform admins manage the plan catalog
+type PlanHandler struct {
+	catalog *subscription.Catalog
+}
+
+// NewPlanHandler creates a new plan handler
+func NewPlanHandler(catalog *subscription.Catalog) *PlanHandler {
+	return &PlanHandler{
+		catalog: catalog,
+	}
+}
+
+// planRequest is the body of plan create and update requests
+type planRequest struct {
+	ID            string                  `json:"id"`            // ignored on update
+	Tier          models.SubscriptionTier `json:"tier"`          // ignored on update
+	BillingCycle  models.BillingCycle     `json:"billing_cycle"` // empty = both cycles, ignored on update
+	Name          string                  `json:"name" binding:"required"`
+	PriceMonthly  float64                 `json:"price_monthly"`
+	PriceYearly   float64                 `json:"price_yearly"`
+	Currency      string                  `json:"currency"`
+	Limits        models.PlanLimits       `json:"limits"`
+	ProviderPlans map[string]string       `json:"provider_plans"`
+	SortOrder     int                     `json:"sort_order"`
+	Active        *bool                   `json:"active"` // defaults to true
+}
+
+// plan converts the request to a plan
+func (r *planRequest) plan() *models.SubscriptionPlan {
+	active := true
+	if r.Active != nil {
+		active = *r.Active
+	}
+	return &models.SubscriptionPlan{
+		ID:            r.ID,
+		Tier:          r.Tier,
+		BillingCycle:  r.BillingCycle,
+		Name:          r.Name,
+		PriceMonthly:  r.PriceMonthly,
+		PriceYearly:   r.PriceYearly,
+		Currency:      r.Currency,
+		Limits:        r.Limits,
+		ProviderPlans: r.ProviderPlans,
+		SortOrder:     r.SortOrder,
+		Active:        active,
+	}
+}
+
+// ListPlans handles GET /admin/api/v1/plans
+func (h *PlanHandler) ListPlans(c *gin.Context) {
+	if err := h.catalog.Load(c.Request.Context()); err != nil {
+		logger.Error("Failed to load plans", "error", err.Error())
+		c.JSON(500, gin.H{"error": "Failed to list plans"})
+		return
+	}
+
+	plans := h.catalog.Plans()
+	c.JSON(200, gin.H{
+		"plans": plans,
+		"total": len(plans),
+	})
+}
+
+// GetPlan handles GET /admin/api/v1/plans/:id
+func (h *PlanHandler) GetPlan(c *gin.Context) {
+	plan, versions, err := h.catalog.GetPlan(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		h.handleError(c, err, "Failed to get plan")
+		return
+	}
+
+	c.JSON(200, gin.H{
+		"plan":     plan,
+		"versions": versions,
+	})
+}
+
+// CreatePlan handles POST /admin/api/v1/plans
+func (h *PlanHandler) CreatePlan(c *gin.Context) {
+	var req planRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(400, gin.H{"error": "Invalid request: " + err.Error()})
+		return
+	}
+
+	plan := req.plan()
+	adminID, ok := middleware.GetAdminID(c)
+	if ok {
+		plan.CreatedBy = &adminID
+	}
+
+	if err := h.catalog.CreatePlan(c.Request.Context(), plan); err != nil {
+		h.handleError(c, err, "Failed to create plan")
+		return
+	}
+
+	logger.Info("Plan created by platform admin", "plan_id", plan.ID, "admin_id", adminID)
+	c.JSON(201, plan)
+}
+
+// UpdatePlan handles PUT /admin/api/v1/plans/:id
+// Changed prices, limits or provider plans create a new version, existing
+// subscribers keep theirs
+func (h *PlanHandler) UpdatePlan(c *gin.Context) {
+	var req planRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(400, gin.H{"error": "Invalid request: " + err.Error()})
+		return
+	}
+
+	update := req.plan()
+	adminID, ok := middleware.GetAdminID(c)
+	if ok {
+		update.CreatedBy = &adminID
+	}
+
+	plan, err := h.catalog.UpdatePlan(c.Request.Context(), c.Param("id"), update)
+	if err != nil {
+		h.handleError(c, err, "Failed to update plan")
+		return
+	}
+
+	logger.Info("Plan updated by platform admin", "plan_id", plan.ID, "version", plan.Version, "admin_id", adminID)
+	c.JSON(200, plan)
+}
+
+// handleError responds with the status of a plan error
+func (h *PlanHandler) handleError(c *gin.Context, err error, message string) {
+	switch {
+	case err == domainErrors.ErrNotFound:
+		c.JSON(404, gin.H{"error": "Plan not found"})
+	case err == domainErrors.ErrPlanIDTaken:
+		c.JSON(409, gin.H{"error": err.Error()})
+	case errors.Is(err, domainErrors.ErrInvalidInput):
+		c.JSON(400, gin.H{"error": err.Error()})
+	default:
+		logger.Error(message, "error", err.Error())
+		c.JSON(500, gin.H{"error": message})
+	}
+}
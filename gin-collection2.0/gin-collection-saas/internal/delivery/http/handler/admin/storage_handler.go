@@ -0,0 +1,103 @@
+package admin
+
+import (
+	"github.com/gin-gonic/gin"
+	domainErrors "github.com/yourusername/gin-collection-saas/internal/domain/errors"
+	"github.com/yourusername/gin-collection-saas/internal/usecase/storagesync"
+	"github.com/yourusername/gin-collection-saas/pkg/logger"
+)
+
+// StorageHandler handles storage migration and reconciliation
+type StorageHandler struct {
+	storageSync *storagesync.Service
+}
+
+// NewStorageHandler creates a new storage handler
+func NewStorageHandler(storageSync *storagesync.Service) *StorageHandler {
+	return &StorageHandler{
+		storageSync: storageSync,
+	}
+}
+
+// ListBackends handles GET /admin/api/v1/storage/backends
+func (h *StorageHandler) ListBackends(c *gin.Context) {
+	c.JSON(200, gin.H{"backends": h.storageSync.Backends()})
+}
+
+// MigrateRequest represents a storage migration request
+type MigrateRequest struct {
+	TenantID     int64  `json:"tenant_id"`
+	From         string `json:"from" binding:"required,oneof=local s3"`
+	To           string `json:"to" binding:"required,oneof=local s3"`
+	DeleteSource bool   `json:"delete_source"`
+	DryRun       bool   `json:"dry_run"`
+}
+
+// Migrate handles POST /admin/api/v1/storage/migrate
+func (h *StorageHandler) Migrate(c *gin.Context) {
+	var req MigrateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(400, gin.H{"error": "Invalid request: " + err.Error()})
+		return
+	}
+
+	job, err := h.storageSync.StartMigration(&storagesync.MigrateOptions{
+		TenantID:     req.TenantID,
+		From:         req.From,
+		To:           req.To,
+		DeleteSource: req.DeleteSource,
+		DryRun:       req.DryRun,
+	})
+	if err == domainErrors.ErrConflict {
+		c.JSON(409, gin.H{"error": "A storage migration is already running"})
+		return
+	}
+	if err != nil {
+		c.JSON(400, gin.H{"error": "Invalid migration: " + err.Error()})
+		return
+	}
+
+	logger.Info("Storage migration started", "job_id", job.ID, "from", req.From, "to", req.To, "tenant_id", req.TenantID)
+
+	c.JSON(202, job)
+}
+
+// GetMigration handles GET /admin/api/v1/storage/migrations/:id
+func (h *StorageHandler) GetMigration(c *gin.Context) {
+	job, err := h.storageSync.GetMigrationJob(c.Param("id"))
+	if err != nil {
+		c.JSON(404, gin.H{"error": "Migration job not found"})
+		return
+	}
+
+	c.JSON(200, job)
+}
+
+// ReconcileRequest represents a storage reconciliation request
+type ReconcileRequest struct {
+	TenantID      int64  `json:"tenant_id"`
+	Backend       string `json:"backend" binding:"required,oneof=local s3"`
+	DeleteOrphans bool   `json:"delete_orphans"`
+}
+
+// Reconcile handles POST /admin/api/v1/storage/reconcile
+func (h *StorageHandler) Reconcile(c *gin.Context) {
+	var req ReconcileRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(400, gin.H{"error": "Invalid request: " + err.Error()})
+		return
+	}
+
+	report, err := h.storageSync.Reconcile(c.Request.Context(), &storagesync.ReconcileOptions{
+		TenantID:      req.TenantID,
+		Backend:       req.Backend,
+		DeleteOrphans: req.DeleteOrphans,
+	})
+	if err != nil {
+		logger.Error("Storage reconciliation failed", "error", err.Error())
+		c.JSON(500, gin.H{"error": "Storage reconciliation failed: " + err.Error(), "report": report})
+		return
+	}
+
+	c.JSON(200, report)
+}
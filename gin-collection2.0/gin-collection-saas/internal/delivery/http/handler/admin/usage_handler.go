@@ -0,0 +1,113 @@
+package admin
+
+import (
+	"errors"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/yourusername/gin-collection-saas/internal/delivery/http/middleware"
+	domainErrors "github.com/yourusername/gin-collection-saas/internal/domain/errors"
+	"github.com/yourusername/gin-collection-saas/internal/domain/models"
+	"github.com/yourusername/gin-collection-saas/internal/usecase/usage"
+	"github.com/yourusername/gin-collection-saas/pkg/logger"
+)
+
+// UsageHandler lets platform admins view the usage of tenants and set limits
+// that differ from their plan
+type UsageHandler struct {
+	usageService *usage.Service
+}
+
+// NewUsageHandler creates a new usage handler
+func NewUsageHandler(usageService *usage.Service) *UsageHandler {
+	return &UsageHandler{
+		usageService: usageService,
+	}
+}
+
+// GetTenantUsage handles GET /admin/api/v1/tenants/:id/usage
+func (h *UsageHandler) GetTenantUsage(c *gin.Context) {
+	tenantID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(400, gin.H{"error": "Invalid tenant ID"})
+		return
+	}
+
+	periods, _ := strconv.Atoi(c.DefaultQuery("periods", "12"))
+
+	tenant, report, err := h.usageService.GetTenantReport(c.Request.Context(), tenantID, periods)
+	if err != nil {
+		h.handleError(c, err, "Failed to get tenant usage")
+		return
+	}
+
+	c.JSON(200, gin.H{
+		"tenant_id": tenant.ID,
+		"tier":      tenant.Tier,
+		"usage":     report,
+	})
+}
+
+// UpdateTenantLimits handles PUT /admin/api/v1/tenants/:id/limits
+// Limits left out keep the plan's limit, -1 is unlimited
+func (h *UsageHandler) UpdateTenantLimits(c *gin.Context) {
+	tenantID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(400, gin.H{"error": "Invalid tenant ID"})
+		return
+	}
+
+	var overrides models.LimitOverrides
+	if err := c.ShouldBindJSON(&overrides); err != nil {
+		c.JSON(400, gin.H{"error": "Invalid request: " + err.Error()})
+		return
+	}
+
+	adminID, _ := middleware.GetAdminID(c)
+	tenant, err := h.usageService.SetOverrides(c.Request.Context(), tenantID, &overrides, adminID)
+	if err != nil {
+		h.handleError(c, err, "Failed to update tenant limits")
+		return
+	}
+
+	c.JSON(200, gin.H{
+		"tenant_id":       tenant.ID,
+		"limit_overrides": tenant.LimitOverrides,
+		"limits":          tenant.GetLimits(),
+	})
+}
+
+// DeleteTenantLimits handles DELETE /admin/api/v1/tenants/:id/limits
+// The tenant gets the limits of its plan again
+func (h *UsageHandler) DeleteTenantLimits(c *gin.Context) {
+	tenantID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(400, gin.H{"error": "Invalid tenant ID"})
+		return
+	}
+
+	adminID, _ := middleware.GetAdminID(c)
+	tenant, err := h.usageService.SetOverrides(c.Request.Context(), tenantID, nil, adminID)
+	if err != nil {
+		h.handleError(c, err, "Failed to remove tenant limits")
+		return
+	}
+
+	c.JSON(200, gin.H{
+		"tenant_id": tenant.ID,
+		"limits":    tenant.GetLimits(),
+	})
+}
+
+// handleError responds with the status of a usage error
+func (h *UsageHandler) handleError(c *gin.Context, err error, message string) {
+	switch {
+	case err == domainErrors.ErrNotFound || err == domainErrors.ErrTenantNotFound:
+		c.JSON(404, gin.H{"error": "Tenant not found"})
+	case errors.Is(err, domainErrors.ErrInvalidInput):
+		c.JSON(400, gin.H{"error": err.Error()})
+	default:
+		logger.Error(message, "error", err.Error())
+		c.JSON(500, gin.H{"error": message})
+	}
+}
@@ -0,0 +1,105 @@
+package admin
+
+import (
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/yourusername/gin-collection-saas/internal/delivery/http/middleware"
+	"github.com/yourusername/gin-collection-saas/internal/domain/errors"
+	"github.com/yourusername/gin-collection-saas/internal/domain/models"
+	"github.com/yourusername/gin-collection-saas/internal/usecase/subscription"
+	"github.com/yourusername/gin-collection-saas/pkg/logger"
+)
+
+// WebhookHandler lets platform admins inspect and replay payment webhooks
+type WebhookHandler struct {
+	subscriptionService *subscription.Service
+}
+
+// NewWebhookHandler creates a new webhook handler
+func NewWebhookHandler(subscriptionService *subscription.Service) *WebhookHandler {
+	return &WebhookHandler{
+		subscriptionService: subscriptionService,
+	}
+}
+
+// ListEvents handles GET /admin/api/v1/webhooks
+func (h *WebhookHandler) ListEvents(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "50"))
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 200 {
+		limit = 50
+	}
+
+	filter := &models.WebhookEventFilter{
+		Provider:   c.Query("provider"),
+		Status:     models.WebhookEventStatus(c.Query("status")),
+		EventType:  c.Query("event_type"),
+		ResourceID: c.Query("resource_id"),
+		Limit:      limit,
+		Offset:     (page - 1) * limit,
+	}
+
+	events, total, err := h.subscriptionService.ListWebhookEvents(c.Request.Context(), filter)
+	if err != nil {
+		logger.Error("Failed to list webhook events", "error", err.Error())
+		c.JSON(500, gin.H{"error": "Failed to list webhook events"})
+		return
+	}
+
+	c.JSON(200, gin.H{
+		"events": events,
+		"total":  total,
+		"page":   page,
+		"limit":  limit,
+	})
+}
+
+// GetEvent handles GET /admin/api/v1/webhooks/:id
+func (h *WebhookHandler) GetEvent(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(400, gin.H{"error": "Invalid webhook event ID"})
+		return
+	}
+
+	event, err := h.subscriptionService.GetWebhookEvent(c.Request.Context(), id)
+	if err == errors.ErrNotFound {
+		c.JSON(404, gin.H{"error": "Webhook event not found"})
+		return
+	}
+	if err != nil {
+		logger.Error("Failed to get webhook event", "id", id, "error", err.Error())
+		c.JSON(500, gin.H{"error": "Failed to get webhook event"})
+		return
+	}
+
+	c.JSON(200, event)
+}
+
+// ReplayEvent handles POST /admin/api/v1/webhooks/:id/replay
+func (h *WebhookHandler) ReplayEvent(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(400, gin.H{"error": "Invalid webhook event ID"})
+		return
+	}
+
+	event, err := h.subscriptionService.ReplayWebhookEvent(c.Request.Context(), id)
+	if err == errors.ErrNotFound {
+		c.JSON(404, gin.H{"error": "Webhook event not found"})
+		return
+	}
+	if err != nil {
+		logger.Error("Failed to replay webhook event", "id", id, "error", err.Error())
+		c.JSON(500, gin.H{"error": "Failed to replay webhook event"})
+		return
+	}
+
+	adminID, _ := middleware.GetAdminID(c)
+	logger.Info("Webhook event replayed by platform admin", "id", id, "status", event.Status, "admin_id", adminID)
+	c.JSON(200, event)
+}
@@ -0,0 +1,155 @@
+package handler
+
+import (
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/yourusername/gin-collection-saas/internal/delivery/http/middleware"
+	"github.com/yourusername/gin-collection-saas/internal/delivery/http/response"
+	"github.com/yourusername/gin-collection-saas/internal/domain/models"
+	apiKeyUsecase "github.com/yourusername/gin-collection-saas/internal/usecase/apikey"
+	"github.com/yourusername/gin-collection-saas/pkg/logger"
+)
+
+// APIKeyHandler handles API key management HTTP requests
+type APIKeyHandler struct {
+	apiKeyService *apiKeyUsecase.Service
+}
+
+// NewAPIKeyHandler creates a new API key handler
+func NewAPIKeyHandler(apiKeyService *apiKeyUsecase.Service) *APIKeyHandler {
+	return &APIKeyHandler{
+		apiKeyService: apiKeyService,
+	}
+}
+
+// List handles GET /api/v1/api-keys
+func (h *APIKeyHandler) List(c *gin.Context) {
+	tenantID, userID, ok := h.requester(c)
+	if !ok {
+		return
+	}
+
+	h.list(c, tenantID, userID)
+}
+
+// Create handles POST /api/v1/api-keys
+func (h *APIKeyHandler) Create(c *gin.Context) {
+	tenantID, userID, ok := h.requester(c)
+	if !ok {
+		return
+	}
+
+	var req models.CreateAPIKeyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.ValidationError(c, map[string]string{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	if err := req.Validate(); err != nil {
+		response.ValidationError(c, map[string]string{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	created, err := h.apiKeyService.Create(c.Request.Context(), tenantID, userID, &req)
+	if err != nil {
+		logger.Error("Failed to create API key", "user_id", userID, "error", err.Error())
+		response.Error(c, err)
+		return
+	}
+
+	response.Created(c, gin.H{
+		"api_key": created.APIKey,
+		"key":     created.Key,
+		"message": "API key created successfully - store this securely, it will not be shown again",
+	})
+}
+
+// Revoke handles DELETE /api/v1/api-keys/:id
+func (h *APIKeyHandler) Revoke(c *gin.Context) {
+	tenantID, userID, ok := h.requester(c)
+	if !ok {
+		return
+	}
+
+	h.revoke(c, tenantID, userID, userID, c.Param("id"))
+}
+
+// ListForUser handles GET /api/v1/users/:id/api-keys
+func (h *APIKeyHandler) ListForUser(c *gin.Context) {
+	tenantID, _, ok := h.requester(c)
+	if !ok {
+		return
+	}
+
+	targetUserID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(400, gin.H{"error": "Invalid user ID"})
+		return
+	}
+
+	h.list(c, tenantID, targetUserID)
+}
+
+// RevokeForUser handles DELETE /api/v1/users/:id/api-keys/:key_id
+func (h *APIKeyHandler) RevokeForUser(c *gin.Context) {
+	tenantID, requesterUserID, ok := h.requester(c)
+	if !ok {
+		return
+	}
+
+	targetUserID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(400, gin.H{"error": "Invalid user ID"})
+		return
+	}
+
+	h.revoke(c, tenantID, requesterUserID, targetUserID, c.Param("key_id"))
+}
+
+func (h *APIKeyHandler) list(c *gin.Context, tenantID, userID int64) {
+	keys, err := h.apiKeyService.List(c.Request.Context(), tenantID, userID)
+	if err != nil {
+		logger.Error("Failed to list API keys", "user_id", userID, "error", err.Error())
+		response.Error(c, err)
+		return
+	}
+
+	response.Success(c, gin.H{
+		"api_keys": keys,
+		"scopes":   models.APIKeyScopes,
+	})
+}
+
+func (h *APIKeyHandler) revoke(c *gin.Context, tenantID, requesterUserID, userID int64, keyID string) {
+	if err := h.apiKeyService.Revoke(c.Request.Context(), tenantID, requesterUserID, userID, keyID); err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	response.Success(c, gin.H{
+		"message": "API key revoked successfully",
+	})
+}
+
+// requester returns the tenant and user of a request, responding with an
+// error if they are missing
+func (h *APIKeyHandler) requester(c *gin.Context) (int64, int64, bool) {
+	tenantID, ok := middleware.GetTenantID(c)
+	if !ok {
+		c.JSON(400, gin.H{"error": "Tenant not found"})
+		return 0, 0, false
+	}
+
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		c.JSON(400, gin.H{"error": "User not found"})
+		return 0, 0, false
+	}
+
+	return tenantID, userID, true
+}
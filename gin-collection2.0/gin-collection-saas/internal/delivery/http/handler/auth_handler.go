@@ -1,7 +1,9 @@
 package handler
 
 import (
+	"context"
 	"net/http"
+	"strconv"
 	"time"
 
 	"github.com/gin-gonic/gin"
@@ -31,6 +33,12 @@ func NewAuthHandler(authService *auth.Service, cookieConfig *utils.CookieConfig,
 	}
 }
 
+// clientContext returns the request context with the IP address and user
+// agent a session is started or refreshed from
+func clientContext(c *gin.Context) context.Context {
+	return auth.WithClientInfo(c.Request.Context(), c.ClientIP(), c.Request.UserAgent())
+}
+
 // Register handles POST /api/v1/auth/register
 func (h *AuthHandler) Register(c *gin.Context) {
 	var req models.RegisterRequest
@@ -44,7 +52,7 @@ func (h *AuthHandler) Register(c *gin.Context) {
 	}
 
 	// Register new tenant and user
-	authResp, err := h.authService.Register(c.Request.Context(), &req)
+	authResp, err := h.authService.Register(clientContext(c), &req)
 	if err != nil {
 		logger.Error("Registration failed", "error", err.Error())
 		response.Error(c, err)
@@ -80,6 +88,11 @@ func (h *AuthHandler) Login(c *gin.Context) {
 		return
 	}
 
+	// A remembered device may skip the second factor
+	if req.DeviceToken == "" {
+		req.DeviceToken, _ = utils.GetTrustedDeviceFromCookie(c)
+	}
+
 	var authResp *models.AuthResponse
 	var err error
 
@@ -87,11 +100,11 @@ func (h *AuthHandler) Login(c *gin.Context) {
 	tenantID, ok := middleware.GetTenantID(c)
 	if ok {
 		// Login with known tenant
-		authResp, err = h.authService.Login(c.Request.Context(), &req, tenantID)
+		authResp, err = h.authService.Login(clientContext(c), &req, tenantID)
 	} else {
 		// Fallback: Login by email only (for localhost or no subdomain)
 		logger.Debug("No tenant in context, trying login by email only")
-		authResp, err = h.authService.LoginByEmail(c.Request.Context(), &req)
+		authResp, err = h.authService.LoginByEmail(clientContext(c), &req)
 	}
 
 	if err != nil {
@@ -100,6 +113,11 @@ func (h *AuthHandler) Login(c *gin.Context) {
 		return
 	}
 
+	if authResp.TwoFactor != nil {
+		respondTwoFactorChallenge(c, authResp.TwoFactor)
+		return
+	}
+
 	// Set HttpOnly cookies for authentication
 	utils.SetAuthCookies(
 		c,
@@ -120,6 +138,7 @@ func (h *AuthHandler) Login(c *gin.Context) {
 // RefreshToken handles POST /api/v1/auth/refresh
 func (h *AuthHandler) RefreshToken(c *gin.Context) {
 	// Try to get refresh token from HttpOnly cookie first
+	fromBody := false
 	refreshToken, err := utils.GetRefreshTokenFromCookie(c)
 	if err != nil || refreshToken == "" {
 		// Fallback: Try JSON body (backward compatibility for API clients)
@@ -133,25 +152,46 @@ func (h *AuthHandler) RefreshToken(c *gin.Context) {
 			return
 		}
 		refreshToken = req.RefreshToken
+		fromBody = true
 	}
 
-	// Generate new access token
-	newToken, err := h.authService.RefreshToken(c.Request.Context(), refreshToken)
+	// Generate new access token, the refresh token rotates
+	newToken, newRefreshToken, err := h.authService.RefreshToken(clientContext(c), refreshToken)
 	if err != nil {
 		response.Error(c, err)
 		return
 	}
 
-	// Set new access token cookie
-	utils.SetAccessTokenCookie(c, newToken, h.cookieConfig, h.jwtExpiry)
+	// Set new cookies
+	utils.SetAuthCookies(
+		c,
+		newToken,
+		newRefreshToken,
+		h.cookieConfig,
+		h.jwtExpiry,
+		utils.RefreshTokenExpiry,
+	)
 
-	response.Success(c, gin.H{
+	body := gin.H{
 		"message": "Token refreshed successfully",
-	})
+	}
+	// API clients without cookies need the rotated refresh token
+	if fromBody {
+		body["refresh_token"] = newRefreshToken
+	}
+
+	response.Success(c, body)
 }
 
 // Logout handles POST /api/v1/auth/logout
 func (h *AuthHandler) Logout(c *gin.Context) {
+	// End the session, its refresh token stops working
+	if userID, ok := middleware.GetUserID(c); ok {
+		if err := h.authService.Logout(c.Request.Context(), userID, middleware.GetSessionID(c)); err != nil {
+			logger.Error("Failed to end session", "error", err.Error(), "user_id", userID)
+		}
+	}
+
 	// Blacklist current token if available
 	if h.tokenBlacklist != nil {
 		claims, ok := c.Get("jwt_claims")
@@ -337,3 +377,629 @@ func (h *AuthHandler) ValidateResetToken(c *gin.Context) {
 		"valid": valid,
 	})
 }
+
+// AcceptInvite handles POST /api/v1/auth/accept-invite
+func (h *AuthHandler) AcceptInvite(c *gin.Context) {
+	var req models.AcceptInviteRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		logger.Debug("Invalid accept invite request", "error", err.Error())
+		response.ValidationError(c, map[string]string{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	authResp, err := h.authService.AcceptInvite(clientContext(c), &req)
+	if err != nil {
+		logger.Error("Accepting invite failed", "error", err.Error())
+		response.Error(c, err)
+		return
+	}
+
+	if authResp.TwoFactor != nil {
+		respondTwoFactorChallenge(c, authResp.TwoFactor)
+		return
+	}
+
+	// The invitee is logged in right away
+	utils.SetAuthCookies(
+		c,
+		authResp.Token,
+		authResp.RefreshToken,
+		h.cookieConfig,
+		h.jwtExpiry,
+		30*24*time.Hour, // Refresh token: 30 days
+	)
+
+	response.Success(c, gin.H{
+		"user":   authResp.User,
+		"tenant": authResp.Tenant,
+	})
+}
+
+// VerifyTwoFactor handles POST /api/v1/auth/2fa/verify
+func (h *AuthHandler) VerifyTwoFactor(c *gin.Context) {
+	var req models.TwoFactorVerifyRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.ValidationError(c, map[string]string{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	authResp, err := h.authService.VerifyTwoFactor(clientContext(c), &req, c.Request.UserAgent())
+	if err != nil {
+		logger.Debug("Two-factor verification failed", "error", err.Error())
+		response.Error(c, err)
+		return
+	}
+
+	utils.SetAuthCookies(
+		c,
+		authResp.Token,
+		authResp.RefreshToken,
+		h.cookieConfig,
+		h.jwtExpiry,
+		30*24*time.Hour, // Refresh token: 30 days
+	)
+
+	if authResp.TrustedDeviceToken != "" {
+		utils.SetTrustedDeviceCookie(c, authResp.TrustedDeviceToken, h.cookieConfig, models.TrustedDeviceExpiry)
+	}
+
+	result := gin.H{
+		"user":   authResp.User,
+		"tenant": authResp.Tenant,
+	}
+	if len(authResp.RecoveryCodes) > 0 {
+		result["recovery_codes"] = authResp.RecoveryCodes
+	}
+
+	response.Success(c, result)
+}
+
+// SetupTwoFactorChallenge handles POST /api/v1/auth/2fa/enroll
+// (enrollment during login when the tenant requires 2FA)
+func (h *AuthHandler) SetupTwoFactorChallenge(c *gin.Context) {
+	var req models.TwoFactorSetupRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.ValidationError(c, map[string]string{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	enrollment, err := h.authService.BeginChallengeEnrollment(c.Request.Context(), req.ChallengeToken)
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	response.Success(c, enrollment)
+}
+
+// GetTwoFactor handles GET /api/v1/auth/2fa
+func (h *AuthHandler) GetTwoFactor(c *gin.Context) {
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		response.ValidationError(c, map[string]string{
+			"error": "User not found in context",
+		})
+		return
+	}
+
+	tenantID, ok := middleware.GetTenantID(c)
+	if !ok {
+		c.JSON(400, gin.H{"error": "Tenant not found"})
+		return
+	}
+
+	status, err := h.authService.GetTwoFactorStatus(c.Request.Context(), userID, tenantID)
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	response.Success(c, status)
+}
+
+// SetupTwoFactor handles POST /api/v1/auth/2fa/setup
+func (h *AuthHandler) SetupTwoFactor(c *gin.Context) {
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		response.ValidationError(c, map[string]string{
+			"error": "User not found in context",
+		})
+		return
+	}
+
+	enrollment, err := h.authService.BeginTwoFactorSetup(c.Request.Context(), userID)
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	response.Success(c, enrollment)
+}
+
+// ConfirmTwoFactor handles POST /api/v1/auth/2fa/confirm
+func (h *AuthHandler) ConfirmTwoFactor(c *gin.Context) {
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		response.ValidationError(c, map[string]string{
+			"error": "User not found in context",
+		})
+		return
+	}
+
+	var req models.TwoFactorCodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.ValidationError(c, map[string]string{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	codes, err := h.authService.ConfirmTwoFactorSetup(c.Request.Context(), userID, req.Code)
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	response.Success(c, gin.H{
+		"enabled":        true,
+		"recovery_codes": codes,
+	})
+}
+
+// RegenerateRecoveryCodes handles POST /api/v1/auth/2fa/recovery-codes
+func (h *AuthHandler) RegenerateRecoveryCodes(c *gin.Context) {
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		response.ValidationError(c, map[string]string{
+			"error": "User not found in context",
+		})
+		return
+	}
+
+	var req models.TwoFactorCodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.ValidationError(c, map[string]string{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	codes, err := h.authService.RegenerateRecoveryCodes(c.Request.Context(), userID, req.Code)
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	response.Success(c, gin.H{
+		"recovery_codes": codes,
+	})
+}
+
+// DisableTwoFactor handles DELETE /api/v1/auth/2fa
+func (h *AuthHandler) DisableTwoFactor(c *gin.Context) {
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		response.ValidationError(c, map[string]string{
+			"error": "User not found in context",
+		})
+		return
+	}
+
+	tenantID, ok := middleware.GetTenantID(c)
+	if !ok {
+		c.JSON(400, gin.H{"error": "Tenant not found"})
+		return
+	}
+
+	var req models.TwoFactorDisableRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.ValidationError(c, map[string]string{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	if err := h.authService.DisableTwoFactor(c.Request.Context(), userID, tenantID, req.Code, req.RecoveryCode); err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	response.Success(c, gin.H{
+		"enabled": false,
+	})
+}
+
+// ForgetTrustedDevices handles DELETE /api/v1/auth/2fa/devices
+func (h *AuthHandler) ForgetTrustedDevices(c *gin.Context) {
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		response.ValidationError(c, map[string]string{
+			"error": "User not found in context",
+		})
+		return
+	}
+
+	if err := h.authService.ForgetTrustedDevices(c.Request.Context(), userID); err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	response.Success(c, gin.H{
+		"message": "Trusted devices removed",
+	})
+}
+
+// BeginPasskeyLogin handles POST /api/v1/auth/passkeys/login/begin
+func (h *AuthHandler) BeginPasskeyLogin(c *gin.Context) {
+	var req models.WebAuthnLoginBeginRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.ValidationError(c, map[string]string{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	// Tenant is optional (localhost or no subdomain), as for password login
+	tenantID, _ := middleware.GetTenantID(c)
+
+	options, err := h.authService.BeginPasskeyLogin(c.Request.Context(), req.Email, tenantID)
+	if err != nil {
+		logger.Error("Failed to start passkey login", "error", err.Error())
+		response.Error(c, err)
+		return
+	}
+
+	response.Success(c, options)
+}
+
+// FinishPasskeyLogin handles POST /api/v1/auth/passkeys/login/finish
+func (h *AuthHandler) FinishPasskeyLogin(c *gin.Context) {
+	var req models.WebAuthnLoginRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.ValidationError(c, map[string]string{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	tenantID, _ := middleware.GetTenantID(c)
+
+	authResp, err := h.authService.FinishPasskeyLogin(clientContext(c), &req, tenantID)
+	if err != nil {
+		logger.Debug("Passkey login failed", "error", err.Error())
+		response.Error(c, err)
+		return
+	}
+
+	if authResp.TwoFactor != nil {
+		respondTwoFactorChallenge(c, authResp.TwoFactor)
+		return
+	}
+
+	utils.SetAuthCookies(
+		c,
+		authResp.Token,
+		authResp.RefreshToken,
+		h.cookieConfig,
+		h.jwtExpiry,
+		30*24*time.Hour, // Refresh token: 30 days
+	)
+
+	response.Success(c, gin.H{
+		"user":   authResp.User,
+		"tenant": authResp.Tenant,
+	})
+}
+
+// ListPasskeys handles GET /api/v1/auth/passkeys
+func (h *AuthHandler) ListPasskeys(c *gin.Context) {
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		response.ValidationError(c, map[string]string{
+			"error": "User not found in context",
+		})
+		return
+	}
+
+	passkeys, err := h.authService.ListPasskeys(c.Request.Context(), userID)
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	response.Success(c, gin.H{
+		"passkeys": passkeys,
+	})
+}
+
+// BeginPasskeyRegistration handles POST /api/v1/auth/passkeys/register/begin
+func (h *AuthHandler) BeginPasskeyRegistration(c *gin.Context) {
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		response.ValidationError(c, map[string]string{
+			"error": "User not found in context",
+		})
+		return
+	}
+
+	options, err := h.authService.BeginPasskeyRegistration(c.Request.Context(), userID)
+	if err != nil {
+		logger.Error("Failed to start passkey registration", "user_id", userID, "error", err.Error())
+		response.Error(c, err)
+		return
+	}
+
+	response.Success(c, options)
+}
+
+// FinishPasskeyRegistration handles POST /api/v1/auth/passkeys/register/finish
+func (h *AuthHandler) FinishPasskeyRegistration(c *gin.Context) {
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		response.ValidationError(c, map[string]string{
+			"error": "User not found in context",
+		})
+		return
+	}
+
+	var req models.WebAuthnRegistrationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.ValidationError(c, map[string]string{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	passkey, err := h.authService.FinishPasskeyRegistration(c.Request.Context(), userID, &req)
+	if err != nil {
+		logger.Debug("Passkey registration failed", "user_id", userID, "error", err.Error())
+		response.Error(c, err)
+		return
+	}
+
+	response.Created(c, passkey)
+}
+
+// DeletePasskey handles DELETE /api/v1/auth/passkeys/:id
+func (h *AuthHandler) DeletePasskey(c *gin.Context) {
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		response.ValidationError(c, map[string]string{
+			"error": "User not found in context",
+		})
+		return
+	}
+
+	passkeyID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(400, gin.H{"error": "Invalid passkey ID"})
+		return
+	}
+
+	if err := h.authService.DeletePasskey(c.Request.Context(), userID, passkeyID); err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	response.Success(c, gin.H{
+		"message": "Passkey removed",
+	})
+}
+
+// respondTwoFactorChallenge returns the challenge of a login that needs a second factor
+func respondTwoFactorChallenge(c *gin.Context, challenge *models.TwoFactorChallenge) {
+	response.Success(c, gin.H{
+		"two_factor_required": true,
+		"two_factor":          challenge,
+	})
+}
+
+// ListSessions handles GET /api/v1/auth/sessions
+func (h *AuthHandler) ListSessions(c *gin.Context) {
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		response.ValidationError(c, map[string]string{
+			"error": "User not found in context",
+		})
+		return
+	}
+
+	sessions, err := h.authService.ListSessions(c.Request.Context(), userID, middleware.GetSessionID(c))
+	if err != nil {
+		logger.Error("Failed to list sessions", "user_id", userID, "error", err.Error())
+		response.Error(c, err)
+		return
+	}
+
+	response.Success(c, gin.H{
+		"sessions": sessions,
+	})
+}
+
+// RevokeSession handles DELETE /api/v1/auth/sessions/:id
+func (h *AuthHandler) RevokeSession(c *gin.Context) {
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		response.ValidationError(c, map[string]string{
+			"error": "User not found in context",
+		})
+		return
+	}
+
+	sessionID := c.Param("id")
+	if err := h.authService.RevokeSession(c.Request.Context(), userID, sessionID); err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	// Revoking the current session logs out
+	if sessionID == middleware.GetSessionID(c) {
+		utils.ClearAuthCookies(c, h.cookieConfig)
+	}
+
+	response.Success(c, gin.H{
+		"message": "Session revoked successfully",
+	})
+}
+
+// RevokeOtherSessions handles DELETE /api/v1/auth/sessions and logs out
+// everywhere except the current session
+func (h *AuthHandler) RevokeOtherSessions(c *gin.Context) {
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		response.ValidationError(c, map[string]string{
+			"error": "User not found in context",
+		})
+		return
+	}
+
+	count, err := h.authService.RevokeOtherSessions(c.Request.Context(), userID, middleware.GetSessionID(c))
+	if err != nil {
+		logger.Error("Failed to revoke sessions", "user_id", userID, "error", err.Error())
+		response.Error(c, err)
+		return
+	}
+
+	response.Success(c, gin.H{
+		"revoked": count,
+		"message": "Logged out of all other sessions",
+	})
+}
+
+// VerifyEmail handles POST /api/v1/auth/verify-email (links of address
+// verifications and of both sides of an email change)
+func (h *AuthHandler) VerifyEmail(c *gin.Context) {
+	var req models.VerifyEmailRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.ValidationError(c, map[string]string{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	verification, err := h.authService.VerifyEmail(c.Request.Context(), req.Token)
+	if err != nil {
+		logger.Debug("Email verification failed", "error", err.Error())
+		response.Error(c, err)
+		return
+	}
+
+	message := "E-Mail-Adresse wurde bestätigt."
+	if verification.Purpose == models.EmailVerificationPurposeChange {
+		if verification.IsCompleted() {
+			message = "Deine E-Mail-Adresse wurde geändert."
+		} else {
+			message = "Bestätigung erhalten. Bitte bestätige die Änderung auch über den Link an die andere Adresse."
+		}
+	}
+
+	response.Success(c, gin.H{
+		"purpose":   verification.Purpose,
+		"completed": verification.IsCompleted(),
+		"message":   message,
+	})
+}
+
+// ResendEmailVerification handles POST /api/v1/auth/verify-email/resend
+func (h *AuthHandler) ResendEmailVerification(c *gin.Context) {
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		response.ValidationError(c, map[string]string{
+			"error": "User not found in context",
+		})
+		return
+	}
+
+	if err := h.authService.ResendEmailVerification(c.Request.Context(), userID); err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	response.Success(c, gin.H{
+		"message": "Verification email sent",
+	})
+}
+
+// GetEmailChange handles GET /api/v1/auth/email-change
+func (h *AuthHandler) GetEmailChange(c *gin.Context) {
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		response.ValidationError(c, map[string]string{
+			"error": "User not found in context",
+		})
+		return
+	}
+
+	pending, err := h.authService.GetPendingEmailChange(c.Request.Context(), userID)
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	response.Success(c, gin.H{
+		"pending": pending,
+	})
+}
+
+// RequestEmailChange handles POST /api/v1/auth/email-change
+func (h *AuthHandler) RequestEmailChange(c *gin.Context) {
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		response.ValidationError(c, map[string]string{
+			"error": "User not found in context",
+		})
+		return
+	}
+
+	var req models.ChangeEmailRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.ValidationError(c, map[string]string{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	pending, err := h.authService.RequestEmailChange(c.Request.Context(), userID, &req)
+	if err != nil {
+		logger.Debug("Email change request failed", "user_id", userID, "error", err.Error())
+		response.Error(c, err)
+		return
+	}
+
+	response.Success(c, gin.H{
+		"pending": pending,
+		"message": "Confirmation links were sent to the current and the new address",
+	})
+}
+
+// CancelEmailChange handles DELETE /api/v1/auth/email-change
+func (h *AuthHandler) CancelEmailChange(c *gin.Context) {
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		response.ValidationError(c, map[string]string{
+			"error": "User not found in context",
+		})
+		return
+	}
+
+	if err := h.authService.CancelEmailChange(c.Request.Context(), userID); err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	response.Success(c, gin.H{
+		"message": "Email change cancelled",
+	})
+}
@@ -0,0 +1,197 @@
+package handler
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/yourusername/gin-collection-saas/internal/delivery/http/middleware"
+	"github.com/yourusername/gin-collection-saas/internal/delivery/http/response"
+	"github.com/yourusername/gin-collection-saas/internal/domain/models"
+	subscriptionUsecase "github.com/yourusername/gin-collection-saas/internal/usecase/subscription"
+	"github.com/yourusername/gin-collection-saas/pkg/logger"
+)
+
+// BillingHandler handles the billing history, upcoming charges and billing
+// details of a tenant
+type BillingHandler struct {
+	subscriptionService *subscriptionUsecase.Service
+}
+
+// NewBillingHandler creates a new billing handler
+func NewBillingHandler(subscriptionService *subscriptionUsecase.Service) *BillingHandler {
+	return &BillingHandler{
+		subscriptionService: subscriptionService,
+	}
+}
+
+// ListInvoices handles GET /api/v1/billing/invoices
+func (h *BillingHandler) ListInvoices(c *gin.Context) {
+	tenantID, ok := middleware.GetTenantID(c)
+	if !ok {
+		c.JSON(400, gin.H{"error": "Tenant not found"})
+		return
+	}
+
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 100 {
+		limit = 20
+	}
+
+	invoices, total, err := h.subscriptionService.ListInvoices(c.Request.Context(), tenantID, limit, (page-1)*limit)
+	if err != nil {
+		logger.Error("Failed to list invoices", "tenant_id", tenantID, "error", err.Error())
+		response.Error(c, err)
+		return
+	}
+
+	response.Success(c, gin.H{
+		"invoices": invoices,
+		"total":    total,
+		"page":     page,
+		"limit":    limit,
+	})
+}
+
+// GetInvoice handles GET /api/v1/billing/invoices/:id
+func (h *BillingHandler) GetInvoice(c *gin.Context) {
+	tenantID, ok := middleware.GetTenantID(c)
+	if !ok {
+		c.JSON(400, gin.H{"error": "Tenant not found"})
+		return
+	}
+
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		response.BadRequest(c, "Invalid invoice ID")
+		return
+	}
+
+	invoice, err := h.subscriptionService.GetInvoice(c.Request.Context(), tenantID, id)
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	response.Success(c, invoice)
+}
+
+// DownloadInvoice handles GET /api/v1/billing/invoices/:id/pdf
+func (h *BillingHandler) DownloadInvoice(c *gin.Context) {
+	tenantID, ok := middleware.GetTenantID(c)
+	if !ok {
+		c.JSON(400, gin.H{"error": "Tenant not found"})
+		return
+	}
+
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		response.BadRequest(c, "Invalid invoice ID")
+		return
+	}
+
+	invoice, document, err := h.subscriptionService.InvoicePDF(c.Request.Context(), tenantID, id)
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%s.pdf", invoice.Number))
+	c.Data(200, "application/pdf", document)
+}
+
+// UpcomingCharges handles GET /api/v1/billing/upcoming
+func (h *BillingHandler) UpcomingCharges(c *gin.Context) {
+	tenantID, ok := middleware.GetTenantID(c)
+	if !ok {
+		c.JSON(400, gin.H{"error": "Tenant not found"})
+		return
+	}
+
+	count, _ := strconv.Atoi(c.DefaultQuery("count", "3"))
+	if count < 1 || count > 12 {
+		count = 3
+	}
+
+	upcoming, err := h.subscriptionService.UpcomingCharges(c.Request.Context(), tenantID, count)
+	if err != nil {
+		logger.Error("Failed to list upcoming charges", "tenant_id", tenantID, "error", err.Error())
+		response.Error(c, err)
+		return
+	}
+
+	response.Success(c, upcoming)
+}
+
+// GetDetails handles GET /api/v1/billing/details
+func (h *BillingHandler) GetDetails(c *gin.Context) {
+	tenantID, ok := middleware.GetTenantID(c)
+	if !ok {
+		c.JSON(400, gin.H{"error": "Tenant not found"})
+		return
+	}
+
+	details, err := h.subscriptionService.GetBillingDetails(c.Request.Context(), tenantID)
+	if err != nil {
+		logger.Error("Failed to get billing details", "tenant_id", tenantID, "error", err.Error())
+		response.Error(c, err)
+		return
+	}
+
+	response.Success(c, gin.H{
+		"details": details,
+	})
+}
+
+// UpdateDetails handles PUT /api/v1/billing/details
+func (h *BillingHandler) UpdateDetails(c *gin.Context) {
+	tenantID, ok := middleware.GetTenantID(c)
+	if !ok {
+		c.JSON(400, gin.H{"error": "Tenant not found"})
+		return
+	}
+
+	var req struct {
+		Name         string `json:"name" binding:"required,max=255"`
+		Email        string `json:"email" binding:"omitempty,email"`
+		AddressLine1 string `json:"address_line1" binding:"required,max=255"`
+		AddressLine2 string `json:"address_line2" binding:"max=255"`
+		PostalCode   string `json:"postal_code" binding:"required,max=20"`
+		City         string `json:"city" binding:"required,max=100"`
+		Country      string `json:"country" binding:"required,len=2"`
+		VATID        string `json:"vat_id" binding:"max=20"`
+	}
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		logger.Debug("Invalid billing details", "error", err.Error())
+		response.ValidationError(c, map[string]string{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	details, err := h.subscriptionService.UpdateBillingDetails(c.Request.Context(), tenantID, &models.BillingDetails{
+		Name:         req.Name,
+		Email:        req.Email,
+		AddressLine1: req.AddressLine1,
+		AddressLine2: req.AddressLine2,
+		PostalCode:   req.PostalCode,
+		City:         req.City,
+		Country:      req.Country,
+		VATID:        req.VATID,
+	})
+	if err != nil {
+		logger.Error("Failed to update billing details", "tenant_id", tenantID, "error", err.Error())
+		response.Error(c, err)
+		return
+	}
+
+	response.Success(c, gin.H{
+		"message": "Billing details updated",
+		"details": details,
+	})
+}
@@ -0,0 +1,69 @@
+package handler
+
+import (
+	"io"
+
+	"github.com/gin-gonic/gin"
+	"github.com/yourusername/gin-collection-saas/internal/delivery/http/middleware"
+	"github.com/yourusername/gin-collection-saas/internal/delivery/http/response"
+	"github.com/yourusername/gin-collection-saas/internal/domain/errors"
+	"github.com/yourusername/gin-collection-saas/internal/usecase/labelscan"
+	"github.com/yourusername/gin-collection-saas/pkg/logger"
+)
+
+// LabelScanHandler handles label scan HTTP requests
+type LabelScanHandler struct {
+	labelScanService *labelscan.Service
+}
+
+// NewLabelScanHandler creates a new label scan handler
+func NewLabelScanHandler(labelScanService *labelscan.Service) *LabelScanHandler {
+	return &LabelScanHandler{
+		labelScanService: labelScanService,
+	}
+}
+
+// ScanLabel handles POST /api/v1/gins/scan-label
+func (h *LabelScanHandler) ScanLabel(c *gin.Context) {
+	tenantID, ok := middleware.GetTenantID(c)
+	if !ok {
+		c.JSON(400, gin.H{"error": "Tenant not found"})
+		return
+	}
+
+	if !h.labelScanService.IsEnabled() {
+		response.ServiceUnavailable(c, "Etikett-Erkennung ist nicht verfügbar")
+		return
+	}
+
+	// Parse multipart form (label photo)
+	file, _, err := c.Request.FormFile("photo")
+	if err != nil {
+		logger.Debug("Failed to get form file", "error", err.Error())
+		response.ValidationError(c, map[string]string{
+			"error": "Photo file is required",
+		})
+		return
+	}
+	defer file.Close()
+
+	data, err := io.ReadAll(file)
+	if err != nil {
+		logger.Error("Failed to read file", "error", err.Error())
+		c.JSON(500, gin.H{"error": "Failed to read file"})
+		return
+	}
+
+	result, err := h.labelScanService.ScanLabel(c.Request.Context(), tenantID, data)
+	if err != nil {
+		if err == errors.ErrInvalidFileType {
+			response.Error(c, err)
+			return
+		}
+		logger.Error("Label scan failed", "tenant_id", tenantID, "error", err.Error())
+		response.InternalError(c, "Etikett konnte nicht gelesen werden")
+		return
+	}
+
+	response.Success(c, result)
+}
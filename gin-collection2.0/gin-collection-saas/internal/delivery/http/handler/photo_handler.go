@@ -1,12 +1,16 @@
 package handler
 
 import (
+	"errors"
 	"io"
+	"net/http"
 	"strconv"
+	"strings"
 
 	"github.com/gin-gonic/gin"
 	"github.com/yourusername/gin-collection-saas/internal/delivery/http/middleware"
 	"github.com/yourusername/gin-collection-saas/internal/delivery/http/response"
+	domainErrors "github.com/yourusername/gin-collection-saas/internal/domain/errors"
 	"github.com/yourusername/gin-collection-saas/internal/domain/models"
 	photoUsecase "github.com/yourusername/gin-collection-saas/internal/usecase/photo"
 	"github.com/yourusername/gin-collection-saas/pkg/logger"
@@ -130,6 +134,106 @@ func (h *PhotoHandler) Upload(c *gin.Context) {
 	response.Created(c, photo)
 }
 
+// CreateUploadURL handles POST /api/v1/gins/:id/photos/upload-url
+func (h *PhotoHandler) CreateUploadURL(c *gin.Context) {
+	tenantID, ok := middleware.GetTenantID(c)
+	if !ok {
+		c.JSON(400, gin.H{"error": "Tenant not found"})
+		return
+	}
+
+	ginID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(400, gin.H{"error": "Invalid gin ID"})
+		return
+	}
+
+	var req models.UploadURLRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.ValidationError(c, map[string]string{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	var userID *int64
+	if id, ok := middleware.GetUserID(c); ok {
+		userID = &id
+	}
+
+	result, err := h.photoService.CreateUploadURL(c.Request.Context(), tenantID, userID, ginID, &req)
+	if err != nil {
+		logger.Error("Failed to create upload URL", "error", err.Error())
+		response.Error(c, err)
+		return
+	}
+
+	response.Created(c, result)
+}
+
+// CompleteUpload handles POST /api/v1/gins/:id/photos/complete
+func (h *PhotoHandler) CompleteUpload(c *gin.Context) {
+	tenantID, ok := middleware.GetTenantID(c)
+	if !ok {
+		c.JSON(400, gin.H{"error": "Tenant not found"})
+		return
+	}
+
+	ginID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(400, gin.H{"error": "Invalid gin ID"})
+		return
+	}
+
+	var req models.CompleteUploadRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.ValidationError(c, map[string]string{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	photo, err := h.photoService.CompleteUpload(c.Request.Context(), tenantID, ginID, req.UploadToken)
+	if err != nil {
+		logger.Error("Failed to complete upload", "error", err.Error())
+		response.Error(c, err)
+		return
+	}
+
+	response.Created(c, photo)
+}
+
+// ReceiveUpload handles PUT /api/v1/uploads/*key (signed local storage uploads)
+func (h *PhotoHandler) ReceiveUpload(c *gin.Context) {
+	key := strings.TrimPrefix(c.Param("key"), "/")
+
+	expires, err := strconv.ParseInt(c.Query("expires"), 10, 64)
+	if err != nil {
+		response.Error(c, domainErrors.ErrInvalidToken)
+		return
+	}
+
+	err = h.photoService.ReceiveSignedUpload(
+		c.Request.Context(),
+		key,
+		c.ContentType(),
+		expires,
+		c.Query("signature"),
+		c.Request.Body,
+	)
+	if err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			err = domainErrors.ErrFileTooLarge
+		}
+		logger.Warn("Signed upload rejected", "key", key, "error", err.Error())
+		response.Error(c, err)
+		return
+	}
+
+	c.Status(http.StatusOK)
+}
+
 // Delete handles DELETE /api/v1/gins/:gin_id/photos/:photo_id
 func (h *PhotoHandler) Delete(c *gin.Context) {
 	tenantID, ok := middleware.GetTenantID(c)
@@ -190,3 +294,136 @@ func (h *PhotoHandler) SetPrimary(c *gin.Context) {
 		"message": "Primary photo set successfully",
 	})
 }
+
+// Update handles PATCH /api/v1/gins/:id/photos/:photo_id (caption, type, tags)
+func (h *PhotoHandler) Update(c *gin.Context) {
+	tenantID, ok := middleware.GetTenantID(c)
+	if !ok {
+		c.JSON(400, gin.H{"error": "Tenant not found"})
+		return
+	}
+
+	ginID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(400, gin.H{"error": "Invalid gin ID"})
+		return
+	}
+
+	photoID, err := strconv.ParseInt(c.Param("photo_id"), 10, 64)
+	if err != nil {
+		c.JSON(400, gin.H{"error": "Invalid photo ID"})
+		return
+	}
+
+	var req models.UpdatePhotoRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.ValidationError(c, map[string]string{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	photo, err := h.photoService.UpdatePhoto(c.Request.Context(), tenantID, ginID, photoID, &req)
+	if err != nil {
+		logger.Error("Failed to update photo", "error", err.Error())
+		response.Error(c, err)
+		return
+	}
+
+	response.Success(c, photo)
+}
+
+// Reorder handles PUT /api/v1/gins/:id/photos/order
+func (h *PhotoHandler) Reorder(c *gin.Context) {
+	tenantID, ok := middleware.GetTenantID(c)
+	if !ok {
+		c.JSON(400, gin.H{"error": "Tenant not found"})
+		return
+	}
+
+	ginID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(400, gin.H{"error": "Invalid gin ID"})
+		return
+	}
+
+	var req models.ReorderPhotosRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.ValidationError(c, map[string]string{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	photos, err := h.photoService.ReorderPhotos(c.Request.Context(), tenantID, ginID, req.PhotoIDs)
+	if err != nil {
+		logger.Error("Failed to reorder photos", "error", err.Error())
+		response.Error(c, err)
+		return
+	}
+
+	response.Success(c, gin.H{
+		"photos": photos,
+		"count":  len(photos),
+	})
+}
+
+// Gallery handles GET /api/v1/photos?type=&tag=&gin_id=&limit=&offset=
+func (h *PhotoHandler) Gallery(c *gin.Context) {
+	tenantID, ok := middleware.GetTenantID(c)
+	if !ok {
+		c.JSON(400, gin.H{"error": "Tenant not found"})
+		return
+	}
+
+	filter := &models.PhotoFilter{
+		TenantID: tenantID,
+		Tag:      c.Query("tag"),
+		Limit:    50, // Default
+	}
+
+	if typeStr := c.Query("type"); typeStr != "" {
+		photoType := models.PhotoType(typeStr)
+		if photoType != models.PhotoTypeBottle &&
+			photoType != models.PhotoTypeLabel &&
+			photoType != models.PhotoTypeMoment &&
+			photoType != models.PhotoTypeTasting {
+			response.ValidationError(c, map[string]string{
+				"error": "Invalid type. Allowed: bottle, label, moment, tasting",
+			})
+			return
+		}
+		filter.PhotoType = &photoType
+	}
+
+	if ginIDStr := c.Query("gin_id"); ginIDStr != "" {
+		ginID, err := strconv.ParseInt(ginIDStr, 10, 64)
+		if err != nil {
+			c.JSON(400, gin.H{"error": "Invalid gin ID"})
+			return
+		}
+		filter.GinID = &ginID
+	}
+
+	// Pagination
+	if limit, err := strconv.Atoi(c.Query("limit")); err == nil && limit > 0 && limit <= 100 {
+		filter.Limit = limit
+	}
+	if offset, err := strconv.Atoi(c.Query("offset")); err == nil && offset > 0 {
+		filter.Offset = offset
+	}
+
+	photos, total, err := h.photoService.ListGallery(c.Request.Context(), filter)
+	if err != nil {
+		logger.Error("Failed to list photos", "error", err.Error())
+		response.Error(c, err)
+		return
+	}
+
+	response.Success(c, gin.H{
+		"photos": photos,
+		"total":  total,
+		"limit":  filter.Limit,
+		"offset": filter.Offset,
+	})
+}
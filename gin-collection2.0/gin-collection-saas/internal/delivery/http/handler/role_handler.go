@@ -0,0 +1,224 @@
+package handler
+
+import (
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/yourusername/gin-collection-saas/internal/delivery/http/middleware"
+	"github.com/yourusername/gin-collection-saas/internal/delivery/http/response"
+	"github.com/yourusername/gin-collection-saas/internal/domain/models"
+	roleUsecase "github.com/yourusername/gin-collection-saas/internal/usecase/role"
+	"github.com/yourusername/gin-collection-saas/pkg/logger"
+)
+
+// RoleHandler handles role and permission HTTP requests (custom roles are Enterprise only)
+type RoleHandler struct {
+	roleService *roleUsecase.Service
+}
+
+// NewRoleHandler creates a new role handler
+func NewRoleHandler(roleService *roleUsecase.Service) *RoleHandler {
+	return &RoleHandler{
+		roleService: roleService,
+	}
+}
+
+// List handles GET /api/v1/roles
+func (h *RoleHandler) List(c *gin.Context) {
+	tenantID, _, ok := h.requester(c)
+	if !ok {
+		return
+	}
+
+	roles, err := h.roleService.List(c.Request.Context(), tenantID)
+	if err != nil {
+		logger.Error("Failed to list roles", "tenant_id", tenantID, "error", err.Error())
+		response.Error(c, err)
+		return
+	}
+
+	response.Success(c, gin.H{
+		"roles":       roles,
+		"permissions": models.PermissionRegistry,
+	})
+}
+
+// Create handles POST /api/v1/roles
+func (h *RoleHandler) Create(c *gin.Context) {
+	tenantID, userID, ok := h.requester(c)
+	if !ok {
+		return
+	}
+
+	req, ok := h.bindRequest(c)
+	if !ok {
+		return
+	}
+
+	role, err := h.roleService.Create(c.Request.Context(), tenantID, userID, req)
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	response.Created(c, gin.H{
+		"role": role,
+	})
+}
+
+// Update handles PUT /api/v1/roles/:id
+func (h *RoleHandler) Update(c *gin.Context) {
+	tenantID, userID, ok := h.requester(c)
+	if !ok {
+		return
+	}
+
+	roleID, ok := parseRoleID(c)
+	if !ok {
+		return
+	}
+
+	req, ok := h.bindRequest(c)
+	if !ok {
+		return
+	}
+
+	role, err := h.roleService.Update(c.Request.Context(), tenantID, userID, roleID, req)
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	response.Success(c, gin.H{
+		"role": role,
+	})
+}
+
+// Delete handles DELETE /api/v1/roles/:id
+func (h *RoleHandler) Delete(c *gin.Context) {
+	tenantID, userID, ok := h.requester(c)
+	if !ok {
+		return
+	}
+
+	roleID, ok := parseRoleID(c)
+	if !ok {
+		return
+	}
+
+	if err := h.roleService.Delete(c.Request.Context(), tenantID, userID, roleID); err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	response.Success(c, gin.H{
+		"message": "Role deleted successfully",
+	})
+}
+
+// AssignUser handles PUT /api/v1/roles/:id/members/:user_id
+func (h *RoleHandler) AssignUser(c *gin.Context) {
+	tenantID, userID, ok := h.requester(c)
+	if !ok {
+		return
+	}
+
+	roleID, targetUserID, ok := parseRoleMember(c)
+	if !ok {
+		return
+	}
+
+	if err := h.roleService.AssignUser(c.Request.Context(), tenantID, userID, roleID, targetUserID); err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	response.Success(c, gin.H{
+		"message": "Role assigned successfully",
+	})
+}
+
+// UnassignUser handles DELETE /api/v1/roles/:id/members/:user_id
+func (h *RoleHandler) UnassignUser(c *gin.Context) {
+	tenantID, userID, ok := h.requester(c)
+	if !ok {
+		return
+	}
+
+	roleID, targetUserID, ok := parseRoleMember(c)
+	if !ok {
+		return
+	}
+
+	if err := h.roleService.UnassignUser(c.Request.Context(), tenantID, userID, roleID, targetUserID); err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	response.Success(c, gin.H{
+		"message": "Role unassigned successfully",
+	})
+}
+
+// bindRequest binds and validates a role request, responding with an error
+// if it is invalid
+func (h *RoleHandler) bindRequest(c *gin.Context) (*models.RoleRequest, bool) {
+	var req models.RoleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.ValidationError(c, map[string]string{
+			"error": err.Error(),
+		})
+		return nil, false
+	}
+
+	if err := req.Validate(); err != nil {
+		response.ValidationError(c, map[string]string{
+			"error": err.Error(),
+		})
+		return nil, false
+	}
+
+	return &req, true
+}
+
+// requester returns the tenant and user of a request, responding with an
+// error if they are missing
+func (h *RoleHandler) requester(c *gin.Context) (int64, int64, bool) {
+	tenantID, ok := middleware.GetTenantID(c)
+	if !ok {
+		c.JSON(400, gin.H{"error": "Tenant not found"})
+		return 0, 0, false
+	}
+
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		c.JSON(400, gin.H{"error": "User not found"})
+		return 0, 0, false
+	}
+
+	return tenantID, userID, true
+}
+
+func parseRoleID(c *gin.Context) (int64, bool) {
+	roleID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(400, gin.H{"error": "Invalid role ID"})
+		return 0, false
+	}
+	return roleID, true
+}
+
+func parseRoleMember(c *gin.Context) (int64, int64, bool) {
+	roleID, ok := parseRoleID(c)
+	if !ok {
+		return 0, 0, false
+	}
+
+	userID, err := strconv.ParseInt(c.Param("user_id"), 10, 64)
+	if err != nil {
+		c.JSON(400, gin.H{"error": "Invalid user ID"})
+		return 0, 0, false
+	}
+
+	return roleID, userID, true
+}
@@ -0,0 +1,416 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/yourusername/gin-collection-saas/internal/delivery/http/middleware"
+	"github.com/yourusername/gin-collection-saas/internal/delivery/http/response"
+	domainErrors "github.com/yourusername/gin-collection-saas/internal/domain/errors"
+	"github.com/yourusername/gin-collection-saas/internal/domain/models"
+	"github.com/yourusername/gin-collection-saas/internal/usecase/scim"
+	"github.com/yourusername/gin-collection-saas/pkg/logger"
+)
+
+// SCIMHandler handles the SCIM token of a tenant and the SCIM 2.0 endpoints
+// identity providers provision users and groups with
+type SCIMHandler struct {
+	scimService *scim.Service
+}
+
+// NewSCIMHandler creates a new SCIM handler
+func NewSCIMHandler(scimService *scim.Service) *SCIMHandler {
+	return &SCIMHandler{
+		scimService: scimService,
+	}
+}
+
+// GetStatus handles GET /api/v1/tenants/current/scim
+func (h *SCIMHandler) GetStatus(c *gin.Context) {
+	tenant, ok := h.ownerTenant(c)
+	if !ok {
+		return
+	}
+
+	status, err := h.scimService.Status(c.Request.Context(), tenant)
+	if err != nil {
+		logger.Error("Failed to get scim status", "error", err.Error())
+		response.Error(c, err)
+		return
+	}
+
+	response.Success(c, status)
+}
+
+// GenerateToken handles POST /api/v1/tenants/current/scim/token
+func (h *SCIMHandler) GenerateToken(c *gin.Context) {
+	tenant, ok := h.ownerTenant(c)
+	if !ok {
+		return
+	}
+
+	userID, _ := middleware.GetUserID(c)
+
+	value, token, err := h.scimService.GenerateToken(c.Request.Context(), tenant, userID)
+	if err != nil {
+		logger.Error("Failed to generate scim token", "error", err.Error())
+		response.Error(c, err)
+		return
+	}
+
+	response.Created(c, gin.H{
+		"token":    value,
+		"scim":     token,
+		"base_url": h.scimService.BaseURL(),
+		"message":  "SCIM token generated successfully - store this securely, it will not be shown again",
+	})
+}
+
+// RevokeToken handles DELETE /api/v1/tenants/current/scim/token
+func (h *SCIMHandler) RevokeToken(c *gin.Context) {
+	tenant, ok := h.ownerTenant(c)
+	if !ok {
+		return
+	}
+
+	userID, _ := middleware.GetUserID(c)
+
+	if err := h.scimService.RevokeToken(c.Request.Context(), tenant, userID); err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	response.Success(c, gin.H{
+		"message": "SCIM token revoked successfully",
+	})
+}
+
+// ServiceProviderConfig handles GET /api/v1/scim/v2/ServiceProviderConfig
+func (h *SCIMHandler) ServiceProviderConfig(c *gin.Context) {
+	supported := func(value bool) gin.H { return gin.H{"supported": value} }
+
+	h.respond(c, http.StatusOK, gin.H{
+		"schemas":          []string{models.SCIMServiceProviderConfigSchema},
+		"patch":            supported(true),
+		"bulk":             gin.H{"supported": false, "maxOperations": 0, "maxPayloadSize": 0},
+		"filter":           gin.H{"supported": true, "maxResults": models.SCIMMaxResults},
+		"changePassword":   supported(false),
+		"sort":             supported(false),
+		"etag":             supported(false),
+		"documentationUri": "",
+		"authenticationSchemes": []gin.H{{
+			"type":        "oauthbearertoken",
+			"name":        "OAuth Bearer Token",
+			"description": "Authentication with the SCIM token of the tenant",
+			"primary":     true,
+		}},
+	})
+}
+
+// ListUsers handles GET /api/v1/scim/v2/Users
+func (h *SCIMHandler) ListUsers(c *gin.Context) {
+	tenant, startIndex, count, ok := h.listParams(c)
+	if !ok {
+		return
+	}
+
+	list, err := h.scimService.ListUsers(c.Request.Context(), tenant, c.Query("filter"), startIndex, count)
+	if err != nil {
+		h.respondError(c, err)
+		return
+	}
+
+	h.respond(c, http.StatusOK, list)
+}
+
+// GetUser handles GET /api/v1/scim/v2/Users/:id
+func (h *SCIMHandler) GetUser(c *gin.Context) {
+	tenant, ok := h.scimTenant(c)
+	if !ok {
+		return
+	}
+
+	user, err := h.scimService.GetUser(c.Request.Context(), tenant, c.Param("id"))
+	if err != nil {
+		h.respondError(c, err)
+		return
+	}
+
+	h.respond(c, http.StatusOK, user)
+}
+
+// CreateUser handles POST /api/v1/scim/v2/Users
+func (h *SCIMHandler) CreateUser(c *gin.Context) {
+	tenant, ok := h.scimTenant(c)
+	if !ok {
+		return
+	}
+
+	var req models.SCIMUser
+	if !h.bind(c, &req) {
+		return
+	}
+
+	user, err := h.scimService.CreateUser(c.Request.Context(), tenant, &req)
+	if err != nil {
+		h.respondError(c, err)
+		return
+	}
+
+	h.respond(c, http.StatusCreated, user)
+}
+
+// ReplaceUser handles PUT /api/v1/scim/v2/Users/:id
+func (h *SCIMHandler) ReplaceUser(c *gin.Context) {
+	tenant, ok := h.scimTenant(c)
+	if !ok {
+		return
+	}
+
+	var req models.SCIMUser
+	if !h.bind(c, &req) {
+		return
+	}
+
+	user, err := h.scimService.ReplaceUser(c.Request.Context(), tenant, c.Param("id"), &req)
+	if err != nil {
+		h.respondError(c, err)
+		return
+	}
+
+	h.respond(c, http.StatusOK, user)
+}
+
+// PatchUser handles PATCH /api/v1/scim/v2/Users/:id
+func (h *SCIMHandler) PatchUser(c *gin.Context) {
+	tenant, ok := h.scimTenant(c)
+	if !ok {
+		return
+	}
+
+	var req models.SCIMPatchRequest
+	if !h.bind(c, &req) {
+		return
+	}
+
+	user, err := h.scimService.PatchUser(c.Request.Context(), tenant, c.Param("id"), &req)
+	if err != nil {
+		h.respondError(c, err)
+		return
+	}
+
+	h.respond(c, http.StatusOK, user)
+}
+
+// DeleteUser handles DELETE /api/v1/scim/v2/Users/:id
+func (h *SCIMHandler) DeleteUser(c *gin.Context) {
+	tenant, ok := h.scimTenant(c)
+	if !ok {
+		return
+	}
+
+	if err := h.scimService.DeleteUser(c.Request.Context(), tenant, c.Param("id")); err != nil {
+		h.respondError(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// ListGroups handles GET /api/v1/scim/v2/Groups
+func (h *SCIMHandler) ListGroups(c *gin.Context) {
+	tenant, startIndex, count, ok := h.listParams(c)
+	if !ok {
+		return
+	}
+
+	list, err := h.scimService.ListGroups(c.Request.Context(), tenant, c.Query("filter"), startIndex, count)
+	if err != nil {
+		h.respondError(c, err)
+		return
+	}
+
+	h.respond(c, http.StatusOK, list)
+}
+
+// GetGroup handles GET /api/v1/scim/v2/Groups/:id
+func (h *SCIMHandler) GetGroup(c *gin.Context) {
+	tenant, ok := h.scimTenant(c)
+	if !ok {
+		return
+	}
+
+	group, err := h.scimService.GetGroup(c.Request.Context(), tenant, c.Param("id"))
+	if err != nil {
+		h.respondError(c, err)
+		return
+	}
+
+	h.respond(c, http.StatusOK, group)
+}
+
+// CreateGroup handles POST /api/v1/scim/v2/Groups
+func (h *SCIMHandler) CreateGroup(c *gin.Context) {
+	tenant, ok := h.scimTenant(c)
+	if !ok {
+		return
+	}
+
+	var req models.SCIMGroupResource
+	if !h.bind(c, &req) {
+		return
+	}
+
+	group, err := h.scimService.CreateGroup(c.Request.Context(), tenant, &req)
+	if err != nil {
+		h.respondError(c, err)
+		return
+	}
+
+	h.respond(c, http.StatusCreated, group)
+}
+
+// ReplaceGroup handles PUT /api/v1/scim/v2/Groups/:id
+func (h *SCIMHandler) ReplaceGroup(c *gin.Context) {
+	tenant, ok := h.scimTenant(c)
+	if !ok {
+		return
+	}
+
+	var req models.SCIMGroupResource
+	if !h.bind(c, &req) {
+		return
+	}
+
+	group, err := h.scimService.ReplaceGroup(c.Request.Context(), tenant, c.Param("id"), &req)
+	if err != nil {
+		h.respondError(c, err)
+		return
+	}
+
+	h.respond(c, http.StatusOK, group)
+}
+
+// PatchGroup handles PATCH /api/v1/scim/v2/Groups/:id
+func (h *SCIMHandler) PatchGroup(c *gin.Context) {
+	tenant, ok := h.scimTenant(c)
+	if !ok {
+		return
+	}
+
+	var req models.SCIMPatchRequest
+	if !h.bind(c, &req) {
+		return
+	}
+
+	group, err := h.scimService.PatchGroup(c.Request.Context(), tenant, c.Param("id"), &req)
+	if err != nil {
+		h.respondError(c, err)
+		return
+	}
+
+	h.respond(c, http.StatusOK, group)
+}
+
+// DeleteGroup handles DELETE /api/v1/scim/v2/Groups/:id
+func (h *SCIMHandler) DeleteGroup(c *gin.Context) {
+	tenant, ok := h.scimTenant(c)
+	if !ok {
+		return
+	}
+
+	if err := h.scimService.DeleteGroup(c.Request.Context(), tenant, c.Param("id")); err != nil {
+		h.respondError(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// ownerTenant returns the tenant if the requester is its owner
+func (h *SCIMHandler) ownerTenant(c *gin.Context) (*models.Tenant, bool) {
+	tenant, ok := middleware.GetTenant(c)
+	if !ok {
+		response.ValidationError(c, map[string]string{
+			"error": "Tenant not found in context",
+		})
+		return nil, false
+	}
+
+	userRole, _ := c.Get("user_role")
+	if userRole != "owner" {
+		response.ValidationError(c, map[string]string{
+			"error": "Only the tenant owner can manage SCIM provisioning",
+		})
+		return nil, false
+	}
+
+	return tenant, true
+}
+
+// scimTenant returns the tenant set by the SCIM auth middleware
+func (h *SCIMHandler) scimTenant(c *gin.Context) (*models.Tenant, bool) {
+	tenant, ok := middleware.GetTenant(c)
+	if !ok {
+		h.respond(c, http.StatusUnauthorized, models.NewSCIMError(http.StatusUnauthorized, "", "Tenant not found in context"))
+		return nil, false
+	}
+	return tenant, true
+}
+
+// listParams parses the 1-based startIndex and count of list requests
+func (h *SCIMHandler) listParams(c *gin.Context) (*models.Tenant, int, int, bool) {
+	tenant, ok := h.scimTenant(c)
+	if !ok {
+		return nil, 0, 0, false
+	}
+
+	startIndex, err := strconv.Atoi(c.DefaultQuery("startIndex", "1"))
+	if err != nil {
+		h.respond(c, http.StatusBadRequest, models.NewSCIMError(http.StatusBadRequest, "invalidValue", "startIndex must be a number"))
+		return nil, 0, 0, false
+	}
+
+	count, err := strconv.Atoi(c.DefaultQuery("count", strconv.Itoa(models.SCIMMaxResults)))
+	if err != nil {
+		h.respond(c, http.StatusBadRequest, models.NewSCIMError(http.StatusBadRequest, "invalidValue", "count must be a number"))
+		return nil, 0, 0, false
+	}
+
+	return tenant, startIndex, count, true
+}
+
+// bind decodes a SCIM request body
+func (h *SCIMHandler) bind(c *gin.Context, req interface{}) bool {
+	if err := c.ShouldBindJSON(req); err != nil {
+		h.respond(c, http.StatusBadRequest, models.NewSCIMError(http.StatusBadRequest, "invalidSyntax", err.Error()))
+		return false
+	}
+	return true
+}
+
+// respond writes a SCIM response
+func (h *SCIMHandler) respond(c *gin.Context, status int, body interface{}) {
+	c.Header("Content-Type", models.SCIMContentType)
+	c.JSON(status, body)
+}
+
+// respondError maps errors to SCIM errors
+func (h *SCIMHandler) respondError(c *gin.Context, err error) {
+	var scimErr *scim.Error
+	switch {
+	case errors.As(err, &scimErr):
+		h.respond(c, scimErr.Status, models.NewSCIMError(scimErr.Status, scimErr.ScimType, scimErr.Detail))
+	case err == domainErrors.ErrNotFound, err == domainErrors.ErrUserNotInTenant:
+		h.respond(c, http.StatusNotFound, models.NewSCIMError(http.StatusNotFound, "", "Resource not found"))
+	case err == domainErrors.ErrEmailAlreadyExists, err == domainErrors.ErrConflict:
+		h.respond(c, http.StatusConflict, models.NewSCIMError(http.StatusConflict, "uniqueness", err.Error()))
+	case errors.Is(err, domainErrors.ErrFeatureNotAvailable), err == domainErrors.ErrForbidden:
+		h.respond(c, http.StatusForbidden, models.NewSCIMError(http.StatusForbidden, "", err.Error()))
+	default:
+		logger.Error("SCIM request failed", "path", c.FullPath(), "error", err.Error())
+		h.respond(c, http.StatusInternalServerError, models.NewSCIMError(http.StatusInternalServerError, "", "Internal server error"))
+	}
+}
@@ -0,0 +1,257 @@
+package handler
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/yourusername/gin-collection-saas/internal/delivery/http/middleware"
+	"github.com/yourusername/gin-collection-saas/internal/delivery/http/response"
+	domainErrors "github.com/yourusername/gin-collection-saas/internal/domain/errors"
+	"github.com/yourusername/gin-collection-saas/internal/domain/models"
+	"github.com/yourusername/gin-collection-saas/internal/usecase/auth"
+	"github.com/yourusername/gin-collection-saas/internal/usecase/sso"
+	"github.com/yourusername/gin-collection-saas/pkg/logger"
+	"github.com/yourusername/gin-collection-saas/pkg/utils"
+)
+
+// maxSAMLResponseSize bounds the form posted to the assertion consumer service
+const maxSAMLResponseSize = 512 << 10
+
+// SSOHandler handles single sign-on configuration and the browser redirects
+// of OpenID Connect and SAML logins
+type SSOHandler struct {
+	ssoService   *sso.Service
+	authService  *auth.Service
+	cookieConfig *utils.CookieConfig
+	jwtExpiry    time.Duration
+	baseURL      string
+}
+
+// NewSSOHandler creates a new SSO handler. Browsers are sent to baseURL after the login.
+func NewSSOHandler(ssoService *sso.Service, authService *auth.Service, cookieConfig *utils.CookieConfig, jwtExpiry time.Duration, baseURL string) *SSOHandler {
+	return &SSOHandler{
+		ssoService:   ssoService,
+		authService:  authService,
+		cookieConfig: cookieConfig,
+		jwtExpiry:    jwtExpiry,
+		baseURL:      strings.TrimSuffix(baseURL, "/"),
+	}
+}
+
+// GetSettings handles GET /api/v1/tenants/current/sso
+func (h *SSOHandler) GetSettings(c *gin.Context) {
+	tenant, ok := middleware.GetTenant(c)
+	if !ok {
+		response.ValidationError(c, map[string]string{
+			"error": "Tenant not found in context",
+		})
+		return
+	}
+
+	userRole, _ := c.Get("user_role")
+	if userRole != "owner" {
+		response.ValidationError(c, map[string]string{
+			"error": "Only the tenant owner can manage single sign-on",
+		})
+		return
+	}
+
+	settings, err := h.ssoService.Settings(c.Request.Context(), tenant.ID)
+	if err != nil {
+		logger.Error("Failed to get sso settings", "error", err.Error())
+		response.Error(c, err)
+		return
+	}
+
+	h.respondSettings(c, tenant, settings, "")
+}
+
+// UpdateSettings handles PUT /api/v1/tenants/current/sso
+func (h *SSOHandler) UpdateSettings(c *gin.Context) {
+	tenant, ok := middleware.GetTenant(c)
+	if !ok {
+		response.ValidationError(c, map[string]string{
+			"error": "Tenant not found in context",
+		})
+		return
+	}
+
+	userRole, _ := c.Get("user_role")
+	if userRole != "owner" {
+		response.ValidationError(c, map[string]string{
+			"error": "Only the tenant owner can manage single sign-on",
+		})
+		return
+	}
+
+	var req models.SSOSettings
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.ValidationError(c, map[string]string{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	if err := req.Validate(); err != nil {
+		response.ValidationError(c, map[string]string{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	settings, err := h.ssoService.UpdateSettings(c.Request.Context(), tenant, &req)
+	if err != nil {
+		logger.Error("Failed to update sso settings", "error", err.Error())
+		response.Error(c, err)
+		return
+	}
+
+	h.respondSettings(c, tenant, settings, "Single sign-on settings updated successfully")
+}
+
+// GetLoginInfo handles GET /api/v1/auth/sso/:subdomain
+func (h *SSOHandler) GetLoginInfo(c *gin.Context) {
+	info, err := h.ssoService.LoginInfo(c.Request.Context(), c.Param("subdomain"))
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	response.Success(c, info)
+}
+
+// Login handles GET /api/v1/auth/sso/:subdomain/login and redirects to the identity provider
+func (h *SSOHandler) Login(c *gin.Context) {
+	loginURL, state, err := h.ssoService.BeginLogin(c.Request.Context(), c.Param("subdomain"), c.Query("redirect"))
+	if err != nil {
+		logger.Debug("SSO login could not be started", "error", err.Error())
+		h.redirectError(c, err)
+		return
+	}
+
+	utils.SetSSOStateCookie(c, state, h.cookieConfig, models.SSOLoginStateTTL)
+	c.Redirect(http.StatusFound, loginURL)
+}
+
+// OIDCCallback handles GET /api/v1/auth/sso/:subdomain/oidc/callback
+func (h *SSOHandler) OIDCCallback(c *gin.Context) {
+	state := c.Query("state")
+	if !h.stateMatchesCookie(c, state) {
+		h.redirectError(c, domainErrors.ErrSSOVerification)
+		return
+	}
+
+	// The user cancelled or the identity provider refused the login
+	if idpError := c.Query("error"); idpError != "" {
+		logger.Info("Identity provider returned an error", "error", idpError, "description", c.Query("error_description"))
+		h.redirectError(c, domainErrors.ErrSSOVerification)
+		return
+	}
+
+	authResp, redirectPath, err := h.authService.FinishOIDCLogin(clientContext(c), c.Param("subdomain"), state, c.Query("code"))
+	h.completeLogin(c, authResp, redirectPath, err)
+}
+
+// SAMLACS handles POST /api/v1/auth/sso/:subdomain/saml/acs (assertion consumer service)
+func (h *SSOHandler) SAMLACS(c *gin.Context) {
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxSAMLResponseSize)
+
+	relayState := c.PostForm("RelayState")
+	if !h.stateMatchesCookie(c, relayState) {
+		h.redirectError(c, domainErrors.ErrSSOVerification)
+		return
+	}
+
+	authResp, redirectPath, err := h.authService.FinishSAMLLogin(clientContext(c), c.Param("subdomain"), c.PostForm("SAMLResponse"), relayState)
+	h.completeLogin(c, authResp, redirectPath, err)
+}
+
+// SAMLMetadata handles GET /api/v1/auth/sso/:subdomain/saml/metadata
+func (h *SSOHandler) SAMLMetadata(c *gin.Context) {
+	metadata, err := h.ssoService.Metadata(c.Request.Context(), c.Param("subdomain"))
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	c.Data(http.StatusOK, "application/samlmetadata+xml", metadata)
+}
+
+// completeLogin sets the auth cookies and sends the browser back to the app
+func (h *SSOHandler) completeLogin(c *gin.Context, authResp *models.AuthResponse, redirectPath string, err error) {
+	utils.ClearSSOStateCookie(c, h.cookieConfig)
+
+	if err != nil {
+		logger.Debug("SSO login failed", "error", err.Error())
+		h.redirectError(c, err)
+		return
+	}
+
+	utils.SetAuthCookies(
+		c,
+		authResp.Token,
+		authResp.RefreshToken,
+		h.cookieConfig,
+		h.jwtExpiry,
+		30*24*time.Hour, // Refresh token: 30 days
+	)
+
+	// 303 turns the SAML POST into a GET
+	c.Redirect(http.StatusSeeOther, h.baseURL+redirectPath)
+}
+
+// stateMatchesCookie checks the callback belongs to a login started in this browser
+func (h *SSOHandler) stateMatchesCookie(c *gin.Context, state string) bool {
+	cookie, err := utils.GetSSOStateFromCookie(c)
+	if err != nil || state == "" {
+		return false
+	}
+	return subtle.ConstantTimeCompare([]byte(cookie), []byte(state)) == 1
+}
+
+// redirectError sends the browser to the login page with an error code
+func (h *SSOHandler) redirectError(c *gin.Context, err error) {
+	code := "failed"
+	switch err {
+	case domainErrors.ErrSSONotConfigured:
+		code = "not_configured"
+	case domainErrors.ErrSSOUserNotProvisioned:
+		code = "not_provisioned"
+	case domainErrors.ErrForbidden:
+		code = "account_disabled"
+	case domainErrors.ErrTenantSuspended:
+		code = "tenant_suspended"
+	case domainErrors.ErrTenantNotFound:
+		code = "tenant_not_found"
+	}
+
+	c.Redirect(http.StatusSeeOther, h.baseURL+"/login?sso_error="+url.QueryEscape(code))
+}
+
+// respondSettings returns the SSO configuration without the client secret,
+// together with the endpoints to register at the identity provider
+func (h *SSOHandler) respondSettings(c *gin.Context, tenant *models.Tenant, settings *models.SSOSettings, message string) {
+	redacted := *settings
+	clientSecretSet := false
+	if settings.OIDC != nil {
+		oidc := *settings.OIDC
+		clientSecretSet = oidc.ClientSecret != ""
+		oidc.ClientSecret = ""
+		redacted.OIDC = &oidc
+	}
+
+	body := gin.H{
+		"sso":               redacted,
+		"client_secret_set": clientSecretSet,
+		"service_provider":  h.ssoService.ServiceProvider(tenant.Subdomain),
+	}
+	if message != "" {
+		body["message"] = message
+	}
+
+	response.Success(c, body)
+}
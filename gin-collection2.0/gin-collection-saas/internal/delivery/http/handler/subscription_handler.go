@@ -52,8 +52,14 @@ func (h *SubscriptionHandler) GetCurrent(c *gin.Context) {
 }
 
 // GetPlans handles GET /api/v1/subscriptions/plans
+// The features are described in the language of ?lang= or Accept-Language
 func (h *SubscriptionHandler) GetPlans(c *gin.Context) {
-	plans := h.subscriptionService.GetAvailablePlans()
+	lang := c.Query("lang")
+	if lang == "" {
+		lang = c.GetHeader("Accept-Language")
+	}
+
+	plans := h.subscriptionService.GetAvailablePlans(models.PlanLanguage(lang))
 
 	response.Success(c, gin.H{
 		"plans": plans,
@@ -71,6 +77,8 @@ func (h *SubscriptionHandler) Upgrade(c *gin.Context) {
 	var req struct {
 		PlanID       string                 `json:"plan_id" binding:"required"`
 		BillingCycle models.BillingCycle    `json:"billing_cycle" binding:"required,oneof=monthly yearly"`
+		Provider     string                 `json:"provider"` // optional, defaults to the tenant's provider
+		CouponCode   string                 `json:"coupon_code"` // optional
 	}
 
 	if err := c.ShouldBindJSON(&req); err != nil {
@@ -87,6 +95,8 @@ func (h *SubscriptionHandler) Upgrade(c *gin.Context) {
 		tenantID,
 		req.PlanID,
 		req.BillingCycle,
+		req.Provider,
+		req.CouponCode,
 	)
 
 	if err != nil {
@@ -98,10 +108,84 @@ func (h *SubscriptionHandler) Upgrade(c *gin.Context) {
 	response.Success(c, upgradeResp)
 }
 
+// StartTrial handles POST /api/v1/subscriptions/trial
+func (h *SubscriptionHandler) StartTrial(c *gin.Context) {
+	tenantID, ok := middleware.GetTenantID(c)
+	if !ok {
+		c.JSON(400, gin.H{"error": "Tenant not found"})
+		return
+	}
+
+	var req struct {
+		PlanID string `json:"plan_id" binding:"required"`
+	}
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		logger.Debug("Invalid trial request", "error", err.Error())
+		response.ValidationError(c, map[string]string{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	subscription, err := h.subscriptionService.StartTrial(c.Request.Context(), tenantID, req.PlanID)
+	if err != nil {
+		logger.Error("Failed to start trial", "error", err.Error())
+		response.Error(c, err)
+		return
+	}
+
+	response.Created(c, gin.H{
+		"message":      "Trial started",
+		"subscription": subscription,
+	})
+}
+
+// PreviewCoupon handles GET /api/v1/subscriptions/coupons/:code
+func (h *SubscriptionHandler) PreviewCoupon(c *gin.Context) {
+	tenantID, ok := middleware.GetTenantID(c)
+	if !ok {
+		c.JSON(400, gin.H{"error": "Tenant not found"})
+		return
+	}
+
+	planID := c.Query("plan_id")
+	if planID == "" {
+		response.ValidationError(c, map[string]string{
+			"plan_id": "plan_id is required",
+		})
+		return
+	}
+
+	billingCycle := models.BillingCycle(c.DefaultQuery("billing_cycle", string(models.BillingCycleMonthly)))
+	if billingCycle != models.BillingCycleMonthly && billingCycle != models.BillingCycleYearly {
+		response.ValidationError(c, map[string]string{
+			"billing_cycle": "billing_cycle must be monthly or yearly",
+		})
+		return
+	}
+
+	preview, err := h.subscriptionService.PreviewCoupon(c.Request.Context(), tenantID, c.Param("code"), planID, billingCycle)
+	if err != nil {
+		logger.Debug("Coupon rejected", "error", err.Error())
+		response.Error(c, err)
+		return
+	}
+
+	response.Success(c, preview)
+}
+
 // Activate handles POST /api/v1/subscriptions/activate
 func (h *SubscriptionHandler) Activate(c *gin.Context) {
+	tenantID, ok := middleware.GetTenantID(c)
+	if !ok {
+		c.JSON(400, gin.H{"error": "Tenant not found"})
+		return
+	}
+
 	var req struct {
-		PayPalSubscriptionID string `json:"paypal_subscription_id" binding:"required"`
+		Provider   string `json:"provider" binding:"required"`
+		CheckoutID string `json:"checkout_id" binding:"required"`
 	}
 
 	if err := c.ShouldBindJSON(&req); err != nil {
@@ -113,17 +197,161 @@ func (h *SubscriptionHandler) Activate(c *gin.Context) {
 	}
 
 	// Activate subscription
-	if err := h.subscriptionService.ActivateSubscription(c.Request.Context(), req.PayPalSubscriptionID); err != nil {
+	subscription, err := h.subscriptionService.ActivateCheckout(c.Request.Context(), tenantID, req.Provider, req.CheckoutID)
+	if err != nil {
 		logger.Error("Failed to activate subscription", "error", err.Error())
 		response.Error(c, err)
 		return
 	}
 
 	response.Success(c, gin.H{
-		"message": "Subscription activated successfully",
+		"message":      "Subscription activated successfully",
+		"subscription": subscription,
 	})
 }
 
+// ChangePlan handles POST /api/v1/subscriptions/change-plan
+func (h *SubscriptionHandler) ChangePlan(c *gin.Context) {
+	tenantID, ok := middleware.GetTenantID(c)
+	if !ok {
+		c.JSON(400, gin.H{"error": "Tenant not found"})
+		return
+	}
+
+	var req struct {
+		PlanID string `json:"plan_id" binding:"required"`
+	}
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		logger.Debug("Invalid change plan request", "error", err.Error())
+		response.ValidationError(c, map[string]string{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	change, err := h.subscriptionService.ChangePlan(c.Request.Context(), tenantID, req.PlanID)
+	if err != nil {
+		logger.Error("Failed to change plan", "error", err.Error())
+		response.Error(c, err)
+		return
+	}
+
+	response.Success(c, change)
+}
+
+// PreviewPlanChange handles GET /api/v1/subscriptions/change-plan/preview
+func (h *SubscriptionHandler) PreviewPlanChange(c *gin.Context) {
+	tenantID, ok := middleware.GetTenantID(c)
+	if !ok {
+		c.JSON(400, gin.H{"error": "Tenant not found"})
+		return
+	}
+
+	planID := c.Query("plan_id")
+	if planID == "" {
+		response.ValidationError(c, map[string]string{
+			"plan_id": "plan_id is required",
+		})
+		return
+	}
+
+	preview, err := h.subscriptionService.PreviewPlanChange(c.Request.Context(), tenantID, planID)
+	if err != nil {
+		logger.Error("Failed to preview plan change", "error", err.Error())
+		response.Error(c, err)
+		return
+	}
+
+	response.Success(c, preview)
+}
+
+// CancelScheduledChange handles DELETE /api/v1/subscriptions/scheduled-change
+func (h *SubscriptionHandler) CancelScheduledChange(c *gin.Context) {
+	tenantID, ok := middleware.GetTenantID(c)
+	if !ok {
+		c.JSON(400, gin.H{"error": "Tenant not found"})
+		return
+	}
+
+	change, err := h.subscriptionService.CancelScheduledChange(c.Request.Context(), tenantID)
+	if err != nil {
+		logger.Error("Failed to cancel scheduled plan change", "error", err.Error())
+		response.Error(c, err)
+		return
+	}
+
+	response.Success(c, change)
+}
+
+// Portal handles GET /api/v1/subscriptions/portal
+func (h *SubscriptionHandler) Portal(c *gin.Context) {
+	tenantID, ok := middleware.GetTenantID(c)
+	if !ok {
+		c.JSON(400, gin.H{"error": "Tenant not found"})
+		return
+	}
+
+	url, err := h.subscriptionService.CustomerPortalURL(c.Request.Context(), tenantID)
+	if err != nil {
+		logger.Error("Failed to get customer portal", "error", err.Error())
+		response.Error(c, err)
+		return
+	}
+
+	response.Success(c, gin.H{
+		"url": url,
+	})
+}
+
+// GetProviders handles GET /api/v1/subscriptions/providers
+func (h *SubscriptionHandler) GetProviders(c *gin.Context) {
+	tenantID, ok := middleware.GetTenantID(c)
+	if !ok {
+		c.JSON(400, gin.H{"error": "Tenant not found"})
+		return
+	}
+
+	providers, err := h.subscriptionService.BillingProviders(c.Request.Context(), tenantID)
+	if err != nil {
+		logger.Error("Failed to get billing providers", "error", err.Error())
+		response.Error(c, err)
+		return
+	}
+
+	response.Success(c, providers)
+}
+
+// SelectProvider handles PUT /api/v1/subscriptions/providers
+func (h *SubscriptionHandler) SelectProvider(c *gin.Context) {
+	tenantID, ok := middleware.GetTenantID(c)
+	if !ok {
+		c.JSON(400, gin.H{"error": "Tenant not found"})
+		return
+	}
+
+	var req struct {
+		Provider string `json:"provider"` // empty uses the platform default
+	}
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		logger.Debug("Invalid select provider request", "error", err.Error())
+		response.ValidationError(c, map[string]string{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	providers, err := h.subscriptionService.SelectBillingProvider(c.Request.Context(), tenantID, req.Provider)
+	if err != nil {
+		logger.Error("Failed to select billing provider", "error", err.Error())
+		response.Error(c, err)
+		return
+	}
+
+	response.Success(c, providers)
+}
+
 // Cancel handles POST /api/v1/subscriptions/cancel
 func (h *SubscriptionHandler) Cancel(c *gin.Context) {
 	tenantID, ok := middleware.GetTenantID(c)
@@ -142,14 +370,104 @@ func (h *SubscriptionHandler) Cancel(c *gin.Context) {
 		req.Reason = "User requested cancellation"
 	}
 
-	// Cancel subscription
-	if err := h.subscriptionService.CancelSubscription(c.Request.Context(), tenantID, req.Reason); err != nil {
+	// Cancel subscription at the end of the period
+	subscription, err := h.subscriptionService.CancelSubscription(c.Request.Context(), tenantID, req.Reason)
+	if err != nil {
 		logger.Error("Failed to cancel subscription", "error", err.Error())
 		response.Error(c, err)
 		return
 	}
 
+	message := "Subscription cancelled successfully"
+	if subscription.CancelAtPeriodEnd {
+		message = "Subscription will be cancelled at the end of the billing period"
+	}
+
 	response.Success(c, gin.H{
-		"message": "Subscription cancelled successfully",
+		"message":      message,
+		"subscription": subscription,
 	})
 }
+
+// Pause handles POST /api/v1/subscriptions/pause
+func (h *SubscriptionHandler) Pause(c *gin.Context) {
+	tenantID, ok := middleware.GetTenantID(c)
+	if !ok {
+		c.JSON(400, gin.H{"error": "Tenant not found"})
+		return
+	}
+
+	var req struct {
+		Reason string `json:"reason"`
+	}
+
+	c.ShouldBindJSON(&req)
+
+	if req.Reason == "" {
+		req.Reason = "User paused the subscription"
+	}
+
+	subscription, err := h.subscriptionService.PauseSubscription(c.Request.Context(), tenantID, req.Reason)
+	if err != nil {
+		logger.Error("Failed to pause subscription", "error", err.Error())
+		response.Error(c, err)
+		return
+	}
+
+	response.Success(c, gin.H{
+		"message":      "Subscription paused",
+		"subscription": subscription,
+	})
+}
+
+// Resume handles POST /api/v1/subscriptions/resume
+// Resumes a paused subscription, or one cancelled at the end of the period
+func (h *SubscriptionHandler) Resume(c *gin.Context) {
+	tenantID, ok := middleware.GetTenantID(c)
+	if !ok {
+		c.JSON(400, gin.H{"error": "Tenant not found"})
+		return
+	}
+
+	subscription, err := h.subscriptionService.ResumeSubscription(c.Request.Context(), tenantID, "User resumed the subscription")
+	if err != nil {
+		logger.Error("Failed to resume subscription", "error", err.Error())
+		response.Error(c, err)
+		return
+	}
+
+	response.Success(c, gin.H{
+		"message":      "Subscription resumed",
+		"subscription": subscription,
+	})
+}
+
+// ChangeBillingCycle handles POST /api/v1/subscriptions/billing-cycle
+func (h *SubscriptionHandler) ChangeBillingCycle(c *gin.Context) {
+	tenantID, ok := middleware.GetTenantID(c)
+	if !ok {
+		c.JSON(400, gin.H{"error": "Tenant not found"})
+		return
+	}
+
+	var req struct {
+		BillingCycle models.BillingCycle `json:"billing_cycle" binding:"required,oneof=monthly yearly"`
+	}
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		logger.Debug("Invalid billing cycle request", "error", err.Error())
+		response.ValidationError(c, map[string]string{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	change, err := h.subscriptionService.ChangeBillingCycle(c.Request.Context(), tenantID, req.BillingCycle)
+	if err != nil {
+		logger.Error("Failed to change billing cycle", "error", err.Error())
+		response.Error(c, err)
+		return
+	}
+
+	response.Success(c, change)
+}
@@ -12,13 +12,15 @@ import (
 type TenantHandler struct {
 	tenantRepo       repositories.TenantRepository
 	usageMetricsRepo repositories.UsageMetricsRepository
+	storageUsageRepo repositories.StorageUsageRepository
 }
 
 // NewTenantHandler creates a new tenant handler
-func NewTenantHandler(tenantRepo repositories.TenantRepository, usageMetricsRepo repositories.UsageMetricsRepository) *TenantHandler {
+func NewTenantHandler(tenantRepo repositories.TenantRepository, usageMetricsRepo repositories.UsageMetricsRepository, storageUsageRepo repositories.StorageUsageRepository) *TenantHandler {
 	return &TenantHandler{
 		tenantRepo:       tenantRepo,
 		usageMetricsRepo: usageMetricsRepo,
+		storageUsageRepo: storageUsageRepo,
 	}
 }
 
@@ -87,6 +89,52 @@ func (h *TenantHandler) UpdateCurrent(c *gin.Context) {
 	})
 }
 
+// UpdateSecurity handles PUT /api/v1/tenants/current/security
+func (h *TenantHandler) UpdateSecurity(c *gin.Context) {
+	tenant, ok := middleware.GetTenant(c)
+	if !ok {
+		response.ValidationError(c, map[string]string{
+			"error": "Tenant not found in context",
+		})
+		return
+	}
+
+	// Only owner can change security settings
+	userRole, _ := c.Get("user_role")
+	if userRole != "owner" {
+		response.ValidationError(c, map[string]string{
+			"error": "Only the tenant owner can update security settings",
+		})
+		return
+	}
+
+	var req struct {
+		RequireTwoFactor *bool `json:"require_two_factor" binding:"required"`
+	}
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.ValidationError(c, map[string]string{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	if err := h.tenantRepo.UpdateRequireTwoFactor(c.Request.Context(), tenant.ID, *req.RequireTwoFactor); err != nil {
+		logger.Error("Failed to update tenant security settings", "error", err.Error())
+		response.Error(c, err)
+		return
+	}
+
+	tenant.RequireTwoFactor = *req.RequireTwoFactor
+
+	logger.Info("Tenant security settings updated", "tenant_id", tenant.ID, "require_two_factor", tenant.RequireTwoFactor)
+
+	response.Success(c, gin.H{
+		"tenant":  tenant,
+		"message": "Security settings updated successfully",
+	})
+}
+
 // GetUsage handles GET /api/v1/tenants/usage
 func (h *TenantHandler) GetUsage(c *gin.Context) {
 	tenant, ok := middleware.GetTenant(c)
@@ -99,11 +147,24 @@ func (h *TenantHandler) GetUsage(c *gin.Context) {
 
 	// Get usage metrics
 	ginCount, _ := h.usageMetricsRepo.GetMetric(c.Request.Context(), tenant.ID, "gin_count")
-	storageUsedMB, _ := h.usageMetricsRepo.GetMetric(c.Request.Context(), tenant.ID, "storage_mb")
-	photoCount, _ := h.usageMetricsRepo.GetMetric(c.Request.Context(), tenant.ID, "photo_count")
 
-	// Get plan limits
-	limits := tenant.GetLimits()
+	// Get storage usage (tracked in bytes)
+	storageUsage, err := h.storageUsageRepo.GetUsage(c.Request.Context(), tenant.ID)
+	if err != nil {
+		logger.Error("Failed to get storage usage", "error", err.Error())
+		response.Error(c, err)
+		return
+	}
+
+	ginBreakdown, err := h.storageUsageRepo.GetBreakdownByGin(c.Request.Context(), tenant.ID)
+	if err != nil {
+		logger.Error("Failed to get storage breakdown", "error", err.Error())
+		response.Error(c, err)
+		return
+	}
+
+	// Get the enforced limits, Free while a payment is overdue
+	limits := tenant.EnforcedLimits()
 
 	// Calculate percentages
 	var ginPercentage float64
@@ -114,7 +175,7 @@ func (h *TenantHandler) GetUsage(c *gin.Context) {
 	}
 
 	if limits.StorageLimitMB != nil && *limits.StorageLimitMB > 0 {
-		storagePercentage = float64(storageUsedMB) / float64(*limits.StorageLimitMB) * 100
+		storagePercentage = storageUsage.MB() / float64(*limits.StorageLimitMB) * 100
 	}
 
 	response.Success(c, gin.H{
@@ -124,27 +185,37 @@ func (h *TenantHandler) GetUsage(c *gin.Context) {
 				"limit":      limits.MaxGins,
 				"percentage": ginPercentage,
 				"unlimited":  limits.MaxGins == nil,
+				"over_limit": limits.GinsOverLimit(ginCount),
 			},
 			"storage": gin.H{
-				"current_mb":  storageUsedMB,
-				"limit_mb":    limits.StorageLimitMB,
-				"percentage":  storagePercentage,
-				"unlimited":   limits.StorageLimitMB == nil,
+				"current_bytes": storageUsage.BytesUsed,
+				"current_mb":    storageUsage.MB(),
+				"limit_mb":      limits.StorageLimitMB,
+				"percentage":    storagePercentage,
+				"unlimited":     limits.StorageLimitMB == nil,
+				"reconciled_at": storageUsage.ReconciledAt,
+				"by_gin":        ginBreakdown,
 			},
 			"photos": gin.H{
-				"total":            photoCount,
+				"total":            storageUsage.PhotoCount,
 				"per_gin_limit":    limits.MaxPhotosPerGin,
 			},
 		},
 		"tier": tenant.Tier,
+		"billing_restricted": tenant.BillingRestricted,
+		// Over the gin limit (e.g. after a downgrade) the collection is read-only
+		"read_only": limits.GinsOverLimit(ginCount) > 0,
 		"features": gin.H{
-			"botanicals":     limits.HasBotanicals,
-			"cocktails":      limits.HasCocktails,
-			"ai_suggestions": limits.HasAISuggestions,
-			"export":         limits.HasExport,
-			"import":         limits.HasImport,
-			"multi_user":     limits.HasMultiUser,
-			"api_access":     limits.HasAPIAccess,
+			"botanicals":         limits.HasBotanicals,
+			"cocktails":          limits.HasCocktails,
+			"ai_suggestions":     limits.HasAISuggestions,
+			"export":             limits.HasExport,
+			"import":             limits.HasImport,
+			"multi_user":         limits.HasMultiUser,
+			"api_access":         limits.HasAPIAccess,
+			"sso":                limits.HasSSO,
+			"scim":               limits.HasSCIM,
+			"dedicated_database": limits.HasDedicatedDatabase,
 		},
 	})
 }
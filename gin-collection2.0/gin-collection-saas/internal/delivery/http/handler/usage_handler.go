@@ -0,0 +1,49 @@
+package handler
+
+import (
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/yourusername/gin-collection-saas/internal/delivery/http/middleware"
+	"github.com/yourusername/gin-collection-saas/internal/delivery/http/response"
+	"github.com/yourusername/gin-collection-saas/internal/usecase/usage"
+	"github.com/yourusername/gin-collection-saas/pkg/logger"
+)
+
+// UsageHandler handles the metered usage of a tenant
+type UsageHandler struct {
+	usageService *usage.Service
+}
+
+// NewUsageHandler creates a new usage handler
+func NewUsageHandler(usageService *usage.Service) *UsageHandler {
+	return &UsageHandler{
+		usageService: usageService,
+	}
+}
+
+// Dashboard handles GET /api/v1/tenants/usage/dashboard
+// Shows the consumption of the current billing period against the limits and
+// the usage of earlier periods (?periods=, default 6)
+func (h *UsageHandler) Dashboard(c *gin.Context) {
+	tenant, ok := middleware.GetTenant(c)
+	if !ok {
+		c.JSON(400, gin.H{"error": "Tenant not found"})
+		return
+	}
+
+	periods, err := strconv.Atoi(c.DefaultQuery("periods", "6"))
+	if err != nil || periods < 0 {
+		response.BadRequest(c, "Invalid number of periods")
+		return
+	}
+
+	report, err := h.usageService.Report(c.Request.Context(), tenant, periods)
+	if err != nil {
+		logger.Error("Failed to get usage report", "tenant_id", tenant.ID, "error", err.Error())
+		response.Error(c, err)
+		return
+	}
+
+	response.Success(c, report)
+}
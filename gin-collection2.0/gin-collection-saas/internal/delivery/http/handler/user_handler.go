@@ -191,8 +191,28 @@ func (h *UserHandler) Delete(c *gin.Context) {
 	})
 }
 
-// GenerateAPIKey handles POST /api/v1/users/:id/api-key
-func (h *UserHandler) GenerateAPIKey(c *gin.Context) {
+// ListInvites handles GET /api/v1/users/invites
+func (h *UserHandler) ListInvites(c *gin.Context) {
+	tenantID, ok := middleware.GetTenantID(c)
+	if !ok {
+		c.JSON(400, gin.H{"error": "Tenant not found"})
+		return
+	}
+
+	invites, err := h.userService.ListPendingInvites(c.Request.Context(), tenantID)
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	response.Success(c, gin.H{
+		"invites": invites,
+		"count":   len(invites),
+	})
+}
+
+// ResendInvite handles POST /api/v1/users/:id/invite/resend
+func (h *UserHandler) ResendInvite(c *gin.Context) {
 	tenantID, ok := middleware.GetTenantID(c)
 	if !ok {
 		c.JSON(400, gin.H{"error": "Tenant not found"})
@@ -205,27 +225,53 @@ func (h *UserHandler) GenerateAPIKey(c *gin.Context) {
 		return
 	}
 
-	targetUserIDStr := c.Param("id")
-	targetUserID, err := strconv.ParseInt(targetUserIDStr, 10, 64)
+	targetUserID, err := strconv.ParseInt(c.Param("id"), 10, 64)
 	if err != nil {
 		c.JSON(400, gin.H{"error": "Invalid user ID"})
 		return
 	}
 
-	apiKey, err := h.userService.GenerateAPIKey(c.Request.Context(), tenantID, requesterUserID, targetUserID)
+	invite, err := h.userService.ResendInvite(c.Request.Context(), tenantID, requesterUserID, targetUserID)
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	response.Success(c, invite)
+}
+
+// RevokeInvite handles DELETE /api/v1/users/:id/invite
+func (h *UserHandler) RevokeInvite(c *gin.Context) {
+	tenantID, ok := middleware.GetTenantID(c)
+	if !ok {
+		c.JSON(400, gin.H{"error": "Tenant not found"})
+		return
+	}
+
+	requesterUserID, ok := middleware.GetUserID(c)
+	if !ok {
+		c.JSON(400, gin.H{"error": "User not found"})
+		return
+	}
+
+	targetUserID, err := strconv.ParseInt(c.Param("id"), 10, 64)
 	if err != nil {
+		c.JSON(400, gin.H{"error": "Invalid user ID"})
+		return
+	}
+
+	if err := h.userService.RevokeInvite(c.Request.Context(), tenantID, requesterUserID, targetUserID); err != nil {
 		response.Error(c, err)
 		return
 	}
 
 	response.Success(c, gin.H{
-		"api_key": apiKey,
-		"message": "API key generated successfully - store this securely, it will not be shown again",
+		"message": "Invite revoked successfully",
 	})
 }
 
-// RevokeAPIKey handles DELETE /api/v1/users/:id/api-key
-func (h *UserHandler) RevokeAPIKey(c *gin.Context) {
+// Unlock handles POST /api/v1/users/:id/unlock
+func (h *UserHandler) Unlock(c *gin.Context) {
 	tenantID, ok := middleware.GetTenantID(c)
 	if !ok {
 		c.JSON(400, gin.H{"error": "Tenant not found"})
@@ -238,19 +284,19 @@ func (h *UserHandler) RevokeAPIKey(c *gin.Context) {
 		return
 	}
 
-	targetUserIDStr := c.Param("id")
-	targetUserID, err := strconv.ParseInt(targetUserIDStr, 10, 64)
+	targetUserID, err := strconv.ParseInt(c.Param("id"), 10, 64)
 	if err != nil {
 		c.JSON(400, gin.H{"error": "Invalid user ID"})
 		return
 	}
 
-	if err := h.userService.RevokeAPIKey(c.Request.Context(), tenantID, requesterUserID, targetUserID); err != nil {
+	if err := h.userService.UnlockUser(c.Request.Context(), tenantID, requesterUserID, targetUserID); err != nil {
+		logger.Error("Failed to unlock user", "error", err.Error())
 		response.Error(c, err)
 		return
 	}
 
 	response.Success(c, gin.H{
-		"message": "API key revoked successfully",
+		"message": "User unlocked successfully",
 	})
 }
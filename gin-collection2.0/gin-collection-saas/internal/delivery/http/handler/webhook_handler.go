@@ -1,12 +1,18 @@
 package handler
 
 import (
+	"io"
+
 	"github.com/gin-gonic/gin"
 	"github.com/yourusername/gin-collection-saas/internal/delivery/http/response"
+	"github.com/yourusername/gin-collection-saas/internal/domain/models"
 	subscriptionUsecase "github.com/yourusername/gin-collection-saas/internal/usecase/subscription"
 	"github.com/yourusername/gin-collection-saas/pkg/logger"
 )
 
+// maxWebhookBodySize limits the size of webhook payloads
+const maxWebhookBodySize = 1 << 20
+
 // WebhookHandler handles webhook HTTP requests
 type WebhookHandler struct {
 	subscriptionService *subscriptionUsecase.Service
@@ -21,21 +27,27 @@ func NewWebhookHandler(subscriptionService *subscriptionUsecase.Service) *Webhoo
 
 // PayPal handles POST /api/v1/webhooks/paypal
 func (h *WebhookHandler) PayPal(c *gin.Context) {
-	var event subscriptionUsecase.WebhookEvent
+	h.process(c, models.BillingProviderPayPal)
+}
 
-	if err := c.ShouldBindJSON(&event); err != nil {
-		logger.Error("Invalid webhook payload", "error", err.Error())
-		response.ValidationError(c, map[string]string{
-			"error": "Invalid webhook payload",
-		})
+// Stripe handles POST /api/v1/webhooks/stripe
+func (h *WebhookHandler) Stripe(c *gin.Context) {
+	h.process(c, models.BillingProviderStripe)
+}
+
+// process verifies and processes a webhook delivery of a billing provider
+func (h *WebhookHandler) process(c *gin.Context, provider string) {
+	// The signature covers the payload as sent, so it is read before decoding
+	body, err := io.ReadAll(io.LimitReader(c.Request.Body, maxWebhookBodySize))
+	if err != nil {
+		logger.Error("Failed to read webhook payload", "error", err.Error())
+		response.BadRequest(c, "Invalid webhook payload")
 		return
 	}
 
-	logger.Info("Received PayPal webhook", "event_type", event.EventType, "event_id", event.ID)
-
 	// Process webhook event
-	if err := h.subscriptionService.HandleWebhookEvent(c.Request.Context(), &event); err != nil {
-		logger.Error("Failed to process webhook", "error", err.Error(), "event_type", event.EventType)
+	if err := h.subscriptionService.ProcessWebhook(c.Request.Context(), provider, c.Request.Header, body); err != nil {
+		logger.Error("Failed to process webhook", "provider", provider, "error", err.Error())
 		response.Error(c, err)
 		return
 	}
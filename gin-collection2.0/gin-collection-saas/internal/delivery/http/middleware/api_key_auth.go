@@ -1,107 +1,188 @@
 package middleware
 
 import (
+	"crypto/subtle"
+	"net/http"
 	"strings"
 
 	"github.com/gin-gonic/gin"
+	"github.com/yourusername/gin-collection-saas/internal/domain/models"
 	"github.com/yourusername/gin-collection-saas/internal/domain/repositories"
+	"github.com/yourusername/gin-collection-saas/internal/usecase/entitlement"
 	"github.com/yourusername/gin-collection-saas/pkg/logger"
+	"github.com/yourusername/gin-collection-saas/pkg/utils"
 )
 
-// APIKeyAuthMiddleware handles API key authentication for Enterprise tier
+// apiKeyRouteScopes maps routes to the scopes an API key needs for reading
+// (GET, HEAD) and writing (other methods). Routes are matched by prefix in
+// order, routes without an entry or without a write scope can't be called
+// with an API key.
+var apiKeyRouteScopes = []struct {
+	prefix string
+	read   string
+	write  string
+}{
+	{"/api/v1/gins/export", models.ScopeGinsRead, models.ScopeGinsRead},
+	{"/api/v1/gins/:id/tastings", models.ScopeTastingsRead, models.ScopeTastingsWrite},
+	{"/api/v1/tastings", models.ScopeTastingsRead, models.ScopeTastingsWrite},
+	{"/api/v1/gins/:id/photos", models.ScopePhotosRead, models.ScopePhotosWrite},
+	{"/api/v1/photos", models.ScopePhotosRead, models.ScopePhotosWrite},
+	{"/api/v1/gins", models.ScopeGinsRead, models.ScopeGinsWrite},
+	{"/api/v1/botanicals", models.ScopeCatalogRead, ""},
+	{"/api/v1/cocktails", models.ScopeCatalogRead, ""},
+	{"/api/v1/gin-references", models.ScopeCatalogRead, ""},
+}
+
+// requiredAPIKeyScope returns the scope an API key needs for a route, empty
+// if API keys can't call it
+func requiredAPIKeyScope(method, route string) string {
+	for _, r := range apiKeyRouteScopes {
+		if route != r.prefix && !strings.HasPrefix(route, r.prefix+"/") {
+			continue
+		}
+		if method == http.MethodGet || method == http.MethodHead {
+			return r.read
+		}
+		return r.write
+	}
+	return ""
+}
+
+// APIKeyAuthMiddleware handles API key authentication for Pro and Enterprise tier
 type APIKeyAuthMiddleware struct {
-	userRepo   repositories.UserRepository
-	tenantRepo repositories.TenantRepository
+	apiKeyRepo   repositories.APIKeyRepository
+	userRepo     repositories.UserRepository
+	tenantRepo   repositories.TenantRepository
+	entitlements *entitlement.Service
 }
 
 // NewAPIKeyAuthMiddleware creates a new API key authentication middleware
-func NewAPIKeyAuthMiddleware(userRepo repositories.UserRepository, tenantRepo repositories.TenantRepository) *APIKeyAuthMiddleware {
+func NewAPIKeyAuthMiddleware(apiKeyRepo repositories.APIKeyRepository, userRepo repositories.UserRepository, tenantRepo repositories.TenantRepository, entitlements *entitlement.Service) *APIKeyAuthMiddleware {
 	return &APIKeyAuthMiddleware{
-		userRepo:   userRepo,
-		tenantRepo: tenantRepo,
+		apiKeyRepo:   apiKeyRepo,
+		userRepo:     userRepo,
+		tenantRepo:   tenantRepo,
+		entitlements: entitlements,
+	}
+}
+
+// extractAPIKey returns the API key from "Authorization: Bearer sk_..." or
+// the X-API-Key header, empty if the request has none
+func extractAPIKey(c *gin.Context) string {
+	parts := strings.Split(c.GetHeader("Authorization"), " ")
+	if len(parts) == 2 && parts[0] == "Bearer" && strings.HasPrefix(parts[1], models.APIKeyPrefix) {
+		return parts[1]
+	}
+	if apiKey := c.GetHeader("X-API-Key"); strings.HasPrefix(apiKey, models.APIKeyPrefix) {
+		return apiKey
 	}
+	return ""
 }
 
-// Authenticate validates the API key and sets user context
+// Authenticate validates the API key, checks it has the scope of the route
+// and sets user context
 func (m *APIKeyAuthMiddleware) Authenticate() gin.HandlerFunc {
 	return func(c *gin.Context) {
-		// Get API key from Authorization header: "Bearer sk_..."
-		authHeader := c.GetHeader("Authorization")
-		if authHeader == "" {
+		apiKey := extractAPIKey(c)
+		if apiKey == "" {
 			c.JSON(401, gin.H{"error": "API key required - provide Authorization: Bearer sk_xxx"})
 			c.Abort()
 			return
 		}
 
-		// Extract the API key
-		parts := strings.Split(authHeader, " ")
-		if len(parts) != 2 || parts[0] != "Bearer" {
-			c.JSON(401, gin.H{"error": "Invalid Authorization header format - use: Bearer sk_xxx"})
+		// Lookup key by hash
+		keyHash := utils.HashToken(apiKey)
+		key, err := m.apiKeyRepo.GetByHash(c.Request.Context(), keyHash)
+		if err != nil || subtle.ConstantTimeCompare([]byte(key.KeyHash), []byte(keyHash)) != 1 {
+			logger.Debug("Invalid API key attempt", "ip", c.ClientIP())
+			c.JSON(401, gin.H{"error": "Invalid API key"})
 			c.Abort()
 			return
 		}
 
-		apiKey := parts[1]
+		if key.RevokedAt != nil {
+			logger.Debug("Revoked API key used", "prefix", key.Prefix, "user_id", key.UserID)
+			c.JSON(401, gin.H{"error": "API key has been revoked"})
+			c.Abort()
+			return
+		}
 
-		// Validate API key prefix
-		if !strings.HasPrefix(apiKey, "sk_") {
-			c.JSON(401, gin.H{"error": "Invalid API key format"})
+		if key.IsExpired() {
+			logger.Debug("Expired API key used", "prefix", key.Prefix, "user_id", key.UserID)
+			c.JSON(401, gin.H{"error": "API key has expired"})
 			c.Abort()
 			return
 		}
 
-		// Lookup user by API key
-		user, err := m.userRepo.GetByAPIKey(c.Request.Context(), apiKey)
+		user, err := m.userRepo.GetByID(c.Request.Context(), key.UserID)
 		if err != nil {
-			logger.Debug("Invalid API key attempt", "api_key_prefix", apiKey[:10])
+			logger.Error("Failed to get user for API key auth", "user_id", key.UserID, "error", err.Error())
 			c.JSON(401, gin.H{"error": "Invalid API key"})
 			c.Abort()
 			return
 		}
 
 		// Check if user is active
-		if !user.IsActive {
+		if !user.IsActive || user.TenantID != key.TenantID {
 			logger.Warn("Inactive user attempted API access", "user_id", user.ID, "tenant_id", user.TenantID)
 			c.JSON(403, gin.H{"error": "User account is inactive"})
 			c.Abort()
 			return
 		}
 
-		// Get tenant to verify Enterprise tier
-		tenant, err := m.tenantRepo.GetByID(c.Request.Context(), user.TenantID)
+		tenant, err := m.tenantRepo.GetByID(c.Request.Context(), key.TenantID)
 		if err != nil {
-			logger.Error("Failed to get tenant for API key auth", "tenant_id", user.TenantID, "error", err.Error())
+			logger.Error("Failed to get tenant for API key auth", "tenant_id", key.TenantID, "error", err.Error())
 			c.JSON(500, gin.H{"error": "Internal server error"})
 			c.Abort()
 			return
 		}
 
-		// Verify tenant has Pro or Enterprise tier (API access)
-		if tenant.Tier != "enterprise" && tenant.Tier != "pro" {
-			logger.Warn("Non-Pro/Enterprise tenant attempted API access", "tenant_id", user.TenantID, "tier", tenant.Tier)
-			c.JSON(403, gin.H{
-				"error":            "API access requires Pro or Enterprise subscription",
-				"upgrade_required": true,
-			})
-			c.Abort()
+		// Verify tenant tier has API access, keys stay for an upgrade
+		if decision := m.entitlements.Feature(tenant, models.FeatureAPIAccess); !decision.Allowed {
+			logger.Warn("API access denied", "tenant_id", tenant.ID, "tier", tenant.Tier, "reason", decision.Reason)
+			abortDenied(c, decision)
 			return
 		}
 
 		// Verify tenant is active
-		if tenant.Status != "active" {
-			logger.Warn("Inactive tenant attempted API access", "tenant_id", user.TenantID, "status", tenant.Status)
+		if tenant.Status != models.TenantStatusActive {
+			logger.Warn("Inactive tenant attempted API access", "tenant_id", tenant.ID, "status", tenant.Status)
 			c.JSON(403, gin.H{"error": "Tenant account is not active"})
 			c.Abort()
 			return
 		}
 
+		scope := requiredAPIKeyScope(c.Request.Method, c.FullPath())
+		if scope == "" {
+			c.JSON(403, gin.H{"error": "This endpoint can't be called with an API key"})
+			c.Abort()
+			return
+		}
+		if !key.HasScope(scope) {
+			logger.Debug("API key missing scope", "prefix", key.Prefix, "scope", scope)
+			c.JSON(403, gin.H{
+				"error":          "API key is missing the scope " + scope,
+				"required_scope": scope,
+			})
+			c.Abort()
+			return
+		}
+
+		if err := m.apiKeyRepo.TouchLastUsed(c.Request.Context(), key.ID, c.ClientIP()); err != nil {
+			logger.Error("Failed to update API key usage", "prefix", key.Prefix, "error", err.Error())
+		}
+
 		// Set user and tenant in context
+		c.Set("api_key", key)
 		c.Set("user", user)
 		c.Set("user_id", user.ID)
+		c.Set("user_email", user.Email)
+		c.Set("user_role", string(user.Role))
 		c.Set("tenant", tenant)
-		c.Set("tenant_id", user.TenantID)
+		c.Set("tenant_id", tenant.ID)
 
-		logger.Debug("API key authenticated successfully", "user_id", user.ID, "tenant_id", user.TenantID)
+		logger.Debug("API key authenticated successfully", "user_id", user.ID, "tenant_id", tenant.ID, "prefix", key.Prefix)
 
 		c.Next()
 	}
@@ -110,24 +191,26 @@ func (m *APIKeyAuthMiddleware) Authenticate() gin.HandlerFunc {
 // OptionalAPIKey allows both JWT and API key authentication
 // This middleware tries API key first, then falls back to JWT
 func (m *APIKeyAuthMiddleware) OptionalAPIKey(authMiddleware *AuthMiddleware) gin.HandlerFunc {
-	return func(c *gin.Context) {
-		authHeader := c.GetHeader("Authorization")
+	authenticate := m.Authenticate()
+	requireAuth := authMiddleware.RequireAuth()
 
-		// If no Authorization header, skip API key auth
-		if authHeader == "" {
-			c.Next()
+	return func(c *gin.Context) {
+		if extractAPIKey(c) != "" {
+			authenticate(c)
 			return
 		}
 
-		// Check if it's an API key (starts with "Bearer sk_")
-		parts := strings.Split(authHeader, " ")
-		if len(parts) == 2 && parts[0] == "Bearer" && strings.HasPrefix(parts[1], "sk_") {
-			// Use API key authentication
-			m.Authenticate()(c)
-			return
-		}
+		// Otherwise, use JWT authentication (header or cookie)
+		requireAuth(c)
+	}
+}
 
-		// Otherwise, use JWT authentication
-		authMiddleware.RequireAuth()(c)
+// GetAPIKey helper to retrieve the API key of a request, false for JWT authentication
+func GetAPIKey(c *gin.Context) (*models.APIKey, bool) {
+	key, exists := c.Get("api_key")
+	if !exists {
+		return nil, false
 	}
+	apiKey, ok := key.(*models.APIKey)
+	return apiKey, ok
 }
@@ -72,6 +72,16 @@ func (am *AuthMiddleware) RequireAuth() gin.HandlerFunc {
 			return
 		}
 
+		// Refresh tokens are only accepted by the refresh endpoint
+		if claims.Type == utils.TokenTypeRefresh {
+			logger.Debug("Refresh token used as access token", "user_id", claims.UserID, "source", tokenSource)
+			c.JSON(http.StatusUnauthorized, gin.H{
+				"error": "Invalid or expired token",
+			})
+			c.Abort()
+			return
+		}
+
 		// Check if token is blacklisted
 		if am.tokenBlacklist != nil {
 			// Check specific token (by JTI)
@@ -93,6 +103,16 @@ func (am *AuthMiddleware) RequireAuth() gin.HandlerFunc {
 				c.Abort()
 				return
 			}
+
+			// Check if the session was revoked (logout, other device)
+			if am.tokenBlacklist.IsSessionRevoked(c.Request.Context(), claims.SessionID) {
+				logger.Debug("Session revoked", "session_id", claims.SessionID, "user_id", claims.UserID)
+				c.JSON(http.StatusUnauthorized, gin.H{
+					"error": "Token has been revoked",
+				})
+				c.Abort()
+				return
+			}
 		}
 
 		// Store claims in context
@@ -101,6 +121,7 @@ func (am *AuthMiddleware) RequireAuth() gin.HandlerFunc {
 		c.Set("tenant_id", claims.TenantID)
 		c.Set("user_email", claims.Email)
 		c.Set("user_role", claims.Role)
+		c.Set("session_id", claims.SessionID)
 		c.Set("token_source", tokenSource)
 
 		logger.Debug("User authenticated", "user_id", claims.UserID, "email", claims.Email, "source", tokenSource)
@@ -135,7 +156,7 @@ func (am *AuthMiddleware) OptionalAuth() gin.HandlerFunc {
 		}
 
 		claims, err := utils.ValidateToken(tokenString, am.jwtSecret)
-		if err != nil {
+		if err != nil || claims.Type == utils.TokenTypeRefresh {
 			c.Next()
 			return
 		}
@@ -150,6 +171,10 @@ func (am *AuthMiddleware) OptionalAuth() gin.HandlerFunc {
 				c.Next()
 				return
 			}
+			if am.tokenBlacklist.IsSessionRevoked(c.Request.Context(), claims.SessionID) {
+				c.Next()
+				return
+			}
 		}
 
 		c.Set("jwt_claims", claims)
@@ -157,6 +182,7 @@ func (am *AuthMiddleware) OptionalAuth() gin.HandlerFunc {
 		c.Set("tenant_id", claims.TenantID)
 		c.Set("user_email", claims.Email)
 		c.Set("user_role", claims.Role)
+		c.Set("session_id", claims.SessionID)
 
 		c.Next()
 	}
@@ -191,8 +217,9 @@ func RequireRole(allowedRoles ...models.UserRole) gin.HandlerFunc {
 	}
 }
 
-// RequirePermission middleware checks if user has specific permission
-func RequirePermission(permission string) gin.HandlerFunc {
+// RequirePermission middleware checks if the built-in role of the user has a
+// permission, PermissionMiddleware also resolves custom roles
+func RequirePermission(permission models.Permission) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		userRole, exists := c.Get("user_role")
 		if !exists {
@@ -206,47 +233,12 @@ func RequirePermission(permission string) gin.HandlerFunc {
 		role := models.UserRole(userRole.(string))
 		if !role.HasPermission(permission) {
 			c.JSON(http.StatusForbidden, gin.H{
-				"error": "Insufficient permissions for action: " + permission,
-			})
-			c.Abort()
-			return
-		}
-
-		c.Next()
-	}
-}
-
-// APIKeyAuth middleware authenticates using API key (Enterprise only)
-func (am *AuthMiddleware) APIKeyAuth() gin.HandlerFunc {
-	return func(c *gin.Context) {
-		apiKey := c.GetHeader("X-API-Key")
-		if apiKey == "" {
-			c.JSON(http.StatusUnauthorized, gin.H{
-				"error": "API key required. Use X-API-Key header.",
-			})
-			c.Abort()
-			return
-		}
-
-		// Retrieve user by API key
-		user, err := am.userRepo.GetByAPIKey(c.Request.Context(), apiKey)
-		if err != nil {
-			logger.Debug("Invalid API key", "error", err.Error())
-			c.JSON(http.StatusUnauthorized, gin.H{
-				"error": "Invalid API key",
+				"error": "Insufficient permissions for action: " + string(permission),
 			})
 			c.Abort()
 			return
 		}
 
-		// Store user info in context
-		c.Set("user_id", user.ID)
-		c.Set("tenant_id", user.TenantID)
-		c.Set("user_email", user.Email)
-		c.Set("user_role", string(user.Role))
-
-		logger.Debug("API key authenticated", "user_id", user.ID, "email", user.Email)
-
 		c.Next()
 	}
 }
@@ -261,6 +253,14 @@ func GetUserID(c *gin.Context) (int64, bool) {
 	return id, ok
 }
 
+// GetSessionID helper to retrieve the login session ID from context, empty
+// for API keys and tokens without a session
+func GetSessionID(c *gin.Context) string {
+	sessionID, _ := c.Get("session_id")
+	id, _ := sessionID.(string)
+	return id
+}
+
 // GetUserRole helper to retrieve user role from context
 func GetUserRole(c *gin.Context) (models.UserRole, bool) {
 	userRole, exists := c.Get("user_role")
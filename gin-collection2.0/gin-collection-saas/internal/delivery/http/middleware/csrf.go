@@ -86,6 +86,12 @@ func (m *CSRFMiddleware) ValidateToken() gin.HandlerFunc {
 			return
 		}
 
+		// API keys are sent by clients, not browsers, there is no cookie to ride on
+		if _, isAPIKey := GetAPIKey(c); isAPIKey {
+			c.Next()
+			return
+		}
+
 		// Get token from header
 		headerToken := c.GetHeader(csrfHeaderName)
 		if headerToken == "" {
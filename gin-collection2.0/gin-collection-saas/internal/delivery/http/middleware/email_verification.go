@@ -0,0 +1,78 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/yourusername/gin-collection-saas/internal/domain/errors"
+	"github.com/yourusername/gin-collection-saas/internal/domain/models"
+	"github.com/yourusername/gin-collection-saas/internal/domain/repositories"
+	"github.com/yourusername/gin-collection-saas/pkg/logger"
+)
+
+// EmailVerificationMiddleware restricts users with an unverified email on
+// the configured tiers
+type EmailVerificationMiddleware struct {
+	userRepo repositories.UserRepository
+	tiers    map[models.SubscriptionTier]bool
+}
+
+// NewEmailVerificationMiddleware creates a new email verification middleware.
+// Without tiers nobody is restricted.
+func NewEmailVerificationMiddleware(userRepo repositories.UserRepository, tiers []string) *EmailVerificationMiddleware {
+	m := &EmailVerificationMiddleware{
+		userRepo: userRepo,
+		tiers:    make(map[models.SubscriptionTier]bool),
+	}
+	for _, tier := range tiers {
+		m.tiers[models.SubscriptionTier(tier)] = true
+	}
+	return m
+}
+
+// RequireVerifiedEmail rejects state-changing requests of unverified users
+// on the restricted tiers. Reading stays possible so the collection remains
+// accessible until the address is verified.
+func (m *EmailVerificationMiddleware) RequireVerifiedEmail() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		method := c.Request.Method
+		if len(m.tiers) == 0 || method == "GET" || method == "HEAD" || method == "OPTIONS" {
+			c.Next()
+			return
+		}
+
+		tenant, ok := GetTenant(c)
+		if !ok || !m.tiers[tenant.Tier] {
+			c.Next()
+			return
+		}
+
+		// API key requests already carry the user
+		current, ok := getUser(c)
+		if !ok {
+			userID, _ := GetUserID(c)
+			loaded, err := m.userRepo.GetByID(c.Request.Context(), userID)
+			if err != nil {
+				logger.Error("Failed to get user for email verification check", "user_id", userID, "error", err.Error())
+				c.JSON(http.StatusUnauthorized, gin.H{
+					"error": "User not found",
+				})
+				c.Abort()
+				return
+			}
+			current = loaded
+		}
+
+		if !current.IsEmailVerified() {
+			logger.Debug("Unverified user blocked", "user_id", current.ID, "tenant_id", tenant.ID, "tier", tenant.Tier)
+			c.JSON(http.StatusForbidden, gin.H{
+				"error":                       errors.ErrEmailNotVerified.Error(),
+				"email_verification_required": true,
+			})
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
@@ -0,0 +1,257 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/yourusername/gin-collection-saas/internal/domain/errors"
+	"github.com/yourusername/gin-collection-saas/internal/domain/models"
+	"github.com/yourusername/gin-collection-saas/internal/domain/repositories"
+	"github.com/yourusername/gin-collection-saas/pkg/logger"
+)
+
+// routePermissions maps every route behind Authorize ("METHOD /path" as
+// registered in the router) to the permission it requires
+var routePermissions = map[string]models.Permission{
+	// Tenant
+	"GET /api/v1/tenants/current":               models.PermissionTenantRead,
+	"GET /api/v1/tenants/usage":                 models.PermissionTenantRead,
+	"GET /api/v1/tenants/usage/dashboard":       models.PermissionTenantRead,
+	"PUT /api/v1/tenants/current":               models.PermissionTenantManage,
+	"PUT /api/v1/tenants/current/security":      models.PermissionTenantManage,
+	"GET /api/v1/tenants/current/sso":           models.PermissionTenantManage,
+	"PUT /api/v1/tenants/current/sso":           models.PermissionTenantManage,
+	"GET /api/v1/tenants/current/scim":          models.PermissionTenantManage,
+	"POST /api/v1/tenants/current/scim/token":   models.PermissionTenantManage,
+	"DELETE /api/v1/tenants/current/scim/token": models.PermissionTenantManage,
+
+	// Subscriptions
+	"GET /api/v1/subscriptions/current":             models.PermissionTenantRead,
+	"GET /api/v1/subscriptions/plans":               models.PermissionTenantRead,
+	"POST /api/v1/subscriptions/upgrade":            models.PermissionBillingManage,
+	"POST /api/v1/subscriptions/trial":              models.PermissionBillingManage,
+	"GET /api/v1/subscriptions/coupons/:code":       models.PermissionBillingManage,
+	"POST /api/v1/subscriptions/activate":           models.PermissionBillingManage,
+	"POST /api/v1/subscriptions/change-plan":        models.PermissionBillingManage,
+	"GET /api/v1/subscriptions/change-plan/preview": models.PermissionTenantRead,
+	"DELETE /api/v1/subscriptions/scheduled-change": models.PermissionBillingManage,
+	"POST /api/v1/subscriptions/cancel":             models.PermissionBillingManage,
+	"POST /api/v1/subscriptions/pause":              models.PermissionBillingManage,
+	"POST /api/v1/subscriptions/resume":             models.PermissionBillingManage,
+	"POST /api/v1/subscriptions/billing-cycle":      models.PermissionBillingManage,
+	"GET /api/v1/subscriptions/portal":              models.PermissionBillingManage,
+	"GET /api/v1/subscriptions/providers":           models.PermissionTenantRead,
+	"PUT /api/v1/subscriptions/providers":           models.PermissionBillingManage,
+
+	// Billing
+	"GET /api/v1/billing/invoices":         models.PermissionBillingManage,
+	"GET /api/v1/billing/invoices/:id":     models.PermissionBillingManage,
+	"GET /api/v1/billing/invoices/:id/pdf": models.PermissionBillingManage,
+	"GET /api/v1/billing/upcoming":         models.PermissionBillingManage,
+	"GET /api/v1/billing/details":          models.PermissionBillingManage,
+	"PUT /api/v1/billing/details":          models.PermissionBillingManage,
+
+	// Gins
+	"GET /api/v1/gins":                 models.PermissionGinsRead,
+	"GET /api/v1/gins/search":          models.PermissionGinsRead,
+	"GET /api/v1/gins/stats":           models.PermissionGinsRead,
+	"GET /api/v1/gins/:id":             models.PermissionGinsRead,
+	"POST /api/v1/gins":                models.PermissionGinsWrite,
+	"PUT /api/v1/gins/:id":             models.PermissionGinsWrite,
+	"DELETE /api/v1/gins/:id":          models.PermissionGinsDelete,
+	"POST /api/v1/gins/export":         models.PermissionGinsExport,
+	"POST /api/v1/gins/import":         models.PermissionGinsImport,
+	"POST /api/v1/gins/scan-label":     models.PermissionAIUse,
+	"GET /api/v1/gins/:id/suggestions": models.PermissionAIUse,
+
+	"GET /api/v1/gins/:id/botanicals":                models.PermissionGinsRead,
+	"PUT /api/v1/gins/:id/botanicals":                models.PermissionGinsWrite,
+	"GET /api/v1/gins/:id/cocktails":                 models.PermissionGinsRead,
+	"POST /api/v1/gins/:id/cocktails/:cocktail_id":   models.PermissionGinsWrite,
+	"DELETE /api/v1/gins/:id/cocktails/:cocktail_id": models.PermissionGinsWrite,
+
+	// Photos
+	"GET /api/v1/gins/:id/photos":                   models.PermissionGinsRead,
+	"GET /api/v1/photos":                            models.PermissionGinsRead,
+	"POST /api/v1/gins/:id/photos":                  models.PermissionPhotosWrite,
+	"POST /api/v1/gins/:id/photos/upload-url":       models.PermissionPhotosWrite,
+	"POST /api/v1/gins/:id/photos/complete":         models.PermissionPhotosWrite,
+	"PUT /api/v1/gins/:id/photos/order":             models.PermissionPhotosWrite,
+	"PATCH /api/v1/gins/:id/photos/:photo_id":       models.PermissionPhotosWrite,
+	"PUT /api/v1/gins/:id/photos/:photo_id/primary": models.PermissionPhotosWrite,
+	"DELETE /api/v1/gins/:id/photos/:photo_id":      models.PermissionPhotosDelete,
+
+	// Tastings
+	"GET /api/v1/gins/:id/tastings":                models.PermissionGinsRead,
+	"GET /api/v1/gins/:id/tastings/:session_id":    models.PermissionGinsRead,
+	"GET /api/v1/tastings/recent":                  models.PermissionGinsRead,
+	"POST /api/v1/gins/:id/tastings":               models.PermissionTastingsWrite,
+	"PUT /api/v1/gins/:id/tastings/:session_id":    models.PermissionTastingsWrite,
+	"DELETE /api/v1/gins/:id/tastings/:session_id": models.PermissionTastingsDelete,
+
+	// Reference data
+	"GET /api/v1/botanicals":                      models.PermissionGinsRead,
+	"GET /api/v1/cocktails":                       models.PermissionGinsRead,
+	"GET /api/v1/cocktails/:id":                   models.PermissionGinsRead,
+	"GET /api/v1/gin-references":                  models.PermissionGinsRead,
+	"GET /api/v1/gin-references/filters":          models.PermissionGinsRead,
+	"GET /api/v1/gin-references/barcode/:barcode": models.PermissionGinsRead,
+	"GET /api/v1/gin-references/:id":              models.PermissionGinsRead,
+
+	// Users
+	"GET /api/v1/users":                         models.PermissionUsersManage,
+	"POST /api/v1/users/invite":                 models.PermissionUsersManage,
+	"GET /api/v1/users/invites":                 models.PermissionUsersManage,
+	"POST /api/v1/users/:id/invite/resend":      models.PermissionUsersManage,
+	"DELETE /api/v1/users/:id/invite":           models.PermissionUsersManage,
+	"PUT /api/v1/users/:id":                     models.PermissionUsersManage,
+	"DELETE /api/v1/users/:id":                  models.PermissionUsersManage,
+	"POST /api/v1/users/:id/unlock":             models.PermissionUsersManage,
+	"GET /api/v1/users/:id/api-keys":            models.PermissionUsersManage,
+	"DELETE /api/v1/users/:id/api-keys/:key_id": models.PermissionUsersManage,
+
+	// Roles
+	"GET /api/v1/roles":                         models.PermissionUsersManage,
+	"POST /api/v1/roles":                        models.PermissionRolesManage,
+	"PUT /api/v1/roles/:id":                     models.PermissionRolesManage,
+	"DELETE /api/v1/roles/:id":                  models.PermissionRolesManage,
+	"PUT /api/v1/roles/:id/members/:user_id":    models.PermissionRolesManage,
+	"DELETE /api/v1/roles/:id/members/:user_id": models.PermissionRolesManage,
+
+	// API keys of the current user
+	"GET /api/v1/api-keys":        models.PermissionAPIKeysManage,
+	"POST /api/v1/api-keys":       models.PermissionAPIKeysManage,
+	"DELETE /api/v1/api-keys/:id": models.PermissionAPIKeysManage,
+
+	// AI
+	"GET /api/v1/ai/status":       models.PermissionGinsRead,
+	"POST /api/v1/ai/suggest-gin": models.PermissionAIUse,
+}
+
+// RoutePermission returns the permission a route requires, false if the
+// route is not in the registry
+func RoutePermission(method, route string) (models.Permission, bool) {
+	permission, ok := routePermissions[method+" "+route]
+	return permission, ok
+}
+
+// PermissionMiddleware enforces the permission registry, resolving the
+// custom roles of Enterprise tenants
+type PermissionMiddleware struct {
+	userRepo repositories.UserRepository
+	roleRepo repositories.RoleRepository
+}
+
+// NewPermissionMiddleware creates a new permission middleware
+func NewPermissionMiddleware(userRepo repositories.UserRepository, roleRepo repositories.RoleRepository) *PermissionMiddleware {
+	return &PermissionMiddleware{
+		userRepo: userRepo,
+		roleRepo: roleRepo,
+	}
+}
+
+// Authorize checks the user has the permission of the route. Routes missing
+// from the registry are denied, so every new route has to be registered.
+func (m *PermissionMiddleware) Authorize() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		permission, ok := RoutePermission(c.Request.Method, c.FullPath())
+		if !ok {
+			logger.Error("Route has no permission in the registry", "method", c.Request.Method, "route", c.FullPath())
+			c.JSON(http.StatusForbidden, gin.H{
+				"error": "Insufficient permissions",
+			})
+			c.Abort()
+			return
+		}
+
+		permissions, err := m.permissions(c)
+		if err == errors.ErrUnauthorized || err == errors.ErrNotFound || err == errors.ErrUserNotInTenant {
+			c.JSON(http.StatusUnauthorized, gin.H{
+				"error": "User not found",
+			})
+			c.Abort()
+			return
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": "Internal server error",
+			})
+			c.Abort()
+			return
+		}
+
+		if !permissions.Has(permission) {
+			c.JSON(http.StatusForbidden, gin.H{
+				"error":               "Insufficient permissions for action: " + string(permission),
+				"required_permission": permission,
+			})
+			c.Abort()
+			return
+		}
+
+		c.Set("permissions", permissions)
+		c.Next()
+	}
+}
+
+// permissions resolves the permissions of the authenticated user. Custom
+// roles only exist for tenants with multiple users, for the others the role
+// of the token is enough.
+func (m *PermissionMiddleware) permissions(c *gin.Context) (models.PermissionSet, error) {
+	role, ok := GetUserRole(c)
+	if !ok {
+		return nil, errors.ErrUnauthorized
+	}
+
+	tenant, ok := GetTenant(c)
+	if !ok || !tenant.GetLimits().HasMultiUser {
+		return role.Permissions(), nil
+	}
+
+	// API key requests already carry the user
+	current, ok := getUser(c)
+	if !ok {
+		userID, _ := GetUserID(c)
+		loaded, err := m.userRepo.GetByID(c.Request.Context(), userID)
+		if err != nil {
+			logger.Error("Failed to get user for permission check", "user_id", userID, "error", err.Error())
+			return nil, err
+		}
+		current = loaded
+	}
+	if current.TenantID != tenant.ID {
+		return nil, errors.ErrUserNotInTenant
+	}
+
+	var customRole *models.CustomRole
+	if current.CustomRoleID != nil {
+		loaded, err := m.roleRepo.GetByID(c.Request.Context(), tenant.ID, *current.CustomRoleID)
+		if err != nil && err != errors.ErrNotFound {
+			logger.Error("Failed to get custom role", "role_id", *current.CustomRoleID, "error", err.Error())
+			return nil, err
+		}
+		customRole = loaded
+	}
+
+	return models.EffectivePermissions(current.Role, customRole), nil
+}
+
+// getUser returns the user set by the API key authentication
+func getUser(c *gin.Context) (*models.User, bool) {
+	user, exists := c.Get("user")
+	if !exists {
+		return nil, false
+	}
+	u, ok := user.(*models.User)
+	return u, ok
+}
+
+// GetPermissions helper to retrieve the permissions of the user from context
+func GetPermissions(c *gin.Context) (models.PermissionSet, bool) {
+	permissions, exists := c.Get("permissions")
+	if !exists {
+		return nil, false
+	}
+	p, ok := permissions.(models.PermissionSet)
+	return p, ok
+}
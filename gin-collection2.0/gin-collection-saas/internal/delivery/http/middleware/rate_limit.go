@@ -133,8 +133,8 @@ func (m *RateLimitMiddleware) RateLimitByTenant() gin.HandlerFunc {
 			return
 		}
 
-		// Get rate limit for tenant's tier
-		limits := models.PlanLimitsMap[tenant.Tier]
+		// Get rate limit for tenant's plan
+		limits := tenant.GetLimits()
 		rateLimit := limits.APIRateLimit
 
 		// Skip rate limiting for tiers without API access
@@ -210,6 +210,20 @@ func (m *RateLimitMiddleware) RateLimitByTenant() gin.HandlerFunc {
 	}
 }
 
+// RateLimitByAPIKey enforces the hourly limit of API keys that have one, on
+// top of the limit of the tenant
+func (m *RateLimitMiddleware) RateLimitByAPIKey() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key, ok := GetAPIKey(c)
+		if !ok || key.RateLimit == nil {
+			c.Next()
+			return
+		}
+
+		m.rateLimitByIPHourly(c, "apikey", key.UUID, *key.RateLimit)
+	}
+}
+
 // RateLimitByIP enforces rate limits by IP address (for unauthenticated endpoints)
 func (m *RateLimitMiddleware) RateLimitByIP(requestsPerMinute int) gin.HandlerFunc {
 	return func(c *gin.Context) {
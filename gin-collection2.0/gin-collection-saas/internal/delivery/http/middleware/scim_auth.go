@@ -0,0 +1,89 @@
+package middleware
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/yourusername/gin-collection-saas/internal/domain/models"
+	"github.com/yourusername/gin-collection-saas/internal/domain/repositories"
+	"github.com/yourusername/gin-collection-saas/internal/usecase/entitlement"
+	"github.com/yourusername/gin-collection-saas/pkg/logger"
+	"github.com/yourusername/gin-collection-saas/pkg/utils"
+)
+
+// SCIMAuthMiddleware authenticates identity providers with the tenant-scoped
+// SCIM bearer token (Enterprise)
+type SCIMAuthMiddleware struct {
+	scimRepo     repositories.SCIMRepository
+	tenantRepo   repositories.TenantRepository
+	entitlements *entitlement.Service
+}
+
+// NewSCIMAuthMiddleware creates a new SCIM authentication middleware
+func NewSCIMAuthMiddleware(scimRepo repositories.SCIMRepository, tenantRepo repositories.TenantRepository, entitlements *entitlement.Service) *SCIMAuthMiddleware {
+	return &SCIMAuthMiddleware{
+		scimRepo:     scimRepo,
+		tenantRepo:   tenantRepo,
+		entitlements: entitlements,
+	}
+}
+
+// Authenticate validates the SCIM token and sets the tenant context. Errors
+// use the SCIM error format identity providers expect.
+func (m *SCIMAuthMiddleware) Authenticate() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		authHeader := c.GetHeader("Authorization")
+		parts := strings.SplitN(authHeader, " ", 2)
+		if len(parts) != 2 || !strings.EqualFold(parts[0], "Bearer") || !strings.HasPrefix(parts[1], models.SCIMTokenPrefix) {
+			abortSCIM(c, http.StatusUnauthorized, "SCIM token required - provide Authorization: Bearer scim_xxx")
+			return
+		}
+
+		tokenHash := utils.HashToken(parts[1])
+		token, err := m.scimRepo.GetTokenByHash(c.Request.Context(), tokenHash)
+		if err != nil || subtle.ConstantTimeCompare([]byte(token.TokenHash), []byte(tokenHash)) != 1 {
+			logger.Debug("Invalid SCIM token attempt", "ip", c.ClientIP())
+			abortSCIM(c, http.StatusUnauthorized, "Invalid SCIM token")
+			return
+		}
+
+		tenant, err := m.tenantRepo.GetByID(c.Request.Context(), token.TenantID)
+		if err != nil {
+			logger.Error("Failed to get tenant for SCIM auth", "tenant_id", token.TenantID, "error", err.Error())
+			abortSCIM(c, http.StatusInternalServerError, "Internal server error")
+			return
+		}
+
+		if tenant.Status != models.TenantStatusActive {
+			logger.Warn("Inactive tenant attempted SCIM access", "tenant_id", tenant.ID, "status", tenant.Status)
+			abortSCIM(c, http.StatusForbidden, "Tenant account is not active")
+			return
+		}
+
+		// Provisioning stops after a downgrade, the token stays for an upgrade.
+		// It keeps working while a payment is overdue, so users can still be
+		// deprovisioned.
+		if !m.entitlements.Included(tenant, models.FeatureSCIM) {
+			logger.Warn("Non-Enterprise tenant attempted SCIM access", "tenant_id", tenant.ID, "tier", tenant.Tier)
+			abortSCIM(c, http.StatusForbidden, "SCIM provisioning requires Enterprise subscription")
+			return
+		}
+
+		if err := m.scimRepo.TouchToken(c.Request.Context(), token.ID); err != nil {
+			logger.Error("Failed to update SCIM token usage", "token_id", token.ID, "error", err.Error())
+		}
+
+		c.Set("tenant", tenant)
+		c.Set("tenant_id", tenant.ID)
+
+		c.Next()
+	}
+}
+
+// abortSCIM ends the request with a SCIM error
+func abortSCIM(c *gin.Context, status int, detail string) {
+	c.Header("Content-Type", models.SCIMContentType)
+	c.AbortWithStatusJSON(status, models.NewSCIMError(status, "", detail))
+}
@@ -29,8 +29,14 @@ func (tm *TenantMiddleware) ExtractTenant() gin.HandlerFunc {
 		var tenant *models.Tenant
 		var err error
 
+		// 0. API keys belong to the tenant set by the API key middleware, a
+		// subdomain can't point them at another tenant
+		if _, isAPIKey := GetAPIKey(c); isAPIKey {
+			tenant, _ = GetTenant(c)
+		}
+
 		// 1. Try to extract from X-Tenant-Subdomain header (for localhost development)
-		if headerSubdomain := c.GetHeader("X-Tenant-Subdomain"); headerSubdomain != "" {
+		if headerSubdomain := c.GetHeader("X-Tenant-Subdomain"); tenant == nil && headerSubdomain != "" {
 			tenant, err = tm.tenantRepo.GetBySubdomain(c.Request.Context(), headerSubdomain)
 			if err != nil {
 				logger.Debug("Tenant not found by header subdomain", "subdomain", headerSubdomain, "error", err.Error())
@@ -131,8 +137,14 @@ func (tm *TenantMiddleware) OptionalTenant() gin.HandlerFunc {
 		var tenant *models.Tenant
 		var err error
 
+		// 0. API keys belong to the tenant set by the API key middleware, a
+		// subdomain can't point them at another tenant
+		if _, isAPIKey := GetAPIKey(c); isAPIKey {
+			tenant, _ = GetTenant(c)
+		}
+
 		// 1. Try to extract from X-Tenant-Subdomain header (for localhost development)
-		if headerSubdomain := c.GetHeader("X-Tenant-Subdomain"); headerSubdomain != "" {
+		if headerSubdomain := c.GetHeader("X-Tenant-Subdomain"); tenant == nil && headerSubdomain != "" {
 			tenant, err = tm.tenantRepo.GetBySubdomain(c.Request.Context(), headerSubdomain)
 			if err != nil {
 				logger.Debug("Tenant not found by header subdomain", "subdomain", headerSubdomain, "error", err.Error())
@@ -4,26 +4,31 @@ import (
 	"net/http"
 
 	"github.com/gin-gonic/gin"
+	"github.com/yourusername/gin-collection-saas/internal/delivery/http/response"
 	"github.com/yourusername/gin-collection-saas/internal/domain/models"
 	"github.com/yourusername/gin-collection-saas/internal/domain/repositories"
+	"github.com/yourusername/gin-collection-saas/internal/usecase/entitlement"
 	"github.com/yourusername/gin-collection-saas/pkg/logger"
 )
 
-// TierEnforcementMiddleware enforces subscription tier limits
+// TierEnforcementMiddleware enforces the entitlements of the tenant's plan on
+// routes. Tenants with a payment overdue past the grace period are held to
+// the Free limits.
 type TierEnforcementMiddleware struct {
-	usageRepo repositories.UsageMetricsRepository
-	ginRepo   repositories.GinRepository
+	entitlements *entitlement.Service
+	ginRepo      repositories.GinRepository
 }
 
 // NewTierEnforcementMiddleware creates a new tier enforcement middleware
-func NewTierEnforcementMiddleware(usageRepo repositories.UsageMetricsRepository, ginRepo repositories.GinRepository) *TierEnforcementMiddleware {
+func NewTierEnforcementMiddleware(entitlements *entitlement.Service, ginRepo repositories.GinRepository) *TierEnforcementMiddleware {
 	return &TierEnforcementMiddleware{
-		usageRepo: usageRepo,
-		ginRepo:   ginRepo,
+		entitlements: entitlements,
+		ginRepo:      ginRepo,
 	}
 }
 
-// RequireFeature checks if a feature is available in the current tier
+// RequireFeature checks if a feature is available in the current tier.
+// Unknown features are denied.
 func (tem *TierEnforcementMiddleware) RequireFeature(feature string) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		tenant, ok := GetTenant(c)
@@ -35,46 +40,9 @@ func (tem *TierEnforcementMiddleware) RequireFeature(feature string) gin.Handler
 			return
 		}
 
-		limits := tenant.GetLimits()
-
-		var hasFeature bool
-		var featureName string
-
-		switch feature {
-		case "botanicals":
-			hasFeature = limits.HasBotanicals
-			featureName = "Botanicals tracking"
-		case "cocktails":
-			hasFeature = limits.HasCocktails
-			featureName = "Cocktail recipes"
-		case "ai_suggestions":
-			hasFeature = limits.HasAISuggestions
-			featureName = "AI suggestions"
-		case "export":
-			hasFeature = limits.HasExport
-			featureName = "Data export"
-		case "import":
-			hasFeature = limits.HasImport
-			featureName = "Data import"
-		case "multi_user":
-			hasFeature = limits.HasMultiUser
-			featureName = "Multi-user support"
-		case "api_access":
-			hasFeature = limits.HasAPIAccess
-			featureName = "API access"
-		default:
-			hasFeature = true // Unknown features are allowed by default
-		}
-
-		if !hasFeature {
-			logger.Debug("Feature not available", "tenant_id", tenant.ID, "tier", tenant.Tier, "feature", feature)
-			c.JSON(http.StatusForbidden, gin.H{
-				"error":            featureName + " requires " + getRequiredTier(feature) + " tier",
-				"upgrade_required": true,
-				"current_tier":     tenant.Tier,
-				"feature":          feature,
-			})
-			c.Abort()
+		if decision := tem.entitlements.Feature(tenant, feature); !decision.Allowed {
+			logger.Debug("Feature denied", "tenant_id", tenant.ID, "tier", tenant.Tier, "feature", feature, "reason", decision.Reason)
+			abortDenied(c, decision)
 			return
 		}
 
@@ -100,10 +68,8 @@ func (tem *TierEnforcementMiddleware) CheckGinLimit() gin.HandlerFunc {
 			return
 		}
 
-		limits := tenant.GetLimits()
-
 		// If unlimited, allow
-		if limits.MaxGins == nil {
+		if tenant.EnforcedLimits().MaxGins == nil {
 			c.Next()
 			return
 		}
@@ -119,17 +85,9 @@ func (tem *TierEnforcementMiddleware) CheckGinLimit() gin.HandlerFunc {
 			return
 		}
 
-		// Check if limit reached
-		if currentCount >= *limits.MaxGins {
-			logger.Debug("Gin limit reached", "tenant_id", tenant.ID, "current", currentCount, "limit", *limits.MaxGins)
-			c.JSON(http.StatusForbidden, gin.H{
-				"error":            "Gin limit reached. Please upgrade to add more gins.",
-				"upgrade_required": true,
-				"current_tier":     tenant.Tier,
-				"limit":            *limits.MaxGins,
-				"current_count":    currentCount,
-			})
-			c.Abort()
+		if decision := tem.entitlements.GinLimit(tenant, currentCount); !decision.Allowed {
+			logger.Debug("Gin limit reached", "tenant_id", tenant.ID, "current", currentCount, "limit", *decision.Limit)
+			abortDenied(c, decision)
 			return
 		}
 
@@ -137,40 +95,29 @@ func (tem *TierEnforcementMiddleware) CheckGinLimit() gin.HandlerFunc {
 	}
 }
 
-// CheckPhotoLimit checks if tenant can upload more photos for a gin
-func (tem *TierEnforcementMiddleware) CheckPhotoLimit(currentPhotoCount int) gin.HandlerFunc {
+// readOnlyAllowed are the write routes that stay available while a collection
+// is read-only, exporting doesn't change it
+var readOnlyAllowed = map[string]bool{
+	"/api/v1/gins/export": true,
+}
+
+// ReadOnlyOverLimit makes the collection read-only while the tenant has more
+// gins than its tier allows (e.g. after a downgrade, or while a payment is
+// overdue past the grace period). Nothing is deleted:
+// reading, exporting and deleting stay possible until the tenant is back
+// within the limit or upgrades.
+func (tem *TierEnforcementMiddleware) ReadOnlyOverLimit() gin.HandlerFunc {
 	return func(c *gin.Context) {
-		tenant, ok := GetTenant(c)
-		if !ok {
-			c.JSON(http.StatusUnauthorized, gin.H{
-				"error": "Tenant not found in context",
-			})
-			c.Abort()
+		switch c.Request.Method {
+		case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodDelete:
+			c.Next()
 			return
 		}
-
-		limits := tenant.GetLimits()
-
-		if currentPhotoCount >= limits.MaxPhotosPerGin {
-			logger.Debug("Photo limit reached", "tenant_id", tenant.ID, "current", currentPhotoCount, "limit", limits.MaxPhotosPerGin)
-			c.JSON(http.StatusForbidden, gin.H{
-				"error":            "Photo limit reached for this gin. Please upgrade to add more photos.",
-				"upgrade_required": true,
-				"current_tier":     tenant.Tier,
-				"limit":            limits.MaxPhotosPerGin,
-				"current_count":    currentPhotoCount,
-			})
-			c.Abort()
+		if readOnlyAllowed[c.FullPath()] {
+			c.Next()
 			return
 		}
 
-		c.Next()
-	}
-}
-
-// CheckStorageLimit checks if tenant has storage available
-func (tem *TierEnforcementMiddleware) CheckStorageLimit(fileSizeKB int) gin.HandlerFunc {
-	return func(c *gin.Context) {
 		tenant, ok := GetTenant(c)
 		if !ok {
 			c.JSON(http.StatusUnauthorized, gin.H{
@@ -180,39 +127,24 @@ func (tem *TierEnforcementMiddleware) CheckStorageLimit(fileSizeKB int) gin.Hand
 			return
 		}
 
-		limits := tenant.GetLimits()
-
-		// If unlimited storage, allow
-		if limits.StorageLimitMB == nil {
+		if tenant.EnforcedLimits().MaxGins == nil {
 			c.Next()
 			return
 		}
 
-		// Get current storage usage
-		currentStorageMB, err := tem.usageRepo.GetMetric(c.Request.Context(), tenant.ID, "storage_mb")
+		currentCount, err := tem.ginRepo.Count(c.Request.Context(), tenant.ID, nil)
 		if err != nil {
-			logger.Error("Failed to get storage usage", "error", err.Error())
+			logger.Error("Failed to get gin count", "error", err.Error())
 			c.JSON(http.StatusInternalServerError, gin.H{
-				"error": "Failed to check storage limit",
+				"error": "Failed to check gin limit",
 			})
 			c.Abort()
 			return
 		}
 
-		// Convert KB to MB
-		fileSizeMB := fileSizeKB / 1024
-
-		// Check if limit would be exceeded
-		if currentStorageMB+fileSizeMB > *limits.StorageLimitMB {
-			logger.Debug("Storage limit would be exceeded", "tenant_id", tenant.ID, "current", currentStorageMB, "limit", *limits.StorageLimitMB)
-			c.JSON(http.StatusForbidden, gin.H{
-				"error":            "Storage limit would be exceeded. Please upgrade for more storage.",
-				"upgrade_required": true,
-				"current_tier":     tenant.Tier,
-				"limit_mb":         *limits.StorageLimitMB,
-				"current_mb":       currentStorageMB,
-			})
-			c.Abort()
+		if decision := tem.entitlements.Collection(tenant, currentCount); !decision.Allowed {
+			logger.Debug("Collection is read-only over limit", "tenant_id", tenant.ID, "current", currentCount, "limit", *decision.Limit)
+			abortDenied(c, decision)
 			return
 		}
 
@@ -220,23 +152,8 @@ func (tem *TierEnforcementMiddleware) CheckStorageLimit(fileSizeKB int) gin.Hand
 	}
 }
 
-// getRequiredTier returns the minimum tier required for a feature
-func getRequiredTier(feature string) string {
-	switch feature {
-	case "botanicals", "cocktails", "ai_suggestions", "export", "import":
-		return "Pro"
-	case "multi_user", "api_access":
-		return "Enterprise"
-	default:
-		return "Pro"
-	}
-}
-
-// UpgradeResponse is the response structure for upgrade suggestions
-type UpgradeResponse struct {
-	Error           string                   `json:"error"`
-	UpgradeRequired bool                     `json:"upgrade_required"`
-	CurrentTier     models.SubscriptionTier  `json:"current_tier"`
-	RequiredTier    string                   `json:"required_tier"`
-	Feature         string                   `json:"feature"`
+// abortDenied responds with a denied entitlement and stops the request
+func abortDenied(c *gin.Context, decision *models.Entitlement) {
+	response.Denied(c, decision)
+	c.Abort()
 }
@@ -0,0 +1,75 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/yourusername/gin-collection-saas/internal/domain/errors"
+	"github.com/yourusername/gin-collection-saas/internal/domain/models"
+	"github.com/yourusername/gin-collection-saas/internal/usecase/entitlement"
+	"github.com/yourusername/gin-collection-saas/internal/usecase/usage"
+	"github.com/yourusername/gin-collection-saas/pkg/logger"
+)
+
+// UsageMeteringMiddleware counts metered usage per billing period and stops
+// requests once the tenant used up its limit for the period
+type UsageMeteringMiddleware struct {
+	usageService *usage.Service
+	entitlements *entitlement.Service
+}
+
+// NewUsageMeteringMiddleware creates a new usage metering middleware
+func NewUsageMeteringMiddleware(usageService *usage.Service, entitlements *entitlement.Service) *UsageMeteringMiddleware {
+	return &UsageMeteringMiddleware{
+		usageService: usageService,
+		entitlements: entitlements,
+	}
+}
+
+// MeterAPICalls counts the requests made with API keys, every request counts
+func (m *UsageMeteringMiddleware) MeterAPICalls() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if _, ok := GetAPIKey(c); !ok {
+			c.Next()
+			return
+		}
+
+		m.meter(c, models.MetricAPICalls, false)
+	}
+}
+
+// Meter counts the successful requests of a route as usage of a metric
+func (m *UsageMeteringMiddleware) Meter(metric string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		m.meter(c, metric, true)
+	}
+}
+
+// meter checks the limit of a metric before the request and records it after
+func (m *UsageMeteringMiddleware) meter(c *gin.Context, metric string, successOnly bool) {
+	tenant, ok := GetTenant(c)
+	if !ok {
+		c.Next()
+		return
+	}
+
+	meter, err := m.usageService.Check(c.Request.Context(), tenant, metric)
+	if err == errors.ErrUsageLimitReached {
+		logger.Debug("Usage limit reached", "tenant_id", tenant.ID, "metric", metric, "current", meter.Current, "limit", *meter.Limit)
+		abortDenied(c, m.entitlements.UsageLimit(tenant, meter))
+		return
+	}
+	if err != nil {
+		// Metering must not take the API down, the request is let through
+		logger.Error("Failed to check usage limit", "tenant_id", tenant.ID, "metric", metric, "error", err.Error())
+	}
+
+	c.Next()
+
+	if successOnly && c.Writer.Status() >= http.StatusBadRequest {
+		return
+	}
+	if err := m.usageService.Record(c.Request.Context(), tenant, metric, 1); err != nil {
+		logger.Error("Failed to record usage", "tenant_id", tenant.ID, "metric", metric, "error", err.Error())
+	}
+}
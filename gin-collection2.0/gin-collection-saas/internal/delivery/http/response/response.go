@@ -5,6 +5,7 @@ import (
 
 	"github.com/gin-gonic/gin"
 	domainErrors "github.com/yourusername/gin-collection-saas/internal/domain/errors"
+	"github.com/yourusername/gin-collection-saas/internal/domain/models"
 )
 
 // Success sends a success response
@@ -25,39 +26,70 @@ func Created(c *gin.Context, data interface{}) {
 
 // Error sends an error response based on the error type
 func Error(c *gin.Context, err error) {
+	if denial, ok := models.AsEntitlement(err); ok {
+		Denied(c, denial)
+		return
+	}
+
 	switch err {
-	case domainErrors.ErrNotFound, domainErrors.ErrGinNotFound, domainErrors.ErrTenantNotFound, domainErrors.ErrPhotoNotFound:
+	case domainErrors.ErrNotFound, domainErrors.ErrGinNotFound, domainErrors.ErrTenantNotFound, domainErrors.ErrPhotoNotFound,
+		domainErrors.ErrSSONotConfigured, domainErrors.ErrUserNotInTenant, domainErrors.ErrNoActiveSubscription,
+		domainErrors.ErrNoScheduledChange:
 		c.JSON(http.StatusNotFound, gin.H{
 			"success": false,
 			"error":   err.Error(),
 		})
-	case domainErrors.ErrUnauthorized, domainErrors.ErrInvalidCredentials, domainErrors.ErrInvalidToken:
+	case domainErrors.ErrUnauthorized, domainErrors.ErrInvalidCredentials, domainErrors.ErrInvalidToken, domainErrors.ErrInvalidTwoFactorCode,
+		domainErrors.ErrPasskeyVerification, domainErrors.ErrSSOVerification, domainErrors.ErrInvalidAPIKey,
+		domainErrors.ErrInvalidWebhookSignature:
 		c.JSON(http.StatusUnauthorized, gin.H{
 			"success": false,
 			"error":   err.Error(),
 		})
-	case domainErrors.ErrForbidden, domainErrors.ErrTenantSuspended:
+	case domainErrors.ErrForbidden, domainErrors.ErrTenantSuspended, domainErrors.ErrTwoFactorEnforced,
+		domainErrors.ErrPasswordLoginDisabled, domainErrors.ErrSSOUserNotProvisioned, domainErrors.ErrEmailNotVerified:
 		c.JSON(http.StatusForbidden, gin.H{
 			"success": false,
 			"error":   err.Error(),
 		})
-	case domainErrors.ErrLimitReached, domainErrors.ErrFeatureNotAvailable:
+	case domainErrors.ErrLimitReached, domainErrors.ErrFeatureNotAvailable, domainErrors.ErrPhotoLimitReached, domainErrors.ErrStorageLimitReached,
+		domainErrors.ErrAPIAccessNotAllowed, domainErrors.ErrOverLimit, domainErrors.ErrUsageLimitReached:
 		c.JSON(http.StatusForbidden, gin.H{
 			"success":          false,
 			"error":            err.Error(),
 			"upgrade_required": true,
 		})
-	case domainErrors.ErrConflict, domainErrors.ErrEmailAlreadyExists, domainErrors.ErrSubdomainTaken, domainErrors.ErrBarcodeAlreadyExists:
+	case domainErrors.ErrPaymentOverdue:
+		c.JSON(http.StatusPaymentRequired, gin.H{
+			"success":          false,
+			"error":            err.Error(),
+			"payment_required": true,
+		})
+	case domainErrors.ErrConflict, domainErrors.ErrEmailAlreadyExists, domainErrors.ErrSubdomainTaken, domainErrors.ErrBarcodeAlreadyExists,
+		domainErrors.ErrTwoFactorAlreadyEnabled, domainErrors.ErrRoleNameTaken, domainErrors.ErrRoleInUse,
+		domainErrors.ErrEmailAlreadyVerified, domainErrors.ErrCheckoutIncomplete, domainErrors.ErrSubscriptionInactive,
+		domainErrors.ErrPlanChangeRequired, domainErrors.ErrTrialNotAvailable, domainErrors.ErrCouponRedeemed,
+		domainErrors.ErrCouponCodeTaken, domainErrors.ErrPlanIDTaken, domainErrors.ErrPlanChangeScheduled,
+		domainErrors.ErrNotResumable:
 		c.JSON(http.StatusConflict, gin.H{
 			"success": false,
 			"error":   err.Error(),
 		})
-	case domainErrors.ErrInvalidInput, domainErrors.ErrInvalidRating, domainErrors.ErrInvalidFileType:
+	case domainErrors.ErrInvalidInput, domainErrors.ErrInvalidRating, domainErrors.ErrInvalidFileType,
+		domainErrors.ErrUploadExpired, domainErrors.ErrUploadMissing, domainErrors.ErrTokenExpired,
+		domainErrors.ErrTwoFactorNotEnabled, domainErrors.ErrUnknownBillingProvider, domainErrors.ErrPlanNotOffered,
+		domainErrors.ErrInvalidCoupon, domainErrors.ErrCouponNotApplicable, domainErrors.ErrInvalidVATID,
+		domainErrors.ErrPlanNotAvailable, domainErrors.ErrBillingCycleNotAvailable:
 		c.JSON(http.StatusBadRequest, gin.H{
 			"success": false,
 			"error":   err.Error(),
 		})
-	case domainErrors.ErrRateLimitExceeded:
+	case domainErrors.ErrFileTooLarge:
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{
+			"success": false,
+			"error":   err.Error(),
+		})
+	case domainErrors.ErrRateLimitExceeded, domainErrors.ErrAccountLocked:
 		c.JSON(http.StatusTooManyRequests, gin.H{
 			"success": false,
 			"error":   err.Error(),
@@ -70,6 +102,43 @@ func Error(c *gin.Context, err error) {
 	}
 }
 
+// Denied sends a denied entitlement with its reason and upgrade hint: 402 if
+// a payment is overdue, else 403
+func Denied(c *gin.Context, denial *models.Entitlement) {
+	status := http.StatusForbidden
+	if denial.Reason == models.DenialPaymentOverdue {
+		status = http.StatusPaymentRequired
+	}
+
+	body := gin.H{
+		"success":          false,
+		"error":            denial.Err().Error(),
+		"reason":           denial.Reason,
+		"feature":          denial.Feature,
+		"current_tier":     denial.CurrentTier,
+		"upgrade_required": denial.UpgradeRequired(),
+		"payment_required": denial.PaymentRequired,
+	}
+	if denial.RequiredTier != "" {
+		body["required_tier"] = denial.RequiredTier
+	}
+	if denial.UpgradePlanID != "" {
+		body["upgrade_plan_id"] = denial.UpgradePlanID
+	}
+	if denial.Limit != nil {
+		body["limit"] = *denial.Limit
+	}
+	if denial.Current != nil {
+		body["current_count"] = *denial.Current
+	}
+	if denial.Reason == models.DenialOverLimit {
+		body["over_limit"] = true
+		body["gins_over_limit"] = denial.OverLimit
+	}
+
+	c.JSON(status, body)
+}
+
 // ValidationError sends a validation error response
 func ValidationError(c *gin.Context, errs map[string]string) {
 	c.JSON(http.StatusBadRequest, gin.H{
@@ -11,7 +11,13 @@ import (
 type AdminRouterConfig struct {
 	AdminHandler        *adminHandler.Handler
 	ServerHandler       *adminHandler.ServerHandler
+	StorageHandler      *adminHandler.StorageHandler
+	WebhookHandler      *adminHandler.WebhookHandler
+	CouponHandler       *adminHandler.CouponHandler
+	PlanHandler         *adminHandler.PlanHandler
+	UsageHandler        *adminHandler.UsageHandler
 	PlatformAdminMiddle *middleware.PlatformAdminMiddleware
+	RateLimitMiddleware *middleware.RateLimitMiddleware
 	AllowedOrigins      []string
 }
 
@@ -24,6 +30,16 @@ func SetupAdminRoutes(r *gin.Engine, cfg *AdminRouterConfig) {
 		auth := adminAPI.Group("/auth")
 		{
 			auth.POST("/login", cfg.AdminHandler.Login)
+
+			// Second factor: 10 per hour per IP (codes have only 6 digits)
+			twoFactorMiddleware := []gin.HandlerFunc{}
+			if cfg.RateLimitMiddleware != nil {
+				twoFactorMiddleware = append(twoFactorMiddleware, cfg.RateLimitMiddleware.RateLimitByIPHourly(10))
+			}
+			auth.POST("/2fa/verify", append(twoFactorMiddleware, cfg.AdminHandler.VerifyTwoFactor)...)
+			auth.POST("/2fa/enroll", append(twoFactorMiddleware, cfg.AdminHandler.SetupTwoFactor)...)
+			auth.POST("/passkeys/login/begin", cfg.AdminHandler.BeginPasskeyLogin)
+			auth.POST("/passkeys/login/finish", cfg.AdminHandler.FinishPasskeyLogin)
 		}
 
 		// Protected admin routes
@@ -33,6 +49,12 @@ func SetupAdminRoutes(r *gin.Engine, cfg *AdminRouterConfig) {
 			// Auth
 			protected.GET("/auth/me", cfg.AdminHandler.Me)
 			protected.POST("/auth/change-password", cfg.AdminHandler.ChangePassword)
+			protected.GET("/auth/2fa", cfg.AdminHandler.GetTwoFactor)
+			protected.POST("/auth/2fa/recovery-codes", cfg.AdminHandler.RegenerateRecoveryCodes)
+			protected.GET("/auth/passkeys", cfg.AdminHandler.ListPasskeys)
+			protected.POST("/auth/passkeys/register/begin", cfg.AdminHandler.BeginPasskeyRegistration)
+			protected.POST("/auth/passkeys/register/finish", cfg.AdminHandler.FinishPasskeyRegistration)
+			protected.DELETE("/auth/passkeys/:id", cfg.AdminHandler.DeletePasskey)
 
 			// Statistics
 			protected.GET("/stats/overview", cfg.AdminHandler.GetStats)
@@ -45,6 +67,13 @@ func SetupAdminRoutes(r *gin.Engine, cfg *AdminRouterConfig) {
 				tenants.POST("/:id/suspend", cfg.AdminHandler.SuspendTenant)
 				tenants.POST("/:id/activate", cfg.AdminHandler.ActivateTenant)
 				tenants.PUT("/:id/tier", cfg.AdminHandler.UpdateTenantTier)
+
+				// Metered usage and limits that differ from the plan
+				if cfg.UsageHandler != nil {
+					tenants.GET("/:id/usage", cfg.UsageHandler.GetTenantUsage)
+					tenants.PUT("/:id/limits", cfg.UsageHandler.UpdateTenantLimits)
+					tenants.DELETE("/:id/limits", cfg.UsageHandler.DeleteTenantLimits)
+				}
 			}
 
 			// Users
@@ -56,6 +85,50 @@ func SetupAdminRoutes(r *gin.Engine, cfg *AdminRouterConfig) {
 			// Health
 			protected.GET("/health", cfg.AdminHandler.GetHealth)
 
+			// Storage migration and reconciliation
+			if cfg.StorageHandler != nil {
+				storage := protected.Group("/storage")
+				{
+					storage.GET("/backends", cfg.StorageHandler.ListBackends)
+					storage.POST("/migrate", cfg.StorageHandler.Migrate)
+					storage.GET("/migrations/:id", cfg.StorageHandler.GetMigration)
+					storage.POST("/reconcile", cfg.StorageHandler.Reconcile)
+				}
+			}
+
+			// Payment webhook events
+			if cfg.WebhookHandler != nil {
+				webhooks := protected.Group("/webhooks")
+				{
+					webhooks.GET("", cfg.WebhookHandler.ListEvents)
+					webhooks.GET("/:id", cfg.WebhookHandler.GetEvent)
+					webhooks.POST("/:id/replay", cfg.WebhookHandler.ReplayEvent)
+				}
+			}
+
+			// Coupons
+			if cfg.CouponHandler != nil {
+				coupons := protected.Group("/coupons")
+				{
+					coupons.GET("", cfg.CouponHandler.ListCoupons)
+					coupons.POST("", cfg.CouponHandler.CreateCoupon)
+					coupons.GET("/:id", cfg.CouponHandler.GetCoupon)
+					coupons.PUT("/:id", cfg.CouponHandler.UpdateCoupon)
+					coupons.DELETE("/:id", cfg.CouponHandler.DeleteCoupon)
+				}
+			}
+
+			// Plan catalog
+			if cfg.PlanHandler != nil {
+				plans := protected.Group("/plans")
+				{
+					plans.GET("", cfg.PlanHandler.ListPlans)
+					plans.POST("", cfg.PlanHandler.CreatePlan)
+					plans.GET("/:id", cfg.PlanHandler.GetPlan)
+					plans.PUT("/:id", cfg.PlanHandler.UpdatePlan)
+				}
+			}
+
 			// Server Management (only if ServerHandler is configured)
 			if cfg.ServerHandler != nil {
 				server := protected.Group("/server")
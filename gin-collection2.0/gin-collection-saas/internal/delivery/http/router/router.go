@@ -14,6 +14,7 @@ type RouterConfig struct {
 	GinHandler           *handler.GinHandler
 	GinReferenceHandler  *handler.GinReferenceHandler
 	SubscriptionHandler  *handler.SubscriptionHandler
+	BillingHandler       *handler.BillingHandler
 	WebhookHandler       *handler.WebhookHandler
 	BotanicalHandler     *handler.BotanicalHandler
 	CocktailHandler      *handler.CocktailHandler
@@ -21,12 +22,23 @@ type RouterConfig struct {
 	UserHandler          *handler.UserHandler
 	TenantHandler        *handler.TenantHandler
 	AIHandler            *handler.AIHandler
+	LabelScanHandler     *handler.LabelScanHandler
 	TastingHandler       *handler.TastingHandler
+	SSOHandler           *handler.SSOHandler
+	SCIMHandler          *handler.SCIMHandler
+	APIKeyHandler        *handler.APIKeyHandler
+	RoleHandler          *handler.RoleHandler
+	UsageHandler         *handler.UsageHandler
 	AuthMiddleware       *middleware.AuthMiddleware
 	TenantMiddleware     *middleware.TenantMiddleware
 	TierEnforcement      *middleware.TierEnforcementMiddleware
 	RateLimitMiddleware  *middleware.RateLimitMiddleware
 	CSRFMiddleware       *middleware.CSRFMiddleware
+	SCIMAuthMiddleware   *middleware.SCIMAuthMiddleware
+	APIKeyAuthMiddleware *middleware.APIKeyAuthMiddleware
+	PermissionMiddleware *middleware.PermissionMiddleware
+	EmailVerification    *middleware.EmailVerificationMiddleware
+	UsageMetering        *middleware.UsageMeteringMiddleware
 	AllowedOrigins       []string
 }
 
@@ -75,6 +87,24 @@ func Setup(cfg *RouterConfig) *gin.Engine {
 			}
 			auth.POST("/login", append(loginMiddleware, cfg.AuthHandler.Login)...)
 
+			// Passkey login, same tenant resolution and rate limit as password login
+			auth.POST("/passkeys/login/begin", append(loginMiddleware, cfg.AuthHandler.BeginPasskeyLogin)...)
+			auth.POST("/passkeys/login/finish", append(loginMiddleware, cfg.AuthHandler.FinishPasskeyLogin)...)
+
+			// Single sign-on (Enterprise), the browser is redirected through these
+			ssoMiddleware := []gin.HandlerFunc{}
+			if cfg.RateLimitMiddleware != nil {
+				ssoMiddleware = append(ssoMiddleware, cfg.RateLimitMiddleware.RateLimitByIPHourly(60))
+			}
+			sso := auth.Group("/sso/:subdomain")
+			{
+				sso.GET("", cfg.SSOHandler.GetLoginInfo)
+				sso.GET("/login", append(ssoMiddleware, cfg.SSOHandler.Login)...)
+				sso.GET("/oidc/callback", append(ssoMiddleware, cfg.SSOHandler.OIDCCallback)...)
+				sso.POST("/saml/acs", append(ssoMiddleware, cfg.SSOHandler.SAMLACS)...)
+				sso.GET("/saml/metadata", cfg.SSOHandler.SAMLMetadata)
+			}
+
 			// Refresh token
 			auth.POST("/refresh", cfg.AuthHandler.RefreshToken)
 
@@ -86,11 +116,22 @@ func Setup(cfg *RouterConfig) *gin.Engine {
 				auth.POST("/reset-password", cfg.RateLimitMiddleware.RateLimitByIPHourly(3), cfg.AuthHandler.ResetPassword)
 				// Validate reset token: 3 per hour per IP
 				auth.GET("/validate-reset-token", cfg.RateLimitMiddleware.RateLimitByIPHourly(3), cfg.AuthHandler.ValidateResetToken)
+				// Accept invite: 10 per hour per IP
+				auth.POST("/accept-invite", cfg.RateLimitMiddleware.RateLimitByIPHourly(10), cfg.AuthHandler.AcceptInvite)
+				// Second factor: 20 per hour per IP (codes have only 6 digits)
+				auth.POST("/2fa/verify", cfg.RateLimitMiddleware.RateLimitByIPHourly(20), cfg.AuthHandler.VerifyTwoFactor)
+				auth.POST("/2fa/enroll", cfg.RateLimitMiddleware.RateLimitByIPHourly(20), cfg.AuthHandler.SetupTwoFactorChallenge)
+				// Email verification links: 10 per hour per IP
+				auth.POST("/verify-email", cfg.RateLimitMiddleware.RateLimitByIPHourly(10), cfg.AuthHandler.VerifyEmail)
 			} else {
 				// Fallback without rate limiting
 				auth.POST("/forgot-password", cfg.AuthHandler.ForgotPassword)
 				auth.POST("/reset-password", cfg.AuthHandler.ResetPassword)
 				auth.GET("/validate-reset-token", cfg.AuthHandler.ValidateResetToken)
+				auth.POST("/accept-invite", cfg.AuthHandler.AcceptInvite)
+				auth.POST("/2fa/verify", cfg.AuthHandler.VerifyTwoFactor)
+				auth.POST("/2fa/enroll", cfg.AuthHandler.SetupTwoFactorChallenge)
+				auth.POST("/verify-email", cfg.AuthHandler.VerifyEmail)
 			}
 
 			// Logout (requires auth)
@@ -104,29 +145,79 @@ func Setup(cfg *RouterConfig) *gin.Engine {
 				authProtected.GET("/me", cfg.AuthHandler.GetMe)
 				authProtected.PUT("/profile", cfg.AuthHandler.UpdateProfile)
 				authProtected.POST("/change-password", cfg.AuthHandler.ChangePassword)
+
+				// Email verification and change (sending mails: 5 per hour per IP)
+				mailMiddleware := []gin.HandlerFunc{}
+				if cfg.RateLimitMiddleware != nil {
+					mailMiddleware = append(mailMiddleware, cfg.RateLimitMiddleware.RateLimitByIPHourly(5))
+				}
+				authProtected.POST("/verify-email/resend", append(mailMiddleware, cfg.AuthHandler.ResendEmailVerification)...)
+				authProtected.GET("/email-change", cfg.AuthHandler.GetEmailChange)
+				authProtected.POST("/email-change", append(mailMiddleware, cfg.AuthHandler.RequestEmailChange)...)
+				authProtected.DELETE("/email-change", cfg.AuthHandler.CancelEmailChange)
+
+				// Two-factor authentication
+				authProtected.GET("/2fa", cfg.AuthHandler.GetTwoFactor)
+				authProtected.POST("/2fa/setup", cfg.AuthHandler.SetupTwoFactor)
+				authProtected.POST("/2fa/confirm", cfg.AuthHandler.ConfirmTwoFactor)
+				authProtected.POST("/2fa/recovery-codes", cfg.AuthHandler.RegenerateRecoveryCodes)
+				authProtected.DELETE("/2fa", cfg.AuthHandler.DisableTwoFactor)
+				authProtected.DELETE("/2fa/devices", cfg.AuthHandler.ForgetTrustedDevices)
+
+				// Passkeys
+				authProtected.GET("/passkeys", cfg.AuthHandler.ListPasskeys)
+				authProtected.POST("/passkeys/register/begin", cfg.AuthHandler.BeginPasskeyRegistration)
+				authProtected.POST("/passkeys/register/finish", cfg.AuthHandler.FinishPasskeyRegistration)
+				authProtected.DELETE("/passkeys/:id", cfg.AuthHandler.DeletePasskey)
+
+				// Sessions
+				authProtected.GET("/sessions", cfg.AuthHandler.ListSessions)
+				authProtected.DELETE("/sessions", cfg.AuthHandler.RevokeOtherSessions)
+				authProtected.DELETE("/sessions/:id", cfg.AuthHandler.RevokeSession)
 			}
 		}
 
-		// Protected routes (require tenant + auth + rate limiting + CSRF)
+		// Protected routes (require tenant + auth + rate limiting + CSRF + permission)
 		// Note: Auth middleware must run before Tenant middleware so JWT claims are available
+		// API keys (Pro/Enterprise) are accepted for the routes of their scopes
+		// Every route needs an entry in the permission registry (middleware/permission.go)
+		// Unverified users of restricted tiers can only read
 		protected := v1.Group("")
-		protected.Use(cfg.AuthMiddleware.RequireAuth())
+		protected.Use(cfg.APIKeyAuthMiddleware.OptionalAPIKey(cfg.AuthMiddleware))
 		protected.Use(cfg.TenantMiddleware.ExtractTenant())
 		// Apply rate limiting if available
 		if cfg.RateLimitMiddleware != nil {
 			protected.Use(cfg.RateLimitMiddleware.RateLimitByTenant())
+			protected.Use(cfg.RateLimitMiddleware.RateLimitByAPIKey())
 		}
 		// Apply CSRF validation for state-changing requests
 		if cfg.CSRFMiddleware != nil {
 			protected.Use(cfg.CSRFMiddleware.ValidateToken())
 		}
+		protected.Use(cfg.EmailVerification.RequireVerifiedEmail())
+		protected.Use(cfg.PermissionMiddleware.Authorize())
+		// API calls and AI suggestions are metered per billing period
+		aiMiddleware := []gin.HandlerFunc{}
+		if cfg.UsageMetering != nil {
+			protected.Use(cfg.UsageMetering.MeterAPICalls())
+			aiMiddleware = append(aiMiddleware, cfg.UsageMetering.Meter(models.MetricAISuggestions))
+		}
 		{
 			// Tenants
 			tenants := protected.Group("/tenants")
 			{
 				tenants.GET("/current", cfg.TenantHandler.GetCurrent)
 				tenants.PUT("/current", cfg.TenantHandler.UpdateCurrent)
+				tenants.PUT("/current/security", cfg.TenantHandler.UpdateSecurity)
+				tenants.GET("/current/sso", cfg.SSOHandler.GetSettings)
+				tenants.PUT("/current/sso", cfg.SSOHandler.UpdateSettings)
+				tenants.GET("/current/scim", cfg.SCIMHandler.GetStatus)
+				tenants.POST("/current/scim/token", cfg.SCIMHandler.GenerateToken)
+				tenants.DELETE("/current/scim/token", cfg.SCIMHandler.RevokeToken)
 				tenants.GET("/usage", cfg.TenantHandler.GetUsage)
+				if cfg.UsageHandler != nil {
+					tenants.GET("/usage/dashboard", cfg.UsageHandler.Dashboard)
+				}
 			}
 
 			// Subscriptions
@@ -135,36 +226,64 @@ func Setup(cfg *RouterConfig) *gin.Engine {
 				subscriptions.GET("/current", cfg.SubscriptionHandler.GetCurrent)
 				subscriptions.GET("/plans", cfg.SubscriptionHandler.GetPlans)
 				subscriptions.POST("/upgrade", cfg.SubscriptionHandler.Upgrade)
+				subscriptions.POST("/trial", cfg.SubscriptionHandler.StartTrial)
+				subscriptions.GET("/coupons/:code", cfg.SubscriptionHandler.PreviewCoupon)
 				subscriptions.POST("/activate", cfg.SubscriptionHandler.Activate)
-				subscriptions.POST("/cancel", middleware.RequirePermission("manage_billing"), cfg.SubscriptionHandler.Cancel)
+				subscriptions.POST("/change-plan", cfg.SubscriptionHandler.ChangePlan)
+				subscriptions.GET("/change-plan/preview", cfg.SubscriptionHandler.PreviewPlanChange)
+				subscriptions.DELETE("/scheduled-change", cfg.SubscriptionHandler.CancelScheduledChange)
+				subscriptions.POST("/cancel", cfg.SubscriptionHandler.Cancel)
+				subscriptions.POST("/pause", cfg.SubscriptionHandler.Pause)
+				subscriptions.POST("/resume", cfg.SubscriptionHandler.Resume)
+				subscriptions.POST("/billing-cycle", cfg.SubscriptionHandler.ChangeBillingCycle)
+				subscriptions.GET("/portal", cfg.SubscriptionHandler.Portal)
+				subscriptions.GET("/providers", cfg.SubscriptionHandler.GetProviders)
+				subscriptions.PUT("/providers", cfg.SubscriptionHandler.SelectProvider)
+			}
+
+			// Billing history and details
+			billing := protected.Group("/billing")
+			{
+				billing.GET("/invoices", cfg.BillingHandler.ListInvoices)
+				billing.GET("/invoices/:id", cfg.BillingHandler.GetInvoice)
+				billing.GET("/invoices/:id/pdf", cfg.BillingHandler.DownloadInvoice)
+				billing.GET("/upcoming", cfg.BillingHandler.UpcomingCharges)
+				billing.GET("/details", cfg.BillingHandler.GetDetails)
+				billing.PUT("/details", cfg.BillingHandler.UpdateDetails)
 			}
 
-			// Gins
+			// Gins (read-only while the collection exceeds the tier's limits)
 			gins := protected.Group("/gins")
+			gins.Use(cfg.TierEnforcement.ReadOnlyOverLimit())
 			{
 				gins.GET("", cfg.GinHandler.List)
 				gins.POST("", cfg.TierEnforcement.CheckGinLimit(), cfg.GinHandler.Create)
 				gins.GET("/search", cfg.GinHandler.Search)
 				gins.GET("/stats", cfg.GinHandler.Stats)
-				gins.POST("/export", cfg.TierEnforcement.RequireFeature("export"), cfg.GinHandler.Export)
-				gins.POST("/import", cfg.TierEnforcement.RequireFeature("import"), cfg.GinHandler.Import)
+				gins.POST("/export", cfg.TierEnforcement.RequireFeature(models.FeatureExport), cfg.GinHandler.Export)
+				gins.POST("/import", cfg.TierEnforcement.RequireFeature(models.FeatureImport), cfg.GinHandler.Import)
+				gins.POST("/scan-label", append(aiMiddleware, cfg.TierEnforcement.RequireFeature(models.FeatureAISuggestions), middleware.LimitImageUpload(), cfg.LabelScanHandler.ScanLabel)...)
 				gins.GET("/:id", cfg.GinHandler.Get)
 				gins.PUT("/:id", cfg.GinHandler.Update)
-				gins.DELETE("/:id", middleware.RequirePermission("delete"), cfg.GinHandler.Delete)
-				gins.GET("/:id/suggestions", cfg.TierEnforcement.RequireFeature("ai_suggestions"), cfg.GinHandler.Suggestions)
+				gins.DELETE("/:id", cfg.GinHandler.Delete)
+				gins.GET("/:id/suggestions", append(aiMiddleware, cfg.TierEnforcement.RequireFeature(models.FeatureAISuggestions), cfg.GinHandler.Suggestions)...)
 
 				// Gin Botanicals (Pro+ feature)
-				gins.GET("/:id/botanicals", cfg.TierEnforcement.RequireFeature("botanicals"), cfg.BotanicalHandler.GetGinBotanicals)
-				gins.PUT("/:id/botanicals", cfg.TierEnforcement.RequireFeature("botanicals"), cfg.BotanicalHandler.UpdateGinBotanicals)
+				gins.GET("/:id/botanicals", cfg.TierEnforcement.RequireFeature(models.FeatureBotanicals), cfg.BotanicalHandler.GetGinBotanicals)
+				gins.PUT("/:id/botanicals", cfg.TierEnforcement.RequireFeature(models.FeatureBotanicals), cfg.BotanicalHandler.UpdateGinBotanicals)
 
 				// Gin Cocktails (Pro+ feature)
-				gins.GET("/:id/cocktails", cfg.TierEnforcement.RequireFeature("cocktails"), cfg.CocktailHandler.GetGinCocktails)
-				gins.POST("/:id/cocktails/:cocktail_id", cfg.TierEnforcement.RequireFeature("cocktails"), cfg.CocktailHandler.LinkCocktail)
-				gins.DELETE("/:id/cocktails/:cocktail_id", cfg.TierEnforcement.RequireFeature("cocktails"), cfg.CocktailHandler.UnlinkCocktail)
+				gins.GET("/:id/cocktails", cfg.TierEnforcement.RequireFeature(models.FeatureCocktails), cfg.CocktailHandler.GetGinCocktails)
+				gins.POST("/:id/cocktails/:cocktail_id", cfg.TierEnforcement.RequireFeature(models.FeatureCocktails), cfg.CocktailHandler.LinkCocktail)
+				gins.DELETE("/:id/cocktails/:cocktail_id", cfg.TierEnforcement.RequireFeature(models.FeatureCocktails), cfg.CocktailHandler.UnlinkCocktail)
 
 				// Gin Photos (upload has 50MB size limit)
 				gins.GET("/:id/photos", cfg.PhotoHandler.GetPhotos)
 				gins.POST("/:id/photos", middleware.LimitImageUpload(), cfg.PhotoHandler.Upload)
+				gins.POST("/:id/photos/upload-url", cfg.PhotoHandler.CreateUploadURL)
+				gins.POST("/:id/photos/complete", cfg.PhotoHandler.CompleteUpload)
+				gins.PUT("/:id/photos/order", cfg.PhotoHandler.Reorder)
+				gins.PATCH("/:id/photos/:photo_id", cfg.PhotoHandler.Update)
 				gins.DELETE("/:id/photos/:photo_id", cfg.PhotoHandler.Delete)
 				gins.PUT("/:id/photos/:photo_id/primary", cfg.PhotoHandler.SetPrimary)
 
@@ -195,29 +314,58 @@ func Setup(cfg *RouterConfig) *gin.Engine {
 				tastings.GET("/recent", cfg.TastingHandler.GetRecentSessions)
 			}
 
+			// Photos (gallery across all gins)
+			photos := protected.Group("/photos")
+			{
+				photos.GET("", cfg.PhotoHandler.Gallery)
+			}
+
 			// Users (Enterprise only)
 			users := protected.Group("/users")
-			users.Use(middleware.RequireRole(models.RoleOwner, models.RoleAdmin))
 			{
 				users.GET("", cfg.UserHandler.List)
 				users.POST("/invite", cfg.UserHandler.Invite)
+				users.GET("/invites", cfg.UserHandler.ListInvites)
+				users.POST("/:id/invite/resend", cfg.UserHandler.ResendInvite)
+				users.DELETE("/:id/invite", cfg.UserHandler.RevokeInvite)
 				users.PUT("/:id", cfg.UserHandler.Update)
 				users.DELETE("/:id", cfg.UserHandler.Delete)
-				users.POST("/:id/api-key", cfg.UserHandler.GenerateAPIKey)
-				users.DELETE("/:id/api-key", cfg.UserHandler.RevokeAPIKey)
+				users.POST("/:id/unlock", cfg.UserHandler.Unlock)
+				users.GET("/:id/api-keys", cfg.APIKeyHandler.ListForUser)
+				users.DELETE("/:id/api-keys/:key_id", cfg.APIKeyHandler.RevokeForUser)
+			}
+
+			// Roles and custom roles (custom roles are Enterprise only)
+			roles := protected.Group("/roles")
+			{
+				roles.GET("", cfg.RoleHandler.List)
+				roles.POST("", cfg.RoleHandler.Create)
+				roles.PUT("/:id", cfg.RoleHandler.Update)
+				roles.DELETE("/:id", cfg.RoleHandler.Delete)
+				roles.PUT("/:id/members/:user_id", cfg.RoleHandler.AssignUser)
+				roles.DELETE("/:id/members/:user_id", cfg.RoleHandler.UnassignUser)
+			}
+
+			// API keys of the current user
+			apiKeys := protected.Group("/api-keys")
+			{
+				apiKeys.GET("", cfg.APIKeyHandler.List)
+				apiKeys.POST("", cfg.APIKeyHandler.Create)
+				apiKeys.DELETE("/:id", cfg.APIKeyHandler.Revoke)
 			}
 
 			// AI routes (requires auth)
 			ai := protected.Group("/ai")
 			{
 				ai.GET("/status", cfg.AIHandler.Status)
-				ai.POST("/suggest-gin", cfg.AIHandler.SuggestGinInfo)
+				ai.POST("/suggest-gin", append(aiMiddleware, cfg.AIHandler.SuggestGinInfo)...)
 			}
 		}
 
 		// Gin References (public catalog, requires auth but no tenant)
 		ginRefs := v1.Group("/gin-references")
-		ginRefs.Use(cfg.AuthMiddleware.RequireAuth())
+		ginRefs.Use(cfg.APIKeyAuthMiddleware.OptionalAPIKey(cfg.AuthMiddleware))
+		ginRefs.Use(cfg.PermissionMiddleware.Authorize())
 		{
 			ginRefs.GET("", cfg.GinReferenceHandler.Search)
 			ginRefs.GET("/filters", cfg.GinReferenceHandler.GetFilters)
@@ -225,10 +373,38 @@ func Setup(cfg *RouterConfig) *gin.Engine {
 			ginRefs.GET("/:id", cfg.GinReferenceHandler.GetByID)
 		}
 
+		// Signed direct uploads for local storage (no auth, validated by signature)
+		v1.PUT("/uploads/*key", middleware.LimitImageUpload(), cfg.PhotoHandler.ReceiveUpload)
+
+		// SCIM 2.0 provisioning (SCIM token, no CSRF)
+		scimV2 := v1.Group("/scim/v2")
+		scimV2.Use(cfg.SCIMAuthMiddleware.Authenticate())
+		if cfg.RateLimitMiddleware != nil {
+			scimV2.Use(cfg.RateLimitMiddleware.RateLimitByTenant())
+		}
+		{
+			scimV2.GET("/ServiceProviderConfig", cfg.SCIMHandler.ServiceProviderConfig)
+
+			scimV2.GET("/Users", cfg.SCIMHandler.ListUsers)
+			scimV2.POST("/Users", cfg.SCIMHandler.CreateUser)
+			scimV2.GET("/Users/:id", cfg.SCIMHandler.GetUser)
+			scimV2.PUT("/Users/:id", cfg.SCIMHandler.ReplaceUser)
+			scimV2.PATCH("/Users/:id", cfg.SCIMHandler.PatchUser)
+			scimV2.DELETE("/Users/:id", cfg.SCIMHandler.DeleteUser)
+
+			scimV2.GET("/Groups", cfg.SCIMHandler.ListGroups)
+			scimV2.POST("/Groups", cfg.SCIMHandler.CreateGroup)
+			scimV2.GET("/Groups/:id", cfg.SCIMHandler.GetGroup)
+			scimV2.PUT("/Groups/:id", cfg.SCIMHandler.ReplaceGroup)
+			scimV2.PATCH("/Groups/:id", cfg.SCIMHandler.PatchGroup)
+			scimV2.DELETE("/Groups/:id", cfg.SCIMHandler.DeleteGroup)
+		}
+
 		// Webhooks (no auth, validated by signature)
 		webhooks := v1.Group("/webhooks")
 		{
 			webhooks.POST("/paypal", cfg.WebhookHandler.PayPal)
+			webhooks.POST("/stripe", cfg.WebhookHandler.Stripe)
 		}
 	}
 
@@ -18,6 +18,19 @@ var (
 	ErrInvalidToken        = errors.New("invalid or expired token")
 	ErrTokenExpired        = errors.New("token has expired")
 	ErrEmailNotVerified    = errors.New("email not verified")
+	ErrEmailAlreadyVerified    = errors.New("email is already verified")
+	ErrInvalidTwoFactorCode    = errors.New("invalid two-factor code")
+	ErrTwoFactorNotEnabled     = errors.New("two-factor authentication is not enabled")
+	ErrTwoFactorAlreadyEnabled = errors.New("two-factor authentication is already enabled")
+	ErrTwoFactorEnforced       = errors.New("two-factor authentication is required by your organization")
+	ErrPasskeyVerification     = errors.New("passkey verification failed")
+	ErrPasswordLoginDisabled   = errors.New("password login is disabled, sign in with single sign-on")
+	ErrAccountLocked           = errors.New("account is temporarily locked after too many failed login attempts")
+
+	// Single sign-on errors (Enterprise)
+	ErrSSONotConfigured      = errors.New("single sign-on is not configured for this tenant")
+	ErrSSOVerification       = errors.New("single sign-on verification failed")
+	ErrSSOUserNotProvisioned = errors.New("no account exists for this single sign-on identity")
 
 	// Tenant errors
 	ErrTenantNotFound      = errors.New("tenant not found")
@@ -28,6 +41,27 @@ var (
 	ErrLimitReached        = errors.New("tier limit reached - upgrade required")
 	ErrFeatureNotAvailable = errors.New("feature not available in current tier")
 	ErrSubscriptionInactive = errors.New("subscription is not active")
+	ErrInvalidWebhookSignature = errors.New("invalid webhook signature")
+	ErrNoActiveSubscription = errors.New("no active subscription")
+	ErrUnknownBillingProvider = errors.New("unknown billing provider")
+	ErrPlanNotOffered = errors.New("plan is not offered by the billing provider")
+	ErrCheckoutIncomplete = errors.New("checkout has not been completed")
+	ErrNoScheduledChange = errors.New("no plan change is scheduled")
+	ErrPlanChangeRequired = errors.New("tenant already has an active subscription - change its plan instead")
+	ErrOverLimit = errors.New("your collection exceeds the limits of your plan and is read-only - delete gins or upgrade")
+	ErrTrialNotAvailable = errors.New("no trial is available - the plan has none or the tenant already had a trial")
+	ErrInvalidCoupon = errors.New("coupon code is invalid or has expired")
+	ErrCouponNotApplicable = errors.New("coupon cannot be applied to this plan or billing provider")
+	ErrCouponRedeemed = errors.New("coupon has already been redeemed")
+	ErrCouponCodeTaken = errors.New("a coupon with this code already exists")
+	ErrInvalidVATID = errors.New("VAT ID is not valid for the billing country")
+	ErrPaymentOverdue = errors.New("a payment is overdue - premium features are restricted until it's paid")
+	ErrPlanIDTaken = errors.New("a plan with this ID already exists")
+	ErrPlanNotAvailable = errors.New("plan is not available for new subscriptions")
+	ErrUsageLimitReached = errors.New("usage limit of the billing period reached - upgrade or wait for the next period")
+	ErrPlanChangeScheduled = errors.New("a plan change is scheduled - cancel it first")
+	ErrBillingCycleNotAvailable = errors.New("the plan is not offered in this billing cycle")
+	ErrNotResumable = errors.New("subscription is neither paused nor cancelled at the end of the period")
 
 	// Gin-specific errors
 	ErrGinNotFound         = errors.New("gin not found")
@@ -40,13 +74,17 @@ var (
 	ErrStorageLimitReached = errors.New("storage limit reached")
 	ErrInvalidFileType     = errors.New("invalid file type - only images allowed")
 	ErrFileTooLarge        = errors.New("file too large")
+	ErrUploadExpired       = errors.New("upload session has expired")
+	ErrUploadMissing       = errors.New("uploaded file not found in storage")
 
 	// Multi-user errors (Enterprise)
 	ErrMultiUserNotAllowed = errors.New("multi-user feature requires Enterprise tier")
 	ErrUserNotInTenant     = errors.New("user does not belong to this tenant")
+	ErrRoleNameTaken       = errors.New("a role with this name already exists")
+	ErrRoleInUse           = errors.New("role is still assigned to users")
 
-	// API errors (Enterprise)
-	ErrAPIAccessNotAllowed = errors.New("API access requires Enterprise tier")
+	// API errors (Pro and Enterprise)
+	ErrAPIAccessNotAllowed = errors.New("API access requires Pro or Enterprise tier")
 	ErrInvalidAPIKey       = errors.New("invalid API key")
 	ErrRateLimitExceeded   = errors.New("rate limit exceeded")
 )
@@ -0,0 +1,122 @@
+package models
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// API key settings
+const (
+	// APIKeyPrefix starts every API key
+	APIKeyPrefix = "sk_"
+	// APIKeyVisiblePrefixLength is the length of the start of a key that is
+	// stored in plaintext so users can tell their keys apart
+	APIKeyVisiblePrefixLength = 11
+	// MaxAPIKeysPerUser is the number of active keys a user can have
+	MaxAPIKeysPerUser = 20
+)
+
+// API key scopes, a key can only call the endpoints of its scopes
+const (
+	ScopeGinsRead      = "gins:read"
+	ScopeGinsWrite     = "gins:write"
+	ScopeTastingsRead  = "tastings:read"
+	ScopeTastingsWrite = "tastings:write"
+	ScopePhotosRead    = "photos:read"
+	ScopePhotosWrite   = "photos:write"
+	ScopeCatalogRead   = "catalog:read"
+)
+
+// APIKeyScopes lists all scopes an API key can be granted
+var APIKeyScopes = []string{
+	ScopeGinsRead,
+	ScopeGinsWrite,
+	ScopeTastingsRead,
+	ScopeTastingsWrite,
+	ScopePhotosRead,
+	ScopePhotosWrite,
+	ScopeCatalogRead,
+}
+
+// IsValidAPIKeyScope checks if a scope exists
+func IsValidAPIKeyScope(scope string) bool {
+	for _, s := range APIKeyScopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// APIKey is a named API key of a user. Only the hash of the key is stored,
+// the key itself is shown once when it is created.
+type APIKey struct {
+	ID         int64      `json:"-"`
+	UUID       string     `json:"id"`
+	TenantID   int64      `json:"-"`
+	UserID     int64      `json:"user_id"`
+	Name       string     `json:"name"`
+	Prefix     string     `json:"prefix"`
+	KeyHash    string     `json:"-"`
+	Scopes     []string   `json:"scopes"`
+	RateLimit  *int       `json:"rate_limit,omitempty"` // requests per hour, nil = plan limit only
+	ExpiresAt  *time.Time `json:"expires_at,omitempty"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+	LastUsedIP *string    `json:"last_used_ip,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+	RevokedAt  *time.Time `json:"-"`
+}
+
+// IsExpired checks if the key is past its expiry date
+func (k *APIKey) IsExpired() bool {
+	return k.ExpiresAt != nil && !time.Now().Before(*k.ExpiresAt)
+}
+
+// IsActive checks if the key can still be used
+func (k *APIKey) IsActive() bool {
+	return k.RevokedAt == nil && !k.IsExpired()
+}
+
+// HasScope checks if the key was granted a scope
+func (k *APIKey) HasScope(scope string) bool {
+	for _, s := range k.Scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// CreateAPIKeyRequest represents a request to create an API key
+type CreateAPIKeyRequest struct {
+	Name      string     `json:"name" binding:"required,max=100"`
+	Scopes    []string   `json:"scopes" binding:"required,min=1"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+	RateLimit *int       `json:"rate_limit,omitempty"`
+}
+
+// Validate checks the scopes, expiry and rate limit of the request
+func (r *CreateAPIKeyRequest) Validate() error {
+	if strings.TrimSpace(r.Name) == "" {
+		return fmt.Errorf("name is required")
+	}
+	for _, scope := range r.Scopes {
+		if !IsValidAPIKeyScope(scope) {
+			return fmt.Errorf("unknown scope %q, valid scopes are %s", scope, strings.Join(APIKeyScopes, ", "))
+		}
+	}
+	if r.ExpiresAt != nil && !r.ExpiresAt.After(time.Now()) {
+		return fmt.Errorf("expires_at must be in the future")
+	}
+	if r.RateLimit != nil && *r.RateLimit < 1 {
+		return fmt.Errorf("rate_limit must be at least 1 request per hour")
+	}
+	return nil
+}
+
+// CreateAPIKeyResponse is returned once when a key is created
+type CreateAPIKeyResponse struct {
+	APIKey *APIKey `json:"api_key"`
+	Key    string  `json:"key"`
+}
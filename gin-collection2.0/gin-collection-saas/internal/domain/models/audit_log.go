@@ -30,11 +30,22 @@ const (
 	AuditActionUpdateUser  AuditAction = "update_user"
 	AuditActionDeleteUser  AuditAction = "delete_user"
 	AuditActionInviteUser  AuditAction = "invite_user"
+	AuditActionResendInvite AuditAction = "resend_invite"
+	AuditActionRevokeInvite AuditAction = "revoke_invite"
+	AuditActionUnlockUser   AuditAction = "unlock_user"
+
+	// Role actions
+	AuditActionCreateRole   AuditAction = "create_role"
+	AuditActionUpdateRole   AuditAction = "update_role"
+	AuditActionDeleteRole   AuditAction = "delete_role"
+	AuditActionAssignRole   AuditAction = "assign_role"
+	AuditActionUnassignRole AuditAction = "unassign_role"
 
 	// Authentication actions
 	AuditActionLogin         AuditAction = "login"
 	AuditActionLogout        AuditAction = "logout"
 	AuditActionFailedLogin   AuditAction = "failed_login"
+	AuditActionNewDeviceLogin AuditAction = "new_device_login"
 	AuditActionGenerateAPIKey AuditAction = "generate_api_key"
 	AuditActionRevokeAPIKey   AuditAction = "revoke_api_key"
 
@@ -43,6 +54,16 @@ const (
 	AuditActionCancelSubscription    AuditAction = "cancel_subscription"
 	AuditActionActivateSubscription  AuditAction = "activate_subscription"
 
+	// Dunning actions, taken by the system
+	AuditActionDunningStarted    AuditAction = "dunning_started"
+	AuditActionDunningRestricted AuditAction = "dunning_restricted"
+	AuditActionDunningDowngraded AuditAction = "dunning_downgraded"
+	AuditActionDunningSuspended  AuditAction = "dunning_suspended"
+	AuditActionDunningRecovered  AuditAction = "dunning_recovered"
+
+	// Limit overrides, set by platform admins
+	AuditActionOverrideLimits AuditAction = "override_limits"
+
 	// Photo actions
 	AuditActionUploadPhoto AuditAction = "upload_photo"
 	AuditActionDeletePhoto AuditAction = "delete_photo"
@@ -63,4 +84,5 @@ const (
 	EntityTypeTenant       EntityType = "tenant"
 	EntityTypeBotanical    EntityType = "botanical"
 	EntityTypeCocktail     EntityType = "cocktail"
+	EntityTypeRole         EntityType = "role"
 )
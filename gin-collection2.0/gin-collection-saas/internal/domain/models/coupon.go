@@ -0,0 +1,147 @@
+package models
+
+import (
+	"math"
+	"strings"
+	"time"
+)
+
+// CouponDiscountType is how a coupon reduces the price
+type CouponDiscountType string
+
+const (
+	CouponDiscountPercent CouponDiscountType = "percent"
+	CouponDiscountFixed   CouponDiscountType = "fixed"
+)
+
+// CouponDuration is how long a coupon reduces the price of a subscription
+type CouponDuration string
+
+const (
+	CouponDurationOnce      CouponDuration = "once"      // first billing period
+	CouponDurationRepeating CouponDuration = "repeating" // DurationMonths from the start
+	CouponDurationForever   CouponDuration = "forever"
+)
+
+// Coupon is a discount code tenants apply when they subscribe to a plan
+type Coupon struct {
+	ID             int64              `json:"id"`
+	Code           string             `json:"code"`
+	Description    string             `json:"description,omitempty"`
+	DiscountType   CouponDiscountType `json:"discount_type"`
+	DiscountValue  float64            `json:"discount_value"` // percent or amount in Currency
+	Currency       string             `json:"currency"`
+	Duration       CouponDuration     `json:"duration"`
+	DurationMonths *int               `json:"duration_months,omitempty"`
+	MaxRedemptions *int               `json:"max_redemptions,omitempty"` // nil = unlimited
+	Redemptions    int                `json:"redemptions"`               // including the ones reserved by open checkouts
+	ExpiresAt      *time.Time         `json:"expires_at,omitempty"`
+	PlanIDs        []string           `json:"plan_ids"` // empty = all plans
+	Active         bool               `json:"active"`
+	CreatedBy      *int64             `json:"created_by,omitempty"` // platform admin
+	CreatedAt      time.Time          `json:"created_at"`
+	UpdatedAt      time.Time          `json:"updated_at"`
+}
+
+// NormalizeCouponCode returns the form coupon codes are stored and looked up in
+func NormalizeCouponCode(code string) string {
+	return strings.ToUpper(strings.TrimSpace(code))
+}
+
+// Redeemable reports whether the coupon is active and not expired
+func (c *Coupon) Redeemable(now time.Time) bool {
+	if !c.Active {
+		return false
+	}
+	return c.ExpiresAt == nil || now.Before(*c.ExpiresAt)
+}
+
+// LimitReached reports whether all redemptions of the coupon are redeemed
+// or reserved
+func (c *Coupon) LimitReached() bool {
+	return c.MaxRedemptions != nil && c.Redemptions >= *c.MaxRedemptions
+}
+
+// AppliesTo reports whether the coupon can be used for a plan
+func (c *Coupon) AppliesTo(planID string) bool {
+	if len(c.PlanIDs) == 0 {
+		return true
+	}
+	for _, id := range c.PlanIDs {
+		if id == planID {
+			return true
+		}
+	}
+	return false
+}
+
+// DiscountFor returns the discount on a price, never more than the price
+func (c *Coupon) DiscountFor(price float64) float64 {
+	discount := c.DiscountValue
+	if c.DiscountType == CouponDiscountPercent {
+		discount = price * c.DiscountValue / 100
+	}
+	discount = math.Round(discount*100) / 100
+	if discount > price {
+		return price
+	}
+	return discount
+}
+
+// DiscountEndsAt returns when the discount of a subscription started at the
+// given time ends, nil if it doesn't
+func (c *Coupon) DiscountEndsAt(start time.Time, cycle BillingCycle) *time.Time {
+	var end time.Time
+	switch c.Duration {
+	case CouponDurationOnce:
+		if cycle == BillingCycleYearly {
+			end = start.AddDate(1, 0, 0)
+		} else {
+			end = start.AddDate(0, 1, 0)
+		}
+	case CouponDurationRepeating:
+		months := 1
+		if c.DurationMonths != nil {
+			months = *c.DurationMonths
+		}
+		end = start.AddDate(0, months, 0)
+	default:
+		return nil
+	}
+	return &end
+}
+
+// CouponRedemptionStatus is the state of a coupon redemption
+type CouponRedemptionStatus string
+
+const (
+	CouponRedemptionPending  CouponRedemptionStatus = "pending" // reserved by an open checkout
+	CouponRedemptionRedeemed CouponRedemptionStatus = "redeemed"
+)
+
+// CouponRedemption records a tenant redeeming a coupon, each tenant can
+// redeem a coupon once
+type CouponRedemption struct {
+	ID             int64                  `json:"id"`
+	CouponID       int64                  `json:"coupon_id"`
+	TenantID       int64                  `json:"tenant_id"`
+	SubscriptionID *int64                 `json:"subscription_id,omitempty"`
+	Discount       float64                `json:"discount"`
+	Status         CouponRedemptionStatus `json:"status"`
+	ExpiresAt      *time.Time             `json:"expires_at,omitempty"` // when a pending redemption is released
+	CreatedAt      time.Time              `json:"created_at"`
+}
+
+// CouponPreview shows the price of a plan with a coupon applied
+type CouponPreview struct {
+	Code            string         `json:"code"`
+	Description     string         `json:"description,omitempty"`
+	PlanID          string         `json:"plan_id"`
+	BillingCycle    BillingCycle   `json:"billing_cycle"`
+	Price           float64        `json:"price"`
+	Discount        float64        `json:"discount"`
+	DiscountedPrice float64        `json:"discounted_price"`
+	Currency        string         `json:"currency"`
+	Duration        CouponDuration `json:"duration"`
+	DurationMonths  *int           `json:"duration_months,omitempty"`
+}
@@ -0,0 +1,73 @@
+package models
+
+import "time"
+
+// DunningStage is how far the collection of an overdue payment has gone
+type DunningStage string
+
+const (
+	DunningStageGrace      DunningStage = "grace"      // full access, the billing managers are reminded
+	DunningStageRestricted DunningStage = "restricted" // held to the Free limits until the payment is made
+	DunningStageDowngraded DunningStage = "downgraded" // the subscription was cancelled, the tenant is on Free
+	DunningStageSuspended  DunningStage = "suspended"  // the tenant was suspended
+)
+
+// Actions taken when an overdue payment isn't made in time
+const (
+	DunningActionDowngrade = "downgrade"
+	DunningActionSuspend   = "suspend"
+)
+
+// DunningPolicy is the timeline of dunning, counted from the failed payment
+type DunningPolicy struct {
+	GracePeriod  time.Duration // full access after the failed payment
+	ReminderDays []int         // days after the failed payment the billing managers are reminded, ascending
+	FinalAfter   time.Duration // the final action is taken this long after the failed payment
+	FinalAction  string        // downgrade or suspend
+}
+
+// Stage returns the stage of a subscription past due since a time
+func (p *DunningPolicy) Stage(pastDueSince, now time.Time) DunningStage {
+	elapsed := now.Sub(pastDueSince)
+	switch {
+	case elapsed >= p.FinalAfter:
+		if p.FinalAction == DunningActionSuspend {
+			return DunningStageSuspended
+		}
+		return DunningStageDowngraded
+	case elapsed >= p.GracePeriod:
+		return DunningStageRestricted
+	default:
+		return DunningStageGrace
+	}
+}
+
+// RemindersDue returns how many reminders of a subscription past due since a
+// time are due
+func (p *DunningPolicy) RemindersDue(pastDueSince, now time.Time) int {
+	due := 0
+	for _, day := range p.ReminderDays {
+		if !now.Before(pastDueSince.AddDate(0, 0, day)) {
+			due++
+		}
+	}
+	return due
+}
+
+// FinalActionAt returns when the final action is taken on a subscription
+// past due since a time
+func (p *DunningPolicy) FinalActionAt(pastDueSince time.Time) time.Time {
+	return pastDueSince.Add(p.FinalAfter)
+}
+
+// InDunning returns whether an overdue payment of the subscription is being
+// collected
+func (s *Subscription) InDunning() bool {
+	return s.DunningStage == DunningStageGrace || s.DunningStage == DunningStageRestricted
+}
+
+// PaymentOverdue returns whether the subscription has a payment that is still
+// unpaid: it's in dunning or its tenant was suspended for it
+func (s *Subscription) PaymentOverdue() bool {
+	return s.InDunning() || s.DunningStage == DunningStageSuspended
+}
@@ -0,0 +1,70 @@
+package models
+
+import "time"
+
+// EmailVerificationExpiry is the duration for which a verification link is valid
+const EmailVerificationExpiry = 48 * time.Hour
+
+// EmailChangeExpiry is the duration for which the links of an email change are valid
+const EmailChangeExpiry = 24 * time.Hour
+
+// EmailVerificationPurpose tells a verification of the current address from
+// an email change
+type EmailVerificationPurpose string
+
+const (
+	EmailVerificationPurposeVerify EmailVerificationPurpose = "verify"
+	EmailVerificationPurposeChange EmailVerificationPurpose = "change"
+)
+
+// EmailVerification is a pending confirmation of an email address. An email
+// change has a second token sent to the current address, the change is
+// applied once both addresses confirmed. Only SHA-256 hashes of the tokens
+// are stored.
+type EmailVerification struct {
+	ID             int64                    `json:"-"`
+	UserID         int64                    `json:"user_id"`
+	Purpose        EmailVerificationPurpose `json:"purpose"`
+	Email          string                   `json:"email"` // address to verify, the new address of a change
+	TokenHash      string                   `json:"-"`
+	OldTokenHash   *string                  `json:"-"` // change only, sent to the current address
+	ConfirmedAt    *time.Time               `json:"confirmed_at,omitempty"`
+	OldConfirmedAt *time.Time               `json:"old_confirmed_at,omitempty"`
+	ExpiresAt      time.Time                `json:"expires_at"`
+	CompletedAt    *time.Time               `json:"completed_at,omitempty"`
+	CreatedAt      time.Time                `json:"created_at"`
+}
+
+// IsExpired checks if the links have expired
+func (v *EmailVerification) IsExpired() bool {
+	return time.Now().After(v.ExpiresAt)
+}
+
+// IsCompleted checks if the address was verified or the change applied
+func (v *EmailVerification) IsCompleted() bool {
+	return v.CompletedAt != nil
+}
+
+// IsValid checks if the links can still be used
+func (v *EmailVerification) IsValid() bool {
+	return !v.IsExpired() && !v.IsCompleted()
+}
+
+// IsConfirmed checks if all required addresses have confirmed
+func (v *EmailVerification) IsConfirmed() bool {
+	if v.ConfirmedAt == nil {
+		return false
+	}
+	return v.Purpose != EmailVerificationPurposeChange || v.OldConfirmedAt != nil
+}
+
+// VerifyEmailRequest is the request for confirming an address
+type VerifyEmailRequest struct {
+	Token string `json:"token" binding:"required"`
+}
+
+// ChangeEmailRequest is the request for changing the email of the current user
+type ChangeEmailRequest struct {
+	NewEmail string `json:"new_email" binding:"required,email"`
+	Password string `json:"password" binding:"required"`
+}
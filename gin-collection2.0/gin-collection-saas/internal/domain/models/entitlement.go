@@ -0,0 +1,150 @@
+package models
+
+import (
+	"errors"
+
+	domainErrors "github.com/yourusername/gin-collection-saas/internal/domain/errors"
+)
+
+// Limits of the plan limits, checked by the entitlement service
+const (
+	LimitGins         = "gins"
+	LimitPhotosPerGin = "photos_per_gin"
+	LimitStorageMB    = MetricStorageMB
+)
+
+// DenialReason tells why an entitlement was denied
+type DenialReason string
+
+const (
+	DenialFeatureNotInPlan DenialReason = "feature_not_in_plan" // the plan doesn't include the feature
+	DenialLimitReached     DenialReason = "limit_reached"       // adding more would exceed a limit
+	DenialOverLimit        DenialReason = "over_limit"          // the collection exceeds a limit and is read-only
+	DenialPaymentOverdue   DenialReason = "payment_overdue"     // the plan includes it, but a payment is overdue
+	DenialUnknownFeature   DenialReason = "unknown_feature"     // the feature isn't declared, so it's denied
+)
+
+// FeatureRule declares a feature plans can include
+type FeatureRule struct {
+	Feature  string
+	Name     string                  // shown in denials
+	Included func(l PlanLimits) bool // whether limits include the feature
+	Err      error                   // returned when the plan doesn't include it
+}
+
+// FeatureRules are the features of the plan limits, in display order.
+// Features not declared here are denied.
+var FeatureRules = []FeatureRule{
+	{FeatureBotanicals, "Botanicals tracking", func(l PlanLimits) bool { return l.HasBotanicals }, domainErrors.ErrFeatureNotAvailable},
+	{FeatureCocktails, "Cocktail recipes", func(l PlanLimits) bool { return l.HasCocktails }, domainErrors.ErrFeatureNotAvailable},
+	{FeatureAISuggestions, "AI suggestions", func(l PlanLimits) bool { return l.HasAISuggestions }, domainErrors.ErrFeatureNotAvailable},
+	{FeatureExport, "Data export", func(l PlanLimits) bool { return l.HasExport }, domainErrors.ErrFeatureNotAvailable},
+	{FeatureImport, "Data import", func(l PlanLimits) bool { return l.HasImport }, domainErrors.ErrFeatureNotAvailable},
+	{FeatureMultiUser, "Multi-user support", func(l PlanLimits) bool { return l.HasMultiUser }, domainErrors.ErrMultiUserNotAllowed},
+	{FeatureAPIAccess, "API access", func(l PlanLimits) bool { return l.HasAPIAccess }, domainErrors.ErrAPIAccessNotAllowed},
+	{FeatureSSO, "Single sign-on", func(l PlanLimits) bool { return l.HasSSO }, domainErrors.ErrFeatureNotAvailable},
+	{FeatureSCIM, "SCIM provisioning", func(l PlanLimits) bool { return l.HasSCIM }, domainErrors.ErrFeatureNotAvailable},
+	{FeatureDedicatedDatabase, "Dedicated database", func(l PlanLimits) bool { return l.HasDedicatedDatabase }, domainErrors.ErrFeatureNotAvailable},
+}
+
+// FeatureRuleFor returns the rule of a feature, nil if it isn't declared
+func FeatureRuleFor(feature string) *FeatureRule {
+	for i := range FeatureRules {
+		if FeatureRules[i].Feature == feature {
+			return &FeatureRules[i]
+		}
+	}
+	return nil
+}
+
+// Entitlement is the decision whether a tenant may use a feature or add to a
+// limit. Denials carry the reason and, where an upgrade helps, the lowest
+// tier and plan that allow it.
+type Entitlement struct {
+	Allowed         bool             `json:"allowed"`
+	Feature         string           `json:"feature"` // feature or limit checked
+	Reason          DenialReason     `json:"reason,omitempty"`
+	Message         string           `json:"message,omitempty"`
+	CurrentTier     SubscriptionTier `json:"current_tier"`
+	RequiredTier    SubscriptionTier `json:"required_tier,omitempty"` // upgrade hint
+	UpgradePlanID   string           `json:"upgrade_plan_id,omitempty"`
+	Limit           *int             `json:"limit,omitempty"` // storage in MB
+	Current         *int             `json:"current_count,omitempty"`
+	OverLimit       int              `json:"over_limit_by,omitempty"` // how far the collection exceeds the limit
+	PaymentRequired bool             `json:"payment_required"`
+
+	err error // sentinel of the denial
+}
+
+// NewDenial creates a denied entitlement. err is the sentinel callers can
+// match with errors.Is.
+func NewDenial(tenant *Tenant, feature string, reason DenialReason, message string, err error) *Entitlement {
+	return &Entitlement{
+		Feature:         feature,
+		Reason:          reason,
+		Message:         message,
+		CurrentTier:     tenant.Tier,
+		PaymentRequired: tenant.BillingRestricted,
+		err:             err,
+	}
+}
+
+// UpgradeRequired reports whether a higher plan would allow what was denied
+func (e *Entitlement) UpgradeRequired() bool {
+	return !e.Allowed && e.Reason != DenialPaymentOverdue && e.Reason != DenialUnknownFeature
+}
+
+// Err returns the denial as error, nil if allowed
+func (e *Entitlement) Err() error {
+	if e.Allowed {
+		return nil
+	}
+	return &EntitlementError{Entitlement: e}
+}
+
+// EntitlementError is a denied entitlement. errors.Is matches its sentinel
+// (e.g. ErrMultiUserNotAllowed) and the sentinel of its reason
+// (ErrFeatureNotAvailable, ErrLimitReached, ErrOverLimit, ErrPaymentOverdue).
+type EntitlementError struct {
+	Entitlement *Entitlement
+}
+
+func (e *EntitlementError) Error() string {
+	if e.Entitlement.Message != "" {
+		return e.Entitlement.Message
+	}
+	return e.Unwrap().Error()
+}
+
+func (e *EntitlementError) Unwrap() error {
+	if e.Entitlement.err != nil {
+		return e.Entitlement.err
+	}
+	return e.reasonErr()
+}
+
+// Is matches the sentinel of the reason
+func (e *EntitlementError) Is(target error) bool {
+	return target == e.reasonErr()
+}
+
+func (e *EntitlementError) reasonErr() error {
+	switch e.Entitlement.Reason {
+	case DenialLimitReached:
+		return domainErrors.ErrLimitReached
+	case DenialOverLimit:
+		return domainErrors.ErrOverLimit
+	case DenialPaymentOverdue:
+		return domainErrors.ErrPaymentOverdue
+	}
+	return domainErrors.ErrFeatureNotAvailable
+}
+
+// AsEntitlement returns the denied entitlement of an error, if it is one
+func AsEntitlement(err error) (*Entitlement, bool) {
+	var denial *EntitlementError
+	if errors.As(err, &denial) {
+		return denial.Entitlement, true
+	}
+	return nil, false
+}
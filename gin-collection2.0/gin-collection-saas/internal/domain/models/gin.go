@@ -87,16 +87,20 @@ const (
 
 // GinPhoto represents a photo of a gin bottle
 type GinPhoto struct {
-	ID         int64      `json:"id"`
-	TenantID   int64      `json:"tenant_id"`
-	GinID      int64      `json:"gin_id"`
-	PhotoURL   string     `json:"photo_url"`
-	PhotoType  PhotoType  `json:"photo_type"`
-	Caption    *string    `json:"caption,omitempty"`
-	IsPrimary  bool       `json:"is_primary"`
-	StorageKey *string    `json:"storage_key,omitempty"`
-	FileSizeKB *int       `json:"file_size_kb,omitempty"`
-	CreatedAt  time.Time  `json:"created_at"`
+	ID             int64     `json:"id"`
+	TenantID       int64     `json:"tenant_id"`
+	GinID          int64     `json:"gin_id"`
+	PhotoURL       string    `json:"photo_url"`
+	PhotoType      PhotoType `json:"photo_type"`
+	Caption        *string   `json:"caption,omitempty"`
+	IsPrimary      bool      `json:"is_primary"`
+	SortOrder      int       `json:"sort_order"`
+	Tags           []string  `json:"tags"`
+	StorageKey     *string   `json:"storage_key,omitempty"`
+	StorageBackend string    `json:"storage_backend,omitempty"` // empty = the active backend
+	FileSizeKB     *int      `json:"file_size_kb,omitempty"`
+	FileSizeBytes  *int64    `json:"file_size_bytes,omitempty"`
+	CreatedAt      time.Time `json:"created_at"`
 }
 
 // PhotoType represents the type of photo
@@ -0,0 +1,52 @@
+package models
+
+import "time"
+
+// InviteTokenExpiry is the duration for which an invitation is valid
+const InviteTokenExpiry = 7 * 24 * time.Hour
+
+// InviteToken represents an invitation of a user to a tenant. Only the
+// SHA-256 hash of the token is stored.
+type InviteToken struct {
+	ID        int64      `json:"id"`
+	TenantID  int64      `json:"tenant_id"`
+	UserID    int64      `json:"user_id"`
+	TokenHash string     `json:"-"`
+	Email     string     `json:"email"`
+	Role      UserRole   `json:"role"`
+	InvitedBy *int64     `json:"invited_by,omitempty"`
+	ExpiresAt time.Time  `json:"expires_at"`
+	UsedAt    *time.Time `json:"used_at,omitempty"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+// IsExpired checks if the invitation has expired
+func (t *InviteToken) IsExpired() bool {
+	return time.Now().After(t.ExpiresAt)
+}
+
+// IsUsed checks if the invitation has been accepted
+func (t *InviteToken) IsUsed() bool {
+	return t.UsedAt != nil
+}
+
+// IsValid checks if the invitation can still be accepted
+func (t *InviteToken) IsValid() bool {
+	return !t.IsExpired() && !t.IsUsed()
+}
+
+// PendingInvite is an invitation that has not been accepted yet
+type PendingInvite struct {
+	InviteToken
+	FirstName *string `json:"first_name,omitempty"`
+	LastName  *string `json:"last_name,omitempty"`
+	Expired   bool    `json:"expired"`
+}
+
+// AcceptInviteRequest is the request for accepting an invitation
+type AcceptInviteRequest struct {
+	Token     string  `json:"token" binding:"required"`
+	Password  string  `json:"password" binding:"required,min=12"`
+	FirstName *string `json:"first_name"`
+	LastName  *string `json:"last_name"`
+}
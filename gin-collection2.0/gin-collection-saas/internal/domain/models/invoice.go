@@ -0,0 +1,126 @@
+package models
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// BillingDetails is the billing address of a tenant or the seller on invoices
+type BillingDetails struct {
+	Name         string `json:"name"` // company or person
+	Email        string `json:"email,omitempty"`
+	AddressLine1 string `json:"address_line1"`
+	AddressLine2 string `json:"address_line2,omitempty"`
+	PostalCode   string `json:"postal_code"`
+	City         string `json:"city"`
+	Country      string `json:"country"` // ISO 3166-1 alpha-2, e.g. DE
+	VATID        string `json:"vat_id,omitempty"`
+}
+
+// euCountries are the member states of the EU VAT area
+var euCountries = map[string]bool{
+	"AT": true, "BE": true, "BG": true, "CY": true, "CZ": true, "DE": true, "DK": true,
+	"EE": true, "ES": true, "FI": true, "FR": true, "GR": true, "HR": true, "HU": true,
+	"IE": true, "IT": true, "LT": true, "LU": true, "LV": true, "MT": true, "NL": true,
+	"PL": true, "PT": true, "RO": true, "SE": true, "SI": true, "SK": true,
+}
+
+// IsEUCountry reports whether a country belongs to the EU VAT area
+func IsEUCountry(country string) bool {
+	return euCountries[strings.ToUpper(country)]
+}
+
+var vatIDPattern = regexp.MustCompile(`^[A-Z]{2}[0-9A-Z+*]{2,13}$`)
+
+// NormalizeVATID removes spaces, dots and dashes and uppercases a VAT ID
+func NormalizeVATID(vatID string) string {
+	return strings.ToUpper(strings.NewReplacer(" ", "", ".", "", "-", "").Replace(vatID))
+}
+
+// ValidVATID reports whether a VAT ID has the format of the given EU country.
+// Greek VAT IDs start with EL.
+func ValidVATID(vatID, country string) bool {
+	if !vatIDPattern.MatchString(vatID) {
+		return false
+	}
+	prefix := strings.ToUpper(country)
+	if prefix == "GR" {
+		prefix = "EL"
+	}
+	return vatID[:2] == prefix
+}
+
+// Invoice is the invoice of a payment. The addresses and the tax are fixed
+// when the invoice is issued.
+type Invoice struct {
+	ID                int64           `json:"id"`
+	TenantID          int64           `json:"tenant_id"`
+	SubscriptionID    *int64          `json:"subscription_id,omitempty"`
+	Number            string          `json:"number"`
+	Provider          string          `json:"provider"`
+	ProviderPaymentID string          `json:"provider_payment_id"`
+	PlanID            string          `json:"plan_id"`
+	Description       string          `json:"description"`
+	PeriodStart       *time.Time      `json:"period_start,omitempty"`
+	PeriodEnd         *time.Time      `json:"period_end,omitempty"`
+	Currency          string          `json:"currency"`
+	NetAmount         float64         `json:"net_amount"`
+	TaxRate           float64         `json:"tax_rate"` // percent
+	TaxAmount         float64         `json:"tax_amount"`
+	TotalAmount       float64         `json:"total_amount"` // amount paid
+	ReverseCharge     bool            `json:"reverse_charge"`
+	Customer          *BillingDetails `json:"customer"`
+	Seller            *BillingDetails `json:"seller"`
+	IssuedAt          time.Time       `json:"issued_at"`
+	CreatedAt         time.Time       `json:"created_at"`
+}
+
+// InvoiceNumber formats the sequential number of an invoice, e.g. GV-2026-000042
+func InvoiceNumber(prefix string, year, sequence int) string {
+	return fmt.Sprintf("%s-%d-%06d", prefix, year, sequence)
+}
+
+// InvoiceTax is how a payment is taxed
+type InvoiceTax struct {
+	Rate          float64
+	ReverseCharge bool
+}
+
+// TaxFor returns the tax of a payment in a currency by a customer. Plan
+// prices include VAT. EUR customers in the seller's country, in other EU
+// countries without a VAT ID and without billing details pay the seller's
+// VAT. EU businesses with a VAT ID in another country are reverse charged,
+// customers outside the EU and payments in other currencies pay no VAT.
+func TaxFor(currency string, customer, seller *BillingDetails, vatRate float64) InvoiceTax {
+	if !strings.EqualFold(currency, "EUR") {
+		return InvoiceTax{}
+	}
+	if customer == nil || customer.Country == "" || strings.EqualFold(customer.Country, seller.Country) {
+		return InvoiceTax{Rate: vatRate}
+	}
+	if !IsEUCountry(customer.Country) {
+		return InvoiceTax{}
+	}
+	if customer.VATID != "" {
+		return InvoiceTax{ReverseCharge: true}
+	}
+	return InvoiceTax{Rate: vatRate}
+}
+
+// ApplyTax splits the total of an invoice into net amount and tax
+func (i *Invoice) ApplyTax(tax InvoiceTax) {
+	i.TaxRate = tax.Rate
+	i.ReverseCharge = tax.ReverseCharge
+	i.NetAmount = math.Round(i.TotalAmount/(1+tax.Rate/100)*100) / 100
+	i.TaxAmount = math.Round((i.TotalAmount-i.NetAmount)*100) / 100
+}
+
+// InvoiceFilter represents filtering options for the invoice list of a tenant
+type InvoiceFilter struct {
+	TenantID int64
+	Limit    int
+	Offset   int
+}
@@ -0,0 +1,15 @@
+package models
+
+import "time"
+
+// LoginDevice is a device and network a user has logged in from. Logins
+// from a device or network not seen before are reported to the user.
+type LoginDevice struct {
+	ID          int64     `json:"-"`
+	UserID      int64     `json:"-"`
+	Device      string    `json:"device"`  // e.g. "Chrome on macOS"
+	Network     string    `json:"network"` // /24 (IPv4) or /48 (IPv6) of the address
+	IPAddress   *string   `json:"ip_address,omitempty"`
+	FirstSeenAt time.Time `json:"first_seen_at"`
+	LastSeenAt  time.Time `json:"last_seen_at"`
+}
@@ -0,0 +1,167 @@
+package models
+
+// Permission is an action a user can be allowed to perform within a tenant
+type Permission string
+
+// Permissions of the registry, every protected route requires one of them
+const (
+	PermissionTenantRead     Permission = "tenant:read"
+	PermissionTenantManage   Permission = "tenant:manage"
+	PermissionBillingManage  Permission = "billing:manage"
+	PermissionUsersManage    Permission = "users:manage"
+	PermissionRolesManage    Permission = "roles:manage"
+	PermissionAPIKeysManage  Permission = "api_keys:manage"
+	PermissionGinsRead       Permission = "gins:read"
+	PermissionGinsWrite      Permission = "gins:write"
+	PermissionGinsDelete     Permission = "gins:delete"
+	PermissionGinsExport     Permission = "gins:export"
+	PermissionGinsImport     Permission = "gins:import"
+	PermissionPhotosWrite    Permission = "photos:write"
+	PermissionPhotosDelete   Permission = "photos:delete"
+	PermissionTastingsWrite  Permission = "tastings:write"
+	PermissionTastingsDelete Permission = "tastings:delete"
+	PermissionAIUse          Permission = "ai:use"
+)
+
+// PermissionInfo describes a permission of the registry
+type PermissionInfo struct {
+	Name        Permission `json:"name"`
+	Description string     `json:"description"`
+	// Assignable permissions can be granted to custom roles, the others stay
+	// with the built-in roles so a custom role can't escalate its privileges
+	Assignable bool `json:"assignable"`
+}
+
+// PermissionRegistry lists all permissions
+var PermissionRegistry = []PermissionInfo{
+	{PermissionTenantRead, "View the tenant, its usage and subscription", true},
+	{PermissionTenantManage, "Change tenant settings, security, SSO and SCIM", false},
+	{PermissionBillingManage, "Change and cancel the subscription", false},
+	{PermissionUsersManage, "Invite, change and remove users", false},
+	{PermissionRolesManage, "Create custom roles and assign them", false},
+	{PermissionAPIKeysManage, "Create and revoke own API keys", true},
+	{PermissionGinsRead, "View gins, photos, tastings and the catalog", true},
+	{PermissionGinsWrite, "Add and change gins, botanicals and cocktails", true},
+	{PermissionGinsDelete, "Delete gins", true},
+	{PermissionGinsExport, "Export the collection", true},
+	{PermissionGinsImport, "Import gins", true},
+	{PermissionPhotosWrite, "Upload and change photos", true},
+	{PermissionPhotosDelete, "Delete photos", true},
+	{PermissionTastingsWrite, "Add and change tasting sessions", true},
+	{PermissionTastingsDelete, "Delete tasting sessions", true},
+	{PermissionAIUse, "Use AI suggestions and label scanning", true},
+}
+
+// rolePermissions are the permissions of the built-in roles, owners have all
+var rolePermissions = map[UserRole][]Permission{
+	RoleAdmin: {
+		PermissionTenantRead,
+		PermissionUsersManage,
+		PermissionRolesManage,
+		PermissionAPIKeysManage,
+		PermissionGinsRead,
+		PermissionGinsWrite,
+		PermissionGinsDelete,
+		PermissionGinsExport,
+		PermissionGinsImport,
+		PermissionPhotosWrite,
+		PermissionPhotosDelete,
+		PermissionTastingsWrite,
+		PermissionTastingsDelete,
+		PermissionAIUse,
+	},
+	RoleMember: {
+		PermissionTenantRead,
+		PermissionAPIKeysManage,
+		PermissionGinsRead,
+		PermissionGinsWrite,
+		PermissionGinsExport,
+		PermissionGinsImport,
+		PermissionPhotosWrite,
+		PermissionTastingsWrite,
+		PermissionAIUse,
+	},
+	RoleViewer: {
+		PermissionTenantRead,
+		PermissionAPIKeysManage,
+		PermissionGinsRead,
+	},
+}
+
+// BuiltInRoles lists the roles every tenant has
+var BuiltInRoles = []UserRole{RoleOwner, RoleAdmin, RoleMember, RoleViewer}
+
+// IsBuiltInRole checks if a name is taken by a built-in role
+func IsBuiltInRole(name string) bool {
+	for _, role := range BuiltInRoles {
+		if string(role) == name {
+			return true
+		}
+	}
+	return false
+}
+
+// LookupPermission returns the registry entry of a permission
+func LookupPermission(p Permission) (PermissionInfo, bool) {
+	for _, info := range PermissionRegistry {
+		if info.Name == p {
+			return info, true
+		}
+	}
+	return PermissionInfo{}, false
+}
+
+// PermissionSet is a set of permissions
+type PermissionSet map[Permission]bool
+
+// NewPermissionSet creates a set of permissions
+func NewPermissionSet(permissions ...Permission) PermissionSet {
+	set := make(PermissionSet, len(permissions))
+	for _, p := range permissions {
+		set[p] = true
+	}
+	return set
+}
+
+// Has checks if the set contains a permission
+func (s PermissionSet) Has(p Permission) bool {
+	return s[p]
+}
+
+// List returns the permissions of the set in registry order
+func (s PermissionSet) List() []Permission {
+	permissions := []Permission{}
+	for _, info := range PermissionRegistry {
+		if s[info.Name] {
+			permissions = append(permissions, info.Name)
+		}
+	}
+	return permissions
+}
+
+// Permissions returns the permissions of a built-in role
+func (r UserRole) Permissions() PermissionSet {
+	if r == RoleOwner {
+		all := make(PermissionSet, len(PermissionRegistry))
+		for _, info := range PermissionRegistry {
+			all[info.Name] = true
+		}
+		return all
+	}
+	return NewPermissionSet(rolePermissions[r]...)
+}
+
+// HasPermission checks if the user role has a permission
+func (r UserRole) HasPermission(p Permission) bool {
+	return r.Permissions().Has(p)
+}
+
+// EffectivePermissions returns the permissions of a user. A custom role
+// replaces the permissions of the built-in role, except for owners who always
+// keep all permissions.
+func EffectivePermissions(role UserRole, customRole *CustomRole) PermissionSet {
+	if customRole == nil || role == RoleOwner {
+		return role.Permissions()
+	}
+	return NewPermissionSet(customRole.Permissions...)
+}
@@ -0,0 +1,30 @@
+package models
+
+// Photo tag limits
+const (
+	MaxPhotoTags      = 20
+	MaxPhotoTagLength = 50
+)
+
+// PhotoFilter represents filtering options for the photo gallery
+type PhotoFilter struct {
+	TenantID  int64
+	GinID     *int64
+	PhotoType *PhotoType
+	Tag       string
+	MaxPerGin int // only the first N photos of each gin (by sort order), 0 = all
+	Limit     int
+	Offset    int
+}
+
+// UpdatePhotoRequest represents a photo update; nil fields are left unchanged
+type UpdatePhotoRequest struct {
+	Caption   *string    `json:"caption" binding:"omitempty,max=500"`
+	PhotoType *PhotoType `json:"photo_type"`
+	Tags      []string   `json:"tags"`
+}
+
+// ReorderPhotosRequest represents the new order of all photos of a gin
+type ReorderPhotosRequest struct {
+	PhotoIDs []int64 `json:"photo_ids" binding:"required,min=1"`
+}
@@ -0,0 +1,59 @@
+package models
+
+import "time"
+
+// UploadSessionStatus represents the state of a direct upload session
+type UploadSessionStatus string
+
+const (
+	UploadSessionPending   UploadSessionStatus = "pending"
+	UploadSessionCompleted UploadSessionStatus = "completed"
+	UploadSessionExpired   UploadSessionStatus = "expired"
+)
+
+// PhotoUploadSession tracks a photo that is uploaded directly to storage
+// via a presigned URL and registered afterwards
+type PhotoUploadSession struct {
+	ID          int64               `json:"id"`
+	TenantID    int64               `json:"tenant_id"`
+	GinID       int64               `json:"gin_id"`
+	UserID      *int64              `json:"user_id,omitempty"`
+	Token       string              `json:"token"`
+	StorageKey  string              `json:"storage_key"`
+	Filename    string              `json:"filename"`
+	ContentType string              `json:"content_type"`
+	PhotoType   PhotoType           `json:"photo_type"`
+	Caption     *string             `json:"caption,omitempty"`
+	Status      UploadSessionStatus `json:"status"`
+	ExpiresAt   time.Time           `json:"expires_at"`
+	CompletedAt *time.Time          `json:"completed_at,omitempty"`
+	CreatedAt   time.Time           `json:"created_at"`
+}
+
+// IsExpired checks if the upload session has expired
+func (s *PhotoUploadSession) IsExpired() bool {
+	return time.Now().After(s.ExpiresAt)
+}
+
+// UploadURLRequest is the request for a presigned photo upload URL
+type UploadURLRequest struct {
+	Filename    string  `json:"filename" binding:"required,max=255"`
+	ContentType string  `json:"content_type" binding:"required"`
+	SizeBytes   int64   `json:"size_bytes" binding:"required,min=1"`
+	PhotoType   string  `json:"photo_type"`
+	Caption     *string `json:"caption" binding:"omitempty,max=500"`
+}
+
+// UploadURLResponse is returned when an upload session is created
+type UploadURLResponse struct {
+	UploadToken string            `json:"upload_token"`
+	UploadURL   string            `json:"upload_url"`
+	Method      string            `json:"method"`
+	Headers     map[string]string `json:"headers"`
+	ExpiresAt   time.Time         `json:"expires_at"`
+}
+
+// CompleteUploadRequest is the request to register an uploaded photo
+type CompleteUploadRequest struct {
+	UploadToken string `json:"upload_token" binding:"required"`
+}
@@ -0,0 +1,126 @@
+package models
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Languages plan features are described in
+const (
+	PlanLanguageGerman  = "de"
+	PlanLanguageEnglish = "en"
+
+	// DefaultPlanLanguage is the language of the app
+	DefaultPlanLanguage = PlanLanguageGerman
+)
+
+// planFeatureTexts are the descriptions of the plan limits per language
+var planFeatureTexts = map[string]map[string]string{
+	PlanLanguageGerman: {
+		"gins":                   "Bis zu %d Gins",
+		"gin":                    "Bis zu 1 Gin",
+		"gins_unlimited":         "Unbegrenzte Gins",
+		"photos":                 "%d Fotos pro Gin",
+		"photos_unlimited":       "Unbegrenzte Fotos",
+		"storage_mb":             "%d MB Speicherplatz",
+		"storage_gb":             "%d GB Speicherplatz",
+		"storage_unlimited":      "Unbegrenzter Speicherplatz",
+		"api_quota":              "%d API-Anfragen pro Abrechnungszeitraum",
+		"ai_quota":               "%d KI-Vorschläge pro Abrechnungszeitraum",
+		FeatureBotanicals:        "Botanicals",
+		FeatureCocktails:         "Cocktail-Rezepte",
+		FeatureAISuggestions:     "KI-Vorschläge",
+		FeatureExport:            "Export-Funktion",
+		FeatureImport:            "Import-Funktion",
+		FeatureMultiUser:         "Team-Verwaltung",
+		FeatureAPIAccess:         "API-Zugang (%d Anfragen pro Stunde)",
+		FeatureSSO:               "Single Sign-on (OIDC, SAML)",
+		FeatureSCIM:              "Benutzer-Provisionierung per SCIM",
+		FeatureDedicatedDatabase: "Eigene Datenbank",
+	},
+	PlanLanguageEnglish: {
+		"gins":                   "Up to %d gins",
+		"gin":                    "Up to 1 gin",
+		"gins_unlimited":         "Unlimited gins",
+		"photos":                 "%d photos per gin",
+		"photos_unlimited":       "Unlimited photos",
+		"storage_mb":             "%d MB storage",
+		"storage_gb":             "%d GB storage",
+		"storage_unlimited":      "Unlimited storage",
+		"api_quota":              "%d API requests per billing period",
+		"ai_quota":               "%d AI suggestions per billing period",
+		FeatureBotanicals:        "Botanicals",
+		FeatureCocktails:         "Cocktail recipes",
+		FeatureAISuggestions:     "AI suggestions",
+		FeatureExport:            "Export",
+		FeatureImport:            "Import",
+		FeatureMultiUser:         "Team management",
+		FeatureAPIAccess:         "API access (%d requests per hour)",
+		FeatureSSO:               "Single sign-on (OIDC, SAML)",
+		FeatureSCIM:              "SCIM user provisioning",
+		FeatureDedicatedDatabase: "Dedicated database",
+	},
+}
+
+// PlanLanguage returns the supported language of a language tag or an
+// Accept-Language header, the default language if none is supported
+func PlanLanguage(value string) string {
+	for _, tag := range strings.Split(value, ",") {
+		tag = strings.TrimSpace(strings.SplitN(tag, ";", 2)[0])
+		lang := strings.ToLower(strings.SplitN(tag, "-", 2)[0])
+		if _, ok := planFeatureTexts[lang]; ok {
+			return lang
+		}
+	}
+	return DefaultPlanLanguage
+}
+
+// PlanFeatures describes the limits of a plan in a language, the default
+// language if it isn't supported
+func PlanFeatures(limits PlanLimits, lang string) []string {
+	texts, ok := planFeatureTexts[lang]
+	if !ok {
+		texts = planFeatureTexts[DefaultPlanLanguage]
+	}
+
+	features := []string{}
+	switch {
+	case limits.MaxGins == nil:
+		features = append(features, texts["gins_unlimited"])
+	case *limits.MaxGins == 1:
+		features = append(features, texts["gin"])
+	default:
+		features = append(features, fmt.Sprintf(texts["gins"], *limits.MaxGins))
+	}
+
+	if limits.MaxPhotosPerGin < 0 {
+		features = append(features, texts["photos_unlimited"])
+	} else {
+		features = append(features, fmt.Sprintf(texts["photos"], limits.MaxPhotosPerGin))
+	}
+
+	switch {
+	case limits.StorageLimitMB == nil:
+		features = append(features, texts["storage_unlimited"])
+	case *limits.StorageLimitMB >= 1000 && *limits.StorageLimitMB%1000 == 0:
+		features = append(features, fmt.Sprintf(texts["storage_gb"], *limits.StorageLimitMB/1000))
+	default:
+		features = append(features, fmt.Sprintf(texts["storage_mb"], *limits.StorageLimitMB))
+	}
+
+	for _, feature := range limits.Features() {
+		switch {
+		case feature == FeatureAPIAccess:
+			features = append(features, fmt.Sprintf(texts[feature], limits.APIRateLimit))
+			if limits.MaxAPICallsPerPeriod != nil {
+				features = append(features, fmt.Sprintf(texts["api_quota"], *limits.MaxAPICallsPerPeriod))
+			}
+		case feature == FeatureAISuggestions && limits.MaxAISuggestionsPerPeriod != nil:
+			features = append(features, texts[feature], fmt.Sprintf(texts["ai_quota"], *limits.MaxAISuggestionsPerPeriod))
+		default:
+			features = append(features, texts[feature])
+		}
+	}
+
+	return features
+}
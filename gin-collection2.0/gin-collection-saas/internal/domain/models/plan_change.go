@@ -0,0 +1,115 @@
+package models
+
+import "time"
+
+// tierRanks orders the tiers from lowest to highest
+var tierRanks = map[SubscriptionTier]int{
+	TierFree:       0,
+	TierBasic:      1,
+	TierPro:        2,
+	TierEnterprise: 3,
+}
+
+// Tiers lists the tiers from lowest to highest
+var Tiers = []SubscriptionTier{TierFree, TierBasic, TierPro, TierEnterprise}
+
+// IsUpgrade reports whether moving from one tier to another is an upgrade
+func IsUpgrade(from, to SubscriptionTier) bool {
+	return tierRanks[to] > tierRanks[from]
+}
+
+// IsDowngrade reports whether moving from one tier to another loses limits or features
+func IsDowngrade(from, to SubscriptionTier) bool {
+	return tierRanks[to] < tierRanks[from]
+}
+
+// Features of the plan limits, named as in the feature checks of the API
+const (
+	FeatureBotanicals        = "botanicals"
+	FeatureCocktails         = "cocktails"
+	FeatureAISuggestions     = "ai_suggestions"
+	FeatureExport            = "export"
+	FeatureImport            = "import"
+	FeatureMultiUser         = "multi_user"
+	FeatureAPIAccess         = "api_access"
+	FeatureSSO               = "sso"
+	FeatureSCIM              = "scim"
+	FeatureDedicatedDatabase = "dedicated_database"
+)
+
+// Features returns the features included in the limits
+func (l PlanLimits) Features() []string {
+	features := []string{}
+	for _, rule := range FeatureRules {
+		if rule.Included(l) {
+			features = append(features, rule.Feature)
+		}
+	}
+	return features
+}
+
+// LostFeatures returns the features of the limits that the target limits don't include
+func (l PlanLimits) LostFeatures(target PlanLimits) []string {
+	kept := map[string]bool{}
+	for _, feature := range target.Features() {
+		kept[feature] = true
+	}
+
+	lost := []string{}
+	for _, feature := range l.Features() {
+		if !kept[feature] {
+			lost = append(lost, feature)
+		}
+	}
+	return lost
+}
+
+// GinsOverLimit returns how many gins exceed the gin limit
+func (l PlanLimits) GinsOverLimit(ginCount int) int {
+	if l.MaxGins == nil || ginCount <= *l.MaxGins {
+		return 0
+	}
+	return ginCount - *l.MaxGins
+}
+
+// Proration is the credit for the unused part of the current billing period
+// and the charge for the new plan over the same time
+type Proration struct {
+	Credit        float64 `json:"credit"`
+	Charge        float64 `json:"charge"`
+	AmountDue     float64 `json:"amount_due"`     // charge minus credit, never negative
+	CarriedCredit float64 `json:"carried_credit"` // credit left over for later invoices
+	Currency      string  `json:"currency"`
+	RemainingDays int     `json:"remaining_days"`
+	PeriodDays    int     `json:"period_days"`
+	Invoiced      bool    `json:"invoiced"` // the provider invoices the proration
+}
+
+// DowngradeImpact reports what a tenant loses by moving to a lower tier.
+// Nothing is deleted: while the collection exceeds the new limits it is
+// read-only, photos beyond the per-gin limit are hidden and locked.
+type DowngradeImpact struct {
+	FromTier                SubscriptionTier `json:"from_tier"`
+	ToTier                  SubscriptionTier `json:"to_tier"`
+	GinCount                int              `json:"gin_count"`
+	GinLimit                *int             `json:"gin_limit"` // nil = unlimited
+	GinsOverLimit           int              `json:"gins_over_limit"`
+	PhotoLimit              int              `json:"photo_limit"` // per gin, -1 = unlimited
+	PhotosOverLimit         int              `json:"photos_over_limit"`
+	GinsWithPhotosOverLimit int              `json:"gins_with_photos_over_limit"`
+	StorageMB               float64          `json:"storage_mb"`
+	StorageLimitMB          *int             `json:"storage_limit_mb"` // nil = unlimited
+	StorageOverLimit        bool             `json:"storage_over_limit"`
+	FeaturesLost            []string         `json:"features_lost"`
+	ReadOnly                bool             `json:"read_only"` // the collection becomes read-only
+}
+
+// PlanChangePreview describes a plan change before it is made
+type PlanChangePreview struct {
+	CurrentPlanID string           `json:"current_plan_id"`
+	TargetPlan    *Plan            `json:"target_plan"`
+	Downgrade     bool             `json:"downgrade"`
+	EffectiveAt   time.Time        `json:"effective_at"` // scheduled changes take effect at the end of the period
+	Proration     *Proration       `json:"proration,omitempty"`
+	Impact        *DowngradeImpact `json:"impact,omitempty"`
+}
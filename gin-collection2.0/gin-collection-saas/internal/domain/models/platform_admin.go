@@ -16,8 +16,15 @@ type PlatformAdmin struct {
 
 // PlatformAdminAuthResponse is returned after successful admin authentication
 type PlatformAdminAuthResponse struct {
-	Token string         `json:"token"`
-	Admin *PlatformAdmin `json:"admin"`
+	Token string         `json:"token,omitempty"`
+	Admin *PlatformAdmin `json:"admin,omitempty"`
+
+	// Set instead of the token while the second factor is pending
+	TwoFactor *TwoFactorChallenge `json:"two_factor,omitempty"`
+	// Returned once when 2FA was enabled during login
+	RecoveryCodes []string `json:"recovery_codes,omitempty"`
+	// Returned when the device should be remembered
+	TrustedDeviceToken string `json:"trusted_device_token,omitempty"`
 }
 
 // PlatformStats represents overall platform statistics
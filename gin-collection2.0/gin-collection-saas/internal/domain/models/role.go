@@ -0,0 +1,61 @@
+package models
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// MaxCustomRolesPerTenant is the number of custom roles a tenant can define
+const MaxCustomRolesPerTenant = 50
+
+// CustomRole is a tenant defined role (Enterprise). Users assigned to it get
+// its permissions instead of those of their built-in role.
+type CustomRole struct {
+	ID          int64        `json:"id"`
+	TenantID    int64        `json:"-"`
+	Name        string       `json:"name"`
+	Description *string      `json:"description,omitempty"`
+	Permissions []Permission `json:"permissions"`
+	UserCount   int          `json:"user_count"`
+	CreatedAt   time.Time    `json:"created_at"`
+	UpdatedAt   time.Time    `json:"updated_at"`
+}
+
+// RoleInfo describes a built-in or custom role in the roles API
+type RoleInfo struct {
+	ID          *int64       `json:"id,omitempty"` // custom roles only
+	Name        string       `json:"name"`
+	Description *string      `json:"description,omitempty"`
+	BuiltIn     bool         `json:"built_in"`
+	Permissions []Permission `json:"permissions"`
+	UserCount   *int         `json:"user_count,omitempty"` // custom roles only
+}
+
+// RoleRequest represents a request to create or change a custom role
+type RoleRequest struct {
+	Name        string       `json:"name" binding:"required,max=50"`
+	Description *string      `json:"description,omitempty" binding:"omitempty,max=255"`
+	Permissions []Permission `json:"permissions" binding:"required,min=1"`
+}
+
+// Validate checks the name and the permissions of the request
+func (r *RoleRequest) Validate() error {
+	name := strings.TrimSpace(r.Name)
+	if name == "" {
+		return fmt.Errorf("name is required")
+	}
+	if IsBuiltInRole(strings.ToLower(name)) {
+		return fmt.Errorf("%q is the name of a built-in role", name)
+	}
+	for _, p := range r.Permissions {
+		info, ok := LookupPermission(p)
+		if !ok {
+			return fmt.Errorf("unknown permission %q", p)
+		}
+		if !info.Assignable {
+			return fmt.Errorf("permission %q can't be granted to custom roles", p)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,161 @@
+package models
+
+import (
+	"encoding/json"
+	"strconv"
+	"time"
+)
+
+// SCIM 2.0 schema URNs (RFC 7643, RFC 7644)
+const (
+	SCIMUserSchema                  = "urn:ietf:params:scim:schemas:core:2.0:User"
+	SCIMGroupSchema                 = "urn:ietf:params:scim:schemas:core:2.0:Group"
+	SCIMListResponseSchema          = "urn:ietf:params:scim:api:messages:2.0:ListResponse"
+	SCIMPatchOpSchema               = "urn:ietf:params:scim:api:messages:2.0:PatchOp"
+	SCIMErrorSchema                 = "urn:ietf:params:scim:api:messages:2.0:Error"
+	SCIMServiceProviderConfigSchema = "urn:ietf:params:scim:schemas:core:2.0:ServiceProviderConfig"
+)
+
+// SCIMContentType is the media type of SCIM requests and responses
+const SCIMContentType = "application/scim+json"
+
+// SCIMTokenPrefix starts every SCIM bearer token, so leaked tokens are recognizable
+const SCIMTokenPrefix = "scim_"
+
+// SCIMMaxResults caps the page size of list requests
+const SCIMMaxResults = 200
+
+// SCIMToken is the bearer token an identity provider uses to provision the
+// users of a tenant. Each tenant has at most one, only its hash is stored.
+type SCIMToken struct {
+	ID          int64      `json:"id"`
+	TenantID    int64      `json:"tenant_id"`
+	TokenHash   string     `json:"-"`
+	TokenPrefix string     `json:"token_prefix"` // first characters, to tell tokens apart
+	CreatedBy   *int64     `json:"created_by,omitempty"`
+	LastUsedAt  *time.Time `json:"last_used_at,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"`
+}
+
+// SCIMStatus is the SCIM provisioning setup of a tenant
+type SCIMStatus struct {
+	Enabled bool       `json:"enabled"`
+	BaseURL string     `json:"base_url"` // the SCIM endpoint to configure at the identity provider
+	Token   *SCIMToken `json:"token,omitempty"`
+}
+
+// SCIMGroup is a group pushed by the identity provider of a tenant. Through
+// the role mapping of the SSO settings, membership decides the user roles.
+type SCIMGroup struct {
+	ID          int64     `json:"id"`
+	TenantID    int64     `json:"tenant_id"`
+	DisplayName string    `json:"display_name"`
+	ExternalID  *string   `json:"external_id,omitempty"`
+	MemberIDs   []int64   `json:"member_ids"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// HasMember reports whether a user is a member of the group
+func (g *SCIMGroup) HasMember(userID int64) bool {
+	for _, id := range g.MemberIDs {
+		if id == userID {
+			return true
+		}
+	}
+	return false
+}
+
+// SCIMMeta is the meta attribute of SCIM resources
+type SCIMMeta struct {
+	ResourceType string     `json:"resourceType"`
+	Created      *time.Time `json:"created,omitempty"`
+	LastModified *time.Time `json:"lastModified,omitempty"`
+	Location     string     `json:"location,omitempty"`
+}
+
+// SCIMUser is the SCIM representation of a user. The userName is the email
+// address the user signs in with.
+type SCIMUser struct {
+	Schemas     []string       `json:"schemas"`
+	ID          string         `json:"id,omitempty"`
+	ExternalID  string         `json:"externalId,omitempty"`
+	UserName    string         `json:"userName"`
+	Name        *SCIMName      `json:"name,omitempty"`
+	DisplayName string         `json:"displayName,omitempty"`
+	Emails      []SCIMEmail    `json:"emails,omitempty"`
+	Active      *bool          `json:"active,omitempty"`
+	Groups      []SCIMResource `json:"groups,omitempty"` // read-only, managed through /Groups
+	Meta        *SCIMMeta      `json:"meta,omitempty"`
+}
+
+// SCIMName is the name of a SCIM user
+type SCIMName struct {
+	Formatted  string `json:"formatted,omitempty"`
+	GivenName  string `json:"givenName,omitempty"`
+	FamilyName string `json:"familyName,omitempty"`
+}
+
+// SCIMEmail is an email address of a SCIM user
+type SCIMEmail struct {
+	Value   string `json:"value"`
+	Type    string `json:"type,omitempty"`
+	Primary bool   `json:"primary,omitempty"`
+}
+
+// SCIMGroupResource is the SCIM representation of a group
+type SCIMGroupResource struct {
+	Schemas     []string       `json:"schemas"`
+	ID          string         `json:"id,omitempty"`
+	ExternalID  string         `json:"externalId,omitempty"`
+	DisplayName string         `json:"displayName"`
+	Members     []SCIMResource `json:"members,omitempty"`
+	Meta        *SCIMMeta      `json:"meta,omitempty"`
+}
+
+// SCIMResource references a user or group (group members, user groups)
+type SCIMResource struct {
+	Value   string `json:"value"`
+	Display string `json:"display,omitempty"`
+	Ref     string `json:"$ref,omitempty"`
+}
+
+// SCIMListResponse is a page of resources
+type SCIMListResponse struct {
+	Schemas      []string    `json:"schemas"`
+	TotalResults int         `json:"totalResults"`
+	StartIndex   int         `json:"startIndex"`
+	ItemsPerPage int         `json:"itemsPerPage"`
+	Resources    interface{} `json:"Resources"`
+}
+
+// SCIMPatchRequest is a SCIM PATCH request (RFC 7644 section 3.5.2)
+type SCIMPatchRequest struct {
+	Schemas    []string             `json:"schemas"`
+	Operations []SCIMPatchOperation `json:"Operations" binding:"required,min=1,max=100"`
+}
+
+// SCIMPatchOperation is a single add, replace or remove operation
+type SCIMPatchOperation struct {
+	Op    string          `json:"op"`
+	Path  string          `json:"path,omitempty"`
+	Value json.RawMessage `json:"value,omitempty"`
+}
+
+// SCIMError is the body of SCIM error responses (RFC 7644 section 3.12)
+type SCIMError struct {
+	Schemas  []string `json:"schemas"`
+	Status   string   `json:"status"`
+	ScimType string   `json:"scimType,omitempty"`
+	Detail   string   `json:"detail,omitempty"`
+}
+
+// NewSCIMError creates a SCIM error body
+func NewSCIMError(status int, scimType, detail string) *SCIMError {
+	return &SCIMError{
+		Schemas:  []string{SCIMErrorSchema},
+		Status:   strconv.Itoa(status),
+		ScimType: scimType,
+		Detail:   detail,
+	}
+}
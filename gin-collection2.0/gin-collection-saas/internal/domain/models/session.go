@@ -0,0 +1,41 @@
+package models
+
+import "time"
+
+// Session settings
+const (
+	// SessionExpiry is how long a session lasts without a refresh
+	SessionExpiry = 30 * 24 * time.Hour
+)
+
+// Reasons a session was revoked
+const (
+	SessionRevokedLogout            = "logout"
+	SessionRevokedByUser            = "revoked"
+	SessionRevokedPasswordChange    = "password_changed"
+	SessionRevokedRefreshTokenReuse = "refresh_token_reuse"
+)
+
+// Session is a login of a user on a device. The refresh token of a session
+// rotates on every refresh, only its current token ID is valid.
+type Session struct {
+	ID             int64      `json:"-"`
+	UUID           string     `json:"id"`
+	UserID         int64      `json:"-"`
+	TenantID       int64      `json:"-"`
+	RefreshTokenID string     `json:"-"`
+	Device         string     `json:"device"`
+	IPAddress      *string    `json:"ip_address,omitempty"`
+	UserAgent      *string    `json:"user_agent,omitempty"`
+	Current        bool       `json:"current"`
+	CreatedAt      time.Time  `json:"created_at"`
+	LastUsedAt     time.Time  `json:"last_used_at"`
+	ExpiresAt      time.Time  `json:"expires_at"`
+	RevokedAt      *time.Time `json:"-"`
+	RevokedReason  *string    `json:"-"`
+}
+
+// IsActive checks if the session is neither revoked nor expired
+func (s *Session) IsActive() bool {
+	return s.RevokedAt == nil && time.Now().Before(s.ExpiresAt)
+}
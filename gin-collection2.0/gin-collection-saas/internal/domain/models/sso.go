@@ -0,0 +1,277 @@
+package models
+
+import (
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// SSO protocols
+const (
+	SSOProtocolOIDC = "oidc"
+	SSOProtocolSAML = "saml"
+)
+
+// SSOSettingsKey is the key of the SSO configuration in the tenant settings
+const SSOSettingsKey = "sso"
+
+// SSOLoginStateTTL is how long a user has to complete the login at the identity provider
+const SSOLoginStateTTL = 10 * time.Minute
+
+// Default claim and attribute names
+const (
+	DefaultSSOGroupsClaim         = "groups"
+	DefaultSAMLEmailAttribute     = "email"
+	DefaultSAMLFirstNameAttribute = "firstName"
+	DefaultSAMLLastNameAttribute  = "lastName"
+)
+
+// DefaultOIDCScopes are requested if a tenant doesn't configure scopes
+var DefaultOIDCScopes = []string{"openid", "email", "profile"}
+
+// SSOSettings is the single sign-on configuration of an Enterprise tenant,
+// stored under the "sso" key of the tenant settings.
+//
+// With a role mapping the identity provider owns the roles: on every login the
+// highest role of the user's groups (or the default role) is applied. Without a
+// mapping roles are managed in the app and the default role only applies to
+// users created on their first login. Owners are never changed.
+type SSOSettings struct {
+	Enabled              bool                `json:"enabled"`
+	Protocol             string              `json:"protocol" binding:"omitempty,oneof=oidc saml"`
+	DisablePasswordLogin bool                `json:"disable_password_login"`
+	JITProvisioning      bool                `json:"jit_provisioning"`
+	DefaultRole          UserRole            `json:"default_role,omitempty" binding:"omitempty,oneof=admin member viewer"`
+	GroupsClaim          string              `json:"groups_claim,omitempty" binding:"max=255"`
+	RoleMapping          map[string]UserRole `json:"role_mapping,omitempty"`
+	OIDC                 *OIDCSettings       `json:"oidc,omitempty"`
+	SAML                 *SAMLSettings       `json:"saml,omitempty"`
+}
+
+// OIDCSettings configures an OpenID Connect identity provider
+type OIDCSettings struct {
+	Issuer       string   `json:"issuer" binding:"max=500"`
+	ClientID     string   `json:"client_id" binding:"max=255"`
+	ClientSecret string   `json:"client_secret,omitempty" binding:"max=500"`
+	Scopes       []string `json:"scopes,omitempty"`
+}
+
+// SAMLSettings configures a SAML 2.0 identity provider
+type SAMLSettings struct {
+	IdPEntityID        string `json:"idp_entity_id" binding:"max=500"`
+	IdPSSOURL          string `json:"idp_sso_url" binding:"max=500"`
+	IdPCertificate     string `json:"idp_certificate"`
+	EmailAttribute     string `json:"email_attribute,omitempty" binding:"max=255"`
+	FirstNameAttribute string `json:"first_name_attribute,omitempty" binding:"max=255"`
+	LastNameAttribute  string `json:"last_name_attribute,omitempty" binding:"max=255"`
+}
+
+// Validate checks an SSO configuration before it is stored
+func (s *SSOSettings) Validate() error {
+	if s.DefaultRole != "" && !isMappableRole(s.DefaultRole) {
+		return fmt.Errorf("default_role must be admin, member or viewer")
+	}
+	for group, role := range s.RoleMapping {
+		if strings.TrimSpace(group) == "" {
+			return fmt.Errorf("role_mapping contains an empty group")
+		}
+		if !isMappableRole(role) {
+			return fmt.Errorf("role_mapping of %q must be admin, member or viewer", group)
+		}
+	}
+
+	// A disabled configuration may be incomplete
+	if !s.Enabled {
+		if s.DisablePasswordLogin {
+			return fmt.Errorf("password login can only be disabled while single sign-on is enabled")
+		}
+		return nil
+	}
+
+	switch s.Protocol {
+	case SSOProtocolOIDC:
+		if s.OIDC == nil {
+			return fmt.Errorf("oidc settings are required")
+		}
+		if err := validateSSOURL(s.OIDC.Issuer); err != nil {
+			return fmt.Errorf("oidc.issuer: %w", err)
+		}
+		if s.OIDC.ClientID == "" {
+			return fmt.Errorf("oidc.client_id is required")
+		}
+	case SSOProtocolSAML:
+		if s.SAML == nil {
+			return fmt.Errorf("saml settings are required")
+		}
+		if s.SAML.IdPEntityID == "" {
+			return fmt.Errorf("saml.idp_entity_id is required")
+		}
+		if err := validateSSOURL(s.SAML.IdPSSOURL); err != nil {
+			return fmt.Errorf("saml.idp_sso_url: %w", err)
+		}
+		if _, err := ParseSAMLCertificates(s.SAML.IdPCertificate); err != nil {
+			return fmt.Errorf("saml.idp_certificate: %w", err)
+		}
+	default:
+		return fmt.Errorf("protocol must be oidc or saml")
+	}
+
+	return nil
+}
+
+// MapRole returns the role for the given IdP groups and whether the identity
+// provider decides the role at all (i.e. a role mapping is configured)
+func (s *SSOSettings) MapRole(groups []string) (UserRole, bool) {
+	if len(s.RoleMapping) == 0 {
+		return s.defaultRole(), false
+	}
+
+	var role UserRole
+	for _, group := range groups {
+		mapped, ok := s.RoleMapping[group]
+		if ok && rolePrecedence[mapped] > rolePrecedence[role] {
+			role = mapped
+		}
+	}
+	if role == "" {
+		role = s.defaultRole()
+	}
+
+	return role, true
+}
+
+// PasswordLoginDisabled reports whether users must sign in with SSO
+func (s *SSOSettings) PasswordLoginDisabled() bool {
+	return s.Enabled && s.DisablePasswordLogin
+}
+
+// GroupsClaimName returns the claim (OIDC) or attribute (SAML) holding the groups
+func (s *SSOSettings) GroupsClaimName() string {
+	if s.GroupsClaim == "" {
+		return DefaultSSOGroupsClaim
+	}
+	return s.GroupsClaim
+}
+
+func (s *SSOSettings) defaultRole() UserRole {
+	if s.DefaultRole == "" {
+		return RoleMember
+	}
+	return s.DefaultRole
+}
+
+// rolePrecedence ranks the roles an identity provider may grant
+var rolePrecedence = map[UserRole]int{
+	RoleViewer: 1,
+	RoleMember: 2,
+	RoleAdmin:  3,
+}
+
+// isMappableRole reports whether SSO may grant a role. Ownership is never
+// granted by an identity provider.
+func isMappableRole(role UserRole) bool {
+	_, ok := rolePrecedence[role]
+	return ok
+}
+
+// validateSSOURL requires https, except for local development
+func validateSSOURL(raw string) error {
+	u, err := url.Parse(raw)
+	if err != nil || u.Host == "" {
+		return fmt.Errorf("must be an absolute URL")
+	}
+	switch u.Scheme {
+	case "https":
+		return nil
+	case "http":
+		host := u.Hostname()
+		if host == "localhost" || host == "127.0.0.1" || host == "::1" {
+			return nil
+		}
+	}
+	return fmt.Errorf("must use https")
+}
+
+// ParseSAMLCertificates parses the signing certificates of a SAML identity
+// provider, either PEM encoded or as the bare base64 from IdP metadata
+func ParseSAMLCertificates(data string) ([]*x509.Certificate, error) {
+	data = strings.TrimSpace(data)
+	if data == "" {
+		return nil, fmt.Errorf("certificate is required")
+	}
+	if !strings.HasPrefix(data, "-----BEGIN") {
+		data = "-----BEGIN CERTIFICATE-----\n" + data + "\n-----END CERTIFICATE-----"
+	}
+
+	var certs []*x509.Certificate
+	rest := []byte(data)
+	for {
+		var block *pem.Block
+		block, rest = pem.Decode(rest)
+		if block == nil {
+			break
+		}
+		if block.Type != "CERTIFICATE" {
+			continue
+		}
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("invalid certificate: %w", err)
+		}
+		certs = append(certs, cert)
+	}
+
+	if len(certs) == 0 {
+		return nil, fmt.Errorf("no certificate found")
+	}
+
+	return certs, nil
+}
+
+// SSOServiceProvider lists the endpoints a tenant registers at its identity provider
+type SSOServiceProvider struct {
+	LoginURL        string `json:"login_url"`
+	OIDCRedirectURI string `json:"oidc_redirect_uri"`
+	SAMLEntityID    string `json:"saml_entity_id"`
+	SAMLACSURL      string `json:"saml_acs_url"`
+	SAMLMetadataURL string `json:"saml_metadata_url"`
+}
+
+// SSOLoginInfo tells the login page which sign-in methods a tenant offers
+type SSOLoginInfo struct {
+	Enabled              bool   `json:"enabled"`
+	Protocol             string `json:"protocol,omitempty"`
+	PasswordLoginEnabled bool   `json:"password_login_enabled"`
+	LoginURL             string `json:"login_url,omitempty"`
+}
+
+// SSOLoginState is a pending SSO login, consumed by the callback
+type SSOLoginState struct {
+	ID           string // SHA-256 of the state parameter
+	TenantID     int64
+	Protocol     string
+	Nonce        string // OIDC
+	CodeVerifier string // OIDC (PKCE)
+	RequestID    string // SAML AuthnRequest ID
+	RedirectPath string
+	ExpiresAt    time.Time
+	CreatedAt    time.Time
+}
+
+// IsExpired checks if the login took too long
+func (s *SSOLoginState) IsExpired() bool {
+	return time.Now().After(s.ExpiresAt)
+}
+
+// SSOIdentity links an identity provider subject to a user
+type SSOIdentity struct {
+	ID        int64
+	TenantID  int64
+	UserID    int64
+	Protocol  string
+	Subject   string
+	CreatedAt time.Time
+}
@@ -0,0 +1,43 @@
+package models
+
+import "time"
+
+// BytesPerMB is the number of bytes in a megabyte (as used for plan limits)
+const BytesPerMB = 1024 * 1024
+
+// StorageUsage represents the storage used by a tenant
+type StorageUsage struct {
+	TenantID     int64      `json:"tenant_id"`
+	BytesUsed    int64      `json:"bytes_used"`
+	PhotoCount   int        `json:"photo_count"`
+	ReconciledAt *time.Time `json:"reconciled_at,omitempty"`
+	UpdatedAt    time.Time  `json:"updated_at"`
+}
+
+// MB returns the used storage in megabytes
+func (u *StorageUsage) MB() float64 {
+	return float64(u.BytesUsed) / BytesPerMB
+}
+
+// WouldExceed checks if adding the given bytes exceeds a limit in MB (nil = unlimited)
+func (u *StorageUsage) WouldExceed(addBytes int64, limitMB *int) bool {
+	if limitMB == nil {
+		return false
+	}
+	return u.BytesUsed+addBytes > int64(*limitMB)*BytesPerMB
+}
+
+// PhotoQuota holds the limits a new photo is checked against when its record
+// is created, in the same transaction as the storage usage update
+type PhotoQuota struct {
+	MaxPhotosPerGin int  // -1 = unlimited
+	StorageLimitMB  *int // nil = unlimited
+}
+
+// GinStorageUsage represents the storage used by the photos of one gin
+type GinStorageUsage struct {
+	GinID      int64  `json:"gin_id"`
+	GinName    string `json:"gin_name"`
+	PhotoCount int    `json:"photo_count"`
+	BytesUsed  int64  `json:"bytes_used"`
+}
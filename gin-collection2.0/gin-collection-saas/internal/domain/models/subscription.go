@@ -4,25 +4,38 @@ import "time"
 
 // Subscription represents a tenant's subscription
 type Subscription struct {
-	ID                   int64              `json:"id"`
-	TenantID             int64              `json:"tenant_id"`
-	UUID                 string             `json:"uuid"`
-	PlanID               string             `json:"plan_id"` // free, basic_monthly, pro_yearly, etc.
-	Status               SubscriptionStatus `json:"status"`
-	BillingCycle         BillingCycle       `json:"billing_cycle"`
-	CurrentPeriodStart   *time.Time         `json:"current_period_start,omitempty"`
-	CurrentPeriodEnd     *time.Time         `json:"current_period_end,omitempty"`
-	NextBillingDate      *time.Time         `json:"next_billing_date,omitempty"`
-	CancelAtPeriodEnd    bool               `json:"cancel_at_period_end"`
-	PayPalCustomerID     *string            `json:"paypal_customer_id,omitempty"`
-	PayPalSubscriptionID *string            `json:"paypal_subscription_id,omitempty"`
-	PayPalPlanID         *string            `json:"paypal_plan_id,omitempty"`
-	Amount               float64            `json:"amount"`
-	Currency             string             `json:"currency"`
-	TrialEndsAt          *time.Time         `json:"trial_ends_at,omitempty"`
-	CancelledAt          *time.Time         `json:"cancelled_at,omitempty"`
-	CreatedAt            time.Time          `json:"created_at"`
-	UpdatedAt            time.Time          `json:"updated_at"`
+	ID                     int64              `json:"id"`
+	TenantID               int64              `json:"tenant_id"`
+	UUID                   string             `json:"uuid"`
+	PlanID                 string             `json:"plan_id"` // free, basic_monthly, pro_yearly, etc.
+	PlanVersion            int                `json:"plan_version"` // version of the plan the subscriber keeps
+	Status                 SubscriptionStatus `json:"status"`
+	BillingCycle           BillingCycle       `json:"billing_cycle"`
+	CurrentPeriodStart     *time.Time         `json:"current_period_start,omitempty"`
+	CurrentPeriodEnd       *time.Time         `json:"current_period_end,omitempty"`
+	NextBillingDate        *time.Time         `json:"next_billing_date,omitempty"`
+	CancelAtPeriodEnd      bool               `json:"cancel_at_period_end"`
+	ScheduledPlanID        *string            `json:"scheduled_plan_id,omitempty"` // plan change at the end of the period
+	ScheduledChangeAt      *time.Time         `json:"scheduled_change_at,omitempty"`
+	CouponID               *int64             `json:"coupon_id,omitempty"`
+	Discount               float64            `json:"discount"` // per billing period while the coupon applies
+	DiscountEndsAt         *time.Time         `json:"discount_ends_at,omitempty"` // nil = the discount doesn't end
+	Provider               string             `json:"provider"` // paypal, stripe
+	ProviderCustomerID     *string            `json:"provider_customer_id,omitempty"`
+	ProviderSubscriptionID *string            `json:"provider_subscription_id,omitempty"`
+	ProviderPlanID         *string            `json:"provider_plan_id,omitempty"`
+	ProviderCheckoutID     *string            `json:"-"`
+	Amount                 float64            `json:"amount"`
+	Currency               string             `json:"currency"`
+	TrialEndsAt            *time.Time         `json:"trial_ends_at,omitempty"`
+	TrialReminderSentAt    *time.Time         `json:"-"`
+	PastDueSince           *time.Time         `json:"past_due_since,omitempty"` // the payment failed
+	DunningStage           DunningStage       `json:"dunning_stage,omitempty"`
+	DunningRemindersSent   int                `json:"-"`
+	PausedAt               *time.Time         `json:"paused_at,omitempty"` // paused by the owner
+	CancelledAt            *time.Time         `json:"cancelled_at,omitempty"`
+	CreatedAt              time.Time          `json:"created_at"`
+	UpdatedAt              time.Time          `json:"updated_at"`
 }
 
 // SubscriptionStatus represents the status of a subscription
@@ -34,10 +47,26 @@ const (
 	SubscriptionStatusPastDue   SubscriptionStatus = "past_due"
 	SubscriptionStatusCancelled SubscriptionStatus = "cancelled"
 	SubscriptionStatusSuspended SubscriptionStatus = "suspended"
+	SubscriptionStatusPaused    SubscriptionStatus = "paused" // by the owner, billing stops until resumed
 	SubscriptionStatusExpired   SubscriptionStatus = "expired"
 	SubscriptionStatusTrialing  SubscriptionStatus = "trialing"
 )
 
+// Billing providers subscriptions can be billed with
+const (
+	BillingProviderPayPal = "paypal"
+	BillingProviderStripe = "stripe"
+)
+
+// BillingSettingsKey is the key of the billing settings in the tenant settings
+const BillingSettingsKey = "billing"
+
+// BillingSettings holds the billing preferences of a tenant
+type BillingSettings struct {
+	Provider string          `json:"provider,omitempty"` // empty uses the platform default
+	Details  *BillingDetails `json:"details,omitempty"`  // billing address on invoices
+}
+
 // BillingCycle represents how often the subscription is billed
 type BillingCycle string
 
@@ -46,112 +75,99 @@ const (
 	BillingCycleYearly  BillingCycle = "yearly"
 )
 
-// SubscriptionPlan represents a subscription plan
+// SubscriptionPlan represents a version of a subscription plan. Prices and
+// limits are versioned, subscribers keep the version they subscribed to.
 type SubscriptionPlan struct {
-	ID            string           `json:"id"`
-	Name          string           `json:"name"`
-	Tier          SubscriptionTier `json:"tier"`
-	BillingCycle  BillingCycle     `json:"billing_cycle"`
-	PriceMonthly  float64          `json:"price_monthly"`
-	PriceYearly   float64          `json:"price_yearly"`
-	Currency      string           `json:"currency"`
-	PayPalPlanID  string           `json:"paypal_plan_id"`
-	Features      []string         `json:"features"`
-	Limits        PlanLimits       `json:"limits"`
+	ID            string            `json:"id"`
+	Name          string            `json:"name"`
+	Tier          SubscriptionTier  `json:"tier"`
+	BillingCycle  BillingCycle      `json:"billing_cycle"` // empty = offered in both cycles
+	PriceMonthly  float64           `json:"price_monthly"`
+	PriceYearly   float64           `json:"price_yearly"`
+	Currency      string            `json:"currency"`
+	TrialDays     int               `json:"trial_days"` // 0 = no trial
+	Features      []string          `json:"features"`   // generated from the limits
+	Limits        PlanLimits        `json:"limits"`
+	Version       int               `json:"version"`
+	Active        bool              `json:"active"` // offered to new subscribers
+	SortOrder     int               `json:"sort_order"`
+	ProviderPlans map[string]string `json:"provider_plans,omitempty"` // billing provider -> plan or price ID of the version
+	CreatedBy     *int64            `json:"created_by,omitempty"`     // platform admin who created the version
+	CreatedAt     *time.Time        `json:"created_at,omitempty"`     // when the version was created
 }
 
 // Plan represents a subscription plan (alias for backward compatibility)
 type Plan = SubscriptionPlan
 
-// AvailablePlans defines all available subscription plans
-var AvailablePlans = []SubscriptionPlan{
+// FreePlanID is the plan of tenants without a subscription
+const FreePlanID = "PLAN_FREE"
+
+// DefaultPlans are the built-in plans. The plan catalog is seeded with them
+// and falls back to them when it isn't stored in the database.
+var DefaultPlans = []SubscriptionPlan{
 	{
-		ID:           "PLAN_FREE",
+		ID:           FreePlanID,
 		Name:         "Free",
 		Tier:         TierFree,
 		PriceMonthly: 0,
 		PriceYearly:  0,
 		Currency:     "EUR",
-		PayPalPlanID: "",
-		Features: []string{
-			"Bis zu 25 Gins",
-			"3 Fotos pro Gin",
-			"Basis-Statistiken",
-			"Community Support",
-		},
-		Limits: PlanLimitsMap[TierFree],
+		Limits:       PlanLimitsMap[TierFree],
+		Version:      1,
+		Active:       true,
+		SortOrder:    0,
 	},
 	{
 		ID:           "PLAN_BASIC_MONTHLY",
 		Name:         "Basic",
 		Tier:         TierBasic,
+		BillingCycle: BillingCycleMonthly,
 		PriceMonthly: 4.99,
 		PriceYearly:  49.99,
 		Currency:     "EUR",
-		PayPalPlanID: "P-BASIC-MONTHLY",
-		Features: []string{
-			"Bis zu 100 Gins",
-			"10 Fotos pro Gin",
-			"Erweiterte Statistiken",
-			"Export-Funktion",
-			"E-Mail Support",
-		},
-		Limits: PlanLimitsMap[TierBasic],
+		Limits:       PlanLimitsMap[TierBasic],
+		Version:      1,
+		Active:       true,
+		SortOrder:    10,
 	},
 	{
 		ID:           "PLAN_BASIC_YEARLY",
 		Name:         "Basic (Yearly)",
 		Tier:         TierBasic,
+		BillingCycle: BillingCycleYearly,
 		PriceMonthly: 4.99,
 		PriceYearly:  49.99,
 		Currency:     "EUR",
-		PayPalPlanID: "P-BASIC-YEARLY",
-		Features: []string{
-			"Bis zu 100 Gins",
-			"10 Fotos pro Gin",
-			"Erweiterte Statistiken",
-			"Export-Funktion",
-			"E-Mail Support",
-			"17% Ersparnis",
-		},
-		Limits: PlanLimitsMap[TierBasic],
+		Limits:       PlanLimitsMap[TierBasic],
+		Version:      1,
+		Active:       true,
+		SortOrder:    20,
 	},
 	{
 		ID:           "PLAN_PRO_MONTHLY",
 		Name:         "Pro",
 		Tier:         TierPro,
+		BillingCycle: BillingCycleMonthly,
 		PriceMonthly: 9.99,
 		PriceYearly:  99.99,
 		Currency:     "EUR",
-		PayPalPlanID: "P-PRO-MONTHLY",
-		Features: []string{
-			"Bis zu 500 Gins",
-			"25 Fotos pro Gin",
-			"Alle Statistiken",
-			"API-Zugang",
-			"Priority Support",
-			"Barcode-Scanner",
-		},
-		Limits: PlanLimitsMap[TierPro],
+		Limits:       PlanLimitsMap[TierPro],
+		Version:      1,
+		Active:       true,
+		SortOrder:    30,
 	},
 	{
 		ID:           "PLAN_PRO_YEARLY",
 		Name:         "Pro (Yearly)",
 		Tier:         TierPro,
+		BillingCycle: BillingCycleYearly,
 		PriceMonthly: 9.99,
 		PriceYearly:  99.99,
 		Currency:     "EUR",
-		PayPalPlanID: "P-PRO-YEARLY",
-		Features: []string{
-			"Bis zu 500 Gins",
-			"25 Fotos pro Gin",
-			"Alle Statistiken",
-			"API-Zugang",
-			"Priority Support",
-			"Barcode-Scanner",
-			"17% Ersparnis",
-		},
-		Limits: PlanLimitsMap[TierPro],
+		Limits:       PlanLimitsMap[TierPro],
+		Version:      1,
+		Active:       true,
+		SortOrder:    40,
 	},
 	{
 		ID:           "PLAN_ENTERPRISE",
@@ -160,35 +176,18 @@ var AvailablePlans = []SubscriptionPlan{
 		PriceMonthly: 29.99,
 		PriceYearly:  299.99,
 		Currency:     "EUR",
-		PayPalPlanID: "P-ENTERPRISE",
-		Features: []string{
-			"Unbegrenzte Gins",
-			"Unbegrenzte Fotos",
-			"Team-Verwaltung",
-			"White-Label Option",
-			"Dedizierter Support",
-			"Custom Integrations",
-			"SLA-Garantie",
-		},
-		Limits: PlanLimitsMap[TierEnterprise],
+		Limits:       PlanLimitsMap[TierEnterprise],
+		Version:      1,
+		Active:       true,
+		SortOrder:    50,
 	},
 }
 
-// GetPlanByID retrieves a plan by its ID
-func GetPlanByID(planID string) *SubscriptionPlan {
-	for i := range AvailablePlans {
-		if AvailablePlans[i].ID == planID {
-			return &AvailablePlans[i]
-		}
-	}
-	return nil
-}
-
 // UsageMetric represents usage tracking for a tenant
 type UsageMetric struct {
 	ID          int64     `json:"id"`
 	TenantID    int64     `json:"tenant_id"`
-	MetricName  string    `json:"metric_name"` // gin_count, api_calls, storage_mb
+	MetricName  string    `json:"metric_name"` // gin_count, api_calls, ai_suggestions, storage_mb
 	CurrentValue int      `json:"current_value"`
 	LimitValue  *int      `json:"limit_value,omitempty"` // nil = unlimited
 	PeriodStart time.Time `json:"period_start"`
@@ -203,7 +202,7 @@ type UpgradeRequest struct {
 
 // UpgradeResponse represents a subscription upgrade response
 type UpgradeResponse struct {
-	PayPalApprovalURL string        `json:"paypal_approval_url,omitempty"`
-	Subscription      *Subscription `json:"subscription"`
-	Message           string        `json:"message"`
+	ApprovalURL  string        `json:"approval_url,omitempty"`
+	Subscription *Subscription `json:"subscription"`
+	Message      string        `json:"message"`
 }
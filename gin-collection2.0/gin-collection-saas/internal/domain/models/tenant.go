@@ -15,6 +15,10 @@ type Tenant struct {
 	IsEnterprise       bool            `json:"is_enterprise"`
 	DBConnectionString *string         `json:"-"` // Hidden from JSON, only for Enterprise
 	Status             TenantStatus    `json:"status"`
+	RequireTwoFactor   bool            `json:"require_two_factor"`
+	BillingRestricted  bool            `json:"billing_restricted"` // a payment is overdue past the grace period
+	PlanLimits         *PlanLimits     `json:"-"`                  // limits of the subscribed plan version, nil = the tier's limits
+	LimitOverrides     *LimitOverrides `json:"limit_overrides,omitempty"` // limits set for the tenant by a platform admin
 	Settings           json.RawMessage `json:"settings,omitempty"`
 	Branding           *TenantBranding `json:"branding,omitempty"`
 	CreatedAt          time.Time       `json:"created_at"`
@@ -49,20 +53,32 @@ const (
 
 // PlanLimits defines limits for each subscription tier
 type PlanLimits struct {
-	MaxGins          *int  // nil = unlimited
-	MaxPhotosPerGin  int
-	HasBotanicals    bool
-	HasCocktails     bool
-	HasAISuggestions bool
-	HasExport        bool
-	HasImport        bool
-	HasMultiUser     bool
-	HasAPIAccess     bool
-	APIRateLimit     int  // requests per hour
-	StorageLimitMB   *int // nil = unlimited
+	MaxGins          *int `json:"max_gins"` // nil = unlimited
+	MaxPhotosPerGin  int  `json:"max_photos_per_gin"` // -1 = unlimited
+	HasBotanicals    bool `json:"has_botanicals"`
+	HasCocktails     bool `json:"has_cocktails"`
+	HasAISuggestions bool `json:"has_ai_suggestions"`
+	HasExport        bool `json:"has_export"`
+	HasImport        bool `json:"has_import"`
+	HasMultiUser     bool `json:"has_multi_user"`
+	HasAPIAccess     bool `json:"has_api_access"`
+	APIRateLimit     int  `json:"api_rate_limit"`   // requests per hour
+	StorageLimitMB   *int `json:"storage_limit_mb"` // nil = unlimited
+
+	// Single sign-on and SCIM user provisioning (Enterprise)
+	HasSSO  bool `json:"has_sso"`
+	HasSCIM bool `json:"has_scim"`
+
+	// Metered per billing period, nil = unlimited
+	MaxAPICallsPerPeriod      *int `json:"max_api_calls_per_period"`
+	MaxAISuggestionsPerPeriod *int `json:"max_ai_suggestions_per_period"`
+
+	// Tenant data in a database of its own (Enterprise)
+	HasDedicatedDatabase bool `json:"has_dedicated_database"`
 }
 
-// PlanLimitsMap defines limits for each tier
+// PlanLimitsMap defines the limits of each tier. Tenants without a plan of
+// the catalog (Free) and tenants an admin moved to a tier get these.
 var PlanLimitsMap = map[SubscriptionTier]PlanLimits{
 	TierFree: {
 		MaxGins:          intPtr(5),
@@ -115,12 +131,32 @@ var PlanLimitsMap = map[SubscriptionTier]PlanLimits{
 		HasAPIAccess:     true,
 		APIRateLimit:     10000,
 		StorageLimitMB:   nil, // unlimited
+		HasSSO:           true,
+		HasSCIM:          true,
+
+		HasDedicatedDatabase: true,
 	},
 }
 
-// GetLimits returns the plan limits for this tenant's tier
+// GetLimits returns the plan limits for this tenant: those of the plan
+// version it subscribed to, or else those of its tier, with the limits a
+// platform admin set for the tenant
 func (t *Tenant) GetLimits() PlanLimits {
-	return PlanLimitsMap[t.Tier]
+	limits := PlanLimitsMap[t.Tier]
+	if t.PlanLimits != nil {
+		limits = *t.PlanLimits
+	}
+	return t.LimitOverrides.Apply(limits)
+}
+
+// EnforcedLimits returns the limits enforced on the tenant's collection. While
+// a payment is overdue past the grace period these are the Free limits,
+// without overrides.
+func (t *Tenant) EnforcedLimits() PlanLimits {
+	if t.BillingRestricted {
+		return PlanLimitsMap[TierFree]
+	}
+	return t.GetLimits()
 }
 
 // helper function to create int pointer
@@ -0,0 +1,93 @@
+package models
+
+import "time"
+
+// Subjects that can have a second factor
+const (
+	TwoFactorSubjectUser          = "user"
+	TwoFactorSubjectPlatformAdmin = "platform_admin"
+)
+
+// Two-factor settings
+const (
+	RecoveryCodeCount           = 10
+	TrustedDeviceExpiry         = 30 * 24 * time.Hour
+	TwoFactorChallengeTTL       = 5 * time.Minute
+	TwoFactorMethodTOTP         = "totp"
+	TwoFactorMethodRecoveryCode = "recovery_code"
+)
+
+// TwoFactorCredential is the TOTP secret of a user or platform admin. It is
+// only active once the first code was confirmed.
+type TwoFactorCredential struct {
+	ID           int64      `json:"id"`
+	SubjectType  string     `json:"subject_type"`
+	SubjectID    int64      `json:"subject_id"`
+	Secret       string     `json:"-"`
+	ConfirmedAt  *time.Time `json:"confirmed_at,omitempty"`
+	LastUsedStep *int64     `json:"-"` // prevents replay of a code within its validity window
+	CreatedAt    time.Time  `json:"created_at"`
+	UpdatedAt    time.Time  `json:"updated_at"`
+}
+
+// IsEnabled checks if the credential has been confirmed
+func (c *TwoFactorCredential) IsEnabled() bool {
+	return c.ConfirmedAt != nil
+}
+
+// TrustedDevice is a device that may skip the second factor until it expires
+type TrustedDevice struct {
+	ID          int64     `json:"id"`
+	SubjectType string    `json:"subject_type"`
+	SubjectID   int64     `json:"subject_id"`
+	TokenHash   string    `json:"-"`
+	UserAgent   *string   `json:"user_agent,omitempty"`
+	ExpiresAt   time.Time `json:"expires_at"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// TwoFactorStatus describes the 2FA setup of a user
+type TwoFactorStatus struct {
+	Enabled                bool       `json:"enabled"`
+	Required               bool       `json:"required"`
+	ConfirmedAt            *time.Time `json:"confirmed_at,omitempty"`
+	RecoveryCodesRemaining int        `json:"recovery_codes_remaining"`
+}
+
+// TwoFactorEnrollment is returned when 2FA setup starts
+type TwoFactorEnrollment struct {
+	Secret     string `json:"secret"`
+	OTPAuthURI string `json:"otpauth_uri"`
+}
+
+// TwoFactorChallenge is returned by a login that still needs a second factor
+type TwoFactorChallenge struct {
+	ChallengeToken     string    `json:"challenge_token"`
+	Methods            []string  `json:"methods"`
+	EnrollmentRequired bool      `json:"enrollment_required"`
+	ExpiresAt          time.Time `json:"expires_at"`
+}
+
+// TwoFactorVerifyRequest completes a login with a second factor
+type TwoFactorVerifyRequest struct {
+	ChallengeToken string `json:"challenge_token" binding:"required"`
+	Code           string `json:"code"`
+	RecoveryCode   string `json:"recovery_code"`
+	RememberDevice bool   `json:"remember_device"`
+}
+
+// TwoFactorSetupRequest starts enrollment during a login challenge
+type TwoFactorSetupRequest struct {
+	ChallengeToken string `json:"challenge_token" binding:"required"`
+}
+
+// TwoFactorCodeRequest confirms an action with a TOTP code
+type TwoFactorCodeRequest struct {
+	Code string `json:"code" binding:"required"`
+}
+
+// TwoFactorDisableRequest disables 2FA with a TOTP or recovery code
+type TwoFactorDisableRequest struct {
+	Code         string `json:"code"`
+	RecoveryCode string `json:"recovery_code"`
+}
@@ -0,0 +1,42 @@
+package models
+
+import (
+	"math"
+	"time"
+)
+
+// UpcomingCharge is a payment the provider will collect on a billing date,
+// with its coupon discount and the VAT it includes
+type UpcomingCharge struct {
+	Date          time.Time    `json:"date"`
+	PlanID        string       `json:"plan_id"`
+	PlanName      string       `json:"plan_name"`
+	BillingCycle  BillingCycle `json:"billing_cycle"`
+	Price         float64      `json:"price"`
+	Discount      float64      `json:"discount"`
+	TotalAmount   float64      `json:"total_amount"` // price minus discount, what is paid
+	NetAmount     float64      `json:"net_amount"`
+	TaxRate       float64      `json:"tax_rate"` // percent
+	TaxAmount     float64      `json:"tax_amount"`
+	ReverseCharge bool         `json:"reverse_charge"`
+	Currency      string       `json:"currency"`
+	Scheduled     bool         `json:"scheduled"` // billed with a scheduled plan change
+}
+
+// ApplyTax splits the total of a charge into net amount and tax
+func (c *UpcomingCharge) ApplyTax(tax InvoiceTax) {
+	c.TaxRate = tax.Rate
+	c.ReverseCharge = tax.ReverseCharge
+	c.NetAmount = math.Round(c.TotalAmount/(1+tax.Rate/100)*100) / 100
+	c.TaxAmount = math.Round((c.TotalAmount-c.NetAmount)*100) / 100
+}
+
+// UpcomingCharges lists the next payments of a subscription. Paused
+// subscriptions and ones cancelled at the end of the period have none.
+type UpcomingCharges struct {
+	SubscriptionID    int64              `json:"subscription_id"`
+	Status            SubscriptionStatus `json:"status"`
+	CancelAtPeriodEnd bool               `json:"cancel_at_period_end"`
+	EndsAt            *time.Time         `json:"ends_at,omitempty"` // end of the last paid period
+	Charges           []UpcomingCharge   `json:"charges"`
+}
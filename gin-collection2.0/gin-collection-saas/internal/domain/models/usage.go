@@ -0,0 +1,132 @@
+package models
+
+import "time"
+
+// Metered usage, counted per billing period
+const (
+	MetricAPICalls      = "api_calls"      // requests made with API keys
+	MetricAISuggestions = "ai_suggestions" // AI suggestions and label scans
+	MetricStorageMB     = "storage_mb"     // highest storage used in the period
+)
+
+// MeteredMetrics are the metrics of the usage dashboard, in display order
+var MeteredMetrics = []string{MetricAPICalls, MetricAISuggestions, MetricStorageMB}
+
+// LimitOverrides are limits a platform admin set for a single tenant (e.g. a
+// negotiated deal), applied on top of the limits of its plan. nil keeps the
+// plan's limit, -1 is unlimited.
+type LimitOverrides struct {
+	MaxGins                   *int `json:"max_gins,omitempty"`
+	MaxPhotosPerGin           *int `json:"max_photos_per_gin,omitempty"`
+	StorageLimitMB            *int `json:"storage_limit_mb,omitempty"`
+	APIRateLimit              *int `json:"api_rate_limit,omitempty"` // requests per hour, 0 = unlimited
+	MaxAPICallsPerPeriod      *int `json:"max_api_calls_per_period,omitempty"`
+	MaxAISuggestionsPerPeriod *int `json:"max_ai_suggestions_per_period,omitempty"`
+}
+
+// IsEmpty reports whether no limit is overridden
+func (o *LimitOverrides) IsEmpty() bool {
+	return o == nil || *o == LimitOverrides{}
+}
+
+// Overrides reports whether the limit of a metric is overridden
+func (o *LimitOverrides) Overrides(metric string) bool {
+	if o == nil {
+		return false
+	}
+	switch metric {
+	case MetricAPICalls:
+		return o.MaxAPICallsPerPeriod != nil
+	case MetricAISuggestions:
+		return o.MaxAISuggestionsPerPeriod != nil
+	case MetricStorageMB:
+		return o.StorageLimitMB != nil
+	}
+	return false
+}
+
+// Apply returns the limits with the overrides applied
+func (o *LimitOverrides) Apply(limits PlanLimits) PlanLimits {
+	if o == nil {
+		return limits
+	}
+
+	if o.MaxGins != nil {
+		limits.MaxGins = overrideLimit(*o.MaxGins)
+	}
+	if o.MaxPhotosPerGin != nil {
+		limits.MaxPhotosPerGin = *o.MaxPhotosPerGin
+	}
+	if o.StorageLimitMB != nil {
+		limits.StorageLimitMB = overrideLimit(*o.StorageLimitMB)
+	}
+	if o.APIRateLimit != nil {
+		limits.APIRateLimit = *o.APIRateLimit
+	}
+	if o.MaxAPICallsPerPeriod != nil {
+		limits.MaxAPICallsPerPeriod = overrideLimit(*o.MaxAPICallsPerPeriod)
+	}
+	if o.MaxAISuggestionsPerPeriod != nil {
+		limits.MaxAISuggestionsPerPeriod = overrideLimit(*o.MaxAISuggestionsPerPeriod)
+	}
+	return limits
+}
+
+// overrideLimit converts an overridden limit to a plan limit, nil for unlimited
+func overrideLimit(value int) *int {
+	if value < 0 {
+		return nil
+	}
+	return &value
+}
+
+// MeteredLimit returns the limit of a metered metric, nil if unlimited
+func (l PlanLimits) MeteredLimit(metric string) *int {
+	switch metric {
+	case MetricAPICalls:
+		return l.MaxAPICallsPerPeriod
+	case MetricAISuggestions:
+		return l.MaxAISuggestionsPerPeriod
+	case MetricStorageMB:
+		return l.StorageLimitMB
+	}
+	return nil
+}
+
+// UsagePeriod is the usage of a tenant in one billing period
+type UsagePeriod struct {
+	PeriodStart time.Time     `json:"period_start"`
+	PeriodEnd   time.Time     `json:"period_end"`
+	Meters      []*UsageMeter `json:"meters"`
+}
+
+// UsageMeter is the consumption of a metric against its limit
+type UsageMeter struct {
+	Metric     string  `json:"metric"`
+	Current    int     `json:"current"`
+	Limit      *int    `json:"limit"` // nil = unlimited
+	Percentage float64 `json:"percentage"`
+	Reached    bool    `json:"reached"`              // the limit is used up
+	Overridden bool    `json:"overridden,omitempty"` // the limit was set for the tenant
+}
+
+// NewUsageMeter creates the meter of a metric
+func NewUsageMeter(metric string, current int, limit *int) *UsageMeter {
+	meter := &UsageMeter{Metric: metric, Current: current, Limit: limit}
+	if limit != nil {
+		meter.Reached = current >= *limit
+		if *limit > 0 {
+			meter.Percentage = float64(current) / float64(*limit) * 100
+		}
+	}
+	return meter
+}
+
+// UsageReport is the usage dashboard of a tenant: the current billing period
+// and earlier ones, newest first
+type UsageReport struct {
+	Current   *UsagePeriod    `json:"current"`
+	History   []*UsagePeriod  `json:"history"`
+	Limits    PlanLimits      `json:"limits"`
+	Overrides *LimitOverrides `json:"overrides,omitempty"`
+}
@@ -12,14 +12,20 @@ type User struct {
 	FirstName       *string   `json:"first_name,omitempty"`
 	LastName        *string   `json:"last_name,omitempty"`
 	Role            UserRole  `json:"role"`
-	APIKey          *string   `json:"api_key,omitempty"` // Enterprise only
+	CustomRoleID    *int64    `json:"custom_role_id,omitempty"` // Enterprise, replaces the permissions of the role
 	IsActive        bool      `json:"is_active"`
 	EmailVerifiedAt *time.Time `json:"email_verified_at,omitempty"`
 	LastLoginAt     *time.Time `json:"last_login_at,omitempty"`
+	LockedUntil     *time.Time `json:"locked_until,omitempty"` // set when listing users, not stored
 	CreatedAt       time.Time `json:"created_at"`
 	UpdatedAt       time.Time `json:"updated_at"`
 }
 
+// IsEmailVerified checks if the user confirmed their email address
+func (u *User) IsEmailVerified() bool {
+	return u.EmailVerifiedAt != nil
+}
+
 // UserRole represents the role of a user within a tenant
 type UserRole string
 
@@ -30,47 +36,11 @@ const (
 	RoleViewer UserRole = "viewer"
 )
 
-// HasPermission checks if the user role has permission for an action
-func (r UserRole) HasPermission(action string) bool {
-	permissions := map[UserRole]map[string]bool{
-		RoleOwner: {
-			"create":         true,
-			"read":           true,
-			"update":         true,
-			"delete":         true,
-			"manage_users":   true,
-			"manage_billing": true,
-		},
-		RoleAdmin: {
-			"create":       true,
-			"read":         true,
-			"update":       true,
-			"delete":       true,
-			"manage_users": true,
-		},
-		RoleMember: {
-			"create": true,
-			"read":   true,
-			"update": true,
-			"delete": false,
-		},
-		RoleViewer: {
-			"read": true,
-		},
-	}
-
-	rolePerms, exists := permissions[r]
-	if !exists {
-		return false
-	}
-
-	return rolePerms[action]
-}
-
 // LoginRequest represents a login request
 type LoginRequest struct {
-	Email    string `json:"email" binding:"required,email"`
-	Password string `json:"password" binding:"required,min=12"`
+	Email       string `json:"email" binding:"required,email"`
+	Password    string `json:"password" binding:"required,min=12"`
+	DeviceToken string `json:"device_token,omitempty"` // trusted device, skips the second factor
 }
 
 // RegisterRequest represents a registration request
@@ -89,4 +59,11 @@ type AuthResponse struct {
 	RefreshToken string  `json:"refresh_token,omitempty"`
 	User         *User   `json:"user"`
 	Tenant       *Tenant `json:"tenant"`
+
+	// Set instead of the tokens while a second factor is pending
+	TwoFactor *TwoFactorChallenge `json:"two_factor,omitempty"`
+	// Returned once when 2FA was enabled during login
+	RecoveryCodes []string `json:"recovery_codes,omitempty"`
+	// Set when the device should be remembered
+	TrustedDeviceToken string `json:"-"`
 }
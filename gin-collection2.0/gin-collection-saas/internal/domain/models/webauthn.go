@@ -0,0 +1,167 @@
+package models
+
+import "time"
+
+// WebAuthn ceremonies
+const (
+	WebAuthnCeremonyRegistration = "registration"
+	WebAuthnCeremonyLogin        = "login"
+)
+
+// WebAuthn settings
+const (
+	WebAuthnSessionTTL     = 5 * time.Minute
+	WebAuthnChallengeBytes = 32
+)
+
+// WebAuthnCredential is a passkey registered by a user or platform admin
+type WebAuthnCredential struct {
+	ID                int64      `json:"id"`
+	SubjectType       string     `json:"-"`
+	SubjectID         int64      `json:"-"`
+	CredentialID      string     `json:"credential_id"` // base64url
+	PublicKey         []byte     `json:"-"`             // COSE_Key
+	Algorithm         int64      `json:"algorithm"`
+	SignCount         uint32     `json:"-"`
+	AAGUID            []byte     `json:"-"`
+	AttestationFormat string     `json:"attestation_format"`
+	Transports        []string   `json:"transports,omitempty"`
+	BackupEligible    bool       `json:"backup_eligible"`
+	BackedUp          bool       `json:"backed_up"`
+	Name              string     `json:"name"`
+	LastUsedAt        *time.Time `json:"last_used_at,omitempty"`
+	CreatedAt         time.Time  `json:"created_at"`
+}
+
+// WebAuthnSession holds the challenge of a pending registration or login.
+// SubjectID is nil for a login without username (discoverable credentials).
+type WebAuthnSession struct {
+	ID          string    `json:"id"`
+	SubjectType string    `json:"subject_type"`
+	SubjectID   *int64    `json:"subject_id,omitempty"`
+	Ceremony    string    `json:"ceremony"`
+	Challenge   string    `json:"-"` // base64url
+	ExpiresAt   time.Time `json:"expires_at"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// IsExpired checks if the session has expired
+func (s *WebAuthnSession) IsExpired() bool {
+	return time.Now().After(s.ExpiresAt)
+}
+
+// WebAuthnRelyingPartyEntity identifies the relying party in creation options
+type WebAuthnRelyingPartyEntity struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+// WebAuthnUserEntity identifies the account in creation options
+type WebAuthnUserEntity struct {
+	ID          string `json:"id"` // base64url user handle
+	Name        string `json:"name"`
+	DisplayName string `json:"displayName"`
+}
+
+// WebAuthnCredentialParameter is an accepted key type
+type WebAuthnCredentialParameter struct {
+	Type string `json:"type"`
+	Alg  int64  `json:"alg"`
+}
+
+// WebAuthnCredentialDescriptor references an existing credential
+type WebAuthnCredentialDescriptor struct {
+	Type       string   `json:"type"`
+	ID         string   `json:"id"` // base64url
+	Transports []string `json:"transports,omitempty"`
+}
+
+// WebAuthnAuthenticatorSelection states authenticator requirements
+type WebAuthnAuthenticatorSelection struct {
+	ResidentKey      string `json:"residentKey"`
+	UserVerification string `json:"userVerification"`
+}
+
+// WebAuthnCreationOptions are passed to navigator.credentials.create()
+// (binary values base64url encoded, as in PublicKeyCredential.parseCreationOptionsFromJSON)
+type WebAuthnCreationOptions struct {
+	Challenge              string                         `json:"challenge"`
+	RP                     WebAuthnRelyingPartyEntity     `json:"rp"`
+	User                   WebAuthnUserEntity             `json:"user"`
+	PubKeyCredParams       []WebAuthnCredentialParameter  `json:"pubKeyCredParams"`
+	Timeout                int64                          `json:"timeout"`
+	ExcludeCredentials     []WebAuthnCredentialDescriptor `json:"excludeCredentials"`
+	AuthenticatorSelection WebAuthnAuthenticatorSelection `json:"authenticatorSelection"`
+	Attestation            string                         `json:"attestation"`
+}
+
+// WebAuthnRequestOptions are passed to navigator.credentials.get()
+type WebAuthnRequestOptions struct {
+	Challenge        string                         `json:"challenge"`
+	RPID             string                         `json:"rpId"`
+	Timeout          int64                          `json:"timeout"`
+	AllowCredentials []WebAuthnCredentialDescriptor `json:"allowCredentials"`
+	UserVerification string                         `json:"userVerification"`
+}
+
+// WebAuthnRegistrationBegin is returned when passkey registration starts
+type WebAuthnRegistrationBegin struct {
+	SessionID string                   `json:"session_id"`
+	PublicKey *WebAuthnCreationOptions `json:"public_key"`
+}
+
+// WebAuthnLoginBegin is returned when passkey login starts
+type WebAuthnLoginBegin struct {
+	SessionID string                  `json:"session_id"`
+	PublicKey *WebAuthnRequestOptions `json:"public_key"`
+}
+
+// WebAuthnAttestationResponse is the credential returned by navigator.credentials.create()
+type WebAuthnAttestationResponse struct {
+	ID       string `json:"id" binding:"required"`
+	RawID    string `json:"rawId"`
+	Type     string `json:"type" binding:"required,eq=public-key"`
+	Response struct {
+		ClientDataJSON    string   `json:"clientDataJSON" binding:"required"`
+		AttestationObject string   `json:"attestationObject" binding:"required"`
+		Transports        []string `json:"transports"`
+	} `json:"response"`
+}
+
+// WebAuthnAssertionResponse is the credential returned by navigator.credentials.get()
+type WebAuthnAssertionResponse struct {
+	ID       string `json:"id" binding:"required"`
+	RawID    string `json:"rawId"`
+	Type     string `json:"type" binding:"required,eq=public-key"`
+	Response struct {
+		ClientDataJSON    string `json:"clientDataJSON" binding:"required"`
+		AuthenticatorData string `json:"authenticatorData" binding:"required"`
+		Signature         string `json:"signature" binding:"required"`
+		UserHandle        string `json:"userHandle"`
+	} `json:"response"`
+}
+
+// WebAuthnRegistrationRequest completes a passkey registration
+type WebAuthnRegistrationRequest struct {
+	SessionID  string                      `json:"session_id" binding:"required"`
+	Name       string                      `json:"name" binding:"max=100"`
+	Credential WebAuthnAttestationResponse `json:"credential" binding:"required"`
+}
+
+// WebAuthnLoginBeginRequest starts a passkey login. Without an email the
+// browser offers all discoverable passkeys for this site.
+type WebAuthnLoginBeginRequest struct {
+	Email string `json:"email" binding:"omitempty,email"`
+}
+
+// WebAuthnLoginRequest completes a passkey login
+type WebAuthnLoginRequest struct {
+	SessionID  string                    `json:"session_id" binding:"required"`
+	Credential WebAuthnAssertionResponse `json:"credential" binding:"required"`
+}
+
+// WebAuthnAssertion is the result of a verified passkey login
+type WebAuthnAssertion struct {
+	Credential   *WebAuthnCredential
+	UserVerified bool // the authenticator verified the user (PIN, biometrics)
+}
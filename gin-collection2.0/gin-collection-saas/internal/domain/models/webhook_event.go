@@ -0,0 +1,44 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// WebhookEventStatus represents the processing state of a received webhook
+type WebhookEventStatus string
+
+const (
+	WebhookEventStatusReceived  WebhookEventStatus = "received"
+	WebhookEventStatusProcessed WebhookEventStatus = "processed"
+	WebhookEventStatusSkipped   WebhookEventStatus = "skipped" // older than an already processed event of the resource
+	WebhookEventStatusFailed    WebhookEventStatus = "failed"
+)
+
+// WebhookEvent is a webhook event received from a payment provider. Events
+// are stored by their provider ID so deliveries of the same event are only
+// processed once.
+type WebhookEvent struct {
+	ID                int64              `json:"id"`
+	Provider          string             `json:"provider"` // BillingProviderPayPal, BillingProviderStripe
+	EventID           string             `json:"event_id"`
+	EventType         string             `json:"event_type"`
+	ResourceID        string             `json:"resource_id"`
+	ResourceUpdatedAt *time.Time         `json:"resource_updated_at,omitempty"`
+	Status            WebhookEventStatus `json:"status"`
+	Attempts          int                `json:"attempts"`
+	Error             *string            `json:"error,omitempty"`
+	Payload           json.RawMessage    `json:"payload,omitempty"`
+	ReceivedAt        time.Time          `json:"received_at"`
+	ProcessedAt       *time.Time         `json:"processed_at,omitempty"`
+}
+
+// WebhookEventFilter represents filtering options for the webhook event list
+type WebhookEventFilter struct {
+	Provider   string
+	Status     WebhookEventStatus
+	EventType  string
+	ResourceID string
+	Limit      int
+	Offset     int
+}
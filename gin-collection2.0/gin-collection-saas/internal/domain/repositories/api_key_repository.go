@@ -0,0 +1,31 @@
+package repositories
+
+import (
+	"context"
+
+	"github.com/yourusername/gin-collection-saas/internal/domain/models"
+)
+
+// APIKeyRepository defines the interface for API key data access
+type APIKeyRepository interface {
+	// Create creates a new API key
+	Create(ctx context.Context, key *models.APIKey) error
+
+	// GetByHash retrieves an API key by the SHA-256 hash of the key
+	GetByHash(ctx context.Context, keyHash string) (*models.APIKey, error)
+
+	// GetByUUID retrieves an API key by its public ID
+	GetByUUID(ctx context.Context, uuid string) (*models.APIKey, error)
+
+	// ListByUser lists the keys of a user that are not revoked, expired ones included
+	ListByUser(ctx context.Context, userID int64) ([]*models.APIKey, error)
+
+	// CountActiveByUser counts the keys of a user that are neither revoked nor expired
+	CountActiveByUser(ctx context.Context, userID int64) (int, error)
+
+	// Revoke revokes an API key
+	Revoke(ctx context.Context, id int64) error
+
+	// TouchLastUsed records when and from where a key was last used
+	TouchLastUsed(ctx context.Context, id int64, ipAddress string) error
+}
@@ -0,0 +1,55 @@
+package repositories
+
+import (
+	"context"
+
+	"github.com/yourusername/gin-collection-saas/internal/domain/models"
+)
+
+// CouponRepository defines coupon data access
+type CouponRepository interface {
+	// Create creates a coupon, ErrConflict if the code is taken
+	Create(ctx context.Context, coupon *models.Coupon) error
+
+	// GetByID retrieves a coupon by ID
+	GetByID(ctx context.Context, id int64) (*models.Coupon, error)
+
+	// GetByCode retrieves a coupon by its normalized code
+	GetByCode(ctx context.Context, code string) (*models.Coupon, error)
+
+	// List lists all coupons, newest first
+	List(ctx context.Context) ([]*models.Coupon, error)
+
+	// Update updates the settings of a coupon, the code and redemptions are kept
+	Update(ctx context.Context, coupon *models.Coupon) error
+
+	// Delete deletes a coupon with its redemptions
+	Delete(ctx context.Context, id int64) error
+
+	// GetRedemption retrieves the redemption of a coupon by a tenant, pending
+	// or redeemed
+	GetRedemption(ctx context.Context, couponID, tenantID int64) (*models.CouponRedemption, error)
+
+	// Reserve records a pending redemption until redemption.ExpiresAt and
+	// counts it on the coupon, so concurrent checkouts can't exceed the
+	// redemption limit. An earlier pending redemption of the tenant is
+	// replaced. ErrConflict if the tenant redeemed the coupon before and
+	// ErrLimitReached if it has no redemptions left.
+	Reserve(ctx context.Context, redemption *models.CouponRedemption) error
+
+	// Release deletes the pending redemption of a tenant and uncounts it
+	Release(ctx context.Context, couponID, tenantID int64) error
+
+	// ReleaseExpired releases the pending redemptions that expired and
+	// returns how many were released
+	ReleaseExpired(ctx context.Context) (int, error)
+
+	// Redeem confirms the pending redemption of a tenant. Without one, e.g.
+	// after it expired, the redemption is recorded and counted: ErrConflict
+	// if the tenant redeemed the coupon before and ErrLimitReached if it has
+	// no redemptions left.
+	Redeem(ctx context.Context, redemption *models.CouponRedemption) error
+
+	// ListRedemptions lists the redemptions of a coupon, newest first
+	ListRedemptions(ctx context.Context, couponID int64) ([]*models.CouponRedemption, error)
+}
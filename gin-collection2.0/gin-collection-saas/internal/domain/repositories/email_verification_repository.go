@@ -0,0 +1,32 @@
+package repositories
+
+import (
+	"context"
+
+	"github.com/yourusername/gin-collection-saas/internal/domain/models"
+)
+
+// EmailVerificationRepository defines the interface for email verification data access
+type EmailVerificationRepository interface {
+	// Create creates a new verification
+	Create(ctx context.Context, verification *models.EmailVerification) error
+
+	// GetByTokenHash retrieves a verification by the hash of either of its tokens
+	GetByTokenHash(ctx context.Context, tokenHash string) (*models.EmailVerification, error)
+
+	// GetPending retrieves the latest uncompleted verification of a user for a purpose
+	GetPending(ctx context.Context, userID int64, purpose models.EmailVerificationPurpose) (*models.EmailVerification, error)
+
+	// Confirm marks one address of a verification as confirmed, the current
+	// address of an email change if old is set. Fails with ErrInvalidToken if
+	// the verification was completed or has expired.
+	Confirm(ctx context.Context, id int64, old bool) error
+
+	// Complete marks a verification as completed and stores email and
+	// verification time of the user. Fails with ErrInvalidToken if the
+	// verification was already completed.
+	Complete(ctx context.Context, id int64, user *models.User) error
+
+	// DeletePending deletes all uncompleted verifications of a user for a purpose
+	DeletePending(ctx context.Context, userID int64, purpose models.EmailVerificationPurpose) error
+}
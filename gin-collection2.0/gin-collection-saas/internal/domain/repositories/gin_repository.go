@@ -2,6 +2,8 @@ package repositories
 
 import (
 	"context"
+	"time"
+
 	"github.com/yourusername/gin-collection-saas/internal/domain/models"
 )
 
@@ -51,4 +53,20 @@ type UsageMetricsRepository interface {
 
 	// SetMetric sets a metric to a specific value
 	SetMetric(ctx context.Context, tenantID int64, metricName string, value int) error
+
+	// GetPeriodValue retrieves the value of a metric in the billing period
+	// starting at periodStart, 0 if nothing was recorded
+	GetPeriodValue(ctx context.Context, tenantID int64, metricName string, periodStart time.Time) (int, error)
+
+	// Record adds delta to a metric in its billing period and stores the limit
+	// that applied
+	Record(ctx context.Context, metric *models.UsageMetric, delta int) error
+
+	// RecordPeak raises a metric in its billing period to its current value if
+	// that is higher, and stores the limit that applied
+	RecordPeak(ctx context.Context, metric *models.UsageMetric) error
+
+	// ListSince lists the metrics of a tenant in the billing periods starting
+	// at or after since, newest period first
+	ListSince(ctx context.Context, tenantID int64, since time.Time) ([]*models.UsageMetric, error)
 }
@@ -0,0 +1,32 @@
+package repositories
+
+import (
+	"context"
+
+	"github.com/yourusername/gin-collection-saas/internal/domain/models"
+)
+
+// InviteTokenRepository defines the interface for invitation data access
+type InviteTokenRepository interface {
+	// CreateWithUser creates an invited user and its invitation in one
+	// transaction, setting the IDs of both
+	CreateWithUser(ctx context.Context, user *models.User, invite *models.InviteToken) error
+
+	// GetByTokenHash retrieves an invitation by the hash of its token
+	GetByTokenHash(ctx context.Context, tokenHash string) (*models.InviteToken, error)
+
+	// GetPendingByUserID retrieves the latest unaccepted invitation of a user
+	GetPendingByUserID(ctx context.Context, tenantID, userID int64) (*models.InviteToken, error)
+
+	// ListPending lists all unaccepted invitations of a tenant
+	ListPending(ctx context.Context, tenantID int64) ([]*models.PendingInvite, error)
+
+	// Accept marks an invitation as used and activates the invited user with
+	// the given password hash and name. Fails with ErrInvalidToken if the
+	// invitation was already used or has expired.
+	Accept(ctx context.Context, inviteID int64, user *models.User) error
+
+	// Replace deletes the pending invitations of the invite's user and
+	// creates the new one in one transaction
+	Replace(ctx context.Context, invite *models.InviteToken) error
+}
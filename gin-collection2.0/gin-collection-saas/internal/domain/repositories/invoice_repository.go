@@ -0,0 +1,23 @@
+package repositories
+
+import (
+	"context"
+
+	"github.com/yourusername/gin-collection-saas/internal/domain/models"
+)
+
+// InvoiceRepository defines invoice data access
+type InvoiceRepository interface {
+	// Create creates an invoice with the next number of the year it's issued
+	// in, ErrConflict if the payment has an invoice already
+	Create(ctx context.Context, invoice *models.Invoice, numberPrefix string) error
+
+	// GetByID retrieves an invoice of a tenant by ID
+	GetByID(ctx context.Context, tenantID, id int64) (*models.Invoice, error)
+
+	// GetByPayment retrieves the invoice of a provider payment
+	GetByPayment(ctx context.Context, provider, paymentID string) (*models.Invoice, error)
+
+	// List lists the invoices of a tenant, newest first, with the total number
+	List(ctx context.Context, filter *models.InvoiceFilter) ([]*models.Invoice, int, error)
+}
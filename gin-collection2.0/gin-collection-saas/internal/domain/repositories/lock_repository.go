@@ -0,0 +1,10 @@
+package repositories
+
+import "context"
+
+// LockRepository defines locks shared by all instances of the application
+type LockRepository interface {
+	// TryLock takes the named lock unless another instance holds it, without
+	// waiting. The lock is held until release is called.
+	TryLock(ctx context.Context, name string) (release func(), acquired bool, err error)
+}
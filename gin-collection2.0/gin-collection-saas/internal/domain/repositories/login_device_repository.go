@@ -0,0 +1,17 @@
+package repositories
+
+import (
+	"context"
+
+	"github.com/yourusername/gin-collection-saas/internal/domain/models"
+)
+
+// LoginDeviceRepository defines the interface for the devices and networks
+// users logged in from
+type LoginDeviceRepository interface {
+	// ListByUser lists the known devices of a user
+	ListByUser(ctx context.Context, userID int64) ([]*models.LoginDevice, error)
+
+	// Record adds a device and network of a user or updates when it was last seen
+	Record(ctx context.Context, device *models.LoginDevice) error
+}
@@ -8,19 +8,25 @@ import (
 
 // PhotoRepository defines photo data access
 type PhotoRepository interface {
-	// Create creates a new photo record
-	Create(ctx context.Context, photo *models.GinPhoto) error
+	// Create creates a new photo record (and adds it to the tenant's storage usage),
+	// failing with ErrPhotoLimitReached or ErrStorageLimitReached if the quota is exceeded
+	Create(ctx context.Context, photo *models.GinPhoto, quota *models.PhotoQuota) error
+
+	// CreateFromUpload creates the photo of a direct upload like Create and marks
+	// its upload session completed in the same transaction. Returns ErrConflict
+	// if the session is no longer pending.
+	CreateFromUpload(ctx context.Context, photo *models.GinPhoto, quota *models.PhotoQuota, sessionID int64) error
 
 	// GetByID retrieves a photo by ID
 	GetByID(ctx context.Context, tenantID, id int64) (*models.GinPhoto, error)
 
-	// GetByGinID retrieves all photos for a specific gin
+	// GetByGinID retrieves all photos for a specific gin in gallery order
 	GetByGinID(ctx context.Context, tenantID, ginID int64) ([]*models.GinPhoto, error)
 
-	// Update updates a photo record
+	// Update updates a photo record and replaces its tags (unless Tags is nil)
 	Update(ctx context.Context, photo *models.GinPhoto) error
 
-	// Delete deletes a photo record
+	// Delete deletes a photo record (and subtracts it from the tenant's storage usage)
 	Delete(ctx context.Context, tenantID, id int64) error
 
 	// SetPrimary sets a photo as primary (and unsets all others for that gin)
@@ -29,6 +35,28 @@ type PhotoRepository interface {
 	// CountByGinID counts photos for a gin
 	CountByGinID(ctx context.Context, tenantID, ginID int64) (int, error)
 
+	// CountBeyondLimit counts the photos ranked beyond maxPerGin in their gin
+	// (by gallery order) and the gins they belong to
+	CountBeyondLimit(ctx context.Context, tenantID int64, maxPerGin int) (photos int, gins int, err error)
+
 	// GetTotalStorageUsage gets total storage usage for a tenant in KB
 	GetTotalStorageUsage(ctx context.Context, tenantID int64) (int, error)
+
+	// ListByTenant retrieves all photos of a tenant
+	ListByTenant(ctx context.Context, tenantID int64) ([]*models.GinPhoto, error)
+
+	// UpdateStorageLocation updates the storage backend, key and URL of a photo
+	UpdateStorageLocation(ctx context.Context, tenantID, id int64, backend, storageKey, photoURL string) error
+
+	// List retrieves the photos of a tenant across all gins, newest first
+	List(ctx context.Context, filter *models.PhotoFilter) ([]*models.GinPhoto, int, error)
+
+	// UpdateSortOrder sets the order of all photos of a gin
+	UpdateSortOrder(ctx context.Context, tenantID, ginID int64, photoIDs []int64) error
+
+	// SetTags replaces the tags of a photo
+	SetTags(ctx context.Context, tenantID, photoID int64, tags []string) error
+
+	// UpdateFileSize corrects the recorded size of a photo (and the tenant's storage usage)
+	UpdateFileSize(ctx context.Context, tenantID, id int64, sizeBytes int64) error
 }
@@ -0,0 +1,29 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"github.com/yourusername/gin-collection-saas/internal/domain/models"
+)
+
+// PhotoUploadSessionRepository defines data access for direct upload sessions
+type PhotoUploadSessionRepository interface {
+	// Create creates a new upload session
+	Create(ctx context.Context, session *models.PhotoUploadSession) error
+
+	// GetByToken retrieves an upload session by token within a tenant
+	GetByToken(ctx context.Context, tenantID int64, token string) (*models.PhotoUploadSession, error)
+
+	// GetByStorageKey retrieves the upload session of an object key
+	GetByStorageKey(ctx context.Context, storageKey string) (*models.PhotoUploadSession, error)
+
+	// MarkExpired marks a pending session as expired
+	MarkExpired(ctx context.Context, id int64) error
+
+	// ListExpiredPending lists pending sessions that expired before the given time
+	ListExpiredPending(ctx context.Context, before time.Time, limit int) ([]*models.PhotoUploadSession, error)
+
+	// DeleteFinishedBefore removes completed and expired sessions older than the given time
+	DeleteFinishedBefore(ctx context.Context, before time.Time) (int64, error)
+}
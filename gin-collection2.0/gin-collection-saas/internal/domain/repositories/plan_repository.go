@@ -0,0 +1,29 @@
+package repositories
+
+import (
+	"context"
+
+	"github.com/yourusername/gin-collection-saas/internal/domain/models"
+)
+
+// PlanRepository defines plan catalog data access
+type PlanRepository interface {
+	// List lists the current versions of all plans, by sort order
+	List(ctx context.Context) ([]*models.SubscriptionPlan, error)
+
+	// GetByID retrieves the current version of a plan
+	GetByID(ctx context.Context, id string) (*models.SubscriptionPlan, error)
+
+	// ListVersions lists all versions of a plan, oldest first
+	ListVersions(ctx context.Context, id string) ([]*models.SubscriptionPlan, error)
+
+	// Create creates a plan with its first version, ErrConflict if the ID is taken
+	Create(ctx context.Context, plan *models.SubscriptionPlan) error
+
+	// Update updates the name, sort order and availability of a plan
+	Update(ctx context.Context, plan *models.SubscriptionPlan) error
+
+	// CreateVersion adds a version to a plan and makes it the current one,
+	// ErrConflict if the version exists
+	CreateVersion(ctx context.Context, plan *models.SubscriptionPlan) error
+}
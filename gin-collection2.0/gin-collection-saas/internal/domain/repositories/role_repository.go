@@ -0,0 +1,28 @@
+package repositories
+
+import (
+	"context"
+
+	"github.com/yourusername/gin-collection-saas/internal/domain/models"
+)
+
+// RoleRepository defines the interface for custom role data access
+type RoleRepository interface {
+	// Create creates a new custom role
+	Create(ctx context.Context, role *models.CustomRole) error
+
+	// GetByID retrieves a custom role of a tenant
+	GetByID(ctx context.Context, tenantID, id int64) (*models.CustomRole, error)
+
+	// ListByTenant lists the custom roles of a tenant with their number of users
+	ListByTenant(ctx context.Context, tenantID int64) ([]*models.CustomRole, error)
+
+	// Update updates the name, description and permissions of a custom role
+	Update(ctx context.Context, role *models.CustomRole) error
+
+	// Delete deletes a custom role of a tenant
+	Delete(ctx context.Context, tenantID, id int64) error
+
+	// SetUserRole assigns a custom role to a user, nil unassigns it
+	SetUserRole(ctx context.Context, tenantID, userID int64, roleID *int64) error
+}
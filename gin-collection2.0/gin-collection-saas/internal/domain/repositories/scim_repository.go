@@ -0,0 +1,40 @@
+package repositories
+
+import (
+	"context"
+
+	"github.com/yourusername/gin-collection-saas/internal/domain/models"
+)
+
+// SCIMRepository defines the interface for SCIM provisioning data access
+type SCIMRepository interface {
+	// GetToken retrieves the SCIM token of a tenant
+	GetToken(ctx context.Context, tenantID int64) (*models.SCIMToken, error)
+
+	// GetTokenByHash retrieves a SCIM token by the hash of its value
+	GetTokenByHash(ctx context.Context, tokenHash string) (*models.SCIMToken, error)
+
+	// SaveToken stores the SCIM token of a tenant, replacing the previous one
+	SaveToken(ctx context.Context, token *models.SCIMToken) error
+
+	// DeleteToken removes the SCIM token of a tenant
+	DeleteToken(ctx context.Context, tenantID int64) error
+
+	// TouchToken records the use of a SCIM token
+	TouchToken(ctx context.Context, id int64) error
+
+	// ListGroups lists the groups of a tenant with their members
+	ListGroups(ctx context.Context, tenantID int64) ([]*models.SCIMGroup, error)
+
+	// GetGroup retrieves a group with its members
+	GetGroup(ctx context.Context, tenantID, id int64) (*models.SCIMGroup, error)
+
+	// CreateGroup creates a group with its members
+	CreateGroup(ctx context.Context, group *models.SCIMGroup) error
+
+	// UpdateGroup updates name, external ID and members of a group
+	UpdateGroup(ctx context.Context, group *models.SCIMGroup) error
+
+	// DeleteGroup deletes a group
+	DeleteGroup(ctx context.Context, tenantID, id int64) error
+}
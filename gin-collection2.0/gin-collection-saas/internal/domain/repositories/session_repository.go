@@ -0,0 +1,33 @@
+package repositories
+
+import (
+	"context"
+
+	"github.com/yourusername/gin-collection-saas/internal/domain/models"
+)
+
+// SessionRepository defines the interface for login session data access
+type SessionRepository interface {
+	// Create creates a new session
+	Create(ctx context.Context, session *models.Session) error
+
+	// GetByUUID retrieves a session by its public ID
+	GetByUUID(ctx context.Context, uuid string) (*models.Session, error)
+
+	// ListActiveByUser lists the sessions of a user that are neither revoked nor expired
+	ListActiveByUser(ctx context.Context, userID int64) ([]*models.Session, error)
+
+	// Rotate replaces the refresh token ID of an active session if it is still
+	// the given one, returns ErrNotFound otherwise
+	Rotate(ctx context.Context, session *models.Session, previousTokenID string) error
+
+	// Revoke revokes a session
+	Revoke(ctx context.Context, id int64, reason string) error
+
+	// RevokeAllByUser revokes the active sessions of a user except one (0 for
+	// none) and returns the public IDs of the revoked sessions
+	RevokeAllByUser(ctx context.Context, userID, exceptID int64, reason string) ([]string, error)
+
+	// DeleteExpired removes expired sessions, revoked ones are kept until they expire
+	DeleteExpired(ctx context.Context) (int64, error)
+}
@@ -0,0 +1,25 @@
+package repositories
+
+import (
+	"context"
+
+	"github.com/yourusername/gin-collection-saas/internal/domain/models"
+)
+
+// SSORepository defines the interface for single sign-on data access
+type SSORepository interface {
+	// CreateLoginState stores a pending login
+	CreateLoginState(ctx context.Context, state *models.SSOLoginState) error
+
+	// ConsumeLoginState retrieves and deletes a pending login, so a callback can only be used once
+	ConsumeLoginState(ctx context.Context, id string) (*models.SSOLoginState, error)
+
+	// DeleteExpiredLoginStates removes logins that were never completed
+	DeleteExpiredLoginStates(ctx context.Context) (int64, error)
+
+	// GetIdentity retrieves the link of an identity provider subject
+	GetIdentity(ctx context.Context, tenantID int64, protocol, subject string) (*models.SSOIdentity, error)
+
+	// CreateIdentity links an identity provider subject to a user
+	CreateIdentity(ctx context.Context, identity *models.SSOIdentity) error
+}
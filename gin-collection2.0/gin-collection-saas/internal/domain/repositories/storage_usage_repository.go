@@ -0,0 +1,23 @@
+package repositories
+
+import (
+	"context"
+
+	"github.com/yourusername/gin-collection-saas/internal/domain/models"
+)
+
+// StorageUsageRepository defines data access for per-tenant storage usage.
+// Photo create/delete adjust usage transactionally in the photo repository.
+type StorageUsageRepository interface {
+	// GetUsage retrieves the storage usage of a tenant (zero usage if none recorded)
+	GetUsage(ctx context.Context, tenantID int64) (*models.StorageUsage, error)
+
+	// SetUsage overwrites the storage usage of a tenant (reconciliation)
+	SetUsage(ctx context.Context, tenantID int64, bytesUsed int64, photoCount int) error
+
+	// ComputeFromPhotos sums the recorded photo sizes of a tenant
+	ComputeFromPhotos(ctx context.Context, tenantID int64) (int64, int, error)
+
+	// GetBreakdownByGin returns storage usage per gin, largest first
+	GetBreakdownByGin(ctx context.Context, tenantID int64) ([]*models.GinStorageUsage, error)
+}
@@ -2,6 +2,7 @@ package repositories
 
 import (
 	"context"
+	"time"
 
 	"github.com/yourusername/gin-collection-saas/internal/domain/models"
 )
@@ -17,8 +18,11 @@ type SubscriptionRepository interface {
 	// GetByTenantID retrieves the current subscription for a tenant
 	GetByTenantID(ctx context.Context, tenantID int64) (*models.Subscription, error)
 
-	// GetByPayPalSubscriptionID retrieves a subscription by PayPal subscription ID
-	GetByPayPalSubscriptionID(ctx context.Context, paypalSubscriptionID string) (*models.Subscription, error)
+	// GetByProviderSubscriptionID retrieves a subscription by the subscription ID of its provider
+	GetByProviderSubscriptionID(ctx context.Context, provider, subscriptionID string) (*models.Subscription, error)
+
+	// GetByProviderCheckoutID retrieves a subscription by the checkout it was started with
+	GetByProviderCheckoutID(ctx context.Context, provider, checkoutID string) (*models.Subscription, error)
 
 	// Update updates a subscription
 	Update(ctx context.Context, subscription *models.Subscription) error
@@ -31,4 +35,16 @@ type SubscriptionRepository interface {
 
 	// GetActiveSubscription retrieves the active subscription for a tenant
 	GetActiveSubscription(ctx context.Context, tenantID int64) (*models.Subscription, error)
+
+	// ListScheduledChanges retrieves the subscriptions with a plan change due at the given time
+	ListScheduledChanges(ctx context.Context, dueBefore time.Time) ([]*models.Subscription, error)
+
+	// ListCancellationsDue retrieves the active subscriptions cancelled at the end of a period that ended at the given time
+	ListCancellationsDue(ctx context.Context, dueBefore time.Time) ([]*models.Subscription, error)
+
+	// ListTrialsEndingBefore retrieves the trialing subscriptions whose trial ends before the given time
+	ListTrialsEndingBefore(ctx context.Context, before time.Time) ([]*models.Subscription, error)
+
+	// ListInDunning retrieves the subscriptions whose overdue payment is being collected
+	ListInDunning(ctx context.Context) ([]*models.Subscription, error)
 }
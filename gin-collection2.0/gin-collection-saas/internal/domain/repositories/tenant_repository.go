@@ -2,6 +2,8 @@ package repositories
 
 import (
 	"context"
+	"encoding/json"
+
 	"github.com/yourusername/gin-collection-saas/internal/domain/models"
 )
 
@@ -27,4 +29,24 @@ type TenantRepository interface {
 
 	// UpdateTier updates tenant subscription tier
 	UpdateTier(ctx context.Context, id int64, tier models.SubscriptionTier) error
+
+	// UpdateRequireTwoFactor sets whether all users of a tenant must use 2FA
+	UpdateRequireTwoFactor(ctx context.Context, id int64, required bool) error
+
+	// UpdateBillingRestricted sets whether a tenant is held to the Free limits
+	// for an overdue payment
+	UpdateBillingRestricted(ctx context.Context, id int64, restricted bool) error
+
+	// UpdateLimitOverrides sets the limits a platform admin set for a tenant,
+	// nil removes them
+	UpdateLimitOverrides(ctx context.Context, id int64, overrides *models.LimitOverrides) error
+
+	// GetSettings retrieves the settings document of a tenant
+	GetSettings(ctx context.Context, id int64) (json.RawMessage, error)
+
+	// UpdateSettings replaces the settings document of a tenant
+	UpdateSettings(ctx context.Context, id int64, settings json.RawMessage) error
+
+	// ListIDs lists the IDs of all tenants (for maintenance jobs)
+	ListIDs(ctx context.Context) ([]int64, error)
 }
@@ -0,0 +1,46 @@
+package repositories
+
+import (
+	"context"
+
+	"github.com/yourusername/gin-collection-saas/internal/domain/models"
+)
+
+// TwoFactorRepository defines the interface for second factor data access.
+// Subjects are identified by type (user, platform admin) and ID.
+type TwoFactorRepository interface {
+	// GetCredential retrieves the TOTP credential of a subject
+	GetCredential(ctx context.Context, subjectType string, subjectID int64) (*models.TwoFactorCredential, error)
+
+	// SaveCredential creates or replaces the (unconfirmed) TOTP credential of a subject
+	SaveCredential(ctx context.Context, credential *models.TwoFactorCredential) error
+
+	// ConfirmCredential activates a credential
+	ConfirmCredential(ctx context.Context, id int64) error
+
+	// UseStep records the time step of an accepted code. Returns false if
+	// the step (or a later one) was already used.
+	UseStep(ctx context.Context, id int64, step int64) (bool, error)
+
+	// Delete removes credential, recovery codes and trusted devices of a subject
+	Delete(ctx context.Context, subjectType string, subjectID int64) error
+
+	// ReplaceRecoveryCodes replaces all recovery codes of a subject
+	ReplaceRecoveryCodes(ctx context.Context, subjectType string, subjectID int64, codeHashes []string) error
+
+	// UseRecoveryCode marks an unused recovery code as used. Returns false if
+	// there is no such unused code.
+	UseRecoveryCode(ctx context.Context, subjectType string, subjectID int64, codeHash string) (bool, error)
+
+	// CountRecoveryCodes counts the unused recovery codes of a subject
+	CountRecoveryCodes(ctx context.Context, subjectType string, subjectID int64) (int, error)
+
+	// CreateTrustedDevice stores a remembered device
+	CreateTrustedDevice(ctx context.Context, device *models.TrustedDevice) error
+
+	// IsTrustedDevice checks for an unexpired trusted device token of a subject
+	IsTrustedDevice(ctx context.Context, subjectType string, subjectID int64, tokenHash string) (bool, error)
+
+	// DeleteTrustedDevices forgets all trusted devices of a subject
+	DeleteTrustedDevices(ctx context.Context, subjectType string, subjectID int64) error
+}
@@ -19,9 +19,6 @@ type UserRepository interface {
 	// GetByEmailGlobal retrieves a user by email across all tenants (for login without subdomain)
 	GetByEmailGlobal(ctx context.Context, email string) (*models.User, error)
 
-	// GetByAPIKey retrieves a user by API key
-	GetByAPIKey(ctx context.Context, apiKey string) (*models.User, error)
-
 	// Update updates a user
 	Update(ctx context.Context, user *models.User) error
 
@@ -34,12 +31,6 @@ type UserRepository interface {
 	// Delete deletes a user
 	Delete(ctx context.Context, id int64) error
 
-	// GenerateAPIKey generates a new API key for a user (Enterprise only)
-	GenerateAPIKey(ctx context.Context, userID int64) (string, error)
-
-	// RevokeAPIKey revokes the API key for a user
-	RevokeAPIKey(ctx context.Context, userID int64) error
-
 	// CountByTenant counts users in a tenant
 	CountByTenant(ctx context.Context, tenantID int64) (int, error)
 }
@@ -0,0 +1,35 @@
+package repositories
+
+import (
+	"context"
+
+	"github.com/yourusername/gin-collection-saas/internal/domain/models"
+)
+
+// WebAuthnRepository defines the interface for passkey data access.
+// Subjects are identified by type (user, platform admin) and ID.
+type WebAuthnRepository interface {
+	// CreateCredential stores a registered passkey
+	CreateCredential(ctx context.Context, credential *models.WebAuthnCredential) error
+
+	// GetCredentialByCredentialID retrieves a passkey by its base64url credential ID
+	GetCredentialByCredentialID(ctx context.Context, credentialID string) (*models.WebAuthnCredential, error)
+
+	// ListCredentials lists the passkeys of a subject
+	ListCredentials(ctx context.Context, subjectType string, subjectID int64) ([]*models.WebAuthnCredential, error)
+
+	// UpdateCredentialUsage stores the sign count and backup state after a login
+	UpdateCredentialUsage(ctx context.Context, id int64, signCount uint32, backedUp bool) error
+
+	// DeleteCredential removes a passkey of a subject
+	DeleteCredential(ctx context.Context, subjectType string, subjectID int64, id int64) error
+
+	// CreateSession stores a pending registration or login challenge
+	CreateSession(ctx context.Context, session *models.WebAuthnSession) error
+
+	// ConsumeSession retrieves and deletes a session, so a challenge can only be answered once
+	ConsumeSession(ctx context.Context, id string) (*models.WebAuthnSession, error)
+
+	// DeleteExpiredSessions removes sessions that were never completed
+	DeleteExpiredSessions(ctx context.Context) (int64, error)
+}
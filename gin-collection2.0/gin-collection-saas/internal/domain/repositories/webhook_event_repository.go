@@ -0,0 +1,31 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"github.com/yourusername/gin-collection-saas/internal/domain/models"
+)
+
+// WebhookEventRepository defines the interface for received webhook events
+type WebhookEventRepository interface {
+	// Create stores a received event, ErrConflict if the provider already
+	// delivered an event with the same ID
+	Create(ctx context.Context, event *models.WebhookEvent) error
+
+	// GetByID retrieves an event by its ID
+	GetByID(ctx context.Context, id int64) (*models.WebhookEvent, error)
+
+	// GetByEventID retrieves an event by the ID the provider assigned to it
+	GetByEventID(ctx context.Context, provider, eventID string) (*models.WebhookEvent, error)
+
+	// Update updates the status, attempts, error and processing time of an event
+	Update(ctx context.Context, event *models.WebhookEvent) error
+
+	// LatestResourceUpdate returns the latest resource update time of the
+	// processed events of a resource, nil if none was processed
+	LatestResourceUpdate(ctx context.Context, provider, resourceID string) (*time.Time, error)
+
+	// List lists events, newest first, with the total number of matches
+	List(ctx context.Context, filter *models.WebhookEventFilter) ([]*models.WebhookEvent, int, error)
+}
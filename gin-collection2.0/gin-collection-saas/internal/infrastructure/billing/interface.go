@@ -0,0 +1,301 @@
+package billing
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"time"
+)
+
+var (
+	// ErrUnknownProvider is returned for providers that aren't configured
+	ErrUnknownProvider = errors.New("unknown billing provider")
+	// ErrPlanNotOffered is returned for plans a provider has no plan or price for
+	ErrPlanNotOffered = errors.New("plan is not offered by the billing provider")
+	// ErrInvalidSignature is returned for webhook deliveries the provider didn't sign
+	ErrInvalidSignature = errors.New("invalid webhook signature")
+	// ErrNoCustomer is returned when a provider needs a customer that doesn't exist yet
+	ErrNoCustomer = errors.New("no billing customer exists yet")
+	// ErrDiscountNotSupported is returned for discounts a provider can't apply
+	ErrDiscountNotSupported = errors.New("discount is not supported by the billing provider")
+)
+
+// Subscription states reported by providers, the values match models.SubscriptionStatus
+const (
+	StatusPending   = "pending"
+	StatusActive    = "active"
+	StatusPastDue   = "past_due"
+	StatusSuspended = "suspended"
+	StatusCancelled = "cancelled"
+	StatusExpired   = "expired"
+)
+
+// EventType is a provider-neutral webhook event type
+type EventType string
+
+const (
+	EventCheckoutCompleted     EventType = "checkout.completed"
+	EventSubscriptionActivated EventType = "subscription.activated"
+	EventSubscriptionUpdated   EventType = "subscription.updated"
+	EventSubscriptionSuspended EventType = "subscription.suspended"
+	EventSubscriptionCancelled EventType = "subscription.cancelled"
+	EventSubscriptionExpired   EventType = "subscription.expired"
+	EventPaymentCompleted      EventType = "payment.completed"
+	EventPaymentFailed         EventType = "payment.failed"
+)
+
+// BillingProvider is a payment provider subscriptions are billed with. Plans
+// are passed and returned by their own IDs and versions, providers map them
+// to their plan or price IDs.
+type BillingProvider interface {
+	// Name returns the identifier of the provider, e.g. "paypal"
+	Name() string
+
+	// CreateSubscription starts a subscription the customer approves at the
+	// returned redirect URL
+	CreateSubscription(ctx context.Context, req *CreateSubscriptionRequest) (*Checkout, error)
+
+	// CompleteCheckout returns the subscription of an approved checkout
+	CompleteCheckout(ctx context.Context, checkoutID string) (*SubscriptionInfo, error)
+
+	// GetSubscription retrieves the current state of a subscription
+	GetSubscription(ctx context.Context, subscriptionID string) (*SubscriptionInfo, error)
+
+	// CancelSubscription ends a subscription, no further payments are taken
+	CancelSubscription(ctx context.Context, subscriptionID, reason string) error
+
+	// CancelAtPeriodEnd ends a subscription with its current period, no
+	// further payments are taken. ResumeSubscription undoes it. Providers
+	// that can't schedule it leave the subscription to be cancelled once the
+	// period ended.
+	CancelAtPeriodEnd(ctx context.Context, subscriptionID, reason string) error
+
+	// SuspendSubscription pauses the payments of a subscription
+	SuspendSubscription(ctx context.Context, subscriptionID, reason string) error
+
+	// ResumeSubscription resumes the payments of a suspended subscription,
+	// or of one cancelled at the end of the period
+	ResumeSubscription(ctx context.Context, subscriptionID, reason string) error
+
+	// ChangePlan switches a subscription to a version of a plan, 0 for the
+	// current one. With prorate the difference for the rest of the period is
+	// charged or credited, otherwise the new price applies from the next
+	// billing period.
+	ChangePlan(ctx context.Context, subscriptionID, planID string, version int, prorate bool) (*PlanChange, error)
+
+	// ProratesPlanChanges reports whether ChangePlan can charge the
+	// difference for the rest of the period. Upgrades at providers that
+	// can't take effect with the next billing period.
+	ProratesPlanChanges() bool
+
+	// CustomerPortalURL returns where a customer manages payment methods and
+	// invoices, returnURL is where the provider links back to
+	CustomerPortalURL(ctx context.Context, customerID, returnURL string) (string, error)
+
+	// VerifyWebhook checks that a webhook delivery was signed by the provider,
+	// ErrInvalidSignature if it wasn't
+	VerifyWebhook(ctx context.Context, headers http.Header, body []byte) error
+
+	// ParseWebhook decodes a webhook payload into a provider-neutral event
+	ParseWebhook(body []byte) (*WebhookEvent, error)
+}
+
+// CreateSubscriptionRequest describes a subscription to start
+type CreateSubscriptionRequest struct {
+	TenantID      int64
+	PlanID        string
+	PlanVersion   int    // 0 for the current version
+	CustomerID    string // customer of the provider from an earlier subscription, if any
+	CustomerEmail string
+	BrandName     string
+	ReturnURL     string
+	CancelURL     string
+	Discount      *Discount // coupon applied to the subscription, if any
+}
+
+// Discount durations, the values match models.CouponDuration
+const (
+	DiscountOnce      = "once"
+	DiscountRepeating = "repeating"
+	DiscountForever   = "forever"
+)
+
+// Discount is a coupon applied to a new subscription
+type Discount struct {
+	Code           string
+	PercentOff     float64 // set for percentage discounts
+	AmountOff      float64 // set for fixed discounts, in Currency
+	Currency       string
+	Duration       string
+	DurationMonths int     // for repeating discounts
+	Price          float64 // discounted price of a billing period
+}
+
+// Checkout is a started subscription waiting for the customer's approval
+type Checkout struct {
+	CheckoutID     string // reference the provider returns the customer with
+	SubscriptionID string // empty until the checkout is completed for some providers
+	ProviderPlanID string
+	RedirectURL    string
+}
+
+// SubscriptionInfo is the state of a subscription at the provider
+type SubscriptionInfo struct {
+	ID                 string
+	CustomerID         string
+	PlanID             string // empty if the provider plan isn't mapped to a plan
+	ProviderPlanID     string
+	Status             string
+	CurrentPeriodStart *time.Time
+	CurrentPeriodEnd   *time.Time
+	NextBillingDate    *time.Time
+	CancelAtPeriodEnd  bool
+}
+
+// PlanChange is the result of changing the plan of a subscription
+type PlanChange struct {
+	ApprovalURL  string            // set when the customer has to approve the change
+	Subscription *SubscriptionInfo // state after the change, nil while waiting for approval
+}
+
+// WebhookEvent is a provider-neutral webhook event. Events of types that
+// aren't handled have an empty Type.
+type WebhookEvent struct {
+	ID             string
+	Type           EventType
+	RawType        string
+	SubscriptionID string
+	CheckoutID     string
+	CustomerID     string
+	Status         string     // state of the subscription, if the event carries it
+	UpdatedAt      *time.Time // when the subscription reached the state, nil for payments
+	PaymentID      string     // provider ID of a payment, e.g. the invoice or sale
+	PaidAt         *time.Time // when a payment was taken
+	Amount         string
+	Currency       string
+}
+
+// PlanCatalog maps the versions of plans to the plans or prices of providers
+type PlanCatalog interface {
+	// ProviderPlan returns the plan or price of a provider for a version of a
+	// plan, 0 for the current version, "" if the version has none
+	ProviderPlan(provider, planID string, version int) string
+
+	// PlanFor returns the plan a plan or price of a provider belongs to, "" if none
+	PlanFor(provider, providerPlanID string) string
+}
+
+// Plans maps plan IDs to the plan or price IDs of a provider
+type Plans map[string]string
+
+// providerPlan returns the provider plan of a plan
+func (p Plans) providerPlan(planID string) (string, error) {
+	if id := p[planID]; id != "" {
+		return id, nil
+	}
+	return "", ErrPlanNotOffered
+}
+
+// planFor returns the plan a provider plan belongs to, "" if none
+func (p Plans) planFor(providerPlanID string) string {
+	for planID, id := range p {
+		if id == providerPlanID {
+			return planID
+		}
+	}
+	return ""
+}
+
+// planMapping maps plans to the plans or prices of a provider. The catalog
+// takes precedence, the configured plans are used for plans it has none for.
+type planMapping struct {
+	provider string
+	plans    Plans
+	catalog  PlanCatalog
+}
+
+// providerPlan returns the provider plan of a version of a plan
+func (m *planMapping) providerPlan(planID string, version int) (string, error) {
+	if m.catalog != nil {
+		if id := m.catalog.ProviderPlan(m.provider, planID, version); id != "" {
+			return id, nil
+		}
+	}
+	return m.plans.providerPlan(planID)
+}
+
+// planFor returns the plan a provider plan belongs to, "" if none
+func (m *planMapping) planFor(providerPlanID string) string {
+	if m.catalog != nil {
+		if planID := m.catalog.PlanFor(m.provider, providerPlanID); planID != "" {
+			return planID
+		}
+	}
+	return m.plans.planFor(providerPlanID)
+}
+
+// Registry holds the configured providers and the default for tenants that
+// haven't chosen one
+type Registry struct {
+	providers   map[string]BillingProvider
+	defaultName string
+}
+
+// NewRegistry creates a registry of providers
+func NewRegistry(defaultName string, providers ...BillingProvider) *Registry {
+	r := &Registry{
+		providers:   make(map[string]BillingProvider),
+		defaultName: defaultName,
+	}
+	for _, provider := range providers {
+		r.providers[provider.Name()] = provider
+	}
+	return r
+}
+
+// Get returns a provider by name
+func (r *Registry) Get(name string) (BillingProvider, error) {
+	provider, ok := r.providers[name]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownProvider, name)
+	}
+	return provider, nil
+}
+
+// Default returns the name of the default provider
+func (r *Registry) Default() string {
+	return r.defaultName
+}
+
+// Names returns the names of all providers, sorted
+func (r *Registry) Names() []string {
+	names := make([]string, 0, len(r.providers))
+	for name := range r.providers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// parseTime parses an RFC 3339 time of a provider, nil if empty or invalid
+func parseTime(value string) *time.Time {
+	if value == "" {
+		return nil
+	}
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return nil
+	}
+	return &t
+}
+
+// unixTime converts a Unix timestamp of a provider, nil if unset
+func unixTime(value int64) *time.Time {
+	if value == 0 {
+		return nil
+	}
+	t := time.Unix(value, 0)
+	return &t
+}
@@ -0,0 +1,308 @@
+package billing
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/yourusername/gin-collection-saas/internal/infrastructure/external"
+)
+
+// ProviderPayPal is the name of the PayPal provider
+const ProviderPayPal = "paypal"
+
+// PayPalProvider bills subscriptions with PayPal subscriptions
+type PayPalProvider struct {
+	client *external.PayPalClient
+	plans  planMapping
+}
+
+// NewPayPalProvider creates a PayPal provider, plans maps plan IDs to PayPal
+// plan IDs for plans the catalog has none for
+func NewPayPalProvider(client *external.PayPalClient, plans Plans) *PayPalProvider {
+	return &PayPalProvider{
+		client: client,
+		plans:  planMapping{provider: ProviderPayPal, plans: plans},
+	}
+}
+
+// SetCatalog maps plans with the PayPal plans of the plan catalog
+func (p *PayPalProvider) SetCatalog(catalog PlanCatalog) {
+	p.plans.catalog = catalog
+}
+
+// Name returns "paypal"
+func (p *PayPalProvider) Name() string {
+	return ProviderPayPal
+}
+
+// CreateSubscription creates a PayPal subscription, the subscriber approves it at PayPal.
+// PayPal subscriptions have no coupons, permanent discounts override the price
+// of the plan's billing cycle, others aren't supported.
+func (p *PayPalProvider) CreateSubscription(ctx context.Context, req *CreateSubscriptionRequest) (*Checkout, error) {
+	planID, err := p.plans.providerPlan(req.PlanID, req.PlanVersion)
+	if err != nil {
+		return nil, err
+	}
+	if req.Discount != nil && req.Discount.Duration != DiscountForever {
+		return nil, ErrDiscountNotSupported
+	}
+
+	paypalReq := &external.PayPalSubscriptionRequest{
+		PlanID: planID,
+		ApplicationContext: &external.PayPalApplicationContext{
+			BrandName:  req.BrandName,
+			ReturnURL:  req.ReturnURL,
+			CancelURL:  req.CancelURL,
+			UserAction: "SUBSCRIBE_NOW",
+			PaymentMethod: &external.PayPalPaymentMethod{
+				PayeePreferred: "IMMEDIATE_PAYMENT_REQUIRED",
+			},
+		},
+	}
+	if req.CustomerEmail != "" {
+		paypalReq.Subscriber = &external.PayPalSubscriber{EmailAddress: req.CustomerEmail}
+	}
+	if req.Discount != nil {
+		paypalReq.Plan = &external.PayPalPlanOverride{
+			BillingCycles: []external.PayPalBillingCycleOverride{{
+				Sequence: 1,
+				PricingScheme: external.PayPalPricingScheme{
+					FixedPrice: &external.PayPalMoney{
+						CurrencyCode: req.Discount.Currency,
+						Value:        fmt.Sprintf("%.2f", req.Discount.Price),
+					},
+				},
+			}},
+		}
+	}
+
+	resp, err := p.client.CreateSubscription(paypalReq)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Checkout{
+		CheckoutID:     resp.ID,
+		SubscriptionID: resp.ID,
+		ProviderPlanID: planID,
+		RedirectURL:    resp.GetApprovalURL(),
+	}, nil
+}
+
+// CompleteCheckout returns the approved subscription, PayPal returns the
+// subscriber with the subscription ID
+func (p *PayPalProvider) CompleteCheckout(ctx context.Context, checkoutID string) (*SubscriptionInfo, error) {
+	return p.GetSubscription(ctx, checkoutID)
+}
+
+// GetSubscription retrieves a PayPal subscription
+func (p *PayPalProvider) GetSubscription(ctx context.Context, subscriptionID string) (*SubscriptionInfo, error) {
+	resp, err := p.client.GetSubscription(subscriptionID)
+	if err != nil {
+		return nil, err
+	}
+	return p.subscriptionInfo(resp), nil
+}
+
+// CancelSubscription cancels a PayPal subscription
+func (p *PayPalProvider) CancelSubscription(ctx context.Context, subscriptionID, reason string) error {
+	return p.client.CancelSubscription(subscriptionID, reason)
+}
+
+// CancelAtPeriodEnd suspends a PayPal subscription until the end of the
+// period. PayPal can't cancel at the end of the period and cancelled
+// subscriptions can't be reactivated, a suspended one resumes with
+// ResumeSubscription.
+func (p *PayPalProvider) CancelAtPeriodEnd(ctx context.Context, subscriptionID, reason string) error {
+	return p.client.SuspendSubscription(subscriptionID, reason)
+}
+
+// SuspendSubscription suspends a PayPal subscription
+func (p *PayPalProvider) SuspendSubscription(ctx context.Context, subscriptionID, reason string) error {
+	return p.client.SuspendSubscription(subscriptionID, reason)
+}
+
+// ResumeSubscription reactivates a suspended PayPal subscription
+func (p *PayPalProvider) ResumeSubscription(ctx context.Context, subscriptionID, reason string) error {
+	return p.client.ActivateSubscription(subscriptionID, reason)
+}
+
+// ChangePlan revises a PayPal subscription. The subscriber approves the new
+// plan at PayPal, the change is reported with a webhook afterwards. PayPal
+// doesn't prorate revisions, the new price applies from the next billing cycle.
+func (p *PayPalProvider) ChangePlan(ctx context.Context, subscriptionID, planID string, version int, prorate bool) (*PlanChange, error) {
+	providerPlanID, err := p.plans.providerPlan(planID, version)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := p.client.ReviseSubscription(subscriptionID, providerPlanID)
+	if err != nil {
+		return nil, err
+	}
+	if approvalURL := resp.GetApprovalURL(); approvalURL != "" {
+		return &PlanChange{ApprovalURL: approvalURL}, nil
+	}
+
+	info, err := p.GetSubscription(ctx, subscriptionID)
+	if err != nil {
+		return nil, err
+	}
+	return &PlanChange{Subscription: info}, nil
+}
+
+// ProratesPlanChanges returns false, PayPal charges a revised plan from the
+// next billing cycle
+func (p *PayPalProvider) ProratesPlanChanges() bool {
+	return false
+}
+
+// CustomerPortalURL returns the PayPal page for automatic payments, PayPal
+// has no portal per customer
+func (p *PayPalProvider) CustomerPortalURL(ctx context.Context, customerID, returnURL string) (string, error) {
+	return p.client.ManageURL(), nil
+}
+
+// VerifyWebhook verifies the transmission signature with PayPal
+func (p *PayPalProvider) VerifyWebhook(ctx context.Context, headers http.Header, body []byte) error {
+	sig := &external.PayPalWebhookSignature{
+		TransmissionID:   headers.Get("PAYPAL-TRANSMISSION-ID"),
+		TransmissionTime: headers.Get("PAYPAL-TRANSMISSION-TIME"),
+		TransmissionSig:  headers.Get("PAYPAL-TRANSMISSION-SIG"),
+		CertURL:          headers.Get("PAYPAL-CERT-URL"),
+		AuthAlgo:         headers.Get("PAYPAL-AUTH-ALGO"),
+	}
+
+	if err := p.client.VerifyWebhookSignature(sig, body); err != nil {
+		if errors.Is(err, external.ErrWebhookSignature) {
+			return ErrInvalidSignature
+		}
+		return err
+	}
+	return nil
+}
+
+// paypalWebhookEvent represents a PayPal webhook payload
+type paypalWebhookEvent struct {
+	ID         string                `json:"id"`
+	EventType  string                `json:"event_type"`
+	CreateTime string                `json:"create_time"`
+	Resource   paypalWebhookResource `json:"resource"`
+}
+
+// paypalWebhookResource represents the resource of a PayPal webhook event
+type paypalWebhookResource struct {
+	ID                 string `json:"id"`
+	Status             string `json:"status"`
+	BillingAgreementID string `json:"billing_agreement_id"`
+	Amount             *struct {
+		Total    string `json:"total"`
+		Currency string `json:"currency"`
+	} `json:"amount,omitempty"`
+	CreateTime       string `json:"create_time,omitempty"`
+	UpdateTime       string `json:"update_time,omitempty"`
+	StatusUpdateTime string `json:"status_update_time,omitempty"`
+}
+
+// ParseWebhook decodes a PayPal webhook event
+func (p *PayPalProvider) ParseWebhook(body []byte) (*WebhookEvent, error) {
+	var raw paypalWebhookEvent
+	if err := json.Unmarshal(body, &raw); err != nil {
+		return nil, fmt.Errorf("failed to decode PayPal webhook: %w", err)
+	}
+	if raw.ID == "" || raw.EventType == "" {
+		return nil, fmt.Errorf("PayPal webhook without id or event type")
+	}
+
+	event := &WebhookEvent{
+		ID:      raw.ID,
+		RawType: raw.EventType,
+	}
+
+	// The subscription changed at the time of its last update
+	updatedAt := parseTime(raw.Resource.UpdateTime)
+	if updatedAt == nil {
+		updatedAt = parseTime(raw.Resource.StatusUpdateTime)
+	}
+	if updatedAt == nil {
+		updatedAt = parseTime(raw.CreateTime)
+	}
+
+	subscriptionEvent := func(eventType EventType, status string) {
+		event.Type = eventType
+		event.SubscriptionID = raw.Resource.ID
+		event.CheckoutID = raw.Resource.ID
+		event.Status = status
+		event.UpdatedAt = updatedAt
+	}
+
+	switch raw.EventType {
+	case "BILLING.SUBSCRIPTION.ACTIVATED", "BILLING.SUBSCRIPTION.RE-ACTIVATED":
+		subscriptionEvent(EventSubscriptionActivated, StatusActive)
+	case "BILLING.SUBSCRIPTION.UPDATED":
+		subscriptionEvent(EventSubscriptionUpdated, paypalStatus(raw.Resource.Status))
+	case "BILLING.SUBSCRIPTION.SUSPENDED":
+		subscriptionEvent(EventSubscriptionSuspended, StatusSuspended)
+	case "BILLING.SUBSCRIPTION.CANCELLED":
+		subscriptionEvent(EventSubscriptionCancelled, StatusCancelled)
+	case "BILLING.SUBSCRIPTION.EXPIRED":
+		subscriptionEvent(EventSubscriptionExpired, StatusExpired)
+	case "BILLING.SUBSCRIPTION.PAYMENT.FAILED":
+		event.Type = EventPaymentFailed
+		event.SubscriptionID = raw.Resource.ID
+	case "PAYMENT.SALE.COMPLETED", "PAYMENT.SALE.DENIED":
+		event.Type = EventPaymentCompleted
+		if raw.EventType == "PAYMENT.SALE.DENIED" {
+			event.Type = EventPaymentFailed
+		}
+		event.SubscriptionID = raw.Resource.BillingAgreementID
+		event.PaymentID = raw.Resource.ID
+		event.PaidAt = parseTime(raw.Resource.CreateTime)
+		if event.PaidAt == nil {
+			event.PaidAt = parseTime(raw.CreateTime)
+		}
+		if raw.Resource.Amount != nil {
+			event.Amount = raw.Resource.Amount.Total
+			event.Currency = raw.Resource.Amount.Currency
+		}
+	}
+
+	return event, nil
+}
+
+// subscriptionInfo converts a PayPal subscription
+func (p *PayPalProvider) subscriptionInfo(resp *external.PayPalSubscriptionResponse) *SubscriptionInfo {
+	info := &SubscriptionInfo{
+		ID:                 resp.ID,
+		PlanID:             p.plans.planFor(resp.PlanID),
+		ProviderPlanID:     resp.PlanID,
+		Status:             paypalStatus(resp.Status),
+		CurrentPeriodStart: parseTime(resp.StartTime),
+	}
+	if resp.Subscriber != nil {
+		info.CustomerID = resp.Subscriber.PayerID
+	}
+	if resp.BillingInfo != nil {
+		info.NextBillingDate = parseTime(resp.BillingInfo.NextBillingTime)
+	}
+	return info
+}
+
+// paypalStatus maps the status of a PayPal subscription
+func paypalStatus(status string) string {
+	switch status {
+	case "ACTIVE":
+		return StatusActive
+	case "SUSPENDED":
+		return StatusSuspended
+	case "CANCELLED":
+		return StatusCancelled
+	case "EXPIRED":
+		return StatusExpired
+	default: // APPROVAL_PENDING, APPROVED
+		return StatusPending
+	}
+}
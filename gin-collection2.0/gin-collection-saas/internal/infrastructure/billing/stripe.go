@@ -0,0 +1,425 @@
+package billing
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/yourusername/gin-collection-saas/internal/infrastructure/external"
+)
+
+// ProviderStripe is the name of the Stripe provider
+const ProviderStripe = "stripe"
+
+// StripeProvider bills subscriptions with Stripe Checkout and Stripe Billing
+type StripeProvider struct {
+	client *external.StripeClient
+	plans  planMapping
+}
+
+// NewStripeProvider creates a Stripe provider, plans maps plan IDs to Stripe
+// price IDs for plans the catalog has none for
+func NewStripeProvider(client *external.StripeClient, plans Plans) *StripeProvider {
+	return &StripeProvider{
+		client: client,
+		plans:  planMapping{provider: ProviderStripe, plans: plans},
+	}
+}
+
+// SetCatalog maps plans with the Stripe prices of the plan catalog
+func (p *StripeProvider) SetCatalog(catalog PlanCatalog) {
+	p.plans.catalog = catalog
+}
+
+// Name returns "stripe"
+func (p *StripeProvider) Name() string {
+	return ProviderStripe
+}
+
+// CreateSubscription starts a Stripe Checkout session for the plan's price.
+// The subscription is created by Stripe once the customer completes it.
+func (p *StripeProvider) CreateSubscription(ctx context.Context, req *CreateSubscriptionRequest) (*Checkout, error) {
+	priceID, err := p.plans.providerPlan(req.PlanID, req.PlanVersion)
+	if err != nil {
+		return nil, err
+	}
+
+	params := url.Values{}
+	params.Set("line_items[0][price]", priceID)
+	params.Set("line_items[0][quantity]", "1")
+	params.Set("success_url", withQuery(req.ReturnURL, "session_id={CHECKOUT_SESSION_ID}"))
+	params.Set("cancel_url", req.CancelURL)
+	params.Set("client_reference_id", strconv.FormatInt(req.TenantID, 10))
+	params.Set("metadata[tenant_id]", strconv.FormatInt(req.TenantID, 10))
+	params.Set("subscription_data[metadata][tenant_id]", strconv.FormatInt(req.TenantID, 10))
+	if req.CustomerID != "" {
+		params.Set("customer", req.CustomerID)
+	} else if req.CustomerEmail != "" {
+		params.Set("customer_email", req.CustomerEmail)
+	}
+	if req.Discount != nil {
+		couponID, err := p.createCoupon(req.Discount)
+		if err != nil {
+			return nil, err
+		}
+		params.Set("discounts[0][coupon]", couponID)
+	}
+
+	session, err := p.client.CreateCheckoutSession(params)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Checkout{
+		CheckoutID:     session.ID,
+		SubscriptionID: session.Subscription,
+		ProviderPlanID: priceID,
+		RedirectURL:    session.URL,
+	}, nil
+}
+
+// createCoupon creates a Stripe coupon for a single checkout, so changes to
+// the coupon don't affect Stripe coupons created before
+func (p *StripeProvider) createCoupon(discount *Discount) (string, error) {
+	params := url.Values{}
+	params.Set("name", discount.Code)
+	params.Set("max_redemptions", "1")
+	params.Set("duration", discount.Duration)
+	params.Set("metadata[code]", discount.Code)
+	if discount.Duration == DiscountRepeating {
+		params.Set("duration_in_months", strconv.Itoa(discount.DurationMonths))
+	}
+	if discount.PercentOff > 0 {
+		params.Set("percent_off", strconv.FormatFloat(discount.PercentOff, 'f', -1, 64))
+	} else {
+		params.Set("amount_off", strconv.FormatInt(minorUnits(discount.AmountOff), 10))
+		params.Set("currency", strings.ToLower(discount.Currency))
+	}
+
+	coupon, err := p.client.CreateCoupon(params)
+	if err != nil {
+		return "", err
+	}
+	return coupon.ID, nil
+}
+
+// CompleteCheckout returns the subscription created by a completed Checkout session
+func (p *StripeProvider) CompleteCheckout(ctx context.Context, checkoutID string) (*SubscriptionInfo, error) {
+	session, err := p.client.GetCheckoutSession(checkoutID)
+	if err != nil {
+		return nil, err
+	}
+	if session.Subscription == "" {
+		// The customer hasn't completed the checkout yet
+		return &SubscriptionInfo{CustomerID: session.Customer, Status: StatusPending}, nil
+	}
+
+	return p.GetSubscription(ctx, session.Subscription)
+}
+
+// GetSubscription retrieves a Stripe subscription
+func (p *StripeProvider) GetSubscription(ctx context.Context, subscriptionID string) (*SubscriptionInfo, error) {
+	subscription, err := p.client.GetSubscription(subscriptionID)
+	if err != nil {
+		return nil, err
+	}
+	return p.subscriptionInfo(subscription), nil
+}
+
+// CancelSubscription cancels a Stripe subscription immediately
+func (p *StripeProvider) CancelSubscription(ctx context.Context, subscriptionID, reason string) error {
+	return p.client.CancelSubscription(subscriptionID, reason)
+}
+
+// CancelAtPeriodEnd lets Stripe cancel the subscription at the end of the period
+func (p *StripeProvider) CancelAtPeriodEnd(ctx context.Context, subscriptionID, reason string) error {
+	params := url.Values{}
+	params.Set("cancel_at_period_end", "true")
+	if reason != "" {
+		params.Set("metadata[cancel_reason]", reason)
+	}
+
+	_, err := p.client.UpdateSubscription(subscriptionID, params)
+	return err
+}
+
+// SuspendSubscription pauses payment collection, open invoices are voided
+func (p *StripeProvider) SuspendSubscription(ctx context.Context, subscriptionID, reason string) error {
+	params := url.Values{}
+	params.Set("pause_collection[behavior]", "void")
+	if reason != "" {
+		params.Set("metadata[suspend_reason]", reason)
+	}
+
+	_, err := p.client.UpdateSubscription(subscriptionID, params)
+	return err
+}
+
+// ResumeSubscription resumes payment collection of a paused subscription and
+// keeps it from being cancelled at the end of the period
+func (p *StripeProvider) ResumeSubscription(ctx context.Context, subscriptionID, reason string) error {
+	params := url.Values{}
+	params.Set("pause_collection", "")
+	params.Set("cancel_at_period_end", "false")
+	if reason != "" {
+		params.Set("metadata[resume_reason]", reason)
+	}
+
+	_, err := p.client.UpdateSubscription(subscriptionID, params)
+	return err
+}
+
+// ChangePlan swaps the price of a subscription. Prorations are added to the
+// next invoice.
+func (p *StripeProvider) ChangePlan(ctx context.Context, subscriptionID, planID string, version int, prorate bool) (*PlanChange, error) {
+	priceID, err := p.plans.providerPlan(planID, version)
+	if err != nil {
+		return nil, err
+	}
+
+	current, err := p.client.GetSubscription(subscriptionID)
+	if err != nil {
+		return nil, err
+	}
+	if len(current.Items.Data) == 0 {
+		return nil, fmt.Errorf("Stripe subscription %s has no items", subscriptionID)
+	}
+
+	params := url.Values{}
+	params.Set("items[0][id]", current.Items.Data[0].ID)
+	params.Set("items[0][price]", priceID)
+	if prorate {
+		params.Set("proration_behavior", "create_prorations")
+	} else {
+		params.Set("proration_behavior", "none")
+	}
+
+	updated, err := p.client.UpdateSubscription(subscriptionID, params)
+	if err != nil {
+		return nil, err
+	}
+
+	return &PlanChange{Subscription: p.subscriptionInfo(updated)}, nil
+}
+
+// ProratesPlanChanges returns true, Stripe invoices prorations
+func (p *StripeProvider) ProratesPlanChanges() bool {
+	return true
+}
+
+// CustomerPortalURL creates a Stripe customer portal session
+func (p *StripeProvider) CustomerPortalURL(ctx context.Context, customerID, returnURL string) (string, error) {
+	if customerID == "" {
+		return "", ErrNoCustomer
+	}
+
+	session, err := p.client.CreatePortalSession(customerID, returnURL)
+	if err != nil {
+		return "", err
+	}
+	return session.URL, nil
+}
+
+// VerifyWebhook checks the Stripe-Signature header against the endpoint secret
+func (p *StripeProvider) VerifyWebhook(ctx context.Context, headers http.Header, body []byte) error {
+	if err := p.client.VerifyWebhookSignature(headers.Get("Stripe-Signature"), body); err != nil {
+		if errors.Is(err, external.ErrStripeWebhookSignature) {
+			return ErrInvalidSignature
+		}
+		return err
+	}
+	return nil
+}
+
+// stripeWebhookEvent represents a Stripe event payload
+type stripeWebhookEvent struct {
+	ID      string `json:"id"`
+	Type    string `json:"type"`
+	Created int64  `json:"created"`
+	Data    struct {
+		Object json.RawMessage `json:"object"`
+	} `json:"data"`
+}
+
+// stripeCheckoutObject is the Checkout session of checkout events
+type stripeCheckoutObject struct {
+	ID           string `json:"id"`
+	Customer     string `json:"customer"`
+	Subscription string `json:"subscription"`
+}
+
+// stripeInvoiceObject is the invoice of invoice events
+type stripeInvoiceObject struct {
+	ID           string `json:"id"`
+	Customer     string `json:"customer"`
+	Subscription string `json:"subscription"`
+	AmountPaid   int64  `json:"amount_paid"`
+	AmountDue    int64  `json:"amount_due"`
+	Currency     string `json:"currency"`
+	Parent       *struct {
+		SubscriptionDetails *struct {
+			Subscription string `json:"subscription"`
+		} `json:"subscription_details"`
+	} `json:"parent"`
+}
+
+// subscriptionID returns the subscription of an invoice, newer API versions
+// moved it to the invoice parent
+func (o *stripeInvoiceObject) subscriptionID() string {
+	if o.Subscription != "" {
+		return o.Subscription
+	}
+	if o.Parent != nil && o.Parent.SubscriptionDetails != nil {
+		return o.Parent.SubscriptionDetails.Subscription
+	}
+	return ""
+}
+
+// ParseWebhook decodes a Stripe event
+func (p *StripeProvider) ParseWebhook(body []byte) (*WebhookEvent, error) {
+	var raw stripeWebhookEvent
+	if err := json.Unmarshal(body, &raw); err != nil {
+		return nil, fmt.Errorf("failed to decode Stripe webhook: %w", err)
+	}
+	if raw.ID == "" || raw.Type == "" {
+		return nil, fmt.Errorf("Stripe webhook without id or type")
+	}
+
+	event := &WebhookEvent{
+		ID:      raw.ID,
+		RawType: raw.Type,
+	}
+
+	switch raw.Type {
+	case "checkout.session.completed":
+		var session stripeCheckoutObject
+		if err := json.Unmarshal(raw.Data.Object, &session); err != nil {
+			return nil, fmt.Errorf("failed to decode Stripe checkout session: %w", err)
+		}
+		event.Type = EventCheckoutCompleted
+		event.CheckoutID = session.ID
+		event.SubscriptionID = session.Subscription
+		event.CustomerID = session.Customer
+		event.Status = StatusActive
+		event.UpdatedAt = unixTime(raw.Created)
+
+	case "customer.subscription.created", "customer.subscription.updated", "customer.subscription.deleted",
+		"customer.subscription.paused", "customer.subscription.resumed":
+		var subscription external.StripeSubscription
+		if err := json.Unmarshal(raw.Data.Object, &subscription); err != nil {
+			return nil, fmt.Errorf("failed to decode Stripe subscription: %w", err)
+		}
+		event.SubscriptionID = subscription.ID
+		event.CustomerID = subscription.Customer
+		event.Status = stripeStatus(&subscription)
+		event.UpdatedAt = unixTime(raw.Created)
+
+		switch raw.Type {
+		case "customer.subscription.deleted":
+			event.Type = EventSubscriptionCancelled
+			event.Status = StatusCancelled
+		case "customer.subscription.paused":
+			event.Type = EventSubscriptionSuspended
+		case "customer.subscription.resumed":
+			event.Type = EventSubscriptionActivated
+		default:
+			event.Type = EventSubscriptionUpdated
+		}
+
+	case "invoice.paid", "invoice.payment_failed":
+		var invoice stripeInvoiceObject
+		if err := json.Unmarshal(raw.Data.Object, &invoice); err != nil {
+			return nil, fmt.Errorf("failed to decode Stripe invoice: %w", err)
+		}
+		event.SubscriptionID = invoice.subscriptionID()
+		event.CustomerID = invoice.Customer
+		event.PaymentID = invoice.ID
+		event.PaidAt = unixTime(raw.Created)
+		event.Currency = strings.ToUpper(invoice.Currency)
+		if raw.Type == "invoice.paid" {
+			event.Type = EventPaymentCompleted
+			event.Amount = formatMinorUnits(invoice.AmountPaid)
+		} else {
+			event.Type = EventPaymentFailed
+			event.Amount = formatMinorUnits(invoice.AmountDue)
+		}
+	}
+
+	return event, nil
+}
+
+// subscriptionInfo converts a Stripe subscription
+func (p *StripeProvider) subscriptionInfo(subscription *external.StripeSubscription) *SubscriptionInfo {
+	info := &SubscriptionInfo{
+		ID:                 subscription.ID,
+		CustomerID:         subscription.Customer,
+		Status:             stripeStatus(subscription),
+		CurrentPeriodStart: unixTime(subscription.CurrentPeriodStart),
+		CurrentPeriodEnd:   unixTime(subscription.CurrentPeriodEnd),
+		CancelAtPeriodEnd:  subscription.CancelAtPeriodEnd,
+	}
+
+	if len(subscription.Items.Data) > 0 {
+		item := subscription.Items.Data[0]
+		info.ProviderPlanID = item.Price.ID
+		info.PlanID = p.plans.planFor(item.Price.ID)
+		// Newer API versions report the period on the items
+		if info.CurrentPeriodStart == nil {
+			info.CurrentPeriodStart = unixTime(item.CurrentPeriodStart)
+		}
+		if info.CurrentPeriodEnd == nil {
+			info.CurrentPeriodEnd = unixTime(item.CurrentPeriodEnd)
+		}
+	}
+	if !info.CancelAtPeriodEnd && info.Status == StatusActive {
+		info.NextBillingDate = info.CurrentPeriodEnd
+	}
+
+	return info
+}
+
+// stripeStatus maps the status of a Stripe subscription
+func stripeStatus(subscription *external.StripeSubscription) string {
+	if subscription.PauseCollection != nil {
+		return StatusSuspended
+	}
+
+	switch subscription.Status {
+	case "active", "trialing":
+		return StatusActive
+	case "past_due", "unpaid":
+		return StatusPastDue
+	case "paused":
+		return StatusSuspended
+	case "canceled":
+		return StatusCancelled
+	case "incomplete_expired":
+		return StatusExpired
+	default: // incomplete
+		return StatusPending
+	}
+}
+
+// withQuery appends a raw query to a URL
+func withQuery(rawURL, query string) string {
+	if strings.Contains(rawURL, "?") {
+		return rawURL + "&" + query
+	}
+	return rawURL + "?" + query
+}
+
+// formatMinorUnits formats an amount in cents as a decimal
+func formatMinorUnits(amount int64) string {
+	return fmt.Sprintf("%d.%02d", amount/100, amount%100)
+}
+
+// minorUnits converts a decimal amount to cents
+func minorUnits(amount float64) int64 {
+	return int64(math.Round(amount * 100))
+}
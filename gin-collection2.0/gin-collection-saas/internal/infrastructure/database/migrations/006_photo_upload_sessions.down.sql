@@ -0,0 +1,4 @@
+-- Migration: photo_upload_sessions (down)
+-- Created at: 2026-02-02T10:14:08+01:00
+
+DROP TABLE IF EXISTS photo_upload_sessions;
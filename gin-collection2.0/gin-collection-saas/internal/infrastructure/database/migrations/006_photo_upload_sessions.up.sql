@@ -0,0 +1,27 @@
+-- Migration: photo_upload_sessions
+-- Created at: 2026-02-02T10:14:08+01:00
+
+-- Pending direct-to-storage photo uploads (presigned URLs)
+CREATE TABLE IF NOT EXISTS photo_upload_sessions (
+    id BIGINT UNSIGNED AUTO_INCREMENT PRIMARY KEY,
+    tenant_id BIGINT UNSIGNED NOT NULL,
+    gin_id BIGINT UNSIGNED NOT NULL,
+    user_id BIGINT UNSIGNED NULL,
+    token VARCHAR(64) NOT NULL UNIQUE,
+    storage_key VARCHAR(255) NOT NULL,
+    filename VARCHAR(255) NOT NULL,
+    content_type VARCHAR(100) NOT NULL,
+    photo_type ENUM('bottle', 'label', 'moment', 'tasting') DEFAULT 'bottle',
+    caption VARCHAR(500),
+    status ENUM('pending', 'completed', 'expired') NOT NULL DEFAULT 'pending',
+    expires_at TIMESTAMP NOT NULL,
+    completed_at TIMESTAMP NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+
+    FOREIGN KEY (tenant_id) REFERENCES tenants(id) ON DELETE CASCADE,
+    FOREIGN KEY (gin_id) REFERENCES gins(id) ON DELETE CASCADE,
+    -- Signed local uploads look up their session by object key
+    UNIQUE INDEX idx_photo_upload_sessions_storage_key (storage_key),
+    INDEX idx_photo_upload_sessions_tenant_gin (tenant_id, gin_id),
+    INDEX idx_photo_upload_sessions_status_expires (status, expires_at)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
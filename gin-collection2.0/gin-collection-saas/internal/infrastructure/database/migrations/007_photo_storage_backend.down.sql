@@ -0,0 +1,4 @@
+-- Migration: photo_storage_backend (down)
+-- Created at: 2026-02-03T15:26:41+01:00
+
+ALTER TABLE gin_photos DROP COLUMN storage_backend;
@@ -0,0 +1,6 @@
+-- Migration: photo_storage_backend
+-- Created at: 2026-02-03T15:26:41+01:00
+
+-- Backend holding the photo's object ("local", "s3"), NULL = the active
+-- backend, photos are deleted from and reconciled against this backend
+ALTER TABLE gin_photos ADD COLUMN storage_backend VARCHAR(20) NULL AFTER storage_key;
@@ -0,0 +1,5 @@
+-- Migration: storage_accounting (down)
+-- Created at: 2026-02-04T09:32:51+01:00
+
+DROP TABLE IF EXISTS tenant_storage_usage;
+ALTER TABLE gin_photos DROP COLUMN file_size_bytes;
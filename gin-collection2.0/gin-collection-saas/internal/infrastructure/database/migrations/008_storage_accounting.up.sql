@@ -0,0 +1,29 @@
+-- Migration: storage_accounting
+-- Created at: 2026-02-04T09:32:51+01:00
+
+-- Track exact photo sizes in bytes (file_size_kb is kept for compatibility)
+ALTER TABLE gin_photos ADD COLUMN file_size_bytes BIGINT UNSIGNED NULL AFTER file_size_kb;
+
+UPDATE gin_photos
+SET file_size_bytes = file_size_kb * 1024
+WHERE file_size_bytes IS NULL AND file_size_kb IS NOT NULL;
+
+-- Per-tenant storage usage, updated in the same transaction as gin_photos
+CREATE TABLE IF NOT EXISTS tenant_storage_usage (
+    tenant_id BIGINT UNSIGNED PRIMARY KEY,
+    bytes_used BIGINT UNSIGNED NOT NULL DEFAULT 0,
+    photo_count INT UNSIGNED NOT NULL DEFAULT 0,
+    reconciled_at TIMESTAMP NULL,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+
+    FOREIGN KEY (tenant_id) REFERENCES tenants(id) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+-- Seed usage from existing photos
+INSERT INTO tenant_storage_usage (tenant_id, bytes_used, photo_count)
+SELECT tenant_id, COALESCE(SUM(file_size_bytes), 0), COUNT(*)
+FROM gin_photos
+GROUP BY tenant_id
+ON DUPLICATE KEY UPDATE
+    bytes_used = VALUES(bytes_used),
+    photo_count = VALUES(photo_count);
@@ -0,0 +1,9 @@
+-- Migration: photo_gallery (down)
+-- Created at: 2026-02-06T16:41:52+01:00
+
+DROP TABLE IF EXISTS photo_tags;
+
+ALTER TABLE gin_photos
+    DROP INDEX idx_gin_photos_type,
+    DROP INDEX idx_gin_photos_sort,
+    DROP COLUMN sort_order;
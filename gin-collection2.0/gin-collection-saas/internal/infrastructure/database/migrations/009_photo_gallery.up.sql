@@ -0,0 +1,29 @@
+-- Migration: photo_gallery
+-- Created at: 2026-02-06T16:41:52+01:00
+
+-- Manual photo order per gin
+ALTER TABLE gin_photos
+    ADD COLUMN sort_order INT UNSIGNED NOT NULL DEFAULT 0 AFTER is_primary,
+    ADD INDEX idx_gin_photos_sort (tenant_id, gin_id, sort_order),
+    ADD INDEX idx_gin_photos_type (tenant_id, photo_type, created_at);
+
+-- Keep the previous order: primary photo first, then by upload time
+UPDATE gin_photos p
+JOIN (
+    SELECT id, ROW_NUMBER() OVER (PARTITION BY gin_id ORDER BY is_primary DESC, created_at ASC, id ASC) - 1 AS position
+    FROM gin_photos
+) ordered ON ordered.id = p.id
+SET p.sort_order = ordered.position;
+
+-- Free-form photo tags
+CREATE TABLE IF NOT EXISTS photo_tags (
+    photo_id BIGINT UNSIGNED NOT NULL,
+    tenant_id BIGINT UNSIGNED NOT NULL,
+    tag VARCHAR(50) NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+
+    PRIMARY KEY (photo_id, tag),
+    FOREIGN KEY (photo_id) REFERENCES gin_photos(id) ON DELETE CASCADE,
+    FOREIGN KEY (tenant_id) REFERENCES tenants(id) ON DELETE CASCADE,
+    INDEX idx_photo_tags_tenant_tag (tenant_id, tag)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
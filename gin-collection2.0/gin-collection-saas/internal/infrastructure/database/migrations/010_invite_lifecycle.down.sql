@@ -0,0 +1,7 @@
+-- Migration: invite_lifecycle (down)
+-- Created at: 2026-02-09T10:18:34+01:00
+
+ALTER TABLE invite_tokens
+    DROP FOREIGN KEY fk_invite_tokens_invited_by,
+    DROP INDEX idx_invite_tokens_pending,
+    DROP COLUMN invited_by;
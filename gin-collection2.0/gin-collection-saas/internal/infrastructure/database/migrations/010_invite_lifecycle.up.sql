@@ -0,0 +1,9 @@
+-- Migration: invite_lifecycle
+-- Created at: 2026-02-09T10:18:34+01:00
+
+-- invite_tokens.token now holds the SHA-256 hash of the token sent by email.
+-- Nothing wrote to the table before, so there are no plain tokens to migrate.
+ALTER TABLE invite_tokens
+    ADD COLUMN invited_by BIGINT UNSIGNED NULL AFTER role,
+    ADD CONSTRAINT fk_invite_tokens_invited_by FOREIGN KEY (invited_by) REFERENCES users(id) ON DELETE SET NULL,
+    ADD INDEX idx_invite_tokens_pending (tenant_id, used_at);
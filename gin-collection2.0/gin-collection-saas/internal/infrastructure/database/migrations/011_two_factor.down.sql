@@ -0,0 +1,8 @@
+-- Migration: two_factor (down)
+-- Created at: 2026-02-11T09:42:17+01:00
+
+ALTER TABLE tenants DROP COLUMN require_two_factor;
+
+DROP TABLE IF EXISTS two_factor_trusted_devices;
+DROP TABLE IF EXISTS two_factor_recovery_codes;
+DROP TABLE IF EXISTS two_factor_credentials;
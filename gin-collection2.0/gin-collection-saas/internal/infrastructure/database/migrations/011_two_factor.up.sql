@@ -0,0 +1,46 @@
+-- Migration: two_factor
+-- Created at: 2026-02-11T09:42:17+01:00
+
+-- TOTP credentials of tenant users and platform admins
+CREATE TABLE IF NOT EXISTS two_factor_credentials (
+    id BIGINT UNSIGNED AUTO_INCREMENT PRIMARY KEY,
+    subject_type VARCHAR(20) NOT NULL,
+    subject_id BIGINT UNSIGNED NOT NULL,
+    secret VARCHAR(64) NOT NULL,
+    confirmed_at TIMESTAMP NULL,
+    last_used_step BIGINT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+
+    UNIQUE KEY uk_two_factor_subject (subject_type, subject_id)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+-- One-time recovery codes (SHA-256 hashes)
+CREATE TABLE IF NOT EXISTS two_factor_recovery_codes (
+    id BIGINT UNSIGNED AUTO_INCREMENT PRIMARY KEY,
+    subject_type VARCHAR(20) NOT NULL,
+    subject_id BIGINT UNSIGNED NOT NULL,
+    code_hash CHAR(64) NOT NULL,
+    used_at TIMESTAMP NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+
+    INDEX idx_recovery_codes_subject (subject_type, subject_id)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+-- "Remember this device" tokens (SHA-256 hashes)
+CREATE TABLE IF NOT EXISTS two_factor_trusted_devices (
+    id BIGINT UNSIGNED AUTO_INCREMENT PRIMARY KEY,
+    subject_type VARCHAR(20) NOT NULL,
+    subject_id BIGINT UNSIGNED NOT NULL,
+    token_hash CHAR(64) NOT NULL UNIQUE,
+    user_agent VARCHAR(255),
+    expires_at TIMESTAMP NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+
+    INDEX idx_trusted_devices_subject (subject_type, subject_id),
+    INDEX idx_trusted_devices_expires (expires_at)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+-- Owners can require 2FA for all users of their tenant
+ALTER TABLE tenants
+    ADD COLUMN require_two_factor BOOLEAN NOT NULL DEFAULT FALSE AFTER status;
@@ -0,0 +1,5 @@
+-- Migration: webauthn (down)
+-- Created at: 2026-02-14T15:06:51+01:00
+
+DROP TABLE IF EXISTS webauthn_sessions;
+DROP TABLE IF EXISTS webauthn_credentials;
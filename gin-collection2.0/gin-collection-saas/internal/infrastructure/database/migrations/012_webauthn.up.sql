@@ -0,0 +1,37 @@
+-- Migration: webauthn
+-- Created at: 2026-02-14T15:06:51+01:00
+
+-- Passkeys of tenant users and platform admins
+CREATE TABLE IF NOT EXISTS webauthn_credentials (
+    id BIGINT UNSIGNED AUTO_INCREMENT PRIMARY KEY,
+    subject_type VARCHAR(20) NOT NULL,
+    subject_id BIGINT UNSIGNED NOT NULL,
+    credential_id VARCHAR(1400) CHARACTER SET ascii COLLATE ascii_bin NOT NULL,
+    public_key BLOB NOT NULL,
+    algorithm INT NOT NULL,
+    sign_count INT UNSIGNED NOT NULL DEFAULT 0,
+    aaguid BINARY(16),
+    attestation_format VARCHAR(32) NOT NULL,
+    transports VARCHAR(255),
+    backup_eligible BOOLEAN NOT NULL DEFAULT FALSE,
+    backed_up BOOLEAN NOT NULL DEFAULT FALSE,
+    name VARCHAR(100) NOT NULL,
+    last_used_at TIMESTAMP NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+
+    UNIQUE KEY uk_webauthn_credential_id (credential_id),
+    INDEX idx_webauthn_credentials_subject (subject_type, subject_id)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+-- Pending registration and login challenges (single use)
+CREATE TABLE IF NOT EXISTS webauthn_sessions (
+    id CHAR(36) PRIMARY KEY,
+    subject_type VARCHAR(20) NOT NULL,
+    subject_id BIGINT UNSIGNED NULL,
+    ceremony VARCHAR(20) NOT NULL,
+    challenge VARCHAR(64) NOT NULL,
+    expires_at TIMESTAMP NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+
+    INDEX idx_webauthn_sessions_expires (expires_at)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
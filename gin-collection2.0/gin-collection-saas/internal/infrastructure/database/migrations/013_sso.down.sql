@@ -0,0 +1,5 @@
+-- Migration: sso (down)
+-- Created at: 2026-02-18T10:21:33+01:00
+
+DROP TABLE IF EXISTS sso_identities;
+DROP TABLE IF EXISTS sso_login_states;
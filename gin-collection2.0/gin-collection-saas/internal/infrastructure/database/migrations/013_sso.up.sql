@@ -0,0 +1,33 @@
+-- Migration: sso
+-- Created at: 2026-02-18T10:21:33+01:00
+
+-- Pending single sign-on logins (state parameter as SHA-256 hash, single use)
+CREATE TABLE IF NOT EXISTS sso_login_states (
+    id CHAR(64) PRIMARY KEY,
+    tenant_id BIGINT UNSIGNED NOT NULL,
+    protocol VARCHAR(10) NOT NULL,
+    nonce VARCHAR(64) NULL,
+    code_verifier VARCHAR(128) NULL,
+    request_id VARCHAR(64) NULL,
+    redirect_path VARCHAR(255) NOT NULL DEFAULT '/',
+    expires_at TIMESTAMP NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+
+    INDEX idx_sso_login_states_expires (expires_at),
+    FOREIGN KEY (tenant_id) REFERENCES tenants(id) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+-- Identity provider subjects linked to users
+CREATE TABLE IF NOT EXISTS sso_identities (
+    id BIGINT UNSIGNED AUTO_INCREMENT PRIMARY KEY,
+    tenant_id BIGINT UNSIGNED NOT NULL,
+    user_id BIGINT UNSIGNED NOT NULL,
+    protocol VARCHAR(10) NOT NULL,
+    subject VARCHAR(255) NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+
+    UNIQUE KEY uk_sso_identities_subject (tenant_id, protocol, subject),
+    INDEX idx_sso_identities_user (user_id),
+    FOREIGN KEY (tenant_id) REFERENCES tenants(id) ON DELETE CASCADE,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
@@ -0,0 +1,6 @@
+-- Migration: scim (down)
+-- Created at: 2026-02-24T09:12:47+01:00
+
+DROP TABLE IF EXISTS scim_group_members;
+DROP TABLE IF EXISTS scim_groups;
+DROP TABLE IF EXISTS scim_tokens;
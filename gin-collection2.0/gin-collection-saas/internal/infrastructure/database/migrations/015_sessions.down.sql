@@ -0,0 +1,4 @@
+-- Migration: sessions (down)
+-- Created at: 2026-03-03T10:27:54+01:00
+
+DROP TABLE IF EXISTS user_sessions;
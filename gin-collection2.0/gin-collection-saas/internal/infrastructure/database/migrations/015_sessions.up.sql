@@ -0,0 +1,26 @@
+-- Migration: sessions
+-- Created at: 2026-03-03T10:27:54+01:00
+
+-- Logins of tenant users, one row per device. refresh_token_id is the ID of
+-- the only refresh token of the session that is still valid.
+CREATE TABLE IF NOT EXISTS user_sessions (
+    id BIGINT UNSIGNED AUTO_INCREMENT PRIMARY KEY,
+    uuid VARCHAR(36) NOT NULL,
+    user_id BIGINT UNSIGNED NOT NULL,
+    tenant_id BIGINT UNSIGNED NOT NULL,
+    refresh_token_id VARCHAR(36) NOT NULL,
+    device VARCHAR(100) NOT NULL,
+    ip_address VARCHAR(45),
+    user_agent VARCHAR(255),
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    last_used_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    expires_at TIMESTAMP NOT NULL,
+    revoked_at TIMESTAMP NULL,
+    revoked_reason VARCHAR(32) NULL,
+
+    UNIQUE KEY uk_user_sessions_uuid (uuid),
+    INDEX idx_user_sessions_user (user_id, revoked_at),
+    INDEX idx_user_sessions_expires (expires_at),
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+    FOREIGN KEY (tenant_id) REFERENCES tenants(id) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
@@ -0,0 +1,8 @@
+-- Migration: api_keys (down)
+-- Created at: 2026-03-05T14:08:31+01:00
+
+-- Keys are only stored as hashes, users have to generate new ones
+ALTER TABLE users ADD COLUMN api_key VARCHAR(64) UNIQUE COMMENT 'Enterprise only' AFTER role;
+ALTER TABLE users ADD INDEX idx_api_key (api_key);
+
+DROP TABLE IF EXISTS api_keys;
@@ -0,0 +1,39 @@
+-- Migration: api_keys
+-- Created at: 2026-03-05T14:08:31+01:00
+
+-- API keys of tenant users (SHA-256 hash, several named keys per user).
+-- scopes is a JSON array like ["gins:read", "tastings:write"], rate_limit
+-- is in requests per hour on top of the limit of the plan.
+CREATE TABLE IF NOT EXISTS api_keys (
+    id BIGINT UNSIGNED AUTO_INCREMENT PRIMARY KEY,
+    uuid VARCHAR(36) NOT NULL,
+    tenant_id BIGINT UNSIGNED NOT NULL,
+    user_id BIGINT UNSIGNED NOT NULL,
+    name VARCHAR(100) NOT NULL,
+    key_prefix VARCHAR(16) NOT NULL,
+    key_hash CHAR(64) NOT NULL,
+    scopes JSON NOT NULL,
+    rate_limit INT UNSIGNED NULL,
+    expires_at TIMESTAMP NULL,
+    last_used_at TIMESTAMP NULL,
+    last_used_ip VARCHAR(45) NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    revoked_at TIMESTAMP NULL,
+
+    UNIQUE KEY uk_api_keys_uuid (uuid),
+    UNIQUE KEY uk_api_keys_hash (key_hash),
+    INDEX idx_api_keys_user (user_id, revoked_at),
+    FOREIGN KEY (tenant_id) REFERENCES tenants(id) ON DELETE CASCADE,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+-- Existing plaintext keys keep working with every scope
+INSERT INTO api_keys (uuid, tenant_id, user_id, name, key_prefix, key_hash, scopes)
+SELECT UUID(), tenant_id, id, 'Migrated key', LEFT(api_key, 11), SHA2(api_key, 256),
+       JSON_ARRAY('gins:read', 'gins:write', 'tastings:read', 'tastings:write',
+                  'photos:read', 'photos:write', 'catalog:read')
+FROM users
+WHERE api_key IS NOT NULL;
+
+ALTER TABLE users DROP INDEX idx_api_key;
+ALTER TABLE users DROP COLUMN api_key;
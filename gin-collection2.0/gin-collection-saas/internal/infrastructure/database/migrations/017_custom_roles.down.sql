@@ -0,0 +1,7 @@
+-- Migration: custom_roles (down)
+-- Created at: 2026-03-09T10:21:47+01:00
+
+ALTER TABLE users DROP FOREIGN KEY fk_users_custom_role;
+ALTER TABLE users DROP COLUMN custom_role_id;
+
+DROP TABLE IF EXISTS custom_roles;
@@ -0,0 +1,22 @@
+-- Migration: custom_roles
+-- Created at: 2026-03-09T10:21:47+01:00
+
+-- Tenant defined roles (Enterprise). permissions is a JSON array like
+-- ["gins:read", "tastings:write"], a user assigned to a custom role gets its
+-- permissions instead of those of the built-in role.
+CREATE TABLE IF NOT EXISTS custom_roles (
+    id BIGINT UNSIGNED AUTO_INCREMENT PRIMARY KEY,
+    tenant_id BIGINT UNSIGNED NOT NULL,
+    name VARCHAR(50) NOT NULL,
+    description VARCHAR(255) NULL,
+    permissions JSON NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+
+    UNIQUE KEY uk_custom_roles_tenant_name (tenant_id, name),
+    FOREIGN KEY (tenant_id) REFERENCES tenants(id) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+ALTER TABLE users ADD COLUMN custom_role_id BIGINT UNSIGNED NULL AFTER role;
+ALTER TABLE users ADD CONSTRAINT fk_users_custom_role
+    FOREIGN KEY (custom_role_id) REFERENCES custom_roles(id) ON DELETE SET NULL;
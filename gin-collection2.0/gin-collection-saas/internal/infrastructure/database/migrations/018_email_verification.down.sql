@@ -0,0 +1,9 @@
+-- Migration: email_verification (down)
+-- Created at: 2026-03-12T14:08:26+01:00
+
+UPDATE users u
+SET u.email_verified_at = NULL
+WHERE u.email_verified_at = u.created_at
+  AND NOT EXISTS (SELECT 1 FROM email_verifications ev WHERE ev.user_id = u.id);
+
+DROP TABLE IF EXISTS email_verifications;
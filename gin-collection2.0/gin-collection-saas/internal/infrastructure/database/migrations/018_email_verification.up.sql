@@ -0,0 +1,35 @@
+-- Migration: email_verification
+-- Created at: 2026-03-12T14:08:26+01:00
+
+-- Pending address verifications and email changes (SHA-256 token hashes).
+-- An email change needs confirmation from the new (token_hash) and the
+-- current address (old_token_hash).
+CREATE TABLE IF NOT EXISTS email_verifications (
+    id BIGINT UNSIGNED AUTO_INCREMENT PRIMARY KEY,
+    user_id BIGINT UNSIGNED NOT NULL,
+    purpose VARCHAR(10) NOT NULL,
+    email VARCHAR(255) NOT NULL,
+    token_hash CHAR(64) NOT NULL,
+    old_token_hash CHAR(64) NULL,
+    confirmed_at TIMESTAMP NULL,
+    old_confirmed_at TIMESTAMP NULL,
+    expires_at TIMESTAMP NOT NULL,
+    completed_at TIMESTAMP NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+
+    UNIQUE KEY uk_email_verifications_token (token_hash),
+    UNIQUE KEY uk_email_verifications_old_token (old_token_hash),
+    INDEX idx_email_verifications_user (user_id, purpose),
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+-- Accounts created before email verification existed never received a
+-- verification email. They are treated as verified, otherwise the tiers in
+-- EMAIL_VERIFICATION_REQUIRED_TIERS would make them read-only. Accounts
+-- registered since then always have an email_verifications row, pending
+-- invitations are still inactive.
+UPDATE users u
+SET u.email_verified_at = u.created_at
+WHERE u.email_verified_at IS NULL
+  AND u.is_active = TRUE
+  AND NOT EXISTS (SELECT 1 FROM email_verifications ev WHERE ev.user_id = u.id);
@@ -0,0 +1,4 @@
+-- Migration: login_devices (down)
+-- Created at: 2026-03-14T09:42:13+01:00
+
+DROP TABLE IF EXISTS login_devices;
@@ -0,0 +1,17 @@
+-- Migration: login_devices
+-- Created at: 2026-03-14T09:42:13+01:00
+
+-- Devices and networks users logged in from. A login from a device or
+-- network that isn't listed yet is reported to the user by email.
+CREATE TABLE IF NOT EXISTS login_devices (
+    id BIGINT UNSIGNED AUTO_INCREMENT PRIMARY KEY,
+    user_id BIGINT UNSIGNED NOT NULL,
+    device VARCHAR(100) NOT NULL,
+    network VARCHAR(64) NOT NULL,
+    ip_address VARCHAR(45) NULL,
+    first_seen_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    last_seen_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+
+    UNIQUE KEY uk_login_devices_user_device_network (user_id, device, network),
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
@@ -0,0 +1,4 @@
+-- Migration: webhook_events (down)
+-- Created at: 2026-03-16T10:18:37+01:00
+
+DROP TABLE IF EXISTS webhook_events;
@@ -0,0 +1,24 @@
+-- Migration: webhook_events
+-- Created at: 2026-03-16T10:18:37+01:00
+
+-- Webhook events received from payment providers. Events are unique per
+-- provider ID so redelivered events are only processed once, the resource
+-- update time keeps older events from overwriting newer state.
+CREATE TABLE IF NOT EXISTS webhook_events (
+    id BIGINT UNSIGNED AUTO_INCREMENT PRIMARY KEY,
+    provider VARCHAR(20) NOT NULL,
+    event_id VARCHAR(100) NOT NULL,
+    event_type VARCHAR(100) NOT NULL,
+    resource_id VARCHAR(100) NOT NULL DEFAULT '',
+    resource_updated_at TIMESTAMP NULL,
+    status ENUM('received', 'processed', 'skipped', 'failed') NOT NULL DEFAULT 'received',
+    attempts INT UNSIGNED NOT NULL DEFAULT 0,
+    error TEXT NULL,
+    payload JSON NOT NULL,
+    received_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    processed_at TIMESTAMP NULL,
+
+    UNIQUE KEY uk_webhook_events_provider_event (provider, event_id),
+    INDEX idx_webhook_events_resource (provider, resource_id, status),
+    INDEX idx_webhook_events_received (received_at)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
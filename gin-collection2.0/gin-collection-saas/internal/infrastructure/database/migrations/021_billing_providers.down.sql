@@ -0,0 +1,29 @@
+-- Migration: billing_providers (down)
+-- Created at: 2026-03-17T09:42:11+01:00
+
+ALTER TABLE subscriptions
+    DROP INDEX uk_subscriptions_provider_subscription,
+    DROP INDEX idx_subscriptions_provider_checkout;
+
+-- Only the latest subscription of a tenant is kept
+DELETE s FROM subscriptions s
+JOIN subscriptions newer ON newer.tenant_id = s.tenant_id AND newer.id > s.id;
+
+UPDATE subscriptions SET status = 'cancelled' WHERE status IN ('pending', 'suspended', 'expired');
+UPDATE subscriptions SET current_period_start = COALESCE(current_period_start, created_at),
+                         current_period_end = COALESCE(current_period_end, created_at);
+
+ALTER TABLE subscriptions
+    DROP COLUMN uuid,
+    DROP COLUMN provider,
+    CHANGE COLUMN provider_customer_id paypal_customer_id VARCHAR(255),
+    CHANGE COLUMN provider_subscription_id paypal_subscription_id VARCHAR(255),
+    DROP COLUMN provider_plan_id,
+    DROP COLUMN provider_checkout_id,
+    DROP COLUMN amount,
+    DROP COLUMN currency,
+    DROP COLUMN next_billing_date,
+    MODIFY COLUMN status ENUM('active', 'past_due', 'cancelled', 'trialing') NOT NULL DEFAULT 'active',
+    MODIFY COLUMN current_period_start DATE NOT NULL,
+    MODIFY COLUMN current_period_end DATE NOT NULL,
+    ADD UNIQUE KEY tenant_id (tenant_id);
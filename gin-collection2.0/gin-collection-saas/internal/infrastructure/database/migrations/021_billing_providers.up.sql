@@ -0,0 +1,26 @@
+-- Migration: billing_providers
+-- Created at: 2026-03-17T09:42:11+01:00
+
+-- Subscriptions are billed with PayPal or Stripe. The provider fields replace
+-- the PayPal ones, a tenant keeps its earlier subscriptions when it changes
+-- plans or providers so tenant_id is no longer unique.
+ALTER TABLE subscriptions DROP INDEX tenant_id;
+
+ALTER TABLE subscriptions
+    ADD COLUMN uuid VARCHAR(36) NULL AFTER tenant_id,
+    ADD COLUMN provider VARCHAR(20) NOT NULL DEFAULT 'paypal' AFTER cancel_at_period_end,
+    CHANGE COLUMN paypal_customer_id provider_customer_id VARCHAR(255) NULL,
+    CHANGE COLUMN paypal_subscription_id provider_subscription_id VARCHAR(255) NULL,
+    ADD COLUMN provider_plan_id VARCHAR(255) NULL AFTER provider_subscription_id,
+    ADD COLUMN provider_checkout_id VARCHAR(255) NULL AFTER provider_plan_id,
+    ADD COLUMN amount DECIMAL(10, 2) NOT NULL DEFAULT 0 AFTER provider_checkout_id,
+    ADD COLUMN currency CHAR(3) NOT NULL DEFAULT 'EUR' AFTER amount,
+    ADD COLUMN next_billing_date TIMESTAMP NULL AFTER current_period_end,
+    MODIFY COLUMN status ENUM('pending', 'active', 'past_due', 'suspended', 'cancelled', 'expired', 'trialing')
+        NOT NULL DEFAULT 'pending',
+    MODIFY COLUMN current_period_start TIMESTAMP NULL,
+    MODIFY COLUMN current_period_end TIMESTAMP NULL;
+
+ALTER TABLE subscriptions
+    ADD UNIQUE KEY uk_subscriptions_provider_subscription (provider, provider_subscription_id),
+    ADD INDEX idx_subscriptions_provider_checkout (provider, provider_checkout_id);
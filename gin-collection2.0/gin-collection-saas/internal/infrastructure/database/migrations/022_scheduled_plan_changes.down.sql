@@ -0,0 +1,7 @@
+-- Migration: scheduled_plan_changes (down)
+-- Created at: 2026-03-24T10:15:37+01:00
+
+ALTER TABLE subscriptions
+    DROP INDEX idx_subscriptions_scheduled_change,
+    DROP COLUMN scheduled_change_at,
+    DROP COLUMN scheduled_plan_id;
@@ -0,0 +1,9 @@
+-- Migration: scheduled_plan_changes
+-- Created at: 2026-03-24T10:15:37+01:00
+
+-- Downgrades take effect at the end of the billing period, the target plan
+-- is kept on the subscription until then
+ALTER TABLE subscriptions
+    ADD COLUMN scheduled_plan_id VARCHAR(50) NULL AFTER cancel_at_period_end,
+    ADD COLUMN scheduled_change_at TIMESTAMP NULL AFTER scheduled_plan_id,
+    ADD INDEX idx_subscriptions_scheduled_change (scheduled_change_at);
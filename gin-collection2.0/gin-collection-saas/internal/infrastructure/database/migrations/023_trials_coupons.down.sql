@@ -0,0 +1,13 @@
+-- Migration: trials_coupons (down)
+-- Created at: 2026-03-27T09:42:11+01:00
+
+ALTER TABLE subscriptions
+    DROP FOREIGN KEY fk_subscriptions_coupon,
+    DROP INDEX idx_subscriptions_trial,
+    DROP COLUMN trial_reminder_sent_at,
+    DROP COLUMN discount_ends_at,
+    DROP COLUMN discount,
+    DROP COLUMN coupon_id;
+
+DROP TABLE IF EXISTS coupon_redemptions;
+DROP TABLE IF EXISTS coupons;
@@ -0,0 +1,56 @@
+-- Migration: trials_coupons
+-- Created at: 2026-03-27T09:42:11+01:00
+
+-- Coupon codes created by platform admins. Codes are stored upper case,
+-- plan_ids restricts the plans a coupon applies to (NULL = all plans).
+CREATE TABLE IF NOT EXISTS coupons (
+    id BIGINT UNSIGNED AUTO_INCREMENT PRIMARY KEY,
+    code VARCHAR(50) NOT NULL,
+    description VARCHAR(255) NULL,
+    discount_type ENUM('percent', 'fixed') NOT NULL,
+    discount_value DECIMAL(10,2) NOT NULL,
+    currency VARCHAR(3) NOT NULL DEFAULT 'EUR',
+    duration ENUM('once', 'repeating', 'forever') NOT NULL DEFAULT 'once',
+    duration_months INT UNSIGNED NULL,
+    max_redemptions INT UNSIGNED NULL,
+    redemptions INT UNSIGNED NOT NULL DEFAULT 0,
+    expires_at TIMESTAMP NULL,
+    plan_ids JSON NULL,
+    active BOOLEAN NOT NULL DEFAULT TRUE,
+    created_by BIGINT UNSIGNED NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+
+    UNIQUE KEY uk_coupons_code (code),
+    FOREIGN KEY (created_by) REFERENCES platform_admins(id) ON DELETE SET NULL
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+-- Each tenant redeems a coupon once. A checkout reserves a pending
+-- redemption, it is redeemed when the discounted subscription is activated
+-- and released when the checkout fails or expires_at passes.
+CREATE TABLE IF NOT EXISTS coupon_redemptions (
+    id BIGINT UNSIGNED AUTO_INCREMENT PRIMARY KEY,
+    coupon_id BIGINT UNSIGNED NOT NULL,
+    tenant_id BIGINT UNSIGNED NOT NULL,
+    subscription_id BIGINT UNSIGNED NULL,
+    discount DECIMAL(10,2) NOT NULL DEFAULT 0,
+    status ENUM('pending', 'redeemed') NOT NULL DEFAULT 'redeemed',
+    expires_at TIMESTAMP NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+
+    UNIQUE KEY uk_coupon_redemptions_tenant (coupon_id, tenant_id),
+    INDEX idx_coupon_redemptions_pending (status, expires_at),
+    FOREIGN KEY (coupon_id) REFERENCES coupons(id) ON DELETE CASCADE,
+    FOREIGN KEY (tenant_id) REFERENCES tenants(id) ON DELETE CASCADE,
+    FOREIGN KEY (subscription_id) REFERENCES subscriptions(id) ON DELETE SET NULL
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+-- Subscriptions keep the coupon they were started with, trials remember
+-- when the reminder about their end was sent
+ALTER TABLE subscriptions
+    ADD COLUMN coupon_id BIGINT UNSIGNED NULL AFTER scheduled_change_at,
+    ADD COLUMN discount DECIMAL(10,2) NOT NULL DEFAULT 0 AFTER coupon_id,
+    ADD COLUMN discount_ends_at TIMESTAMP NULL AFTER discount,
+    ADD COLUMN trial_reminder_sent_at TIMESTAMP NULL AFTER trial_ends_at,
+    ADD INDEX idx_subscriptions_trial (status, trial_ends_at),
+    ADD CONSTRAINT fk_subscriptions_coupon FOREIGN KEY (coupon_id) REFERENCES coupons(id) ON DELETE SET NULL;
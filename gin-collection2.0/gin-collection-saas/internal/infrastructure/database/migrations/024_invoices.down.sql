@@ -0,0 +1,5 @@
+-- Migration: invoices (down)
+-- Created at: 2026-04-02T14:18:37+02:00
+
+DROP TABLE IF EXISTS invoices;
+DROP TABLE IF EXISTS invoice_sequences;
@@ -0,0 +1,39 @@
+-- Migration: invoices
+-- Created at: 2026-04-02T14:18:37+02:00
+
+-- Last invoice number of each year, invoice numbers are sequential per year
+CREATE TABLE IF NOT EXISTS invoice_sequences (
+    year SMALLINT UNSIGNED PRIMARY KEY,
+    last_number INT UNSIGNED NOT NULL DEFAULT 0
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+-- Invoices of payments taken by the billing providers. Customer and seller
+-- are stored as issued. Invoices have to be kept for bookkeeping, so they
+-- aren't deleted with their tenant.
+CREATE TABLE IF NOT EXISTS invoices (
+    id BIGINT UNSIGNED AUTO_INCREMENT PRIMARY KEY,
+    tenant_id BIGINT UNSIGNED NOT NULL,
+    subscription_id BIGINT UNSIGNED NULL,
+    number VARCHAR(50) NOT NULL,
+    provider VARCHAR(20) NOT NULL,
+    provider_payment_id VARCHAR(255) NOT NULL,
+    plan_id VARCHAR(100) NOT NULL,
+    description VARCHAR(255) NOT NULL,
+    period_start TIMESTAMP NULL,
+    period_end TIMESTAMP NULL,
+    currency VARCHAR(3) NOT NULL DEFAULT 'EUR',
+    net_amount DECIMAL(10,2) NOT NULL,
+    tax_rate DECIMAL(5,2) NOT NULL DEFAULT 0,
+    tax_amount DECIMAL(10,2) NOT NULL DEFAULT 0,
+    total_amount DECIMAL(10,2) NOT NULL,
+    reverse_charge BOOLEAN NOT NULL DEFAULT FALSE,
+    customer JSON NULL,
+    seller JSON NOT NULL,
+    issued_at TIMESTAMP NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+
+    UNIQUE KEY uk_invoices_number (number),
+    UNIQUE KEY uk_invoices_payment (provider, provider_payment_id),
+    INDEX idx_invoices_tenant (tenant_id, issued_at),
+    FOREIGN KEY (subscription_id) REFERENCES subscriptions(id) ON DELETE SET NULL
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
@@ -0,0 +1,10 @@
+-- Migration: dunning (down)
+-- Created at: 2026-04-07T09:42:18+02:00
+
+ALTER TABLE tenants DROP COLUMN billing_restricted;
+
+ALTER TABLE subscriptions
+    DROP INDEX idx_subscriptions_dunning_stage,
+    DROP COLUMN dunning_reminders_sent,
+    DROP COLUMN dunning_stage,
+    DROP COLUMN past_due_since;
@@ -0,0 +1,14 @@
+-- Migration: dunning
+-- Created at: 2026-04-07T09:42:18+02:00
+
+-- A failed payment starts dunning: the subscription is past due since the
+-- failure and moves through the dunning stages until it's paid
+ALTER TABLE subscriptions
+    ADD COLUMN past_due_since TIMESTAMP NULL AFTER trial_reminder_sent_at,
+    ADD COLUMN dunning_stage VARCHAR(20) NULL AFTER past_due_since,
+    ADD COLUMN dunning_reminders_sent INT NOT NULL DEFAULT 0 AFTER dunning_stage,
+    ADD INDEX idx_subscriptions_dunning_stage (dunning_stage);
+
+-- Tenants past the grace period are held to the Free limits until they pay
+ALTER TABLE tenants
+    ADD COLUMN billing_restricted BOOLEAN NOT NULL DEFAULT FALSE AFTER require_two_factor;
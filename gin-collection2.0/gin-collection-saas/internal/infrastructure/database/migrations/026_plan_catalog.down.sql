@@ -0,0 +1,9 @@
+-- Migration: plan_catalog (down)
+-- Created at: 2026-04-14T10:12:37+02:00
+
+ALTER TABLE tenants DROP COLUMN plan_limits;
+
+ALTER TABLE subscriptions DROP COLUMN plan_version;
+
+DROP TABLE IF EXISTS plan_versions;
+DROP TABLE IF EXISTS plans;
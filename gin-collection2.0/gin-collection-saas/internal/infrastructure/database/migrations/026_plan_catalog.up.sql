@@ -0,0 +1,66 @@
+-- Migration: plan_catalog
+-- Created at: 2026-04-14T10:12:37+02:00
+
+-- Plans offered to tenants, managed by platform admins. Prices and limits
+-- are versioned, current_version is offered to new subscribers.
+CREATE TABLE IF NOT EXISTS plans (
+    id VARCHAR(50) PRIMARY KEY,
+    name VARCHAR(100) NOT NULL,
+    tier ENUM('free', 'basic', 'pro', 'enterprise') NOT NULL,
+    sort_order INT NOT NULL DEFAULT 0,
+    active BOOLEAN NOT NULL DEFAULT TRUE,
+    current_version INT UNSIGNED NOT NULL DEFAULT 1,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+
+    INDEX idx_plans_sort_order (sort_order)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+-- provider_plans maps billing providers to the plan or price ID of the
+-- version (NULL = the plans configured for the provider)
+CREATE TABLE IF NOT EXISTS plan_versions (
+    id BIGINT UNSIGNED AUTO_INCREMENT PRIMARY KEY,
+    plan_id VARCHAR(50) NOT NULL,
+    version INT UNSIGNED NOT NULL,
+    price_monthly DECIMAL(10,2) NOT NULL DEFAULT 0,
+    price_yearly DECIMAL(10,2) NOT NULL DEFAULT 0,
+    currency VARCHAR(3) NOT NULL DEFAULT 'EUR',
+    limits JSON NOT NULL,
+    provider_plans JSON NULL,
+    created_by BIGINT UNSIGNED NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+
+    UNIQUE KEY uk_plan_versions_version (plan_id, version),
+    FOREIGN KEY (plan_id) REFERENCES plans(id) ON DELETE CASCADE,
+    FOREIGN KEY (created_by) REFERENCES platform_admins(id) ON DELETE SET NULL
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+INSERT INTO plans (id, name, tier, sort_order) VALUES
+    ('PLAN_FREE', 'Free', 'free', 0),
+    ('PLAN_BASIC_MONTHLY', 'Basic', 'basic', 10),
+    ('PLAN_BASIC_YEARLY', 'Basic (Yearly)', 'basic', 20),
+    ('PLAN_PRO_MONTHLY', 'Pro', 'pro', 30),
+    ('PLAN_PRO_YEARLY', 'Pro (Yearly)', 'pro', 40),
+    ('PLAN_ENTERPRISE', 'Enterprise', 'enterprise', 50);
+
+INSERT INTO plan_versions (plan_id, version, price_monthly, price_yearly, limits) VALUES
+    ('PLAN_FREE', 1, 0, 0,
+     '{"max_gins": 5, "max_photos_per_gin": 3, "has_botanicals": false, "has_cocktails": false, "has_ai_suggestions": false, "has_export": false, "has_import": false, "has_multi_user": false, "has_api_access": false, "api_rate_limit": 100, "storage_limit_mb": 100, "has_sso": false, "has_scim": false}'),
+    ('PLAN_BASIC_MONTHLY', 1, 4.99, 49.99,
+     '{"max_gins": 15, "max_photos_per_gin": 10, "has_botanicals": false, "has_cocktails": false, "has_ai_suggestions": false, "has_export": true, "has_import": false, "has_multi_user": false, "has_api_access": false, "api_rate_limit": 500, "storage_limit_mb": 1000, "has_sso": false, "has_scim": false}'),
+    ('PLAN_BASIC_YEARLY', 1, 4.99, 49.99,
+     '{"max_gins": 15, "max_photos_per_gin": 10, "has_botanicals": false, "has_cocktails": false, "has_ai_suggestions": false, "has_export": true, "has_import": false, "has_multi_user": false, "has_api_access": false, "api_rate_limit": 500, "storage_limit_mb": 1000, "has_sso": false, "has_scim": false}'),
+    ('PLAN_PRO_MONTHLY', 1, 9.99, 99.99,
+     '{"max_gins": 100, "max_photos_per_gin": 25, "has_botanicals": true, "has_cocktails": true, "has_ai_suggestions": true, "has_export": true, "has_import": true, "has_multi_user": false, "has_api_access": true, "api_rate_limit": 5000, "storage_limit_mb": 5000, "has_sso": false, "has_scim": false}'),
+    ('PLAN_PRO_YEARLY', 1, 9.99, 99.99,
+     '{"max_gins": 100, "max_photos_per_gin": 25, "has_botanicals": true, "has_cocktails": true, "has_ai_suggestions": true, "has_export": true, "has_import": true, "has_multi_user": false, "has_api_access": true, "api_rate_limit": 5000, "storage_limit_mb": 5000, "has_sso": false, "has_scim": false}'),
+    ('PLAN_ENTERPRISE', 1, 29.99, 299.99,
+     '{"max_gins": null, "max_photos_per_gin": -1, "has_botanicals": true, "has_cocktails": true, "has_ai_suggestions": true, "has_export": true, "has_import": true, "has_multi_user": true, "has_api_access": true, "api_rate_limit": 10000, "storage_limit_mb": null, "has_sso": true, "has_scim": true}');
+
+-- Subscribers keep the version they subscribed to, existing ones are on the
+-- first. Tenants keep the limits of that version (NULL = the tier's limits).
+ALTER TABLE subscriptions
+    ADD COLUMN plan_version INT UNSIGNED NOT NULL DEFAULT 1 AFTER plan_id;
+
+ALTER TABLE tenants
+    ADD COLUMN plan_limits JSON NULL AFTER billing_restricted;
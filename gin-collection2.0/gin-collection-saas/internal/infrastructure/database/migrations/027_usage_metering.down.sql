@@ -0,0 +1,7 @@
+-- Migration: usage_metering (down)
+-- Created at: 2026-04-21T14:05:51+02:00
+
+ALTER TABLE usage_metrics
+    MODIFY COLUMN metric_name VARCHAR(50) NOT NULL COMMENT 'gin_count, api_calls, storage_mb';
+
+ALTER TABLE tenants DROP COLUMN limit_overrides;
@@ -0,0 +1,12 @@
+-- Migration: usage_metering
+-- Created at: 2026-04-21T14:05:51+02:00
+
+-- Limits a platform admin set for a single tenant (e.g. a negotiated deal),
+-- applied on top of the limits of its plan
+ALTER TABLE tenants
+    ADD COLUMN limit_overrides JSON NULL AFTER plan_limits;
+
+-- Metered usage is counted per billing period of the tenant, with the limit
+-- that applied
+ALTER TABLE usage_metrics
+    MODIFY COLUMN metric_name VARCHAR(50) NOT NULL COMMENT 'gin_count, api_calls, ai_suggestions, storage_mb';
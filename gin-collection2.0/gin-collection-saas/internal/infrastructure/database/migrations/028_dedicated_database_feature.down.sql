@@ -0,0 +1,9 @@
+-- Migration: dedicated_database_feature (down)
+-- Created at: 2026-04-28T09:41:12+02:00
+
+UPDATE tenants
+SET plan_limits = JSON_REMOVE(plan_limits, '$.has_dedicated_database')
+WHERE plan_limits IS NOT NULL;
+
+UPDATE plan_versions
+SET limits = JSON_REMOVE(limits, '$.has_dedicated_database');
@@ -0,0 +1,12 @@
+-- Migration: dedicated_database_feature
+-- Created at: 2026-04-28T09:41:12+02:00
+
+-- A database of their own is a feature of the plan limits now, included in
+-- Enterprise plans. Catalog limits and the limits copied to tenants get it.
+UPDATE plan_versions pv
+JOIN plans p ON p.id = pv.plan_id
+SET pv.limits = JSON_SET(pv.limits, '$.has_dedicated_database', IF(p.tier = 'enterprise', CAST('true' AS JSON), CAST('false' AS JSON)));
+
+UPDATE tenants
+SET plan_limits = JSON_SET(plan_limits, '$.has_dedicated_database', IF(tier = 'enterprise', CAST('true' AS JSON), CAST('false' AS JSON)))
+WHERE plan_limits IS NOT NULL;
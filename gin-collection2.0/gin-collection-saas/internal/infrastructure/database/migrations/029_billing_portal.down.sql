@@ -0,0 +1,11 @@
+-- Migration: billing_portal (down)
+-- Created at: 2026-05-04T11:23:08+02:00
+
+ALTER TABLE plans DROP COLUMN billing_cycle;
+
+UPDATE subscriptions SET status = 'suspended' WHERE status = 'paused';
+
+ALTER TABLE subscriptions
+    DROP COLUMN paused_at,
+    MODIFY COLUMN status ENUM('pending', 'active', 'past_due', 'suspended', 'cancelled', 'expired', 'trialing')
+        NOT NULL DEFAULT 'pending';
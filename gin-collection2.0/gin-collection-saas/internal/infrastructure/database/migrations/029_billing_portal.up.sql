@@ -0,0 +1,16 @@
+-- Migration: billing_portal
+-- Created at: 2026-05-04T11:23:08+02:00
+
+-- Owners pause subscriptions themselves, billing stops until they resume
+ALTER TABLE subscriptions
+    MODIFY COLUMN status ENUM('pending', 'active', 'past_due', 'suspended', 'paused', 'cancelled', 'expired', 'trialing')
+        NOT NULL DEFAULT 'pending',
+    ADD COLUMN paused_at TIMESTAMP NULL AFTER dunning_reminders_sent;
+
+-- The billing cycle a plan is billed in, NULL = the plan is offered in both.
+-- Switching cycles moves a subscription to the plan of its tier in the other.
+ALTER TABLE plans
+    ADD COLUMN billing_cycle ENUM('monthly', 'yearly') NULL AFTER tier;
+
+UPDATE plans SET billing_cycle = 'monthly' WHERE id IN ('PLAN_BASIC_MONTHLY', 'PLAN_PRO_MONTHLY');
+UPDATE plans SET billing_cycle = 'yearly' WHERE id IN ('PLAN_BASIC_YEARLY', 'PLAN_PRO_YEARLY');
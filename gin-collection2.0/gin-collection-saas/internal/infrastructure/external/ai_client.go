@@ -2,6 +2,7 @@ package external
 
 import (
 	"bytes"
+	"encoding/base64"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -35,6 +36,17 @@ type GinSuggestion struct {
 	ABV                float64  `json:"abv"`
 }
 
+// LabelExtraction represents gin details read from a label photo
+type LabelExtraction struct {
+	Name         string             `json:"name"`
+	Brand        string             `json:"brand"`
+	ABV          float64            `json:"abv"`
+	BottleSizeML int                `json:"bottle_size_ml"`
+	Country      string             `json:"country"`
+	Text         string             `json:"text"`
+	Confidence   map[string]float64 `json:"confidence"`
+}
+
 // AIClientConfig holds configuration for the AI client
 type AIClientConfig struct {
 	Provider        string
@@ -97,6 +109,43 @@ func (c *AIClient) SuggestGinInfo(name, brand string) (*GinSuggestion, error) {
 	return parseGinSuggestion(responseText)
 }
 
+// ExtractLabelInfo reads name, brand, ABV, bottle size and country from a label photo
+func (c *AIClient) ExtractLabelInfo(image []byte, mediaType string) (*LabelExtraction, error) {
+	if !c.IsEnabled() {
+		return nil, fmt.Errorf("AI service is not enabled")
+	}
+
+	prompt := buildLabelPrompt()
+
+	var responseText string
+	var err error
+
+	switch c.provider {
+	case "ollama":
+		responseText, err = c.callOllama(prompt, image)
+	case "anthropic":
+		responseText, err = c.callAnthropicWithImage(prompt, image, mediaType)
+	default:
+		return nil, fmt.Errorf("unknown AI provider: %s", c.provider)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	jsonText, err := extractJSONObject(responseText)
+	if err != nil {
+		return nil, err
+	}
+
+	var extraction LabelExtraction
+	if err := json.Unmarshal([]byte(jsonText), &extraction); err != nil {
+		return nil, fmt.Errorf("failed to parse label JSON: %w (response: %s)", err, jsonText)
+	}
+
+	return &extraction, nil
+}
+
 func buildGinPrompt(name, brand string) string {
 	return fmt.Sprintf(`Du bist ein Gin-Experte und Barkeeper. Generiere detaillierte Informationen für diesen Gin:
 
@@ -115,11 +164,29 @@ Antworte NUR mit diesem JSON (ersetze alle Werte durch echte Informationen):
 Falls du den Gin nicht kennst, mache plausible Annahmen. Gib NUR valides JSON zurück.`, name, brand)
 }
 
+func buildLabelPrompt() string {
+	return `Du bist ein Gin-Experte. Lies das Etikett auf diesem Foto einer Gin-Flasche.
+
+Extrahiere NUR Angaben, die tatsächlich auf dem Etikett stehen. Lass unbekannte Felder leer ("" bzw. 0).
+
+- name: Name des Gins (ohne Marke, falls getrennt erkennbar)
+- brand: Marke bzw. Destillerie
+- abv: Alkoholgehalt in Prozent (z.B. 47.0)
+- bottle_size_ml: Flaschengröße in Milliliter (z.B. 700 für 70cl oder 0,7l)
+- country: Herkunftsland auf Deutsch
+- text: der gesamte lesbare Text des Etiketts
+- confidence: deine Sicherheit pro Feld zwischen 0 und 1
+
+Antworte NUR mit diesem JSON:
+{"name":"","brand":"","abv":0,"bottle_size_ml":0,"country":"","text":"","confidence":{"name":0,"brand":0,"abv":0,"bottle_size":0,"country":0}}`
+}
+
 // Ollama API types
 type ollamaRequest struct {
-	Model  string `json:"model"`
-	Prompt string `json:"prompt"`
-	Stream bool   `json:"stream"`
+	Model  string   `json:"model"`
+	Prompt string   `json:"prompt"`
+	Stream bool     `json:"stream"`
+	Images []string `json:"images,omitempty"` // base64, for multimodal models
 }
 
 type ollamaResponse struct {
@@ -127,13 +194,17 @@ type ollamaResponse struct {
 	Done     bool   `json:"done"`
 }
 
-func (c *AIClient) callOllama(prompt string) (string, error) {
+func (c *AIClient) callOllama(prompt string, images ...[]byte) (string, error) {
 	req := ollamaRequest{
 		Model:  c.model,
 		Prompt: prompt,
 		Stream: false,
 	}
 
+	for _, image := range images {
+		req.Images = append(req.Images, base64.StdEncoding.EncodeToString(image))
+	}
+
 	jsonBody, err := json.Marshal(req)
 	if err != nil {
 		return "", fmt.Errorf("failed to marshal request: %w", err)
@@ -178,8 +249,20 @@ type anthropicRequest struct {
 }
 
 type anthropicMessage struct {
-	Role    string `json:"role"`
-	Content string `json:"content"`
+	Role    string      `json:"role"`
+	Content interface{} `json:"content"` // string or []anthropicContentBlock
+}
+
+type anthropicContentBlock struct {
+	Type   string                `json:"type"`
+	Text   string                `json:"text,omitempty"`
+	Source *anthropicImageSource `json:"source,omitempty"`
+}
+
+type anthropicImageSource struct {
+	Type      string `json:"type"`
+	MediaType string `json:"media_type"`
+	Data      string `json:"data"`
 }
 
 type anthropicResponse struct {
@@ -194,12 +277,31 @@ type anthropicResponse struct {
 }
 
 func (c *AIClient) callAnthropic(prompt string) (string, error) {
+	return c.sendAnthropic(anthropicMessage{Role: "user", Content: prompt})
+}
+
+func (c *AIClient) callAnthropicWithImage(prompt string, image []byte, mediaType string) (string, error) {
+	return c.sendAnthropic(anthropicMessage{
+		Role: "user",
+		Content: []anthropicContentBlock{
+			{
+				Type: "image",
+				Source: &anthropicImageSource{
+					Type:      "base64",
+					MediaType: mediaType,
+					Data:      base64.StdEncoding.EncodeToString(image),
+				},
+			},
+			{Type: "text", Text: prompt},
+		},
+	})
+}
+
+func (c *AIClient) sendAnthropic(message anthropicMessage) (string, error) {
 	req := anthropicRequest{
 		Model:     c.model,
 		MaxTokens: 1024,
-		Messages: []anthropicMessage{
-			{Role: "user", Content: prompt},
-		},
+		Messages:  []anthropicMessage{message},
 	}
 
 	jsonBody, err := json.Marshal(req)
@@ -248,7 +350,21 @@ func (c *AIClient) callAnthropic(prompt string) (string, error) {
 }
 
 func parseGinSuggestion(responseText string) (*GinSuggestion, error) {
-	// Try to extract JSON from the response (in case there's extra text)
+	jsonText, err := extractJSONObject(responseText)
+	if err != nil {
+		return nil, err
+	}
+
+	var suggestion GinSuggestion
+	if err := json.Unmarshal([]byte(jsonText), &suggestion); err != nil {
+		return nil, fmt.Errorf("failed to parse suggestion JSON: %w (response: %s)", err, jsonText)
+	}
+
+	return &suggestion, nil
+}
+
+// extractJSONObject returns the first JSON object in a response (in case there's extra text)
+func extractJSONObject(responseText string) (string, error) {
 	jsonStart := -1
 	jsonEnd := -1
 	braceCount := 0
@@ -269,15 +385,8 @@ func parseGinSuggestion(responseText string) (*GinSuggestion, error) {
 	}
 
 	if jsonStart == -1 || jsonEnd == -1 {
-		return nil, fmt.Errorf("no valid JSON found in response")
-	}
-
-	jsonText := responseText[jsonStart:jsonEnd]
-
-	var suggestion GinSuggestion
-	if err := json.Unmarshal([]byte(jsonText), &suggestion); err != nil {
-		return nil, fmt.Errorf("failed to parse suggestion JSON: %w (response: %s)", err, jsonText)
+		return "", fmt.Errorf("no valid JSON found in response")
 	}
 
-	return &suggestion, nil
+	return responseText[jsonStart:jsonEnd], nil
 }
@@ -55,6 +55,18 @@ func (c *EmailClient) loadTemplates() {
 
 	// Subscription confirmation template
 	c.templates["subscription_confirmation"] = template.Must(template.New("subscription_confirmation").Parse(subscriptionConfirmationTemplate))
+	c.templates["payment_receipt"] = template.Must(template.New("payment_receipt").Parse(paymentReceiptTemplate))
+	c.templates["trial_ending"] = template.Must(template.New("trial_ending").Parse(trialEndingTemplate))
+	c.templates["payment_overdue"] = template.Must(template.New("payment_overdue").Parse(paymentOverdueTemplate))
+
+	// Email verification templates
+	c.templates["email_verification"] = template.Must(template.New("email_verification").Parse(emailVerificationTemplate))
+	c.templates["email_change_confirmation"] = template.Must(template.New("email_change_confirmation").Parse(emailChangeConfirmationTemplate))
+	c.templates["email_changed"] = template.Must(template.New("email_changed").Parse(emailChangedTemplate))
+
+	// Login security templates
+	c.templates["new_login"] = template.Must(template.New("new_login").Parse(newLoginTemplate))
+	c.templates["account_locked"] = template.Must(template.New("account_locked").Parse(accountLockedTemplate))
 }
 
 // EmailData holds common email data
@@ -97,6 +109,76 @@ type SubscriptionData struct {
 	Amount        string
 	BillingCycle  string
 	NextBilling   string
+	InvoiceNumber string
+	InvoiceLink   string // download of the invoice PDF, empty if there's no invoice
+}
+
+// TrialEndingData holds data for the reminder before a trial ends
+type TrialEndingData struct {
+	RecipientName string
+	TenantName    string
+	PlanName      string
+	TrialEndsAt   string
+	DaysLeft      int
+	UpgradeLink   string
+}
+
+// PaymentOverdueData holds data for the reminders of an overdue payment
+type PaymentOverdueData struct {
+	RecipientName string
+	TenantName    string
+	PlanName      string
+	PastDueSince  string
+	Restricted    bool   // premium features are restricted already
+	FinalActionAt string // date the subscription is downgraded or the tenant suspended
+	Suspend       bool   // the tenant is suspended instead of downgraded
+	PaymentLink   string
+}
+
+// EmailVerificationData holds data for address verification emails
+type EmailVerificationData struct {
+	RecipientName string
+	VerifyLink    string
+	ExpiresIn     string
+}
+
+// EmailChangeData holds data for the confirmation emails of an email change,
+// sent to the current and to the new address
+type EmailChangeData struct {
+	RecipientName string
+	OldEmail      string
+	NewEmail      string
+	ConfirmLink   string
+	ExpiresIn     string
+	ToNewAddress  bool
+}
+
+// EmailChangedData holds data for the notification after an email change
+type EmailChangedData struct {
+	RecipientName string
+	OldEmail      string
+	NewEmail      string
+}
+
+// NewLoginData holds data for the notification about a login from a new
+// device or network
+type NewLoginData struct {
+	RecipientName string
+	Device        string
+	IPAddress     string
+	Time          string
+	NewDevice     bool
+	NewNetwork    bool
+	SettingsLink  string
+}
+
+// AccountLockedData holds data for the notification about a locked account
+type AccountLockedData struct {
+	RecipientName  string
+	FailedAttempts int64
+	LockedFor      string
+	IPAddress      string
+	ResetLink      string
 }
 
 // Send sends an email using the configured SMTP server
@@ -255,6 +337,88 @@ func (c *EmailClient) SendSubscriptionConfirmation(to string, data *Subscription
 	})
 }
 
+// SendPaymentReceipt sends the receipt of a subscription payment with its invoice
+func (c *EmailClient) SendPaymentReceipt(to string, data *SubscriptionData) error {
+	return c.Send(&EmailData{
+		To:          to,
+		Subject:     fmt.Sprintf("Deine Rechnung %s - GinVault", data.InvoiceNumber),
+		TemplateKey: "payment_receipt",
+		Data:        data,
+	})
+}
+
+// SendTrialEnding reminds the owners of a tenant that its trial ends soon
+func (c *EmailClient) SendTrialEnding(to string, data *TrialEndingData) error {
+	return c.Send(&EmailData{
+		To:          to,
+		Subject:     fmt.Sprintf("Deine %s-Testphase endet bald - GinVault", data.PlanName),
+		TemplateKey: "trial_ending",
+		Data:        data,
+	})
+}
+
+// SendPaymentOverdue reminds the owners of a tenant of a failed payment
+func (c *EmailClient) SendPaymentOverdue(to string, data *PaymentOverdueData) error {
+	return c.Send(&EmailData{
+		To:          to,
+		Subject:     fmt.Sprintf("Zahlung für dein %s-Abonnement fehlgeschlagen - GinVault", data.PlanName),
+		TemplateKey: "payment_overdue",
+		Data:        data,
+	})
+}
+
+// SendEmailVerification sends the link for verifying an address
+func (c *EmailClient) SendEmailVerification(to string, data *EmailVerificationData) error {
+	return c.Send(&EmailData{
+		To:          to,
+		Subject:     "E-Mail-Adresse bestätigen - GinVault",
+		TemplateKey: "email_verification",
+		Data:        data,
+	})
+}
+
+// SendEmailChangeConfirmation sends the confirmation link of an email change
+// to the current or the new address
+func (c *EmailClient) SendEmailChangeConfirmation(to string, data *EmailChangeData) error {
+	return c.Send(&EmailData{
+		To:          to,
+		Subject:     "Änderung deiner E-Mail-Adresse bestätigen - GinVault",
+		TemplateKey: "email_change_confirmation",
+		Data:        data,
+	})
+}
+
+// SendEmailChanged notifies the previous address that the email was changed
+func (c *EmailClient) SendEmailChanged(to string, data *EmailChangedData) error {
+	return c.Send(&EmailData{
+		To:          to,
+		Subject:     "Deine E-Mail-Adresse wurde geändert - GinVault",
+		TemplateKey: "email_changed",
+		Data:        data,
+	})
+}
+
+// SendNewLogin notifies a user about a login from a new device or network
+func (c *EmailClient) SendNewLogin(to string, data *NewLoginData) error {
+	return c.Send(&EmailData{
+		To:          to,
+		Subject:     "Neue Anmeldung bei deinem Konto - GinVault",
+		TemplateKey: "new_login",
+		Data:        data,
+	})
+}
+
+// SendAccountLocked notifies a user that the account was locked after too
+// many failed logins
+func (c *EmailClient) SendAccountLocked(to string, data *AccountLockedData) error {
+	return c.Send(&EmailData{
+		To:          to,
+		Subject:     "Dein Konto wurde vorübergehend gesperrt - GinVault",
+		TemplateKey: "account_locked",
+		Data:        data,
+	})
+}
+
 // Email templates
 const userInvitationTemplate = `<!DOCTYPE html>
 <html>
@@ -403,6 +567,10 @@ const subscriptionConfirmationTemplate = `<!DOCTYPE html>
             <span>Nächste Abrechnung:</span>
             <strong>{{.NextBilling}}</strong>
         </div>
+        {{if .InvoiceLink}}<div class="detail-row">
+            <span>Rechnung:</span>
+            <strong><a href="{{.InvoiceLink}}">{{.InvoiceNumber}}</a></strong>
+        </div>{{end}}
         <p style="margin-top: 20px;">Du hast jetzt Zugang zu allen Premium-Funktionen. Viel Spaß mit deiner erweiterten Gin-Sammlung!</p>
     </div>
     <div class="footer">
@@ -410,3 +578,308 @@ const subscriptionConfirmationTemplate = `<!DOCTYPE html>
     </div>
 </body>
 </html>`
+
+const paymentReceiptTemplate = `<!DOCTYPE html>
+<html>
+<head>
+    <meta charset="UTF-8">
+    <meta name="viewport" content="width=device-width, initial-scale=1.0">
+    <title>Zahlungsbestätigung</title>
+    <style>
+        body { font-family: -apple-system, BlinkMacSystemFont, 'Segoe UI', Roboto, sans-serif; line-height: 1.6; color: #333; max-width: 600px; margin: 0 auto; padding: 20px; }
+        .header { text-align: center; padding: 20px 0; border-bottom: 2px solid #10b981; }
+        .logo { font-size: 24px; font-weight: bold; color: #10b981; }
+        .content { padding: 30px 0; }
+        .detail-row { display: flex; justify-content: space-between; padding: 8px 0; border-bottom: 1px solid #e5e7eb; }
+        .button { display: inline-block; background: #10b981; color: white; padding: 14px 28px; text-decoration: none; border-radius: 8px; font-weight: 600; margin: 20px 0; }
+        .footer { text-align: center; padding-top: 20px; border-top: 1px solid #e5e7eb; color: #6b7280; font-size: 14px; }
+    </style>
+</head>
+<body>
+    <div class="header">
+        <div class="logo">🍸 GinVault</div>
+    </div>
+    <div class="content">
+        <h2>Vielen Dank für deine Zahlung</h2>
+        <p>Hallo {{.RecipientName}},</p>
+        <p>wir haben deine Zahlung für dein <strong>{{.PlanName}}</strong>-Abonnement erhalten.</p>
+        <div class="detail-row">
+            <span>Rechnungsnummer:</span>
+            <strong>{{.InvoiceNumber}}</strong>
+        </div>
+        <div class="detail-row">
+            <span>Betrag:</span>
+            <strong>{{.Amount}}</strong>
+        </div>
+        <div class="detail-row">
+            <span>Abrechnungszeitraum:</span>
+            <strong>{{.BillingCycle}}</strong>
+        </div>
+        {{if .NextBilling}}<div class="detail-row">
+            <span>Nächste Abrechnung:</span>
+            <strong>{{.NextBilling}}</strong>
+        </div>{{end}}
+        <p style="text-align: center;">
+            <a href="{{.InvoiceLink}}" class="button">Rechnung herunterladen</a>
+        </p>
+        <p>Alle Rechnungen findest du auch in deinen Abonnement-Einstellungen.</p>
+    </div>
+    <div class="footer">
+        <p>&copy; 2026 GinVault. Alle Rechte vorbehalten.</p>
+    </div>
+</body>
+</html>`
+
+const trialEndingTemplate = `<!DOCTYPE html>
+<html>
+<head>
+    <meta charset="UTF-8">
+    <meta name="viewport" content="width=device-width, initial-scale=1.0">
+    <title>Testphase endet bald</title>
+    <style>
+        body { font-family: -apple-system, BlinkMacSystemFont, 'Segoe UI', Roboto, sans-serif; line-height: 1.6; color: #333; max-width: 600px; margin: 0 auto; padding: 20px; }
+        .header { text-align: center; padding: 20px 0; border-bottom: 2px solid #10b981; }
+        .logo { font-size: 24px; font-weight: bold; color: #10b981; }
+        .content { padding: 30px 0; }
+        .plan-box { background: #f0fdf4; border: 2px solid #10b981; border-radius: 12px; padding: 20px; margin: 20px 0; text-align: center; }
+        .plan-name { font-size: 24px; font-weight: bold; color: #10b981; }
+        .button { display: inline-block; background: #10b981; color: white; padding: 14px 28px; text-decoration: none; border-radius: 8px; font-weight: 600; margin: 20px 0; }
+        .footer { text-align: center; padding-top: 20px; border-top: 1px solid #e5e7eb; color: #6b7280; font-size: 14px; }
+    </style>
+</head>
+<body>
+    <div class="header">
+        <div class="logo">🍸 GinVault</div>
+    </div>
+    <div class="content">
+        <h2>Deine Testphase endet bald</h2>
+        <p>Hallo{{if .RecipientName}} {{.RecipientName}}{{end}},</p>
+        <p>die Testphase von {{.TenantName}} endet {{if eq .DaysLeft 1}}morgen{{else}}in {{.DaysLeft}} Tagen{{end}}, am {{.TrialEndsAt}}.</p>
+        <div class="plan-box">
+            <div class="plan-name">{{.PlanName}}</div>
+        </div>
+        <p>Schließe jetzt ein Abonnement ab, um alle Funktionen weiter zu nutzen. Ohne Abonnement wechselt deine Sammlung danach in den Free-Tarif. Es werden keine Daten gelöscht, Sammlungen über den Limits des Free-Tarifs können aber nur noch gelesen werden.</p>
+        <p style="text-align: center;">
+            <a href="{{.UpgradeLink}}" class="button">Jetzt upgraden</a>
+        </p>
+    </div>
+    <div class="footer">
+        <p>&copy; 2026 GinVault. Alle Rechte vorbehalten.</p>
+    </div>
+</body>
+</html>`
+
+const paymentOverdueTemplate = `<!DOCTYPE html>
+<html>
+<head>
+    <meta charset="UTF-8">
+    <meta name="viewport" content="width=device-width, initial-scale=1.0">
+    <title>Zahlung fehlgeschlagen</title>
+    <style>
+        body { font-family: -apple-system, BlinkMacSystemFont, 'Segoe UI', Roboto, sans-serif; line-height: 1.6; color: #333; max-width: 600px; margin: 0 auto; padding: 20px; }
+        .header { text-align: center; padding: 20px 0; border-bottom: 2px solid #10b981; }
+        .logo { font-size: 24px; font-weight: bold; color: #10b981; }
+        .content { padding: 30px 0; }
+        .warning-box { background: #fef3c7; border: 1px solid #f59e0b; border-radius: 8px; padding: 15px; margin: 20px 0; }
+        .button { display: inline-block; background: #10b981; color: white; padding: 14px 28px; text-decoration: none; border-radius: 8px; font-weight: 600; margin: 20px 0; }
+        .footer { text-align: center; padding-top: 20px; border-top: 1px solid #e5e7eb; color: #6b7280; font-size: 14px; }
+    </style>
+</head>
+<body>
+    <div class="header">
+        <div class="logo">🍸 GinVault</div>
+    </div>
+    <div class="content">
+        <h2>Zahlung fehlgeschlagen</h2>
+        <p>Hallo{{if .RecipientName}} {{.RecipientName}}{{end}},</p>
+        <p>die Zahlung für das {{.PlanName}}-Abonnement von {{.TenantName}} ist am {{.PastDueSince}} fehlgeschlagen. Bitte prüfe deine Zahlungsmethode.</p>
+        <div class="warning-box">
+            {{if .Restricted}}<p><strong>Premium-Funktionen sind eingeschränkt:</strong> Bis zur Zahlung gelten die Limits des Free-Tarifs. Es werden keine Daten gelöscht.</p>{{end}}
+            <p>{{if .Suspend}}Ohne Zahlung wird dein Konto am {{.FinalActionAt}} gesperrt.{{else}}Ohne Zahlung wechselt deine Sammlung am {{.FinalActionAt}} in den Free-Tarif.{{end}}</p>
+        </div>
+        <p style="text-align: center;">
+            <a href="{{.PaymentLink}}" class="button">Zahlungsmethode prüfen</a>
+        </p>
+    </div>
+    <div class="footer">
+        <p>&copy; 2026 GinVault. Alle Rechte vorbehalten.</p>
+    </div>
+</body>
+</html>`
+
+const emailVerificationTemplate = `<!DOCTYPE html>
+<html>
+<head>
+    <meta charset="UTF-8">
+    <meta name="viewport" content="width=device-width, initial-scale=1.0">
+    <title>E-Mail-Adresse bestätigen</title>
+    <style>
+        body { font-family: -apple-system, BlinkMacSystemFont, 'Segoe UI', Roboto, sans-serif; line-height: 1.6; color: #333; max-width: 600px; margin: 0 auto; padding: 20px; }
+        .header { text-align: center; padding: 20px 0; border-bottom: 2px solid #10b981; }
+        .logo { font-size: 24px; font-weight: bold; color: #10b981; }
+        .content { padding: 30px 0; }
+        .button { display: inline-block; background: #10b981; color: white; padding: 14px 28px; text-decoration: none; border-radius: 8px; font-weight: 600; margin: 20px 0; }
+        .footer { text-align: center; padding-top: 20px; border-top: 1px solid #e5e7eb; color: #6b7280; font-size: 14px; }
+    </style>
+</head>
+<body>
+    <div class="header">
+        <div class="logo">🍸 GinVault</div>
+    </div>
+    <div class="content">
+        <h2>E-Mail-Adresse bestätigen</h2>
+        <p>Hallo{{if .RecipientName}} {{.RecipientName}}{{end}},</p>
+        <p>Bitte bestätige deine E-Mail-Adresse, damit wir dich bei Fragen zu deinem Konto erreichen können:</p>
+        <p style="text-align: center;">
+            <a href="{{.VerifyLink}}" class="button">E-Mail-Adresse bestätigen</a>
+        </p>
+        <p style="color: #6b7280; font-size: 14px;">Dieser Link ist {{.ExpiresIn}} gültig.</p>
+        <p>Falls du kein Konto bei GinVault erstellt hast, kannst du diese E-Mail ignorieren.</p>
+    </div>
+    <div class="footer">
+        <p>&copy; 2026 GinVault. Alle Rechte vorbehalten.</p>
+    </div>
+</body>
+</html>`
+
+const emailChangeConfirmationTemplate = `<!DOCTYPE html>
+<html>
+<head>
+    <meta charset="UTF-8">
+    <meta name="viewport" content="width=device-width, initial-scale=1.0">
+    <title>E-Mail-Änderung bestätigen</title>
+    <style>
+        body { font-family: -apple-system, BlinkMacSystemFont, 'Segoe UI', Roboto, sans-serif; line-height: 1.6; color: #333; max-width: 600px; margin: 0 auto; padding: 20px; }
+        .header { text-align: center; padding: 20px 0; border-bottom: 2px solid #10b981; }
+        .logo { font-size: 24px; font-weight: bold; color: #10b981; }
+        .content { padding: 30px 0; }
+        .button { display: inline-block; background: #10b981; color: white; padding: 14px 28px; text-decoration: none; border-radius: 8px; font-weight: 600; margin: 20px 0; }
+        .footer { text-align: center; padding-top: 20px; border-top: 1px solid #e5e7eb; color: #6b7280; font-size: 14px; }
+    </style>
+</head>
+<body>
+    <div class="header">
+        <div class="logo">🍸 GinVault</div>
+    </div>
+    <div class="content">
+        <h2>E-Mail-Änderung bestätigen</h2>
+        <p>Hallo{{if .RecipientName}} {{.RecipientName}}{{end}},</p>
+        <p>Die E-Mail-Adresse deines Kontos soll von <strong>{{.OldEmail}}</strong> auf <strong>{{.NewEmail}}</strong> geändert werden.</p>
+        {{if .ToNewAddress}}<p>Bitte bestätige, dass diese Adresse dir gehört:</p>{{else}}<p>Bitte bestätige die Änderung auch von deiner bisherigen Adresse:</p>{{end}}
+        <p style="text-align: center;">
+            <a href="{{.ConfirmLink}}" class="button">Änderung bestätigen</a>
+        </p>
+        <p style="color: #6b7280; font-size: 14px;">Dieser Link ist {{.ExpiresIn}} gültig. Die Änderung wird erst übernommen, wenn beide Adressen bestätigt wurden.</p>
+        <p>Falls du diese Änderung nicht angefordert hast, ignoriere diese E-Mail und ändere dein Passwort.</p>
+    </div>
+    <div class="footer">
+        <p>&copy; 2026 GinVault. Alle Rechte vorbehalten.</p>
+    </div>
+</body>
+</html>`
+
+const emailChangedTemplate = `<!DOCTYPE html>
+<html>
+<head>
+    <meta charset="UTF-8">
+    <meta name="viewport" content="width=device-width, initial-scale=1.0">
+    <title>E-Mail-Adresse geändert</title>
+    <style>
+        body { font-family: -apple-system, BlinkMacSystemFont, 'Segoe UI', Roboto, sans-serif; line-height: 1.6; color: #333; max-width: 600px; margin: 0 auto; padding: 20px; }
+        .header { text-align: center; padding: 20px 0; border-bottom: 2px solid #10b981; }
+        .logo { font-size: 24px; font-weight: bold; color: #10b981; }
+        .content { padding: 30px 0; }
+        .footer { text-align: center; padding-top: 20px; border-top: 1px solid #e5e7eb; color: #6b7280; font-size: 14px; }
+    </style>
+</head>
+<body>
+    <div class="header">
+        <div class="logo">🍸 GinVault</div>
+    </div>
+    <div class="content">
+        <h2>E-Mail-Adresse geändert</h2>
+        <p>Hallo{{if .RecipientName}} {{.RecipientName}}{{end}},</p>
+        <p>Die E-Mail-Adresse deines Kontos wurde von <strong>{{.OldEmail}}</strong> auf <strong>{{.NewEmail}}</strong> geändert. Ab sofort meldest du dich mit der neuen Adresse an.</p>
+        <p>Falls du diese Änderung nicht vorgenommen hast, wende dich bitte umgehend an unseren Support.</p>
+    </div>
+    <div class="footer">
+        <p>&copy; 2026 GinVault. Alle Rechte vorbehalten.</p>
+    </div>
+</body>
+</html>`
+
+
+const newLoginTemplate = `<!DOCTYPE html>
+<html>
+<head>
+    <meta charset="UTF-8">
+    <meta name="viewport" content="width=device-width, initial-scale=1.0">
+    <title>Neue Anmeldung</title>
+    <style>
+        body { font-family: -apple-system, BlinkMacSystemFont, 'Segoe UI', Roboto, sans-serif; line-height: 1.6; color: #333; max-width: 600px; margin: 0 auto; padding: 20px; }
+        .header { text-align: center; padding: 20px 0; border-bottom: 2px solid #10b981; }
+        .logo { font-size: 24px; font-weight: bold; color: #10b981; }
+        .content { padding: 30px 0; }
+        .details { background: #f9fafb; border-radius: 8px; padding: 15px 20px; margin: 20px 0; }
+        .button { display: inline-block; background: #10b981; color: white; padding: 14px 28px; text-decoration: none; border-radius: 8px; font-weight: 600; margin: 20px 0; }
+        .footer { text-align: center; padding-top: 20px; border-top: 1px solid #e5e7eb; color: #6b7280; font-size: 14px; }
+    </style>
+</head>
+<body>
+    <div class="header">
+        <div class="logo">🍸 GinVault</div>
+    </div>
+    <div class="content">
+        <h2>Neue Anmeldung bei deinem Konto</h2>
+        <p>Hallo{{if .RecipientName}} {{.RecipientName}}{{end}},</p>
+        <p>wir haben eine Anmeldung {{if .NewDevice}}von einem neuen Gerät{{else}}aus einem neuen Netzwerk{{end}} festgestellt:</p>
+        <div class="details">
+            <p><strong>Gerät:</strong> {{.Device}}<br>
+            <strong>IP-Adresse:</strong> {{.IPAddress}}<br>
+            <strong>Zeitpunkt:</strong> {{.Time}}</p>
+        </div>
+        <p>Warst du das? Dann ist nichts weiter zu tun.</p>
+        <p>Falls nicht, ändere bitte sofort dein Passwort und melde alle anderen Sitzungen ab:</p>
+        <p style="text-align: center;">
+            <a href="{{.SettingsLink}}" class="button">Sicherheitseinstellungen öffnen</a>
+        </p>
+    </div>
+    <div class="footer">
+        <p>&copy; 2026 GinVault. Alle Rechte vorbehalten.</p>
+    </div>
+</body>
+</html>`
+
+const accountLockedTemplate = `<!DOCTYPE html>
+<html>
+<head>
+    <meta charset="UTF-8">
+    <meta name="viewport" content="width=device-width, initial-scale=1.0">
+    <title>Konto gesperrt</title>
+    <style>
+        body { font-family: -apple-system, BlinkMacSystemFont, 'Segoe UI', Roboto, sans-serif; line-height: 1.6; color: #333; max-width: 600px; margin: 0 auto; padding: 20px; }
+        .header { text-align: center; padding: 20px 0; border-bottom: 2px solid #10b981; }
+        .logo { font-size: 24px; font-weight: bold; color: #10b981; }
+        .content { padding: 30px 0; }
+        .button { display: inline-block; background: #10b981; color: white; padding: 14px 28px; text-decoration: none; border-radius: 8px; font-weight: 600; margin: 20px 0; }
+        .footer { text-align: center; padding-top: 20px; border-top: 1px solid #e5e7eb; color: #6b7280; font-size: 14px; }
+    </style>
+</head>
+<body>
+    <div class="header">
+        <div class="logo">🍸 GinVault</div>
+    </div>
+    <div class="content">
+        <h2>Konto vorübergehend gesperrt</h2>
+        <p>Hallo{{if .RecipientName}} {{.RecipientName}}{{end}},</p>
+        <p>nach {{.FailedAttempts}} fehlgeschlagenen Anmeldeversuchen{{if .IPAddress}} (zuletzt von {{.IPAddress}}){{end}} haben wir dein Konto für {{.LockedFor}} gesperrt.</p>
+        <p>Falls du das nicht warst, versucht möglicherweise jemand, sich Zugang zu deinem Konto zu verschaffen. Setze in diesem Fall dein Passwort zurück, dadurch wird die Sperre aufgehoben:</p>
+        <p style="text-align: center;">
+            <a href="{{.ResetLink}}" class="button">Passwort zurücksetzen</a>
+        </p>
+    </div>
+    <div class="footer">
+        <p>&copy; 2026 GinVault. Alle Rechte vorbehalten.</p>
+    </div>
+</body>
+</html>`
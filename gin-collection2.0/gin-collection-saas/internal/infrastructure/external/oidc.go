@@ -0,0 +1,403 @@
+package external
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// oidcDiscoveryTTL is how long discovery documents and key sets are cached
+const oidcDiscoveryTTL = 1 * time.Hour
+
+// oidcMaxResponseSize bounds responses of identity providers
+const oidcMaxResponseSize = 1 << 20
+
+// OIDCClient talks to OpenID Connect providers using the authorization code
+// flow with PKCE. Discovery documents and signing keys are cached per issuer.
+type OIDCClient struct {
+	httpClient *http.Client
+
+	mu        sync.Mutex
+	providers map[string]*OIDCProvider
+}
+
+// OIDCProvider is the discovery document of an identity provider
+type OIDCProvider struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	JWKSURI               string `json:"jwks_uri"`
+
+	fetchedAt     time.Time
+	keys          map[string]crypto.PublicKey
+	keysFetchedAt time.Time
+}
+
+// OIDCAuthRequest holds the parameters of an authorization request
+type OIDCAuthRequest struct {
+	ClientID      string
+	RedirectURI   string
+	Scopes        []string
+	State         string
+	Nonce         string
+	CodeChallenge string
+}
+
+// OIDCCodeExchange holds the parameters of a token request
+type OIDCCodeExchange struct {
+	ClientID     string
+	ClientSecret string
+	Code         string
+	RedirectURI  string
+	CodeVerifier string
+}
+
+// OIDCClaims are the verified claims of an ID token
+type OIDCClaims struct {
+	Subject       string
+	Email         string
+	EmailVerified *bool
+	GivenName     string
+	FamilyName    string
+	raw           jwt.MapClaims
+}
+
+// NewOIDCClient creates a new OpenID Connect client
+func NewOIDCClient(httpClient *http.Client) *OIDCClient {
+	if httpClient == nil {
+		httpClient = &http.Client{Timeout: 10 * time.Second}
+	}
+	return &OIDCClient{
+		httpClient: httpClient,
+		providers:  map[string]*OIDCProvider{},
+	}
+}
+
+// GeneratePKCEVerifier returns a random PKCE code verifier
+func GeneratePKCEVerifier() (string, error) {
+	verifier := make([]byte, 32)
+	if _, err := rand.Read(verifier); err != nil {
+		return "", fmt.Errorf("failed to generate code verifier: %w", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(verifier), nil
+}
+
+// PKCEChallenge returns the S256 code challenge of a verifier
+func PKCEChallenge(verifier string) string {
+	sum := sha256.Sum256([]byte(verifier))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+// Discover fetches (or returns the cached) discovery document of an issuer
+func (c *OIDCClient) Discover(ctx context.Context, issuer string) (*OIDCProvider, error) {
+	c.mu.Lock()
+	provider, ok := c.providers[issuer]
+	c.mu.Unlock()
+	if ok && time.Since(provider.fetchedAt) < oidcDiscoveryTTL {
+		return provider, nil
+	}
+
+	provider = &OIDCProvider{}
+	discoveryURL := strings.TrimSuffix(issuer, "/") + "/.well-known/openid-configuration"
+	if err := c.getJSON(ctx, discoveryURL, provider); err != nil {
+		return nil, fmt.Errorf("failed to discover OIDC provider: %w", err)
+	}
+
+	// The issuer of the document must be the configured one (OpenID Connect Discovery 4.3)
+	if strings.TrimSuffix(provider.Issuer, "/") != strings.TrimSuffix(issuer, "/") {
+		return nil, fmt.Errorf("OIDC discovery issuer mismatch: %s", provider.Issuer)
+	}
+	if provider.AuthorizationEndpoint == "" || provider.TokenEndpoint == "" || provider.JWKSURI == "" {
+		return nil, fmt.Errorf("OIDC discovery document is incomplete")
+	}
+	provider.fetchedAt = time.Now()
+
+	c.mu.Lock()
+	c.providers[issuer] = provider
+	c.mu.Unlock()
+
+	return provider, nil
+}
+
+// AuthCodeURL builds the URL the browser is sent to for login
+func (c *OIDCClient) AuthCodeURL(provider *OIDCProvider, req OIDCAuthRequest) (string, error) {
+	u, err := url.Parse(provider.AuthorizationEndpoint)
+	if err != nil {
+		return "", fmt.Errorf("invalid authorization endpoint: %w", err)
+	}
+
+	query := u.Query()
+	query.Set("response_type", "code")
+	query.Set("client_id", req.ClientID)
+	query.Set("redirect_uri", req.RedirectURI)
+	query.Set("scope", strings.Join(req.Scopes, " "))
+	query.Set("state", req.State)
+	query.Set("nonce", req.Nonce)
+	query.Set("code_challenge", req.CodeChallenge)
+	query.Set("code_challenge_method", "S256")
+	u.RawQuery = query.Encode()
+
+	return u.String(), nil
+}
+
+// Exchange redeems an authorization code and returns the raw ID token
+func (c *OIDCClient) Exchange(ctx context.Context, provider *OIDCProvider, req OIDCCodeExchange) (string, error) {
+	form := url.Values{}
+	form.Set("grant_type", "authorization_code")
+	form.Set("code", req.Code)
+	form.Set("redirect_uri", req.RedirectURI)
+	form.Set("code_verifier", req.CodeVerifier)
+	if req.ClientSecret == "" {
+		// Public client
+		form.Set("client_id", req.ClientID)
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, provider.TokenEndpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", fmt.Errorf("failed to create token request: %w", err)
+	}
+	httpReq.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	httpReq.Header.Set("Accept", "application/json")
+	if req.ClientSecret != "" {
+		// client_secret_basic (RFC 6749 2.3.1)
+		httpReq.SetBasicAuth(url.QueryEscape(req.ClientID), url.QueryEscape(req.ClientSecret))
+	}
+
+	resp, err := c.httpClient.Do(httpReq)
+	if err != nil {
+		return "", fmt.Errorf("failed to send token request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, oidcMaxResponseSize))
+	if err != nil {
+		return "", fmt.Errorf("failed to read token response: %w", err)
+	}
+
+	var tokenResp struct {
+		IDToken          string `json:"id_token"`
+		Error            string `json:"error"`
+		ErrorDescription string `json:"error_description"`
+	}
+	if err := json.Unmarshal(body, &tokenResp); err != nil {
+		return "", fmt.Errorf("failed to parse token response (status %d): %w", resp.StatusCode, err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("token request failed (status %d): %s %s", resp.StatusCode, tokenResp.Error, tokenResp.ErrorDescription)
+	}
+	if tokenResp.IDToken == "" {
+		return "", fmt.Errorf("token response contains no id_token")
+	}
+
+	return tokenResp.IDToken, nil
+}
+
+// VerifyIDToken verifies signature, issuer, audience, expiry and nonce of an ID token
+func (c *OIDCClient) VerifyIDToken(ctx context.Context, provider *OIDCProvider, rawIDToken, clientID, nonce string) (*OIDCClaims, error) {
+	claims := jwt.MapClaims{}
+
+	_, err := jwt.ParseWithClaims(rawIDToken, claims, func(token *jwt.Token) (interface{}, error) {
+		kid, _ := token.Header["kid"].(string)
+		return c.signingKey(ctx, provider, kid)
+	},
+		jwt.WithValidMethods([]string{"RS256", "RS384", "RS512", "ES256", "ES384"}),
+		jwt.WithIssuer(provider.Issuer),
+		jwt.WithAudience(clientID),
+		jwt.WithExpirationRequired(),
+		jwt.WithIssuedAt(),
+		jwt.WithLeeway(time.Minute),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("invalid ID token: %w", err)
+	}
+
+	// With several audiences the token must have been issued to us (OIDC Core 3.1.3.7)
+	if audiences, _ := claims.GetAudience(); len(audiences) > 1 {
+		if azp, _ := claims["azp"].(string); azp != clientID {
+			return nil, fmt.Errorf("invalid ID token: azp mismatch")
+		}
+	}
+
+	if tokenNonce, _ := claims["nonce"].(string); tokenNonce == "" || tokenNonce != nonce {
+		return nil, fmt.Errorf("invalid ID token: nonce mismatch")
+	}
+
+	subject, _ := claims.GetSubject()
+	if subject == "" {
+		return nil, fmt.Errorf("invalid ID token: missing subject")
+	}
+
+	result := &OIDCClaims{
+		Subject: subject,
+		raw:     claims,
+	}
+	result.Email, _ = claims["email"].(string)
+	result.GivenName, _ = claims["given_name"].(string)
+	result.FamilyName, _ = claims["family_name"].(string)
+	switch verified := claims["email_verified"].(type) {
+	case bool:
+		result.EmailVerified = &verified
+	case string:
+		// Some providers send the flag as a string
+		value := verified == "true"
+		result.EmailVerified = &value
+	}
+
+	return result, nil
+}
+
+// Strings returns a claim as a list of strings (single strings are accepted)
+func (c *OIDCClaims) Strings(name string) []string {
+	switch value := c.raw[name].(type) {
+	case string:
+		return []string{value}
+	case []interface{}:
+		var values []string
+		for _, item := range value {
+			if s, ok := item.(string); ok {
+				values = append(values, s)
+			}
+		}
+		return values
+	}
+	return nil
+}
+
+// signingKey returns the key for a key ID, refreshing the key set once for unknown IDs
+func (c *OIDCClient) signingKey(ctx context.Context, provider *OIDCProvider, kid string) (crypto.PublicKey, error) {
+	c.mu.Lock()
+	keys := provider.keys
+	fresh := time.Since(provider.keysFetchedAt) < oidcDiscoveryTTL
+	c.mu.Unlock()
+
+	if key, ok := lookupJWK(keys, kid); ok && fresh {
+		return key, nil
+	}
+
+	keys, err := c.fetchKeys(ctx, provider.JWKSURI)
+	if err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	provider.keys = keys
+	provider.keysFetchedAt = time.Now()
+	c.mu.Unlock()
+
+	key, ok := lookupJWK(keys, kid)
+	if !ok {
+		return nil, fmt.Errorf("unknown signing key %q", kid)
+	}
+	return key, nil
+}
+
+// lookupJWK finds a key by ID. Without an ID the only key of the set is used.
+func lookupJWK(keys map[string]crypto.PublicKey, kid string) (crypto.PublicKey, bool) {
+	if kid == "" && len(keys) == 1 {
+		for _, key := range keys {
+			return key, true
+		}
+	}
+	key, ok := keys[kid]
+	return key, ok
+}
+
+// fetchKeys downloads and parses a JSON Web Key Set
+func (c *OIDCClient) fetchKeys(ctx context.Context, jwksURI string) (map[string]crypto.PublicKey, error) {
+	var set struct {
+		Keys []struct {
+			Kty string `json:"kty"`
+			Kid string `json:"kid"`
+			Use string `json:"use"`
+			N   string `json:"n"`
+			E   string `json:"e"`
+			Crv string `json:"crv"`
+			X   string `json:"x"`
+			Y   string `json:"y"`
+		} `json:"keys"`
+	}
+	if err := c.getJSON(ctx, jwksURI, &set); err != nil {
+		return nil, fmt.Errorf("failed to fetch signing keys: %w", err)
+	}
+
+	keys := map[string]crypto.PublicKey{}
+	for _, jwk := range set.Keys {
+		if jwk.Use != "" && jwk.Use != "sig" {
+			continue
+		}
+
+		switch jwk.Kty {
+		case "RSA":
+			n, errN := base64.RawURLEncoding.DecodeString(jwk.N)
+			e, errE := base64.RawURLEncoding.DecodeString(jwk.E)
+			if errN != nil || errE != nil || len(e) == 0 || len(e) > 4 {
+				continue
+			}
+			keys[jwk.Kid] = &rsa.PublicKey{
+				N: new(big.Int).SetBytes(n),
+				E: int(new(big.Int).SetBytes(e).Int64()),
+			}
+		case "EC":
+			var curve elliptic.Curve
+			switch jwk.Crv {
+			case "P-256":
+				curve = elliptic.P256()
+			case "P-384":
+				curve = elliptic.P384()
+			default:
+				continue
+			}
+			x, errX := base64.RawURLEncoding.DecodeString(jwk.X)
+			y, errY := base64.RawURLEncoding.DecodeString(jwk.Y)
+			size := (curve.Params().BitSize + 7) / 8
+			if errX != nil || errY != nil || len(x) != size || len(y) != size {
+				continue
+			}
+			point := append([]byte{0x04}, append(x, y...)...)
+			key, err := ecdsa.ParseUncompressedPublicKey(curve, point)
+			if err != nil {
+				continue
+			}
+			keys[jwk.Kid] = key
+		}
+	}
+
+	return keys, nil
+}
+
+// getJSON fetches a JSON document
+func (c *OIDCClient) getJSON(ctx context.Context, rawURL string, v interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status %d from %s", resp.StatusCode, rawURL)
+	}
+
+	return json.NewDecoder(io.LimitReader(resp.Body, oidcMaxResponseSize)).Decode(v)
+}
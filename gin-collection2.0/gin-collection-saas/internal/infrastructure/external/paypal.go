@@ -3,6 +3,7 @@ package external
 import (
 	"bytes"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
@@ -17,6 +18,7 @@ type PayPalClient struct {
 	clientSecret string
 	baseURL      string
 	mode         string // "sandbox" or "live"
+	webhookID    string
 	httpClient   *http.Client
 	accessToken  string
 	tokenExpiry  time.Time
@@ -27,6 +29,8 @@ type PayPalConfig struct {
 	ClientID     string
 	ClientSecret string
 	Mode         string // "sandbox" or "live"
+	WebhookID    string // ID of the webhook the signatures are verified for
+	BaseURL      string // overrides the API URL of the mode (e.g. a local fake)
 }
 
 // PayPalSubscriptionRequest represents a subscription creation request
@@ -35,10 +39,28 @@ type PayPalSubscriptionRequest struct {
 	StartTime          string                        `json:"start_time,omitempty"`
 	Subscriber         *PayPalSubscriber             `json:"subscriber,omitempty"`
 	ApplicationContext *PayPalApplicationContext     `json:"application_context,omitempty"`
+	Plan               *PayPalPlanOverride           `json:"plan,omitempty"`
+}
+
+// PayPalPlanOverride overrides the plan settings for a single subscription
+type PayPalPlanOverride struct {
+	BillingCycles []PayPalBillingCycleOverride `json:"billing_cycles,omitempty"`
+}
+
+// PayPalBillingCycleOverride overrides the price of a billing cycle of the plan
+type PayPalBillingCycleOverride struct {
+	Sequence      int                 `json:"sequence"`
+	PricingScheme PayPalPricingScheme `json:"pricing_scheme"`
+}
+
+// PayPalPricingScheme represents the price of a billing cycle
+type PayPalPricingScheme struct {
+	FixedPrice *PayPalMoney `json:"fixed_price,omitempty"`
 }
 
 // PayPalSubscriber represents subscriber information
 type PayPalSubscriber struct {
+	PayerID      string             `json:"payer_id,omitempty"`
 	EmailAddress string             `json:"email_address,omitempty"`
 	Name         *PayPalName        `json:"name,omitempty"`
 }
@@ -74,6 +96,7 @@ type PayPalSubscriptionResponse struct {
 	Quantity      string                 `json:"quantity"`
 	Links         []PayPalLink           `json:"links"`
 	BillingInfo   *PayPalBillingInfo     `json:"billing_info,omitempty"`
+	Subscriber    *PayPalSubscriber      `json:"subscriber,omitempty"`
 }
 
 // PayPalLink represents a HATEOAS link
@@ -133,18 +156,51 @@ type PayPalErrorDetail struct {
 	Description string `json:"description"`
 }
 
+// PayPalWebhookSignature holds the transmission headers PayPal signs a
+// webhook delivery with
+type PayPalWebhookSignature struct {
+	TransmissionID   string // PAYPAL-TRANSMISSION-ID
+	TransmissionTime string // PAYPAL-TRANSMISSION-TIME
+	TransmissionSig  string // PAYPAL-TRANSMISSION-SIG
+	CertURL          string // PAYPAL-CERT-URL
+	AuthAlgo         string // PAYPAL-AUTH-ALGO
+}
+
+// payPalVerifyWebhookRequest represents a webhook signature verification request
+type payPalVerifyWebhookRequest struct {
+	AuthAlgo         string          `json:"auth_algo"`
+	CertURL          string          `json:"cert_url"`
+	TransmissionID   string          `json:"transmission_id"`
+	TransmissionSig  string          `json:"transmission_sig"`
+	TransmissionTime string          `json:"transmission_time"`
+	WebhookID        string          `json:"webhook_id"`
+	WebhookEvent     json.RawMessage `json:"webhook_event"`
+}
+
+// payPalVerifyWebhookResponse represents a webhook signature verification response
+type payPalVerifyWebhookResponse struct {
+	VerificationStatus string `json:"verification_status"` // "SUCCESS" or "FAILURE"
+}
+
+// ErrWebhookSignature is returned for webhook deliveries PayPal didn't sign
+var ErrWebhookSignature = errors.New("invalid PayPal webhook signature")
+
 // NewPayPalClient creates a new PayPal client
 func NewPayPalClient(cfg *PayPalConfig) *PayPalClient {
 	baseURL := "https://api-m.sandbox.paypal.com"
 	if cfg.Mode == "live" {
 		baseURL = "https://api-m.paypal.com"
 	}
+	if cfg.BaseURL != "" {
+		baseURL = cfg.BaseURL
+	}
 
 	return &PayPalClient{
 		clientID:     cfg.ClientID,
 		clientSecret: cfg.ClientSecret,
 		baseURL:      baseURL,
 		mode:         cfg.Mode,
+		webhookID:    cfg.WebhookID,
 		httpClient: &http.Client{
 			Timeout: 30 * time.Second,
 		},
@@ -330,6 +386,69 @@ func (c *PayPalClient) ActivateSubscription(subscriptionID, reason string) error
 	return nil
 }
 
+// ReviseSubscription switches a subscription to another plan. PayPal returns
+// an approve link when the subscriber has to confirm the new price.
+func (c *PayPalClient) ReviseSubscription(subscriptionID, planID string) (*PayPalSubscriptionResponse, error) {
+	path := fmt.Sprintf("/v1/billing/subscriptions/%s/revise", subscriptionID)
+
+	reqBody := map[string]string{
+		"plan_id": planID,
+	}
+
+	var resp PayPalSubscriptionResponse
+	if err := c.doRequest("POST", path, reqBody, &resp); err != nil {
+		return nil, fmt.Errorf("failed to revise subscription: %w", err)
+	}
+
+	logger.Info("PayPal subscription revised", "subscription_id", subscriptionID, "plan_id", planID)
+
+	return &resp, nil
+}
+
+// ManageURL returns the PayPal page where subscribers manage their automatic payments
+func (c *PayPalClient) ManageURL() string {
+	if c.mode == "live" {
+		return "https://www.paypal.com/myaccount/autopay/"
+	}
+	return "https://www.sandbox.paypal.com/myaccount/autopay/"
+}
+
+// VerifyWebhookSignature checks with PayPal that a webhook delivery was sent
+// by PayPal for the configured webhook. Deliveries with missing headers or a
+// signature PayPal rejects return ErrWebhookSignature.
+func (c *PayPalClient) VerifyWebhookSignature(sig *PayPalWebhookSignature, body []byte) error {
+	if c.webhookID == "" {
+		return fmt.Errorf("PayPal webhook ID is not configured")
+	}
+	if sig.TransmissionID == "" || sig.TransmissionTime == "" || sig.TransmissionSig == "" || sig.CertURL == "" || sig.AuthAlgo == "" {
+		return ErrWebhookSignature
+	}
+	if !json.Valid(body) {
+		return ErrWebhookSignature
+	}
+
+	req := &payPalVerifyWebhookRequest{
+		AuthAlgo:         sig.AuthAlgo,
+		CertURL:          sig.CertURL,
+		TransmissionID:   sig.TransmissionID,
+		TransmissionSig:  sig.TransmissionSig,
+		TransmissionTime: sig.TransmissionTime,
+		WebhookID:        c.webhookID,
+		WebhookEvent:     json.RawMessage(body),
+	}
+
+	var resp payPalVerifyWebhookResponse
+	if err := c.doRequest("POST", "/v1/notifications/verify-webhook-signature", req, &resp); err != nil {
+		return fmt.Errorf("failed to verify webhook signature: %w", err)
+	}
+	if resp.VerificationStatus != "SUCCESS" {
+		logger.Warn("PayPal webhook signature rejected", "transmission_id", sig.TransmissionID, "status", resp.VerificationStatus)
+		return ErrWebhookSignature
+	}
+
+	return nil
+}
+
 // GetApprovalURL extracts the approval URL from subscription response links
 func (r *PayPalSubscriptionResponse) GetApprovalURL() string {
 	for _, link := range r.Links {
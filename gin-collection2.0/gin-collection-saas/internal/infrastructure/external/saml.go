@@ -0,0 +1,395 @@
+package external
+
+import (
+	"bytes"
+	"compress/flate"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/beevik/etree"
+	dsig "github.com/russellhaering/goxmldsig"
+	"github.com/russellhaering/goxmldsig/etreeutils"
+)
+
+// SAML 2.0 namespaces and identifiers
+const (
+	SAMLProtocolNamespace  = "urn:oasis:names:tc:SAML:2.0:protocol"
+	SAMLAssertionNamespace = "urn:oasis:names:tc:SAML:2.0:assertion"
+	SAMLMetadataNamespace  = "urn:oasis:names:tc:SAML:2.0:metadata"
+	SAMLStatusSuccess      = "urn:oasis:names:tc:SAML:2.0:status:Success"
+	SAMLBearerMethod       = "urn:oasis:names:tc:SAML:2.0:cm:bearer"
+	SAMLHTTPPostBinding    = "urn:oasis:names:tc:SAML:2.0:bindings:HTTP-POST"
+	SAMLNameIDEmail        = "urn:oasis:names:tc:SAML:1.1:nameid-format:emailAddress"
+	SAMLNameIDUnspecified  = "urn:oasis:names:tc:SAML:1.1:nameid-format:unspecified"
+)
+
+// samlClockSkew is tolerated between our clock and the identity provider's
+const samlClockSkew = 2 * time.Minute
+
+// SAMLServiceProvider is the service provider side of SAML 2.0 Web Browser
+// SSO. Authentication requests use the HTTP-Redirect binding, responses the
+// HTTP-POST binding. Only SP-initiated logins are supported.
+type SAMLServiceProvider struct {
+	EntityID        string
+	ACSURL          string
+	IdPEntityID     string
+	IdPSSOURL       string
+	IdPCertificates []*x509.Certificate
+	Now             func() time.Time // defaults to time.Now
+}
+
+// SAMLAssertion is the verified content of an assertion
+type SAMLAssertion struct {
+	NameID       string
+	NameIDFormat string
+	Attributes   map[string][]string // by Name and FriendlyName
+}
+
+// Attribute returns the first value of an attribute
+func (a *SAMLAssertion) Attribute(name string) string {
+	if values := a.Attributes[name]; len(values) > 0 {
+		return values[0]
+	}
+	return ""
+}
+
+// AuthnRequestURL builds the identity provider URL that starts a login and
+// returns it together with the request ID the response must refer to
+func (sp *SAMLServiceProvider) AuthnRequestURL(relayState string) (string, string, error) {
+	id, err := samlID()
+	if err != nil {
+		return "", "", err
+	}
+
+	request := fmt.Sprintf(
+		`<samlp:AuthnRequest xmlns:samlp="%s" xmlns:saml="%s" ID="%s" Version="2.0" IssueInstant="%s" Destination="%s" AssertionConsumerServiceURL="%s" ProtocolBinding="%s">`+
+			`<saml:Issuer>%s</saml:Issuer>`+
+			`<samlp:NameIDPolicy Format="%s" AllowCreate="true"/>`+
+			`</samlp:AuthnRequest>`,
+		SAMLProtocolNamespace,
+		SAMLAssertionNamespace,
+		id,
+		sp.now().UTC().Format(time.RFC3339),
+		samlEscape(sp.IdPSSOURL),
+		samlEscape(sp.ACSURL),
+		SAMLHTTPPostBinding,
+		samlEscape(sp.EntityID),
+		SAMLNameIDUnspecified,
+	)
+
+	// HTTP-Redirect binding: raw DEFLATE, base64, URL encoded (SAML Bindings 3.4.4.1)
+	var compressed bytes.Buffer
+	writer, err := flate.NewWriter(&compressed, flate.BestCompression)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to compress SAML request: %w", err)
+	}
+	if _, err := writer.Write([]byte(request)); err != nil {
+		return "", "", fmt.Errorf("failed to compress SAML request: %w", err)
+	}
+	if err := writer.Close(); err != nil {
+		return "", "", fmt.Errorf("failed to compress SAML request: %w", err)
+	}
+
+	u, err := url.Parse(sp.IdPSSOURL)
+	if err != nil {
+		return "", "", fmt.Errorf("invalid IdP SSO URL: %w", err)
+	}
+	query := u.Query()
+	query.Set("SAMLRequest", base64.StdEncoding.EncodeToString(compressed.Bytes()))
+	query.Set("RelayState", relayState)
+	u.RawQuery = query.Encode()
+
+	return u.String(), id, nil
+}
+
+// ParseResponse verifies a base64 encoded SAML response to the request with
+// the given ID and returns its assertion. Either the response or the assertion
+// must be signed by the identity provider.
+func (sp *SAMLServiceProvider) ParseResponse(encoded, requestID string) (*SAMLAssertion, error) {
+	data, err := base64.StdEncoding.DecodeString(strings.Join(strings.Fields(encoded), ""))
+	if err != nil {
+		return nil, fmt.Errorf("invalid SAML response encoding: %w", err)
+	}
+
+	doc := etree.NewDocument()
+	if err := doc.ReadFromBytes(data); err != nil {
+		return nil, fmt.Errorf("invalid SAML response: %w", err)
+	}
+	for _, token := range doc.Child {
+		if _, ok := token.(*etree.Directive); ok {
+			return nil, fmt.Errorf("SAML response must not contain a DTD")
+		}
+	}
+	response := doc.Root()
+	if response == nil || response.NamespaceURI() != SAMLProtocolNamespace || response.Tag != "Response" {
+		return nil, fmt.Errorf("not a SAML response")
+	}
+
+	// Everything is read from the trees the signatures were verified on, so a
+	// signature can't be reused for injected content
+	responseSigned := false
+	switch verified, err := sp.verifySignature(response); {
+	case err == nil:
+		response = verified
+		responseSigned = true
+	case err != dsig.ErrMissingSignature:
+		return nil, fmt.Errorf("invalid SAML response signature: %w", err)
+	}
+
+	if samlAttr(response, "Version") != "2.0" {
+		return nil, fmt.Errorf("unsupported SAML version")
+	}
+	if destination := samlAttr(response, "Destination"); destination != "" && destination != sp.ACSURL {
+		return nil, fmt.Errorf("SAML response destination mismatch: %s", destination)
+	}
+	if requestID == "" || samlAttr(response, "InResponseTo") != requestID {
+		return nil, fmt.Errorf("SAML response does not answer our request")
+	}
+	if issuer := samlChild(response, SAMLAssertionNamespace, "Issuer"); issuer != nil && strings.TrimSpace(samlText(issuer)) != sp.IdPEntityID {
+		return nil, fmt.Errorf("SAML response issuer mismatch")
+	}
+
+	status := samlChild(response, SAMLProtocolNamespace, "Status")
+	if status == nil {
+		return nil, fmt.Errorf("SAML response has no status")
+	}
+	statusCode := samlChild(status, SAMLProtocolNamespace, "StatusCode")
+	if statusCode == nil || samlAttr(statusCode, "Value") != SAMLStatusSuccess {
+		value := ""
+		if statusCode != nil {
+			value = samlAttr(statusCode, "Value")
+		}
+		return nil, fmt.Errorf("identity provider returned status %s", value)
+	}
+
+	if len(samlChildren(response, SAMLAssertionNamespace, "EncryptedAssertion")) > 0 {
+		return nil, fmt.Errorf("encrypted assertions are not supported")
+	}
+	assertions := samlChildren(response, SAMLAssertionNamespace, "Assertion")
+	if len(assertions) != 1 {
+		return nil, fmt.Errorf("SAML response must contain exactly one assertion")
+	}
+
+	// The assertion is verified on its own, with the namespaces declared by
+	// the response
+	nsContext, err := etreeutils.NSBuildParentContext(assertions[0])
+	if err != nil {
+		return nil, fmt.Errorf("invalid SAML assertion: %w", err)
+	}
+	assertion, err := etreeutils.NSDetatch(nsContext, assertions[0])
+	if err != nil {
+		return nil, fmt.Errorf("invalid SAML assertion: %w", err)
+	}
+
+	switch verified, err := sp.verifySignature(assertion); {
+	case err == nil:
+		assertion = verified
+	case err == dsig.ErrMissingSignature && responseSigned:
+	case err == dsig.ErrMissingSignature:
+		return nil, fmt.Errorf("SAML response is not signed")
+	default:
+		return nil, fmt.Errorf("invalid SAML assertion signature: %w", err)
+	}
+
+	return sp.verifyAssertion(assertion, requestID)
+}
+
+// verifySignature verifies the enveloped signature of an element against the
+// identity provider certificates and returns the signed content.
+// dsig.ErrMissingSignature means no signature references the element.
+func (sp *SAMLServiceProvider) verifySignature(e *etree.Element) (*etree.Element, error) {
+	ctx := dsig.NewDefaultValidationContext(&dsig.MemoryX509CertificateStore{Roots: sp.IdPCertificates})
+	ctx.Clock = dsig.NewFakeClockAt(sp.now())
+	return ctx.Validate(e)
+}
+
+// verifyAssertion checks issuer, subject confirmation, conditions and
+// audience of a signed assertion and reads its attributes
+func (sp *SAMLServiceProvider) verifyAssertion(assertion *etree.Element, requestID string) (*SAMLAssertion, error) {
+	now := sp.now()
+
+	if samlAttr(assertion, "Version") != "2.0" {
+		return nil, fmt.Errorf("unsupported SAML assertion version")
+	}
+
+	issuer := samlChild(assertion, SAMLAssertionNamespace, "Issuer")
+	if issuer == nil || strings.TrimSpace(samlText(issuer)) != sp.IdPEntityID {
+		return nil, fmt.Errorf("SAML assertion issuer mismatch")
+	}
+
+	subject := samlChild(assertion, SAMLAssertionNamespace, "Subject")
+	if subject == nil {
+		return nil, fmt.Errorf("SAML assertion has no subject")
+	}
+	nameID := samlChild(subject, SAMLAssertionNamespace, "NameID")
+	if nameID == nil || strings.TrimSpace(samlText(nameID)) == "" {
+		return nil, fmt.Errorf("SAML assertion has no NameID")
+	}
+
+	// Web Browser SSO profile 4.1.4.2: a bearer confirmation for our ACS
+	confirmed := false
+	for _, confirmation := range samlChildren(subject, SAMLAssertionNamespace, "SubjectConfirmation") {
+		if samlAttr(confirmation, "Method") != SAMLBearerMethod {
+			continue
+		}
+		data := samlChild(confirmation, SAMLAssertionNamespace, "SubjectConfirmationData")
+		if data == nil || samlAttr(data, "Recipient") != sp.ACSURL {
+			continue
+		}
+		if inResponseTo := samlAttr(data, "InResponseTo"); inResponseTo != "" && inResponseTo != requestID {
+			continue
+		}
+		notOnOrAfter, err := time.Parse(time.RFC3339Nano, samlAttr(data, "NotOnOrAfter"))
+		if err != nil || !now.Before(notOnOrAfter.Add(samlClockSkew)) {
+			continue
+		}
+		confirmed = true
+		break
+	}
+	if !confirmed {
+		return nil, fmt.Errorf("SAML assertion has no valid bearer subject confirmation")
+	}
+
+	conditions := samlChild(assertion, SAMLAssertionNamespace, "Conditions")
+	if conditions == nil {
+		return nil, fmt.Errorf("SAML assertion has no conditions")
+	}
+	if notBefore := samlAttr(conditions, "NotBefore"); notBefore != "" {
+		t, err := time.Parse(time.RFC3339Nano, notBefore)
+		if err != nil || now.Add(samlClockSkew).Before(t) {
+			return nil, fmt.Errorf("SAML assertion is not yet valid")
+		}
+	}
+	if notOnOrAfter := samlAttr(conditions, "NotOnOrAfter"); notOnOrAfter != "" {
+		t, err := time.Parse(time.RFC3339Nano, notOnOrAfter)
+		if err != nil || !now.Before(t.Add(samlClockSkew)) {
+			return nil, fmt.Errorf("SAML assertion has expired")
+		}
+	}
+
+	// Every audience restriction must include us
+	restrictions := samlChildren(conditions, SAMLAssertionNamespace, "AudienceRestriction")
+	if len(restrictions) == 0 {
+		return nil, fmt.Errorf("SAML assertion has no audience restriction")
+	}
+	for _, restriction := range restrictions {
+		found := false
+		for _, audience := range samlChildren(restriction, SAMLAssertionNamespace, "Audience") {
+			if strings.TrimSpace(samlText(audience)) == sp.EntityID {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("SAML assertion audience mismatch")
+		}
+	}
+
+	result := &SAMLAssertion{
+		NameID:       strings.TrimSpace(samlText(nameID)),
+		NameIDFormat: samlAttr(nameID, "Format"),
+		Attributes:   map[string][]string{},
+	}
+
+	for _, statement := range samlChildren(assertion, SAMLAssertionNamespace, "AttributeStatement") {
+		for _, attribute := range samlChildren(statement, SAMLAssertionNamespace, "Attribute") {
+			var values []string
+			for _, value := range samlChildren(attribute, SAMLAssertionNamespace, "AttributeValue") {
+				values = append(values, strings.TrimSpace(samlText(value)))
+			}
+			for _, name := range []string{samlAttr(attribute, "Name"), samlAttr(attribute, "FriendlyName")} {
+				if name != "" {
+					result.Attributes[name] = append(result.Attributes[name], values...)
+				}
+			}
+		}
+	}
+
+	return result, nil
+}
+
+// Metadata returns the service provider metadata to register at the identity provider
+func (sp *SAMLServiceProvider) Metadata() []byte {
+	return []byte(fmt.Sprintf(
+		`<?xml version="1.0" encoding="UTF-8"?>`+"\n"+
+			`<md:EntityDescriptor xmlns:md="%s" entityID="%s">`+
+			`<md:SPSSODescriptor AuthnRequestsSigned="false" WantAssertionsSigned="true" protocolSupportEnumeration="%s">`+
+			`<md:NameIDFormat>%s</md:NameIDFormat>`+
+			`<md:AssertionConsumerService Binding="%s" Location="%s" index="0" isDefault="true"/>`+
+			`</md:SPSSODescriptor>`+
+			`</md:EntityDescriptor>`,
+		SAMLMetadataNamespace,
+		samlEscape(sp.EntityID),
+		SAMLProtocolNamespace,
+		SAMLNameIDEmail,
+		SAMLHTTPPostBinding,
+		samlEscape(sp.ACSURL),
+	))
+}
+
+func (sp *SAMLServiceProvider) now() time.Time {
+	if sp.Now != nil {
+		return sp.Now()
+	}
+	return time.Now()
+}
+
+// samlID returns a random message ID (an xs:ID must not start with a digit)
+func samlID() (string, error) {
+	b := make([]byte, 20)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate SAML request ID: %w", err)
+	}
+	return "_" + hex.EncodeToString(b), nil
+}
+
+// samlEscape escapes a value for use in XML text or attributes
+func samlEscape(s string) string {
+	var buf bytes.Buffer
+	xml.EscapeText(&buf, []byte(s))
+	return buf.String()
+}
+
+// samlChildren returns the child elements with the given namespace and local name
+func samlChildren(e *etree.Element, space, local string) []*etree.Element {
+	var children []*etree.Element
+	for _, child := range e.ChildElements() {
+		if child.Tag == local && child.NamespaceURI() == space {
+			children = append(children, child)
+		}
+	}
+	return children
+}
+
+// samlChild returns the first child element with the given namespace and local name
+func samlChild(e *etree.Element, space, local string) *etree.Element {
+	if children := samlChildren(e, space, local); len(children) > 0 {
+		return children[0]
+	}
+	return nil
+}
+
+// samlText returns all character data directly inside an element. Comments
+// are not part of the signed canonical form, so text split by a comment must
+// be read as a whole, otherwise a comment could cut a signed NameID short.
+func samlText(e *etree.Element) string {
+	var text strings.Builder
+	for _, token := range e.Child {
+		if data, ok := token.(*etree.CharData); ok {
+			text.WriteString(data.Data)
+		}
+	}
+	return text.String()
+}
+
+// samlAttr returns the value of an unqualified attribute
+func samlAttr(e *etree.Element, name string) string {
+	return e.SelectAttrValue(name, "")
+}
@@ -0,0 +1,308 @@
+package external
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/yourusername/gin-collection-saas/pkg/logger"
+)
+
+// stripeSignatureTolerance is how old a signed webhook delivery may be
+const stripeSignatureTolerance = 5 * time.Minute
+
+// StripeClient handles Stripe API interactions
+type StripeClient struct {
+	secretKey     string
+	webhookSecret string
+	baseURL       string
+	httpClient    *http.Client
+}
+
+// StripeConfig holds Stripe configuration
+type StripeConfig struct {
+	SecretKey     string
+	WebhookSecret string // signing secret of the webhook endpoint (whsec_...)
+	BaseURL       string // overrides the API URL (e.g. a local fake)
+}
+
+// StripeCheckoutSession represents a Stripe Checkout session
+type StripeCheckoutSession struct {
+	ID           string `json:"id"`
+	URL          string `json:"url"`
+	Status       string `json:"status"` // "open", "complete" or "expired"
+	Customer     string `json:"customer"`
+	Subscription string `json:"subscription"`
+}
+
+// StripeSubscription represents a Stripe subscription
+type StripeSubscription struct {
+	ID                 string                  `json:"id"`
+	Customer           string                  `json:"customer"`
+	Status             string                  `json:"status"`
+	CurrentPeriodStart int64                   `json:"current_period_start"`
+	CurrentPeriodEnd   int64                   `json:"current_period_end"`
+	CancelAtPeriodEnd  bool                    `json:"cancel_at_period_end"`
+	PauseCollection    *StripePauseCollection  `json:"pause_collection"`
+	Items              StripeSubscriptionItems `json:"items"`
+}
+
+// StripePauseCollection is set while the payments of a subscription are paused
+type StripePauseCollection struct {
+	Behavior string `json:"behavior"`
+}
+
+// StripeSubscriptionItems holds the items of a subscription
+type StripeSubscriptionItems struct {
+	Data []StripeSubscriptionItem `json:"data"`
+}
+
+// StripeSubscriptionItem is a price a subscription is billed for
+type StripeSubscriptionItem struct {
+	ID                 string      `json:"id"`
+	Price              StripePrice `json:"price"`
+	CurrentPeriodStart int64       `json:"current_period_start"`
+	CurrentPeriodEnd   int64       `json:"current_period_end"`
+}
+
+// StripePrice represents a Stripe price
+type StripePrice struct {
+	ID string `json:"id"`
+}
+
+// StripeCoupon represents a Stripe coupon
+type StripeCoupon struct {
+	ID string `json:"id"`
+}
+
+// StripePortalSession represents a Stripe customer portal session
+type StripePortalSession struct {
+	ID  string `json:"id"`
+	URL string `json:"url"`
+}
+
+// StripeError represents a Stripe API error
+type StripeError struct {
+	Error struct {
+		Type    string `json:"type"`
+		Code    string `json:"code"`
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+// ErrStripeWebhookSignature is returned for webhook deliveries Stripe didn't sign
+var ErrStripeWebhookSignature = errors.New("invalid Stripe webhook signature")
+
+// NewStripeClient creates a new Stripe client
+func NewStripeClient(cfg *StripeConfig) *StripeClient {
+	baseURL := "https://api.stripe.com"
+	if cfg.BaseURL != "" {
+		baseURL = cfg.BaseURL
+	}
+
+	return &StripeClient{
+		secretKey:     cfg.SecretKey,
+		webhookSecret: cfg.WebhookSecret,
+		baseURL:       baseURL,
+		httpClient: &http.Client{
+			Timeout: 30 * time.Second,
+		},
+	}
+}
+
+// doRequest performs an authenticated API request, Stripe takes form-encoded parameters
+func (c *StripeClient) doRequest(method, path string, params url.Values, result interface{}) error {
+	if c.secretKey == "" {
+		return fmt.Errorf("Stripe secret key is not configured")
+	}
+
+	var reqBody io.Reader
+	endpoint := c.baseURL + path
+	if len(params) > 0 {
+		if method == "GET" {
+			endpoint += "?" + params.Encode()
+		} else {
+			reqBody = strings.NewReader(params.Encode())
+		}
+	}
+
+	req, err := http.NewRequest(method, endpoint, reqBody)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+
+	req.SetBasicAuth(c.secretKey, "")
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	bodyBytes, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("failed to read response: %w", err)
+	}
+
+	logger.Debug("Stripe response", "method", method, "path", path, "status", resp.StatusCode)
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		var stripeErr StripeError
+		if err := json.Unmarshal(bodyBytes, &stripeErr); err == nil && stripeErr.Error.Message != "" {
+			return fmt.Errorf("Stripe API error: %s - %s", stripeErr.Error.Type, stripeErr.Error.Message)
+		}
+		return fmt.Errorf("request failed with status %d: %s", resp.StatusCode, string(bodyBytes))
+	}
+
+	if result != nil {
+		if err := json.Unmarshal(bodyBytes, result); err != nil {
+			return fmt.Errorf("failed to decode response: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// CreateCheckoutSession starts a Stripe Checkout for a subscription to a price
+func (c *StripeClient) CreateCheckoutSession(params url.Values) (*StripeCheckoutSession, error) {
+	params.Set("mode", "subscription")
+
+	var session StripeCheckoutSession
+	if err := c.doRequest("POST", "/v1/checkout/sessions", params, &session); err != nil {
+		return nil, fmt.Errorf("failed to create checkout session: %w", err)
+	}
+
+	logger.Info("Stripe checkout session created", "session_id", session.ID)
+
+	return &session, nil
+}
+
+// CreateCoupon creates a coupon that can be applied to Checkout sessions
+func (c *StripeClient) CreateCoupon(params url.Values) (*StripeCoupon, error) {
+	var coupon StripeCoupon
+	if err := c.doRequest("POST", "/v1/coupons", params, &coupon); err != nil {
+		return nil, fmt.Errorf("failed to create coupon: %w", err)
+	}
+
+	return &coupon, nil
+}
+
+// GetCheckoutSession retrieves a Checkout session by ID
+func (c *StripeClient) GetCheckoutSession(sessionID string) (*StripeCheckoutSession, error) {
+	var session StripeCheckoutSession
+	if err := c.doRequest("GET", "/v1/checkout/sessions/"+url.PathEscape(sessionID), nil, &session); err != nil {
+		return nil, fmt.Errorf("failed to get checkout session: %w", err)
+	}
+
+	return &session, nil
+}
+
+// GetSubscription retrieves a subscription by ID
+func (c *StripeClient) GetSubscription(subscriptionID string) (*StripeSubscription, error) {
+	var subscription StripeSubscription
+	if err := c.doRequest("GET", "/v1/subscriptions/"+url.PathEscape(subscriptionID), nil, &subscription); err != nil {
+		return nil, fmt.Errorf("failed to get subscription: %w", err)
+	}
+
+	return &subscription, nil
+}
+
+// UpdateSubscription updates a subscription with the given parameters
+func (c *StripeClient) UpdateSubscription(subscriptionID string, params url.Values) (*StripeSubscription, error) {
+	var subscription StripeSubscription
+	if err := c.doRequest("POST", "/v1/subscriptions/"+url.PathEscape(subscriptionID), params, &subscription); err != nil {
+		return nil, fmt.Errorf("failed to update subscription: %w", err)
+	}
+
+	return &subscription, nil
+}
+
+// CancelSubscription cancels a subscription immediately
+func (c *StripeClient) CancelSubscription(subscriptionID, reason string) error {
+	params := url.Values{}
+	if reason != "" {
+		params.Set("cancellation_details[comment]", reason)
+	}
+
+	if err := c.doRequest("DELETE", "/v1/subscriptions/"+url.PathEscape(subscriptionID), params, nil); err != nil {
+		return fmt.Errorf("failed to cancel subscription: %w", err)
+	}
+
+	logger.Info("Stripe subscription cancelled", "subscription_id", subscriptionID, "reason", reason)
+
+	return nil
+}
+
+// CreatePortalSession creates a customer portal session for a customer
+func (c *StripeClient) CreatePortalSession(customerID, returnURL string) (*StripePortalSession, error) {
+	params := url.Values{}
+	params.Set("customer", customerID)
+	params.Set("return_url", returnURL)
+
+	var session StripePortalSession
+	if err := c.doRequest("POST", "/v1/billing_portal/sessions", params, &session); err != nil {
+		return nil, fmt.Errorf("failed to create portal session: %w", err)
+	}
+
+	return &session, nil
+}
+
+// VerifyWebhookSignature checks the Stripe-Signature header of a webhook
+// delivery against the signing secret of the endpoint
+func (c *StripeClient) VerifyWebhookSignature(header string, body []byte) error {
+	if c.webhookSecret == "" {
+		return fmt.Errorf("Stripe webhook secret is not configured")
+	}
+
+	var timestamp string
+	var signatures []string
+	for _, part := range strings.Split(header, ",") {
+		key, value, ok := strings.Cut(strings.TrimSpace(part), "=")
+		if !ok {
+			continue
+		}
+		switch key {
+		case "t":
+			timestamp = value
+		case "v1":
+			signatures = append(signatures, value)
+		}
+	}
+
+	unix, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil || len(signatures) == 0 {
+		return ErrStripeWebhookSignature
+	}
+	if age := time.Since(time.Unix(unix, 0)); age > stripeSignatureTolerance || age < -stripeSignatureTolerance {
+		return ErrStripeWebhookSignature
+	}
+
+	expected := StripeWebhookSignature(c.webhookSecret, timestamp, body)
+	for _, signature := range signatures {
+		if hmac.Equal([]byte(signature), []byte(expected)) {
+			return nil
+		}
+	}
+
+	return ErrStripeWebhookSignature
+}
+
+// StripeWebhookSignature computes the v1 signature of a webhook payload
+func StripeWebhookSignature(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,186 @@
+package pdf
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/yourusername/gin-collection-saas/internal/domain/models"
+)
+
+const (
+	marginLeft  = 56.0
+	marginRight = PageWidth - 56.0
+)
+
+// providerNames are the payment methods shown on invoices
+var providerNames = map[string]string{
+	models.BillingProviderPayPal: "PayPal",
+	models.BillingProviderStripe: "Kreditkarte (Stripe)",
+}
+
+// RenderInvoice renders an invoice as PDF
+func RenderInvoice(invoice *models.Invoice) []byte {
+	d := NewDocument()
+	seller := invoice.Seller
+	if seller == nil {
+		seller = &models.BillingDetails{}
+	}
+
+	// Sender line and recipient
+	d.Text(marginLeft, 130, 7, false, strings.Join(nonEmpty(seller.Name, seller.AddressLine1,
+		strings.TrimSpace(seller.PostalCode+" "+seller.City)), " · "))
+	y := 150.0
+	for _, line := range addressLines(invoice.Customer) {
+		d.Text(marginLeft, y, 10, false, line)
+		y += 13
+	}
+
+	// Seller
+	y = 60.0
+	d.TextRight(marginRight, y, 14, true, seller.Name)
+	y += 16
+	for _, line := range nonEmpty(seller.AddressLine1, seller.AddressLine2,
+		strings.TrimSpace(seller.PostalCode+" "+seller.City), seller.Country) {
+		d.TextRight(marginRight, y, 9, false, line)
+		y += 12
+	}
+	if seller.Email != "" {
+		d.TextRight(marginRight, y, 9, false, seller.Email)
+	}
+
+	// Invoice details
+	d.Text(marginLeft, 260, 16, true, "Rechnung")
+	y = 285
+	details := [][2]string{
+		{"Rechnungsnummer", invoice.Number},
+		{"Rechnungsdatum", invoice.IssuedAt.Format("02.01.2006")},
+	}
+	if invoice.PeriodStart != nil && invoice.PeriodEnd != nil {
+		details = append(details, [2]string{"Leistungszeitraum",
+			invoice.PeriodStart.Format("02.01.2006") + " – " + invoice.PeriodEnd.Format("02.01.2006")})
+	}
+	if invoice.Customer != nil && invoice.Customer.VATID != "" {
+		details = append(details, [2]string{"Ihre USt-IdNr.", invoice.Customer.VATID})
+	}
+	for _, detail := range details {
+		d.Text(marginLeft, y, 10, false, detail[0])
+		d.Text(marginLeft+120, y, 10, false, detail[1])
+		y += 14
+	}
+
+	// Items
+	y += 20
+	d.Text(marginLeft, y, 10, true, "Beschreibung")
+	d.TextRight(marginRight, y, 10, true, "Betrag")
+	y += 6
+	d.Line(marginLeft, y, marginRight, y, 0.5)
+	y += 16
+	d.Text(marginLeft, y, 10, false, invoice.Description)
+	d.TextRight(marginRight, y, 10, false, formatAmount(invoice.NetAmount, invoice.Currency))
+	y += 10
+	d.Line(marginLeft, y, marginRight, y, 0.5)
+
+	// Totals
+	y += 18
+	totals := [][2]string{{"Nettobetrag", formatAmount(invoice.NetAmount, invoice.Currency)}}
+	if invoice.TaxRate > 0 {
+		totals = append(totals, [2]string{
+			"Umsatzsteuer " + strings.Replace(strconv.FormatFloat(invoice.TaxRate, 'f', -1, 64), ".", ",", 1) + " %",
+			formatAmount(invoice.TaxAmount, invoice.Currency),
+		})
+	} else {
+		totals = append(totals, [2]string{"Umsatzsteuer", formatAmount(0, invoice.Currency)})
+	}
+	for _, total := range totals {
+		d.Text(marginRight-200, y, 10, false, total[0])
+		d.TextRight(marginRight, y, 10, false, total[1])
+		y += 14
+	}
+	d.Line(marginRight-200, y-8, marginRight, y-8, 0.5)
+	y += 4
+	d.Text(marginRight-200, y, 11, true, "Gesamtbetrag")
+	d.TextRight(marginRight, y, 11, true, formatAmount(invoice.TotalAmount, invoice.Currency))
+
+	// Notes
+	y += 40
+	for _, note := range invoiceNotes(invoice) {
+		d.Text(marginLeft, y, 9, false, note)
+		y += 13
+	}
+
+	// Footer
+	footer := nonEmpty(seller.Name, seller.Email)
+	if seller.VATID != "" {
+		footer = append(footer, "USt-IdNr. "+seller.VATID)
+	}
+	d.Line(marginLeft, 790, marginRight, 790, 0.5)
+	d.Text(marginLeft, 805, 7, false, strings.Join(footer, " · "))
+
+	return d.Bytes()
+}
+
+// invoiceNotes returns the payment and tax notes of an invoice
+func invoiceNotes(invoice *models.Invoice) []string {
+	method := providerNames[invoice.Provider]
+	if method == "" {
+		method = invoice.Provider
+	}
+	notes := []string{fmt.Sprintf("Der Betrag wurde am %s per %s bezahlt.", invoice.IssuedAt.Format("02.01.2006"), method)}
+
+	switch {
+	case invoice.ReverseCharge:
+		notes = append(notes, "Steuerschuldnerschaft des Leistungsempfängers (Reverse Charge).")
+	case invoice.TaxRate == 0:
+		notes = append(notes, "Nicht im Inland steuerbare Leistung.")
+	}
+	return notes
+}
+
+// addressLines returns the lines of an address, empty ones left out
+func addressLines(details *models.BillingDetails) []string {
+	if details == nil {
+		return []string{""}
+	}
+	lines := nonEmpty(details.Name, details.AddressLine1, details.AddressLine2,
+		strings.TrimSpace(details.PostalCode+" "+details.City), details.Country)
+	if len(lines) == 0 {
+		return []string{""}
+	}
+	return lines
+}
+
+// nonEmpty returns the values that aren't empty
+func nonEmpty(values ...string) []string {
+	var result []string
+	for _, value := range values {
+		if value != "" {
+			result = append(result, value)
+		}
+	}
+	return result
+}
+
+// formatAmount formats an amount the German way, e.g. 1.234,56 €
+func formatAmount(amount float64, currency string) string {
+	negative := amount < 0
+	if negative {
+		amount = -amount
+	}
+
+	formatted := fmt.Sprintf("%.2f", amount)
+	whole, cents := formatted[:len(formatted)-3], formatted[len(formatted)-2:]
+	for i := len(whole) - 3; i > 0; i -= 3 {
+		whole = whole[:i] + "." + whole[i:]
+	}
+
+	symbol := currency
+	if currency == "EUR" {
+		symbol = "€"
+	}
+	result := whole + "," + cents + " " + symbol
+	if negative {
+		result = "-" + result
+	}
+	return result
+}
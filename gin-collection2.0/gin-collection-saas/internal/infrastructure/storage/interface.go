@@ -1,6 +1,14 @@
 package storage
 
-import "context"
+import (
+	"context"
+	"fmt"
+	"io"
+	"path/filepath"
+	"time"
+
+	"github.com/google/uuid"
+)
 
 // Storage defines the interface for photo storage
 type Storage interface {
@@ -8,4 +16,49 @@ type Storage interface {
 	DeletePhoto(ctx context.Context, key string) error
 	DownloadPhoto(ctx context.Context, key string) ([]byte, error)
 	CheckExists(ctx context.Context, key string) (bool, error)
+
+	// PresignUpload returns a URL the client can PUT the object to directly
+	PresignUpload(ctx context.Context, key string, contentType string, expiration time.Duration) (string, error)
+	// ReadObjectHead reads up to n leading bytes of an object (for magic byte checks)
+	ReadObjectHead(ctx context.Context, key string, n int) ([]byte, error)
+	// GetObjectSize gets the size of an object in bytes
+	GetObjectSize(ctx context.Context, key string) (int64, error)
+	// ObjectURL returns the URL under which a stored object is served
+	ObjectURL(key string) string
+
+	// PutObject writes an object under an exact key (used for backend migrations)
+	PutObject(ctx context.Context, key string, data []byte, contentType string) (*UploadResult, error)
+	// CopyObject copies an object to another key of the same backend
+	CopyObject(ctx context.Context, srcKey, dstKey string) error
+	// ListObjects lists all objects below a key prefix
+	ListObjects(ctx context.Context, prefix string) ([]ObjectInfo, error)
+}
+
+// ObjectInfo describes a stored object
+type ObjectInfo struct {
+	Key          string
+	SizeBytes    int64
+	LastModified time.Time
+}
+
+// SignedUploadReceiver is implemented by backends whose presigned uploads
+// are received by the API itself (local storage)
+type SignedUploadReceiver interface {
+	ReceiveSignedUpload(ctx context.Context, key string, contentType string, expires int64, signature string, body io.Reader) (int64, error)
+}
+
+// StagingPrefix is the key prefix of direct uploads that were not validated
+// yet. Photos are copied to a key below tenants/ once they are, so the
+// presigned URL never points at a registered photo.
+const StagingPrefix = "staging/"
+
+// NewPhotoKey generates a unique object key for a gin photo
+func NewPhotoKey(tenantID, ginID int64, filename string) string {
+	ext := filepath.Ext(filename)
+	return fmt.Sprintf("tenants/%d/gins/%d/%s%s", tenantID, ginID, uuid.New().String(), ext)
+}
+
+// NewStagingKey generates a unique object key for a direct upload
+func NewStagingKey(tenantID, ginID int64, filename string) string {
+	return StagingPrefix + NewPhotoKey(tenantID, ginID, filename)
 }
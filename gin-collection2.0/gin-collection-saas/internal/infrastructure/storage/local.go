@@ -2,24 +2,45 @@ package storage
 
 import (
 	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
 	"fmt"
+	"io"
+	"net/url"
 	"os"
 	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
 
-	"github.com/google/uuid"
 	"github.com/yourusername/gin-collection-saas/pkg/logger"
 )
 
+var (
+	// ErrInvalidUploadSignature is returned when a signed upload URL does not verify
+	ErrInvalidUploadSignature = errors.New("invalid upload signature")
+	// ErrUploadURLExpired is returned when a signed upload URL has expired
+	ErrUploadURLExpired = errors.New("upload URL has expired")
+	// ErrInvalidKey is returned for object keys that escape the storage directory
+	ErrInvalidKey = errors.New("invalid storage key")
+)
+
 // LocalStorage handles local file storage as S3 fallback
 type LocalStorage struct {
-	basePath string
-	baseURL  string
+	basePath      string
+	baseURL       string
+	uploadURL     string
+	signingSecret string
 }
 
 // LocalStorageConfig holds local storage configuration
 type LocalStorageConfig struct {
-	BasePath string // e.g., "/app/uploads"
-	BaseURL  string // e.g., "https://ginvault.cloud/uploads"
+	BasePath      string // e.g., "/app/uploads"
+	BaseURL       string // e.g., "https://ginvault.cloud/uploads"
+	UploadURL     string // e.g., "https://ginvault.cloud/api/v1/uploads" (signed PUT endpoint)
+	SigningSecret string // HMAC secret for signed upload URLs
 }
 
 // NewLocalStorage creates a new local storage client
@@ -32,16 +53,17 @@ func NewLocalStorage(cfg *LocalStorageConfig) (*LocalStorage, error) {
 	logger.Info("Local storage initialized", "path", cfg.BasePath, "url", cfg.BaseURL)
 
 	return &LocalStorage{
-		basePath: cfg.BasePath,
-		baseURL:  cfg.BaseURL,
+		basePath:      cfg.BasePath,
+		baseURL:       cfg.BaseURL,
+		uploadURL:     cfg.UploadURL,
+		signingSecret: cfg.SigningSecret,
 	}, nil
 }
 
 // UploadPhoto uploads a photo to local storage
 func (s *LocalStorage) UploadPhoto(ctx context.Context, tenantID int64, ginID int64, filename string, data []byte, contentType string) (*UploadResult, error) {
 	// Generate unique key
-	ext := filepath.Ext(filename)
-	key := fmt.Sprintf("tenants/%d/gins/%d/%s%s", tenantID, ginID, uuid.New().String(), ext)
+	key := NewPhotoKey(tenantID, ginID, filename)
 
 	// Create full path
 	fullPath := filepath.Join(s.basePath, key)
@@ -109,3 +131,246 @@ func (s *LocalStorage) CheckExists(ctx context.Context, key string) (bool, error
 	}
 	return true, nil
 }
+
+// PresignUpload generates a signed URL for uploading a file through the API
+func (s *LocalStorage) PresignUpload(ctx context.Context, key string, contentType string, expiration time.Duration) (string, error) {
+	if s.signingSecret == "" || s.uploadURL == "" {
+		return "", fmt.Errorf("signed uploads are not configured for local storage")
+	}
+	if _, err := s.resolvePath(key); err != nil {
+		return "", err
+	}
+
+	expires := time.Now().Add(expiration).Unix()
+	signature := s.sign(key, contentType, expires)
+
+	query := url.Values{}
+	query.Set("expires", strconv.FormatInt(expires, 10))
+	query.Set("signature", signature)
+
+	return fmt.Sprintf("%s/%s?%s", strings.TrimSuffix(s.uploadURL, "/"), key, query.Encode()), nil
+}
+
+// ReceiveSignedUpload verifies a signed upload URL and writes the request body to disk
+func (s *LocalStorage) ReceiveSignedUpload(ctx context.Context, key string, contentType string, expires int64, signature string, body io.Reader) (int64, error) {
+	if s.signingSecret == "" {
+		return 0, ErrInvalidUploadSignature
+	}
+
+	expected := s.sign(key, contentType, expires)
+	if !hmac.Equal([]byte(expected), []byte(signature)) {
+		return 0, ErrInvalidUploadSignature
+	}
+
+	if time.Now().Unix() > expires {
+		return 0, ErrUploadURLExpired
+	}
+
+	fullPath, err := s.resolvePath(key)
+	if err != nil {
+		return 0, err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(fullPath), 0755); err != nil {
+		return 0, fmt.Errorf("failed to create directory: %w", err)
+	}
+
+	// Write to a temp file first so a partial upload never replaces the object
+	tmp, err := os.CreateTemp(filepath.Dir(fullPath), ".upload-*")
+	if err != nil {
+		return 0, fmt.Errorf("failed to create temp file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	written, err := io.Copy(tmp, body)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to write file: %w", err)
+	}
+
+	if err := os.Chmod(tmp.Name(), 0644); err != nil {
+		return 0, fmt.Errorf("failed to set file permissions: %w", err)
+	}
+
+	if err := os.Rename(tmp.Name(), fullPath); err != nil {
+		return 0, fmt.Errorf("failed to store file: %w", err)
+	}
+
+	logger.Info("Signed upload received", "key", key, "size", written)
+
+	return written, nil
+}
+
+// ReadObjectHead reads up to n leading bytes of a file
+func (s *LocalStorage) ReadObjectHead(ctx context.Context, key string, n int) ([]byte, error) {
+	fullPath, err := s.resolvePath(key)
+	if err != nil {
+		return nil, err
+	}
+
+	f, err := os.Open(fullPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open file: %w", err)
+	}
+	defer f.Close()
+
+	data, err := io.ReadAll(io.LimitReader(f, int64(n)))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read file: %w", err)
+	}
+
+	return data, nil
+}
+
+// GetObjectSize gets the size of a file in bytes
+func (s *LocalStorage) GetObjectSize(ctx context.Context, key string) (int64, error) {
+	fullPath, err := s.resolvePath(key)
+	if err != nil {
+		return 0, err
+	}
+
+	info, err := os.Stat(fullPath)
+	if err != nil {
+		return 0, fmt.Errorf("failed to stat file: %w", err)
+	}
+
+	return info.Size(), nil
+}
+
+// ObjectURL returns the public URL of a stored file
+func (s *LocalStorage) ObjectURL(key string) string {
+	return fmt.Sprintf("%s/%s", s.baseURL, key)
+}
+
+// PutObject writes a file under an exact key
+func (s *LocalStorage) PutObject(ctx context.Context, key string, data []byte, contentType string) (*UploadResult, error) {
+	fullPath, err := s.resolvePath(key)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(fullPath), 0755); err != nil {
+		return nil, fmt.Errorf("failed to create directory: %w", err)
+	}
+
+	if err := os.WriteFile(fullPath, data, 0644); err != nil {
+		return nil, fmt.Errorf("failed to write file: %w", err)
+	}
+
+	return &UploadResult{
+		Key:       key,
+		URL:       s.ObjectURL(key),
+		SizeBytes: int64(len(data)),
+	}, nil
+}
+
+// CopyObject copies a file to another key
+func (s *LocalStorage) CopyObject(ctx context.Context, srcKey, dstKey string) error {
+	srcPath, err := s.resolvePath(srcKey)
+	if err != nil {
+		return err
+	}
+	dstPath, err := s.resolvePath(dstKey)
+	if err != nil {
+		return err
+	}
+
+	src, err := os.Open(srcPath)
+	if err != nil {
+		return fmt.Errorf("failed to open file: %w", err)
+	}
+	defer src.Close()
+
+	if err := os.MkdirAll(filepath.Dir(dstPath), 0755); err != nil {
+		return fmt.Errorf("failed to create directory: %w", err)
+	}
+
+	// Copy to a temp file first so the object only appears once complete
+	tmp, err := os.CreateTemp(filepath.Dir(dstPath), ".upload-*")
+	if err != nil {
+		return fmt.Errorf("failed to create temp file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	_, err = io.Copy(tmp, src)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return fmt.Errorf("failed to copy file: %w", err)
+	}
+
+	if err := os.Chmod(tmp.Name(), 0644); err != nil {
+		return fmt.Errorf("failed to set file permissions: %w", err)
+	}
+
+	if err := os.Rename(tmp.Name(), dstPath); err != nil {
+		return fmt.Errorf("failed to store file: %w", err)
+	}
+
+	return nil
+}
+
+// ListObjects lists all files below a key prefix
+func (s *LocalStorage) ListObjects(ctx context.Context, prefix string) ([]ObjectInfo, error) {
+	root := filepath.Join(s.basePath, filepath.FromSlash(prefix))
+
+	var objects []ObjectInfo
+	err := filepath.WalkDir(root, func(path string, d os.DirEntry, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) {
+				return nil
+			}
+			return err
+		}
+		// Skip directories and in-flight signed uploads
+		if d.IsDir() || strings.HasPrefix(d.Name(), ".upload-") {
+			return nil
+		}
+
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+
+		rel, err := filepath.Rel(s.basePath, path)
+		if err != nil {
+			return err
+		}
+
+		objects = append(objects, ObjectInfo{
+			Key:          filepath.ToSlash(rel),
+			SizeBytes:    info.Size(),
+			LastModified: info.ModTime(),
+		})
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list files: %w", err)
+	}
+
+	return objects, nil
+}
+
+// sign computes the HMAC signature of an upload URL
+func (s *LocalStorage) sign(key, contentType string, expires int64) string {
+	mac := hmac.New(sha256.New, []byte(s.signingSecret))
+	mac.Write([]byte(key + "\n" + contentType + "\n" + strconv.FormatInt(expires, 10)))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// resolvePath maps a key to a path inside the storage directory
+func (s *LocalStorage) resolvePath(key string) (string, error) {
+	if key == "" || filepath.IsAbs(key) || strings.Contains(key, "..") {
+		return "", ErrInvalidKey
+	}
+
+	fullPath := filepath.Join(s.basePath, filepath.FromSlash(key))
+	if !strings.HasPrefix(fullPath, filepath.Clean(s.basePath)+string(filepath.Separator)) {
+		return "", ErrInvalidKey
+	}
+
+	return fullPath, nil
+}
@@ -5,7 +5,7 @@ import (
 	"context"
 	"fmt"
 	"io"
-	"path/filepath"
+	"net/url"
 	"time"
 
 	"github.com/aws/aws-sdk-go/aws"
@@ -13,7 +13,6 @@ import (
 	"github.com/aws/aws-sdk-go/aws/session"
 	"github.com/aws/aws-sdk-go/service/s3"
 	"github.com/aws/aws-sdk-go/service/s3/s3manager"
-	"github.com/google/uuid"
 	"github.com/yourusername/gin-collection-saas/pkg/logger"
 )
 
@@ -79,8 +78,7 @@ func NewS3Client(cfg *S3Config) (*S3Client, error) {
 // UploadPhoto uploads a photo to S3
 func (c *S3Client) UploadPhoto(ctx context.Context, tenantID int64, ginID int64, filename string, data []byte, contentType string) (*UploadResult, error) {
 	// Generate unique key
-	ext := filepath.Ext(filename)
-	key := fmt.Sprintf("tenants/%d/gins/%d/%s%s", tenantID, ginID, uuid.New().String(), ext)
+	key := NewPhotoKey(tenantID, ginID, filename)
 
 	logger.Info("Uploading photo to S3", "key", key, "size", len(data), "content_type", contentType)
 
@@ -203,3 +201,123 @@ func (c *S3Client) GetObjectSize(ctx context.Context, key string) (int64, error)
 
 	return *result.ContentLength, nil
 }
+
+// PresignUpload generates a presigned PUT URL for a direct client upload
+func (c *S3Client) PresignUpload(ctx context.Context, key string, contentType string, expiration time.Duration) (string, error) {
+	req, _ := c.client.PutObjectRequest(&s3.PutObjectInput{
+		Bucket:      aws.String(c.bucket),
+		Key:         aws.String(key),
+		ContentType: aws.String(contentType),
+		ACL:         aws.String("private"),
+	})
+	req.SetContext(ctx)
+
+	uploadURL, err := req.Presign(expiration)
+	if err != nil {
+		return "", fmt.Errorf("failed to generate presigned upload URL: %w", err)
+	}
+
+	return uploadURL, nil
+}
+
+// ReadObjectHead reads up to n leading bytes of an object using a range request
+func (c *S3Client) ReadObjectHead(ctx context.Context, key string, n int) ([]byte, error) {
+	result, err := c.client.GetObjectWithContext(ctx, &s3.GetObjectInput{
+		Bucket: aws.String(c.bucket),
+		Key:    aws.String(key),
+		Range:  aws.String(fmt.Sprintf("bytes=0-%d", n-1)),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read S3 object: %w", err)
+	}
+	defer result.Body.Close()
+
+	data, err := io.ReadAll(io.LimitReader(result.Body, int64(n)))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read S3 object: %w", err)
+	}
+
+	return data, nil
+}
+
+// ObjectURL returns the URL of an object (public URL if configured)
+func (c *S3Client) ObjectURL(key string) string {
+	if c.publicURL != "" {
+		return fmt.Sprintf("%s/%s", c.publicURL, key)
+	}
+
+	// Build the unsigned object URL the same way the SDK addresses it
+	req, _ := c.client.GetObjectRequest(&s3.GetObjectInput{
+		Bucket: aws.String(c.bucket),
+		Key:    aws.String(key),
+	})
+	if err := req.Build(); err != nil {
+		return fmt.Sprintf("https://%s.s3.%s.amazonaws.com/%s", c.bucket, c.region, key)
+	}
+
+	objectURL := url.URL{
+		Scheme: req.HTTPRequest.URL.Scheme,
+		Host:   req.HTTPRequest.URL.Host,
+		Path:   req.HTTPRequest.URL.Path,
+	}
+	return objectURL.String()
+}
+
+// PutObject uploads an object to S3 under an exact key
+func (c *S3Client) PutObject(ctx context.Context, key string, data []byte, contentType string) (*UploadResult, error) {
+	_, err := c.uploader.UploadWithContext(ctx, &s3manager.UploadInput{
+		Bucket:      aws.String(c.bucket),
+		Key:         aws.String(key),
+		Body:        bytes.NewReader(data),
+		ContentType: aws.String(contentType),
+		ACL:         aws.String("private"),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to upload to S3: %w", err)
+	}
+
+	return &UploadResult{
+		Key:       key,
+		URL:       c.ObjectURL(key),
+		SizeBytes: int64(len(data)),
+	}, nil
+}
+
+// CopyObject copies an object to another key in the bucket
+func (c *S3Client) CopyObject(ctx context.Context, srcKey, dstKey string) error {
+	_, err := c.client.CopyObjectWithContext(ctx, &s3.CopyObjectInput{
+		Bucket:     aws.String(c.bucket),
+		Key:        aws.String(dstKey),
+		CopySource: aws.String(url.PathEscape(c.bucket) + "/" + (&url.URL{Path: srcKey}).EscapedPath()),
+		ACL:        aws.String("private"),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to copy S3 object: %w", err)
+	}
+
+	return nil
+}
+
+// ListObjects lists all objects below a key prefix
+func (c *S3Client) ListObjects(ctx context.Context, prefix string) ([]ObjectInfo, error) {
+	var objects []ObjectInfo
+
+	err := c.client.ListObjectsV2PagesWithContext(ctx, &s3.ListObjectsV2Input{
+		Bucket: aws.String(c.bucket),
+		Prefix: aws.String(prefix),
+	}, func(page *s3.ListObjectsV2Output, lastPage bool) bool {
+		for _, obj := range page.Contents {
+			objects = append(objects, ObjectInfo{
+				Key:          aws.StringValue(obj.Key),
+				SizeBytes:    aws.Int64Value(obj.Size),
+				LastModified: aws.TimeValue(obj.LastModified),
+			})
+		}
+		return true
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list S3 objects: %w", err)
+	}
+
+	return objects, nil
+}
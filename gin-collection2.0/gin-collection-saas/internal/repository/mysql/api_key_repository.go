@@ -0,0 +1,209 @@
+package mysql
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+
+	"github.com/yourusername/gin-collection-saas/internal/domain/errors"
+	"github.com/yourusername/gin-collection-saas/internal/domain/models"
+)
+
+// APIKeyRepository implements the API key repository interface
+type APIKeyRepository struct {
+	db *sql.DB
+}
+
+// NewAPIKeyRepository creates a new API key repository
+func NewAPIKeyRepository(db *sql.DB) *APIKeyRepository {
+	return &APIKeyRepository{db: db}
+}
+
+const apiKeyColumns = `id, uuid, tenant_id, user_id, name, key_prefix, key_hash, scopes, rate_limit,
+		       expires_at, last_used_at, last_used_ip, created_at, revoked_at`
+
+// Create creates a new API key
+func (r *APIKeyRepository) Create(ctx context.Context, key *models.APIKey) error {
+	scopes, err := json.Marshal(key.Scopes)
+	if err != nil {
+		return fmt.Errorf("failed to encode scopes: %w", err)
+	}
+
+	query := `
+		INSERT INTO api_keys (uuid, tenant_id, user_id, name, key_prefix, key_hash, scopes, rate_limit, expires_at, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`
+
+	result, err := r.db.ExecContext(ctx, query,
+		key.UUID,
+		key.TenantID,
+		key.UserID,
+		key.Name,
+		key.Prefix,
+		key.KeyHash,
+		scopes,
+		key.RateLimit,
+		key.ExpiresAt,
+		key.CreatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to create API key: %w", err)
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return fmt.Errorf("failed to get API key ID: %w", err)
+	}
+	key.ID = id
+
+	return nil
+}
+
+// GetByHash retrieves an API key by the SHA-256 hash of the key
+func (r *APIKeyRepository) GetByHash(ctx context.Context, keyHash string) (*models.APIKey, error) {
+	query := `SELECT ` + apiKeyColumns + ` FROM api_keys WHERE key_hash = ?`
+
+	key, err := scanAPIKey(r.db.QueryRowContext(ctx, query, keyHash))
+	if err == sql.ErrNoRows {
+		return nil, errors.ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get API key: %w", err)
+	}
+
+	return key, nil
+}
+
+// GetByUUID retrieves an API key by its public ID
+func (r *APIKeyRepository) GetByUUID(ctx context.Context, uuid string) (*models.APIKey, error) {
+	query := `SELECT ` + apiKeyColumns + ` FROM api_keys WHERE uuid = ?`
+
+	key, err := scanAPIKey(r.db.QueryRowContext(ctx, query, uuid))
+	if err == sql.ErrNoRows {
+		return nil, errors.ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get API key: %w", err)
+	}
+
+	return key, nil
+}
+
+// ListByUser lists the keys of a user that are not revoked, expired ones included
+func (r *APIKeyRepository) ListByUser(ctx context.Context, userID int64) ([]*models.APIKey, error) {
+	query := `
+		SELECT ` + apiKeyColumns + `
+		FROM api_keys
+		WHERE user_id = ? AND revoked_at IS NULL
+		ORDER BY created_at DESC
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list API keys: %w", err)
+	}
+	defer rows.Close()
+
+	var keys []*models.APIKey
+	for rows.Next() {
+		key, err := scanAPIKey(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan API key: %w", err)
+		}
+		keys = append(keys, key)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate API keys: %w", err)
+	}
+
+	return keys, nil
+}
+
+// CountActiveByUser counts the keys of a user that are neither revoked nor expired
+func (r *APIKeyRepository) CountActiveByUser(ctx context.Context, userID int64) (int, error) {
+	query := `
+		SELECT COUNT(*)
+		FROM api_keys
+		WHERE user_id = ? AND revoked_at IS NULL AND (expires_at IS NULL OR expires_at > NOW())
+	`
+
+	var count int
+	if err := r.db.QueryRowContext(ctx, query, userID).Scan(&count); err != nil {
+		return 0, fmt.Errorf("failed to count API keys: %w", err)
+	}
+
+	return count, nil
+}
+
+// Revoke revokes an API key
+func (r *APIKeyRepository) Revoke(ctx context.Context, id int64) error {
+	query := `UPDATE api_keys SET revoked_at = NOW() WHERE id = ? AND revoked_at IS NULL`
+
+	if _, err := r.db.ExecContext(ctx, query, id); err != nil {
+		return fmt.Errorf("failed to revoke API key: %w", err)
+	}
+
+	return nil
+}
+
+// TouchLastUsed records when and from where a key was last used
+func (r *APIKeyRepository) TouchLastUsed(ctx context.Context, id int64, ipAddress string) error {
+	query := `UPDATE api_keys SET last_used_at = NOW(), last_used_ip = ? WHERE id = ?`
+
+	if _, err := r.db.ExecContext(ctx, query, ipAddress, id); err != nil {
+		return fmt.Errorf("failed to update API key last used: %w", err)
+	}
+
+	return nil
+}
+
+func scanAPIKey(row rowScanner) (*models.APIKey, error) {
+	key := &models.APIKey{}
+	var scopes []byte
+	var rateLimit sql.NullInt64
+	var expiresAt, lastUsedAt, revokedAt sql.NullTime
+	var lastUsedIP sql.NullString
+
+	err := row.Scan(
+		&key.ID,
+		&key.UUID,
+		&key.TenantID,
+		&key.UserID,
+		&key.Name,
+		&key.Prefix,
+		&key.KeyHash,
+		&scopes,
+		&rateLimit,
+		&expiresAt,
+		&lastUsedAt,
+		&lastUsedIP,
+		&key.CreatedAt,
+		&revokedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal(scopes, &key.Scopes); err != nil {
+		return nil, fmt.Errorf("failed to decode scopes: %w", err)
+	}
+	if rateLimit.Valid {
+		limit := int(rateLimit.Int64)
+		key.RateLimit = &limit
+	}
+	if expiresAt.Valid {
+		key.ExpiresAt = &expiresAt.Time
+	}
+	if lastUsedAt.Valid {
+		key.LastUsedAt = &lastUsedAt.Time
+	}
+	if lastUsedIP.Valid {
+		key.LastUsedIP = &lastUsedIP.String
+	}
+	if revokedAt.Valid {
+		key.RevokedAt = &revokedAt.Time
+	}
+
+	return key, nil
+}
@@ -0,0 +1,548 @@
+package mysql
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/yourusername/gin-collection-saas/internal/domain/errors"
+	"github.com/yourusername/gin-collection-saas/internal/domain/models"
+)
+
+// CouponRepository implements coupon data access
+type CouponRepository struct {
+	db *sql.DB
+}
+
+// NewCouponRepository creates a new coupon repository
+func NewCouponRepository(db *sql.DB) *CouponRepository {
+	return &CouponRepository{db: db}
+}
+
+const couponColumns = `id, code, description, discount_type, discount_value, currency, duration,
+	duration_months, max_redemptions, redemptions, expires_at, plan_ids, active, created_by,
+	created_at, updated_at`
+
+const redemptionColumns = `id, coupon_id, tenant_id, subscription_id, discount, status, expires_at, created_at`
+
+// Create creates a coupon, ErrConflict if the code is taken
+func (r *CouponRepository) Create(ctx context.Context, coupon *models.Coupon) error {
+	planIDs, err := encodePlanIDs(coupon.PlanIDs)
+	if err != nil {
+		return err
+	}
+
+	query := `
+		INSERT IGNORE INTO coupons (code, description, discount_type, discount_value, currency, duration,
+		                            duration_months, max_redemptions, expires_at, plan_ids, active, created_by,
+		                            created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, NOW(), NOW())
+	`
+
+	result, err := r.db.ExecContext(ctx, query,
+		coupon.Code,
+		nullString(coupon.Description),
+		coupon.DiscountType,
+		coupon.DiscountValue,
+		coupon.Currency,
+		coupon.Duration,
+		coupon.DurationMonths,
+		coupon.MaxRedemptions,
+		coupon.ExpiresAt,
+		planIDs,
+		coupon.Active,
+		coupon.CreatedBy,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to create coupon: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get affected rows: %w", err)
+	}
+	if rows == 0 {
+		return errors.ErrConflict
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return fmt.Errorf("failed to get coupon ID: %w", err)
+	}
+
+	coupon.ID = id
+	coupon.CreatedAt = time.Now()
+	coupon.UpdatedAt = coupon.CreatedAt
+
+	return nil
+}
+
+// GetByID retrieves a coupon by ID
+func (r *CouponRepository) GetByID(ctx context.Context, id int64) (*models.Coupon, error) {
+	query := `SELECT ` + couponColumns + ` FROM coupons WHERE id = ?`
+	return r.getOne(ctx, query, id)
+}
+
+// GetByCode retrieves a coupon by its normalized code
+func (r *CouponRepository) GetByCode(ctx context.Context, code string) (*models.Coupon, error) {
+	query := `SELECT ` + couponColumns + ` FROM coupons WHERE code = ?`
+	return r.getOne(ctx, query, code)
+}
+
+// List lists all coupons, newest first
+func (r *CouponRepository) List(ctx context.Context) ([]*models.Coupon, error) {
+	query := `SELECT ` + couponColumns + ` FROM coupons ORDER BY created_at DESC, id DESC`
+
+	rows, err := r.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list coupons: %w", err)
+	}
+	defer rows.Close()
+
+	coupons := []*models.Coupon{}
+	for rows.Next() {
+		coupon, err := scanCoupon(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan coupon: %w", err)
+		}
+		coupons = append(coupons, coupon)
+	}
+
+	return coupons, rows.Err()
+}
+
+// Update updates the settings of a coupon, the code and redemptions are kept
+func (r *CouponRepository) Update(ctx context.Context, coupon *models.Coupon) error {
+	planIDs, err := encodePlanIDs(coupon.PlanIDs)
+	if err != nil {
+		return err
+	}
+
+	query := `
+		UPDATE coupons
+		SET description = ?, discount_type = ?, discount_value = ?, currency = ?, duration = ?,
+		    duration_months = ?, max_redemptions = ?, expires_at = ?, plan_ids = ?, active = ?,
+		    updated_at = NOW()
+		WHERE id = ?
+	`
+
+	_, err = r.db.ExecContext(ctx, query,
+		nullString(coupon.Description),
+		coupon.DiscountType,
+		coupon.DiscountValue,
+		coupon.Currency,
+		coupon.Duration,
+		coupon.DurationMonths,
+		coupon.MaxRedemptions,
+		coupon.ExpiresAt,
+		planIDs,
+		coupon.Active,
+		coupon.ID,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to update coupon: %w", err)
+	}
+
+	coupon.UpdatedAt = time.Now()
+
+	return nil
+}
+
+// Delete deletes a coupon with its redemptions
+func (r *CouponRepository) Delete(ctx context.Context, id int64) error {
+	if _, err := r.db.ExecContext(ctx, `DELETE FROM coupons WHERE id = ?`, id); err != nil {
+		return fmt.Errorf("failed to delete coupon: %w", err)
+	}
+	return nil
+}
+
+// GetRedemption retrieves the redemption of a coupon by a tenant, pending
+// or redeemed
+func (r *CouponRepository) GetRedemption(ctx context.Context, couponID, tenantID int64) (*models.CouponRedemption, error) {
+	query := `SELECT ` + redemptionColumns + ` FROM coupon_redemptions WHERE coupon_id = ? AND tenant_id = ?`
+
+	redemption, err := scanRedemption(r.db.QueryRowContext(ctx, query, couponID, tenantID))
+	if err == sql.ErrNoRows {
+		return nil, errors.ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get coupon redemption: %w", err)
+	}
+	return redemption, nil
+}
+
+// Reserve records a pending redemption and counts it on the coupon in one
+// transaction. The coupon row stays locked meanwhile, so concurrent
+// checkouts can't exceed the redemption limit.
+func (r *CouponRepository) Reserve(ctx context.Context, redemption *models.CouponRedemption) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := lockCoupon(ctx, tx, redemption.CouponID); err != nil {
+		return err
+	}
+
+	// Expired reservations and an earlier one of the tenant free their redemption
+	if _, err := releasePending(ctx, tx, redemption.CouponID, `(expires_at <= NOW() OR tenant_id = ?)`, redemption.TenantID); err != nil {
+		return err
+	}
+
+	if err := countRedemption(ctx, tx, redemption.CouponID); err != nil {
+		return err
+	}
+
+	result, err := tx.ExecContext(ctx, `
+		INSERT IGNORE INTO coupon_redemptions (coupon_id, tenant_id, subscription_id, discount, status, expires_at, created_at)
+		VALUES (?, ?, ?, ?, 'pending', ?, NOW())
+	`, redemption.CouponID, redemption.TenantID, redemption.SubscriptionID, redemption.Discount, redemption.ExpiresAt)
+	if err != nil {
+		return fmt.Errorf("failed to reserve coupon redemption: %w", err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get affected rows: %w", err)
+	}
+	if affected == 0 {
+		return errors.ErrConflict
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return fmt.Errorf("failed to get coupon redemption ID: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	redemption.ID = id
+	redemption.Status = models.CouponRedemptionPending
+	redemption.CreatedAt = time.Now()
+
+	return nil
+}
+
+// Release deletes the pending redemption of a tenant and uncounts it
+func (r *CouponRepository) Release(ctx context.Context, couponID, tenantID int64) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := lockCoupon(ctx, tx, couponID); err != nil {
+		return err
+	}
+	if _, err := releasePending(ctx, tx, couponID, `tenant_id = ?`, tenantID); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
+// ReleaseExpired releases the pending redemptions that expired, one coupon
+// at a time
+func (r *CouponRepository) ReleaseExpired(ctx context.Context) (int, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT DISTINCT coupon_id FROM coupon_redemptions
+		WHERE status = 'pending' AND expires_at <= NOW()
+	`)
+	if err != nil {
+		return 0, fmt.Errorf("failed to list expired coupon redemptions: %w", err)
+	}
+
+	var couponIDs []int64
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return 0, fmt.Errorf("failed to scan coupon ID: %w", err)
+		}
+		couponIDs = append(couponIDs, id)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+
+	released := 0
+	for _, couponID := range couponIDs {
+		count, err := r.releaseExpired(ctx, couponID)
+		if err != nil {
+			return released, err
+		}
+		released += int(count)
+	}
+
+	return released, nil
+}
+
+// releaseExpired releases the expired pending redemptions of a coupon
+func (r *CouponRepository) releaseExpired(ctx context.Context, couponID int64) (int64, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := lockCoupon(ctx, tx, couponID); err != nil {
+		return 0, err
+	}
+	released, err := releasePending(ctx, tx, couponID, `expires_at <= NOW()`)
+	if err != nil {
+		return 0, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return released, nil
+}
+
+// Redeem confirms the pending redemption of a tenant or, without one,
+// records a redemption and counts it on the coupon in one transaction
+func (r *CouponRepository) Redeem(ctx context.Context, redemption *models.CouponRedemption) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := lockCoupon(ctx, tx, redemption.CouponID); err != nil {
+		return err
+	}
+
+	result, err := tx.ExecContext(ctx, `
+		UPDATE coupon_redemptions
+		SET status = 'redeemed', subscription_id = ?, discount = ?, expires_at = NULL
+		WHERE coupon_id = ? AND tenant_id = ? AND status = 'pending'
+	`, redemption.SubscriptionID, redemption.Discount, redemption.CouponID, redemption.TenantID)
+	if err != nil {
+		return fmt.Errorf("failed to confirm coupon redemption: %w", err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get affected rows: %w", err)
+	}
+
+	if affected > 0 {
+		query := `SELECT id, created_at FROM coupon_redemptions WHERE coupon_id = ? AND tenant_id = ?`
+		if err := tx.QueryRowContext(ctx, query, redemption.CouponID, redemption.TenantID).Scan(&redemption.ID, &redemption.CreatedAt); err != nil {
+			return fmt.Errorf("failed to get coupon redemption: %w", err)
+		}
+	} else {
+		if err := countRedemption(ctx, tx, redemption.CouponID); err != nil {
+			return err
+		}
+
+		result, err = tx.ExecContext(ctx, `
+			INSERT IGNORE INTO coupon_redemptions (coupon_id, tenant_id, subscription_id, discount, status, created_at)
+			VALUES (?, ?, ?, ?, 'redeemed', NOW())
+		`, redemption.CouponID, redemption.TenantID, redemption.SubscriptionID, redemption.Discount)
+		if err != nil {
+			return fmt.Errorf("failed to create coupon redemption: %w", err)
+		}
+
+		affected, err = result.RowsAffected()
+		if err != nil {
+			return fmt.Errorf("failed to get affected rows: %w", err)
+		}
+		if affected == 0 {
+			return errors.ErrConflict
+		}
+
+		id, err := result.LastInsertId()
+		if err != nil {
+			return fmt.Errorf("failed to get coupon redemption ID: %w", err)
+		}
+		redemption.ID = id
+		redemption.CreatedAt = time.Now()
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	redemption.Status = models.CouponRedemptionRedeemed
+	redemption.ExpiresAt = nil
+
+	return nil
+}
+
+// ListRedemptions lists the redemptions of a coupon, newest first
+func (r *CouponRepository) ListRedemptions(ctx context.Context, couponID int64) ([]*models.CouponRedemption, error) {
+	query := `SELECT ` + redemptionColumns + ` FROM coupon_redemptions WHERE coupon_id = ? ORDER BY created_at DESC, id DESC`
+
+	rows, err := r.db.QueryContext(ctx, query, couponID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list coupon redemptions: %w", err)
+	}
+	defer rows.Close()
+
+	redemptions := []*models.CouponRedemption{}
+	for rows.Next() {
+		redemption, err := scanRedemption(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan coupon redemption: %w", err)
+		}
+		redemptions = append(redemptions, redemption)
+	}
+
+	return redemptions, rows.Err()
+}
+
+// lockCoupon locks the row of a coupon until the transaction ends, so
+// redemptions of the coupon are counted one at a time
+func lockCoupon(ctx context.Context, tx *sql.Tx, couponID int64) error {
+	var id int64
+	err := tx.QueryRowContext(ctx, `SELECT id FROM coupons WHERE id = ? FOR UPDATE`, couponID).Scan(&id)
+	if err == sql.ErrNoRows {
+		return errors.ErrNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("failed to lock coupon: %w", err)
+	}
+	return nil
+}
+
+// countRedemption counts a redemption on a locked coupon, ErrLimitReached
+// if it has no redemptions left
+func countRedemption(ctx context.Context, tx *sql.Tx, couponID int64) error {
+	result, err := tx.ExecContext(ctx, `
+		UPDATE coupons
+		SET redemptions = redemptions + 1, updated_at = NOW()
+		WHERE id = ? AND (max_redemptions IS NULL OR redemptions < max_redemptions)
+	`, couponID)
+	if err != nil {
+		return fmt.Errorf("failed to count coupon redemption: %w", err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get affected rows: %w", err)
+	}
+	if affected == 0 {
+		return errors.ErrLimitReached
+	}
+	return nil
+}
+
+// releasePending deletes the pending redemptions of a locked coupon that
+// match condition and uncounts them
+func releasePending(ctx context.Context, tx *sql.Tx, couponID int64, condition string, args ...interface{}) (int64, error) {
+	query := `DELETE FROM coupon_redemptions WHERE coupon_id = ? AND status = 'pending' AND ` + condition
+	result, err := tx.ExecContext(ctx, query, append([]interface{}{couponID}, args...)...)
+	if err != nil {
+		return 0, fmt.Errorf("failed to release coupon redemptions: %w", err)
+	}
+
+	released, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get affected rows: %w", err)
+	}
+	if released == 0 {
+		return 0, nil
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		UPDATE coupons
+		SET redemptions = GREATEST(CAST(redemptions AS SIGNED) - ?, 0), updated_at = NOW()
+		WHERE id = ?
+	`, released, couponID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to uncount coupon redemptions: %w", err)
+	}
+	return released, nil
+}
+
+// getOne runs a query for a single coupon
+func (r *CouponRepository) getOne(ctx context.Context, query string, args ...interface{}) (*models.Coupon, error) {
+	coupon, err := scanCoupon(r.db.QueryRowContext(ctx, query, args...))
+	if err == sql.ErrNoRows {
+		return nil, errors.ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get coupon: %w", err)
+	}
+	return coupon, nil
+}
+
+// scanCoupon scans a single coupon row
+func scanCoupon(row rowScanner) (*models.Coupon, error) {
+	coupon := &models.Coupon{}
+	var description sql.NullString
+	var planIDs []byte
+
+	err := row.Scan(
+		&coupon.ID,
+		&coupon.Code,
+		&description,
+		&coupon.DiscountType,
+		&coupon.DiscountValue,
+		&coupon.Currency,
+		&coupon.Duration,
+		&coupon.DurationMonths,
+		&coupon.MaxRedemptions,
+		&coupon.Redemptions,
+		&coupon.ExpiresAt,
+		&planIDs,
+		&coupon.Active,
+		&coupon.CreatedBy,
+		&coupon.CreatedAt,
+		&coupon.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	coupon.Description = description.String
+	coupon.PlanIDs = []string{}
+	if len(planIDs) > 0 {
+		if err := json.Unmarshal(planIDs, &coupon.PlanIDs); err != nil {
+			return nil, fmt.Errorf("failed to decode coupon plans: %w", err)
+		}
+	}
+
+	return coupon, nil
+}
+
+// scanRedemption scans a single coupon redemption row
+func scanRedemption(row rowScanner) (*models.CouponRedemption, error) {
+	redemption := &models.CouponRedemption{}
+	err := row.Scan(
+		&redemption.ID,
+		&redemption.CouponID,
+		&redemption.TenantID,
+		&redemption.SubscriptionID,
+		&redemption.Discount,
+		&redemption.Status,
+		&redemption.ExpiresAt,
+		&redemption.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return redemption, nil
+}
+
+// encodePlanIDs encodes the plan restriction of a coupon, NULL for all plans
+func encodePlanIDs(planIDs []string) ([]byte, error) {
+	if len(planIDs) == 0 {
+		return nil, nil
+	}
+	encoded, err := json.Marshal(planIDs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode coupon plans: %w", err)
+	}
+	return encoded, nil
+}
@@ -0,0 +1,212 @@
+package mysql
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/yourusername/gin-collection-saas/internal/domain/errors"
+	"github.com/yourusername/gin-collection-saas/internal/domain/models"
+)
+
+// EmailVerificationRepository implements the email verification repository interface
+type EmailVerificationRepository struct {
+	db *sql.DB
+}
+
+// NewEmailVerificationRepository creates a new email verification repository
+func NewEmailVerificationRepository(db *sql.DB) *EmailVerificationRepository {
+	return &EmailVerificationRepository{db: db}
+}
+
+const emailVerificationColumns = `id, user_id, purpose, email, token_hash, old_token_hash, confirmed_at,
+	old_confirmed_at, expires_at, completed_at, created_at`
+
+// Create creates a new verification
+func (r *EmailVerificationRepository) Create(ctx context.Context, verification *models.EmailVerification) error {
+	query := `
+		INSERT INTO email_verifications (user_id, purpose, email, token_hash, old_token_hash, expires_at, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, NOW())
+	`
+
+	result, err := r.db.ExecContext(ctx, query,
+		verification.UserID,
+		verification.Purpose,
+		verification.Email,
+		verification.TokenHash,
+		verification.OldTokenHash,
+		verification.ExpiresAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to create email verification: %w", err)
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return fmt.Errorf("failed to get last insert id: %w", err)
+	}
+
+	verification.ID = id
+	return nil
+}
+
+// GetByTokenHash retrieves a verification by the hash of either of its tokens
+func (r *EmailVerificationRepository) GetByTokenHash(ctx context.Context, tokenHash string) (*models.EmailVerification, error) {
+	query := `SELECT ` + emailVerificationColumns + ` FROM email_verifications WHERE token_hash = ? OR old_token_hash = ?`
+
+	verification, err := scanEmailVerification(r.db.QueryRowContext(ctx, query, tokenHash, tokenHash))
+	if err == sql.ErrNoRows {
+		return nil, errors.ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get email verification: %w", err)
+	}
+
+	return verification, nil
+}
+
+// GetPending retrieves the latest uncompleted verification of a user for a purpose
+func (r *EmailVerificationRepository) GetPending(ctx context.Context, userID int64, purpose models.EmailVerificationPurpose) (*models.EmailVerification, error) {
+	query := `
+		SELECT ` + emailVerificationColumns + `
+		FROM email_verifications
+		WHERE user_id = ? AND purpose = ? AND completed_at IS NULL
+		ORDER BY created_at DESC, id DESC
+		LIMIT 1
+	`
+
+	verification, err := scanEmailVerification(r.db.QueryRowContext(ctx, query, userID, purpose))
+	if err == sql.ErrNoRows {
+		return nil, errors.ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get pending email verification: %w", err)
+	}
+
+	return verification, nil
+}
+
+// Confirm marks one address of a verification as confirmed. Confirming twice
+// is harmless, completed or expired verifications are rejected.
+func (r *EmailVerificationRepository) Confirm(ctx context.Context, id int64, old bool) error {
+	column := "confirmed_at"
+	if old {
+		column = "old_confirmed_at"
+	}
+
+	result, err := r.db.ExecContext(ctx, `
+		UPDATE email_verifications
+		SET `+column+` = COALESCE(`+column+`, NOW())
+		WHERE id = ? AND completed_at IS NULL AND expires_at > NOW()
+	`, id)
+	if err != nil {
+		return fmt.Errorf("failed to confirm email verification: %w", err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get affected rows: %w", err)
+	}
+	if affected == 0 {
+		return errors.ErrInvalidToken
+	}
+
+	return nil
+}
+
+// Complete marks a verification as completed and updates the user in one
+// transaction, so a verification can only be applied once
+func (r *EmailVerificationRepository) Complete(ctx context.Context, id int64, user *models.User) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	result, err := tx.ExecContext(ctx, `
+		UPDATE email_verifications
+		SET completed_at = NOW()
+		WHERE id = ? AND user_id = ? AND completed_at IS NULL
+	`, id, user.ID)
+	if err != nil {
+		return fmt.Errorf("failed to complete email verification: %w", err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get affected rows: %w", err)
+	}
+	if affected == 0 {
+		return errors.ErrInvalidToken
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		UPDATE users
+		SET email = ?, email_verified_at = ?, updated_at = NOW()
+		WHERE id = ?
+	`, user.Email, user.EmailVerifiedAt, user.ID)
+	if err != nil {
+		return fmt.Errorf("failed to update user email: %w", err)
+	}
+
+	// Links sent for the old address are void now
+	_, err = tx.ExecContext(ctx, `DELETE FROM email_verifications WHERE user_id = ? AND completed_at IS NULL`, user.ID)
+	if err != nil {
+		return fmt.Errorf("failed to delete other email verifications: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+// DeletePending deletes all uncompleted verifications of a user for a purpose
+func (r *EmailVerificationRepository) DeletePending(ctx context.Context, userID int64, purpose models.EmailVerificationPurpose) error {
+	query := `DELETE FROM email_verifications WHERE user_id = ? AND purpose = ? AND completed_at IS NULL`
+
+	if _, err := r.db.ExecContext(ctx, query, userID, purpose); err != nil {
+		return fmt.Errorf("failed to delete pending email verifications: %w", err)
+	}
+
+	return nil
+}
+
+// scanEmailVerification scans a single email verification row
+func scanEmailVerification(row rowScanner) (*models.EmailVerification, error) {
+	verification := &models.EmailVerification{}
+	var oldTokenHash sql.NullString
+	var confirmedAt, oldConfirmedAt, completedAt sql.NullTime
+
+	if err := row.Scan(
+		&verification.ID,
+		&verification.UserID,
+		&verification.Purpose,
+		&verification.Email,
+		&verification.TokenHash,
+		&oldTokenHash,
+		&confirmedAt,
+		&oldConfirmedAt,
+		&verification.ExpiresAt,
+		&completedAt,
+		&verification.CreatedAt,
+	); err != nil {
+		return nil, err
+	}
+
+	if oldTokenHash.Valid {
+		verification.OldTokenHash = &oldTokenHash.String
+	}
+	if confirmedAt.Valid {
+		verification.ConfirmedAt = &confirmedAt.Time
+	}
+	if oldConfirmedAt.Valid {
+		verification.OldConfirmedAt = &oldConfirmedAt.Time
+	}
+	if completedAt.Valid {
+		verification.CompletedAt = &completedAt.Time
+	}
+
+	return verification, nil
+}
@@ -362,9 +362,27 @@ func (r *GinRepository) Update(ctx context.Context, gin *models.Gin) error {
 
 // Delete deletes a gin
 func (r *GinRepository) Delete(ctx context.Context, tenantID, id int64) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to start transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	// Photos are deleted by cascade; release their storage usage in the same transaction
+	var photoBytes int64
+	var photoCount int
+	photoQuery := `
+		SELECT COALESCE(SUM(COALESCE(file_size_bytes, file_size_kb * 1024, 0)), 0), COUNT(*)
+		FROM gin_photos
+		WHERE tenant_id = ? AND gin_id = ?
+	`
+	if err := tx.QueryRowContext(ctx, photoQuery, tenantID, id).Scan(&photoBytes, &photoCount); err != nil {
+		return fmt.Errorf("failed to sum gin photos: %w", err)
+	}
+
 	query := `DELETE FROM gins WHERE tenant_id = ? AND id = ?`
 
-	result, err := r.db.ExecContext(ctx, query, tenantID, id)
+	result, err := tx.ExecContext(ctx, query, tenantID, id)
 	if err != nil {
 		return fmt.Errorf("failed to delete gin: %w", err)
 	}
@@ -378,6 +396,16 @@ func (r *GinRepository) Delete(ctx context.Context, tenantID, id int64) error {
 		return errors.ErrGinNotFound
 	}
 
+	if photoCount > 0 {
+		if err := adjustStorageUsage(ctx, tx, tenantID, -photoBytes, -photoCount); err != nil {
+			return err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
 	return nil
 }
 
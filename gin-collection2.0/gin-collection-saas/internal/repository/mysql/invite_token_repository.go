@@ -0,0 +1,284 @@
+package mysql
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/yourusername/gin-collection-saas/internal/domain/errors"
+	"github.com/yourusername/gin-collection-saas/internal/domain/models"
+)
+
+// InviteTokenRepository implements the invite token repository interface
+type InviteTokenRepository struct {
+	db *sql.DB
+}
+
+// NewInviteTokenRepository creates a new invite token repository
+func NewInviteTokenRepository(db *sql.DB) *InviteTokenRepository {
+	return &InviteTokenRepository{db: db}
+}
+
+const inviteTokenColumns = `id, tenant_id, user_id, token, email, role, invited_by, expires_at, used_at, created_at`
+
+// CreateWithUser creates an invited user together with its invitation, so
+// a failed invitation leaves no account behind
+func (r *InviteTokenRepository) CreateWithUser(ctx context.Context, user *models.User, invite *models.InviteToken) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := insertUser(ctx, tx, user); err != nil {
+		return err
+	}
+
+	invite.UserID = user.ID
+	if err := insertInviteToken(ctx, tx, invite); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+// Replace voids the pending invitations of the invite's user and stores the
+// new one, the old link only stops working once the new one exists
+func (r *InviteTokenRepository) Replace(ctx context.Context, invite *models.InviteToken) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	_, err = tx.ExecContext(ctx, `DELETE FROM invite_tokens WHERE user_id = ? AND used_at IS NULL`, invite.UserID)
+	if err != nil {
+		return fmt.Errorf("failed to delete old invites: %w", err)
+	}
+
+	if err := insertInviteToken(ctx, tx, invite); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+// insertInviteToken inserts an invitation within a transaction
+func insertInviteToken(ctx context.Context, tx *sql.Tx, invite *models.InviteToken) error {
+	query := `
+		INSERT INTO invite_tokens (tenant_id, user_id, token, email, role, invited_by, expires_at, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, NOW())
+	`
+
+	result, err := tx.ExecContext(ctx, query,
+		invite.TenantID,
+		invite.UserID,
+		invite.TokenHash,
+		invite.Email,
+		invite.Role,
+		invite.InvitedBy,
+		invite.ExpiresAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to create invite token: %w", err)
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return fmt.Errorf("failed to get last insert id: %w", err)
+	}
+
+	invite.ID = id
+	return nil
+}
+
+// GetByTokenHash retrieves an invitation by the hash of its token
+func (r *InviteTokenRepository) GetByTokenHash(ctx context.Context, tokenHash string) (*models.InviteToken, error) {
+	query := `SELECT ` + inviteTokenColumns + ` FROM invite_tokens WHERE token = ?`
+
+	invite, err := scanInviteToken(r.db.QueryRowContext(ctx, query, tokenHash))
+	if err == sql.ErrNoRows {
+		return nil, errors.ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get invite token: %w", err)
+	}
+
+	return invite, nil
+}
+
+// GetPendingByUserID retrieves the latest unaccepted invitation of a user
+func (r *InviteTokenRepository) GetPendingByUserID(ctx context.Context, tenantID, userID int64) (*models.InviteToken, error) {
+	query := `
+		SELECT ` + inviteTokenColumns + `
+		FROM invite_tokens
+		WHERE tenant_id = ? AND user_id = ? AND used_at IS NULL
+		ORDER BY created_at DESC, id DESC
+		LIMIT 1
+	`
+
+	invite, err := scanInviteToken(r.db.QueryRowContext(ctx, query, tenantID, userID))
+	if err == sql.ErrNoRows {
+		return nil, errors.ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get pending invite: %w", err)
+	}
+
+	return invite, nil
+}
+
+// ListPending lists all unaccepted invitations of a tenant, newest first
+func (r *InviteTokenRepository) ListPending(ctx context.Context, tenantID int64) ([]*models.PendingInvite, error) {
+	query := `
+		SELECT it.id, it.tenant_id, it.user_id, it.token, it.email, it.role, it.invited_by,
+		       it.expires_at, it.used_at, it.created_at, u.first_name, u.last_name
+		FROM invite_tokens it
+		INNER JOIN users u ON u.id = it.user_id
+		WHERE it.tenant_id = ? AND it.used_at IS NULL
+		ORDER BY it.created_at DESC, it.id DESC
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, tenantID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list pending invites: %w", err)
+	}
+	defer rows.Close()
+
+	invites := []*models.PendingInvite{}
+	for rows.Next() {
+		invite := &models.PendingInvite{}
+		var invitedBy sql.NullInt64
+		var usedAt sql.NullTime
+		var firstName, lastName sql.NullString
+
+		if err := rows.Scan(
+			&invite.ID,
+			&invite.TenantID,
+			&invite.UserID,
+			&invite.TokenHash,
+			&invite.Email,
+			&invite.Role,
+			&invitedBy,
+			&invite.ExpiresAt,
+			&usedAt,
+			&invite.CreatedAt,
+			&firstName,
+			&lastName,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan pending invite: %w", err)
+		}
+
+		if invitedBy.Valid {
+			invite.InvitedBy = &invitedBy.Int64
+		}
+		if usedAt.Valid {
+			invite.UsedAt = &usedAt.Time
+		}
+		if firstName.Valid {
+			invite.FirstName = &firstName.String
+		}
+		if lastName.Valid {
+			invite.LastName = &lastName.String
+		}
+		invite.Expired = invite.IsExpired()
+
+		invites = append(invites, invite)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating pending invites: %w", err)
+	}
+
+	return invites, nil
+}
+
+// Accept marks an invitation as used and activates the invited user. The
+// conditional update makes sure a token can only be redeemed once.
+func (r *InviteTokenRepository) Accept(ctx context.Context, inviteID int64, user *models.User) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	result, err := tx.ExecContext(ctx, `
+		UPDATE invite_tokens
+		SET used_at = NOW()
+		WHERE id = ? AND user_id = ? AND used_at IS NULL AND expires_at > NOW()
+	`, inviteID, user.ID)
+	if err != nil {
+		return fmt.Errorf("failed to mark invite as used: %w", err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get affected rows: %w", err)
+	}
+	if affected == 0 {
+		return errors.ErrInvalidToken
+	}
+
+	// Accepting the invitation proves ownership of the email address
+	_, err = tx.ExecContext(ctx, `
+		UPDATE users
+		SET password_hash = ?, first_name = ?, last_name = ?, is_active = TRUE,
+		    email_verified_at = COALESCE(email_verified_at, NOW()), updated_at = NOW()
+		WHERE id = ?
+	`, user.PasswordHash, user.FirstName, user.LastName, user.ID)
+	if err != nil {
+		return fmt.Errorf("failed to activate invited user: %w", err)
+	}
+
+	// Other invitations sent to the same user are void now
+	_, err = tx.ExecContext(ctx, `DELETE FROM invite_tokens WHERE user_id = ? AND used_at IS NULL`, user.ID)
+	if err != nil {
+		return fmt.Errorf("failed to delete other invites: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	user.IsActive = true
+	return nil
+}
+
+// scanInviteToken scans a single invite token row
+func scanInviteToken(row rowScanner) (*models.InviteToken, error) {
+	invite := &models.InviteToken{}
+	var invitedBy sql.NullInt64
+	var usedAt sql.NullTime
+
+	if err := row.Scan(
+		&invite.ID,
+		&invite.TenantID,
+		&invite.UserID,
+		&invite.TokenHash,
+		&invite.Email,
+		&invite.Role,
+		&invitedBy,
+		&invite.ExpiresAt,
+		&usedAt,
+		&invite.CreatedAt,
+	); err != nil {
+		return nil, err
+	}
+
+	if invitedBy.Valid {
+		invite.InvitedBy = &invitedBy.Int64
+	}
+	if usedAt.Valid {
+		invite.UsedAt = &usedAt.Time
+	}
+
+	return invite, nil
+}
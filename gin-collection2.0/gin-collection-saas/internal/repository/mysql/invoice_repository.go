@@ -0,0 +1,249 @@
+package mysql
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/yourusername/gin-collection-saas/internal/domain/errors"
+	"github.com/yourusername/gin-collection-saas/internal/domain/models"
+)
+
+// InvoiceRepository implements invoice data access
+type InvoiceRepository struct {
+	db *sql.DB
+}
+
+// NewInvoiceRepository creates a new invoice repository
+func NewInvoiceRepository(db *sql.DB) *InvoiceRepository {
+	return &InvoiceRepository{db: db}
+}
+
+const invoiceColumns = `id, tenant_id, subscription_id, number, provider, provider_payment_id, plan_id,
+	description, period_start, period_end, currency, net_amount, tax_rate, tax_amount, total_amount,
+	reverse_charge, customer, seller, issued_at, created_at`
+
+// Create creates an invoice with the next number of the year it's issued
+// in. Numbering and insert share a transaction, so numbers have no gaps.
+func (r *InvoiceRepository) Create(ctx context.Context, invoice *models.Invoice, numberPrefix string) error {
+	customer, err := encodeBillingDetails(invoice.Customer)
+	if err != nil {
+		return err
+	}
+	seller, err := encodeBillingDetails(invoice.Seller)
+	if err != nil {
+		return err
+	}
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	// The payment may have been invoiced by a concurrent delivery
+	var existing int
+	err = tx.QueryRowContext(ctx, `
+		SELECT COUNT(*) FROM invoices WHERE provider = ? AND provider_payment_id = ?
+	`, invoice.Provider, invoice.ProviderPaymentID).Scan(&existing)
+	if err != nil {
+		return fmt.Errorf("failed to check invoice of payment: %w", err)
+	}
+	if existing > 0 {
+		return errors.ErrConflict
+	}
+
+	// Locks the sequence of the year until the invoice is stored. The first
+	// invoices of a year create it without waiting on a shared lock, which
+	// would deadlock concurrent ones.
+	year := invoice.IssuedAt.Year()
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO invoice_sequences (year, last_number) VALUES (?, 1)
+		ON DUPLICATE KEY UPDATE last_number = last_number + 1
+	`, year)
+	if err != nil {
+		return fmt.Errorf("failed to update invoice sequence: %w", err)
+	}
+
+	var sequence int
+	err = tx.QueryRowContext(ctx, `SELECT last_number FROM invoice_sequences WHERE year = ? FOR UPDATE`, year).Scan(&sequence)
+	if err != nil {
+		return fmt.Errorf("failed to get invoice sequence: %w", err)
+	}
+
+	number := models.InvoiceNumber(numberPrefix, year, sequence)
+
+	query := `
+		INSERT IGNORE INTO invoices (tenant_id, subscription_id, number, provider, provider_payment_id, plan_id,
+		                             description, period_start, period_end, currency, net_amount, tax_rate,
+		                             tax_amount, total_amount, reverse_charge, customer, seller, issued_at,
+		                             created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, NOW())
+	`
+
+	result, err := tx.ExecContext(ctx, query,
+		invoice.TenantID,
+		invoice.SubscriptionID,
+		number,
+		invoice.Provider,
+		invoice.ProviderPaymentID,
+		invoice.PlanID,
+		invoice.Description,
+		invoice.PeriodStart,
+		invoice.PeriodEnd,
+		invoice.Currency,
+		invoice.NetAmount,
+		invoice.TaxRate,
+		invoice.TaxAmount,
+		invoice.TotalAmount,
+		invoice.ReverseCharge,
+		customer,
+		seller,
+		invoice.IssuedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to create invoice: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get affected rows: %w", err)
+	}
+	if rows == 0 {
+		return errors.ErrConflict
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return fmt.Errorf("failed to get invoice ID: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	invoice.ID = id
+	invoice.Number = number
+	invoice.CreatedAt = time.Now()
+
+	return nil
+}
+
+// GetByID retrieves an invoice of a tenant by ID
+func (r *InvoiceRepository) GetByID(ctx context.Context, tenantID, id int64) (*models.Invoice, error) {
+	query := `SELECT ` + invoiceColumns + ` FROM invoices WHERE id = ? AND tenant_id = ?`
+	return r.getOne(ctx, query, id, tenantID)
+}
+
+// GetByPayment retrieves the invoice of a provider payment
+func (r *InvoiceRepository) GetByPayment(ctx context.Context, provider, paymentID string) (*models.Invoice, error) {
+	query := `SELECT ` + invoiceColumns + ` FROM invoices WHERE provider = ? AND provider_payment_id = ?`
+	return r.getOne(ctx, query, provider, paymentID)
+}
+
+// List lists the invoices of a tenant, newest first, with the total number
+func (r *InvoiceRepository) List(ctx context.Context, filter *models.InvoiceFilter) ([]*models.Invoice, int, error) {
+	var total int
+	if err := r.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM invoices WHERE tenant_id = ?`, filter.TenantID).Scan(&total); err != nil {
+		return nil, 0, fmt.Errorf("failed to count invoices: %w", err)
+	}
+
+	query := `
+		SELECT ` + invoiceColumns + `
+		FROM invoices
+		WHERE tenant_id = ?
+		ORDER BY issued_at DESC, id DESC
+		LIMIT ? OFFSET ?
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, filter.TenantID, filter.Limit, filter.Offset)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to list invoices: %w", err)
+	}
+	defer rows.Close()
+
+	invoices := []*models.Invoice{}
+	for rows.Next() {
+		invoice, err := scanInvoice(rows)
+		if err != nil {
+			return nil, 0, fmt.Errorf("failed to scan invoice: %w", err)
+		}
+		invoices = append(invoices, invoice)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, fmt.Errorf("failed to iterate invoices: %w", err)
+	}
+
+	return invoices, total, nil
+}
+
+// getOne runs a query for a single invoice
+func (r *InvoiceRepository) getOne(ctx context.Context, query string, args ...interface{}) (*models.Invoice, error) {
+	invoice, err := scanInvoice(r.db.QueryRowContext(ctx, query, args...))
+	if err == sql.ErrNoRows {
+		return nil, errors.ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get invoice: %w", err)
+	}
+	return invoice, nil
+}
+
+// scanInvoice scans a single invoice row
+func scanInvoice(row rowScanner) (*models.Invoice, error) {
+	invoice := &models.Invoice{}
+	var customer, seller []byte
+
+	err := row.Scan(
+		&invoice.ID,
+		&invoice.TenantID,
+		&invoice.SubscriptionID,
+		&invoice.Number,
+		&invoice.Provider,
+		&invoice.ProviderPaymentID,
+		&invoice.PlanID,
+		&invoice.Description,
+		&invoice.PeriodStart,
+		&invoice.PeriodEnd,
+		&invoice.Currency,
+		&invoice.NetAmount,
+		&invoice.TaxRate,
+		&invoice.TaxAmount,
+		&invoice.TotalAmount,
+		&invoice.ReverseCharge,
+		&customer,
+		&seller,
+		&invoice.IssuedAt,
+		&invoice.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(customer) > 0 {
+		invoice.Customer = &models.BillingDetails{}
+		if err := json.Unmarshal(customer, invoice.Customer); err != nil {
+			return nil, fmt.Errorf("failed to decode invoice customer: %w", err)
+		}
+	}
+	invoice.Seller = &models.BillingDetails{}
+	if err := json.Unmarshal(seller, invoice.Seller); err != nil {
+		return nil, fmt.Errorf("failed to decode invoice seller: %w", err)
+	}
+
+	return invoice, nil
+}
+
+// encodeBillingDetails encodes the address of an invoice, NULL if unset
+func encodeBillingDetails(details *models.BillingDetails) ([]byte, error) {
+	if details == nil {
+		return nil, nil
+	}
+	encoded, err := json.Marshal(details)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode billing details: %w", err)
+	}
+	return encoded, nil
+}
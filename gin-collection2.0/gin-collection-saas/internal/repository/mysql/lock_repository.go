@@ -0,0 +1,52 @@
+package mysql
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+)
+
+// LockRepository implements shared locks with MySQL named locks. Names are
+// prefixed with the database, so instances of other databases on the same
+// server don't share them.
+type LockRepository struct {
+	db *sql.DB
+}
+
+// NewLockRepository creates a new lock repository
+func NewLockRepository(db *sql.DB) *LockRepository {
+	return &LockRepository{db: db}
+}
+
+// TryLock takes the named lock unless another instance holds it, without
+// waiting. Named locks belong to a connection, it is kept until the lock is
+// released. The server releases the lock if the connection breaks.
+func (r *LockRepository) TryLock(ctx context.Context, name string) (func(), bool, error) {
+	conn, err := r.db.Conn(ctx)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to get connection: %w", err)
+	}
+
+	var acquired sql.NullInt64
+	err = conn.QueryRowContext(ctx, `SELECT GET_LOCK(CONCAT(DATABASE(), '.', ?), 0)`, name).Scan(&acquired)
+	if err != nil {
+		conn.Close()
+		return nil, false, fmt.Errorf("failed to take lock %s: %w", name, err)
+	}
+	if acquired.Int64 != 1 {
+		conn.Close()
+		return nil, false, nil
+	}
+
+	release := func() {
+		// The context of the locked work may be cancelled by now
+		if _, err := conn.ExecContext(context.Background(), `DO RELEASE_LOCK(CONCAT(DATABASE(), '.', ?))`, name); err != nil {
+			// Discard the connection instead of returning it to the pool
+			// with the lock held
+			conn.Raw(func(interface{}) error { return driver.ErrBadConn })
+		}
+		conn.Close()
+	}
+	return release, true, nil
+}
@@ -0,0 +1,83 @@
+package mysql
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/yourusername/gin-collection-saas/internal/domain/models"
+)
+
+// LoginDeviceRepository implements the login device repository interface
+type LoginDeviceRepository struct {
+	db *sql.DB
+}
+
+// NewLoginDeviceRepository creates a new login device repository
+func NewLoginDeviceRepository(db *sql.DB) *LoginDeviceRepository {
+	return &LoginDeviceRepository{db: db}
+}
+
+// ListByUser lists the known devices of a user
+func (r *LoginDeviceRepository) ListByUser(ctx context.Context, userID int64) ([]*models.LoginDevice, error) {
+	query := `
+		SELECT id, user_id, device, network, ip_address, first_seen_at, last_seen_at
+		FROM login_devices
+		WHERE user_id = ?
+		ORDER BY last_seen_at DESC
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list login devices: %w", err)
+	}
+	defer rows.Close()
+
+	var devices []*models.LoginDevice
+	for rows.Next() {
+		device := &models.LoginDevice{}
+		var ipAddress sql.NullString
+		if err := rows.Scan(
+			&device.ID,
+			&device.UserID,
+			&device.Device,
+			&device.Network,
+			&ipAddress,
+			&device.FirstSeenAt,
+			&device.LastSeenAt,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan login device: %w", err)
+		}
+		if ipAddress.Valid {
+			device.IPAddress = &ipAddress.String
+		}
+		devices = append(devices, device)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate login devices: %w", err)
+	}
+
+	return devices, nil
+}
+
+// Record adds a device and network of a user or updates when it was last seen
+func (r *LoginDeviceRepository) Record(ctx context.Context, device *models.LoginDevice) error {
+	query := `
+		INSERT INTO login_devices (user_id, device, network, ip_address, first_seen_at, last_seen_at)
+		VALUES (?, ?, ?, ?, ?, ?)
+		ON DUPLICATE KEY UPDATE ip_address = VALUES(ip_address), last_seen_at = VALUES(last_seen_at)
+	`
+
+	if _, err := r.db.ExecContext(ctx, query,
+		device.UserID,
+		device.Device,
+		device.Network,
+		device.IPAddress,
+		device.FirstSeenAt,
+		device.LastSeenAt,
+	); err != nil {
+		return fmt.Errorf("failed to record login device: %w", err)
+	}
+
+	return nil
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"database/sql"
 	"fmt"
+	"strings"
 	"time"
 
 	domainErrors "github.com/yourusername/gin-collection-saas/internal/domain/errors"
@@ -20,24 +21,86 @@ func NewPhotoRepository(db *sql.DB) *PhotoRepository {
 	return &PhotoRepository{db: db}
 }
 
-// Create creates a new photo record
-func (r *PhotoRepository) Create(ctx context.Context, photo *models.GinPhoto) error {
+// Create creates a new photo record and adds its size to the tenant's storage
+// usage. The quota (nil = none) is checked in the same transaction. The first
+// photo of a gin becomes its primary photo.
+func (r *PhotoRepository) Create(ctx context.Context, photo *models.GinPhoto, quota *models.PhotoQuota) error {
+	return r.create(ctx, photo, quota, 0)
+}
+
+// CreateFromUpload creates the photo of a direct upload like Create and
+// completes its upload session in the same transaction, so a session
+// registers one photo and stays pending if the photo can't be created
+func (r *PhotoRepository) CreateFromUpload(ctx context.Context, photo *models.GinPhoto, quota *models.PhotoQuota, sessionID int64) error {
+	return r.create(ctx, photo, quota, sessionID)
+}
+
+// create inserts a photo, sessionID 0 means no upload session is completed
+func (r *PhotoRepository) create(ctx context.Context, photo *models.GinPhoto, quota *models.PhotoQuota, sessionID int64) error {
 	query := `
 		INSERT INTO gin_photos (
 			tenant_id, gin_id, photo_url, photo_type, caption,
-			is_primary, storage_key, file_size_kb, created_at
-		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, NOW())
+			is_primary, sort_order, storage_key, storage_backend, file_size_kb, file_size_bytes, created_at
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, NULLIF(?, ''), ?, ?, NOW())
 	`
 
-	result, err := r.db.ExecContext(ctx, query,
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to start transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	// Concurrent completions of a session wait for each other here, the
+	// later one finds it completed
+	if sessionID != 0 {
+		if err := completeUploadSession(ctx, tx, sessionID); err != nil {
+			return err
+		}
+	}
+
+	// Photos of a tenant are created one at a time. The storage usage is
+	// locked before the photos, like Delete does, so the photo locks of
+	// concurrent creates don't deadlock.
+	bytesUsed, err := lockStorageUsage(ctx, tx, photo.TenantID)
+	if err != nil {
+		return err
+	}
+
+	// New photos are appended to the gin's gallery
+	var photoCount int
+	sortQuery := `
+		SELECT COALESCE(MAX(sort_order) + 1, 0), COUNT(*)
+		FROM gin_photos
+		WHERE tenant_id = ? AND gin_id = ?
+		FOR UPDATE
+	`
+	if err := tx.QueryRowContext(ctx, sortQuery, photo.TenantID, photo.GinID).Scan(&photo.SortOrder, &photoCount); err != nil {
+		return fmt.Errorf("failed to get photo sort order: %w", err)
+	}
+	photo.IsPrimary = photoCount == 0
+
+	if quota != nil {
+		if quota.MaxPhotosPerGin >= 0 && photoCount >= quota.MaxPhotosPerGin {
+			return domainErrors.ErrPhotoLimitReached
+		}
+		usage := &models.StorageUsage{TenantID: photo.TenantID, BytesUsed: bytesUsed}
+		if usage.WouldExceed(photoSizeBytes(photo), quota.StorageLimitMB) {
+			return domainErrors.ErrStorageLimitReached
+		}
+	}
+
+	result, err := tx.ExecContext(ctx, query,
 		photo.TenantID,
 		photo.GinID,
 		photo.PhotoURL,
 		photo.PhotoType,
 		photo.Caption,
 		photo.IsPrimary,
+		photo.SortOrder,
 		photo.StorageKey,
+		photo.StorageBackend,
 		photo.FileSizeKB,
+		photo.FileSizeBytes,
 	)
 
 	if err != nil {
@@ -49,38 +112,34 @@ func (r *PhotoRepository) Create(ctx context.Context, photo *models.GinPhoto) er
 		return fmt.Errorf("failed to get photo ID: %w", err)
 	}
 
+	if err := adjustStorageUsage(ctx, tx, photo.TenantID, photoSizeBytes(photo), 1); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
 	photo.ID = id
+	photo.Tags = []string{}
 	photo.CreatedAt = time.Now()
 
 	return nil
 }
 
+// photoColumns are the selected columns of a photo, in scanPhoto order
+const photoColumns = `id, tenant_id, gin_id, photo_url, photo_type, caption,
+		       is_primary, sort_order, storage_key, storage_backend, file_size_kb, file_size_bytes, created_at`
+
 // GetByID retrieves a photo by ID
 func (r *PhotoRepository) GetByID(ctx context.Context, tenantID, id int64) (*models.GinPhoto, error) {
 	query := `
-		SELECT id, tenant_id, gin_id, photo_url, photo_type, caption,
-		       is_primary, storage_key, file_size_kb, created_at
+		SELECT ` + photoColumns + `
 		FROM gin_photos
 		WHERE tenant_id = ? AND id = ?
 	`
 
-	photo := &models.GinPhoto{}
-	var caption, storageKey sql.NullString
-	var fileSizeKB sql.NullInt64
-
-	err := r.db.QueryRowContext(ctx, query, tenantID, id).Scan(
-		&photo.ID,
-		&photo.TenantID,
-		&photo.GinID,
-		&photo.PhotoURL,
-		&photo.PhotoType,
-		&caption,
-		&photo.IsPrimary,
-		&storageKey,
-		&fileSizeKB,
-		&photo.CreatedAt,
-	)
-
+	photo, err := scanPhoto(r.db.QueryRowContext(ctx, query, tenantID, id))
 	if err == sql.ErrNoRows {
 		return nil, domainErrors.ErrPhotoNotFound
 	}
@@ -88,78 +147,27 @@ func (r *PhotoRepository) GetByID(ctx context.Context, tenantID, id int64) (*mod
 		return nil, fmt.Errorf("failed to get photo: %w", err)
 	}
 
-	if caption.Valid {
-		photo.Caption = &caption.String
-	}
-	if storageKey.Valid {
-		photo.StorageKey = &storageKey.String
-	}
-	if fileSizeKB.Valid {
-		size := int(fileSizeKB.Int64)
-		photo.FileSizeKB = &size
+	if err := r.loadTags(ctx, tenantID, []*models.GinPhoto{photo}); err != nil {
+		return nil, err
 	}
 
 	return photo, nil
 }
 
-// GetByGinID retrieves all photos for a specific gin
+// GetByGinID retrieves all photos for a specific gin in gallery order
 func (r *PhotoRepository) GetByGinID(ctx context.Context, tenantID, ginID int64) ([]*models.GinPhoto, error) {
 	query := `
-		SELECT id, tenant_id, gin_id, photo_url, photo_type, caption,
-		       is_primary, storage_key, file_size_kb, created_at
+		SELECT ` + photoColumns + `
 		FROM gin_photos
 		WHERE tenant_id = ? AND gin_id = ?
-		ORDER BY is_primary DESC, created_at ASC
+		ORDER BY sort_order ASC, id ASC
 	`
 
-	rows, err := r.db.QueryContext(ctx, query, tenantID, ginID)
-	if err != nil {
-		return nil, fmt.Errorf("failed to get photos: %w", err)
-	}
-	defer rows.Close()
-
-	var photos []*models.GinPhoto
-
-	for rows.Next() {
-		photo := &models.GinPhoto{}
-		var caption, storageKey sql.NullString
-		var fileSizeKB sql.NullInt64
-
-		err := rows.Scan(
-			&photo.ID,
-			&photo.TenantID,
-			&photo.GinID,
-			&photo.PhotoURL,
-			&photo.PhotoType,
-			&caption,
-			&photo.IsPrimary,
-			&storageKey,
-			&fileSizeKB,
-			&photo.CreatedAt,
-		)
-
-		if err != nil {
-			return nil, fmt.Errorf("failed to scan photo: %w", err)
-		}
-
-		if caption.Valid {
-			photo.Caption = &caption.String
-		}
-		if storageKey.Valid {
-			photo.StorageKey = &storageKey.String
-		}
-		if fileSizeKB.Valid {
-			size := int(fileSizeKB.Int64)
-			photo.FileSizeKB = &size
-		}
-
-		photos = append(photos, photo)
-	}
-
-	return photos, nil
+	return r.queryPhotos(ctx, tenantID, query, tenantID, ginID)
 }
 
-// Update updates a photo record
+// Update updates a photo record and replaces its tags (unless Tags is nil)
+// in one transaction
 func (r *PhotoRepository) Update(ctx context.Context, photo *models.GinPhoto) error {
 	query := `
 		UPDATE gin_photos
@@ -167,7 +175,13 @@ func (r *PhotoRepository) Update(ctx context.Context, photo *models.GinPhoto) er
 		WHERE tenant_id = ? AND id = ?
 	`
 
-	_, err := r.db.ExecContext(ctx, query,
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to start transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	_, err = tx.ExecContext(ctx, query,
 		photo.PhotoURL,
 		photo.PhotoType,
 		photo.Caption,
@@ -180,21 +194,64 @@ func (r *PhotoRepository) Update(ctx context.Context, photo *models.GinPhoto) er
 		return fmt.Errorf("failed to update photo: %w", err)
 	}
 
+	if photo.Tags != nil {
+		if err := replaceTags(ctx, tx, photo.TenantID, photo.ID, photo.Tags); err != nil {
+			return err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
 	return nil
 }
 
-// Delete deletes a photo record
+// Delete deletes a photo record and subtracts its size from the tenant's storage usage
 func (r *PhotoRepository) Delete(ctx context.Context, tenantID, id int64) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to start transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := lockStorageUsage(ctx, tx, tenantID); err != nil {
+		return err
+	}
+
+	// Lock the row and read its size
+	var sizeBytes int64
+	sizeQuery := `
+		SELECT COALESCE(file_size_bytes, file_size_kb * 1024, 0)
+		FROM gin_photos
+		WHERE tenant_id = ? AND id = ?
+		FOR UPDATE
+	`
+	err = tx.QueryRowContext(ctx, sizeQuery, tenantID, id).Scan(&sizeBytes)
+	if err == sql.ErrNoRows {
+		return domainErrors.ErrPhotoNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get photo size: %w", err)
+	}
+
 	query := `
 		DELETE FROM gin_photos
 		WHERE tenant_id = ? AND id = ?
 	`
 
-	_, err := r.db.ExecContext(ctx, query, tenantID, id)
-	if err != nil {
+	if _, err := tx.ExecContext(ctx, query, tenantID, id); err != nil {
 		return fmt.Errorf("failed to delete photo: %w", err)
 	}
 
+	if err := adjustStorageUsage(ctx, tx, tenantID, -sizeBytes, -1); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
 	return nil
 }
 
@@ -265,6 +322,29 @@ func (r *PhotoRepository) CountByGinID(ctx context.Context, tenantID, ginID int6
 	return count, nil
 }
 
+// CountBeyondLimit counts the photos ranked beyond maxPerGin in their gin
+// (by gallery order) and the gins they belong to
+func (r *PhotoRepository) CountBeyondLimit(ctx context.Context, tenantID int64, maxPerGin int) (int, int, error) {
+	query := `
+		SELECT COUNT(*), COUNT(DISTINCT p.gin_id)
+		FROM (
+			SELECT gin_id,
+			       ROW_NUMBER() OVER (PARTITION BY gin_id ORDER BY sort_order ASC, id ASC) AS gin_position
+			FROM gin_photos
+			WHERE tenant_id = ?
+		) p
+		WHERE p.gin_position > ?
+	`
+
+	var photos, gins int
+	err := r.db.QueryRowContext(ctx, query, tenantID, maxPerGin).Scan(&photos, &gins)
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to count photos beyond limit: %w", err)
+	}
+
+	return photos, gins, nil
+}
+
 // GetTotalStorageUsage gets total storage usage for a tenant in KB
 func (r *PhotoRepository) GetTotalStorageUsage(ctx context.Context, tenantID int64) (int, error) {
 	query := `
@@ -281,3 +361,360 @@ func (r *PhotoRepository) GetTotalStorageUsage(ctx context.Context, tenantID int
 
 	return totalKB, nil
 }
+
+// ListByTenant retrieves all photos of a tenant
+func (r *PhotoRepository) ListByTenant(ctx context.Context, tenantID int64) ([]*models.GinPhoto, error) {
+	query := `
+		SELECT ` + photoColumns + `
+		FROM gin_photos
+		WHERE tenant_id = ?
+		ORDER BY id ASC
+	`
+
+	return r.queryPhotos(ctx, tenantID, query, tenantID)
+}
+
+// List retrieves the photos of a tenant across all gins, newest first
+func (r *PhotoRepository) List(ctx context.Context, filter *models.PhotoFilter) ([]*models.GinPhoto, int, error) {
+	// Positions are computed over all photos of a gin, before filtering
+	from := `
+		FROM (
+			SELECT gin_photos.*,
+			       ROW_NUMBER() OVER (PARTITION BY gin_id ORDER BY sort_order ASC, id ASC) AS gin_position
+			FROM gin_photos
+			WHERE tenant_id = ?
+		) p
+		WHERE 1 = 1
+	`
+	args := []interface{}{filter.TenantID}
+
+	if filter.MaxPerGin > 0 {
+		from += " AND p.gin_position <= ?"
+		args = append(args, filter.MaxPerGin)
+	}
+
+	if filter.GinID != nil {
+		from += " AND p.gin_id = ?"
+		args = append(args, *filter.GinID)
+	}
+
+	if filter.PhotoType != nil {
+		from += " AND p.photo_type = ?"
+		args = append(args, *filter.PhotoType)
+	}
+
+	if filter.Tag != "" {
+		from += " AND EXISTS (SELECT 1 FROM photo_tags t WHERE t.photo_id = p.id AND t.tag = ?)"
+		args = append(args, filter.Tag)
+	}
+
+	var total int
+	if err := r.db.QueryRowContext(ctx, "SELECT COUNT(*) "+from, args...).Scan(&total); err != nil {
+		return nil, 0, fmt.Errorf("failed to count photos: %w", err)
+	}
+
+	query := `SELECT ` + photoColumns + from + " ORDER BY p.created_at DESC, p.id DESC"
+
+	if filter.Limit > 0 {
+		query += " LIMIT ? OFFSET ?"
+		args = append(args, filter.Limit, filter.Offset)
+	}
+
+	photos, err := r.queryPhotos(ctx, filter.TenantID, query, args...)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return photos, total, nil
+}
+
+// UpdateSortOrder sets the order of the photos of a gin. photoIDs must
+// contain every photo of the gin exactly once.
+func (r *PhotoRepository) UpdateSortOrder(ctx context.Context, tenantID, ginID int64, photoIDs []int64) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to start transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	rows, err := tx.QueryContext(ctx, `
+		SELECT id FROM gin_photos
+		WHERE tenant_id = ? AND gin_id = ?
+		FOR UPDATE
+	`, tenantID, ginID)
+	if err != nil {
+		return fmt.Errorf("failed to get photos: %w", err)
+	}
+
+	existing := make(map[int64]bool)
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return fmt.Errorf("failed to scan photo: %w", err)
+		}
+		existing[id] = true
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("error iterating photos: %w", err)
+	}
+
+	if len(photoIDs) != len(existing) {
+		return domainErrors.ErrInvalidInput
+	}
+
+	query := `
+		UPDATE gin_photos
+		SET sort_order = ?
+		WHERE tenant_id = ? AND id = ?
+	`
+
+	for position, id := range photoIDs {
+		if !existing[id] {
+			return domainErrors.ErrInvalidInput
+		}
+		delete(existing, id) // rejects duplicates
+
+		if _, err := tx.ExecContext(ctx, query, position, tenantID, id); err != nil {
+			return fmt.Errorf("failed to update photo sort order: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+// SetTags replaces the tags of a photo
+func (r *PhotoRepository) SetTags(ctx context.Context, tenantID, photoID int64, tags []string) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to start transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := replaceTags(ctx, tx, tenantID, photoID, tags); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+// replaceTags replaces the tags of a photo inside an existing transaction
+func replaceTags(ctx context.Context, tx *sql.Tx, tenantID, photoID int64, tags []string) error {
+	if _, err := tx.ExecContext(ctx, "DELETE FROM photo_tags WHERE tenant_id = ? AND photo_id = ?", tenantID, photoID); err != nil {
+		return fmt.Errorf("failed to delete photo tags: %w", err)
+	}
+
+	if len(tags) == 0 {
+		return nil
+	}
+
+	query := "INSERT INTO photo_tags (photo_id, tenant_id, tag) VALUES " +
+		strings.TrimSuffix(strings.Repeat("(?, ?, ?), ", len(tags)), ", ")
+
+	args := make([]interface{}, 0, len(tags)*3)
+	for _, tag := range tags {
+		args = append(args, photoID, tenantID, tag)
+	}
+
+	if _, err := tx.ExecContext(ctx, query, args...); err != nil {
+		return fmt.Errorf("failed to insert photo tags: %w", err)
+	}
+
+	return nil
+}
+
+// queryPhotos runs a photo query and loads the tags of the result
+func (r *PhotoRepository) queryPhotos(ctx context.Context, tenantID int64, query string, args ...interface{}) ([]*models.GinPhoto, error) {
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get photos: %w", err)
+	}
+	defer rows.Close()
+
+	var photos []*models.GinPhoto
+
+	for rows.Next() {
+		photo, err := scanPhoto(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan photo: %w", err)
+		}
+		photos = append(photos, photo)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating photos: %w", err)
+	}
+
+	if err := r.loadTags(ctx, tenantID, photos); err != nil {
+		return nil, err
+	}
+
+	return photos, nil
+}
+
+// loadTags sets the tags of the given photos
+func (r *PhotoRepository) loadTags(ctx context.Context, tenantID int64, photos []*models.GinPhoto) error {
+	if len(photos) == 0 {
+		return nil
+	}
+
+	byID := make(map[int64]*models.GinPhoto, len(photos))
+	args := []interface{}{tenantID}
+	for _, photo := range photos {
+		photo.Tags = []string{}
+		byID[photo.ID] = photo
+		args = append(args, photo.ID)
+	}
+
+	query := `
+		SELECT photo_id, tag
+		FROM photo_tags
+		WHERE tenant_id = ? AND photo_id IN (` + strings.TrimSuffix(strings.Repeat("?, ", len(photos)), ", ") + `)
+		ORDER BY tag ASC
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return fmt.Errorf("failed to get photo tags: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var photoID int64
+		var tag string
+		if err := rows.Scan(&photoID, &tag); err != nil {
+			return fmt.Errorf("failed to scan photo tag: %w", err)
+		}
+		if photo, ok := byID[photoID]; ok {
+			photo.Tags = append(photo.Tags, tag)
+		}
+	}
+
+	return rows.Err()
+}
+
+// scanPhoto scans a single photo row selected with photoColumns
+func scanPhoto(row rowScanner) (*models.GinPhoto, error) {
+	photo := &models.GinPhoto{}
+	var caption, storageKey, storageBackend sql.NullString
+	var fileSizeKB, fileSizeBytes sql.NullInt64
+
+	err := row.Scan(
+		&photo.ID,
+		&photo.TenantID,
+		&photo.GinID,
+		&photo.PhotoURL,
+		&photo.PhotoType,
+		&caption,
+		&photo.IsPrimary,
+		&photo.SortOrder,
+		&storageKey,
+		&storageBackend,
+		&fileSizeKB,
+		&fileSizeBytes,
+		&photo.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if caption.Valid {
+		photo.Caption = &caption.String
+	}
+	if storageKey.Valid {
+		photo.StorageKey = &storageKey.String
+	}
+	photo.StorageBackend = storageBackend.String
+	if fileSizeKB.Valid {
+		size := int(fileSizeKB.Int64)
+		photo.FileSizeKB = &size
+	}
+	if fileSizeBytes.Valid {
+		photo.FileSizeBytes = &fileSizeBytes.Int64
+	}
+
+	return photo, nil
+}
+
+// UpdateStorageLocation updates the storage backend, key and URL of a photo
+func (r *PhotoRepository) UpdateStorageLocation(ctx context.Context, tenantID, id int64, backend, storageKey, photoURL string) error {
+	query := `
+		UPDATE gin_photos
+		SET storage_backend = ?, storage_key = ?, photo_url = ?
+		WHERE tenant_id = ? AND id = ?
+	`
+
+	_, err := r.db.ExecContext(ctx, query, backend, storageKey, photoURL, tenantID, id)
+	if err != nil {
+		return fmt.Errorf("failed to update photo storage location: %w", err)
+	}
+
+	return nil
+}
+
+// UpdateFileSize corrects the recorded size of a photo and the tenant's storage usage
+func (r *PhotoRepository) UpdateFileSize(ctx context.Context, tenantID, id int64, sizeBytes int64) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to start transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := lockStorageUsage(ctx, tx, tenantID); err != nil {
+		return err
+	}
+
+	var oldBytes int64
+	sizeQuery := `
+		SELECT COALESCE(file_size_bytes, file_size_kb * 1024, 0)
+		FROM gin_photos
+		WHERE tenant_id = ? AND id = ?
+		FOR UPDATE
+	`
+	err = tx.QueryRowContext(ctx, sizeQuery, tenantID, id).Scan(&oldBytes)
+	if err == sql.ErrNoRows {
+		return domainErrors.ErrPhotoNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get photo size: %w", err)
+	}
+
+	query := `
+		UPDATE gin_photos
+		SET file_size_bytes = ?, file_size_kb = ?
+		WHERE tenant_id = ? AND id = ?
+	`
+	if _, err := tx.ExecContext(ctx, query, sizeBytes, sizeBytes/1024, tenantID, id); err != nil {
+		return fmt.Errorf("failed to update photo size: %w", err)
+	}
+
+	if err := adjustStorageUsage(ctx, tx, tenantID, sizeBytes-oldBytes, 0); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+// photoSizeBytes returns the size of a photo in bytes, falling back to the KB size
+func photoSizeBytes(photo *models.GinPhoto) int64 {
+	if photo.FileSizeBytes != nil {
+		return *photo.FileSizeBytes
+	}
+	if photo.FileSizeKB != nil {
+		return int64(*photo.FileSizeKB) * 1024
+	}
+	return 0
+}
@@ -0,0 +1,233 @@
+package mysql
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	domainErrors "github.com/yourusername/gin-collection-saas/internal/domain/errors"
+	"github.com/yourusername/gin-collection-saas/internal/domain/models"
+)
+
+// PhotoUploadSessionRepository implements upload session data access
+type PhotoUploadSessionRepository struct {
+	db *sql.DB
+}
+
+// NewPhotoUploadSessionRepository creates a new upload session repository
+func NewPhotoUploadSessionRepository(db *sql.DB) *PhotoUploadSessionRepository {
+	return &PhotoUploadSessionRepository{db: db}
+}
+
+const uploadSessionColumns = `
+	id, tenant_id, gin_id, user_id, token, storage_key, filename, content_type,
+	photo_type, caption, status, expires_at, completed_at, created_at
+`
+
+// Create creates a new upload session
+func (r *PhotoUploadSessionRepository) Create(ctx context.Context, session *models.PhotoUploadSession) error {
+	query := `
+		INSERT INTO photo_upload_sessions (
+			tenant_id, gin_id, user_id, token, storage_key, filename, content_type,
+			photo_type, caption, status, expires_at, created_at
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, NOW())
+	`
+
+	if session.Status == "" {
+		session.Status = models.UploadSessionPending
+	}
+
+	result, err := r.db.ExecContext(ctx, query,
+		session.TenantID,
+		session.GinID,
+		session.UserID,
+		session.Token,
+		session.StorageKey,
+		session.Filename,
+		session.ContentType,
+		session.PhotoType,
+		session.Caption,
+		session.Status,
+		session.ExpiresAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to create upload session: %w", err)
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return fmt.Errorf("failed to get last insert id: %w", err)
+	}
+
+	session.ID = id
+	session.CreatedAt = time.Now()
+	return nil
+}
+
+// GetByToken retrieves an upload session by token within a tenant
+func (r *PhotoUploadSessionRepository) GetByToken(ctx context.Context, tenantID int64, token string) (*models.PhotoUploadSession, error) {
+	query := `SELECT ` + uploadSessionColumns + `
+		FROM photo_upload_sessions
+		WHERE tenant_id = ? AND token = ?
+	`
+
+	session, err := scanUploadSession(r.db.QueryRowContext(ctx, query, tenantID, token))
+	if err == sql.ErrNoRows {
+		return nil, domainErrors.ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get upload session: %w", err)
+	}
+
+	return session, nil
+}
+
+// GetByStorageKey retrieves the upload session of an object key
+func (r *PhotoUploadSessionRepository) GetByStorageKey(ctx context.Context, storageKey string) (*models.PhotoUploadSession, error) {
+	query := `SELECT ` + uploadSessionColumns + `
+		FROM photo_upload_sessions
+		WHERE storage_key = ?
+	`
+
+	session, err := scanUploadSession(r.db.QueryRowContext(ctx, query, storageKey))
+	if err == sql.ErrNoRows {
+		return nil, domainErrors.ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get upload session: %w", err)
+	}
+
+	return session, nil
+}
+
+// MarkExpired marks a pending session as expired
+func (r *PhotoUploadSessionRepository) MarkExpired(ctx context.Context, id int64) error {
+	query := `
+		UPDATE photo_upload_sessions
+		SET status = 'expired'
+		WHERE id = ? AND status = 'pending'
+	`
+
+	if _, err := r.db.ExecContext(ctx, query, id); err != nil {
+		return fmt.Errorf("failed to expire upload session: %w", err)
+	}
+
+	return nil
+}
+
+// ListExpiredPending lists pending sessions that expired before the given time
+func (r *PhotoUploadSessionRepository) ListExpiredPending(ctx context.Context, before time.Time, limit int) ([]*models.PhotoUploadSession, error) {
+	query := `SELECT ` + uploadSessionColumns + `
+		FROM photo_upload_sessions
+		WHERE status = 'pending' AND expires_at < ?
+		ORDER BY expires_at ASC
+		LIMIT ?
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, before, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list expired upload sessions: %w", err)
+	}
+	defer rows.Close()
+
+	var sessions []*models.PhotoUploadSession
+	for rows.Next() {
+		session, err := scanUploadSession(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan upload session: %w", err)
+		}
+		sessions = append(sessions, session)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating upload sessions: %w", err)
+	}
+
+	return sessions, nil
+}
+
+// DeleteFinishedBefore removes completed and expired sessions older than the given time
+func (r *PhotoUploadSessionRepository) DeleteFinishedBefore(ctx context.Context, before time.Time) (int64, error) {
+	query := `
+		DELETE FROM photo_upload_sessions
+		WHERE status IN ('completed', 'expired') AND created_at < ?
+	`
+
+	result, err := r.db.ExecContext(ctx, query, before)
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete upload sessions: %w", err)
+	}
+
+	return result.RowsAffected()
+}
+
+// rowScanner is satisfied by *sql.Row and *sql.Rows
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// scanUploadSession scans a single upload session row
+func scanUploadSession(row rowScanner) (*models.PhotoUploadSession, error) {
+	session := &models.PhotoUploadSession{}
+	var userID sql.NullInt64
+	var caption sql.NullString
+	var completedAt sql.NullTime
+
+	err := row.Scan(
+		&session.ID,
+		&session.TenantID,
+		&session.GinID,
+		&userID,
+		&session.Token,
+		&session.StorageKey,
+		&session.Filename,
+		&session.ContentType,
+		&session.PhotoType,
+		&caption,
+		&session.Status,
+		&session.ExpiresAt,
+		&completedAt,
+		&session.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if userID.Valid {
+		session.UserID = &userID.Int64
+	}
+	if caption.Valid {
+		session.Caption = &caption.String
+	}
+	if completedAt.Valid {
+		session.CompletedAt = &completedAt.Time
+	}
+
+	return session, nil
+}
+
+// completeUploadSession marks a pending session as completed within a
+// transaction. Returns ErrConflict if the session was no longer pending.
+func completeUploadSession(ctx context.Context, tx *sql.Tx, id int64) error {
+	query := `
+		UPDATE photo_upload_sessions
+		SET status = 'completed', completed_at = NOW()
+		WHERE id = ? AND status = 'pending'
+	`
+
+	result, err := tx.ExecContext(ctx, query, id)
+	if err != nil {
+		return fmt.Errorf("failed to complete upload session: %w", err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get affected rows: %w", err)
+	}
+	if affected != 1 {
+		return domainErrors.ErrConflict
+	}
+
+	return nil
+}
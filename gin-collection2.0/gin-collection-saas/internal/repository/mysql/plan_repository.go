@@ -0,0 +1,244 @@
+package mysql
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/yourusername/gin-collection-saas/internal/domain/errors"
+	"github.com/yourusername/gin-collection-saas/internal/domain/models"
+)
+
+// PlanRepository implements plan catalog data access
+type PlanRepository struct {
+	db *sql.DB
+}
+
+// NewPlanRepository creates a new plan repository
+func NewPlanRepository(db *sql.DB) *PlanRepository {
+	return &PlanRepository{db: db}
+}
+
+const planColumns = `p.id, p.name, p.tier, p.billing_cycle, p.sort_order, p.active, v.version, v.price_monthly, v.price_yearly,
+	v.currency, v.limits, v.provider_plans, v.created_by, v.created_at`
+
+// List lists the current versions of all plans, by sort order
+func (r *PlanRepository) List(ctx context.Context) ([]*models.SubscriptionPlan, error) {
+	query := `
+		SELECT ` + planColumns + `
+		FROM plans p
+		JOIN plan_versions v ON v.plan_id = p.id AND v.version = p.current_version
+		ORDER BY p.sort_order, p.id
+	`
+	return r.list(ctx, query)
+}
+
+// GetByID retrieves the current version of a plan
+func (r *PlanRepository) GetByID(ctx context.Context, id string) (*models.SubscriptionPlan, error) {
+	query := `
+		SELECT ` + planColumns + `
+		FROM plans p
+		JOIN plan_versions v ON v.plan_id = p.id AND v.version = p.current_version
+		WHERE p.id = ?
+	`
+
+	plan, err := scanPlan(r.db.QueryRowContext(ctx, query, id))
+	if err == sql.ErrNoRows {
+		return nil, errors.ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get plan: %w", err)
+	}
+	return plan, nil
+}
+
+// ListVersions lists all versions of a plan, oldest first
+func (r *PlanRepository) ListVersions(ctx context.Context, id string) ([]*models.SubscriptionPlan, error) {
+	query := `
+		SELECT ` + planColumns + `
+		FROM plans p
+		JOIN plan_versions v ON v.plan_id = p.id
+		WHERE p.id = ?
+		ORDER BY v.version
+	`
+	return r.list(ctx, query, id)
+}
+
+// Create creates a plan with its first version, ErrConflict if the ID is taken
+func (r *PlanRepository) Create(ctx context.Context, plan *models.SubscriptionPlan) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	result, err := tx.ExecContext(ctx, `
+		INSERT IGNORE INTO plans (id, name, tier, billing_cycle, sort_order, active, current_version, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, NOW(), NOW())
+	`, plan.ID, plan.Name, plan.Tier, nullString(string(plan.BillingCycle)), plan.SortOrder, plan.Active, plan.Version)
+	if err != nil {
+		return fmt.Errorf("failed to create plan: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get affected rows: %w", err)
+	}
+	if rows == 0 {
+		return errors.ErrConflict
+	}
+
+	if err := insertPlanVersion(ctx, tx, plan); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+// Update updates the name, sort order and availability of a plan
+func (r *PlanRepository) Update(ctx context.Context, plan *models.SubscriptionPlan) error {
+	query := `UPDATE plans SET name = ?, sort_order = ?, active = ?, updated_at = NOW() WHERE id = ?`
+
+	if _, err := r.db.ExecContext(ctx, query, plan.Name, plan.SortOrder, plan.Active, plan.ID); err != nil {
+		return fmt.Errorf("failed to update plan: %w", err)
+	}
+	return nil
+}
+
+// CreateVersion adds a version to a plan and makes it the current one,
+// ErrConflict if the version exists
+func (r *PlanRepository) CreateVersion(ctx context.Context, plan *models.SubscriptionPlan) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := insertPlanVersion(ctx, tx, plan); err != nil {
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx, `UPDATE plans SET current_version = ?, updated_at = NOW() WHERE id = ?`,
+		plan.Version, plan.ID)
+	if err != nil {
+		return fmt.Errorf("failed to update current plan version: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+// insertPlanVersion inserts the version of a plan, ErrConflict if it exists
+func insertPlanVersion(ctx context.Context, tx *sql.Tx, plan *models.SubscriptionPlan) error {
+	limits, err := json.Marshal(plan.Limits)
+	if err != nil {
+		return fmt.Errorf("failed to encode plan limits: %w", err)
+	}
+	var providerPlans []byte
+	if len(plan.ProviderPlans) > 0 {
+		if providerPlans, err = json.Marshal(plan.ProviderPlans); err != nil {
+			return fmt.Errorf("failed to encode provider plans: %w", err)
+		}
+	}
+
+	result, err := tx.ExecContext(ctx, `
+		INSERT IGNORE INTO plan_versions (plan_id, version, price_monthly, price_yearly, currency, limits,
+		                                  provider_plans, created_by, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, NOW())
+	`,
+		plan.ID,
+		plan.Version,
+		plan.PriceMonthly,
+		plan.PriceYearly,
+		plan.Currency,
+		limits,
+		providerPlans,
+		plan.CreatedBy,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to create plan version: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get affected rows: %w", err)
+	}
+	if rows == 0 {
+		return errors.ErrConflict
+	}
+
+	now := time.Now()
+	plan.CreatedAt = &now
+
+	return nil
+}
+
+// list runs a query for several plans
+func (r *PlanRepository) list(ctx context.Context, query string, args ...interface{}) ([]*models.SubscriptionPlan, error) {
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list plans: %w", err)
+	}
+	defer rows.Close()
+
+	plans := []*models.SubscriptionPlan{}
+	for rows.Next() {
+		plan, err := scanPlan(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan plan: %w", err)
+		}
+		plans = append(plans, plan)
+	}
+
+	return plans, rows.Err()
+}
+
+// scanPlan scans a single plan version row
+func scanPlan(row rowScanner) (*models.SubscriptionPlan, error) {
+	plan := &models.SubscriptionPlan{}
+	var limits, providerPlans []byte
+	var billingCycle sql.NullString
+	var createdAt time.Time
+
+	err := row.Scan(
+		&plan.ID,
+		&plan.Name,
+		&plan.Tier,
+		&billingCycle,
+		&plan.SortOrder,
+		&plan.Active,
+		&plan.Version,
+		&plan.PriceMonthly,
+		&plan.PriceYearly,
+		&plan.Currency,
+		&limits,
+		&providerPlans,
+		&plan.CreatedBy,
+		&createdAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal(limits, &plan.Limits); err != nil {
+		return nil, fmt.Errorf("failed to decode plan limits: %w", err)
+	}
+	if len(providerPlans) > 0 {
+		if err := json.Unmarshal(providerPlans, &plan.ProviderPlans); err != nil {
+			return nil, fmt.Errorf("failed to decode provider plans: %w", err)
+		}
+	}
+	plan.BillingCycle = models.BillingCycle(billingCycle.String)
+	plan.CreatedAt = &createdAt
+
+	return plan, nil
+}
@@ -344,9 +344,9 @@ func (r *PlatformAdminRepository) UpdateTenantStatus(ctx context.Context, tenant
 	return err
 }
 
-// UpdateTenantTier updates a tenant's subscription tier
+// UpdateTenantTier moves a tenant to a tier, it gets the limits of the tier
 func (r *PlatformAdminRepository) UpdateTenantTier(ctx context.Context, tenantID int64, tier models.SubscriptionTier) error {
-	query := `UPDATE tenants SET tier = ?, updated_at = ? WHERE id = ?`
+	query := `UPDATE tenants SET tier = ?, plan_limits = NULL, updated_at = ? WHERE id = ?`
 	_, err := r.db.ExecContext(ctx, query, tier, time.Now(), tenantID)
 	return err
 }
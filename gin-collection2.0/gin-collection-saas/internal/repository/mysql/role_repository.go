@@ -0,0 +1,172 @@
+package mysql
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+
+	"github.com/yourusername/gin-collection-saas/internal/domain/errors"
+	"github.com/yourusername/gin-collection-saas/internal/domain/models"
+)
+
+// RoleRepository implements the custom role repository interface
+type RoleRepository struct {
+	db *sql.DB
+}
+
+// NewRoleRepository creates a new custom role repository
+func NewRoleRepository(db *sql.DB) *RoleRepository {
+	return &RoleRepository{db: db}
+}
+
+const customRoleColumns = `cr.id, cr.tenant_id, cr.name, cr.description, cr.permissions,
+		       (SELECT COUNT(*) FROM users u WHERE u.custom_role_id = cr.id) AS user_count,
+		       cr.created_at, cr.updated_at`
+
+// Create creates a new custom role
+func (r *RoleRepository) Create(ctx context.Context, role *models.CustomRole) error {
+	permissions, err := json.Marshal(role.Permissions)
+	if err != nil {
+		return fmt.Errorf("failed to encode permissions: %w", err)
+	}
+
+	query := `
+		INSERT INTO custom_roles (tenant_id, name, description, permissions, created_at, updated_at)
+		VALUES (?, ?, ?, ?, NOW(), NOW())
+	`
+
+	result, err := r.db.ExecContext(ctx, query,
+		role.TenantID,
+		role.Name,
+		role.Description,
+		permissions,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to create custom role: %w", err)
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return fmt.Errorf("failed to get custom role ID: %w", err)
+	}
+	role.ID = id
+
+	return nil
+}
+
+// GetByID retrieves a custom role of a tenant
+func (r *RoleRepository) GetByID(ctx context.Context, tenantID, id int64) (*models.CustomRole, error) {
+	query := `SELECT ` + customRoleColumns + ` FROM custom_roles cr WHERE cr.id = ? AND cr.tenant_id = ?`
+
+	role, err := scanCustomRole(r.db.QueryRowContext(ctx, query, id, tenantID))
+	if err == sql.ErrNoRows {
+		return nil, errors.ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get custom role: %w", err)
+	}
+
+	return role, nil
+}
+
+// ListByTenant lists the custom roles of a tenant with their number of users
+func (r *RoleRepository) ListByTenant(ctx context.Context, tenantID int64) ([]*models.CustomRole, error) {
+	query := `
+		SELECT ` + customRoleColumns + `
+		FROM custom_roles cr
+		WHERE cr.tenant_id = ?
+		ORDER BY cr.name
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, tenantID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list custom roles: %w", err)
+	}
+	defer rows.Close()
+
+	var roles []*models.CustomRole
+	for rows.Next() {
+		role, err := scanCustomRole(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan custom role: %w", err)
+		}
+		roles = append(roles, role)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate custom roles: %w", err)
+	}
+
+	return roles, nil
+}
+
+// Update updates the name, description and permissions of a custom role
+func (r *RoleRepository) Update(ctx context.Context, role *models.CustomRole) error {
+	permissions, err := json.Marshal(role.Permissions)
+	if err != nil {
+		return fmt.Errorf("failed to encode permissions: %w", err)
+	}
+
+	query := `
+		UPDATE custom_roles
+		SET name = ?, description = ?, permissions = ?, updated_at = NOW()
+		WHERE id = ? AND tenant_id = ?
+	`
+
+	if _, err := r.db.ExecContext(ctx, query, role.Name, role.Description, permissions, role.ID, role.TenantID); err != nil {
+		return fmt.Errorf("failed to update custom role: %w", err)
+	}
+
+	return nil
+}
+
+// Delete deletes a custom role of a tenant
+func (r *RoleRepository) Delete(ctx context.Context, tenantID, id int64) error {
+	query := `DELETE FROM custom_roles WHERE id = ? AND tenant_id = ?`
+
+	if _, err := r.db.ExecContext(ctx, query, id, tenantID); err != nil {
+		return fmt.Errorf("failed to delete custom role: %w", err)
+	}
+
+	return nil
+}
+
+// SetUserRole assigns a custom role to a user, nil unassigns it
+func (r *RoleRepository) SetUserRole(ctx context.Context, tenantID, userID int64, roleID *int64) error {
+	query := `UPDATE users SET custom_role_id = ?, updated_at = NOW() WHERE id = ? AND tenant_id = ?`
+
+	if _, err := r.db.ExecContext(ctx, query, roleID, userID, tenantID); err != nil {
+		return fmt.Errorf("failed to set custom role of user: %w", err)
+	}
+
+	return nil
+}
+
+func scanCustomRole(row rowScanner) (*models.CustomRole, error) {
+	role := &models.CustomRole{}
+	var description sql.NullString
+	var permissions []byte
+
+	err := row.Scan(
+		&role.ID,
+		&role.TenantID,
+		&role.Name,
+		&description,
+		&permissions,
+		&role.UserCount,
+		&role.CreatedAt,
+		&role.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal(permissions, &role.Permissions); err != nil {
+		return nil, fmt.Errorf("failed to decode permissions: %w", err)
+	}
+	if description.Valid {
+		role.Description = &description.String
+	}
+
+	return role, nil
+}
@@ -0,0 +1,357 @@
+package mysql
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"github.com/yourusername/gin-collection-saas/internal/domain/errors"
+	"github.com/yourusername/gin-collection-saas/internal/domain/models"
+)
+
+// SCIMRepository implements the SCIM repository interface
+type SCIMRepository struct {
+	db *sql.DB
+}
+
+// NewSCIMRepository creates a new SCIM repository
+func NewSCIMRepository(db *sql.DB) *SCIMRepository {
+	return &SCIMRepository{db: db}
+}
+
+// GetToken retrieves the SCIM token of a tenant
+func (r *SCIMRepository) GetToken(ctx context.Context, tenantID int64) (*models.SCIMToken, error) {
+	query := `
+		SELECT id, tenant_id, token_hash, token_prefix, created_by, last_used_at, created_at
+		FROM scim_tokens
+		WHERE tenant_id = ?
+	`
+
+	return r.scanToken(r.db.QueryRowContext(ctx, query, tenantID))
+}
+
+// GetTokenByHash retrieves a SCIM token by the hash of its value
+func (r *SCIMRepository) GetTokenByHash(ctx context.Context, tokenHash string) (*models.SCIMToken, error) {
+	query := `
+		SELECT id, tenant_id, token_hash, token_prefix, created_by, last_used_at, created_at
+		FROM scim_tokens
+		WHERE token_hash = ?
+	`
+
+	return r.scanToken(r.db.QueryRowContext(ctx, query, tokenHash))
+}
+
+func (r *SCIMRepository) scanToken(row *sql.Row) (*models.SCIMToken, error) {
+	token := &models.SCIMToken{}
+	err := row.Scan(
+		&token.ID,
+		&token.TenantID,
+		&token.TokenHash,
+		&token.TokenPrefix,
+		&token.CreatedBy,
+		&token.LastUsedAt,
+		&token.CreatedAt,
+	)
+	if err == sql.ErrNoRows {
+		return nil, errors.ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get scim token: %w", err)
+	}
+
+	return token, nil
+}
+
+// SaveToken stores the SCIM token of a tenant, replacing the previous one
+func (r *SCIMRepository) SaveToken(ctx context.Context, token *models.SCIMToken) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM scim_tokens WHERE tenant_id = ?`, token.TenantID); err != nil {
+		return fmt.Errorf("failed to delete old scim token: %w", err)
+	}
+
+	query := `
+		INSERT INTO scim_tokens (tenant_id, token_hash, token_prefix, created_by, created_at)
+		VALUES (?, ?, ?, ?, NOW())
+	`
+
+	result, err := tx.ExecContext(ctx, query,
+		token.TenantID,
+		token.TokenHash,
+		token.TokenPrefix,
+		token.CreatedBy,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to create scim token: %w", err)
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return fmt.Errorf("failed to get scim token ID: %w", err)
+	}
+	token.ID = id
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+// DeleteToken removes the SCIM token of a tenant
+func (r *SCIMRepository) DeleteToken(ctx context.Context, tenantID int64) error {
+	result, err := r.db.ExecContext(ctx, `DELETE FROM scim_tokens WHERE tenant_id = ?`, tenantID)
+	if err != nil {
+		return fmt.Errorf("failed to delete scim token: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rows == 0 {
+		return errors.ErrNotFound
+	}
+
+	return nil
+}
+
+// TouchToken records the use of a SCIM token
+func (r *SCIMRepository) TouchToken(ctx context.Context, id int64) error {
+	_, err := r.db.ExecContext(ctx, `UPDATE scim_tokens SET last_used_at = NOW() WHERE id = ?`, id)
+	if err != nil {
+		return fmt.Errorf("failed to update scim token: %w", err)
+	}
+
+	return nil
+}
+
+// ListGroups lists the groups of a tenant with their members
+func (r *SCIMRepository) ListGroups(ctx context.Context, tenantID int64) ([]*models.SCIMGroup, error) {
+	query := `
+		SELECT id, tenant_id, display_name, external_id, created_at, updated_at
+		FROM scim_groups
+		WHERE tenant_id = ?
+		ORDER BY id
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, tenantID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list scim groups: %w", err)
+	}
+	defer rows.Close()
+
+	var groups []*models.SCIMGroup
+	byID := make(map[int64]*models.SCIMGroup)
+	for rows.Next() {
+		group := &models.SCIMGroup{MemberIDs: []int64{}}
+		if err := rows.Scan(
+			&group.ID,
+			&group.TenantID,
+			&group.DisplayName,
+			&group.ExternalID,
+			&group.CreatedAt,
+			&group.UpdatedAt,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan scim group: %w", err)
+		}
+		groups = append(groups, group)
+		byID[group.ID] = group
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate scim groups: %w", err)
+	}
+
+	memberQuery := `
+		SELECT m.group_id, m.user_id
+		FROM scim_group_members m
+		JOIN scim_groups g ON g.id = m.group_id
+		WHERE g.tenant_id = ?
+		ORDER BY m.group_id, m.user_id
+	`
+
+	memberRows, err := r.db.QueryContext(ctx, memberQuery, tenantID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list scim group members: %w", err)
+	}
+	defer memberRows.Close()
+
+	for memberRows.Next() {
+		var groupID, userID int64
+		if err := memberRows.Scan(&groupID, &userID); err != nil {
+			return nil, fmt.Errorf("failed to scan scim group member: %w", err)
+		}
+		if group, ok := byID[groupID]; ok {
+			group.MemberIDs = append(group.MemberIDs, userID)
+		}
+	}
+	if err := memberRows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate scim group members: %w", err)
+	}
+
+	return groups, nil
+}
+
+// GetGroup retrieves a group with its members
+func (r *SCIMRepository) GetGroup(ctx context.Context, tenantID, id int64) (*models.SCIMGroup, error) {
+	query := `
+		SELECT id, tenant_id, display_name, external_id, created_at, updated_at
+		FROM scim_groups
+		WHERE id = ? AND tenant_id = ?
+	`
+
+	group := &models.SCIMGroup{MemberIDs: []int64{}}
+	err := r.db.QueryRowContext(ctx, query, id, tenantID).Scan(
+		&group.ID,
+		&group.TenantID,
+		&group.DisplayName,
+		&group.ExternalID,
+		&group.CreatedAt,
+		&group.UpdatedAt,
+	)
+	if err == sql.ErrNoRows {
+		return nil, errors.ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get scim group: %w", err)
+	}
+
+	rows, err := r.db.QueryContext(ctx, `SELECT user_id FROM scim_group_members WHERE group_id = ? ORDER BY user_id`, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list scim group members: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var userID int64
+		if err := rows.Scan(&userID); err != nil {
+			return nil, fmt.Errorf("failed to scan scim group member: %w", err)
+		}
+		group.MemberIDs = append(group.MemberIDs, userID)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate scim group members: %w", err)
+	}
+
+	return group, nil
+}
+
+// CreateGroup creates a group with its members
+func (r *SCIMRepository) CreateGroup(ctx context.Context, group *models.SCIMGroup) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	query := `
+		INSERT INTO scim_groups (tenant_id, display_name, external_id, created_at, updated_at)
+		VALUES (?, ?, ?, NOW(), NOW())
+	`
+
+	result, err := tx.ExecContext(ctx, query, group.TenantID, group.DisplayName, group.ExternalID)
+	if err != nil {
+		return fmt.Errorf("failed to create scim group: %w", err)
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return fmt.Errorf("failed to get scim group ID: %w", err)
+	}
+
+	if err := insertGroupMembers(ctx, tx, id, group.MemberIDs); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	group.ID = id
+	return nil
+}
+
+// UpdateGroup updates name, external ID and members of a group
+func (r *SCIMRepository) UpdateGroup(ctx context.Context, group *models.SCIMGroup) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	query := `
+		UPDATE scim_groups
+		SET display_name = ?, external_id = ?, updated_at = NOW()
+		WHERE id = ? AND tenant_id = ?
+	`
+
+	result, err := tx.ExecContext(ctx, query, group.DisplayName, group.ExternalID, group.ID, group.TenantID)
+	if err != nil {
+		return fmt.Errorf("failed to update scim group: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rows == 0 {
+		return errors.ErrNotFound
+	}
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM scim_group_members WHERE group_id = ?`, group.ID); err != nil {
+		return fmt.Errorf("failed to delete scim group members: %w", err)
+	}
+	if err := insertGroupMembers(ctx, tx, group.ID, group.MemberIDs); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+// DeleteGroup deletes a group, its memberships cascade
+func (r *SCIMRepository) DeleteGroup(ctx context.Context, tenantID, id int64) error {
+	result, err := r.db.ExecContext(ctx, `DELETE FROM scim_groups WHERE id = ? AND tenant_id = ?`, id, tenantID)
+	if err != nil {
+		return fmt.Errorf("failed to delete scim group: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rows == 0 {
+		return errors.ErrNotFound
+	}
+
+	return nil
+}
+
+// insertGroupMembers adds members to a group in one statement
+func insertGroupMembers(ctx context.Context, tx *sql.Tx, groupID int64, userIDs []int64) error {
+	if len(userIDs) == 0 {
+		return nil
+	}
+
+	placeholders := make([]string, len(userIDs))
+	args := make([]interface{}, 0, len(userIDs)*2)
+	for i, userID := range userIDs {
+		placeholders[i] = "(?, ?)"
+		args = append(args, groupID, userID)
+	}
+
+	query := `INSERT INTO scim_group_members (group_id, user_id) VALUES ` + strings.Join(placeholders, ", ")
+	if _, err := tx.ExecContext(ctx, query, args...); err != nil {
+		return fmt.Errorf("failed to add scim group members: %w", err)
+	}
+
+	return nil
+}
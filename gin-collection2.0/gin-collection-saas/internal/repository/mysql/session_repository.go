@@ -0,0 +1,256 @@
+package mysql
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"github.com/yourusername/gin-collection-saas/internal/domain/errors"
+	"github.com/yourusername/gin-collection-saas/internal/domain/models"
+)
+
+// SessionRepository implements the session repository interface
+type SessionRepository struct {
+	db *sql.DB
+}
+
+// NewSessionRepository creates a new session repository
+func NewSessionRepository(db *sql.DB) *SessionRepository {
+	return &SessionRepository{db: db}
+}
+
+const sessionColumns = `id, uuid, user_id, tenant_id, refresh_token_id, device, ip_address, user_agent,
+		       created_at, last_used_at, expires_at, revoked_at, revoked_reason`
+
+// Create creates a new session
+func (r *SessionRepository) Create(ctx context.Context, session *models.Session) error {
+	query := `
+		INSERT INTO user_sessions (uuid, user_id, tenant_id, refresh_token_id, device, ip_address, user_agent,
+		                           created_at, last_used_at, expires_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`
+
+	result, err := r.db.ExecContext(ctx, query,
+		session.UUID,
+		session.UserID,
+		session.TenantID,
+		session.RefreshTokenID,
+		session.Device,
+		session.IPAddress,
+		session.UserAgent,
+		session.CreatedAt,
+		session.LastUsedAt,
+		session.ExpiresAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to create session: %w", err)
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return fmt.Errorf("failed to get session ID: %w", err)
+	}
+	session.ID = id
+
+	return nil
+}
+
+// GetByUUID retrieves a session by its public ID
+func (r *SessionRepository) GetByUUID(ctx context.Context, uuid string) (*models.Session, error) {
+	query := `SELECT ` + sessionColumns + ` FROM user_sessions WHERE uuid = ?`
+
+	session, err := scanSession(r.db.QueryRowContext(ctx, query, uuid))
+	if err == sql.ErrNoRows {
+		return nil, errors.ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get session: %w", err)
+	}
+
+	return session, nil
+}
+
+// ListActiveByUser lists the sessions of a user that are neither revoked nor expired
+func (r *SessionRepository) ListActiveByUser(ctx context.Context, userID int64) ([]*models.Session, error) {
+	query := `
+		SELECT ` + sessionColumns + `
+		FROM user_sessions
+		WHERE user_id = ? AND revoked_at IS NULL AND expires_at > NOW()
+		ORDER BY last_used_at DESC
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list sessions: %w", err)
+	}
+	defer rows.Close()
+
+	var sessions []*models.Session
+	for rows.Next() {
+		session, err := scanSession(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan session: %w", err)
+		}
+		sessions = append(sessions, session)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate sessions: %w", err)
+	}
+
+	return sessions, nil
+}
+
+// Rotate replaces the refresh token ID of an active session if it is still
+// the given one. Concurrent refreshes with the same token can't both succeed.
+func (r *SessionRepository) Rotate(ctx context.Context, session *models.Session, previousTokenID string) error {
+	query := `
+		UPDATE user_sessions
+		SET refresh_token_id = ?, ip_address = ?, user_agent = ?, last_used_at = ?, expires_at = ?
+		WHERE id = ? AND refresh_token_id = ? AND revoked_at IS NULL
+	`
+
+	result, err := r.db.ExecContext(ctx, query,
+		session.RefreshTokenID,
+		session.IPAddress,
+		session.UserAgent,
+		session.LastUsedAt,
+		session.ExpiresAt,
+		session.ID,
+		previousTokenID,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to rotate session: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rows == 0 {
+		return errors.ErrNotFound
+	}
+
+	return nil
+}
+
+// Revoke revokes a session
+func (r *SessionRepository) Revoke(ctx context.Context, id int64, reason string) error {
+	query := `
+		UPDATE user_sessions
+		SET revoked_at = NOW(), revoked_reason = ?
+		WHERE id = ? AND revoked_at IS NULL
+	`
+
+	if _, err := r.db.ExecContext(ctx, query, reason, id); err != nil {
+		return fmt.Errorf("failed to revoke session: %w", err)
+	}
+
+	return nil
+}
+
+// RevokeAllByUser revokes the active sessions of a user except one (0 for
+// none) and returns the public IDs of the revoked sessions
+func (r *SessionRepository) RevokeAllByUser(ctx context.Context, userID, exceptID int64, reason string) ([]string, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	rows, err := tx.QueryContext(ctx, `
+		SELECT id, uuid
+		FROM user_sessions
+		WHERE user_id = ? AND id != ? AND revoked_at IS NULL AND expires_at > NOW()
+		FOR UPDATE
+	`, userID, exceptID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list sessions: %w", err)
+	}
+
+	var ids []interface{}
+	var uuids []string
+	for rows.Next() {
+		var id int64
+		var uuid string
+		if err := rows.Scan(&id, &uuid); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("failed to scan session: %w", err)
+		}
+		ids = append(ids, id)
+		uuids = append(uuids, uuid)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate sessions: %w", err)
+	}
+
+	if len(ids) == 0 {
+		return nil, nil
+	}
+
+	query := `
+		UPDATE user_sessions
+		SET revoked_at = NOW(), revoked_reason = ?
+		WHERE id IN (` + strings.TrimSuffix(strings.Repeat("?, ", len(ids)), ", ") + `)
+	`
+	if _, err := tx.ExecContext(ctx, query, append([]interface{}{reason}, ids...)...); err != nil {
+		return nil, fmt.Errorf("failed to revoke sessions: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return uuids, nil
+}
+
+// DeleteExpired removes expired sessions, revoked ones are kept until they expire
+func (r *SessionRepository) DeleteExpired(ctx context.Context) (int64, error) {
+	result, err := r.db.ExecContext(ctx, `DELETE FROM user_sessions WHERE expires_at < NOW()`)
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete expired sessions: %w", err)
+	}
+
+	return result.RowsAffected()
+}
+
+func scanSession(row rowScanner) (*models.Session, error) {
+	session := &models.Session{}
+	var ipAddress, userAgent, revokedReason sql.NullString
+	var revokedAt sql.NullTime
+
+	err := row.Scan(
+		&session.ID,
+		&session.UUID,
+		&session.UserID,
+		&session.TenantID,
+		&session.RefreshTokenID,
+		&session.Device,
+		&ipAddress,
+		&userAgent,
+		&session.CreatedAt,
+		&session.LastUsedAt,
+		&session.ExpiresAt,
+		&revokedAt,
+		&revokedReason,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if ipAddress.Valid {
+		session.IPAddress = &ipAddress.String
+	}
+	if userAgent.Valid {
+		session.UserAgent = &userAgent.String
+	}
+	if revokedAt.Valid {
+		session.RevokedAt = &revokedAt.Time
+	}
+	if revokedReason.Valid {
+		session.RevokedReason = &revokedReason.String
+	}
+
+	return session, nil
+}
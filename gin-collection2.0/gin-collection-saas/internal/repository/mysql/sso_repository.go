@@ -0,0 +1,164 @@
+package mysql
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/yourusername/gin-collection-saas/internal/domain/errors"
+	"github.com/yourusername/gin-collection-saas/internal/domain/models"
+)
+
+// SSORepository implements the SSO repository interface
+type SSORepository struct {
+	db *sql.DB
+}
+
+// NewSSORepository creates a new SSO repository
+func NewSSORepository(db *sql.DB) *SSORepository {
+	return &SSORepository{db: db}
+}
+
+// CreateLoginState stores a pending login
+func (r *SSORepository) CreateLoginState(ctx context.Context, state *models.SSOLoginState) error {
+	query := `
+		INSERT INTO sso_login_states (
+			id, tenant_id, protocol, nonce, code_verifier, request_id, redirect_path, expires_at, created_at
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, NOW())
+	`
+
+	_, err := r.db.ExecContext(ctx, query,
+		state.ID,
+		state.TenantID,
+		state.Protocol,
+		nullString(state.Nonce),
+		nullString(state.CodeVerifier),
+		nullString(state.RequestID),
+		state.RedirectPath,
+		state.ExpiresAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to create sso login state: %w", err)
+	}
+
+	return nil
+}
+
+// ConsumeLoginState retrieves and deletes a pending login, so a callback can only be used once
+func (r *SSORepository) ConsumeLoginState(ctx context.Context, id string) (*models.SSOLoginState, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	query := `
+		SELECT id, tenant_id, protocol, nonce, code_verifier, request_id, redirect_path, expires_at, created_at
+		FROM sso_login_states
+		WHERE id = ?
+		FOR UPDATE
+	`
+
+	state := &models.SSOLoginState{}
+	var nonce, codeVerifier, requestID sql.NullString
+
+	err = tx.QueryRowContext(ctx, query, id).Scan(
+		&state.ID,
+		&state.TenantID,
+		&state.Protocol,
+		&nonce,
+		&codeVerifier,
+		&requestID,
+		&state.RedirectPath,
+		&state.ExpiresAt,
+		&state.CreatedAt,
+	)
+	if err == sql.ErrNoRows {
+		return nil, errors.ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get sso login state: %w", err)
+	}
+
+	state.Nonce = nonce.String
+	state.CodeVerifier = codeVerifier.String
+	state.RequestID = requestID.String
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM sso_login_states WHERE id = ?`, id); err != nil {
+		return nil, fmt.Errorf("failed to delete sso login state: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return state, nil
+}
+
+// DeleteExpiredLoginStates removes logins that were never completed
+func (r *SSORepository) DeleteExpiredLoginStates(ctx context.Context) (int64, error) {
+	result, err := r.db.ExecContext(ctx, `DELETE FROM sso_login_states WHERE expires_at < NOW()`)
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete expired sso login states: %w", err)
+	}
+
+	return result.RowsAffected()
+}
+
+// GetIdentity retrieves the link of an identity provider subject
+func (r *SSORepository) GetIdentity(ctx context.Context, tenantID int64, protocol, subject string) (*models.SSOIdentity, error) {
+	query := `
+		SELECT id, tenant_id, user_id, protocol, subject, created_at
+		FROM sso_identities
+		WHERE tenant_id = ? AND protocol = ? AND subject = ?
+	`
+
+	identity := &models.SSOIdentity{}
+	err := r.db.QueryRowContext(ctx, query, tenantID, protocol, subject).Scan(
+		&identity.ID,
+		&identity.TenantID,
+		&identity.UserID,
+		&identity.Protocol,
+		&identity.Subject,
+		&identity.CreatedAt,
+	)
+	if err == sql.ErrNoRows {
+		return nil, errors.ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get sso identity: %w", err)
+	}
+
+	return identity, nil
+}
+
+// CreateIdentity links an identity provider subject to a user
+func (r *SSORepository) CreateIdentity(ctx context.Context, identity *models.SSOIdentity) error {
+	query := `
+		INSERT INTO sso_identities (tenant_id, user_id, protocol, subject, created_at)
+		VALUES (?, ?, ?, ?, NOW())
+	`
+
+	result, err := r.db.ExecContext(ctx, query,
+		identity.TenantID,
+		identity.UserID,
+		identity.Protocol,
+		identity.Subject,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to create sso identity: %w", err)
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return fmt.Errorf("failed to get sso identity ID: %w", err)
+	}
+	identity.ID = id
+
+	return nil
+}
+
+// nullString stores empty strings as NULL
+func nullString(s string) sql.NullString {
+	return sql.NullString{String: s, Valid: s != ""}
+}
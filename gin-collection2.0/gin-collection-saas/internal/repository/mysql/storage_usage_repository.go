@@ -0,0 +1,171 @@
+package mysql
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/yourusername/gin-collection-saas/internal/domain/models"
+)
+
+// StorageUsageRepository implements per-tenant storage usage tracking
+type StorageUsageRepository struct {
+	db *sql.DB
+}
+
+// NewStorageUsageRepository creates a new storage usage repository
+func NewStorageUsageRepository(db *sql.DB) *StorageUsageRepository {
+	return &StorageUsageRepository{db: db}
+}
+
+// photoSizeExpr is the size of a photo in bytes, falling back to the legacy KB column
+const photoSizeExpr = `COALESCE(p.file_size_bytes, p.file_size_kb * 1024, 0)`
+
+// GetUsage retrieves the storage usage of a tenant
+func (r *StorageUsageRepository) GetUsage(ctx context.Context, tenantID int64) (*models.StorageUsage, error) {
+	query := `
+		SELECT tenant_id, bytes_used, photo_count, reconciled_at, updated_at
+		FROM tenant_storage_usage
+		WHERE tenant_id = ?
+	`
+
+	usage := &models.StorageUsage{}
+	var reconciledAt sql.NullTime
+
+	err := r.db.QueryRowContext(ctx, query, tenantID).Scan(
+		&usage.TenantID,
+		&usage.BytesUsed,
+		&usage.PhotoCount,
+		&reconciledAt,
+		&usage.UpdatedAt,
+	)
+	if err == sql.ErrNoRows {
+		// Nothing stored yet
+		return &models.StorageUsage{TenantID: tenantID}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get storage usage: %w", err)
+	}
+
+	if reconciledAt.Valid {
+		usage.ReconciledAt = &reconciledAt.Time
+	}
+
+	return usage, nil
+}
+
+// SetUsage overwrites the storage usage of a tenant
+func (r *StorageUsageRepository) SetUsage(ctx context.Context, tenantID int64, bytesUsed int64, photoCount int) error {
+	query := `
+		INSERT INTO tenant_storage_usage (tenant_id, bytes_used, photo_count, reconciled_at, updated_at)
+		VALUES (?, ?, ?, NOW(), NOW())
+		ON DUPLICATE KEY UPDATE
+			bytes_used = VALUES(bytes_used),
+			photo_count = VALUES(photo_count),
+			reconciled_at = NOW(),
+			updated_at = NOW()
+	`
+
+	if _, err := r.db.ExecContext(ctx, query, tenantID, bytesUsed, photoCount); err != nil {
+		return fmt.Errorf("failed to set storage usage: %w", err)
+	}
+
+	return nil
+}
+
+// ComputeFromPhotos sums the recorded photo sizes of a tenant
+func (r *StorageUsageRepository) ComputeFromPhotos(ctx context.Context, tenantID int64) (int64, int, error) {
+	query := `
+		SELECT COALESCE(SUM(` + photoSizeExpr + `), 0), COUNT(*)
+		FROM gin_photos p
+		WHERE p.tenant_id = ?
+	`
+
+	var bytesUsed int64
+	var photoCount int
+	if err := r.db.QueryRowContext(ctx, query, tenantID).Scan(&bytesUsed, &photoCount); err != nil {
+		return 0, 0, fmt.Errorf("failed to compute storage usage: %w", err)
+	}
+
+	return bytesUsed, photoCount, nil
+}
+
+// GetBreakdownByGin returns storage usage per gin, largest first
+func (r *StorageUsageRepository) GetBreakdownByGin(ctx context.Context, tenantID int64) ([]*models.GinStorageUsage, error) {
+	query := `
+		SELECT g.id, g.name, COUNT(p.id), COALESCE(SUM(` + photoSizeExpr + `), 0) AS bytes_used
+		FROM gin_photos p
+		JOIN gins g ON g.id = p.gin_id AND g.tenant_id = p.tenant_id
+		WHERE p.tenant_id = ?
+		GROUP BY g.id, g.name
+		ORDER BY bytes_used DESC
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, tenantID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get storage breakdown: %w", err)
+	}
+	defer rows.Close()
+
+	breakdown := []*models.GinStorageUsage{}
+	for rows.Next() {
+		usage := &models.GinStorageUsage{}
+		if err := rows.Scan(&usage.GinID, &usage.GinName, &usage.PhotoCount, &usage.BytesUsed); err != nil {
+			return nil, fmt.Errorf("failed to scan storage breakdown: %w", err)
+		}
+		breakdown = append(breakdown, usage)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating storage breakdown: %w", err)
+	}
+
+	return breakdown, nil
+}
+
+// lockStorageUsage locks the tenant's storage usage row until the end of the
+// transaction and returns the bytes used. Uploads of a tenant wait for each
+// other, limits checked against the result hold until commit.
+func lockStorageUsage(ctx context.Context, tx *sql.Tx, tenantID int64) (int64, error) {
+	// The row must exist to be locked
+	ensureQuery := `
+		INSERT INTO tenant_storage_usage (tenant_id, bytes_used, photo_count, updated_at)
+		VALUES (?, 0, 0, NOW())
+		ON DUPLICATE KEY UPDATE tenant_id = tenant_id
+	`
+	if _, err := tx.ExecContext(ctx, ensureQuery, tenantID); err != nil {
+		return 0, fmt.Errorf("failed to lock storage usage: %w", err)
+	}
+
+	var bytesUsed int64
+	query := `
+		SELECT bytes_used
+		FROM tenant_storage_usage
+		WHERE tenant_id = ?
+		FOR UPDATE
+	`
+	if err := tx.QueryRowContext(ctx, query, tenantID).Scan(&bytesUsed); err != nil {
+		return 0, fmt.Errorf("failed to lock storage usage: %w", err)
+	}
+
+	return bytesUsed, nil
+}
+
+// adjustStorageUsage applies a usage delta inside an existing transaction
+func adjustStorageUsage(ctx context.Context, tx *sql.Tx, tenantID int64, deltaBytes int64, deltaPhotos int) error {
+	query := `
+		INSERT INTO tenant_storage_usage (tenant_id, bytes_used, photo_count, updated_at)
+		VALUES (?, GREATEST(?, 0), GREATEST(?, 0), NOW())
+		ON DUPLICATE KEY UPDATE
+			bytes_used = GREATEST(CAST(bytes_used AS SIGNED) + ?, 0),
+			photo_count = GREATEST(CAST(photo_count AS SIGNED) + ?, 0),
+			updated_at = NOW()
+	`
+
+	_, err := tx.ExecContext(ctx, query, tenantID, deltaBytes, deltaPhotos, deltaBytes, deltaPhotos)
+	if err != nil {
+		return fmt.Errorf("failed to update storage usage: %w", err)
+	}
+
+	return nil
+}
@@ -7,6 +7,7 @@ import (
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/yourusername/gin-collection-saas/internal/domain/errors"
 	"github.com/yourusername/gin-collection-saas/internal/domain/models"
 )
 
@@ -20,30 +21,59 @@ func NewSubscriptionRepository(db *sql.DB) *SubscriptionRepository {
 	return &SubscriptionRepository{db: db}
 }
 
+const subscriptionColumns = `id, tenant_id, uuid, plan_id, plan_version, status, billing_cycle,
+	provider, provider_customer_id, provider_subscription_id, provider_plan_id, provider_checkout_id,
+	amount, currency, current_period_start, current_period_end,
+	next_billing_date, cancel_at_period_end, scheduled_plan_id, scheduled_change_at,
+	coupon_id, discount, discount_ends_at, trial_ends_at, trial_reminder_sent_at,
+	past_due_since, dunning_stage, dunning_reminders_sent, paused_at,
+	cancelled_at, created_at, updated_at`
+
 // Create creates a new subscription
 func (r *SubscriptionRepository) Create(ctx context.Context, subscription *models.Subscription) error {
 	query := `
 		INSERT INTO subscriptions (
-			tenant_id, uuid, plan_id, status, billing_cycle,
-			paypal_subscription_id, paypal_plan_id, amount, currency,
-			current_period_start, current_period_end,
-			next_billing_date, cancelled_at, created_at, updated_at
-		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, NOW(), NOW())
+			tenant_id, uuid, plan_id, plan_version, status, billing_cycle,
+			provider, provider_customer_id, provider_subscription_id, provider_plan_id, provider_checkout_id,
+			amount, currency, current_period_start, current_period_end,
+			next_billing_date, cancel_at_period_end, scheduled_plan_id, scheduled_change_at,
+			coupon_id, discount, discount_ends_at, trial_ends_at, trial_reminder_sent_at,
+			past_due_since, dunning_stage, dunning_reminders_sent, paused_at,
+			cancelled_at, created_at, updated_at
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, NOW(), NOW())
 	`
 
+	subscription.UUID = uuid.New().String()
+
 	result, err := r.db.ExecContext(ctx, query,
 		subscription.TenantID,
-		uuid.New().String(),
+		subscription.UUID,
 		subscription.PlanID,
+		subscription.PlanVersion,
 		subscription.Status,
 		subscription.BillingCycle,
-		subscription.PayPalSubscriptionID,
-		subscription.PayPalPlanID,
+		subscription.Provider,
+		subscription.ProviderCustomerID,
+		subscription.ProviderSubscriptionID,
+		subscription.ProviderPlanID,
+		subscription.ProviderCheckoutID,
 		subscription.Amount,
 		subscription.Currency,
 		subscription.CurrentPeriodStart,
 		subscription.CurrentPeriodEnd,
 		subscription.NextBillingDate,
+		subscription.CancelAtPeriodEnd,
+		subscription.ScheduledPlanID,
+		subscription.ScheduledChangeAt,
+		subscription.CouponID,
+		subscription.Discount,
+		subscription.DiscountEndsAt,
+		subscription.TrialEndsAt,
+		subscription.TrialReminderSentAt,
+		subscription.PastDueSince,
+		nullString(string(subscription.DunningStage)),
+		subscription.DunningRemindersSent,
+		subscription.PausedAt,
 		subscription.CancelledAt,
 	)
 
@@ -65,174 +95,90 @@ func (r *SubscriptionRepository) Create(ctx context.Context, subscription *model
 
 // GetByID retrieves a subscription by ID
 func (r *SubscriptionRepository) GetByID(ctx context.Context, id int64) (*models.Subscription, error) {
-	query := `
-		SELECT
-			id, tenant_id, uuid, plan_id, status, billing_cycle,
-			paypal_subscription_id, paypal_plan_id, amount, currency,
-			current_period_start, current_period_end,
-			next_billing_date, cancelled_at, created_at, updated_at
-		FROM subscriptions
-		WHERE id = ?
-	`
-
-	subscription := &models.Subscription{}
-	var cancelledAt sql.NullTime
-
-	err := r.db.QueryRowContext(ctx, query, id).Scan(
-		&subscription.ID,
-		&subscription.TenantID,
-		&subscription.UUID,
-		&subscription.PlanID,
-		&subscription.Status,
-		&subscription.BillingCycle,
-		&subscription.PayPalSubscriptionID,
-		&subscription.PayPalPlanID,
-		&subscription.Amount,
-		&subscription.Currency,
-		&subscription.CurrentPeriodStart,
-		&subscription.CurrentPeriodEnd,
-		&subscription.NextBillingDate,
-		&cancelledAt,
-		&subscription.CreatedAt,
-		&subscription.UpdatedAt,
-	)
-
-	if err == sql.ErrNoRows {
-		return nil, fmt.Errorf("subscription not found")
-	}
-	if err != nil {
-		return nil, fmt.Errorf("failed to get subscription: %w", err)
-	}
-
-	if cancelledAt.Valid {
-		subscription.CancelledAt = &cancelledAt.Time
-	}
-
-	return subscription, nil
+	query := `SELECT ` + subscriptionColumns + ` FROM subscriptions WHERE id = ?`
+	return r.getOne(ctx, query, id)
 }
 
 // GetByTenantID retrieves the current subscription for a tenant
 func (r *SubscriptionRepository) GetByTenantID(ctx context.Context, tenantID int64) (*models.Subscription, error) {
 	query := `
-		SELECT
-			id, tenant_id, uuid, plan_id, status, billing_cycle,
-			paypal_subscription_id, paypal_plan_id, amount, currency,
-			current_period_start, current_period_end,
-			next_billing_date, cancelled_at, created_at, updated_at
+		SELECT ` + subscriptionColumns + `
 		FROM subscriptions
 		WHERE tenant_id = ?
-		ORDER BY created_at DESC
+		ORDER BY created_at DESC, id DESC
 		LIMIT 1
 	`
-
-	subscription := &models.Subscription{}
-	var cancelledAt sql.NullTime
-
-	err := r.db.QueryRowContext(ctx, query, tenantID).Scan(
-		&subscription.ID,
-		&subscription.TenantID,
-		&subscription.UUID,
-		&subscription.PlanID,
-		&subscription.Status,
-		&subscription.BillingCycle,
-		&subscription.PayPalSubscriptionID,
-		&subscription.PayPalPlanID,
-		&subscription.Amount,
-		&subscription.Currency,
-		&subscription.CurrentPeriodStart,
-		&subscription.CurrentPeriodEnd,
-		&subscription.NextBillingDate,
-		&cancelledAt,
-		&subscription.CreatedAt,
-		&subscription.UpdatedAt,
-	)
-
-	if err == sql.ErrNoRows {
-		return nil, fmt.Errorf("subscription not found")
-	}
-	if err != nil {
-		return nil, fmt.Errorf("failed to get subscription: %w", err)
-	}
-
-	if cancelledAt.Valid {
-		subscription.CancelledAt = &cancelledAt.Time
-	}
-
-	return subscription, nil
+	return r.getOne(ctx, query, tenantID)
 }
 
-// GetByPayPalSubscriptionID retrieves a subscription by PayPal subscription ID
-func (r *SubscriptionRepository) GetByPayPalSubscriptionID(ctx context.Context, paypalSubscriptionID string) (*models.Subscription, error) {
+// GetByProviderSubscriptionID retrieves a subscription by the subscription ID of its provider
+func (r *SubscriptionRepository) GetByProviderSubscriptionID(ctx context.Context, provider, subscriptionID string) (*models.Subscription, error) {
 	query := `
-		SELECT
-			id, tenant_id, uuid, plan_id, status, billing_cycle,
-			paypal_subscription_id, paypal_plan_id, amount, currency,
-			current_period_start, current_period_end,
-			next_billing_date, cancelled_at, created_at, updated_at
+		SELECT ` + subscriptionColumns + `
 		FROM subscriptions
-		WHERE paypal_subscription_id = ?
+		WHERE provider = ? AND provider_subscription_id = ?
 	`
+	return r.getOne(ctx, query, provider, subscriptionID)
+}
 
-	subscription := &models.Subscription{}
-	var cancelledAt sql.NullTime
-
-	err := r.db.QueryRowContext(ctx, query, paypalSubscriptionID).Scan(
-		&subscription.ID,
-		&subscription.TenantID,
-		&subscription.UUID,
-		&subscription.PlanID,
-		&subscription.Status,
-		&subscription.BillingCycle,
-		&subscription.PayPalSubscriptionID,
-		&subscription.PayPalPlanID,
-		&subscription.Amount,
-		&subscription.Currency,
-		&subscription.CurrentPeriodStart,
-		&subscription.CurrentPeriodEnd,
-		&subscription.NextBillingDate,
-		&cancelledAt,
-		&subscription.CreatedAt,
-		&subscription.UpdatedAt,
-	)
-
-	if err == sql.ErrNoRows {
-		return nil, fmt.Errorf("subscription not found")
-	}
-	if err != nil {
-		return nil, fmt.Errorf("failed to get subscription: %w", err)
-	}
-
-	if cancelledAt.Valid {
-		subscription.CancelledAt = &cancelledAt.Time
-	}
-
-	return subscription, nil
+// GetByProviderCheckoutID retrieves a subscription by the checkout it was started with
+func (r *SubscriptionRepository) GetByProviderCheckoutID(ctx context.Context, provider, checkoutID string) (*models.Subscription, error) {
+	query := `
+		SELECT ` + subscriptionColumns + `
+		FROM subscriptions
+		WHERE provider = ? AND provider_checkout_id = ?
+		ORDER BY id DESC
+		LIMIT 1
+	`
+	return r.getOne(ctx, query, provider, checkoutID)
 }
 
 // Update updates a subscription
 func (r *SubscriptionRepository) Update(ctx context.Context, subscription *models.Subscription) error {
 	query := `
 		UPDATE subscriptions
-		SET plan_id = ?, status = ?, billing_cycle = ?,
-		    paypal_subscription_id = ?, paypal_plan_id = ?,
+		SET plan_id = ?, plan_version = ?, status = ?, billing_cycle = ?,
+		    provider = ?, provider_customer_id = ?, provider_subscription_id = ?,
+		    provider_plan_id = ?, provider_checkout_id = ?,
 		    amount = ?, currency = ?,
 		    current_period_start = ?, current_period_end = ?,
-		    next_billing_date = ?, cancelled_at = ?,
+		    next_billing_date = ?, cancel_at_period_end = ?,
+		    scheduled_plan_id = ?, scheduled_change_at = ?,
+		    coupon_id = ?, discount = ?, discount_ends_at = ?,
+		    trial_ends_at = ?, trial_reminder_sent_at = ?,
+		    past_due_since = ?, dunning_stage = ?, dunning_reminders_sent = ?, paused_at = ?,
+		    cancelled_at = ?,
 		    updated_at = NOW()
 		WHERE id = ?
 	`
 
 	_, err := r.db.ExecContext(ctx, query,
 		subscription.PlanID,
+		subscription.PlanVersion,
 		subscription.Status,
 		subscription.BillingCycle,
-		subscription.PayPalSubscriptionID,
-		subscription.PayPalPlanID,
+		subscription.Provider,
+		subscription.ProviderCustomerID,
+		subscription.ProviderSubscriptionID,
+		subscription.ProviderPlanID,
+		subscription.ProviderCheckoutID,
 		subscription.Amount,
 		subscription.Currency,
 		subscription.CurrentPeriodStart,
 		subscription.CurrentPeriodEnd,
 		subscription.NextBillingDate,
+		subscription.CancelAtPeriodEnd,
+		subscription.ScheduledPlanID,
+		subscription.ScheduledChangeAt,
+		subscription.CouponID,
+		subscription.Discount,
+		subscription.DiscountEndsAt,
+		subscription.TrialEndsAt,
+		subscription.TrialReminderSentAt,
+		subscription.PastDueSince,
+		nullString(string(subscription.DunningStage)),
+		subscription.DunningRemindersSent,
+		subscription.PausedAt,
 		subscription.CancelledAt,
 		subscription.ID,
 	)
@@ -265,14 +211,10 @@ func (r *SubscriptionRepository) UpdateStatus(ctx context.Context, id int64, sta
 // List retrieves all subscriptions for a tenant
 func (r *SubscriptionRepository) List(ctx context.Context, tenantID int64) ([]*models.Subscription, error) {
 	query := `
-		SELECT
-			id, tenant_id, uuid, plan_id, status, billing_cycle,
-			paypal_subscription_id, paypal_plan_id, amount, currency,
-			current_period_start, current_period_end,
-			next_billing_date, cancelled_at, created_at, updated_at
+		SELECT ` + subscriptionColumns + `
 		FROM subscriptions
 		WHERE tenant_id = ?
-		ORDER BY created_at DESC
+		ORDER BY created_at DESC, id DESC
 	`
 
 	rows, err := r.db.QueryContext(ctx, query, tenantID)
@@ -284,85 +226,200 @@ func (r *SubscriptionRepository) List(ctx context.Context, tenantID int64) ([]*m
 	var subscriptions []*models.Subscription
 
 	for rows.Next() {
-		subscription := &models.Subscription{}
-		var cancelledAt sql.NullTime
-
-		err := rows.Scan(
-			&subscription.ID,
-			&subscription.TenantID,
-			&subscription.UUID,
-			&subscription.PlanID,
-			&subscription.Status,
-			&subscription.BillingCycle,
-			&subscription.PayPalSubscriptionID,
-			&subscription.PayPalPlanID,
-			&subscription.Amount,
-			&subscription.Currency,
-			&subscription.CurrentPeriodStart,
-			&subscription.CurrentPeriodEnd,
-			&subscription.NextBillingDate,
-			&cancelledAt,
-			&subscription.CreatedAt,
-			&subscription.UpdatedAt,
-		)
-
+		subscription, err := scanSubscription(rows)
 		if err != nil {
 			return nil, fmt.Errorf("failed to scan subscription: %w", err)
 		}
-
-		if cancelledAt.Valid {
-			subscription.CancelledAt = &cancelledAt.Time
-		}
-
 		subscriptions = append(subscriptions, subscription)
 	}
 
-	return subscriptions, nil
+	return subscriptions, rows.Err()
 }
 
 // GetActiveSubscription retrieves the active subscription for a tenant
 func (r *SubscriptionRepository) GetActiveSubscription(ctx context.Context, tenantID int64) (*models.Subscription, error) {
 	query := `
-		SELECT
-			id, tenant_id, uuid, plan_id, status, billing_cycle,
-			paypal_subscription_id, paypal_plan_id, amount, currency,
-			current_period_start, current_period_end,
-			next_billing_date, cancelled_at, created_at, updated_at
+		SELECT ` + subscriptionColumns + `
 		FROM subscriptions
 		WHERE tenant_id = ? AND status = 'active'
-		ORDER BY created_at DESC
+		ORDER BY created_at DESC, id DESC
 		LIMIT 1
 	`
+	return r.getOne(ctx, query, tenantID)
+}
+
+// ListScheduledChanges retrieves the subscriptions with a plan change due at the given time
+func (r *SubscriptionRepository) ListScheduledChanges(ctx context.Context, dueBefore time.Time) ([]*models.Subscription, error) {
+	query := `
+		SELECT ` + subscriptionColumns + `
+		FROM subscriptions
+		WHERE scheduled_plan_id IS NOT NULL AND scheduled_change_at <= ?
+		ORDER BY scheduled_change_at ASC, id ASC
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, dueBefore)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list scheduled plan changes: %w", err)
+	}
+	defer rows.Close()
+
+	var subscriptions []*models.Subscription
+
+	for rows.Next() {
+		subscription, err := scanSubscription(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan subscription: %w", err)
+		}
+		subscriptions = append(subscriptions, subscription)
+	}
+
+	return subscriptions, rows.Err()
+}
+
+// ListCancellationsDue retrieves the active subscriptions cancelled at the end of a period that ended at the given time
+func (r *SubscriptionRepository) ListCancellationsDue(ctx context.Context, dueBefore time.Time) ([]*models.Subscription, error) {
+	query := `
+		SELECT ` + subscriptionColumns + `
+		FROM subscriptions
+		WHERE status = 'active' AND cancel_at_period_end = TRUE
+		  AND COALESCE(current_period_end, next_billing_date) <= ?
+		ORDER BY id ASC
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, dueBefore)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list due cancellations: %w", err)
+	}
+	defer rows.Close()
+
+	var subscriptions []*models.Subscription
+
+	for rows.Next() {
+		subscription, err := scanSubscription(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan subscription: %w", err)
+		}
+		subscriptions = append(subscriptions, subscription)
+	}
+
+	return subscriptions, rows.Err()
+}
+
+// ListTrialsEndingBefore retrieves the trialing subscriptions whose trial ends before the given time
+func (r *SubscriptionRepository) ListTrialsEndingBefore(ctx context.Context, before time.Time) ([]*models.Subscription, error) {
+	query := `
+		SELECT ` + subscriptionColumns + `
+		FROM subscriptions
+		WHERE status = 'trialing' AND trial_ends_at <= ?
+		ORDER BY trial_ends_at ASC, id ASC
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, before)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list ending trials: %w", err)
+	}
+	defer rows.Close()
+
+	var subscriptions []*models.Subscription
+
+	for rows.Next() {
+		subscription, err := scanSubscription(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan subscription: %w", err)
+		}
+		subscriptions = append(subscriptions, subscription)
+	}
+
+	return subscriptions, rows.Err()
+}
+
+// ListInDunning retrieves the subscriptions whose overdue payment is being collected
+func (r *SubscriptionRepository) ListInDunning(ctx context.Context) ([]*models.Subscription, error) {
+	query := `
+		SELECT ` + subscriptionColumns + `
+		FROM subscriptions
+		WHERE dunning_stage IN ('grace', 'restricted')
+		ORDER BY past_due_since ASC, id ASC
+	`
+
+	rows, err := r.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list subscriptions in dunning: %w", err)
+	}
+	defer rows.Close()
+
+	var subscriptions []*models.Subscription
+
+	for rows.Next() {
+		subscription, err := scanSubscription(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan subscription: %w", err)
+		}
+		subscriptions = append(subscriptions, subscription)
+	}
+
+	return subscriptions, rows.Err()
+}
+
+// getOne runs a query for a single subscription
+func (r *SubscriptionRepository) getOne(ctx context.Context, query string, args ...interface{}) (*models.Subscription, error) {
+	subscription, err := scanSubscription(r.db.QueryRowContext(ctx, query, args...))
+	if err == sql.ErrNoRows {
+		return nil, errors.ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get subscription: %w", err)
+	}
+	return subscription, nil
+}
 
+// scanSubscription scans a single subscription row
+func scanSubscription(row rowScanner) (*models.Subscription, error) {
 	subscription := &models.Subscription{}
+	var subscriptionUUID sql.NullString
 	var cancelledAt sql.NullTime
+	var dunningStage sql.NullString
 
-	err := r.db.QueryRowContext(ctx, query, tenantID).Scan(
+	err := row.Scan(
 		&subscription.ID,
 		&subscription.TenantID,
-		&subscription.UUID,
+		&subscriptionUUID,
 		&subscription.PlanID,
+		&subscription.PlanVersion,
 		&subscription.Status,
 		&subscription.BillingCycle,
-		&subscription.PayPalSubscriptionID,
-		&subscription.PayPalPlanID,
+		&subscription.Provider,
+		&subscription.ProviderCustomerID,
+		&subscription.ProviderSubscriptionID,
+		&subscription.ProviderPlanID,
+		&subscription.ProviderCheckoutID,
 		&subscription.Amount,
 		&subscription.Currency,
 		&subscription.CurrentPeriodStart,
 		&subscription.CurrentPeriodEnd,
 		&subscription.NextBillingDate,
+		&subscription.CancelAtPeriodEnd,
+		&subscription.ScheduledPlanID,
+		&subscription.ScheduledChangeAt,
+		&subscription.CouponID,
+		&subscription.Discount,
+		&subscription.DiscountEndsAt,
+		&subscription.TrialEndsAt,
+		&subscription.TrialReminderSentAt,
+		&subscription.PastDueSince,
+		&dunningStage,
+		&subscription.DunningRemindersSent,
+		&subscription.PausedAt,
 		&cancelledAt,
 		&subscription.CreatedAt,
 		&subscription.UpdatedAt,
 	)
-
-	if err == sql.ErrNoRows {
-		return nil, fmt.Errorf("no active subscription found")
-	}
 	if err != nil {
-		return nil, fmt.Errorf("failed to get active subscription: %w", err)
+		return nil, err
 	}
 
+	subscription.UUID = subscriptionUUID.String
+	subscription.DunningStage = models.DunningStage(dunningStage.String)
 	if cancelledAt.Valid {
 		subscription.CancelledAt = &cancelledAt.Time
 	}
@@ -3,6 +3,7 @@ package mysql
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
 	"fmt"
 
 	"github.com/google/uuid"
@@ -52,12 +53,14 @@ func (r *TenantRepository) Create(ctx context.Context, tenant *models.Tenant) er
 func (r *TenantRepository) GetByID(ctx context.Context, id int64) (*models.Tenant, error) {
 	query := `
 		SELECT id, uuid, name, subdomain, tier, is_enterprise, db_connection_string,
-		       status, created_at, updated_at
+		       status, require_two_factor, billing_restricted, plan_limits,
+		       limit_overrides, created_at, updated_at
 		FROM tenants
 		WHERE id = ?
 	`
 
 	tenant := &models.Tenant{}
+	var planLimits, limitOverrides []byte
 	err := r.db.QueryRowContext(ctx, query, id).Scan(
 		&tenant.ID,
 		&tenant.UUID,
@@ -67,6 +70,10 @@ func (r *TenantRepository) GetByID(ctx context.Context, id int64) (*models.Tenan
 		&tenant.IsEnterprise,
 		&tenant.DBConnectionString,
 		&tenant.Status,
+		&tenant.RequireTwoFactor,
+		&tenant.BillingRestricted,
+		&planLimits,
+		&limitOverrides,
 		&tenant.CreatedAt,
 		&tenant.UpdatedAt,
 	)
@@ -77,6 +84,12 @@ func (r *TenantRepository) GetByID(ctx context.Context, id int64) (*models.Tenan
 	if err != nil {
 		return nil, fmt.Errorf("failed to get tenant: %w", err)
 	}
+	if tenant.PlanLimits, err = decodePlanLimits(planLimits); err != nil {
+		return nil, err
+	}
+	if tenant.LimitOverrides, err = decodeLimitOverrides(limitOverrides); err != nil {
+		return nil, err
+	}
 
 	return tenant, nil
 }
@@ -85,12 +98,14 @@ func (r *TenantRepository) GetByID(ctx context.Context, id int64) (*models.Tenan
 func (r *TenantRepository) GetBySubdomain(ctx context.Context, subdomain string) (*models.Tenant, error) {
 	query := `
 		SELECT id, uuid, name, subdomain, tier, is_enterprise, db_connection_string,
-		       status, created_at, updated_at
+		       status, require_two_factor, billing_restricted, plan_limits,
+		       limit_overrides, created_at, updated_at
 		FROM tenants
 		WHERE subdomain = ?
 	`
 
 	tenant := &models.Tenant{}
+	var planLimits, limitOverrides []byte
 	err := r.db.QueryRowContext(ctx, query, subdomain).Scan(
 		&tenant.ID,
 		&tenant.UUID,
@@ -100,6 +115,10 @@ func (r *TenantRepository) GetBySubdomain(ctx context.Context, subdomain string)
 		&tenant.IsEnterprise,
 		&tenant.DBConnectionString,
 		&tenant.Status,
+		&tenant.RequireTwoFactor,
+		&tenant.BillingRestricted,
+		&planLimits,
+		&limitOverrides,
 		&tenant.CreatedAt,
 		&tenant.UpdatedAt,
 	)
@@ -110,6 +129,12 @@ func (r *TenantRepository) GetBySubdomain(ctx context.Context, subdomain string)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get tenant by subdomain: %w", err)
 	}
+	if tenant.PlanLimits, err = decodePlanLimits(planLimits); err != nil {
+		return nil, err
+	}
+	if tenant.LimitOverrides, err = decodeLimitOverrides(limitOverrides); err != nil {
+		return nil, err
+	}
 
 	return tenant, nil
 }
@@ -118,12 +143,14 @@ func (r *TenantRepository) GetBySubdomain(ctx context.Context, subdomain string)
 func (r *TenantRepository) GetByUUID(ctx context.Context, uuid string) (*models.Tenant, error) {
 	query := `
 		SELECT id, uuid, name, subdomain, tier, is_enterprise, db_connection_string,
-		       status, created_at, updated_at
+		       status, require_two_factor, billing_restricted, plan_limits,
+		       limit_overrides, created_at, updated_at
 		FROM tenants
 		WHERE uuid = ?
 	`
 
 	tenant := &models.Tenant{}
+	var planLimits, limitOverrides []byte
 	err := r.db.QueryRowContext(ctx, query, uuid).Scan(
 		&tenant.ID,
 		&tenant.UUID,
@@ -133,6 +160,10 @@ func (r *TenantRepository) GetByUUID(ctx context.Context, uuid string) (*models.
 		&tenant.IsEnterprise,
 		&tenant.DBConnectionString,
 		&tenant.Status,
+		&tenant.RequireTwoFactor,
+		&tenant.BillingRestricted,
+		&planLimits,
+		&limitOverrides,
 		&tenant.CreatedAt,
 		&tenant.UpdatedAt,
 	)
@@ -143,22 +174,34 @@ func (r *TenantRepository) GetByUUID(ctx context.Context, uuid string) (*models.
 	if err != nil {
 		return nil, fmt.Errorf("failed to get tenant by UUID: %w", err)
 	}
+	if tenant.PlanLimits, err = decodePlanLimits(planLimits); err != nil {
+		return nil, err
+	}
+	if tenant.LimitOverrides, err = decodeLimitOverrides(limitOverrides); err != nil {
+		return nil, err
+	}
 
 	return tenant, nil
 }
 
 // Update updates a tenant
 func (r *TenantRepository) Update(ctx context.Context, tenant *models.Tenant) error {
+	planLimits, err := encodePlanLimits(tenant.PlanLimits)
+	if err != nil {
+		return err
+	}
+
 	query := `
 		UPDATE tenants
-		SET name = ?, tier = ?, status = ?, updated_at = NOW()
+		SET name = ?, tier = ?, status = ?, plan_limits = ?, updated_at = NOW()
 		WHERE id = ?
 	`
 
-	_, err := r.db.ExecContext(ctx, query,
+	_, err = r.db.ExecContext(ctx, query,
 		tenant.Name,
 		tenant.Tier,
 		tenant.Status,
+		planLimits,
 		tenant.ID,
 	)
 
@@ -192,3 +235,135 @@ func (r *TenantRepository) UpdateTier(ctx context.Context, id int64, tier models
 
 	return nil
 }
+
+// UpdateRequireTwoFactor sets whether all users of a tenant must use 2FA
+func (r *TenantRepository) UpdateRequireTwoFactor(ctx context.Context, id int64, required bool) error {
+	query := `UPDATE tenants SET require_two_factor = ?, updated_at = NOW() WHERE id = ?`
+
+	_, err := r.db.ExecContext(ctx, query, required, id)
+	if err != nil {
+		return fmt.Errorf("failed to update tenant two-factor requirement: %w", err)
+	}
+
+	return nil
+}
+
+// UpdateBillingRestricted sets whether a tenant is held to the Free limits for
+// an overdue payment
+func (r *TenantRepository) UpdateBillingRestricted(ctx context.Context, id int64, restricted bool) error {
+	query := `UPDATE tenants SET billing_restricted = ?, updated_at = NOW() WHERE id = ?`
+
+	_, err := r.db.ExecContext(ctx, query, restricted, id)
+	if err != nil {
+		return fmt.Errorf("failed to update tenant billing restriction: %w", err)
+	}
+
+	return nil
+}
+
+// UpdateLimitOverrides sets the limits a platform admin set for a tenant, nil
+// removes them
+func (r *TenantRepository) UpdateLimitOverrides(ctx context.Context, id int64, overrides *models.LimitOverrides) error {
+	var encoded []byte
+	if !overrides.IsEmpty() {
+		var err error
+		if encoded, err = json.Marshal(overrides); err != nil {
+			return fmt.Errorf("failed to encode limit overrides: %w", err)
+		}
+	}
+
+	query := `UPDATE tenants SET limit_overrides = ?, updated_at = NOW() WHERE id = ?`
+
+	if _, err := r.db.ExecContext(ctx, query, encoded, id); err != nil {
+		return fmt.Errorf("failed to update tenant limit overrides: %w", err)
+	}
+
+	return nil
+}
+
+// GetSettings retrieves the settings document of a tenant
+func (r *TenantRepository) GetSettings(ctx context.Context, id int64) (json.RawMessage, error) {
+	var settings sql.NullString
+
+	err := r.db.QueryRowContext(ctx, `SELECT settings FROM tenants WHERE id = ?`, id).Scan(&settings)
+	if err == sql.ErrNoRows {
+		return nil, errors.ErrTenantNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get tenant settings: %w", err)
+	}
+
+	if !settings.Valid {
+		return nil, nil
+	}
+
+	return json.RawMessage(settings.String), nil
+}
+
+// UpdateSettings replaces the settings document of a tenant
+func (r *TenantRepository) UpdateSettings(ctx context.Context, id int64, settings json.RawMessage) error {
+	query := `UPDATE tenants SET settings = ?, updated_at = NOW() WHERE id = ?`
+
+	_, err := r.db.ExecContext(ctx, query, string(settings), id)
+	if err != nil {
+		return fmt.Errorf("failed to update tenant settings: %w", err)
+	}
+
+	return nil
+}
+
+// ListIDs lists the IDs of all tenants
+func (r *TenantRepository) ListIDs(ctx context.Context) ([]int64, error) {
+	rows, err := r.db.QueryContext(ctx, `SELECT id FROM tenants ORDER BY id`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list tenants: %w", err)
+	}
+	defer rows.Close()
+
+	var ids []int64
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("failed to scan tenant id: %w", err)
+		}
+		ids = append(ids, id)
+	}
+
+	return ids, rows.Err()
+}
+
+// encodePlanLimits encodes the plan limits of a tenant, NULL for the tier's limits
+func encodePlanLimits(limits *models.PlanLimits) ([]byte, error) {
+	if limits == nil {
+		return nil, nil
+	}
+	encoded, err := json.Marshal(limits)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode plan limits: %w", err)
+	}
+	return encoded, nil
+}
+
+// decodePlanLimits decodes the plan limits of a tenant, nil for the tier's limits
+func decodePlanLimits(encoded []byte) (*models.PlanLimits, error) {
+	if len(encoded) == 0 {
+		return nil, nil
+	}
+	limits := &models.PlanLimits{}
+	if err := json.Unmarshal(encoded, limits); err != nil {
+		return nil, fmt.Errorf("failed to decode plan limits: %w", err)
+	}
+	return limits, nil
+}
+
+// decodeLimitOverrides decodes the limit overrides of a tenant, nil if none
+func decodeLimitOverrides(encoded []byte) (*models.LimitOverrides, error) {
+	if len(encoded) == 0 {
+		return nil, nil
+	}
+	overrides := &models.LimitOverrides{}
+	if err := json.Unmarshal(encoded, overrides); err != nil {
+		return nil, fmt.Errorf("failed to decode limit overrides: %w", err)
+	}
+	return overrides, nil
+}
@@ -0,0 +1,264 @@
+package mysql
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/yourusername/gin-collection-saas/internal/domain/errors"
+	"github.com/yourusername/gin-collection-saas/internal/domain/models"
+)
+
+// TwoFactorRepository implements the two-factor repository interface
+type TwoFactorRepository struct {
+	db *sql.DB
+}
+
+// NewTwoFactorRepository creates a new two-factor repository
+func NewTwoFactorRepository(db *sql.DB) *TwoFactorRepository {
+	return &TwoFactorRepository{db: db}
+}
+
+// GetCredential retrieves the TOTP credential of a subject
+func (r *TwoFactorRepository) GetCredential(ctx context.Context, subjectType string, subjectID int64) (*models.TwoFactorCredential, error) {
+	query := `
+		SELECT id, subject_type, subject_id, secret, confirmed_at, last_used_step, created_at, updated_at
+		FROM two_factor_credentials
+		WHERE subject_type = ? AND subject_id = ?
+	`
+
+	credential := &models.TwoFactorCredential{}
+	var confirmedAt sql.NullTime
+	var lastUsedStep sql.NullInt64
+
+	err := r.db.QueryRowContext(ctx, query, subjectType, subjectID).Scan(
+		&credential.ID,
+		&credential.SubjectType,
+		&credential.SubjectID,
+		&credential.Secret,
+		&confirmedAt,
+		&lastUsedStep,
+		&credential.CreatedAt,
+		&credential.UpdatedAt,
+	)
+	if err == sql.ErrNoRows {
+		return nil, errors.ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get two-factor credential: %w", err)
+	}
+
+	if confirmedAt.Valid {
+		credential.ConfirmedAt = &confirmedAt.Time
+	}
+	if lastUsedStep.Valid {
+		credential.LastUsedStep = &lastUsedStep.Int64
+	}
+
+	return credential, nil
+}
+
+// SaveCredential creates or replaces the (unconfirmed) TOTP credential of a subject
+func (r *TwoFactorRepository) SaveCredential(ctx context.Context, credential *models.TwoFactorCredential) error {
+	query := `
+		INSERT INTO two_factor_credentials (subject_type, subject_id, secret, created_at, updated_at)
+		VALUES (?, ?, ?, NOW(), NOW())
+		ON DUPLICATE KEY UPDATE
+			id = LAST_INSERT_ID(id),
+			secret = VALUES(secret),
+			confirmed_at = NULL,
+			last_used_step = NULL,
+			updated_at = NOW()
+	`
+
+	result, err := r.db.ExecContext(ctx, query, credential.SubjectType, credential.SubjectID, credential.Secret)
+	if err != nil {
+		return fmt.Errorf("failed to save two-factor credential: %w", err)
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return fmt.Errorf("failed to get last insert id: %w", err)
+	}
+
+	credential.ID = id
+	credential.ConfirmedAt = nil
+	credential.LastUsedStep = nil
+	return nil
+}
+
+// ConfirmCredential activates a credential
+func (r *TwoFactorRepository) ConfirmCredential(ctx context.Context, id int64) error {
+	query := `UPDATE two_factor_credentials SET confirmed_at = NOW() WHERE id = ?`
+
+	if _, err := r.db.ExecContext(ctx, query, id); err != nil {
+		return fmt.Errorf("failed to confirm two-factor credential: %w", err)
+	}
+
+	return nil
+}
+
+// UseStep records the time step of an accepted code
+func (r *TwoFactorRepository) UseStep(ctx context.Context, id int64, step int64) (bool, error) {
+	query := `
+		UPDATE two_factor_credentials
+		SET last_used_step = ?
+		WHERE id = ? AND (last_used_step IS NULL OR last_used_step < ?)
+	`
+
+	result, err := r.db.ExecContext(ctx, query, step, id, step)
+	if err != nil {
+		return false, fmt.Errorf("failed to record two-factor step: %w", err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to get affected rows: %w", err)
+	}
+
+	return affected > 0, nil
+}
+
+// Delete removes credential, recovery codes and trusted devices of a subject
+func (r *TwoFactorRepository) Delete(ctx context.Context, subjectType string, subjectID int64) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	for _, table := range []string{"two_factor_credentials", "two_factor_recovery_codes", "two_factor_trusted_devices"} {
+		query := `DELETE FROM ` + table + ` WHERE subject_type = ? AND subject_id = ?`
+		if _, err := tx.ExecContext(ctx, query, subjectType, subjectID); err != nil {
+			return fmt.Errorf("failed to delete from %s: %w", table, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+// ReplaceRecoveryCodes replaces all recovery codes of a subject
+func (r *TwoFactorRepository) ReplaceRecoveryCodes(ctx context.Context, subjectType string, subjectID int64, codeHashes []string) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	_, err = tx.ExecContext(ctx, `DELETE FROM two_factor_recovery_codes WHERE subject_type = ? AND subject_id = ?`, subjectType, subjectID)
+	if err != nil {
+		return fmt.Errorf("failed to delete recovery codes: %w", err)
+	}
+
+	for _, codeHash := range codeHashes {
+		_, err := tx.ExecContext(ctx, `
+			INSERT INTO two_factor_recovery_codes (subject_type, subject_id, code_hash, created_at)
+			VALUES (?, ?, ?, NOW())
+		`, subjectType, subjectID, codeHash)
+		if err != nil {
+			return fmt.Errorf("failed to create recovery code: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+// UseRecoveryCode marks an unused recovery code as used
+func (r *TwoFactorRepository) UseRecoveryCode(ctx context.Context, subjectType string, subjectID int64, codeHash string) (bool, error) {
+	query := `
+		UPDATE two_factor_recovery_codes
+		SET used_at = NOW()
+		WHERE subject_type = ? AND subject_id = ? AND code_hash = ? AND used_at IS NULL
+		LIMIT 1
+	`
+
+	result, err := r.db.ExecContext(ctx, query, subjectType, subjectID, codeHash)
+	if err != nil {
+		return false, fmt.Errorf("failed to use recovery code: %w", err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to get affected rows: %w", err)
+	}
+
+	return affected > 0, nil
+}
+
+// CountRecoveryCodes counts the unused recovery codes of a subject
+func (r *TwoFactorRepository) CountRecoveryCodes(ctx context.Context, subjectType string, subjectID int64) (int, error) {
+	query := `
+		SELECT COUNT(*)
+		FROM two_factor_recovery_codes
+		WHERE subject_type = ? AND subject_id = ? AND used_at IS NULL
+	`
+
+	var count int
+	if err := r.db.QueryRowContext(ctx, query, subjectType, subjectID).Scan(&count); err != nil {
+		return 0, fmt.Errorf("failed to count recovery codes: %w", err)
+	}
+
+	return count, nil
+}
+
+// CreateTrustedDevice stores a remembered device
+func (r *TwoFactorRepository) CreateTrustedDevice(ctx context.Context, device *models.TrustedDevice) error {
+	query := `
+		INSERT INTO two_factor_trusted_devices (subject_type, subject_id, token_hash, user_agent, expires_at, created_at)
+		VALUES (?, ?, ?, ?, ?, NOW())
+	`
+
+	result, err := r.db.ExecContext(ctx, query,
+		device.SubjectType,
+		device.SubjectID,
+		device.TokenHash,
+		device.UserAgent,
+		device.ExpiresAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to create trusted device: %w", err)
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return fmt.Errorf("failed to get last insert id: %w", err)
+	}
+
+	device.ID = id
+	return nil
+}
+
+// IsTrustedDevice checks for an unexpired trusted device token of a subject
+func (r *TwoFactorRepository) IsTrustedDevice(ctx context.Context, subjectType string, subjectID int64, tokenHash string) (bool, error) {
+	query := `
+		SELECT COUNT(*)
+		FROM two_factor_trusted_devices
+		WHERE subject_type = ? AND subject_id = ? AND token_hash = ? AND expires_at > NOW()
+	`
+
+	var count int
+	if err := r.db.QueryRowContext(ctx, query, subjectType, subjectID, tokenHash).Scan(&count); err != nil {
+		return false, fmt.Errorf("failed to check trusted device: %w", err)
+	}
+
+	return count > 0, nil
+}
+
+// DeleteTrustedDevices forgets all trusted devices of a subject
+func (r *TwoFactorRepository) DeleteTrustedDevices(ctx context.Context, subjectType string, subjectID int64) error {
+	query := `DELETE FROM two_factor_trusted_devices WHERE subject_type = ? AND subject_id = ?`
+
+	if _, err := r.db.ExecContext(ctx, query, subjectType, subjectID); err != nil {
+		return fmt.Errorf("failed to delete trusted devices: %w", err)
+	}
+
+	return nil
+}
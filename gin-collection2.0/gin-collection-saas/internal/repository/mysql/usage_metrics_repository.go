@@ -5,6 +5,8 @@ import (
 	"database/sql"
 	"fmt"
 	"time"
+
+	"github.com/yourusername/gin-collection-saas/internal/domain/models"
 )
 
 // UsageMetricsRepository implements usage metrics tracking
@@ -110,3 +112,106 @@ func (r *UsageMetricsRepository) SetMetric(ctx context.Context, tenantID int64,
 
 	return nil
 }
+
+// GetPeriodValue retrieves the value of a metric in the billing period
+// starting at periodStart, 0 if nothing was recorded
+func (r *UsageMetricsRepository) GetPeriodValue(ctx context.Context, tenantID int64, metricName string, periodStart time.Time) (int, error) {
+	query := `
+		SELECT current_value
+		FROM usage_metrics
+		WHERE tenant_id = ? AND metric_name = ? AND period_start = ?
+	`
+
+	var value int
+	err := r.db.QueryRowContext(ctx, query, tenantID, metricName, periodStart).Scan(&value)
+	if err == sql.ErrNoRows {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to get metric: %w", err)
+	}
+
+	return value, nil
+}
+
+// Record adds delta to a metric in its billing period and stores the limit
+// that applied
+func (r *UsageMetricsRepository) Record(ctx context.Context, metric *models.UsageMetric, delta int) error {
+	query := `
+		INSERT INTO usage_metrics (tenant_id, metric_name, current_value, limit_value, period_start, period_end, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, NOW())
+		ON DUPLICATE KEY UPDATE
+			current_value = current_value + VALUES(current_value),
+			limit_value = VALUES(limit_value),
+			period_end = VALUES(period_end),
+			updated_at = NOW()
+	`
+
+	_, err := r.db.ExecContext(ctx, query, metric.TenantID, metric.MetricName, delta, metric.LimitValue,
+		metric.PeriodStart, metric.PeriodEnd)
+	if err != nil {
+		return fmt.Errorf("failed to record metric: %w", err)
+	}
+
+	return nil
+}
+
+// RecordPeak raises a metric in its billing period to its current value if
+// that is higher, and stores the limit that applied
+func (r *UsageMetricsRepository) RecordPeak(ctx context.Context, metric *models.UsageMetric) error {
+	query := `
+		INSERT INTO usage_metrics (tenant_id, metric_name, current_value, limit_value, period_start, period_end, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, NOW())
+		ON DUPLICATE KEY UPDATE
+			current_value = GREATEST(current_value, VALUES(current_value)),
+			limit_value = VALUES(limit_value),
+			period_end = VALUES(period_end),
+			updated_at = NOW()
+	`
+
+	_, err := r.db.ExecContext(ctx, query, metric.TenantID, metric.MetricName, metric.CurrentValue, metric.LimitValue,
+		metric.PeriodStart, metric.PeriodEnd)
+	if err != nil {
+		return fmt.Errorf("failed to record metric peak: %w", err)
+	}
+
+	return nil
+}
+
+// ListSince lists the metrics of a tenant in the billing periods starting at
+// or after since, newest period first
+func (r *UsageMetricsRepository) ListSince(ctx context.Context, tenantID int64, since time.Time) ([]*models.UsageMetric, error) {
+	query := `
+		SELECT id, tenant_id, metric_name, current_value, limit_value, period_start, period_end, updated_at
+		FROM usage_metrics
+		WHERE tenant_id = ? AND period_start >= ?
+		ORDER BY period_start DESC, metric_name
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, tenantID, since)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list metrics: %w", err)
+	}
+	defer rows.Close()
+
+	metrics := []*models.UsageMetric{}
+	for rows.Next() {
+		metric := &models.UsageMetric{}
+		err := rows.Scan(
+			&metric.ID,
+			&metric.TenantID,
+			&metric.MetricName,
+			&metric.CurrentValue,
+			&metric.LimitValue,
+			&metric.PeriodStart,
+			&metric.PeriodEnd,
+			&metric.UpdatedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan metric: %w", err)
+		}
+		metrics = append(metrics, metric)
+	}
+
+	return metrics, rows.Err()
+}
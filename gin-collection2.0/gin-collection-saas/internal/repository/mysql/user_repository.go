@@ -23,13 +23,23 @@ func NewUserRepository(db *sql.DB) *UserRepository {
 
 // Create creates a new user
 func (r *UserRepository) Create(ctx context.Context, user *models.User) error {
+	return insertUser(ctx, r.db, user)
+}
+
+// execer is implemented by *sql.DB and *sql.Tx
+type execer interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+}
+
+// insertUser inserts a user, on its own or within a transaction
+func insertUser(ctx context.Context, db execer, user *models.User) error {
 	query := `
 		INSERT INTO users (tenant_id, uuid, email, password_hash, first_name, last_name,
-		                   role, is_active, created_at, updated_at)
-		VALUES (?, ?, ?, ?, ?, ?, ?, ?, NOW(), NOW())
+		                   role, is_active, email_verified_at, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, NOW(), NOW())
 	`
 
-	result, err := r.db.ExecContext(ctx, query,
+	result, err := db.ExecContext(ctx, query,
 		user.TenantID,
 		uuid.New().String(),
 		user.Email,
@@ -38,6 +48,7 @@ func (r *UserRepository) Create(ctx context.Context, user *models.User) error {
 		user.LastName,
 		user.Role,
 		user.IsActive,
+		user.EmailVerifiedAt,
 	)
 	if err != nil {
 		return fmt.Errorf("failed to create user: %w", err)
@@ -56,7 +67,7 @@ func (r *UserRepository) Create(ctx context.Context, user *models.User) error {
 func (r *UserRepository) GetByID(ctx context.Context, id int64) (*models.User, error) {
 	query := `
 		SELECT id, tenant_id, uuid, email, password_hash, first_name, last_name,
-		       role, api_key, is_active, email_verified_at, last_login_at,
+		       role, custom_role_id, is_active, email_verified_at, last_login_at,
 		       created_at, updated_at
 		FROM users
 		WHERE id = ?
@@ -72,7 +83,7 @@ func (r *UserRepository) GetByID(ctx context.Context, id int64) (*models.User, e
 		&user.FirstName,
 		&user.LastName,
 		&user.Role,
-		&user.APIKey,
+		&user.CustomRoleID,
 		&user.IsActive,
 		&user.EmailVerifiedAt,
 		&user.LastLoginAt,
@@ -94,7 +105,7 @@ func (r *UserRepository) GetByID(ctx context.Context, id int64) (*models.User, e
 func (r *UserRepository) GetByEmail(ctx context.Context, tenantID int64, email string) (*models.User, error) {
 	query := `
 		SELECT id, tenant_id, uuid, email, password_hash, first_name, last_name,
-		       role, api_key, is_active, email_verified_at, last_login_at,
+		       role, custom_role_id, is_active, email_verified_at, last_login_at,
 		       created_at, updated_at
 		FROM users
 		WHERE tenant_id = ? AND email = ?
@@ -110,7 +121,7 @@ func (r *UserRepository) GetByEmail(ctx context.Context, tenantID int64, email s
 		&user.FirstName,
 		&user.LastName,
 		&user.Role,
-		&user.APIKey,
+		&user.CustomRoleID,
 		&user.IsActive,
 		&user.EmailVerifiedAt,
 		&user.LastLoginAt,
@@ -132,7 +143,7 @@ func (r *UserRepository) GetByEmail(ctx context.Context, tenantID int64, email s
 func (r *UserRepository) GetByEmailGlobal(ctx context.Context, email string) (*models.User, error) {
 	query := `
 		SELECT id, tenant_id, uuid, email, password_hash, first_name, last_name,
-		       role, api_key, is_active, email_verified_at, last_login_at,
+		       role, custom_role_id, is_active, email_verified_at, last_login_at,
 		       created_at, updated_at
 		FROM users
 		WHERE email = ?
@@ -149,7 +160,7 @@ func (r *UserRepository) GetByEmailGlobal(ctx context.Context, email string) (*m
 		&user.FirstName,
 		&user.LastName,
 		&user.Role,
-		&user.APIKey,
+		&user.CustomRoleID,
 		&user.IsActive,
 		&user.EmailVerifiedAt,
 		&user.LastLoginAt,
@@ -167,50 +178,12 @@ func (r *UserRepository) GetByEmailGlobal(ctx context.Context, email string) (*m
 	return user, nil
 }
 
-// GetByAPIKey retrieves a user by API key
-func (r *UserRepository) GetByAPIKey(ctx context.Context, apiKey string) (*models.User, error) {
-	query := `
-		SELECT id, tenant_id, uuid, email, password_hash, first_name, last_name,
-		       role, api_key, is_active, email_verified_at, last_login_at,
-		       created_at, updated_at
-		FROM users
-		WHERE api_key = ? AND is_active = TRUE
-	`
-
-	user := &models.User{}
-	err := r.db.QueryRowContext(ctx, query, apiKey).Scan(
-		&user.ID,
-		&user.TenantID,
-		&user.UUID,
-		&user.Email,
-		&user.PasswordHash,
-		&user.FirstName,
-		&user.LastName,
-		&user.Role,
-		&user.APIKey,
-		&user.IsActive,
-		&user.EmailVerifiedAt,
-		&user.LastLoginAt,
-		&user.CreatedAt,
-		&user.UpdatedAt,
-	)
-
-	if err == sql.ErrNoRows {
-		return nil, errors.ErrInvalidAPIKey
-	}
-	if err != nil {
-		return nil, fmt.Errorf("failed to get user by API key: %w", err)
-	}
-
-	return user, nil
-}
-
 // Update updates a user
 func (r *UserRepository) Update(ctx context.Context, user *models.User) error {
 	query := `
 		UPDATE users
 		SET email = ?, first_name = ?, last_name = ?, role = ?,
-		    is_active = ?, updated_at = NOW()
+		    is_active = ?, email_verified_at = ?, updated_at = NOW()
 		WHERE id = ?
 	`
 
@@ -220,6 +193,7 @@ func (r *UserRepository) Update(ctx context.Context, user *models.User) error {
 		user.LastName,
 		user.Role,
 		user.IsActive,
+		user.EmailVerifiedAt,
 		user.ID,
 	)
 
@@ -246,7 +220,7 @@ func (r *UserRepository) UpdateLastLogin(ctx context.Context, id int64) error {
 func (r *UserRepository) List(ctx context.Context, tenantID int64) ([]*models.User, error) {
 	query := `
 		SELECT id, tenant_id, uuid, email, password_hash, first_name, last_name,
-		       role, api_key, is_active, email_verified_at, last_login_at,
+		       role, custom_role_id, is_active, email_verified_at, last_login_at,
 		       created_at, updated_at
 		FROM users
 		WHERE tenant_id = ?
@@ -271,7 +245,7 @@ func (r *UserRepository) List(ctx context.Context, tenantID int64) ([]*models.Us
 			&user.FirstName,
 			&user.LastName,
 			&user.Role,
-			&user.APIKey,
+			&user.CustomRoleID,
 			&user.IsActive,
 			&user.EmailVerifiedAt,
 			&user.LastLoginAt,
@@ -299,33 +273,6 @@ func (r *UserRepository) Delete(ctx context.Context, id int64) error {
 	return nil
 }
 
-// GenerateAPIKey generates a new API key for a user (Enterprise only)
-func (r *UserRepository) GenerateAPIKey(ctx context.Context, userID int64) (string, error) {
-	// Generate a secure API key using UUID
-	apiKey := "sk_" + uuid.New().String()
-
-	query := `UPDATE users SET api_key = ?, updated_at = NOW() WHERE id = ?`
-
-	_, err := r.db.ExecContext(ctx, query, apiKey, userID)
-	if err != nil {
-		return "", fmt.Errorf("failed to generate API key: %w", err)
-	}
-
-	return apiKey, nil
-}
-
-// RevokeAPIKey revokes the API key for a user
-func (r *UserRepository) RevokeAPIKey(ctx context.Context, userID int64) error {
-	query := `UPDATE users SET api_key = NULL, updated_at = NOW() WHERE id = ?`
-
-	_, err := r.db.ExecContext(ctx, query, userID)
-	if err != nil {
-		return fmt.Errorf("failed to revoke API key: %w", err)
-	}
-
-	return nil
-}
-
 // CountByTenant counts users in a tenant
 func (r *UserRepository) CountByTenant(ctx context.Context, tenantID int64) (int, error) {
 	query := `SELECT COUNT(*) FROM users WHERE tenant_id = ?`
@@ -0,0 +1,276 @@
+package mysql
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"github.com/yourusername/gin-collection-saas/internal/domain/errors"
+	"github.com/yourusername/gin-collection-saas/internal/domain/models"
+)
+
+// WebAuthnRepository implements the WebAuthn repository interface
+type WebAuthnRepository struct {
+	db *sql.DB
+}
+
+// NewWebAuthnRepository creates a new WebAuthn repository
+func NewWebAuthnRepository(db *sql.DB) *WebAuthnRepository {
+	return &WebAuthnRepository{db: db}
+}
+
+const webAuthnCredentialColumns = `
+	id, subject_type, subject_id, credential_id, public_key, algorithm, sign_count, aaguid,
+	attestation_format, transports, backup_eligible, backed_up, name, last_used_at, created_at
+`
+
+// CreateCredential stores a registered passkey
+func (r *WebAuthnRepository) CreateCredential(ctx context.Context, credential *models.WebAuthnCredential) error {
+	query := `
+		INSERT INTO webauthn_credentials (
+			subject_type, subject_id, credential_id, public_key, algorithm, sign_count, aaguid,
+			attestation_format, transports, backup_eligible, backed_up, name, created_at
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, NOW())
+	`
+
+	var transports sql.NullString
+	if len(credential.Transports) > 0 {
+		transports = sql.NullString{String: strings.Join(credential.Transports, ","), Valid: true}
+	}
+
+	var aaguid interface{}
+	if len(credential.AAGUID) == 16 {
+		aaguid = credential.AAGUID
+	}
+
+	result, err := r.db.ExecContext(ctx, query,
+		credential.SubjectType,
+		credential.SubjectID,
+		credential.CredentialID,
+		credential.PublicKey,
+		credential.Algorithm,
+		credential.SignCount,
+		aaguid,
+		credential.AttestationFormat,
+		transports,
+		credential.BackupEligible,
+		credential.BackedUp,
+		credential.Name,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to create webauthn credential: %w", err)
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return fmt.Errorf("failed to get webauthn credential ID: %w", err)
+	}
+	credential.ID = id
+
+	return nil
+}
+
+// GetCredentialByCredentialID retrieves a passkey by its base64url credential ID
+func (r *WebAuthnRepository) GetCredentialByCredentialID(ctx context.Context, credentialID string) (*models.WebAuthnCredential, error) {
+	query := `SELECT ` + webAuthnCredentialColumns + ` FROM webauthn_credentials WHERE credential_id = ?`
+
+	credential, err := scanWebAuthnCredential(r.db.QueryRowContext(ctx, query, credentialID))
+	if err == sql.ErrNoRows {
+		return nil, errors.ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get webauthn credential: %w", err)
+	}
+
+	return credential, nil
+}
+
+// ListCredentials lists the passkeys of a subject
+func (r *WebAuthnRepository) ListCredentials(ctx context.Context, subjectType string, subjectID int64) ([]*models.WebAuthnCredential, error) {
+	query := `
+		SELECT ` + webAuthnCredentialColumns + `
+		FROM webauthn_credentials
+		WHERE subject_type = ? AND subject_id = ?
+		ORDER BY created_at ASC
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, subjectType, subjectID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list webauthn credentials: %w", err)
+	}
+	defer rows.Close()
+
+	var credentials []*models.WebAuthnCredential
+	for rows.Next() {
+		credential, err := scanWebAuthnCredential(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan webauthn credential: %w", err)
+		}
+		credentials = append(credentials, credential)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate webauthn credentials: %w", err)
+	}
+
+	return credentials, nil
+}
+
+// UpdateCredentialUsage stores the sign count and backup state after a login
+func (r *WebAuthnRepository) UpdateCredentialUsage(ctx context.Context, id int64, signCount uint32, backedUp bool) error {
+	query := `
+		UPDATE webauthn_credentials
+		SET sign_count = ?, backed_up = ?, last_used_at = NOW()
+		WHERE id = ?
+	`
+
+	if _, err := r.db.ExecContext(ctx, query, signCount, backedUp, id); err != nil {
+		return fmt.Errorf("failed to update webauthn credential: %w", err)
+	}
+
+	return nil
+}
+
+// DeleteCredential removes a passkey of a subject
+func (r *WebAuthnRepository) DeleteCredential(ctx context.Context, subjectType string, subjectID int64, id int64) error {
+	query := `DELETE FROM webauthn_credentials WHERE id = ? AND subject_type = ? AND subject_id = ?`
+
+	result, err := r.db.ExecContext(ctx, query, id, subjectType, subjectID)
+	if err != nil {
+		return fmt.Errorf("failed to delete webauthn credential: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rowsAffected == 0 {
+		return errors.ErrNotFound
+	}
+
+	return nil
+}
+
+// CreateSession stores a pending registration or login challenge
+func (r *WebAuthnRepository) CreateSession(ctx context.Context, session *models.WebAuthnSession) error {
+	query := `
+		INSERT INTO webauthn_sessions (id, subject_type, subject_id, ceremony, challenge, expires_at, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, NOW())
+	`
+
+	var subjectID sql.NullInt64
+	if session.SubjectID != nil {
+		subjectID = sql.NullInt64{Int64: *session.SubjectID, Valid: true}
+	}
+
+	_, err := r.db.ExecContext(ctx, query,
+		session.ID,
+		session.SubjectType,
+		subjectID,
+		session.Ceremony,
+		session.Challenge,
+		session.ExpiresAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to create webauthn session: %w", err)
+	}
+
+	return nil
+}
+
+// ConsumeSession retrieves and deletes a session, so a challenge can only be answered once
+func (r *WebAuthnRepository) ConsumeSession(ctx context.Context, id string) (*models.WebAuthnSession, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	query := `
+		SELECT id, subject_type, subject_id, ceremony, challenge, expires_at, created_at
+		FROM webauthn_sessions
+		WHERE id = ?
+		FOR UPDATE
+	`
+
+	session := &models.WebAuthnSession{}
+	var subjectID sql.NullInt64
+
+	err = tx.QueryRowContext(ctx, query, id).Scan(
+		&session.ID,
+		&session.SubjectType,
+		&subjectID,
+		&session.Ceremony,
+		&session.Challenge,
+		&session.ExpiresAt,
+		&session.CreatedAt,
+	)
+	if err == sql.ErrNoRows {
+		return nil, errors.ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get webauthn session: %w", err)
+	}
+
+	if subjectID.Valid {
+		session.SubjectID = &subjectID.Int64
+	}
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM webauthn_sessions WHERE id = ?`, id); err != nil {
+		return nil, fmt.Errorf("failed to delete webauthn session: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return session, nil
+}
+
+// DeleteExpiredSessions removes sessions that were never completed
+func (r *WebAuthnRepository) DeleteExpiredSessions(ctx context.Context) (int64, error) {
+	result, err := r.db.ExecContext(ctx, `DELETE FROM webauthn_sessions WHERE expires_at < NOW()`)
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete expired webauthn sessions: %w", err)
+	}
+
+	return result.RowsAffected()
+}
+
+// scanWebAuthnCredential scans a single passkey row
+func scanWebAuthnCredential(row rowScanner) (*models.WebAuthnCredential, error) {
+	credential := &models.WebAuthnCredential{}
+	var transports sql.NullString
+	var lastUsedAt sql.NullTime
+
+	err := row.Scan(
+		&credential.ID,
+		&credential.SubjectType,
+		&credential.SubjectID,
+		&credential.CredentialID,
+		&credential.PublicKey,
+		&credential.Algorithm,
+		&credential.SignCount,
+		&credential.AAGUID,
+		&credential.AttestationFormat,
+		&transports,
+		&credential.BackupEligible,
+		&credential.BackedUp,
+		&credential.Name,
+		&lastUsedAt,
+		&credential.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if transports.Valid && transports.String != "" {
+		credential.Transports = strings.Split(transports.String, ",")
+	}
+	if lastUsedAt.Valid {
+		credential.LastUsedAt = &lastUsedAt.Time
+	}
+
+	return credential, nil
+}
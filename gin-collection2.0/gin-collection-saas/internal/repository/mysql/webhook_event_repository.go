@@ -0,0 +1,248 @@
+package mysql
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/yourusername/gin-collection-saas/internal/domain/errors"
+	"github.com/yourusername/gin-collection-saas/internal/domain/models"
+)
+
+// WebhookEventRepository implements the webhook event repository interface
+type WebhookEventRepository struct {
+	db *sql.DB
+}
+
+// NewWebhookEventRepository creates a new webhook event repository
+func NewWebhookEventRepository(db *sql.DB) *WebhookEventRepository {
+	return &WebhookEventRepository{db: db}
+}
+
+const webhookEventColumns = `id, provider, event_id, event_type, resource_id, resource_updated_at, status,
+	attempts, error, payload, received_at, processed_at`
+
+// Create stores a received event, ErrConflict if the provider already
+// delivered an event with the same ID
+func (r *WebhookEventRepository) Create(ctx context.Context, event *models.WebhookEvent) error {
+	query := `
+		INSERT IGNORE INTO webhook_events (provider, event_id, event_type, resource_id, resource_updated_at,
+		                                   status, attempts, payload, received_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`
+
+	result, err := r.db.ExecContext(ctx, query,
+		event.Provider,
+		event.EventID,
+		event.EventType,
+		event.ResourceID,
+		event.ResourceUpdatedAt,
+		event.Status,
+		event.Attempts,
+		[]byte(event.Payload),
+		event.ReceivedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to create webhook event: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get affected rows: %w", err)
+	}
+	if rows == 0 {
+		return errors.ErrConflict
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return fmt.Errorf("failed to get last insert id: %w", err)
+	}
+
+	event.ID = id
+	return nil
+}
+
+// GetByID retrieves an event by its ID
+func (r *WebhookEventRepository) GetByID(ctx context.Context, id int64) (*models.WebhookEvent, error) {
+	query := `SELECT ` + webhookEventColumns + ` FROM webhook_events WHERE id = ?`
+
+	event, err := scanWebhookEvent(r.db.QueryRowContext(ctx, query, id))
+	if err == sql.ErrNoRows {
+		return nil, errors.ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get webhook event: %w", err)
+	}
+
+	return event, nil
+}
+
+// GetByEventID retrieves an event by the ID the provider assigned to it
+func (r *WebhookEventRepository) GetByEventID(ctx context.Context, provider, eventID string) (*models.WebhookEvent, error) {
+	query := `SELECT ` + webhookEventColumns + ` FROM webhook_events WHERE provider = ? AND event_id = ?`
+
+	event, err := scanWebhookEvent(r.db.QueryRowContext(ctx, query, provider, eventID))
+	if err == sql.ErrNoRows {
+		return nil, errors.ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get webhook event: %w", err)
+	}
+
+	return event, nil
+}
+
+// Update updates the status, attempts, error and processing time of an event
+func (r *WebhookEventRepository) Update(ctx context.Context, event *models.WebhookEvent) error {
+	query := `
+		UPDATE webhook_events
+		SET status = ?, attempts = ?, error = ?, processed_at = ?
+		WHERE id = ?
+	`
+
+	result, err := r.db.ExecContext(ctx, query,
+		event.Status,
+		event.Attempts,
+		event.Error,
+		event.ProcessedAt,
+		event.ID,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to update webhook event: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get affected rows: %w", err)
+	}
+	if rows == 0 {
+		return errors.ErrNotFound
+	}
+
+	return nil
+}
+
+// LatestResourceUpdate returns the latest resource update time of the
+// processed events of a resource, nil if none was processed
+func (r *WebhookEventRepository) LatestResourceUpdate(ctx context.Context, provider, resourceID string) (*time.Time, error) {
+	query := `
+		SELECT MAX(resource_updated_at)
+		FROM webhook_events
+		WHERE provider = ? AND resource_id = ? AND status = ?
+	`
+
+	var latest sql.NullTime
+	if err := r.db.QueryRowContext(ctx, query, provider, resourceID, models.WebhookEventStatusProcessed).Scan(&latest); err != nil {
+		return nil, fmt.Errorf("failed to get latest resource update: %w", err)
+	}
+	if !latest.Valid {
+		return nil, nil
+	}
+
+	return &latest.Time, nil
+}
+
+// List lists events, newest first, with the total number of matches. The
+// payload is left out, it is only loaded with a single event.
+func (r *WebhookEventRepository) List(ctx context.Context, filter *models.WebhookEventFilter) ([]*models.WebhookEvent, int, error) {
+	var conditions []string
+	var args []interface{}
+
+	if filter.Provider != "" {
+		conditions = append(conditions, "provider = ?")
+		args = append(args, filter.Provider)
+	}
+	if filter.Status != "" {
+		conditions = append(conditions, "status = ?")
+		args = append(args, filter.Status)
+	}
+	if filter.EventType != "" {
+		conditions = append(conditions, "event_type = ?")
+		args = append(args, filter.EventType)
+	}
+	if filter.ResourceID != "" {
+		conditions = append(conditions, "resource_id = ?")
+		args = append(args, filter.ResourceID)
+	}
+
+	where := ""
+	if len(conditions) > 0 {
+		where = "WHERE " + strings.Join(conditions, " AND ")
+	}
+
+	var total int
+	if err := r.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM webhook_events `+where, args...).Scan(&total); err != nil {
+		return nil, 0, fmt.Errorf("failed to count webhook events: %w", err)
+	}
+
+	query := `
+		SELECT id, provider, event_id, event_type, resource_id, resource_updated_at, status,
+		       attempts, error, NULL, received_at, processed_at
+		FROM webhook_events
+		` + where + `
+		ORDER BY received_at DESC, id DESC
+		LIMIT ? OFFSET ?
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, append(args, filter.Limit, filter.Offset)...)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to list webhook events: %w", err)
+	}
+	defer rows.Close()
+
+	var events []*models.WebhookEvent
+	for rows.Next() {
+		event, err := scanWebhookEvent(rows)
+		if err != nil {
+			return nil, 0, fmt.Errorf("failed to scan webhook event: %w", err)
+		}
+		events = append(events, event)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, fmt.Errorf("failed to iterate webhook events: %w", err)
+	}
+
+	return events, total, nil
+}
+
+func scanWebhookEvent(row rowScanner) (*models.WebhookEvent, error) {
+	event := &models.WebhookEvent{}
+	var resourceUpdatedAt, processedAt sql.NullTime
+	var errorMessage sql.NullString
+	var payload []byte
+
+	if err := row.Scan(
+		&event.ID,
+		&event.Provider,
+		&event.EventID,
+		&event.EventType,
+		&event.ResourceID,
+		&resourceUpdatedAt,
+		&event.Status,
+		&event.Attempts,
+		&errorMessage,
+		&payload,
+		&event.ReceivedAt,
+		&processedAt,
+	); err != nil {
+		return nil, err
+	}
+
+	if resourceUpdatedAt.Valid {
+		event.ResourceUpdatedAt = &resourceUpdatedAt.Time
+	}
+	if errorMessage.Valid {
+		event.Error = &errorMessage.String
+	}
+	if len(payload) > 0 {
+		event.Payload = payload
+	}
+	if processedAt.Valid {
+		event.ProcessedAt = &processedAt.Time
+	}
+
+	return event, nil
+}
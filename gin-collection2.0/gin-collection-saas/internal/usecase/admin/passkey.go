@@ -0,0 +1,132 @@
+package admin
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/yourusername/gin-collection-saas/internal/domain/models"
+	"github.com/yourusername/gin-collection-saas/internal/usecase/webauthn"
+	"github.com/yourusername/gin-collection-saas/pkg/logger"
+)
+
+// BeginPasskeyRegistration starts registering a passkey for an admin
+func (s *Service) BeginPasskeyRegistration(ctx context.Context, adminID int64) (*models.WebAuthnRegistrationBegin, error) {
+	if s.webAuthn == nil {
+		return nil, fmt.Errorf("passkeys not configured")
+	}
+
+	admin, err := s.adminRepo.GetByID(ctx, adminID)
+	if err != nil {
+		return nil, err
+	}
+	if admin == nil {
+		return nil, ErrAdminNotFound
+	}
+
+	account := webauthn.Account{
+		SubjectType: models.TwoFactorSubjectPlatformAdmin,
+		SubjectID:   admin.ID,
+		Name:        admin.Email,
+	}
+	if admin.Name != nil {
+		account.DisplayName = *admin.Name
+	}
+
+	return s.webAuthn.BeginRegistration(ctx, account)
+}
+
+// FinishPasskeyRegistration verifies and stores a passkey of an admin
+func (s *Service) FinishPasskeyRegistration(ctx context.Context, adminID int64, req *models.WebAuthnRegistrationRequest) (*models.WebAuthnCredential, error) {
+	if s.webAuthn == nil {
+		return nil, fmt.Errorf("passkeys not configured")
+	}
+
+	return s.webAuthn.FinishRegistration(ctx, models.TwoFactorSubjectPlatformAdmin, adminID, req)
+}
+
+// ListPasskeys lists the passkeys of an admin
+func (s *Service) ListPasskeys(ctx context.Context, adminID int64) ([]*models.WebAuthnCredential, error) {
+	if s.webAuthn == nil {
+		return nil, fmt.Errorf("passkeys not configured")
+	}
+
+	return s.webAuthn.ListCredentials(ctx, models.TwoFactorSubjectPlatformAdmin, adminID)
+}
+
+// DeletePasskey removes a passkey of an admin
+func (s *Service) DeletePasskey(ctx context.Context, adminID, passkeyID int64) error {
+	if s.webAuthn == nil {
+		return fmt.Errorf("passkeys not configured")
+	}
+
+	return s.webAuthn.DeleteCredential(ctx, models.TwoFactorSubjectPlatformAdmin, adminID, passkeyID)
+}
+
+// BeginPasskeyLogin starts an admin passkey login, optionally for a known email
+func (s *Service) BeginPasskeyLogin(ctx context.Context, email string) (*models.WebAuthnLoginBegin, error) {
+	if s.webAuthn == nil {
+		return nil, fmt.Errorf("passkeys not configured")
+	}
+
+	var subjectID *int64
+	if email != "" {
+		admin, err := s.adminRepo.GetByEmail(ctx, email)
+		if err == nil && admin != nil {
+			subjectID = &admin.ID
+		}
+	}
+
+	return s.webAuthn.BeginLogin(ctx, models.TwoFactorSubjectPlatformAdmin, subjectID)
+}
+
+// FinishPasskeyLogin verifies a passkey assertion of an admin. Without user
+// verification the mandatory second factor is still asked for.
+func (s *Service) FinishPasskeyLogin(ctx context.Context, req *models.WebAuthnLoginRequest) (*models.PlatformAdminAuthResponse, error) {
+	if s.webAuthn == nil {
+		return nil, fmt.Errorf("passkeys not configured")
+	}
+
+	assertion, err := s.webAuthn.FinishLogin(ctx, models.TwoFactorSubjectPlatformAdmin, req)
+	if err != nil {
+		return nil, err
+	}
+
+	admin, err := s.adminRepo.GetByID(ctx, assertion.Credential.SubjectID)
+	if err != nil {
+		return nil, err
+	}
+	if admin == nil {
+		return nil, ErrInvalidCredentials
+	}
+	if !admin.IsActive {
+		logger.Warn("Admin account not active", "email", admin.Email)
+		return nil, ErrAdminNotActive
+	}
+
+	if !assertion.UserVerified && s.twoFactor != nil {
+		challenge, err := s.twoFactorChallenge(ctx, admin, "")
+		if err != nil {
+			return nil, err
+		}
+		if challenge != nil {
+			return &models.PlatformAdminAuthResponse{TwoFactor: challenge}, nil
+		}
+	}
+
+	if err := s.adminRepo.UpdateLastLogin(ctx, admin.ID); err != nil {
+		logger.Error("Failed to update last login", "error", err.Error())
+	}
+
+	token, err := s.generateAdminToken(admin)
+	if err != nil {
+		logger.Error("Failed to generate token", "error", err.Error())
+		return nil, err
+	}
+
+	logger.Info("Platform admin login with passkey successful", "admin_id", admin.ID, "passkey_id", assertion.Credential.ID)
+
+	return &models.PlatformAdminAuthResponse{
+		Token: token,
+		Admin: admin,
+	}, nil
+}
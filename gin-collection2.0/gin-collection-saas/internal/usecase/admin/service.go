@@ -9,6 +9,8 @@ import (
 	"github.com/golang-jwt/jwt/v5"
 	"github.com/yourusername/gin-collection-saas/internal/domain/models"
 	"github.com/yourusername/gin-collection-saas/internal/repository/mysql"
+	"github.com/yourusername/gin-collection-saas/internal/usecase/twofactor"
+	"github.com/yourusername/gin-collection-saas/internal/usecase/webauthn"
 	"github.com/yourusername/gin-collection-saas/pkg/logger"
 	"github.com/yourusername/gin-collection-saas/pkg/utils"
 )
@@ -17,8 +19,22 @@ var (
 	ErrInvalidCredentials = errors.New("invalid email or password")
 	ErrAdminNotActive     = errors.New("admin account is not active")
 	ErrAdminNotFound      = errors.New("admin not found")
+	ErrInvalidChallenge   = errors.New("invalid or expired challenge")
 )
 
+// twoFactorChallengePurpose scopes challenge tokens of platform admin logins
+const twoFactorChallengePurpose = "admin_2fa"
+
+// TwoFactorLockoutPolicy stops guessing second factor codes: after 5 wrong
+// codes the admin's challenges are rejected. The lock outlasts the challenge,
+// so the challenge that was guessed at can't be used anymore.
+var TwoFactorLockoutPolicy = utils.LockoutPolicy{
+	Threshold:    5,
+	BaseDuration: models.TwoFactorChallengeTTL,
+	MaxDuration:  time.Hour,
+	Window:       time.Hour,
+}
+
 // AdminJWTClaims extends standard JWT claims for platform admins
 type AdminJWTClaims struct {
 	AdminID         int64  `json:"admin_id"`
@@ -32,6 +48,10 @@ type Service struct {
 	adminRepo *mysql.PlatformAdminRepository
 	db        *sql.DB
 	jwtSecret string
+	twoFactor *twofactor.Service
+	webAuthn  *webauthn.Service
+
+	twoFactorAttempts *utils.LoginAttemptTracker
 }
 
 // NewService creates a new admin service
@@ -43,8 +63,26 @@ func NewService(adminRepo *mysql.PlatformAdminRepository, db *sql.DB, jwtSecret
 	}
 }
 
-// Login authenticates a platform admin and returns a JWT token
-func (s *Service) Login(ctx context.Context, email, password string) (*models.PlatformAdminAuthResponse, error) {
+// SetTwoFactorService sets the two-factor service. Once set, every platform
+// admin login requires a second factor.
+func (s *Service) SetTwoFactorService(svc *twofactor.Service) {
+	s.twoFactor = svc
+}
+
+// SetTwoFactorAttemptTracker sets the tracker of wrong second factor codes
+// (optional dependency), see TwoFactorLockoutPolicy
+func (s *Service) SetTwoFactorAttemptTracker(tracker *utils.LoginAttemptTracker) {
+	s.twoFactorAttempts = tracker
+}
+
+// SetWebAuthnService sets the passkey service (optional dependency)
+func (s *Service) SetWebAuthnService(svc *webauthn.Service) {
+	s.webAuthn = svc
+}
+
+// Login authenticates a platform admin and returns a JWT token, or a
+// two-factor challenge if the second factor is still pending
+func (s *Service) Login(ctx context.Context, email, password, deviceToken string) (*models.PlatformAdminAuthResponse, error) {
 	logger.Info("Platform admin login attempt", "email", email)
 
 	// Get admin by email
@@ -70,6 +108,17 @@ func (s *Service) Login(ctx context.Context, email, password string) (*models.Pl
 		return nil, ErrInvalidCredentials
 	}
 
+	// Second factor is mandatory for platform admins
+	if s.twoFactor != nil {
+		challenge, err := s.twoFactorChallenge(ctx, admin, deviceToken)
+		if err != nil {
+			return nil, err
+		}
+		if challenge != nil {
+			return &models.PlatformAdminAuthResponse{TwoFactor: challenge}, nil
+		}
+	}
+
 	// Update last login
 	if err := s.adminRepo.UpdateLastLogin(ctx, admin.ID); err != nil {
 		logger.Error("Failed to update last login", "error", err.Error())
@@ -0,0 +1,184 @@
+package admin
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	domainErrors "github.com/yourusername/gin-collection-saas/internal/domain/errors"
+	"github.com/yourusername/gin-collection-saas/internal/domain/models"
+	"github.com/yourusername/gin-collection-saas/pkg/logger"
+	"github.com/yourusername/gin-collection-saas/pkg/utils"
+)
+
+// VerifyTwoFactor completes an admin login challenge. Admins without 2FA
+// confirm their enrollment with the first code and get their recovery codes.
+func (s *Service) VerifyTwoFactor(ctx context.Context, req *models.TwoFactorVerifyRequest, userAgent string) (*models.PlatformAdminAuthResponse, error) {
+	admin, err := s.resolveChallenge(ctx, req.ChallengeToken)
+	if err != nil {
+		return nil, err
+	}
+
+	enabled, err := s.twoFactor.IsEnabled(ctx, models.TwoFactorSubjectPlatformAdmin, admin.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check two-factor status: %w", err)
+	}
+
+	var recoveryCodes []string
+	if enabled {
+		err = s.twoFactor.Verify(ctx, models.TwoFactorSubjectPlatformAdmin, admin.ID, req.Code, req.RecoveryCode)
+	} else {
+		recoveryCodes, err = s.twoFactor.ConfirmEnrollment(ctx, models.TwoFactorSubjectPlatformAdmin, admin.ID, req.Code)
+	}
+	if err != nil {
+		logger.Warn("Admin second factor rejected", "admin_id", admin.ID, "error", err.Error())
+		if errors.Is(err, domainErrors.ErrInvalidTwoFactorCode) && s.recordTwoFactorFailure(ctx, admin.ID) {
+			return nil, ErrInvalidChallenge
+		}
+		return nil, err
+	}
+
+	if s.twoFactorAttempts != nil {
+		if err := s.twoFactorAttempts.Reset(ctx, admin.ID); err != nil {
+			logger.Error("Failed to reset second factor failures", "error", err.Error(), "admin_id", admin.ID)
+		}
+	}
+
+	if err := s.adminRepo.UpdateLastLogin(ctx, admin.ID); err != nil {
+		logger.Error("Failed to update last login", "error", err.Error())
+	}
+
+	token, err := s.generateAdminToken(admin)
+	if err != nil {
+		logger.Error("Failed to generate token", "error", err.Error())
+		return nil, err
+	}
+
+	response := &models.PlatformAdminAuthResponse{
+		Token:         token,
+		Admin:         admin,
+		RecoveryCodes: recoveryCodes,
+	}
+
+	if req.RememberDevice {
+		deviceToken, err := s.twoFactor.TrustDevice(ctx, models.TwoFactorSubjectPlatformAdmin, admin.ID, userAgent)
+		if err != nil {
+			logger.Error("Failed to trust device", "error", err.Error(), "admin_id", admin.ID)
+		} else {
+			response.TrustedDeviceToken = deviceToken
+		}
+	}
+
+	logger.Info("Platform admin login successful", "admin_id", admin.ID, "email", admin.Email)
+
+	return response, nil
+}
+
+// BeginTwoFactorEnrollment starts 2FA setup for an admin during login
+func (s *Service) BeginTwoFactorEnrollment(ctx context.Context, challengeToken string) (*models.TwoFactorEnrollment, error) {
+	admin, err := s.resolveChallenge(ctx, challengeToken)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.twoFactor.BeginEnrollment(ctx, models.TwoFactorSubjectPlatformAdmin, admin.ID, admin.Email)
+}
+
+// GetTwoFactorStatus returns the 2FA setup of an admin
+func (s *Service) GetTwoFactorStatus(ctx context.Context, adminID int64) (*models.TwoFactorStatus, error) {
+	if s.twoFactor == nil {
+		return nil, fmt.Errorf("two-factor authentication not configured")
+	}
+
+	status, err := s.twoFactor.Status(ctx, models.TwoFactorSubjectPlatformAdmin, adminID)
+	if err != nil {
+		return nil, err
+	}
+	status.Required = true
+
+	return status, nil
+}
+
+// RegenerateRecoveryCodes replaces the recovery codes of an admin
+func (s *Service) RegenerateRecoveryCodes(ctx context.Context, adminID int64, code string) ([]string, error) {
+	if s.twoFactor == nil {
+		return nil, fmt.Errorf("two-factor authentication not configured")
+	}
+
+	return s.twoFactor.RegenerateRecoveryCodes(ctx, models.TwoFactorSubjectPlatformAdmin, adminID, code)
+}
+
+// twoFactorChallenge returns a challenge unless the admin logs in from a trusted device
+func (s *Service) twoFactorChallenge(ctx context.Context, admin *models.PlatformAdmin, deviceToken string) (*models.TwoFactorChallenge, error) {
+	enabled, err := s.twoFactor.IsEnabled(ctx, models.TwoFactorSubjectPlatformAdmin, admin.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check two-factor status: %w", err)
+	}
+
+	if enabled && s.twoFactor.IsTrustedDevice(ctx, models.TwoFactorSubjectPlatformAdmin, admin.ID, deviceToken) {
+		return nil, nil
+	}
+
+	token, err := utils.GenerateChallengeToken(admin.ID, 0, twoFactorChallengePurpose, s.jwtSecret, models.TwoFactorChallengeTTL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate challenge token: %w", err)
+	}
+
+	challenge := &models.TwoFactorChallenge{
+		ChallengeToken:     token,
+		Methods:            []string{models.TwoFactorMethodTOTP},
+		EnrollmentRequired: !enabled,
+		ExpiresAt:          time.Now().Add(models.TwoFactorChallengeTTL),
+	}
+	if enabled {
+		challenge.Methods = append(challenge.Methods, models.TwoFactorMethodRecoveryCode)
+	}
+
+	logger.Info("Admin second factor required", "admin_id", admin.ID, "enrollment_required", !enabled)
+
+	return challenge, nil
+}
+
+// resolveChallenge validates a challenge token and loads the admin
+func (s *Service) resolveChallenge(ctx context.Context, challengeToken string) (*models.PlatformAdmin, error) {
+	if s.twoFactor == nil {
+		return nil, fmt.Errorf("two-factor authentication not configured")
+	}
+
+	claims, err := utils.ValidateChallengeToken(challengeToken, twoFactorChallengePurpose, s.jwtSecret)
+	if err != nil {
+		return nil, ErrInvalidChallenge
+	}
+
+	admin, err := s.adminRepo.GetByID(ctx, claims.SubjectID)
+	if err != nil || admin == nil {
+		return nil, ErrInvalidChallenge
+	}
+	if !admin.IsActive {
+		return nil, ErrAdminNotActive
+	}
+
+	// Too many wrong codes invalidate the admin's challenges
+	if s.twoFactorAttempts != nil && s.twoFactorAttempts.LockedFor(ctx, admin.ID) > 0 {
+		logger.Warn("Admin second factor locked", "admin_id", admin.ID)
+		return nil, ErrInvalidChallenge
+	}
+
+	return admin, nil
+}
+
+// recordTwoFactorFailure counts a wrong code and reports whether the admin's
+// challenges are locked now
+func (s *Service) recordTwoFactorFailure(ctx context.Context, adminID int64) bool {
+	if s.twoFactorAttempts == nil {
+		return false
+	}
+
+	failures, lockout := s.twoFactorAttempts.RecordFailure(ctx, adminID)
+	if lockout > 0 {
+		logger.Warn("Admin second factor locked after failed codes", "admin_id", adminID, "failures", failures, "lockout", lockout.String())
+		return true
+	}
+	return false
+}
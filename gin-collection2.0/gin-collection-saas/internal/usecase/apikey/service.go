@@ -0,0 +1,198 @@
+package apikey
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/yourusername/gin-collection-saas/internal/domain/errors"
+	"github.com/yourusername/gin-collection-saas/internal/domain/models"
+	"github.com/yourusername/gin-collection-saas/internal/domain/repositories"
+	"github.com/yourusername/gin-collection-saas/internal/usecase/entitlement"
+	"github.com/yourusername/gin-collection-saas/pkg/logger"
+	"github.com/yourusername/gin-collection-saas/pkg/utils"
+)
+
+// Service handles the API keys of tenant users (Pro and Enterprise)
+type Service struct {
+	apiKeyRepo   repositories.APIKeyRepository
+	userRepo     repositories.UserRepository
+	tenantRepo   repositories.TenantRepository
+	auditLogRepo repositories.AuditLogRepository
+	entitlements *entitlement.Service
+}
+
+// NewService creates a new API key service
+func NewService(
+	apiKeyRepo repositories.APIKeyRepository,
+	userRepo repositories.UserRepository,
+	tenantRepo repositories.TenantRepository,
+	auditLogRepo repositories.AuditLogRepository,
+	entitlements *entitlement.Service,
+) *Service {
+	return &Service{
+		apiKeyRepo:   apiKeyRepo,
+		userRepo:     userRepo,
+		tenantRepo:   tenantRepo,
+		auditLogRepo: auditLogRepo,
+		entitlements: entitlements,
+	}
+}
+
+// Create creates an API key for a user. The key is only returned here, only
+// its hash and visible prefix are stored.
+func (s *Service) Create(ctx context.Context, tenantID, userID int64, req *models.CreateAPIKeyRequest) (*models.CreateAPIKeyResponse, error) {
+	tenant, err := s.tenantRepo.GetByID(ctx, tenantID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get tenant: %w", err)
+	}
+	if err := s.entitlements.RequireFeature(tenant, models.FeatureAPIAccess); err != nil {
+		return nil, err
+	}
+
+	user, err := s.tenantUser(ctx, tenantID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	// A key can't do more than its user, viewers only get read scopes
+	scopes := uniqueScopes(req.Scopes)
+	for _, scope := range scopes {
+		if strings.HasSuffix(scope, ":write") && !user.Role.HasPermission(models.PermissionGinsWrite) {
+			return nil, errors.ErrForbidden
+		}
+	}
+
+	count, err := s.apiKeyRepo.CountActiveByUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if count >= models.MaxAPIKeysPerUser {
+		return nil, errors.ErrLimitReached
+	}
+
+	secret, err := utils.GenerateSecureToken(24)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate API key: %w", err)
+	}
+	plaintext := models.APIKeyPrefix + secret
+
+	key := &models.APIKey{
+		UUID:      uuid.New().String(),
+		TenantID:  tenantID,
+		UserID:    userID,
+		Name:      strings.TrimSpace(req.Name),
+		Prefix:    plaintext[:models.APIKeyVisiblePrefixLength],
+		KeyHash:   utils.HashToken(plaintext),
+		Scopes:    scopes,
+		RateLimit: req.RateLimit,
+		ExpiresAt: req.ExpiresAt,
+		CreatedAt: time.Now(),
+	}
+
+	if err := s.apiKeyRepo.Create(ctx, key); err != nil {
+		return nil, err
+	}
+
+	s.audit(ctx, tenantID, userID, models.AuditActionGenerateAPIKey, key)
+
+	logger.Info("API key created", "user_id", userID, "tenant_id", tenantID, "prefix", key.Prefix, "scopes", strings.Join(scopes, ","))
+
+	return &models.CreateAPIKeyResponse{
+		APIKey: key,
+		Key:    plaintext,
+	}, nil
+}
+
+// List returns the API keys of a user of a tenant
+func (s *Service) List(ctx context.Context, tenantID, userID int64) ([]*models.APIKey, error) {
+	if _, err := s.tenantUser(ctx, tenantID, userID); err != nil {
+		return nil, err
+	}
+
+	keys, err := s.apiKeyRepo.ListByUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if keys == nil {
+		keys = []*models.APIKey{}
+	}
+
+	return keys, nil
+}
+
+// Revoke revokes an API key of a user. Users revoke their own keys, admins
+// also the keys of others.
+func (s *Service) Revoke(ctx context.Context, tenantID, requesterUserID, userID int64, keyID string) error {
+	if _, err := s.tenantUser(ctx, tenantID, userID); err != nil {
+		return err
+	}
+
+	key, err := s.apiKeyRepo.GetByUUID(ctx, keyID)
+	if err != nil {
+		return err
+	}
+	if key.UserID != userID || key.TenantID != tenantID || key.RevokedAt != nil {
+		return errors.ErrNotFound
+	}
+
+	if err := s.apiKeyRepo.Revoke(ctx, key.ID); err != nil {
+		return err
+	}
+
+	s.audit(ctx, tenantID, requesterUserID, models.AuditActionRevokeAPIKey, key)
+
+	logger.Info("API key revoked", "user_id", userID, "tenant_id", tenantID, "prefix", key.Prefix, "revoked_by", requesterUserID)
+
+	return nil
+}
+
+// tenantUser gets a user and verifies it belongs to the tenant
+func (s *Service) tenantUser(ctx context.Context, tenantID, userID int64) (*models.User, error) {
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if user.TenantID != tenantID {
+		return nil, errors.ErrUserNotInTenant
+	}
+	return user, nil
+}
+
+// audit records the creation or revocation of a key on its user
+func (s *Service) audit(ctx context.Context, tenantID, requesterUserID int64, action models.AuditAction, key *models.APIKey) {
+	changes, _ := json.Marshal(map[string]interface{}{
+		"name":   key.Name,
+		"prefix": key.Prefix,
+		"scopes": key.Scopes,
+	})
+	changesStr := string(changes)
+
+	auditLog := &models.AuditLog{
+		TenantID:   tenantID,
+		UserID:     &requesterUserID,
+		Action:     string(action),
+		EntityType: string(models.EntityTypeUser),
+		EntityID:   &key.UserID,
+		Changes:    &changesStr,
+	}
+	if err := s.auditLogRepo.Create(ctx, auditLog); err != nil {
+		logger.Error("Failed to create audit log", "action", action, "error", err.Error())
+	}
+}
+
+// uniqueScopes removes duplicate scopes, keeping their order
+func uniqueScopes(scopes []string) []string {
+	seen := make(map[string]bool, len(scopes))
+	var unique []string
+	for _, scope := range scopes {
+		if !seen[scope] {
+			seen[scope] = true
+			unique = append(unique, scope)
+		}
+	}
+	return unique
+}
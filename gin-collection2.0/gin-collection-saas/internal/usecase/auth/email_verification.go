@@ -0,0 +1,72 @@
+package auth
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/yourusername/gin-collection-saas/internal/domain/errors"
+	"github.com/yourusername/gin-collection-saas/internal/domain/models"
+	"github.com/yourusername/gin-collection-saas/pkg/logger"
+	"github.com/yourusername/gin-collection-saas/pkg/utils"
+)
+
+// VerifyEmail redeems a verification or email change link
+func (s *Service) VerifyEmail(ctx context.Context, token string) (*models.EmailVerification, error) {
+	if s.emailVerification == nil {
+		return nil, fmt.Errorf("email verification not configured")
+	}
+
+	return s.emailVerification.Verify(ctx, token)
+}
+
+// ResendEmailVerification sends a new verification link to the current user
+func (s *Service) ResendEmailVerification(ctx context.Context, userID int64) error {
+	if s.emailVerification == nil {
+		return fmt.Errorf("email verification not configured")
+	}
+
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return fmt.Errorf("failed to get user: %w", err)
+	}
+
+	return s.emailVerification.SendVerification(ctx, user)
+}
+
+// RequestEmailChange starts changing the current user's email. The password
+// is required, so a stolen session alone can't take over the account.
+func (s *Service) RequestEmailChange(ctx context.Context, userID int64, req *models.ChangeEmailRequest) (*models.EmailVerification, error) {
+	if s.emailVerification == nil {
+		return nil, fmt.Errorf("email verification not configured")
+	}
+
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user: %w", err)
+	}
+
+	if !utils.CheckPasswordHash(req.Password, user.PasswordHash) {
+		logger.Debug("Invalid password for email change", "user_id", userID)
+		return nil, errors.ErrInvalidCredentials
+	}
+
+	return s.emailVerification.RequestChange(ctx, user, req.NewEmail)
+}
+
+// GetPendingEmailChange returns the pending email change of the current user, nil if there is none
+func (s *Service) GetPendingEmailChange(ctx context.Context, userID int64) (*models.EmailVerification, error) {
+	if s.emailVerification == nil {
+		return nil, nil
+	}
+
+	return s.emailVerification.PendingChange(ctx, userID)
+}
+
+// CancelEmailChange withdraws the pending email change of the current user
+func (s *Service) CancelEmailChange(ctx context.Context, userID int64) error {
+	if s.emailVerification == nil {
+		return fmt.Errorf("email verification not configured")
+	}
+
+	return s.emailVerification.CancelChange(ctx, userID)
+}
@@ -0,0 +1,229 @@
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"time"
+
+	"github.com/yourusername/gin-collection-saas/internal/domain/errors"
+	"github.com/yourusername/gin-collection-saas/internal/domain/models"
+	"github.com/yourusername/gin-collection-saas/internal/infrastructure/external"
+	"github.com/yourusername/gin-collection-saas/pkg/logger"
+	"github.com/yourusername/gin-collection-saas/pkg/utils"
+)
+
+// Reasons of failed logins in the audit log
+const (
+	failedLoginPassword     = "invalid_password"
+	failedLoginSecondFactor = "invalid_second_factor"
+	failedLoginLocked       = "account_locked"
+)
+
+// checkLockout rejects logins of accounts locked after too many failures.
+// The attempt is audited but doesn't extend the lockout.
+func (s *Service) checkLockout(ctx context.Context, user *models.User) error {
+	if s.loginAttempts == nil {
+		return nil
+	}
+
+	lockedFor := s.loginAttempts.LockedFor(ctx, user.ID)
+	if lockedFor <= 0 {
+		return nil
+	}
+
+	logger.Debug("Login attempt on locked account", "user_id", user.ID, "locked_for", lockedFor.String())
+	s.auditLogin(ctx, user, models.AuditActionFailedLogin, map[string]interface{}{
+		"reason":     failedLoginLocked,
+		"locked_for": int64(lockedFor.Seconds()),
+	})
+	return errors.ErrAccountLocked
+}
+
+// loginFailed counts a failed login of an account. Reaching the threshold
+// locks the account and the user is notified.
+func (s *Service) loginFailed(ctx context.Context, user *models.User, reason string) {
+	changes := map[string]interface{}{"reason": reason}
+
+	if s.loginAttempts != nil {
+		failures, lockout := s.loginAttempts.RecordFailure(ctx, user.ID)
+		changes["failed_attempts"] = failures
+		if lockout > 0 {
+			changes["locked_for"] = int64(lockout.Seconds())
+			logger.Warn("Account locked after failed logins", "user_id", user.ID, "failed_attempts", failures, "locked_for", lockout.String())
+			s.notifyAccountLocked(user, failures, lockout, clientInfoFrom(ctx).ipAddress)
+		}
+	}
+
+	s.auditLogin(ctx, user, models.AuditActionFailedLogin, changes)
+}
+
+// loginSucceeded forgets the failed logins of an account and reports
+// logins from a device or network the user hasn't used before
+func (s *Service) loginSucceeded(ctx context.Context, user *models.User) {
+	if s.loginAttempts != nil {
+		if err := s.loginAttempts.Reset(ctx, user.ID); err != nil {
+			logger.Error("Failed to reset failed logins", "user_id", user.ID, "error", err.Error())
+		}
+	}
+
+	s.recordLoginDevice(ctx, user)
+}
+
+// recordLoginDevice remembers the device and network of a login. The first
+// login of a user only records them, later ones from an unknown device or
+// network are reported by email and in the audit log.
+func (s *Service) recordLoginDevice(ctx context.Context, user *models.User) {
+	info := clientInfoFrom(ctx)
+	if s.loginDeviceRepo == nil || info.ipAddress == "" {
+		return
+	}
+
+	device := utils.DescribeUserAgent(info.userAgent)
+	network := loginNetwork(info.ipAddress)
+
+	known, err := s.loginDeviceRepo.ListByUser(ctx, user.ID)
+	if err != nil {
+		logger.Error("Failed to list login devices", "user_id", user.ID, "error", err.Error())
+		return
+	}
+
+	newDevice, newNetwork := true, true
+	for _, k := range known {
+		if k.Device == device {
+			newDevice = false
+		}
+		if k.Network == network {
+			newNetwork = false
+		}
+	}
+
+	now := time.Now()
+	ipAddress := info.ipAddress
+	if err := s.loginDeviceRepo.Record(ctx, &models.LoginDevice{
+		UserID:      user.ID,
+		Device:      device,
+		Network:     network,
+		IPAddress:   &ipAddress,
+		FirstSeenAt: now,
+		LastSeenAt:  now,
+	}); err != nil {
+		logger.Error("Failed to record login device", "user_id", user.ID, "error", err.Error())
+	}
+
+	if len(known) == 0 || (!newDevice && !newNetwork) {
+		return
+	}
+
+	logger.Info("Login from new device or network", "user_id", user.ID, "device", device, "new_device", newDevice, "new_network", newNetwork)
+	s.auditLogin(ctx, user, models.AuditActionNewDeviceLogin, map[string]interface{}{
+		"device":      device,
+		"new_device":  newDevice,
+		"new_network": newNetwork,
+	})
+
+	if s.emailClient != nil {
+		emailData := &external.NewLoginData{
+			RecipientName: firstName(user),
+			Device:        device,
+			IPAddress:     ipAddress,
+			Time:          now.Format("02.01.2006 15:04 MST"),
+			NewDevice:     newDevice,
+			NewNetwork:    newNetwork,
+			SettingsLink:  fmt.Sprintf("%s/settings", s.baseURL),
+		}
+		if err := s.emailClient.SendNewLogin(user.Email, emailData); err != nil {
+			logger.Error("Failed to send new login notification", "error", err.Error(), "user_id", user.ID)
+		}
+	}
+}
+
+// notifyAccountLocked tells the user the account was locked, resetting the
+// password lifts the lock
+func (s *Service) notifyAccountLocked(user *models.User, failures int64, lockout time.Duration, ipAddress string) {
+	if s.emailClient == nil {
+		return
+	}
+
+	emailData := &external.AccountLockedData{
+		RecipientName:  firstName(user),
+		FailedAttempts: failures,
+		LockedFor:      formatLockout(lockout),
+		IPAddress:      ipAddress,
+		ResetLink:      fmt.Sprintf("%s/forgot-password", s.baseURL),
+	}
+	if err := s.emailClient.SendAccountLocked(user.Email, emailData); err != nil {
+		logger.Error("Failed to send account locked notification", "error", err.Error(), "user_id", user.ID)
+	}
+}
+
+// auditLogin writes an audit log entry about a login of a user
+func (s *Service) auditLogin(ctx context.Context, user *models.User, action models.AuditAction, changes map[string]interface{}) {
+	if s.auditLogRepo == nil {
+		return
+	}
+
+	changesJSON, _ := json.Marshal(changes)
+	changesStr := string(changesJSON)
+	auditLog := &models.AuditLog{
+		TenantID:   user.TenantID,
+		UserID:     &user.ID,
+		Action:     string(action),
+		EntityType: string(models.EntityTypeUser),
+		EntityID:   &user.ID,
+		Changes:    &changesStr,
+	}
+
+	info := clientInfoFrom(ctx)
+	if info.ipAddress != "" {
+		auditLog.IPAddress = &info.ipAddress
+	}
+	if info.userAgent != "" {
+		userAgent := info.userAgent
+		if len(userAgent) > maxUserAgentLength {
+			userAgent = userAgent[:maxUserAgentLength]
+		}
+		auditLog.UserAgent = &userAgent
+	}
+
+	if err := s.auditLogRepo.Create(ctx, auditLog); err != nil {
+		logger.Error("Failed to create audit log", "action", string(action), "user_id", user.ID, "error", err.Error())
+	}
+}
+
+// loginNetwork returns the network an address belongs to: /24 for IPv4 and
+// /48 for IPv6. Addresses that can't be parsed are their own network.
+func loginNetwork(ipAddress string) string {
+	ip := net.ParseIP(ipAddress)
+	if ip == nil {
+		return ipAddress
+	}
+	if ip4 := ip.To4(); ip4 != nil {
+		return (&net.IPNet{IP: ip4.Mask(net.CIDRMask(24, 32)), Mask: net.CIDRMask(24, 32)}).String()
+	}
+	return (&net.IPNet{IP: ip.Mask(net.CIDRMask(48, 128)), Mask: net.CIDRMask(48, 128)}).String()
+}
+
+// formatLockout returns a lockout duration in German, e.g. "4 Minuten"
+func formatLockout(d time.Duration) string {
+	if d >= time.Hour && d%time.Hour == 0 {
+		if d == time.Hour {
+			return "1 Stunde"
+		}
+		return fmt.Sprintf("%d Stunden", int(d.Hours()))
+	}
+	minutes := int((d + time.Minute - 1) / time.Minute)
+	if minutes == 1 {
+		return "1 Minute"
+	}
+	return fmt.Sprintf("%d Minuten", minutes)
+}
+
+// firstName returns the first name used to greet a user
+func firstName(user *models.User) string {
+	if user.FirstName != nil {
+		return *user.FirstName
+	}
+	return ""
+}
@@ -0,0 +1,161 @@
+package auth
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/yourusername/gin-collection-saas/internal/domain/errors"
+	"github.com/yourusername/gin-collection-saas/internal/domain/models"
+	"github.com/yourusername/gin-collection-saas/internal/usecase/webauthn"
+	"github.com/yourusername/gin-collection-saas/pkg/logger"
+)
+
+// BeginPasskeyRegistration starts registering a passkey for the current user
+func (s *Service) BeginPasskeyRegistration(ctx context.Context, userID int64) (*models.WebAuthnRegistrationBegin, error) {
+	if s.webAuthn == nil {
+		return nil, fmt.Errorf("passkeys not configured")
+	}
+
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user: %w", err)
+	}
+
+	return s.webAuthn.BeginRegistration(ctx, webauthn.Account{
+		SubjectType: models.TwoFactorSubjectUser,
+		SubjectID:   user.ID,
+		Name:        user.Email,
+		DisplayName: userDisplayName(user),
+	})
+}
+
+// FinishPasskeyRegistration verifies and stores a passkey of the current user
+func (s *Service) FinishPasskeyRegistration(ctx context.Context, userID int64, req *models.WebAuthnRegistrationRequest) (*models.WebAuthnCredential, error) {
+	if s.webAuthn == nil {
+		return nil, fmt.Errorf("passkeys not configured")
+	}
+
+	return s.webAuthn.FinishRegistration(ctx, models.TwoFactorSubjectUser, userID, req)
+}
+
+// ListPasskeys lists the passkeys of the current user
+func (s *Service) ListPasskeys(ctx context.Context, userID int64) ([]*models.WebAuthnCredential, error) {
+	if s.webAuthn == nil {
+		return nil, fmt.Errorf("passkeys not configured")
+	}
+
+	return s.webAuthn.ListCredentials(ctx, models.TwoFactorSubjectUser, userID)
+}
+
+// DeletePasskey removes a passkey of the current user
+func (s *Service) DeletePasskey(ctx context.Context, userID, passkeyID int64) error {
+	if s.webAuthn == nil {
+		return fmt.Errorf("passkeys not configured")
+	}
+
+	return s.webAuthn.DeleteCredential(ctx, models.TwoFactorSubjectUser, userID, passkeyID)
+}
+
+// BeginPasskeyLogin starts a passkey login. With an email only the passkeys of
+// that user are offered, otherwise the browser lists discoverable passkeys.
+// tenantID is 0 if no tenant was resolved from the request.
+func (s *Service) BeginPasskeyLogin(ctx context.Context, email string, tenantID int64) (*models.WebAuthnLoginBegin, error) {
+	if s.webAuthn == nil {
+		return nil, fmt.Errorf("passkeys not configured")
+	}
+
+	var subjectID *int64
+	if email != "" {
+		var user *models.User
+		var err error
+		if tenantID != 0 {
+			user, err = s.userRepo.GetByEmail(ctx, tenantID, email)
+		} else {
+			user, err = s.userRepo.GetByEmailGlobal(ctx, email)
+		}
+		// Unknown emails fall back to a discoverable login, so the response
+		// doesn't reveal whether an account exists
+		if err == nil && user != nil {
+			subjectID = &user.ID
+		}
+	}
+
+	return s.webAuthn.BeginLogin(ctx, models.TwoFactorSubjectUser, subjectID)
+}
+
+// FinishPasskeyLogin verifies a passkey assertion and logs the user in. A
+// passkey with user verification counts as two factors, otherwise the usual
+// two-factor challenge applies.
+func (s *Service) FinishPasskeyLogin(ctx context.Context, req *models.WebAuthnLoginRequest, tenantID int64) (*models.AuthResponse, error) {
+	if s.webAuthn == nil {
+		return nil, fmt.Errorf("passkeys not configured")
+	}
+
+	assertion, err := s.webAuthn.FinishLogin(ctx, models.TwoFactorSubjectUser, req)
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := s.userRepo.GetByID(ctx, assertion.Credential.SubjectID)
+	if err != nil {
+		logger.Error("Failed to get user of passkey", "user_id", assertion.Credential.SubjectID, "error", err.Error())
+		return nil, errors.ErrPasskeyVerification
+	}
+
+	// A passkey only signs in to the tenant of its user
+	if tenantID != 0 && user.TenantID != tenantID {
+		logger.Debug("Passkey used on foreign tenant", "user_id", user.ID, "tenant_id", tenantID)
+		return nil, errors.ErrPasskeyVerification
+	}
+
+	if !user.IsActive {
+		logger.Debug("Inactive user attempted passkey login", "user_id", user.ID)
+		return nil, errors.ErrForbidden
+	}
+
+	tenant, err := s.tenantRepo.GetByID(ctx, user.TenantID)
+	if err != nil {
+		logger.Error("Failed to get tenant", "tenant_id", user.TenantID, "error", err.Error())
+		return nil, fmt.Errorf("failed to get tenant: %w", err)
+	}
+
+	if tenant.Status != models.TenantStatusActive {
+		logger.Debug("Suspended tenant attempted login", "tenant_id", tenant.ID)
+		return nil, errors.ErrTenantSuspended
+	}
+
+	if !assertion.UserVerified {
+		challenge, err := s.twoFactorChallenge(ctx, user, tenant, "")
+		if err != nil {
+			return nil, err
+		}
+		if challenge != nil {
+			return &models.AuthResponse{TwoFactor: challenge}, nil
+		}
+	}
+
+	authResp, err := s.issueTokens(ctx, user, tenant)
+	if err != nil {
+		return nil, err
+	}
+
+	logger.Info("User logged in with passkey", "user_id", user.ID, "tenant_id", tenant.ID, "passkey_id", assertion.Credential.ID)
+
+	return authResp, nil
+}
+
+// userDisplayName returns the full name of a user, or the email if unset
+func userDisplayName(user *models.User) string {
+	var parts []string
+	if user.FirstName != nil && *user.FirstName != "" {
+		parts = append(parts, *user.FirstName)
+	}
+	if user.LastName != nil && *user.LastName != "" {
+		parts = append(parts, *user.LastName)
+	}
+	if len(parts) == 0 {
+		return user.Email
+	}
+	return strings.Join(parts, " ")
+}
@@ -12,6 +12,10 @@ import (
 	"github.com/yourusername/gin-collection-saas/internal/domain/models"
 	"github.com/yourusername/gin-collection-saas/internal/domain/repositories"
 	"github.com/yourusername/gin-collection-saas/internal/infrastructure/external"
+	"github.com/yourusername/gin-collection-saas/internal/usecase/emailverification"
+	"github.com/yourusername/gin-collection-saas/internal/usecase/sso"
+	"github.com/yourusername/gin-collection-saas/internal/usecase/twofactor"
+	"github.com/yourusername/gin-collection-saas/internal/usecase/webauthn"
 	"github.com/yourusername/gin-collection-saas/pkg/logger"
 	"github.com/yourusername/gin-collection-saas/pkg/utils"
 )
@@ -19,17 +23,29 @@ import (
 // Token expiry duration for password reset
 const PasswordResetTokenExpiry = 1 * time.Hour
 
+// twoFactorChallengePurpose scopes challenge tokens of tenant user logins
+const twoFactorChallengePurpose = "user_2fa"
+
 // Service handles authentication business logic
 type Service struct {
 	userRepo            repositories.UserRepository
 	tenantRepo          repositories.TenantRepository
 	passwordResetRepo   repositories.PasswordResetRepository
 	passwordHistoryRepo repositories.PasswordHistoryRepository
+	inviteRepo          repositories.InviteTokenRepository
+	twoFactor           *twofactor.Service
+	webAuthn            *webauthn.Service
+	sso                 *sso.Service
+	emailVerification   *emailverification.Service
 	emailClient         *external.EmailClient
 	baseURL             string
 	jwtSecret           string
 	jwtExpiration       time.Duration
 	tokenBlacklist      *utils.TokenBlacklist
+	sessionRepo         repositories.SessionRepository
+	auditLogRepo        repositories.AuditLogRepository
+	loginDeviceRepo     repositories.LoginDeviceRepository
+	loginAttempts       *utils.LoginAttemptTracker
 }
 
 // NewService creates a new auth service
@@ -67,11 +83,61 @@ func (s *Service) SetTokenBlacklist(blacklist *utils.TokenBlacklist) {
 	s.tokenBlacklist = blacklist
 }
 
+// SetSessionRepo sets the session repository. Without it refresh tokens are
+// stateless and don't rotate.
+func (s *Service) SetSessionRepo(repo repositories.SessionRepository) {
+	s.sessionRepo = repo
+}
+
 // SetPasswordHistoryRepo sets the password history repository
 func (s *Service) SetPasswordHistoryRepo(repo repositories.PasswordHistoryRepository) {
 	s.passwordHistoryRepo = repo
 }
 
+// SetInviteTokenRepo sets the invite token repository for accepting invitations
+func (s *Service) SetInviteTokenRepo(repo repositories.InviteTokenRepository) {
+	s.inviteRepo = repo
+}
+
+// SetTwoFactorService sets the two-factor service (optional dependency)
+func (s *Service) SetTwoFactorService(svc *twofactor.Service) {
+	s.twoFactor = svc
+}
+
+// SetWebAuthnService sets the passkey service (optional dependency)
+func (s *Service) SetWebAuthnService(svc *webauthn.Service) {
+	s.webAuthn = svc
+}
+
+// SetSSOService sets the single sign-on service (optional dependency)
+func (s *Service) SetSSOService(svc *sso.Service) {
+	s.sso = svc
+}
+
+// SetEmailVerificationService sets the email verification service (optional
+// dependency). Without it addresses aren't verified and the email can't be changed.
+func (s *Service) SetEmailVerificationService(svc *emailverification.Service) {
+	s.emailVerification = svc
+}
+
+// SetLoginAttemptTracker sets the tracker of failed logins (optional
+// dependency). With it accounts are locked after too many failures.
+func (s *Service) SetLoginAttemptTracker(tracker *utils.LoginAttemptTracker) {
+	s.loginAttempts = tracker
+}
+
+// SetAuditLogRepo sets the audit log repository (optional dependency) failed
+// logins and logins from new devices are recorded in
+func (s *Service) SetAuditLogRepo(repo repositories.AuditLogRepository) {
+	s.auditLogRepo = repo
+}
+
+// SetLoginDeviceRepo sets the login device repository (optional dependency).
+// With it users are notified about logins from new devices and networks.
+func (s *Service) SetLoginDeviceRepo(repo repositories.LoginDeviceRepository) {
+	s.loginDeviceRepo = repo
+}
+
 // Register registers a new tenant with an owner user
 func (s *Service) Register(ctx context.Context, req *models.RegisterRequest) (*models.AuthResponse, error) {
 	logger.Info("Registering new tenant", "subdomain", req.Subdomain, "email", req.Email)
@@ -133,40 +199,16 @@ func (s *Service) Register(ctx context.Context, req *models.RegisterRequest) (*m
 		return nil, fmt.Errorf("failed to create user: %w", err)
 	}
 
-	// Generate JWT token
-	token, err := utils.GenerateToken(
-		user.ID,
-		tenant.ID,
-		user.Email,
-		string(user.Role),
-		s.jwtSecret,
-		s.jwtExpiration,
-	)
-	if err != nil {
-		logger.Error("Failed to generate token", "error", err.Error())
-		return nil, fmt.Errorf("failed to generate token: %w", err)
-	}
+	logger.Info("User registered successfully", "user_id", user.ID, "tenant_id", tenant.ID)
 
-	// Generate refresh token
-	refreshToken, err := utils.GenerateRefreshToken(
-		user.ID,
-		tenant.ID,
-		user.Email,
-		s.jwtSecret,
-	)
-	if err != nil {
-		logger.Error("Failed to generate refresh token", "error", err.Error())
-		return nil, fmt.Errorf("failed to generate refresh token: %w", err)
+	// The owner can use the account right away, the address is verified later
+	if s.emailVerification != nil {
+		if err := s.emailVerification.SendVerification(ctx, user); err != nil {
+			logger.Error("Failed to send email verification", "error", err.Error(), "user_id", user.ID)
+		}
 	}
 
-	logger.Info("User registered successfully", "user_id", user.ID, "tenant_id", tenant.ID)
-
-	return &models.AuthResponse{
-		Token:        token,
-		RefreshToken: refreshToken,
-		User:         user,
-		Tenant:       tenant,
-	}, nil
+	return s.issueTokens(ctx, user, tenant)
 }
 
 // Login authenticates a user and returns a JWT token
@@ -186,9 +228,15 @@ func (s *Service) Login(ctx context.Context, req *models.LoginRequest, tenantID
 		return nil, errors.ErrForbidden
 	}
 
+	// Locked accounts can't log in, not even with the right password
+	if err := s.checkLockout(ctx, user); err != nil {
+		return nil, err
+	}
+
 	// Check password
 	if !utils.CheckPasswordHash(req.Password, user.PasswordHash) {
 		logger.Debug("Invalid password", "user_id", user.ID)
+		s.loginFailed(ctx, user, failedLoginPassword)
 		return nil, errors.ErrInvalidCredentials
 	}
 
@@ -205,46 +253,29 @@ func (s *Service) Login(ctx context.Context, req *models.LoginRequest, tenantID
 		return nil, errors.ErrTenantSuspended
 	}
 
-	// Generate JWT token
-	token, err := utils.GenerateToken(
-		user.ID,
-		tenant.ID,
-		user.Email,
-		string(user.Role),
-		s.jwtSecret,
-		s.jwtExpiration,
-	)
-	if err != nil {
-		logger.Error("Failed to generate token", "error", err.Error())
-		return nil, fmt.Errorf("failed to generate token: %w", err)
+	// Tenants with single sign-on may require it
+	if s.passwordLoginDisabled(ctx, user, tenant) {
+		logger.Debug("Password login disabled by tenant", "user_id", user.ID, "tenant_id", tenant.ID)
+		return nil, errors.ErrPasswordLoginDisabled
 	}
 
-	// Generate refresh token
-	refreshToken, err := utils.GenerateRefreshToken(
-		user.ID,
-		tenant.ID,
-		user.Email,
-		s.jwtSecret,
-	)
+	// Ask for the second factor before issuing tokens
+	challenge, err := s.twoFactorChallenge(ctx, user, tenant, req.DeviceToken)
 	if err != nil {
-		logger.Error("Failed to generate refresh token", "error", err.Error())
-		return nil, fmt.Errorf("failed to generate refresh token: %w", err)
+		return nil, err
+	}
+	if challenge != nil {
+		return &models.AuthResponse{TwoFactor: challenge}, nil
 	}
 
-	// Update last login
-	if err := s.userRepo.UpdateLastLogin(ctx, user.ID); err != nil {
-		logger.Error("Failed to update last login", "user_id", user.ID, "error", err.Error())
-		// Don't fail the login, just log the error
+	authResp, err := s.issueTokens(ctx, user, tenant)
+	if err != nil {
+		return nil, err
 	}
 
 	logger.Info("User logged in successfully", "user_id", user.ID)
 
-	return &models.AuthResponse{
-		Token:        token,
-		RefreshToken: refreshToken,
-		User:         user,
-		Tenant:       tenant,
-	}, nil
+	return authResp, nil
 }
 
 // LoginByEmail authenticates a user by email only (without knowing tenant upfront)
@@ -265,9 +296,15 @@ func (s *Service) LoginByEmail(ctx context.Context, req *models.LoginRequest) (*
 		return nil, errors.ErrForbidden
 	}
 
+	// Locked accounts can't log in, not even with the right password
+	if err := s.checkLockout(ctx, user); err != nil {
+		return nil, err
+	}
+
 	// Check password
 	if !utils.CheckPasswordHash(req.Password, user.PasswordHash) {
 		logger.Debug("Invalid password", "user_id", user.ID)
+		s.loginFailed(ctx, user, failedLoginPassword)
 		return nil, errors.ErrInvalidCredentials
 	}
 
@@ -284,71 +321,29 @@ func (s *Service) LoginByEmail(ctx context.Context, req *models.LoginRequest) (*
 		return nil, errors.ErrTenantSuspended
 	}
 
-	// Generate JWT token
-	token, err := utils.GenerateToken(
-		user.ID,
-		tenant.ID,
-		user.Email,
-		string(user.Role),
-		s.jwtSecret,
-		s.jwtExpiration,
-	)
-	if err != nil {
-		logger.Error("Failed to generate token", "error", err.Error())
-		return nil, fmt.Errorf("failed to generate token: %w", err)
+	// Tenants with single sign-on may require it
+	if s.passwordLoginDisabled(ctx, user, tenant) {
+		logger.Debug("Password login disabled by tenant", "user_id", user.ID, "tenant_id", tenant.ID)
+		return nil, errors.ErrPasswordLoginDisabled
 	}
 
-	// Generate refresh token
-	refreshToken, err := utils.GenerateRefreshToken(
-		user.ID,
-		tenant.ID,
-		user.Email,
-		s.jwtSecret,
-	)
+	// Ask for the second factor before issuing tokens
+	challenge, err := s.twoFactorChallenge(ctx, user, tenant, req.DeviceToken)
 	if err != nil {
-		logger.Error("Failed to generate refresh token", "error", err.Error())
-		return nil, fmt.Errorf("failed to generate refresh token: %w", err)
+		return nil, err
 	}
-
-	// Update last login
-	if err := s.userRepo.UpdateLastLogin(ctx, user.ID); err != nil {
-		logger.Error("Failed to update last login", "user_id", user.ID, "error", err.Error())
-		// Don't fail the login, just log the error
+	if challenge != nil {
+		return &models.AuthResponse{TwoFactor: challenge}, nil
 	}
 
-	logger.Info("User logged in successfully via email", "user_id", user.ID, "tenant_id", tenant.ID)
-
-	return &models.AuthResponse{
-		Token:        token,
-		RefreshToken: refreshToken,
-		User:         user,
-		Tenant:       tenant,
-	}, nil
-}
-
-// RefreshToken generates a new access token from a refresh token
-func (s *Service) RefreshToken(ctx context.Context, refreshToken string) (string, error) {
-	// Validate refresh token
-	claims, err := utils.ValidateToken(refreshToken, s.jwtSecret)
+	authResp, err := s.issueTokens(ctx, user, tenant)
 	if err != nil {
-		return "", errors.ErrInvalidToken
-	}
-
-	// Generate new access token
-	newToken, err := utils.GenerateToken(
-		claims.UserID,
-		claims.TenantID,
-		claims.Email,
-		claims.Role,
-		s.jwtSecret,
-		s.jwtExpiration,
-	)
-	if err != nil {
-		logger.Error("Failed to generate new token", "error", err.Error())
-		return "", fmt.Errorf("failed to generate new token: %w", err)
+		return nil, err
 	}
 
-	return newToken, nil
+	logger.Info("User logged in successfully via email", "user_id", user.ID, "tenant_id", tenant.ID)
+
+	return authResp, nil
 }
 
 // UpdateProfile updates the current user's profile information
@@ -457,6 +452,7 @@ func (s *Service) ChangePassword(ctx context.Context, userID int64, currentPassw
 			logger.Info("All user tokens revoked after password change", "user_id", userID)
 		}
 	}
+	s.revokeAllSessions(ctx, userID, models.SessionRevokedPasswordChange)
 
 	logger.Info("User password changed successfully", "user_id", userID)
 
@@ -639,11 +635,100 @@ func (s *Service) ResetPassword(ctx context.Context, token, newPassword string)
 			logger.Info("All user tokens revoked after password reset", "user_id", user.ID)
 		}
 	}
+	s.revokeAllSessions(ctx, user.ID, models.SessionRevokedPasswordChange)
+
+	// The owner of the address proved access, a lockout no longer protects anything
+	if s.loginAttempts != nil {
+		if err := s.loginAttempts.Reset(ctx, user.ID); err != nil {
+			logger.Error("Failed to unlock account after password reset", "error", err.Error(), "user_id", user.ID)
+		}
+	}
 
 	logger.Info("Password reset successful", "user_id", user.ID)
 	return nil
 }
 
+// AcceptInvite redeems an invitation token: the invited user sets a password,
+// is activated and logged in
+func (s *Service) AcceptInvite(ctx context.Context, req *models.AcceptInviteRequest) (*models.AuthResponse, error) {
+	logger.Info("Invite acceptance attempt")
+
+	// Check if invite repo is configured
+	if s.inviteRepo == nil {
+		return nil, fmt.Errorf("invitations not configured")
+	}
+
+	invite, err := s.inviteRepo.GetByTokenHash(ctx, utils.HashToken(req.Token))
+	if err != nil {
+		logger.Debug("Invalid invite token")
+		return nil, errors.ErrInvalidToken
+	}
+
+	if !invite.IsValid() {
+		logger.Debug("Invite token expired or used", "invite_id", invite.ID)
+		return nil, errors.ErrTokenExpired
+	}
+
+	user, err := s.userRepo.GetByID(ctx, invite.UserID)
+	if err != nil {
+		logger.Error("Failed to get invited user", "error", err.Error())
+		return nil, fmt.Errorf("failed to get user: %w", err)
+	}
+
+	tenant, err := s.tenantRepo.GetByID(ctx, invite.TenantID)
+	if err != nil {
+		logger.Error("Failed to get tenant", "tenant_id", invite.TenantID, "error", err.Error())
+		return nil, fmt.Errorf("failed to get tenant: %w", err)
+	}
+
+	if tenant.Status != models.TenantStatusActive {
+		return nil, errors.ErrTenantSuspended
+	}
+
+	// Validate password against policy
+	if err := utils.ValidatePasswordWithPolicy(req.Password); err != nil {
+		logger.Debug("Password validation failed", "error", err.Error(), "user_id", user.ID)
+		return nil, fmt.Errorf("password validation failed: %w", err)
+	}
+
+	passwordHash, err := utils.HashPassword(req.Password)
+	if err != nil {
+		logger.Error("Failed to hash password", "error", err.Error())
+		return nil, fmt.Errorf("failed to hash password: %w", err)
+	}
+
+	user.PasswordHash = passwordHash
+	if req.FirstName != nil {
+		user.FirstName = req.FirstName
+	}
+	if req.LastName != nil {
+		user.LastName = req.LastName
+	}
+
+	// Marks the token as used and activates the user in one step, so a token
+	// can't be redeemed twice
+	if err := s.inviteRepo.Accept(ctx, invite.ID, user); err != nil {
+		if err == errors.ErrInvalidToken {
+			return nil, errors.ErrTokenExpired
+		}
+		logger.Error("Failed to accept invite", "error", err.Error(), "user_id", user.ID)
+		return nil, fmt.Errorf("failed to accept invite: %w", err)
+	}
+
+	logger.Info("Invite accepted", "user_id", user.ID, "tenant_id", tenant.ID)
+
+	// Tenants that require 2FA enroll the new user before the first session
+	challenge, err := s.twoFactorChallenge(ctx, user, tenant, "")
+	if err != nil {
+		return nil, err
+	}
+	if challenge != nil {
+		return &models.AuthResponse{TwoFactor: challenge}, nil
+	}
+
+	return s.issueTokens(ctx, user, tenant)
+}
+
 // ValidateResetToken checks if a password reset token is valid
 func (s *Service) ValidateResetToken(ctx context.Context, token string) (bool, error) {
 	if s.passwordResetRepo == nil {
@@ -0,0 +1,364 @@
+package auth
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/yourusername/gin-collection-saas/internal/domain/errors"
+	"github.com/yourusername/gin-collection-saas/internal/domain/models"
+	"github.com/yourusername/gin-collection-saas/pkg/logger"
+	"github.com/yourusername/gin-collection-saas/pkg/utils"
+)
+
+// maxUserAgentLength is the length of the stored user agent
+const maxUserAgentLength = 255
+
+type clientInfoKey struct{}
+
+// clientInfo is the device a session is used from
+type clientInfo struct {
+	ipAddress string
+	userAgent string
+}
+
+// WithClientInfo returns a context carrying the IP address and user agent
+// sessions started or refreshed with it are recorded with
+func WithClientInfo(ctx context.Context, ipAddress, userAgent string) context.Context {
+	return context.WithValue(ctx, clientInfoKey{}, clientInfo{ipAddress: ipAddress, userAgent: userAgent})
+}
+
+func clientInfoFrom(ctx context.Context) clientInfo {
+	info, _ := ctx.Value(clientInfoKey{}).(clientInfo)
+	return info
+}
+
+// issueTokens generates access and refresh token for a fully authenticated
+// user and starts a session
+func (s *Service) issueTokens(ctx context.Context, user *models.User, tenant *models.Tenant) (*models.AuthResponse, error) {
+	sessionID, refreshToken, err := s.startSession(ctx, user, tenant)
+	if err != nil {
+		return nil, err
+	}
+
+	token, err := utils.GenerateToken(
+		user.ID,
+		tenant.ID,
+		user.Email,
+		string(user.Role),
+		sessionID,
+		s.jwtSecret,
+		s.jwtExpiration,
+	)
+	if err != nil {
+		logger.Error("Failed to generate token", "error", err.Error())
+		return nil, fmt.Errorf("failed to generate token: %w", err)
+	}
+
+	if err := s.userRepo.UpdateLastLogin(ctx, user.ID); err != nil {
+		logger.Error("Failed to update last login", "user_id", user.ID, "error", err.Error())
+	}
+	s.loginSucceeded(ctx, user)
+
+	return &models.AuthResponse{
+		Token:        token,
+		RefreshToken: refreshToken,
+		User:         user,
+		Tenant:       tenant,
+	}, nil
+}
+
+// startSession creates the session of a login and returns its ID and first
+// refresh token. Without a session repository the token has no session.
+func (s *Service) startSession(ctx context.Context, user *models.User, tenant *models.Tenant) (string, string, error) {
+	if s.sessionRepo == nil {
+		refreshToken, _, err := utils.GenerateRefreshToken(user.ID, tenant.ID, user.Email, "", s.jwtSecret)
+		if err != nil {
+			logger.Error("Failed to generate refresh token", "error", err.Error())
+			return "", "", fmt.Errorf("failed to generate refresh token: %w", err)
+		}
+		return "", refreshToken, nil
+	}
+
+	// Expired sessions are cleaned up as new ones start
+	if _, err := s.sessionRepo.DeleteExpired(ctx); err != nil {
+		logger.Error("Failed to delete expired sessions", "error", err.Error())
+	}
+
+	sessionID := uuid.New().String()
+	refreshToken, tokenID, err := utils.GenerateRefreshToken(user.ID, tenant.ID, user.Email, sessionID, s.jwtSecret)
+	if err != nil {
+		logger.Error("Failed to generate refresh token", "error", err.Error())
+		return "", "", fmt.Errorf("failed to generate refresh token: %w", err)
+	}
+
+	now := time.Now()
+	session := &models.Session{
+		UUID:           sessionID,
+		UserID:         user.ID,
+		TenantID:       tenant.ID,
+		RefreshTokenID: tokenID,
+		CreatedAt:      now,
+		LastUsedAt:     now,
+		ExpiresAt:      now.Add(models.SessionExpiry),
+	}
+	setSessionClient(session, clientInfoFrom(ctx))
+
+	if err := s.sessionRepo.Create(ctx, session); err != nil {
+		logger.Error("Failed to create session", "user_id", user.ID, "error", err.Error())
+		return "", "", fmt.Errorf("failed to create session: %w", err)
+	}
+
+	logger.Info("Session started", "user_id", user.ID, "session_id", sessionID, "device", session.Device)
+
+	return sessionID, refreshToken, nil
+}
+
+// RefreshToken issues a new access token and rotates the refresh token of
+// its session. A refresh token that was already rotated is a sign it was
+// stolen, so its reuse revokes the whole session.
+func (s *Service) RefreshToken(ctx context.Context, refreshToken string) (string, string, error) {
+	// Validate refresh token
+	claims, err := utils.ValidateToken(refreshToken, s.jwtSecret)
+	if err != nil {
+		return "", "", errors.ErrInvalidToken
+	}
+
+	user, err := s.userRepo.GetByID(ctx, claims.UserID)
+	if err != nil || user.TenantID != claims.TenantID || !user.IsActive {
+		return "", "", errors.ErrInvalidToken
+	}
+
+	// Stateless refresh tokens are valid until they expire
+	if s.sessionRepo == nil {
+		newToken, err := s.refreshAccessToken(user, "")
+		if err != nil {
+			return "", "", err
+		}
+		return newToken, refreshToken, nil
+	}
+
+	// Refresh tokens issued before sessions existed need a new login
+	if claims.Type != utils.TokenTypeRefresh || claims.SessionID == "" {
+		return "", "", errors.ErrInvalidToken
+	}
+
+	session, err := s.sessionRepo.GetByUUID(ctx, claims.SessionID)
+	if err == errors.ErrNotFound {
+		return "", "", errors.ErrInvalidToken
+	}
+	if err != nil {
+		return "", "", err
+	}
+	if session.UserID != user.ID || !session.IsActive() {
+		return "", "", errors.ErrInvalidToken
+	}
+
+	if session.RefreshTokenID != claims.ID {
+		s.refreshTokenReused(ctx, session)
+		return "", "", errors.ErrInvalidToken
+	}
+
+	newRefreshToken, tokenID, err := utils.GenerateRefreshToken(user.ID, user.TenantID, user.Email, session.UUID, s.jwtSecret)
+	if err != nil {
+		logger.Error("Failed to generate refresh token", "error", err.Error())
+		return "", "", fmt.Errorf("failed to generate refresh token: %w", err)
+	}
+
+	now := time.Now()
+	session.RefreshTokenID = tokenID
+	session.LastUsedAt = now
+	session.ExpiresAt = now.Add(models.SessionExpiry)
+	setSessionClient(session, clientInfoFrom(ctx))
+
+	// Another refresh with the same token won the race
+	err = s.sessionRepo.Rotate(ctx, session, claims.ID)
+	if err == errors.ErrNotFound {
+		s.refreshTokenReused(ctx, session)
+		return "", "", errors.ErrInvalidToken
+	}
+	if err != nil {
+		return "", "", err
+	}
+
+	newToken, err := s.refreshAccessToken(user, session.UUID)
+	if err != nil {
+		return "", "", err
+	}
+
+	return newToken, newRefreshToken, nil
+}
+
+// refreshAccessToken generates an access token with the current role of a user
+func (s *Service) refreshAccessToken(user *models.User, sessionID string) (string, error) {
+	newToken, err := utils.GenerateToken(
+		user.ID,
+		user.TenantID,
+		user.Email,
+		string(user.Role),
+		sessionID,
+		s.jwtSecret,
+		s.jwtExpiration,
+	)
+	if err != nil {
+		logger.Error("Failed to generate new token", "error", err.Error())
+		return "", fmt.Errorf("failed to generate new token: %w", err)
+	}
+
+	return newToken, nil
+}
+
+// refreshTokenReused revokes a session whose old refresh token was presented
+func (s *Service) refreshTokenReused(ctx context.Context, session *models.Session) {
+	logger.Warn("Refresh token reused, revoking session", "user_id", session.UserID, "session_id", session.UUID)
+	if err := s.revokeSession(ctx, session, models.SessionRevokedRefreshTokenReuse); err != nil {
+		logger.Error("Failed to revoke session", "session_id", session.UUID, "error", err.Error())
+	}
+}
+
+// ListSessions returns the active sessions of a user, the one of the request marked as current
+func (s *Service) ListSessions(ctx context.Context, userID int64, currentSessionID string) ([]*models.Session, error) {
+	if s.sessionRepo == nil {
+		return nil, fmt.Errorf("sessions not configured")
+	}
+
+	sessions, err := s.sessionRepo.ListActiveByUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, session := range sessions {
+		session.Current = session.UUID == currentSessionID
+	}
+
+	return sessions, nil
+}
+
+// RevokeSession revokes a session of a user, its tokens stop working
+func (s *Service) RevokeSession(ctx context.Context, userID int64, sessionID string) error {
+	if s.sessionRepo == nil {
+		return fmt.Errorf("sessions not configured")
+	}
+
+	session, err := s.sessionRepo.GetByUUID(ctx, sessionID)
+	if err != nil {
+		return err
+	}
+	if session.UserID != userID || !session.IsActive() {
+		return errors.ErrNotFound
+	}
+
+	if err := s.revokeSession(ctx, session, models.SessionRevokedByUser); err != nil {
+		return err
+	}
+
+	logger.Info("Session revoked", "user_id", userID, "session_id", sessionID)
+
+	return nil
+}
+
+// RevokeOtherSessions logs a user out everywhere except the current session
+// and returns the number of revoked sessions
+func (s *Service) RevokeOtherSessions(ctx context.Context, userID int64, currentSessionID string) (int, error) {
+	if s.sessionRepo == nil {
+		return 0, fmt.Errorf("sessions not configured")
+	}
+
+	var exceptID int64
+	if currentSessionID != "" {
+		current, err := s.sessionRepo.GetByUUID(ctx, currentSessionID)
+		if err != nil && err != errors.ErrNotFound {
+			return 0, err
+		}
+		if current != nil && current.UserID == userID {
+			exceptID = current.ID
+		}
+	}
+
+	revoked, err := s.sessionRepo.RevokeAllByUser(ctx, userID, exceptID, models.SessionRevokedByUser)
+	if err != nil {
+		return 0, err
+	}
+	s.revokeSessionTokens(ctx, revoked...)
+
+	logger.Info("Other sessions revoked", "user_id", userID, "count", len(revoked))
+
+	return len(revoked), nil
+}
+
+// Logout ends the session of a request
+func (s *Service) Logout(ctx context.Context, userID int64, sessionID string) error {
+	if s.sessionRepo == nil || sessionID == "" {
+		return nil
+	}
+
+	session, err := s.sessionRepo.GetByUUID(ctx, sessionID)
+	if err == errors.ErrNotFound {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if session.UserID != userID {
+		return nil
+	}
+
+	return s.revokeSession(ctx, session, models.SessionRevokedLogout)
+}
+
+// revokeSession revokes a session and the access tokens issued for it
+func (s *Service) revokeSession(ctx context.Context, session *models.Session, reason string) error {
+	if err := s.sessionRepo.Revoke(ctx, session.ID, reason); err != nil {
+		return err
+	}
+	s.revokeSessionTokens(ctx, session.UUID)
+	return nil
+}
+
+// revokeAllSessions revokes every session of a user, errors are only logged
+func (s *Service) revokeAllSessions(ctx context.Context, userID int64, reason string) {
+	if s.sessionRepo == nil {
+		return
+	}
+
+	revoked, err := s.sessionRepo.RevokeAllByUser(ctx, userID, 0, reason)
+	if err != nil {
+		logger.Error("Failed to revoke sessions", "user_id", userID, "error", err.Error())
+		return
+	}
+	s.revokeSessionTokens(ctx, revoked...)
+}
+
+// revokeSessionTokens blacklists the access tokens of sessions until the
+// last of them expires
+func (s *Service) revokeSessionTokens(ctx context.Context, sessionIDs ...string) {
+	if s.tokenBlacklist == nil {
+		return
+	}
+
+	until := time.Now().Add(s.jwtExpiration)
+	for _, sessionID := range sessionIDs {
+		if err := s.tokenBlacklist.RevokeSession(ctx, sessionID, until); err != nil {
+			logger.Error("Failed to blacklist session", "session_id", sessionID, "error", err.Error())
+		}
+	}
+}
+
+// setSessionClient records the device a session is used from
+func setSessionClient(session *models.Session, client clientInfo) {
+	if session.Device == "" || client.userAgent != "" {
+		session.Device = utils.DescribeUserAgent(client.userAgent)
+	}
+	if client.ipAddress != "" {
+		ipAddress := client.ipAddress
+		session.IPAddress = &ipAddress
+	}
+	if client.userAgent != "" {
+		userAgent := client.userAgent
+		if len(userAgent) > maxUserAgentLength {
+			userAgent = userAgent[:maxUserAgentLength]
+		}
+		session.UserAgent = &userAgent
+	}
+}
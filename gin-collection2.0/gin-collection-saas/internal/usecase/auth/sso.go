@@ -0,0 +1,70 @@
+package auth
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/yourusername/gin-collection-saas/internal/domain/errors"
+	"github.com/yourusername/gin-collection-saas/internal/domain/models"
+	"github.com/yourusername/gin-collection-saas/internal/usecase/sso"
+	"github.com/yourusername/gin-collection-saas/pkg/logger"
+)
+
+// FinishOIDCLogin completes an OpenID Connect login and returns the tokens
+// together with the path the user wanted to go to
+func (s *Service) FinishOIDCLogin(ctx context.Context, subdomain, state, code string) (*models.AuthResponse, string, error) {
+	if s.sso == nil {
+		return nil, "", fmt.Errorf("single sign-on not configured")
+	}
+
+	tenant, identity, err := s.sso.CompleteOIDC(ctx, subdomain, state, code)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return s.finishSSOLogin(ctx, tenant, identity)
+}
+
+// FinishSAMLLogin completes a SAML login and returns the tokens together with
+// the path the user wanted to go to
+func (s *Service) FinishSAMLLogin(ctx context.Context, subdomain, samlResponse, relayState string) (*models.AuthResponse, string, error) {
+	if s.sso == nil {
+		return nil, "", fmt.Errorf("single sign-on not configured")
+	}
+
+	tenant, identity, err := s.sso.CompleteSAML(ctx, subdomain, samlResponse, relayState)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return s.finishSSOLogin(ctx, tenant, identity)
+}
+
+// finishSSOLogin logs in the user of a verified identity. Multi-factor
+// authentication is the identity provider's job, so no second factor is asked.
+func (s *Service) finishSSOLogin(ctx context.Context, tenant *models.Tenant, identity *sso.Identity) (*models.AuthResponse, string, error) {
+	user, err := s.sso.ResolveUser(ctx, tenant, identity)
+	if err != nil {
+		return nil, "", err
+	}
+
+	if !user.IsActive {
+		logger.Debug("Inactive user attempted SSO login", "user_id", user.ID)
+		return nil, "", errors.ErrForbidden
+	}
+
+	authResp, err := s.issueTokens(ctx, user, tenant)
+	if err != nil {
+		return nil, "", err
+	}
+
+	logger.Info("User logged in with single sign-on", "user_id", user.ID, "tenant_id", tenant.ID, "protocol", identity.Protocol)
+
+	return authResp, identity.RedirectPath, nil
+}
+
+// passwordLoginDisabled reports whether a user has to sign in with SSO. Owners
+// keep their password as a fallback for when the identity provider is unavailable.
+func (s *Service) passwordLoginDisabled(ctx context.Context, user *models.User, tenant *models.Tenant) bool {
+	return s.sso != nil && user.Role != models.RoleOwner && s.sso.PasswordLoginDisabled(ctx, tenant)
+}
@@ -0,0 +1,226 @@
+package auth
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/yourusername/gin-collection-saas/internal/domain/errors"
+	"github.com/yourusername/gin-collection-saas/internal/domain/models"
+	"github.com/yourusername/gin-collection-saas/pkg/logger"
+	"github.com/yourusername/gin-collection-saas/pkg/utils"
+)
+
+// VerifyTwoFactor completes a login challenge with a TOTP or recovery code.
+// If the user had to enroll during login, the code confirms the enrollment
+// and the recovery codes are returned once.
+func (s *Service) VerifyTwoFactor(ctx context.Context, req *models.TwoFactorVerifyRequest, userAgent string) (*models.AuthResponse, error) {
+	user, tenant, err := s.resolveChallenge(ctx, req.ChallengeToken)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.checkLockout(ctx, user); err != nil {
+		return nil, err
+	}
+
+	enabled, err := s.twoFactor.IsEnabled(ctx, models.TwoFactorSubjectUser, user.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check two-factor status: %w", err)
+	}
+
+	var recoveryCodes []string
+	if enabled {
+		err = s.twoFactor.Verify(ctx, models.TwoFactorSubjectUser, user.ID, req.Code, req.RecoveryCode)
+	} else {
+		recoveryCodes, err = s.twoFactor.ConfirmEnrollment(ctx, models.TwoFactorSubjectUser, user.ID, req.Code)
+	}
+	if err != nil {
+		logger.Debug("Second factor rejected", "user_id", user.ID, "error", err.Error())
+		if err == errors.ErrInvalidTwoFactorCode {
+			s.loginFailed(ctx, user, failedLoginSecondFactor)
+		}
+		return nil, err
+	}
+
+	authResp, err := s.issueTokens(ctx, user, tenant)
+	if err != nil {
+		return nil, err
+	}
+	authResp.RecoveryCodes = recoveryCodes
+
+	if req.RememberDevice {
+		deviceToken, err := s.twoFactor.TrustDevice(ctx, models.TwoFactorSubjectUser, user.ID, userAgent)
+		if err != nil {
+			// Don't fail the login, the device just isn't remembered
+			logger.Error("Failed to trust device", "error", err.Error(), "user_id", user.ID)
+		} else {
+			authResp.TrustedDeviceToken = deviceToken
+		}
+	}
+
+	logger.Info("Second factor verified", "user_id", user.ID, "tenant_id", tenant.ID)
+
+	return authResp, nil
+}
+
+// BeginChallengeEnrollment starts 2FA setup for a user whose tenant requires
+// it but who has not enrolled yet. Confirm it with VerifyTwoFactor.
+func (s *Service) BeginChallengeEnrollment(ctx context.Context, challengeToken string) (*models.TwoFactorEnrollment, error) {
+	user, _, err := s.resolveChallenge(ctx, challengeToken)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.twoFactor.BeginEnrollment(ctx, models.TwoFactorSubjectUser, user.ID, user.Email)
+}
+
+// GetTwoFactorStatus returns the 2FA setup of the current user
+func (s *Service) GetTwoFactorStatus(ctx context.Context, userID, tenantID int64) (*models.TwoFactorStatus, error) {
+	if s.twoFactor == nil {
+		return nil, fmt.Errorf("two-factor authentication not configured")
+	}
+
+	status, err := s.twoFactor.Status(ctx, models.TwoFactorSubjectUser, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get two-factor status: %w", err)
+	}
+
+	tenant, err := s.tenantRepo.GetByID(ctx, tenantID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get tenant: %w", err)
+	}
+	status.Required = tenant.RequireTwoFactor
+
+	return status, nil
+}
+
+// BeginTwoFactorSetup starts 2FA setup for the current user
+func (s *Service) BeginTwoFactorSetup(ctx context.Context, userID int64) (*models.TwoFactorEnrollment, error) {
+	if s.twoFactor == nil {
+		return nil, fmt.Errorf("two-factor authentication not configured")
+	}
+
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user: %w", err)
+	}
+
+	return s.twoFactor.BeginEnrollment(ctx, models.TwoFactorSubjectUser, user.ID, user.Email)
+}
+
+// ConfirmTwoFactorSetup enables 2FA for the current user and returns the recovery codes
+func (s *Service) ConfirmTwoFactorSetup(ctx context.Context, userID int64, code string) ([]string, error) {
+	if s.twoFactor == nil {
+		return nil, fmt.Errorf("two-factor authentication not configured")
+	}
+
+	return s.twoFactor.ConfirmEnrollment(ctx, models.TwoFactorSubjectUser, userID, code)
+}
+
+// RegenerateRecoveryCodes replaces the recovery codes of the current user
+func (s *Service) RegenerateRecoveryCodes(ctx context.Context, userID int64, code string) ([]string, error) {
+	if s.twoFactor == nil {
+		return nil, fmt.Errorf("two-factor authentication not configured")
+	}
+
+	return s.twoFactor.RegenerateRecoveryCodes(ctx, models.TwoFactorSubjectUser, userID, code)
+}
+
+// DisableTwoFactor disables 2FA for the current user, unless the tenant requires it
+func (s *Service) DisableTwoFactor(ctx context.Context, userID, tenantID int64, code, recoveryCode string) error {
+	if s.twoFactor == nil {
+		return fmt.Errorf("two-factor authentication not configured")
+	}
+
+	tenant, err := s.tenantRepo.GetByID(ctx, tenantID)
+	if err != nil {
+		return fmt.Errorf("failed to get tenant: %w", err)
+	}
+	if tenant.RequireTwoFactor {
+		return errors.ErrTwoFactorEnforced
+	}
+
+	return s.twoFactor.Disable(ctx, models.TwoFactorSubjectUser, userID, code, recoveryCode)
+}
+
+// ForgetTrustedDevices makes all remembered devices of the current user ask for 2FA again
+func (s *Service) ForgetTrustedDevices(ctx context.Context, userID int64) error {
+	if s.twoFactor == nil {
+		return fmt.Errorf("two-factor authentication not configured")
+	}
+
+	return s.twoFactor.ForgetDevices(ctx, models.TwoFactorSubjectUser, userID)
+}
+
+// twoFactorChallenge returns a challenge if the user has to provide a second
+// factor: when 2FA is enabled (and the device isn't trusted) or the tenant
+// requires it. Returns nil if tokens can be issued right away.
+func (s *Service) twoFactorChallenge(ctx context.Context, user *models.User, tenant *models.Tenant, deviceToken string) (*models.TwoFactorChallenge, error) {
+	if s.twoFactor == nil {
+		return nil, nil
+	}
+
+	enabled, err := s.twoFactor.IsEnabled(ctx, models.TwoFactorSubjectUser, user.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check two-factor status: %w", err)
+	}
+
+	if !enabled && !tenant.RequireTwoFactor {
+		return nil, nil
+	}
+	if enabled && s.twoFactor.IsTrustedDevice(ctx, models.TwoFactorSubjectUser, user.ID, deviceToken) {
+		logger.Debug("Second factor skipped on trusted device", "user_id", user.ID)
+		return nil, nil
+	}
+
+	token, err := utils.GenerateChallengeToken(user.ID, tenant.ID, twoFactorChallengePurpose, s.jwtSecret, models.TwoFactorChallengeTTL)
+	if err != nil {
+		logger.Error("Failed to generate challenge token", "error", err.Error())
+		return nil, fmt.Errorf("failed to generate challenge token: %w", err)
+	}
+
+	challenge := &models.TwoFactorChallenge{
+		ChallengeToken:     token,
+		Methods:            []string{models.TwoFactorMethodTOTP},
+		EnrollmentRequired: !enabled,
+		ExpiresAt:          time.Now().Add(models.TwoFactorChallengeTTL),
+	}
+	if enabled {
+		challenge.Methods = append(challenge.Methods, models.TwoFactorMethodRecoveryCode)
+	}
+
+	logger.Info("Second factor required", "user_id", user.ID, "enrollment_required", !enabled)
+
+	return challenge, nil
+}
+
+// resolveChallenge validates a challenge token and loads user and tenant
+func (s *Service) resolveChallenge(ctx context.Context, challengeToken string) (*models.User, *models.Tenant, error) {
+	if s.twoFactor == nil {
+		return nil, nil, fmt.Errorf("two-factor authentication not configured")
+	}
+
+	claims, err := utils.ValidateChallengeToken(challengeToken, twoFactorChallengePurpose, s.jwtSecret)
+	if err != nil {
+		return nil, nil, errors.ErrInvalidToken
+	}
+
+	user, err := s.userRepo.GetByID(ctx, claims.SubjectID)
+	if err != nil {
+		return nil, nil, errors.ErrInvalidToken
+	}
+	if user.TenantID != claims.TenantID || !user.IsActive {
+		return nil, nil, errors.ErrInvalidToken
+	}
+
+	tenant, err := s.tenantRepo.GetByID(ctx, claims.TenantID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get tenant: %w", err)
+	}
+	if tenant.Status != models.TenantStatusActive {
+		return nil, nil, errors.ErrTenantSuspended
+	}
+
+	return user, tenant, nil
+}
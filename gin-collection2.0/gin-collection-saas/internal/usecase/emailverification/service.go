@@ -0,0 +1,282 @@
+package emailverification
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/yourusername/gin-collection-saas/internal/domain/errors"
+	"github.com/yourusername/gin-collection-saas/internal/domain/models"
+	"github.com/yourusername/gin-collection-saas/internal/domain/repositories"
+	"github.com/yourusername/gin-collection-saas/internal/infrastructure/external"
+	"github.com/yourusername/gin-collection-saas/pkg/logger"
+	"github.com/yourusername/gin-collection-saas/pkg/utils"
+)
+
+// Service handles verification of user email addresses and email changes
+// confirmed through the current and the new address
+type Service struct {
+	repo        repositories.EmailVerificationRepository
+	userRepo    repositories.UserRepository
+	emailClient *external.EmailClient
+	baseURL     string
+}
+
+// NewService creates a new email verification service. Without an email
+// client the links are created but not sent.
+func NewService(
+	repo repositories.EmailVerificationRepository,
+	userRepo repositories.UserRepository,
+	emailClient *external.EmailClient,
+	baseURL string,
+) *Service {
+	return &Service{
+		repo:        repo,
+		userRepo:    userRepo,
+		emailClient: emailClient,
+		baseURL:     baseURL,
+	}
+}
+
+// SendVerification replaces a pending verification of the user's address
+// with a new link and sends it
+func (s *Service) SendVerification(ctx context.Context, user *models.User) error {
+	if user.IsEmailVerified() {
+		return errors.ErrEmailAlreadyVerified
+	}
+
+	if err := s.repo.DeletePending(ctx, user.ID, models.EmailVerificationPurposeVerify); err != nil {
+		return fmt.Errorf("failed to delete old verification: %w", err)
+	}
+
+	token, err := utils.GenerateSecureToken(32)
+	if err != nil {
+		return fmt.Errorf("failed to generate verification token: %w", err)
+	}
+
+	verification := &models.EmailVerification{
+		UserID:    user.ID,
+		Purpose:   models.EmailVerificationPurposeVerify,
+		Email:     user.Email,
+		TokenHash: utils.HashToken(token),
+		ExpiresAt: time.Now().Add(models.EmailVerificationExpiry),
+	}
+
+	if err := s.repo.Create(ctx, verification); err != nil {
+		return fmt.Errorf("failed to create verification: %w", err)
+	}
+
+	if s.emailClient != nil {
+		emailData := &external.EmailVerificationData{
+			RecipientName: recipientName(user),
+			VerifyLink:    s.link(token),
+			ExpiresIn:     "48 Stunden",
+		}
+		if err := s.emailClient.SendEmailVerification(user.Email, emailData); err != nil {
+			// Don't fail, the link can be sent again
+			logger.Error("Failed to send verification email", "error", err.Error(), "user_id", user.ID)
+		}
+	}
+
+	logger.Info("Email verification sent", "user_id", user.ID)
+	return nil
+}
+
+// RequestChange starts changing the email of a user. Both addresses get a
+// confirmation link, the email is changed once both were confirmed. A
+// previous pending change is replaced.
+func (s *Service) RequestChange(ctx context.Context, user *models.User, newEmail string) (*models.EmailVerification, error) {
+	newEmail = strings.TrimSpace(newEmail)
+	if newEmail == "" || strings.EqualFold(newEmail, user.Email) {
+		return nil, errors.ErrInvalidInput
+	}
+
+	// The address must be free within the tenant
+	existing, err := s.userRepo.GetByEmail(ctx, user.TenantID, newEmail)
+	if err != nil && err != errors.ErrNotFound {
+		return nil, fmt.Errorf("failed to check email: %w", err)
+	}
+	if existing != nil {
+		return nil, errors.ErrEmailAlreadyExists
+	}
+
+	if err := s.repo.DeletePending(ctx, user.ID, models.EmailVerificationPurposeChange); err != nil {
+		return nil, fmt.Errorf("failed to delete pending email change: %w", err)
+	}
+
+	newToken, err := utils.GenerateSecureToken(32)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate verification token: %w", err)
+	}
+	oldToken, err := utils.GenerateSecureToken(32)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate verification token: %w", err)
+	}
+	oldTokenHash := utils.HashToken(oldToken)
+
+	verification := &models.EmailVerification{
+		UserID:       user.ID,
+		Purpose:      models.EmailVerificationPurposeChange,
+		Email:        newEmail,
+		TokenHash:    utils.HashToken(newToken),
+		OldTokenHash: &oldTokenHash,
+		ExpiresAt:    time.Now().Add(models.EmailChangeExpiry),
+	}
+
+	if err := s.repo.Create(ctx, verification); err != nil {
+		return nil, fmt.Errorf("failed to create email change: %w", err)
+	}
+
+	if s.emailClient != nil {
+		for _, recipient := range []struct {
+			email string
+			token string
+			isNew bool
+		}{
+			{newEmail, newToken, true},
+			{user.Email, oldToken, false},
+		} {
+			emailData := &external.EmailChangeData{
+				RecipientName: recipientName(user),
+				OldEmail:      user.Email,
+				NewEmail:      newEmail,
+				ConfirmLink:   s.link(recipient.token),
+				ExpiresIn:     "24 Stunden",
+				ToNewAddress:  recipient.isNew,
+			}
+			if err := s.emailClient.SendEmailChangeConfirmation(recipient.email, emailData); err != nil {
+				logger.Error("Failed to send email change confirmation", "error", err.Error(), "user_id", user.ID)
+			}
+		}
+	}
+
+	logger.Info("Email change requested", "user_id", user.ID)
+	return verification, nil
+}
+
+// PendingChange returns the pending email change of a user, nil if there is none
+func (s *Service) PendingChange(ctx context.Context, userID int64) (*models.EmailVerification, error) {
+	verification, err := s.repo.GetPending(ctx, userID, models.EmailVerificationPurposeChange)
+	if err == errors.ErrNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if verification.IsExpired() {
+		return nil, nil
+	}
+	return verification, nil
+}
+
+// CancelChange withdraws a pending email change, its links stop working
+func (s *Service) CancelChange(ctx context.Context, userID int64) error {
+	if err := s.repo.DeletePending(ctx, userID, models.EmailVerificationPurposeChange); err != nil {
+		return fmt.Errorf("failed to cancel email change: %w", err)
+	}
+
+	logger.Info("Email change cancelled", "user_id", userID)
+	return nil
+}
+
+// Verify redeems a link. For an email change the address of the token is
+// marked as confirmed and the change is applied once the other address
+// confirmed too. The returned verification tells what is still missing.
+func (s *Service) Verify(ctx context.Context, token string) (*models.EmailVerification, error) {
+	tokenHash := utils.HashToken(token)
+
+	verification, err := s.repo.GetByTokenHash(ctx, tokenHash)
+	if err == errors.ErrNotFound {
+		return nil, errors.ErrInvalidToken
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if !verification.IsValid() {
+		logger.Debug("Email verification expired or completed", "user_id", verification.UserID)
+		return nil, errors.ErrTokenExpired
+	}
+
+	old := verification.OldTokenHash != nil && *verification.OldTokenHash == tokenHash
+	if err := s.repo.Confirm(ctx, verification.ID, old); err != nil {
+		if err == errors.ErrInvalidToken {
+			return nil, errors.ErrTokenExpired
+		}
+		return nil, err
+	}
+
+	now := time.Now()
+	if old {
+		verification.OldConfirmedAt = &now
+	} else {
+		verification.ConfirmedAt = &now
+	}
+
+	if !verification.IsConfirmed() {
+		logger.Info("Email change confirmed by one address", "user_id", verification.UserID, "current_address", old)
+		return verification, nil
+	}
+
+	user, err := s.userRepo.GetByID(ctx, verification.UserID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user: %w", err)
+	}
+	previousEmail := user.Email
+
+	if verification.Purpose == models.EmailVerificationPurposeChange {
+		// Someone may have taken the address in the meantime
+		existing, err := s.userRepo.GetByEmail(ctx, user.TenantID, verification.Email)
+		if err != nil && err != errors.ErrNotFound {
+			return nil, fmt.Errorf("failed to check email: %w", err)
+		}
+		if existing != nil && existing.ID != user.ID {
+			return nil, errors.ErrEmailAlreadyExists
+		}
+		user.Email = verification.Email
+	} else if !strings.EqualFold(user.Email, verification.Email) {
+		// The address was changed after the link was sent
+		return nil, errors.ErrTokenExpired
+	}
+	user.EmailVerifiedAt = &now
+
+	if err := s.repo.Complete(ctx, verification.ID, user); err != nil {
+		if err == errors.ErrInvalidToken {
+			return nil, errors.ErrTokenExpired
+		}
+		return nil, err
+	}
+	verification.CompletedAt = &now
+
+	if verification.Purpose == models.EmailVerificationPurposeChange {
+		logger.Info("Email changed", "user_id", user.ID)
+		if s.emailClient != nil {
+			emailData := &external.EmailChangedData{
+				RecipientName: recipientName(user),
+				OldEmail:      previousEmail,
+				NewEmail:      user.Email,
+			}
+			if err := s.emailClient.SendEmailChanged(previousEmail, emailData); err != nil {
+				logger.Error("Failed to send email changed notification", "error", err.Error(), "user_id", user.ID)
+			}
+		}
+	} else {
+		logger.Info("Email verified", "user_id", user.ID)
+	}
+
+	return verification, nil
+}
+
+// link returns the frontend link for redeeming a token
+func (s *Service) link(token string) string {
+	return fmt.Sprintf("%s/verify-email?token=%s", s.baseURL, token)
+}
+
+// recipientName returns the first name used to greet a user
+func recipientName(user *models.User) string {
+	if user.FirstName != nil {
+		return *user.FirstName
+	}
+	return ""
+}
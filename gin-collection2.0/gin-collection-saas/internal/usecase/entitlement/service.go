@@ -0,0 +1,234 @@
+package entitlement
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	domainErrors "github.com/yourusername/gin-collection-saas/internal/domain/errors"
+	"github.com/yourusername/gin-collection-saas/internal/domain/models"
+	"github.com/yourusername/gin-collection-saas/internal/domain/repositories"
+	"github.com/yourusername/gin-collection-saas/pkg/logger"
+)
+
+// PlanCatalog lists the plans upgrade hints are taken from
+type PlanCatalog interface {
+	Plans() []models.SubscriptionPlan
+}
+
+// Service decides what a tenant may use: the features of its plan and how
+// much it may add within the limits. Tenants with a payment overdue past the
+// grace period are held to the Free limits. Denials carry the reason and the
+// lowest tier that allows what was denied.
+type Service struct {
+	storageUsageRepo repositories.StorageUsageRepository
+	catalog          PlanCatalog
+}
+
+// NewService creates a new entitlement service
+func NewService(storageUsageRepo repositories.StorageUsageRepository) *Service {
+	return &Service{
+		storageUsageRepo: storageUsageRepo,
+	}
+}
+
+// SetPlanCatalog takes upgrade hints from the plans offered in the catalog
+// (optional dependency). Without it hints use the built-in tier limits.
+func (s *Service) SetPlanCatalog(catalog PlanCatalog) {
+	s.catalog = catalog
+}
+
+// Feature decides whether a tenant may use a feature. Features that aren't
+// declared in models.FeatureRules are denied.
+func (s *Service) Feature(tenant *models.Tenant, feature string) *models.Entitlement {
+	rule := models.FeatureRuleFor(feature)
+	if rule == nil {
+		logger.Warn("Unknown feature denied", "tenant_id", tenant.ID, "feature", feature)
+		return models.NewDenial(tenant, feature, models.DenialUnknownFeature, fmt.Sprintf("unknown feature %q", feature), domainErrors.ErrFeatureNotAvailable)
+	}
+
+	if rule.Included(tenant.EnforcedLimits()) {
+		return allowed(tenant, feature)
+	}
+	if tenant.BillingRestricted && rule.Included(tenant.GetLimits()) {
+		return models.NewDenial(tenant, feature, models.DenialPaymentOverdue, domainErrors.ErrPaymentOverdue.Error(), domainErrors.ErrPaymentOverdue)
+	}
+
+	denial := models.NewDenial(tenant, feature, models.DenialFeatureNotInPlan, "", rule.Err)
+	s.hint(denial, tenant, rule.Included)
+	if denial.RequiredTier != "" {
+		denial.Message = rule.Name + " requires " + tierName(denial.RequiredTier) + " tier"
+	} else {
+		denial.Message = rule.Name + " is not available in your plan"
+	}
+	return denial
+}
+
+// RequireFeature returns the denial of a feature as error, nil if the tenant
+// may use it
+func (s *Service) RequireFeature(tenant *models.Tenant, feature string) error {
+	return s.Feature(tenant, feature).Err()
+}
+
+// Included reports whether the plan of a tenant includes a feature, also
+// while a payment is overdue. For sign-in and provisioning, which keep
+// working so the tenant can still pay.
+func (s *Service) Included(tenant *models.Tenant, feature string) bool {
+	rule := models.FeatureRuleFor(feature)
+	return rule != nil && rule.Included(tenant.GetLimits())
+}
+
+// GinLimit decides whether a tenant with ginCount gins may add another
+func (s *Service) GinLimit(tenant *models.Tenant, ginCount int) *models.Entitlement {
+	allows := func(l models.PlanLimits) bool { return l.MaxGins == nil || ginCount < *l.MaxGins }
+
+	limits := tenant.EnforcedLimits()
+	if allows(limits) {
+		return allowed(tenant, models.LimitGins)
+	}
+
+	denial := models.NewDenial(tenant, models.LimitGins, models.DenialLimitReached, "Gin limit reached. Please upgrade to add more gins.", domainErrors.ErrLimitReached)
+	denial.Limit = limits.MaxGins
+	denial.Current = &ginCount
+	s.hint(denial, tenant, allows)
+	return denial
+}
+
+// Collection decides whether a tenant with ginCount gins may change its
+// collection. Over the gin limit (e.g. after a downgrade) it is read-only.
+func (s *Service) Collection(tenant *models.Tenant, ginCount int) *models.Entitlement {
+	allows := func(l models.PlanLimits) bool { return l.GinsOverLimit(ginCount) == 0 }
+
+	limits := tenant.EnforcedLimits()
+	if allows(limits) {
+		return allowed(tenant, models.LimitGins)
+	}
+
+	denial := models.NewDenial(tenant, models.LimitGins, models.DenialOverLimit, domainErrors.ErrOverLimit.Error(), domainErrors.ErrOverLimit)
+	denial.Limit = limits.MaxGins
+	denial.Current = &ginCount
+	denial.OverLimit = limits.GinsOverLimit(ginCount)
+	s.hint(denial, tenant, allows)
+	return denial
+}
+
+// PhotoLimit decides whether a tenant may add a photo to a gin with photoCount photos
+func (s *Service) PhotoLimit(tenant *models.Tenant, photoCount int) *models.Entitlement {
+	allows := func(l models.PlanLimits) bool { return l.MaxPhotosPerGin < 0 || photoCount < l.MaxPhotosPerGin }
+
+	limits := tenant.EnforcedLimits()
+	if allows(limits) {
+		return allowed(tenant, models.LimitPhotosPerGin)
+	}
+
+	denial := models.NewDenial(tenant, models.LimitPhotosPerGin, models.DenialLimitReached, "Photo limit reached for this gin. Please upgrade to add more photos.", domainErrors.ErrPhotoLimitReached)
+	denial.Limit = &limits.MaxPhotosPerGin
+	denial.Current = &photoCount
+	s.hint(denial, tenant, allows)
+	return denial
+}
+
+// StorageLimit decides whether a tenant may store addBytes more
+func (s *Service) StorageLimit(ctx context.Context, tenant *models.Tenant, addBytes int64) (*models.Entitlement, error) {
+	limits := tenant.EnforcedLimits()
+	if limits.StorageLimitMB == nil {
+		return allowed(tenant, models.LimitStorageMB), nil
+	}
+
+	usage, err := s.storageUsageRepo.GetUsage(ctx, tenant.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get storage usage: %w", err)
+	}
+
+	allows := func(l models.PlanLimits) bool { return !usage.WouldExceed(addBytes, l.StorageLimitMB) }
+	if allows(limits) {
+		return allowed(tenant, models.LimitStorageMB), nil
+	}
+
+	usedMB := int((usage.BytesUsed + models.BytesPerMB - 1) / models.BytesPerMB)
+	denial := models.NewDenial(tenant, models.LimitStorageMB, models.DenialLimitReached, "Storage limit would be exceeded. Please upgrade for more storage.", domainErrors.ErrStorageLimitReached)
+	denial.Limit = limits.StorageLimitMB
+	denial.Current = &usedMB
+	s.hint(denial, tenant, allows)
+	return denial, nil
+}
+
+// UsageLimit turns a metered metric used up for the billing period (see
+// usage.Service.Check) into a denial with an upgrade hint
+func (s *Service) UsageLimit(tenant *models.Tenant, meter *models.UsageMeter) *models.Entitlement {
+	allows := func(l models.PlanLimits) bool {
+		limit := l.MeteredLimit(meter.Metric)
+		return limit == nil || meter.Current < *limit
+	}
+
+	if !meter.Reached {
+		return allowed(tenant, meter.Metric)
+	}
+
+	current := meter.Current
+	denial := models.NewDenial(tenant, meter.Metric, models.DenialLimitReached, "", domainErrors.ErrUsageLimitReached)
+	denial.Limit = meter.Limit
+	denial.Current = &current
+	s.hint(denial, tenant, allows)
+	return denial
+}
+
+// PhotoQuota returns the limits enforced again when a photo is stored, so
+// concurrent uploads can't pass PhotoLimit and StorageLimit together
+func (s *Service) PhotoQuota(tenant *models.Tenant) *models.PhotoQuota {
+	limits := tenant.EnforcedLimits()
+	return &models.PhotoQuota{
+		MaxPhotosPerGin: limits.MaxPhotosPerGin,
+		StorageLimitMB:  limits.StorageLimitMB,
+	}
+}
+
+// hint adds the lowest tier (and plan, with a catalog) above the tenant's
+// that allows what was denied. The tenant's limit overrides carry over to
+// the new plan. Nothing is hinted if paying the overdue bill is enough.
+func (s *Service) hint(denial *models.Entitlement, tenant *models.Tenant, allows func(models.PlanLimits) bool) {
+	if tenant.BillingRestricted && allows(tenant.GetLimits()) {
+		return
+	}
+
+	if s.catalog != nil {
+		var best *models.SubscriptionPlan
+		plans := s.catalog.Plans()
+		for i := range plans {
+			plan := &plans[i]
+			if !plan.Active || !models.IsUpgrade(tenant.Tier, plan.Tier) || !allows(tenant.LimitOverrides.Apply(plan.Limits)) {
+				continue
+			}
+			// Plans are in sort order, the first of the lowest tier wins
+			if best == nil || models.IsDowngrade(best.Tier, plan.Tier) {
+				best = plan
+			}
+		}
+		if best != nil {
+			denial.RequiredTier = best.Tier
+			denial.UpgradePlanID = best.ID
+		}
+		return
+	}
+
+	for _, tier := range models.Tiers {
+		if models.IsUpgrade(tenant.Tier, tier) && allows(tenant.LimitOverrides.Apply(models.PlanLimitsMap[tier])) {
+			denial.RequiredTier = tier
+			return
+		}
+	}
+}
+
+// allowed creates an allowed entitlement
+func allowed(tenant *models.Tenant, feature string) *models.Entitlement {
+	return &models.Entitlement{Allowed: true, Feature: feature, CurrentTier: tenant.Tier}
+}
+
+// tierName returns the display name of a tier, e.g. "Pro"
+func tierName(tier models.SubscriptionTier) string {
+	name := string(tier)
+	if name == "" {
+		return name
+	}
+	return strings.ToUpper(name[:1]) + name[1:]
+}
@@ -0,0 +1,287 @@
+package labelscan
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/yourusername/gin-collection-saas/internal/infrastructure/external"
+)
+
+// Label fields reported with a confidence
+const (
+	FieldName       = "name"
+	FieldBrand      = "brand"
+	FieldABV        = "abv"
+	FieldBottleSize = "bottle_size"
+	FieldCountry    = "country"
+)
+
+// RecognizedLabel holds the details a recognizer read from a label photo
+type RecognizedLabel struct {
+	Text         string             `json:"text,omitempty"`
+	Name         string             `json:"name,omitempty"`
+	Brand        string             `json:"brand,omitempty"`
+	ABV          *float64           `json:"abv,omitempty"`
+	BottleSizeML *int               `json:"bottle_size_ml,omitempty"`
+	Country      string             `json:"country,omitempty"`
+	Confidence   map[string]float64 `json:"confidence"` // per field, 0.0 - 1.0
+}
+
+// LabelRecognizer extracts gin details from a label photo
+type LabelRecognizer interface {
+	RecognizeLabel(ctx context.Context, image []byte, contentType string) (*RecognizedLabel, error)
+}
+
+// LocalRecognizer is a deterministic recognizer that looks up the label text
+// of known images by checksum and parses it. Unknown images yield an empty label.
+type LocalRecognizer struct {
+	mu     sync.RWMutex
+	labels map[string]string
+}
+
+// NewLocalRecognizer creates a new local recognizer
+func NewLocalRecognizer() *LocalRecognizer {
+	return &LocalRecognizer{
+		labels: make(map[string]string),
+	}
+}
+
+// Register sets the label text returned for an image
+func (r *LocalRecognizer) Register(image []byte, labelText string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.labels[imageChecksum(image)] = labelText
+}
+
+// RecognizeLabel parses the registered label text of an image
+func (r *LocalRecognizer) RecognizeLabel(ctx context.Context, image []byte, contentType string) (*RecognizedLabel, error) {
+	r.mu.RLock()
+	text := r.labels[imageChecksum(image)]
+	r.mu.RUnlock()
+
+	return ParseLabelText(text), nil
+}
+
+// AIRecognizer reads labels with the configured AI provider
+type AIRecognizer struct {
+	aiClient *external.AIClient
+}
+
+// NewAIRecognizer creates a recognizer backed by the AI client
+func NewAIRecognizer(aiClient *external.AIClient) *AIRecognizer {
+	return &AIRecognizer{
+		aiClient: aiClient,
+	}
+}
+
+// RecognizeLabel sends the label photo to the AI provider
+func (r *AIRecognizer) RecognizeLabel(ctx context.Context, image []byte, contentType string) (*RecognizedLabel, error) {
+	extraction, err := r.aiClient.ExtractLabelInfo(image, contentType)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read label: %w", err)
+	}
+
+	label := &RecognizedLabel{
+		Text:       extraction.Text,
+		Name:       strings.TrimSpace(extraction.Name),
+		Brand:      strings.TrimSpace(extraction.Brand),
+		Country:    strings.TrimSpace(extraction.Country),
+		Confidence: make(map[string]float64),
+	}
+
+	if extraction.ABV > 0 {
+		abv := extraction.ABV
+		label.ABV = &abv
+	}
+	if extraction.BottleSizeML > 0 {
+		size := extraction.BottleSizeML
+		label.BottleSizeML = &size
+	}
+
+	// Only keep confidences of fields that were actually read
+	for field, present := range map[string]bool{
+		FieldName:       label.Name != "",
+		FieldBrand:      label.Brand != "",
+		FieldABV:        label.ABV != nil,
+		FieldBottleSize: label.BottleSizeML != nil,
+		FieldCountry:    label.Country != "",
+	} {
+		if present {
+			label.Confidence[field] = clampConfidence(extraction.Confidence[field])
+		}
+	}
+
+	// The model may still have read the text but parse it better than we do
+	if label.Name == "" && extraction.Text != "" {
+		parsed := ParseLabelText(extraction.Text)
+		label.Name = parsed.Name
+		label.Confidence[FieldName] = parsed.Confidence[FieldName]
+	}
+
+	return label, nil
+}
+
+var (
+	abvPattern  = regexp.MustCompile(`(?i)(\d{2}(?:[.,]\d{1,2})?)\s*%\s*(vol|alc|abv)?`)
+	sizePattern = regexp.MustCompile(`(?i)(\d+(?:[.,]\d+)?)\s*(ml|cl|ltr|liter|litre|l)\b`)
+)
+
+// labelCountries maps words found on labels to the country names of the reference catalog
+var labelCountries = map[string]string{
+	"england":     "England",
+	"english":     "England",
+	"scotland":    "Scotland",
+	"scottish":    "Scotland",
+	"ireland":     "Ireland",
+	"irish":       "Ireland",
+	"germany":     "Germany",
+	"deutschland": "Germany",
+	"france":      "France",
+	"italy":       "Italy",
+	"italia":      "Italy",
+	"spain":       "Spain",
+	"españa":      "Spain",
+	"netherlands": "Netherlands",
+	"holland":     "Netherlands",
+	"sweden":      "Sweden",
+	"japan":       "Japan",
+	"usa":         "USA",
+	"australia":   "Australia",
+}
+
+// countryPrefixes mark a line that only states the origin
+var countryPrefixes = []string{"product of", "produce of", "made in", "distilled in", "bottled in"}
+
+// ParseLabelText extracts gin details from the text of a label. ABV, bottle
+// size and country are matched by pattern; the first remaining line is taken
+// as name and the second as brand, both with low confidence.
+func ParseLabelText(text string) *RecognizedLabel {
+	label := &RecognizedLabel{
+		Text:       text,
+		Confidence: make(map[string]float64),
+	}
+
+	var remaining []string
+	for _, line := range strings.Split(text, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+
+		matched := false
+
+		if label.ABV == nil {
+			if m := abvPattern.FindStringSubmatch(line); m != nil {
+				if abv, err := strconv.ParseFloat(strings.Replace(m[1], ",", ".", 1), 64); err == nil && abv >= 30 && abv <= 80 {
+					label.ABV = &abv
+					label.Confidence[FieldABV] = 0.7
+					if m[2] != "" {
+						label.Confidence[FieldABV] = 0.9
+					}
+					matched = true
+				}
+			}
+		}
+
+		if label.BottleSizeML == nil {
+			if m := sizePattern.FindStringSubmatch(line); m != nil {
+				if size := parseBottleSize(m[1], m[2]); size > 0 {
+					label.BottleSizeML = &size
+					label.Confidence[FieldBottleSize] = 0.9
+					matched = true
+				}
+			}
+		}
+
+		if label.Country == "" {
+			normalized := normalize(line)
+			words := strings.Fields(normalized)
+			for _, word := range words {
+				if country, ok := labelCountries[word]; ok {
+					label.Country = country
+					label.Confidence[FieldCountry] = 0.6
+
+					// A country inside a name ("Irish Gin") still leaves the line as name candidate
+					if len(words) == 1 || hasAnyPrefix(normalized, countryPrefixes) {
+						label.Confidence[FieldCountry] = 0.8
+						matched = true
+					}
+					break
+				}
+			}
+		}
+
+		if !matched {
+			remaining = append(remaining, line)
+		}
+	}
+
+	if len(remaining) > 0 {
+		label.Name = remaining[0]
+		label.Confidence[FieldName] = 0.5
+	}
+	if len(remaining) > 1 {
+		label.Brand = remaining[1]
+		label.Confidence[FieldBrand] = 0.4
+	}
+
+	return label
+}
+
+// parseBottleSize converts a size with unit to milliliters
+func parseBottleSize(value, unit string) int {
+	amount, err := strconv.ParseFloat(strings.Replace(value, ",", ".", 1), 64)
+	if err != nil {
+		return 0
+	}
+
+	var ml float64
+	switch strings.ToLower(unit) {
+	case "ml":
+		ml = amount
+	case "cl":
+		ml = amount * 10
+	default: // l, ltr, liter, litre
+		ml = amount * 1000
+	}
+
+	// Ignore anything that can't be a bottle
+	if ml < 20 || ml > 5000 {
+		return 0
+	}
+
+	return int(ml + 0.5)
+}
+
+// hasAnyPrefix checks if s starts with one of the prefixes
+func hasAnyPrefix(s string, prefixes []string) bool {
+	for _, prefix := range prefixes {
+		if strings.HasPrefix(s, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// imageChecksum returns the hex SHA-256 of an image
+func imageChecksum(image []byte) string {
+	sum := sha256.Sum256(image)
+	return hex.EncodeToString(sum[:])
+}
+
+// clampConfidence limits a confidence to 0.0 - 1.0
+func clampConfidence(confidence float64) float64 {
+	if confidence < 0 {
+		return 0
+	}
+	if confidence > 1 {
+		return 1
+	}
+	return confidence
+}
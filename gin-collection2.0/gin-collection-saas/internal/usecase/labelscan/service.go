@@ -0,0 +1,362 @@
+package labelscan
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"unicode"
+
+	"github.com/yourusername/gin-collection-saas/internal/domain/errors"
+	"github.com/yourusername/gin-collection-saas/internal/domain/models"
+	"github.com/yourusername/gin-collection-saas/internal/domain/repositories"
+	"github.com/yourusername/gin-collection-saas/pkg/logger"
+	"github.com/yourusername/gin-collection-saas/pkg/utils"
+)
+
+// MatchThreshold is the minimum score for a reference gin to be used
+const MatchThreshold = 0.75
+
+// referenceCandidates is the number of references fetched per search term
+const referenceCandidates = 10
+
+// searchPrefixLength is the length of the word prefix used as search term
+const searchPrefixLength = 5
+
+// genericWords are ignored when comparing names
+var genericWords = map[string]bool{
+	"gin":       true,
+	"dry":       true,
+	"london":    true,
+	"distilled": true,
+	"the":       true,
+}
+
+// ScanResult is the draft gin extracted from a label photo
+type ScanResult struct {
+	Draft      *models.Gin        `json:"draft"`
+	Confidence map[string]float64 `json:"confidence"`
+	Reference  *ReferenceMatch    `json:"reference,omitempty"`
+	Label      *RecognizedLabel   `json:"label"`
+}
+
+// ReferenceMatch is the reference catalog gin the label matched
+type ReferenceMatch struct {
+	Gin   *models.GinReference `json:"gin"`
+	Score float64              `json:"score"` // 0.0 - 1.0
+}
+
+// Service scans gin labels and matches them against the reference catalog
+type Service struct {
+	recognizer LabelRecognizer
+	refRepo    repositories.GinReferenceRepository
+}
+
+// NewService creates a new label scan service
+func NewService(recognizer LabelRecognizer, refRepo repositories.GinReferenceRepository) *Service {
+	return &Service{
+		recognizer: recognizer,
+		refRepo:    refRepo,
+	}
+}
+
+// IsEnabled returns whether a recognizer is configured
+func (s *Service) IsEnabled() bool {
+	return s.recognizer != nil
+}
+
+// ScanLabel reads a label photo and returns a draft gin with per-field confidence
+func (s *Service) ScanLabel(ctx context.Context, tenantID int64, image []byte) (*ScanResult, error) {
+	// Validate file type using magic bytes
+	contentType, err := utils.ValidateImageMagicBytes(image)
+	if err != nil {
+		return nil, errors.ErrInvalidFileType
+	}
+
+	label, err := s.recognizer.RecognizeLabel(ctx, image, contentType)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &ScanResult{
+		Draft:      &models.Gin{TenantID: tenantID},
+		Confidence: make(map[string]float64),
+		Label:      label,
+	}
+
+	// Start with what was read from the label
+	if label.Name != "" {
+		result.Draft.Name = label.Name
+		result.Confidence[FieldName] = label.Confidence[FieldName]
+	}
+	if label.Brand != "" {
+		brand := label.Brand
+		result.Draft.Brand = &brand
+		result.Confidence[FieldBrand] = label.Confidence[FieldBrand]
+	}
+	if label.ABV != nil {
+		abv := *label.ABV
+		result.Draft.ABV = &abv
+		result.Confidence[FieldABV] = label.Confidence[FieldABV]
+	}
+	if label.BottleSizeML != nil {
+		size := *label.BottleSizeML
+		result.Draft.BottleSize = &size
+		result.Confidence[FieldBottleSize] = label.Confidence[FieldBottleSize]
+	}
+	if label.Country != "" {
+		country := label.Country
+		result.Draft.Country = &country
+		result.Confidence[FieldCountry] = label.Confidence[FieldCountry]
+	}
+
+	match, err := s.matchReference(ctx, label)
+	if err != nil {
+		return nil, err
+	}
+
+	if match != nil {
+		result.Reference = match
+		applyReference(result, match)
+	}
+
+	logger.Info("Label scanned", "tenant_id", tenantID, "name", result.Draft.Name, "matched", match != nil)
+
+	return result, nil
+}
+
+// matchReference returns the best matching reference gin above MatchThreshold
+func (s *Service) matchReference(ctx context.Context, label *RecognizedLabel) (*ReferenceMatch, error) {
+	terms := searchTerms(label)
+	if len(terms) == 0 {
+		return nil, nil
+	}
+
+	seen := make(map[int64]bool)
+	var best *ReferenceMatch
+
+	for _, term := range terms {
+		refs, _, err := s.refRepo.Search(ctx, &models.GinReferenceSearchParams{Query: term, Limit: referenceCandidates})
+		if err != nil {
+			return nil, fmt.Errorf("failed to search gin references: %w", err)
+		}
+
+		for _, ref := range refs {
+			if seen[ref.ID] {
+				continue
+			}
+			seen[ref.ID] = true
+
+			score := matchScore(label, ref)
+			if score >= MatchThreshold && (best == nil || score > best.Score) {
+				best = &ReferenceMatch{Gin: ref, Score: score}
+			}
+		}
+	}
+
+	return best, nil
+}
+
+// applyReference fills the draft from the matched reference gin. Values read
+// from the label win; matching values raise the confidence of a field.
+func applyReference(result *ScanResult, match *ReferenceMatch) {
+	ref := match.Gin
+	draft := result.Draft
+
+	// The catalog spelling of name and brand is preferred
+	draft.Name = ref.Name
+	result.Confidence[FieldName] = maxConfidence(result.Confidence[FieldName], match.Score)
+
+	if ref.Brand != nil {
+		brand := *ref.Brand
+		draft.Brand = &brand
+		result.Confidence[FieldBrand] = maxConfidence(result.Confidence[FieldBrand], match.Score)
+	}
+
+	switch {
+	case draft.ABV == nil && ref.ABV != nil:
+		abv := *ref.ABV
+		draft.ABV = &abv
+		result.Confidence[FieldABV] = match.Score * 0.8
+	case draft.ABV != nil && ref.ABV != nil && abs64(*draft.ABV-*ref.ABV) < 0.05:
+		result.Confidence[FieldABV] = maxConfidence(result.Confidence[FieldABV], match.Score)
+	}
+
+	switch {
+	case draft.BottleSize == nil && ref.BottleSize != nil:
+		size := *ref.BottleSize
+		draft.BottleSize = &size
+		result.Confidence[FieldBottleSize] = match.Score * 0.6 // editions come in several sizes
+	case draft.BottleSize != nil && ref.BottleSize != nil && *draft.BottleSize == *ref.BottleSize:
+		result.Confidence[FieldBottleSize] = maxConfidence(result.Confidence[FieldBottleSize], match.Score)
+	}
+
+	switch {
+	case draft.Country == nil && ref.Country != nil:
+		country := *ref.Country
+		draft.Country = &country
+		result.Confidence[FieldCountry] = match.Score
+	case draft.Country != nil && ref.Country != nil && strings.EqualFold(*draft.Country, *ref.Country):
+		result.Confidence[FieldCountry] = maxConfidence(result.Confidence[FieldCountry], match.Score)
+	}
+
+	// Details that are never on a label come from the catalog only
+	draft.Region = ref.Region
+	draft.GinType = ref.GinType
+	draft.Description = ref.Description
+	draft.NoseNotes = ref.NoseNotes
+	draft.PalateNotes = ref.PalateNotes
+	draft.FinishNotes = ref.FinishNotes
+	draft.RecommendedTonic = ref.RecommendedTonic
+	draft.RecommendedGarnish = ref.RecommendedGarnish
+	draft.Barcode = ref.Barcode
+}
+
+// searchTerms returns the catalog search terms for a label: name, brand and
+// the start of the longest word of the name (catches spelling differences)
+func searchTerms(label *RecognizedLabel) []string {
+	var terms []string
+	seen := make(map[string]bool)
+
+	add := func(term string) {
+		term = strings.TrimSpace(term)
+		key := strings.ToLower(term)
+		if len(term) < 3 || seen[key] {
+			return
+		}
+		seen[key] = true
+		terms = append(terms, term)
+	}
+
+	add(label.Name)
+	add(label.Brand)
+
+	longest := ""
+	for _, word := range strings.Fields(normalize(label.Name)) {
+		if len(word) > len(longest) {
+			longest = word
+		}
+	}
+	if runes := []rune(longest); len(runes) > searchPrefixLength {
+		longest = string(runes[:searchPrefixLength])
+	}
+	add(longest)
+
+	return terms
+}
+
+// matchScore scores how well a label matches a reference gin (0.0 - 1.0)
+func matchScore(label *RecognizedLabel, ref *models.GinReference) float64 {
+	refBrand := ""
+	if ref.Brand != nil {
+		refBrand = *ref.Brand
+	}
+
+	// Labels often print brand and name together, in either order
+	score := similarity(label.Name, ref.Name)
+	if label.Brand != "" {
+		combined := 0.7*similarity(label.Name, ref.Name) + 0.3*similarity(label.Brand, refBrand)
+		score = maxConfidence(score, combined)
+		score = maxConfidence(score, similarity(label.Brand+" "+label.Name, refBrand+" "+ref.Name))
+		score = maxConfidence(score, similarity(label.Name+" "+label.Brand, ref.Name))
+	}
+	score = maxConfidence(score, similarity(label.Name, refBrand+" "+ref.Name))
+
+	return score
+}
+
+// similarity returns the normalized Levenshtein similarity of two names
+func similarity(a, b string) float64 {
+	a, b = significant(a), significant(b)
+	if a == "" || b == "" {
+		return 0
+	}
+	if a == b {
+		return 1
+	}
+
+	ra, rb := []rune(a), []rune(b)
+	maxLen := len(ra)
+	if len(rb) > maxLen {
+		maxLen = len(rb)
+	}
+
+	return 1 - float64(levenshtein(ra, rb))/float64(maxLen)
+}
+
+// levenshtein returns the edit distance of two rune slices
+func levenshtein(a, b []rune) int {
+	prev := make([]int, len(b)+1)
+	curr := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+
+	for i := 1; i <= len(a); i++ {
+		curr[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+
+	return prev[len(b)]
+}
+
+// normalize lowercases a string, drops apostrophes and reduces all other
+// punctuation to single spaces
+func normalize(s string) string {
+	var b strings.Builder
+	space := false
+	for _, r := range strings.ToLower(s) {
+		switch {
+		case r == '\'' || r == '’':
+			continue
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			if space && b.Len() > 0 {
+				b.WriteRune(' ')
+			}
+			space = false
+			b.WriteRune(r)
+		default:
+			space = true
+		}
+	}
+	return b.String()
+}
+
+// significant normalizes a name and drops generic words (unless nothing else is left)
+func significant(s string) string {
+	words := strings.Fields(normalize(s))
+
+	kept := words[:0:0]
+	for _, word := range words {
+		if !genericWords[word] {
+			kept = append(kept, word)
+		}
+	}
+	if len(kept) == 0 {
+		kept = words
+	}
+
+	return strings.Join(kept, " ")
+}
+
+// maxConfidence returns the larger of two confidences
+func maxConfidence(a, b float64) float64 {
+	if a > b {
+		return a
+	}
+	return b
+}
+
+// abs64 returns absolute value of float64
+func abs64(x float64) float64 {
+	if x < 0 {
+		return -x
+	}
+	return x
+}
@@ -0,0 +1,420 @@
+package photo
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	domainErrors "github.com/yourusername/gin-collection-saas/internal/domain/errors"
+	"github.com/yourusername/gin-collection-saas/internal/domain/models"
+	"github.com/yourusername/gin-collection-saas/internal/infrastructure/storage"
+	"github.com/yourusername/gin-collection-saas/pkg/logger"
+	"github.com/yourusername/gin-collection-saas/pkg/utils"
+)
+
+const (
+	// UploadSessionTTL is how long a presigned upload URL stays valid
+	UploadSessionTTL = 15 * time.Minute
+
+	// MaxPhotoSizeBytes is the maximum size of a directly uploaded photo (50 MB)
+	MaxPhotoSizeBytes = 50 << 20
+
+	// uploadSweepGrace keeps expired sessions around a little longer so uploads
+	// that started right before expiry can still finish before being swept
+	uploadSweepGrace = 10 * time.Minute
+
+	// uploadSessionRetention is how long finished sessions are kept for reference
+	uploadSessionRetention = 7 * 24 * time.Hour
+
+	// magicBytesLength is how much of an object is read to detect its type
+	magicBytesLength = 512
+)
+
+// CreateUploadURL creates an upload session and returns a presigned URL
+// the client uploads the photo to directly
+func (s *Service) CreateUploadURL(ctx context.Context, tenantID int64, userID *int64, ginID int64, req *models.UploadURLRequest) (*models.UploadURLResponse, error) {
+	if s.uploadSessionRepo == nil {
+		return nil, fmt.Errorf("direct uploads are not configured")
+	}
+
+	logger.Info("Creating photo upload URL", "tenant_id", tenantID, "gin_id", ginID, "filename", req.Filename, "size", req.SizeBytes)
+
+	if !utils.IsAllowedImageType(req.ContentType) {
+		return nil, domainErrors.ErrInvalidFileType
+	}
+
+	if req.SizeBytes > MaxPhotoSizeBytes {
+		return nil, domainErrors.ErrFileTooLarge
+	}
+
+	photoType := models.PhotoType(req.PhotoType)
+	if photoType == "" {
+		photoType = models.PhotoTypeBottle
+	}
+	if !isValidPhotoType(photoType) {
+		return nil, domainErrors.ErrInvalidInput
+	}
+
+	// Verify gin exists and belongs to tenant
+	if _, err := s.ginRepo.GetByID(ctx, tenantID, ginID); err != nil {
+		return nil, err
+	}
+
+	// Check limits up front with the declared size; they are checked again on completion
+	if _, err := s.checkUploadLimits(ctx, tenantID, ginID, req.SizeBytes); err != nil {
+		return nil, err
+	}
+
+	// Derive the extension from the content type, never trust the client filename.
+	// The client uploads to a staging key, the photo is copied out on completion.
+	key := storage.NewStagingKey(tenantID, ginID, utils.GetExtensionForContentType(req.ContentType))
+
+	uploadURL, err := s.storage.PresignUpload(ctx, key, req.ContentType, UploadSessionTTL)
+	if err != nil {
+		logger.Error("Failed to presign upload", "error", err.Error())
+		return nil, fmt.Errorf("failed to create upload URL: %w", err)
+	}
+
+	token, err := generateUploadToken()
+	if err != nil {
+		return nil, err
+	}
+
+	session := &models.PhotoUploadSession{
+		TenantID:    tenantID,
+		GinID:       ginID,
+		UserID:      userID,
+		Token:       token,
+		StorageKey:  key,
+		Filename:    req.Filename,
+		ContentType: req.ContentType,
+		PhotoType:   photoType,
+		Caption:     req.Caption,
+		Status:      models.UploadSessionPending,
+		ExpiresAt:   time.Now().Add(UploadSessionTTL),
+	}
+
+	if err := s.uploadSessionRepo.Create(ctx, session); err != nil {
+		return nil, fmt.Errorf("failed to create upload session: %w", err)
+	}
+
+	logger.Info("Photo upload URL created", "session_id", session.ID, "key", key)
+
+	return &models.UploadURLResponse{
+		UploadToken: token,
+		UploadURL:   uploadURL,
+		Method:      "PUT",
+		Headers: map[string]string{
+			"Content-Type": req.ContentType,
+		},
+		ExpiresAt: session.ExpiresAt,
+	}, nil
+}
+
+// CompleteUpload validates a directly uploaded object and registers it as a
+// photo. The object is copied from its staging key to a key that was never
+// presigned and validated again there, so it can't be replaced by uploading
+// to the presigned URL again.
+func (s *Service) CompleteUpload(ctx context.Context, tenantID, ginID int64, token string) (*models.GinPhoto, error) {
+	if s.uploadSessionRepo == nil {
+		return nil, fmt.Errorf("direct uploads are not configured")
+	}
+
+	session, err := s.uploadSessionRepo.GetByToken(ctx, tenantID, token)
+	if err != nil {
+		return nil, err
+	}
+
+	if session.GinID != ginID {
+		return nil, domainErrors.ErrNotFound
+	}
+
+	switch session.Status {
+	case models.UploadSessionCompleted:
+		return nil, domainErrors.ErrConflict
+	case models.UploadSessionExpired:
+		return nil, domainErrors.ErrUploadExpired
+	}
+
+	if session.IsExpired() {
+		s.discardUpload(ctx, session)
+		return nil, domainErrors.ErrUploadExpired
+	}
+
+	logger.Info("Completing photo upload", "tenant_id", tenantID, "gin_id", ginID, "session_id", session.ID)
+
+	size, err := s.storage.GetObjectSize(ctx, session.StorageKey)
+	if err != nil {
+		logger.Debug("Uploaded object not found", "key", session.StorageKey, "error", err.Error())
+		return nil, domainErrors.ErrUploadMissing
+	}
+
+	// Don't copy what can't be accepted anyway
+	if size > MaxPhotoSizeBytes {
+		s.discardUpload(ctx, session)
+		return nil, domainErrors.ErrFileTooLarge
+	}
+
+	key := storage.NewPhotoKey(tenantID, ginID, utils.GetExtensionForContentType(session.ContentType))
+	if err := s.storage.CopyObject(ctx, session.StorageKey, key); err != nil {
+		return nil, fmt.Errorf("failed to store uploaded photo: %w", err)
+	}
+
+	size, quota, err := s.validateUpload(ctx, session, key)
+	if err != nil {
+		s.deleteObject(ctx, key)
+		s.discardUpload(ctx, session)
+		return nil, err
+	}
+
+	fileSizeBytes := size
+	fileSizeKB := int(size / 1024)
+	photo := &models.GinPhoto{
+		TenantID:       tenantID,
+		GinID:          ginID,
+		PhotoURL:       s.storage.ObjectURL(key),
+		PhotoType:      session.PhotoType,
+		Caption:        session.Caption,
+		StorageKey:     &key,
+		StorageBackend: s.backend,
+		FileSizeKB:     &fileSizeKB,
+		FileSizeBytes:  &fileSizeBytes,
+	}
+
+	// Storage usage is updated, limits are checked and the session is
+	// completed together with the photo record, so concurrent completions
+	// register the photo only once. Signed local uploads are refused from now on.
+	if err := s.photoRepo.CreateFromUpload(ctx, photo, quota, session.ID); err != nil {
+		// Rollback: delete the copy. The session stays pending with its
+		// uploaded object, so the client can complete it again.
+		s.deleteObject(ctx, key)
+		if err == domainErrors.ErrConflict {
+			return nil, err
+		}
+		return nil, s.createError(ctx, tenantID, ginID, size, err)
+	}
+	s.deleteObject(ctx, session.StorageKey)
+
+	logger.Info("Direct photo upload completed", "photo_id", photo.ID, "gin_id", ginID)
+
+	return photo, nil
+}
+
+// validateUpload checks size, content and limits of an uploaded photo at its
+// final key. Returns its size and the quota to store it with.
+func (s *Service) validateUpload(ctx context.Context, session *models.PhotoUploadSession, key string) (int64, *models.PhotoQuota, error) {
+	size, err := s.storage.GetObjectSize(ctx, key)
+	if err != nil {
+		return 0, nil, fmt.Errorf("failed to read uploaded object: %w", err)
+	}
+
+	if size > MaxPhotoSizeBytes {
+		return 0, nil, domainErrors.ErrFileTooLarge
+	}
+
+	// Validate the actual content, not the declared content type
+	head, err := s.storage.ReadObjectHead(ctx, key, magicBytesLength)
+	if err != nil {
+		return 0, nil, fmt.Errorf("failed to read uploaded object: %w", err)
+	}
+
+	contentType, err := utils.ValidateImageMagicBytes(head)
+	if err != nil || contentType != session.ContentType {
+		logger.Warn("Invalid direct upload", "tenant_id", session.TenantID, "gin_id", session.GinID, "key", key, "declared", session.ContentType, "detected", contentType)
+		return 0, nil, domainErrors.ErrInvalidFileType
+	}
+
+	quota, err := s.checkUploadLimits(ctx, session.TenantID, session.GinID, size)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	return size, quota, nil
+}
+
+// ReceiveSignedUpload stores the body of a signed upload (local storage only).
+// Uploads are only accepted while their session is pending.
+func (s *Service) ReceiveSignedUpload(ctx context.Context, key, contentType string, expires int64, signature string, body io.Reader) error {
+	receiver, ok := s.storage.(storage.SignedUploadReceiver)
+	if !ok || s.uploadSessionRepo == nil {
+		return domainErrors.ErrNotFound
+	}
+
+	session, err := s.uploadSessionRepo.GetByStorageKey(ctx, key)
+	if err == domainErrors.ErrNotFound {
+		return domainErrors.ErrInvalidToken
+	}
+	if err != nil {
+		return err
+	}
+	if session.Status != models.UploadSessionPending || session.IsExpired() {
+		return domainErrors.ErrUploadExpired
+	}
+
+	if _, err := receiver.ReceiveSignedUpload(ctx, key, contentType, expires, signature, body); err != nil {
+		switch {
+		case errors.Is(err, storage.ErrInvalidUploadSignature), errors.Is(err, storage.ErrInvalidKey):
+			return domainErrors.ErrInvalidToken
+		case errors.Is(err, storage.ErrUploadURLExpired):
+			return domainErrors.ErrUploadExpired
+		}
+		return err
+	}
+
+	return nil
+}
+
+// SweepAbandonedUploads deletes objects of upload sessions that were never
+// completed and expires the sessions. Staging objects left behind once their
+// upload URL expired (e.g. uploaded again after completion) are deleted too.
+// Returns the number of swept sessions.
+func (s *Service) SweepAbandonedUploads(ctx context.Context) (int, error) {
+	if s.uploadSessionRepo == nil {
+		return 0, nil
+	}
+
+	swept := 0
+	cutoff := time.Now().Add(-uploadSweepGrace)
+
+	for {
+		sessions, err := s.uploadSessionRepo.ListExpiredPending(ctx, cutoff, 100)
+		if err != nil {
+			return swept, err
+		}
+
+		for _, session := range sessions {
+			s.discardUpload(ctx, session)
+			swept++
+		}
+
+		if len(sessions) < 100 {
+			break
+		}
+	}
+
+	s.sweepStagingObjects(ctx, time.Now().Add(-UploadSessionTTL-uploadSweepGrace))
+
+	if _, err := s.uploadSessionRepo.DeleteFinishedBefore(ctx, time.Now().Add(-uploadSessionRetention)); err != nil {
+		logger.Error("Failed to delete old upload sessions", "error", err.Error())
+	}
+
+	if swept > 0 {
+		logger.Info("Swept abandoned photo uploads", "count", swept)
+	}
+
+	return swept, nil
+}
+
+// sweepStagingObjects deletes staging objects written before the cutoff.
+// Their upload URL has expired, no session can complete them anymore.
+func (s *Service) sweepStagingObjects(ctx context.Context, cutoff time.Time) {
+	objects, err := s.storage.ListObjects(ctx, storage.StagingPrefix)
+	if err != nil {
+		logger.Error("Failed to list staged uploads", "error", err.Error())
+		return
+	}
+
+	for _, object := range objects {
+		if object.LastModified.Before(cutoff) {
+			s.deleteObject(ctx, object.Key)
+		}
+	}
+}
+
+// StartUploadSweeper periodically sweeps abandoned uploads until ctx is cancelled
+func (s *Service) StartUploadSweeper(ctx context.Context, interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if _, err := s.SweepAbandonedUploads(ctx); err != nil {
+					logger.Error("Upload sweeper failed", "error", err.Error())
+				}
+			}
+		}
+	}()
+}
+
+// checkUploadLimits checks photo count and storage limits for an upload of
+// sizeBytes to a gin. Returns the quota to create the photo record with, it
+// enforces the limits again in the same transaction as the usage update.
+func (s *Service) checkUploadLimits(ctx context.Context, tenantID, ginID int64, sizeBytes int64) (*models.PhotoQuota, error) {
+	tenant, err := s.tenantRepo.GetByID(ctx, tenantID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get tenant: %w", err)
+	}
+
+	currentCount, err := s.photoRepo.CountByGinID(ctx, tenantID, ginID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count photos: %w", err)
+	}
+
+	if err := s.entitlements.PhotoLimit(tenant, currentCount).Err(); err != nil {
+		return nil, err
+	}
+
+	quota, err := s.entitlements.StorageLimit(ctx, tenant, sizeBytes)
+	if err != nil {
+		return nil, err
+	}
+	if err := quota.Err(); err != nil {
+		return nil, err
+	}
+
+	return s.entitlements.PhotoQuota(tenant), nil
+}
+
+// createError returns the error of a failed photo record creation. A limit
+// reached by a concurrent upload is reported like the pre-check reports it.
+func (s *Service) createError(ctx context.Context, tenantID, ginID int64, sizeBytes int64, err error) error {
+	if err != domainErrors.ErrPhotoLimitReached && err != domainErrors.ErrStorageLimitReached {
+		return fmt.Errorf("failed to create photo record: %w", err)
+	}
+	if _, denial := s.checkUploadLimits(ctx, tenantID, ginID, sizeBytes); denial != nil {
+		return denial
+	}
+	return err
+}
+
+// discardUpload deletes the uploaded object and expires the session
+func (s *Service) discardUpload(ctx context.Context, session *models.PhotoUploadSession) {
+	s.deleteObject(ctx, session.StorageKey)
+
+	if err := s.uploadSessionRepo.MarkExpired(ctx, session.ID); err != nil {
+		logger.Error("Failed to expire upload session", "session_id", session.ID, "error", err.Error())
+	}
+}
+
+// deleteObject deletes a stored object, failures are only logged
+func (s *Service) deleteObject(ctx context.Context, key string) {
+	if err := s.storage.DeletePhoto(ctx, key); err != nil {
+		logger.Error("Failed to delete uploaded object", "key", key, "error", err.Error())
+	}
+}
+
+// isValidPhotoType checks if a photo type is supported
+func isValidPhotoType(photoType models.PhotoType) bool {
+	switch photoType {
+	case models.PhotoTypeBottle, models.PhotoTypeLabel, models.PhotoTypeMoment, models.PhotoTypeTasting:
+		return true
+	}
+	return false
+}
+
+// generateUploadToken generates a secure random upload session token
+func generateUploadToken() (string, error) {
+	tokenBytes := make([]byte, 32)
+	if _, err := rand.Read(tokenBytes); err != nil {
+		return "", fmt.Errorf("failed to generate token: %w", err)
+	}
+	return hex.EncodeToString(tokenBytes), nil
+}
@@ -0,0 +1,172 @@
+package photo
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	domainErrors "github.com/yourusername/gin-collection-saas/internal/domain/errors"
+	"github.com/yourusername/gin-collection-saas/internal/domain/models"
+	"github.com/yourusername/gin-collection-saas/pkg/logger"
+)
+
+// UpdatePhoto updates caption, type and tags of a photo. Photos beyond the
+// tier's MaxPhotosPerGin (by sort order) can't be edited.
+func (s *Service) UpdatePhoto(ctx context.Context, tenantID, ginID, photoID int64, req *models.UpdatePhotoRequest) (*models.GinPhoto, error) {
+	logger.Info("Updating photo", "tenant_id", tenantID, "gin_id", ginID, "photo_id", photoID)
+
+	photo, err := s.photoRepo.GetByID(ctx, tenantID, photoID)
+	if err != nil {
+		return nil, err
+	}
+
+	if photo.GinID != ginID {
+		return nil, domainErrors.ErrPhotoNotFound
+	}
+
+	if err := s.checkWithinPhotoLimit(ctx, tenantID, photo); err != nil {
+		return nil, err
+	}
+
+	if req.PhotoType != nil {
+		if !isValidPhotoType(*req.PhotoType) {
+			return nil, domainErrors.ErrInvalidInput
+		}
+		photo.PhotoType = *req.PhotoType
+	}
+
+	if req.Caption != nil {
+		caption := strings.TrimSpace(*req.Caption)
+		if caption == "" {
+			photo.Caption = nil
+		} else {
+			photo.Caption = &caption
+		}
+	}
+
+	if req.Tags != nil {
+		tags, err := normalizeTags(req.Tags)
+		if err != nil {
+			return nil, err
+		}
+		photo.Tags = tags
+	}
+
+	// Fields and tags are saved in one transaction
+	if err := s.photoRepo.Update(ctx, photo); err != nil {
+		return nil, fmt.Errorf("failed to update photo: %w", err)
+	}
+
+	logger.Info("Photo updated successfully", "photo_id", photoID)
+
+	return photo, nil
+}
+
+// ReorderPhotos sets the gallery order of all photos of a gin
+func (s *Service) ReorderPhotos(ctx context.Context, tenantID, ginID int64, photoIDs []int64) ([]*models.GinPhoto, error) {
+	logger.Info("Reordering photos", "tenant_id", tenantID, "gin_id", ginID, "count", len(photoIDs))
+
+	// Verify gin exists and belongs to tenant
+	gin, err := s.ginRepo.GetByID(ctx, tenantID, ginID)
+	if err != nil {
+		return nil, fmt.Errorf("gin not found: %w", err)
+	}
+
+	if gin.TenantID != tenantID {
+		return nil, fmt.Errorf("gin does not belong to tenant")
+	}
+
+	if err := s.photoRepo.UpdateSortOrder(ctx, tenantID, ginID, photoIDs); err != nil {
+		return nil, err
+	}
+
+	return s.photoRepo.GetByGinID(ctx, tenantID, ginID)
+}
+
+// ListGallery lists the photos of a tenant across all gins. Only photos
+// within the tier's MaxPhotosPerGin of each gin are included.
+func (s *Service) ListGallery(ctx context.Context, filter *models.PhotoFilter) ([]*models.GinPhoto, int, error) {
+	tenant, err := s.tenantRepo.GetByID(ctx, filter.TenantID)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to get tenant: %w", err)
+	}
+
+	limits := tenant.GetLimits()
+	if limits.MaxPhotosPerGin >= 0 {
+		filter.MaxPerGin = limits.MaxPhotosPerGin
+	}
+
+	if filter.Tag != "" {
+		filter.Tag = normalizeTag(filter.Tag)
+	}
+
+	photos, total, err := s.photoRepo.List(ctx, filter)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to list photos: %w", err)
+	}
+
+	if photos == nil {
+		photos = []*models.GinPhoto{}
+	}
+
+	return photos, total, nil
+}
+
+// checkWithinPhotoLimit rejects photos ranked beyond MaxPhotosPerGin in their
+// gin (e.g. after a downgrade); they can be moved up by reordering
+func (s *Service) checkWithinPhotoLimit(ctx context.Context, tenantID int64, photo *models.GinPhoto) error {
+	tenant, err := s.tenantRepo.GetByID(ctx, tenantID)
+	if err != nil {
+		return fmt.Errorf("failed to get tenant: %w", err)
+	}
+
+	limits := tenant.GetLimits()
+	if limits.MaxPhotosPerGin < 0 {
+		return nil
+	}
+
+	photos, err := s.photoRepo.GetByGinID(ctx, tenantID, photo.GinID)
+	if err != nil {
+		return fmt.Errorf("failed to get photos: %w", err)
+	}
+
+	for position, p := range photos {
+		if p.ID == photo.ID {
+			if position >= limits.MaxPhotosPerGin {
+				return domainErrors.ErrPhotoLimitReached
+			}
+			return nil
+		}
+	}
+
+	return domainErrors.ErrPhotoNotFound
+}
+
+// normalizeTags lowercases, trims and deduplicates photo tags
+func normalizeTags(tags []string) ([]string, error) {
+	normalized := make([]string, 0, len(tags))
+	seen := make(map[string]bool)
+
+	for _, tag := range tags {
+		tag = normalizeTag(tag)
+		if tag == "" || seen[tag] {
+			continue
+		}
+		if len([]rune(tag)) > models.MaxPhotoTagLength {
+			return nil, domainErrors.ErrInvalidInput
+		}
+		seen[tag] = true
+		normalized = append(normalized, tag)
+	}
+
+	if len(normalized) > models.MaxPhotoTags {
+		return nil, domainErrors.ErrInvalidInput
+	}
+
+	return normalized, nil
+}
+
+// normalizeTag lowercases a tag and collapses whitespace
+func normalizeTag(tag string) string {
+	return strings.ToLower(strings.Join(strings.Fields(tag), " "))
+}
@@ -7,6 +7,7 @@ import (
 	"github.com/yourusername/gin-collection-saas/internal/domain/models"
 	"github.com/yourusername/gin-collection-saas/internal/domain/repositories"
 	"github.com/yourusername/gin-collection-saas/internal/infrastructure/storage"
+	"github.com/yourusername/gin-collection-saas/internal/usecase/entitlement"
 	"github.com/yourusername/gin-collection-saas/pkg/logger"
 	"github.com/yourusername/gin-collection-saas/pkg/utils"
 )
@@ -15,26 +16,63 @@ import (
 type Service struct {
 	photoRepo        repositories.PhotoRepository
 	ginRepo          repositories.GinRepository
-	usageMetricsRepo repositories.UsageMetricsRepository
+	storageUsageRepo repositories.StorageUsageRepository
 	tenantRepo       repositories.TenantRepository
+	entitlements     *entitlement.Service
 	storage          storage.Storage
+	backend          string
+	backends         map[string]storage.Storage
+
+	uploadSessionRepo repositories.PhotoUploadSessionRepository
 }
 
 // NewService creates a new photo service
 func NewService(
 	photoRepo repositories.PhotoRepository,
 	ginRepo repositories.GinRepository,
-	usageMetricsRepo repositories.UsageMetricsRepository,
+	storageUsageRepo repositories.StorageUsageRepository,
 	tenantRepo repositories.TenantRepository,
+	entitlements *entitlement.Service,
 	storageClient storage.Storage,
 ) *Service {
 	return &Service{
 		photoRepo:        photoRepo,
 		ginRepo:          ginRepo,
-		usageMetricsRepo: usageMetricsRepo,
+		storageUsageRepo: storageUsageRepo,
 		tenantRepo:       tenantRepo,
+		entitlements:     entitlements,
 		storage:          storageClient,
+		backends:         make(map[string]storage.Storage),
+	}
+}
+
+// SetActiveBackend names the backend new photos are stored on ("local", "s3")
+func (s *Service) SetActiveBackend(name string) {
+	s.backend = name
+}
+
+// RegisterBackend makes another backend available for photos stored there,
+// e.g. before a migration to the active backend finished
+func (s *Service) RegisterBackend(name string, backend storage.Storage) {
+	s.backends[name] = backend
+}
+
+// storageFor returns the backend holding a photo's object, photos without a
+// recorded backend are on the active one
+func (s *Service) storageFor(photo *models.GinPhoto) (storage.Storage, error) {
+	if photo.StorageBackend == "" || photo.StorageBackend == s.backend {
+		return s.storage, nil
 	}
+	backend, ok := s.backends[photo.StorageBackend]
+	if !ok {
+		return nil, fmt.Errorf("storage backend %s is not available", photo.StorageBackend)
+	}
+	return backend, nil
+}
+
+// SetUploadSessionRepo sets the repository for direct upload sessions
+func (s *Service) SetUploadSessionRepo(repo repositories.PhotoUploadSessionRepository) {
+	s.uploadSessionRepo = repo
 }
 
 // UploadPhoto uploads a photo for a gin
@@ -51,36 +89,12 @@ func (s *Service) UploadPhoto(ctx context.Context, tenantID, ginID int64, filena
 		return nil, fmt.Errorf("gin does not belong to tenant")
 	}
 
-	// Get tenant for limits
-	tenant, err := s.tenantRepo.GetByID(ctx, tenantID)
-	if err != nil {
-		return nil, fmt.Errorf("failed to get tenant: %w", err)
-	}
-
-	limits := tenant.GetLimits()
-
-	// Check photo count limit
-	currentCount, err := s.photoRepo.CountByGinID(ctx, tenantID, ginID)
-	if err != nil {
-		return nil, fmt.Errorf("failed to count photos: %w", err)
-	}
-
-	if currentCount >= limits.MaxPhotosPerGin {
-		return nil, fmt.Errorf("photo limit reached (%d/%d) - upgrade to add more photos", currentCount, limits.MaxPhotosPerGin)
-	}
-
-	// Check storage limit (if applicable)
+	// Check photo count and storage limits
+	fileSizeBytes := int64(len(data))
 	fileSizeKB := len(data) / 1024
-	if limits.StorageLimitMB != nil {
-		currentStorageMB, err := s.usageMetricsRepo.GetMetric(ctx, tenantID, "storage_mb")
-		if err != nil {
-			return nil, fmt.Errorf("failed to get storage usage: %w", err)
-		}
-
-		fileSizeMB := fileSizeKB / 1024
-		if currentStorageMB+fileSizeMB > *limits.StorageLimitMB {
-			return nil, fmt.Errorf("storage limit would be exceeded (%dMB/%dMB)", currentStorageMB+fileSizeMB, *limits.StorageLimitMB)
-		}
+	quota, err := s.checkUploadLimits(ctx, tenantID, ginID, fileSizeBytes)
+	if err != nil {
+		return nil, err
 	}
 
 	// Validate file type using magic bytes (actual file content, not extension)
@@ -98,33 +112,24 @@ func (s *Service) UploadPhoto(ctx context.Context, tenantID, ginID int64, filena
 		return nil, fmt.Errorf("failed to upload photo: %w", err)
 	}
 
-	// Check if this is the first photo (make it primary)
-	isPrimary := currentCount == 0
-
-	// Create photo record
+	// Create photo record (the first photo of a gin becomes primary)
 	photo := &models.GinPhoto{
-		TenantID:   tenantID,
-		GinID:      ginID,
-		PhotoURL:   uploadResult.URL,
-		PhotoType:  photoType,
-		Caption:    caption,
-		IsPrimary:  isPrimary,
-		StorageKey: &uploadResult.Key,
-		FileSizeKB: &fileSizeKB,
-	}
-
-	if err := s.photoRepo.Create(ctx, photo); err != nil {
+		TenantID:       tenantID,
+		GinID:          ginID,
+		PhotoURL:       uploadResult.URL,
+		PhotoType:      photoType,
+		Caption:        caption,
+		StorageKey:     &uploadResult.Key,
+		StorageBackend: s.backend,
+		FileSizeKB:     &fileSizeKB,
+		FileSizeBytes:  &fileSizeBytes,
+	}
+
+	// Storage usage is updated and limits are checked together with the photo record
+	if err := s.photoRepo.Create(ctx, photo, quota); err != nil {
 		// Rollback: delete from S3
 		s.storage.DeletePhoto(ctx, uploadResult.Key)
-		return nil, fmt.Errorf("failed to create photo record: %w", err)
-	}
-
-	// Update storage metrics
-	storageMB := fileSizeKB / 1024
-	if storageMB > 0 {
-		if err := s.usageMetricsRepo.IncrementMetric(ctx, tenantID, "storage_mb", storageMB); err != nil {
-			logger.Error("Failed to update storage metrics", "error", err.Error())
-		}
+		return nil, s.createError(ctx, tenantID, ginID, fileSizeBytes, err)
 	}
 
 	logger.Info("Photo uploaded successfully", "photo_id", photo.ID, "gin_id", ginID)
@@ -166,29 +171,23 @@ func (s *Service) DeletePhoto(ctx context.Context, tenantID, photoID int64) erro
 	wasPrimary := photo.IsPrimary
 	ginID := photo.GinID
 
-	// Delete from S3
+	// Delete from the backend holding the photo
 	if photo.StorageKey != nil {
-		if err := s.storage.DeletePhoto(ctx, *photo.StorageKey); err != nil {
-			logger.Error("Failed to delete from S3", "error", err.Error())
-			// Continue anyway to delete DB record
+		backend, err := s.storageFor(photo)
+		if err == nil {
+			err = backend.DeletePhoto(ctx, *photo.StorageKey)
+		}
+		if err != nil {
+			logger.Error("Failed to delete from storage", "backend", photo.StorageBackend, "error", err.Error())
+			// Continue anyway to delete DB record, reconciliation reports the orphan
 		}
 	}
 
-	// Delete from database
+	// Delete from database (releases the photo's storage usage)
 	if err := s.photoRepo.Delete(ctx, tenantID, photoID); err != nil {
 		return fmt.Errorf("failed to delete photo: %w", err)
 	}
 
-	// Update storage metrics
-	if photo.FileSizeKB != nil {
-		storageMB := *photo.FileSizeKB / 1024
-		if storageMB > 0 {
-			if err := s.usageMetricsRepo.DecrementMetric(ctx, tenantID, "storage_mb", storageMB); err != nil {
-				logger.Error("Failed to update storage metrics", "error", err.Error())
-			}
-		}
-	}
-
 	// If deleted photo was primary, set another photo as primary
 	if wasPrimary {
 		remainingPhotos, err := s.photoRepo.GetByGinID(ctx, tenantID, ginID)
@@ -232,4 +231,3 @@ func (s *Service) SetPrimaryPhoto(ctx context.Context, tenantID, ginID, photoID
 
 	return nil
 }
-
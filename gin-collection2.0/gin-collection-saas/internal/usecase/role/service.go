@@ -0,0 +1,320 @@
+package role
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/yourusername/gin-collection-saas/internal/domain/errors"
+	"github.com/yourusername/gin-collection-saas/internal/domain/models"
+	"github.com/yourusername/gin-collection-saas/internal/domain/repositories"
+	"github.com/yourusername/gin-collection-saas/internal/usecase/entitlement"
+	"github.com/yourusername/gin-collection-saas/pkg/logger"
+)
+
+// Service handles the built-in roles and the custom roles of tenants
+// (custom roles are an Enterprise feature)
+type Service struct {
+	roleRepo     repositories.RoleRepository
+	userRepo     repositories.UserRepository
+	tenantRepo   repositories.TenantRepository
+	auditLogRepo repositories.AuditLogRepository
+	entitlements *entitlement.Service
+}
+
+// NewService creates a new role service
+func NewService(
+	roleRepo repositories.RoleRepository,
+	userRepo repositories.UserRepository,
+	tenantRepo repositories.TenantRepository,
+	auditLogRepo repositories.AuditLogRepository,
+	entitlements *entitlement.Service,
+) *Service {
+	return &Service{
+		roleRepo:     roleRepo,
+		userRepo:     userRepo,
+		tenantRepo:   tenantRepo,
+		auditLogRepo: auditLogRepo,
+		entitlements: entitlements,
+	}
+}
+
+// List returns the built-in roles and, for Enterprise tenants, the custom roles
+func (s *Service) List(ctx context.Context, tenantID int64) ([]*models.RoleInfo, error) {
+	tenant, err := s.tenantRepo.GetByID(ctx, tenantID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get tenant: %w", err)
+	}
+
+	roles := make([]*models.RoleInfo, 0, len(models.BuiltInRoles))
+	for _, role := range models.BuiltInRoles {
+		roles = append(roles, &models.RoleInfo{
+			Name:        string(role),
+			BuiltIn:     true,
+			Permissions: role.Permissions().List(),
+		})
+	}
+
+	if !s.entitlements.Included(tenant, models.FeatureMultiUser) {
+		return roles, nil
+	}
+
+	customRoles, err := s.roleRepo.ListByTenant(ctx, tenantID)
+	if err != nil {
+		return nil, err
+	}
+	for _, role := range customRoles {
+		id, userCount := role.ID, role.UserCount
+		roles = append(roles, &models.RoleInfo{
+			ID:          &id,
+			Name:        role.Name,
+			Description: role.Description,
+			Permissions: models.NewPermissionSet(role.Permissions...).List(),
+			UserCount:   &userCount,
+		})
+	}
+
+	return roles, nil
+}
+
+// Create creates a custom role (Enterprise only)
+func (s *Service) Create(ctx context.Context, tenantID, requesterUserID int64, req *models.RoleRequest) (*models.CustomRole, error) {
+	existing, err := s.customRoles(ctx, tenantID)
+	if err != nil {
+		return nil, err
+	}
+	if len(existing) >= models.MaxCustomRolesPerTenant {
+		return nil, errors.ErrLimitReached
+	}
+
+	role := &models.CustomRole{
+		TenantID:    tenantID,
+		Name:        strings.TrimSpace(req.Name),
+		Description: req.Description,
+		Permissions: models.NewPermissionSet(req.Permissions...).List(),
+	}
+	if nameTaken(existing, role.Name, 0) {
+		return nil, errors.ErrRoleNameTaken
+	}
+
+	if err := s.roleRepo.Create(ctx, role); err != nil {
+		return nil, err
+	}
+
+	s.audit(ctx, tenantID, requesterUserID, models.AuditActionCreateRole, role.ID, map[string]interface{}{
+		"name":        role.Name,
+		"permissions": role.Permissions,
+	})
+
+	logger.Info("Custom role created", "tenant_id", tenantID, "role_id", role.ID, "name", role.Name)
+
+	return role, nil
+}
+
+// Update changes the name, description and permissions of a custom role, its
+// users get the new permissions with their next request (Enterprise only)
+func (s *Service) Update(ctx context.Context, tenantID, requesterUserID, roleID int64, req *models.RoleRequest) (*models.CustomRole, error) {
+	existing, err := s.customRoles(ctx, tenantID)
+	if err != nil {
+		return nil, err
+	}
+
+	role, err := s.roleRepo.GetByID(ctx, tenantID, roleID)
+	if err != nil {
+		return nil, err
+	}
+
+	name := strings.TrimSpace(req.Name)
+	if nameTaken(existing, name, role.ID) {
+		return nil, errors.ErrRoleNameTaken
+	}
+
+	changes := map[string]interface{}{}
+	if name != role.Name {
+		changes["name"] = map[string]string{"old": role.Name, "new": name}
+	}
+	permissions := models.NewPermissionSet(req.Permissions...).List()
+	if !samePermissions(role.Permissions, permissions) {
+		changes["permissions"] = map[string][]models.Permission{"old": role.Permissions, "new": permissions}
+	}
+
+	role.Name = name
+	role.Description = req.Description
+	role.Permissions = permissions
+
+	if err := s.roleRepo.Update(ctx, role); err != nil {
+		return nil, err
+	}
+
+	if len(changes) > 0 {
+		s.audit(ctx, tenantID, requesterUserID, models.AuditActionUpdateRole, role.ID, changes)
+	}
+
+	logger.Info("Custom role updated", "tenant_id", tenantID, "role_id", role.ID)
+
+	return role, nil
+}
+
+// Delete deletes a custom role that no user is assigned to (Enterprise only)
+func (s *Service) Delete(ctx context.Context, tenantID, requesterUserID, roleID int64) error {
+	if _, err := s.customRoles(ctx, tenantID); err != nil {
+		return err
+	}
+
+	role, err := s.roleRepo.GetByID(ctx, tenantID, roleID)
+	if err != nil {
+		return err
+	}
+	if role.UserCount > 0 {
+		return errors.ErrRoleInUse
+	}
+
+	if err := s.roleRepo.Delete(ctx, tenantID, roleID); err != nil {
+		return err
+	}
+
+	s.audit(ctx, tenantID, requesterUserID, models.AuditActionDeleteRole, role.ID, map[string]interface{}{
+		"name": role.Name,
+	})
+
+	logger.Info("Custom role deleted", "tenant_id", tenantID, "role_id", role.ID)
+
+	return nil
+}
+
+// AssignUser assigns a custom role to a user, replacing the permissions of
+// the user's built-in role. Owners keep all permissions and can't be
+// assigned (Enterprise only).
+func (s *Service) AssignUser(ctx context.Context, tenantID, requesterUserID, roleID, userID int64) error {
+	if _, err := s.customRoles(ctx, tenantID); err != nil {
+		return err
+	}
+
+	role, err := s.roleRepo.GetByID(ctx, tenantID, roleID)
+	if err != nil {
+		return err
+	}
+
+	user, err := s.tenantUser(ctx, tenantID, userID)
+	if err != nil {
+		return err
+	}
+	if user.Role == models.RoleOwner {
+		return errors.ErrForbidden
+	}
+
+	if err := s.roleRepo.SetUserRole(ctx, tenantID, userID, &role.ID); err != nil {
+		return err
+	}
+
+	s.audit(ctx, tenantID, requesterUserID, models.AuditActionAssignRole, role.ID, map[string]interface{}{
+		"name":    role.Name,
+		"user_id": userID,
+	})
+
+	logger.Info("Custom role assigned", "tenant_id", tenantID, "role_id", role.ID, "user_id", userID)
+
+	return nil
+}
+
+// UnassignUser removes a custom role from a user, who gets the permissions of
+// the built-in role again (Enterprise only)
+func (s *Service) UnassignUser(ctx context.Context, tenantID, requesterUserID, roleID, userID int64) error {
+	if _, err := s.customRoles(ctx, tenantID); err != nil {
+		return err
+	}
+
+	role, err := s.roleRepo.GetByID(ctx, tenantID, roleID)
+	if err != nil {
+		return err
+	}
+
+	user, err := s.tenantUser(ctx, tenantID, userID)
+	if err != nil {
+		return err
+	}
+	if user.CustomRoleID == nil || *user.CustomRoleID != role.ID {
+		return errors.ErrNotFound
+	}
+
+	if err := s.roleRepo.SetUserRole(ctx, tenantID, userID, nil); err != nil {
+		return err
+	}
+
+	s.audit(ctx, tenantID, requesterUserID, models.AuditActionUnassignRole, role.ID, map[string]interface{}{
+		"name":    role.Name,
+		"user_id": userID,
+	})
+
+	logger.Info("Custom role unassigned", "tenant_id", tenantID, "role_id", role.ID, "user_id", userID)
+
+	return nil
+}
+
+// customRoles verifies the tenant can define custom roles and returns them
+func (s *Service) customRoles(ctx context.Context, tenantID int64) ([]*models.CustomRole, error) {
+	tenant, err := s.tenantRepo.GetByID(ctx, tenantID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get tenant: %w", err)
+	}
+	if err := s.entitlements.RequireFeature(tenant, models.FeatureMultiUser); err != nil {
+		return nil, err
+	}
+
+	return s.roleRepo.ListByTenant(ctx, tenantID)
+}
+
+// tenantUser gets a user and verifies it belongs to the tenant
+func (s *Service) tenantUser(ctx context.Context, tenantID, userID int64) (*models.User, error) {
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if user.TenantID != tenantID {
+		return nil, errors.ErrUserNotInTenant
+	}
+	return user, nil
+}
+
+// audit records a change of a custom role
+func (s *Service) audit(ctx context.Context, tenantID, requesterUserID int64, action models.AuditAction, roleID int64, changes map[string]interface{}) {
+	changesJSON, _ := json.Marshal(changes)
+	changesStr := string(changesJSON)
+
+	auditLog := &models.AuditLog{
+		TenantID:   tenantID,
+		UserID:     &requesterUserID,
+		Action:     string(action),
+		EntityType: string(models.EntityTypeRole),
+		EntityID:   &roleID,
+		Changes:    &changesStr,
+	}
+	if err := s.auditLogRepo.Create(ctx, auditLog); err != nil {
+		logger.Error("Failed to create audit log", "action", action, "error", err.Error())
+	}
+}
+
+// nameTaken checks if another role already has a name, ignoring case
+func nameTaken(roles []*models.CustomRole, name string, exceptID int64) bool {
+	for _, role := range roles {
+		if role.ID != exceptID && strings.EqualFold(role.Name, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// samePermissions compares two permission lists in registry order
+func samePermissions(a, b []models.Permission) bool {
+	a = models.NewPermissionSet(a...).List()
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
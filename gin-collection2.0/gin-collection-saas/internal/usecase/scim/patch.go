@@ -0,0 +1,280 @@
+package scim
+
+import (
+	"encoding/json"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/yourusername/gin-collection-saas/internal/domain/models"
+)
+
+// filterPattern matches the equality filters identity providers use to look
+// up existing resources, e.g. userName eq "alice@example.com"
+var filterPattern = regexp.MustCompile(`^\s*([A-Za-z][A-Za-z0-9._:-]*)\s+(?i:eq)\s+"((?:[^"\\]|\\.)*)"\s*$`)
+
+// memberFilterPath matches value filters of members, e.g. members[value eq "42"]
+var memberFilterPath = regexp.MustCompile(`^(?i:members)\[(.+)\]$`)
+
+// parseFilter parses a filter of the form `attribute eq "value"`. An empty
+// filter matches everything and returns an empty attribute.
+func parseFilter(filter string) (string, string, error) {
+	if strings.TrimSpace(filter) == "" {
+		return "", "", nil
+	}
+
+	match := filterPattern.FindStringSubmatch(filter)
+	if match == nil {
+		return "", "", &Error{Status: http.StatusBadRequest, ScimType: "invalidFilter", Detail: `only filters of the form attribute eq "value" are supported`}
+	}
+
+	value, err := strconv.Unquote(`"` + match[2] + `"`)
+	if err != nil {
+		return "", "", &Error{Status: http.StatusBadRequest, ScimType: "invalidFilter", Detail: "invalid filter value"}
+	}
+
+	return trimSchema(match[1]), value, nil
+}
+
+// trimSchema removes the core schema URN attributes may be qualified with
+func trimSchema(path string) string {
+	for _, schema := range []string{models.SCIMUserSchema, models.SCIMGroupSchema} {
+		if len(path) > len(schema) && strings.EqualFold(path[:len(schema)+1], schema+":") {
+			return path[len(schema)+1:]
+		}
+	}
+	return path
+}
+
+// patchOp returns the lower-case op of a PATCH operation
+func patchOp(operation models.SCIMPatchOperation) (string, error) {
+	op := strings.ToLower(operation.Op)
+	switch op {
+	case "add", "replace", "remove":
+		return op, nil
+	}
+	return "", invalidValue("unsupported op %q", operation.Op)
+}
+
+// applyUserOperation applies a PATCH operation to a user resource. Attributes
+// the app doesn't store (phone numbers, titles, extensions) are ignored.
+func applyUserOperation(resource *models.SCIMUser, operation models.SCIMPatchOperation) error {
+	op, err := patchOp(operation)
+	if err != nil {
+		return err
+	}
+
+	if operation.Path == "" {
+		if op == "remove" {
+			return &Error{Status: http.StatusBadRequest, ScimType: "noTarget", Detail: "remove requires a path"}
+		}
+
+		// Without a path the value holds the attributes to set
+		var attributes map[string]json.RawMessage
+		if err := json.Unmarshal(operation.Value, &attributes); err != nil {
+			return invalidValue("value must be an object if no path is given")
+		}
+		for path, value := range attributes {
+			if err := setUserAttribute(resource, path, value); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	if op == "remove" {
+		return removeUserAttribute(resource, operation.Path)
+	}
+	return setUserAttribute(resource, operation.Path, operation.Value)
+}
+
+func setUserAttribute(resource *models.SCIMUser, path string, value json.RawMessage) error {
+	switch strings.ToLower(trimSchema(path)) {
+	case "active":
+		active, err := decodeBool(value)
+		if err != nil {
+			return invalidValue("active must be a boolean")
+		}
+		resource.Active = &active
+	case "username":
+		return decodeString(value, "userName", &resource.UserName)
+	case "name":
+		var name models.SCIMName
+		if err := json.Unmarshal(value, &name); err != nil {
+			return invalidValue("name must be an object")
+		}
+		resource.Name = &name
+	case "name.givenname":
+		return decodeString(value, "name.givenName", &userName(resource).GivenName)
+	case "name.familyname":
+		return decodeString(value, "name.familyName", &userName(resource).FamilyName)
+	}
+	return nil
+}
+
+func removeUserAttribute(resource *models.SCIMUser, path string) error {
+	switch strings.ToLower(trimSchema(path)) {
+	case "active", "username":
+		return invalidValue("%s can't be removed", path)
+	case "name":
+		resource.Name = nil
+	case "name.givenname":
+		userName(resource).GivenName = ""
+	case "name.familyname":
+		userName(resource).FamilyName = ""
+	}
+	return nil
+}
+
+func userName(resource *models.SCIMUser) *models.SCIMName {
+	if resource.Name == nil {
+		resource.Name = &models.SCIMName{}
+	}
+	return resource.Name
+}
+
+// applyGroupOperation applies a PATCH operation to a group
+func applyGroupOperation(group *models.SCIMGroup, operation models.SCIMPatchOperation) error {
+	op, err := patchOp(operation)
+	if err != nil {
+		return err
+	}
+
+	path := trimSchema(operation.Path)
+	switch {
+	case path == "":
+		if op == "remove" {
+			return &Error{Status: http.StatusBadRequest, ScimType: "noTarget", Detail: "remove requires a path"}
+		}
+
+		var attributes map[string]json.RawMessage
+		if err := json.Unmarshal(operation.Value, &attributes); err != nil {
+			return invalidValue("value must be an object if no path is given")
+		}
+		for name, value := range attributes {
+			if err := applyGroupOperation(group, models.SCIMPatchOperation{Op: op, Path: name, Value: value}); err != nil {
+				return err
+			}
+		}
+
+	case strings.EqualFold(path, "members"):
+		var members []models.SCIMResource
+		if len(operation.Value) > 0 && string(operation.Value) != "null" {
+			if err := json.Unmarshal(operation.Value, &members); err != nil {
+				return invalidValue("members must be a list of {\"value\": id}")
+			}
+		}
+
+		switch op {
+		case "replace":
+			group.MemberIDs = []int64{}
+			fallthrough
+		case "add":
+			for _, member := range members {
+				if err := addMember(group, member.Value); err != nil {
+					return err
+				}
+			}
+		case "remove":
+			// Without a value all members are removed
+			if len(members) == 0 {
+				group.MemberIDs = []int64{}
+			}
+			for _, member := range members {
+				removeMember(group, member.Value)
+			}
+		}
+
+	case memberFilterPath.MatchString(path):
+		if op != "remove" {
+			return &Error{Status: http.StatusBadRequest, ScimType: "invalidPath", Detail: "member filters are only supported to remove members"}
+		}
+		attribute, value, err := parseFilter(memberFilterPath.FindStringSubmatch(path)[1])
+		if err != nil {
+			return err
+		}
+		if !strings.EqualFold(attribute, "value") {
+			return &Error{Status: http.StatusBadRequest, ScimType: "invalidFilter", Detail: "members can only be filtered by value"}
+		}
+		removeMember(group, value)
+
+	case strings.EqualFold(path, "displayName"):
+		if op == "remove" {
+			return invalidValue("displayName can't be removed")
+		}
+		var displayName string
+		if err := decodeString(operation.Value, "displayName", &displayName); err != nil {
+			return err
+		}
+		group.DisplayName = strings.TrimSpace(displayName)
+
+	case strings.EqualFold(path, "externalId"):
+		group.ExternalID = nil
+		if op != "remove" {
+			var externalID string
+			if err := decodeString(operation.Value, "externalId", &externalID); err != nil {
+				return err
+			}
+			if externalID != "" {
+				group.ExternalID = &externalID
+			}
+		}
+
+	case strings.EqualFold(path, "id"):
+		// Some identity providers send the ID along with the attributes
+
+	default:
+		return &Error{Status: http.StatusBadRequest, ScimType: "invalidPath", Detail: "unsupported path " + operation.Path}
+	}
+
+	return nil
+}
+
+// addMember adds a user to a group, once
+func addMember(group *models.SCIMGroup, value string) error {
+	userID, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return invalidValue("invalid member %q", value)
+	}
+	if !group.HasMember(userID) {
+		group.MemberIDs = append(group.MemberIDs, userID)
+	}
+	return nil
+}
+
+// removeMember removes a user from a group, unknown members are ignored
+func removeMember(group *models.SCIMGroup, value string) {
+	userID, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return
+	}
+	for i, id := range group.MemberIDs {
+		if id == userID {
+			group.MemberIDs = append(group.MemberIDs[:i], group.MemberIDs[i+1:]...)
+			return
+		}
+	}
+}
+
+func decodeString(value json.RawMessage, name string, target *string) error {
+	if err := json.Unmarshal(value, target); err != nil {
+		return invalidValue("%s must be a string", name)
+	}
+	return nil
+}
+
+// decodeBool accepts JSON booleans and the "True"/"False" strings some
+// identity providers send
+func decodeBool(value json.RawMessage) (bool, error) {
+	var b bool
+	if err := json.Unmarshal(value, &b); err == nil {
+		return b, nil
+	}
+
+	var s string
+	if err := json.Unmarshal(value, &s); err != nil {
+		return false, err
+	}
+	return strconv.ParseBool(strings.ToLower(s))
+}
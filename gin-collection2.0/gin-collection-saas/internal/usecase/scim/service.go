@@ -0,0 +1,762 @@
+package scim
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/mail"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/yourusername/gin-collection-saas/internal/domain/errors"
+	"github.com/yourusername/gin-collection-saas/internal/domain/models"
+	"github.com/yourusername/gin-collection-saas/internal/domain/repositories"
+	"github.com/yourusername/gin-collection-saas/internal/usecase/entitlement"
+	"github.com/yourusername/gin-collection-saas/internal/usecase/sso"
+	"github.com/yourusername/gin-collection-saas/internal/usecase/user"
+	"github.com/yourusername/gin-collection-saas/pkg/logger"
+	"github.com/yourusername/gin-collection-saas/pkg/utils"
+)
+
+// Error is a SCIM protocol error, rendered with the HTTP status and scimType
+// of RFC 7644 section 3.12
+type Error struct {
+	Status   int
+	ScimType string
+	Detail   string
+}
+
+func (e *Error) Error() string {
+	return e.Detail
+}
+
+func invalidValue(format string, args ...interface{}) error {
+	return &Error{Status: http.StatusBadRequest, ScimType: "invalidValue", Detail: fmt.Sprintf(format, args...)}
+}
+
+// Service implements SCIM 2.0 provisioning of users and groups for Enterprise
+// tenants. Users are created, updated and deleted through the user service.
+// Group memberships decide the user roles through the role mapping of the
+// SSO settings, without a mapping roles stay managed in the app.
+type Service struct {
+	repo         repositories.SCIMRepository
+	userRepo     repositories.UserRepository
+	users        *user.Service
+	ssoService   *sso.Service
+	entitlements *entitlement.Service
+	baseURL      string
+}
+
+// NewService creates a new SCIM service. baseURL is the public URL of the API.
+func NewService(
+	repo repositories.SCIMRepository,
+	userRepo repositories.UserRepository,
+	users *user.Service,
+	ssoService *sso.Service,
+	entitlements *entitlement.Service,
+	baseURL string,
+) *Service {
+	return &Service{
+		repo:         repo,
+		userRepo:     userRepo,
+		users:        users,
+		ssoService:   ssoService,
+		entitlements: entitlements,
+		baseURL:      strings.TrimSuffix(baseURL, "/"),
+	}
+}
+
+// BaseURL returns the SCIM endpoint identity providers are configured with
+func (s *Service) BaseURL() string {
+	return s.baseURL + "/api/v1/scim/v2"
+}
+
+// Status returns whether SCIM provisioning is set up for a tenant
+func (s *Service) Status(ctx context.Context, tenant *models.Tenant) (*models.SCIMStatus, error) {
+	if err := s.entitlements.RequireFeature(tenant, models.FeatureSCIM); err != nil {
+		return nil, err
+	}
+
+	token, err := s.repo.GetToken(ctx, tenant.ID)
+	if err != nil && err != errors.ErrNotFound {
+		return nil, err
+	}
+
+	return &models.SCIMStatus{
+		Enabled: token != nil,
+		BaseURL: s.BaseURL(),
+		Token:   token,
+	}, nil
+}
+
+// GenerateToken creates the SCIM token of a tenant and returns its value,
+// which is shown once. A previous token stops working.
+func (s *Service) GenerateToken(ctx context.Context, tenant *models.Tenant, userID int64) (string, *models.SCIMToken, error) {
+	if err := s.entitlements.RequireFeature(tenant, models.FeatureSCIM); err != nil {
+		return "", nil, err
+	}
+
+	secret, err := utils.GenerateSecureToken(32)
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to generate scim token: %w", err)
+	}
+	value := models.SCIMTokenPrefix + secret
+
+	token := &models.SCIMToken{
+		TenantID:    tenant.ID,
+		TokenHash:   utils.HashToken(value),
+		TokenPrefix: value[:len(models.SCIMTokenPrefix)+6],
+		CreatedBy:   &userID,
+	}
+	if err := s.repo.SaveToken(ctx, token); err != nil {
+		return "", nil, err
+	}
+
+	logger.Info("SCIM token generated", "tenant_id", tenant.ID, "user_id", userID)
+
+	return value, token, nil
+}
+
+// RevokeToken removes the SCIM token of a tenant, provisioning stops
+func (s *Service) RevokeToken(ctx context.Context, tenant *models.Tenant, userID int64) error {
+	if err := s.repo.DeleteToken(ctx, tenant.ID); err != nil {
+		return err
+	}
+
+	logger.Info("SCIM token revoked", "tenant_id", tenant.ID, "user_id", userID)
+
+	return nil
+}
+
+// ListUsers returns a page of the users of a tenant matching the filter
+func (s *Service) ListUsers(ctx context.Context, tenant *models.Tenant, filter string, startIndex, count int) (*models.SCIMListResponse, error) {
+	attribute, value, err := parseFilter(filter)
+	if err != nil {
+		return nil, err
+	}
+
+	users, err := s.userRepo.List(ctx, tenant.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list users: %w", err)
+	}
+	sort.Slice(users, func(i, j int) bool { return users[i].ID < users[j].ID })
+
+	var matching []*models.User
+	for _, u := range users {
+		switch {
+		case attribute == "":
+		case strings.EqualFold(attribute, "userName"), strings.EqualFold(attribute, "emails.value"):
+			// userName is case-insensitive (RFC 7643 section 4.1.1)
+			if !strings.EqualFold(u.Email, value) {
+				continue
+			}
+		case strings.EqualFold(attribute, "id"):
+			if strconv.FormatInt(u.ID, 10) != value {
+				continue
+			}
+		case strings.EqualFold(attribute, "externalId"):
+			// External IDs are not stored, identity providers fall back to userName
+			continue
+		default:
+			return nil, &Error{Status: http.StatusBadRequest, ScimType: "invalidFilter", Detail: "filtering by " + attribute + " is not supported"}
+		}
+		matching = append(matching, u)
+	}
+
+	groups, err := s.repo.ListGroups(ctx, tenant.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	page, startIndex := paginate(len(matching), startIndex, count)
+	resources := make([]*models.SCIMUser, 0, page.end-page.start)
+	for _, u := range matching[page.start:page.end] {
+		resources = append(resources, s.userResource(u, groups))
+	}
+
+	return listResponse(len(matching), startIndex, resources, len(resources)), nil
+}
+
+// GetUser returns a user of a tenant
+func (s *Service) GetUser(ctx context.Context, tenant *models.Tenant, id string) (*models.SCIMUser, error) {
+	u, err := s.getUser(ctx, tenant, id)
+	if err != nil {
+		return nil, err
+	}
+
+	groups, err := s.repo.ListGroups(ctx, tenant.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.userResource(u, groups), nil
+}
+
+// CreateUser provisions a user. The role is the default role of the SSO
+// settings, group memberships may raise it later.
+func (s *Service) CreateUser(ctx context.Context, tenant *models.Tenant, resource *models.SCIMUser) (*models.SCIMUser, error) {
+	email, err := userEmail(resource)
+	if err != nil {
+		return nil, err
+	}
+
+	settings, err := s.ssoService.Settings(ctx, tenant.ID)
+	if err != nil {
+		return nil, err
+	}
+	role, _ := settings.MapRole(nil)
+
+	active := resource.Active == nil || *resource.Active
+	firstName, lastName := userNames(resource)
+
+	created, err := s.users.ProvisionUser(ctx, tenant.ID, email, optionalString(firstName), optionalString(lastName), role, active)
+	if err == errors.ErrEmailAlreadyExists {
+		return nil, &Error{Status: http.StatusConflict, ScimType: "uniqueness", Detail: "a user with this userName already exists"}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return s.userResource(created, nil), nil
+}
+
+// ReplaceUser replaces the attributes of a user (PUT)
+func (s *Service) ReplaceUser(ctx context.Context, tenant *models.Tenant, id string, resource *models.SCIMUser) (*models.SCIMUser, error) {
+	u, err := s.getUser(ctx, tenant, id)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.saveUser(ctx, tenant, u, resource)
+}
+
+// PatchUser applies add, replace and remove operations to a user (PATCH).
+// Deprovisioning identity providers set active to false.
+func (s *Service) PatchUser(ctx context.Context, tenant *models.Tenant, id string, patch *models.SCIMPatchRequest) (*models.SCIMUser, error) {
+	u, err := s.getUser(ctx, tenant, id)
+	if err != nil {
+		return nil, err
+	}
+
+	resource := s.userResource(u, nil)
+	for _, operation := range patch.Operations {
+		if err := applyUserOperation(resource, operation); err != nil {
+			return nil, err
+		}
+	}
+
+	return s.saveUser(ctx, tenant, u, resource)
+}
+
+// DeleteUser deletes a user
+func (s *Service) DeleteUser(ctx context.Context, tenant *models.Tenant, id string) error {
+	u, err := s.getUser(ctx, tenant, id)
+	if err != nil {
+		return err
+	}
+	if u.Role == models.RoleOwner {
+		return ownerNotManaged()
+	}
+
+	return s.users.DeleteProvisionedUser(ctx, tenant.ID, u.ID)
+}
+
+// saveUser stores the attributes of a user resource. The role is only
+// changed through group memberships.
+func (s *Service) saveUser(ctx context.Context, tenant *models.Tenant, u *models.User, resource *models.SCIMUser) (*models.SCIMUser, error) {
+	if u.Role == models.RoleOwner {
+		return nil, ownerNotManaged()
+	}
+
+	email, err := userEmail(resource)
+	if err != nil {
+		return nil, err
+	}
+	if !strings.EqualFold(email, u.Email) {
+		if existing, _ := s.userRepo.GetByEmail(ctx, tenant.ID, email); existing != nil {
+			return nil, &Error{Status: http.StatusConflict, ScimType: "uniqueness", Detail: "a user with this userName already exists"}
+		}
+	}
+
+	active := u.IsActive
+	if resource.Active != nil {
+		active = *resource.Active
+	}
+	// Replacing the resource clears names it doesn't have
+	firstName, lastName := userNames(resource)
+
+	updated, err := s.users.UpdateProvisionedUser(ctx, tenant.ID, u.ID, email, &firstName, &lastName, u.Role, active)
+	if err != nil {
+		return nil, err
+	}
+
+	groups, err := s.repo.ListGroups(ctx, tenant.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.userResource(updated, groups), nil
+}
+
+// ListGroups returns a page of the groups of a tenant matching the filter
+func (s *Service) ListGroups(ctx context.Context, tenant *models.Tenant, filter string, startIndex, count int) (*models.SCIMListResponse, error) {
+	attribute, value, err := parseFilter(filter)
+	if err != nil {
+		return nil, err
+	}
+
+	groups, err := s.repo.ListGroups(ctx, tenant.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	var matching []*models.SCIMGroup
+	for _, group := range groups {
+		switch {
+		case attribute == "":
+		case strings.EqualFold(attribute, "displayName"):
+			if !strings.EqualFold(group.DisplayName, value) {
+				continue
+			}
+		case strings.EqualFold(attribute, "externalId"):
+			if group.ExternalID == nil || *group.ExternalID != value {
+				continue
+			}
+		case strings.EqualFold(attribute, "id"):
+			if strconv.FormatInt(group.ID, 10) != value {
+				continue
+			}
+		default:
+			return nil, &Error{Status: http.StatusBadRequest, ScimType: "invalidFilter", Detail: "filtering by " + attribute + " is not supported"}
+		}
+		matching = append(matching, group)
+	}
+
+	users, err := s.usersByID(ctx, tenant)
+	if err != nil {
+		return nil, err
+	}
+
+	page, startIndex := paginate(len(matching), startIndex, count)
+	resources := make([]*models.SCIMGroupResource, 0, page.end-page.start)
+	for _, group := range matching[page.start:page.end] {
+		resources = append(resources, s.groupResource(group, users))
+	}
+
+	return listResponse(len(matching), startIndex, resources, len(resources)), nil
+}
+
+// GetGroup returns a group of a tenant
+func (s *Service) GetGroup(ctx context.Context, tenant *models.Tenant, id string) (*models.SCIMGroupResource, error) {
+	group, err := s.getGroup(ctx, tenant, id)
+	if err != nil {
+		return nil, err
+	}
+
+	users, err := s.usersByID(ctx, tenant)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.groupResource(group, users), nil
+}
+
+// CreateGroup creates a group and applies the roles of its members
+func (s *Service) CreateGroup(ctx context.Context, tenant *models.Tenant, resource *models.SCIMGroupResource) (*models.SCIMGroupResource, error) {
+	group := &models.SCIMGroup{TenantID: tenant.ID}
+	if err := s.applyGroupResource(ctx, tenant, group, resource); err != nil {
+		return nil, err
+	}
+
+	if err := s.repo.CreateGroup(ctx, group); err != nil {
+		return nil, err
+	}
+
+	logger.Info("SCIM group created", "tenant_id", tenant.ID, "group_id", group.ID, "members", len(group.MemberIDs))
+
+	return s.saveGroupMembers(ctx, tenant, group, nil)
+}
+
+// ReplaceGroup replaces name and members of a group (PUT)
+func (s *Service) ReplaceGroup(ctx context.Context, tenant *models.Tenant, id string, resource *models.SCIMGroupResource) (*models.SCIMGroupResource, error) {
+	group, err := s.getGroup(ctx, tenant, id)
+	if err != nil {
+		return nil, err
+	}
+	previous := append([]int64(nil), group.MemberIDs...)
+
+	if err := s.applyGroupResource(ctx, tenant, group, resource); err != nil {
+		return nil, err
+	}
+	if err := s.repo.UpdateGroup(ctx, group); err != nil {
+		return nil, err
+	}
+
+	return s.saveGroupMembers(ctx, tenant, group, previous)
+}
+
+// PatchGroup applies add, replace and remove operations to a group (PATCH),
+// which is how identity providers change memberships
+func (s *Service) PatchGroup(ctx context.Context, tenant *models.Tenant, id string, patch *models.SCIMPatchRequest) (*models.SCIMGroupResource, error) {
+	group, err := s.getGroup(ctx, tenant, id)
+	if err != nil {
+		return nil, err
+	}
+	previous := append([]int64(nil), group.MemberIDs...)
+
+	for _, operation := range patch.Operations {
+		if err := applyGroupOperation(group, operation); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := s.validateGroup(ctx, tenant, group); err != nil {
+		return nil, err
+	}
+	if err := s.repo.UpdateGroup(ctx, group); err != nil {
+		return nil, err
+	}
+
+	return s.saveGroupMembers(ctx, tenant, group, previous)
+}
+
+// DeleteGroup deletes a group and applies the roles of its former members
+func (s *Service) DeleteGroup(ctx context.Context, tenant *models.Tenant, id string) error {
+	group, err := s.getGroup(ctx, tenant, id)
+	if err != nil {
+		return err
+	}
+
+	if err := s.repo.DeleteGroup(ctx, tenant.ID, group.ID); err != nil {
+		return err
+	}
+
+	logger.Info("SCIM group deleted", "tenant_id", tenant.ID, "group_id", group.ID)
+
+	return s.syncRoles(ctx, tenant, group.MemberIDs)
+}
+
+// saveGroupMembers applies the roles of added and removed members and returns the group
+func (s *Service) saveGroupMembers(ctx context.Context, tenant *models.Tenant, group *models.SCIMGroup, previous []int64) (*models.SCIMGroupResource, error) {
+	if err := s.syncRoles(ctx, tenant, append(previous, group.MemberIDs...)); err != nil {
+		return nil, err
+	}
+
+	users, err := s.usersByID(ctx, tenant)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.groupResource(group, users), nil
+}
+
+// syncRoles applies the role mapping of the SSO settings to the given users.
+// Owners keep their role.
+func (s *Service) syncRoles(ctx context.Context, tenant *models.Tenant, userIDs []int64) error {
+	settings, err := s.ssoService.Settings(ctx, tenant.ID)
+	if err != nil {
+		return err
+	}
+	if len(settings.RoleMapping) == 0 || len(userIDs) == 0 {
+		return nil
+	}
+
+	groups, err := s.repo.ListGroups(ctx, tenant.ID)
+	if err != nil {
+		return err
+	}
+
+	synced := make(map[int64]bool)
+	for _, userID := range userIDs {
+		if synced[userID] {
+			continue
+		}
+		synced[userID] = true
+
+		u, err := s.userRepo.GetByID(ctx, userID)
+		if err == errors.ErrNotFound {
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("failed to get user: %w", err)
+		}
+		if u.TenantID != tenant.ID || u.Role == models.RoleOwner {
+			continue
+		}
+
+		var names []string
+		for _, group := range groups {
+			if group.HasMember(u.ID) {
+				names = append(names, group.DisplayName)
+			}
+		}
+
+		role, _ := settings.MapRole(names)
+		if role == u.Role {
+			continue
+		}
+
+		logger.Info("User role updated from SCIM groups", "user_id", u.ID, "from", u.Role, "to", role)
+		if _, err := s.users.UpdateProvisionedUser(ctx, tenant.ID, u.ID, u.Email, u.FirstName, u.LastName, role, u.IsActive); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// applyGroupResource copies name and members of a group resource to a group
+func (s *Service) applyGroupResource(ctx context.Context, tenant *models.Tenant, group *models.SCIMGroup, resource *models.SCIMGroupResource) error {
+	group.DisplayName = strings.TrimSpace(resource.DisplayName)
+	group.ExternalID = nil
+	if resource.ExternalID != "" {
+		externalID := resource.ExternalID
+		group.ExternalID = &externalID
+	}
+
+	group.MemberIDs = []int64{}
+	for _, member := range resource.Members {
+		if err := addMember(group, member.Value); err != nil {
+			return err
+		}
+	}
+
+	return s.validateGroup(ctx, tenant, group)
+}
+
+// validateGroup checks the name is unique and all members belong to the tenant
+func (s *Service) validateGroup(ctx context.Context, tenant *models.Tenant, group *models.SCIMGroup) error {
+	if group.DisplayName == "" || len(group.DisplayName) > 255 {
+		return invalidValue("displayName is required and must be at most 255 characters")
+	}
+
+	groups, err := s.repo.ListGroups(ctx, tenant.ID)
+	if err != nil {
+		return err
+	}
+	for _, other := range groups {
+		if other.ID != group.ID && strings.EqualFold(other.DisplayName, group.DisplayName) {
+			return &Error{Status: http.StatusConflict, ScimType: "uniqueness", Detail: "a group with this displayName already exists"}
+		}
+	}
+
+	users, err := s.usersByID(ctx, tenant)
+	if err != nil {
+		return err
+	}
+	for _, userID := range group.MemberIDs {
+		if _, ok := users[userID]; !ok {
+			return invalidValue("member %d is not a user of this tenant", userID)
+		}
+	}
+
+	return nil
+}
+
+// getUser loads a user of the tenant by its SCIM ID
+func (s *Service) getUser(ctx context.Context, tenant *models.Tenant, id string) (*models.User, error) {
+	userID, err := strconv.ParseInt(id, 10, 64)
+	if err != nil {
+		return nil, errors.ErrNotFound
+	}
+
+	u, err := s.userRepo.GetByID(ctx, userID)
+	if err == errors.ErrNotFound {
+		return nil, errors.ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user: %w", err)
+	}
+
+	// Users of other tenants don't exist for this token
+	if u.TenantID != tenant.ID {
+		return nil, errors.ErrNotFound
+	}
+
+	return u, nil
+}
+
+// getGroup loads a group of the tenant by its SCIM ID
+func (s *Service) getGroup(ctx context.Context, tenant *models.Tenant, id string) (*models.SCIMGroup, error) {
+	groupID, err := strconv.ParseInt(id, 10, 64)
+	if err != nil {
+		return nil, errors.ErrNotFound
+	}
+
+	return s.repo.GetGroup(ctx, tenant.ID, groupID)
+}
+
+// usersByID returns the users of a tenant by ID
+func (s *Service) usersByID(ctx context.Context, tenant *models.Tenant) (map[int64]*models.User, error) {
+	users, err := s.userRepo.List(ctx, tenant.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list users: %w", err)
+	}
+
+	byID := make(map[int64]*models.User, len(users))
+	for _, u := range users {
+		byID[u.ID] = u
+	}
+	return byID, nil
+}
+
+// userResource returns the SCIM representation of a user
+func (s *Service) userResource(u *models.User, groups []*models.SCIMGroup) *models.SCIMUser {
+	id := strconv.FormatInt(u.ID, 10)
+	active := u.IsActive
+
+	resource := &models.SCIMUser{
+		Schemas:  []string{models.SCIMUserSchema},
+		ID:       id,
+		UserName: u.Email,
+		Emails:   []models.SCIMEmail{{Value: u.Email, Type: "work", Primary: true}},
+		Active:   &active,
+		Meta:     s.meta("User", "/Users/"+id, u.CreatedAt, u.UpdatedAt),
+	}
+
+	name := &models.SCIMName{}
+	if u.FirstName != nil {
+		name.GivenName = *u.FirstName
+	}
+	if u.LastName != nil {
+		name.FamilyName = *u.LastName
+	}
+	if name.GivenName != "" || name.FamilyName != "" {
+		name.Formatted = strings.TrimSpace(name.GivenName + " " + name.FamilyName)
+		resource.Name = name
+		resource.DisplayName = name.Formatted
+	}
+
+	for _, group := range groups {
+		if group.HasMember(u.ID) {
+			groupID := strconv.FormatInt(group.ID, 10)
+			resource.Groups = append(resource.Groups, models.SCIMResource{
+				Value:   groupID,
+				Display: group.DisplayName,
+				Ref:     s.BaseURL() + "/Groups/" + groupID,
+			})
+		}
+	}
+
+	return resource
+}
+
+// groupResource returns the SCIM representation of a group
+func (s *Service) groupResource(group *models.SCIMGroup, users map[int64]*models.User) *models.SCIMGroupResource {
+	id := strconv.FormatInt(group.ID, 10)
+
+	resource := &models.SCIMGroupResource{
+		Schemas:     []string{models.SCIMGroupSchema},
+		ID:          id,
+		DisplayName: group.DisplayName,
+		Members:     []models.SCIMResource{},
+		Meta:        s.meta("Group", "/Groups/"+id, group.CreatedAt, group.UpdatedAt),
+	}
+	if group.ExternalID != nil {
+		resource.ExternalID = *group.ExternalID
+	}
+
+	for _, userID := range group.MemberIDs {
+		member := models.SCIMResource{
+			Value: strconv.FormatInt(userID, 10),
+			Ref:   s.BaseURL() + "/Users/" + strconv.FormatInt(userID, 10),
+		}
+		if u, ok := users[userID]; ok {
+			member.Display = u.Email
+		}
+		resource.Members = append(resource.Members, member)
+	}
+
+	return resource
+}
+
+func (s *Service) meta(resourceType, path string, created, lastModified time.Time) *models.SCIMMeta {
+	meta := &models.SCIMMeta{
+		ResourceType: resourceType,
+		Location:     s.BaseURL() + path,
+	}
+	if !created.IsZero() {
+		meta.Created = &created
+	}
+	if !lastModified.IsZero() {
+		meta.LastModified = &lastModified
+	}
+	return meta
+}
+
+// ownerNotManaged is returned for changes to the tenant owner, who stays
+// managed in the app so the tenant can't be locked out by its identity provider
+func ownerNotManaged() error {
+	return &Error{Status: http.StatusForbidden, Detail: "the tenant owner can't be managed through SCIM"}
+}
+
+// userEmail returns the email address of a user resource, which is its userName
+func userEmail(resource *models.SCIMUser) (string, error) {
+	email := strings.ToLower(strings.TrimSpace(resource.UserName))
+	if email == "" {
+		return "", invalidValue("userName is required")
+	}
+
+	address, err := mail.ParseAddress(email)
+	if err != nil || address.Address != email || len(email) > 255 {
+		return "", invalidValue("userName must be an email address")
+	}
+
+	return email, nil
+}
+
+// userNames returns first and last name of a user resource
+func userNames(resource *models.SCIMUser) (string, string) {
+	if resource.Name == nil {
+		return "", ""
+	}
+	return strings.TrimSpace(resource.Name.GivenName), strings.TrimSpace(resource.Name.FamilyName)
+}
+
+func optionalString(s string) *string {
+	if s == "" {
+		return nil
+	}
+	return &s
+}
+
+// pageBounds are the slice bounds of a page
+type pageBounds struct {
+	start, end int
+}
+
+// paginate applies the 1-based startIndex and count of a list request
+func paginate(total, startIndex, count int) (pageBounds, int) {
+	if startIndex < 1 {
+		startIndex = 1
+	}
+	if count < 0 {
+		count = 0
+	}
+	if count > models.SCIMMaxResults {
+		count = models.SCIMMaxResults
+	}
+
+	start := startIndex - 1
+	if start > total {
+		start = total
+	}
+	end := start + count
+	if end > total {
+		end = total
+	}
+
+	return pageBounds{start: start, end: end}, startIndex
+}
+
+func listResponse(total, startIndex int, resources interface{}, itemsPerPage int) *models.SCIMListResponse {
+	return &models.SCIMListResponse{
+		Schemas:      []string{models.SCIMListResponseSchema},
+		TotalResults: total,
+		StartIndex:   startIndex,
+		ItemsPerPage: itemsPerPage,
+		Resources:    resources,
+	}
+}
@@ -0,0 +1,610 @@
+package sso
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/yourusername/gin-collection-saas/internal/domain/errors"
+	"github.com/yourusername/gin-collection-saas/internal/domain/models"
+	"github.com/yourusername/gin-collection-saas/internal/domain/repositories"
+	"github.com/yourusername/gin-collection-saas/internal/infrastructure/external"
+	"github.com/yourusername/gin-collection-saas/internal/usecase/entitlement"
+	"github.com/yourusername/gin-collection-saas/pkg/logger"
+	"github.com/yourusername/gin-collection-saas/pkg/utils"
+)
+
+// samlTransientNameID identifies NameIDs that change with every login
+const samlTransientNameID = "urn:oasis:names:tc:SAML:2.0:nameid-format:transient"
+
+// Identity is a user identity asserted by the identity provider of a tenant
+type Identity struct {
+	Protocol     string
+	Subject      string // empty if the identity provider has no stable identifier
+	Email        string
+	FirstName    string
+	LastName     string
+	Groups       []string
+	RedirectPath string // where the user wanted to go when the login started
+}
+
+// Service handles the single sign-on configuration of Enterprise tenants,
+// OpenID Connect and SAML logins and just-in-time provisioning of users
+type Service struct {
+	tenantRepo   repositories.TenantRepository
+	userRepo     repositories.UserRepository
+	repo         repositories.SSORepository
+	entitlements *entitlement.Service
+	oidc         *external.OIDCClient
+	baseURL      string
+}
+
+// NewService creates a new SSO service. baseURL is the public URL of the API,
+// the identity providers redirect the browser back to it.
+func NewService(
+	tenantRepo repositories.TenantRepository,
+	userRepo repositories.UserRepository,
+	repo repositories.SSORepository,
+	entitlements *entitlement.Service,
+	oidcClient *external.OIDCClient,
+	baseURL string,
+) *Service {
+	return &Service{
+		tenantRepo:   tenantRepo,
+		userRepo:     userRepo,
+		repo:         repo,
+		entitlements: entitlements,
+		oidc:         oidcClient,
+		baseURL:      strings.TrimSuffix(baseURL, "/"),
+	}
+}
+
+// ServiceProvider returns the endpoints a tenant registers at its identity provider
+func (s *Service) ServiceProvider(subdomain string) models.SSOServiceProvider {
+	base := s.baseURL + "/api/v1/auth/sso/" + url.PathEscape(subdomain)
+	return models.SSOServiceProvider{
+		LoginURL:        base + "/login",
+		OIDCRedirectURI: base + "/oidc/callback",
+		SAMLEntityID:    base + "/saml/metadata",
+		SAMLACSURL:      base + "/saml/acs",
+		SAMLMetadataURL: base + "/saml/metadata",
+	}
+}
+
+// Settings returns the SSO configuration of a tenant, an empty one if unset
+func (s *Service) Settings(ctx context.Context, tenantID int64) (*models.SSOSettings, error) {
+	raw, err := s.tenantRepo.GetSettings(ctx, tenantID)
+	if err != nil {
+		return nil, err
+	}
+
+	settings := &models.SSOSettings{}
+	if len(raw) == 0 {
+		return settings, nil
+	}
+
+	var document map[string]json.RawMessage
+	if err := json.Unmarshal(raw, &document); err != nil {
+		return nil, fmt.Errorf("failed to parse tenant settings: %w", err)
+	}
+	if value, ok := document[models.SSOSettingsKey]; ok {
+		if err := json.Unmarshal(value, settings); err != nil {
+			return nil, fmt.Errorf("failed to parse sso settings: %w", err)
+		}
+	}
+
+	return settings, nil
+}
+
+// UpdateSettings stores a validated SSO configuration. An empty client secret
+// keeps the stored one, so it never has to be sent back to the browser.
+func (s *Service) UpdateSettings(ctx context.Context, tenant *models.Tenant, settings *models.SSOSettings) (*models.SSOSettings, error) {
+	if err := s.entitlements.RequireFeature(tenant, models.FeatureSSO); err != nil {
+		return nil, err
+	}
+
+	current, err := s.Settings(ctx, tenant.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	if oidc := settings.OIDC; oidc != nil {
+		if oidc.ClientSecret == "" && current.OIDC != nil && current.OIDC.Issuer == oidc.Issuer && current.OIDC.ClientID == oidc.ClientID {
+			oidc.ClientSecret = current.OIDC.ClientSecret
+		}
+		oidc.Scopes = normalizeScopes(oidc.Scopes)
+	}
+
+	raw, err := s.tenantRepo.GetSettings(ctx, tenant.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Other keys of the settings document are kept as they are
+	document := map[string]json.RawMessage{}
+	if len(raw) > 0 {
+		if err := json.Unmarshal(raw, &document); err != nil {
+			return nil, fmt.Errorf("failed to parse tenant settings: %w", err)
+		}
+	}
+
+	value, err := json.Marshal(settings)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode sso settings: %w", err)
+	}
+	document[models.SSOSettingsKey] = value
+
+	updated, err := json.Marshal(document)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode tenant settings: %w", err)
+	}
+
+	if err := s.tenantRepo.UpdateSettings(ctx, tenant.ID, updated); err != nil {
+		return nil, err
+	}
+
+	logger.Info("SSO settings updated", "tenant_id", tenant.ID, "enabled", settings.Enabled, "protocol", settings.Protocol,
+		"disable_password_login", settings.DisablePasswordLogin)
+
+	return settings, nil
+}
+
+// PasswordLoginDisabled reports whether the users of a tenant must sign in with SSO
+func (s *Service) PasswordLoginDisabled(ctx context.Context, tenant *models.Tenant) bool {
+	if !s.entitlements.Included(tenant, models.FeatureSSO) {
+		return false
+	}
+
+	settings, err := s.Settings(ctx, tenant.ID)
+	if err != nil {
+		logger.Error("Failed to get sso settings", "tenant_id", tenant.ID, "error", err.Error())
+		return false
+	}
+
+	return settings.PasswordLoginDisabled()
+}
+
+// LoginInfo tells the login page of a tenant which sign-in methods are offered
+func (s *Service) LoginInfo(ctx context.Context, subdomain string) (*models.SSOLoginInfo, error) {
+	tenant, settings, err := s.enabledSettings(ctx, subdomain)
+	if err == errors.ErrSSONotConfigured {
+		return &models.SSOLoginInfo{PasswordLoginEnabled: true}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.SSOLoginInfo{
+		Enabled:              true,
+		Protocol:             settings.Protocol,
+		PasswordLoginEnabled: !settings.DisablePasswordLogin,
+		LoginURL:             s.ServiceProvider(tenant.Subdomain).LoginURL,
+	}, nil
+}
+
+// Metadata returns the SAML service provider metadata of a tenant
+func (s *Service) Metadata(ctx context.Context, subdomain string) ([]byte, error) {
+	tenant, err := s.tenantRepo.GetBySubdomain(ctx, subdomain)
+	if err != nil {
+		return nil, err
+	}
+	if !s.entitlements.Included(tenant, models.FeatureSSO) {
+		return nil, errors.ErrSSONotConfigured
+	}
+
+	sp := s.ServiceProvider(tenant.Subdomain)
+	provider := &external.SAMLServiceProvider{
+		EntityID: sp.SAMLEntityID,
+		ACSURL:   sp.SAMLACSURL,
+	}
+
+	return provider.Metadata(), nil
+}
+
+// BeginLogin starts a login at the identity provider of a tenant. It returns
+// the URL to redirect the browser to and the state, which the caller binds to
+// the browser so the callback can't be completed in another one.
+func (s *Service) BeginLogin(ctx context.Context, subdomain, redirectPath string) (string, string, error) {
+	tenant, settings, err := s.enabledSettings(ctx, subdomain)
+	if err != nil {
+		return "", "", err
+	}
+
+	// Housekeeping, abandoned logins are never consumed
+	if _, err := s.repo.DeleteExpiredLoginStates(ctx); err != nil {
+		logger.Error("Failed to delete expired sso login states", "error", err.Error())
+	}
+
+	state, err := utils.GenerateSecureToken(32)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to generate state: %w", err)
+	}
+
+	loginState := &models.SSOLoginState{
+		ID:           utils.HashToken(state),
+		TenantID:     tenant.ID,
+		Protocol:     settings.Protocol,
+		RedirectPath: safeRedirectPath(redirectPath),
+		ExpiresAt:    time.Now().Add(models.SSOLoginStateTTL),
+	}
+
+	var loginURL string
+	switch settings.Protocol {
+	case models.SSOProtocolOIDC:
+		provider, err := s.oidc.Discover(ctx, settings.OIDC.Issuer)
+		if err != nil {
+			logger.Error("OIDC discovery failed", "tenant_id", tenant.ID, "issuer", settings.OIDC.Issuer, "error", err.Error())
+			return "", "", err
+		}
+
+		verifier, err := external.GeneratePKCEVerifier()
+		if err != nil {
+			return "", "", err
+		}
+		nonce, err := utils.GenerateSecureToken(16)
+		if err != nil {
+			return "", "", fmt.Errorf("failed to generate nonce: %w", err)
+		}
+		loginState.CodeVerifier = verifier
+		loginState.Nonce = nonce
+
+		loginURL, err = s.oidc.AuthCodeURL(provider, external.OIDCAuthRequest{
+			ClientID:      settings.OIDC.ClientID,
+			RedirectURI:   s.ServiceProvider(tenant.Subdomain).OIDCRedirectURI,
+			Scopes:        normalizeScopes(settings.OIDC.Scopes),
+			State:         state,
+			Nonce:         nonce,
+			CodeChallenge: external.PKCEChallenge(verifier),
+		})
+		if err != nil {
+			return "", "", err
+		}
+
+	case models.SSOProtocolSAML:
+		provider, err := s.samlServiceProvider(tenant, settings)
+		if err != nil {
+			return "", "", err
+		}
+
+		var requestID string
+		loginURL, requestID, err = provider.AuthnRequestURL(state)
+		if err != nil {
+			return "", "", err
+		}
+		loginState.RequestID = requestID
+
+	default:
+		return "", "", errors.ErrSSONotConfigured
+	}
+
+	if err := s.repo.CreateLoginState(ctx, loginState); err != nil {
+		return "", "", err
+	}
+
+	logger.Debug("SSO login started", "tenant_id", tenant.ID, "protocol", settings.Protocol)
+
+	return loginURL, state, nil
+}
+
+// CompleteOIDC redeems the authorization code of an OpenID Connect login and
+// returns the verified identity
+func (s *Service) CompleteOIDC(ctx context.Context, subdomain, state, code string) (*models.Tenant, *Identity, error) {
+	tenant, settings, err := s.enabledSettings(ctx, subdomain)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	loginState, err := s.consumeLoginState(ctx, tenant, settings, models.SSOProtocolOIDC, state)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	provider, err := s.oidc.Discover(ctx, settings.OIDC.Issuer)
+	if err != nil {
+		logger.Error("OIDC discovery failed", "tenant_id", tenant.ID, "issuer", settings.OIDC.Issuer, "error", err.Error())
+		return nil, nil, err
+	}
+
+	rawIDToken, err := s.oidc.Exchange(ctx, provider, external.OIDCCodeExchange{
+		ClientID:     settings.OIDC.ClientID,
+		ClientSecret: settings.OIDC.ClientSecret,
+		Code:         code,
+		RedirectURI:  s.ServiceProvider(tenant.Subdomain).OIDCRedirectURI,
+		CodeVerifier: loginState.CodeVerifier,
+	})
+	if err != nil {
+		return nil, nil, s.verificationFailed(tenant, err)
+	}
+
+	claims, err := s.oidc.VerifyIDToken(ctx, provider, rawIDToken, settings.OIDC.ClientID, loginState.Nonce)
+	if err != nil {
+		return nil, nil, s.verificationFailed(tenant, err)
+	}
+
+	// An unverified email must not take over the account that owns it
+	if claims.EmailVerified != nil && !*claims.EmailVerified {
+		return nil, nil, s.verificationFailed(tenant, fmt.Errorf("email %s is not verified", claims.Email))
+	}
+
+	return tenant, &Identity{
+		Protocol:     models.SSOProtocolOIDC,
+		Subject:      claims.Subject,
+		Email:        claims.Email,
+		FirstName:    claims.GivenName,
+		LastName:     claims.FamilyName,
+		Groups:       claims.Strings(settings.GroupsClaimName()),
+		RedirectPath: loginState.RedirectPath,
+	}, nil
+}
+
+// CompleteSAML verifies the SAML response posted to the assertion consumer
+// service and returns the verified identity
+func (s *Service) CompleteSAML(ctx context.Context, subdomain, samlResponse, relayState string) (*models.Tenant, *Identity, error) {
+	tenant, settings, err := s.enabledSettings(ctx, subdomain)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	loginState, err := s.consumeLoginState(ctx, tenant, settings, models.SSOProtocolSAML, relayState)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	provider, err := s.samlServiceProvider(tenant, settings)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	assertion, err := provider.ParseResponse(samlResponse, loginState.RequestID)
+	if err != nil {
+		return nil, nil, s.verificationFailed(tenant, err)
+	}
+
+	email := assertion.Attribute(orDefault(settings.SAML.EmailAttribute, models.DefaultSAMLEmailAttribute))
+	if email == "" && assertion.NameIDFormat == external.SAMLNameIDEmail {
+		email = assertion.NameID
+	}
+
+	subject := assertion.NameID
+	if assertion.NameIDFormat == samlTransientNameID {
+		subject = ""
+	}
+
+	return tenant, &Identity{
+		Protocol:     models.SSOProtocolSAML,
+		Subject:      subject,
+		Email:        email,
+		FirstName:    assertion.Attribute(orDefault(settings.SAML.FirstNameAttribute, models.DefaultSAMLFirstNameAttribute)),
+		LastName:     assertion.Attribute(orDefault(settings.SAML.LastNameAttribute, models.DefaultSAMLLastNameAttribute)),
+		Groups:       assertion.Attributes[settings.GroupsClaimName()],
+		RedirectPath: loginState.RedirectPath,
+	}, nil
+}
+
+// ResolveUser returns the user of a verified identity. Unknown identities are
+// linked to the user with the same email, or provisioned if the tenant allows
+// it. With a role mapping the role follows the identity provider groups.
+func (s *Service) ResolveUser(ctx context.Context, tenant *models.Tenant, identity *Identity) (*models.User, error) {
+	settings, err := s.Settings(ctx, tenant.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	email := strings.ToLower(strings.TrimSpace(identity.Email))
+
+	var user *models.User
+	if identity.Subject != "" {
+		link, err := s.repo.GetIdentity(ctx, tenant.ID, identity.Protocol, identity.Subject)
+		switch {
+		case err == nil:
+			user, err = s.userRepo.GetByID(ctx, link.UserID)
+			if err != nil {
+				return nil, fmt.Errorf("failed to get user: %w", err)
+			}
+		case err != errors.ErrNotFound:
+			return nil, err
+		}
+	}
+
+	if user == nil {
+		if email == "" {
+			logger.Warn("SSO identity without email", "tenant_id", tenant.ID, "protocol", identity.Protocol)
+			return nil, errors.ErrSSOVerification
+		}
+
+		existing, err := s.userRepo.GetByEmail(ctx, tenant.ID, email)
+		switch {
+		case err == nil:
+			user = existing
+		case err == errors.ErrNotFound:
+			if !settings.JITProvisioning {
+				logger.Info("SSO login of unknown user", "tenant_id", tenant.ID, "email", email)
+				return nil, errors.ErrSSOUserNotProvisioned
+			}
+			user, err = s.provisionUser(ctx, tenant, settings, identity, email)
+			if err != nil {
+				return nil, err
+			}
+		default:
+			return nil, err
+		}
+
+		if identity.Subject != "" {
+			link := &models.SSOIdentity{
+				TenantID: tenant.ID,
+				UserID:   user.ID,
+				Protocol: identity.Protocol,
+				Subject:  identity.Subject,
+			}
+			if err := s.repo.CreateIdentity(ctx, link); err != nil {
+				// Linking again on the next login is harmless
+				logger.Error("Failed to link sso identity", "user_id", user.ID, "error", err.Error())
+			}
+		}
+	}
+
+	if user.TenantID != tenant.ID {
+		logger.Warn("SSO identity linked to user of another tenant", "user_id", user.ID, "tenant_id", tenant.ID)
+		return nil, errors.ErrSSOVerification
+	}
+
+	if role, managed := settings.MapRole(identity.Groups); managed && user.Role != models.RoleOwner && user.Role != role {
+		logger.Info("User role updated from identity provider groups", "user_id", user.ID, "from", user.Role, "to", role)
+		user.Role = role
+		if err := s.userRepo.Update(ctx, user); err != nil {
+			return nil, fmt.Errorf("failed to update user role: %w", err)
+		}
+	}
+
+	return user, nil
+}
+
+// provisionUser creates the account of a user on their first SSO login. The
+// password is random and unknown, so the account can only be used with SSO
+// until the user resets it.
+func (s *Service) provisionUser(ctx context.Context, tenant *models.Tenant, settings *models.SSOSettings, identity *Identity, email string) (*models.User, error) {
+	password, err := utils.GenerateSecureToken(32)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate password: %w", err)
+	}
+	passwordHash, err := utils.HashPassword(password)
+	if err != nil {
+		return nil, fmt.Errorf("failed to hash password: %w", err)
+	}
+
+	role, _ := settings.MapRole(identity.Groups)
+
+	// The identity provider owns the address
+	verifiedAt := time.Now()
+	user := &models.User{
+		TenantID:        tenant.ID,
+		Email:           email,
+		PasswordHash:    passwordHash,
+		FirstName:       optionalString(identity.FirstName),
+		LastName:        optionalString(identity.LastName),
+		Role:            role,
+		IsActive:        true,
+		EmailVerifiedAt: &verifiedAt,
+	}
+
+	if err := s.userRepo.Create(ctx, user); err != nil {
+		return nil, fmt.Errorf("failed to create user: %w", err)
+	}
+
+	logger.Info("User provisioned by single sign-on", "user_id", user.ID, "tenant_id", tenant.ID, "role", role)
+
+	return user, nil
+}
+
+// enabledSettings loads a tenant and its SSO configuration, if SSO is usable
+func (s *Service) enabledSettings(ctx context.Context, subdomain string) (*models.Tenant, *models.SSOSettings, error) {
+	tenant, err := s.tenantRepo.GetBySubdomain(ctx, subdomain)
+	if err != nil {
+		return nil, nil, err
+	}
+	if tenant.Status != models.TenantStatusActive {
+		return nil, nil, errors.ErrTenantSuspended
+	}
+
+	// SSO stops working after a downgrade, password login works again
+	if !s.entitlements.Included(tenant, models.FeatureSSO) {
+		return nil, nil, errors.ErrSSONotConfigured
+	}
+
+	settings, err := s.Settings(ctx, tenant.ID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if !settings.Enabled || settings.Validate() != nil {
+		return nil, nil, errors.ErrSSONotConfigured
+	}
+
+	return tenant, settings, nil
+}
+
+// consumeLoginState loads and removes the pending login of a callback
+func (s *Service) consumeLoginState(ctx context.Context, tenant *models.Tenant, settings *models.SSOSettings, protocol, state string) (*models.SSOLoginState, error) {
+	if state == "" {
+		return nil, errors.ErrSSOVerification
+	}
+
+	loginState, err := s.repo.ConsumeLoginState(ctx, utils.HashToken(state))
+	if err == errors.ErrNotFound {
+		return nil, s.verificationFailed(tenant, fmt.Errorf("unknown login state"))
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// The configuration may have changed while the user was at the identity provider
+	if loginState.IsExpired() || loginState.TenantID != tenant.ID || loginState.Protocol != protocol || settings.Protocol != protocol {
+		return nil, s.verificationFailed(tenant, fmt.Errorf("login state expired or does not match"))
+	}
+
+	return loginState, nil
+}
+
+// samlServiceProvider returns the SAML service provider of a tenant
+func (s *Service) samlServiceProvider(tenant *models.Tenant, settings *models.SSOSettings) (*external.SAMLServiceProvider, error) {
+	certs, err := models.ParseSAMLCertificates(settings.SAML.IdPCertificate)
+	if err != nil {
+		return nil, fmt.Errorf("invalid IdP certificate: %w", err)
+	}
+
+	sp := s.ServiceProvider(tenant.Subdomain)
+	return &external.SAMLServiceProvider{
+		EntityID:        sp.SAMLEntityID,
+		ACSURL:          sp.SAMLACSURL,
+		IdPEntityID:     settings.SAML.IdPEntityID,
+		IdPSSOURL:       settings.SAML.IdPSSOURL,
+		IdPCertificates: certs,
+	}, nil
+}
+
+// verificationFailed logs the protocol error and hides details from the client
+func (s *Service) verificationFailed(tenant *models.Tenant, err error) error {
+	logger.Warn("SSO verification failed", "tenant_id", tenant.ID, "error", err.Error())
+	return errors.ErrSSOVerification
+}
+
+// normalizeScopes applies the default scopes and makes sure "openid" is requested
+func normalizeScopes(scopes []string) []string {
+	if len(scopes) == 0 {
+		return models.DefaultOIDCScopes
+	}
+	for _, scope := range scopes {
+		if scope == "openid" {
+			return scopes
+		}
+	}
+	return append([]string{"openid"}, scopes...)
+}
+
+// safeRedirectPath only allows local paths, so the login can't be used as an open redirect
+func safeRedirectPath(path string) string {
+	if !strings.HasPrefix(path, "/") || strings.HasPrefix(path, "//") || strings.Contains(path, "\\") {
+		return "/"
+	}
+	u, err := url.Parse(path)
+	if err != nil || u.Scheme != "" || u.Host != "" || len(path) > 255 {
+		return "/"
+	}
+	return path
+}
+
+func orDefault(value, fallback string) string {
+	if value == "" {
+		return fallback
+	}
+	return value
+}
+
+func optionalString(s string) *string {
+	if s == "" {
+		return nil
+	}
+	return &s
+}
@@ -0,0 +1,500 @@
+package storagesync
+
+import (
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	domainErrors "github.com/yourusername/gin-collection-saas/internal/domain/errors"
+	"github.com/yourusername/gin-collection-saas/internal/domain/models"
+	"github.com/yourusername/gin-collection-saas/internal/domain/repositories"
+	"github.com/yourusername/gin-collection-saas/internal/infrastructure/storage"
+	"github.com/yourusername/gin-collection-saas/pkg/logger"
+	"github.com/yourusername/gin-collection-saas/pkg/utils"
+)
+
+// orphanGracePeriod protects recently written objects (e.g. pending direct
+// uploads) from being reported or deleted as orphans
+const orphanGracePeriod = time.Hour
+
+// Service migrates photos between storage backends and reconciles the
+// database against storage
+type Service struct {
+	photoRepo        repositories.PhotoRepository
+	tenantRepo       repositories.TenantRepository
+	storageUsageRepo repositories.StorageUsageRepository
+	backends         map[string]storage.Storage
+	active           string
+
+	mu   sync.Mutex
+	jobs map[string]*MigrationJob
+}
+
+// NewService creates a new storage sync service
+func NewService(
+	photoRepo repositories.PhotoRepository,
+	tenantRepo repositories.TenantRepository,
+	storageUsageRepo repositories.StorageUsageRepository,
+) *Service {
+	return &Service{
+		photoRepo:        photoRepo,
+		tenantRepo:       tenantRepo,
+		storageUsageRepo: storageUsageRepo,
+		backends:         make(map[string]storage.Storage),
+		jobs:             make(map[string]*MigrationJob),
+	}
+}
+
+// RegisterBackend makes a storage backend available under a name ("local", "s3")
+func (s *Service) RegisterBackend(name string, backend storage.Storage) {
+	s.backends[name] = backend
+}
+
+// SetActiveBackend names the backend the API stores new photos on. Photos
+// without a recorded backend are deleted from and reconciled against it.
+func (s *Service) SetActiveBackend(name string) {
+	s.active = name
+}
+
+// Backends returns the names of all registered backends
+func (s *Service) Backends() []string {
+	names := make([]string, 0, len(s.backends))
+	for name := range s.backends {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// MigrateOptions controls a migration run
+type MigrateOptions struct {
+	TenantID     int64  `json:"tenant_id"` // 0 = all tenants
+	From         string `json:"from"`
+	To           string `json:"to"`
+	DeleteSource bool   `json:"delete_source"`
+	DryRun       bool   `json:"dry_run"`
+}
+
+// MigrationReport summarizes a migration run
+type MigrationReport struct {
+	From             string           `json:"from"`
+	To               string           `json:"to"`
+	DryRun           bool             `json:"dry_run"`
+	TenantsProcessed int              `json:"tenants_processed"`
+	Copied           int              `json:"copied"`
+	Skipped          int              `json:"skipped"`
+	Failed           int              `json:"failed"`
+	BytesCopied      int64            `json:"bytes_copied"`
+	Errors           []MigrationError `json:"errors,omitempty"`
+}
+
+// MigrationError describes a photo that could not be migrated
+type MigrationError struct {
+	TenantID int64  `json:"tenant_id"`
+	PhotoID  int64  `json:"photo_id"`
+	Key      string `json:"key"`
+	Error    string `json:"error"`
+}
+
+// MigrationJob is a migration running in the background
+type MigrationJob struct {
+	ID         string           `json:"id"`
+	Status     string           `json:"status"`
+	Options    MigrateOptions   `json:"options"`
+	Report     *MigrationReport `json:"report,omitempty"`
+	Error      string           `json:"error,omitempty"`
+	StartedAt  time.Time        `json:"started_at"`
+	FinishedAt *time.Time       `json:"finished_at,omitempty"`
+}
+
+// Migration job statuses
+const (
+	MigrationJobRunning   = "running"
+	MigrationJobCompleted = "completed"
+	MigrationJobFailed    = "failed"
+)
+
+// StartMigration runs a migration in the background and returns its job.
+// Only one migration runs at a time.
+func (s *Service) StartMigration(opts *MigrateOptions) (*MigrationJob, error) {
+	if _, _, err := s.migrationBackends(opts); err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, job := range s.jobs {
+		if job.Status == MigrationJobRunning {
+			return nil, domainErrors.ErrConflict
+		}
+	}
+
+	job := &MigrationJob{
+		ID:        uuid.New().String(),
+		Status:    MigrationJobRunning,
+		Options:   *opts,
+		StartedAt: time.Now(),
+	}
+	s.jobs[job.ID] = job
+
+	go func() {
+		report, err := s.Migrate(context.Background(), &job.Options)
+
+		s.mu.Lock()
+		defer s.mu.Unlock()
+
+		now := time.Now()
+		job.Report = report
+		job.FinishedAt = &now
+		job.Status = MigrationJobCompleted
+		if err != nil {
+			logger.Error("Storage migration failed", "job_id", job.ID, "error", err.Error())
+			job.Status = MigrationJobFailed
+			job.Error = err.Error()
+		}
+	}()
+
+	copied := *job
+	return &copied, nil
+}
+
+// GetMigrationJob returns a migration job started on this instance
+func (s *Service) GetMigrationJob(id string) (*MigrationJob, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	job, ok := s.jobs[id]
+	if !ok {
+		return nil, domainErrors.ErrNotFound
+	}
+	copied := *job
+	return &copied, nil
+}
+
+// migrationBackends returns the source and target backend of a migration
+func (s *Service) migrationBackends(opts *MigrateOptions) (storage.Storage, storage.Storage, error) {
+	src, ok := s.backends[opts.From]
+	if !ok {
+		return nil, nil, fmt.Errorf("unknown source backend: %s", opts.From)
+	}
+	dst, ok := s.backends[opts.To]
+	if !ok {
+		return nil, nil, fmt.Errorf("unknown target backend: %s", opts.To)
+	}
+	if opts.From == opts.To {
+		return nil, nil, fmt.Errorf("source and target backend must differ")
+	}
+	return src, dst, nil
+}
+
+// Migrate copies photos from one backend to another, verifies each copy by
+// checksum and rewrites the photo's storage backend, key and URL. Photos
+// recorded on another backend are skipped, photos without a recorded backend
+// are read from the source.
+func (s *Service) Migrate(ctx context.Context, opts *MigrateOptions) (*MigrationReport, error) {
+	src, dst, err := s.migrationBackends(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	tenantIDs, err := s.tenantIDs(ctx, opts.TenantID)
+	if err != nil {
+		return nil, err
+	}
+
+	logger.Info("Starting storage migration", "from", opts.From, "to", opts.To, "tenants", len(tenantIDs), "dry_run", opts.DryRun)
+
+	report := &MigrationReport{From: opts.From, To: opts.To, DryRun: opts.DryRun}
+
+	for _, tenantID := range tenantIDs {
+		photos, err := s.photoRepo.ListByTenant(ctx, tenantID)
+		if err != nil {
+			return report, err
+		}
+
+		for _, photo := range photos {
+			if photo.StorageKey == nil || (photo.StorageBackend != "" && photo.StorageBackend != opts.From) {
+				// External URL or not on the source backend, nothing to migrate
+				report.Skipped++
+				continue
+			}
+
+			copied, size, err := s.migratePhoto(ctx, photo, src, dst, opts)
+			if err != nil {
+				logger.Error("Failed to migrate photo", "tenant_id", tenantID, "photo_id", photo.ID, "error", err.Error())
+				report.Failed++
+				report.Errors = append(report.Errors, MigrationError{
+					TenantID: tenantID,
+					PhotoID:  photo.ID,
+					Key:      *photo.StorageKey,
+					Error:    err.Error(),
+				})
+				continue
+			}
+
+			if copied {
+				report.Copied++
+				report.BytesCopied += size
+			} else {
+				report.Skipped++
+			}
+		}
+
+		report.TenantsProcessed++
+	}
+
+	logger.Info("Storage migration finished", "copied", report.Copied, "skipped", report.Skipped, "failed", report.Failed)
+
+	return report, nil
+}
+
+// migratePhoto copies a single photo and returns whether data was copied
+func (s *Service) migratePhoto(ctx context.Context, photo *models.GinPhoto, src, dst storage.Storage, opts *MigrateOptions) (bool, int64, error) {
+	key := *photo.StorageKey
+
+	data, err := src.DownloadPhoto(ctx, key)
+	if err != nil {
+		return false, 0, fmt.Errorf("failed to read source object: %w", err)
+	}
+	checksum := sha256.Sum256(data)
+
+	// Skip the copy if the target already holds identical content
+	copied := true
+	if exists, _ := dst.CheckExists(ctx, key); exists {
+		if existing, err := dst.DownloadPhoto(ctx, key); err == nil && sha256.Sum256(existing) == checksum {
+			copied = false
+		}
+	}
+
+	if opts.DryRun {
+		return copied, int64(len(data)), nil
+	}
+
+	if copied {
+		contentType, err := utils.ValidateImageMagicBytes(data)
+		if err != nil {
+			contentType = "application/octet-stream"
+		}
+
+		if _, err := dst.PutObject(ctx, key, data, contentType); err != nil {
+			return false, 0, fmt.Errorf("failed to write target object: %w", err)
+		}
+
+		written, err := dst.DownloadPhoto(ctx, key)
+		if err != nil {
+			return false, 0, fmt.Errorf("failed to verify target object: %w", err)
+		}
+		if sha256.Sum256(written) != checksum {
+			return false, 0, fmt.Errorf("checksum mismatch after copy")
+		}
+	}
+
+	if err := s.photoRepo.UpdateStorageLocation(ctx, photo.TenantID, photo.ID, opts.To, key, dst.ObjectURL(key)); err != nil {
+		return false, 0, err
+	}
+
+	if opts.DeleteSource {
+		if err := src.DeletePhoto(ctx, key); err != nil {
+			logger.Warn("Failed to delete source object after migration", "key", key, "error", err.Error())
+		}
+	}
+
+	return copied, int64(len(data)), nil
+}
+
+// ReconcileOptions controls a reconciliation run
+type ReconcileOptions struct {
+	TenantID      int64  `json:"tenant_id"` // 0 = all tenants
+	Backend       string `json:"backend"`
+	DeleteOrphans bool   `json:"delete_orphans"`
+}
+
+// ReconcileReport summarizes a reconciliation run
+type ReconcileReport struct {
+	Backend string                   `json:"backend"`
+	Tenants []*TenantReconcileResult `json:"tenants"`
+}
+
+// TenantReconcileResult holds the reconciliation result of one tenant
+type TenantReconcileResult struct {
+	TenantID       int64           `json:"tenant_id"`
+	Photos         int             `json:"photos"`
+	Objects        int             `json:"objects"`
+	Orphans        []OrphanObject  `json:"orphans"`
+	Missing        []MissingObject `json:"missing"`
+	OrphansDeleted int             `json:"orphans_deleted"`
+	SizesCorrected int             `json:"sizes_corrected"`
+	StorageBytes   int64           `json:"storage_bytes"`
+	StorageMB      float64         `json:"storage_mb"`
+}
+
+// OrphanObject is a stored object no photo refers to
+type OrphanObject struct {
+	Key       string `json:"key"`
+	SizeBytes int64  `json:"size_bytes"`
+}
+
+// MissingObject is a photo whose object is missing from storage
+type MissingObject struct {
+	PhotoID int64  `json:"photo_id"`
+	GinID   int64  `json:"gin_id"`
+	Key     string `json:"key"`
+}
+
+// Reconcile compares the photo records of a storage backend against it,
+// reports orphans and missing files, corrects recorded photo sizes from the
+// listing and recomputes the tenant's storage usage
+func (s *Service) Reconcile(ctx context.Context, opts *ReconcileOptions) (*ReconcileReport, error) {
+	backend, ok := s.backends[opts.Backend]
+	if !ok {
+		return nil, fmt.Errorf("unknown backend: %s", opts.Backend)
+	}
+
+	tenantIDs, err := s.tenantIDs(ctx, opts.TenantID)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &ReconcileReport{Backend: opts.Backend}
+
+	for _, tenantID := range tenantIDs {
+		result, err := s.reconcileTenant(ctx, tenantID, opts.Backend, backend, opts.DeleteOrphans)
+		if err != nil {
+			return report, err
+		}
+		report.Tenants = append(report.Tenants, result)
+	}
+
+	return report, nil
+}
+
+// reconcileTenant reconciles the photos of a single tenant
+func (s *Service) reconcileTenant(ctx context.Context, tenantID int64, name string, backend storage.Storage, deleteOrphans bool) (*TenantReconcileResult, error) {
+	photos, err := s.photoRepo.ListByTenant(ctx, tenantID)
+	if err != nil {
+		return nil, err
+	}
+
+	objects, err := backend.ListObjects(ctx, fmt.Sprintf("tenants/%d/", tenantID))
+	if err != nil {
+		return nil, err
+	}
+
+	result := &TenantReconcileResult{
+		TenantID: tenantID,
+		Objects:  len(objects),
+		Orphans:  []OrphanObject{},
+		Missing:  []MissingObject{},
+	}
+
+	stored := make(map[string]storage.ObjectInfo, len(objects))
+	for _, obj := range objects {
+		stored[obj.Key] = obj
+	}
+
+	referenced := make(map[string]bool, len(photos))
+	for _, photo := range photos {
+		if photo.StorageKey == nil {
+			continue
+		}
+		key := *photo.StorageKey
+		switch {
+		case photo.StorageBackend == "":
+			// Photos without a recorded backend may be on any backend until
+			// they are migrated, their objects are never orphans
+			referenced[key] = true
+			if name != s.active {
+				continue
+			}
+		case photo.StorageBackend != name:
+			continue
+		}
+		referenced[key] = true
+		result.Photos++
+
+		obj, ok := stored[key]
+		if !ok {
+			result.Missing = append(result.Missing, MissingObject{PhotoID: photo.ID, GinID: photo.GinID, Key: key})
+			continue
+		}
+
+		// The listing is authoritative for the size of stored photos
+		if photo.FileSizeBytes == nil || *photo.FileSizeBytes != obj.SizeBytes {
+			if err := s.photoRepo.UpdateFileSize(ctx, tenantID, photo.ID, obj.SizeBytes); err != nil {
+				return nil, err
+			}
+			result.SizesCorrected++
+		}
+	}
+
+	cutoff := time.Now().Add(-orphanGracePeriod)
+	for _, obj := range objects {
+		if referenced[obj.Key] || obj.LastModified.After(cutoff) {
+			continue
+		}
+		result.Orphans = append(result.Orphans, OrphanObject{Key: obj.Key, SizeBytes: obj.SizeBytes})
+
+		if deleteOrphans {
+			if err := backend.DeletePhoto(ctx, obj.Key); err != nil {
+				logger.Error("Failed to delete orphan object", "key", obj.Key, "error", err.Error())
+				continue
+			}
+			result.OrphansDeleted++
+		}
+	}
+
+	// Recompute usage from the (corrected) photo records
+	bytesUsed, photoCount, err := s.storageUsageRepo.ComputeFromPhotos(ctx, tenantID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.storageUsageRepo.SetUsage(ctx, tenantID, bytesUsed, photoCount); err != nil {
+		return nil, err
+	}
+
+	result.StorageBytes = bytesUsed
+	result.StorageMB = float64(bytesUsed) / models.BytesPerMB
+
+	logger.Info("Storage reconciled", "tenant_id", tenantID, "orphans", len(result.Orphans), "missing", len(result.Missing), "sizes_corrected", result.SizesCorrected, "storage_bytes", result.StorageBytes)
+
+	return result, nil
+}
+
+// StartUsageReconciler periodically reconciles the storage usage of all
+// tenants against the given backend until ctx is cancelled
+func (s *Service) StartUsageReconciler(ctx context.Context, backend string, interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				report, err := s.Reconcile(ctx, &ReconcileOptions{Backend: backend})
+				if err != nil {
+					logger.Error("Storage usage reconciliation failed", "backend", backend, "error", err.Error())
+					continue
+				}
+				logger.Info("Storage usage reconciled", "backend", backend, "tenants", len(report.Tenants))
+			}
+		}
+	}()
+}
+
+// tenantIDs returns the given tenant or all tenants when tenantID is 0
+func (s *Service) tenantIDs(ctx context.Context, tenantID int64) ([]int64, error) {
+	if tenantID != 0 {
+		if _, err := s.tenantRepo.GetByID(ctx, tenantID); err != nil {
+			return nil, err
+		}
+		return []int64{tenantID}, nil
+	}
+	return s.tenantRepo.ListIDs(ctx)
+}
@@ -0,0 +1,415 @@
+package subscription
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"regexp"
+	"sort"
+	"sync"
+	"time"
+
+	domainErrors "github.com/yourusername/gin-collection-saas/internal/domain/errors"
+	"github.com/yourusername/gin-collection-saas/internal/domain/models"
+	"github.com/yourusername/gin-collection-saas/internal/domain/repositories"
+	"github.com/yourusername/gin-collection-saas/pkg/logger"
+)
+
+// planIDPattern is the format of plan IDs, e.g. PLAN_PRO_MONTHLY
+var planIDPattern = regexp.MustCompile(`^[A-Z0-9_]{1,50}$`)
+
+// Catalog holds the plans tenants subscribe to. The plans are stored in the
+// database and cached with all their versions, subscribers keep the version
+// they subscribed to. Without a plan repository the built-in plans are used.
+type Catalog struct {
+	planRepo repositories.PlanRepository
+
+	mu       sync.RWMutex
+	plans    []*models.SubscriptionPlan // current versions, by sort order
+	versions map[string]map[int]*models.SubscriptionPlan
+}
+
+// NewCatalog creates a catalog of the built-in plans, Load replaces them
+// with the stored plans
+func NewCatalog(planRepo repositories.PlanRepository) *Catalog {
+	c := &Catalog{planRepo: planRepo}
+
+	plans := make([]*models.SubscriptionPlan, len(models.DefaultPlans))
+	for i := range models.DefaultPlans {
+		plan := models.DefaultPlans[i]
+		plans[i] = &plan
+	}
+	c.set(plans, plans)
+
+	return c
+}
+
+// Load reloads the plans from the database
+func (c *Catalog) Load(ctx context.Context) error {
+	if c.planRepo == nil {
+		return nil
+	}
+
+	current, err := c.planRepo.List(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to load plans: %w", err)
+	}
+
+	versions := []*models.SubscriptionPlan{}
+	for _, plan := range current {
+		planVersions, err := c.planRepo.ListVersions(ctx, plan.ID)
+		if err != nil {
+			return fmt.Errorf("failed to load versions of plan %s: %w", plan.ID, err)
+		}
+		versions = append(versions, planVersions...)
+	}
+
+	c.set(current, versions)
+	return nil
+}
+
+// StartRefresh periodically reloads the plans until ctx is cancelled, so
+// changes made on other instances are picked up
+func (c *Catalog) StartRefresh(ctx context.Context, interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if err := c.Load(ctx); err != nil {
+					logger.Error("Plan catalog refresh failed", "error", err.Error())
+				}
+			}
+		}
+	}()
+}
+
+// set replaces the cached plans, features are described in the default language
+func (c *Catalog) set(current, versions []*models.SubscriptionPlan) {
+	byID := make(map[string]map[int]*models.SubscriptionPlan)
+	for _, plan := range versions {
+		plan.Features = models.PlanFeatures(plan.Limits, models.DefaultPlanLanguage)
+		if byID[plan.ID] == nil {
+			byID[plan.ID] = make(map[int]*models.SubscriptionPlan)
+		}
+		byID[plan.ID][plan.Version] = plan
+	}
+
+	plans := make([]*models.SubscriptionPlan, 0, len(current))
+	for _, plan := range current {
+		if byID[plan.ID] == nil {
+			byID[plan.ID] = make(map[int]*models.SubscriptionPlan)
+		}
+		if byID[plan.ID][plan.Version] == nil {
+			plan.Features = models.PlanFeatures(plan.Limits, models.DefaultPlanLanguage)
+			byID[plan.ID][plan.Version] = plan
+		}
+		plans = append(plans, byID[plan.ID][plan.Version])
+	}
+	sort.SliceStable(plans, func(i, j int) bool {
+		return plans[i].SortOrder < plans[j].SortOrder
+	})
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.plans = plans
+	c.versions = byID
+}
+
+// Plans returns the current versions of all plans, by sort order
+func (c *Catalog) Plans() []models.SubscriptionPlan {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	plans := make([]models.SubscriptionPlan, len(c.plans))
+	for i, plan := range c.plans {
+		plans[i] = *plan
+	}
+	return plans
+}
+
+// Plan returns the current version of a plan, nil if it doesn't exist
+func (c *Catalog) Plan(planID string) *models.SubscriptionPlan {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	for _, plan := range c.plans {
+		if plan.ID == planID {
+			copied := *plan
+			return &copied
+		}
+	}
+	return nil
+}
+
+// Version returns a version of a plan. Unknown versions are the current
+// one, nil if the plan doesn't exist.
+func (c *Catalog) Version(planID string, version int) *models.SubscriptionPlan {
+	c.mu.RLock()
+	plan := c.versions[planID][version]
+	c.mu.RUnlock()
+
+	if plan == nil {
+		return c.Plan(planID)
+	}
+	copied := *plan
+	return &copied
+}
+
+// CyclePlan returns the current version of the plan a subscriber to a plan
+// switches to for another billing cycle: the first offered plan of its tier
+// billed in that cycle, nil if there is none
+func (c *Catalog) CyclePlan(planID string, billingCycle models.BillingCycle) *models.SubscriptionPlan {
+	current := c.Plan(planID)
+	if current == nil {
+		return nil
+	}
+
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	for _, plan := range c.plans {
+		if plan.Active && plan.Tier == current.Tier && plan.BillingCycle == billingCycle {
+			copied := *plan
+			return &copied
+		}
+	}
+	return nil
+}
+
+// ProviderPlan returns the plan or price of a provider for a version of a
+// plan, 0 for the current version, "" if the version has none
+func (c *Catalog) ProviderPlan(provider, planID string, version int) string {
+	plan := c.Version(planID, version)
+	if plan == nil {
+		return ""
+	}
+	return plan.ProviderPlans[provider]
+}
+
+// PlanFor returns the plan a plan or price of a provider belongs to, "" if none
+func (c *Catalog) PlanFor(provider, providerPlanID string) string {
+	if plan := c.versionFor(provider, providerPlanID); plan != nil {
+		return plan.ID
+	}
+	return ""
+}
+
+// versionFor returns the version of a plan that is billed with a plan or
+// price of a provider, nil if none
+func (c *Catalog) versionFor(provider, providerPlanID string) *models.SubscriptionPlan {
+	if providerPlanID == "" {
+		return nil
+	}
+
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	for _, versions := range c.versions {
+		for _, plan := range versions {
+			if plan.ProviderPlans[provider] == providerPlanID {
+				copied := *plan
+				return &copied
+			}
+		}
+	}
+	return nil
+}
+
+// billedVersion returns the version of a plan a subscription is billed with
+// at a provider, the current version if the provider plan isn't in the catalog
+func (c *Catalog) billedVersion(provider, planID, providerPlanID string) *models.SubscriptionPlan {
+	if plan := c.versionFor(provider, providerPlanID); plan != nil && plan.ID == planID {
+		return plan
+	}
+	return c.Plan(planID)
+}
+
+// GetPlan returns the current version of a plan with all its versions, oldest first
+func (c *Catalog) GetPlan(ctx context.Context, planID string) (*models.SubscriptionPlan, []*models.SubscriptionPlan, error) {
+	if err := c.Load(ctx); err != nil {
+		return nil, nil, err
+	}
+
+	plan := c.Plan(planID)
+	if plan == nil {
+		return nil, nil, domainErrors.ErrNotFound
+	}
+
+	c.mu.RLock()
+	versions := make([]*models.SubscriptionPlan, 0, len(c.versions[planID]))
+	for _, version := range c.versions[planID] {
+		copied := *version
+		versions = append(versions, &copied)
+	}
+	c.mu.RUnlock()
+
+	sort.Slice(versions, func(i, j int) bool {
+		return versions[i].Version < versions[j].Version
+	})
+	return plan, versions, nil
+}
+
+// CreatePlan adds a plan to the catalog
+func (c *Catalog) CreatePlan(ctx context.Context, plan *models.SubscriptionPlan) error {
+	if c.planRepo == nil {
+		return fmt.Errorf("the plan catalog is not stored")
+	}
+
+	if !planIDPattern.MatchString(plan.ID) {
+		return fmt.Errorf("%w: id must have 1 to 50 upper case letters, digits or underscores", domainErrors.ErrInvalidInput)
+	}
+	switch plan.Tier {
+	case models.TierBasic, models.TierPro, models.TierEnterprise:
+	default:
+		return fmt.Errorf("%w: tier must be basic, pro or enterprise", domainErrors.ErrInvalidInput)
+	}
+	switch plan.BillingCycle {
+	case "", models.BillingCycleMonthly, models.BillingCycleYearly:
+	default:
+		return fmt.Errorf("%w: billing_cycle must be monthly, yearly or empty for both", domainErrors.ErrInvalidInput)
+	}
+	if err := validatePlan(plan); err != nil {
+		return err
+	}
+
+	plan.Version = 1
+	if err := c.planRepo.Create(ctx, plan); err != nil {
+		if err == domainErrors.ErrConflict {
+			return domainErrors.ErrPlanIDTaken
+		}
+		return err
+	}
+	plan.Features = models.PlanFeatures(plan.Limits, models.DefaultPlanLanguage)
+
+	logger.Info("Plan created", "plan_id", plan.ID)
+	return c.Load(ctx)
+}
+
+// UpdatePlan updates a plan. The name, sort order and availability change in
+// place. Changed prices, limits or provider plans become a new version that
+// new subscribers get, existing subscribers keep theirs. The tier and
+// billing cycle are kept.
+func (c *Catalog) UpdatePlan(ctx context.Context, planID string, update *models.SubscriptionPlan) (*models.SubscriptionPlan, error) {
+	if c.planRepo == nil {
+		return nil, domainErrors.ErrNotFound
+	}
+
+	plan, err := c.planRepo.GetByID(ctx, planID)
+	if err != nil {
+		return nil, err
+	}
+
+	update.ID = plan.ID
+	update.Tier = plan.Tier
+	update.BillingCycle = plan.BillingCycle
+	if err := validatePlan(update); err != nil {
+		return nil, err
+	}
+
+	versioned := update.PriceMonthly != plan.PriceMonthly || update.PriceYearly != plan.PriceYearly ||
+		update.Currency != plan.Currency || !reflect.DeepEqual(update.Limits, plan.Limits) ||
+		!reflect.DeepEqual(update.ProviderPlans, plan.ProviderPlans)
+
+	if plan.Tier == models.TierFree && versioned &&
+		(update.PriceMonthly != 0 || update.PriceYearly != 0 || !reflect.DeepEqual(update.Limits, models.PlanLimitsMap[models.TierFree])) {
+		return nil, fmt.Errorf("%w: the free plan has the built-in Free limits and no price", domainErrors.ErrInvalidInput)
+	}
+
+	plan.Name = update.Name
+	plan.SortOrder = update.SortOrder
+	plan.Active = update.Active
+	if err := c.planRepo.Update(ctx, plan); err != nil {
+		return nil, err
+	}
+
+	if versioned {
+		versions, err := c.planRepo.ListVersions(ctx, planID)
+		if err != nil {
+			return nil, err
+		}
+		if update.PriceMonthly != plan.PriceMonthly || update.PriceYearly != plan.PriceYearly || update.Currency != plan.Currency {
+			if err := checkProviderPlansReused(update, versions); err != nil {
+				return nil, err
+			}
+		}
+
+		update.Version = versions[len(versions)-1].Version + 1
+		if err := c.planRepo.CreateVersion(ctx, update); err != nil {
+			return nil, err
+		}
+		plan = update
+
+		logger.Info("Plan version created", "plan_id", planID, "version", plan.Version)
+	}
+
+	if err := c.Load(ctx); err != nil {
+		return nil, err
+	}
+
+	logger.Info("Plan updated", "plan_id", planID)
+	return c.Plan(planID), nil
+}
+
+// validatePlan checks the settings of a plan version
+func validatePlan(plan *models.SubscriptionPlan) error {
+	if plan.Name == "" || len(plan.Name) > 100 {
+		return fmt.Errorf("%w: name must have 1 to 100 characters", domainErrors.ErrInvalidInput)
+	}
+	if plan.PriceMonthly < 0 || plan.PriceYearly < 0 {
+		return fmt.Errorf("%w: prices must not be negative", domainErrors.ErrInvalidInput)
+	}
+	if plan.Currency == "" {
+		plan.Currency = "EUR"
+	}
+	if len(plan.Currency) != 3 {
+		return fmt.Errorf("%w: currency must be an ISO 4217 code", domainErrors.ErrInvalidInput)
+	}
+
+	limits := plan.Limits
+	if limits.MaxGins != nil && *limits.MaxGins < 1 {
+		return fmt.Errorf("%w: max_gins must be at least 1 or null for unlimited", domainErrors.ErrInvalidInput)
+	}
+	if limits.MaxPhotosPerGin < -1 {
+		return fmt.Errorf("%w: max_photos_per_gin must be at least 0 or -1 for unlimited", domainErrors.ErrInvalidInput)
+	}
+	if limits.StorageLimitMB != nil && *limits.StorageLimitMB < 1 {
+		return fmt.Errorf("%w: storage_limit_mb must be at least 1 or null for unlimited", domainErrors.ErrInvalidInput)
+	}
+	if limits.APIRateLimit < 0 {
+		return fmt.Errorf("%w: api_rate_limit must not be negative", domainErrors.ErrInvalidInput)
+	}
+	if (limits.MaxAPICallsPerPeriod != nil && *limits.MaxAPICallsPerPeriod < 0) ||
+		(limits.MaxAISuggestionsPerPeriod != nil && *limits.MaxAISuggestionsPerPeriod < 0) {
+		return fmt.Errorf("%w: usage limits per period must not be negative, null is unlimited", domainErrors.ErrInvalidInput)
+	}
+
+	for provider, providerPlanID := range plan.ProviderPlans {
+		if provider == "" || providerPlanID == "" {
+			return fmt.Errorf("%w: provider plans need a provider and a plan ID", domainErrors.ErrInvalidInput)
+		}
+	}
+	if len(plan.ProviderPlans) == 0 {
+		plan.ProviderPlans = nil
+	}
+
+	return nil
+}
+
+// checkProviderPlansReused rejects new prices billed with the provider plans
+// of earlier versions, providers would keep charging the old price
+func checkProviderPlansReused(plan *models.SubscriptionPlan, versions []*models.SubscriptionPlan) error {
+	for _, version := range versions {
+		for provider, providerPlanID := range plan.ProviderPlans {
+			if version.ProviderPlans[provider] == providerPlanID {
+				return fmt.Errorf("%w: a new price needs new %s plans, %s bills version %d",
+					domainErrors.ErrInvalidInput, provider, providerPlanID, version.Version)
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,337 @@
+package subscription
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	domainErrors "github.com/yourusername/gin-collection-saas/internal/domain/errors"
+	"github.com/yourusername/gin-collection-saas/internal/domain/models"
+	"github.com/yourusername/gin-collection-saas/internal/domain/repositories"
+	"github.com/yourusername/gin-collection-saas/internal/infrastructure/billing"
+	"github.com/yourusername/gin-collection-saas/pkg/logger"
+)
+
+// couponReservation is how long a checkout holds a redemption of its coupon,
+// as long as providers keep checkouts open
+const couponReservation = 24 * time.Hour
+
+// SetCouponRepo enables coupon codes. Without it upgrades with a coupon code
+// are rejected.
+func (s *Service) SetCouponRepo(couponRepo repositories.CouponRepository) {
+	s.couponRepo = couponRepo
+}
+
+// PreviewCoupon checks a coupon code for a plan and returns the discounted price
+func (s *Service) PreviewCoupon(ctx context.Context, tenantID int64, code, planID string, billingCycle models.BillingCycle) (*models.CouponPreview, error) {
+	plan := s.catalog.Plan(planID)
+	if plan == nil {
+		return nil, domainErrors.ErrInvalidInput
+	}
+
+	coupon, err := s.redeemableCoupon(ctx, tenantID, code, planID)
+	if err != nil {
+		return nil, err
+	}
+
+	price := planPrice(plan, billingCycle)
+	discount := coupon.DiscountFor(price)
+
+	return &models.CouponPreview{
+		Code:            coupon.Code,
+		Description:     coupon.Description,
+		PlanID:          planID,
+		BillingCycle:    billingCycle,
+		Price:           price,
+		Discount:        discount,
+		DiscountedPrice: roundCents(price - discount),
+		Currency:        plan.Currency,
+		Duration:        coupon.Duration,
+		DurationMonths:  coupon.DurationMonths,
+	}, nil
+}
+
+// redeemableCoupon returns the coupon of a code if the tenant can redeem it for a plan
+func (s *Service) redeemableCoupon(ctx context.Context, tenantID int64, code, planID string) (*models.Coupon, error) {
+	if s.couponRepo == nil {
+		return nil, domainErrors.ErrInvalidCoupon
+	}
+
+	coupon, err := s.couponRepo.GetByCode(ctx, models.NormalizeCouponCode(code))
+	if err == domainErrors.ErrNotFound {
+		return nil, domainErrors.ErrInvalidCoupon
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if !coupon.Redeemable(time.Now()) {
+		return nil, domainErrors.ErrInvalidCoupon
+	}
+	if !coupon.AppliesTo(planID) {
+		return nil, domainErrors.ErrCouponNotApplicable
+	}
+
+	redemption, err := s.couponRepo.GetRedemption(ctx, coupon.ID, tenantID)
+	if err != nil && err != domainErrors.ErrNotFound {
+		return nil, err
+	}
+	if redemption != nil && redemption.Status == models.CouponRedemptionRedeemed {
+		return nil, domainErrors.ErrCouponRedeemed
+	}
+
+	// A redemption the tenant reserved before is passed to its next checkout
+	if redemption == nil && coupon.LimitReached() {
+		return nil, domainErrors.ErrInvalidCoupon
+	}
+
+	return coupon, nil
+}
+
+// providerDiscount describes a coupon for the billing provider
+func providerDiscount(coupon *models.Coupon, plan *models.SubscriptionPlan, price float64) *billing.Discount {
+	discount := &billing.Discount{
+		Code:     coupon.Code,
+		Currency: plan.Currency,
+		Duration: string(coupon.Duration),
+		Price:    roundCents(price - coupon.DiscountFor(price)),
+	}
+	if coupon.DurationMonths != nil {
+		discount.DurationMonths = *coupon.DurationMonths
+	}
+	if coupon.DiscountType == models.CouponDiscountPercent {
+		discount.PercentOff = coupon.DiscountValue
+	} else {
+		discount.AmountOff = coupon.DiscountValue
+		discount.Currency = coupon.Currency
+	}
+	return discount
+}
+
+// reserveCoupon reserves a redemption of a coupon for the checkout of a tenant
+func (s *Service) reserveCoupon(ctx context.Context, coupon *models.Coupon, tenantID int64, discount float64) error {
+	expiresAt := time.Now().Add(couponReservation)
+	err := s.couponRepo.Reserve(ctx, &models.CouponRedemption{
+		CouponID:  coupon.ID,
+		TenantID:  tenantID,
+		Discount:  discount,
+		ExpiresAt: &expiresAt,
+	})
+	switch err {
+	case domainErrors.ErrLimitReached, domainErrors.ErrNotFound:
+		return domainErrors.ErrInvalidCoupon
+	case domainErrors.ErrConflict:
+		return domainErrors.ErrCouponRedeemed
+	}
+	return err
+}
+
+// releaseCoupon releases the redemption reserved for a checkout that
+// couldn't be started, if it had a coupon
+func (s *Service) releaseCoupon(ctx context.Context, coupon *models.Coupon, tenantID int64) {
+	if coupon == nil {
+		return
+	}
+	if err := s.couponRepo.Release(ctx, coupon.ID, tenantID); err != nil {
+		logger.Error("Failed to release coupon redemption", "coupon_id", coupon.ID, "tenant_id", tenantID, "error", err.Error())
+	}
+}
+
+// ReleaseExpiredCoupons releases the coupon redemptions reserved by
+// checkouts that were never completed
+func (s *Service) ReleaseExpiredCoupons(ctx context.Context) (int, error) {
+	if s.couponRepo == nil {
+		return 0, nil
+	}
+
+	released, err := s.couponRepo.ReleaseExpired(ctx)
+	if err != nil {
+		return released, fmt.Errorf("failed to release expired coupon redemptions: %w", err)
+	}
+	if released > 0 {
+		logger.Info("Expired coupon redemptions released", "count", released)
+	}
+	return released, nil
+}
+
+// redeemCoupon redeems the coupon reserved for an activated subscription.
+// The payment is set up already, so failures are logged only.
+func (s *Service) redeemCoupon(ctx context.Context, subscription *models.Subscription) {
+	if subscription.CouponID == nil || s.couponRepo == nil {
+		return
+	}
+
+	err := s.couponRepo.Redeem(ctx, &models.CouponRedemption{
+		CouponID:       *subscription.CouponID,
+		TenantID:       subscription.TenantID,
+		SubscriptionID: &subscription.ID,
+		Discount:       subscription.Discount,
+	})
+	if err == domainErrors.ErrConflict {
+		return
+	}
+	if err != nil {
+		logger.Error("Failed to redeem coupon", "subscription_id", subscription.ID, "coupon_id", *subscription.CouponID,
+			"error", err.Error())
+		return
+	}
+
+	logger.Info("Coupon redeemed", "subscription_id", subscription.ID, "coupon_id", *subscription.CouponID)
+}
+
+// ListCoupons lists all coupons
+func (s *Service) ListCoupons(ctx context.Context) ([]*models.Coupon, error) {
+	if s.couponRepo == nil {
+		return []*models.Coupon{}, nil
+	}
+	return s.couponRepo.List(ctx)
+}
+
+// GetCoupon retrieves a coupon with its redemptions
+func (s *Service) GetCoupon(ctx context.Context, id int64) (*models.Coupon, []*models.CouponRedemption, error) {
+	if s.couponRepo == nil {
+		return nil, nil, domainErrors.ErrNotFound
+	}
+
+	coupon, err := s.couponRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	redemptions, err := s.couponRepo.ListRedemptions(ctx, id)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return coupon, redemptions, nil
+}
+
+// CreateCoupon creates a coupon
+func (s *Service) CreateCoupon(ctx context.Context, coupon *models.Coupon) error {
+	if s.couponRepo == nil {
+		return fmt.Errorf("coupons are not enabled")
+	}
+
+	coupon.Code = models.NormalizeCouponCode(coupon.Code)
+	if err := s.validateCoupon(coupon); err != nil {
+		return err
+	}
+
+	if err := s.couponRepo.Create(ctx, coupon); err != nil {
+		if err == domainErrors.ErrConflict {
+			return domainErrors.ErrCouponCodeTaken
+		}
+		return err
+	}
+
+	logger.Info("Coupon created", "coupon_id", coupon.ID, "code", coupon.Code)
+	return nil
+}
+
+// UpdateCoupon updates the settings of a coupon. The code and the
+// redemptions are kept, subscriptions started with the coupon keep their discount.
+func (s *Service) UpdateCoupon(ctx context.Context, id int64, update *models.Coupon) (*models.Coupon, error) {
+	if s.couponRepo == nil {
+		return nil, domainErrors.ErrNotFound
+	}
+
+	coupon, err := s.couponRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	coupon.Description = update.Description
+	coupon.DiscountType = update.DiscountType
+	coupon.DiscountValue = update.DiscountValue
+	coupon.Currency = update.Currency
+	coupon.Duration = update.Duration
+	coupon.DurationMonths = update.DurationMonths
+	coupon.MaxRedemptions = update.MaxRedemptions
+	coupon.ExpiresAt = update.ExpiresAt
+	coupon.PlanIDs = update.PlanIDs
+	coupon.Active = update.Active
+
+	if err := s.validateCoupon(coupon); err != nil {
+		return nil, err
+	}
+
+	if err := s.couponRepo.Update(ctx, coupon); err != nil {
+		return nil, err
+	}
+
+	logger.Info("Coupon updated", "coupon_id", coupon.ID, "code", coupon.Code)
+	return coupon, nil
+}
+
+// DeleteCoupon deletes a coupon, subscriptions started with it keep their discount
+func (s *Service) DeleteCoupon(ctx context.Context, id int64) error {
+	if s.couponRepo == nil {
+		return domainErrors.ErrNotFound
+	}
+
+	if _, err := s.couponRepo.GetByID(ctx, id); err != nil {
+		return err
+	}
+
+	if err := s.couponRepo.Delete(ctx, id); err != nil {
+		return err
+	}
+
+	logger.Info("Coupon deleted", "coupon_id", id)
+	return nil
+}
+
+// validateCoupon checks the settings of a coupon
+func (s *Service) validateCoupon(coupon *models.Coupon) error {
+	if coupon.Code == "" || len(coupon.Code) > 50 {
+		return fmt.Errorf("%w: code must have 1 to 50 characters", domainErrors.ErrInvalidInput)
+	}
+
+	switch coupon.DiscountType {
+	case models.CouponDiscountPercent:
+		if coupon.DiscountValue <= 0 || coupon.DiscountValue > 100 {
+			return fmt.Errorf("%w: percent discounts must be between 0 and 100", domainErrors.ErrInvalidInput)
+		}
+	case models.CouponDiscountFixed:
+		if coupon.DiscountValue <= 0 {
+			return fmt.Errorf("%w: fixed discounts must be positive", domainErrors.ErrInvalidInput)
+		}
+	default:
+		return fmt.Errorf("%w: discount type must be percent or fixed", domainErrors.ErrInvalidInput)
+	}
+
+	switch coupon.Duration {
+	case models.CouponDurationOnce, models.CouponDurationForever:
+		coupon.DurationMonths = nil
+	case models.CouponDurationRepeating:
+		if coupon.DurationMonths == nil || *coupon.DurationMonths < 1 {
+			return fmt.Errorf("%w: repeating coupons need duration_months", domainErrors.ErrInvalidInput)
+		}
+	default:
+		return fmt.Errorf("%w: duration must be once, repeating or forever", domainErrors.ErrInvalidInput)
+	}
+
+	if coupon.MaxRedemptions != nil && *coupon.MaxRedemptions < 1 {
+		return fmt.Errorf("%w: max_redemptions must be at least 1", domainErrors.ErrInvalidInput)
+	}
+
+	for _, planID := range coupon.PlanIDs {
+		if s.catalog.Plan(planID) == nil {
+			return fmt.Errorf("%w: unknown plan %s", domainErrors.ErrInvalidInput, planID)
+		}
+	}
+
+	if coupon.Currency == "" {
+		coupon.Currency = "EUR"
+	}
+
+	return nil
+}
+
+// planPrice returns the price of a plan for a billing period
+func planPrice(plan *models.SubscriptionPlan, billingCycle models.BillingCycle) float64 {
+	if billingCycle == models.BillingCycleYearly {
+		return plan.PriceYearly
+	}
+	return plan.PriceMonthly
+}
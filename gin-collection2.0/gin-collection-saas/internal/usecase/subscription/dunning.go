@@ -0,0 +1,358 @@
+package subscription
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/yourusername/gin-collection-saas/internal/domain/models"
+	"github.com/yourusername/gin-collection-saas/internal/domain/repositories"
+	"github.com/yourusername/gin-collection-saas/internal/infrastructure/billing"
+	"github.com/yourusername/gin-collection-saas/internal/infrastructure/external"
+	"github.com/yourusername/gin-collection-saas/pkg/logger"
+)
+
+// SetDunning enables dunning of failed payments. Without it failed payments
+// are logged only and the status of the provider is taken as is.
+func (s *Service) SetDunning(policy *models.DunningPolicy) {
+	s.dunning = policy
+}
+
+// SetAuditLogRepo sets the audit log the dunning transitions are recorded in
+func (s *Service) SetAuditLogRepo(repo repositories.AuditLogRepository) {
+	s.auditLogRepo = repo
+}
+
+// startDunning puts a paying subscription past due. The tenant keeps full
+// access for the grace period, reminders and restrictions follow with
+// ProcessDunning. Subscriptions in dunning already are left as they are.
+func (s *Service) startDunning(ctx context.Context, subscription *models.Subscription, reason string) error {
+	if subscription.InDunning() {
+		return nil
+	}
+	switch subscription.Status {
+	case models.SubscriptionStatusActive, models.SubscriptionStatusPastDue:
+	default:
+		return nil
+	}
+
+	now := s.clock()
+	subscription.Status = models.SubscriptionStatusPastDue
+	subscription.PastDueSince = &now
+	subscription.DunningStage = models.DunningStageGrace
+	subscription.DunningRemindersSent = 0
+	if err := s.subscriptionRepo.Update(ctx, subscription); err != nil {
+		return err
+	}
+
+	finalActionAt := s.dunning.FinalActionAt(now)
+	s.auditDunning(ctx, subscription, models.AuditActionDunningStarted, map[string]interface{}{
+		"reason":          reason,
+		"final_action":    s.dunning.FinalAction,
+		"final_action_at": finalActionAt,
+	})
+	logger.Warn("Dunning started", "subscription_id", subscription.ID, "tenant_id", subscription.TenantID,
+		"reason", reason, "final_action_at", finalActionAt)
+
+	return nil
+}
+
+// recoverDunning ends dunning once the overdue payment is made: the
+// subscription is active again and restrictions and suspension are lifted
+func (s *Service) recoverDunning(ctx context.Context, subscription *models.Subscription, reason string) error {
+	if !subscription.PaymentOverdue() {
+		return nil
+	}
+
+	stage, pastDueSince := subscription.DunningStage, subscription.PastDueSince
+	subscription.Status = models.SubscriptionStatusActive
+	subscription.PastDueSince = nil
+	subscription.DunningStage = ""
+	subscription.DunningRemindersSent = 0
+	if err := s.subscriptionRepo.Update(ctx, subscription); err != nil {
+		return err
+	}
+
+	if stage != models.DunningStageGrace {
+		if err := s.tenantRepo.UpdateBillingRestricted(ctx, subscription.TenantID, false); err != nil {
+			return fmt.Errorf("failed to lift billing restriction: %w", err)
+		}
+	}
+	if stage == models.DunningStageSuspended {
+		if err := s.tenantRepo.UpdateStatus(ctx, subscription.TenantID, models.TenantStatusActive); err != nil {
+			return fmt.Errorf("failed to reactivate tenant: %w", err)
+		}
+	}
+
+	s.auditDunning(ctx, subscription, models.AuditActionDunningRecovered, map[string]interface{}{
+		"reason":         reason,
+		"stage":          stage,
+		"past_due_since": pastDueSince,
+	})
+	logger.Info("Dunning recovered", "subscription_id", subscription.ID, "tenant_id", subscription.TenantID,
+		"reason", reason, "stage", stage)
+
+	return nil
+}
+
+// applyProviderStatus takes the status a subscription has at its provider.
+// With dunning a past due or suspended subscription starts dunning. Only a
+// payment ends it, PayPal keeps subscriptions active while it retries.
+// Paused subscriptions are suspended at the provider, they resume when it
+// reports them active again (e.g. resumed in its customer portal). PayPal
+// suspends subscriptions cancelled at the end of the period as well.
+func (s *Service) applyProviderStatus(ctx context.Context, subscription *models.Subscription, status string) error {
+	switch status {
+	case billing.StatusActive:
+		if subscription.PaymentOverdue() {
+			return nil
+		}
+		if subscription.Status == models.SubscriptionStatusPaused {
+			return s.unpause(ctx, subscription)
+		}
+		subscription.Status = models.SubscriptionStatusActive
+	case billing.StatusPastDue, billing.StatusSuspended:
+		if status == billing.StatusSuspended && (subscription.Status == models.SubscriptionStatusPaused || subscription.CancelAtPeriodEnd) {
+			return nil
+		}
+		if s.dunning != nil {
+			return s.startDunning(ctx, subscription, "provider_"+status)
+		}
+		subscription.Status = models.SubscriptionStatus(status)
+	}
+	return nil
+}
+
+// ProcessDunning moves the subscriptions in dunning to the stage they're due
+// for and sends the reminders due. It returns how many subscriptions changed
+// their stage.
+func (s *Service) ProcessDunning(ctx context.Context) (int, error) {
+	if s.dunning == nil {
+		return 0, nil
+	}
+
+	now := s.clock()
+	subscriptions, err := s.subscriptionRepo.ListInDunning(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	changed := 0
+	for _, subscription := range subscriptions {
+		if subscription.PastDueSince == nil {
+			continue
+		}
+
+		stage := s.dunning.Stage(*subscription.PastDueSince, now)
+		if stage != subscription.DunningStage && stage != models.DunningStageGrace {
+			if err := s.advanceDunning(ctx, subscription, stage); err != nil {
+				logger.Error("Failed to advance dunning", "subscription_id", subscription.ID, "stage", stage, "error", err.Error())
+				continue
+			}
+			changed++
+		}
+
+		if subscription.InDunning() && s.emailClient != nil &&
+			subscription.DunningRemindersSent < s.dunning.RemindersDue(*subscription.PastDueSince, now) {
+			s.sendDunningReminder(ctx, subscription, now)
+		}
+	}
+
+	if changed > 0 {
+		logger.Info("Dunning advanced", "count", changed)
+	}
+
+	return changed, nil
+}
+
+// advanceDunning moves a subscription in dunning to a later stage
+func (s *Service) advanceDunning(ctx context.Context, subscription *models.Subscription, stage models.DunningStage) error {
+	switch stage {
+	case models.DunningStageRestricted:
+		return s.restrictForDunning(ctx, subscription)
+	case models.DunningStageDowngraded:
+		return s.downgradeForDunning(ctx, subscription)
+	case models.DunningStageSuspended:
+		return s.suspendForDunning(ctx, subscription)
+	}
+	return nil
+}
+
+// restrictForDunning holds the tenant to the Free limits after the grace period
+func (s *Service) restrictForDunning(ctx context.Context, subscription *models.Subscription) error {
+	if err := s.tenantRepo.UpdateBillingRestricted(ctx, subscription.TenantID, true); err != nil {
+		return fmt.Errorf("failed to restrict tenant: %w", err)
+	}
+
+	subscription.DunningStage = models.DunningStageRestricted
+	if err := s.subscriptionRepo.Update(ctx, subscription); err != nil {
+		return err
+	}
+
+	s.auditDunning(ctx, subscription, models.AuditActionDunningRestricted, map[string]interface{}{
+		"past_due_since": subscription.PastDueSince,
+	})
+	logger.Warn("Tenant restricted for overdue payment", "subscription_id", subscription.ID, "tenant_id", subscription.TenantID)
+
+	return nil
+}
+
+// downgradeForDunning cancels the subscription at its provider and moves the
+// tenant to Free. Nothing is deleted, collections over the Free limits become
+// read-only.
+func (s *Service) downgradeForDunning(ctx context.Context, subscription *models.Subscription) error {
+	if subscription.ProviderSubscriptionID != nil {
+		provider, err := s.provider(subscription.Provider)
+		if err != nil {
+			return err
+		}
+		if err := provider.CancelSubscription(ctx, *subscription.ProviderSubscriptionID, "Payment overdue"); err != nil {
+			return fmt.Errorf("failed to cancel %s subscription: %w", provider.Name(), err)
+		}
+	}
+
+	now := s.clock()
+	subscription.Status = models.SubscriptionStatusCancelled
+	subscription.CancelledAt = &now
+	subscription.DunningStage = models.DunningStageDowngraded
+	if err := s.subscriptionRepo.Update(ctx, subscription); err != nil {
+		return err
+	}
+
+	if err := s.tenantRepo.UpdateBillingRestricted(ctx, subscription.TenantID, false); err != nil {
+		return fmt.Errorf("failed to lift billing restriction: %w", err)
+	}
+	if active, err := s.subscriptionRepo.GetActiveSubscription(ctx, subscription.TenantID); err != nil || active.ID == subscription.ID {
+		if err := s.setTenantPlan(ctx, subscription.TenantID, nil); err != nil {
+			return err
+		}
+	}
+
+	s.auditDunning(ctx, subscription, models.AuditActionDunningDowngraded, map[string]interface{}{
+		"past_due_since": subscription.PastDueSince,
+		"plan_id":        subscription.PlanID,
+	})
+	logger.Warn("Subscription downgraded for overdue payment", "subscription_id", subscription.ID, "tenant_id", subscription.TenantID)
+
+	return nil
+}
+
+// suspendForDunning suspends the tenant. The subscription stays with its
+// provider, a late payment reactivates the tenant.
+func (s *Service) suspendForDunning(ctx context.Context, subscription *models.Subscription) error {
+	if err := s.tenantRepo.UpdateStatus(ctx, subscription.TenantID, models.TenantStatusSuspended); err != nil {
+		return fmt.Errorf("failed to suspend tenant: %w", err)
+	}
+
+	subscription.Status = models.SubscriptionStatusSuspended
+	subscription.DunningStage = models.DunningStageSuspended
+	if err := s.subscriptionRepo.Update(ctx, subscription); err != nil {
+		return err
+	}
+
+	s.auditDunning(ctx, subscription, models.AuditActionDunningSuspended, map[string]interface{}{
+		"past_due_since": subscription.PastDueSince,
+	})
+	logger.Warn("Tenant suspended for overdue payment", "subscription_id", subscription.ID, "tenant_id", subscription.TenantID)
+
+	return nil
+}
+
+// sendDunningReminder emails the billing managers of the tenant about the
+// overdue payment. Reminders that came due together are sent as one, failures
+// are logged and retried with the next run.
+func (s *Service) sendDunningReminder(ctx context.Context, subscription *models.Subscription, now time.Time) {
+	tenant, err := s.tenantRepo.GetByID(ctx, subscription.TenantID)
+	if err != nil {
+		logger.Error("Failed to get tenant of overdue payment", "tenant_id", subscription.TenantID, "error", err.Error())
+		return
+	}
+
+	users, err := s.billingManagers(ctx, subscription.TenantID)
+	if err != nil {
+		logger.Error("Failed to list billing managers of overdue payment", "tenant_id", subscription.TenantID, "error", err.Error())
+		return
+	}
+
+	planName := s.planName(subscription)
+
+	data := &external.PaymentOverdueData{
+		TenantName:    tenant.Name,
+		PlanName:      planName,
+		PastDueSince:  subscription.PastDueSince.Format("02.01.2006"),
+		Restricted:    subscription.DunningStage == models.DunningStageRestricted,
+		FinalActionAt: s.dunning.FinalActionAt(*subscription.PastDueSince).Format("02.01.2006"),
+		Suspend:       s.dunning.FinalAction == models.DunningActionSuspend,
+		PaymentLink:   s.baseURL + "/subscription",
+	}
+
+	sent := 0
+	for _, user := range users {
+		recipient := *data
+		if user.FirstName != nil {
+			recipient.RecipientName = *user.FirstName
+		}
+		if err := s.emailClient.SendPaymentOverdue(user.Email, &recipient); err != nil {
+			logger.Error("Failed to send dunning reminder", "tenant_id", subscription.TenantID, "user_id", user.ID, "error", err.Error())
+			continue
+		}
+		sent++
+	}
+	if sent == 0 {
+		return
+	}
+
+	subscription.DunningRemindersSent = s.dunning.RemindersDue(*subscription.PastDueSince, now)
+	if err := s.subscriptionRepo.Update(ctx, subscription); err != nil {
+		logger.Error("Failed to record dunning reminder", "subscription_id", subscription.ID, "error", err.Error())
+		return
+	}
+
+	logger.Info("Dunning reminder sent", "subscription_id", subscription.ID, "tenant_id", subscription.TenantID,
+		"reminder", subscription.DunningRemindersSent, "recipients", sent)
+}
+
+// auditDunning records a dunning transition of a subscription. Transitions
+// are made by the system, so the entry has no user.
+func (s *Service) auditDunning(ctx context.Context, subscription *models.Subscription, action models.AuditAction, changes map[string]interface{}) {
+	if s.auditLogRepo == nil {
+		return
+	}
+
+	changesJSON, _ := json.Marshal(changes)
+	changesStr := string(changesJSON)
+	auditLog := &models.AuditLog{
+		TenantID:   subscription.TenantID,
+		Action:     string(action),
+		EntityType: string(models.EntityTypeSubscription),
+		EntityID:   &subscription.ID,
+		Changes:    &changesStr,
+	}
+	if err := s.auditLogRepo.Create(ctx, auditLog); err != nil {
+		logger.Error("Failed to create audit log", "action", string(action), "subscription_id", subscription.ID, "error", err.Error())
+	}
+}
+
+// StartDunningScheduler periodically advances dunning and sends its
+// reminders until ctx is cancelled. With a lock repository one instance runs
+// it at a time.
+func (s *Service) StartDunningScheduler(ctx context.Context, interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				s.runExclusive(ctx, "subscription_dunning", func() {
+					if _, err := s.ProcessDunning(ctx); err != nil {
+						logger.Error("Dunning scheduler failed", "error", err.Error())
+					}
+				})
+			}
+		}
+	}()
+}
@@ -0,0 +1,212 @@
+package subscription
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	domainErrors "github.com/yourusername/gin-collection-saas/internal/domain/errors"
+	"github.com/yourusername/gin-collection-saas/internal/domain/models"
+	"github.com/yourusername/gin-collection-saas/internal/domain/repositories"
+	"github.com/yourusername/gin-collection-saas/internal/infrastructure/billing"
+	"github.com/yourusername/gin-collection-saas/internal/infrastructure/external"
+	"github.com/yourusername/gin-collection-saas/internal/infrastructure/pdf"
+	"github.com/yourusername/gin-collection-saas/pkg/logger"
+)
+
+// InvoiceSettings holds the seller and numbering of invoices
+type InvoiceSettings struct {
+	Seller       models.BillingDetails
+	VATRate      float64 // percent, charged to customers in the seller's country
+	NumberPrefix string
+}
+
+// SetInvoicing enables invoices of payments. Without it payments are logged only.
+func (s *Service) SetInvoicing(invoiceRepo repositories.InvoiceRepository, settings *InvoiceSettings) {
+	s.invoiceRepo = invoiceRepo
+	s.invoiceSettings = settings
+}
+
+// createInvoice issues the invoice of a payment, ErrConflict if the payment
+// has an invoice already
+func (s *Service) createInvoice(ctx context.Context, providerName string, subscription *models.Subscription, event *billing.WebhookEvent) (*models.Invoice, error) {
+	total, err := strconv.ParseFloat(event.Amount, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid payment amount %q: %w", event.Amount, err)
+	}
+
+	currency := strings.ToUpper(event.Currency)
+	if currency == "" {
+		currency = subscription.Currency
+	}
+
+	issuedAt := time.Now()
+	if event.PaidAt != nil {
+		issuedAt = *event.PaidAt
+	}
+
+	customer, err := s.invoiceCustomer(ctx, subscription.TenantID)
+	if err != nil {
+		return nil, err
+	}
+
+	seller := s.invoiceSettings.Seller
+	invoice := &models.Invoice{
+		TenantID:          subscription.TenantID,
+		SubscriptionID:    &subscription.ID,
+		Provider:          providerName,
+		ProviderPaymentID: event.PaymentID,
+		PlanID:            subscription.PlanID,
+		Description:       s.invoiceDescription(subscription),
+		PeriodStart:       subscription.CurrentPeriodStart,
+		PeriodEnd:         subscription.CurrentPeriodEnd,
+		Currency:          currency,
+		TotalAmount:       total,
+		Customer:          customer,
+		Seller:            &seller,
+		IssuedAt:          issuedAt,
+	}
+	invoice.ApplyTax(models.TaxFor(currency, customer, &seller, s.invoiceSettings.VATRate))
+
+	if err := s.invoiceRepo.Create(ctx, invoice, s.invoiceSettings.NumberPrefix); err != nil {
+		return nil, err
+	}
+
+	logger.Info("Invoice issued", "invoice_id", invoice.ID, "number", invoice.Number, "tenant_id", invoice.TenantID,
+		"total", invoice.TotalAmount, "tax", invoice.TaxAmount, "reverse_charge", invoice.ReverseCharge)
+
+	return invoice, nil
+}
+
+// invoiceCustomer returns the billing details of a tenant, its name if it
+// has none
+func (s *Service) invoiceCustomer(ctx context.Context, tenantID int64) (*models.BillingDetails, error) {
+	settings, err := s.BillingSettings(ctx, tenantID)
+	if err != nil {
+		return nil, err
+	}
+	if settings.Details != nil {
+		return settings.Details, nil
+	}
+
+	tenant, err := s.tenantRepo.GetByID(ctx, tenantID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get tenant: %w", err)
+	}
+	return &models.BillingDetails{Name: tenant.Name}, nil
+}
+
+// invoiceDescription describes the plan and billing cycle of a subscription
+func (s *Service) invoiceDescription(subscription *models.Subscription) string {
+	name := s.planName(subscription)
+
+	return fmt.Sprintf("GinVault %s-Abonnement (%s)", name, billingCycleName(subscription.BillingCycle))
+}
+
+// billingCycleName returns the German name of a billing cycle
+func billingCycleName(billingCycle models.BillingCycle) string {
+	if billingCycle == models.BillingCycleYearly {
+		return "jährlich"
+	}
+	return "monatlich"
+}
+
+// sendReceipt emails the billing managers of the tenant the invoice of a
+// payment, failures are logged only
+func (s *Service) sendReceipt(ctx context.Context, subscription *models.Subscription, invoice *models.Invoice) {
+	if s.emailClient == nil {
+		return
+	}
+
+	users, err := s.billingManagers(ctx, subscription.TenantID)
+	if err != nil {
+		logger.Error("Failed to list billing managers for receipt", "tenant_id", subscription.TenantID, "error", err.Error())
+		return
+	}
+
+	planName := s.planName(subscription)
+	data := &external.SubscriptionData{
+		PlanName:      planName,
+		Amount:        fmt.Sprintf("%.2f %s", invoice.TotalAmount, invoice.Currency),
+		BillingCycle:  billingCycleName(subscription.BillingCycle),
+		InvoiceNumber: invoice.Number,
+		InvoiceLink:   fmt.Sprintf("%s/api/v1/billing/invoices/%d/pdf", s.baseURL, invoice.ID),
+	}
+	if subscription.NextBillingDate != nil {
+		data.NextBilling = subscription.NextBillingDate.Format("02.01.2006")
+	}
+
+	for _, user := range users {
+		recipient := *data
+		if user.FirstName != nil {
+			recipient.RecipientName = *user.FirstName
+		}
+		if err := s.emailClient.SendPaymentReceipt(user.Email, &recipient); err != nil {
+			logger.Error("Failed to send payment receipt", "invoice_id", invoice.ID, "user_id", user.ID, "error", err.Error())
+		}
+	}
+}
+
+// ListInvoices lists the invoices of a tenant, newest first, with the total number
+func (s *Service) ListInvoices(ctx context.Context, tenantID int64, limit, offset int) ([]*models.Invoice, int, error) {
+	if s.invoiceRepo == nil {
+		return []*models.Invoice{}, 0, nil
+	}
+	return s.invoiceRepo.List(ctx, &models.InvoiceFilter{TenantID: tenantID, Limit: limit, Offset: offset})
+}
+
+// GetInvoice retrieves an invoice of a tenant
+func (s *Service) GetInvoice(ctx context.Context, tenantID, id int64) (*models.Invoice, error) {
+	if s.invoiceRepo == nil {
+		return nil, domainErrors.ErrNotFound
+	}
+	return s.invoiceRepo.GetByID(ctx, tenantID, id)
+}
+
+// InvoicePDF renders an invoice of a tenant as PDF
+func (s *Service) InvoicePDF(ctx context.Context, tenantID, id int64) (*models.Invoice, []byte, error) {
+	invoice, err := s.GetInvoice(ctx, tenantID, id)
+	if err != nil {
+		return nil, nil, err
+	}
+	return invoice, pdf.RenderInvoice(invoice), nil
+}
+
+// GetBillingDetails returns the billing address of a tenant, nil if unset
+func (s *Service) GetBillingDetails(ctx context.Context, tenantID int64) (*models.BillingDetails, error) {
+	settings, err := s.BillingSettings(ctx, tenantID)
+	if err != nil {
+		return nil, err
+	}
+	return settings.Details, nil
+}
+
+// UpdateBillingDetails sets the billing address of a tenant. It applies to
+// invoices issued from now on.
+func (s *Service) UpdateBillingDetails(ctx context.Context, tenantID int64, details *models.BillingDetails) (*models.BillingDetails, error) {
+	details.Country = strings.ToUpper(strings.TrimSpace(details.Country))
+	details.VATID = models.NormalizeVATID(details.VATID)
+
+	if len(details.Country) != 2 {
+		return nil, domainErrors.ErrInvalidInput
+	}
+	if details.VATID != "" && (!models.IsEUCountry(details.Country) || !models.ValidVATID(details.VATID, details.Country)) {
+		return nil, domainErrors.ErrInvalidVATID
+	}
+
+	settings, err := s.BillingSettings(ctx, tenantID)
+	if err != nil {
+		return nil, err
+	}
+
+	settings.Details = details
+	if err := s.saveBillingSettings(ctx, tenantID, settings); err != nil {
+		return nil, err
+	}
+
+	logger.Info("Billing details updated", "tenant_id", tenantID, "country", details.Country, "vat_id", details.VATID != "")
+
+	return details, nil
+}
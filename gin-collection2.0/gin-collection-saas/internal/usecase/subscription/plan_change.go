@@ -0,0 +1,410 @@
+package subscription
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	domainErrors "github.com/yourusername/gin-collection-saas/internal/domain/errors"
+	"github.com/yourusername/gin-collection-saas/internal/domain/models"
+	"github.com/yourusername/gin-collection-saas/internal/infrastructure/billing"
+	"github.com/yourusername/gin-collection-saas/pkg/logger"
+)
+
+// ChangePlan switches the active subscription of a tenant to another plan.
+// Upgrades take effect immediately and are prorated. Downgrades, and
+// upgrades at providers that don't prorate, are billed from the next period
+// and take effect at the end of the current one.
+// Providers that need the customer's approval return an approval URL, the
+// change is applied when the provider reports it.
+func (s *Service) ChangePlan(ctx context.Context, tenantID int64, planID string) (*PlanChangeResponse, error) {
+	logger.Info("Changing subscription plan", "tenant_id", tenantID, "plan_id", planID)
+
+	plan := s.catalog.Plan(planID)
+	if plan == nil || plan.Tier == models.TierFree {
+		return nil, domainErrors.ErrInvalidInput
+	}
+	if !plan.Active {
+		return nil, domainErrors.ErrPlanNotAvailable
+	}
+
+	subscription, err := s.subscriptionRepo.GetActiveSubscription(ctx, tenantID)
+	if err == domainErrors.ErrNotFound {
+		return nil, domainErrors.ErrNoActiveSubscription
+	}
+	if err != nil {
+		return nil, err
+	}
+	if subscription.PlanID == planID || subscription.ProviderSubscriptionID == nil {
+		return nil, domainErrors.ErrInvalidInput
+	}
+
+	provider, err := s.provider(subscription.Provider)
+	if err != nil {
+		return nil, err
+	}
+
+	downgrade := models.IsDowngrade(s.planTier(subscription.PlanID), plan.Tier)
+	if downgrade || !provider.ProratesPlanChanges() {
+		return s.schedulePlanChange(ctx, provider, subscription, plan, downgrade)
+	}
+
+	now := time.Now()
+	proration := prorate(subscription, plan, now)
+
+	change, err := s.changePlanAtProvider(ctx, provider, subscription, planID, 0, true)
+	if err != nil {
+		return nil, err
+	}
+
+	response := &PlanChangeResponse{
+		ApprovalURL:  change.ApprovalURL,
+		Subscription: subscription,
+		Plan:         plan,
+		Tier:         plan.Tier,
+		EffectiveAt:  now,
+		Proration:    proration,
+	}
+	if change.Subscription == nil {
+		logger.Info("Plan change waits for approval", "subscription_id", subscription.ID, "plan_id", planID)
+		return response, nil
+	}
+
+	// The upgrade replaces a downgrade scheduled before
+	subscription.ScheduledPlanID = nil
+	subscription.ScheduledChangeAt = nil
+
+	if err := s.applyPlan(ctx, subscription, planID, change.Subscription.ProviderPlanID); err != nil {
+		return nil, err
+	}
+	return response, nil
+}
+
+// schedulePlanChange switches the provider to the plan without proration
+// and moves the tenant to its tier at the end of the period
+func (s *Service) schedulePlanChange(ctx context.Context, provider billing.BillingProvider, subscription *models.Subscription, plan *models.SubscriptionPlan, downgrade bool) (*PlanChangeResponse, error) {
+	var impact *models.DowngradeImpact
+	if downgrade {
+		var err error
+		impact, err = s.downgradeImpact(ctx, subscription.TenantID, plan)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	change, err := s.changePlanAtProvider(ctx, provider, subscription, plan.ID, 0, false)
+	if err != nil {
+		return nil, err
+	}
+
+	effectiveAt := time.Now()
+	if subscription.CurrentPeriodEnd != nil && subscription.CurrentPeriodEnd.After(effectiveAt) {
+		effectiveAt = *subscription.CurrentPeriodEnd
+	}
+
+	planID := plan.ID
+	subscription.ScheduledPlanID = &planID
+	subscription.ScheduledChangeAt = &effectiveAt
+
+	if err := s.subscriptionRepo.Update(ctx, subscription); err != nil {
+		return nil, fmt.Errorf("failed to update subscription: %w", err)
+	}
+
+	logger.Info("Plan change scheduled", "subscription_id", subscription.ID, "plan_id", planID,
+		"effective_at", effectiveAt, "downgrade", downgrade)
+
+	return &PlanChangeResponse{
+		ApprovalURL:  change.ApprovalURL,
+		Subscription: subscription,
+		Plan:         plan,
+		Tier:         plan.Tier,
+		Scheduled:    true,
+		EffectiveAt:  effectiveAt,
+		Impact:       impact,
+	}, nil
+}
+
+// changePlanAtProvider switches a subscription to a version of a plan at its
+// provider, 0 for the current version
+func (s *Service) changePlanAtProvider(ctx context.Context, provider billing.BillingProvider, subscription *models.Subscription, planID string, version int, prorate bool) (*billing.PlanChange, error) {
+	change, err := provider.ChangePlan(ctx, *subscription.ProviderSubscriptionID, planID, version, prorate)
+	if err != nil {
+		if errors.Is(err, billing.ErrPlanNotOffered) {
+			return nil, domainErrors.ErrPlanNotOffered
+		}
+		return nil, fmt.Errorf("failed to change %s plan: %w", provider.Name(), err)
+	}
+	return change, nil
+}
+
+// CancelScheduledChange keeps the current plan of a tenant whose plan change
+// is scheduled
+func (s *Service) CancelScheduledChange(ctx context.Context, tenantID int64) (*PlanChangeResponse, error) {
+	logger.Info("Cancelling scheduled plan change", "tenant_id", tenantID)
+
+	subscription, err := s.subscriptionRepo.GetActiveSubscription(ctx, tenantID)
+	if err == domainErrors.ErrNotFound {
+		return nil, domainErrors.ErrNoActiveSubscription
+	}
+	if err != nil {
+		return nil, err
+	}
+	if subscription.ScheduledPlanID == nil {
+		return nil, domainErrors.ErrNoScheduledChange
+	}
+
+	provider, err := s.provider(subscription.Provider)
+	if err != nil {
+		return nil, err
+	}
+
+	// Bill the current plan again from the next period, in the version the
+	// subscriber has
+	change, err := s.changePlanAtProvider(ctx, provider, subscription, subscription.PlanID, subscription.PlanVersion, false)
+	if err != nil {
+		return nil, err
+	}
+
+	subscription.ScheduledPlanID = nil
+	subscription.ScheduledChangeAt = nil
+	if err := s.subscriptionRepo.Update(ctx, subscription); err != nil {
+		return nil, fmt.Errorf("failed to update subscription: %w", err)
+	}
+
+	plan := s.catalog.Version(subscription.PlanID, subscription.PlanVersion)
+	return &PlanChangeResponse{
+		ApprovalURL:  change.ApprovalURL,
+		Subscription: subscription,
+		Plan:         plan,
+		Tier:         s.planTier(subscription.PlanID),
+		EffectiveAt:  time.Now(),
+	}, nil
+}
+
+// PreviewPlanChange reports what changing to a plan costs and, for
+// downgrades, which gins, photos and features exceed the new limits.
+// PLAN_FREE previews a cancellation, which takes effect immediately.
+func (s *Service) PreviewPlanChange(ctx context.Context, tenantID int64, planID string) (*models.PlanChangePreview, error) {
+	plan := s.catalog.Plan(planID)
+	if plan == nil {
+		return nil, domainErrors.ErrInvalidInput
+	}
+
+	tenant, err := s.tenantRepo.GetByID(ctx, tenantID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get tenant: %w", err)
+	}
+
+	now := time.Now()
+	preview := &models.PlanChangePreview{
+		TargetPlan:  plan,
+		Downgrade:   models.IsDowngrade(tenant.Tier, plan.Tier),
+		EffectiveAt: now,
+	}
+
+	subscription, err := s.subscriptionRepo.GetActiveSubscription(ctx, tenantID)
+	if err != nil && err != domainErrors.ErrNotFound {
+		return nil, err
+	}
+	if subscription != nil {
+		if subscription.PlanID == planID {
+			return nil, domainErrors.ErrInvalidInput
+		}
+		preview.CurrentPlanID = subscription.PlanID
+
+		switch {
+		case plan.Tier == models.TierFree:
+			// Cancellations take effect immediately
+		case preview.Downgrade || !s.prorates(subscription):
+			if subscription.CurrentPeriodEnd != nil && subscription.CurrentPeriodEnd.After(now) {
+				preview.EffectiveAt = *subscription.CurrentPeriodEnd
+			}
+		default:
+			preview.Proration = prorate(subscription, plan, now)
+		}
+	}
+
+	if preview.Downgrade {
+		preview.Impact, err = s.downgradeImpact(ctx, tenantID, plan)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return preview, nil
+}
+
+// prorates reports whether the provider of a subscription charges plan
+// changes for the rest of the period
+func (s *Service) prorates(subscription *models.Subscription) bool {
+	provider, err := s.provider(subscription.Provider)
+	return err == nil && provider.ProratesPlanChanges()
+}
+
+// downgradeImpact compares the collection of a tenant with the limits of a plan
+func (s *Service) downgradeImpact(ctx context.Context, tenantID int64, plan *models.SubscriptionPlan) (*models.DowngradeImpact, error) {
+	tenant, err := s.tenantRepo.GetByID(ctx, tenantID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get tenant: %w", err)
+	}
+
+	tier := plan.Tier
+	limits := plan.Limits
+	if tier == models.TierFree {
+		limits = models.PlanLimitsMap[models.TierFree]
+	}
+	// Limits set for the tenant stay with the new plan
+	limits = tenant.LimitOverrides.Apply(limits)
+
+	ginCount, err := s.ginRepo.Count(ctx, tenantID, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count gins: %w", err)
+	}
+
+	usage, err := s.storageUsageRepo.GetUsage(ctx, tenantID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get storage usage: %w", err)
+	}
+
+	impact := &models.DowngradeImpact{
+		FromTier:         tenant.Tier,
+		ToTier:           tier,
+		GinCount:         ginCount,
+		GinLimit:         limits.MaxGins,
+		GinsOverLimit:    limits.GinsOverLimit(ginCount),
+		PhotoLimit:       limits.MaxPhotosPerGin,
+		StorageMB:        usage.MB(),
+		StorageLimitMB:   limits.StorageLimitMB,
+		StorageOverLimit: usage.WouldExceed(0, limits.StorageLimitMB),
+		FeaturesLost:     tenant.GetLimits().LostFeatures(limits),
+	}
+	impact.ReadOnly = impact.GinsOverLimit > 0
+
+	if limits.MaxPhotosPerGin >= 0 {
+		impact.PhotosOverLimit, impact.GinsWithPhotosOverLimit, err = s.photoRepo.CountBeyondLimit(ctx, tenantID, limits.MaxPhotosPerGin)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return impact, nil
+}
+
+// ApplyScheduledChanges moves the subscriptions whose plan change is due to
+// their new plan and returns how many were changed
+func (s *Service) ApplyScheduledChanges(ctx context.Context) (int, error) {
+	subscriptions, err := s.subscriptionRepo.ListScheduledChanges(ctx, time.Now())
+	if err != nil {
+		return 0, err
+	}
+
+	applied := 0
+	for _, subscription := range subscriptions {
+		if err := s.applyScheduledChange(ctx, subscription); err != nil {
+			logger.Error("Failed to apply scheduled plan change", "subscription_id", subscription.ID, "error", err.Error())
+			continue
+		}
+		applied++
+	}
+
+	if applied > 0 {
+		logger.Info("Scheduled plan changes applied", "count", applied)
+	}
+
+	return applied, nil
+}
+
+// applyScheduledChange moves a subscription to its scheduled plan
+func (s *Service) applyScheduledChange(ctx context.Context, subscription *models.Subscription) error {
+	planID := *subscription.ScheduledPlanID
+	subscription.ScheduledPlanID = nil
+	subscription.ScheduledChangeAt = nil
+
+	// Ended subscriptions don't change plans anymore, paused ones are
+	// moved to the plan they resume with
+	if subscription.Status != models.SubscriptionStatusActive && subscription.Status != models.SubscriptionStatusPaused {
+		return s.subscriptionRepo.Update(ctx, subscription)
+	}
+
+	// The provider plan is reported by the provider, the local one is kept if it can't be reached
+	var providerPlanID string
+	if provider, err := s.provider(subscription.Provider); err == nil && subscription.ProviderSubscriptionID != nil {
+		if info, err := provider.GetSubscription(ctx, *subscription.ProviderSubscriptionID); err == nil {
+			providerPlanID = info.ProviderPlanID
+		}
+	}
+
+	return s.applyPlan(ctx, subscription, planID, providerPlanID)
+}
+
+// StartPlanChangeScheduler periodically ends the subscriptions cancelled at
+// the end of their period and applies due plan changes until ctx is
+// cancelled. With a lock repository one instance runs it at a time.
+func (s *Service) StartPlanChangeScheduler(ctx context.Context, interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				s.runExclusive(ctx, "subscription_plan_changes", func() {
+					if _, err := s.EndCancelledSubscriptions(ctx); err != nil {
+						logger.Error("Cancellation scheduler failed", "error", err.Error())
+					}
+					if _, err := s.ApplyScheduledChanges(ctx); err != nil {
+						logger.Error("Plan change scheduler failed", "error", err.Error())
+					}
+				})
+			}
+		}
+	}()
+}
+
+// prorate credits the unused part of the current period and charges the
+// new plan for the same time. Only providers that prorate plan changes get
+// here, they invoice the difference.
+func prorate(subscription *models.Subscription, plan *models.SubscriptionPlan, now time.Time) *models.Proration {
+	proration := &models.Proration{Currency: subscription.Currency, Invoiced: true}
+	if proration.Currency == "" {
+		proration.Currency = plan.Currency
+	}
+
+	start, end := subscription.CurrentPeriodStart, subscription.CurrentPeriodEnd
+	if start == nil || end == nil || !end.After(now) || !end.After(*start) {
+		return proration
+	}
+	if now.Before(*start) {
+		now = *start
+	}
+
+	period := end.Sub(*start)
+	remaining := end.Sub(now)
+	fraction := remaining.Seconds() / period.Seconds()
+
+	newAmount := plan.PriceMonthly
+	if subscription.BillingCycle == models.BillingCycleYearly {
+		newAmount = plan.PriceYearly
+	}
+
+	proration.PeriodDays = int(math.Ceil(period.Hours() / 24))
+	proration.RemainingDays = int(math.Ceil(remaining.Hours() / 24))
+	proration.Credit = roundCents(subscription.Amount * fraction)
+	proration.Charge = roundCents(newAmount * fraction)
+	if proration.Charge >= proration.Credit {
+		proration.AmountDue = roundCents(proration.Charge - proration.Credit)
+	} else {
+		proration.CarriedCredit = roundCents(proration.Credit - proration.Charge)
+	}
+
+	return proration
+}
+
+// roundCents rounds an amount to cents
+func roundCents(amount float64) float64 {
+	return math.Round(amount*100) / 100
+}
@@ -0,0 +1,263 @@
+package subscription
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"time"
+
+	domainErrors "github.com/yourusername/gin-collection-saas/internal/domain/errors"
+	"github.com/yourusername/gin-collection-saas/internal/domain/models"
+	"github.com/yourusername/gin-collection-saas/pkg/logger"
+)
+
+// PauseSubscription pauses the active subscription of a tenant. The provider
+// stops billing it (PayPal suspends the subscription, Stripe pauses payment
+// collection) and the tenant is held to the Free limits until it resumes.
+// Nothing is deleted, a collection over the Free limits is read-only.
+func (s *Service) PauseSubscription(ctx context.Context, tenantID int64, reason string) (*models.Subscription, error) {
+	logger.Info("Pausing subscription", "tenant_id", tenantID)
+
+	subscription, err := s.subscriptionRepo.GetActiveSubscription(ctx, tenantID)
+	if err == domainErrors.ErrNotFound {
+		return nil, domainErrors.ErrNoActiveSubscription
+	}
+	if err != nil {
+		return nil, err
+	}
+	// A subscription cancelled at the end of the period is resumed instead
+	if subscription.ProviderSubscriptionID == nil || subscription.CancelAtPeriodEnd {
+		return nil, domainErrors.ErrInvalidInput
+	}
+
+	provider, err := s.provider(subscription.Provider)
+	if err != nil {
+		return nil, err
+	}
+	if err := provider.SuspendSubscription(ctx, *subscription.ProviderSubscriptionID, reason); err != nil {
+		return nil, fmt.Errorf("failed to suspend %s subscription: %w", provider.Name(), err)
+	}
+
+	now := s.clock()
+	subscription.Status = models.SubscriptionStatusPaused
+	subscription.PausedAt = &now
+	subscription.NextBillingDate = nil
+	if err := s.subscriptionRepo.Update(ctx, subscription); err != nil {
+		return nil, fmt.Errorf("failed to update subscription: %w", err)
+	}
+
+	if err := s.setTenantPlan(ctx, tenantID, nil); err != nil {
+		return nil, err
+	}
+
+	logger.Info("Subscription paused", "subscription_id", subscription.ID, "tenant_id", tenantID, "provider", subscription.Provider)
+
+	return subscription, nil
+}
+
+// ResumeSubscription resumes the paused subscription of a tenant, or keeps
+// one that is cancelled at the end of the period. PayPal reactivates the
+// subscription, Stripe resumes payment collection. A paused subscription
+// gets the tenant its plan back.
+func (s *Service) ResumeSubscription(ctx context.Context, tenantID int64, reason string) (*models.Subscription, error) {
+	logger.Info("Resuming subscription", "tenant_id", tenantID)
+
+	subscription, err := s.subscriptionRepo.GetByTenantID(ctx, tenantID)
+	if err == domainErrors.ErrNotFound {
+		return nil, domainErrors.ErrNoActiveSubscription
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	paused := subscription.Status == models.SubscriptionStatusPaused
+	cancelling := subscription.Status == models.SubscriptionStatusActive && subscription.CancelAtPeriodEnd
+	if (!paused && !cancelling) || subscription.ProviderSubscriptionID == nil {
+		return nil, domainErrors.ErrNotResumable
+	}
+
+	provider, err := s.provider(subscription.Provider)
+	if err != nil {
+		return nil, err
+	}
+	if err := provider.ResumeSubscription(ctx, *subscription.ProviderSubscriptionID, reason); err != nil {
+		return nil, fmt.Errorf("failed to resume %s subscription: %w", provider.Name(), err)
+	}
+
+	// The billing dates are reported by the provider, the local ones are
+	// kept if it can't be reached
+	if info, err := provider.GetSubscription(ctx, *subscription.ProviderSubscriptionID); err == nil {
+		if info.CurrentPeriodStart != nil && info.CurrentPeriodEnd != nil {
+			subscription.CurrentPeriodStart = info.CurrentPeriodStart
+			subscription.CurrentPeriodEnd = info.CurrentPeriodEnd
+		}
+		if info.NextBillingDate != nil {
+			subscription.NextBillingDate = info.NextBillingDate
+		}
+	}
+
+	subscription.CancelAtPeriodEnd = false
+	if paused {
+		if err := s.unpause(ctx, subscription); err != nil {
+			return nil, err
+		}
+	}
+	if err := s.subscriptionRepo.Update(ctx, subscription); err != nil {
+		return nil, fmt.Errorf("failed to update subscription: %w", err)
+	}
+
+	logger.Info("Subscription resumed", "subscription_id", subscription.ID, "tenant_id", tenantID, "was_paused", paused)
+
+	return subscription, nil
+}
+
+// unpause marks a paused subscription active and moves the tenant back to
+// the plan version it subscribed to. The caller saves the subscription.
+func (s *Service) unpause(ctx context.Context, subscription *models.Subscription) error {
+	subscription.Status = models.SubscriptionStatusActive
+	subscription.PausedAt = nil
+
+	plan := s.catalog.Version(subscription.PlanID, subscription.PlanVersion)
+	return s.setTenantPlan(ctx, subscription.TenantID, plan)
+}
+
+// ChangeBillingCycle switches the active subscription of a tenant between
+// monthly and yearly billing. It moves to the plan of its tier billed in the
+// other cycle, keeping the tier. Providers that prorate credit the unused
+// part of the current period, providers that need the customer's approval
+// return an approval URL and the change is applied when they report it.
+func (s *Service) ChangeBillingCycle(ctx context.Context, tenantID int64, billingCycle models.BillingCycle) (*PlanChangeResponse, error) {
+	logger.Info("Changing billing cycle", "tenant_id", tenantID, "billing_cycle", billingCycle)
+
+	subscription, err := s.subscriptionRepo.GetActiveSubscription(ctx, tenantID)
+	if err == domainErrors.ErrNotFound {
+		return nil, domainErrors.ErrNoActiveSubscription
+	}
+	if err != nil {
+		return nil, err
+	}
+	if subscription.BillingCycle == billingCycle || subscription.ProviderSubscriptionID == nil {
+		return nil, domainErrors.ErrInvalidInput
+	}
+	if subscription.ScheduledPlanID != nil {
+		return nil, domainErrors.ErrPlanChangeScheduled
+	}
+
+	plan := s.catalog.CyclePlan(subscription.PlanID, billingCycle)
+	if plan == nil {
+		return nil, domainErrors.ErrBillingCycleNotAvailable
+	}
+
+	provider, err := s.provider(subscription.Provider)
+	if err != nil {
+		return nil, err
+	}
+
+	change, err := s.changePlanAtProvider(ctx, provider, subscription, plan.ID, 0, true)
+	if err != nil {
+		return nil, err
+	}
+
+	response := &PlanChangeResponse{
+		ApprovalURL:  change.ApprovalURL,
+		Subscription: subscription,
+		Plan:         plan,
+		Tier:         plan.Tier,
+		EffectiveAt:  time.Now(),
+	}
+	if change.Subscription == nil {
+		logger.Info("Billing cycle change waits for approval", "subscription_id", subscription.ID, "plan_id", plan.ID)
+		return response, nil
+	}
+
+	if err := s.applyPlan(ctx, subscription, plan.ID, change.Subscription.ProviderPlanID); err != nil {
+		return nil, err
+	}
+	return response, nil
+}
+
+// UpcomingCharges lists the next payments of the subscription of a tenant:
+// the price of its plan version, or of the plan a downgrade is scheduled to,
+// less the coupon discount while it applies. The VAT included is that of
+// the tenant's billing address. Paused subscriptions and ones cancelled at
+// the end of the period have no upcoming charges.
+func (s *Service) UpcomingCharges(ctx context.Context, tenantID int64, count int) (*models.UpcomingCharges, error) {
+	subscription, err := s.subscriptionRepo.GetByTenantID(ctx, tenantID)
+	if err == domainErrors.ErrNotFound {
+		return nil, domainErrors.ErrNoActiveSubscription
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	upcoming := &models.UpcomingCharges{
+		SubscriptionID:    subscription.ID,
+		Status:            subscription.Status,
+		CancelAtPeriodEnd: subscription.CancelAtPeriodEnd,
+		Charges:           []models.UpcomingCharge{},
+	}
+	if subscription.CancelAtPeriodEnd {
+		upcoming.EndsAt = subscription.CurrentPeriodEnd
+	}
+	if subscription.Status != models.SubscriptionStatusActive || subscription.CancelAtPeriodEnd {
+		return upcoming, nil
+	}
+
+	date := subscription.NextBillingDate
+	if date == nil {
+		date = subscription.CurrentPeriodEnd
+	}
+	if date == nil {
+		return upcoming, nil
+	}
+
+	customer, err := s.GetBillingDetails(ctx, tenantID)
+	if err != nil {
+		return nil, err
+	}
+
+	planID, planName := subscription.PlanID, s.planName(subscription)
+	billingCycle, price := subscription.BillingCycle, subscription.Amount
+	scheduled := false
+
+	for next := *date; len(upcoming.Charges) < count; {
+		changeAt := subscription.ScheduledChangeAt
+		if !scheduled && subscription.ScheduledPlanID != nil && changeAt != nil && !next.Before(*changeAt) {
+			if plan := s.catalog.Plan(*subscription.ScheduledPlanID); plan != nil {
+				planID, planName, scheduled = plan.ID, plan.Name, true
+				if plan.BillingCycle != "" {
+					billingCycle = plan.BillingCycle
+				}
+				price = planPrice(plan, billingCycle)
+			}
+		}
+
+		charge := models.UpcomingCharge{
+			Date:         next,
+			PlanID:       planID,
+			PlanName:     planName,
+			BillingCycle: billingCycle,
+			Price:        price,
+			Currency:     subscription.Currency,
+			Scheduled:    scheduled,
+		}
+		if subscription.Discount > 0 && (subscription.DiscountEndsAt == nil || next.Before(*subscription.DiscountEndsAt)) {
+			charge.Discount = math.Min(subscription.Discount, price)
+		}
+		charge.TotalAmount = roundCents(price - charge.Discount)
+		if s.invoiceSettings != nil {
+			charge.ApplyTax(models.TaxFor(charge.Currency, customer, &s.invoiceSettings.Seller, s.invoiceSettings.VATRate))
+		} else {
+			charge.NetAmount = charge.TotalAmount
+		}
+		upcoming.Charges = append(upcoming.Charges, charge)
+
+		if billingCycle == models.BillingCycleYearly {
+			next = next.AddDate(1, 0, 0)
+		} else {
+			next = next.AddDate(0, 1, 0)
+		}
+	}
+
+	return upcoming, nil
+}
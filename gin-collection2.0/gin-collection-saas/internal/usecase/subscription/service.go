@@ -2,11 +2,15 @@ package subscription
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"time"
 
+	domainErrors "github.com/yourusername/gin-collection-saas/internal/domain/errors"
 	"github.com/yourusername/gin-collection-saas/internal/domain/models"
 	"github.com/yourusername/gin-collection-saas/internal/domain/repositories"
+	"github.com/yourusername/gin-collection-saas/internal/infrastructure/billing"
 	"github.com/yourusername/gin-collection-saas/internal/infrastructure/external"
 	"github.com/yourusername/gin-collection-saas/pkg/logger"
 )
@@ -15,23 +19,116 @@ import (
 type Service struct {
 	subscriptionRepo repositories.SubscriptionRepository
 	tenantRepo       repositories.TenantRepository
-	paypalClient     *external.PayPalClient
+	ginRepo          repositories.GinRepository
+	photoRepo        repositories.PhotoRepository
+	storageUsageRepo repositories.StorageUsageRepository
+	providers        *billing.Registry
+	catalog          *Catalog
 	baseURL          string
+	webhookEventRepo repositories.WebhookEventRepository
+	couponRepo       repositories.CouponRepository
+	lockRepo         repositories.LockRepository
+
+	// Trials
+	trialDays           map[string]int
+	trialReminderBefore time.Duration
+
+	// Invoices
+	invoiceRepo     repositories.InvoiceRepository
+	invoiceSettings *InvoiceSettings
+
+	// Notifications of billing managers
+	userRepo    repositories.UserRepository
+	emailClient *external.EmailClient
+
+	// Dunning of failed payments
+	dunning      *models.DunningPolicy
+	auditLogRepo repositories.AuditLogRepository
+
+	clock func() time.Time
 }
 
 // NewService creates a new subscription service
 func NewService(
 	subscriptionRepo repositories.SubscriptionRepository,
 	tenantRepo repositories.TenantRepository,
-	paypalClient *external.PayPalClient,
+	ginRepo repositories.GinRepository,
+	photoRepo repositories.PhotoRepository,
+	storageUsageRepo repositories.StorageUsageRepository,
+	providers *billing.Registry,
 	baseURL string,
 ) *Service {
 	return &Service{
 		subscriptionRepo: subscriptionRepo,
 		tenantRepo:       tenantRepo,
-		paypalClient:     paypalClient,
+		ginRepo:          ginRepo,
+		photoRepo:        photoRepo,
+		storageUsageRepo: storageUsageRepo,
+		providers:        providers,
+		catalog:          NewCatalog(nil),
 		baseURL:          baseURL,
+		clock:            time.Now,
+	}
+}
+
+// SetCatalog replaces the built-in plans with a plan catalog
+func (s *Service) SetCatalog(catalog *Catalog) {
+	s.catalog = catalog
+}
+
+// SetClock replaces the clock trials, dunning, pauses and cancellations are timed with (for tests)
+func (s *Service) SetClock(clock func() time.Time) {
+	s.clock = clock
+}
+
+// SetLockRepo makes each scheduler job run on one instance at a time.
+// Without it every instance runs them.
+func (s *Service) SetLockRepo(lockRepo repositories.LockRepository) {
+	s.lockRepo = lockRepo
+}
+
+// runExclusive runs a scheduler job unless another instance is running it
+func (s *Service) runExclusive(ctx context.Context, job string, run func()) {
+	if s.lockRepo == nil {
+		run()
+		return
+	}
+
+	release, acquired, err := s.lockRepo.TryLock(ctx, job)
+	if err != nil {
+		logger.Error("Failed to lock scheduler job", "job", job, "error", err.Error())
+		return
+	}
+	if !acquired {
+		logger.Debug("Scheduler job runs on another instance", "job", job)
+		return
 	}
+	defer release()
+
+	run()
+}
+
+// SetNotifications enables emailing the billing managers of a tenant about
+// trials and payments
+func (s *Service) SetNotifications(userRepo repositories.UserRepository, emailClient *external.EmailClient) {
+	s.userRepo = userRepo
+	s.emailClient = emailClient
+}
+
+// billingManagers returns the active users of a tenant that manage billing
+func (s *Service) billingManagers(ctx context.Context, tenantID int64) ([]*models.User, error) {
+	users, err := s.userRepo.List(ctx, tenantID)
+	if err != nil {
+		return nil, err
+	}
+
+	managers := make([]*models.User, 0, len(users))
+	for _, user := range users {
+		if user.IsActive && user.Role.HasPermission(models.PermissionBillingManage) {
+			managers = append(managers, user)
+		}
+	}
+	return managers, nil
 }
 
 // GetCurrentSubscription retrieves the current subscription for a tenant
@@ -45,13 +142,28 @@ func (s *Service) GetCurrentSubscription(ctx context.Context, tenantID int64) (*
 	return subscription, nil
 }
 
-// GetAvailablePlans returns all available subscription plans
-func (s *Service) GetAvailablePlans() []models.SubscriptionPlan {
-	return models.AvailablePlans
+// GetAvailablePlans returns the plans offered to new subscribers with their
+// trials, the features are described in a language
+func (s *Service) GetAvailablePlans(lang string) []models.SubscriptionPlan {
+	plans := []models.SubscriptionPlan{}
+	for _, plan := range s.catalog.Plans() {
+		if !plan.Active {
+			continue
+		}
+		plan.TrialDays = s.trialDays[plan.ID]
+		plan.Features = models.PlanFeatures(plan.Limits, lang)
+		plan.ProviderPlans = nil
+		plan.CreatedBy = nil
+		plans = append(plans, plan)
+	}
+	return plans
 }
 
-// InitiateUpgrade initiates a subscription upgrade to a new plan
-func (s *Service) InitiateUpgrade(ctx context.Context, tenantID int64, planID string, billingCycle models.BillingCycle) (*UpgradeResponse, error) {
+// InitiateUpgrade starts a subscription to a new plan. The provider is the
+// requested one, the tenant's chosen provider or the platform default. A
+// coupon code reduces the price, a redemption of the coupon is reserved for
+// the checkout and redeemed on activation.
+func (s *Service) InitiateUpgrade(ctx context.Context, tenantID int64, planID string, billingCycle models.BillingCycle, providerName, couponCode string) (*UpgradeResponse, error) {
 	logger.Info("Initiating subscription upgrade", "tenant_id", tenantID, "plan_id", planID, "billing_cycle", billingCycle)
 
 	// Verify tenant exists
@@ -60,364 +172,872 @@ func (s *Service) InitiateUpgrade(ctx context.Context, tenantID int64, planID st
 		return nil, fmt.Errorf("failed to get tenant: %w", err)
 	}
 
-	// Validate plan
-	plan := models.GetPlanByID(planID)
+	// Validate plan, new subscribers get its current version
+	plan := s.catalog.Plan(planID)
 	if plan == nil {
-		return nil, fmt.Errorf("invalid plan ID: %s", planID)
+		return nil, domainErrors.ErrInvalidInput
 	}
+	if !plan.Active {
+		return nil, domainErrors.ErrPlanNotAvailable
+	}
+	tier := plan.Tier
 
-	// Determine tier from plan ID
-	tier := s.getTierFromPlanID(planID)
-
-	// Get PayPal plan ID based on billing cycle
-	paypalPlanID := s.getPayPalPlanID(planID, billingCycle)
-	if paypalPlanID == "" {
-		return nil, fmt.Errorf("no PayPal plan configured for %s %s", planID, billingCycle)
+	provider, err := s.resolveProvider(ctx, tenantID, providerName)
+	if err != nil {
+		return nil, err
 	}
 
-	// Create PayPal subscription
-	paypalReq := &external.PayPalSubscriptionRequest{
-		PlanID: paypalPlanID,
-		ApplicationContext: &external.PayPalApplicationContext{
-			BrandName: "Gin Collection SaaS",
-			ReturnURL: fmt.Sprintf("%s/subscription/success", s.baseURL),
-			CancelURL: fmt.Sprintf("%s/subscription/cancel", s.baseURL),
-			UserAction: "SUBSCRIBE_NOW",
-			PaymentMethod: &external.PayPalPaymentMethod{
-				PayeePreferred: "IMMEDIATE_PAYMENT_REQUIRED",
-			},
-		},
+	// Tenants subscribed with the provider change the plan of their
+	// subscription instead, so the change is prorated
+	if active, err := s.subscriptionRepo.GetActiveSubscription(ctx, tenantID); err == nil &&
+		active.Provider == provider.Name() && active.ProviderSubscriptionID != nil {
+		return nil, domainErrors.ErrPlanChangeRequired
 	}
 
-	paypalResp, err := s.paypalClient.CreateSubscription(paypalReq)
-	if err != nil {
-		logger.Error("Failed to create PayPal subscription", "error", err.Error())
-		return nil, fmt.Errorf("failed to create PayPal subscription: %w", err)
+	// Customers of the provider are reused, so they keep their payment methods
+	var customerID *string
+	if previous, err := s.subscriptionRepo.GetByTenantID(ctx, tenantID); err == nil && previous.Provider == provider.Name() {
+		customerID = previous.ProviderCustomerID
 	}
 
 	// Calculate amount based on billing cycle
-	amount := plan.PriceMonthly
-	if billingCycle == models.BillingCycleYearly {
-		amount = plan.PriceYearly
+	amount := planPrice(plan, billingCycle)
+
+	var coupon *models.Coupon
+	var discount *billing.Discount
+	if couponCode != "" {
+		coupon, err = s.redeemableCoupon(ctx, tenantID, couponCode, planID)
+		if err != nil {
+			return nil, err
+		}
+		discount = providerDiscount(coupon, plan, amount)
+
+		// Reserved before the checkout starts, so concurrent checkouts can't
+		// exceed the redemption limit
+		if err := s.reserveCoupon(ctx, coupon, tenantID, coupon.DiscountFor(amount)); err != nil {
+			return nil, err
+		}
 	}
 
-	// Create subscription record
+	checkout, err := provider.CreateSubscription(ctx, &billing.CreateSubscriptionRequest{
+		TenantID:    tenantID,
+		PlanID:      planID,
+		PlanVersion: plan.Version,
+		CustomerID:  stringValue(customerID),
+		BrandName:   "Gin Collection SaaS",
+		ReturnURL:   fmt.Sprintf("%s/subscription/success?provider=%s", s.baseURL, provider.Name()),
+		CancelURL:   fmt.Sprintf("%s/subscription/cancel", s.baseURL),
+		Discount:    discount,
+	})
+	if err != nil {
+		s.releaseCoupon(ctx, coupon, tenantID)
+		if errors.Is(err, billing.ErrPlanNotOffered) {
+			return nil, domainErrors.ErrPlanNotOffered
+		}
+		if errors.Is(err, billing.ErrDiscountNotSupported) {
+			return nil, domainErrors.ErrCouponNotApplicable
+		}
+		logger.Error("Failed to create provider subscription", "provider", provider.Name(), "error", err.Error())
+		return nil, fmt.Errorf("failed to create %s subscription: %w", provider.Name(), err)
+	}
+
+	// Create subscription record, periods are set when activated
 	subscription := &models.Subscription{
-		TenantID:              tenantID,
-		PlanID:                planID,
-		Status:                models.SubscriptionStatusPending,
-		BillingCycle:          billingCycle,
-		PayPalSubscriptionID:  &paypalResp.ID,
-		PayPalPlanID:          &paypalPlanID,
-		Amount:                amount,
-		Currency:              "EUR",
-		CurrentPeriodStart:    nil, // Set when activated
-		CurrentPeriodEnd:      nil,
-		NextBillingDate:       nil,
+		TenantID:               tenantID,
+		PlanID:                 planID,
+		PlanVersion:            plan.Version,
+		Status:                 models.SubscriptionStatusPending,
+		BillingCycle:           billingCycle,
+		Provider:               provider.Name(),
+		ProviderCustomerID:     customerID,
+		ProviderSubscriptionID: optionalString(checkout.SubscriptionID),
+		ProviderPlanID:         optionalString(checkout.ProviderPlanID),
+		ProviderCheckoutID:     optionalString(checkout.CheckoutID),
+		Amount:                 amount,
+		Currency:               "EUR",
+	}
+	if coupon != nil {
+		subscription.CouponID = &coupon.ID
+		subscription.Discount = coupon.DiscountFor(amount)
 	}
 
 	if err := s.subscriptionRepo.Create(ctx, subscription); err != nil {
+		s.releaseCoupon(ctx, coupon, tenantID)
 		logger.Error("Failed to create subscription record", "error", err.Error())
 		return nil, fmt.Errorf("failed to create subscription: %w", err)
 	}
 
-	logger.Info("Subscription created", "subscription_id", subscription.ID, "paypal_subscription_id", paypalResp.ID)
+	logger.Info("Subscription created", "subscription_id", subscription.ID, "provider", provider.Name(),
+		"checkout_id", checkout.CheckoutID)
 
 	return &UpgradeResponse{
-		SubscriptionID:    subscription.ID,
-		PayPalApprovalURL: paypalResp.GetApprovalURL(),
-		Plan:              plan,
-		Tier:              tier,
-		Amount:            amount,
-		Currency:          "EUR",
-		BillingCycle:      billingCycle,
+		SubscriptionID: subscription.ID,
+		Provider:       provider.Name(),
+		CheckoutID:     checkout.CheckoutID,
+		ApprovalURL:    checkout.RedirectURL,
+		Plan:           plan,
+		Tier:           tier,
+		Amount:         amount,
+		Discount:       subscription.Discount,
+		Currency:       "EUR",
+		BillingCycle:   billingCycle,
 	}, nil
 }
 
-// ActivateSubscription activates a subscription after PayPal approval
-func (s *Service) ActivateSubscription(ctx context.Context, paypalSubscriptionID string) error {
-	logger.Info("Activating subscription", "paypal_subscription_id", paypalSubscriptionID)
+// ActivateCheckout activates the subscription of a checkout the customer
+// completed at the provider
+func (s *Service) ActivateCheckout(ctx context.Context, tenantID int64, providerName, checkoutID string) (*models.Subscription, error) {
+	logger.Info("Activating subscription", "tenant_id", tenantID, "provider", providerName, "checkout_id", checkoutID)
 
-	// Get subscription from DB
-	subscription, err := s.subscriptionRepo.GetByPayPalSubscriptionID(ctx, paypalSubscriptionID)
+	provider, err := s.provider(providerName)
 	if err != nil {
-		return fmt.Errorf("subscription not found: %w", err)
+		return nil, err
+	}
+
+	subscription, err := s.subscriptionRepo.GetByProviderCheckoutID(ctx, provider.Name(), checkoutID)
+	if err != nil {
+		return nil, err
+	}
+	if subscription.TenantID != tenantID {
+		return nil, domainErrors.ErrNotFound
 	}
 
-	// Get PayPal subscription details
-	paypalSub, err := s.paypalClient.GetSubscription(paypalSubscriptionID)
+	info, err := provider.CompleteCheckout(ctx, checkoutID)
 	if err != nil {
-		return fmt.Errorf("failed to get PayPal subscription: %w", err)
+		return nil, fmt.Errorf("failed to get %s subscription: %w", provider.Name(), err)
 	}
 
-	// Parse timestamps
-	startTime, _ := time.Parse(time.RFC3339, paypalSub.StartTime)
+	if err := s.activate(ctx, subscription, info); err != nil {
+		return nil, err
+	}
+	return subscription, nil
+}
 
-	var nextBillingTime *time.Time
-	if paypalSub.BillingInfo != nil && paypalSub.BillingInfo.NextBillingTime != "" {
-		t, _ := time.Parse(time.RFC3339, paypalSub.BillingInfo.NextBillingTime)
-		nextBillingTime = &t
+// activate marks a subscription active with the state reported by its
+// provider and moves the tenant to the subscribed tier
+func (s *Service) activate(ctx context.Context, subscription *models.Subscription, info *billing.SubscriptionInfo) error {
+	if info.ID == "" {
+		return domainErrors.ErrCheckoutIncomplete
+	}
+	if info.Status == billing.StatusCancelled || info.Status == billing.StatusExpired {
+		return domainErrors.ErrSubscriptionInactive
 	}
 
-	// Calculate period end
-	var periodEnd *time.Time
-	if subscription.BillingCycle == models.BillingCycleMonthly {
-		t := startTime.AddDate(0, 1, 0)
-		periodEnd = &t
-	} else if subscription.BillingCycle == models.BillingCycleYearly {
-		t := startTime.AddDate(1, 0, 0)
+	startTime := time.Now()
+	if info.CurrentPeriodStart != nil {
+		startTime = *info.CurrentPeriodStart
+	}
+
+	// Calculate period end, unless the provider reports it
+	periodEnd := info.CurrentPeriodEnd
+	if periodEnd == nil {
+		var t time.Time
+		if subscription.BillingCycle == models.BillingCycleYearly {
+			t = startTime.AddDate(1, 0, 0)
+		} else {
+			t = startTime.AddDate(0, 1, 0)
+		}
 		periodEnd = &t
 	}
 
-	// Update subscription
 	subscription.Status = models.SubscriptionStatusActive
+	subscription.PausedAt = nil
+	subscription.ProviderSubscriptionID = &info.ID
+	if info.CustomerID != "" {
+		subscription.ProviderCustomerID = &info.CustomerID
+	}
+	if info.ProviderPlanID != "" {
+		subscription.ProviderPlanID = &info.ProviderPlanID
+	}
 	subscription.CurrentPeriodStart = &startTime
 	subscription.CurrentPeriodEnd = periodEnd
-	subscription.NextBillingDate = nextBillingTime
+	subscription.NextBillingDate = info.NextBillingDate
+	subscription.CancelAtPeriodEnd = info.CancelAtPeriodEnd
+	if subscription.CouponID != nil && subscription.DiscountEndsAt == nil && s.couponRepo != nil {
+		if coupon, err := s.couponRepo.GetByID(ctx, *subscription.CouponID); err == nil {
+			subscription.DiscountEndsAt = coupon.DiscountEndsAt(startTime, subscription.BillingCycle)
+		}
+	}
 
 	if err := s.subscriptionRepo.Update(ctx, subscription); err != nil {
 		return fmt.Errorf("failed to update subscription: %w", err)
 	}
 
-	// Update tenant tier
-	tier := s.getTierFromPlanID(subscription.PlanID)
-	tenant, err := s.tenantRepo.GetByID(ctx, subscription.TenantID)
-	if err != nil {
-		return fmt.Errorf("failed to get tenant: %w", err)
-	}
+	s.redeemCoupon(ctx, subscription)
+	s.replacePreviousSubscription(ctx, subscription)
 
-	tenant.Tier = tier
-	if err := s.tenantRepo.Update(ctx, tenant); err != nil {
-		return fmt.Errorf("failed to update tenant tier: %w", err)
+	// Move the tenant to the plan version it subscribed to
+	plan := s.catalog.Version(subscription.PlanID, subscription.PlanVersion)
+	if err := s.setTenantPlan(ctx, subscription.TenantID, plan); err != nil {
+		return err
 	}
 
-	logger.Info("Subscription activated", "subscription_id", subscription.ID, "tenant_id", subscription.TenantID, "tier", tier)
+	logger.Info("Subscription activated", "subscription_id", subscription.ID, "tenant_id", subscription.TenantID,
+		"provider", subscription.Provider, "plan_version", subscription.PlanVersion)
 
 	return nil
 }
 
-// CancelSubscription cancels a subscription
-func (s *Service) CancelSubscription(ctx context.Context, tenantID int64, reason string) error {
+// replacePreviousSubscription cancels the subscriptions a newly activated
+// one replaces, e.g. after switching providers, so the tenant isn't billed
+// twice. A running trial ends with the subscription.
+func (s *Service) replacePreviousSubscription(ctx context.Context, current *models.Subscription) {
+	subscriptions, err := s.subscriptionRepo.List(ctx, current.TenantID)
+	if err != nil {
+		logger.Error("Failed to list subscriptions", "tenant_id", current.TenantID, "error", err.Error())
+		return
+	}
+
+	for _, previous := range subscriptions {
+		if previous.ID == current.ID {
+			continue
+		}
+		switch previous.Status {
+		case models.SubscriptionStatusActive, models.SubscriptionStatusPastDue, models.SubscriptionStatusSuspended,
+			models.SubscriptionStatusPaused, models.SubscriptionStatusTrialing:
+		default:
+			continue
+		}
+
+		s.cancelAtProvider(ctx, previous, "Replaced by a new subscription")
+
+		now := time.Now()
+		previous.Status = models.SubscriptionStatusCancelled
+		previous.CancelledAt = &now
+		previous.ScheduledPlanID = nil
+		previous.ScheduledChangeAt = nil
+		if err := s.subscriptionRepo.Update(ctx, previous); err != nil {
+			logger.Error("Failed to cancel replaced subscription", "subscription_id", previous.ID, "error", err.Error())
+			continue
+		}
+		logger.Info("Replaced subscription cancelled", "subscription_id", previous.ID, "replaced_by", current.ID)
+	}
+}
+
+// CancelSubscription cancels a subscription at the end of the current
+// period. The tenant keeps its plan until then and can resume the
+// subscription. Trials and subscriptions without a provider subscription
+// end right away, nothing is billed for them.
+func (s *Service) CancelSubscription(ctx context.Context, tenantID int64, reason string) (*models.Subscription, error) {
 	logger.Info("Cancelling subscription", "tenant_id", tenantID, "reason", reason)
 
-	// Get active subscription
+	// Get active subscription, or the running trial
 	subscription, err := s.subscriptionRepo.GetActiveSubscription(ctx, tenantID)
+	if err == domainErrors.ErrNotFound {
+		subscription, err = s.subscriptionRepo.GetByTenantID(ctx, tenantID)
+		if err == domainErrors.ErrNotFound || (err == nil && subscription.Status != models.SubscriptionStatusTrialing) {
+			return nil, domainErrors.ErrNoActiveSubscription
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if subscription.Status == models.SubscriptionStatusTrialing || subscription.ProviderSubscriptionID == nil {
+		return subscription, s.endSubscription(ctx, subscription, reason)
+	}
+	if subscription.CancelAtPeriodEnd {
+		return subscription, nil
+	}
+
+	provider, err := s.provider(subscription.Provider)
+	if err != nil {
+		return nil, err
+	}
+	if err := provider.CancelAtPeriodEnd(ctx, *subscription.ProviderSubscriptionID, reason); err != nil {
+		return nil, fmt.Errorf("failed to cancel %s subscription: %w", provider.Name(), err)
+	}
+
+	// The period end is reported by the provider, the local one is kept if
+	// it can't be reached
+	if info, err := provider.GetSubscription(ctx, *subscription.ProviderSubscriptionID); err == nil &&
+		info.CurrentPeriodStart != nil && info.CurrentPeriodEnd != nil {
+		subscription.CurrentPeriodStart = info.CurrentPeriodStart
+		subscription.CurrentPeriodEnd = info.CurrentPeriodEnd
+	}
+
+	subscription.CancelAtPeriodEnd = true
+	if err := s.subscriptionRepo.Update(ctx, subscription); err != nil {
+		return nil, fmt.Errorf("failed to update subscription: %w", err)
+	}
+
+	logger.Info("Subscription cancelled at the end of the period", "subscription_id", subscription.ID, "tenant_id", tenantID,
+		"provider", subscription.Provider, "period_end", subscription.CurrentPeriodEnd)
+
+	return subscription, nil
+}
+
+// EndCancelledSubscriptions ends the subscriptions cancelled at the end of a
+// period that is over and returns how many were ended. Stripe reports the
+// end with a webhook, PayPal subscriptions are cancelled here.
+func (s *Service) EndCancelledSubscriptions(ctx context.Context) (int, error) {
+	subscriptions, err := s.subscriptionRepo.ListCancellationsDue(ctx, s.clock())
 	if err != nil {
-		return fmt.Errorf("no active subscription found: %w", err)
+		return 0, err
+	}
+
+	ended := 0
+	for _, subscription := range subscriptions {
+		if err := s.endSubscription(ctx, subscription, "Cancelled at the end of the period"); err != nil {
+			logger.Error("Failed to end cancelled subscription", "subscription_id", subscription.ID, "error", err.Error())
+			continue
+		}
+		ended++
 	}
 
-	// Cancel in PayPal
-	if subscription.PayPalSubscriptionID != nil {
-		if err := s.paypalClient.CancelSubscription(*subscription.PayPalSubscriptionID, reason); err != nil {
-			logger.Error("Failed to cancel PayPal subscription", "error", err.Error())
-			// Continue anyway to update local status
+	if ended > 0 {
+		logger.Info("Cancelled subscriptions ended", "count", ended)
+	}
+
+	return ended, nil
+}
+
+// endSubscription cancels a subscription at its provider, unless it ended
+// there already, and moves the tenant to Free
+func (s *Service) endSubscription(ctx context.Context, subscription *models.Subscription, reason string) error {
+	ended := false
+	if provider, err := s.provider(subscription.Provider); err == nil && subscription.ProviderSubscriptionID != nil {
+		if info, err := provider.GetSubscription(ctx, *subscription.ProviderSubscriptionID); err == nil {
+			ended = info.Status == billing.StatusCancelled || info.Status == billing.StatusExpired
 		}
 	}
+	if !ended {
+		// Cancel at the provider, the local status is updated anyway
+		s.cancelAtProvider(ctx, subscription, reason)
+	}
 
-	// Update subscription status
-	now := time.Now()
+	now := s.clock()
 	subscription.Status = models.SubscriptionStatusCancelled
 	subscription.CancelledAt = &now
+	subscription.ScheduledPlanID = nil
+	subscription.ScheduledChangeAt = nil
+
+	if err := s.subscriptionRepo.Update(ctx, subscription); err != nil {
+		return fmt.Errorf("failed to update subscription: %w", err)
+	}
+
+	// Downgrade tenant to Free tier, unless another subscription replaced this one
+	if active, err := s.subscriptionRepo.GetActiveSubscription(ctx, subscription.TenantID); err == nil && active.ID != subscription.ID {
+		return nil
+	}
+	if err := s.setTenantPlan(ctx, subscription.TenantID, nil); err != nil {
+		return err
+	}
+
+	logger.Info("Subscription cancelled", "subscription_id", subscription.ID, "tenant_id", subscription.TenantID)
+
+	return nil
+}
+
+// cancelAtProvider cancels a subscription at its provider, failures are logged
+func (s *Service) cancelAtProvider(ctx context.Context, subscription *models.Subscription, reason string) {
+	if subscription.ProviderSubscriptionID == nil {
+		return
+	}
+
+	provider, err := s.provider(subscription.Provider)
+	if err != nil {
+		logger.Error("Billing provider of subscription is not configured", "subscription_id", subscription.ID, "provider", subscription.Provider)
+		return
+	}
+
+	if err := provider.CancelSubscription(ctx, *subscription.ProviderSubscriptionID, reason); err != nil {
+		logger.Error("Failed to cancel provider subscription", "provider", subscription.Provider, "error", err.Error())
+	}
+}
+
+// applyPlan records the plan a subscription was changed to and moves the
+// tenant to it. The version is the one billed with the provider plan, plans
+// billed in one cycle switch the subscription to it.
+func (s *Service) applyPlan(ctx context.Context, subscription *models.Subscription, planID, providerPlanID string) error {
+	plan := s.catalog.billedVersion(subscription.Provider, planID, providerPlanID)
+	if plan == nil {
+		return fmt.Errorf("unknown plan %s", planID)
+	}
+
+	subscription.PlanID = planID
+	subscription.PlanVersion = plan.Version
+	if providerPlanID != "" {
+		subscription.ProviderPlanID = &providerPlanID
+	}
+	if plan.BillingCycle != "" {
+		subscription.BillingCycle = plan.BillingCycle
+	}
+	subscription.Amount = plan.PriceMonthly
+	if subscription.BillingCycle == models.BillingCycleYearly {
+		subscription.Amount = plan.PriceYearly
+	}
 
 	if err := s.subscriptionRepo.Update(ctx, subscription); err != nil {
 		return fmt.Errorf("failed to update subscription: %w", err)
 	}
 
-	// Downgrade tenant to Free tier
+	if subscription.Status == models.SubscriptionStatusActive {
+		if err := s.setTenantPlan(ctx, subscription.TenantID, plan); err != nil {
+			return err
+		}
+	}
+
+	logger.Info("Subscription plan changed", "subscription_id", subscription.ID, "plan_id", planID)
+	return nil
+}
+
+// CustomerPortalURL returns where the tenant manages payment methods and
+// invoices at its provider
+func (s *Service) CustomerPortalURL(ctx context.Context, tenantID int64) (string, error) {
+	subscription, err := s.subscriptionRepo.GetByTenantID(ctx, tenantID)
+	if err == domainErrors.ErrNotFound {
+		return "", domainErrors.ErrNoActiveSubscription
+	}
+	if err != nil {
+		return "", err
+	}
+
+	provider, err := s.provider(subscription.Provider)
+	if err != nil {
+		return "", err
+	}
+
+	url, err := provider.CustomerPortalURL(ctx, stringValue(subscription.ProviderCustomerID), s.baseURL+"/subscription")
+	if errors.Is(err, billing.ErrNoCustomer) {
+		return "", domainErrors.ErrNoActiveSubscription
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to create %s portal session: %w", provider.Name(), err)
+	}
+	return url, nil
+}
+
+// BillingProviders returns the configured providers and the one the tenant uses
+func (s *Service) BillingProviders(ctx context.Context, tenantID int64) (*BillingProvidersResponse, error) {
+	settings, err := s.BillingSettings(ctx, tenantID)
+	if err != nil {
+		return nil, err
+	}
+
+	selected := settings.Provider
+	if _, err := s.providers.Get(selected); err != nil {
+		selected = s.providers.Default()
+	}
+
+	return &BillingProvidersResponse{
+		Available: s.providers.Names(),
+		Default:   s.providers.Default(),
+		Selected:  selected,
+	}, nil
+}
+
+// BillingSettings returns the billing settings of a tenant, empty ones if unset
+func (s *Service) BillingSettings(ctx context.Context, tenantID int64) (*models.BillingSettings, error) {
+	raw, err := s.tenantRepo.GetSettings(ctx, tenantID)
+	if err != nil {
+		return nil, err
+	}
+
+	settings := &models.BillingSettings{}
+	if len(raw) == 0 {
+		return settings, nil
+	}
+
+	var document map[string]json.RawMessage
+	if err := json.Unmarshal(raw, &document); err != nil {
+		return nil, fmt.Errorf("failed to parse tenant settings: %w", err)
+	}
+	if value, ok := document[models.BillingSettingsKey]; ok {
+		if err := json.Unmarshal(value, settings); err != nil {
+			return nil, fmt.Errorf("failed to parse billing settings: %w", err)
+		}
+	}
+
+	return settings, nil
+}
+
+// SelectBillingProvider sets the provider new subscriptions of a tenant are
+// billed with, an empty name returns to the platform default. The current
+// subscription keeps its provider until it's replaced.
+func (s *Service) SelectBillingProvider(ctx context.Context, tenantID int64, providerName string) (*BillingProvidersResponse, error) {
+	if providerName != "" {
+		if _, err := s.provider(providerName); err != nil {
+			return nil, err
+		}
+	}
+
+	settings, err := s.BillingSettings(ctx, tenantID)
+	if err != nil {
+		return nil, err
+	}
+
+	settings.Provider = providerName
+	if err := s.saveBillingSettings(ctx, tenantID, settings); err != nil {
+		return nil, err
+	}
+
+	logger.Info("Billing provider selected", "tenant_id", tenantID, "provider", providerName)
+
+	return s.BillingProviders(ctx, tenantID)
+}
+
+// saveBillingSettings stores the billing settings of a tenant
+func (s *Service) saveBillingSettings(ctx context.Context, tenantID int64, settings *models.BillingSettings) error {
+	raw, err := s.tenantRepo.GetSettings(ctx, tenantID)
+	if err != nil {
+		return err
+	}
+
+	// Other keys of the settings document are kept as they are
+	document := map[string]json.RawMessage{}
+	if len(raw) > 0 {
+		if err := json.Unmarshal(raw, &document); err != nil {
+			return fmt.Errorf("failed to parse tenant settings: %w", err)
+		}
+	}
+
+	value, err := json.Marshal(settings)
+	if err != nil {
+		return fmt.Errorf("failed to encode billing settings: %w", err)
+	}
+	document[models.BillingSettingsKey] = value
+
+	updated, err := json.Marshal(document)
+	if err != nil {
+		return fmt.Errorf("failed to encode tenant settings: %w", err)
+	}
+
+	return s.tenantRepo.UpdateSettings(ctx, tenantID, updated)
+}
+
+// resolveProvider returns the requested provider, the one the tenant chose
+// or the platform default
+func (s *Service) resolveProvider(ctx context.Context, tenantID int64, requested string) (billing.BillingProvider, error) {
+	if requested != "" {
+		return s.provider(requested)
+	}
+
+	settings, err := s.BillingSettings(ctx, tenantID)
+	if err != nil {
+		return nil, err
+	}
+	if settings.Provider != "" {
+		if provider, err := s.providers.Get(settings.Provider); err == nil {
+			return provider, nil
+		}
+		logger.Warn("Billing provider of tenant is not configured, using default", "tenant_id", tenantID, "provider", settings.Provider)
+	}
+
+	return s.provider(s.providers.Default())
+}
+
+// provider returns a configured provider by name
+func (s *Service) provider(name string) (billing.BillingProvider, error) {
+	provider, err := s.providers.Get(name)
+	if err != nil {
+		return nil, domainErrors.ErrUnknownBillingProvider
+	}
+	return provider, nil
+}
+
+// setTenantPlan moves a tenant to the tier of a plan version and keeps its
+// limits, so later versions don't change them. Without a plan the tenant
+// moves to Free.
+func (s *Service) setTenantPlan(ctx context.Context, tenantID int64, plan *models.SubscriptionPlan) error {
 	tenant, err := s.tenantRepo.GetByID(ctx, tenantID)
 	if err != nil {
 		return fmt.Errorf("failed to get tenant: %w", err)
 	}
 
 	tenant.Tier = models.TierFree
+	tenant.PlanLimits = nil
+	if plan != nil && plan.Tier != models.TierFree {
+		limits := plan.Limits
+		tenant.Tier = plan.Tier
+		tenant.PlanLimits = &limits
+	}
 	if err := s.tenantRepo.Update(ctx, tenant); err != nil {
-		return fmt.Errorf("failed to downgrade tenant: %w", err)
+		return fmt.Errorf("failed to update tenant tier: %w", err)
 	}
-
-	logger.Info("Subscription cancelled", "subscription_id", subscription.ID, "tenant_id", tenantID)
-
 	return nil
 }
 
-// HandleWebhookEvent processes PayPal webhook events
-func (s *Service) HandleWebhookEvent(ctx context.Context, event *WebhookEvent) error {
-	logger.Info("Processing PayPal webhook", "event_type", event.EventType, "resource_id", event.Resource.ID)
-
-	switch event.EventType {
-	case "BILLING.SUBSCRIPTION.ACTIVATED":
-		return s.handleSubscriptionActivated(ctx, event)
-	case "BILLING.SUBSCRIPTION.UPDATED":
-		return s.handleSubscriptionUpdated(ctx, event)
-	case "BILLING.SUBSCRIPTION.CANCELLED":
-		return s.handleSubscriptionCancelled(ctx, event)
-	case "BILLING.SUBSCRIPTION.SUSPENDED":
-		return s.handleSubscriptionSuspended(ctx, event)
-	case "BILLING.SUBSCRIPTION.EXPIRED":
-		return s.handleSubscriptionExpired(ctx, event)
-	case "PAYMENT.SALE.COMPLETED":
-		return s.handlePaymentCompleted(ctx, event)
+// HandleWebhookEvent processes a webhook event of a provider
+func (s *Service) HandleWebhookEvent(ctx context.Context, provider billing.BillingProvider, event *billing.WebhookEvent) error {
+	logger.Info("Processing billing webhook", "provider", provider.Name(), "event_type", event.RawType,
+		"subscription_id", event.SubscriptionID)
+
+	switch event.Type {
+	case billing.EventCheckoutCompleted, billing.EventSubscriptionActivated:
+		return s.handleSubscriptionActivated(ctx, provider, event)
+	case billing.EventSubscriptionUpdated:
+		return s.handleSubscriptionUpdated(ctx, provider, event)
+	case billing.EventSubscriptionCancelled:
+		return s.handleSubscriptionEnded(ctx, provider, event, models.SubscriptionStatusCancelled)
+	case billing.EventSubscriptionSuspended:
+		return s.handleSubscriptionSuspended(ctx, provider, event)
+	case billing.EventSubscriptionExpired:
+		return s.handleSubscriptionEnded(ctx, provider, event, models.SubscriptionStatusExpired)
+	case billing.EventPaymentCompleted:
+		return s.handlePaymentCompleted(ctx, provider, event)
+	case billing.EventPaymentFailed:
+		return s.handlePaymentFailed(ctx, provider, event)
 	default:
-		logger.Debug("Unhandled webhook event type", "event_type", event.EventType)
+		logger.Debug("Unhandled webhook event type", "provider", provider.Name(), "event_type", event.RawType)
 		return nil
 	}
 }
 
-// getTierFromPlanID maps plan ID to subscription tier
-func (s *Service) getTierFromPlanID(planID string) models.SubscriptionTier {
-	switch planID {
-	case "PLAN_BASIC_MONTHLY", "PLAN_BASIC_YEARLY":
-		return models.TierBasic
-	case "PLAN_PRO_MONTHLY", "PLAN_PRO_YEARLY":
-		return models.TierPro
-	case "PLAN_ENTERPRISE":
-		return models.TierEnterprise
-	default:
-		return models.TierFree
-	}
-}
-
-// getPayPalPlanID returns the PayPal plan ID for a given plan and billing cycle
-func (s *Service) getPayPalPlanID(planID string, billingCycle models.BillingCycle) string {
-	// In production, these should come from config/environment
-	// For now, return placeholder IDs
-	switch planID {
-	case "PLAN_BASIC_MONTHLY":
-		return "P-BASIC-MONTHLY"
-	case "PLAN_BASIC_YEARLY":
-		return "P-BASIC-YEARLY"
-	case "PLAN_PRO_MONTHLY":
-		return "P-PRO-MONTHLY"
-	case "PLAN_PRO_YEARLY":
-		return "P-PRO-YEARLY"
-	case "PLAN_ENTERPRISE":
-		return "P-ENTERPRISE"
-	default:
-		return ""
+// planTier returns the tier of a plan, Free for unknown plans
+func (s *Service) planTier(planID string) models.SubscriptionTier {
+	if plan := s.catalog.Plan(planID); plan != nil {
+		return plan.Tier
+	}
+	return models.TierFree
+}
+
+// planName returns the name of the plan version of a subscription, its plan
+// ID for unknown plans
+func (s *Service) planName(subscription *models.Subscription) string {
+	if plan := s.catalog.Version(subscription.PlanID, subscription.PlanVersion); plan != nil {
+		return plan.Name
+	}
+	return subscription.PlanID
+}
+
+// findSubscription returns the subscription of a webhook event, by its
+// provider subscription or the checkout it was started with
+func (s *Service) findSubscription(ctx context.Context, provider billing.BillingProvider, event *billing.WebhookEvent) (*models.Subscription, error) {
+	if event.SubscriptionID != "" {
+		subscription, err := s.subscriptionRepo.GetByProviderSubscriptionID(ctx, provider.Name(), event.SubscriptionID)
+		if err != domainErrors.ErrNotFound {
+			return subscription, err
+		}
 	}
+	if event.CheckoutID != "" {
+		return s.subscriptionRepo.GetByProviderCheckoutID(ctx, provider.Name(), event.CheckoutID)
+	}
+	return nil, domainErrors.ErrNotFound
 }
 
 // handleSubscriptionActivated handles subscription activation webhook
-func (s *Service) handleSubscriptionActivated(ctx context.Context, event *WebhookEvent) error {
-	return s.ActivateSubscription(ctx, event.Resource.ID)
+func (s *Service) handleSubscriptionActivated(ctx context.Context, provider billing.BillingProvider, event *billing.WebhookEvent) error {
+	subscription, err := s.findSubscription(ctx, provider, event)
+	if err != nil {
+		return fmt.Errorf("subscription not found: %w", err)
+	}
+
+	info, err := provider.GetSubscription(ctx, event.SubscriptionID)
+	if err != nil {
+		return fmt.Errorf("failed to get %s subscription: %w", provider.Name(), err)
+	}
+
+	return s.activate(ctx, subscription, info)
 }
 
 // handleSubscriptionUpdated handles subscription update webhook
-func (s *Service) handleSubscriptionUpdated(ctx context.Context, event *WebhookEvent) error {
-	subscription, err := s.subscriptionRepo.GetByPayPalSubscriptionID(ctx, event.Resource.ID)
+func (s *Service) handleSubscriptionUpdated(ctx context.Context, provider billing.BillingProvider, event *billing.WebhookEvent) error {
+	subscription, err := s.findSubscription(ctx, provider, event)
 	if err != nil {
 		return fmt.Errorf("subscription not found: %w", err)
 	}
 
-	// Get updated details from PayPal
-	paypalSub, err := s.paypalClient.GetSubscription(event.Resource.ID)
+	// Get updated details from the provider
+	info, err := provider.GetSubscription(ctx, event.SubscriptionID)
 	if err != nil {
-		return fmt.Errorf("failed to get PayPal subscription: %w", err)
+		return fmt.Errorf("failed to get %s subscription: %w", provider.Name(), err)
+	}
+
+	if err := s.applyProviderStatus(ctx, subscription, info.Status); err != nil {
+		return err
+	}
+	if info.CurrentPeriodStart != nil && info.CurrentPeriodEnd != nil {
+		subscription.CurrentPeriodStart = info.CurrentPeriodStart
+		subscription.CurrentPeriodEnd = info.CurrentPeriodEnd
 	}
+	if info.NextBillingDate != nil {
+		subscription.NextBillingDate = info.NextBillingDate
+	}
+	// PayPal reports a subscription cancelled at the end of the period as suspended
+	subscription.CancelAtPeriodEnd = info.CancelAtPeriodEnd || (subscription.CancelAtPeriodEnd && info.Status == billing.StatusSuspended)
 
-	// Update next billing date
-	if paypalSub.BillingInfo != nil && paypalSub.BillingInfo.NextBillingTime != "" {
-		t, _ := time.Parse(time.RFC3339, paypalSub.BillingInfo.NextBillingTime)
-		subscription.NextBillingDate = &t
+	// A scheduled downgrade is billed by the provider already, the tier
+	// changes at the end of the period
+	if subscription.ScheduledPlanID != nil && info.PlanID == *subscription.ScheduledPlanID {
+		return s.subscriptionRepo.Update(ctx, subscription)
+	}
+
+	// A plan change approved at the provider
+	if info.PlanID != "" && info.PlanID != subscription.PlanID {
+		return s.applyPlan(ctx, subscription, info.PlanID, info.ProviderPlanID)
 	}
 
 	return s.subscriptionRepo.Update(ctx, subscription)
 }
 
-// handleSubscriptionCancelled handles subscription cancellation webhook
-func (s *Service) handleSubscriptionCancelled(ctx context.Context, event *WebhookEvent) error {
-	subscription, err := s.subscriptionRepo.GetByPayPalSubscriptionID(ctx, event.Resource.ID)
+// handleSubscriptionEnded handles subscription cancellation and expiration webhooks
+func (s *Service) handleSubscriptionEnded(ctx context.Context, provider billing.BillingProvider, event *billing.WebhookEvent, status models.SubscriptionStatus) error {
+	subscription, err := s.findSubscription(ctx, provider, event)
 	if err != nil {
 		return fmt.Errorf("subscription not found: %w", err)
 	}
 
-	now := time.Now()
-	subscription.Status = models.SubscriptionStatusCancelled
-	subscription.CancelledAt = &now
+	subscription.Status = status
+	if status == models.SubscriptionStatusCancelled && subscription.CancelledAt == nil {
+		now := time.Now()
+		subscription.CancelledAt = &now
+	}
+
+	// The provider gave up on an overdue payment, dunning ends with the
+	// downgrade below
+	restricted := subscription.DunningStage == models.DunningStageRestricted
+	inDunning := subscription.InDunning()
+	if inDunning {
+		subscription.DunningStage = models.DunningStageDowngraded
+	}
 
 	if err := s.subscriptionRepo.Update(ctx, subscription); err != nil {
 		return err
 	}
 
-	// Downgrade tenant
-	tenant, _ := s.tenantRepo.GetByID(ctx, subscription.TenantID)
-	if tenant != nil {
-		tenant.Tier = models.TierFree
-		s.tenantRepo.Update(ctx, tenant)
+	if restricted {
+		if err := s.tenantRepo.UpdateBillingRestricted(ctx, subscription.TenantID, false); err != nil {
+			return fmt.Errorf("failed to lift billing restriction: %w", err)
+		}
+	}
+	if inDunning {
+		s.auditDunning(ctx, subscription, models.AuditActionDunningDowngraded, map[string]interface{}{
+			"reason":         "provider_" + string(status),
+			"past_due_since": subscription.PastDueSince,
+			"plan_id":        subscription.PlanID,
+		})
 	}
 
-	return nil
+	// Downgrade tenant, unless another subscription replaced this one
+	if active, err := s.subscriptionRepo.GetActiveSubscription(ctx, subscription.TenantID); err == nil && active.ID != subscription.ID {
+		return nil
+	}
+	return s.setTenantPlan(ctx, subscription.TenantID, nil)
 }
 
 // handleSubscriptionSuspended handles subscription suspension webhook
-func (s *Service) handleSubscriptionSuspended(ctx context.Context, event *WebhookEvent) error {
-	subscription, err := s.subscriptionRepo.GetByPayPalSubscriptionID(ctx, event.Resource.ID)
+func (s *Service) handleSubscriptionSuspended(ctx context.Context, provider billing.BillingProvider, event *billing.WebhookEvent) error {
+	subscription, err := s.findSubscription(ctx, provider, event)
 	if err != nil {
 		return fmt.Errorf("subscription not found: %w", err)
 	}
 
+	// The owner paused it, or cancelled it at the end of the period (PayPal
+	// suspends it until then)
+	if subscription.Status == models.SubscriptionStatusPaused || subscription.CancelAtPeriodEnd {
+		return nil
+	}
+
+	// Providers suspend subscriptions whose payments failed, with dunning the
+	// tenant keeps access until the grace period is over
+	if s.dunning != nil {
+		return s.startDunning(ctx, subscription, "subscription_suspended")
+	}
+
 	subscription.Status = models.SubscriptionStatusSuspended
 	return s.subscriptionRepo.Update(ctx, subscription)
 }
 
-// handleSubscriptionExpired handles subscription expiration webhook
-func (s *Service) handleSubscriptionExpired(ctx context.Context, event *WebhookEvent) error {
-	subscription, err := s.subscriptionRepo.GetByPayPalSubscriptionID(ctx, event.Resource.ID)
+// handlePaymentCompleted handles successful payment webhook. The payment ends
+// dunning, it's invoiced and the billing managers get a receipt.
+func (s *Service) handlePaymentCompleted(ctx context.Context, provider billing.BillingProvider, event *billing.WebhookEvent) error {
+	logger.Info("Payment completed", "provider", provider.Name(), "subscription_id", event.SubscriptionID,
+		"amount", event.Amount, "currency", event.Currency)
+
+	invoiced := s.invoiceRepo != nil && event.PaymentID != ""
+	if s.dunning == nil && !invoiced {
+		return nil
+	}
+
+	subscription, err := s.findSubscription(ctx, provider, event)
 	if err != nil {
 		return fmt.Errorf("subscription not found: %w", err)
 	}
 
-	subscription.Status = models.SubscriptionStatusExpired
-
-	if err := s.subscriptionRepo.Update(ctx, subscription); err != nil {
+	if err := s.recoverDunning(ctx, subscription, "payment_completed"); err != nil {
 		return err
 	}
+	if !invoiced {
+		return nil
+	}
 
-	// Downgrade tenant
-	tenant, _ := s.tenantRepo.GetByID(ctx, subscription.TenantID)
-	if tenant != nil {
-		tenant.Tier = models.TierFree
-		s.tenantRepo.Update(ctx, tenant)
+	invoice, err := s.createInvoice(ctx, provider.Name(), subscription, event)
+	if err == domainErrors.ErrConflict {
+		// Delivered again, the payment is invoiced already
+		return nil
+	}
+	if err != nil {
+		return err
 	}
 
+	s.sendReceipt(ctx, subscription, invoice)
 	return nil
 }
 
-// handlePaymentCompleted handles successful payment webhook
-func (s *Service) handlePaymentCompleted(ctx context.Context, event *WebhookEvent) error {
-	// Log successful payment for accounting/monitoring
-	logger.Info("Payment completed", "billing_agreement_id", event.Resource.BillingAgreementID, "amount", event.Resource.Amount)
-	return nil
+// handlePaymentFailed handles failed payment webhook, the subscription starts
+// dunning
+func (s *Service) handlePaymentFailed(ctx context.Context, provider billing.BillingProvider, event *billing.WebhookEvent) error {
+	logger.Warn("Payment failed", "provider", provider.Name(), "subscription_id", event.SubscriptionID,
+		"amount", event.Amount, "currency", event.Currency)
+
+	if s.dunning == nil {
+		return nil
+	}
+
+	subscription, err := s.findSubscription(ctx, provider, event)
+	if err != nil {
+		return fmt.Errorf("subscription not found: %w", err)
+	}
+
+	return s.startDunning(ctx, subscription, "payment_failed")
 }
 
 // UpgradeResponse represents the response for an upgrade request
 type UpgradeResponse struct {
-	SubscriptionID    int64                    `json:"subscription_id"`
-	PayPalApprovalURL string                   `json:"paypal_approval_url"`
-	Plan              *models.SubscriptionPlan `json:"plan"`
-	Tier              models.SubscriptionTier  `json:"tier"`
-	Amount            float64                  `json:"amount"`
-	Currency          string                   `json:"currency"`
-	BillingCycle      models.BillingCycle      `json:"billing_cycle"`
-}
-
-// WebhookEvent represents a PayPal webhook event
-type WebhookEvent struct {
-	ID         string             `json:"id"`
-	EventType  string             `json:"event_type"`
-	CreateTime string             `json:"create_time"`
-	Resource   WebhookResource    `json:"resource"`
-}
-
-// WebhookResource represents the resource in a webhook event
-type WebhookResource struct {
-	ID                  string  `json:"id"`
-	Status              string  `json:"status"`
-	BillingAgreementID  string  `json:"billing_agreement_id"`
-	Amount              *Amount `json:"amount,omitempty"`
-}
-
-// Amount represents payment amount
-type Amount struct {
-	Total    string `json:"total"`
-	Currency string `json:"currency"`
+	SubscriptionID int64                    `json:"subscription_id"`
+	Provider       string                   `json:"provider"`
+	CheckoutID     string                   `json:"checkout_id"`
+	ApprovalURL    string                   `json:"approval_url"`
+	Plan           *models.SubscriptionPlan `json:"plan"`
+	Tier           models.SubscriptionTier  `json:"tier"`
+	Amount         float64                  `json:"amount"`
+	Discount       float64                  `json:"discount"` // coupon discount per billing period
+	Currency       string                   `json:"currency"`
+	BillingCycle   models.BillingCycle      `json:"billing_cycle"`
+}
+
+// PlanChangeResponse represents the response for a plan change
+type PlanChangeResponse struct {
+	ApprovalURL  string                   `json:"approval_url,omitempty"` // set when the change waits for approval
+	Subscription *models.Subscription     `json:"subscription"`
+	Plan         *models.SubscriptionPlan `json:"plan"`
+	Tier         models.SubscriptionTier  `json:"tier"`
+	Scheduled    bool                     `json:"scheduled"` // the change takes effect at the end of the period
+	EffectiveAt  time.Time                `json:"effective_at"`
+	Proration    *models.Proration        `json:"proration,omitempty"`
+	Impact       *models.DowngradeImpact  `json:"impact,omitempty"`
+}
+
+// BillingProvidersResponse lists the billing providers a tenant can choose from
+type BillingProvidersResponse struct {
+	Available []string `json:"available"`
+	Default   string   `json:"default"`
+	Selected  string   `json:"selected"`
+}
+
+// optionalString returns nil for an empty string
+func optionalString(value string) *string {
+	if value == "" {
+		return nil
+	}
+	return &value
+}
+
+// stringValue returns the value of an optional string
+func stringValue(value *string) string {
+	if value == nil {
+		return ""
+	}
+	return *value
 }
@@ -0,0 +1,209 @@
+package subscription
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"time"
+
+	domainErrors "github.com/yourusername/gin-collection-saas/internal/domain/errors"
+	"github.com/yourusername/gin-collection-saas/internal/domain/models"
+	"github.com/yourusername/gin-collection-saas/internal/infrastructure/external"
+	"github.com/yourusername/gin-collection-saas/pkg/logger"
+)
+
+// SetTrialDays sets the length of the trial of each plan, plans without an
+// entry have no trial
+func (s *Service) SetTrialDays(trialDays map[string]int) {
+	s.trialDays = trialDays
+}
+
+// SetTrialReminders sets how long before its end the billing managers are
+// reminded of a trial, reminders need notifications
+func (s *Service) SetTrialReminders(before time.Duration) {
+	s.trialReminderBefore = before
+}
+
+// StartTrial starts the trial of a plan. Trials need no payment method, the
+// tenant gets the plan's tier until the trial ends and falls back to Free
+// unless it subscribes in the meantime. Each tenant gets one trial.
+func (s *Service) StartTrial(ctx context.Context, tenantID int64, planID string) (*models.Subscription, error) {
+	plan := s.catalog.Plan(planID)
+	if plan == nil {
+		return nil, domainErrors.ErrInvalidInput
+	}
+	if !plan.Active {
+		return nil, domainErrors.ErrPlanNotAvailable
+	}
+
+	days := s.trialDays[planID]
+	if days <= 0 {
+		return nil, domainErrors.ErrTrialNotAvailable
+	}
+
+	subscriptions, err := s.subscriptionRepo.List(ctx, tenantID)
+	if err != nil {
+		return nil, err
+	}
+	for _, previous := range subscriptions {
+		if previous.TrialEndsAt != nil {
+			return nil, domainErrors.ErrTrialNotAvailable
+		}
+		switch previous.Status {
+		case models.SubscriptionStatusActive, models.SubscriptionStatusPastDue, models.SubscriptionStatusSuspended:
+			return nil, domainErrors.ErrTrialNotAvailable
+		}
+	}
+
+	now := s.clock()
+	trialEnd := now.AddDate(0, 0, days)
+	subscription := &models.Subscription{
+		TenantID:           tenantID,
+		PlanID:             planID,
+		PlanVersion:        plan.Version,
+		Status:             models.SubscriptionStatusTrialing,
+		BillingCycle:       models.BillingCycleMonthly,
+		CurrentPeriodStart: &now,
+		CurrentPeriodEnd:   &trialEnd,
+		TrialEndsAt:        &trialEnd,
+		Currency:           plan.Currency,
+	}
+
+	if err := s.subscriptionRepo.Create(ctx, subscription); err != nil {
+		return nil, fmt.Errorf("failed to create trial: %w", err)
+	}
+
+	if err := s.setTenantPlan(ctx, tenantID, plan); err != nil {
+		return nil, err
+	}
+
+	logger.Info("Trial started", "subscription_id", subscription.ID, "tenant_id", tenantID, "plan_id", planID,
+		"trial_ends_at", trialEnd)
+
+	return subscription, nil
+}
+
+// ProcessTrials ends the trials that are over and sends the reminders of
+// trials ending soon. It returns how many trials were ended.
+func (s *Service) ProcessTrials(ctx context.Context) (int, error) {
+	now := s.clock()
+	subscriptions, err := s.subscriptionRepo.ListTrialsEndingBefore(ctx, now.Add(s.trialReminderBefore))
+	if err != nil {
+		return 0, err
+	}
+
+	ended := 0
+	for _, subscription := range subscriptions {
+		if !subscription.TrialEndsAt.After(now) {
+			if err := s.endTrial(ctx, subscription); err != nil {
+				logger.Error("Failed to end trial", "subscription_id", subscription.ID, "error", err.Error())
+				continue
+			}
+			ended++
+			continue
+		}
+
+		if subscription.TrialReminderSentAt == nil && s.emailClient != nil {
+			s.sendTrialReminder(ctx, subscription, now)
+		}
+	}
+
+	if ended > 0 {
+		logger.Info("Trials ended", "count", ended)
+	}
+
+	return ended, nil
+}
+
+// endTrial expires a trial that ended without a subscription and moves the
+// tenant back to Free
+func (s *Service) endTrial(ctx context.Context, subscription *models.Subscription) error {
+	subscription.Status = models.SubscriptionStatusExpired
+	if err := s.subscriptionRepo.Update(ctx, subscription); err != nil {
+		return err
+	}
+
+	// A subscription started during the trial keeps its tier
+	if active, err := s.subscriptionRepo.GetActiveSubscription(ctx, subscription.TenantID); err == nil && active.ID != subscription.ID {
+		return nil
+	}
+
+	logger.Info("Trial ended without a subscription", "subscription_id", subscription.ID, "tenant_id", subscription.TenantID)
+	return s.setTenantPlan(ctx, subscription.TenantID, nil)
+}
+
+// sendTrialReminder emails the billing managers of the tenant that the trial
+// ends soon, failures are logged and retried with the next run
+func (s *Service) sendTrialReminder(ctx context.Context, subscription *models.Subscription, now time.Time) {
+	tenant, err := s.tenantRepo.GetByID(ctx, subscription.TenantID)
+	if err != nil {
+		logger.Error("Failed to get tenant of trial", "tenant_id", subscription.TenantID, "error", err.Error())
+		return
+	}
+
+	users, err := s.billingManagers(ctx, subscription.TenantID)
+	if err != nil {
+		logger.Error("Failed to list billing managers of trial", "tenant_id", subscription.TenantID, "error", err.Error())
+		return
+	}
+
+	planName := s.planName(subscription)
+
+	data := &external.TrialEndingData{
+		TenantName:  tenant.Name,
+		PlanName:    planName,
+		TrialEndsAt: subscription.TrialEndsAt.Format("02.01.2006"),
+		DaysLeft:    int(math.Ceil(subscription.TrialEndsAt.Sub(now).Hours() / 24)),
+		UpgradeLink: s.baseURL + "/subscription",
+	}
+
+	sent := 0
+	for _, user := range users {
+		recipient := *data
+		if user.FirstName != nil {
+			recipient.RecipientName = *user.FirstName
+		}
+		if err := s.emailClient.SendTrialEnding(user.Email, &recipient); err != nil {
+			logger.Error("Failed to send trial reminder", "tenant_id", subscription.TenantID, "user_id", user.ID, "error", err.Error())
+			continue
+		}
+		sent++
+	}
+	if sent == 0 {
+		return
+	}
+
+	subscription.TrialReminderSentAt = &now
+	if err := s.subscriptionRepo.Update(ctx, subscription); err != nil {
+		logger.Error("Failed to record trial reminder", "subscription_id", subscription.ID, "error", err.Error())
+		return
+	}
+
+	logger.Info("Trial reminder sent", "subscription_id", subscription.ID, "tenant_id", subscription.TenantID, "recipients", sent)
+}
+
+// StartTrialScheduler periodically ends trials that are over, sends
+// reminders and releases the coupons of expired checkouts until ctx is
+// cancelled. With a lock repository one instance runs it at a time.
+func (s *Service) StartTrialScheduler(ctx context.Context, interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				s.runExclusive(ctx, "subscription_trials", func() {
+					if _, err := s.ProcessTrials(ctx); err != nil {
+						logger.Error("Trial scheduler failed", "error", err.Error())
+					}
+					if _, err := s.ReleaseExpiredCoupons(ctx); err != nil {
+						logger.Error("Coupon scheduler failed", "error", err.Error())
+					}
+				})
+			}
+		}
+	}()
+}
@@ -0,0 +1,203 @@
+package subscription
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	domainErrors "github.com/yourusername/gin-collection-saas/internal/domain/errors"
+	"github.com/yourusername/gin-collection-saas/internal/domain/models"
+	"github.com/yourusername/gin-collection-saas/internal/domain/repositories"
+	"github.com/yourusername/gin-collection-saas/internal/infrastructure/billing"
+	"github.com/yourusername/gin-collection-saas/pkg/logger"
+)
+
+// webhookInProgressFor is how long a received event that wasn't processed
+// yet counts as in progress. Redeliveries within it are acknowledged without
+// processing, later ones take over.
+const webhookInProgressFor = 5 * time.Minute
+
+// SetWebhookEventRepo enables storing webhook events. Without it every
+// delivery is processed, including replays and redeliveries.
+func (s *Service) SetWebhookEventRepo(webhookEventRepo repositories.WebhookEventRepository) {
+	s.webhookEventRepo = webhookEventRepo
+}
+
+// ProcessWebhook verifies and processes a webhook delivery of a billing
+// provider. Events already processed are acknowledged without processing them
+// again, events older than the last processed event of their resource are skipped.
+func (s *Service) ProcessWebhook(ctx context.Context, providerName string, headers http.Header, body []byte) error {
+	provider, err := s.provider(providerName)
+	if err != nil {
+		return domainErrors.ErrNotFound
+	}
+
+	if err := provider.VerifyWebhook(ctx, headers, body); err != nil {
+		if errors.Is(err, billing.ErrInvalidSignature) {
+			logger.Warn("Rejected webhook with invalid signature", "provider", provider.Name())
+			return domainErrors.ErrInvalidWebhookSignature
+		}
+		return err
+	}
+
+	event, err := provider.ParseWebhook(body)
+	if err != nil {
+		return domainErrors.ErrInvalidInput
+	}
+
+	logger.Info("Received billing webhook", "provider", provider.Name(), "event_type", event.RawType, "event_id", event.ID)
+
+	if s.webhookEventRepo == nil {
+		return s.HandleWebhookEvent(ctx, provider, event)
+	}
+
+	stored, err := s.storeWebhookEvent(ctx, provider, event, body)
+	if err != nil || stored == nil {
+		return err
+	}
+
+	if stale, latest := s.isStaleWebhookEvent(ctx, stored); stale {
+		logger.Info("Skipping outdated webhook", "provider", provider.Name(), "event_id", event.ID, "resource_id", stored.ResourceID)
+		message := fmt.Sprintf("resource was already updated at %s", latest.Format(time.RFC3339))
+		return s.finishWebhookEvent(ctx, stored, models.WebhookEventStatusSkipped, &message)
+	}
+
+	return s.runWebhookEvent(ctx, provider, stored, event)
+}
+
+// storeWebhookEvent stores a received event. It returns nil if the event
+// was delivered before and must not be processed again.
+func (s *Service) storeWebhookEvent(ctx context.Context, provider billing.BillingProvider, event *billing.WebhookEvent, body []byte) (*models.WebhookEvent, error) {
+	resourceID := event.SubscriptionID
+	if resourceID == "" {
+		resourceID = event.CheckoutID
+	}
+
+	stored := &models.WebhookEvent{
+		Provider:          provider.Name(),
+		EventID:           event.ID,
+		EventType:         event.RawType,
+		ResourceID:        resourceID,
+		ResourceUpdatedAt: event.UpdatedAt,
+		Status:            models.WebhookEventStatusReceived,
+		Payload:           json.RawMessage(body),
+		ReceivedAt:        time.Now(),
+	}
+
+	err := s.webhookEventRepo.Create(ctx, stored)
+	if err == nil {
+		return stored, nil
+	}
+	if err != domainErrors.ErrConflict {
+		return nil, fmt.Errorf("failed to store webhook event: %w", err)
+	}
+
+	existing, err := s.webhookEventRepo.GetByEventID(ctx, provider.Name(), event.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get webhook event: %w", err)
+	}
+
+	switch {
+	case existing.Status == models.WebhookEventStatusProcessed, existing.Status == models.WebhookEventStatusSkipped:
+		logger.Info("Ignoring redelivered webhook", "provider", provider.Name(), "event_id", event.ID, "status", existing.Status)
+		return nil, nil
+	case existing.Status == models.WebhookEventStatusReceived && time.Since(existing.ReceivedAt) < webhookInProgressFor:
+		logger.Info("Ignoring webhook that is being processed", "provider", provider.Name(), "event_id", event.ID)
+		return nil, nil
+	}
+
+	// Failed before or abandoned while processing, providers retry until it succeeds
+	return existing, nil
+}
+
+// isStaleWebhookEvent reports whether a newer state of the event's resource
+// was already processed
+func (s *Service) isStaleWebhookEvent(ctx context.Context, event *models.WebhookEvent) (bool, *time.Time) {
+	if event.ResourceID == "" || event.ResourceUpdatedAt == nil {
+		return false, nil
+	}
+
+	latest, err := s.webhookEventRepo.LatestResourceUpdate(ctx, event.Provider, event.ResourceID)
+	if err != nil {
+		logger.Error("Failed to get latest resource update", "resource_id", event.ResourceID, "error", err.Error())
+		return false, nil
+	}
+	if latest == nil || !event.ResourceUpdatedAt.Before(*latest) {
+		return false, nil
+	}
+	return true, latest
+}
+
+// runWebhookEvent processes a stored event and records the outcome
+func (s *Service) runWebhookEvent(ctx context.Context, provider billing.BillingProvider, stored *models.WebhookEvent, event *billing.WebhookEvent) error {
+	stored.Attempts++
+
+	if err := s.HandleWebhookEvent(ctx, provider, event); err != nil {
+		message := err.Error()
+		if updateErr := s.finishWebhookEvent(ctx, stored, models.WebhookEventStatusFailed, &message); updateErr != nil {
+			logger.Error("Failed to record webhook failure", "event_id", stored.EventID, "error", updateErr.Error())
+		}
+		return err
+	}
+
+	return s.finishWebhookEvent(ctx, stored, models.WebhookEventStatusProcessed, nil)
+}
+
+// finishWebhookEvent records the outcome of processing an event
+func (s *Service) finishWebhookEvent(ctx context.Context, stored *models.WebhookEvent, status models.WebhookEventStatus, message *string) error {
+	now := time.Now()
+	stored.Status = status
+	stored.Error = message
+	stored.ProcessedAt = &now
+
+	if err := s.webhookEventRepo.Update(ctx, stored); err != nil {
+		return fmt.Errorf("failed to update webhook event: %w", err)
+	}
+	return nil
+}
+
+// ListWebhookEvents lists the stored webhook events for the admin area
+func (s *Service) ListWebhookEvents(ctx context.Context, filter *models.WebhookEventFilter) ([]*models.WebhookEvent, int, error) {
+	if s.webhookEventRepo == nil {
+		return nil, 0, domainErrors.ErrNotFound
+	}
+	return s.webhookEventRepo.List(ctx, filter)
+}
+
+// GetWebhookEvent retrieves a stored webhook event with its payload
+func (s *Service) GetWebhookEvent(ctx context.Context, id int64) (*models.WebhookEvent, error) {
+	if s.webhookEventRepo == nil {
+		return nil, domainErrors.ErrNotFound
+	}
+	return s.webhookEventRepo.GetByID(ctx, id)
+}
+
+// ReplayWebhookEvent processes a stored event again, whatever its status.
+// The signature was verified when it was received, the resource update time
+// isn't checked as an admin replaying an event wants it applied.
+func (s *Service) ReplayWebhookEvent(ctx context.Context, id int64) (*models.WebhookEvent, error) {
+	stored, err := s.GetWebhookEvent(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	provider, err := s.provider(stored.Provider)
+	if err != nil {
+		return nil, err
+	}
+
+	event, err := provider.ParseWebhook(stored.Payload)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode webhook payload: %w", err)
+	}
+
+	logger.Info("Replaying webhook", "provider", stored.Provider, "event_id", stored.EventID, "event_type", stored.EventType)
+
+	if err := s.runWebhookEvent(ctx, provider, stored, event); err != nil {
+		logger.Error("Replayed webhook failed", "event_id", stored.EventID, "error", err.Error())
+	}
+	return stored, nil
+}
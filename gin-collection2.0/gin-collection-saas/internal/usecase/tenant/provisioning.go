@@ -5,32 +5,37 @@ import (
 	"database/sql"
 	"fmt"
 
+	domainErrors "github.com/yourusername/gin-collection-saas/internal/domain/errors"
 	"github.com/yourusername/gin-collection-saas/internal/domain/models"
 	"github.com/yourusername/gin-collection-saas/internal/domain/repositories"
+	"github.com/yourusername/gin-collection-saas/internal/usecase/entitlement"
 	"github.com/yourusername/gin-collection-saas/pkg/logger"
 )
 
 // ProvisioningService handles Enterprise database provisioning
 type ProvisioningService struct {
-	db         *sql.DB
-	tenantRepo repositories.TenantRepository
-	dbHost     string
-	dbUser     string
-	dbPassword string
+	db           *sql.DB
+	tenantRepo   repositories.TenantRepository
+	entitlements *entitlement.Service
+	dbHost       string
+	dbUser       string
+	dbPassword   string
 }
 
 // NewProvisioningService creates a new provisioning service
 func NewProvisioningService(
 	db *sql.DB,
 	tenantRepo repositories.TenantRepository,
+	entitlements *entitlement.Service,
 	dbHost, dbUser, dbPassword string,
 ) *ProvisioningService {
 	return &ProvisioningService{
-		db:         db,
-		tenantRepo: tenantRepo,
-		dbHost:     dbHost,
-		dbUser:     dbUser,
-		dbPassword: dbPassword,
+		db:           db,
+		tenantRepo:   tenantRepo,
+		entitlements: entitlements,
+		dbHost:       dbHost,
+		dbUser:       dbUser,
+		dbPassword:   dbPassword,
 	}
 }
 
@@ -44,9 +49,9 @@ func (s *ProvisioningService) ProvisionEnterpriseDatabase(ctx context.Context, t
 		return fmt.Errorf("failed to get tenant: %w", err)
 	}
 
-	// Verify tenant is Enterprise
-	if tenant.Tier != "enterprise" {
-		return fmt.Errorf("tenant is not Enterprise tier")
+	// Verify the plan includes a dedicated database
+	if !s.entitlements.Included(tenant, models.FeatureDedicatedDatabase) {
+		return fmt.Errorf("plan does not include a dedicated database: %w", domainErrors.ErrFeatureNotAvailable)
 	}
 
 	// Check if already provisioned
@@ -108,9 +113,9 @@ func (s *ProvisioningService) MigrateTenantToEnterprise(ctx context.Context, ten
 		return fmt.Errorf("failed to get tenant: %w", err)
 	}
 
-	// Verify tenant is Enterprise
-	if tenant.Tier != "enterprise" {
-		return fmt.Errorf("tenant is not Enterprise tier")
+	// Verify the plan includes a dedicated database
+	if !s.entitlements.Included(tenant, models.FeatureDedicatedDatabase) {
+		return fmt.Errorf("plan does not include a dedicated database: %w", domainErrors.ErrFeatureNotAvailable)
 	}
 
 	// Check if database is provisioned
@@ -0,0 +1,297 @@
+package twofactor
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/yourusername/gin-collection-saas/internal/domain/errors"
+	"github.com/yourusername/gin-collection-saas/internal/domain/models"
+	"github.com/yourusername/gin-collection-saas/internal/domain/repositories"
+	"github.com/yourusername/gin-collection-saas/pkg/logger"
+	"github.com/yourusername/gin-collection-saas/pkg/utils"
+)
+
+// Skew is the number of time steps accepted before and after the current one
+const Skew = 1
+
+// recoveryCodeBytes is the entropy of a recovery code (printed as 10 hex digits)
+const recoveryCodeBytes = 5
+
+// Service handles TOTP enrollment and verification, recovery codes and
+// trusted devices for tenant users and platform admins
+type Service struct {
+	repo   repositories.TwoFactorRepository
+	issuer string
+}
+
+// NewService creates a new two-factor service. The issuer is shown in
+// authenticator apps.
+func NewService(repo repositories.TwoFactorRepository, issuer string) *Service {
+	return &Service{
+		repo:   repo,
+		issuer: issuer,
+	}
+}
+
+// IsEnabled checks if a subject has a confirmed second factor
+func (s *Service) IsEnabled(ctx context.Context, subjectType string, subjectID int64) (bool, error) {
+	credential, err := s.getCredential(ctx, subjectType, subjectID)
+	if err != nil {
+		return false, err
+	}
+
+	return credential != nil && credential.IsEnabled(), nil
+}
+
+// Status returns the 2FA setup of a subject
+func (s *Service) Status(ctx context.Context, subjectType string, subjectID int64) (*models.TwoFactorStatus, error) {
+	status := &models.TwoFactorStatus{}
+
+	credential, err := s.getCredential(ctx, subjectType, subjectID)
+	if err != nil {
+		return nil, err
+	}
+	if credential == nil || !credential.IsEnabled() {
+		return status, nil
+	}
+
+	remaining, err := s.repo.CountRecoveryCodes(ctx, subjectType, subjectID)
+	if err != nil {
+		return nil, err
+	}
+
+	status.Enabled = true
+	status.ConfirmedAt = credential.ConfirmedAt
+	status.RecoveryCodesRemaining = remaining
+
+	return status, nil
+}
+
+// BeginEnrollment creates a new secret for a subject. It becomes active once
+// a code is confirmed with ConfirmEnrollment.
+func (s *Service) BeginEnrollment(ctx context.Context, subjectType string, subjectID int64, accountName string) (*models.TwoFactorEnrollment, error) {
+	enabled, err := s.IsEnabled(ctx, subjectType, subjectID)
+	if err != nil {
+		return nil, err
+	}
+	if enabled {
+		return nil, errors.ErrTwoFactorAlreadyEnabled
+	}
+
+	secret, err := utils.GenerateTOTPSecret()
+	if err != nil {
+		return nil, err
+	}
+
+	credential := &models.TwoFactorCredential{
+		SubjectType: subjectType,
+		SubjectID:   subjectID,
+		Secret:      secret,
+	}
+	if err := s.repo.SaveCredential(ctx, credential); err != nil {
+		return nil, err
+	}
+
+	logger.Info("Two-factor enrollment started", "subject_type", subjectType, "subject_id", subjectID)
+
+	return &models.TwoFactorEnrollment{
+		Secret:     secret,
+		OTPAuthURI: utils.TOTPURI(s.issuer, accountName, secret),
+	}, nil
+}
+
+// ConfirmEnrollment activates a pending secret with a first valid code and
+// returns the recovery codes. They are only shown once.
+func (s *Service) ConfirmEnrollment(ctx context.Context, subjectType string, subjectID int64, code string) ([]string, error) {
+	credential, err := s.getCredential(ctx, subjectType, subjectID)
+	if err != nil {
+		return nil, err
+	}
+	if credential == nil {
+		return nil, errors.ErrTwoFactorNotEnabled
+	}
+	if credential.IsEnabled() {
+		return nil, errors.ErrTwoFactorAlreadyEnabled
+	}
+
+	if err := s.checkCode(ctx, credential, code); err != nil {
+		return nil, err
+	}
+
+	if err := s.repo.ConfirmCredential(ctx, credential.ID); err != nil {
+		return nil, err
+	}
+
+	codes, err := s.replaceRecoveryCodes(ctx, subjectType, subjectID)
+	if err != nil {
+		return nil, err
+	}
+
+	logger.Info("Two-factor authentication enabled", "subject_type", subjectType, "subject_id", subjectID)
+
+	return codes, nil
+}
+
+// Verify checks a TOTP code or, if no code is given, a one-time recovery code
+func (s *Service) Verify(ctx context.Context, subjectType string, subjectID int64, code, recoveryCode string) error {
+	credential, err := s.getCredential(ctx, subjectType, subjectID)
+	if err != nil {
+		return err
+	}
+	if credential == nil || !credential.IsEnabled() {
+		return errors.ErrTwoFactorNotEnabled
+	}
+
+	if code != "" {
+		return s.checkCode(ctx, credential, code)
+	}
+
+	if recoveryCode == "" {
+		return errors.ErrInvalidTwoFactorCode
+	}
+
+	used, err := s.repo.UseRecoveryCode(ctx, subjectType, subjectID, hashRecoveryCode(recoveryCode))
+	if err != nil {
+		return err
+	}
+	if !used {
+		return errors.ErrInvalidTwoFactorCode
+	}
+
+	logger.Info("Recovery code used", "subject_type", subjectType, "subject_id", subjectID)
+
+	return nil
+}
+
+// RegenerateRecoveryCodes replaces all recovery codes after verifying a TOTP code
+func (s *Service) RegenerateRecoveryCodes(ctx context.Context, subjectType string, subjectID int64, code string) ([]string, error) {
+	if err := s.Verify(ctx, subjectType, subjectID, code, ""); err != nil {
+		return nil, err
+	}
+
+	return s.replaceRecoveryCodes(ctx, subjectType, subjectID)
+}
+
+// Disable removes the second factor of a subject after verifying a TOTP or recovery code
+func (s *Service) Disable(ctx context.Context, subjectType string, subjectID int64, code, recoveryCode string) error {
+	if err := s.Verify(ctx, subjectType, subjectID, code, recoveryCode); err != nil {
+		return err
+	}
+
+	if err := s.repo.Delete(ctx, subjectType, subjectID); err != nil {
+		return err
+	}
+
+	logger.Info("Two-factor authentication disabled", "subject_type", subjectType, "subject_id", subjectID)
+
+	return nil
+}
+
+// TrustDevice remembers a device so it can skip the second factor for
+// TrustedDeviceExpiry. Returns the token to be stored on the device.
+func (s *Service) TrustDevice(ctx context.Context, subjectType string, subjectID int64, userAgent string) (string, error) {
+	token, err := utils.GenerateSecureToken(32)
+	if err != nil {
+		return "", fmt.Errorf("failed to generate device token: %w", err)
+	}
+
+	device := &models.TrustedDevice{
+		SubjectType: subjectType,
+		SubjectID:   subjectID,
+		TokenHash:   utils.HashToken(token),
+		ExpiresAt:   time.Now().Add(models.TrustedDeviceExpiry),
+	}
+	if userAgent != "" {
+		if len(userAgent) > 255 {
+			userAgent = userAgent[:255]
+		}
+		device.UserAgent = &userAgent
+	}
+
+	if err := s.repo.CreateTrustedDevice(ctx, device); err != nil {
+		return "", err
+	}
+
+	return token, nil
+}
+
+// IsTrustedDevice checks a device token. Errors count as untrusted.
+func (s *Service) IsTrustedDevice(ctx context.Context, subjectType string, subjectID int64, token string) bool {
+	if token == "" {
+		return false
+	}
+
+	trusted, err := s.repo.IsTrustedDevice(ctx, subjectType, subjectID, utils.HashToken(token))
+	if err != nil {
+		logger.Error("Failed to check trusted device", "error", err.Error(), "subject_id", subjectID)
+		return false
+	}
+
+	return trusted
+}
+
+// ForgetDevices removes all trusted devices of a subject
+func (s *Service) ForgetDevices(ctx context.Context, subjectType string, subjectID int64) error {
+	return s.repo.DeleteTrustedDevices(ctx, subjectType, subjectID)
+}
+
+// getCredential returns the credential of a subject, or nil if there is none
+func (s *Service) getCredential(ctx context.Context, subjectType string, subjectID int64) (*models.TwoFactorCredential, error) {
+	credential, err := s.repo.GetCredential(ctx, subjectType, subjectID)
+	if err == errors.ErrNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return credential, nil
+}
+
+// checkCode validates a TOTP code and makes sure it isn't replayed
+func (s *Service) checkCode(ctx context.Context, credential *models.TwoFactorCredential, code string) error {
+	step, ok := utils.ValidateTOTP(credential.Secret, code, time.Now(), Skew)
+	if !ok {
+		return errors.ErrInvalidTwoFactorCode
+	}
+
+	fresh, err := s.repo.UseStep(ctx, credential.ID, step)
+	if err != nil {
+		return err
+	}
+	if !fresh {
+		logger.Warn("Replayed two-factor code", "subject_type", credential.SubjectType, "subject_id", credential.SubjectID)
+		return errors.ErrInvalidTwoFactorCode
+	}
+
+	return nil
+}
+
+// replaceRecoveryCodes generates new recovery codes and stores their hashes
+func (s *Service) replaceRecoveryCodes(ctx context.Context, subjectType string, subjectID int64) ([]string, error) {
+	codes := make([]string, models.RecoveryCodeCount)
+	hashes := make([]string, models.RecoveryCodeCount)
+
+	for i := range codes {
+		raw, err := utils.GenerateSecureToken(recoveryCodeBytes)
+		if err != nil {
+			return nil, fmt.Errorf("failed to generate recovery code: %w", err)
+		}
+		codes[i] = raw[:5] + "-" + raw[5:]
+		hashes[i] = hashRecoveryCode(codes[i])
+	}
+
+	if err := s.repo.ReplaceRecoveryCodes(ctx, subjectType, subjectID, hashes); err != nil {
+		return nil, err
+	}
+
+	return codes, nil
+}
+
+// hashRecoveryCode hashes a recovery code ignoring case, dashes and spaces
+func hashRecoveryCode(code string) string {
+	normalized := strings.ToLower(strings.NewReplacer("-", "", " ", "").Replace(code))
+	return utils.HashToken(normalized)
+}
@@ -0,0 +1,367 @@
+package usage
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	domainErrors "github.com/yourusername/gin-collection-saas/internal/domain/errors"
+	"github.com/yourusername/gin-collection-saas/internal/domain/models"
+	"github.com/yourusername/gin-collection-saas/internal/domain/repositories"
+	"github.com/yourusername/gin-collection-saas/pkg/logger"
+)
+
+// MaxHistoryPeriods is the most earlier billing periods a usage report shows
+const MaxHistoryPeriods = 24
+
+// Service meters the usage of tenants per billing period and manages the
+// limits platform admins set for single tenants
+type Service struct {
+	usageRepo        repositories.UsageMetricsRepository
+	tenantRepo       repositories.TenantRepository
+	subscriptionRepo repositories.SubscriptionRepository
+	storageUsageRepo repositories.StorageUsageRepository
+	auditLogRepo     repositories.AuditLogRepository
+}
+
+// NewService creates a new usage service
+func NewService(
+	usageRepo repositories.UsageMetricsRepository,
+	tenantRepo repositories.TenantRepository,
+	subscriptionRepo repositories.SubscriptionRepository,
+	storageUsageRepo repositories.StorageUsageRepository,
+) *Service {
+	return &Service{
+		usageRepo:        usageRepo,
+		tenantRepo:       tenantRepo,
+		subscriptionRepo: subscriptionRepo,
+		storageUsageRepo: storageUsageRepo,
+	}
+}
+
+// SetAuditLogRepository records limit overrides in the audit log of the tenant
+func (s *Service) SetAuditLogRepository(auditLogRepo repositories.AuditLogRepository) {
+	s.auditLogRepo = auditLogRepo
+}
+
+// Period returns the current billing period of a tenant: that of its
+// subscription, or else the calendar month. Periods are whole days (UTC).
+func (s *Service) Period(ctx context.Context, tenantID int64) (time.Time, time.Time, error) {
+	now := time.Now().UTC()
+
+	subscription, err := s.subscriptionRepo.GetByTenantID(ctx, tenantID)
+	if err != nil && err != domainErrors.ErrNotFound {
+		return time.Time{}, time.Time{}, fmt.Errorf("failed to get subscription: %w", err)
+	}
+	if subscription != nil && subscription.CurrentPeriodStart != nil && subscription.CurrentPeriodEnd != nil &&
+		!now.Before(*subscription.CurrentPeriodStart) && now.Before(*subscription.CurrentPeriodEnd) {
+		switch subscription.Status {
+		case models.SubscriptionStatusActive, models.SubscriptionStatusTrialing, models.SubscriptionStatusPastDue:
+			return day(*subscription.CurrentPeriodStart), day(*subscription.CurrentPeriodEnd), nil
+		}
+	}
+
+	start := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+	return start, start.AddDate(0, 1, 0), nil
+}
+
+// day truncates a time to its day in UTC
+func day(t time.Time) time.Time {
+	t = t.UTC()
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+// Check returns the meter of a metered metric in the current billing period,
+// ErrUsageLimitReached with it if the tenant used up its limit. The meter is
+// nil if the metric is unlimited.
+func (s *Service) Check(ctx context.Context, tenant *models.Tenant, metric string) (*models.UsageMeter, error) {
+	limit := tenant.EnforcedLimits().MeteredLimit(metric)
+	if limit == nil {
+		return nil, nil
+	}
+
+	start, _, err := s.Period(ctx, tenant.ID)
+	if err != nil {
+		return nil, err
+	}
+	current, err := s.usageRepo.GetPeriodValue(ctx, tenant.ID, metric, start)
+	if err != nil {
+		return nil, err
+	}
+
+	meter := models.NewUsageMeter(metric, current, limit)
+	if meter.Reached {
+		return meter, domainErrors.ErrUsageLimitReached
+	}
+	return meter, nil
+}
+
+// Record counts usage of a metered metric in the current billing period
+func (s *Service) Record(ctx context.Context, tenant *models.Tenant, metric string, delta int) error {
+	start, end, err := s.Period(ctx, tenant.ID)
+	if err != nil {
+		return err
+	}
+
+	return s.usageRepo.Record(ctx, &models.UsageMetric{
+		TenantID:    tenant.ID,
+		MetricName:  metric,
+		LimitValue:  tenant.EnforcedLimits().MeteredLimit(metric),
+		PeriodStart: start,
+		PeriodEnd:   end,
+	}, delta)
+}
+
+// RecordStorage records the storage a tenant uses in the current billing
+// period, the period keeps its highest value. Returns the used MB.
+func (s *Service) RecordStorage(ctx context.Context, tenant *models.Tenant) (int, error) {
+	usage, err := s.storageUsageRepo.GetUsage(ctx, tenant.ID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get storage usage: %w", err)
+	}
+	usedMB := int((usage.BytesUsed + models.BytesPerMB - 1) / models.BytesPerMB)
+
+	start, end, err := s.Period(ctx, tenant.ID)
+	if err != nil {
+		return 0, err
+	}
+
+	err = s.usageRepo.RecordPeak(ctx, &models.UsageMetric{
+		TenantID:     tenant.ID,
+		MetricName:   models.MetricStorageMB,
+		CurrentValue: usedMB,
+		LimitValue:   tenant.EnforcedLimits().StorageLimitMB,
+		PeriodStart:  start,
+		PeriodEnd:    end,
+	})
+	if err != nil {
+		return 0, err
+	}
+	return usedMB, nil
+}
+
+// RecordAllStorage records the storage of all tenants, returns how many were recorded
+func (s *Service) RecordAllStorage(ctx context.Context) (int, error) {
+	tenantIDs, err := s.tenantRepo.ListIDs(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	recorded := 0
+	for _, tenantID := range tenantIDs {
+		tenant, err := s.tenantRepo.GetByID(ctx, tenantID)
+		if err != nil {
+			logger.Error("Failed to get tenant for storage metering", "tenant_id", tenantID, "error", err.Error())
+			continue
+		}
+		if _, err := s.RecordStorage(ctx, tenant); err != nil {
+			logger.Error("Failed to record storage usage", "tenant_id", tenantID, "error", err.Error())
+			continue
+		}
+		recorded++
+	}
+
+	return recorded, nil
+}
+
+// StartStorageScheduler periodically records the storage of all tenants
+// until ctx is cancelled
+func (s *Service) StartStorageScheduler(ctx context.Context, interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if _, err := s.RecordAllStorage(ctx); err != nil {
+					logger.Error("Storage metering scheduler failed", "error", err.Error())
+				}
+			}
+		}
+	}()
+}
+
+// Report returns the usage of a tenant in the current billing period against
+// its limits, with up to periods earlier periods
+func (s *Service) Report(ctx context.Context, tenant *models.Tenant, periods int) (*models.UsageReport, error) {
+	if periods < 0 {
+		periods = 0
+	}
+	if periods > MaxHistoryPeriods {
+		periods = MaxHistoryPeriods
+	}
+
+	storageMB, err := s.RecordStorage(ctx, tenant)
+	if err != nil {
+		return nil, err
+	}
+
+	start, end, err := s.Period(ctx, tenant.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Yearly periods are listed from the calendar months before them
+	metrics, err := s.usageRepo.ListSince(ctx, tenant.ID, start.AddDate(0, -periods, 0))
+	if err != nil {
+		return nil, err
+	}
+
+	limits := tenant.EnforcedLimits()
+	values := map[string]int{models.MetricStorageMB: storageMB}
+	history := []*models.UsagePeriod{}
+	byStart := map[time.Time]*models.UsagePeriod{}
+
+	for _, metric := range metrics {
+		if !isMetered(metric.MetricName) {
+			continue
+		}
+		periodStart := day(metric.PeriodStart)
+		if periodStart.Equal(start) {
+			if metric.MetricName != models.MetricStorageMB {
+				values[metric.MetricName] = metric.CurrentValue
+			}
+			continue
+		}
+
+		period, ok := byStart[periodStart]
+		if !ok {
+			if len(history) == periods {
+				continue
+			}
+			period = &models.UsagePeriod{PeriodStart: periodStart, PeriodEnd: day(metric.PeriodEnd), Meters: []*models.UsageMeter{}}
+			byStart[periodStart] = period
+			history = append(history, period)
+		}
+		period.Meters = append(period.Meters, models.NewUsageMeter(metric.MetricName, metric.CurrentValue, metric.LimitValue))
+	}
+
+	current := &models.UsagePeriod{PeriodStart: start, PeriodEnd: end, Meters: []*models.UsageMeter{}}
+	for _, metric := range models.MeteredMetrics {
+		meter := models.NewUsageMeter(metric, values[metric], limits.MeteredLimit(metric))
+		meter.Overridden = !tenant.BillingRestricted && tenant.LimitOverrides.Overrides(metric)
+		current.Meters = append(current.Meters, meter)
+	}
+
+	return &models.UsageReport{
+		Current:   current,
+		History:   history,
+		Limits:    limits,
+		Overrides: tenant.LimitOverrides,
+	}, nil
+}
+
+// isMetered reports whether a metric is metered per billing period
+func isMetered(metric string) bool {
+	for _, metered := range models.MeteredMetrics {
+		if metric == metered {
+			return true
+		}
+	}
+	return false
+}
+
+// GetTenantReport returns the usage report of a tenant by ID
+func (s *Service) GetTenantReport(ctx context.Context, tenantID int64, periods int) (*models.Tenant, *models.UsageReport, error) {
+	tenant, err := s.tenantRepo.GetByID(ctx, tenantID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	report, err := s.Report(ctx, tenant, periods)
+	if err != nil {
+		return nil, nil, err
+	}
+	return tenant, report, nil
+}
+
+// SetOverrides sets the limits of a tenant that differ from its plan, nil or
+// no limits remove them. adminID is the platform admin making the change.
+func (s *Service) SetOverrides(ctx context.Context, tenantID int64, overrides *models.LimitOverrides, adminID int64) (*models.Tenant, error) {
+	if err := validateOverrides(overrides); err != nil {
+		return nil, err
+	}
+	if overrides.IsEmpty() {
+		overrides = nil
+	}
+
+	tenant, err := s.tenantRepo.GetByID(ctx, tenantID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.tenantRepo.UpdateLimitOverrides(ctx, tenantID, overrides); err != nil {
+		return nil, err
+	}
+
+	previous := tenant.LimitOverrides
+	tenant.LimitOverrides = overrides
+
+	s.auditOverrides(ctx, tenant, previous, adminID)
+	logger.Info("Tenant limit overrides updated", "tenant_id", tenantID, "admin_id", adminID, "removed", overrides == nil)
+
+	return tenant, nil
+}
+
+// validateOverrides checks the limits of an override
+func validateOverrides(overrides *models.LimitOverrides) error {
+	if overrides == nil {
+		return nil
+	}
+
+	positive := map[string]*int{
+		"max_gins":         overrides.MaxGins,
+		"storage_limit_mb": overrides.StorageLimitMB,
+	}
+	for name, value := range positive {
+		if value != nil && *value != -1 && *value < 1 {
+			return fmt.Errorf("%w: %s must be at least 1 or -1 for unlimited", domainErrors.ErrInvalidInput, name)
+		}
+	}
+
+	counts := map[string]*int{
+		"max_photos_per_gin":            overrides.MaxPhotosPerGin,
+		"max_api_calls_per_period":      overrides.MaxAPICallsPerPeriod,
+		"max_ai_suggestions_per_period": overrides.MaxAISuggestionsPerPeriod,
+	}
+	for name, value := range counts {
+		if value != nil && *value < -1 {
+			return fmt.Errorf("%w: %s must be at least 0 or -1 for unlimited", domainErrors.ErrInvalidInput, name)
+		}
+	}
+
+	if overrides.APIRateLimit != nil && *overrides.APIRateLimit < 0 {
+		return fmt.Errorf("%w: api_rate_limit must be at least 0 (unlimited)", domainErrors.ErrInvalidInput)
+	}
+
+	return nil
+}
+
+// auditOverrides records a change of the limit overrides in the audit log of
+// the tenant. Platform admins aren't users of the tenant, so the entry has no user.
+func (s *Service) auditOverrides(ctx context.Context, tenant *models.Tenant, previous *models.LimitOverrides, adminID int64) {
+	if s.auditLogRepo == nil {
+		return
+	}
+
+	changesJSON, _ := json.Marshal(map[string]interface{}{
+		"before":            previous,
+		"after":             tenant.LimitOverrides,
+		"platform_admin_id": adminID,
+	})
+	changesStr := string(changesJSON)
+	auditLog := &models.AuditLog{
+		TenantID:   tenant.ID,
+		Action:     string(models.AuditActionOverrideLimits),
+		EntityType: string(models.EntityTypeTenant),
+		EntityID:   &tenant.ID,
+		Changes:    &changesStr,
+	}
+	if err := s.auditLogRepo.Create(ctx, auditLog); err != nil {
+		logger.Error("Failed to create audit log", "action", string(models.AuditActionOverrideLimits), "tenant_id", tenant.ID, "error", err.Error())
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"encoding/base64"
 	"encoding/json"
 	"fmt"
+	"time"
 
 	"golang.org/x/crypto/bcrypt"
 
@@ -13,16 +14,23 @@ import (
 	"github.com/yourusername/gin-collection-saas/internal/domain/models"
 	"github.com/yourusername/gin-collection-saas/internal/domain/repositories"
 	"github.com/yourusername/gin-collection-saas/internal/infrastructure/external"
+	"github.com/yourusername/gin-collection-saas/internal/usecase/emailverification"
+	"github.com/yourusername/gin-collection-saas/internal/usecase/entitlement"
 	"github.com/yourusername/gin-collection-saas/pkg/logger"
+	"github.com/yourusername/gin-collection-saas/pkg/utils"
 )
 
 // Service handles user management business logic (Enterprise feature)
 type Service struct {
-	userRepo      repositories.UserRepository
-	tenantRepo    repositories.TenantRepository
-	auditLogRepo  repositories.AuditLogRepository
-	emailClient   *external.EmailClient
-	baseURL       string
+	userRepo          repositories.UserRepository
+	tenantRepo        repositories.TenantRepository
+	auditLogRepo      repositories.AuditLogRepository
+	inviteRepo        repositories.InviteTokenRepository
+	entitlements      *entitlement.Service
+	emailVerification *emailverification.Service
+	loginAttempts     *utils.LoginAttemptTracker
+	emailClient       *external.EmailClient
+	baseURL           string
 }
 
 // NewService creates a new user management service
@@ -30,6 +38,8 @@ func NewService(
 	userRepo repositories.UserRepository,
 	tenantRepo repositories.TenantRepository,
 	auditLogRepo repositories.AuditLogRepository,
+	inviteRepo repositories.InviteTokenRepository,
+	entitlements *entitlement.Service,
 	emailClient *external.EmailClient,
 	baseURL string,
 ) *Service {
@@ -37,23 +47,47 @@ func NewService(
 		userRepo:     userRepo,
 		tenantRepo:   tenantRepo,
 		auditLogRepo: auditLogRepo,
+		inviteRepo:   inviteRepo,
+		entitlements: entitlements,
 		emailClient:  emailClient,
 		baseURL:      baseURL,
 	}
 }
 
+// SetEmailVerificationService sets the email verification service (optional
+// dependency). With it email changes by admins have to be confirmed by the user.
+func (s *Service) SetEmailVerificationService(svc *emailverification.Service) {
+	s.emailVerification = svc
+}
+
+// SetLoginAttemptTracker sets the tracker of failed logins (optional
+// dependency). With it admins see and lift account lockouts.
+func (s *Service) SetLoginAttemptTracker(tracker *utils.LoginAttemptTracker) {
+	s.loginAttempts = tracker
+}
+
+// requireMultiUser checks that the tenant may manage users. Changes by the
+// identity provider (SCIM) only need the plan to include it, so accounts can
+// still be deprovisioned while a payment is overdue.
+func (s *Service) requireMultiUser(tenant *models.Tenant, provisioned bool) error {
+	if provisioned && s.entitlements.Included(tenant, models.FeatureMultiUser) {
+		return nil
+	}
+	return s.entitlements.RequireFeature(tenant, models.FeatureMultiUser)
+}
+
 // ListUsers lists all users in a tenant (Enterprise only)
 func (s *Service) ListUsers(ctx context.Context, tenantID, requesterUserID int64) ([]*models.User, error) {
 	logger.Info("Listing users", "tenant_id", tenantID)
 
-	// Verify tenant is Enterprise
+	// Verify the plan includes multi-user support
 	tenant, err := s.tenantRepo.GetByID(ctx, tenantID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get tenant: %w", err)
 	}
 
-	if tenant.Tier != "enterprise" {
-		return nil, errors.ErrMultiUserNotAllowed
+	if err := s.requireMultiUser(tenant, false); err != nil {
+		return nil, err
 	}
 
 	// Get all users
@@ -62,6 +96,17 @@ func (s *Service) ListUsers(ctx context.Context, tenantID, requesterUserID int64
 		return nil, fmt.Errorf("failed to list users: %w", err)
 	}
 
+	// Show which accounts are locked after failed logins
+	if s.loginAttempts != nil {
+		now := time.Now()
+		for _, user := range users {
+			if lockedFor := s.loginAttempts.LockedFor(ctx, user.ID); lockedFor > 0 {
+				lockedUntil := now.Add(lockedFor)
+				user.LockedUntil = &lockedUntil
+			}
+		}
+	}
+
 	logger.Info("Users listed successfully", "tenant_id", tenantID, "count", len(users))
 
 	return users, nil
@@ -71,14 +116,14 @@ func (s *Service) ListUsers(ctx context.Context, tenantID, requesterUserID int64
 func (s *Service) InviteUser(ctx context.Context, tenantID, inviterUserID int64, email, firstName, lastName string, role models.UserRole) (*models.User, error) {
 	logger.Info("Inviting user", "tenant_id", tenantID, "email", email, "role", role)
 
-	// Verify tenant is Enterprise
+	// Verify the plan includes multi-user support
 	tenant, err := s.tenantRepo.GetByID(ctx, tenantID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get tenant: %w", err)
 	}
 
-	if tenant.Tier != "enterprise" {
-		return nil, errors.ErrMultiUserNotAllowed
+	if err := s.requireMultiUser(tenant, false); err != nil {
+		return nil, err
 	}
 
 	// Check if email already exists in tenant
@@ -87,7 +132,7 @@ func (s *Service) InviteUser(ctx context.Context, tenantID, inviterUserID int64,
 		return nil, errors.ErrEmailAlreadyExists
 	}
 
-	// Generate a temporary password (user should reset on first login)
+	// The user stays inactive with an unusable password until the invitation is accepted
 	tempPassword := generateTempPassword()
 	passwordHash, err := bcrypt.GenerateFromPassword([]byte(tempPassword), bcrypt.DefaultCost)
 	if err != nil {
@@ -102,11 +147,17 @@ func (s *Service) InviteUser(ctx context.Context, tenantID, inviterUserID int64,
 		FirstName:    &firstName,
 		LastName:     &lastName,
 		Role:         role,
-		IsActive:     true,
+		IsActive:     false,
 	}
 
-	if err := s.userRepo.Create(ctx, user); err != nil {
-		return nil, fmt.Errorf("failed to create user: %w", err)
+	invite, token, err := newInvite(user, inviterUserID)
+	if err != nil {
+		return nil, err
+	}
+
+	// The user is only created together with its invitation
+	if err := s.inviteRepo.CreateWithUser(ctx, user, invite); err != nil {
+		return nil, fmt.Errorf("failed to create invited user: %w", err)
 	}
 
 	// Create audit log
@@ -128,6 +179,285 @@ func (s *Service) InviteUser(ctx context.Context, tenantID, inviterUserID int64,
 
 	logger.Info("User invited successfully", "user_id", user.ID, "tenant_id", tenantID)
 
+	s.sendInvitation(ctx, tenant, user, inviterUserID, token)
+
+	return user, nil
+}
+
+// ProvisionUser creates a user on behalf of the tenant's identity provider
+// (SCIM). There is no invitation, the user signs in with single sign-on or
+// sets a password through the password reset (Enterprise only).
+func (s *Service) ProvisionUser(ctx context.Context, tenantID int64, email string, firstName, lastName *string, role models.UserRole, isActive bool) (*models.User, error) {
+	logger.Info("Provisioning user", "tenant_id", tenantID, "email", email, "role", role)
+
+	// Verify the plan includes multi-user support
+	tenant, err := s.tenantRepo.GetByID(ctx, tenantID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get tenant: %w", err)
+	}
+
+	if err := s.requireMultiUser(tenant, true); err != nil {
+		return nil, err
+	}
+
+	// Check if email already exists in tenant
+	existingUser, _ := s.userRepo.GetByEmail(ctx, tenantID, email)
+	if existingUser != nil {
+		return nil, errors.ErrEmailAlreadyExists
+	}
+
+	// Nobody knows the password
+	passwordHash, err := bcrypt.GenerateFromPassword([]byte(generateTempPassword()), bcrypt.DefaultCost)
+	if err != nil {
+		return nil, fmt.Errorf("failed to hash password: %w", err)
+	}
+
+	// The identity provider owns the address
+	verifiedAt := time.Now()
+	user := &models.User{
+		TenantID:        tenantID,
+		Email:           email,
+		PasswordHash:    string(passwordHash),
+		FirstName:       firstName,
+		LastName:        lastName,
+		Role:            role,
+		IsActive:        isActive,
+		EmailVerifiedAt: &verifiedAt,
+	}
+
+	if err := s.userRepo.Create(ctx, user); err != nil {
+		return nil, fmt.Errorf("failed to create user: %w", err)
+	}
+
+	// Create audit log
+	changes, _ := json.Marshal(map[string]interface{}{
+		"email":     email,
+		"role":      role,
+		"is_active": isActive,
+		"source":    "scim",
+	})
+	changesStr := string(changes)
+	auditLog := &models.AuditLog{
+		TenantID:   tenantID,
+		Action:     string(models.AuditActionCreateUser),
+		EntityType: string(models.EntityTypeUser),
+		EntityID:   &user.ID,
+		Changes:    &changesStr,
+	}
+	s.auditLogRepo.Create(ctx, auditLog)
+
+	logger.Info("User provisioned successfully", "user_id", user.ID, "tenant_id", tenantID)
+
+	return user, nil
+}
+
+// ListPendingInvites lists all invitations of a tenant that have not been accepted (Enterprise only)
+func (s *Service) ListPendingInvites(ctx context.Context, tenantID int64) ([]*models.PendingInvite, error) {
+	// Verify the plan includes multi-user support
+	tenant, err := s.tenantRepo.GetByID(ctx, tenantID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get tenant: %w", err)
+	}
+
+	if err := s.requireMultiUser(tenant, false); err != nil {
+		return nil, err
+	}
+
+	invites, err := s.inviteRepo.ListPending(ctx, tenantID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list pending invites: %w", err)
+	}
+
+	return invites, nil
+}
+
+// ResendInvite replaces the pending invitation of a user with a new token and
+// a fresh expiry and sends it again (Enterprise only)
+func (s *Service) ResendInvite(ctx context.Context, tenantID, requesterUserID, targetUserID int64) (*models.InviteToken, error) {
+	logger.Info("Resending invite", "tenant_id", tenantID, "target_user_id", targetUserID)
+
+	tenant, user, _, err := s.getPendingInvite(ctx, tenantID, targetUserID)
+	if err != nil {
+		return nil, err
+	}
+
+	invite, token, err := newInvite(user, requesterUserID)
+	if err != nil {
+		return nil, err
+	}
+
+	// The new link replaces the old one
+	if err := s.inviteRepo.Replace(ctx, invite); err != nil {
+		return nil, fmt.Errorf("failed to replace invite: %w", err)
+	}
+
+	// Create audit log
+	auditLog := &models.AuditLog{
+		TenantID:   tenantID,
+		UserID:     &requesterUserID,
+		Action:     string(models.AuditActionResendInvite),
+		EntityType: string(models.EntityTypeUser),
+		EntityID:   &targetUserID,
+	}
+	s.auditLogRepo.Create(ctx, auditLog)
+
+	s.sendInvitation(ctx, tenant, user, requesterUserID, token)
+
+	logger.Info("Invite resent successfully", "user_id", targetUserID, "tenant_id", tenantID)
+
+	return s.inviteRepo.GetPendingByUserID(ctx, tenantID, targetUserID)
+}
+
+// RevokeInvite withdraws a pending invitation. The invited user never had
+// access, so the account is removed along with its tokens (Enterprise only).
+func (s *Service) RevokeInvite(ctx context.Context, tenantID, requesterUserID, targetUserID int64) error {
+	logger.Info("Revoking invite", "tenant_id", tenantID, "target_user_id", targetUserID)
+
+	_, user, _, err := s.getPendingInvite(ctx, tenantID, targetUserID)
+	if err != nil {
+		return err
+	}
+
+	// Deleting the user cascades to its invite tokens
+	if err := s.userRepo.Delete(ctx, user.ID); err != nil {
+		return fmt.Errorf("failed to delete invited user: %w", err)
+	}
+
+	// Create audit log
+	changes, _ := json.Marshal(map[string]interface{}{
+		"email":      user.Email,
+		"revoked_by": requesterUserID,
+	})
+	changesStr := string(changes)
+	auditLog := &models.AuditLog{
+		TenantID:   tenantID,
+		UserID:     &requesterUserID,
+		Action:     string(models.AuditActionRevokeInvite),
+		EntityType: string(models.EntityTypeUser),
+		EntityID:   &targetUserID,
+		Changes:    &changesStr,
+	}
+	s.auditLogRepo.Create(ctx, auditLog)
+
+	logger.Info("Invite revoked successfully", "user_id", targetUserID, "tenant_id", tenantID)
+
+	return nil
+}
+
+// UnlockUser lifts the lockout of an account after failed logins and
+// forgets its failed attempts (Enterprise only)
+func (s *Service) UnlockUser(ctx context.Context, tenantID, requesterUserID, targetUserID int64) error {
+	logger.Info("Unlocking user", "tenant_id", tenantID, "target_user_id", targetUserID)
+
+	// Verify the plan includes multi-user support
+	tenant, err := s.tenantRepo.GetByID(ctx, tenantID)
+	if err != nil {
+		return fmt.Errorf("failed to get tenant: %w", err)
+	}
+
+	if err := s.requireMultiUser(tenant, false); err != nil {
+		return err
+	}
+
+	// Get target user
+	user, err := s.userRepo.GetByID(ctx, targetUserID)
+	if err != nil {
+		return fmt.Errorf("failed to get user: %w", err)
+	}
+
+	// Verify user belongs to tenant
+	if user.TenantID != tenantID {
+		return errors.ErrUserNotInTenant
+	}
+
+	if s.loginAttempts == nil {
+		return nil
+	}
+
+	lockedFor := s.loginAttempts.LockedFor(ctx, user.ID)
+	if err := s.loginAttempts.Reset(ctx, user.ID); err != nil {
+		return fmt.Errorf("failed to unlock user: %w", err)
+	}
+
+	// Create audit log
+	changes, _ := json.Marshal(map[string]interface{}{
+		"was_locked": lockedFor > 0,
+		"locked_for": int64(lockedFor.Seconds()),
+	})
+	changesStr := string(changes)
+	auditLog := &models.AuditLog{
+		TenantID:   tenantID,
+		UserID:     &requesterUserID,
+		Action:     string(models.AuditActionUnlockUser),
+		EntityType: string(models.EntityTypeUser),
+		EntityID:   &targetUserID,
+		Changes:    &changesStr,
+	}
+	s.auditLogRepo.Create(ctx, auditLog)
+
+	logger.Info("User unlocked successfully", "user_id", targetUserID, "tenant_id", tenantID)
+
+	return nil
+}
+
+// getPendingInvite loads tenant, user and pending invitation of an invited user
+func (s *Service) getPendingInvite(ctx context.Context, tenantID, targetUserID int64) (*models.Tenant, *models.User, *models.InviteToken, error) {
+	// Verify the plan includes multi-user support
+	tenant, err := s.tenantRepo.GetByID(ctx, tenantID)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("failed to get tenant: %w", err)
+	}
+
+	if err := s.requireMultiUser(tenant, false); err != nil {
+		return nil, nil, nil, err
+	}
+
+	// Get target user
+	user, err := s.userRepo.GetByID(ctx, targetUserID)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("failed to get user: %w", err)
+	}
+
+	// Verify user belongs to tenant
+	if user.TenantID != tenantID {
+		return nil, nil, nil, errors.ErrUserNotInTenant
+	}
+
+	// Users who accepted their invitation are managed via UpdateUser/DeleteUser
+	invite, err := s.inviteRepo.GetPendingByUserID(ctx, tenantID, targetUserID)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	return tenant, user, invite, nil
+}
+
+// newInvite prepares an invitation for a user and returns it with the plain token
+func newInvite(user *models.User, inviterUserID int64) (*models.InviteToken, string, error) {
+	token, err := utils.GenerateSecureToken(32)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to generate invite token: %w", err)
+	}
+
+	invite := &models.InviteToken{
+		TenantID:  user.TenantID,
+		UserID:    user.ID,
+		TokenHash: utils.HashToken(token),
+		Email:     user.Email,
+		Role:      user.Role,
+		InvitedBy: &inviterUserID,
+		ExpiresAt: time.Now().Add(models.InviteTokenExpiry),
+	}
+
+	return invite, token, nil
+}
+
+// sendInvitation emails the invitation link to an invited user
+func (s *Service) sendInvitation(ctx context.Context, tenant *models.Tenant, user *models.User, inviterUserID int64, token string) {
+	if s.emailClient == nil {
+		return
+	}
+
 	// Get inviter info for email
 	inviter, _ := s.userRepo.GetByID(ctx, inviterUserID)
 	inviterName := "Ein Administrator"
@@ -139,10 +469,9 @@ func (s *Service) InviteUser(ctx context.Context, tenantID, inviterUserID int64,
 		}
 	}
 
-	// Send invitation email
 	recipientName := ""
-	if firstName != "" {
-		recipientName = firstName
+	if user.FirstName != nil {
+		recipientName = *user.FirstName
 	}
 
 	roleLabels := map[models.UserRole]string{
@@ -152,40 +481,46 @@ func (s *Service) InviteUser(ctx context.Context, tenantID, inviterUserID int64,
 		models.RoleViewer: "Betrachter",
 	}
 
-	inviteLink := fmt.Sprintf("%s/login?email=%s&invite=true", s.baseURL, email)
-
-	if s.emailClient != nil {
-		emailData := &external.UserInvitationData{
-			RecipientName:  recipientName,
-			RecipientEmail: email,
-			InviterName:    inviterName,
-			TenantName:     tenant.Name,
-			Role:           roleLabels[role],
-			InviteLink:     inviteLink,
-			ExpiresIn:      "7 Tage",
-		}
-
-		if err := s.emailClient.SendUserInvitation(emailData); err != nil {
-			// Log error but don't fail the invitation
-			logger.Error("Failed to send invitation email", "error", err.Error(), "email", email)
-		}
+	emailData := &external.UserInvitationData{
+		RecipientName:  recipientName,
+		RecipientEmail: user.Email,
+		InviterName:    inviterName,
+		TenantName:     tenant.Name,
+		Role:           roleLabels[user.Role],
+		InviteLink:     fmt.Sprintf("%s/accept-invite?token=%s", s.baseURL, token),
+		ExpiresIn:      "7 Tage",
 	}
 
-	return user, nil
+	if err := s.emailClient.SendUserInvitation(emailData); err != nil {
+		// Log error but don't fail the invitation
+		logger.Error("Failed to send invitation email", "error", err.Error(), "email", user.Email)
+	}
 }
 
-// UpdateUser updates user information (Enterprise only)
+// UpdateUser updates user information. A new email only takes effect once
+// the user confirmed it (Enterprise only).
 func (s *Service) UpdateUser(ctx context.Context, tenantID, requesterUserID, targetUserID int64, email string, firstName, lastName *string, role models.UserRole, isActive bool) (*models.User, error) {
+	return s.updateUser(ctx, tenantID, &requesterUserID, targetUserID, email, firstName, lastName, role, isActive)
+}
+
+// UpdateProvisionedUser updates a user on behalf of the tenant's identity
+// provider (SCIM), the audit log has no acting user (Enterprise only)
+func (s *Service) UpdateProvisionedUser(ctx context.Context, tenantID, targetUserID int64, email string, firstName, lastName *string, role models.UserRole, isActive bool) (*models.User, error) {
+	return s.updateUser(ctx, tenantID, nil, targetUserID, email, firstName, lastName, role, isActive)
+}
+
+// updateUser updates a user, requesterUserID is nil for changes by the identity provider
+func (s *Service) updateUser(ctx context.Context, tenantID int64, requesterUserID *int64, targetUserID int64, email string, firstName, lastName *string, role models.UserRole, isActive bool) (*models.User, error) {
 	logger.Info("Updating user", "tenant_id", tenantID, "target_user_id", targetUserID)
 
-	// Verify tenant is Enterprise
+	// Verify the plan includes multi-user support
 	tenant, err := s.tenantRepo.GetByID(ctx, tenantID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get tenant: %w", err)
 	}
 
-	if tenant.Tier != "enterprise" {
-		return nil, errors.ErrMultiUserNotAllowed
+	if err := s.requireMultiUser(tenant, requesterUserID == nil); err != nil {
+		return nil, err
 	}
 
 	// Get target user
@@ -207,8 +542,24 @@ func (s *Service) UpdateUser(ctx context.Context, tenantID, requesterUserID, tar
 	// Track changes for audit
 	changes := map[string]interface{}{}
 	if user.Email != email {
-		changes["email"] = map[string]string{"old": user.Email, "new": email}
-		user.Email = email
+		switch {
+		case requesterUserID != nil && s.emailVerification != nil:
+			// The user confirms the new address and the change from the current one
+			if _, err := s.emailVerification.RequestChange(ctx, user, email); err != nil {
+				return nil, err
+			}
+			changes["email_change_requested"] = map[string]string{"old": user.Email, "new": email}
+		case requesterUserID == nil:
+			// The identity provider owns the address
+			changes["email"] = map[string]string{"old": user.Email, "new": email}
+			user.Email = email
+			verifiedAt := time.Now()
+			user.EmailVerifiedAt = &verifiedAt
+		default:
+			changes["email"] = map[string]string{"old": user.Email, "new": email}
+			user.Email = email
+			user.EmailVerifiedAt = nil
+		}
 	}
 	if role != user.Role {
 		changes["role"] = map[string]string{"old": string(user.Role), "new": string(role)}
@@ -236,7 +587,7 @@ func (s *Service) UpdateUser(ctx context.Context, tenantID, requesterUserID, tar
 		changesStr := string(changesJSON)
 		auditLog := &models.AuditLog{
 			TenantID:   tenantID,
-			UserID:     &requesterUserID,
+			UserID:     requesterUserID,
 			Action:     string(models.AuditActionUpdateUser),
 			EntityType: string(models.EntityTypeUser),
 			EntityID:   &targetUserID,
@@ -252,16 +603,27 @@ func (s *Service) UpdateUser(ctx context.Context, tenantID, requesterUserID, tar
 
 // DeleteUser deletes a user from the tenant (Enterprise only)
 func (s *Service) DeleteUser(ctx context.Context, tenantID, requesterUserID, targetUserID int64) error {
+	return s.deleteUser(ctx, tenantID, &requesterUserID, targetUserID)
+}
+
+// DeleteProvisionedUser deletes a user on behalf of the tenant's identity
+// provider (SCIM), the audit log has no acting user (Enterprise only)
+func (s *Service) DeleteProvisionedUser(ctx context.Context, tenantID, targetUserID int64) error {
+	return s.deleteUser(ctx, tenantID, nil, targetUserID)
+}
+
+// deleteUser deletes a user, requesterUserID is nil for deletions by the identity provider
+func (s *Service) deleteUser(ctx context.Context, tenantID int64, requesterUserID *int64, targetUserID int64) error {
 	logger.Info("Deleting user", "tenant_id", tenantID, "target_user_id", targetUserID)
 
-	// Verify tenant is Enterprise
+	// Verify the plan includes multi-user support
 	tenant, err := s.tenantRepo.GetByID(ctx, tenantID)
 	if err != nil {
 		return fmt.Errorf("failed to get tenant: %w", err)
 	}
 
-	if tenant.Tier != "enterprise" {
-		return errors.ErrMultiUserNotAllowed
+	if err := s.requireMultiUser(tenant, requesterUserID == nil); err != nil {
+		return err
 	}
 
 	// Get target user
@@ -294,7 +656,7 @@ func (s *Service) DeleteUser(ctx context.Context, tenantID, requesterUserID, tar
 	changesStr := string(changes)
 	auditLog := &models.AuditLog{
 		TenantID:   tenantID,
-		UserID:     &requesterUserID,
+		UserID:     requesterUserID,
 		Action:     string(models.AuditActionDeleteUser),
 		EntityType: string(models.EntityTypeUser),
 		EntityID:   &targetUserID,
@@ -307,98 +669,7 @@ func (s *Service) DeleteUser(ctx context.Context, tenantID, requesterUserID, tar
 	return nil
 }
 
-// GenerateAPIKey generates an API key for a user (Enterprise only)
-func (s *Service) GenerateAPIKey(ctx context.Context, tenantID, requesterUserID, targetUserID int64) (string, error) {
-	logger.Info("Generating API key", "tenant_id", tenantID, "target_user_id", targetUserID)
-
-	// Verify tenant is Enterprise
-	tenant, err := s.tenantRepo.GetByID(ctx, tenantID)
-	if err != nil {
-		return "", fmt.Errorf("failed to get tenant: %w", err)
-	}
-
-	if tenant.Tier != "enterprise" {
-		return "", errors.ErrAPIAccessNotAllowed
-	}
-
-	// Get target user
-	user, err := s.userRepo.GetByID(ctx, targetUserID)
-	if err != nil {
-		return "", fmt.Errorf("failed to get user: %w", err)
-	}
-
-	// Verify user belongs to tenant
-	if user.TenantID != tenantID {
-		return "", errors.ErrUserNotInTenant
-	}
-
-	// Generate API key
-	apiKey, err := s.userRepo.GenerateAPIKey(ctx, targetUserID)
-	if err != nil {
-		return "", fmt.Errorf("failed to generate API key: %w", err)
-	}
-
-	// Create audit log
-	auditLog := &models.AuditLog{
-		TenantID:   tenantID,
-		UserID:     &requesterUserID,
-		Action:     string(models.AuditActionGenerateAPIKey),
-		EntityType: string(models.EntityTypeUser),
-		EntityID:   &targetUserID,
-	}
-	s.auditLogRepo.Create(ctx, auditLog)
-
-	logger.Info("API key generated successfully", "user_id", targetUserID, "tenant_id", tenantID)
-
-	return apiKey, nil
-}
-
-// RevokeAPIKey revokes the API key for a user (Enterprise only)
-func (s *Service) RevokeAPIKey(ctx context.Context, tenantID, requesterUserID, targetUserID int64) error {
-	logger.Info("Revoking API key", "tenant_id", tenantID, "target_user_id", targetUserID)
-
-	// Verify tenant is Enterprise
-	tenant, err := s.tenantRepo.GetByID(ctx, tenantID)
-	if err != nil {
-		return fmt.Errorf("failed to get tenant: %w", err)
-	}
-
-	if tenant.Tier != "enterprise" {
-		return errors.ErrAPIAccessNotAllowed
-	}
-
-	// Get target user
-	user, err := s.userRepo.GetByID(ctx, targetUserID)
-	if err != nil {
-		return fmt.Errorf("failed to get user: %w", err)
-	}
-
-	// Verify user belongs to tenant
-	if user.TenantID != tenantID {
-		return errors.ErrUserNotInTenant
-	}
-
-	// Revoke API key
-	if err := s.userRepo.RevokeAPIKey(ctx, targetUserID); err != nil {
-		return fmt.Errorf("failed to revoke API key: %w", err)
-	}
-
-	// Create audit log
-	auditLog := &models.AuditLog{
-		TenantID:   tenantID,
-		UserID:     &requesterUserID,
-		Action:     string(models.AuditActionRevokeAPIKey),
-		EntityType: string(models.EntityTypeUser),
-		EntityID:   &targetUserID,
-	}
-	s.auditLogRepo.Create(ctx, auditLog)
-
-	logger.Info("API key revoked successfully", "user_id", targetUserID, "tenant_id", tenantID)
-
-	return nil
-}
-
-// generateTempPassword generates a secure temporary password for new users
+// generateTempPassword generates a random password nobody knows, used until an invitation is accepted
 func generateTempPassword() string {
 	bytes := make([]byte, 16)
 	if _, err := rand.Read(bytes); err != nil {
@@ -0,0 +1,375 @@
+package webauthn
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/yourusername/gin-collection-saas/internal/domain/errors"
+	"github.com/yourusername/gin-collection-saas/internal/domain/models"
+	"github.com/yourusername/gin-collection-saas/internal/domain/repositories"
+	"github.com/yourusername/gin-collection-saas/pkg/logger"
+	"github.com/yourusername/gin-collection-saas/pkg/utils"
+)
+
+// defaultCredentialName is used when a passkey is registered without a name
+const defaultCredentialName = "Passkey"
+
+// supportedAlgorithms are offered to authenticators, in order of preference
+var supportedAlgorithms = []int64{utils.COSEAlgES256, utils.COSEAlgEdDSA, utils.COSEAlgRS256}
+
+// Account describes the subject a passkey is registered for
+type Account struct {
+	SubjectType string
+	SubjectID   int64
+	Name        string // unique login name, e.g. the email
+	DisplayName string
+}
+
+// Service handles passkey registration and login ceremonies for tenant users
+// and platform admins
+type Service struct {
+	repo repositories.WebAuthnRepository
+	rp   utils.WebAuthnRelyingParty
+}
+
+// NewService creates a new WebAuthn service
+func NewService(repo repositories.WebAuthnRepository, rp utils.WebAuthnRelyingParty) *Service {
+	return &Service{
+		repo: repo,
+		rp:   rp,
+	}
+}
+
+// UserHandle returns the opaque user handle stored with discoverable credentials
+func UserHandle(subjectType string, subjectID int64) []byte {
+	return []byte(fmt.Sprintf("%s:%d", subjectType, subjectID))
+}
+
+// BeginRegistration creates the options for navigator.credentials.create()
+func (s *Service) BeginRegistration(ctx context.Context, account Account) (*models.WebAuthnRegistrationBegin, error) {
+	existing, err := s.repo.ListCredentials(ctx, account.SubjectType, account.SubjectID)
+	if err != nil {
+		return nil, err
+	}
+
+	session, err := s.newSession(ctx, account.SubjectType, &account.SubjectID, models.WebAuthnCeremonyRegistration)
+	if err != nil {
+		return nil, err
+	}
+
+	displayName := account.DisplayName
+	if displayName == "" {
+		displayName = account.Name
+	}
+
+	options := &models.WebAuthnCreationOptions{
+		Challenge: session.Challenge,
+		RP: models.WebAuthnRelyingPartyEntity{
+			ID:   s.rp.ID,
+			Name: s.rp.Name,
+		},
+		User: models.WebAuthnUserEntity{
+			ID:          utils.EncodeWebAuthnBase64(UserHandle(account.SubjectType, account.SubjectID)),
+			Name:        account.Name,
+			DisplayName: displayName,
+		},
+		Timeout:            models.WebAuthnSessionTTL.Milliseconds(),
+		ExcludeCredentials: descriptors(existing),
+		AuthenticatorSelection: models.WebAuthnAuthenticatorSelection{
+			ResidentKey:      "preferred",
+			UserVerification: "preferred",
+		},
+		Attestation: "none",
+	}
+	for _, alg := range supportedAlgorithms {
+		options.PubKeyCredParams = append(options.PubKeyCredParams, models.WebAuthnCredentialParameter{
+			Type: "public-key",
+			Alg:  alg,
+		})
+	}
+
+	return &models.WebAuthnRegistrationBegin{
+		SessionID: session.ID,
+		PublicKey: options,
+	}, nil
+}
+
+// FinishRegistration verifies the attestation of a new passkey and stores it
+func (s *Service) FinishRegistration(ctx context.Context, subjectType string, subjectID int64, req *models.WebAuthnRegistrationRequest) (*models.WebAuthnCredential, error) {
+	session, err := s.consumeSession(ctx, req.SessionID, subjectType, models.WebAuthnCeremonyRegistration)
+	if err != nil {
+		return nil, err
+	}
+	if session.SubjectID == nil || *session.SubjectID != subjectID {
+		return nil, errors.ErrInvalidToken
+	}
+
+	clientDataJSON, err := utils.DecodeWebAuthnBase64(req.Credential.Response.ClientDataJSON)
+	if err != nil {
+		return nil, errors.ErrInvalidInput
+	}
+	attestationObject, err := utils.DecodeWebAuthnBase64(req.Credential.Response.AttestationObject)
+	if err != nil {
+		return nil, errors.ErrInvalidInput
+	}
+
+	if _, err := s.rp.VerifyClientData(clientDataJSON, utils.WebAuthnTypeCreate, session.Challenge); err != nil {
+		return nil, s.verificationFailed(subjectType, subjectID, err)
+	}
+
+	clientDataHash := sha256.Sum256(clientDataJSON)
+	authData, format, err := utils.VerifyAttestation(attestationObject, clientDataHash[:])
+	if err != nil {
+		return nil, s.verificationFailed(subjectType, subjectID, err)
+	}
+	if err := s.rp.VerifyAuthenticatorData(authData); err != nil {
+		return nil, s.verificationFailed(subjectType, subjectID, err)
+	}
+
+	algorithm, _, err := utils.ParseCOSEKey(authData.CredentialPublicKey)
+	if err != nil {
+		return nil, s.verificationFailed(subjectType, subjectID, err)
+	}
+
+	credentialID := utils.EncodeWebAuthnBase64(authData.CredentialID)
+	if rawID, err := utils.DecodeWebAuthnBase64(req.Credential.ID); err != nil || utils.EncodeWebAuthnBase64(rawID) != credentialID {
+		return nil, s.verificationFailed(subjectType, subjectID, fmt.Errorf("%w: credential ID mismatch", utils.ErrWebAuthn))
+	}
+
+	if _, err := s.repo.GetCredentialByCredentialID(ctx, credentialID); err == nil {
+		return nil, errors.ErrConflict
+	} else if err != errors.ErrNotFound {
+		return nil, err
+	}
+
+	name := req.Name
+	if name == "" {
+		name = defaultCredentialName
+	}
+
+	credential := &models.WebAuthnCredential{
+		SubjectType:       subjectType,
+		SubjectID:         subjectID,
+		CredentialID:      credentialID,
+		PublicKey:         authData.CredentialPublicKey,
+		Algorithm:         algorithm,
+		SignCount:         authData.SignCount,
+		AAGUID:            authData.AAGUID,
+		AttestationFormat: format,
+		Transports:        req.Credential.Response.Transports,
+		BackupEligible:    authData.HasFlag(utils.AuthenticatorFlagBackupEligible),
+		BackedUp:          authData.HasFlag(utils.AuthenticatorFlagBackedUp),
+		Name:              name,
+	}
+
+	if err := s.repo.CreateCredential(ctx, credential); err != nil {
+		return nil, err
+	}
+
+	logger.Info("Passkey registered", "subject_type", subjectType, "subject_id", subjectID, "credential_id", credential.ID)
+
+	return credential, nil
+}
+
+// BeginLogin creates the options for navigator.credentials.get(). Without a
+// subject ID any discoverable passkey of the subject type is accepted.
+func (s *Service) BeginLogin(ctx context.Context, subjectType string, subjectID *int64) (*models.WebAuthnLoginBegin, error) {
+	allowed := []models.WebAuthnCredentialDescriptor{}
+	if subjectID != nil {
+		credentials, err := s.repo.ListCredentials(ctx, subjectType, *subjectID)
+		if err != nil {
+			return nil, err
+		}
+		allowed = descriptors(credentials)
+	}
+
+	session, err := s.newSession(ctx, subjectType, subjectID, models.WebAuthnCeremonyLogin)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.WebAuthnLoginBegin{
+		SessionID: session.ID,
+		PublicKey: &models.WebAuthnRequestOptions{
+			Challenge:        session.Challenge,
+			RPID:             s.rp.ID,
+			Timeout:          models.WebAuthnSessionTTL.Milliseconds(),
+			AllowCredentials: allowed,
+			UserVerification: "preferred",
+		},
+	}, nil
+}
+
+// FinishLogin verifies an assertion and returns the passkey that signed it
+func (s *Service) FinishLogin(ctx context.Context, subjectType string, req *models.WebAuthnLoginRequest) (*models.WebAuthnAssertion, error) {
+	session, err := s.consumeSession(ctx, req.SessionID, subjectType, models.WebAuthnCeremonyLogin)
+	if err != nil {
+		return nil, err
+	}
+
+	rawID, err := utils.DecodeWebAuthnBase64(req.Credential.ID)
+	if err != nil {
+		return nil, errors.ErrInvalidInput
+	}
+
+	credential, err := s.repo.GetCredentialByCredentialID(ctx, utils.EncodeWebAuthnBase64(rawID))
+	if err == errors.ErrNotFound {
+		logger.Debug("Unknown passkey used for login", "subject_type", subjectType)
+		return nil, errors.ErrPasskeyVerification
+	}
+	if err != nil {
+		return nil, err
+	}
+	if credential.SubjectType != subjectType || (session.SubjectID != nil && *session.SubjectID != credential.SubjectID) {
+		return nil, errors.ErrPasskeyVerification
+	}
+
+	clientDataJSON, err := utils.DecodeWebAuthnBase64(req.Credential.Response.ClientDataJSON)
+	if err != nil {
+		return nil, errors.ErrInvalidInput
+	}
+	rawAuthData, err := utils.DecodeWebAuthnBase64(req.Credential.Response.AuthenticatorData)
+	if err != nil {
+		return nil, errors.ErrInvalidInput
+	}
+	signature, err := utils.DecodeWebAuthnBase64(req.Credential.Response.Signature)
+	if err != nil {
+		return nil, errors.ErrInvalidInput
+	}
+
+	if _, err := s.rp.VerifyClientData(clientDataJSON, utils.WebAuthnTypeGet, session.Challenge); err != nil {
+		return nil, s.verificationFailed(subjectType, credential.SubjectID, err)
+	}
+
+	authData, err := utils.ParseAuthenticatorData(rawAuthData)
+	if err != nil {
+		return nil, s.verificationFailed(subjectType, credential.SubjectID, err)
+	}
+	if err := s.rp.VerifyAuthenticatorData(authData); err != nil {
+		return nil, s.verificationFailed(subjectType, credential.SubjectID, err)
+	}
+
+	clientDataHash := sha256.Sum256(clientDataJSON)
+	if err := utils.VerifyAssertionSignature(credential.PublicKey, rawAuthData, clientDataHash[:], signature); err != nil {
+		return nil, s.verificationFailed(subjectType, credential.SubjectID, err)
+	}
+
+	if req.Credential.Response.UserHandle != "" {
+		userHandle, err := utils.DecodeWebAuthnBase64(req.Credential.Response.UserHandle)
+		if err != nil || subtle.ConstantTimeCompare(userHandle, UserHandle(credential.SubjectType, credential.SubjectID)) != 1 {
+			return nil, s.verificationFailed(subjectType, credential.SubjectID, fmt.Errorf("%w: user handle mismatch", utils.ErrWebAuthn))
+		}
+	}
+
+	// A counter that doesn't increase hints at a cloned authenticator.
+	// Synced passkeys always report 0.
+	if (authData.SignCount != 0 || credential.SignCount != 0) && authData.SignCount <= credential.SignCount {
+		logger.Warn("Passkey sign count did not increase, possible cloned authenticator",
+			"subject_type", subjectType, "subject_id", credential.SubjectID, "credential_id", credential.ID,
+			"stored", credential.SignCount, "received", authData.SignCount)
+		return nil, errors.ErrPasskeyVerification
+	}
+
+	credential.SignCount = authData.SignCount
+	credential.BackedUp = authData.HasFlag(utils.AuthenticatorFlagBackedUp)
+	if err := s.repo.UpdateCredentialUsage(ctx, credential.ID, credential.SignCount, credential.BackedUp); err != nil {
+		return nil, err
+	}
+
+	return &models.WebAuthnAssertion{
+		Credential:   credential,
+		UserVerified: authData.HasFlag(utils.AuthenticatorFlagUserVerified),
+	}, nil
+}
+
+// ListCredentials lists the passkeys of a subject
+func (s *Service) ListCredentials(ctx context.Context, subjectType string, subjectID int64) ([]*models.WebAuthnCredential, error) {
+	credentials, err := s.repo.ListCredentials(ctx, subjectType, subjectID)
+	if err != nil {
+		return nil, err
+	}
+	if credentials == nil {
+		credentials = []*models.WebAuthnCredential{}
+	}
+	return credentials, nil
+}
+
+// DeleteCredential removes a passkey of a subject
+func (s *Service) DeleteCredential(ctx context.Context, subjectType string, subjectID int64, id int64) error {
+	if err := s.repo.DeleteCredential(ctx, subjectType, subjectID, id); err != nil {
+		return err
+	}
+
+	logger.Info("Passkey removed", "subject_type", subjectType, "subject_id", subjectID, "credential_id", id)
+
+	return nil
+}
+
+// newSession stores a fresh challenge for a ceremony
+func (s *Service) newSession(ctx context.Context, subjectType string, subjectID *int64, ceremony string) (*models.WebAuthnSession, error) {
+	// Housekeeping, abandoned ceremonies are never consumed
+	if _, err := s.repo.DeleteExpiredSessions(ctx); err != nil {
+		logger.Error("Failed to delete expired webauthn sessions", "error", err.Error())
+	}
+
+	challenge := make([]byte, models.WebAuthnChallengeBytes)
+	if _, err := rand.Read(challenge); err != nil {
+		return nil, fmt.Errorf("failed to generate challenge: %w", err)
+	}
+
+	session := &models.WebAuthnSession{
+		ID:          uuid.New().String(),
+		SubjectType: subjectType,
+		SubjectID:   subjectID,
+		Ceremony:    ceremony,
+		Challenge:   utils.EncodeWebAuthnBase64(challenge),
+		ExpiresAt:   time.Now().Add(models.WebAuthnSessionTTL),
+	}
+
+	if err := s.repo.CreateSession(ctx, session); err != nil {
+		return nil, err
+	}
+
+	return session, nil
+}
+
+// consumeSession loads and removes a session and checks it belongs to the ceremony
+func (s *Service) consumeSession(ctx context.Context, id, subjectType, ceremony string) (*models.WebAuthnSession, error) {
+	session, err := s.repo.ConsumeSession(ctx, id)
+	if err == errors.ErrNotFound {
+		return nil, errors.ErrInvalidToken
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if session.IsExpired() || session.Ceremony != ceremony || session.SubjectType != subjectType {
+		return nil, errors.ErrInvalidToken
+	}
+
+	return session, nil
+}
+
+// verificationFailed logs the protocol error and hides details from the client
+func (s *Service) verificationFailed(subjectType string, subjectID int64, err error) error {
+	logger.Warn("Passkey verification failed", "subject_type", subjectType, "subject_id", subjectID, "error", err.Error())
+	return errors.ErrPasskeyVerification
+}
+
+// descriptors lists credentials for allowCredentials / excludeCredentials
+func descriptors(credentials []*models.WebAuthnCredential) []models.WebAuthnCredentialDescriptor {
+	result := make([]models.WebAuthnCredentialDescriptor, 0, len(credentials))
+	for _, credential := range credentials {
+		result = append(result, models.WebAuthnCredentialDescriptor{
+			Type:       "public-key",
+			ID:         credential.CredentialID,
+			Transports: credential.Transports,
+		})
+	}
+	return result
+}
@@ -1,6 +1,9 @@
 package config
 
 import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
 	"net/http"
 	"os"
@@ -10,16 +13,36 @@ import (
 
 // Config holds all application configuration
 type Config struct {
-	Database DatabaseConfig
-	Redis    RedisConfig
-	JWT      JWTConfig
-	Cookie   CookieConfig
-	S3       S3Config
-	Storage  StorageConfig
-	PayPal   PayPalConfig
-	SMTP     SMTPConfig
-	App      AppConfig
-	AI       AIConfig
+	Database        DatabaseConfig
+	Redis           RedisConfig
+	JWT             JWTConfig
+	Cookie          CookieConfig
+	S3              S3Config
+	Storage         StorageConfig
+	PayPal          PayPalConfig
+	Stripe          StripeConfig
+	Billing         BillingConfig
+	Invoice         InvoiceConfig
+	SMTP            SMTPConfig
+	App             AppConfig
+	AI              AIConfig
+	WebAuthn        WebAuthnConfig
+	Email           EmailVerificationConfig
+	LoginProtection LoginProtectionConfig
+}
+
+// EmailVerificationConfig holds the restrictions for unverified accounts
+type EmailVerificationConfig struct {
+	RequiredTiers []string // tiers whose unverified users can only read (empty = no restriction)
+}
+
+// LoginProtectionConfig holds the account lockout after failed logins and
+// the notifications about logins from new devices
+type LoginProtectionConfig struct {
+	LockoutThreshold   int           // failed logins until the account is locked (0 = never)
+	LockoutDuration    time.Duration // first lockout, doubles with every further failure
+	LockoutMaxDuration time.Duration
+	NotifyNewDevices   bool
 }
 
 // CookieConfig holds cookie configuration for auth tokens
@@ -29,20 +52,28 @@ type CookieConfig struct {
 	SameSite http.SameSite
 }
 
+// WebAuthnConfig holds passkey relying party configuration
+type WebAuthnConfig struct {
+	RPID    string // domain passkeys are bound to, tenant subdomains included
+	RPName  string
+	Origins []string // extra allowed origins (e.g. http://localhost:5173 in development)
+}
+
 // AIConfig holds AI service configuration
 type AIConfig struct {
-	Provider string // "ollama" or "anthropic"
+	Provider  string // "ollama" or "anthropic"
 	OllamaURL string
-	Model    string
-	Enabled  bool
+	Model     string
+	Enabled   bool
 	// Anthropic (optional, if you want to use cloud API later)
 	AnthropicAPIKey string
 }
 
 // StorageConfig holds local storage configuration (fallback for S3)
 type StorageConfig struct {
-	BasePath string
-	BaseURL  string
+	BasePath     string
+	BaseURL      string
+	UploadSecret string // HMAC secret for signed local upload URLs (derived from the JWT secret if unset)
 }
 
 // SMTPConfig holds SMTP email configuration
@@ -97,10 +128,49 @@ type PayPalConfig struct {
 	ClientSecret string
 	Mode         string // sandbox or live
 	WebhookID    string
+	PlanIDs      map[string]string // plan ID -> PayPal plan ID, for plans the catalog has none for
+}
+
+// StripeConfig holds Stripe configuration, Stripe is enabled when a secret key is set
+type StripeConfig struct {
+	SecretKey     string
+	WebhookSecret string
+	PriceIDs      map[string]string // plan ID -> Stripe price ID, for plans the catalog has none for
+}
+
+// BillingConfig holds the billing provider configuration
+type BillingConfig struct {
+	DefaultProvider     string         // provider of tenants that haven't chosen one
+	TrialDays           map[string]int // plan ID -> length of its trial, plans without an entry have none
+	TrialReminderBefore time.Duration  // how long before the end of a trial its reminder is sent
+	Dunning             DunningConfig
+}
+
+// DunningConfig holds the timeline of dunning after a failed payment
+type DunningConfig struct {
+	GracePeriod  time.Duration // full access after the failed payment
+	ReminderDays []int         // days after the failed payment the billing managers are reminded
+	FinalAfter   time.Duration // the subscription is downgraded or the tenant suspended this long after the failed payment
+	FinalAction  string        // downgrade or suspend
+}
+
+// InvoiceConfig holds the seller shown on invoices and how they're numbered
+type InvoiceConfig struct {
+	SellerName         string
+	SellerEmail        string
+	SellerAddressLine1 string
+	SellerAddressLine2 string
+	SellerPostalCode   string
+	SellerCity         string
+	SellerCountry      string // ISO 3166-1 alpha-2
+	SellerVATID        string
+	VATRate            float64 // percent, charged to customers in the seller's country
+	NumberPrefix       string  // invoice numbers are <prefix>-<year>-<sequence>
 }
 
 // AppConfig holds general app configuration
 type AppConfig struct {
+	Name           string
 	Env            string
 	Port           int
 	BaseURL        string
@@ -136,6 +206,67 @@ func Load() (*Config, error) {
 		return nil, fmt.Errorf("invalid SMTP_PORT: %w", err)
 	}
 
+	lockoutThreshold, err := strconv.Atoi(getEnv("LOGIN_LOCKOUT_THRESHOLD", "5"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid LOGIN_LOCKOUT_THRESHOLD: %w", err)
+	}
+
+	lockoutDuration, err := time.ParseDuration(getEnv("LOGIN_LOCKOUT_DURATION", "1m"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid LOGIN_LOCKOUT_DURATION: %w", err)
+	}
+
+	lockoutMaxDuration, err := time.ParseDuration(getEnv("LOGIN_LOCKOUT_MAX_DURATION", "1h"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid LOGIN_LOCKOUT_MAX_DURATION: %w", err)
+	}
+
+	trialDays := make(map[string]int)
+	for planID, value := range parseKeyValues(getEnv("BILLING_TRIAL_DAYS", "PLAN_BASIC_MONTHLY=14,PLAN_PRO_MONTHLY=14")) {
+		days, err := strconv.Atoi(value)
+		if err != nil || days < 0 {
+			return nil, fmt.Errorf("invalid BILLING_TRIAL_DAYS for %s: %q", planID, value)
+		}
+		trialDays[planID] = days
+	}
+
+	trialReminderBefore, err := time.ParseDuration(getEnv("BILLING_TRIAL_REMINDER_BEFORE", "72h"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid BILLING_TRIAL_REMINDER_BEFORE: %w", err)
+	}
+
+	dunningGracePeriod, err := time.ParseDuration(getEnv("DUNNING_GRACE_PERIOD", "168h"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid DUNNING_GRACE_PERIOD: %w", err)
+	}
+
+	dunningFinalAfter, err := time.ParseDuration(getEnv("DUNNING_FINAL_AFTER", "336h"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid DUNNING_FINAL_AFTER: %w", err)
+	}
+	if dunningFinalAfter < dunningGracePeriod {
+		return nil, fmt.Errorf("DUNNING_FINAL_AFTER must not be shorter than DUNNING_GRACE_PERIOD")
+	}
+
+	var dunningReminderDays []int
+	for _, value := range parseCSV(getEnv("DUNNING_REMINDER_DAYS", "1,3,7")) {
+		day, err := strconv.Atoi(value)
+		if err != nil || day < 0 || (len(dunningReminderDays) > 0 && day <= dunningReminderDays[len(dunningReminderDays)-1]) {
+			return nil, fmt.Errorf("invalid DUNNING_REMINDER_DAYS: days must be ascending, got %q", value)
+		}
+		dunningReminderDays = append(dunningReminderDays, day)
+	}
+
+	dunningFinalAction := getEnv("DUNNING_FINAL_ACTION", "downgrade")
+	if dunningFinalAction != "downgrade" && dunningFinalAction != "suspend" {
+		return nil, fmt.Errorf("invalid DUNNING_FINAL_ACTION: %q (downgrade or suspend)", dunningFinalAction)
+	}
+
+	vatRate, err := strconv.ParseFloat(getEnv("INVOICE_VAT_RATE", "19"), 64)
+	if err != nil || vatRate < 0 {
+		return nil, fmt.Errorf("invalid INVOICE_VAT_RATE: %q", getEnv("INVOICE_VAT_RATE", "19"))
+	}
+
 	smtpTLS := getEnv("SMTP_TLS", "true") == "true"
 	smtpSkipVerify := getEnv("SMTP_SKIP_VERIFY", "false") == "true"
 
@@ -167,14 +298,44 @@ func Load() (*Config, error) {
 			PublicURL:       getEnv("S3_PUBLIC_URL", ""),
 		},
 		Storage: StorageConfig{
-			BasePath: getEnv("STORAGE_PATH", "/app/uploads"),
-			BaseURL:  getEnv("STORAGE_BASE_URL", ""),
+			BasePath:     getEnv("STORAGE_PATH", "/app/uploads"),
+			BaseURL:      getEnv("STORAGE_BASE_URL", ""),
+			UploadSecret: getEnv("STORAGE_UPLOAD_SECRET", ""),
 		},
 		PayPal: PayPalConfig{
 			ClientID:     getEnv("PAYPAL_CLIENT_ID", ""),
 			ClientSecret: getEnv("PAYPAL_CLIENT_SECRET", ""),
 			Mode:         getEnv("PAYPAL_MODE", "sandbox"),
 			WebhookID:    getEnv("PAYPAL_WEBHOOK_ID", ""),
+			PlanIDs:      parseKeyValues(getEnv("PAYPAL_PLAN_IDS", "")),
+		},
+		Stripe: StripeConfig{
+			SecretKey:     getEnv("STRIPE_SECRET_KEY", ""),
+			WebhookSecret: getEnv("STRIPE_WEBHOOK_SECRET", ""),
+			PriceIDs:      parseKeyValues(getEnv("STRIPE_PRICE_IDS", "")),
+		},
+		Billing: BillingConfig{
+			DefaultProvider:     getEnv("BILLING_DEFAULT_PROVIDER", "paypal"),
+			TrialDays:           trialDays,
+			TrialReminderBefore: trialReminderBefore,
+			Dunning: DunningConfig{
+				GracePeriod:  dunningGracePeriod,
+				ReminderDays: dunningReminderDays,
+				FinalAfter:   dunningFinalAfter,
+				FinalAction:  dunningFinalAction,
+			},
+		},
+		Invoice: InvoiceConfig{
+			SellerName:         getEnv("INVOICE_SELLER_NAME", "GinVault"),
+			SellerEmail:        getEnv("INVOICE_SELLER_EMAIL", ""),
+			SellerAddressLine1: getEnv("INVOICE_SELLER_ADDRESS_LINE1", ""),
+			SellerAddressLine2: getEnv("INVOICE_SELLER_ADDRESS_LINE2", ""),
+			SellerPostalCode:   getEnv("INVOICE_SELLER_POSTAL_CODE", ""),
+			SellerCity:         getEnv("INVOICE_SELLER_CITY", ""),
+			SellerCountry:      getEnv("INVOICE_SELLER_COUNTRY", "DE"),
+			SellerVATID:        getEnv("INVOICE_SELLER_VAT_ID", ""),
+			VATRate:            vatRate,
+			NumberPrefix:       getEnv("INVOICE_NUMBER_PREFIX", "GV"),
 		},
 		SMTP: SMTPConfig{
 			Host:       getEnv("SMTP_HOST", "localhost"),
@@ -187,12 +348,27 @@ func Load() (*Config, error) {
 			SkipVerify: smtpSkipVerify,
 		},
 		App: AppConfig{
+			Name:           getEnv("APP_NAME", "Gin Collection"),
 			Env:            getEnv("APP_ENV", "development"),
 			Port:           appPort,
 			BaseURL:        getEnv("APP_BASE_URL", "http://localhost:8080"),
 			LogLevel:       getEnv("LOG_LEVEL", "info"),
 			AllowedOrigins: parseCSV(getEnv("ALLOWED_ORIGINS", "http://localhost:3000")),
 		},
+		WebAuthn: WebAuthnConfig{
+			RPID:    getEnv("WEBAUTHN_RP_ID", "localhost"),
+			RPName:  getEnv("WEBAUTHN_RP_NAME", getEnv("APP_NAME", "Gin Collection")),
+			Origins: parseCSV(getEnv("WEBAUTHN_ORIGINS", getEnv("ALLOWED_ORIGINS", "http://localhost:3000"))),
+		},
+		Email: EmailVerificationConfig{
+			RequiredTiers: parseCSV(getEnv("EMAIL_VERIFICATION_REQUIRED_TIERS", "")),
+		},
+		LoginProtection: LoginProtectionConfig{
+			LockoutThreshold:   lockoutThreshold,
+			LockoutDuration:    lockoutDuration,
+			LockoutMaxDuration: lockoutMaxDuration,
+			NotifyNewDevices:   getEnv("LOGIN_NOTIFY_NEW_DEVICES", "true") == "true",
+		},
 		AI: AIConfig{
 			Provider:        getEnv("AI_PROVIDER", "ollama"),
 			OllamaURL:       getEnv("OLLAMA_URL", "http://localhost:11434"),
@@ -216,9 +392,22 @@ func Load() (*Config, error) {
 		return nil, fmt.Errorf("DB_PASSWORD is required")
 	}
 
+	// Never sign upload URLs with the JWT secret itself
+	if cfg.Storage.UploadSecret == "" {
+		cfg.Storage.UploadSecret = deriveSecret(cfg.JWT.Secret, "storage-upload")
+	}
+
 	return cfg, nil
 }
 
+// deriveSecret derives a secret for a purpose from another one. Neither can
+// be computed from the other.
+func deriveSecret(secret, label string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(label))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
 // getEnv gets an environment variable with a fallback default value
 func getEnv(key, defaultValue string) string {
 	value := os.Getenv(key)
@@ -257,6 +446,27 @@ func parseCSV(s string) []string {
 	return result
 }
 
+// parseKeyValues parses comma-separated key=value pairs into a map, nil if empty
+func parseKeyValues(s string) map[string]string {
+	items := parseCSV(s)
+	if len(items) == 0 {
+		return nil
+	}
+
+	result := make(map[string]string, len(items))
+	for _, item := range items {
+		parts := splitString(item, '=')
+		if len(parts) != 2 {
+			continue
+		}
+		key, value := trimSpace(parts[0]), trimSpace(parts[1])
+		if key != "" && value != "" {
+			result[key] = value
+		}
+	}
+	return result
+}
+
 // splitString splits a string by delimiter
 func splitString(s string, delimiter rune) []string {
 	var result []string
@@ -12,6 +12,10 @@ const (
 	AccessTokenCookieName = "access_token"
 	// RefreshTokenCookieName is the name of the refresh token cookie
 	RefreshTokenCookieName = "refresh_token"
+	// TrustedDeviceCookieName is the name of the "remember this device" cookie
+	TrustedDeviceCookieName = "trusted_device"
+	// SSOStateCookieName is the name of the cookie binding an SSO login to the browser
+	SSOStateCookieName = "sso_state"
 )
 
 // CookieConfig holds cookie configuration
@@ -90,6 +94,56 @@ func ClearAuthCookies(c *gin.Context, cfg *CookieConfig) {
 	)
 }
 
+// SetTrustedDeviceCookie sets the cookie that lets a device skip the second factor
+func SetTrustedDeviceCookie(c *gin.Context, deviceToken string, cfg *CookieConfig, expiry time.Duration) {
+	c.SetSameSite(cfg.SameSite)
+	c.SetCookie(
+		TrustedDeviceCookieName,
+		deviceToken,
+		int(expiry.Seconds()),
+		"/api/v1/auth", // Only needed for login
+		cfg.Domain,
+		cfg.Secure,
+		true,
+	)
+}
+
+// GetTrustedDeviceFromCookie extracts the trusted device token from the request cookie
+func GetTrustedDeviceFromCookie(c *gin.Context) (string, error) {
+	return c.Cookie(TrustedDeviceCookieName)
+}
+
+// SetSSOStateCookie binds a pending SSO login to the browser. SAML responses
+// are posted cross-site by the identity provider, so with secure cookies the
+// cookie is sent cross-site too; plain HTTP (development) only supports OIDC.
+func SetSSOStateCookie(c *gin.Context, state string, cfg *CookieConfig, expiry time.Duration) {
+	if cfg.Secure {
+		c.SetSameSite(http.SameSiteNoneMode)
+	} else {
+		c.SetSameSite(http.SameSiteLaxMode)
+	}
+	c.SetCookie(
+		SSOStateCookieName,
+		state,
+		int(expiry.Seconds()),
+		"/api/v1/auth/sso", // Only needed for the callbacks
+		cfg.Domain,
+		cfg.Secure,
+		true,
+	)
+}
+
+// ClearSSOStateCookie removes the SSO state cookie after the login
+func ClearSSOStateCookie(c *gin.Context, cfg *CookieConfig) {
+	c.SetSameSite(cfg.SameSite)
+	c.SetCookie(SSOStateCookieName, "", -1, "/api/v1/auth/sso", cfg.Domain, cfg.Secure, true)
+}
+
+// GetSSOStateFromCookie extracts the SSO state from the request cookie
+func GetSSOStateFromCookie(c *gin.Context) (string, error) {
+	return c.Cookie(SSOStateCookieName)
+}
+
 // GetAccessTokenFromCookie extracts the access token from the request cookie
 func GetAccessTokenFromCookie(c *gin.Context) (string, error) {
 	return c.Cookie(AccessTokenCookieName)
@@ -8,22 +8,31 @@ import (
 	"github.com/google/uuid"
 )
 
+// TokenTypeRefresh marks refresh tokens, which are not accepted as access tokens
+const TokenTypeRefresh = "refresh"
+
+// RefreshTokenExpiry is the lifetime of a refresh token
+const RefreshTokenExpiry = 30 * 24 * time.Hour
+
 // JWTClaims represents the claims in a JWT token
 type JWTClaims struct {
-	UserID   int64  `json:"user_id"`
-	TenantID int64  `json:"tenant_id"`
-	Email    string `json:"email"`
-	Role     string `json:"role"`
+	UserID    int64  `json:"user_id"`
+	TenantID  int64  `json:"tenant_id"`
+	Email     string `json:"email"`
+	Role      string `json:"role"`
+	SessionID string `json:"sid,omitempty"` // login session, empty for tokens without one
+	Type      string `json:"typ,omitempty"`
 	jwt.RegisteredClaims
 }
 
 // GenerateToken generates a new JWT token for a user
-func GenerateToken(userID, tenantID int64, email, role, secret string, expiration time.Duration) (string, error) {
+func GenerateToken(userID, tenantID int64, email, role, sessionID, secret string, expiration time.Duration) (string, error) {
 	claims := JWTClaims{
-		UserID:   userID,
-		TenantID: tenantID,
-		Email:    email,
-		Role:     role,
+		UserID:    userID,
+		TenantID:  tenantID,
+		Email:     email,
+		Role:      role,
+		SessionID: sessionID,
 		RegisteredClaims: jwt.RegisteredClaims{
 			ID:        uuid.New().String(), // Unique Token ID for blacklisting
 			ExpiresAt: jwt.NewNumericDate(time.Now().Add(expiration)),
@@ -63,15 +72,18 @@ func ValidateToken(tokenString, secret string) (*JWTClaims, error) {
 	return claims, nil
 }
 
-// GenerateRefreshToken generates a refresh token (longer expiration)
-func GenerateRefreshToken(userID, tenantID int64, email, secret string) (string, error) {
+// GenerateRefreshToken generates a refresh token (longer expiration) for a
+// session and returns it with its token ID
+func GenerateRefreshToken(userID, tenantID int64, email, sessionID, secret string) (string, string, error) {
 	claims := JWTClaims{
-		UserID:   userID,
-		TenantID: tenantID,
-		Email:    email,
+		UserID:    userID,
+		TenantID:  tenantID,
+		Email:     email,
+		SessionID: sessionID,
+		Type:      TokenTypeRefresh,
 		RegisteredClaims: jwt.RegisteredClaims{
-			ID:        uuid.New().String(), // Unique Token ID for blacklisting
-			ExpiresAt: jwt.NewNumericDate(time.Now().Add(30 * 24 * time.Hour)), // 30 days
+			ID:        uuid.New().String(), // Unique Token ID for rotation and blacklisting
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(RefreshTokenExpiry)),
 			IssuedAt:  jwt.NewNumericDate(time.Now()),
 		},
 	}
@@ -79,8 +91,68 @@ func GenerateRefreshToken(userID, tenantID int64, email, secret string) (string,
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
 	tokenString, err := token.SignedString([]byte(secret))
 	if err != nil {
-		return "", fmt.Errorf("failed to sign refresh token: %w", err)
+		return "", "", fmt.Errorf("failed to sign refresh token: %w", err)
+	}
+
+	return tokenString, claims.ID, nil
+}
+
+// ChallengeClaims are the claims of a short-lived token that proves a first
+// authentication step (e.g. password) while a second one is pending
+type ChallengeClaims struct {
+	SubjectID int64  `json:"sub_id"`
+	TenantID  int64  `json:"tenant_id,omitempty"`
+	Purpose   string `json:"purpose"`
+	jwt.RegisteredClaims
+}
+
+// GenerateChallengeToken generates a challenge token for a purpose. It is
+// signed with a key derived from the secret and purpose, so it can never be
+// used as an access token.
+func GenerateChallengeToken(subjectID, tenantID int64, purpose, secret string, expiration time.Duration) (string, error) {
+	claims := ChallengeClaims{
+		SubjectID: subjectID,
+		TenantID:  tenantID,
+		Purpose:   purpose,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        uuid.New().String(),
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(expiration)),
+			IssuedAt:  jwt.NewNumericDate(time.Now()),
+			NotBefore: jwt.NewNumericDate(time.Now()),
+		},
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	tokenString, err := token.SignedString(challengeKey(secret, purpose))
+	if err != nil {
+		return "", fmt.Errorf("failed to sign challenge token: %w", err)
 	}
 
 	return tokenString, nil
 }
+
+// ValidateChallengeToken validates a challenge token for a purpose
+func ValidateChallengeToken(tokenString, purpose, secret string) (*ChallengeClaims, error) {
+	token, err := jwt.ParseWithClaims(tokenString, &ChallengeClaims{}, func(token *jwt.Token) (interface{}, error) {
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+		}
+		return challengeKey(secret, purpose), nil
+	})
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse challenge token: %w", err)
+	}
+
+	claims, ok := token.Claims.(*ChallengeClaims)
+	if !ok || !token.Valid || claims.Purpose != purpose {
+		return nil, fmt.Errorf("invalid challenge token")
+	}
+
+	return claims, nil
+}
+
+// challengeKey derives the signing key of challenge tokens
+func challengeKey(secret, purpose string) []byte {
+	return []byte(secret + ":challenge:" + purpose)
+}
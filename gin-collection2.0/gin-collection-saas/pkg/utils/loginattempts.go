@@ -0,0 +1,196 @@
+package utils
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/yourusername/gin-collection-saas/internal/infrastructure/cache"
+	"github.com/yourusername/gin-collection-saas/pkg/logger"
+)
+
+// loginAttemptPrefix names the keys of the account lockout
+const loginAttemptPrefix = "login"
+
+// maxLoginAttemptEntries is the size of the in-memory store above which
+// expired entries are removed
+const maxLoginAttemptEntries = 10000
+
+// LockoutPolicy configures the progressive account lockout. Reaching the
+// threshold locks the account for the base duration, every further failure
+// doubles it up to the maximum.
+type LockoutPolicy struct {
+	Threshold    int64
+	BaseDuration time.Duration
+	MaxDuration  time.Duration
+	Window       time.Duration // failures are forgotten after this time without a new one
+}
+
+// DefaultLockoutPolicy locks after 5 failures for 1 minute, at most 1 hour
+var DefaultLockoutPolicy = LockoutPolicy{
+	Threshold:    5,
+	BaseDuration: time.Minute,
+	MaxDuration:  time.Hour,
+	Window:       24 * time.Hour,
+}
+
+// lockoutFor returns how long an account is locked after the given number
+// of failed attempts
+func (p LockoutPolicy) lockoutFor(failures int64) time.Duration {
+	if p.Threshold <= 0 || failures < p.Threshold {
+		return 0
+	}
+
+	duration := p.BaseDuration
+	for i := p.Threshold; i < failures && duration < p.MaxDuration; i++ {
+		duration *= 2
+	}
+	if duration > p.MaxDuration {
+		duration = p.MaxDuration
+	}
+	return duration
+}
+
+// loginAttemptEntry is a counter or lock of the in-memory fallback
+type loginAttemptEntry struct {
+	count     int64
+	expiresAt time.Time
+}
+
+// LoginAttemptTracker counts failed logins per account and locks accounts
+// after too many of them. The state is kept in Redis so it applies to all
+// instances, an in-memory store is used without Redis or when it fails.
+type LoginAttemptTracker struct {
+	redis  *cache.RedisClient
+	policy LockoutPolicy
+	prefix string
+
+	mu      sync.Mutex
+	entries map[string]*loginAttemptEntry
+}
+
+// NewLoginAttemptTracker creates a new tracker, redis may be nil
+func NewLoginAttemptTracker(redis *cache.RedisClient, policy LockoutPolicy) *LoginAttemptTracker {
+	return NewAttemptTracker(redis, policy, loginAttemptPrefix)
+}
+
+// NewAttemptTracker creates a tracker whose keys start with prefix, so
+// counters of other subjects (e.g. platform admins) don't share the IDs of
+// user accounts
+func NewAttemptTracker(redis *cache.RedisClient, policy LockoutPolicy, prefix string) *LoginAttemptTracker {
+	return &LoginAttemptTracker{
+		redis:   redis,
+		policy:  policy,
+		prefix:  prefix,
+		entries: make(map[string]*loginAttemptEntry),
+	}
+}
+
+// keys returns the failure counter and lock keys of an account
+func (t *LoginAttemptTracker) keys(id int64) (string, string) {
+	return fmt.Sprintf("%s_failures:%d", t.prefix, id), fmt.Sprintf("%s_locked:%d", t.prefix, id)
+}
+
+// LockedFor returns the remaining lockout of an account, 0 if it isn't locked
+func (t *LoginAttemptTracker) LockedFor(ctx context.Context, userID int64) time.Duration {
+	_, key := t.keys(userID)
+
+	if t.redis != nil {
+		ttl, err := t.redis.TTL(ctx, key)
+		if err == nil {
+			if ttl < 0 {
+				return 0
+			}
+			return ttl
+		}
+		logger.Warn("Redis unavailable for login lockout, falling back to in-memory", "error", err.Error())
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	entry, ok := t.entries[key]
+	if !ok {
+		return 0
+	}
+	remaining := time.Until(entry.expiresAt)
+	if remaining <= 0 {
+		delete(t.entries, key)
+		return 0
+	}
+	return remaining
+}
+
+// RecordFailure counts a failed login and locks the account once the
+// threshold is reached. It returns the number of failures and the lockout
+// that started with this failure (0 if none).
+func (t *LoginAttemptTracker) RecordFailure(ctx context.Context, userID int64) (int64, time.Duration) {
+	failuresKey, lockedKey := t.keys(userID)
+
+	if t.redis != nil {
+		failures, err := t.redis.Incr(ctx, failuresKey)
+		if err == nil {
+			if err := t.redis.Expire(ctx, failuresKey, t.policy.Window); err != nil {
+				logger.Error("Failed to set login failure expiry", "error", err.Error())
+			}
+			lockout := t.policy.lockoutFor(failures)
+			if lockout > 0 {
+				if err := t.redis.Set(ctx, lockedKey, failures, lockout); err != nil {
+					logger.Error("Failed to lock account", "user_id", userID, "error", err.Error())
+				}
+			}
+			return failures, lockout
+		}
+		logger.Warn("Redis unavailable for login lockout, falling back to in-memory", "error", err.Error())
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	now := time.Now()
+	entry, ok := t.entries[failuresKey]
+	if !ok || now.After(entry.expiresAt) {
+		entry = &loginAttemptEntry{}
+		t.entries[failuresKey] = entry
+	}
+	entry.count++
+	entry.expiresAt = now.Add(t.policy.Window)
+
+	lockout := t.policy.lockoutFor(entry.count)
+	if lockout > 0 {
+		t.entries[lockedKey] = &loginAttemptEntry{count: entry.count, expiresAt: now.Add(lockout)}
+	}
+	if len(t.entries) > maxLoginAttemptEntries {
+		t.removeExpired(now)
+	}
+
+	return entry.count, lockout
+}
+
+// Reset forgets the failed logins of an account and unlocks it
+func (t *LoginAttemptTracker) Reset(ctx context.Context, userID int64) error {
+	failuresKey, lockedKey := t.keys(userID)
+
+	// Both stores are cleared, the fallback may hold state from a Redis outage
+	t.mu.Lock()
+	delete(t.entries, failuresKey)
+	delete(t.entries, lockedKey)
+	t.mu.Unlock()
+
+	if t.redis != nil {
+		if err := t.redis.Del(ctx, failuresKey, lockedKey); err != nil {
+			return fmt.Errorf("failed to reset login failures: %w", err)
+		}
+	}
+	return nil
+}
+
+// removeExpired drops expired in-memory entries, the caller holds the lock
+func (t *LoginAttemptTracker) removeExpired(now time.Time) {
+	for key, entry := range t.entries {
+		if now.After(entry.expiresAt) {
+			delete(t.entries, key)
+		}
+	}
+}
@@ -11,6 +11,7 @@ import (
 
 const blacklistPrefix = "blacklist:"
 const userRevokedPrefix = "user_revoked:"
+const sessionRevokedPrefix = "session_revoked:"
 
 // TokenBlacklist manages revoked JWT tokens
 type TokenBlacklist struct {
@@ -84,3 +85,28 @@ func (b *TokenBlacklist) IsUserTokenRevoked(ctx context.Context, userID int64, i
 	// Token is revoked if it was issued before the revocation timestamp
 	return issuedAt.Unix() < revokedSince
 }
+
+// RevokeSession revokes the access tokens of a login session
+// until: When the last access token of the session expires
+func (b *TokenBlacklist) RevokeSession(ctx context.Context, sessionID string, until time.Time) error {
+	if b.redis == nil {
+		return nil
+	}
+
+	ttl := time.Until(until)
+	if ttl <= 0 {
+		return nil
+	}
+
+	return b.redis.Set(ctx, sessionRevokedPrefix+sessionID, "revoked", ttl)
+}
+
+// IsSessionRevoked checks if the session of a token was revoked
+func (b *TokenBlacklist) IsSessionRevoked(ctx context.Context, sessionID string) bool {
+	if b.redis == nil || sessionID == "" {
+		return false
+	}
+
+	exists, _ := b.redis.Exists(ctx, sessionRevokedPrefix+sessionID)
+	return exists
+}
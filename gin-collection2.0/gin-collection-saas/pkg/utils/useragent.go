@@ -0,0 +1,75 @@
+package utils
+
+import "strings"
+
+// userAgentBrowsers are checked in order, Chromium based browsers also
+// announce Chrome and Safari
+var userAgentBrowsers = []struct {
+	token string
+	name  string
+}{
+	{"Edg/", "Edge"},
+	{"OPR/", "Opera"},
+	{"SamsungBrowser/", "Samsung Internet"},
+	{"Firefox/", "Firefox"},
+	{"FxiOS/", "Firefox"},
+	{"CriOS/", "Chrome"},
+	{"Chrome/", "Chrome"},
+	{"Safari/", "Safari"},
+}
+
+var userAgentSystems = []struct {
+	token string
+	name  string
+}{
+	{"iPhone", "iOS"},
+	{"iPad", "iPadOS"},
+	{"Android", "Android"},
+	{"CrOS", "ChromeOS"},
+	{"Windows", "Windows"},
+	{"Mac OS X", "macOS"},
+	{"Macintosh", "macOS"},
+	{"Linux", "Linux"},
+}
+
+// DescribeUserAgent returns a short description of the device of a user
+// agent, e.g. "Chrome on macOS". Other clients are named by their product.
+func DescribeUserAgent(userAgent string) string {
+	userAgent = strings.TrimSpace(userAgent)
+	if userAgent == "" {
+		return "Unknown device"
+	}
+
+	var browser, system string
+	for _, b := range userAgentBrowsers {
+		if strings.Contains(userAgent, b.token) {
+			browser = b.name
+			break
+		}
+	}
+	for _, s := range userAgentSystems {
+		if strings.Contains(userAgent, s.token) {
+			system = s.name
+			break
+		}
+	}
+
+	switch {
+	case browser != "" && system != "":
+		return browser + " on " + system
+	case browser != "":
+		return browser
+	case system != "":
+		return system
+	}
+
+	// API clients like curl/8.4.0 or okhttp/4.12.0
+	product := strings.Fields(userAgent)[0]
+	if i := strings.Index(product, "/"); i > 0 {
+		product = product[:i]
+	}
+	if len(product) > 50 {
+		product = product[:50]
+	}
+	return product
+}
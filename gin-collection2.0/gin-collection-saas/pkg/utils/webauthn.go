@@ -0,0 +1,396 @@
+package utils
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/subtle"
+	"crypto/x509"
+	"encoding/asn1"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/url"
+	"strings"
+)
+
+// WebAuthn ceremony types in the client data
+const (
+	WebAuthnTypeCreate = "webauthn.create"
+	WebAuthnTypeGet    = "webauthn.get"
+)
+
+// COSE algorithms supported for passkeys
+const (
+	COSEAlgES256 int64 = -7
+	COSEAlgEdDSA int64 = -8
+	COSEAlgRS256 int64 = -257
+)
+
+// Authenticator data flags
+const (
+	AuthenticatorFlagUserPresent    byte = 0x01
+	AuthenticatorFlagUserVerified   byte = 0x04
+	AuthenticatorFlagBackupEligible byte = 0x08
+	AuthenticatorFlagBackedUp       byte = 0x10
+	AuthenticatorFlagAttestedData   byte = 0x40
+	AuthenticatorFlagExtensionData  byte = 0x80
+	authenticatorDataMinLength           = 37
+	attestedCredentialDataMinLength      = 18
+)
+
+// Attestation formats verified by VerifyAttestation
+const (
+	AttestationFormatNone   = "none"
+	AttestationFormatPacked = "packed"
+)
+
+// ErrWebAuthn is returned for any malformed or unverifiable WebAuthn response
+var ErrWebAuthn = errors.New("webauthn verification failed")
+
+// oidFIDOGenCEAAGUID is the certificate extension holding the authenticator AAGUID
+var oidFIDOGenCEAAGUID = asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 45724, 1, 1, 4}
+
+// WebAuthnRelyingParty identifies this application to authenticators
+type WebAuthnRelyingParty struct {
+	ID      string   // effective domain, e.g. "example.com"
+	Name    string   // shown by the authenticator
+	Origins []string // allowed origins besides https subdomains of ID
+}
+
+// CollectedClientData is the clientDataJSON signed by the authenticator
+type CollectedClientData struct {
+	Type        string `json:"type"`
+	Challenge   string `json:"challenge"`
+	Origin      string `json:"origin"`
+	CrossOrigin bool   `json:"crossOrigin"`
+}
+
+// AuthenticatorData is the parsed authenticator data of a WebAuthn response
+type AuthenticatorData struct {
+	Raw                 []byte
+	RPIDHash            []byte
+	Flags               byte
+	SignCount           uint32
+	AAGUID              []byte // attested credential data, registration only
+	CredentialID        []byte
+	CredentialPublicKey []byte // COSE_Key
+}
+
+// HasFlag checks an authenticator data flag
+func (d *AuthenticatorData) HasFlag(flag byte) bool {
+	return d.Flags&flag != 0
+}
+
+// EncodeWebAuthnBase64 encodes binary WebAuthn values for JSON (base64url without padding)
+func EncodeWebAuthnBase64(data []byte) string {
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// DecodeWebAuthnBase64 decodes base64url values sent by the browser. Padding
+// is optional and standard base64 is accepted for older client libraries.
+func DecodeWebAuthnBase64(value string) ([]byte, error) {
+	value = strings.TrimRight(strings.TrimSpace(value), "=")
+	if data, err := base64.RawURLEncoding.DecodeString(value); err == nil {
+		return data, nil
+	}
+	return base64.RawStdEncoding.DecodeString(value)
+}
+
+// VerifyClientData checks type, challenge and origin of the clientDataJSON
+func (rp *WebAuthnRelyingParty) VerifyClientData(raw []byte, ceremonyType, challenge string) (*CollectedClientData, error) {
+	var clientData CollectedClientData
+	if err := json.Unmarshal(raw, &clientData); err != nil {
+		return nil, fmt.Errorf("%w: invalid client data", ErrWebAuthn)
+	}
+
+	if clientData.Type != ceremonyType {
+		return nil, fmt.Errorf("%w: unexpected client data type %q", ErrWebAuthn, clientData.Type)
+	}
+	if subtle.ConstantTimeCompare([]byte(strings.TrimRight(clientData.Challenge, "=")), []byte(challenge)) != 1 {
+		return nil, fmt.Errorf("%w: challenge mismatch", ErrWebAuthn)
+	}
+	if clientData.CrossOrigin {
+		return nil, fmt.Errorf("%w: cross-origin requests are not allowed", ErrWebAuthn)
+	}
+	if !rp.IsAllowedOrigin(clientData.Origin) {
+		return nil, fmt.Errorf("%w: origin %q not allowed", ErrWebAuthn, clientData.Origin)
+	}
+
+	return &clientData, nil
+}
+
+// IsAllowedOrigin accepts the configured origins and any https origin on the
+// RP ID or one of its subdomains (tenant subdomains)
+func (rp *WebAuthnRelyingParty) IsAllowedOrigin(origin string) bool {
+	for _, allowed := range rp.Origins {
+		if origin == strings.TrimRight(allowed, "/") {
+			return true
+		}
+	}
+
+	u, err := url.Parse(origin)
+	if err != nil || u.Scheme != "https" {
+		return false
+	}
+	host := u.Hostname()
+	return host == rp.ID || strings.HasSuffix(host, "."+rp.ID)
+}
+
+// VerifyAuthenticatorData checks the RP ID hash and the user presence flag
+func (rp *WebAuthnRelyingParty) VerifyAuthenticatorData(authData *AuthenticatorData) error {
+	expected := sha256.Sum256([]byte(rp.ID))
+	if !bytes.Equal(authData.RPIDHash, expected[:]) {
+		return fmt.Errorf("%w: RP ID hash mismatch", ErrWebAuthn)
+	}
+	if !authData.HasFlag(AuthenticatorFlagUserPresent) {
+		return fmt.Errorf("%w: user not present", ErrWebAuthn)
+	}
+	return nil
+}
+
+// ParseAuthenticatorData parses the binary authenticator data
+func ParseAuthenticatorData(data []byte) (*AuthenticatorData, error) {
+	if len(data) < authenticatorDataMinLength {
+		return nil, fmt.Errorf("%w: authenticator data too short", ErrWebAuthn)
+	}
+
+	authData := &AuthenticatorData{
+		Raw:       data,
+		RPIDHash:  data[:32],
+		Flags:     data[32],
+		SignCount: binary.BigEndian.Uint32(data[33:37]),
+	}
+	rest := data[authenticatorDataMinLength:]
+
+	if authData.HasFlag(AuthenticatorFlagAttestedData) {
+		if len(rest) < attestedCredentialDataMinLength {
+			return nil, fmt.Errorf("%w: attested credential data too short", ErrWebAuthn)
+		}
+		authData.AAGUID = rest[:16]
+		idLength := int(binary.BigEndian.Uint16(rest[16:18]))
+		rest = rest[18:]
+		if len(rest) < idLength {
+			return nil, fmt.Errorf("%w: credential ID truncated", ErrWebAuthn)
+		}
+		authData.CredentialID = rest[:idLength]
+		rest = rest[idLength:]
+
+		_, remaining, err := DecodeCBOR(rest)
+		if err != nil {
+			return nil, fmt.Errorf("%w: invalid credential public key", ErrWebAuthn)
+		}
+		authData.CredentialPublicKey = rest[:len(rest)-len(remaining)]
+		rest = remaining
+	}
+
+	if authData.HasFlag(AuthenticatorFlagExtensionData) {
+		_, remaining, err := DecodeCBOR(rest)
+		if err != nil {
+			return nil, fmt.Errorf("%w: invalid extension data", ErrWebAuthn)
+		}
+		rest = remaining
+	}
+
+	if len(rest) != 0 {
+		return nil, fmt.Errorf("%w: trailing authenticator data", ErrWebAuthn)
+	}
+
+	return authData, nil
+}
+
+// VerifyAttestation decodes an attestation object and verifies its statement
+// over authenticator data and client data hash. Supported formats are "none"
+// and "packed" (self attestation or x5c). Returns the authenticator data and
+// the attestation format.
+func VerifyAttestation(attestationObject, clientDataHash []byte) (*AuthenticatorData, string, error) {
+	decoded, rest, err := DecodeCBOR(attestationObject)
+	if err != nil || len(rest) != 0 {
+		return nil, "", fmt.Errorf("%w: invalid attestation object", ErrWebAuthn)
+	}
+	object, ok := decoded.(map[interface{}]interface{})
+	if !ok {
+		return nil, "", fmt.Errorf("%w: invalid attestation object", ErrWebAuthn)
+	}
+
+	format, _ := object["fmt"].(string)
+	rawAuthData, _ := object["authData"].([]byte)
+	statement, ok := object["attStmt"].(map[interface{}]interface{})
+	if !ok {
+		return nil, "", fmt.Errorf("%w: missing attestation statement", ErrWebAuthn)
+	}
+
+	authData, err := ParseAuthenticatorData(rawAuthData)
+	if err != nil {
+		return nil, "", err
+	}
+	if !authData.HasFlag(AuthenticatorFlagAttestedData) || len(authData.CredentialID) == 0 {
+		return nil, "", fmt.Errorf("%w: no attested credential", ErrWebAuthn)
+	}
+
+	switch format {
+	case AttestationFormatNone:
+		if len(statement) != 0 {
+			return nil, "", fmt.Errorf("%w: unexpected attestation statement", ErrWebAuthn)
+		}
+	case AttestationFormatPacked:
+		if err := verifyPackedAttestation(statement, authData, clientDataHash); err != nil {
+			return nil, "", err
+		}
+	default:
+		return nil, "", fmt.Errorf("%w: unsupported attestation format %q", ErrWebAuthn, format)
+	}
+
+	return authData, format, nil
+}
+
+// verifyPackedAttestation verifies a "packed" attestation statement (WebAuthn §8.2)
+func verifyPackedAttestation(statement map[interface{}]interface{}, authData *AuthenticatorData, clientDataHash []byte) error {
+	alg, ok := statement["alg"].(int64)
+	if !ok {
+		return fmt.Errorf("%w: missing attestation algorithm", ErrWebAuthn)
+	}
+	sig, ok := statement["sig"].([]byte)
+	if !ok {
+		return fmt.Errorf("%w: missing attestation signature", ErrWebAuthn)
+	}
+	signed := append(append([]byte(nil), authData.Raw...), clientDataHash...)
+
+	chain, hasCertificate := statement["x5c"].([]interface{})
+	if !hasCertificate {
+		// Self attestation, signed with the credential key itself
+		credentialAlg, publicKey, err := ParseCOSEKey(authData.CredentialPublicKey)
+		if err != nil {
+			return err
+		}
+		if credentialAlg != alg {
+			return fmt.Errorf("%w: attestation algorithm mismatch", ErrWebAuthn)
+		}
+		return verifyCOSESignature(alg, publicKey, signed, sig)
+	}
+
+	if len(chain) == 0 {
+		return fmt.Errorf("%w: empty certificate chain", ErrWebAuthn)
+	}
+	der, ok := chain[0].([]byte)
+	if !ok {
+		return fmt.Errorf("%w: invalid attestation certificate", ErrWebAuthn)
+	}
+	certificate, err := x509.ParseCertificate(der)
+	if err != nil {
+		return fmt.Errorf("%w: invalid attestation certificate", ErrWebAuthn)
+	}
+	if certificate.Version != 3 || certificate.IsCA {
+		return fmt.Errorf("%w: attestation certificate requirements not met", ErrWebAuthn)
+	}
+	for _, extension := range certificate.Extensions {
+		if !extension.Id.Equal(oidFIDOGenCEAAGUID) {
+			continue
+		}
+		var aaguid []byte
+		if _, err := asn1.Unmarshal(extension.Value, &aaguid); err != nil || !bytes.Equal(aaguid, authData.AAGUID) {
+			return fmt.Errorf("%w: attestation certificate AAGUID mismatch", ErrWebAuthn)
+		}
+	}
+
+	return verifyCOSESignature(alg, certificate.PublicKey, signed, sig)
+}
+
+// ParseCOSEKey parses a COSE_Key (RFC 9053) into its algorithm and public key
+func ParseCOSEKey(data []byte) (int64, crypto.PublicKey, error) {
+	decoded, rest, err := DecodeCBOR(data)
+	if err != nil || len(rest) != 0 {
+		return 0, nil, fmt.Errorf("%w: invalid public key", ErrWebAuthn)
+	}
+	key, ok := decoded.(map[interface{}]interface{})
+	if !ok {
+		return 0, nil, fmt.Errorf("%w: invalid public key", ErrWebAuthn)
+	}
+
+	kty, _ := key[int64(1)].(int64)
+	alg, _ := key[int64(3)].(int64)
+
+	switch {
+	case kty == 2 && alg == COSEAlgES256:
+		crv, _ := key[int64(-1)].(int64)
+		x, _ := key[int64(-2)].([]byte)
+		y, _ := key[int64(-3)].([]byte)
+		if crv != 1 || len(x) != 32 || len(y) != 32 {
+			return 0, nil, fmt.Errorf("%w: invalid EC2 key", ErrWebAuthn)
+		}
+		point := append(append([]byte{0x04}, x...), y...)
+		publicKey, err := ecdsa.ParseUncompressedPublicKey(elliptic.P256(), point)
+		if err != nil {
+			return 0, nil, fmt.Errorf("%w: invalid EC2 key", ErrWebAuthn)
+		}
+		return alg, publicKey, nil
+
+	case kty == 3 && alg == COSEAlgRS256:
+		n, _ := key[int64(-1)].([]byte)
+		e, _ := key[int64(-2)].([]byte)
+		if len(n) < 256 || len(e) == 0 || len(e) > 4 {
+			return 0, nil, fmt.Errorf("%w: invalid RSA key", ErrWebAuthn)
+		}
+		exponent := 0
+		for _, b := range e {
+			exponent = exponent<<8 | int(b)
+		}
+		return alg, &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: exponent}, nil
+
+	case kty == 1 && alg == COSEAlgEdDSA:
+		crv, _ := key[int64(-1)].(int64)
+		x, _ := key[int64(-2)].([]byte)
+		if crv != 6 || len(x) != ed25519.PublicKeySize {
+			return 0, nil, fmt.Errorf("%w: invalid OKP key", ErrWebAuthn)
+		}
+		return alg, ed25519.PublicKey(x), nil
+	}
+
+	return 0, nil, fmt.Errorf("%w: unsupported key type %d / algorithm %d", ErrWebAuthn, kty, alg)
+}
+
+// VerifyAssertionSignature verifies an assertion signature over authenticator
+// data and client data hash with a stored COSE public key
+func VerifyAssertionSignature(coseKey, authData, clientDataHash, sig []byte) error {
+	alg, publicKey, err := ParseCOSEKey(coseKey)
+	if err != nil {
+		return err
+	}
+	signed := append(append([]byte(nil), authData...), clientDataHash...)
+	return verifyCOSESignature(alg, publicKey, signed, sig)
+}
+
+func verifyCOSESignature(alg int64, publicKey crypto.PublicKey, data, sig []byte) error {
+	valid := false
+
+	switch alg {
+	case COSEAlgES256:
+		if key, ok := publicKey.(*ecdsa.PublicKey); ok {
+			digest := sha256.Sum256(data)
+			valid = ecdsa.VerifyASN1(key, digest[:], sig)
+		}
+	case COSEAlgRS256:
+		if key, ok := publicKey.(*rsa.PublicKey); ok {
+			digest := sha256.Sum256(data)
+			valid = rsa.VerifyPKCS1v15(key, crypto.SHA256, digest[:], sig) == nil
+		}
+	case COSEAlgEdDSA:
+		if key, ok := publicKey.(ed25519.PublicKey); ok {
+			valid = ed25519.Verify(key, data, sig)
+		}
+	default:
+		return fmt.Errorf("%w: unsupported algorithm %d", ErrWebAuthn, alg)
+	}
+
+	if !valid {
+		return fmt.Errorf("%w: invalid signature", ErrWebAuthn)
+	}
+	return nil
+}
@@ -0,0 +1,175 @@
+package integration
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	domainErrors "github.com/yourusername/gin-collection-saas/internal/domain/errors"
+	"github.com/yourusername/gin-collection-saas/internal/domain/models"
+	"github.com/yourusername/gin-collection-saas/internal/repository/mysql"
+	"github.com/yourusername/gin-collection-saas/tests/testutil"
+)
+
+// TestCouponRedemptions_Limit verifies that concurrent checkouts can't redeem
+// a coupon more often than its redemption limit allows
+func TestCouponRedemptions_Limit(t *testing.T) {
+	testDB := testutil.SetupTestDB(t)
+	defer testDB.Teardown(t)
+	testDB.ApplyMigrations(t)
+
+	couponRepo := mysql.NewCouponRepository(testDB.DB)
+
+	ctx := context.Background()
+
+	const tenantCount = 5
+	tenantIDs := make([]int64, tenantCount)
+	for i := range tenantIDs {
+		result, err := testDB.DB.ExecContext(ctx, `
+			INSERT INTO tenants (uuid, name, subdomain, tier, status)
+			VALUES (?, ?, ?, ?, ?)
+		`, uuid.New().String(), fmt.Sprintf("Tenant %d", i), fmt.Sprintf("tenant%d", i), "free", "active")
+		if err != nil {
+			t.Fatal(err)
+		}
+		tenantIDs[i], _ = result.LastInsertId()
+	}
+
+	coupon := &models.Coupon{
+		Code:           "LAUNCH",
+		DiscountType:   models.CouponDiscountPercent,
+		DiscountValue:  20,
+		Currency:       "EUR",
+		Duration:       models.CouponDurationOnce,
+		MaxRedemptions: intPtr(1),
+		Active:         true,
+	}
+	if err := couponRepo.Create(ctx, coupon); err != nil {
+		t.Fatalf("Failed to create coupon: %v", err)
+	}
+
+	reservation := func(tenantID int64, expiresAt time.Time) *models.CouponRedemption {
+		return &models.CouponRedemption{CouponID: coupon.ID, TenantID: tenantID, Discount: 2, ExpiresAt: &expiresAt}
+	}
+	redemptions := func(t *testing.T) int {
+		t.Helper()
+		stored, err := couponRepo.GetByID(ctx, coupon.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return stored.Redemptions
+	}
+
+	var reserved int64
+
+	t.Run("ConcurrentReservations_OneWins", func(t *testing.T) {
+		errs := make(chan error, tenantCount)
+		winners := make(chan int64, tenantCount)
+		var wg sync.WaitGroup
+		for _, tenantID := range tenantIDs {
+			wg.Add(1)
+			go func(tenantID int64) {
+				defer wg.Done()
+				err := couponRepo.Reserve(ctx, reservation(tenantID, time.Now().Add(24*time.Hour)))
+				if err == nil {
+					winners <- tenantID
+				}
+				errs <- err
+			}(tenantID)
+		}
+		wg.Wait()
+		close(errs)
+		close(winners)
+
+		for err := range errs {
+			if err != nil && err != domainErrors.ErrLimitReached {
+				t.Errorf("Unexpected error: %v", err)
+			}
+		}
+		if len(winners) != 1 {
+			t.Fatalf("Expected one reservation, got %d", len(winners))
+		}
+		reserved = <-winners
+
+		if count := redemptions(t); count != 1 {
+			t.Errorf("Expected 1 redemption counted, got %d", count)
+		}
+	})
+
+	t.Run("Redeem_ConfirmsReservation", func(t *testing.T) {
+		if err := couponRepo.Redeem(ctx, &models.CouponRedemption{CouponID: coupon.ID, TenantID: reserved, Discount: 2}); err != nil {
+			t.Fatalf("Failed to redeem reserved coupon: %v", err)
+		}
+
+		redemption, err := couponRepo.GetRedemption(ctx, coupon.ID, reserved)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if redemption.Status != models.CouponRedemptionRedeemed || redemption.ExpiresAt != nil {
+			t.Errorf("Expected a redeemed redemption, got %+v", redemption)
+		}
+		if count := redemptions(t); count != 1 {
+			t.Errorf("Expected the reservation to be counted once, got %d", count)
+		}
+
+		// The limit is used up for every other tenant
+		for _, tenantID := range tenantIDs {
+			if tenantID == reserved {
+				continue
+			}
+			err := couponRepo.Redeem(ctx, &models.CouponRedemption{CouponID: coupon.ID, TenantID: tenantID, Discount: 2})
+			if err != domainErrors.ErrLimitReached {
+				t.Errorf("Expected ErrLimitReached for tenant %d, got %v", tenantID, err)
+			}
+		}
+	})
+
+	t.Run("ReleasedReservations_FreeTheLimit", func(t *testing.T) {
+		// One more redemption for the rest of the test
+		if _, err := testDB.DB.ExecContext(ctx, `UPDATE coupons SET max_redemptions = 2 WHERE id = ?`, coupon.ID); err != nil {
+			t.Fatal(err)
+		}
+		var others []int64
+		for _, tenantID := range tenantIDs {
+			if tenantID != reserved {
+				others = append(others, tenantID)
+			}
+		}
+
+		// A failed checkout releases its reservation
+		if err := couponRepo.Reserve(ctx, reservation(others[0], time.Now().Add(24*time.Hour))); err != nil {
+			t.Fatalf("Failed to reserve coupon: %v", err)
+		}
+		if err := couponRepo.Release(ctx, coupon.ID, others[0]); err != nil {
+			t.Fatalf("Failed to release coupon: %v", err)
+		}
+		if count := redemptions(t); count != 1 {
+			t.Errorf("Expected the released reservation to be uncounted, got %d", count)
+		}
+
+		// An abandoned checkout's reservation expires
+		if err := couponRepo.Reserve(ctx, reservation(others[1], time.Now().Add(-24*time.Hour))); err != nil {
+			t.Fatalf("Failed to reserve coupon: %v", err)
+		}
+		released, err := couponRepo.ReleaseExpired(ctx)
+		if err != nil {
+			t.Fatalf("Failed to release expired reservations: %v", err)
+		}
+		if released != 1 {
+			t.Errorf("Expected 1 expired reservation released, got %d", released)
+		}
+		if _, err := couponRepo.GetRedemption(ctx, coupon.ID, others[1]); err != domainErrors.ErrNotFound {
+			t.Errorf("Expected the expired reservation to be deleted, got %v", err)
+		}
+
+		if err := couponRepo.Reserve(ctx, reservation(others[2], time.Now().Add(24*time.Hour))); err != nil {
+			t.Errorf("Expected the freed redemption to be reservable, got %v", err)
+		}
+		if count := redemptions(t); count != 2 {
+			t.Errorf("Expected 2 redemptions counted, got %d", count)
+		}
+	})
+}
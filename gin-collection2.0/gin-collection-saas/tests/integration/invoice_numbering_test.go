@@ -0,0 +1,129 @@
+package integration
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	domainErrors "github.com/yourusername/gin-collection-saas/internal/domain/errors"
+	"github.com/yourusername/gin-collection-saas/internal/domain/models"
+	"github.com/yourusername/gin-collection-saas/internal/repository/mysql"
+	"github.com/yourusername/gin-collection-saas/tests/testutil"
+)
+
+// TestInvoiceNumbering_Concurrent verifies that invoice numbers are sequential
+// per year without gaps or duplicates when payments are invoiced concurrently
+func TestInvoiceNumbering_Concurrent(t *testing.T) {
+	testDB := testutil.SetupTestDB(t)
+	defer testDB.Teardown(t)
+	testDB.ApplyMigrations(t)
+
+	tenantID, _, _, _ := testDB.SeedTestData(t)
+
+	invoiceRepo := mysql.NewInvoiceRepository(testDB.DB)
+
+	ctx := context.Background()
+
+	newInvoice := func(paymentID string, issuedAt time.Time) *models.Invoice {
+		return &models.Invoice{
+			TenantID:          tenantID,
+			Provider:          "stripe",
+			ProviderPaymentID: paymentID,
+			PlanID:            "PLAN_PRO_MONTHLY",
+			Description:       "Pro (monthly)",
+			Currency:          "EUR",
+			NetAmount:         8.39,
+			TaxRate:           19,
+			TaxAmount:         1.6,
+			TotalAmount:       9.99,
+			Seller:            &models.BillingDetails{Name: "GinVault GmbH", Country: "DE"},
+			IssuedAt:          issuedAt,
+		}
+	}
+	issuedAt := time.Date(2026, 3, 1, 10, 0, 0, 0, time.UTC)
+
+	// createConcurrently creates the invoices of the payments at the same
+	// time and returns the numbers of the created ones
+	createConcurrently := func(paymentIDs []string) ([]string, []error) {
+		numbers := make(chan string, len(paymentIDs))
+		errs := make(chan error, len(paymentIDs))
+		var wg sync.WaitGroup
+		for _, paymentID := range paymentIDs {
+			wg.Add(1)
+			go func(paymentID string) {
+				defer wg.Done()
+				invoice := newInvoice(paymentID, issuedAt)
+				if err := invoiceRepo.Create(ctx, invoice, "GV"); err != nil {
+					errs <- err
+					return
+				}
+				numbers <- invoice.Number
+			}(paymentID)
+		}
+		wg.Wait()
+		close(numbers)
+		close(errs)
+
+		var created []string
+		for number := range numbers {
+			created = append(created, number)
+		}
+		sort.Strings(created)
+		var failed []error
+		for err := range errs {
+			failed = append(failed, err)
+		}
+		return created, failed
+	}
+
+	t.Run("ConcurrentPayments_SequentialNumbers", func(t *testing.T) {
+		var paymentIDs []string
+		for i := 1; i <= 10; i++ {
+			paymentIDs = append(paymentIDs, fmt.Sprintf("in_%d", i))
+		}
+
+		numbers, errs := createConcurrently(paymentIDs)
+		if len(errs) > 0 {
+			t.Fatalf("Failed to create invoices: %v", errs)
+		}
+		for i, number := range numbers {
+			if want := models.InvoiceNumber("GV", 2026, i+1); number != want {
+				t.Errorf("Expected invoice number %s, got %s", want, number)
+			}
+		}
+	})
+
+	t.Run("DuplicatePayment_InvoicedOnce", func(t *testing.T) {
+		numbers, errs := createConcurrently([]string{"in_dup", "in_dup", "in_dup", "in_dup", "in_dup"})
+		if len(numbers) != 1 || numbers[0] != models.InvoiceNumber("GV", 2026, 11) {
+			t.Fatalf("Expected one invoice numbered 11, got %v", numbers)
+		}
+		for _, err := range errs {
+			if err != domainErrors.ErrConflict {
+				t.Errorf("Expected ErrConflict, got %v", err)
+			}
+		}
+
+		// The rejected duplicates used no numbers
+		invoice := newInvoice("in_next", issuedAt)
+		if err := invoiceRepo.Create(ctx, invoice, "GV"); err != nil {
+			t.Fatal(err)
+		}
+		if want := models.InvoiceNumber("GV", 2026, 12); invoice.Number != want {
+			t.Errorf("Expected invoice number %s, got %s", want, invoice.Number)
+		}
+	})
+
+	t.Run("NewYear_RestartsNumbering", func(t *testing.T) {
+		invoice := newInvoice("in_2027", time.Date(2027, 1, 2, 10, 0, 0, 0, time.UTC))
+		if err := invoiceRepo.Create(ctx, invoice, "GV"); err != nil {
+			t.Fatal(err)
+		}
+		if want := models.InvoiceNumber("GV", 2027, 1); invoice.Number != want {
+			t.Errorf("Expected invoice number %s, got %s", want, invoice.Number)
+		}
+	})
+}
@@ -0,0 +1,113 @@
+package integration
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/google/uuid"
+	domainErrors "github.com/yourusername/gin-collection-saas/internal/domain/errors"
+	"github.com/yourusername/gin-collection-saas/internal/domain/models"
+	"github.com/yourusername/gin-collection-saas/internal/repository/mysql"
+	"github.com/yourusername/gin-collection-saas/tests/testutil"
+)
+
+// TestPhotoQuota_ConcurrentCreates verifies that the photo and storage limits
+// hold when photos of a tenant are created at the same time
+func TestPhotoQuota_ConcurrentCreates(t *testing.T) {
+	testDB := testutil.SetupTestDB(t)
+	defer testDB.Teardown(t)
+	testDB.ApplyMigrations(t)
+
+	tenantID, _, _, _ := testDB.SeedTestData(t)
+
+	photoRepo := mysql.NewPhotoRepository(testDB.DB)
+	usageRepo := mysql.NewStorageUsageRepository(testDB.DB)
+
+	ctx := context.Background()
+
+	tests := []struct {
+		name      string
+		quota     *models.PhotoQuota
+		sizeBytes int64
+		want      int
+		wantErr   error
+	}{
+		{"Photo limit", &models.PhotoQuota{MaxPhotosPerGin: 3}, 1024, 3, domainErrors.ErrPhotoLimitReached},
+		{"Storage limit", &models.PhotoQuota{MaxPhotosPerGin: -1, StorageLimitMB: intPtr(1)}, 300 * 1000, 3, domainErrors.ErrStorageLimitReached},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Start from an empty collection
+			if _, err := testDB.DB.ExecContext(ctx, `DELETE FROM gins WHERE tenant_id = ?`, tenantID); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := testDB.DB.ExecContext(ctx, `DELETE FROM tenant_storage_usage WHERE tenant_id = ?`, tenantID); err != nil {
+				t.Fatal(err)
+			}
+			result, err := testDB.DB.ExecContext(ctx, `
+				INSERT INTO gins (tenant_id, uuid, name)
+				VALUES (?, ?, ?)
+			`, tenantID, uuid.New().String(), "Gin A")
+			if err != nil {
+				t.Fatal(err)
+			}
+			ginID, _ := result.LastInsertId()
+
+			const attempts = 10
+			errs := make(chan error, attempts)
+			var wg sync.WaitGroup
+			for i := 0; i < attempts; i++ {
+				wg.Add(1)
+				go func(i int) {
+					defer wg.Done()
+					key := fmt.Sprintf("tenants/%d/gins/%d/photo-%d.jpg", tenantID, ginID, i)
+					size := tt.sizeBytes
+					errs <- photoRepo.Create(ctx, &models.GinPhoto{
+						TenantID:      tenantID,
+						GinID:         ginID,
+						PhotoURL:      "https://photos.example.com/" + key,
+						PhotoType:     models.PhotoTypeBottle,
+						StorageKey:    &key,
+						FileSizeBytes: &size,
+					}, tt.quota)
+				}(i)
+			}
+			wg.Wait()
+			close(errs)
+
+			created := 0
+			for err := range errs {
+				switch err {
+				case nil:
+					created++
+				case tt.wantErr:
+				default:
+					t.Errorf("Unexpected error: %v", err)
+				}
+			}
+			if created != tt.want {
+				t.Errorf("Expected %d photos created, got %d", tt.want, created)
+			}
+
+			count, err := photoRepo.CountByGinID(ctx, tenantID, ginID)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if count != tt.want {
+				t.Errorf("Expected %d photos stored, got %d", tt.want, count)
+			}
+
+			usage, err := usageRepo.GetUsage(ctx, tenantID)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if usage.PhotoCount != tt.want || usage.BytesUsed != int64(tt.want)*tt.sizeBytes {
+				t.Errorf("Expected %d photos and %d bytes booked, got %d and %d",
+					tt.want, int64(tt.want)*tt.sizeBytes, usage.PhotoCount, usage.BytesUsed)
+			}
+		})
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"testing"
 
 	"github.com/google/uuid"
+	"github.com/yourusername/gin-collection-saas/internal/domain/models"
 	"github.com/yourusername/gin-collection-saas/internal/repository/mysql"
 	"github.com/yourusername/gin-collection-saas/tests/testutil"
 )
@@ -44,7 +45,7 @@ func TestTenantIsolation_GinRepository(t *testing.T) {
 
 	// Test: Tenant 1 should only see their own gin
 	t.Run("Tenant1_CanOnlySeeOwnGins", func(t *testing.T) {
-		gins, err := ginRepo.List(ctx, tenant1ID, 10, 0, nil)
+		gins, err := ginRepo.List(ctx, &models.GinFilter{TenantID: tenant1ID, Limit: 10})
 		if err != nil {
 			t.Fatalf("Failed to list gins for tenant 1: %v", err)
 		}
@@ -60,7 +61,7 @@ func TestTenantIsolation_GinRepository(t *testing.T) {
 
 	// Test: Tenant 2 should only see their own gin
 	t.Run("Tenant2_CanOnlySeeOwnGins", func(t *testing.T) {
-		gins, err := ginRepo.List(ctx, tenant2ID, 10, 0, nil)
+		gins, err := ginRepo.List(ctx, &models.GinFilter{TenantID: tenant2ID, Limit: 10})
 		if err != nil {
 			t.Fatalf("Failed to list gins for tenant 2: %v", err)
 		}
@@ -77,7 +78,7 @@ func TestTenantIsolation_GinRepository(t *testing.T) {
 	// Test: Cross-tenant data leak prevention
 	t.Run("CrossTenant_DataLeakPrevention", func(t *testing.T) {
 		// Try to get tenant 1's gin with tenant 2's context
-		tenant1Gins, _ := ginRepo.List(ctx, tenant1ID, 10, 0, nil)
+		tenant1Gins, _ := ginRepo.List(ctx, &models.GinFilter{TenantID: tenant1ID, Limit: 10})
 		if len(tenant1Gins) == 0 {
 			t.Fatal("No gins found for tenant 1")
 		}
@@ -7,7 +7,7 @@ import (
 	"github.com/google/uuid"
 	"github.com/yourusername/gin-collection-saas/internal/domain/models"
 	"github.com/yourusername/gin-collection-saas/internal/repository/mysql"
-	ginUsecase "github.com/yourusername/gin-collection-saas/internal/usecase/gin"
+	entitlementUsecase "github.com/yourusername/gin-collection-saas/internal/usecase/entitlement"
 	userUsecase "github.com/yourusername/gin-collection-saas/internal/usecase/user"
 	"github.com/yourusername/gin-collection-saas/tests/testutil"
 )
@@ -196,18 +196,21 @@ func TestTierEnforcement_FeatureAccess(t *testing.T) {
 			}
 
 			// Get limits based on tier
-			limits := models.TierLimits[models.TenantTier(tierValue)]
+			limits := models.PlanLimitsMap[models.SubscriptionTier(tierValue)]
+
+			included := map[string]bool{
+				"botanicals":     limits.HasBotanicals,
+				"cocktails":      limits.HasCocktails,
+				"ai_suggestions": limits.HasAISuggestions,
+				"export":         limits.HasExport,
+				"import":         limits.HasImport,
+				"multi_user":     limits.HasMultiUser,
+				"api_access":     limits.HasAPIAccess,
+			}
 
 			// Verify features
 			for _, feature := range expectedFeatures {
-				hasFeature := false
-				for _, f := range limits.Features {
-					if f == feature {
-						hasFeature = true
-						break
-					}
-				}
-				if !hasFeature {
+				if !included[feature] {
 					t.Errorf("Tier %s should have feature %s", tier, feature)
 				}
 			}
@@ -227,7 +230,10 @@ func TestTierEnforcement_MultiUserRestriction(t *testing.T) {
 	userRepo := mysql.NewUserRepository(testDB.DB)
 	auditRepo := mysql.NewAuditLogRepository(testDB.DB)
 
-	userService := userUsecase.NewService(userRepo, tenantRepo, auditRepo)
+	inviteRepo := mysql.NewInviteTokenRepository(testDB.DB)
+	entitlements := entitlementUsecase.NewService(mysql.NewStorageUsageRepository(testDB.DB))
+
+	userService := userUsecase.NewService(userRepo, tenantRepo, auditRepo, inviteRepo, entitlements, nil, "")
 
 	tests := []struct {
 		tier      string
@@ -331,7 +337,7 @@ func TestTierEnforcement_StorageLimits(t *testing.T) {
 				t.Fatal(err)
 			}
 
-			limits := models.TierLimits[models.TenantTier(tierValue)]
+			limits := models.PlanLimitsMap[models.SubscriptionTier(tierValue)]
 
 			// Verify storage limit matches
 			if tt.storageLimMB == nil && limits.StorageLimitMB != nil {
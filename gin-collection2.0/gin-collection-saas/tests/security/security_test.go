@@ -5,7 +5,11 @@ import (
 	"testing"
 
 	"github.com/google/uuid"
+	"github.com/yourusername/gin-collection-saas/internal/domain/models"
 	"github.com/yourusername/gin-collection-saas/internal/repository/mysql"
+	"github.com/yourusername/gin-collection-saas/internal/usecase/apikey"
+	"github.com/yourusername/gin-collection-saas/internal/usecase/entitlement"
+	"github.com/yourusername/gin-collection-saas/pkg/utils"
 	"github.com/yourusername/gin-collection-saas/tests/testutil"
 	"golang.org/x/crypto/bcrypt"
 )
@@ -81,22 +85,28 @@ func TestSecurity_SQLInjectionPrevention(t *testing.T) {
 	}
 }
 
-// TestSecurity_APIKeyFormat verifies API keys are properly formatted
+// TestSecurity_APIKeyFormat verifies API keys are properly formatted and only stored as hashes
 func TestSecurity_APIKeyFormat(t *testing.T) {
 	testDB := testutil.SetupTestDB(t)
 	defer testDB.Teardown(t)
 	testDB.RunMigrations(t)
 
 	ctx := context.Background()
-	tenant1ID, _, user1ID, _ := testDB.SeedTestData(t)
+	_, tenant2ID, _, user2ID := testDB.SeedTestData(t)
 
-	userRepo := mysql.NewUserRepository(testDB.DB)
+	apiKeyRepo := mysql.NewAPIKeyRepository(testDB.DB)
+	auditLogRepo := mysql.NewAuditLogRepository(testDB.DB)
+	service := apikey.NewService(apiKeyRepo, mysql.NewUserRepository(testDB.DB), mysql.NewTenantRepository(testDB.DB), auditLogRepo, entitlement.NewService(nil))
 
-	// Generate API key
-	apiKey, err := userRepo.GenerateAPIKey(ctx, user1ID)
+	// Generate API key (tenant 2 is Pro)
+	created, err := service.Create(ctx, tenant2ID, user2ID, &models.CreateAPIKeyRequest{
+		Name:   "CI",
+		Scopes: []string{models.ScopeGinsRead},
+	})
 	if err != nil {
 		t.Fatalf("Failed to generate API key: %v", err)
 	}
+	apiKey := created.Key
 
 	// Verify format
 	if len(apiKey) < 40 {
@@ -107,18 +117,22 @@ func TestSecurity_APIKeyFormat(t *testing.T) {
 		t.Error("API key should start with 'sk_'")
 	}
 
-	// Verify it can be retrieved
-	user, err := userRepo.GetByAPIKey(ctx, apiKey)
+	// Verify only the hash is stored and it can be retrieved
+	key, err := apiKeyRepo.GetByHash(ctx, utils.HashToken(apiKey))
 	if err != nil {
-		t.Fatalf("Failed to get user by API key: %v", err)
+		t.Fatalf("Failed to get API key by hash: %v", err)
+	}
+
+	if key.KeyHash == apiKey {
+		t.Error("API key should not be stored in plaintext")
 	}
 
-	if user.ID != user1ID {
+	if key.UserID != user2ID {
 		t.Error("Retrieved user should match")
 	}
 
-	if user.TenantID != tenant1ID {
-		t.Error("User should belong to correct tenant")
+	if key.TenantID != tenant2ID {
+		t.Error("API key should belong to correct tenant")
 	}
 }
 
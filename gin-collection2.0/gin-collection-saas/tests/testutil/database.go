@@ -3,6 +3,10 @@ package testutil
 import (
 	"database/sql"
 	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
 	"testing"
 
 	_ "github.com/go-sql-driver/mysql"
@@ -33,7 +37,7 @@ func SetupTestDB(t *testing.T) *TestDB {
 	}
 
 	// Connect to the test database
-	testDB, err := sql.Open("mysql", fmt.Sprintf("root:test_password@tcp(localhost:3306)/%s?parseTime=true", dbName))
+	testDB, err := sql.Open("mysql", fmt.Sprintf("root:test_password@tcp(localhost:3306)/%s?parseTime=true&multiStatements=true", dbName))
 	if err != nil {
 		db.Exec(fmt.Sprintf("DROP DATABASE %s", dbName))
 		t.Fatalf("Failed to connect to test database: %v", err)
@@ -94,15 +98,42 @@ func (tdb *TestDB) RunMigrations(t *testing.T) {
 		first_name VARCHAR(100),
 		last_name VARCHAR(100),
 		role VARCHAR(20) NOT NULL DEFAULT 'member',
-		api_key VARCHAR(100),
+		custom_role_id BIGINT NULL,
 		is_active BOOLEAN DEFAULT TRUE,
 		email_verified_at TIMESTAMP NULL,
 		last_login_at TIMESTAMP NULL,
 		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
 		updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
 		UNIQUE KEY unique_tenant_email (tenant_id, email),
-		INDEX idx_tenant_id (tenant_id),
-		INDEX idx_api_key (api_key)
+		INDEX idx_tenant_id (tenant_id)
+	);
+
+	CREATE TABLE IF NOT EXISTS custom_roles (
+		id BIGINT PRIMARY KEY AUTO_INCREMENT,
+		tenant_id BIGINT NOT NULL,
+		name VARCHAR(50) NOT NULL,
+		description VARCHAR(255) NULL,
+		permissions JSON NOT NULL,
+		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+		updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+		UNIQUE KEY unique_tenant_name (tenant_id, name)
+	);
+
+	CREATE TABLE IF NOT EXISTS api_keys (
+		id BIGINT PRIMARY KEY AUTO_INCREMENT,
+		uuid VARCHAR(36) UNIQUE NOT NULL,
+		tenant_id BIGINT NOT NULL,
+		user_id BIGINT NOT NULL,
+		name VARCHAR(100) NOT NULL,
+		key_prefix VARCHAR(16) NOT NULL,
+		key_hash CHAR(64) UNIQUE NOT NULL,
+		scopes JSON NOT NULL,
+		rate_limit INT NULL,
+		expires_at TIMESTAMP NULL,
+		last_used_at TIMESTAMP NULL,
+		last_used_ip VARCHAR(45) NULL,
+		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+		revoked_at TIMESTAMP NULL
 	);
 
 	CREATE TABLE IF NOT EXISTS gins (
@@ -149,6 +180,31 @@ func (tdb *TestDB) RunMigrations(t *testing.T) {
 	}
 }
 
+// ApplyMigrations applies the up migrations of the application in order, for
+// tests that need the full schema
+func (tdb *TestDB) ApplyMigrations(t *testing.T) {
+	t.Helper()
+
+	_, file, _, _ := runtime.Caller(0)
+	dir := filepath.Join(filepath.Dir(file), "..", "..", "internal", "infrastructure", "database", "migrations")
+
+	files, err := filepath.Glob(filepath.Join(dir, "*.up.sql"))
+	if err != nil || len(files) == 0 {
+		t.Fatalf("Failed to find migrations in %s: %v", dir, err)
+	}
+	sort.Strings(files)
+
+	for _, file := range files {
+		content, err := os.ReadFile(file)
+		if err != nil {
+			t.Fatalf("Failed to read migration %s: %v", filepath.Base(file), err)
+		}
+		if _, err := tdb.DB.Exec(string(content)); err != nil {
+			t.Fatalf("Failed to apply migration %s: %v", filepath.Base(file), err)
+		}
+	}
+}
+
 // SeedTestData inserts test data
 func (tdb *TestDB) SeedTestData(t *testing.T) (tenant1ID, tenant2ID, user1ID, user2ID int64) {
 	t.Helper()
@@ -0,0 +1,408 @@
+package unit
+
+import (
+	"context"
+	"encoding/json"
+	stdErrors "errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/yourusername/gin-collection-saas/internal/delivery/http/middleware"
+	"github.com/yourusername/gin-collection-saas/internal/domain/errors"
+	"github.com/yourusername/gin-collection-saas/internal/domain/models"
+	"github.com/yourusername/gin-collection-saas/internal/usecase/apikey"
+	"github.com/yourusername/gin-collection-saas/internal/usecase/entitlement"
+	"github.com/yourusername/gin-collection-saas/pkg/utils"
+)
+
+// fakeAPIKeyRepository keeps API keys in memory
+type fakeAPIKeyRepository struct {
+	keys []*models.APIKey
+}
+
+func (r *fakeAPIKeyRepository) Create(ctx context.Context, key *models.APIKey) error {
+	key.ID = int64(len(r.keys) + 1)
+	copied := *key
+	r.keys = append(r.keys, &copied)
+	return nil
+}
+
+func (r *fakeAPIKeyRepository) GetByHash(ctx context.Context, keyHash string) (*models.APIKey, error) {
+	for _, key := range r.keys {
+		if key.KeyHash == keyHash {
+			copied := *key
+			return &copied, nil
+		}
+	}
+	return nil, errors.ErrNotFound
+}
+
+func (r *fakeAPIKeyRepository) GetByUUID(ctx context.Context, uuid string) (*models.APIKey, error) {
+	for _, key := range r.keys {
+		if key.UUID == uuid {
+			copied := *key
+			return &copied, nil
+		}
+	}
+	return nil, errors.ErrNotFound
+}
+
+func (r *fakeAPIKeyRepository) ListByUser(ctx context.Context, userID int64) ([]*models.APIKey, error) {
+	var keys []*models.APIKey
+	for _, key := range r.keys {
+		if key.UserID == userID && key.RevokedAt == nil {
+			copied := *key
+			keys = append(keys, &copied)
+		}
+	}
+	return keys, nil
+}
+
+func (r *fakeAPIKeyRepository) CountActiveByUser(ctx context.Context, userID int64) (int, error) {
+	count := 0
+	for _, key := range r.keys {
+		if key.UserID == userID && key.IsActive() {
+			count++
+		}
+	}
+	return count, nil
+}
+
+func (r *fakeAPIKeyRepository) Revoke(ctx context.Context, id int64) error {
+	for _, key := range r.keys {
+		if key.ID == id {
+			now := time.Now()
+			key.RevokedAt = &now
+		}
+	}
+	return nil
+}
+
+func (r *fakeAPIKeyRepository) TouchLastUsed(ctx context.Context, id int64, ipAddress string) error {
+	for _, key := range r.keys {
+		if key.ID == id {
+			now := time.Now()
+			key.LastUsedAt = &now
+			key.LastUsedIP = &ipAddress
+		}
+	}
+	return nil
+}
+
+// apiKeyFixture is a tenant with an owner and a viewer, and a router with
+// a few protected routes behind the API key middleware
+type apiKeyFixture struct {
+	tenant    *models.Tenant
+	owner     *models.User
+	viewer    *models.User
+	users     *fakeUserRepository
+	keys      *fakeAPIKeyRepository
+	auditLogs *fakeAuditLogRepository
+	service   *apikey.Service
+	router    *gin.Engine
+}
+
+func newAPIKeyFixture(t *testing.T, tier models.SubscriptionTier) *apiKeyFixture {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+
+	f := &apiKeyFixture{
+		tenant:    &models.Tenant{ID: 1, Subdomain: "acme", Tier: tier, Status: models.TenantStatusActive},
+		users:     newFakeUserRepository(),
+		keys:      &fakeAPIKeyRepository{},
+		auditLogs: &fakeAuditLogRepository{},
+	}
+	tenants := newFakeTenantRepository(f.tenant)
+
+	f.owner = &models.User{TenantID: f.tenant.ID, Email: "owner@example.com", Role: models.RoleOwner, IsActive: true}
+	f.viewer = &models.User{TenantID: f.tenant.ID, Email: "viewer@example.com", Role: models.RoleViewer, IsActive: true}
+	for _, user := range []*models.User{f.owner, f.viewer} {
+		if err := f.users.Create(context.Background(), user); err != nil {
+			t.Fatalf("failed to create user: %v", err)
+		}
+	}
+
+	entitlements := entitlement.NewService(nil)
+	f.service = apikey.NewService(f.keys, f.users, tenants, f.auditLogs, entitlements)
+
+	auth := middleware.NewAuthMiddleware(sessionTestSecret, f.users, nil)
+	apiKeyAuth := middleware.NewAPIKeyAuthMiddleware(f.keys, f.users, tenants, entitlements)
+
+	ok := func(c *gin.Context) {
+		userID, _ := middleware.GetUserID(c)
+		tenantID, _ := middleware.GetTenantID(c)
+		c.JSON(http.StatusOK, gin.H{"user_id": userID, "tenant_id": tenantID})
+	}
+
+	f.router = gin.New()
+	v1 := f.router.Group("/api/v1")
+	v1.Use(apiKeyAuth.OptionalAPIKey(auth))
+	v1.GET("/gins", ok)
+	v1.POST("/gins", ok)
+	v1.POST("/gins/export", ok)
+	v1.GET("/gins/:id/tastings", ok)
+	v1.POST("/gins/:id/tastings", ok)
+	v1.GET("/botanicals", ok)
+	v1.GET("/users", ok)
+	v1.POST("/api-keys", ok)
+
+	return f
+}
+
+func (f *apiKeyFixture) create(t *testing.T, user *models.User, req models.CreateAPIKeyRequest) *models.CreateAPIKeyResponse {
+	t.Helper()
+	created, err := f.service.Create(context.Background(), f.tenant.ID, user.ID, &req)
+	if err != nil {
+		t.Fatalf("failed to create API key: %v", err)
+	}
+	return created
+}
+
+func (f *apiKeyFixture) request(method, path, authorization string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, nil)
+	if authorization != "" {
+		req.Header.Set("Authorization", authorization)
+	}
+	w := httptest.NewRecorder()
+	f.router.ServeHTTP(w, req)
+	return w
+}
+
+func TestCreateAPIKeyStoresOnlyHash(t *testing.T) {
+	f := newAPIKeyFixture(t, models.TierPro)
+
+	created := f.create(t, f.owner, models.CreateAPIKeyRequest{
+		Name:   "  Inventory sync ",
+		Scopes: []string{models.ScopeGinsRead, models.ScopeGinsWrite, models.ScopeGinsRead},
+	})
+
+	if !strings.HasPrefix(created.Key, models.APIKeyPrefix) || len(created.Key) < 40 {
+		t.Fatalf("unexpected key format %q", created.Key)
+	}
+	if len(f.keys.keys) != 1 {
+		t.Fatalf("expected 1 stored key, got %d", len(f.keys.keys))
+	}
+
+	stored := f.keys.keys[0]
+	if stored.KeyHash != utils.HashToken(created.Key) || strings.Contains(stored.KeyHash, created.Key) {
+		t.Error("only the hash of the key should be stored")
+	}
+	if stored.Prefix != created.Key[:models.APIKeyVisiblePrefixLength] {
+		t.Errorf("expected visible prefix %q, got %q", created.Key[:models.APIKeyVisiblePrefixLength], stored.Prefix)
+	}
+	if stored.Name != "Inventory sync" {
+		t.Errorf("expected trimmed name, got %q", stored.Name)
+	}
+	if len(stored.Scopes) != 2 {
+		t.Errorf("expected duplicate scopes to be removed, got %v", stored.Scopes)
+	}
+
+	// The key itself never appears in the JSON of the key
+	body, _ := json.Marshal(created.APIKey)
+	if strings.Contains(string(body), created.Key) || strings.Contains(string(body), stored.KeyHash) {
+		t.Errorf("API key JSON exposes the key: %s", body)
+	}
+
+	if len(f.auditLogs.logs) != 1 || f.auditLogs.logs[0].Action != string(models.AuditActionGenerateAPIKey) {
+		t.Errorf("expected a generate_api_key audit log, got %+v", f.auditLogs.logs)
+	}
+}
+
+func TestCreateAPIKeyRules(t *testing.T) {
+	ctx := context.Background()
+
+	free := newAPIKeyFixture(t, models.TierBasic)
+	_, err := free.service.Create(ctx, free.tenant.ID, free.owner.ID, &models.CreateAPIKeyRequest{Name: "CI", Scopes: []string{models.ScopeGinsRead}})
+	if !stdErrors.Is(err, errors.ErrAPIAccessNotAllowed) {
+		t.Errorf("expected ErrAPIAccessNotAllowed without API access, got %v", err)
+	}
+
+	f := newAPIKeyFixture(t, models.TierEnterprise)
+
+	// Viewers can't create keys that write
+	_, err = f.service.Create(ctx, f.tenant.ID, f.viewer.ID, &models.CreateAPIKeyRequest{Name: "CI", Scopes: []string{models.ScopeTastingsWrite}})
+	if err != errors.ErrForbidden {
+		t.Errorf("expected ErrForbidden for a viewer with a write scope, got %v", err)
+	}
+	f.create(t, f.viewer, models.CreateAPIKeyRequest{Name: "Dashboard", Scopes: []string{models.ScopeGinsRead}})
+
+	for i := 0; i < models.MaxAPIKeysPerUser; i++ {
+		f.create(t, f.owner, models.CreateAPIKeyRequest{Name: "Key", Scopes: []string{models.ScopeGinsRead}})
+	}
+	_, err = f.service.Create(ctx, f.tenant.ID, f.owner.ID, &models.CreateAPIKeyRequest{Name: "One too many", Scopes: []string{models.ScopeGinsRead}})
+	if err != errors.ErrLimitReached {
+		t.Errorf("expected ErrLimitReached after %d keys, got %v", models.MaxAPIKeysPerUser, err)
+	}
+}
+
+func TestCreateAPIKeyRequestValidate(t *testing.T) {
+	past := time.Now().Add(-time.Hour)
+	future := time.Now().Add(24 * time.Hour)
+	zero := 0
+	limit := 100
+
+	tests := []struct {
+		name    string
+		req     models.CreateAPIKeyRequest
+		wantErr bool
+	}{
+		{"valid", models.CreateAPIKeyRequest{Name: "CI", Scopes: []string{models.ScopeGinsRead}, ExpiresAt: &future, RateLimit: &limit}, false},
+		{"blank name", models.CreateAPIKeyRequest{Name: "  ", Scopes: []string{models.ScopeGinsRead}}, true},
+		{"unknown scope", models.CreateAPIKeyRequest{Name: "CI", Scopes: []string{"gins:delete"}}, true},
+		{"expired", models.CreateAPIKeyRequest{Name: "CI", Scopes: []string{models.ScopeGinsRead}, ExpiresAt: &past}, true},
+		{"zero rate limit", models.CreateAPIKeyRequest{Name: "CI", Scopes: []string{models.ScopeGinsRead}, RateLimit: &zero}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.req.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestAPIKeyMiddlewareEnforcesScopes(t *testing.T) {
+	f := newAPIKeyFixture(t, models.TierPro)
+	created := f.create(t, f.owner, models.CreateAPIKeyRequest{
+		Name:   "Tasting app",
+		Scopes: []string{models.ScopeGinsRead, models.ScopeTastingsWrite},
+	})
+	bearer := "Bearer " + created.Key
+
+	tests := []struct {
+		method string
+		path   string
+		status int
+		scope  string
+	}{
+		{http.MethodGet, "/api/v1/gins", http.StatusOK, ""},
+		{http.MethodPost, "/api/v1/gins", http.StatusForbidden, models.ScopeGinsWrite},
+		{http.MethodPost, "/api/v1/gins/export", http.StatusOK, ""},
+		{http.MethodPost, "/api/v1/gins/7/tastings", http.StatusOK, ""},
+		{http.MethodGet, "/api/v1/gins/7/tastings", http.StatusForbidden, models.ScopeTastingsRead},
+		{http.MethodGet, "/api/v1/botanicals", http.StatusForbidden, models.ScopeCatalogRead},
+		{http.MethodGet, "/api/v1/users", http.StatusForbidden, ""},
+		{http.MethodPost, "/api/v1/api-keys", http.StatusForbidden, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.method+" "+tt.path, func(t *testing.T) {
+			w := f.request(tt.method, tt.path, bearer)
+			if w.Code != tt.status {
+				t.Fatalf("expected status %d, got %d: %s", tt.status, w.Code, w.Body.String())
+			}
+
+			var body map[string]interface{}
+			_ = json.Unmarshal(w.Body.Bytes(), &body)
+			if tt.status == http.StatusOK && body["user_id"] != float64(f.owner.ID) {
+				t.Errorf("expected user %d in context, got %v", f.owner.ID, body["user_id"])
+			}
+			if tt.scope != "" && body["required_scope"] != tt.scope {
+				t.Errorf("expected required_scope %q, got %v", tt.scope, body["required_scope"])
+			}
+		})
+	}
+
+	if f.keys.keys[0].LastUsedAt == nil {
+		t.Error("expected last used to be recorded")
+	}
+}
+
+func TestAPIKeyMiddlewareRejectsInvalidKeys(t *testing.T) {
+	f := newAPIKeyFixture(t, models.TierPro)
+	scopes := []string{models.ScopeGinsRead}
+
+	revoked := f.create(t, f.owner, models.CreateAPIKeyRequest{Name: "Revoked", Scopes: scopes})
+	if err := f.service.Revoke(context.Background(), f.tenant.ID, f.owner.ID, f.owner.ID, revoked.APIKey.UUID); err != nil {
+		t.Fatalf("failed to revoke key: %v", err)
+	}
+
+	expiring := time.Now().Add(time.Hour)
+	expired := f.create(t, f.owner, models.CreateAPIKeyRequest{Name: "Expired", Scopes: scopes, ExpiresAt: &expiring})
+	past := time.Now().Add(-time.Minute)
+	f.keys.keys[1].ExpiresAt = &past
+
+	tests := []struct {
+		name          string
+		authorization string
+		status        int
+	}{
+		{"unknown key", "Bearer sk_0000000000000000000000000000000000000000", http.StatusUnauthorized},
+		{"revoked key", "Bearer " + revoked.Key, http.StatusUnauthorized},
+		{"expired key", "Bearer " + expired.Key, http.StatusUnauthorized},
+		// Without a key the JWT middleware is used, which finds no token
+		{"no credentials", "", http.StatusUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if w := f.request(http.MethodGet, "/api/v1/gins", tt.authorization); w.Code != tt.status {
+				t.Errorf("expected status %d, got %d: %s", tt.status, w.Code, w.Body.String())
+			}
+		})
+	}
+
+	// Keys of downgraded tenants stop working
+	active := f.create(t, f.owner, models.CreateAPIKeyRequest{Name: "Active", Scopes: scopes})
+	f.tenant.Tier = models.TierBasic
+	if w := f.request(http.MethodGet, "/api/v1/gins", "Bearer "+active.Key); w.Code != http.StatusForbidden {
+		t.Errorf("expected 403 after downgrade, got %d", w.Code)
+	}
+}
+
+func TestAPIKeyMiddlewareAcceptsHeaderAndJWT(t *testing.T) {
+	f := newAPIKeyFixture(t, models.TierPro)
+	created := f.create(t, f.owner, models.CreateAPIKeyRequest{Name: "CI", Scopes: []string{models.ScopeGinsRead}})
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/gins", nil)
+	req.Header.Set("X-API-Key", created.Key)
+	w := httptest.NewRecorder()
+	f.router.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Errorf("expected X-API-Key to authenticate, got %d: %s", w.Code, w.Body.String())
+	}
+
+	// JWTs keep working for routes API keys can't call
+	token, err := utils.GenerateToken(f.owner.ID, f.tenant.ID, f.owner.Email, string(f.owner.Role), "", sessionTestSecret, time.Hour)
+	if err != nil {
+		t.Fatalf("failed to generate token: %v", err)
+	}
+	if w := f.request(http.MethodGet, "/api/v1/users", "Bearer "+token); w.Code != http.StatusOK {
+		t.Errorf("expected JWT to authenticate, got %d: %s", w.Code, w.Body.String())
+	}
+}
+
+func TestRevokeAPIKeyOfOtherUser(t *testing.T) {
+	f := newAPIKeyFixture(t, models.TierEnterprise)
+	ctx := context.Background()
+
+	created := f.create(t, f.viewer, models.CreateAPIKeyRequest{Name: "Dashboard", Scopes: []string{models.ScopeGinsRead}})
+
+	// The key isn't the owner's own key
+	if err := f.service.Revoke(ctx, f.tenant.ID, f.owner.ID, f.owner.ID, created.APIKey.UUID); err != errors.ErrNotFound {
+		t.Errorf("expected ErrNotFound for another user's key, got %v", err)
+	}
+
+	// Admins revoke it on the user
+	if err := f.service.Revoke(ctx, f.tenant.ID, f.owner.ID, f.viewer.ID, created.APIKey.UUID); err != nil {
+		t.Fatalf("failed to revoke key: %v", err)
+	}
+
+	keys, err := f.service.List(ctx, f.tenant.ID, f.viewer.ID)
+	if err != nil {
+		t.Fatalf("failed to list keys: %v", err)
+	}
+	if len(keys) != 0 {
+		t.Errorf("expected revoked key to be hidden, got %d keys", len(keys))
+	}
+
+	// Users of other tenants are not found
+	if _, err := f.service.List(ctx, 2, f.viewer.ID); err != errors.ErrUserNotInTenant {
+		t.Errorf("expected ErrUserNotInTenant, got %v", err)
+	}
+}
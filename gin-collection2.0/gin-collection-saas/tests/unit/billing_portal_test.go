@@ -0,0 +1,399 @@
+package unit
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/yourusername/gin-collection-saas/internal/domain/errors"
+	"github.com/yourusername/gin-collection-saas/internal/domain/models"
+	"github.com/yourusername/gin-collection-saas/internal/infrastructure/billing"
+	"github.com/yourusername/gin-collection-saas/internal/infrastructure/external"
+	"github.com/yourusername/gin-collection-saas/internal/usecase/subscription"
+)
+
+// stripeSubscription returns the Stripe side of the fixture's subscription
+func (f *planChangeFixture) stripeSubscription() *external.StripeSubscription {
+	return f.stripe.subscriptions[*f.subscription.ProviderSubscriptionID]
+}
+
+// updated delivers a subscription update webhook, the provider reports the
+// new state
+func (f *planChangeFixture) updated(t *testing.T) {
+	err := f.service.HandleWebhookEvent(context.Background(), f.provider, &billing.WebhookEvent{
+		ID:             "evt_updated",
+		Type:           billing.EventSubscriptionUpdated,
+		SubscriptionID: *f.subscription.ProviderSubscriptionID,
+	})
+	if err != nil {
+		t.Fatalf("update webhook failed: %v", err)
+	}
+}
+
+func TestPauseAndResumeSubscription(t *testing.T) {
+	f := newPlanChangeFixture(t, "PLAN_PRO_MONTHLY")
+	ctx := context.Background()
+	f.service.SetDunning(&models.DunningPolicy{GracePeriod: 7 * 24 * time.Hour, FinalAfter: 14 * 24 * time.Hour, FinalAction: models.DunningActionDowngrade})
+
+	paused, err := f.service.PauseSubscription(ctx, 1, "Travelling")
+	if err != nil {
+		t.Fatalf("PauseSubscription failed: %v", err)
+	}
+	if paused.Status != models.SubscriptionStatusPaused || paused.PausedAt == nil || paused.NextBillingDate != nil {
+		t.Fatalf("expected a paused subscription, got %+v", paused)
+	}
+	if f.stripeSubscription().PauseCollection == nil {
+		t.Fatal("expected Stripe to pause payment collection")
+	}
+	if tenant := f.tenants.tenants[1]; tenant.Tier != models.TierFree || tenant.PlanLimits != nil {
+		t.Fatalf("expected the tenant on Free while paused, got %s", tenant.Tier)
+	}
+
+	// Stripe reports the pause back, it doesn't start dunning
+	f.updated(t)
+	stored := f.subscriptions.subscriptions[f.subscription.ID]
+	if stored.Status != models.SubscriptionStatusPaused || stored.InDunning() {
+		t.Fatalf("expected the subscription to stay paused, got %s (dunning %q)", stored.Status, stored.DunningStage)
+	}
+
+	upcoming, err := f.service.UpcomingCharges(ctx, 1, 3)
+	if err != nil {
+		t.Fatalf("UpcomingCharges failed: %v", err)
+	}
+	if upcoming.Status != models.SubscriptionStatusPaused || len(upcoming.Charges) != 0 {
+		t.Errorf("expected no charges while paused, got %+v", upcoming)
+	}
+	if _, err := f.service.PauseSubscription(ctx, 1, ""); err != errors.ErrNoActiveSubscription {
+		t.Errorf("expected ErrNoActiveSubscription pausing twice, got %v", err)
+	}
+
+	resumed, err := f.service.ResumeSubscription(ctx, 1, "Back home")
+	if err != nil {
+		t.Fatalf("ResumeSubscription failed: %v", err)
+	}
+	if resumed.Status != models.SubscriptionStatusActive || resumed.PausedAt != nil || resumed.NextBillingDate == nil {
+		t.Fatalf("expected an active subscription, got %+v", resumed)
+	}
+	if f.stripeSubscription().PauseCollection != nil {
+		t.Fatal("expected Stripe to resume payment collection")
+	}
+	if tenant := f.tenants.tenants[1]; tenant.Tier != models.TierPro || tenant.PlanLimits == nil {
+		t.Fatalf("expected the tenant back on Pro, got %s", tenant.Tier)
+	}
+
+	if _, err := f.service.ResumeSubscription(ctx, 1, ""); err != errors.ErrNotResumable {
+		t.Errorf("expected ErrNotResumable for an active subscription, got %v", err)
+	}
+}
+
+func TestSubscriptionResumedAtProvider(t *testing.T) {
+	f := newPlanChangeFixture(t, "PLAN_PRO_MONTHLY")
+
+	if _, err := f.service.PauseSubscription(context.Background(), 1, ""); err != nil {
+		t.Fatalf("PauseSubscription failed: %v", err)
+	}
+
+	// The owner resumes in the Stripe customer portal
+	f.stripeSubscription().PauseCollection = nil
+	f.updated(t)
+
+	stored := f.subscriptions.subscriptions[f.subscription.ID]
+	if stored.Status != models.SubscriptionStatusActive || stored.PausedAt != nil {
+		t.Fatalf("expected the subscription active again, got %+v", stored)
+	}
+	if f.tenants.tenants[1].Tier != models.TierPro {
+		t.Errorf("expected the tenant back on Pro, got %s", f.tenants.tenants[1].Tier)
+	}
+}
+
+func TestResumeSubscriptionCancelledAtPeriodEnd(t *testing.T) {
+	f := newPlanChangeFixture(t, "PLAN_PRO_MONTHLY")
+	ctx := context.Background()
+
+	// The owner cancelled in the Stripe customer portal
+	f.stripeSubscription().CancelAtPeriodEnd = true
+	f.updated(t)
+
+	upcoming, err := f.service.UpcomingCharges(ctx, 1, 3)
+	if err != nil {
+		t.Fatalf("UpcomingCharges failed: %v", err)
+	}
+	if !upcoming.CancelAtPeriodEnd || upcoming.EndsAt == nil || len(upcoming.Charges) != 0 {
+		t.Fatalf("expected the subscription to end without further charges, got %+v", upcoming)
+	}
+
+	resumed, err := f.service.ResumeSubscription(ctx, 1, "")
+	if err != nil {
+		t.Fatalf("ResumeSubscription failed: %v", err)
+	}
+	if resumed.CancelAtPeriodEnd || f.stripeSubscription().CancelAtPeriodEnd {
+		t.Fatalf("expected the cancellation to be undone, got %+v", resumed)
+	}
+	if f.tenants.tenants[1].Tier != models.TierPro {
+		t.Errorf("expected the tenant to stay on Pro, got %s", f.tenants.tenants[1].Tier)
+	}
+}
+
+func TestCancelSubscriptionAtPeriodEnd(t *testing.T) {
+	f := newPlanChangeFixture(t, "PLAN_PRO_MONTHLY")
+	ctx := context.Background()
+
+	cancelled, err := f.service.CancelSubscription(ctx, 1, "Too expensive")
+	if err != nil {
+		t.Fatalf("CancelSubscription failed: %v", err)
+	}
+	if cancelled.Status != models.SubscriptionStatusActive || !cancelled.CancelAtPeriodEnd || cancelled.CancelledAt != nil {
+		t.Fatalf("expected the subscription to run until the end of the period, got %+v", cancelled)
+	}
+	if !f.stripeSubscription().CancelAtPeriodEnd || f.stripeSubscription().Status != "active" {
+		t.Fatal("expected Stripe to cancel at the end of the period")
+	}
+	if f.tenants.tenants[1].Tier != models.TierPro {
+		t.Fatalf("expected the tenant to keep Pro until the end of the period, got %s", f.tenants.tenants[1].Tier)
+	}
+	if _, err := f.service.PauseSubscription(ctx, 1, ""); err != errors.ErrInvalidInput {
+		t.Errorf("expected ErrInvalidInput pausing a cancelled subscription, got %v", err)
+	}
+
+	resumed, err := f.service.ResumeSubscription(ctx, 1, "")
+	if err != nil {
+		t.Fatalf("ResumeSubscription failed: %v", err)
+	}
+	if resumed.CancelAtPeriodEnd || f.stripeSubscription().CancelAtPeriodEnd {
+		t.Fatalf("expected the cancellation to be undone, got %+v", resumed)
+	}
+
+	if _, err := f.service.CancelSubscription(ctx, 1, ""); err != nil {
+		t.Fatalf("CancelSubscription failed: %v", err)
+	}
+	if ended, _ := f.service.EndCancelledSubscriptions(ctx); ended != 0 {
+		t.Fatalf("expected nothing to end before the period is over, got %d", ended)
+	}
+
+	f.service.SetClock(func() time.Time { return time.Now().AddDate(0, 2, 0) })
+	if ended, err := f.service.EndCancelledSubscriptions(ctx); err != nil || ended != 1 {
+		t.Fatalf("expected the subscription to end, got %d (%v)", ended, err)
+	}
+	stored := f.subscriptions.subscriptions[f.subscription.ID]
+	if stored.Status != models.SubscriptionStatusCancelled || stored.CancelledAt == nil {
+		t.Errorf("expected a cancelled subscription, got %+v", stored)
+	}
+	if f.stripeSubscription().Status != "canceled" {
+		t.Errorf("expected the Stripe subscription to be cancelled, got %s", f.stripeSubscription().Status)
+	}
+	if tenant := f.tenants.tenants[1]; tenant.Tier != models.TierFree || tenant.PlanLimits != nil {
+		t.Errorf("expected the tenant on Free, got %s", tenant.Tier)
+	}
+}
+
+func TestPlanChangeSchedulerRunsOnOneInstance(t *testing.T) {
+	f := newPlanChangeFixture(t, "PLAN_PRO_MONTHLY")
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	if _, err := f.service.CancelSubscription(ctx, 1, ""); err != nil {
+		t.Fatalf("CancelSubscription failed: %v", err)
+	}
+	f.service.SetClock(func() time.Time { return time.Now().AddDate(0, 2, 0) })
+
+	// Another instance runs the job meanwhile
+	locks := newFakeLockRepository("subscription_plan_changes")
+	f.service.SetLockRepo(locks)
+	f.service.StartPlanChangeScheduler(ctx, 5*time.Millisecond)
+
+	time.Sleep(50 * time.Millisecond)
+	if status := f.subscriptions.subscriptions[f.subscription.ID].Status; status != models.SubscriptionStatusActive {
+		t.Fatalf("expected the subscription to be left to the other instance, got %s", status)
+	}
+
+	locks.runOnce(t, "subscription_plan_changes")
+	if status := f.subscriptions.subscriptions[f.subscription.ID].Status; status != models.SubscriptionStatusCancelled {
+		t.Fatalf("expected the subscription to end, got %s", status)
+	}
+}
+
+func TestPayPalSubscriptionCancelledAtPeriodEnd(t *testing.T) {
+	contract := newPayPalContract(t)
+	ctx := context.Background()
+
+	tenants := newFakeTenantRepository(&models.Tenant{ID: 1, Name: "Gin Bar", Subdomain: "ginbar", Tier: models.TierFree, Status: models.TenantStatusActive})
+	subscriptions := newFakeSubscriptionRepository()
+	service := subscription.NewService(subscriptions, tenants, &fakeGinRepository{}, &fakePhotoRepository{}, &fakeStorageUsageRepository{},
+		billing.NewRegistry(models.BillingProviderPayPal, contract.provider), "https://app.example.com")
+	service.SetDunning(&models.DunningPolicy{GracePeriod: 7 * 24 * time.Hour, FinalAfter: 14 * 24 * time.Hour, FinalAction: models.DunningActionDowngrade})
+
+	upgrade, err := service.InitiateUpgrade(ctx, 1, "PLAN_PRO_MONTHLY", models.BillingCycleMonthly, "", "")
+	if err != nil {
+		t.Fatalf("InitiateUpgrade failed: %v", err)
+	}
+	contract.approve(t, &billing.Checkout{CheckoutID: upgrade.CheckoutID})
+	active, err := service.ActivateCheckout(ctx, 1, models.BillingProviderPayPal, upgrade.CheckoutID)
+	if err != nil {
+		t.Fatalf("ActivateCheckout failed: %v", err)
+	}
+	paypalID := *active.ProviderSubscriptionID
+	paypalStatus := func() string {
+		info, err := contract.provider.GetSubscription(ctx, paypalID)
+		if err != nil {
+			t.Fatalf("GetSubscription failed: %v", err)
+		}
+		return info.Status
+	}
+
+	// PayPal can't reactivate cancelled subscriptions, it's suspended until then
+	if _, err := service.CancelSubscription(ctx, 1, ""); err != nil {
+		t.Fatalf("CancelSubscription failed: %v", err)
+	}
+	if status := paypalStatus(); status != billing.StatusSuspended {
+		t.Fatalf("expected PayPal to suspend the subscription, got %s", status)
+	}
+
+	// PayPal reports the suspension, it doesn't start dunning
+	for _, eventType := range []billing.EventType{billing.EventSubscriptionSuspended, billing.EventSubscriptionUpdated} {
+		if err := service.HandleWebhookEvent(ctx, contract.provider, &billing.WebhookEvent{ID: "WH-" + string(eventType), Type: eventType, SubscriptionID: paypalID}); err != nil {
+			t.Fatalf("%s webhook failed: %v", eventType, err)
+		}
+	}
+	stored := subscriptions.subscriptions[active.ID]
+	if stored.Status != models.SubscriptionStatusActive || !stored.CancelAtPeriodEnd || stored.InDunning() {
+		t.Fatalf("expected the subscription to run until the end of the period, got %s (dunning %q)", stored.Status, stored.DunningStage)
+	}
+	if tenants.tenants[1].Tier != models.TierPro {
+		t.Fatalf("expected the tenant to keep Pro until the end of the period, got %s", tenants.tenants[1].Tier)
+	}
+
+	if _, err := service.ResumeSubscription(ctx, 1, ""); err != nil {
+		t.Fatalf("ResumeSubscription failed: %v", err)
+	}
+	if status := paypalStatus(); status != billing.StatusActive {
+		t.Fatalf("expected PayPal to reactivate the subscription, got %s", status)
+	}
+
+	if _, err := service.CancelSubscription(ctx, 1, ""); err != nil {
+		t.Fatalf("CancelSubscription failed: %v", err)
+	}
+	service.SetClock(func() time.Time { return time.Now().AddDate(0, 2, 0) })
+	if ended, err := service.EndCancelledSubscriptions(ctx); err != nil || ended != 1 {
+		t.Fatalf("expected the subscription to end, got %d (%v)", ended, err)
+	}
+	if status := paypalStatus(); status != billing.StatusCancelled {
+		t.Errorf("expected the PayPal subscription to be cancelled, got %s", status)
+	}
+	if tenants.tenants[1].Tier != models.TierFree {
+		t.Errorf("expected the tenant on Free, got %s", tenants.tenants[1].Tier)
+	}
+}
+
+func TestChangeBillingCycle(t *testing.T) {
+	f := newPlanChangeFixture(t, "PLAN_BASIC_MONTHLY")
+	ctx := context.Background()
+
+	if _, err := f.service.ChangeBillingCycle(ctx, 1, models.BillingCycleMonthly); err != errors.ErrInvalidInput {
+		t.Errorf("expected ErrInvalidInput for the current cycle, got %v", err)
+	}
+
+	change, err := f.service.ChangeBillingCycle(ctx, 1, models.BillingCycleYearly)
+	if err != nil {
+		t.Fatalf("ChangeBillingCycle failed: %v", err)
+	}
+	if change.Plan.ID != "PLAN_BASIC_YEARLY" || change.Tier != models.TierBasic || change.Scheduled {
+		t.Fatalf("expected a switch to the yearly basic plan, got %+v", change)
+	}
+
+	stored := f.subscriptions.subscriptions[f.subscription.ID]
+	if stored.PlanID != "PLAN_BASIC_YEARLY" || stored.BillingCycle != models.BillingCycleYearly || stored.Amount != 49.99 {
+		t.Errorf("expected the subscription billed yearly, got %+v", stored)
+	}
+	if price := f.stripeSubscription().Items.Data[0].Price.ID; price != "price_basic_yearly" {
+		t.Errorf("expected the yearly Stripe price, got %s", price)
+	}
+	if f.tenants.tenants[1].Tier != models.TierBasic {
+		t.Errorf("expected the tenant to stay on Basic, got %s", f.tenants.tenants[1].Tier)
+	}
+}
+
+func TestChangeBillingCycleWithScheduledDowngrade(t *testing.T) {
+	f := newPlanChangeFixture(t, "PLAN_PRO_MONTHLY")
+	ctx := context.Background()
+
+	if _, err := f.service.ChangePlan(ctx, 1, "PLAN_BASIC_MONTHLY"); err != nil {
+		t.Fatalf("ChangePlan failed: %v", err)
+	}
+	if _, err := f.service.ChangeBillingCycle(ctx, 1, models.BillingCycleYearly); err != errors.ErrPlanChangeScheduled {
+		t.Errorf("expected ErrPlanChangeScheduled, got %v", err)
+	}
+}
+
+func TestCatalogCyclePlan(t *testing.T) {
+	catalog := subscription.NewCatalog(nil)
+
+	if plan := catalog.CyclePlan("PLAN_PRO_MONTHLY", models.BillingCycleYearly); plan == nil || plan.ID != "PLAN_PRO_YEARLY" {
+		t.Errorf("expected the yearly pro plan, got %+v", plan)
+	}
+	if plan := catalog.CyclePlan("PLAN_BASIC_YEARLY", models.BillingCycleMonthly); plan == nil || plan.ID != "PLAN_BASIC_MONTHLY" {
+		t.Errorf("expected the monthly basic plan, got %+v", plan)
+	}
+	// Enterprise has one plan for both cycles, its provider plan has one interval
+	if plan := catalog.CyclePlan("PLAN_ENTERPRISE", models.BillingCycleYearly); plan != nil {
+		t.Errorf("expected no yearly enterprise plan, got %+v", plan)
+	}
+}
+
+func TestUpcomingCharges(t *testing.T) {
+	f := newPlanChangeFixture(t, "PLAN_PRO_MONTHLY")
+	ctx := context.Background()
+	f.service.SetInvoicing(newFakeInvoiceRepository(), &subscription.InvoiceSettings{
+		Seller:  models.BillingDetails{Name: "GinVault GmbH", Country: "DE"},
+		VATRate: 19,
+	})
+
+	// A coupon takes 2.00 off the next two payments, a downgrade to Basic is
+	// scheduled at the end of the period
+	stored := f.subscriptions.subscriptions[f.subscription.ID]
+	discountEndsAt := f.periodEnd.AddDate(0, 1, 1)
+	stored.NextBillingDate = &f.periodEnd
+	stored.Discount = 2
+	stored.DiscountEndsAt = &discountEndsAt
+	if _, err := f.service.ChangePlan(ctx, 1, "PLAN_BASIC_MONTHLY"); err != nil {
+		t.Fatalf("ChangePlan failed: %v", err)
+	}
+
+	upcoming, err := f.service.UpcomingCharges(ctx, 1, 3)
+	if err != nil {
+		t.Fatalf("UpcomingCharges failed: %v", err)
+	}
+	if len(upcoming.Charges) != 3 {
+		t.Fatalf("expected 3 charges, got %+v", upcoming)
+	}
+
+	for i, want := range []struct {
+		date     time.Time
+		discount float64
+		total    float64
+		net      float64
+	}{
+		{f.periodEnd, 2, 2.99, 2.51},
+		{f.periodEnd.AddDate(0, 1, 0), 2, 2.99, 2.51},
+		{f.periodEnd.AddDate(0, 2, 0), 0, 4.99, 4.19},
+	} {
+		charge := upcoming.Charges[i]
+		if !charge.Date.Equal(want.date) || charge.PlanID != "PLAN_BASIC_MONTHLY" || !charge.Scheduled || charge.Price != 4.99 {
+			t.Errorf("charge %d: expected the basic plan on %s, got %+v", i, want.date, charge)
+		}
+		if charge.Discount != want.discount || charge.TotalAmount != want.total || charge.NetAmount != want.net || charge.TaxRate != 19 {
+			t.Errorf("charge %d: expected %.2f less %.2f with %.2f net, got %+v", i, charge.Price, want.discount, want.net, charge)
+		}
+	}
+
+	// EU businesses outside Germany are reverse charged
+	if _, err := f.service.UpdateBillingDetails(ctx, 1, &models.BillingDetails{Name: "Wacholder KG", Country: "AT", VATID: "ATU12345678"}); err != nil {
+		t.Fatalf("UpdateBillingDetails failed: %v", err)
+	}
+	upcoming, err = f.service.UpcomingCharges(ctx, 1, 1)
+	if err != nil {
+		t.Fatalf("UpcomingCharges failed: %v", err)
+	}
+	if charge := upcoming.Charges[0]; !charge.ReverseCharge || charge.TaxAmount != 0 || charge.NetAmount != 2.99 {
+		t.Errorf("expected a reverse charged payment, got %+v", charge)
+	}
+}
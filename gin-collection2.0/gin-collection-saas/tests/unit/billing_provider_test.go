@@ -0,0 +1,539 @@
+package unit
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/yourusername/gin-collection-saas/internal/domain/errors"
+	"github.com/yourusername/gin-collection-saas/internal/domain/models"
+	"github.com/yourusername/gin-collection-saas/internal/infrastructure/billing"
+	"github.com/yourusername/gin-collection-saas/internal/infrastructure/external"
+	"github.com/yourusername/gin-collection-saas/internal/usecase/subscription"
+)
+
+const (
+	fakePayPalSignature = "signed-by-fake-paypal"
+	fakeStripeSecretKey = "sk_test_fake"
+	fakeStripeWebhook   = "whsec_fake"
+)
+
+// fakePayPal is a local stand-in for the PayPal subscriptions API
+type fakePayPal struct {
+	mu            sync.Mutex
+	server        *httptest.Server
+	subscriptions map[string]*external.PayPalSubscriptionResponse
+}
+
+func newFakePayPal(t *testing.T) *fakePayPal {
+	f := &fakePayPal{subscriptions: make(map[string]*external.PayPalSubscriptionResponse)}
+	f.server = httptest.NewServer(http.HandlerFunc(f.handle))
+	t.Cleanup(f.server.Close)
+	return f
+}
+
+func (f *fakePayPal) handle(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if r.URL.Path == "/v1/oauth2/token" {
+		writeJSON(w, http.StatusOK, map[string]interface{}{"access_token": "token", "expires_in": 3600})
+		return
+	}
+	if r.Header.Get("Authorization") != "Bearer token" {
+		writeJSON(w, http.StatusUnauthorized, map[string]string{"name": "AUTHENTICATION_FAILURE"})
+		return
+	}
+
+	var body map[string]json.RawMessage
+	json.NewDecoder(r.Body).Decode(&body)
+
+	path := strings.TrimPrefix(r.URL.Path, "/v1/billing/subscriptions")
+	switch {
+	case r.URL.Path == "/v1/notifications/verify-webhook-signature":
+		status := "FAILURE"
+		if string(body["transmission_sig"]) == strconv.Quote(fakePayPalSignature) {
+			status = "SUCCESS"
+		}
+		writeJSON(w, http.StatusOK, map[string]string{"verification_status": status})
+
+	case r.Method == "POST" && path == "":
+		var planID string
+		json.Unmarshal(body["plan_id"], &planID)
+		id := fmt.Sprintf("I-%d", len(f.subscriptions)+1)
+		f.subscriptions[id] = &external.PayPalSubscriptionResponse{
+			ID:     id,
+			Status: "APPROVAL_PENDING",
+			PlanID: planID,
+			Links:  []external.PayPalLink{{Href: "https://paypal.test/approve?ba=" + id, Rel: "approve", Method: "GET"}},
+		}
+		writeJSON(w, http.StatusCreated, f.subscriptions[id])
+
+	default:
+		id, action, _ := strings.Cut(strings.TrimPrefix(path, "/"), "/")
+		sub, ok := f.subscriptions[id]
+		if !ok {
+			writeJSON(w, http.StatusNotFound, map[string]string{"name": "RESOURCE_NOT_FOUND"})
+			return
+		}
+
+		switch action {
+		case "":
+			writeJSON(w, http.StatusOK, sub)
+			return
+		case "cancel":
+			sub.Status = "CANCELLED"
+		case "suspend":
+			sub.Status = "SUSPENDED"
+		case "activate":
+			sub.Status = "ACTIVE"
+		case "revise":
+			json.Unmarshal(body["plan_id"], &sub.PlanID)
+			writeJSON(w, http.StatusOK, map[string]string{"plan_id": sub.PlanID})
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// approve simulates the subscriber approving a subscription at PayPal
+func (f *fakePayPal) approve(t *testing.T, checkout *billing.Checkout) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	sub := f.subscriptions[checkout.CheckoutID]
+	sub.Status = "ACTIVE"
+	sub.StartTime = time.Now().UTC().Format(time.RFC3339)
+	sub.Subscriber = &external.PayPalSubscriber{PayerID: "PAYER1"}
+	sub.BillingInfo = &external.PayPalBillingInfo{NextBillingTime: time.Now().AddDate(0, 1, 0).UTC().Format(time.RFC3339)}
+}
+
+// cancelledWebhook returns a delivery of a cancellation, signed if signed is set
+func (f *fakePayPal) cancelledWebhook(subscriptionID string, signed bool) (http.Header, []byte) {
+	body := fmt.Sprintf(`{"id":"WH-1","event_type":"BILLING.SUBSCRIPTION.CANCELLED","create_time":%q,"resource":{"id":%q,"status":"CANCELLED","status_update_time":%q}}`,
+		time.Now().UTC().Format(time.RFC3339), subscriptionID, time.Now().UTC().Format(time.RFC3339))
+
+	headers := http.Header{}
+	headers.Set("PAYPAL-TRANSMISSION-ID", "tx-1")
+	headers.Set("PAYPAL-TRANSMISSION-TIME", time.Now().UTC().Format(time.RFC3339))
+	headers.Set("PAYPAL-CERT-URL", "https://api.paypal.test/cert")
+	headers.Set("PAYPAL-AUTH-ALGO", "SHA256withRSA")
+	headers.Set("PAYPAL-TRANSMISSION-SIG", "forged")
+	if signed {
+		headers.Set("PAYPAL-TRANSMISSION-SIG", fakePayPalSignature)
+	}
+	return headers, []byte(body)
+}
+
+// fakeStripe is a local stand-in for the Stripe Checkout and Billing API
+type fakeStripe struct {
+	mu            sync.Mutex
+	server        *httptest.Server
+	sessions      map[string]*external.StripeCheckoutSession
+	sessionPrices map[string]string
+	successURLs   map[string]string
+	subscriptions map[string]*external.StripeSubscription
+	prorations    []string // proration_behavior of each price change
+	coupons       []url.Values
+	sessionCoupon map[string]string
+}
+
+func newFakeStripe(t *testing.T) *fakeStripe {
+	f := &fakeStripe{
+		sessions:      make(map[string]*external.StripeCheckoutSession),
+		sessionPrices: make(map[string]string),
+		successURLs:   make(map[string]string),
+		subscriptions: make(map[string]*external.StripeSubscription),
+		sessionCoupon: make(map[string]string),
+	}
+	f.server = httptest.NewServer(http.HandlerFunc(f.handle))
+	t.Cleanup(f.server.Close)
+	return f
+}
+
+func (f *fakeStripe) handle(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if key, _, ok := r.BasicAuth(); !ok || key != fakeStripeSecretKey {
+		writeJSON(w, http.StatusUnauthorized, map[string]interface{}{"error": map[string]string{"type": "invalid_request_error", "message": "Invalid API Key"}})
+		return
+	}
+	r.ParseForm()
+
+	notFound := func() {
+		writeJSON(w, http.StatusNotFound, map[string]interface{}{"error": map[string]string{"type": "invalid_request_error", "message": "No such resource"}})
+	}
+
+	switch {
+	case r.Method == "POST" && r.URL.Path == "/v1/checkout/sessions":
+		if r.PostForm.Get("mode") != "subscription" || r.PostForm.Get("line_items[0][price]") == "" {
+			writeJSON(w, http.StatusBadRequest, map[string]interface{}{"error": map[string]string{"type": "invalid_request_error", "message": "Missing parameters"}})
+			return
+		}
+		id := fmt.Sprintf("cs_%d", len(f.sessions)+1)
+		f.sessions[id] = &external.StripeCheckoutSession{ID: id, URL: "https://checkout.stripe.test/" + id, Status: "open", Customer: r.PostForm.Get("customer")}
+		f.sessionPrices[id] = r.PostForm.Get("line_items[0][price]")
+		f.successURLs[id] = r.PostForm.Get("success_url")
+		f.sessionCoupon[id] = r.PostForm.Get("discounts[0][coupon]")
+		writeJSON(w, http.StatusOK, f.sessions[id])
+
+	case r.Method == "POST" && r.URL.Path == "/v1/coupons":
+		f.coupons = append(f.coupons, r.PostForm)
+		writeJSON(w, http.StatusOK, external.StripeCoupon{ID: fmt.Sprintf("co_%d", len(f.coupons))})
+
+	case r.Method == "GET" && strings.HasPrefix(r.URL.Path, "/v1/checkout/sessions/"):
+		session, ok := f.sessions[strings.TrimPrefix(r.URL.Path, "/v1/checkout/sessions/")]
+		if !ok {
+			notFound()
+			return
+		}
+		writeJSON(w, http.StatusOK, session)
+
+	case strings.HasPrefix(r.URL.Path, "/v1/subscriptions/"):
+		sub, ok := f.subscriptions[strings.TrimPrefix(r.URL.Path, "/v1/subscriptions/")]
+		if !ok {
+			notFound()
+			return
+		}
+
+		switch r.Method {
+		case "POST":
+			if _, ok := r.PostForm["pause_collection"]; ok {
+				sub.PauseCollection = nil
+			}
+			if behavior := r.PostForm.Get("pause_collection[behavior]"); behavior != "" {
+				sub.PauseCollection = &external.StripePauseCollection{Behavior: behavior}
+			}
+			if cancel := r.PostForm.Get("cancel_at_period_end"); cancel != "" {
+				sub.CancelAtPeriodEnd = cancel == "true"
+			}
+			if price := r.PostForm.Get("items[0][price]"); price != "" {
+				if r.PostForm.Get("items[0][id]") != sub.Items.Data[0].ID {
+					writeJSON(w, http.StatusBadRequest, map[string]interface{}{"error": map[string]string{"type": "invalid_request_error", "message": "Unknown item"}})
+					return
+				}
+				sub.Items.Data[0].Price.ID = price
+				f.prorations = append(f.prorations, r.PostForm.Get("proration_behavior"))
+			}
+		case "DELETE":
+			sub.Status = "canceled"
+		}
+		writeJSON(w, http.StatusOK, sub)
+
+	case r.Method == "POST" && r.URL.Path == "/v1/billing_portal/sessions":
+		writeJSON(w, http.StatusOK, external.StripePortalSession{ID: "bps_1", URL: "https://billing.stripe.test/session/" + r.PostForm.Get("customer")})
+
+	default:
+		notFound()
+	}
+}
+
+// approve simulates the customer completing a Checkout session
+func (f *fakeStripe) approve(t *testing.T, checkout *billing.Checkout) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	session := f.sessions[checkout.CheckoutID]
+	id := fmt.Sprintf("sub_%d", len(f.subscriptions)+1)
+	f.subscriptions[id] = &external.StripeSubscription{
+		ID:                 id,
+		Customer:           "cus_1",
+		Status:             "active",
+		CurrentPeriodStart: time.Now().Unix(),
+		CurrentPeriodEnd:   time.Now().AddDate(0, 1, 0).Unix(),
+		Items: external.StripeSubscriptionItems{Data: []external.StripeSubscriptionItem{
+			{ID: "si_1", Price: external.StripePrice{ID: f.sessionPrices[session.ID]}},
+		}},
+	}
+	session.Status = "complete"
+	session.Customer = "cus_1"
+	session.Subscription = id
+}
+
+// cancelledWebhook returns a delivery of a cancellation, signed if signed is set
+func (f *fakeStripe) cancelledWebhook(subscriptionID string, signed bool) (http.Header, []byte) {
+	body := []byte(fmt.Sprintf(`{"id":"evt_1","type":"customer.subscription.deleted","created":%d,"data":{"object":{"id":%q,"customer":"cus_1","status":"canceled"}}}`,
+		time.Now().Unix(), subscriptionID))
+
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	secret := "whsec_forged"
+	if signed {
+		secret = fakeStripeWebhook
+	}
+
+	headers := http.Header{}
+	headers.Set("Stripe-Signature", "t="+timestamp+",v1="+external.StripeWebhookSignature(secret, timestamp, body))
+	return headers, body
+}
+
+func writeJSON(w http.ResponseWriter, status int, value interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(value)
+}
+
+// providerContract is a provider running against its local fake
+type providerContract struct {
+	provider         billing.BillingProvider
+	approve          func(t *testing.T, checkout *billing.Checkout)
+	cancelledWebhook func(subscriptionID string, signed bool) (http.Header, []byte)
+}
+
+// testPayPalPlans are the PayPal plans the providers under test are configured with
+var testPayPalPlans = billing.Plans{
+	"PLAN_BASIC_MONTHLY": "P-BASIC-MONTHLY",
+	"PLAN_BASIC_YEARLY":  "P-BASIC-YEARLY",
+	"PLAN_PRO_MONTHLY":   "P-PRO-MONTHLY",
+	"PLAN_PRO_YEARLY":    "P-PRO-YEARLY",
+	"PLAN_ENTERPRISE":    "P-ENTERPRISE",
+}
+
+func newPayPalContract(t *testing.T) *providerContract {
+	fake := newFakePayPal(t)
+	client := external.NewPayPalClient(&external.PayPalConfig{
+		ClientID:     "client",
+		ClientSecret: "secret",
+		Mode:         "sandbox",
+		WebhookID:    "WH-CONFIG",
+		BaseURL:      fake.server.URL,
+	})
+	return &providerContract{
+		provider:         billing.NewPayPalProvider(client, testPayPalPlans),
+		approve:          fake.approve,
+		cancelledWebhook: fake.cancelledWebhook,
+	}
+}
+
+func newStripeContract(t *testing.T) (*providerContract, *fakeStripe) {
+	fake := newFakeStripe(t)
+	client := external.NewStripeClient(&external.StripeConfig{
+		SecretKey:     fakeStripeSecretKey,
+		WebhookSecret: fakeStripeWebhook,
+		BaseURL:       fake.server.URL,
+	})
+	plans := billing.Plans{
+		"PLAN_BASIC_MONTHLY": "price_basic",
+		"PLAN_BASIC_YEARLY":  "price_basic_yearly",
+		"PLAN_PRO_MONTHLY":   "price_pro",
+		"PLAN_PRO_YEARLY":    "price_pro_yearly",
+	}
+	return &providerContract{
+		provider:         billing.NewStripeProvider(client, plans),
+		approve:          fake.approve,
+		cancelledWebhook: fake.cancelledWebhook,
+	}, fake
+}
+
+func TestBillingProviderContract(t *testing.T) {
+	contracts := map[string]func(t *testing.T) *providerContract{
+		models.BillingProviderPayPal: newPayPalContract,
+		models.BillingProviderStripe: func(t *testing.T) *providerContract {
+			contract, _ := newStripeContract(t)
+			return contract
+		},
+	}
+
+	for name, newContract := range contracts {
+		t.Run(name, func(t *testing.T) {
+			contract := newContract(t)
+			provider := contract.provider
+			ctx := context.Background()
+
+			if provider.Name() != name {
+				t.Fatalf("expected provider %s, got %s", name, provider.Name())
+			}
+
+			if _, err := provider.CreateSubscription(ctx, &billing.CreateSubscriptionRequest{TenantID: 1, PlanID: "PLAN_FREE"}); err != billing.ErrPlanNotOffered {
+				t.Errorf("expected ErrPlanNotOffered for a plan without provider plan, got %v", err)
+			}
+
+			checkout, err := provider.CreateSubscription(ctx, &billing.CreateSubscriptionRequest{
+				TenantID:  1,
+				PlanID:    "PLAN_PRO_MONTHLY",
+				ReturnURL: "https://app.example.com/subscription/success?provider=" + name,
+				CancelURL: "https://app.example.com/subscription/cancel",
+			})
+			if err != nil {
+				t.Fatalf("CreateSubscription failed: %v", err)
+			}
+			if checkout.CheckoutID == "" || checkout.RedirectURL == "" || checkout.ProviderPlanID == "" {
+				t.Fatalf("incomplete checkout: %+v", checkout)
+			}
+
+			contract.approve(t, checkout)
+
+			info, err := provider.CompleteCheckout(ctx, checkout.CheckoutID)
+			if err != nil {
+				t.Fatalf("CompleteCheckout failed: %v", err)
+			}
+			if info.ID == "" || info.CustomerID == "" || info.Status != billing.StatusActive || info.PlanID != "PLAN_PRO_MONTHLY" {
+				t.Fatalf("unexpected subscription after checkout: %+v", info)
+			}
+			if info.CurrentPeriodStart == nil {
+				t.Errorf("expected the current period to be reported")
+			}
+
+			if err := provider.SuspendSubscription(ctx, info.ID, "Payment dispute"); err != nil {
+				t.Fatalf("SuspendSubscription failed: %v", err)
+			}
+			if got, _ := provider.GetSubscription(ctx, info.ID); got == nil || got.Status != billing.StatusSuspended {
+				t.Errorf("expected suspended subscription, got %+v", got)
+			}
+
+			if err := provider.ResumeSubscription(ctx, info.ID, "Dispute resolved"); err != nil {
+				t.Fatalf("ResumeSubscription failed: %v", err)
+			}
+			if got, _ := provider.GetSubscription(ctx, info.ID); got == nil || got.Status != billing.StatusActive {
+				t.Errorf("expected active subscription, got %+v", got)
+			}
+
+			// No further payments until the cancellation is undone
+			if err := provider.CancelAtPeriodEnd(ctx, info.ID, "Customer request"); err != nil {
+				t.Fatalf("CancelAtPeriodEnd failed: %v", err)
+			}
+			if got, _ := provider.GetSubscription(ctx, info.ID); got == nil || (!got.CancelAtPeriodEnd && got.Status != billing.StatusSuspended) {
+				t.Errorf("expected the subscription to end with the period, got %+v", got)
+			}
+			if err := provider.ResumeSubscription(ctx, info.ID, "Customer stays"); err != nil {
+				t.Fatalf("ResumeSubscription failed: %v", err)
+			}
+			if got, _ := provider.GetSubscription(ctx, info.ID); got == nil || got.Status != billing.StatusActive || got.CancelAtPeriodEnd {
+				t.Errorf("expected the cancellation to be undone, got %+v", got)
+			}
+
+			change, err := provider.ChangePlan(ctx, info.ID, "PLAN_BASIC_MONTHLY", 0, true)
+			if err != nil {
+				t.Fatalf("ChangePlan failed: %v", err)
+			}
+			if change.Subscription == nil || change.Subscription.PlanID != "PLAN_BASIC_MONTHLY" {
+				t.Errorf("expected plan to be changed, got %+v", change)
+			}
+
+			portal, err := provider.CustomerPortalURL(ctx, info.CustomerID, "https://app.example.com/subscription")
+			if err != nil || portal == "" {
+				t.Errorf("expected customer portal URL, got %q (%v)", portal, err)
+			}
+
+			headers, body := contract.cancelledWebhook(info.ID, false)
+			if err := provider.VerifyWebhook(ctx, headers, body); err != billing.ErrInvalidSignature {
+				t.Errorf("expected ErrInvalidSignature for a forged delivery, got %v", err)
+			}
+
+			headers, body = contract.cancelledWebhook(info.ID, true)
+			if err := provider.VerifyWebhook(ctx, headers, body); err != nil {
+				t.Fatalf("expected signed delivery to verify, got %v", err)
+			}
+			event, err := provider.ParseWebhook(body)
+			if err != nil {
+				t.Fatalf("ParseWebhook failed: %v", err)
+			}
+			if event.Type != billing.EventSubscriptionCancelled || event.SubscriptionID != info.ID || event.UpdatedAt == nil {
+				t.Errorf("unexpected webhook event: %+v", event)
+			}
+
+			if err := provider.CancelSubscription(ctx, info.ID, "Customer request"); err != nil {
+				t.Fatalf("CancelSubscription failed: %v", err)
+			}
+			if got, _ := provider.GetSubscription(ctx, info.ID); got == nil || got.Status != billing.StatusCancelled {
+				t.Errorf("expected cancelled subscription, got %+v", got)
+			}
+		})
+	}
+}
+
+func TestStripeCheckoutWithTenantProvider(t *testing.T) {
+	contract, fake := newStripeContract(t)
+	ctx := context.Background()
+
+	tenant := &models.Tenant{ID: 1, Name: "Gin Bar", Subdomain: "ginbar", Tier: models.TierFree, Status: models.TenantStatusActive}
+	tenants := newFakeTenantRepository(tenant)
+	subscriptions := newFakeSubscriptionRepository()
+	registry := billing.NewRegistry(models.BillingProviderPayPal, newPayPalContract(t).provider, contract.provider)
+	service := subscription.NewService(subscriptions, tenants, &fakeGinRepository{count: 3}, &fakePhotoRepository{},
+		&fakeStorageUsageRepository{}, registry, "https://app.example.com")
+
+	if _, err := service.SelectBillingProvider(ctx, 1, "bitcoin"); err != errors.ErrUnknownBillingProvider {
+		t.Fatalf("expected ErrUnknownBillingProvider, got %v", err)
+	}
+	providers, err := service.SelectBillingProvider(ctx, 1, models.BillingProviderStripe)
+	if err != nil || providers.Selected != models.BillingProviderStripe {
+		t.Fatalf("expected stripe to be selected, got %+v (%v)", providers, err)
+	}
+
+	upgrade, err := service.InitiateUpgrade(ctx, 1, "PLAN_PRO_MONTHLY", models.BillingCycleMonthly, "", "")
+	if err != nil {
+		t.Fatalf("InitiateUpgrade failed: %v", err)
+	}
+	if upgrade.Provider != models.BillingProviderStripe || upgrade.ApprovalURL == "" {
+		t.Fatalf("expected a Stripe checkout, got %+v", upgrade)
+	}
+	if successURL := fake.successURLs[upgrade.CheckoutID]; !strings.Contains(successURL, "provider=stripe&session_id={CHECKOUT_SESSION_ID}") {
+		t.Errorf("unexpected success URL %q", successURL)
+	}
+
+	// Completing a checkout the customer abandoned doesn't activate anything
+	if _, err := service.ActivateCheckout(ctx, 1, models.BillingProviderStripe, upgrade.CheckoutID); err != errors.ErrCheckoutIncomplete {
+		t.Fatalf("expected ErrCheckoutIncomplete, got %v", err)
+	}
+
+	fake.approve(t, &billing.Checkout{CheckoutID: upgrade.CheckoutID})
+
+	// Checkouts belong to the tenant that started them
+	if _, err := service.ActivateCheckout(ctx, 2, models.BillingProviderStripe, upgrade.CheckoutID); err != errors.ErrNotFound {
+		t.Errorf("expected ErrNotFound for another tenant, got %v", err)
+	}
+
+	activated, err := service.ActivateCheckout(ctx, 1, models.BillingProviderStripe, upgrade.CheckoutID)
+	if err != nil {
+		t.Fatalf("ActivateCheckout failed: %v", err)
+	}
+	if activated.Status != models.SubscriptionStatusActive || activated.ProviderSubscriptionID == nil || activated.ProviderCustomerID == nil {
+		t.Fatalf("unexpected activated subscription: %+v", activated)
+	}
+	if tenants.tenants[1].Tier != models.TierPro {
+		t.Errorf("expected tenant to be upgraded to pro, got %s", tenants.tenants[1].Tier)
+	}
+
+	portal, err := service.CustomerPortalURL(ctx, 1)
+	if err != nil || !strings.HasSuffix(portal, "/cus_1") {
+		t.Errorf("expected portal of the Stripe customer, got %q (%v)", portal, err)
+	}
+
+	// Subscribed tenants change plans instead of starting another subscription
+	if _, err := service.InitiateUpgrade(ctx, 1, "PLAN_BASIC_MONTHLY", models.BillingCycleMonthly, "", ""); err != errors.ErrPlanChangeRequired {
+		t.Errorf("expected ErrPlanChangeRequired, got %v", err)
+	}
+
+	change, err := service.ChangePlan(ctx, 1, "PLAN_BASIC_MONTHLY")
+	if err != nil {
+		t.Fatalf("ChangePlan failed: %v", err)
+	}
+	if !change.Scheduled || change.ApprovalURL != "" || change.Subscription.ScheduledPlanID == nil {
+		t.Errorf("expected downgrade to be scheduled, got %+v", change)
+	}
+	if tenants.tenants[1].Tier != models.TierPro {
+		t.Errorf("expected tenant to stay pro until the end of the period, got %s", tenants.tenants[1].Tier)
+	}
+
+	// The provider's cancellation webhook downgrades the tenant
+	headers, body := contract.cancelledWebhook(*activated.ProviderSubscriptionID, true)
+	if err := service.ProcessWebhook(ctx, models.BillingProviderStripe, headers, body); err != nil {
+		t.Fatalf("ProcessWebhook failed: %v", err)
+	}
+	if tenants.tenants[1].Tier != models.TierFree {
+		t.Errorf("expected tenant to be downgraded, got %s", tenants.tenants[1].Tier)
+	}
+
+	if err := service.ProcessWebhook(ctx, "bitcoin", headers, body); err != errors.ErrNotFound {
+		t.Errorf("expected ErrNotFound for an unknown provider, got %v", err)
+	}
+}
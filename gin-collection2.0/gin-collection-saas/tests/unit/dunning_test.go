@@ -0,0 +1,339 @@
+package unit
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/yourusername/gin-collection-saas/internal/delivery/http/middleware"
+	"github.com/yourusername/gin-collection-saas/internal/domain/models"
+	"github.com/yourusername/gin-collection-saas/internal/infrastructure/billing"
+	"github.com/yourusername/gin-collection-saas/internal/infrastructure/external"
+	"github.com/yourusername/gin-collection-saas/internal/usecase/entitlement"
+)
+
+type dunningFixture struct {
+	*trialCouponFixture
+	auditLog *fakeAuditLogRepository
+	now      time.Time
+}
+
+func newDunningFixture(t *testing.T, finalAction string) *dunningFixture {
+	f := &dunningFixture{
+		trialCouponFixture: newTrialCouponFixture(t),
+		auditLog:           &fakeAuditLogRepository{},
+		now:                time.Date(2026, 4, 1, 9, 0, 0, 0, time.UTC),
+	}
+
+	users := newFakeUserRepository()
+	users.Create(context.Background(), &models.User{TenantID: 1, Email: "owner@ginbar.test", Role: models.RoleOwner, IsActive: true})
+	users.Create(context.Background(), &models.User{TenantID: 2, Email: "owner@juniper.test", Role: models.RoleOwner, IsActive: true})
+	f.service.SetNotifications(users, external.NewEmailClient(&external.EmailConfig{}))
+	f.service.SetAuditLogRepo(f.auditLog)
+	f.service.SetDunning(&models.DunningPolicy{
+		GracePeriod:  7 * 24 * time.Hour,
+		ReminderDays: []int{1, 3, 7},
+		FinalAfter:   14 * 24 * time.Hour,
+		FinalAction:  finalAction,
+	})
+	f.service.SetClock(func() time.Time { return f.now })
+
+	return f
+}
+
+// deliver hands the service a webhook event about a subscription
+func (f *dunningFixture) deliver(t *testing.T, subscription *models.Subscription, eventType billing.EventType, paymentID string) {
+	err := f.service.HandleWebhookEvent(context.Background(), f.contract.provider, &billing.WebhookEvent{
+		ID:             "evt_" + string(eventType),
+		Type:           eventType,
+		SubscriptionID: *subscription.ProviderSubscriptionID,
+		PaymentID:      paymentID,
+		Amount:         "9.99",
+		Currency:       "EUR",
+	})
+	if err != nil {
+		t.Fatalf("%s webhook failed: %v", eventType, err)
+	}
+}
+
+// advance moves the clock and runs dunning, returning how many subscriptions
+// changed their stage
+func (f *dunningFixture) advance(t *testing.T, d time.Duration) int {
+	f.now = f.now.Add(d)
+	changed, err := f.service.ProcessDunning(context.Background())
+	if err != nil {
+		t.Fatalf("ProcessDunning failed: %v", err)
+	}
+	return changed
+}
+
+// auditActions returns the actions in the audit log
+func (f *dunningFixture) auditActions() []string {
+	actions := make([]string, len(f.auditLog.logs))
+	for i, log := range f.auditLog.logs {
+		actions[i] = log.Action
+	}
+	return actions
+}
+
+func TestDunningDowngradesUnpaidSubscription(t *testing.T) {
+	f := newDunningFixture(t, models.DunningActionDowngrade)
+
+	activated := f.subscribe(t, 1, "PLAN_PRO_MONTHLY", "")
+	failedAt := f.now
+	f.deliver(t, activated, billing.EventPaymentFailed, "")
+
+	stored := f.subscriptions.subscriptions[activated.ID]
+	if stored.Status != models.SubscriptionStatusPastDue || stored.DunningStage != models.DunningStageGrace || !stored.PastDueSince.Equal(failedAt) {
+		t.Fatalf("expected the subscription in its grace period, got %+v", stored)
+	}
+
+	// Failed retries don't restart dunning
+	f.now = f.now.Add(time.Hour)
+	f.deliver(t, activated, billing.EventPaymentFailed, "")
+	if !f.subscriptions.subscriptions[activated.ID].PastDueSince.Equal(failedAt) {
+		t.Fatal("expected dunning to keep its start")
+	}
+
+	// Reminders on days 1, 3 and 7
+	for _, step := range []struct {
+		after     time.Duration
+		reminders int
+	}{
+		{11 * time.Hour, 0},
+		{12 * time.Hour, 1},
+		{24 * time.Hour, 1},
+		{24 * time.Hour, 2},
+	} {
+		if changed := f.advance(t, step.after); changed != 0 {
+			t.Fatalf("expected no stage change in the grace period, got %d", changed)
+		}
+		if sent := f.subscriptions.subscriptions[activated.ID].DunningRemindersSent; sent != step.reminders {
+			t.Fatalf("expected %d reminders at %s, got %d", step.reminders, f.now.Sub(failedAt), sent)
+		}
+	}
+	if f.tenants.tenants[1].BillingRestricted {
+		t.Fatal("expected full access in the grace period")
+	}
+
+	// After the grace period the tenant is held to the Free limits
+	if changed := f.advance(t, 4*24*time.Hour); changed != 1 {
+		t.Fatalf("expected the subscription to be restricted, got %d changes", changed)
+	}
+	stored = f.subscriptions.subscriptions[activated.ID]
+	if stored.DunningStage != models.DunningStageRestricted || stored.DunningRemindersSent != 3 {
+		t.Fatalf("expected a restricted subscription with the last reminder, got %+v", stored)
+	}
+	tenant := f.tenants.tenants[1]
+	if !tenant.BillingRestricted || tenant.Tier != models.TierPro || tenant.EnforcedLimits().HasBotanicals {
+		t.Fatalf("expected the Pro tenant held to the Free limits, got %+v", tenant)
+	}
+
+	// Unpaid after 14 days the subscription is cancelled and the tenant is on Free
+	if changed := f.advance(t, 7*24*time.Hour); changed != 1 {
+		t.Fatalf("expected the subscription to be downgraded, got %d changes", changed)
+	}
+	stored = f.subscriptions.subscriptions[activated.ID]
+	if stored.Status != models.SubscriptionStatusCancelled || stored.DunningStage != models.DunningStageDowngraded || stored.CancelledAt == nil {
+		t.Fatalf("expected a cancelled subscription, got %+v", stored)
+	}
+	if status := f.stripe.subscriptions[*activated.ProviderSubscriptionID].Status; status != "canceled" {
+		t.Fatalf("expected the subscription cancelled at Stripe, got %s", status)
+	}
+	if tenant := f.tenants.tenants[1]; tenant.Tier != models.TierFree || tenant.BillingRestricted || tenant.Status != models.TenantStatusActive {
+		t.Fatalf("expected an active Free tenant, got %+v", tenant)
+	}
+
+	if changed := f.advance(t, 24*time.Hour); changed != 0 {
+		t.Fatalf("expected dunning to be over, got %d changes", changed)
+	}
+
+	// Every transition is audited as a system action on the subscription
+	expected := []string{"dunning_started", "dunning_restricted", "dunning_downgraded"}
+	if actions := f.auditActions(); len(actions) != len(expected) || actions[0] != expected[0] || actions[1] != expected[1] || actions[2] != expected[2] {
+		t.Fatalf("expected audit log %v, got %v", expected, actions)
+	}
+	for _, log := range f.auditLog.logs {
+		if log.TenantID != 1 || log.UserID != nil || log.EntityType != "subscription" || *log.EntityID != activated.ID {
+			t.Fatalf("unexpected audit log entry %+v", log)
+		}
+	}
+}
+
+func TestDunningSchedulerRunsOnOneInstance(t *testing.T) {
+	f := newDunningFixture(t, models.DunningActionDowngrade)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	activated := f.subscribe(t, 1, "PLAN_PRO_MONTHLY", "")
+	f.deliver(t, activated, billing.EventPaymentFailed, "")
+	f.now = f.now.Add(8 * 24 * time.Hour)
+
+	// Another instance runs the job meanwhile
+	locks := newFakeLockRepository("subscription_dunning")
+	f.service.SetLockRepo(locks)
+	f.service.StartDunningScheduler(ctx, 5*time.Millisecond)
+
+	time.Sleep(50 * time.Millisecond)
+	if stage := f.subscriptions.subscriptions[activated.ID].DunningStage; stage != models.DunningStageGrace {
+		t.Fatalf("expected dunning to be left to the other instance, got %s", stage)
+	}
+
+	locks.runOnce(t, "subscription_dunning")
+	if stage := f.subscriptions.subscriptions[activated.ID].DunningStage; stage != models.DunningStageRestricted {
+		t.Fatalf("expected the tenant to be restricted, got %s", stage)
+	}
+}
+
+func TestPaymentEndsDunning(t *testing.T) {
+	f := newDunningFixture(t, models.DunningActionSuspend)
+
+	restricted := f.subscribe(t, 1, "PLAN_PRO_MONTHLY", "")
+	suspended := f.subscribe(t, 2, "PLAN_PRO_MONTHLY", "")
+	f.deliver(t, restricted, billing.EventPaymentFailed, "")
+	f.deliver(t, suspended, billing.EventPaymentFailed, "")
+
+	if changed := f.advance(t, 8*24*time.Hour); changed != 2 {
+		t.Fatalf("expected both subscriptions to be restricted, got %d changes", changed)
+	}
+	f.deliver(t, restricted, billing.EventPaymentCompleted, "in_1")
+
+	// Unpaid after 14 days the tenant is suspended
+	if changed := f.advance(t, 7*24*time.Hour); changed != 1 {
+		t.Fatalf("expected one suspension, got %d changes", changed)
+	}
+	if status := f.tenants.tenants[2].Status; status != models.TenantStatusSuspended {
+		t.Fatalf("expected the tenant to be suspended, got %s", status)
+	}
+	if stored := f.subscriptions.subscriptions[suspended.ID]; stored.Status != models.SubscriptionStatusSuspended || stored.DunningStage != models.DunningStageSuspended {
+		t.Fatalf("expected a suspended subscription, got %+v", stored)
+	}
+	f.deliver(t, suspended, billing.EventPaymentCompleted, "in_2")
+
+	for _, subscription := range []*models.Subscription{restricted, suspended} {
+		stored := f.subscriptions.subscriptions[subscription.ID]
+		if stored.Status != models.SubscriptionStatusActive || stored.DunningStage != "" || stored.PastDueSince != nil {
+			t.Fatalf("expected an active subscription after the payment, got %+v", stored)
+		}
+		tenant := f.tenants.tenants[subscription.TenantID]
+		if tenant.Status != models.TenantStatusActive || tenant.BillingRestricted || tenant.Tier != models.TierPro {
+			t.Fatalf("expected the tenant back on Pro, got %+v", tenant)
+		}
+	}
+
+	recovered := 0
+	for _, log := range f.auditLog.logs {
+		if log.Action == string(models.AuditActionDunningRecovered) {
+			recovered++
+		}
+	}
+	if recovered != 2 {
+		t.Fatalf("expected two recoveries in the audit log, got %v", f.auditActions())
+	}
+}
+
+func TestProviderSuspensionStartsDunning(t *testing.T) {
+	f := newDunningFixture(t, models.DunningActionDowngrade)
+
+	activated := f.subscribe(t, 1, "PLAN_PRO_MONTHLY", "")
+	f.deliver(t, activated, billing.EventSubscriptionSuspended, "")
+
+	stored := f.subscriptions.subscriptions[activated.ID]
+	if stored.Status != models.SubscriptionStatusPastDue || stored.DunningStage != models.DunningStageGrace {
+		t.Fatalf("expected the suspension to start dunning, got %+v", stored)
+	}
+	if tier := f.tenants.tenants[1].Tier; tier != models.TierPro {
+		t.Fatalf("expected the tenant to keep Pro in the grace period, got %s", tier)
+	}
+
+	// The provider reporting the subscription active doesn't end dunning,
+	// only a payment does
+	f.deliver(t, activated, billing.EventSubscriptionUpdated, "")
+	if stored := f.subscriptions.subscriptions[activated.ID]; stored.Status != models.SubscriptionStatusPastDue || !stored.InDunning() {
+		t.Fatalf("expected the subscription to stay past due, got %+v", stored)
+	}
+}
+
+func TestDunningPolicyTimeline(t *testing.T) {
+	policy := &models.DunningPolicy{
+		GracePeriod:  3 * 24 * time.Hour,
+		ReminderDays: []int{1, 3, 7},
+		FinalAfter:   10 * 24 * time.Hour,
+		FinalAction:  models.DunningActionSuspend,
+	}
+	failedAt := time.Date(2026, 4, 1, 9, 0, 0, 0, time.UTC)
+
+	for _, step := range []struct {
+		days      int
+		stage     models.DunningStage
+		reminders int
+	}{
+		{0, models.DunningStageGrace, 0},
+		{1, models.DunningStageGrace, 1},
+		{3, models.DunningStageRestricted, 2},
+		{7, models.DunningStageRestricted, 3},
+		{10, models.DunningStageSuspended, 3},
+	} {
+		now := failedAt.AddDate(0, 0, step.days)
+		if stage := policy.Stage(failedAt, now); stage != step.stage {
+			t.Errorf("day %d: expected stage %s, got %s", step.days, step.stage, stage)
+		}
+		if reminders := policy.RemindersDue(failedAt, now); reminders != step.reminders {
+			t.Errorf("day %d: expected %d reminders, got %d", step.days, step.reminders, reminders)
+		}
+	}
+
+	policy.FinalAction = models.DunningActionDowngrade
+	if stage := policy.Stage(failedAt, failedAt.AddDate(0, 0, 10)); stage != models.DunningStageDowngraded {
+		t.Errorf("expected a downgrade, got %s", stage)
+	}
+}
+
+func TestOverdueTenantHeldToFreeLimits(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	gins := &fakeGinRepository{count: 20}
+	tenant := &models.Tenant{ID: 1, Tier: models.TierPro, BillingRestricted: true}
+	enforcement := middleware.NewTierEnforcementMiddleware(entitlement.NewService(nil), gins)
+
+	router := gin.New()
+	group := router.Group("/api/v1", func(c *gin.Context) {
+		c.Set("tenant", tenant)
+		c.Set("tenant_id", tenant.ID)
+	})
+	ok := func(c *gin.Context) { c.Status(http.StatusOK) }
+	group.GET("/botanicals", enforcement.RequireFeature("botanicals"), ok)
+	group.GET("/gins/:id", enforcement.ReadOnlyOverLimit(), ok)
+	group.PUT("/gins/:id", enforcement.ReadOnlyOverLimit(), ok)
+
+	request := func(method, path string) (*httptest.ResponseRecorder, map[string]interface{}) {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(method, path, nil))
+		var body map[string]interface{}
+		json.Unmarshal(w.Body.Bytes(), &body)
+		return w, body
+	}
+
+	if w, body := request("GET", "/api/v1/botanicals"); w.Code != http.StatusPaymentRequired || body["payment_required"] != true {
+		t.Fatalf("expected Pro features to require the payment, got %d %v", w.Code, body)
+	}
+	if w, body := request("PUT", "/api/v1/gins/1"); w.Code != http.StatusForbidden || body["payment_required"] != true || body["limit"] != float64(5) {
+		t.Fatalf("expected the collection read-only over the Free limit, got %d %v", w.Code, body)
+	}
+	if w, _ := request("GET", "/api/v1/gins/1"); w.Code != http.StatusOK {
+		t.Fatalf("expected reading to stay possible, got %d", w.Code)
+	}
+
+	// Once paid the Pro limits apply again
+	tenant.BillingRestricted = false
+	if w, _ := request("GET", "/api/v1/botanicals"); w.Code != http.StatusOK {
+		t.Fatalf("expected Pro features to be available, got %d", w.Code)
+	}
+	if w, _ := request("PUT", "/api/v1/gins/1"); w.Code != http.StatusOK {
+		t.Fatalf("expected edits within the Pro limit, got %d", w.Code)
+	}
+}
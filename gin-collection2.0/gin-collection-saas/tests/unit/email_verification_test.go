@@ -0,0 +1,435 @@
+package unit
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/yourusername/gin-collection-saas/internal/delivery/http/middleware"
+	"github.com/yourusername/gin-collection-saas/internal/domain/errors"
+	"github.com/yourusername/gin-collection-saas/internal/domain/models"
+	"github.com/yourusername/gin-collection-saas/internal/usecase/auth"
+	"github.com/yourusername/gin-collection-saas/internal/usecase/emailverification"
+	"github.com/yourusername/gin-collection-saas/internal/usecase/entitlement"
+	"github.com/yourusername/gin-collection-saas/internal/usecase/user"
+	"github.com/yourusername/gin-collection-saas/pkg/utils"
+)
+
+// fakeEmailVerificationRepository keeps verifications in memory, completing
+// one updates the user in the user repository
+type fakeEmailVerificationRepository struct {
+	verifications []*models.EmailVerification
+	users         *fakeUserRepository
+}
+
+func (r *fakeEmailVerificationRepository) Create(ctx context.Context, verification *models.EmailVerification) error {
+	verification.ID = int64(len(r.verifications) + 1)
+	verification.CreatedAt = time.Now()
+	copied := *verification
+	r.verifications = append(r.verifications, &copied)
+	return nil
+}
+
+func (r *fakeEmailVerificationRepository) GetByTokenHash(ctx context.Context, tokenHash string) (*models.EmailVerification, error) {
+	for _, v := range r.verifications {
+		if v.TokenHash == tokenHash || (v.OldTokenHash != nil && *v.OldTokenHash == tokenHash) {
+			copied := *v
+			return &copied, nil
+		}
+	}
+	return nil, errors.ErrNotFound
+}
+
+func (r *fakeEmailVerificationRepository) GetPending(ctx context.Context, userID int64, purpose models.EmailVerificationPurpose) (*models.EmailVerification, error) {
+	for _, v := range r.verifications {
+		if v.UserID == userID && v.Purpose == purpose && v.CompletedAt == nil {
+			copied := *v
+			return &copied, nil
+		}
+	}
+	return nil, errors.ErrNotFound
+}
+
+func (r *fakeEmailVerificationRepository) Confirm(ctx context.Context, id int64, old bool) error {
+	for _, v := range r.verifications {
+		if v.ID == id && v.CompletedAt == nil {
+			now := time.Now()
+			if old {
+				v.OldConfirmedAt = &now
+			} else {
+				v.ConfirmedAt = &now
+			}
+			return nil
+		}
+	}
+	return errors.ErrInvalidToken
+}
+
+func (r *fakeEmailVerificationRepository) Complete(ctx context.Context, id int64, user *models.User) error {
+	var completed *models.EmailVerification
+	for _, v := range r.verifications {
+		if v.ID == id && v.CompletedAt == nil {
+			completed = v
+		}
+	}
+	if completed == nil {
+		return errors.ErrInvalidToken
+	}
+
+	now := time.Now()
+	completed.CompletedAt = &now
+	if err := r.users.Update(ctx, user); err != nil {
+		return err
+	}
+
+	remaining := r.verifications[:0]
+	for _, v := range r.verifications {
+		if v.UserID != user.ID || v.CompletedAt != nil {
+			remaining = append(remaining, v)
+		}
+	}
+	r.verifications = remaining
+	return nil
+}
+
+func (r *fakeEmailVerificationRepository) DeletePending(ctx context.Context, userID int64, purpose models.EmailVerificationPurpose) error {
+	remaining := r.verifications[:0]
+	for _, v := range r.verifications {
+		if v.UserID != userID || v.Purpose != purpose || v.CompletedAt != nil {
+			remaining = append(remaining, v)
+		}
+	}
+	r.verifications = remaining
+	return nil
+}
+
+// setTokens replaces the hashed tokens of the pending verification, the
+// generated ones are only sent by email
+func (r *fakeEmailVerificationRepository) setTokens(t *testing.T, userID int64, purpose models.EmailVerificationPurpose, token, oldToken string) {
+	t.Helper()
+	for _, v := range r.verifications {
+		if v.UserID == userID && v.Purpose == purpose && v.CompletedAt == nil {
+			v.TokenHash = utils.HashToken(token)
+			if oldToken != "" {
+				oldHash := utils.HashToken(oldToken)
+				v.OldTokenHash = &oldHash
+			}
+			return
+		}
+	}
+	t.Fatalf("no pending %s verification for user %d", purpose, userID)
+}
+
+const emailVerificationPassword = "Correct-Horse-9-Battery"
+
+type emailVerificationFixture struct {
+	tenant   *models.Tenant
+	users    *fakeUserRepository
+	repo     *fakeEmailVerificationRepository
+	service  *emailverification.Service
+	auth     *auth.Service
+	auditLog *fakeAuditLogRepository
+	userID   int64
+}
+
+func newEmailVerificationFixture(t *testing.T) *emailVerificationFixture {
+	t.Helper()
+
+	f := &emailVerificationFixture{
+		tenant:   &models.Tenant{ID: 1, Subdomain: "acme", Tier: models.TierEnterprise, Status: models.TenantStatusActive},
+		users:    newFakeUserRepository(),
+		auditLog: &fakeAuditLogRepository{},
+	}
+	f.repo = &fakeEmailVerificationRepository{users: f.users}
+	f.service = emailverification.NewService(f.repo, f.users, nil, "https://app.example.com")
+
+	f.auth = auth.NewService(f.users, newFakeTenantRepository(f.tenant), "test-secret", time.Hour)
+	f.auth.SetEmailVerificationService(f.service)
+
+	passwordHash, err := utils.HashPassword(emailVerificationPassword)
+	if err != nil {
+		t.Fatalf("failed to hash password: %v", err)
+	}
+	member := &models.User{
+		TenantID:     f.tenant.ID,
+		Email:        "member@example.com",
+		PasswordHash: passwordHash,
+		Role:         models.RoleMember,
+		IsActive:     true,
+	}
+	if err := f.users.Create(context.Background(), member); err != nil {
+		t.Fatalf("failed to create user: %v", err)
+	}
+	f.userID = member.ID
+	return f
+}
+
+func (f *emailVerificationFixture) user(t *testing.T) *models.User {
+	t.Helper()
+	u, err := f.users.GetByID(context.Background(), f.userID)
+	if err != nil {
+		t.Fatalf("failed to get user: %v", err)
+	}
+	return u
+}
+
+func TestRegisterSendsEmailVerification(t *testing.T) {
+	users := newFakeUserRepository()
+	repo := &fakeEmailVerificationRepository{users: users}
+	authService := auth.NewService(users, newFakeTenantRepository(), "test-secret", time.Hour)
+	authService.SetEmailVerificationService(emailverification.NewService(repo, users, nil, "https://app.example.com"))
+
+	resp, err := authService.Register(context.Background(), &models.RegisterRequest{
+		TenantName: "Acme",
+		Subdomain:  "acme",
+		Email:      "owner@example.com",
+		Password:   emailVerificationPassword,
+	})
+	if err != nil {
+		t.Fatalf("Register failed: %v", err)
+	}
+	if resp.User.IsEmailVerified() {
+		t.Error("expected new owner to be unverified")
+	}
+
+	pending, err := repo.GetPending(context.Background(), resp.User.ID, models.EmailVerificationPurposeVerify)
+	if err != nil {
+		t.Fatalf("expected pending verification: %v", err)
+	}
+	if pending.Email != "owner@example.com" || pending.TokenHash == "" {
+		t.Errorf("unexpected verification %+v", pending)
+	}
+}
+
+func TestVerifyEmail(t *testing.T) {
+	f := newEmailVerificationFixture(t)
+	ctx := context.Background()
+
+	if err := f.auth.ResendEmailVerification(ctx, f.userID); err != nil {
+		t.Fatalf("ResendEmailVerification failed: %v", err)
+	}
+	f.repo.setTokens(t, f.userID, models.EmailVerificationPurposeVerify, "verify-token", "")
+
+	if _, err := f.auth.VerifyEmail(ctx, "unknown-token"); err != errors.ErrInvalidToken {
+		t.Errorf("expected ErrInvalidToken for unknown token, got %v", err)
+	}
+
+	verification, err := f.auth.VerifyEmail(ctx, "verify-token")
+	if err != nil {
+		t.Fatalf("VerifyEmail failed: %v", err)
+	}
+	if !verification.IsCompleted() {
+		t.Error("expected verification to be completed")
+	}
+	if !f.user(t).IsEmailVerified() {
+		t.Error("expected email to be verified")
+	}
+
+	// Links work once, verified users get no new ones
+	if _, err := f.auth.VerifyEmail(ctx, "verify-token"); err != errors.ErrTokenExpired {
+		t.Errorf("expected used token to be rejected, got %v", err)
+	}
+	if err := f.auth.ResendEmailVerification(ctx, f.userID); err != errors.ErrEmailAlreadyVerified {
+		t.Errorf("expected ErrEmailAlreadyVerified, got %v", err)
+	}
+}
+
+func TestVerifyEmailRejectsExpiredToken(t *testing.T) {
+	f := newEmailVerificationFixture(t)
+	ctx := context.Background()
+
+	if err := f.auth.ResendEmailVerification(ctx, f.userID); err != nil {
+		t.Fatalf("ResendEmailVerification failed: %v", err)
+	}
+	f.repo.setTokens(t, f.userID, models.EmailVerificationPurposeVerify, "verify-token", "")
+	f.repo.verifications[0].ExpiresAt = time.Now().Add(-time.Minute)
+
+	if _, err := f.auth.VerifyEmail(ctx, "verify-token"); err != errors.ErrTokenExpired {
+		t.Errorf("expected ErrTokenExpired, got %v", err)
+	}
+	if f.user(t).IsEmailVerified() {
+		t.Error("expected email to stay unverified")
+	}
+}
+
+func TestEmailChangeRequiresBothAddresses(t *testing.T) {
+	f := newEmailVerificationFixture(t)
+	ctx := context.Background()
+
+	pending, err := f.auth.RequestEmailChange(ctx, f.userID, &models.ChangeEmailRequest{
+		NewEmail: "new@example.com",
+		Password: emailVerificationPassword,
+	})
+	if err != nil {
+		t.Fatalf("RequestEmailChange failed: %v", err)
+	}
+	if pending.Email != "new@example.com" {
+		t.Errorf("expected pending change to new@example.com, got %s", pending.Email)
+	}
+	f.repo.setTokens(t, f.userID, models.EmailVerificationPurposeChange, "new-token", "old-token")
+
+	// The new address alone doesn't change anything
+	verification, err := f.auth.VerifyEmail(ctx, "new-token")
+	if err != nil {
+		t.Fatalf("VerifyEmail (new address) failed: %v", err)
+	}
+	if verification.IsCompleted() {
+		t.Error("expected change to wait for the current address")
+	}
+	if got := f.user(t).Email; got != "member@example.com" {
+		t.Errorf("expected email to be unchanged, got %s", got)
+	}
+
+	verification, err = f.auth.VerifyEmail(ctx, "old-token")
+	if err != nil {
+		t.Fatalf("VerifyEmail (current address) failed: %v", err)
+	}
+	if !verification.IsCompleted() {
+		t.Error("expected change to be completed")
+	}
+
+	changed := f.user(t)
+	if changed.Email != "new@example.com" || !changed.IsEmailVerified() {
+		t.Errorf("expected verified new@example.com, got %s (verified %v)", changed.Email, changed.IsEmailVerified())
+	}
+
+	pending, err = f.auth.GetPendingEmailChange(ctx, f.userID)
+	if err != nil || pending != nil {
+		t.Errorf("expected no pending change, got %+v (%v)", pending, err)
+	}
+}
+
+func TestEmailChangeValidation(t *testing.T) {
+	f := newEmailVerificationFixture(t)
+	ctx := context.Background()
+
+	other := &models.User{TenantID: f.tenant.ID, Email: "taken@example.com", Role: models.RoleMember, IsActive: true}
+	if err := f.users.Create(ctx, other); err != nil {
+		t.Fatalf("failed to create user: %v", err)
+	}
+
+	cases := []struct {
+		name     string
+		newEmail string
+		password string
+		want     error
+	}{
+		{"wrong password", "new@example.com", "wrong-password", errors.ErrInvalidCredentials},
+		{"address taken", "Taken@example.com", emailVerificationPassword, errors.ErrEmailAlreadyExists},
+		{"same address", "member@example.com", emailVerificationPassword, errors.ErrInvalidInput},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := f.auth.RequestEmailChange(ctx, f.userID, &models.ChangeEmailRequest{NewEmail: tc.newEmail, Password: tc.password})
+			if err != tc.want {
+				t.Errorf("expected %v, got %v", tc.want, err)
+			}
+		})
+	}
+
+	if len(f.repo.verifications) != 0 {
+		t.Errorf("expected no pending changes, got %d", len(f.repo.verifications))
+	}
+}
+
+func TestEmailChangeCancelled(t *testing.T) {
+	f := newEmailVerificationFixture(t)
+	ctx := context.Background()
+
+	if _, err := f.auth.RequestEmailChange(ctx, f.userID, &models.ChangeEmailRequest{NewEmail: "new@example.com", Password: emailVerificationPassword}); err != nil {
+		t.Fatalf("RequestEmailChange failed: %v", err)
+	}
+	f.repo.setTokens(t, f.userID, models.EmailVerificationPurposeChange, "new-token", "old-token")
+
+	if err := f.auth.CancelEmailChange(ctx, f.userID); err != nil {
+		t.Fatalf("CancelEmailChange failed: %v", err)
+	}
+	if _, err := f.auth.VerifyEmail(ctx, "old-token"); err != errors.ErrInvalidToken {
+		t.Errorf("expected cancelled link to be rejected, got %v", err)
+	}
+}
+
+func TestAdminEmailChangeNeedsConfirmation(t *testing.T) {
+	f := newEmailVerificationFixture(t)
+	ctx := context.Background()
+
+	owner := &models.User{TenantID: f.tenant.ID, Email: "owner@example.com", Role: models.RoleOwner, IsActive: true}
+	if err := f.users.Create(ctx, owner); err != nil {
+		t.Fatalf("failed to create owner: %v", err)
+	}
+
+	userService := user.NewService(f.users, newFakeTenantRepository(f.tenant), f.auditLog, nil, entitlement.NewService(nil), nil, "https://app.example.com")
+	userService.SetEmailVerificationService(f.service)
+
+	updated, err := userService.UpdateUser(ctx, f.tenant.ID, owner.ID, f.userID, "new@example.com", nil, nil, models.RoleMember, true)
+	if err != nil {
+		t.Fatalf("UpdateUser failed: %v", err)
+	}
+	if updated.Email != "member@example.com" {
+		t.Errorf("expected email to stay until confirmed, got %s", updated.Email)
+	}
+
+	pending, err := f.auth.GetPendingEmailChange(ctx, f.userID)
+	if err != nil || pending == nil || pending.Email != "new@example.com" {
+		t.Fatalf("expected pending change to new@example.com, got %+v (%v)", pending, err)
+	}
+
+	// Addresses from the identity provider are trusted
+	if _, err := userService.UpdateProvisionedUser(ctx, f.tenant.ID, f.userID, "scim@example.com", nil, nil, models.RoleMember, true); err != nil {
+		t.Fatalf("UpdateProvisionedUser failed: %v", err)
+	}
+	provisioned := f.user(t)
+	if provisioned.Email != "scim@example.com" || !provisioned.IsEmailVerified() {
+		t.Errorf("expected verified scim@example.com, got %s (verified %v)", provisioned.Email, provisioned.IsEmailVerified())
+	}
+}
+
+func TestRequireVerifiedEmailMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	f := newEmailVerificationFixture(t)
+	f.tenant.Tier = models.TierFree
+
+	newRouter := func(tiers []string) *gin.Engine {
+		m := middleware.NewEmailVerificationMiddleware(f.users, tiers)
+		r := gin.New()
+		r.Use(func(c *gin.Context) {
+			c.Set("user_id", f.userID)
+			c.Set("tenant", f.tenant)
+			c.Set("tenant_id", f.tenant.ID)
+		}, m.RequireVerifiedEmail())
+		ok := func(c *gin.Context) { c.Status(http.StatusOK) }
+		r.GET("/gins", ok)
+		r.POST("/gins", ok)
+		return r
+	}
+	request := func(r *gin.Engine, method string) int {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(method, "/gins", nil))
+		return w.Code
+	}
+
+	restricted := newRouter([]string{"free"})
+	if code := request(restricted, http.MethodGet); code != http.StatusOK {
+		t.Errorf("expected reads to pass, got %d", code)
+	}
+	if code := request(restricted, http.MethodPost); code != http.StatusForbidden {
+		t.Errorf("expected unverified write to be blocked, got %d", code)
+	}
+
+	// Other tiers and an empty configuration are not restricted
+	if code := request(newRouter([]string{"basic"}), http.MethodPost); code != http.StatusOK {
+		t.Errorf("expected unrestricted tier to pass, got %d", code)
+	}
+	if code := request(newRouter(nil), http.MethodPost); code != http.StatusOK {
+		t.Errorf("expected no restriction by default, got %d", code)
+	}
+
+	verifiedAt := time.Now()
+	f.users.users[f.userID].EmailVerifiedAt = &verifiedAt
+	if code := request(restricted, http.MethodPost); code != http.StatusOK {
+		t.Errorf("expected verified write to pass, got %d", code)
+	}
+}
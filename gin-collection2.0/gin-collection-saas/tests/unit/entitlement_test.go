@@ -0,0 +1,443 @@
+package unit
+
+import (
+	"context"
+	"encoding/json"
+	stdErrors "errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/yourusername/gin-collection-saas/internal/delivery/http/middleware"
+	"github.com/yourusername/gin-collection-saas/internal/delivery/http/response"
+	"github.com/yourusername/gin-collection-saas/internal/domain/errors"
+	"github.com/yourusername/gin-collection-saas/internal/domain/models"
+	"github.com/yourusername/gin-collection-saas/internal/usecase/entitlement"
+)
+
+// fakePlanCatalog offers a fixed list of plans
+type fakePlanCatalog struct {
+	plans []models.SubscriptionPlan
+}
+
+func (c *fakePlanCatalog) Plans() []models.SubscriptionPlan {
+	return c.plans
+}
+
+func TestEntitlementFeaturesPerTier(t *testing.T) {
+	service := entitlement.NewService(nil)
+
+	// Lowest tier that includes each feature
+	requiredTier := map[string]models.SubscriptionTier{
+		models.FeatureBotanicals:        models.TierPro,
+		models.FeatureCocktails:         models.TierPro,
+		models.FeatureAISuggestions:     models.TierPro,
+		models.FeatureExport:            models.TierBasic,
+		models.FeatureImport:            models.TierPro,
+		models.FeatureMultiUser:         models.TierEnterprise,
+		models.FeatureAPIAccess:         models.TierPro,
+		models.FeatureSSO:               models.TierEnterprise,
+		models.FeatureSCIM:              models.TierEnterprise,
+		models.FeatureDedicatedDatabase: models.TierEnterprise,
+	}
+	if len(requiredTier) != len(models.FeatureRules) {
+		t.Fatalf("expected every declared feature in the table, got %d of %d", len(requiredTier), len(models.FeatureRules))
+	}
+
+	tests := []struct {
+		tier    models.SubscriptionTier
+		allowed []string
+	}{
+		{models.TierFree, nil},
+		{models.TierBasic, []string{models.FeatureExport}},
+		{models.TierPro, []string{models.FeatureBotanicals, models.FeatureCocktails, models.FeatureAISuggestions, models.FeatureExport, models.FeatureImport, models.FeatureAPIAccess}},
+		{models.TierEnterprise, []string{models.FeatureBotanicals, models.FeatureCocktails, models.FeatureAISuggestions, models.FeatureExport, models.FeatureImport, models.FeatureMultiUser, models.FeatureAPIAccess, models.FeatureSSO, models.FeatureSCIM, models.FeatureDedicatedDatabase}},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.tier), func(t *testing.T) {
+			tenant := &models.Tenant{ID: 1, Tier: tt.tier}
+			allowed := map[string]bool{}
+			for _, feature := range tt.allowed {
+				allowed[feature] = true
+			}
+
+			for _, rule := range models.FeatureRules {
+				decision := service.Feature(tenant, rule.Feature)
+				if decision.Allowed != allowed[rule.Feature] {
+					t.Errorf("%s: expected allowed=%v, got %v", rule.Feature, allowed[rule.Feature], decision.Allowed)
+					continue
+				}
+				if service.Included(tenant, rule.Feature) != allowed[rule.Feature] {
+					t.Errorf("%s: expected Included to agree with the decision", rule.Feature)
+				}
+
+				err := service.RequireFeature(tenant, rule.Feature)
+				if decision.Allowed {
+					if err != nil {
+						t.Errorf("%s: expected no error, got %v", rule.Feature, err)
+					}
+					continue
+				}
+
+				if decision.Reason != models.DenialFeatureNotInPlan || !decision.UpgradeRequired() || decision.PaymentRequired {
+					t.Errorf("%s: expected an upgrade to be required, got %+v", rule.Feature, decision)
+				}
+				if decision.RequiredTier != requiredTier[rule.Feature] {
+					t.Errorf("%s: expected %s as required tier, got %q", rule.Feature, requiredTier[rule.Feature], decision.RequiredTier)
+				}
+				if decision.CurrentTier != tt.tier {
+					t.Errorf("%s: expected current tier %s, got %s", rule.Feature, tt.tier, decision.CurrentTier)
+				}
+				if !stdErrors.Is(err, rule.Err) || !stdErrors.Is(err, errors.ErrFeatureNotAvailable) {
+					t.Errorf("%s: expected the error to match its sentinels, got %v", rule.Feature, err)
+				}
+			}
+		})
+	}
+
+	denial := service.Feature(&models.Tenant{Tier: models.TierFree}, models.FeatureBotanicals)
+	if denial.Message != "Botanicals tracking requires Pro tier" {
+		t.Errorf("unexpected message %q", denial.Message)
+	}
+}
+
+func TestEntitlementUnknownFeatureDenied(t *testing.T) {
+	service := entitlement.NewService(nil)
+
+	for _, tier := range models.Tiers {
+		tenant := &models.Tenant{ID: 1, Tier: tier}
+		decision := service.Feature(tenant, "teleportation")
+		if decision.Allowed || decision.Reason != models.DenialUnknownFeature {
+			t.Errorf("%s: expected unknown features to be denied, got %+v", tier, decision)
+		}
+		if decision.UpgradeRequired() || decision.RequiredTier != "" {
+			t.Errorf("%s: expected no upgrade hint for an unknown feature, got %+v", tier, decision)
+		}
+		if service.Included(tenant, "teleportation") {
+			t.Errorf("%s: expected unknown features not to be included", tier)
+		}
+	}
+}
+
+func TestEntitlementGinLimitPerTier(t *testing.T) {
+	service := entitlement.NewService(nil)
+
+	tests := []struct {
+		tier         models.SubscriptionTier
+		gins         int
+		allowed      bool
+		limit        int
+		requiredTier models.SubscriptionTier
+	}{
+		{models.TierFree, 4, true, 0, ""},
+		{models.TierFree, 5, false, 5, models.TierBasic},
+		{models.TierBasic, 14, true, 0, ""},
+		{models.TierBasic, 15, false, 15, models.TierPro},
+		{models.TierPro, 99, true, 0, ""},
+		{models.TierPro, 100, false, 100, models.TierEnterprise},
+		{models.TierEnterprise, 100000, true, 0, ""},
+	}
+
+	for _, tt := range tests {
+		tenant := &models.Tenant{ID: 1, Tier: tt.tier}
+		decision := service.GinLimit(tenant, tt.gins)
+		if decision.Allowed != tt.allowed {
+			t.Errorf("%s with %d gins: expected allowed=%v", tt.tier, tt.gins, tt.allowed)
+			continue
+		}
+		if tt.allowed {
+			continue
+		}
+		if decision.Reason != models.DenialLimitReached || *decision.Limit != tt.limit || *decision.Current != tt.gins {
+			t.Errorf("%s with %d gins: unexpected denial %+v", tt.tier, tt.gins, decision)
+		}
+		if decision.RequiredTier != tt.requiredTier {
+			t.Errorf("%s with %d gins: expected %s as required tier, got %q", tt.tier, tt.gins, tt.requiredTier, decision.RequiredTier)
+		}
+		if !stdErrors.Is(decision.Err(), errors.ErrLimitReached) {
+			t.Errorf("%s with %d gins: expected ErrLimitReached, got %v", tt.tier, tt.gins, decision.Err())
+		}
+	}
+}
+
+func TestEntitlementPhotoLimitPerTier(t *testing.T) {
+	service := entitlement.NewService(nil)
+
+	tests := []struct {
+		tier         models.SubscriptionTier
+		photos       int
+		allowed      bool
+		requiredTier models.SubscriptionTier
+	}{
+		{models.TierFree, 2, true, ""},
+		{models.TierFree, 3, false, models.TierBasic},
+		{models.TierBasic, 10, false, models.TierPro},
+		{models.TierPro, 24, true, ""},
+		{models.TierPro, 25, false, models.TierEnterprise},
+		{models.TierEnterprise, 500, true, ""}, // unlimited
+	}
+
+	for _, tt := range tests {
+		decision := service.PhotoLimit(&models.Tenant{ID: 1, Tier: tt.tier}, tt.photos)
+		if decision.Allowed != tt.allowed {
+			t.Errorf("%s with %d photos: expected allowed=%v", tt.tier, tt.photos, tt.allowed)
+			continue
+		}
+		if tt.allowed {
+			continue
+		}
+		if decision.RequiredTier != tt.requiredTier {
+			t.Errorf("%s with %d photos: expected %s as required tier, got %q", tt.tier, tt.photos, tt.requiredTier, decision.RequiredTier)
+		}
+		err := decision.Err()
+		if !stdErrors.Is(err, errors.ErrPhotoLimitReached) || !stdErrors.Is(err, errors.ErrLimitReached) {
+			t.Errorf("%s with %d photos: expected the photo limit sentinels, got %v", tt.tier, tt.photos, err)
+		}
+	}
+}
+
+func TestEntitlementStorageLimitPerTier(t *testing.T) {
+	ctx := context.Background()
+
+	tests := []struct {
+		tier         models.SubscriptionTier
+		usedMB       int64
+		allowed      bool
+		requiredTier models.SubscriptionTier
+	}{
+		{models.TierFree, 90, true, ""},
+		{models.TierFree, 99, false, models.TierBasic},
+		{models.TierBasic, 999, false, models.TierPro},
+		{models.TierPro, 4999, false, models.TierEnterprise},
+		{models.TierEnterprise, 1000000, true, ""},
+	}
+
+	for _, tt := range tests {
+		storage := &fakeStorageUsageRepository{bytesUsed: tt.usedMB * models.BytesPerMB}
+		service := entitlement.NewService(storage)
+
+		decision, err := service.StorageLimit(ctx, &models.Tenant{ID: 1, Tier: tt.tier}, 2*models.BytesPerMB)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if decision.Allowed != tt.allowed {
+			t.Errorf("%s with %dMB: expected allowed=%v", tt.tier, tt.usedMB, tt.allowed)
+			continue
+		}
+		if tt.allowed {
+			continue
+		}
+		if *decision.Current != int(tt.usedMB) || decision.RequiredTier != tt.requiredTier {
+			t.Errorf("%s with %dMB: unexpected denial %+v", tt.tier, tt.usedMB, decision)
+		}
+		if !stdErrors.Is(decision.Err(), errors.ErrStorageLimitReached) {
+			t.Errorf("%s with %dMB: expected ErrStorageLimitReached, got %v", tt.tier, tt.usedMB, decision.Err())
+		}
+	}
+}
+
+func TestEntitlementUsageLimit(t *testing.T) {
+	service := entitlement.NewService(nil)
+	limits := models.PlanLimitsMap[models.TierPro]
+	limits.MaxAPICallsPerPeriod = intPtr(1000)
+	tenant := &models.Tenant{ID: 1, Tier: models.TierPro, PlanLimits: &limits}
+
+	if decision := service.UsageLimit(tenant, models.NewUsageMeter(models.MetricAPICalls, 999, intPtr(1000))); !decision.Allowed {
+		t.Fatalf("expected calls below the limit to be allowed, got %+v", decision)
+	}
+
+	decision := service.UsageLimit(tenant, models.NewUsageMeter(models.MetricAPICalls, 1000, intPtr(1000)))
+	if decision.Allowed || decision.Reason != models.DenialLimitReached || *decision.Limit != 1000 || *decision.Current != 1000 {
+		t.Fatalf("expected the used up limit to be denied, got %+v", decision)
+	}
+	if decision.RequiredTier != models.TierEnterprise {
+		t.Errorf("expected Enterprise as required tier, got %q", decision.RequiredTier)
+	}
+	if !stdErrors.Is(decision.Err(), errors.ErrUsageLimitReached) || !stdErrors.Is(decision.Err(), errors.ErrLimitReached) {
+		t.Errorf("expected the error to match its sentinels, got %v", decision.Err())
+	}
+}
+
+func TestEntitlementCollectionOverLimit(t *testing.T) {
+	service := entitlement.NewService(nil)
+	tenant := &models.Tenant{ID: 1, Tier: models.TierFree}
+
+	if decision := service.Collection(tenant, 5); !decision.Allowed {
+		t.Fatalf("expected a collection at the limit to be editable, got %+v", decision)
+	}
+
+	decision := service.Collection(tenant, 8)
+	if decision.Allowed || decision.Reason != models.DenialOverLimit || decision.OverLimit != 3 {
+		t.Fatalf("expected the collection to be read-only 3 gins over the limit, got %+v", decision)
+	}
+	if decision.RequiredTier != models.TierBasic {
+		t.Errorf("expected Basic as required tier, got %q", decision.RequiredTier)
+	}
+	if !stdErrors.Is(decision.Err(), errors.ErrOverLimit) {
+		t.Errorf("expected ErrOverLimit, got %v", decision.Err())
+	}
+}
+
+func TestEntitlementPaymentOverdue(t *testing.T) {
+	service := entitlement.NewService(nil)
+	tenant := &models.Tenant{ID: 1, Tier: models.TierPro, BillingRestricted: true}
+
+	// The plan includes it, paying is enough
+	decision := service.Feature(tenant, models.FeatureBotanicals)
+	if decision.Allowed || decision.Reason != models.DenialPaymentOverdue || !decision.PaymentRequired {
+		t.Fatalf("expected the payment to be required, got %+v", decision)
+	}
+	if decision.UpgradeRequired() || decision.RequiredTier != "" {
+		t.Errorf("expected no upgrade hint while the plan includes the feature, got %+v", decision)
+	}
+	if !stdErrors.Is(decision.Err(), errors.ErrPaymentOverdue) {
+		t.Errorf("expected ErrPaymentOverdue, got %v", decision.Err())
+	}
+	if !service.Included(tenant, models.FeatureBotanicals) {
+		t.Error("expected the plan to still include the feature")
+	}
+
+	// Held to the Free gin limit, without a hint
+	decision = service.GinLimit(tenant, 10)
+	if decision.Allowed || *decision.Limit != 5 || !decision.PaymentRequired || decision.RequiredTier != "" {
+		t.Fatalf("expected the Free limit without hint, got %+v", decision)
+	}
+
+	// Features the plan doesn't include still need an upgrade
+	decision = service.Feature(tenant, models.FeatureMultiUser)
+	if decision.Reason != models.DenialFeatureNotInPlan || decision.RequiredTier != models.TierEnterprise {
+		t.Fatalf("expected an upgrade to Enterprise, got %+v", decision)
+	}
+}
+
+func TestEntitlementCatalogHints(t *testing.T) {
+	botanicalsBasic := models.PlanLimitsMap[models.TierBasic]
+	botanicalsBasic.HasBotanicals = true
+
+	catalog := &fakePlanCatalog{plans: []models.SubscriptionPlan{
+		{ID: models.FreePlanID, Tier: models.TierFree, Limits: models.PlanLimitsMap[models.TierFree], Active: true},
+		{ID: "PLAN_BASIC_LEGACY", Tier: models.TierBasic, Limits: models.PlanLimitsMap[models.TierBasic], Active: false},
+		{ID: "PLAN_BASIC_MONTHLY", Tier: models.TierBasic, Limits: botanicalsBasic, Active: true},
+		{ID: "PLAN_BASIC_YEARLY", Tier: models.TierBasic, Limits: botanicalsBasic, Active: true},
+		{ID: "PLAN_PRO_MONTHLY", Tier: models.TierPro, Limits: models.PlanLimitsMap[models.TierPro], Active: true},
+		{ID: "PLAN_ENTERPRISE", Tier: models.TierEnterprise, Limits: models.PlanLimitsMap[models.TierEnterprise], Active: true},
+	}}
+	service := entitlement.NewService(nil)
+	service.SetPlanCatalog(catalog)
+
+	free := &models.Tenant{ID: 1, Tier: models.TierFree}
+
+	// Limits of the catalog plans decide, the first active plan of the lowest tier wins
+	decision := service.Feature(free, models.FeatureBotanicals)
+	if decision.RequiredTier != models.TierBasic || decision.UpgradePlanID != "PLAN_BASIC_MONTHLY" {
+		t.Errorf("expected PLAN_BASIC_MONTHLY as hint, got %q %q", decision.RequiredTier, decision.UpgradePlanID)
+	}
+
+	decision = service.Feature(free, models.FeatureMultiUser)
+	if decision.RequiredTier != models.TierEnterprise || decision.UpgradePlanID != "PLAN_ENTERPRISE" {
+		t.Errorf("expected PLAN_ENTERPRISE as hint, got %q %q", decision.RequiredTier, decision.UpgradePlanID)
+	}
+
+	// Overrides carry over, a higher plan doesn't lift a limit set for the tenant
+	free.LimitOverrides = &models.LimitOverrides{MaxPhotosPerGin: intPtr(3)}
+	decision = service.PhotoLimit(free, 3)
+	if decision.Allowed || decision.RequiredTier != "" || decision.UpgradePlanID != "" {
+		t.Errorf("expected no upgrade hint for an overridden limit, got %+v", decision)
+	}
+
+	// Without a plan that allows it there is no hint
+	catalog.plans = catalog.plans[:4]
+	decision = service.Feature(&models.Tenant{ID: 2, Tier: models.TierFree}, models.FeatureMultiUser)
+	if decision.RequiredTier != "" || decision.Message != "Multi-user support is not available in your plan" {
+		t.Errorf("expected no hint without a plan that includes it, got %+v", decision)
+	}
+}
+
+func TestDeniedResponse(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	service := entitlement.NewService(nil)
+
+	respond := func(err error) (*httptest.ResponseRecorder, map[string]interface{}) {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		response.Error(c, err)
+		var body map[string]interface{}
+		json.Unmarshal(w.Body.Bytes(), &body)
+		return w, body
+	}
+
+	w, body := respond(service.GinLimit(&models.Tenant{ID: 1, Tier: models.TierBasic}, 15).Err())
+	if w.Code != http.StatusForbidden {
+		t.Fatalf("expected 403, got %d", w.Code)
+	}
+	expected := map[string]interface{}{
+		"success":          false,
+		"reason":           string(models.DenialLimitReached),
+		"feature":          models.LimitGins,
+		"current_tier":     string(models.TierBasic),
+		"required_tier":    string(models.TierPro),
+		"upgrade_required": true,
+		"payment_required": false,
+		"limit":            float64(15),
+		"current_count":    float64(15),
+	}
+	for key, value := range expected {
+		if body[key] != value {
+			t.Errorf("%s: expected %v, got %v", key, value, body[key])
+		}
+	}
+
+	// Wrapped denials keep their details
+	overdue := service.Feature(&models.Tenant{ID: 1, Tier: models.TierPro, BillingRestricted: true}, models.FeatureExport).Err()
+	w, body = respond(fmt.Errorf("failed to export: %w", overdue))
+	if w.Code != http.StatusPaymentRequired || body["payment_required"] != true || body["upgrade_required"] != false {
+		t.Fatalf("expected 402 requiring the payment, got %d %v", w.Code, body)
+	}
+	if _, ok := body["required_tier"]; ok {
+		t.Errorf("expected no required tier while a payment is overdue, got %v", body)
+	}
+
+	w, body = respond(service.Collection(&models.Tenant{ID: 1, Tier: models.TierFree}, 7).Err())
+	if w.Code != http.StatusForbidden || body["over_limit"] != true || body["gins_over_limit"] != float64(2) {
+		t.Fatalf("expected the over limit details, got %d %v", w.Code, body)
+	}
+}
+
+func TestRequireFeatureDeniesUnknownFeatures(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tenant := &models.Tenant{ID: 1, Tier: models.TierEnterprise}
+	enforcement := middleware.NewTierEnforcementMiddleware(entitlement.NewService(nil), &fakeGinRepository{})
+
+	router := gin.New()
+	group := router.Group("/api/v1", func(c *gin.Context) {
+		c.Set("tenant", tenant)
+		c.Set("tenant_id", tenant.ID)
+	})
+	ok := func(c *gin.Context) { c.Status(http.StatusOK) }
+	group.GET("/cocktails", enforcement.RequireFeature(models.FeatureCocktails), ok)
+	group.GET("/typo", enforcement.RequireFeature("coktails"), ok)
+
+	request := func(path string) (*httptest.ResponseRecorder, map[string]interface{}) {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest("GET", path, nil))
+		var body map[string]interface{}
+		json.Unmarshal(w.Body.Bytes(), &body)
+		return w, body
+	}
+
+	if w, _ := request("/api/v1/cocktails"); w.Code != http.StatusOK {
+		t.Fatalf("expected Enterprise to use cocktails, got %d", w.Code)
+	}
+	if w, body := request("/api/v1/typo"); w.Code != http.StatusForbidden || body["reason"] != string(models.DenialUnknownFeature) {
+		t.Fatalf("expected unknown features to be denied, got %d %v", w.Code, body)
+	}
+
+	tenant.Tier = models.TierBasic
+	if w, body := request("/api/v1/cocktails"); w.Code != http.StatusForbidden || body["required_tier"] != string(models.TierPro) {
+		t.Fatalf("expected an upgrade hint to Pro, got %d %v", w.Code, body)
+	}
+}
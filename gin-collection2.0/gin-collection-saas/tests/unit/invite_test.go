@@ -0,0 +1,310 @@
+package unit
+
+import (
+	"context"
+	stdErrors "errors"
+	"testing"
+	"time"
+
+	"github.com/yourusername/gin-collection-saas/internal/domain/errors"
+	"github.com/yourusername/gin-collection-saas/internal/domain/models"
+	"github.com/yourusername/gin-collection-saas/internal/usecase/auth"
+	"github.com/yourusername/gin-collection-saas/internal/usecase/entitlement"
+	"github.com/yourusername/gin-collection-saas/internal/usecase/user"
+	"github.com/yourusername/gin-collection-saas/pkg/utils"
+)
+
+const invitePassword = "Correct-Horse-9-Battery"
+
+var errInviteStore = stdErrors.New("invite store unavailable")
+
+// fakeInviteTokenRepository keeps invitations in memory. Like the MySQL
+// repository it writes nothing when a combined operation fails.
+type fakeInviteTokenRepository struct {
+	nextID  int64
+	invites map[int64]*models.InviteToken
+	users   *fakeUserRepository
+	err     error
+}
+
+func newFakeInviteTokenRepository(users *fakeUserRepository) *fakeInviteTokenRepository {
+	return &fakeInviteTokenRepository{invites: make(map[int64]*models.InviteToken), users: users}
+}
+
+func (r *fakeInviteTokenRepository) insert(invite *models.InviteToken) {
+	r.nextID++
+	invite.ID = r.nextID
+	invite.CreatedAt = time.Now()
+	copied := *invite
+	r.invites[invite.ID] = &copied
+}
+
+func (r *fakeInviteTokenRepository) CreateWithUser(ctx context.Context, u *models.User, invite *models.InviteToken) error {
+	if r.err != nil {
+		return r.err
+	}
+	r.users.Create(ctx, u)
+	invite.UserID = u.ID
+	r.insert(invite)
+	return nil
+}
+
+func (r *fakeInviteTokenRepository) GetByTokenHash(ctx context.Context, tokenHash string) (*models.InviteToken, error) {
+	for _, invite := range r.invites {
+		if invite.TokenHash == tokenHash {
+			copied := *invite
+			return &copied, nil
+		}
+	}
+	return nil, errors.ErrNotFound
+}
+
+func (r *fakeInviteTokenRepository) GetPendingByUserID(ctx context.Context, tenantID, userID int64) (*models.InviteToken, error) {
+	var latest *models.InviteToken
+	for _, invite := range r.invites {
+		if invite.TenantID == tenantID && invite.UserID == userID && invite.UsedAt == nil && (latest == nil || invite.ID > latest.ID) {
+			latest = invite
+		}
+	}
+	if latest == nil {
+		return nil, errors.ErrNotFound
+	}
+	copied := *latest
+	return &copied, nil
+}
+
+func (r *fakeInviteTokenRepository) ListPending(ctx context.Context, tenantID int64) ([]*models.PendingInvite, error) {
+	return nil, nil
+}
+
+func (r *fakeInviteTokenRepository) Accept(ctx context.Context, inviteID int64, u *models.User) error {
+	invite, ok := r.invites[inviteID]
+	if !ok || invite.UserID != u.ID || !invite.IsValid() {
+		return errors.ErrInvalidToken
+	}
+	now := time.Now()
+	invite.UsedAt = &now
+
+	u.IsActive = true
+	r.users.Update(ctx, u)
+	return nil
+}
+
+func (r *fakeInviteTokenRepository) Replace(ctx context.Context, invite *models.InviteToken) error {
+	if r.err != nil {
+		return r.err
+	}
+	for id, existing := range r.invites {
+		if existing.UserID == invite.UserID && existing.UsedAt == nil {
+			delete(r.invites, id)
+		}
+	}
+	r.insert(invite)
+	return nil
+}
+
+type inviteFixture struct {
+	tenant  *models.Tenant
+	users   *fakeUserRepository
+	invites *fakeInviteTokenRepository
+	audit   *fakeAuditLogRepository
+	service *user.Service
+	auth    *auth.Service
+	owner   *models.User
+}
+
+func newInviteFixture(t *testing.T) *inviteFixture {
+	t.Helper()
+
+	f := &inviteFixture{
+		tenant: &models.Tenant{ID: 1, Name: "Acme", Subdomain: "acme", Tier: models.TierEnterprise, Status: models.TenantStatusActive},
+		users:  newFakeUserRepository(),
+		audit:  &fakeAuditLogRepository{},
+	}
+	f.invites = newFakeInviteTokenRepository(f.users)
+	tenants := newFakeTenantRepository(f.tenant)
+	f.service = user.NewService(f.users, tenants, f.audit, f.invites, entitlement.NewService(nil), nil, "https://app.example.com")
+
+	f.auth = auth.NewService(f.users, tenants, "test-secret", time.Hour)
+	f.auth.SetInviteTokenRepo(f.invites)
+
+	f.owner = &models.User{TenantID: f.tenant.ID, Email: "owner@example.com", Role: models.RoleOwner, IsActive: true}
+	f.users.Create(context.Background(), f.owner)
+	return f
+}
+
+// invite invites a member and swaps the emailed token for a known one
+func (f *inviteFixture) invite(t *testing.T, email, token string) *models.User {
+	t.Helper()
+
+	invited, err := f.service.InviteUser(context.Background(), f.tenant.ID, f.owner.ID, email, "Alice", "Smith", models.RoleMember)
+	if err != nil {
+		t.Fatalf("InviteUser failed: %v", err)
+	}
+	invite, err := f.invites.GetPendingByUserID(context.Background(), f.tenant.ID, invited.ID)
+	if err != nil {
+		t.Fatalf("expected a pending invite: %v", err)
+	}
+	f.invites.invites[invite.ID].TokenHash = utils.HashToken(token)
+	return invited
+}
+
+func TestInviteUserCreatesUserWithInvite(t *testing.T) {
+	f := newInviteFixture(t)
+	ctx := context.Background()
+
+	invited := f.invite(t, "alice@example.com", "alice-token")
+	stored, _ := f.users.GetByID(ctx, invited.ID)
+	if stored.IsActive || stored.Role != models.RoleMember {
+		t.Errorf("expected an inactive member, got active=%v role=%s", stored.IsActive, stored.Role)
+	}
+
+	// A failed invitation leaves no account blocking the address
+	f.invites.err = errInviteStore
+	if _, err := f.service.InviteUser(ctx, f.tenant.ID, f.owner.ID, "bob@example.com", "Bob", "Jones", models.RoleMember); !stdErrors.Is(err, errInviteStore) {
+		t.Fatalf("expected the store error, got %v", err)
+	}
+	if _, err := f.users.GetByEmail(ctx, f.tenant.ID, "bob@example.com"); err != errors.ErrNotFound {
+		t.Error("expected no user left behind by the failed invitation")
+	}
+
+	f.invites.err = nil
+	f.invite(t, "bob@example.com", "bob-token")
+}
+
+func TestAcceptInvite(t *testing.T) {
+	tests := []struct {
+		name     string
+		password string
+		setup    func(f *inviteFixture, invite *models.InviteToken)
+		want     error
+	}{
+		{
+			name:     "expired",
+			password: invitePassword,
+			setup: func(f *inviteFixture, invite *models.InviteToken) {
+				f.invites.invites[invite.ID].ExpiresAt = time.Now().Add(-time.Minute)
+			},
+			want: errors.ErrTokenExpired,
+		},
+		{
+			name:     "already used",
+			password: invitePassword,
+			setup: func(f *inviteFixture, invite *models.InviteToken) {
+				usedAt := time.Now()
+				f.invites.invites[invite.ID].UsedAt = &usedAt
+			},
+			want: errors.ErrTokenExpired,
+		},
+		{
+			name:     "password policy",
+			password: "aaaaaaaaaaaa",
+			setup:    func(f *inviteFixture, invite *models.InviteToken) {},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newInviteFixture(t)
+			ctx := context.Background()
+			invited := f.invite(t, "alice@example.com", "alice-token")
+			invite, _ := f.invites.GetByTokenHash(ctx, utils.HashToken("alice-token"))
+			tt.setup(f, invite)
+
+			_, err := f.auth.AcceptInvite(ctx, &models.AcceptInviteRequest{Token: "alice-token", Password: tt.password})
+			if err == nil || (tt.want != nil && err != tt.want) {
+				t.Fatalf("expected %v, got %v", tt.want, err)
+			}
+			if stored, _ := f.users.GetByID(ctx, invited.ID); stored.IsActive {
+				t.Error("expected the user to stay inactive")
+			}
+		})
+	}
+}
+
+func TestAcceptInviteOnlyOnce(t *testing.T) {
+	f := newInviteFixture(t)
+	ctx := context.Background()
+	invited := f.invite(t, "alice@example.com", "alice-token")
+
+	if _, err := f.auth.AcceptInvite(ctx, &models.AcceptInviteRequest{Token: "unknown", Password: invitePassword}); err != errors.ErrInvalidToken {
+		t.Errorf("expected ErrInvalidToken for an unknown token, got %v", err)
+	}
+
+	resp, err := f.auth.AcceptInvite(ctx, &models.AcceptInviteRequest{Token: "alice-token", Password: invitePassword})
+	if err != nil {
+		t.Fatalf("AcceptInvite failed: %v", err)
+	}
+	if resp.Token == "" || resp.User.ID != invited.ID {
+		t.Errorf("expected the invited user to be logged in, got %+v", resp)
+	}
+	stored, _ := f.users.GetByID(ctx, invited.ID)
+	if !stored.IsActive || !utils.CheckPasswordHash(invitePassword, stored.PasswordHash) {
+		t.Error("expected the user activated with the chosen password")
+	}
+
+	if _, err := f.auth.AcceptInvite(ctx, &models.AcceptInviteRequest{Token: "alice-token", Password: invitePassword}); err != errors.ErrTokenExpired {
+		t.Errorf("expected a reused token to be rejected, got %v", err)
+	}
+}
+
+func TestResendInvite(t *testing.T) {
+	f := newInviteFixture(t)
+	ctx := context.Background()
+	invited := f.invite(t, "alice@example.com", "alice-token")
+
+	resent, err := f.service.ResendInvite(ctx, f.tenant.ID, f.owner.ID, invited.ID)
+	if err != nil {
+		t.Fatalf("ResendInvite failed: %v", err)
+	}
+	if len(f.invites.invites) != 1 || f.invites.invites[resent.ID] == nil {
+		t.Fatalf("expected only the new invite, got %d", len(f.invites.invites))
+	}
+	if _, err := f.auth.AcceptInvite(ctx, &models.AcceptInviteRequest{Token: "alice-token", Password: invitePassword}); err != errors.ErrInvalidToken {
+		t.Errorf("expected the old link to stop working, got %v", err)
+	}
+
+	// The old link keeps working when no new one could be created
+	f.invites.invites[resent.ID].TokenHash = utils.HashToken("resent-token")
+	f.invites.err = errInviteStore
+	if _, err := f.service.ResendInvite(ctx, f.tenant.ID, f.owner.ID, invited.ID); !stdErrors.Is(err, errInviteStore) {
+		t.Fatalf("expected the store error, got %v", err)
+	}
+	if _, err := f.invites.GetByTokenHash(ctx, utils.HashToken("resent-token")); err != nil {
+		t.Error("expected the previous invite to be kept")
+	}
+
+	// Accepted invitations are not resent
+	f.invites.err = nil
+	if _, err := f.auth.AcceptInvite(ctx, &models.AcceptInviteRequest{Token: "resent-token", Password: invitePassword}); err != nil {
+		t.Fatalf("AcceptInvite failed: %v", err)
+	}
+	if _, err := f.service.ResendInvite(ctx, f.tenant.ID, f.owner.ID, invited.ID); err != errors.ErrNotFound {
+		t.Errorf("expected ErrNotFound for an accepted invite, got %v", err)
+	}
+}
+
+func TestRevokeInvite(t *testing.T) {
+	f := newInviteFixture(t)
+	ctx := context.Background()
+	invited := f.invite(t, "alice@example.com", "alice-token")
+
+	if err := f.service.RevokeInvite(ctx, f.tenant.ID, f.owner.ID, invited.ID); err != nil {
+		t.Fatalf("RevokeInvite failed: %v", err)
+	}
+	if _, err := f.users.GetByID(ctx, invited.ID); err != errors.ErrNotFound {
+		t.Error("expected the invited user to be removed")
+	}
+	last := f.audit.logs[len(f.audit.logs)-1]
+	if last.Action != string(models.AuditActionRevokeInvite) || *last.EntityID != invited.ID {
+		t.Errorf("expected a revoke audit log, got %s", last.Action)
+	}
+
+	// The address can be invited again
+	f.invite(t, "alice@example.com", "second-token")
+
+	// Active users are removed with DeleteUser instead
+	if err := f.service.RevokeInvite(ctx, f.tenant.ID, f.owner.ID, f.owner.ID); err != errors.ErrNotFound {
+		t.Errorf("expected ErrNotFound for an active user, got %v", err)
+	}
+}
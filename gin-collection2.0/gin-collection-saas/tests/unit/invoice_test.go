@@ -0,0 +1,308 @@
+package unit
+
+import (
+	"bytes"
+	"context"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/yourusername/gin-collection-saas/internal/domain/errors"
+	"github.com/yourusername/gin-collection-saas/internal/domain/models"
+	"github.com/yourusername/gin-collection-saas/internal/infrastructure/billing"
+	"github.com/yourusername/gin-collection-saas/internal/infrastructure/pdf"
+	"github.com/yourusername/gin-collection-saas/internal/usecase/subscription"
+)
+
+// fakeInvoiceRepository keeps invoices in memory and numbers them per year
+type fakeInvoiceRepository struct {
+	invoices  []*models.Invoice
+	sequences map[int]int
+}
+
+func newFakeInvoiceRepository() *fakeInvoiceRepository {
+	return &fakeInvoiceRepository{sequences: make(map[int]int)}
+}
+
+func (r *fakeInvoiceRepository) Create(ctx context.Context, invoice *models.Invoice, numberPrefix string) error {
+	if _, err := r.GetByPayment(ctx, invoice.Provider, invoice.ProviderPaymentID); err == nil {
+		return errors.ErrConflict
+	}
+	year := invoice.IssuedAt.Year()
+	r.sequences[year]++
+	invoice.ID = int64(len(r.invoices) + 1)
+	invoice.Number = models.InvoiceNumber(numberPrefix, year, r.sequences[year])
+	r.invoices = append(r.invoices, invoice)
+	return nil
+}
+
+func (r *fakeInvoiceRepository) GetByID(ctx context.Context, tenantID, id int64) (*models.Invoice, error) {
+	for _, invoice := range r.invoices {
+		if invoice.ID == id && invoice.TenantID == tenantID {
+			return invoice, nil
+		}
+	}
+	return nil, errors.ErrNotFound
+}
+
+func (r *fakeInvoiceRepository) GetByPayment(ctx context.Context, provider, paymentID string) (*models.Invoice, error) {
+	for _, invoice := range r.invoices {
+		if invoice.Provider == provider && invoice.ProviderPaymentID == paymentID {
+			return invoice, nil
+		}
+	}
+	return nil, errors.ErrNotFound
+}
+
+func (r *fakeInvoiceRepository) List(ctx context.Context, filter *models.InvoiceFilter) ([]*models.Invoice, int, error) {
+	invoices := []*models.Invoice{}
+	for i := len(r.invoices) - 1; i >= 0; i-- {
+		if r.invoices[i].TenantID == filter.TenantID {
+			invoices = append(invoices, r.invoices[i])
+		}
+	}
+	total := len(invoices)
+	if filter.Offset >= total {
+		return []*models.Invoice{}, total, nil
+	}
+	end := filter.Offset + filter.Limit
+	if end > total {
+		end = total
+	}
+	return invoices[filter.Offset:end], total, nil
+}
+
+type invoiceFixture struct {
+	*trialCouponFixture
+	invoices *fakeInvoiceRepository
+}
+
+func newInvoiceFixture(t *testing.T) *invoiceFixture {
+	f := &invoiceFixture{
+		trialCouponFixture: newTrialCouponFixture(t),
+		invoices:           newFakeInvoiceRepository(),
+	}
+	f.service.SetInvoicing(f.invoices, &subscription.InvoiceSettings{
+		Seller: models.BillingDetails{
+			Name:         "GinVault GmbH",
+			AddressLine1: "Wacholderweg 1",
+			PostalCode:   "10115",
+			City:         "Berlin",
+			Country:      "DE",
+			VATID:        "DE123456789",
+		},
+		VATRate:      19,
+		NumberPrefix: "GV",
+	})
+	return f
+}
+
+// pay delivers the payment webhook of a subscription
+func (f *invoiceFixture) pay(t *testing.T, subscription *models.Subscription, paymentID string, paidAt time.Time) {
+	err := f.service.HandleWebhookEvent(context.Background(), f.contract.provider, &billing.WebhookEvent{
+		ID:             "evt_" + paymentID,
+		Type:           billing.EventPaymentCompleted,
+		RawType:        "invoice.paid",
+		SubscriptionID: *subscription.ProviderSubscriptionID,
+		PaymentID:      paymentID,
+		PaidAt:         &paidAt,
+		Amount:         "9.99",
+		Currency:       "EUR",
+	})
+	if err != nil {
+		t.Fatalf("payment webhook failed: %v", err)
+	}
+}
+
+func TestPaymentsAreInvoicedOnce(t *testing.T) {
+	f := newInvoiceFixture(t)
+	ctx := context.Background()
+
+	activated := f.subscribe(t, 1, "PLAN_PRO_MONTHLY", "")
+	paidAt := time.Date(2026, 3, 1, 10, 0, 0, 0, time.UTC)
+	f.pay(t, activated, "in_1", paidAt)
+	f.pay(t, activated, "in_1", paidAt)
+	f.pay(t, activated, "in_2", paidAt.AddDate(0, 1, 0))
+
+	invoices, total, err := f.service.ListInvoices(ctx, 1, 20, 0)
+	if err != nil {
+		t.Fatalf("ListInvoices failed: %v", err)
+	}
+	if total != 2 {
+		t.Fatalf("expected two invoices, got %d", total)
+	}
+	if invoices[0].Number != "GV-2026-000002" || invoices[1].Number != "GV-2026-000001" {
+		t.Fatalf("expected sequential numbers newest first, got %s and %s", invoices[0].Number, invoices[1].Number)
+	}
+
+	// Without billing details the tenant is billed by name with German VAT
+	invoice := invoices[1]
+	if invoice.Customer == nil || invoice.Customer.Name != "Gin Bar" {
+		t.Fatalf("expected the tenant name as customer, got %+v", invoice.Customer)
+	}
+	if invoice.TotalAmount != 9.99 || invoice.NetAmount != 8.39 || invoice.TaxAmount != 1.6 || invoice.TaxRate != 19 {
+		t.Fatalf("unexpected amounts %+v", invoice)
+	}
+	if !invoice.IssuedAt.Equal(paidAt) || invoice.SubscriptionID == nil || *invoice.SubscriptionID != activated.ID {
+		t.Fatalf("unexpected invoice %+v", invoice)
+	}
+
+	// Invoices belong to their tenant
+	if _, err := f.service.GetInvoice(ctx, 2, invoice.ID); err != errors.ErrNotFound {
+		t.Fatalf("expected ErrNotFound for another tenant, got %v", err)
+	}
+}
+
+func TestInvoiceTaxFollowsBillingDetails(t *testing.T) {
+	seller := &models.BillingDetails{Country: "DE"}
+	for name, test := range map[string]struct {
+		currency string
+		customer *models.BillingDetails
+		tax      models.InvoiceTax
+	}{
+		"no details":            {"EUR", nil, models.InvoiceTax{Rate: 19}},
+		"domestic business":     {"EUR", &models.BillingDetails{Country: "DE", VATID: "DE999999999"}, models.InvoiceTax{Rate: 19}},
+		"EU consumer":           {"EUR", &models.BillingDetails{Country: "FR"}, models.InvoiceTax{Rate: 19}},
+		"EU business":           {"EUR", &models.BillingDetails{Country: "FR", VATID: "FR12345678901"}, models.InvoiceTax{ReverseCharge: true}},
+		"outside the EU":        {"EUR", &models.BillingDetails{Country: "CH"}, models.InvoiceTax{}},
+		"other currency":        {"USD", &models.BillingDetails{Country: "DE"}, models.InvoiceTax{}},
+		"lower case currencies": {"eur", &models.BillingDetails{Country: "de"}, models.InvoiceTax{Rate: 19}},
+	} {
+		if tax := models.TaxFor(test.currency, test.customer, seller, 19); tax != test.tax {
+			t.Errorf("%s: expected %+v, got %+v", name, test.tax, tax)
+		}
+	}
+
+	invoice := &models.Invoice{TotalAmount: 99.99}
+	invoice.ApplyTax(models.InvoiceTax{ReverseCharge: true})
+	if invoice.NetAmount != 99.99 || invoice.TaxAmount != 0 {
+		t.Fatalf("expected no tax with reverse charge, got %+v", invoice)
+	}
+}
+
+func TestReverseChargeInvoice(t *testing.T) {
+	f := newInvoiceFixture(t)
+	ctx := context.Background()
+
+	details := &models.BillingDetails{
+		Name:         "Genièvre SARL",
+		AddressLine1: "1 Rue du Genièvre",
+		PostalCode:   "75001",
+		City:         "Paris",
+		Country:      "fr",
+		VATID:        "fr 123 456 789 01",
+	}
+	saved, err := f.service.UpdateBillingDetails(ctx, 1, details)
+	if err != nil {
+		t.Fatalf("UpdateBillingDetails failed: %v", err)
+	}
+	if saved.Country != "FR" || saved.VATID != "FR12345678901" {
+		t.Fatalf("expected normalized details, got %+v", saved)
+	}
+
+	// Choosing a provider keeps the billing details
+	if _, err := f.service.SelectBillingProvider(ctx, 1, models.BillingProviderStripe); err != nil {
+		t.Fatalf("SelectBillingProvider failed: %v", err)
+	}
+	if stored, err := f.service.GetBillingDetails(ctx, 1); err != nil || stored == nil || stored.VATID != "FR12345678901" {
+		t.Fatalf("expected the billing details to be kept, got %+v (%v)", stored, err)
+	}
+
+	activated := f.subscribe(t, 1, "PLAN_PRO_MONTHLY", "")
+	f.pay(t, activated, "in_1", time.Now())
+
+	invoice := f.invoices.invoices[0]
+	if !invoice.ReverseCharge || invoice.TaxAmount != 0 || invoice.NetAmount != 9.99 {
+		t.Fatalf("expected a reverse charge invoice, got %+v", invoice)
+	}
+	if invoice.Customer.Name != "Genièvre SARL" || invoice.Seller.VATID != "DE123456789" {
+		t.Fatalf("expected customer and seller on the invoice, got %+v / %+v", invoice.Customer, invoice.Seller)
+	}
+
+	// Later changes of the details don't change issued invoices
+	details.Name = "Renamed SARL"
+	if _, err := f.service.UpdateBillingDetails(ctx, 1, details); err != nil {
+		t.Fatalf("UpdateBillingDetails failed: %v", err)
+	}
+	if invoice.Customer.Name != "Genièvre SARL" {
+		t.Fatalf("expected the issued invoice to keep its customer, got %s", invoice.Customer.Name)
+	}
+}
+
+func TestBillingDetailsValidation(t *testing.T) {
+	f := newInvoiceFixture(t)
+	ctx := context.Background()
+
+	for name, details := range map[string]*models.BillingDetails{
+		"wrong prefix":       {Name: "Gin Bar", Country: "DE", VATID: "FR12345678901"},
+		"outside the EU":     {Name: "Gin Bar", Country: "CH", VATID: "CHE123456789"},
+		"invalid characters": {Name: "Gin Bar", Country: "DE", VATID: "DE12#456789"},
+	} {
+		if _, err := f.service.UpdateBillingDetails(ctx, 1, details); err != errors.ErrInvalidVATID {
+			t.Errorf("%s: expected ErrInvalidVATID, got %v", name, err)
+		}
+	}
+
+	if _, err := f.service.UpdateBillingDetails(ctx, 1, &models.BillingDetails{Name: "Gin Bar", Country: "Germany"}); err != errors.ErrInvalidInput {
+		t.Fatalf("expected ErrInvalidInput for a country name, got %v", err)
+	}
+
+	// Greek VAT IDs start with EL
+	if _, err := f.service.UpdateBillingDetails(ctx, 1, &models.BillingDetails{Name: "Gin Bar", Country: "GR", VATID: "EL123456789"}); err != nil {
+		t.Fatalf("expected a Greek VAT ID to be accepted, got %v", err)
+	}
+}
+
+func TestInvoicePDF(t *testing.T) {
+	f := newInvoiceFixture(t)
+	ctx := context.Background()
+
+	activated := f.subscribe(t, 1, "PLAN_PRO_MONTHLY", "")
+	f.pay(t, activated, "in_1", time.Date(2026, 3, 1, 10, 0, 0, 0, time.UTC))
+
+	invoice, document, err := f.service.InvoicePDF(ctx, 1, 1)
+	if err != nil {
+		t.Fatalf("InvoicePDF failed: %v", err)
+	}
+	if !bytes.HasPrefix(document, []byte("%PDF-1.4")) || !bytes.HasSuffix(document, []byte("%%EOF\n")) {
+		t.Fatal("expected a PDF document")
+	}
+	for _, text := range []string{invoice.Number, "Rechnung", "8,39 \x80", "1,60 \x80", "9,99 \x80", "Umsatzsteuer 19 %", "01.03.2026"} {
+		if !bytes.Contains(document, []byte(text)) {
+			t.Errorf("expected %q in the invoice", text)
+		}
+	}
+
+	// The cross-reference table points at the objects
+	start := bytes.LastIndex(document, []byte("startxref\n"))
+	offset, err := strconv.Atoi(string(bytes.Fields(document[start+len("startxref\n"):])[0]))
+	if err != nil || !bytes.HasPrefix(document[offset:], []byte("xref")) {
+		t.Fatalf("expected startxref to point at the xref table, got %d (%v)", offset, err)
+	}
+	if !bytes.HasPrefix(document[bytes.Index(document, []byte("1 0 obj")):], []byte("1 0 obj\n<< /Type /Catalog")) {
+		t.Fatal("expected the catalog as first object")
+	}
+}
+
+func TestPDFTextEncoding(t *testing.T) {
+	d := pdf.NewDocument()
+	d.Text(50, 50, 10, false, "Empfänger (Straße) – 5 €")
+	document := d.Bytes()
+
+	if !bytes.Contains(document, []byte("(Empf\xe4nger \\(Stra\xdfe\\) \x96 5 \x80) Tj")) {
+		t.Fatalf("expected WinAnsi encoded and escaped text, got %q", document)
+	}
+}
+
+func TestStripeInvoicePaymentID(t *testing.T) {
+	contract, _ := newStripeContract(t)
+
+	event, err := contract.provider.ParseWebhook([]byte(`{"id":"evt_1","type":"invoice.paid","created":1772359200,
+		"data":{"object":{"id":"in_123","customer":"cus_1","subscription":"sub_1","amount_paid":999,"currency":"eur"}}}`))
+	if err != nil {
+		t.Fatalf("ParseWebhook failed: %v", err)
+	}
+	if event.PaymentID != "in_123" || event.PaidAt == nil || event.Amount != "9.99" || event.Currency != "EUR" {
+		t.Fatalf("unexpected payment event %+v", event)
+	}
+}
@@ -0,0 +1,204 @@
+package unit
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/yourusername/gin-collection-saas/internal/domain/errors"
+	"github.com/yourusername/gin-collection-saas/internal/domain/models"
+	"github.com/yourusername/gin-collection-saas/internal/usecase/labelscan"
+)
+
+// fakeGinReferenceRepository serves a fixed catalog with a LIKE-style search
+type fakeGinReferenceRepository struct {
+	refs []*models.GinReference
+}
+
+func (r *fakeGinReferenceRepository) Search(ctx context.Context, params *models.GinReferenceSearchParams) ([]*models.GinReference, int, error) {
+	query := strings.ToLower(params.Query)
+	var result []*models.GinReference
+	for _, ref := range r.refs {
+		brand := ""
+		if ref.Brand != nil {
+			brand = *ref.Brand
+		}
+		if strings.Contains(strings.ToLower(ref.Name), query) || strings.Contains(strings.ToLower(brand), query) {
+			result = append(result, ref)
+		}
+	}
+	return result, len(result), nil
+}
+
+func (r *fakeGinReferenceRepository) GetByID(ctx context.Context, id int64) (*models.GinReference, error) {
+	for _, ref := range r.refs {
+		if ref.ID == id {
+			return ref, nil
+		}
+	}
+	return nil, errors.ErrNotFound
+}
+
+func (r *fakeGinReferenceRepository) GetByBarcode(ctx context.Context, barcode string) (*models.GinReference, error) {
+	return nil, errors.ErrNotFound
+}
+
+func (r *fakeGinReferenceRepository) GetCountries(ctx context.Context) ([]string, error) {
+	return nil, nil
+}
+
+func (r *fakeGinReferenceRepository) GetGinTypes(ctx context.Context) ([]string, error) {
+	return nil, nil
+}
+
+func (r *fakeGinReferenceRepository) GetBrands(ctx context.Context) ([]string, error) {
+	return nil, nil
+}
+
+func strPtr(s string) *string { return &s }
+
+func floatPtr(f float64) *float64 { return &f }
+
+func intPtr(i int) *int { return &i }
+
+func testCatalog() *fakeGinReferenceRepository {
+	return &fakeGinReferenceRepository{refs: []*models.GinReference{
+		{ID: 1, Name: "Monkey 47", Brand: strPtr("Black Forest Distillers"), Country: strPtr("Germany"), GinType: strPtr("Dry Gin"), ABV: floatPtr(47), BottleSize: intPtr(500)},
+		{ID: 2, Name: "Hendrick's", Brand: strPtr("Hendrick's"), Country: strPtr("Scotland"), ABV: floatPtr(41.4), BottleSize: intPtr(700)},
+		{ID: 3, Name: "Tanqueray No. Ten", Brand: strPtr("Tanqueray"), Country: strPtr("England"), ABV: floatPtr(47.3), BottleSize: intPtr(700)},
+	}}
+}
+
+// jpegImage returns a minimal byte slice that passes magic byte validation
+func jpegImage(marker byte) []byte {
+	return []byte{0xFF, 0xD8, 0xFF, 0xE0, 0x00, 0x10, 'J', 'F', 'I', 'F', 0x00, marker}
+}
+
+// TestLabelScan_ParseLabelText verifies field extraction from label text
+func TestLabelScan_ParseLabelText(t *testing.T) {
+	label := labelscan.ParseLabelText("Tanqueray No. Ten\nTanqueray\nDistilled in England\n47,3% vol\n70cl")
+
+	if label.Name != "Tanqueray No. Ten" {
+		t.Errorf("Expected name 'Tanqueray No. Ten', got '%s'", label.Name)
+	}
+	if label.Brand != "Tanqueray" {
+		t.Errorf("Expected brand 'Tanqueray', got '%s'", label.Brand)
+	}
+	if label.ABV == nil || *label.ABV != 47.3 {
+		t.Errorf("Expected ABV 47.3, got %v", label.ABV)
+	}
+	if label.BottleSizeML == nil || *label.BottleSizeML != 700 {
+		t.Errorf("Expected bottle size 700, got %v", label.BottleSizeML)
+	}
+	if label.Country != "England" {
+		t.Errorf("Expected country 'England', got '%s'", label.Country)
+	}
+	if label.Confidence[labelscan.FieldABV] <= label.Confidence[labelscan.FieldName] {
+		t.Error("Pattern matched fields should be more confident than heuristic ones")
+	}
+
+	t.Run("Country inside name", func(t *testing.T) {
+		label := labelscan.ParseLabelText("Drumshanbo Irish Gunpowder Gin\n0.7 L")
+		if label.Name != "Drumshanbo Irish Gunpowder Gin" {
+			t.Errorf("Expected name line to be kept, got '%s'", label.Name)
+		}
+		if label.Country != "Ireland" {
+			t.Errorf("Expected country 'Ireland', got '%s'", label.Country)
+		}
+		if label.BottleSizeML == nil || *label.BottleSizeML != 700 {
+			t.Errorf("Expected bottle size 700, got %v", label.BottleSizeML)
+		}
+	})
+}
+
+// TestLabelScan_MatchesReference verifies fuzzy matching and per-field confidence
+func TestLabelScan_MatchesReference(t *testing.T) {
+	ctx := context.Background()
+	recognizer := labelscan.NewLocalRecognizer()
+	service := labelscan.NewService(recognizer, testCatalog())
+
+	image := jpegImage(1)
+	recognizer.Register(image, "HENDRICKS GIN\n41.4% vol")
+
+	result, err := service.ScanLabel(ctx, 7, image)
+	if err != nil {
+		t.Fatalf("Scan failed: %v", err)
+	}
+
+	if result.Reference == nil || result.Reference.Gin.ID != 2 {
+		t.Fatalf("Expected match with reference 2, got %+v", result.Reference)
+	}
+	if result.Draft.Name != "Hendrick's" {
+		t.Errorf("Expected catalog name, got '%s'", result.Draft.Name)
+	}
+	if result.Draft.TenantID != 7 {
+		t.Errorf("Expected draft for tenant 7, got %d", result.Draft.TenantID)
+	}
+	if result.Draft.Country == nil || *result.Draft.Country != "Scotland" {
+		t.Errorf("Expected country from catalog, got %v", result.Draft.Country)
+	}
+	if result.Confidence[labelscan.FieldABV] < result.Reference.Score {
+		t.Error("ABV confirmed by the catalog should be at least as confident as the match")
+	}
+	if result.Confidence[labelscan.FieldBottleSize] >= result.Confidence[labelscan.FieldABV] {
+		t.Error("Bottle size only known from the catalog should be less confident than a confirmed ABV")
+	}
+}
+
+// TestLabelScan_LabelWinsOverReference verifies values read from the label are kept
+func TestLabelScan_LabelWinsOverReference(t *testing.T) {
+	recognizer := labelscan.NewLocalRecognizer()
+	service := labelscan.NewService(recognizer, testCatalog())
+
+	image := jpegImage(2)
+	recognizer.Register(image, "Monkey 47\nSchwarzwald Dry Gin\n47% vol\n50 cl\nProduct of Germany")
+
+	result, err := service.ScanLabel(context.Background(), 1, image)
+	if err != nil {
+		t.Fatalf("Scan failed: %v", err)
+	}
+
+	if result.Reference == nil || result.Reference.Gin.ID != 1 {
+		t.Fatalf("Expected match with reference 1, got %+v", result.Reference)
+	}
+	if result.Draft.BottleSize == nil || *result.Draft.BottleSize != 500 {
+		t.Errorf("Expected bottle size 500, got %v", result.Draft.BottleSize)
+	}
+	if result.Draft.GinType == nil || *result.Draft.GinType != "Dry Gin" {
+		t.Errorf("Expected gin type from catalog, got %v", result.Draft.GinType)
+	}
+}
+
+// TestLabelScan_NoMatch verifies unknown gins return the label as draft
+func TestLabelScan_NoMatch(t *testing.T) {
+	recognizer := labelscan.NewLocalRecognizer()
+	service := labelscan.NewService(recognizer, testCatalog())
+
+	image := jpegImage(3)
+	recognizer.Register(image, "Kleinbrennerei Sonnenhof\n44% vol")
+
+	result, err := service.ScanLabel(context.Background(), 1, image)
+	if err != nil {
+		t.Fatalf("Scan failed: %v", err)
+	}
+
+	if result.Reference != nil {
+		t.Errorf("Expected no reference match, got %+v", result.Reference)
+	}
+	if result.Draft.Name != "Kleinbrennerei Sonnenhof" {
+		t.Errorf("Expected label name, got '%s'", result.Draft.Name)
+	}
+	if result.Draft.ABV == nil || *result.Draft.ABV != 44 {
+		t.Errorf("Expected ABV 44, got %v", result.Draft.ABV)
+	}
+}
+
+// TestLabelScan_RejectsNonImages verifies magic byte validation
+func TestLabelScan_RejectsNonImages(t *testing.T) {
+	service := labelscan.NewService(labelscan.NewLocalRecognizer(), testCatalog())
+
+	_, err := service.ScanLabel(context.Background(), 1, []byte("%PDF-1.4 not an image"))
+	if err != errors.ErrInvalidFileType {
+		t.Errorf("Expected ErrInvalidFileType, got %v", err)
+	}
+}
@@ -0,0 +1,271 @@
+package unit
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/yourusername/gin-collection-saas/internal/domain/errors"
+	"github.com/yourusername/gin-collection-saas/internal/domain/models"
+	"github.com/yourusername/gin-collection-saas/internal/usecase/admin"
+	"github.com/yourusername/gin-collection-saas/internal/usecase/auth"
+	"github.com/yourusername/gin-collection-saas/internal/usecase/entitlement"
+	"github.com/yourusername/gin-collection-saas/internal/usecase/user"
+	"github.com/yourusername/gin-collection-saas/pkg/utils"
+)
+
+// fakeLoginDeviceRepository keeps known login devices in memory
+type fakeLoginDeviceRepository struct {
+	devices []*models.LoginDevice
+}
+
+func (r *fakeLoginDeviceRepository) ListByUser(ctx context.Context, userID int64) ([]*models.LoginDevice, error) {
+	var devices []*models.LoginDevice
+	for _, device := range r.devices {
+		if device.UserID == userID {
+			copied := *device
+			devices = append(devices, &copied)
+		}
+	}
+	return devices, nil
+}
+
+func (r *fakeLoginDeviceRepository) Record(ctx context.Context, device *models.LoginDevice) error {
+	for _, known := range r.devices {
+		if known.UserID == device.UserID && known.Device == device.Device && known.Network == device.Network {
+			known.IPAddress = device.IPAddress
+			known.LastSeenAt = device.LastSeenAt
+			return nil
+		}
+	}
+	copied := *device
+	r.devices = append(r.devices, &copied)
+	return nil
+}
+
+var testLockoutPolicy = utils.LockoutPolicy{
+	Threshold:    3,
+	BaseDuration: time.Minute,
+	MaxDuration:  5 * time.Minute,
+	Window:       time.Hour,
+}
+
+type loginProtectionFixture struct {
+	*sessionFixture
+	attempts *utils.LoginAttemptTracker
+	auditLog *fakeAuditLogRepository
+	devices  *fakeLoginDeviceRepository
+}
+
+func newLoginProtectionFixture(t *testing.T) *loginProtectionFixture {
+	t.Helper()
+
+	f := &loginProtectionFixture{
+		sessionFixture: newSessionFixture(t),
+		attempts:       utils.NewLoginAttemptTracker(nil, testLockoutPolicy),
+		auditLog:       &fakeAuditLogRepository{},
+		devices:        &fakeLoginDeviceRepository{},
+	}
+	f.service.SetLoginAttemptTracker(f.attempts)
+	f.service.SetAuditLogRepo(f.auditLog)
+	f.service.SetLoginDeviceRepo(f.devices)
+	return f
+}
+
+func (f *loginProtectionFixture) attempt(ipAddress, userAgent, password string) error {
+	ctx := auth.WithClientInfo(context.Background(), ipAddress, userAgent)
+	_, err := f.service.Login(ctx, &models.LoginRequest{Email: f.user.Email, Password: password}, f.tenant.ID)
+	return err
+}
+
+func (f *loginProtectionFixture) auditActions(action models.AuditAction) []*models.AuditLog {
+	var logs []*models.AuditLog
+	for _, log := range f.auditLog.logs {
+		if log.Action == string(action) {
+			logs = append(logs, log)
+		}
+	}
+	return logs
+}
+
+func TestLoginAttemptTrackerProgressiveLockout(t *testing.T) {
+	ctx := context.Background()
+	tracker := utils.NewLoginAttemptTracker(nil, testLockoutPolicy)
+
+	expected := []time.Duration{0, 0, time.Minute, 2 * time.Minute, 4 * time.Minute, 5 * time.Minute, 5 * time.Minute}
+	for i, want := range expected {
+		failures, lockout := tracker.RecordFailure(ctx, 1)
+		if failures != int64(i+1) {
+			t.Errorf("failure %d: expected count %d, got %d", i+1, i+1, failures)
+		}
+		if lockout != want {
+			t.Errorf("failure %d: expected lockout %s, got %s", i+1, want, lockout)
+		}
+	}
+
+	if lockedFor := tracker.LockedFor(ctx, 1); lockedFor <= 4*time.Minute || lockedFor > 5*time.Minute {
+		t.Errorf("expected about 5 minutes lockout, got %s", lockedFor)
+	}
+	if lockedFor := tracker.LockedFor(ctx, 2); lockedFor != 0 {
+		t.Errorf("expected other accounts to be unaffected, got %s", lockedFor)
+	}
+
+	if err := tracker.Reset(ctx, 1); err != nil {
+		t.Fatalf("Reset failed: %v", err)
+	}
+	if lockedFor := tracker.LockedFor(ctx, 1); lockedFor != 0 {
+		t.Errorf("expected reset to unlock, got %s", lockedFor)
+	}
+	if failures, _ := tracker.RecordFailure(ctx, 1); failures != 1 {
+		t.Errorf("expected reset to forget failures, got %d", failures)
+	}
+}
+
+func TestAdminTwoFactorLockoutOutlastsChallenge(t *testing.T) {
+	ctx := context.Background()
+	tracker := utils.NewAttemptTracker(nil, admin.TwoFactorLockoutPolicy, "admin_2fa")
+
+	for i := 1; i < 5; i++ {
+		if _, lockout := tracker.RecordFailure(ctx, 1); lockout != 0 {
+			t.Fatalf("failure %d: expected no lockout, got %s", i, lockout)
+		}
+	}
+
+	// A challenge issued right before the lock has expired when it ends
+	if _, lockout := tracker.RecordFailure(ctx, 1); lockout < models.TwoFactorChallengeTTL {
+		t.Errorf("expected a lockout of at least %s, got %s", models.TwoFactorChallengeTTL, lockout)
+	}
+	if tracker.LockedFor(ctx, 1) == 0 {
+		t.Error("expected the admin's challenges to be locked")
+	}
+}
+
+func TestLoginLocksAccountAfterFailures(t *testing.T) {
+	f := newLoginProtectionFixture(t)
+
+	for i := 0; i < int(testLockoutPolicy.Threshold); i++ {
+		if err := f.attempt("198.51.100.1", chromeOnMac, "wrong password!"); err != errors.ErrInvalidCredentials {
+			t.Fatalf("attempt %d: expected ErrInvalidCredentials, got %v", i+1, err)
+		}
+	}
+
+	// The right password doesn't help while the account is locked, from any address
+	if err := f.attempt("203.0.113.7", chromeOnMac, sessionTestPassword); err != errors.ErrAccountLocked {
+		t.Fatalf("expected ErrAccountLocked, got %v", err)
+	}
+
+	failed := f.auditActions(models.AuditActionFailedLogin)
+	if len(failed) != int(testLockoutPolicy.Threshold)+1 {
+		t.Fatalf("expected %d failed_login entries, got %d", testLockoutPolicy.Threshold+1, len(failed))
+	}
+	if !strings.Contains(*failed[len(failed)-2].Changes, `"locked_for":60`) {
+		t.Errorf("expected the last failure to lock the account, got %s", *failed[len(failed)-2].Changes)
+	}
+	if !strings.Contains(*failed[len(failed)-1].Changes, `"reason":"account_locked"`) {
+		t.Errorf("expected rejected attempt to be audited, got %s", *failed[len(failed)-1].Changes)
+	}
+	if failed[0].IPAddress == nil || *failed[0].IPAddress != "198.51.100.1" {
+		t.Errorf("expected audit entry with IP address, got %v", failed[0].IPAddress)
+	}
+
+	// Unknown accounts are not tracked
+	ctx := auth.WithClientInfo(context.Background(), "198.51.100.1", chromeOnMac)
+	if _, err := f.service.Login(ctx, &models.LoginRequest{Email: "nobody@example.com", Password: "wrong password!"}, f.tenant.ID); err != errors.ErrInvalidCredentials {
+		t.Errorf("expected ErrInvalidCredentials for unknown email, got %v", err)
+	}
+}
+
+func TestSuccessfulLoginResetsFailures(t *testing.T) {
+	f := newLoginProtectionFixture(t)
+
+	for i := 0; i < int(testLockoutPolicy.Threshold)-1; i++ {
+		f.attempt("198.51.100.1", chromeOnMac, "wrong password!")
+	}
+	if err := f.attempt("198.51.100.1", chromeOnMac, sessionTestPassword); err != nil {
+		t.Fatalf("Login failed: %v", err)
+	}
+
+	// The counter starts over, one more failure doesn't lock
+	if err := f.attempt("198.51.100.1", chromeOnMac, "wrong password!"); err != errors.ErrInvalidCredentials {
+		t.Fatalf("expected ErrInvalidCredentials, got %v", err)
+	}
+	if err := f.attempt("198.51.100.1", chromeOnMac, sessionTestPassword); err != nil {
+		t.Errorf("expected login to succeed, got %v", err)
+	}
+}
+
+func TestNewDeviceLoginIsReported(t *testing.T) {
+	f := newLoginProtectionFixture(t)
+
+	// The first login only records the device
+	f.login(t, "203.0.113.7", chromeOnMac)
+	if logs := f.auditActions(models.AuditActionNewDeviceLogin); len(logs) != 0 {
+		t.Fatalf("expected first login not to be reported, got %d", len(logs))
+	}
+
+	// Same device, other address in the same network
+	f.login(t, "203.0.113.99", chromeOnMac)
+	if logs := f.auditActions(models.AuditActionNewDeviceLogin); len(logs) != 0 {
+		t.Fatalf("expected known device and network not to be reported, got %d", len(logs))
+	}
+
+	f.login(t, "203.0.113.7", safariOnIPhone)
+	f.login(t, "192.0.2.1", chromeOnMac)
+
+	logs := f.auditActions(models.AuditActionNewDeviceLogin)
+	if len(logs) != 2 {
+		t.Fatalf("expected 2 reported logins, got %d", len(logs))
+	}
+	if !strings.Contains(*logs[0].Changes, `"new_device":true`) || !strings.Contains(*logs[0].Changes, `"new_network":false`) {
+		t.Errorf("expected new device, got %s", *logs[0].Changes)
+	}
+	if !strings.Contains(*logs[1].Changes, `"new_device":false`) || !strings.Contains(*logs[1].Changes, `"new_network":true`) {
+		t.Errorf("expected new network, got %s", *logs[1].Changes)
+	}
+
+	if len(f.devices.devices) != 3 {
+		t.Errorf("expected 3 known devices, got %d", len(f.devices.devices))
+	}
+}
+
+func TestAdminUnlocksUser(t *testing.T) {
+	f := newLoginProtectionFixture(t)
+	ctx := context.Background()
+	f.tenant.Tier = models.TierEnterprise
+
+	owner := &models.User{TenantID: f.tenant.ID, Email: "owner@example.com", Role: models.RoleOwner, IsActive: true}
+	f.userRepo.Create(ctx, owner)
+
+	for i := 0; i < int(testLockoutPolicy.Threshold); i++ {
+		f.attempt("198.51.100.1", chromeOnMac, "wrong password!")
+	}
+
+	userService := user.NewService(f.userRepo, newFakeTenantRepository(f.tenant), f.auditLog, nil, entitlement.NewService(nil), nil, "https://app.example.com")
+	userService.SetLoginAttemptTracker(f.attempts)
+
+	users, err := userService.ListUsers(ctx, f.tenant.ID, owner.ID)
+	if err != nil {
+		t.Fatalf("ListUsers failed: %v", err)
+	}
+	for _, u := range users {
+		if locked := u.LockedUntil != nil; locked != (u.ID == f.user.ID) {
+			t.Errorf("user %d: unexpected lock state %v", u.ID, u.LockedUntil)
+		}
+	}
+
+	if err := userService.UnlockUser(ctx, f.tenant.ID, owner.ID, f.user.ID); err != nil {
+		t.Fatalf("UnlockUser failed: %v", err)
+	}
+	if err := f.attempt("198.51.100.1", chromeOnMac, sessionTestPassword); err != nil {
+		t.Errorf("expected login after unlock, got %v", err)
+	}
+	if logs := f.auditActions(models.AuditActionUnlockUser); len(logs) != 1 || *logs[0].UserID != owner.ID {
+		t.Errorf("expected unlock to be audited by the owner, got %d entries", len(logs))
+	}
+
+	// Users of other tenants can't be unlocked
+	if err := userService.UnlockUser(ctx, f.tenant.ID, owner.ID, 999); err == nil {
+		t.Error("expected unknown user to be rejected")
+	}
+}
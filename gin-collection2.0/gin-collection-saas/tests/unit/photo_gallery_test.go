@@ -0,0 +1,300 @@
+package unit
+
+import (
+	"context"
+	stdErrors "errors"
+	"sort"
+	"testing"
+
+	"github.com/yourusername/gin-collection-saas/internal/domain/errors"
+	"github.com/yourusername/gin-collection-saas/internal/domain/models"
+)
+
+func (r *fakePhotoStore) Update(ctx context.Context, photo *models.GinPhoto) error {
+	stored, ok := r.photos[photo.ID]
+	if !ok || stored.TenantID != photo.TenantID {
+		return errors.ErrPhotoNotFound
+	}
+	stored.PhotoURL = photo.PhotoURL
+	stored.PhotoType = photo.PhotoType
+	stored.Caption = photo.Caption
+	stored.IsPrimary = photo.IsPrimary
+	if photo.Tags != nil {
+		stored.Tags = append([]string(nil), photo.Tags...)
+	}
+	return nil
+}
+
+func (r *fakePhotoStore) UpdateSortOrder(ctx context.Context, tenantID, ginID int64, photoIDs []int64) error {
+	photos, _ := r.GetByGinID(ctx, tenantID, ginID)
+	if len(photoIDs) != len(photos) {
+		return errors.ErrInvalidInput
+	}
+	for _, id := range photoIDs {
+		if photo, ok := r.photos[id]; !ok || photo.TenantID != tenantID || photo.GinID != ginID {
+			return errors.ErrInvalidInput
+		}
+	}
+	for position, id := range photoIDs {
+		r.photos[id].SortOrder = position
+	}
+	return nil
+}
+
+// List filters like the MySQL repository: positions are computed per gin
+// before filtering, results are newest first
+func (r *fakePhotoStore) List(ctx context.Context, filter *models.PhotoFilter) ([]*models.GinPhoto, int, error) {
+	var photos []*models.GinPhoto
+	for _, photo := range r.photos {
+		if photo.TenantID != filter.TenantID {
+			continue
+		}
+		if filter.MaxPerGin > 0 {
+			gallery, _ := r.GetByGinID(ctx, photo.TenantID, photo.GinID)
+			position := 0
+			for i, p := range gallery {
+				if p.ID == photo.ID {
+					position = i
+				}
+			}
+			if position >= filter.MaxPerGin {
+				continue
+			}
+		}
+		if filter.GinID != nil && photo.GinID != *filter.GinID {
+			continue
+		}
+		if filter.PhotoType != nil && photo.PhotoType != *filter.PhotoType {
+			continue
+		}
+		if filter.Tag != "" && !containsTag(photo.Tags, filter.Tag) {
+			continue
+		}
+		copied := *photo
+		photos = append(photos, &copied)
+	}
+	sort.Slice(photos, func(i, j int) bool { return photos[i].ID > photos[j].ID })
+
+	total := len(photos)
+	if filter.Limit > 0 {
+		if filter.Offset >= len(photos) {
+			return nil, total, nil
+		}
+		photos = photos[filter.Offset:]
+		if len(photos) > filter.Limit {
+			photos = photos[:filter.Limit]
+		}
+	}
+	return photos, total, nil
+}
+
+func containsTag(tags []string, tag string) bool {
+	for _, t := range tags {
+		if t == tag {
+			return true
+		}
+	}
+	return false
+}
+
+// photoIDs returns the IDs of photos in order
+func photoIDs(photos []*models.GinPhoto) []int64 {
+	ids := make([]int64, len(photos))
+	for i, photo := range photos {
+		ids[i] = photo.ID
+	}
+	return ids
+}
+
+func equalIDs(a, b []int64) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func TestUpdatePhoto(t *testing.T) {
+	f := newPhotoUploadFixture()
+	ctx := context.Background()
+	photo := f.photos.add(1, 7, "tenants/1/gins/7/bottle.jpg", 1024)
+
+	caption := "  Summer batch  "
+	label := models.PhotoTypeLabel
+	updated, err := f.service.UpdatePhoto(ctx, 1, 7, photo.ID, &models.UpdatePhotoRequest{
+		Caption:   &caption,
+		PhotoType: &label,
+		Tags:      []string{"Summer  Batch", "summer batch", " Juniper "},
+	})
+	if err != nil {
+		t.Fatalf("UpdatePhoto failed: %v", err)
+	}
+
+	stored := f.photos.photos[photo.ID]
+	if *stored.Caption != "Summer batch" || stored.PhotoType != models.PhotoTypeLabel {
+		t.Errorf("expected caption and type saved, got %+v", stored)
+	}
+	if !equalStrings(stored.Tags, []string{"summer batch", "juniper"}) || !equalStrings(updated.Tags, stored.Tags) {
+		t.Errorf("expected normalized tags saved, got %v", stored.Tags)
+	}
+
+	// Tags are kept when only the caption changes
+	empty := ""
+	if _, err := f.service.UpdatePhoto(ctx, 1, 7, photo.ID, &models.UpdatePhotoRequest{Caption: &empty}); err != nil {
+		t.Fatalf("UpdatePhoto failed: %v", err)
+	}
+	if stored := f.photos.photos[photo.ID]; stored.Caption != nil || len(stored.Tags) != 2 {
+		t.Errorf("expected the caption cleared and tags kept, got %+v", stored)
+	}
+
+	invalid := models.PhotoType("selfie")
+	if _, err := f.service.UpdatePhoto(ctx, 1, 7, photo.ID, &models.UpdatePhotoRequest{PhotoType: &invalid}); err != errors.ErrInvalidInput {
+		t.Errorf("expected ErrInvalidInput for an unknown type, got %v", err)
+	}
+	if _, err := f.service.UpdatePhoto(ctx, 1, 8, photo.ID, &models.UpdatePhotoRequest{Caption: &caption}); err != errors.ErrPhotoNotFound {
+		t.Errorf("expected ErrPhotoNotFound for another gin, got %v", err)
+	}
+}
+
+func equalStrings(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func TestReorderPhotos(t *testing.T) {
+	f := newPhotoUploadFixture()
+	ctx := context.Background()
+	first := f.photos.add(1, 7, "tenants/1/gins/7/a.jpg", 1024)
+	second := f.photos.add(1, 7, "tenants/1/gins/7/b.jpg", 1024)
+	third := f.photos.add(1, 7, "tenants/1/gins/7/c.jpg", 1024)
+	other := f.photos.add(1, 8, "tenants/1/gins/8/d.jpg", 1024)
+
+	photos, err := f.service.ReorderPhotos(ctx, 1, 7, []int64{third.ID, first.ID, second.ID})
+	if err != nil {
+		t.Fatalf("ReorderPhotos failed: %v", err)
+	}
+	if want := []int64{third.ID, first.ID, second.ID}; !equalIDs(photoIDs(photos), want) {
+		t.Errorf("expected order %v, got %v", want, photoIDs(photos))
+	}
+
+	for _, ids := range [][]int64{
+		{third.ID, first.ID},           // incomplete
+		{third.ID, first.ID, other.ID}, // photo of another gin
+		{third.ID, first.ID, second.ID, second.ID},
+	} {
+		if _, err := f.service.ReorderPhotos(ctx, 1, 7, ids); err != errors.ErrInvalidInput {
+			t.Errorf("expected ErrInvalidInput for %v, got %v", ids, err)
+		}
+	}
+	if _, err := f.service.ReorderPhotos(ctx, 2, 7, []int64{third.ID, first.ID, second.ID}); err == nil {
+		t.Error("expected reordering another tenant's gin to fail")
+	}
+}
+
+func TestListGallery(t *testing.T) {
+	f := newPhotoUploadFixture()
+	ctx := context.Background()
+
+	var bottles []*models.GinPhoto
+	for i := 0; i < 3; i++ {
+		bottles = append(bottles, f.photos.add(1, 7, "tenants/1/gins/7/bottle.jpg", 1024))
+	}
+	label := f.photos.add(1, 8, "tenants/1/gins/8/label.jpg", 1024)
+	label.PhotoType = models.PhotoTypeLabel
+	label.Tags = []string{"summer batch"}
+	f.photos.add(2, 9, "tenants/2/gins/9/bottle.jpg", 1024)
+
+	// Pagination, newest first
+	page, total, err := f.service.ListGallery(ctx, &models.PhotoFilter{TenantID: 1, Limit: 2, Offset: 1})
+	if err != nil {
+		t.Fatalf("ListGallery failed: %v", err)
+	}
+	if want := []int64{bottles[2].ID, bottles[1].ID}; total != 4 || !equalIDs(photoIDs(page), want) {
+		t.Errorf("expected %v of 4, got %v of %d", want, photoIDs(page), total)
+	}
+
+	page, total, err = f.service.ListGallery(ctx, &models.PhotoFilter{TenantID: 1, Limit: 2, Offset: 4})
+	if err != nil || total != 4 || page == nil || len(page) != 0 {
+		t.Errorf("expected an empty page past the end, got %v of %d (%v)", page, total, err)
+	}
+
+	labelType := models.PhotoTypeLabel
+	page, total, _ = f.service.ListGallery(ctx, &models.PhotoFilter{TenantID: 1, PhotoType: &labelType})
+	if total != 1 || page[0].ID != label.ID {
+		t.Errorf("expected only the label photo, got %v", photoIDs(page))
+	}
+
+	// Tags are matched normalized
+	page, total, _ = f.service.ListGallery(ctx, &models.PhotoFilter{TenantID: 1, Tag: "  Summer   BATCH "})
+	if total != 1 || page[0].ID != label.ID {
+		t.Errorf("expected only the tagged photo, got %v", photoIDs(page))
+	}
+
+	ginID := int64(7)
+	if _, total, _ = f.service.ListGallery(ctx, &models.PhotoFilter{TenantID: 1, GinID: &ginID}); total != 3 {
+		t.Errorf("expected the 3 photos of gin 7, got %d", total)
+	}
+}
+
+func TestPhotosBeyondTierLimit(t *testing.T) {
+	f := newPhotoUploadFixture()
+	ctx := context.Background()
+
+	// Four photos stored on a higher tier, the Free tier shows three per gin
+	var photos []*models.GinPhoto
+	for i := 0; i < 4; i++ {
+		photos = append(photos, f.photos.add(1, 7, "tenants/1/gins/7/bottle.jpg", 1024))
+	}
+	hidden := photos[3]
+
+	gallery, total, err := f.service.ListGallery(ctx, &models.PhotoFilter{TenantID: 1})
+	if err != nil {
+		t.Fatalf("ListGallery failed: %v", err)
+	}
+	if total != 3 || containsID(gallery, hidden.ID) {
+		t.Errorf("expected the fourth photo hidden, got %v", photoIDs(gallery))
+	}
+
+	caption := "Cask strength"
+	if _, err := f.service.UpdatePhoto(ctx, 1, 7, hidden.ID, &models.UpdatePhotoRequest{Caption: &caption}); err != errors.ErrPhotoLimitReached {
+		t.Errorf("expected ErrPhotoLimitReached editing the fourth photo, got %v", err)
+	}
+	if _, err := f.service.UploadPhoto(ctx, 1, 7, "bottle.jpg", jpegBytes, models.PhotoTypeBottle, nil); !stdErrors.Is(err, errors.ErrPhotoLimitReached) {
+		t.Errorf("expected ErrPhotoLimitReached uploading a fifth photo, got %v", err)
+	}
+
+	// Moving it up hides the photo it displaces instead
+	if _, err := f.service.ReorderPhotos(ctx, 1, 7, []int64{hidden.ID, photos[0].ID, photos[1].ID, photos[2].ID}); err != nil {
+		t.Fatalf("ReorderPhotos failed: %v", err)
+	}
+	if _, err := f.service.UpdatePhoto(ctx, 1, 7, hidden.ID, &models.UpdatePhotoRequest{Caption: &caption}); err != nil {
+		t.Errorf("expected the moved photo to be editable, got %v", err)
+	}
+	if _, err := f.service.UpdatePhoto(ctx, 1, 7, photos[2].ID, &models.UpdatePhotoRequest{Caption: &caption}); err != errors.ErrPhotoLimitReached {
+		t.Errorf("expected ErrPhotoLimitReached for the displaced photo, got %v", err)
+	}
+	gallery, _, _ = f.service.ListGallery(ctx, &models.PhotoFilter{TenantID: 1})
+	if !containsID(gallery, hidden.ID) || containsID(gallery, photos[2].ID) {
+		t.Errorf("expected the displaced photo hidden, got %v", photoIDs(gallery))
+	}
+}
+
+func containsID(photos []*models.GinPhoto, id int64) bool {
+	for _, photo := range photos {
+		if photo.ID == id {
+			return true
+		}
+	}
+	return false
+}
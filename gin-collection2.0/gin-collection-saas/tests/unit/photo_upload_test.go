@@ -0,0 +1,627 @@
+package unit
+
+import (
+	"bytes"
+	"context"
+	stdErrors "errors"
+	"io"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/yourusername/gin-collection-saas/internal/domain/errors"
+	"github.com/yourusername/gin-collection-saas/internal/domain/models"
+	"github.com/yourusername/gin-collection-saas/internal/domain/repositories"
+	"github.com/yourusername/gin-collection-saas/internal/infrastructure/storage"
+	"github.com/yourusername/gin-collection-saas/internal/usecase/entitlement"
+	"github.com/yourusername/gin-collection-saas/internal/usecase/photo"
+)
+
+var jpegBytes = append([]byte{0xFF, 0xD8, 0xFF, 0xE0, 0x00, 0x10, 'J', 'F', 'I', 'F', 0x00, 0x01}, make([]byte, 2036)...)
+
+// fakeObject is an object held by fakeObjectStorage
+type fakeObject struct {
+	data        []byte
+	contentType string
+	modified    time.Time
+}
+
+// fakeObjectStorage keeps objects in memory. Signed uploads are received
+// without checking the signature.
+type fakeObjectStorage struct {
+	name      string
+	objects   map[string]*fakeObject
+	presigned []string
+}
+
+func newFakeObjectStorage(name string) *fakeObjectStorage {
+	return &fakeObjectStorage{name: name, objects: make(map[string]*fakeObject)}
+}
+
+func (s *fakeObjectStorage) put(key string, data []byte, contentType string) {
+	s.objects[key] = &fakeObject{data: data, contentType: contentType, modified: time.Now()}
+}
+
+func (s *fakeObjectStorage) UploadPhoto(ctx context.Context, tenantID int64, ginID int64, filename string, data []byte, contentType string) (*storage.UploadResult, error) {
+	return s.PutObject(ctx, storage.NewPhotoKey(tenantID, ginID, filename), data, contentType)
+}
+
+func (s *fakeObjectStorage) DeletePhoto(ctx context.Context, key string) error {
+	delete(s.objects, key)
+	return nil
+}
+
+func (s *fakeObjectStorage) DownloadPhoto(ctx context.Context, key string) ([]byte, error) {
+	object, ok := s.objects[key]
+	if !ok {
+		return nil, errors.ErrNotFound
+	}
+	return append([]byte(nil), object.data...), nil
+}
+
+func (s *fakeObjectStorage) CheckExists(ctx context.Context, key string) (bool, error) {
+	_, ok := s.objects[key]
+	return ok, nil
+}
+
+func (s *fakeObjectStorage) PresignUpload(ctx context.Context, key string, contentType string, expiration time.Duration) (string, error) {
+	s.presigned = append(s.presigned, key)
+	return "https://" + s.name + ".example.com/" + key + "?signature=test", nil
+}
+
+func (s *fakeObjectStorage) ReadObjectHead(ctx context.Context, key string, n int) ([]byte, error) {
+	data, err := s.DownloadPhoto(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) > n {
+		data = data[:n]
+	}
+	return data, nil
+}
+
+func (s *fakeObjectStorage) GetObjectSize(ctx context.Context, key string) (int64, error) {
+	object, ok := s.objects[key]
+	if !ok {
+		return 0, errors.ErrNotFound
+	}
+	return int64(len(object.data)), nil
+}
+
+func (s *fakeObjectStorage) ObjectURL(key string) string {
+	return "https://" + s.name + ".example.com/" + key
+}
+
+func (s *fakeObjectStorage) PutObject(ctx context.Context, key string, data []byte, contentType string) (*storage.UploadResult, error) {
+	s.put(key, append([]byte(nil), data...), contentType)
+	return &storage.UploadResult{Key: key, URL: s.ObjectURL(key), SizeBytes: int64(len(data))}, nil
+}
+
+func (s *fakeObjectStorage) CopyObject(ctx context.Context, srcKey, dstKey string) error {
+	object, ok := s.objects[srcKey]
+	if !ok {
+		return errors.ErrNotFound
+	}
+	s.put(dstKey, append([]byte(nil), object.data...), object.contentType)
+	return nil
+}
+
+func (s *fakeObjectStorage) ListObjects(ctx context.Context, prefix string) ([]storage.ObjectInfo, error) {
+	var objects []storage.ObjectInfo
+	for key, object := range s.objects {
+		if strings.HasPrefix(key, prefix) {
+			objects = append(objects, storage.ObjectInfo{Key: key, SizeBytes: int64(len(object.data)), LastModified: object.modified})
+		}
+	}
+	sort.Slice(objects, func(i, j int) bool { return objects[i].Key < objects[j].Key })
+	return objects, nil
+}
+
+func (s *fakeObjectStorage) ReceiveSignedUpload(ctx context.Context, key string, contentType string, expires int64, signature string, body io.Reader) (int64, error) {
+	data, err := io.ReadAll(body)
+	if err != nil {
+		return 0, err
+	}
+	s.put(key, data, contentType)
+	return int64(len(data)), nil
+}
+
+// fakeUploadSessionRepository keeps upload sessions in memory
+type fakeUploadSessionRepository struct {
+	sessions map[int64]*models.PhotoUploadSession
+}
+
+func newFakeUploadSessionRepository() *fakeUploadSessionRepository {
+	return &fakeUploadSessionRepository{sessions: make(map[int64]*models.PhotoUploadSession)}
+}
+
+func (r *fakeUploadSessionRepository) Create(ctx context.Context, session *models.PhotoUploadSession) error {
+	session.ID = int64(len(r.sessions) + 1)
+	session.CreatedAt = time.Now()
+	r.sessions[session.ID] = session
+	return nil
+}
+
+func (r *fakeUploadSessionRepository) GetByToken(ctx context.Context, tenantID int64, token string) (*models.PhotoUploadSession, error) {
+	for _, session := range r.sessions {
+		if session.TenantID == tenantID && session.Token == token {
+			copied := *session
+			return &copied, nil
+		}
+	}
+	return nil, errors.ErrNotFound
+}
+
+func (r *fakeUploadSessionRepository) GetByStorageKey(ctx context.Context, storageKey string) (*models.PhotoUploadSession, error) {
+	for _, session := range r.sessions {
+		if session.StorageKey == storageKey {
+			copied := *session
+			return &copied, nil
+		}
+	}
+	return nil, errors.ErrNotFound
+}
+
+func (r *fakeUploadSessionRepository) MarkExpired(ctx context.Context, id int64) error {
+	if session := r.sessions[id]; session.Status == models.UploadSessionPending {
+		session.Status = models.UploadSessionExpired
+	}
+	return nil
+}
+
+func (r *fakeUploadSessionRepository) ListExpiredPending(ctx context.Context, before time.Time, limit int) ([]*models.PhotoUploadSession, error) {
+	var sessions []*models.PhotoUploadSession
+	for _, session := range r.sessions {
+		if session.Status == models.UploadSessionPending && session.ExpiresAt.Before(before) && len(sessions) < limit {
+			copied := *session
+			sessions = append(sessions, &copied)
+		}
+	}
+	return sessions, nil
+}
+
+func (r *fakeUploadSessionRepository) DeleteFinishedBefore(ctx context.Context, before time.Time) (int64, error) {
+	var deleted int64
+	for id, session := range r.sessions {
+		if session.Status != models.UploadSessionPending && session.CreatedAt.Before(before) {
+			delete(r.sessions, id)
+			deleted++
+		}
+	}
+	return deleted, nil
+}
+
+// fakePhotoStore keeps photo records in memory and books their bytes on the
+// storage usage, checking the quota like the MySQL repository does
+type fakePhotoStore struct {
+	repositories.PhotoRepository
+	photos   map[int64]*models.GinPhoto
+	sessions *fakeUploadSessionRepository // completed by CreateFromUpload
+	usage    *fakeStorageUsageRepository
+	nextID   int64
+
+	// failCreate is returned by the next Create, e.g. a lost connection
+	failCreate error
+
+	// beforeCreate runs before the quota is checked, e.g. to simulate a
+	// concurrent upload
+	beforeCreate func()
+}
+
+func newFakePhotoStore(usage *fakeStorageUsageRepository) *fakePhotoStore {
+	return &fakePhotoStore{photos: make(map[int64]*models.GinPhoto), usage: usage}
+}
+
+// add stores a photo of a gin directly
+func (r *fakePhotoStore) add(tenantID, ginID int64, key string, sizeBytes int64) *models.GinPhoto {
+	photo := &models.GinPhoto{TenantID: tenantID, GinID: ginID, PhotoURL: "https://local.example.com/" + key, PhotoType: models.PhotoTypeBottle, StorageKey: &key, FileSizeBytes: &sizeBytes}
+	r.Create(context.Background(), photo, nil)
+	return photo
+}
+
+func (r *fakePhotoStore) Create(ctx context.Context, photo *models.GinPhoto, quota *models.PhotoQuota) error {
+	if err := r.failCreate; err != nil {
+		r.failCreate = nil
+		return err
+	}
+	if r.beforeCreate != nil {
+		r.beforeCreate()
+	}
+	count, _ := r.CountByGinID(ctx, photo.TenantID, photo.GinID)
+	if quota != nil {
+		if quota.MaxPhotosPerGin >= 0 && count >= quota.MaxPhotosPerGin {
+			return errors.ErrPhotoLimitReached
+		}
+		usage := &models.StorageUsage{BytesUsed: r.usage.bytesUsed}
+		if photo.FileSizeBytes != nil && usage.WouldExceed(*photo.FileSizeBytes, quota.StorageLimitMB) {
+			return errors.ErrStorageLimitReached
+		}
+	}
+	photo.IsPrimary = count == 0
+	photo.SortOrder = count
+
+	r.nextID++
+	photo.ID = r.nextID
+	photo.CreatedAt = time.Now()
+	r.photos[photo.ID] = photo
+	if photo.FileSizeBytes != nil {
+		r.usage.bytesUsed += *photo.FileSizeBytes
+	}
+	return nil
+}
+
+func (r *fakePhotoStore) CreateFromUpload(ctx context.Context, photo *models.GinPhoto, quota *models.PhotoQuota, sessionID int64) error {
+	session := r.sessions.sessions[sessionID]
+	if session.Status != models.UploadSessionPending {
+		return errors.ErrConflict
+	}
+	if err := r.Create(ctx, photo, quota); err != nil {
+		return err
+	}
+	now := time.Now()
+	session.Status = models.UploadSessionCompleted
+	session.CompletedAt = &now
+	return nil
+}
+
+func (r *fakePhotoStore) GetByID(ctx context.Context, tenantID, id int64) (*models.GinPhoto, error) {
+	photo, ok := r.photos[id]
+	if !ok || photo.TenantID != tenantID {
+		return nil, errors.ErrNotFound
+	}
+	copied := *photo
+	return &copied, nil
+}
+
+func (r *fakePhotoStore) Delete(ctx context.Context, tenantID, id int64) error {
+	photo, ok := r.photos[id]
+	if !ok || photo.TenantID != tenantID {
+		return errors.ErrNotFound
+	}
+	if photo.FileSizeBytes != nil {
+		r.usage.bytesUsed -= *photo.FileSizeBytes
+	}
+	delete(r.photos, id)
+	return nil
+}
+
+func (r *fakePhotoStore) CountByGinID(ctx context.Context, tenantID, ginID int64) (int, error) {
+	count := 0
+	for _, photo := range r.photos {
+		if photo.TenantID == tenantID && photo.GinID == ginID {
+			count++
+		}
+	}
+	return count, nil
+}
+
+func (r *fakePhotoStore) ListByTenant(ctx context.Context, tenantID int64) ([]*models.GinPhoto, error) {
+	var photos []*models.GinPhoto
+	for _, photo := range r.photos {
+		if photo.TenantID == tenantID {
+			copied := *photo
+			photos = append(photos, &copied)
+		}
+	}
+	sort.Slice(photos, func(i, j int) bool { return photos[i].ID < photos[j].ID })
+	return photos, nil
+}
+
+func (r *fakePhotoStore) GetByGinID(ctx context.Context, tenantID, ginID int64) ([]*models.GinPhoto, error) {
+	var photos []*models.GinPhoto
+	for _, photo := range r.photos {
+		if photo.TenantID == tenantID && photo.GinID == ginID {
+			copied := *photo
+			photos = append(photos, &copied)
+		}
+	}
+	sort.Slice(photos, func(i, j int) bool {
+		if photos[i].SortOrder != photos[j].SortOrder {
+			return photos[i].SortOrder < photos[j].SortOrder
+		}
+		return photos[i].ID < photos[j].ID
+	})
+	return photos, nil
+}
+
+func (r *fakePhotoStore) SetPrimary(ctx context.Context, tenantID, ginID, photoID int64) error {
+	for _, photo := range r.photos {
+		if photo.TenantID == tenantID && photo.GinID == ginID {
+			photo.IsPrimary = photo.ID == photoID
+		}
+	}
+	return nil
+}
+
+// fakeUploadGinRepository knows gin 7 of tenant 1
+type fakeUploadGinRepository struct {
+	repositories.GinRepository
+}
+
+func (r *fakeUploadGinRepository) GetByID(ctx context.Context, tenantID, id int64) (*models.Gin, error) {
+	if tenantID != 1 || id != 7 {
+		return nil, errors.ErrGinNotFound
+	}
+	return &models.Gin{ID: id, TenantID: tenantID, Name: "London Dry"}, nil
+}
+
+// fakeStorageUsageRepository holds the storage usage booked by fakePhotoStore
+type fakeStorageUsageRepository struct {
+	repositories.StorageUsageRepository
+	bytesUsed int64
+	photos    *fakePhotoStore // usage is recomputed from these, if set
+
+	reconciled chan int64 // receives recomputed usages, if set
+}
+
+func (r *fakeStorageUsageRepository) GetUsage(ctx context.Context, tenantID int64) (*models.StorageUsage, error) {
+	return &models.StorageUsage{TenantID: tenantID, BytesUsed: r.bytesUsed}, nil
+}
+
+type photoUploadFixture struct {
+	service  *photo.Service
+	storage  *fakeObjectStorage
+	sessions *fakeUploadSessionRepository
+	photos   *fakePhotoStore
+	usage    *fakeStorageUsageRepository
+}
+
+// newPhotoUploadFixture sets up direct uploads for gin 7 of a Free tenant
+func newPhotoUploadFixture() *photoUploadFixture {
+	f := &photoUploadFixture{
+		storage:  newFakeObjectStorage("local"),
+		sessions: newFakeUploadSessionRepository(),
+		usage:    &fakeStorageUsageRepository{},
+	}
+	f.photos = newFakePhotoStore(f.usage)
+	f.photos.sessions = f.sessions
+	tenants := newFakeTenantRepository(&models.Tenant{ID: 1, Name: "Gin Bar", Subdomain: "ginbar", Tier: models.TierFree, Status: models.TenantStatusActive})
+
+	f.service = photo.NewService(f.photos, &fakeUploadGinRepository{}, f.usage, tenants, entitlement.NewService(f.usage), f.storage)
+	f.service.SetUploadSessionRepo(f.sessions)
+	return f
+}
+
+// presign starts a direct upload of a JPEG and returns its session
+func (f *photoUploadFixture) presign(t *testing.T) *models.PhotoUploadSession {
+	upload, err := f.service.CreateUploadURL(context.Background(), 1, nil, 7, &models.UploadURLRequest{
+		Filename:    "bottle.html",
+		ContentType: "image/jpeg",
+		SizeBytes:   int64(len(jpegBytes)),
+	})
+	if err != nil {
+		t.Fatalf("CreateUploadURL failed: %v", err)
+	}
+
+	session, err := f.sessions.GetByToken(context.Background(), 1, upload.UploadToken)
+	if err != nil {
+		t.Fatalf("upload session not stored: %v", err)
+	}
+	return session
+}
+
+// receive uploads data to the signed URL of a session
+func (f *photoUploadFixture) receive(session *models.PhotoUploadSession, data []byte) error {
+	return f.service.ReceiveSignedUpload(context.Background(), session.StorageKey, session.ContentType, session.ExpiresAt.Unix(), "test", bytes.NewReader(data))
+}
+
+func TestCreateUploadURL(t *testing.T) {
+	f := newPhotoUploadFixture()
+	ctx := context.Background()
+
+	session := f.presign(t)
+	if !strings.HasPrefix(session.StorageKey, storage.StagingPrefix+"tenants/1/gins/7/") || !strings.HasSuffix(session.StorageKey, ".jpg") {
+		t.Errorf("expected a staging key with the extension of the content type, got %s", session.StorageKey)
+	}
+	if session.Status != models.UploadSessionPending || session.PhotoType != models.PhotoTypeBottle || time.Until(session.ExpiresAt) > photo.UploadSessionTTL {
+		t.Errorf("unexpected session: %+v", session)
+	}
+
+	for name, req := range map[string]*models.UploadURLRequest{
+		"content type": {Filename: "page.html", ContentType: "text/html", SizeBytes: 100},
+		"size":         {Filename: "huge.jpg", ContentType: "image/jpeg", SizeBytes: photo.MaxPhotoSizeBytes + 1},
+		"photo type":   {Filename: "bottle.jpg", ContentType: "image/jpeg", SizeBytes: 100, PhotoType: "selfie"},
+	} {
+		if _, err := f.service.CreateUploadURL(ctx, 1, nil, 7, req); err == nil {
+			t.Errorf("expected an invalid %s to be refused", name)
+		}
+	}
+	if _, err := f.service.CreateUploadURL(ctx, 2, nil, 7, &models.UploadURLRequest{Filename: "bottle.jpg", ContentType: "image/jpeg", SizeBytes: 100}); err != errors.ErrGinNotFound {
+		t.Errorf("expected ErrGinNotFound for another tenant's gin, got %v", err)
+	}
+}
+
+func TestCompleteUploadMovesPhotoToFinalKey(t *testing.T) {
+	f := newPhotoUploadFixture()
+	ctx := context.Background()
+
+	session := f.presign(t)
+	if _, err := f.service.CompleteUpload(ctx, 1, 7, session.Token); err != errors.ErrUploadMissing {
+		t.Fatalf("expected ErrUploadMissing before the upload, got %v", err)
+	}
+	if err := f.receive(session, jpegBytes); err != nil {
+		t.Fatalf("ReceiveSignedUpload failed: %v", err)
+	}
+
+	registered, err := f.service.CompleteUpload(ctx, 1, 7, session.Token)
+	if err != nil {
+		t.Fatalf("CompleteUpload failed: %v", err)
+	}
+
+	key := *registered.StorageKey
+	if !strings.HasPrefix(key, "tenants/1/gins/7/") || key == session.StorageKey {
+		t.Fatalf("expected the photo at a new key, got %s", key)
+	}
+	for _, presigned := range f.storage.presigned {
+		if presigned == key {
+			t.Fatalf("the final key %s was presigned", key)
+		}
+	}
+	if _, staged := f.storage.objects[session.StorageKey]; staged {
+		t.Error("expected the staging object to be removed")
+	}
+	if !registered.IsPrimary || *registered.FileSizeBytes != int64(len(jpegBytes)) || f.usage.bytesUsed != int64(len(jpegBytes)) {
+		t.Errorf("unexpected photo %+v (storage used %d)", registered, f.usage.bytesUsed)
+	}
+
+	// The signed URL is still within its TTL, but the session is done
+	if err := f.receive(session, []byte("<html><script>alert(1)</script></html>")); err != errors.ErrUploadExpired {
+		t.Errorf("expected uploads after completion to be refused, got %v", err)
+	}
+	if !bytes.Equal(f.storage.objects[key].data, jpegBytes) {
+		t.Error("expected the registered photo to be unchanged")
+	}
+	if _, err := f.service.CompleteUpload(ctx, 1, 7, session.Token); err != errors.ErrConflict {
+		t.Errorf("expected ErrConflict completing twice, got %v", err)
+	}
+}
+
+func TestCompleteUploadCanBeRetried(t *testing.T) {
+	f := newPhotoUploadFixture()
+	ctx := context.Background()
+
+	session := f.presign(t)
+	if err := f.receive(session, jpegBytes); err != nil {
+		t.Fatalf("ReceiveSignedUpload failed: %v", err)
+	}
+
+	f.photos.failCreate = stdErrors.New("connection reset")
+	if _, err := f.service.CompleteUpload(ctx, 1, 7, session.Token); err == nil {
+		t.Fatal("expected the failed photo record to fail the completion")
+	}
+	if stored := f.sessions.sessions[session.ID]; stored.Status != models.UploadSessionPending {
+		t.Fatalf("expected the session to stay pending, got %s", stored.Status)
+	}
+	if _, staged := f.storage.objects[session.StorageKey]; !staged || len(f.storage.objects) != 1 {
+		t.Fatalf("expected only the staging object to be kept, got %d objects", len(f.storage.objects))
+	}
+
+	registered, err := f.service.CompleteUpload(ctx, 1, 7, session.Token)
+	if err != nil {
+		t.Fatalf("retrying CompleteUpload failed: %v", err)
+	}
+	if !bytes.Equal(f.storage.objects[*registered.StorageKey].data, jpegBytes) || len(f.photos.photos) != 1 {
+		t.Errorf("expected the retry to register the photo once, got %d photos", len(f.photos.photos))
+	}
+	if _, staged := f.storage.objects[session.StorageKey]; staged {
+		t.Error("expected the staging object to be removed after the retry")
+	}
+}
+
+func TestCompleteUploadRejectsInvalidUploads(t *testing.T) {
+	tests := []struct {
+		name  string
+		data  []byte
+		setup func(f *photoUploadFixture)
+		want  error
+	}{
+		{
+			name: "wrong magic bytes",
+			data: append([]byte("<svg onload=alert(1)>"), make([]byte, 100)...),
+			want: errors.ErrInvalidFileType,
+		},
+		{
+			name: "other image type",
+			data: append([]byte{0x89, 'P', 'N', 'G', 0x0D, 0x0A, 0x1A, 0x0A}, make([]byte, 100)...),
+			want: errors.ErrInvalidFileType,
+		},
+		{
+			name: "oversize",
+			data: append(append([]byte(nil), jpegBytes...), make([]byte, photo.MaxPhotoSizeBytes)...),
+			want: errors.ErrFileTooLarge,
+		},
+		{
+			name: "photo limit",
+			data: jpegBytes,
+			setup: func(f *photoUploadFixture) {
+				for i := 0; i < 3; i++ {
+					f.photos.add(1, 7, "tenants/1/gins/7/existing.jpg", 1024)
+				}
+			},
+			want: errors.ErrPhotoLimitReached,
+		},
+		{
+			name: "storage limit",
+			data: jpegBytes,
+			setup: func(f *photoUploadFixture) {
+				f.usage.bytesUsed = 100*models.BytesPerMB - 1024
+			},
+			want: errors.ErrStorageLimitReached,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newPhotoUploadFixture()
+			ctx := context.Background()
+			session := f.presign(t)
+			if tt.setup != nil {
+				tt.setup(f)
+			}
+			if err := f.receive(session, tt.data); err != nil {
+				t.Fatalf("ReceiveSignedUpload failed: %v", err)
+			}
+			photos := len(f.photos.photos)
+
+			if _, err := f.service.CompleteUpload(ctx, 1, 7, session.Token); !stdErrors.Is(err, tt.want) {
+				t.Fatalf("expected %v, got %v", tt.want, err)
+			}
+			if len(f.photos.photos) != photos {
+				t.Error("expected no photo to be registered")
+			}
+			for key := range f.storage.objects {
+				if !strings.HasSuffix(key, "existing.jpg") {
+					t.Errorf("expected the upload to be deleted, found %s", key)
+				}
+			}
+			if f.sessions.sessions[session.ID].Status != models.UploadSessionExpired {
+				t.Errorf("expected the session to expire, got %s", f.sessions.sessions[session.ID].Status)
+			}
+			if _, err := f.service.CompleteUpload(ctx, 1, 7, session.Token); err != errors.ErrUploadExpired {
+				t.Errorf("expected ErrUploadExpired on retry, got %v", err)
+			}
+		})
+	}
+}
+
+func TestSweepAbandonedUploads(t *testing.T) {
+	f := newPhotoUploadFixture()
+	ctx := context.Background()
+
+	abandoned := f.presign(t)
+	if err := f.receive(abandoned, jpegBytes); err != nil {
+		t.Fatalf("ReceiveSignedUpload failed: %v", err)
+	}
+	f.sessions.sessions[abandoned.ID].ExpiresAt = time.Now().Add(-time.Hour)
+
+	inFlight := f.presign(t)
+	if err := f.receive(inFlight, jpegBytes); err != nil {
+		t.Fatalf("ReceiveSignedUpload failed: %v", err)
+	}
+
+	// Uploaded to a URL again after its session completed
+	leftover := storage.StagingPrefix + "tenants/1/gins/7/leftover.jpg"
+	f.storage.put(leftover, jpegBytes, "image/jpeg")
+	f.storage.objects[leftover].modified = time.Now().Add(-time.Hour)
+
+	swept, err := f.service.SweepAbandonedUploads(ctx)
+	if err != nil {
+		t.Fatalf("SweepAbandonedUploads failed: %v", err)
+	}
+	if swept != 1 {
+		t.Errorf("expected 1 swept session, got %d", swept)
+	}
+	if f.sessions.sessions[abandoned.ID].Status != models.UploadSessionExpired {
+		t.Errorf("expected the abandoned session to expire, got %s", f.sessions.sessions[abandoned.ID].Status)
+	}
+	for key, kept := range map[string]bool{abandoned.StorageKey: false, leftover: false, inFlight.StorageKey: true} {
+		if _, ok := f.storage.objects[key]; ok != kept {
+			t.Errorf("%s: expected kept=%v", key, kept)
+		}
+	}
+
+	if _, err := f.service.CompleteUpload(ctx, 1, 7, inFlight.Token); err != nil {
+		t.Errorf("expected the upload in flight to complete, got %v", err)
+	}
+}
@@ -0,0 +1,287 @@
+package unit
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"sort"
+	"testing"
+
+	domainErrors "github.com/yourusername/gin-collection-saas/internal/domain/errors"
+	"github.com/yourusername/gin-collection-saas/internal/domain/models"
+	"github.com/yourusername/gin-collection-saas/internal/infrastructure/billing"
+	"github.com/yourusername/gin-collection-saas/internal/usecase/subscription"
+)
+
+// fakePlanRepository keeps all plan versions in memory
+type fakePlanRepository struct {
+	plans    map[string]*models.SubscriptionPlan // current versions
+	versions map[string][]*models.SubscriptionPlan
+}
+
+// newFakePlanRepository stores the built-in plans, billed with the Stripe
+// prices of newStripeContract
+func newFakePlanRepository() *fakePlanRepository {
+	repo := &fakePlanRepository{
+		plans:    make(map[string]*models.SubscriptionPlan),
+		versions: make(map[string][]*models.SubscriptionPlan),
+	}
+	prices := map[string]string{"PLAN_BASIC_MONTHLY": "price_basic", "PLAN_PRO_MONTHLY": "price_pro"}
+	for _, plan := range models.DefaultPlans {
+		plan := plan
+		if price, ok := prices[plan.ID]; ok {
+			plan.ProviderPlans = map[string]string{models.BillingProviderStripe: price}
+		}
+		repo.Create(context.Background(), &plan)
+	}
+	return repo
+}
+
+func (r *fakePlanRepository) List(ctx context.Context) ([]*models.SubscriptionPlan, error) {
+	plans := []*models.SubscriptionPlan{}
+	for _, plan := range r.plans {
+		copied := *plan
+		plans = append(plans, &copied)
+	}
+	sort.Slice(plans, func(i, j int) bool { return plans[i].SortOrder < plans[j].SortOrder })
+	return plans, nil
+}
+
+func (r *fakePlanRepository) GetByID(ctx context.Context, id string) (*models.SubscriptionPlan, error) {
+	plan, ok := r.plans[id]
+	if !ok {
+		return nil, domainErrors.ErrNotFound
+	}
+	copied := *plan
+	return &copied, nil
+}
+
+func (r *fakePlanRepository) ListVersions(ctx context.Context, id string) ([]*models.SubscriptionPlan, error) {
+	versions := []*models.SubscriptionPlan{}
+	for _, version := range r.versions[id] {
+		copied := *version
+		versions = append(versions, &copied)
+	}
+	return versions, nil
+}
+
+func (r *fakePlanRepository) Create(ctx context.Context, plan *models.SubscriptionPlan) error {
+	if _, ok := r.plans[plan.ID]; ok {
+		return domainErrors.ErrConflict
+	}
+	return r.CreateVersion(ctx, plan)
+}
+
+func (r *fakePlanRepository) Update(ctx context.Context, plan *models.SubscriptionPlan) error {
+	for _, version := range append(r.versions[plan.ID], r.plans[plan.ID]) {
+		version.Name = plan.Name
+		version.SortOrder = plan.SortOrder
+		version.Active = plan.Active
+	}
+	return nil
+}
+
+func (r *fakePlanRepository) CreateVersion(ctx context.Context, plan *models.SubscriptionPlan) error {
+	for _, version := range r.versions[plan.ID] {
+		if version.Version == plan.Version {
+			return domainErrors.ErrConflict
+		}
+	}
+	copied := *plan
+	r.versions[plan.ID] = append(r.versions[plan.ID], &copied)
+	current := copied
+	r.plans[plan.ID] = &current
+	return nil
+}
+
+type planCatalogFixture struct {
+	service       *subscription.Service
+	catalog       *subscription.Catalog
+	plans         *fakePlanRepository
+	stripe        *fakeStripe
+	tenants       *fakeTenantRepository
+	subscriptions *fakeSubscriptionRepository
+}
+
+// newPlanCatalogFixture sets up two Free tenants billed with Stripe and a
+// stored plan catalog
+func newPlanCatalogFixture(t *testing.T) *planCatalogFixture {
+	contract, fake := newStripeContract(t)
+
+	f := &planCatalogFixture{
+		plans:  newFakePlanRepository(),
+		stripe: fake,
+		tenants: newFakeTenantRepository(
+			&models.Tenant{ID: 1, Name: "Gin Bar", Subdomain: "ginbar", Tier: models.TierFree, Status: models.TenantStatusActive},
+			&models.Tenant{ID: 2, Name: "Juniper Club", Subdomain: "juniper", Tier: models.TierFree, Status: models.TenantStatusActive},
+		),
+		subscriptions: newFakeSubscriptionRepository(),
+	}
+	f.catalog = subscription.NewCatalog(f.plans)
+	if err := f.catalog.Load(context.Background()); err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	contract.provider.(*billing.StripeProvider).SetCatalog(f.catalog)
+
+	f.service = subscription.NewService(f.subscriptions, f.tenants, &fakeGinRepository{}, &fakePhotoRepository{},
+		&fakeStorageUsageRepository{}, billing.NewRegistry(models.BillingProviderStripe, contract.provider), "https://app.example.com")
+	f.service.SetCatalog(f.catalog)
+
+	return f
+}
+
+// subscribe subscribes a tenant to a plan and approves the checkout
+func (f *planCatalogFixture) subscribe(t *testing.T, tenantID int64, planID string) *models.Subscription {
+	ctx := context.Background()
+
+	upgrade, err := f.service.InitiateUpgrade(ctx, tenantID, planID, models.BillingCycleMonthly, "", "")
+	if err != nil {
+		t.Fatalf("InitiateUpgrade failed: %v", err)
+	}
+	f.stripe.approve(t, &billing.Checkout{CheckoutID: upgrade.CheckoutID})
+	subscription, err := f.service.ActivateCheckout(ctx, tenantID, models.BillingProviderStripe, upgrade.CheckoutID)
+	if err != nil {
+		t.Fatalf("ActivateCheckout failed: %v", err)
+	}
+	return subscription
+}
+
+func TestPlanFeaturesDescribeLimits(t *testing.T) {
+	free := models.PlanFeatures(models.PlanLimitsMap[models.TierFree], models.PlanLanguageGerman)
+	if want := []string{"Bis zu 5 Gins", "3 Fotos pro Gin", "100 MB Speicherplatz"}; !reflect.DeepEqual(free, want) {
+		t.Errorf("expected %v, got %v", want, free)
+	}
+
+	enterprise := models.PlanFeatures(models.PlanLimitsMap[models.TierEnterprise], models.PlanLanguageEnglish)
+	if len(enterprise) < 3 || enterprise[0] != "Unlimited gins" || enterprise[1] != "Unlimited photos" || enterprise[2] != "Unlimited storage" {
+		t.Errorf("expected unlimited enterprise limits, got %v", enterprise)
+	}
+
+	tests := map[string]string{
+		"en":                   models.PlanLanguageEnglish,
+		"en-US,en;q=0.9":       models.PlanLanguageEnglish,
+		"fr-FR,de;q=0.8":       models.PlanLanguageGerman,
+		"fr":                   models.DefaultPlanLanguage,
+		"":                     models.DefaultPlanLanguage,
+		"EN-GB, de-DE;q=0.5  ": models.PlanLanguageEnglish,
+	}
+	for value, want := range tests {
+		if got := models.PlanLanguage(value); got != want {
+			t.Errorf("PlanLanguage(%q) = %q, want %q", value, got, want)
+		}
+	}
+}
+
+func TestPlanPriceChangeKeepsExistingSubscribers(t *testing.T) {
+	f := newPlanCatalogFixture(t)
+	ctx := context.Background()
+
+	existing := f.subscribe(t, 1, "PLAN_PRO_MONTHLY")
+	if existing.PlanVersion != 1 || existing.Amount != 9.99 {
+		t.Fatalf("expected version 1 at 9.99, got version %d at %.2f", existing.PlanVersion, existing.Amount)
+	}
+
+	update := f.catalog.Plan("PLAN_PRO_MONTHLY")
+	maxGins := 200
+	update.PriceMonthly = 12.99
+	update.Limits.MaxGins = &maxGins
+	update.ProviderPlans = map[string]string{models.BillingProviderStripe: "price_pro_v2"}
+	plan, err := f.catalog.UpdatePlan(ctx, "PLAN_PRO_MONTHLY", update)
+	if err != nil {
+		t.Fatalf("UpdatePlan failed: %v", err)
+	}
+	if plan.Version != 2 || plan.Features[0] != "Bis zu 200 Gins" {
+		t.Fatalf("expected version 2 with 200 gins, got %+v", plan)
+	}
+
+	if stored := f.subscriptions.subscriptions[existing.ID]; stored.PlanVersion != 1 || stored.Amount != 9.99 {
+		t.Errorf("expected existing subscriber to keep version 1, got %+v", stored)
+	}
+	if limits := f.tenants.tenants[1].GetLimits(); *limits.MaxGins != 100 {
+		t.Errorf("expected existing subscriber to keep 100 gins, got %d", *limits.MaxGins)
+	}
+	if planID := f.catalog.PlanFor(models.BillingProviderStripe, "price_pro"); planID != "PLAN_PRO_MONTHLY" {
+		t.Errorf("expected the old price to still belong to the plan, got %q", planID)
+	}
+
+	renewed := f.subscribe(t, 2, "PLAN_PRO_MONTHLY")
+	if renewed.PlanVersion != 2 || renewed.Amount != 12.99 {
+		t.Errorf("expected new subscriber on version 2 at 12.99, got version %d at %.2f", renewed.PlanVersion, renewed.Amount)
+	}
+	if price := f.stripe.subscriptions[*renewed.ProviderSubscriptionID].Items.Data[0].Price.ID; price != "price_pro_v2" {
+		t.Errorf("expected new subscriber billed with price_pro_v2, got %q", price)
+	}
+	if limits := f.tenants.tenants[2].GetLimits(); *limits.MaxGins != 200 {
+		t.Errorf("expected new subscriber to get 200 gins, got %d", *limits.MaxGins)
+	}
+}
+
+func TestInactivePlanIsNotOffered(t *testing.T) {
+	f := newPlanCatalogFixture(t)
+	ctx := context.Background()
+
+	update := f.catalog.Plan("PLAN_BASIC_MONTHLY")
+	update.Active = false
+	plan, err := f.catalog.UpdatePlan(ctx, "PLAN_BASIC_MONTHLY", update)
+	if err != nil {
+		t.Fatalf("UpdatePlan failed: %v", err)
+	}
+	if plan.Version != 1 {
+		t.Errorf("expected deactivation to keep version 1, got %d", plan.Version)
+	}
+
+	for _, offered := range f.service.GetAvailablePlans(models.PlanLanguageEnglish) {
+		if offered.ID == "PLAN_BASIC_MONTHLY" {
+			t.Error("expected the inactive plan not to be offered")
+		}
+		if offered.ID == "PLAN_FREE" && offered.Features[0] != "Up to 5 gins" {
+			t.Errorf("expected English features, got %v", offered.Features)
+		}
+		if offered.ProviderPlans != nil {
+			t.Errorf("expected provider plans to be hidden, got %v", offered.ProviderPlans)
+		}
+	}
+
+	if _, err := f.service.InitiateUpgrade(ctx, 1, "PLAN_BASIC_MONTHLY", models.BillingCycleMonthly, "", ""); err != domainErrors.ErrPlanNotAvailable {
+		t.Errorf("expected ErrPlanNotAvailable, got %v", err)
+	}
+}
+
+func TestPlanCatalogValidation(t *testing.T) {
+	f := newPlanCatalogFixture(t)
+	ctx := context.Background()
+
+	reused := f.catalog.Plan("PLAN_BASIC_MONTHLY")
+	reused.PriceMonthly = 5.99
+	if _, err := f.catalog.UpdatePlan(ctx, "PLAN_BASIC_MONTHLY", reused); !errors.Is(err, domainErrors.ErrInvalidInput) {
+		t.Errorf("expected a new price on the old Stripe price to be rejected, got %v", err)
+	}
+
+	free := f.catalog.Plan(models.FreePlanID)
+	maxGins := 50
+	free.Limits.MaxGins = &maxGins
+	if _, err := f.catalog.UpdatePlan(ctx, models.FreePlanID, free); !errors.Is(err, domainErrors.ErrInvalidInput) {
+		t.Errorf("expected Free limits to be locked, got %v", err)
+	}
+
+	duplicate := f.catalog.Plan("PLAN_PRO_MONTHLY")
+	if err := f.catalog.CreatePlan(ctx, duplicate); err != domainErrors.ErrPlanIDTaken {
+		t.Errorf("expected ErrPlanIDTaken, got %v", err)
+	}
+
+	freeTier := &models.SubscriptionPlan{ID: "PLAN_FREE_PLUS", Name: "Free Plus", Tier: models.TierFree}
+	if err := f.catalog.CreatePlan(ctx, freeTier); !errors.Is(err, domainErrors.ErrInvalidInput) {
+		t.Errorf("expected free tier plans to be rejected, got %v", err)
+	}
+
+	team := &models.SubscriptionPlan{
+		ID: "PLAN_TEAM_MONTHLY", Name: "Team", Tier: models.TierPro, PriceMonthly: 29.99, SortOrder: 35, Active: true,
+		Limits: models.PlanLimitsMap[models.TierPro],
+	}
+	if err := f.catalog.CreatePlan(ctx, team); err != nil {
+		t.Fatalf("CreatePlan failed: %v", err)
+	}
+	if plan := f.catalog.Plan("PLAN_TEAM_MONTHLY"); plan == nil || plan.Version != 1 || plan.Currency != "EUR" {
+		t.Errorf("expected the team plan in the catalog, got %+v", plan)
+	}
+}
@@ -0,0 +1,347 @@
+package unit
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/yourusername/gin-collection-saas/internal/delivery/http/middleware"
+	"github.com/yourusername/gin-collection-saas/internal/domain/errors"
+	"github.com/yourusername/gin-collection-saas/internal/domain/models"
+	"github.com/yourusername/gin-collection-saas/internal/domain/repositories"
+	"github.com/yourusername/gin-collection-saas/internal/infrastructure/billing"
+	"github.com/yourusername/gin-collection-saas/internal/usecase/entitlement"
+	"github.com/yourusername/gin-collection-saas/internal/usecase/subscription"
+)
+
+// fakeGinRepository only counts gins
+type fakeGinRepository struct {
+	repositories.GinRepository
+	count int
+}
+
+func (r *fakeGinRepository) Count(ctx context.Context, tenantID int64, isFinished *bool) (int, error) {
+	return r.count, nil
+}
+
+// fakePhotoRepository only counts photos, perGin holds the photo count of each gin
+type fakePhotoRepository struct {
+	repositories.PhotoRepository
+	perGin map[int64]int
+}
+
+func (r *fakePhotoRepository) CountBeyondLimit(ctx context.Context, tenantID int64, maxPerGin int) (int, int, error) {
+	photos, gins := 0, 0
+	for _, count := range r.perGin {
+		if count > maxPerGin {
+			photos += count - maxPerGin
+			gins++
+		}
+	}
+	return photos, gins, nil
+}
+
+type planChangeFixture struct {
+	service       *subscription.Service
+	provider      billing.BillingProvider
+	stripe        *fakeStripe
+	tenants       *fakeTenantRepository
+	subscriptions *fakeSubscriptionRepository
+	gins          *fakeGinRepository
+	photos        *fakePhotoRepository
+	storage       *fakeStorageUsageRepository
+	subscription  *models.Subscription
+	periodEnd     time.Time
+}
+
+// newPlanChangeFixture subscribes a tenant to a plan with Stripe, 10 days
+// into a 30 day period
+func newPlanChangeFixture(t *testing.T, planID string) *planChangeFixture {
+	contract, fake := newStripeContract(t)
+	f := newPlanChangeFixtureWith(t, contract, planID)
+	f.stripe = fake
+	return f
+}
+
+// newPlanChangeFixtureWith subscribes a tenant to a plan with the provider
+// of contract, 10 days into a 30 day period
+func newPlanChangeFixtureWith(t *testing.T, contract *providerContract, planID string) *planChangeFixture {
+	ctx := context.Background()
+	name := contract.provider.Name()
+
+	f := &planChangeFixture{
+		provider:      contract.provider,
+		tenants:       newFakeTenantRepository(&models.Tenant{ID: 1, Name: "Gin Bar", Subdomain: "ginbar", Tier: models.TierFree, Status: models.TenantStatusActive}),
+		subscriptions: newFakeSubscriptionRepository(),
+		gins:          &fakeGinRepository{},
+		photos:        &fakePhotoRepository{},
+		storage:       &fakeStorageUsageRepository{},
+	}
+	f.service = subscription.NewService(f.subscriptions, f.tenants, f.gins, f.photos, f.storage,
+		billing.NewRegistry(name, f.provider), "https://app.example.com")
+
+	upgrade, err := f.service.InitiateUpgrade(ctx, 1, planID, models.BillingCycleMonthly, "", "")
+	if err != nil {
+		t.Fatalf("InitiateUpgrade failed: %v", err)
+	}
+	contract.approve(t, &billing.Checkout{CheckoutID: upgrade.CheckoutID})
+	activated, err := f.service.ActivateCheckout(ctx, 1, name, upgrade.CheckoutID)
+	if err != nil {
+		t.Fatalf("ActivateCheckout failed: %v", err)
+	}
+
+	start := time.Now().UTC().AddDate(0, 0, -10)
+	f.periodEnd = start.AddDate(0, 0, 30)
+	stored := f.subscriptions.subscriptions[activated.ID]
+	stored.CurrentPeriodStart = &start
+	stored.CurrentPeriodEnd = &f.periodEnd
+	f.subscription = stored
+
+	return f
+}
+
+func TestPlanUpgradeIsProrated(t *testing.T) {
+	f := newPlanChangeFixture(t, "PLAN_BASIC_MONTHLY")
+	ctx := context.Background()
+
+	preview, err := f.service.PreviewPlanChange(ctx, 1, "PLAN_PRO_MONTHLY")
+	if err != nil {
+		t.Fatalf("PreviewPlanChange failed: %v", err)
+	}
+	if preview.Downgrade || preview.Impact != nil || preview.Proration == nil {
+		t.Fatalf("expected a prorated upgrade, got %+v", preview)
+	}
+
+	change, err := f.service.ChangePlan(ctx, 1, "PLAN_PRO_MONTHLY")
+	if err != nil {
+		t.Fatalf("ChangePlan failed: %v", err)
+	}
+	if change.Scheduled || f.tenants.tenants[1].Tier != models.TierPro {
+		t.Fatalf("expected upgrade to apply immediately, got %+v (tier %s)", change, f.tenants.tenants[1].Tier)
+	}
+
+	// 20 of 30 days remain: 2/3 of 4.99 credited, 2/3 of 9.99 charged
+	proration := change.Proration
+	if proration == nil || proration.Credit != 3.33 || proration.Charge != 6.66 || proration.AmountDue != 3.33 ||
+		proration.RemainingDays != 20 || proration.PeriodDays != 30 || !proration.Invoiced {
+		t.Errorf("unexpected proration: %+v", proration)
+	}
+	if got := f.stripe.prorations; len(got) != 1 || got[0] != "create_prorations" {
+		t.Errorf("expected Stripe to prorate the upgrade, got %v", got)
+	}
+
+	if stored := f.subscriptions.subscriptions[f.subscription.ID]; stored.PlanID != "PLAN_PRO_MONTHLY" || stored.Amount != 9.99 {
+		t.Errorf("expected subscription on the pro plan, got %+v", stored)
+	}
+}
+
+func TestUpgradeWithoutProrationIsScheduled(t *testing.T) {
+	f := newPlanChangeFixtureWith(t, newPayPalContract(t), "PLAN_BASIC_MONTHLY")
+	ctx := context.Background()
+
+	// PayPal charges the revised plan from the next billing cycle only
+	preview, err := f.service.PreviewPlanChange(ctx, 1, "PLAN_PRO_MONTHLY")
+	if err != nil {
+		t.Fatalf("PreviewPlanChange failed: %v", err)
+	}
+	if preview.Downgrade || preview.Proration != nil || !preview.EffectiveAt.Equal(f.periodEnd) {
+		t.Fatalf("expected the upgrade at the end of the period without proration, got %+v", preview)
+	}
+
+	change, err := f.service.ChangePlan(ctx, 1, "PLAN_PRO_MONTHLY")
+	if err != nil {
+		t.Fatalf("ChangePlan failed: %v", err)
+	}
+	if !change.Scheduled || change.Proration != nil || change.Impact != nil || !change.EffectiveAt.Equal(f.periodEnd) {
+		t.Fatalf("expected the upgrade to be scheduled, got %+v", change)
+	}
+	if f.tenants.tenants[1].Tier != models.TierBasic {
+		t.Errorf("expected tenant to stay basic until the plan is charged, got %s", f.tenants.tenants[1].Tier)
+	}
+
+	// The period ends
+	ended := time.Now().Add(-time.Minute)
+	f.subscriptions.subscriptions[f.subscription.ID].ScheduledChangeAt = &ended
+	if applied, err := f.service.ApplyScheduledChanges(ctx); err != nil || applied != 1 {
+		t.Fatalf("expected the upgrade to be applied, got %d (%v)", applied, err)
+	}
+	if f.tenants.tenants[1].Tier != models.TierPro || f.subscriptions.subscriptions[f.subscription.ID].PlanID != "PLAN_PRO_MONTHLY" {
+		t.Errorf("expected tenant on the pro plan, got %s", f.tenants.tenants[1].Tier)
+	}
+}
+
+func TestDowngradeIsScheduledAtPeriodEnd(t *testing.T) {
+	f := newPlanChangeFixture(t, "PLAN_PRO_MONTHLY")
+	ctx := context.Background()
+
+	// 80 gins and up to 20 photos per gin don't fit into Basic (15 gins, 10 photos)
+	f.gins.count = 80
+	f.photos.perGin = map[int64]int{1: 20, 2: 12, 3: 5}
+
+	preview, err := f.service.PreviewPlanChange(ctx, 1, "PLAN_BASIC_MONTHLY")
+	if err != nil {
+		t.Fatalf("PreviewPlanChange failed: %v", err)
+	}
+	if !preview.Downgrade || preview.Proration != nil || !preview.EffectiveAt.Equal(f.periodEnd) {
+		t.Fatalf("expected downgrade at the end of the period, got %+v", preview)
+	}
+
+	impact := preview.Impact
+	if impact == nil {
+		t.Fatal("expected a downgrade impact report")
+	}
+	if impact.GinCount != 80 || impact.GinsOverLimit != 65 || !impact.ReadOnly {
+		t.Errorf("expected 65 gins over limit, got %+v", impact)
+	}
+	if impact.PhotosOverLimit != 12 || impact.GinsWithPhotosOverLimit != 2 {
+		t.Errorf("expected 12 photos of 2 gins over limit, got %+v", impact)
+	}
+	lost := []string{models.FeatureBotanicals, models.FeatureCocktails, models.FeatureAISuggestions, models.FeatureImport, models.FeatureAPIAccess}
+	if !reflect.DeepEqual(impact.FeaturesLost, lost) {
+		t.Errorf("expected features lost %v, got %v", lost, impact.FeaturesLost)
+	}
+
+	change, err := f.service.ChangePlan(ctx, 1, "PLAN_BASIC_MONTHLY")
+	if err != nil {
+		t.Fatalf("ChangePlan failed: %v", err)
+	}
+	if !change.Scheduled || !change.EffectiveAt.Equal(f.periodEnd) || change.Impact == nil {
+		t.Fatalf("expected downgrade to be scheduled, got %+v", change)
+	}
+
+	// The provider bills the lower plan from the next period on, the tenant
+	// keeps its tier until then
+	if got := f.stripe.prorations; len(got) != 1 || got[0] != "none" {
+		t.Errorf("expected downgrade without proration at Stripe, got %v", got)
+	}
+	if f.tenants.tenants[1].Tier != models.TierPro {
+		t.Errorf("expected tenant to stay pro, got %s", f.tenants.tenants[1].Tier)
+	}
+
+	if applied, err := f.service.ApplyScheduledChanges(ctx); err != nil || applied != 0 {
+		t.Fatalf("expected no change before the end of the period, got %d (%v)", applied, err)
+	}
+
+	// The period ends
+	ended := time.Now().Add(-time.Minute)
+	f.subscriptions.subscriptions[f.subscription.ID].ScheduledChangeAt = &ended
+
+	if applied, err := f.service.ApplyScheduledChanges(ctx); err != nil || applied != 1 {
+		t.Fatalf("expected the downgrade to be applied, got %d (%v)", applied, err)
+	}
+	if f.tenants.tenants[1].Tier != models.TierBasic {
+		t.Errorf("expected tenant to be moved to basic, got %s", f.tenants.tenants[1].Tier)
+	}
+	stored := f.subscriptions.subscriptions[f.subscription.ID]
+	if stored.PlanID != "PLAN_BASIC_MONTHLY" || stored.ScheduledPlanID != nil || stored.ScheduledChangeAt != nil {
+		t.Errorf("expected subscription on the basic plan without schedule, got %+v", stored)
+	}
+}
+
+func TestCancelScheduledDowngrade(t *testing.T) {
+	f := newPlanChangeFixture(t, "PLAN_PRO_MONTHLY")
+	ctx := context.Background()
+
+	if _, err := f.service.CancelScheduledChange(ctx, 1); err != errors.ErrNoScheduledChange {
+		t.Fatalf("expected ErrNoScheduledChange, got %v", err)
+	}
+
+	if _, err := f.service.ChangePlan(ctx, 1, "PLAN_BASIC_MONTHLY"); err != nil {
+		t.Fatalf("ChangePlan failed: %v", err)
+	}
+
+	change, err := f.service.CancelScheduledChange(ctx, 1)
+	if err != nil {
+		t.Fatalf("CancelScheduledChange failed: %v", err)
+	}
+	if change.Subscription.ScheduledPlanID != nil || change.Tier != models.TierPro {
+		t.Errorf("expected the pro plan to be kept, got %+v", change)
+	}
+
+	if price := f.stripe.subscriptions[*f.subscription.ProviderSubscriptionID].Items.Data[0].Price.ID; price != "price_pro" {
+		t.Errorf("expected Stripe to bill the pro price again, got %s", price)
+	}
+
+	if applied, _ := f.service.ApplyScheduledChanges(ctx); applied != 0 {
+		t.Errorf("expected no scheduled change left, got %d", applied)
+	}
+}
+
+func TestPreviewCancellationTakesEffectImmediately(t *testing.T) {
+	f := newPlanChangeFixture(t, "PLAN_BASIC_MONTHLY")
+	f.gins.count = 12
+
+	before := time.Now()
+	preview, err := f.service.PreviewPlanChange(context.Background(), 1, "PLAN_FREE")
+	if err != nil {
+		t.Fatalf("PreviewPlanChange failed: %v", err)
+	}
+	if !preview.Downgrade || preview.EffectiveAt.Before(before) || preview.EffectiveAt.After(f.periodEnd) {
+		t.Errorf("expected cancellation to take effect immediately, got %+v", preview)
+	}
+	if preview.Impact == nil || preview.Impact.GinsOverLimit != 7 ||
+		!reflect.DeepEqual(preview.Impact.FeaturesLost, []string{models.FeatureExport}) {
+		t.Errorf("unexpected impact: %+v", preview.Impact)
+	}
+}
+
+func TestCollectionIsReadOnlyOverLimit(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	gins := &fakeGinRepository{count: 20}
+	tenant := &models.Tenant{ID: 1, Tier: models.TierBasic} // 15 gins
+
+	router := gin.New()
+	group := router.Group("/api/v1/gins", func(c *gin.Context) {
+		c.Set("tenant", tenant)
+		c.Set("tenant_id", tenant.ID)
+	}, middleware.NewTierEnforcementMiddleware(entitlement.NewService(nil), gins).ReadOnlyOverLimit())
+	ok := func(c *gin.Context) { c.Status(http.StatusOK) }
+	group.GET("/:id", ok)
+	group.PUT("/:id", ok)
+	group.DELETE("/:id", ok)
+	group.POST("/export", ok)
+	group.POST("/:id/photos", ok)
+
+	request := func(method, path string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(method, path, nil))
+		return w
+	}
+
+	for _, allowed := range []struct{ method, path string }{
+		{"GET", "/api/v1/gins/1"},
+		{"DELETE", "/api/v1/gins/1"},
+		{"POST", "/api/v1/gins/export"},
+	} {
+		if w := request(allowed.method, allowed.path); w.Code != http.StatusOK {
+			t.Errorf("expected %s %s to be allowed, got %d", allowed.method, allowed.path, w.Code)
+		}
+	}
+
+	for _, blocked := range []struct{ method, path string }{
+		{"PUT", "/api/v1/gins/1"},
+		{"POST", "/api/v1/gins/1/photos"},
+	} {
+		w := request(blocked.method, blocked.path)
+		if w.Code != http.StatusForbidden {
+			t.Fatalf("expected %s %s to be read-only, got %d", blocked.method, blocked.path, w.Code)
+		}
+		var body map[string]interface{}
+		json.Unmarshal(w.Body.Bytes(), &body)
+		if body["over_limit"] != true || body["gins_over_limit"] != float64(5) {
+			t.Errorf("unexpected over limit response: %v", body)
+		}
+	}
+
+	// Back within the limit the collection can be edited again
+	gins.count = 15
+	if w := request("PUT", "/api/v1/gins/1"); w.Code != http.StatusOK {
+		t.Errorf("expected edits within the limit, got %d", w.Code)
+	}
+}
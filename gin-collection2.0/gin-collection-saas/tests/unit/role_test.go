@@ -0,0 +1,431 @@
+package unit
+
+import (
+	"context"
+	stdErrors "errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/yourusername/gin-collection-saas/internal/delivery/http/middleware"
+	"github.com/yourusername/gin-collection-saas/internal/delivery/http/router"
+	"github.com/yourusername/gin-collection-saas/internal/domain/errors"
+	"github.com/yourusername/gin-collection-saas/internal/domain/models"
+	"github.com/yourusername/gin-collection-saas/internal/usecase/entitlement"
+	"github.com/yourusername/gin-collection-saas/internal/usecase/role"
+)
+
+// fakeRoleRepository keeps custom roles in memory, assignments are stored on
+// the users of the user repository
+type fakeRoleRepository struct {
+	roles []*models.CustomRole
+	users *fakeUserRepository
+}
+
+func (r *fakeRoleRepository) Create(ctx context.Context, role *models.CustomRole) error {
+	role.ID = int64(len(r.roles) + 1)
+	copied := *role
+	r.roles = append(r.roles, &copied)
+	return nil
+}
+
+func (r *fakeRoleRepository) GetByID(ctx context.Context, tenantID, id int64) (*models.CustomRole, error) {
+	for _, role := range r.roles {
+		if role.ID == id && role.TenantID == tenantID {
+			return r.withUserCount(role), nil
+		}
+	}
+	return nil, errors.ErrNotFound
+}
+
+func (r *fakeRoleRepository) ListByTenant(ctx context.Context, tenantID int64) ([]*models.CustomRole, error) {
+	var roles []*models.CustomRole
+	for _, role := range r.roles {
+		if role.TenantID == tenantID {
+			roles = append(roles, r.withUserCount(role))
+		}
+	}
+	return roles, nil
+}
+
+func (r *fakeRoleRepository) Update(ctx context.Context, role *models.CustomRole) error {
+	for i, existing := range r.roles {
+		if existing.ID == role.ID && existing.TenantID == role.TenantID {
+			copied := *role
+			r.roles[i] = &copied
+			return nil
+		}
+	}
+	return errors.ErrNotFound
+}
+
+func (r *fakeRoleRepository) Delete(ctx context.Context, tenantID, id int64) error {
+	for i, role := range r.roles {
+		if role.ID == id && role.TenantID == tenantID {
+			r.roles = append(r.roles[:i], r.roles[i+1:]...)
+			return nil
+		}
+	}
+	return nil
+}
+
+func (r *fakeRoleRepository) SetUserRole(ctx context.Context, tenantID, userID int64, roleID *int64) error {
+	user, ok := r.users.users[userID]
+	if !ok || user.TenantID != tenantID {
+		return nil
+	}
+	user.CustomRoleID = roleID
+	return nil
+}
+
+func (r *fakeRoleRepository) withUserCount(role *models.CustomRole) *models.CustomRole {
+	copied := *role
+	copied.UserCount = 0
+	for _, user := range r.users.users {
+		if user.CustomRoleID != nil && *user.CustomRoleID == role.ID {
+			copied.UserCount++
+		}
+	}
+	return &copied
+}
+
+// roleFixture is an Enterprise tenant with a user of every built-in role and
+// a member assigned to the custom role "Taster"
+type roleFixture struct {
+	tenant   *models.Tenant
+	users    *fakeUserRepository
+	roles    *fakeRoleRepository
+	auditLog *fakeAuditLogRepository
+	service  *role.Service
+	userIDs  map[string]int64
+	router   *gin.Engine
+}
+
+func newRoleFixture(t *testing.T) *roleFixture {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+
+	f := &roleFixture{
+		tenant:   &models.Tenant{ID: 1, Subdomain: "acme", Tier: models.TierEnterprise, Status: models.TenantStatusActive},
+		users:    newFakeUserRepository(),
+		auditLog: &fakeAuditLogRepository{},
+		userIDs:  make(map[string]int64),
+	}
+	f.roles = &fakeRoleRepository{users: f.users}
+	f.service = role.NewService(f.roles, f.users, newFakeTenantRepository(f.tenant), f.auditLog, entitlement.NewService(nil))
+
+	for _, name := range []string{"owner", "admin", "member", "viewer", "taster"} {
+		baseRole := models.UserRole(name)
+		if name == "taster" {
+			baseRole = models.RoleMember
+		}
+		user := &models.User{TenantID: f.tenant.ID, Email: name + "@acme.test", Role: baseRole, IsActive: true}
+		if err := f.users.Create(context.Background(), user); err != nil {
+			t.Fatalf("failed to create user: %v", err)
+		}
+		f.userIDs[name] = user.ID
+	}
+
+	taster, err := f.service.Create(context.Background(), f.tenant.ID, f.userIDs["owner"], &models.RoleRequest{
+		Name:        "Taster",
+		Permissions: []models.Permission{models.PermissionGinsRead, models.PermissionTastingsWrite, models.PermissionTastingsDelete},
+	})
+	if err != nil {
+		t.Fatalf("failed to create custom role: %v", err)
+	}
+	if err := f.service.AssignUser(context.Background(), f.tenant.ID, f.userIDs["owner"], taster.ID, f.userIDs["taster"]); err != nil {
+		t.Fatalf("failed to assign custom role: %v", err)
+	}
+
+	// Stand-in for the authentication and tenant middlewares, the role of the
+	// token is taken from the user
+	authenticate := func(c *gin.Context) {
+		userID, _ := strconv.ParseInt(c.GetHeader("X-Test-User"), 10, 64)
+		user, err := f.users.GetByID(c.Request.Context(), userID)
+		if err != nil {
+			c.AbortWithStatus(http.StatusUnauthorized)
+			return
+		}
+		c.Set("user_id", user.ID)
+		c.Set("user_role", string(user.Role))
+		c.Set("tenant", f.tenant)
+		c.Set("tenant_id", f.tenant.ID)
+	}
+	ok := func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	}
+
+	f.router = gin.New()
+	protected := f.router.Group("", authenticate, middleware.NewPermissionMiddleware(f.users, f.roles).Authorize())
+	for _, tc := range permissionMatrix {
+		method, path, _ := strings.Cut(tc.route, " ")
+		protected.Handle(method, path, ok)
+	}
+	protected.GET("/api/v1/unregistered", ok)
+
+	return f
+}
+
+func (f *roleFixture) request(method, path, user string) int {
+	req := httptest.NewRequest(method, path, nil)
+	req.Header.Set("X-Test-User", strconv.FormatInt(f.userIDs[user], 10))
+	w := httptest.NewRecorder()
+	f.router.ServeHTTP(w, req)
+	return w.Code
+}
+
+// permissionMatrix lists routes with the roles allowed to call them, the
+// other roles must be denied
+var permissionMatrix = []struct {
+	route   string
+	path    string
+	allowed string
+}{
+	{"GET /api/v1/gins", "/api/v1/gins", "owner admin member viewer taster"},
+	{"GET /api/v1/gins/:id", "/api/v1/gins/1", "owner admin member viewer taster"},
+	{"POST /api/v1/gins", "/api/v1/gins", "owner admin member"},
+	{"PUT /api/v1/gins/:id", "/api/v1/gins/1", "owner admin member"},
+	{"DELETE /api/v1/gins/:id", "/api/v1/gins/1", "owner admin"},
+	{"POST /api/v1/gins/export", "/api/v1/gins/export", "owner admin member"},
+	{"POST /api/v1/gins/import", "/api/v1/gins/import", "owner admin member"},
+	{"POST /api/v1/gins/scan-label", "/api/v1/gins/scan-label", "owner admin member"},
+	{"PUT /api/v1/gins/:id/botanicals", "/api/v1/gins/1/botanicals", "owner admin member"},
+	{"POST /api/v1/gins/:id/photos", "/api/v1/gins/1/photos", "owner admin member"},
+	{"PATCH /api/v1/gins/:id/photos/:photo_id", "/api/v1/gins/1/photos/2", "owner admin member"},
+	{"DELETE /api/v1/gins/:id/photos/:photo_id", "/api/v1/gins/1/photos/2", "owner admin"},
+	{"GET /api/v1/gins/:id/tastings", "/api/v1/gins/1/tastings", "owner admin member viewer taster"},
+	{"POST /api/v1/gins/:id/tastings", "/api/v1/gins/1/tastings", "owner admin member taster"},
+	{"PUT /api/v1/gins/:id/tastings/:session_id", "/api/v1/gins/1/tastings/2", "owner admin member taster"},
+	{"DELETE /api/v1/gins/:id/tastings/:session_id", "/api/v1/gins/1/tastings/2", "owner admin taster"},
+	{"POST /api/v1/ai/suggest-gin", "/api/v1/ai/suggest-gin", "owner admin member"},
+	{"GET /api/v1/tenants/current", "/api/v1/tenants/current", "owner admin member viewer"},
+	{"PUT /api/v1/tenants/current", "/api/v1/tenants/current", "owner"},
+	{"PUT /api/v1/tenants/current/sso", "/api/v1/tenants/current/sso", "owner"},
+	{"POST /api/v1/subscriptions/upgrade", "/api/v1/subscriptions/upgrade", "owner"},
+	{"POST /api/v1/subscriptions/cancel", "/api/v1/subscriptions/cancel", "owner"},
+	{"GET /api/v1/users", "/api/v1/users", "owner admin"},
+	{"POST /api/v1/users/invite", "/api/v1/users/invite", "owner admin"},
+	{"DELETE /api/v1/users/:id", "/api/v1/users/3", "owner admin"},
+	{"GET /api/v1/roles", "/api/v1/roles", "owner admin"},
+	{"POST /api/v1/roles", "/api/v1/roles", "owner admin"},
+	{"PUT /api/v1/roles/:id/members/:user_id", "/api/v1/roles/1/members/3", "owner admin"},
+	{"GET /api/v1/api-keys", "/api/v1/api-keys", "owner admin member viewer"},
+	{"POST /api/v1/api-keys", "/api/v1/api-keys", "owner admin member viewer"},
+}
+
+func TestPermissionMatrix(t *testing.T) {
+	f := newRoleFixture(t)
+
+	for _, tc := range permissionMatrix {
+		method, _, _ := strings.Cut(tc.route, " ")
+		allowed := strings.Fields(tc.allowed)
+		for user := range f.userIDs {
+			want := http.StatusForbidden
+			for _, name := range allowed {
+				if name == user {
+					want = http.StatusOK
+				}
+			}
+			if got := f.request(method, tc.path, user); got != want {
+				t.Errorf("%s as %s: expected %d, got %d", tc.route, user, want, got)
+			}
+		}
+	}
+}
+
+func TestPermissionMiddlewareDeniesUnregisteredRoutes(t *testing.T) {
+	f := newRoleFixture(t)
+
+	if code := f.request(http.MethodGet, "/api/v1/unregistered", "owner"); code != http.StatusForbidden {
+		t.Errorf("expected unregistered route to be denied even for owners, got %d", code)
+	}
+}
+
+func TestPermissionMiddlewareAppliesRoleChanges(t *testing.T) {
+	f := newRoleFixture(t)
+	ctx := context.Background()
+	tasterRole := *f.users.users[f.userIDs["taster"]].CustomRoleID
+
+	// New permissions apply with the next request
+	if _, err := f.service.Update(ctx, f.tenant.ID, f.userIDs["owner"], tasterRole, &models.RoleRequest{
+		Name:        "Taster",
+		Permissions: []models.Permission{models.PermissionGinsRead, models.PermissionGinsDelete},
+	}); err != nil {
+		t.Fatalf("failed to update role: %v", err)
+	}
+	if code := f.request(http.MethodDelete, "/api/v1/gins/1", "taster"); code != http.StatusOK {
+		t.Errorf("expected gins:delete after update, got %d", code)
+	}
+	if code := f.request(http.MethodPost, "/api/v1/gins/1/tastings", "taster"); code != http.StatusForbidden {
+		t.Errorf("expected tastings:write to be removed, got %d", code)
+	}
+
+	// Unassigned users fall back to their built-in role
+	if err := f.service.UnassignUser(ctx, f.tenant.ID, f.userIDs["owner"], tasterRole, f.userIDs["taster"]); err != nil {
+		t.Fatalf("failed to unassign role: %v", err)
+	}
+	if code := f.request(http.MethodPut, "/api/v1/gins/1", "taster"); code != http.StatusOK {
+		t.Errorf("expected member permissions after unassign, got %d", code)
+	}
+
+	// Custom roles are ignored once the tenant can't have them
+	if err := f.service.AssignUser(ctx, f.tenant.ID, f.userIDs["owner"], tasterRole, f.userIDs["taster"]); err != nil {
+		t.Fatalf("failed to assign role: %v", err)
+	}
+	f.tenant.Tier = models.TierPro
+	if code := f.request(http.MethodPut, "/api/v1/gins/1", "taster"); code != http.StatusOK {
+		t.Errorf("expected built-in role on Pro tier, got %d", code)
+	}
+}
+
+func TestEveryProtectedRouteHasPermission(t *testing.T) {
+	engine := router.Setup(&router.RouterConfig{})
+
+	// Routes outside the protected groups authenticate differently
+	public := []string{"/health", "/ready", "/uploads", "/api/v1/csrf-token", "/api/v1/auth/", "/api/v1/scim/v2/", "/api/v1/webhooks/", "/api/v1/uploads/"}
+
+	registered := 0
+	for _, route := range engine.Routes() {
+		skip := false
+		for _, prefix := range public {
+			if strings.HasPrefix(route.Path, prefix) {
+				skip = true
+			}
+		}
+		if skip {
+			continue
+		}
+
+		permission, ok := middleware.RoutePermission(route.Method, route.Path)
+		if !ok {
+			t.Errorf("%s %s has no permission in the registry", route.Method, route.Path)
+			continue
+		}
+		if _, ok := models.LookupPermission(permission); !ok {
+			t.Errorf("%s %s requires unknown permission %q", route.Method, route.Path, permission)
+		}
+		registered++
+	}
+
+	if registered == 0 {
+		t.Fatal("expected protected routes")
+	}
+}
+
+func TestBuiltInRolePermissions(t *testing.T) {
+	for _, info := range models.PermissionRegistry {
+		if !models.RoleOwner.HasPermission(info.Name) {
+			t.Errorf("owner is missing %s", info.Name)
+		}
+	}
+
+	for _, p := range []models.Permission{models.PermissionTenantManage, models.PermissionBillingManage} {
+		if models.RoleAdmin.HasPermission(p) {
+			t.Errorf("admin must not have %s", p)
+		}
+	}
+	if models.RoleMember.HasPermission(models.PermissionGinsDelete) || models.RoleMember.HasPermission(models.PermissionUsersManage) {
+		t.Error("members must not delete gins or manage users")
+	}
+	if models.RoleViewer.HasPermission(models.PermissionGinsWrite) || models.RoleViewer.HasPermission(models.PermissionPhotosWrite) {
+		t.Error("viewers must not change gins or photos")
+	}
+	if models.UserRole("unknown").HasPermission(models.PermissionGinsRead) {
+		t.Error("unknown roles must not have permissions")
+	}
+
+	// Owners keep all permissions even with a custom role
+	custom := &models.CustomRole{Permissions: []models.Permission{models.PermissionGinsRead}}
+	if !models.EffectivePermissions(models.RoleOwner, custom).Has(models.PermissionBillingManage) {
+		t.Error("owner lost permissions to a custom role")
+	}
+	if models.EffectivePermissions(models.RoleAdmin, custom).Has(models.PermissionGinsWrite) {
+		t.Error("custom role must replace the permissions of the built-in role")
+	}
+}
+
+func TestRoleRequestValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		req     models.RoleRequest
+		wantErr bool
+	}{
+		{"valid", models.RoleRequest{Name: "Curator", Permissions: []models.Permission{models.PermissionGinsWrite}}, false},
+		{"built-in name", models.RoleRequest{Name: "Admin", Permissions: []models.Permission{models.PermissionGinsRead}}, true},
+		{"blank name", models.RoleRequest{Name: "  ", Permissions: []models.Permission{models.PermissionGinsRead}}, true},
+		{"unknown permission", models.RoleRequest{Name: "Curator", Permissions: []models.Permission{"gins:everything"}}, true},
+		{"owner only permission", models.RoleRequest{Name: "Curator", Permissions: []models.Permission{models.PermissionUsersManage}}, true},
+		{"billing", models.RoleRequest{Name: "Curator", Permissions: []models.Permission{models.PermissionBillingManage}}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.req.Validate()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestRoleServiceRules(t *testing.T) {
+	f := newRoleFixture(t)
+	ctx := context.Background()
+	ownerID := f.userIDs["owner"]
+	tasterRole := *f.users.users[f.userIDs["taster"]].CustomRoleID
+
+	req := &models.RoleRequest{Name: "taster", Permissions: []models.Permission{models.PermissionGinsRead}}
+	if _, err := f.service.Create(ctx, f.tenant.ID, ownerID, req); err != errors.ErrRoleNameTaken {
+		t.Errorf("expected ErrRoleNameTaken, got %v", err)
+	}
+
+	if err := f.service.AssignUser(ctx, f.tenant.ID, ownerID, tasterRole, ownerID); err != errors.ErrForbidden {
+		t.Errorf("expected owner assignment to be forbidden, got %v", err)
+	}
+
+	other := &models.User{TenantID: 2, Email: "other@example.test", Role: models.RoleMember}
+	f.users.Create(ctx, other)
+	if err := f.service.AssignUser(ctx, f.tenant.ID, ownerID, tasterRole, other.ID); err != errors.ErrUserNotInTenant {
+		t.Errorf("expected ErrUserNotInTenant, got %v", err)
+	}
+
+	if err := f.service.Delete(ctx, f.tenant.ID, ownerID, tasterRole); err != errors.ErrRoleInUse {
+		t.Errorf("expected ErrRoleInUse, got %v", err)
+	}
+
+	roles, err := f.service.List(ctx, f.tenant.ID)
+	if err != nil {
+		t.Fatalf("failed to list roles: %v", err)
+	}
+	if len(roles) != len(models.BuiltInRoles)+1 || roles[len(roles)-1].Name != "Taster" || *roles[len(roles)-1].UserCount != 1 {
+		t.Errorf("expected built-in roles and Taster with one user, got %+v", roles)
+	}
+
+	if err := f.service.UnassignUser(ctx, f.tenant.ID, ownerID, tasterRole, f.userIDs["taster"]); err != nil {
+		t.Fatalf("failed to unassign role: %v", err)
+	}
+	if err := f.service.Delete(ctx, f.tenant.ID, ownerID, tasterRole); err != nil {
+		t.Errorf("expected unused role to be deleted, got %v", err)
+	}
+
+	actions := []string{}
+	for _, log := range f.auditLog.logs {
+		actions = append(actions, log.Action)
+	}
+	if got := strings.Join(actions, ","); got != "create_role,assign_role,unassign_role,delete_role" {
+		t.Errorf("unexpected audit log %s", got)
+	}
+
+	// Custom roles are Enterprise only
+	f.tenant.Tier = models.TierPro
+	if _, err := f.service.Create(ctx, f.tenant.ID, ownerID, &models.RoleRequest{Name: "Curator", Permissions: []models.Permission{models.PermissionGinsRead}}); !stdErrors.Is(err, errors.ErrMultiUserNotAllowed) {
+		t.Errorf("expected ErrMultiUserNotAllowed, got %v", err)
+	}
+	roles, _ = f.service.List(ctx, f.tenant.ID)
+	if len(roles) != len(models.BuiltInRoles) {
+		t.Errorf("expected only built-in roles on Pro, got %d", len(roles))
+	}
+}
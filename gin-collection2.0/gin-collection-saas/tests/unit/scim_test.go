@@ -0,0 +1,498 @@
+package unit
+
+import (
+	"context"
+	"encoding/json"
+	stdErrors "errors"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/yourusername/gin-collection-saas/internal/domain/errors"
+	"github.com/yourusername/gin-collection-saas/internal/domain/models"
+	"github.com/yourusername/gin-collection-saas/internal/usecase/entitlement"
+	"github.com/yourusername/gin-collection-saas/internal/usecase/scim"
+	"github.com/yourusername/gin-collection-saas/internal/usecase/user"
+)
+
+// fakeSCIMRepository keeps the SCIM token and groups in memory
+type fakeSCIMRepository struct {
+	tokens      map[int64]*models.SCIMToken
+	groups      map[int64]*models.SCIMGroup
+	nextGroupID int64
+}
+
+func newFakeSCIMRepository() *fakeSCIMRepository {
+	return &fakeSCIMRepository{
+		tokens: make(map[int64]*models.SCIMToken),
+		groups: make(map[int64]*models.SCIMGroup),
+	}
+}
+
+func (r *fakeSCIMRepository) GetToken(ctx context.Context, tenantID int64) (*models.SCIMToken, error) {
+	token, ok := r.tokens[tenantID]
+	if !ok {
+		return nil, errors.ErrNotFound
+	}
+	return token, nil
+}
+
+func (r *fakeSCIMRepository) GetTokenByHash(ctx context.Context, tokenHash string) (*models.SCIMToken, error) {
+	for _, token := range r.tokens {
+		if token.TokenHash == tokenHash {
+			return token, nil
+		}
+	}
+	return nil, errors.ErrNotFound
+}
+
+func (r *fakeSCIMRepository) SaveToken(ctx context.Context, token *models.SCIMToken) error {
+	token.ID = int64(len(r.tokens) + 1)
+	r.tokens[token.TenantID] = token
+	return nil
+}
+
+func (r *fakeSCIMRepository) DeleteToken(ctx context.Context, tenantID int64) error {
+	if _, ok := r.tokens[tenantID]; !ok {
+		return errors.ErrNotFound
+	}
+	delete(r.tokens, tenantID)
+	return nil
+}
+
+func (r *fakeSCIMRepository) TouchToken(ctx context.Context, id int64) error {
+	return nil
+}
+
+func (r *fakeSCIMRepository) ListGroups(ctx context.Context, tenantID int64) ([]*models.SCIMGroup, error) {
+	var groups []*models.SCIMGroup
+	for _, group := range r.groups {
+		if group.TenantID == tenantID {
+			groups = append(groups, copyGroup(group))
+		}
+	}
+	sort.Slice(groups, func(i, j int) bool { return groups[i].ID < groups[j].ID })
+	return groups, nil
+}
+
+func (r *fakeSCIMRepository) GetGroup(ctx context.Context, tenantID, id int64) (*models.SCIMGroup, error) {
+	group, ok := r.groups[id]
+	if !ok || group.TenantID != tenantID {
+		return nil, errors.ErrNotFound
+	}
+	return copyGroup(group), nil
+}
+
+func (r *fakeSCIMRepository) CreateGroup(ctx context.Context, group *models.SCIMGroup) error {
+	r.nextGroupID++
+	group.ID = r.nextGroupID
+	r.groups[group.ID] = copyGroup(group)
+	return nil
+}
+
+func (r *fakeSCIMRepository) UpdateGroup(ctx context.Context, group *models.SCIMGroup) error {
+	if existing, ok := r.groups[group.ID]; !ok || existing.TenantID != group.TenantID {
+		return errors.ErrNotFound
+	}
+	r.groups[group.ID] = copyGroup(group)
+	return nil
+}
+
+func (r *fakeSCIMRepository) DeleteGroup(ctx context.Context, tenantID, id int64) error {
+	if group, ok := r.groups[id]; !ok || group.TenantID != tenantID {
+		return errors.ErrNotFound
+	}
+	delete(r.groups, id)
+	return nil
+}
+
+func copyGroup(group *models.SCIMGroup) *models.SCIMGroup {
+	copied := *group
+	copied.MemberIDs = append([]int64{}, group.MemberIDs...)
+	return &copied
+}
+
+// fakeAuditLogRepository records audit log entries in memory
+type fakeAuditLogRepository struct {
+	logs []*models.AuditLog
+}
+
+func (r *fakeAuditLogRepository) Create(ctx context.Context, log *models.AuditLog) error {
+	r.logs = append(r.logs, log)
+	return nil
+}
+
+func (r *fakeAuditLogRepository) List(ctx context.Context, tenantID int64, limit, offset int) ([]*models.AuditLog, error) {
+	return r.logs, nil
+}
+
+func (r *fakeAuditLogRepository) ListByUser(ctx context.Context, tenantID, userID int64, limit, offset int) ([]*models.AuditLog, error) {
+	return nil, nil
+}
+
+func (r *fakeAuditLogRepository) ListByEntity(ctx context.Context, tenantID int64, entityType string, entityID int64, limit, offset int) ([]*models.AuditLog, error) {
+	return nil, nil
+}
+
+func (r *fakeAuditLogRepository) Count(ctx context.Context, tenantID int64) (int, error) {
+	return len(r.logs), nil
+}
+
+func (r *fakeAuditLogRepository) DeleteOlderThan(ctx context.Context, tenantID int64, days int) error {
+	return nil
+}
+
+type scimFixture struct {
+	*ssoFixture
+	repo    *fakeSCIMRepository
+	audit   *fakeAuditLogRepository
+	service *scim.Service
+	owner   *models.User
+}
+
+func newSCIMFixture(t *testing.T) *scimFixture {
+	t.Helper()
+
+	f := &scimFixture{
+		ssoFixture: newSSOFixture(t, models.TierEnterprise),
+		repo:       newFakeSCIMRepository(),
+		audit:      &fakeAuditLogRepository{},
+	}
+	entitlements := entitlement.NewService(nil)
+	users := user.NewService(f.userRepo, f.tenantRepo, f.audit, nil, entitlements, nil, "https://app.example.com")
+	f.service = scim.NewService(f.repo, f.userRepo, users, f.ssoFixture.service, entitlements, "https://app.example.com/")
+
+	f.owner = &models.User{TenantID: f.tenant.ID, Email: "owner@example.com", Role: models.RoleOwner, IsActive: true}
+	f.userRepo.Create(context.Background(), f.owner)
+	return f
+}
+
+func (f *scimFixture) createUser(t *testing.T, userName string) *models.SCIMUser {
+	t.Helper()
+
+	created, err := f.service.CreateUser(context.Background(), f.tenant, &models.SCIMUser{
+		UserName: userName,
+		Name:     &models.SCIMName{GivenName: "Alice", FamilyName: "Smith"},
+	})
+	if err != nil {
+		t.Fatalf("CreateUser failed: %v", err)
+	}
+	return created
+}
+
+func (f *scimFixture) storedUser(t *testing.T, id string) *models.User {
+	t.Helper()
+
+	userID, _ := strconv.ParseInt(id, 10, 64)
+	u, err := f.userRepo.GetByID(context.Background(), userID)
+	if err != nil {
+		t.Fatalf("user %s not found: %v", id, err)
+	}
+	return u
+}
+
+func patchRequest(t *testing.T, operations ...string) *models.SCIMPatchRequest {
+	t.Helper()
+
+	patch := &models.SCIMPatchRequest{Schemas: []string{models.SCIMPatchOpSchema}}
+	for _, operation := range operations {
+		var op models.SCIMPatchOperation
+		if err := json.Unmarshal([]byte(operation), &op); err != nil {
+			t.Fatalf("invalid operation %s: %v", operation, err)
+		}
+		patch.Operations = append(patch.Operations, op)
+	}
+	return patch
+}
+
+func scimStatus(err error) int {
+	var scimErr *scim.Error
+	if stdErrors.As(err, &scimErr) {
+		return scimErr.Status
+	}
+	return 0
+}
+
+func TestSCIMCreateAndFindUser(t *testing.T) {
+	f := newSCIMFixture(t)
+	ctx := context.Background()
+
+	created := f.createUser(t, "Alice@Example.com")
+	if created.UserName != "alice@example.com" || created.Active == nil || !*created.Active {
+		t.Errorf("unexpected user resource: %+v", created)
+	}
+	if !strings.HasPrefix(created.Meta.Location, "https://app.example.com/api/v1/scim/v2/Users/") {
+		t.Errorf("unexpected location %q", created.Meta.Location)
+	}
+
+	stored := f.storedUser(t, created.ID)
+	if stored.Role != models.RoleMember || stored.FirstName == nil || *stored.FirstName != "Alice" {
+		t.Errorf("unexpected stored user: role=%s", stored.Role)
+	}
+	if len(f.audit.logs) != 1 || f.audit.logs[0].UserID != nil {
+		t.Errorf("expected one audit log without actor, got %d", len(f.audit.logs))
+	}
+
+	// Identity providers look up users by userName before creating them
+	list, err := f.service.ListUsers(ctx, f.tenant, `userName eq "ALICE@example.com"`, 1, 10)
+	if err != nil {
+		t.Fatalf("ListUsers failed: %v", err)
+	}
+	resources := list.Resources.([]*models.SCIMUser)
+	if list.TotalResults != 1 || len(resources) != 1 || resources[0].ID != created.ID {
+		t.Errorf("expected the created user, got %d results", list.TotalResults)
+	}
+
+	if _, err := f.service.CreateUser(ctx, f.tenant, &models.SCIMUser{UserName: "alice@example.com"}); scimStatus(err) != http.StatusConflict {
+		t.Errorf("expected conflict for a duplicate userName, got %v", err)
+	}
+	if _, err := f.service.CreateUser(ctx, f.tenant, &models.SCIMUser{UserName: "not an email"}); scimStatus(err) != http.StatusBadRequest {
+		t.Errorf("expected invalid userName to be rejected, got %v", err)
+	}
+	if _, err := f.service.ListUsers(ctx, f.tenant, `title co "gin"`, 1, 10); scimStatus(err) != http.StatusBadRequest {
+		t.Errorf("expected unsupported filter to be rejected, got %v", err)
+	}
+}
+
+func TestSCIMListUsersPaginates(t *testing.T) {
+	f := newSCIMFixture(t)
+	for i := 0; i < 4; i++ {
+		f.createUser(t, "user"+strconv.Itoa(i)+"@example.com")
+	}
+
+	list, err := f.service.ListUsers(context.Background(), f.tenant, "", 2, 2)
+	if err != nil {
+		t.Fatalf("ListUsers failed: %v", err)
+	}
+	resources := list.Resources.([]*models.SCIMUser)
+	if list.TotalResults != 5 || list.StartIndex != 2 || list.ItemsPerPage != 2 || len(resources) != 2 {
+		t.Fatalf("unexpected page: total=%d start=%d items=%d", list.TotalResults, list.StartIndex, list.ItemsPerPage)
+	}
+	if resources[0].UserName != "user0@example.com" {
+		t.Errorf("expected the page to start at the second user, got %s", resources[0].UserName)
+	}
+}
+
+func TestSCIMPatchUserDeactivates(t *testing.T) {
+	f := newSCIMFixture(t)
+	ctx := context.Background()
+	created := f.createUser(t, "alice@example.com")
+
+	// Azure AD sends booleans as strings
+	patched, err := f.service.PatchUser(ctx, f.tenant, created.ID, patchRequest(t,
+		`{"op": "Replace", "path": "active", "value": "False"}`,
+		`{"op": "replace", "path": "name.givenName", "value": "Alicia"}`,
+	))
+	if err != nil {
+		t.Fatalf("PatchUser failed: %v", err)
+	}
+	if *patched.Active {
+		t.Error("expected user to be deactivated")
+	}
+
+	stored := f.storedUser(t, created.ID)
+	if stored.IsActive || *stored.FirstName != "Alicia" || *stored.LastName != "Smith" {
+		t.Errorf("unexpected stored user: active=%v first=%s last=%s", stored.IsActive, *stored.FirstName, *stored.LastName)
+	}
+
+	// Okta sends the attributes without a path
+	if _, err := f.service.PatchUser(ctx, f.tenant, created.ID, patchRequest(t, `{"op": "replace", "value": {"active": true}}`)); err != nil {
+		t.Fatalf("PatchUser without path failed: %v", err)
+	}
+	if !f.storedUser(t, created.ID).IsActive {
+		t.Error("expected user to be reactivated")
+	}
+
+	if _, err := f.service.PatchUser(ctx, f.tenant, created.ID, patchRequest(t, `{"op": "remove"}`)); scimStatus(err) != http.StatusBadRequest {
+		t.Errorf("expected remove without path to be rejected, got %v", err)
+	}
+}
+
+func TestSCIMOwnerIsNotManaged(t *testing.T) {
+	f := newSCIMFixture(t)
+	ctx := context.Background()
+	ownerID := strconv.FormatInt(f.owner.ID, 10)
+
+	if _, err := f.service.PatchUser(ctx, f.tenant, ownerID, patchRequest(t, `{"op": "replace", "path": "active", "value": false}`)); scimStatus(err) != http.StatusForbidden {
+		t.Errorf("expected owner change to be forbidden, got %v", err)
+	}
+	if err := f.service.DeleteUser(ctx, f.tenant, ownerID); scimStatus(err) != http.StatusForbidden {
+		t.Errorf("expected owner deletion to be forbidden, got %v", err)
+	}
+	if !f.storedUser(t, ownerID).IsActive {
+		t.Error("expected owner to stay active")
+	}
+
+	// Users of other tenants don't exist for this tenant
+	other := &models.User{TenantID: 2, Email: "other@example.com", Role: models.RoleMember, IsActive: true}
+	f.userRepo.Create(ctx, other)
+	if _, err := f.service.GetUser(ctx, f.tenant, strconv.FormatInt(other.ID, 10)); err != errors.ErrNotFound {
+		t.Errorf("expected ErrNotFound for another tenant's user, got %v", err)
+	}
+}
+
+func TestSCIMDeleteUser(t *testing.T) {
+	f := newSCIMFixture(t)
+	ctx := context.Background()
+	created := f.createUser(t, "alice@example.com")
+
+	if err := f.service.DeleteUser(ctx, f.tenant, created.ID); err != nil {
+		t.Fatalf("DeleteUser failed: %v", err)
+	}
+	if _, err := f.service.GetUser(ctx, f.tenant, created.ID); err != errors.ErrNotFound {
+		t.Errorf("expected deleted user to be gone, got %v", err)
+	}
+}
+
+func TestSCIMGroupsMapRoles(t *testing.T) {
+	f := newSCIMFixture(t)
+	f.configure(t, f.oidcSettings())
+	ctx := context.Background()
+
+	alice := f.createUser(t, "alice@example.com")
+	bob := f.createUser(t, "bob@example.com")
+	ownerID := strconv.FormatInt(f.owner.ID, 10)
+
+	group, err := f.service.CreateGroup(ctx, f.tenant, &models.SCIMGroupResource{
+		DisplayName: "gin-admins",
+		Members:     []models.SCIMResource{{Value: alice.ID}, {Value: ownerID}},
+	})
+	if err != nil {
+		t.Fatalf("CreateGroup failed: %v", err)
+	}
+	if len(group.Members) != 2 {
+		t.Errorf("expected 2 members, got %d", len(group.Members))
+	}
+	if role := f.storedUser(t, alice.ID).Role; role != models.RoleAdmin {
+		t.Errorf("expected alice to become admin, got %s", role)
+	}
+	if role := f.storedUser(t, ownerID).Role; role != models.RoleOwner {
+		t.Errorf("expected owner to keep the role, got %s", role)
+	}
+
+	if _, err := f.service.CreateGroup(ctx, f.tenant, &models.SCIMGroupResource{DisplayName: "GIN-ADMINS"}); scimStatus(err) != http.StatusConflict {
+		t.Errorf("expected conflict for a duplicate displayName, got %v", err)
+	}
+	if _, err := f.service.CreateGroup(ctx, f.tenant, &models.SCIMGroupResource{DisplayName: "tasters", Members: []models.SCIMResource{{Value: "999"}}}); scimStatus(err) != http.StatusBadRequest {
+		t.Errorf("expected unknown member to be rejected, got %v", err)
+	}
+
+	// Moving alice out and bob in updates both roles
+	patched, err := f.service.PatchGroup(ctx, f.tenant, group.ID, patchRequest(t,
+		`{"op": "remove", "path": "members[value eq \"`+alice.ID+`\"]"}`,
+		`{"op": "add", "path": "members", "value": [{"value": "`+bob.ID+`"}]}`,
+	))
+	if err != nil {
+		t.Fatalf("PatchGroup failed: %v", err)
+	}
+	if len(patched.Members) != 2 {
+		t.Errorf("expected owner and bob as members, got %d", len(patched.Members))
+	}
+	if role := f.storedUser(t, alice.ID).Role; role != models.RoleMember {
+		t.Errorf("expected alice to fall back to the default role, got %s", role)
+	}
+	if role := f.storedUser(t, bob.ID).Role; role != models.RoleAdmin {
+		t.Errorf("expected bob to become admin, got %s", role)
+	}
+
+	resource, err := f.service.GetUser(ctx, f.tenant, bob.ID)
+	if err != nil {
+		t.Fatalf("GetUser failed: %v", err)
+	}
+	if len(resource.Groups) != 1 || resource.Groups[0].Display != "gin-admins" {
+		t.Errorf("expected bob's groups in the user resource, got %+v", resource.Groups)
+	}
+
+	if err := f.service.DeleteGroup(ctx, f.tenant, group.ID); err != nil {
+		t.Fatalf("DeleteGroup failed: %v", err)
+	}
+	if role := f.storedUser(t, bob.ID).Role; role != models.RoleMember {
+		t.Errorf("expected bob to lose the admin role with the group, got %s", role)
+	}
+}
+
+func TestSCIMGroupPatchPaths(t *testing.T) {
+	f := newSCIMFixture(t)
+	ctx := context.Background()
+	alice := f.createUser(t, "alice@example.com")
+
+	group, err := f.service.CreateGroup(ctx, f.tenant, &models.SCIMGroupResource{
+		DisplayName: "Tasters",
+		Members:     []models.SCIMResource{{Value: alice.ID}},
+	})
+	if err != nil {
+		t.Fatalf("CreateGroup failed: %v", err)
+	}
+
+	patched, err := f.service.PatchGroup(ctx, f.tenant, group.ID, patchRequest(t,
+		`{"op": "replace", "value": {"id": "`+group.ID+`", "displayName": "Senior Tasters"}}`,
+		`{"op": "add", "path": "externalId", "value": "grp-1"}`,
+	))
+	if err != nil {
+		t.Fatalf("PatchGroup failed: %v", err)
+	}
+	if patched.DisplayName != "Senior Tasters" || patched.ExternalID != "grp-1" || len(patched.Members) != 1 {
+		t.Errorf("unexpected group: %+v", patched)
+	}
+
+	list, err := f.service.ListGroups(ctx, f.tenant, `displayName eq "senior tasters"`, 1, 10)
+	if err != nil {
+		t.Fatalf("ListGroups failed: %v", err)
+	}
+	if list.TotalResults != 1 {
+		t.Errorf("expected the renamed group, got %d results", list.TotalResults)
+	}
+
+	// Removing members without a value empties the group
+	patched, err = f.service.PatchGroup(ctx, f.tenant, group.ID, patchRequest(t, `{"op": "remove", "path": "members"}`))
+	if err != nil {
+		t.Fatalf("PatchGroup failed: %v", err)
+	}
+	if len(patched.Members) != 0 {
+		t.Errorf("expected no members, got %d", len(patched.Members))
+	}
+
+	if _, err := f.service.PatchGroup(ctx, f.tenant, group.ID, patchRequest(t, `{"op": "add", "path": "owners", "value": []}`)); scimStatus(err) != http.StatusBadRequest {
+		t.Errorf("expected unsupported path to be rejected, got %v", err)
+	}
+}
+
+func TestSCIMTokenRequiresEnterprise(t *testing.T) {
+	f := newSCIMFixture(t)
+	ctx := context.Background()
+
+	value, token, err := f.service.GenerateToken(ctx, f.tenant, f.owner.ID)
+	if err != nil {
+		t.Fatalf("GenerateToken failed: %v", err)
+	}
+	if !strings.HasPrefix(value, models.SCIMTokenPrefix) || !strings.HasPrefix(value, token.TokenPrefix) || token.TokenHash == value {
+		t.Errorf("unexpected token %s (prefix %s)", value, token.TokenPrefix)
+	}
+
+	status, err := f.service.Status(ctx, f.tenant)
+	if err != nil || !status.Enabled {
+		t.Errorf("expected SCIM to be enabled, got %+v %v", status, err)
+	}
+
+	if err := f.service.RevokeToken(ctx, f.tenant, f.owner.ID); err != nil {
+		t.Fatalf("RevokeToken failed: %v", err)
+	}
+	if err := f.service.RevokeToken(ctx, f.tenant, f.owner.ID); err != errors.ErrNotFound {
+		t.Errorf("expected ErrNotFound without a token, got %v", err)
+	}
+
+	pro := &models.Tenant{ID: 3, Tier: models.TierPro, Status: models.TenantStatusActive}
+	if _, _, err := f.service.GenerateToken(ctx, pro, 1); !stdErrors.Is(err, errors.ErrFeatureNotAvailable) {
+		t.Errorf("expected ErrFeatureNotAvailable for Pro, got %v", err)
+	}
+
+	// Team management alone doesn't include SCIM provisioning
+	limits := models.PlanLimitsMap[models.TierEnterprise]
+	limits.HasSCIM = false
+	withoutSCIM := &models.Tenant{ID: 4, Tier: models.TierEnterprise, Status: models.TenantStatusActive, PlanLimits: &limits}
+	if _, _, err := f.service.GenerateToken(ctx, withoutSCIM, 1); !stdErrors.Is(err, errors.ErrFeatureNotAvailable) {
+		t.Errorf("expected ErrFeatureNotAvailable without SCIM in the plan, got %v", err)
+	}
+}
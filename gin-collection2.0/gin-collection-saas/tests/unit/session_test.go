@@ -0,0 +1,337 @@
+package unit
+
+import (
+	"context"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/yourusername/gin-collection-saas/internal/domain/errors"
+	"github.com/yourusername/gin-collection-saas/internal/domain/models"
+	"github.com/yourusername/gin-collection-saas/internal/usecase/auth"
+	"github.com/yourusername/gin-collection-saas/pkg/utils"
+)
+
+// fakeSessionRepository keeps sessions in memory
+type fakeSessionRepository struct {
+	nextID   int64
+	sessions map[int64]*models.Session
+}
+
+func newFakeSessionRepository() *fakeSessionRepository {
+	return &fakeSessionRepository{sessions: make(map[int64]*models.Session)}
+}
+
+func (r *fakeSessionRepository) Create(ctx context.Context, session *models.Session) error {
+	r.nextID++
+	session.ID = r.nextID
+	copied := *session
+	r.sessions[session.ID] = &copied
+	return nil
+}
+
+func (r *fakeSessionRepository) GetByUUID(ctx context.Context, uuid string) (*models.Session, error) {
+	for _, session := range r.sessions {
+		if session.UUID == uuid {
+			copied := *session
+			return &copied, nil
+		}
+	}
+	return nil, errors.ErrNotFound
+}
+
+func (r *fakeSessionRepository) ListActiveByUser(ctx context.Context, userID int64) ([]*models.Session, error) {
+	var sessions []*models.Session
+	for _, session := range r.sessions {
+		if session.UserID == userID && session.IsActive() {
+			copied := *session
+			sessions = append(sessions, &copied)
+		}
+	}
+	sort.Slice(sessions, func(i, j int) bool { return sessions[i].ID < sessions[j].ID })
+	return sessions, nil
+}
+
+func (r *fakeSessionRepository) Rotate(ctx context.Context, session *models.Session, previousTokenID string) error {
+	stored, ok := r.sessions[session.ID]
+	if !ok || stored.RefreshTokenID != previousTokenID || stored.RevokedAt != nil {
+		return errors.ErrNotFound
+	}
+	copied := *session
+	r.sessions[session.ID] = &copied
+	return nil
+}
+
+func (r *fakeSessionRepository) Revoke(ctx context.Context, id int64, reason string) error {
+	if session, ok := r.sessions[id]; ok && session.RevokedAt == nil {
+		now := time.Now()
+		session.RevokedAt = &now
+		session.RevokedReason = &reason
+	}
+	return nil
+}
+
+func (r *fakeSessionRepository) RevokeAllByUser(ctx context.Context, userID, exceptID int64, reason string) ([]string, error) {
+	var revoked []string
+	for _, session := range r.sessions {
+		if session.UserID == userID && session.ID != exceptID && session.IsActive() {
+			r.Revoke(ctx, session.ID, reason)
+			revoked = append(revoked, session.UUID)
+		}
+	}
+	return revoked, nil
+}
+
+func (r *fakeSessionRepository) DeleteExpired(ctx context.Context) (int64, error) {
+	var deleted int64
+	for id, session := range r.sessions {
+		if time.Now().After(session.ExpiresAt) {
+			delete(r.sessions, id)
+			deleted++
+		}
+	}
+	return deleted, nil
+}
+
+const (
+	sessionTestSecret   = "test-secret"
+	sessionTestPassword = "correct horse battery"
+	chromeOnMac         = "Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/122.0.0.0 Safari/537.36"
+	safariOnIPhone      = "Mozilla/5.0 (iPhone; CPU iPhone OS 17_3 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/17.3 Mobile/15E148 Safari/604.1"
+)
+
+type sessionFixture struct {
+	tenant   *models.Tenant
+	user     *models.User
+	userRepo *fakeUserRepository
+	repo     *fakeSessionRepository
+	service  *auth.Service
+}
+
+func newSessionFixture(t *testing.T) *sessionFixture {
+	t.Helper()
+
+	passwordHash, err := utils.HashPassword(sessionTestPassword)
+	if err != nil {
+		t.Fatalf("HashPassword failed: %v", err)
+	}
+
+	f := &sessionFixture{
+		tenant:   &models.Tenant{ID: 1, Name: "Acme", Subdomain: "acme", Tier: models.TierPro, Status: models.TenantStatusActive},
+		userRepo: newFakeUserRepository(),
+		repo:     newFakeSessionRepository(),
+	}
+	f.user = &models.User{TenantID: f.tenant.ID, Email: "alice@example.com", PasswordHash: passwordHash, Role: models.RoleAdmin, IsActive: true}
+	f.userRepo.Create(context.Background(), f.user)
+
+	f.service = auth.NewService(f.userRepo, newFakeTenantRepository(f.tenant), sessionTestSecret, time.Hour)
+	f.service.SetSessionRepo(f.repo)
+	return f
+}
+
+// login logs in from a device and returns the tokens
+func (f *sessionFixture) login(t *testing.T, ipAddress, userAgent string) *models.AuthResponse {
+	t.Helper()
+
+	ctx := auth.WithClientInfo(context.Background(), ipAddress, userAgent)
+	resp, err := f.service.Login(ctx, &models.LoginRequest{Email: f.user.Email, Password: sessionTestPassword}, f.tenant.ID)
+	if err != nil {
+		t.Fatalf("Login failed: %v", err)
+	}
+	return resp
+}
+
+func sessionClaims(t *testing.T, token string) *utils.JWTClaims {
+	t.Helper()
+
+	claims, err := utils.ValidateToken(token, sessionTestSecret)
+	if err != nil {
+		t.Fatalf("invalid token: %v", err)
+	}
+	return claims
+}
+
+func TestLoginStartsSession(t *testing.T) {
+	f := newSessionFixture(t)
+	resp := f.login(t, "203.0.113.7", chromeOnMac)
+
+	access := sessionClaims(t, resp.Token)
+	refresh := sessionClaims(t, resp.RefreshToken)
+	if access.SessionID == "" || access.SessionID != refresh.SessionID {
+		t.Fatalf("expected both tokens to carry the session, got %q and %q", access.SessionID, refresh.SessionID)
+	}
+	if access.Type == utils.TokenTypeRefresh || refresh.Type != utils.TokenTypeRefresh {
+		t.Errorf("unexpected token types %q and %q", access.Type, refresh.Type)
+	}
+
+	sessions, err := f.service.ListSessions(context.Background(), f.user.ID, access.SessionID)
+	if err != nil {
+		t.Fatalf("ListSessions failed: %v", err)
+	}
+	if len(sessions) != 1 {
+		t.Fatalf("expected 1 session, got %d", len(sessions))
+	}
+	session := sessions[0]
+	if session.UUID != access.SessionID || !session.Current || session.Device != "Chrome on macOS" {
+		t.Errorf("unexpected session: %+v", session)
+	}
+	if session.IPAddress == nil || *session.IPAddress != "203.0.113.7" || session.RefreshTokenID != refresh.ID {
+		t.Errorf("expected IP address and refresh token ID to be recorded")
+	}
+}
+
+func TestRefreshTokenRotates(t *testing.T) {
+	f := newSessionFixture(t)
+	resp := f.login(t, "203.0.113.7", chromeOnMac)
+	ctx := auth.WithClientInfo(context.Background(), "198.51.100.2", chromeOnMac)
+
+	access, rotated, err := f.service.RefreshToken(ctx, resp.RefreshToken)
+	if err != nil {
+		t.Fatalf("RefreshToken failed: %v", err)
+	}
+	if rotated == resp.RefreshToken {
+		t.Fatal("expected the refresh token to rotate")
+	}
+
+	claims := sessionClaims(t, access)
+	if claims.Role != string(models.RoleAdmin) || claims.SessionID != sessionClaims(t, resp.RefreshToken).SessionID {
+		t.Errorf("expected access token with role and session, got role %q session %q", claims.Role, claims.SessionID)
+	}
+
+	session, _ := f.repo.GetByUUID(ctx, claims.SessionID)
+	if session.RefreshTokenID != sessionClaims(t, rotated).ID || *session.IPAddress != "198.51.100.2" {
+		t.Errorf("expected the session to track the new token and IP address")
+	}
+
+	// The rotated token keeps working
+	if _, rotated, err = f.service.RefreshToken(ctx, rotated); err != nil {
+		t.Fatalf("second refresh failed: %v", err)
+	}
+
+	// Access tokens can't be used to refresh
+	if _, _, err := f.service.RefreshToken(ctx, access); err != errors.ErrInvalidToken {
+		t.Errorf("expected access token to be rejected, got %v", err)
+	}
+}
+
+func TestRefreshTokenReuseRevokesSession(t *testing.T) {
+	f := newSessionFixture(t)
+	resp := f.login(t, "203.0.113.7", chromeOnMac)
+	other := f.login(t, "203.0.113.8", safariOnIPhone)
+	ctx := context.Background()
+
+	// The legitimate client refreshes first
+	_, rotated, err := f.service.RefreshToken(ctx, resp.RefreshToken)
+	if err != nil {
+		t.Fatalf("RefreshToken failed: %v", err)
+	}
+
+	// The stolen copy of the old token is presented
+	if _, _, err := f.service.RefreshToken(ctx, resp.RefreshToken); err != errors.ErrInvalidToken {
+		t.Fatalf("expected reused token to be rejected, got %v", err)
+	}
+
+	session, _ := f.repo.GetByUUID(ctx, sessionClaims(t, resp.RefreshToken).SessionID)
+	if session.RevokedAt == nil || *session.RevokedReason != models.SessionRevokedRefreshTokenReuse {
+		t.Fatal("expected the session to be revoked")
+	}
+
+	// The whole family is gone, including the newest token
+	if _, _, err := f.service.RefreshToken(ctx, rotated); err != errors.ErrInvalidToken {
+		t.Errorf("expected rotated token of a revoked session to be rejected, got %v", err)
+	}
+
+	// Other sessions are not affected
+	if _, _, err := f.service.RefreshToken(ctx, other.RefreshToken); err != nil {
+		t.Errorf("expected other session to keep working, got %v", err)
+	}
+}
+
+func TestRevokeSessions(t *testing.T) {
+	f := newSessionFixture(t)
+	current := f.login(t, "203.0.113.7", chromeOnMac)
+	phone := f.login(t, "203.0.113.8", safariOnIPhone)
+	f.login(t, "203.0.113.9", "curl/8.4.0")
+	ctx := context.Background()
+	currentID := sessionClaims(t, current.Token).SessionID
+
+	// Sessions of other users can't be revoked
+	if err := f.service.RevokeSession(ctx, f.user.ID+1, sessionClaims(t, phone.Token).SessionID); err != errors.ErrNotFound {
+		t.Errorf("expected ErrNotFound for another user's session, got %v", err)
+	}
+
+	if err := f.service.RevokeSession(ctx, f.user.ID, sessionClaims(t, phone.Token).SessionID); err != nil {
+		t.Fatalf("RevokeSession failed: %v", err)
+	}
+	if _, _, err := f.service.RefreshToken(ctx, phone.RefreshToken); err != errors.ErrInvalidToken {
+		t.Errorf("expected refresh of revoked session to fail, got %v", err)
+	}
+
+	count, err := f.service.RevokeOtherSessions(ctx, f.user.ID, currentID)
+	if err != nil {
+		t.Fatalf("RevokeOtherSessions failed: %v", err)
+	}
+	if count != 1 {
+		t.Errorf("expected the curl session to be revoked, got %d", count)
+	}
+
+	sessions, _ := f.service.ListSessions(ctx, f.user.ID, currentID)
+	if len(sessions) != 1 || sessions[0].UUID != currentID {
+		t.Fatalf("expected only the current session to remain, got %d", len(sessions))
+	}
+
+	if err := f.service.Logout(ctx, f.user.ID, currentID); err != nil {
+		t.Fatalf("Logout failed: %v", err)
+	}
+	if _, _, err := f.service.RefreshToken(ctx, current.RefreshToken); err != errors.ErrInvalidToken {
+		t.Errorf("expected refresh after logout to fail, got %v", err)
+	}
+}
+
+func TestRefreshTokenRequiresActiveUser(t *testing.T) {
+	f := newSessionFixture(t)
+	resp := f.login(t, "203.0.113.7", chromeOnMac)
+
+	f.userRepo.users[f.user.ID].IsActive = false
+	if _, _, err := f.service.RefreshToken(context.Background(), resp.RefreshToken); err != errors.ErrInvalidToken {
+		t.Errorf("expected deactivated user to be rejected, got %v", err)
+	}
+}
+
+func TestStatelessRefreshWithoutSessions(t *testing.T) {
+	f := newSessionFixture(t)
+	f.service = auth.NewService(f.userRepo, newFakeTenantRepository(f.tenant), sessionTestSecret, time.Hour)
+	resp := f.login(t, "", "")
+
+	access, refresh, err := f.service.RefreshToken(context.Background(), resp.RefreshToken)
+	if err != nil {
+		t.Fatalf("RefreshToken failed: %v", err)
+	}
+	if refresh != resp.RefreshToken {
+		t.Error("expected the refresh token to stay without sessions")
+	}
+	if claims := sessionClaims(t, access); claims.SessionID != "" || claims.Role != string(models.RoleAdmin) {
+		t.Errorf("unexpected claims: session %q role %q", claims.SessionID, claims.Role)
+	}
+}
+
+func TestDescribeUserAgent(t *testing.T) {
+	tests := []struct {
+		userAgent string
+		want      string
+	}{
+		{chromeOnMac, "Chrome on macOS"},
+		{safariOnIPhone, "Safari on iOS"},
+		{"Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/122.0.0.0 Safari/537.36 Edg/122.0.2365.66", "Edge on Windows"},
+		{"Mozilla/5.0 (X11; Linux x86_64; rv:123.0) Gecko/20100101 Firefox/123.0", "Firefox on Linux"},
+		{"Mozilla/5.0 (Linux; Android 14; Pixel 8) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/122.0.0.0 Mobile Safari/537.36", "Chrome on Android"},
+		{"curl/8.4.0", "curl"},
+		{"", "Unknown device"},
+	}
+
+	for _, tt := range tests {
+		if got := utils.DescribeUserAgent(tt.userAgent); got != tt.want {
+			t.Errorf("DescribeUserAgent(%q) = %q, want %q", tt.userAgent, got, tt.want)
+		}
+	}
+}
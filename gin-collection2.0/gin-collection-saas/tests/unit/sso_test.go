@@ -0,0 +1,1221 @@
+package unit
+
+import (
+	"bytes"
+	"compress/flate"
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	stdErrors "errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"regexp"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/beevik/etree"
+	"github.com/golang-jwt/jwt/v5"
+	dsig "github.com/russellhaering/goxmldsig"
+	"github.com/yourusername/gin-collection-saas/internal/domain/errors"
+	"github.com/yourusername/gin-collection-saas/internal/domain/models"
+	"github.com/yourusername/gin-collection-saas/internal/infrastructure/external"
+	"github.com/yourusername/gin-collection-saas/internal/usecase/auth"
+	"github.com/yourusername/gin-collection-saas/internal/usecase/entitlement"
+	"github.com/yourusername/gin-collection-saas/internal/usecase/sso"
+	"github.com/yourusername/gin-collection-saas/pkg/utils"
+)
+
+// fakeTenantRepository keeps tenants and their settings documents in memory
+type fakeTenantRepository struct {
+	tenants  map[int64]*models.Tenant
+	settings map[int64]json.RawMessage
+}
+
+func newFakeTenantRepository(tenants ...*models.Tenant) *fakeTenantRepository {
+	repo := &fakeTenantRepository{
+		tenants:  make(map[int64]*models.Tenant),
+		settings: make(map[int64]json.RawMessage),
+	}
+	for _, tenant := range tenants {
+		repo.tenants[tenant.ID] = tenant
+	}
+	return repo
+}
+
+func (r *fakeTenantRepository) Create(ctx context.Context, tenant *models.Tenant) error {
+	tenant.ID = int64(len(r.tenants) + 1)
+	r.tenants[tenant.ID] = tenant
+	return nil
+}
+
+func (r *fakeTenantRepository) GetByID(ctx context.Context, id int64) (*models.Tenant, error) {
+	tenant, ok := r.tenants[id]
+	if !ok {
+		return nil, errors.ErrTenantNotFound
+	}
+	copied := *tenant
+	return &copied, nil
+}
+
+func (r *fakeTenantRepository) GetBySubdomain(ctx context.Context, subdomain string) (*models.Tenant, error) {
+	for _, tenant := range r.tenants {
+		if tenant.Subdomain == subdomain {
+			copied := *tenant
+			return &copied, nil
+		}
+	}
+	return nil, errors.ErrTenantNotFound
+}
+
+func (r *fakeTenantRepository) GetByUUID(ctx context.Context, uuid string) (*models.Tenant, error) {
+	for _, tenant := range r.tenants {
+		if tenant.UUID == uuid {
+			copied := *tenant
+			return &copied, nil
+		}
+	}
+	return nil, errors.ErrTenantNotFound
+}
+
+func (r *fakeTenantRepository) Update(ctx context.Context, tenant *models.Tenant) error {
+	r.tenants[tenant.ID] = tenant
+	return nil
+}
+
+func (r *fakeTenantRepository) UpdateStatus(ctx context.Context, id int64, status models.TenantStatus) error {
+	r.tenants[id].Status = status
+	return nil
+}
+
+func (r *fakeTenantRepository) UpdateTier(ctx context.Context, id int64, tier models.SubscriptionTier) error {
+	r.tenants[id].Tier = tier
+	return nil
+}
+
+func (r *fakeTenantRepository) UpdateRequireTwoFactor(ctx context.Context, id int64, required bool) error {
+	r.tenants[id].RequireTwoFactor = required
+	return nil
+}
+
+func (r *fakeTenantRepository) UpdateBillingRestricted(ctx context.Context, id int64, restricted bool) error {
+	r.tenants[id].BillingRestricted = restricted
+	return nil
+}
+
+func (r *fakeTenantRepository) UpdateLimitOverrides(ctx context.Context, id int64, overrides *models.LimitOverrides) error {
+	r.tenants[id].LimitOverrides = overrides
+	return nil
+}
+
+func (r *fakeTenantRepository) GetSettings(ctx context.Context, id int64) (json.RawMessage, error) {
+	if _, ok := r.tenants[id]; !ok {
+		return nil, errors.ErrTenantNotFound
+	}
+	return r.settings[id], nil
+}
+
+func (r *fakeTenantRepository) UpdateSettings(ctx context.Context, id int64, settings json.RawMessage) error {
+	r.settings[id] = settings
+	return nil
+}
+
+func (r *fakeTenantRepository) ListIDs(ctx context.Context) ([]int64, error) {
+	ids := make([]int64, 0, len(r.tenants))
+	for id := range r.tenants {
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
+
+// fakeUserRepository keeps users in memory
+type fakeUserRepository struct {
+	nextID int64
+	users  map[int64]*models.User
+}
+
+func newFakeUserRepository() *fakeUserRepository {
+	return &fakeUserRepository{users: make(map[int64]*models.User)}
+}
+
+func (r *fakeUserRepository) Create(ctx context.Context, user *models.User) error {
+	r.nextID++
+	user.ID = r.nextID
+	copied := *user
+	r.users[user.ID] = &copied
+	return nil
+}
+
+func (r *fakeUserRepository) GetByID(ctx context.Context, id int64) (*models.User, error) {
+	user, ok := r.users[id]
+	if !ok {
+		return nil, errors.ErrNotFound
+	}
+	copied := *user
+	return &copied, nil
+}
+
+func (r *fakeUserRepository) GetByEmail(ctx context.Context, tenantID int64, email string) (*models.User, error) {
+	for _, user := range r.users {
+		if user.TenantID == tenantID && strings.EqualFold(user.Email, email) {
+			copied := *user
+			return &copied, nil
+		}
+	}
+	return nil, errors.ErrNotFound
+}
+
+func (r *fakeUserRepository) GetByEmailGlobal(ctx context.Context, email string) (*models.User, error) {
+	for _, user := range r.users {
+		if strings.EqualFold(user.Email, email) {
+			copied := *user
+			return &copied, nil
+		}
+	}
+	return nil, errors.ErrNotFound
+}
+
+func (r *fakeUserRepository) Update(ctx context.Context, user *models.User) error {
+	if _, ok := r.users[user.ID]; !ok {
+		return errors.ErrNotFound
+	}
+	copied := *user
+	r.users[user.ID] = &copied
+	return nil
+}
+
+func (r *fakeUserRepository) UpdateLastLogin(ctx context.Context, id int64) error {
+	return nil
+}
+
+func (r *fakeUserRepository) List(ctx context.Context, tenantID int64) ([]*models.User, error) {
+	var users []*models.User
+	for _, user := range r.users {
+		if user.TenantID == tenantID {
+			copied := *user
+			users = append(users, &copied)
+		}
+	}
+	return users, nil
+}
+
+func (r *fakeUserRepository) Delete(ctx context.Context, id int64) error {
+	delete(r.users, id)
+	return nil
+}
+
+func (r *fakeUserRepository) CountByTenant(ctx context.Context, tenantID int64) (int, error) {
+	users, _ := r.List(ctx, tenantID)
+	return len(users), nil
+}
+
+// fakeSSORepository keeps login states and identity links in memory
+type fakeSSORepository struct {
+	states     map[string]*models.SSOLoginState
+	identities []*models.SSOIdentity
+}
+
+func newFakeSSORepository() *fakeSSORepository {
+	return &fakeSSORepository{states: make(map[string]*models.SSOLoginState)}
+}
+
+func (r *fakeSSORepository) CreateLoginState(ctx context.Context, state *models.SSOLoginState) error {
+	copied := *state
+	r.states[state.ID] = &copied
+	return nil
+}
+
+func (r *fakeSSORepository) ConsumeLoginState(ctx context.Context, id string) (*models.SSOLoginState, error) {
+	state, ok := r.states[id]
+	if !ok {
+		return nil, errors.ErrNotFound
+	}
+	delete(r.states, id)
+	return state, nil
+}
+
+func (r *fakeSSORepository) DeleteExpiredLoginStates(ctx context.Context) (int64, error) {
+	var deleted int64
+	for id, state := range r.states {
+		if state.IsExpired() {
+			delete(r.states, id)
+			deleted++
+		}
+	}
+	return deleted, nil
+}
+
+func (r *fakeSSORepository) GetIdentity(ctx context.Context, tenantID int64, protocol, subject string) (*models.SSOIdentity, error) {
+	for _, identity := range r.identities {
+		if identity.TenantID == tenantID && identity.Protocol == protocol && identity.Subject == subject {
+			copied := *identity
+			return &copied, nil
+		}
+	}
+	return nil, errors.ErrNotFound
+}
+
+func (r *fakeSSORepository) CreateIdentity(ctx context.Context, identity *models.SSOIdentity) error {
+	identity.ID = int64(len(r.identities) + 1)
+	copied := *identity
+	r.identities = append(r.identities, &copied)
+	return nil
+}
+
+// stubIdP is an identity provider speaking OpenID Connect and SAML, signing
+// with a throwaway key
+type stubIdP struct {
+	server       *httptest.Server
+	key          *rsa.PrivateKey
+	certPEM      string
+	clientID     string
+	clientSecret string
+
+	mu             sync.Mutex
+	authorizations map[string]stubAuthorization
+	nonceOverride  string
+}
+
+// stubAuthorization is an authorization code waiting to be redeemed
+type stubAuthorization struct {
+	nonce         string
+	codeChallenge string
+	redirectURI   string
+	claims        jwt.MapClaims
+}
+
+func newStubIdP(t *testing.T) *stubIdP {
+	t.Helper()
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("failed to generate key: %v", err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "stub-idp"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(24 * time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("failed to create certificate: %v", err)
+	}
+
+	idp := &stubIdP{
+		key:            key,
+		certPEM:        string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})),
+		clientID:       "gin-collection",
+		clientSecret:   "client-secret",
+		authorizations: make(map[string]stubAuthorization),
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]string{
+			"issuer":                 idp.server.URL,
+			"authorization_endpoint": idp.server.URL + "/authorize",
+			"token_endpoint":         idp.server.URL + "/token",
+			"jwks_uri":               idp.server.URL + "/jwks",
+		})
+	})
+	mux.HandleFunc("/jwks", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"keys": []map[string]string{{
+				"kty": "RSA",
+				"kid": "stub-key",
+				"use": "sig",
+				"n":   base64.RawURLEncoding.EncodeToString(key.PublicKey.N.Bytes()),
+				"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.PublicKey.E)).Bytes()),
+			}},
+		})
+	})
+	mux.HandleFunc("/token", idp.token)
+
+	idp.server = httptest.NewServer(mux)
+	t.Cleanup(idp.server.Close)
+
+	return idp
+}
+
+// token redeems an authorization code like a real token endpoint would
+func (idp *stubIdP) token(w http.ResponseWriter, r *http.Request) {
+	tokenError := func(code string) {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": code})
+	}
+
+	clientID, clientSecret, ok := r.BasicAuth()
+	if r.Method != http.MethodPost || !ok || clientID != idp.clientID || clientSecret != idp.clientSecret {
+		tokenError("invalid_client")
+		return
+	}
+	if r.PostFormValue("grant_type") != "authorization_code" {
+		tokenError("unsupported_grant_type")
+		return
+	}
+
+	idp.mu.Lock()
+	authorization, ok := idp.authorizations[r.PostFormValue("code")]
+	delete(idp.authorizations, r.PostFormValue("code"))
+	nonce := authorization.nonce
+	if idp.nonceOverride != "" {
+		nonce = idp.nonceOverride
+	}
+	idp.mu.Unlock()
+
+	if !ok || authorization.redirectURI != r.PostFormValue("redirect_uri") ||
+		external.PKCEChallenge(r.PostFormValue("code_verifier")) != authorization.codeChallenge {
+		tokenError("invalid_grant")
+		return
+	}
+
+	claims := jwt.MapClaims{
+		"iss":   idp.server.URL,
+		"aud":   idp.clientID,
+		"iat":   time.Now().Unix(),
+		"exp":   time.Now().Add(5 * time.Minute).Unix(),
+		"nonce": nonce,
+	}
+	for name, value := range authorization.claims {
+		claims[name] = value
+	}
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	token.Header["kid"] = "stub-key"
+	idToken, err := token.SignedString(idp.key)
+	if err != nil {
+		tokenError("server_error")
+		return
+	}
+
+	json.NewEncoder(w).Encode(map[string]string{
+		"access_token": "access-token",
+		"token_type":   "Bearer",
+		"id_token":     idToken,
+	})
+}
+
+// authorize plays the user signing in at the identity provider and returns
+// the code and state the browser brings back to the callback
+func (idp *stubIdP) authorize(t *testing.T, loginURL string, claims jwt.MapClaims) (string, string) {
+	t.Helper()
+
+	u, err := url.Parse(loginURL)
+	if err != nil {
+		t.Fatalf("invalid login URL: %v", err)
+	}
+	query := u.Query()
+	if u.Path != "/authorize" || query.Get("response_type") != "code" || query.Get("client_id") != idp.clientID {
+		t.Fatalf("unexpected authorization request: %s", loginURL)
+	}
+	if query.Get("code_challenge_method") != "S256" || query.Get("code_challenge") == "" {
+		t.Fatalf("authorization request without PKCE: %s", loginURL)
+	}
+	if !strings.Contains(" "+query.Get("scope")+" ", " openid ") {
+		t.Fatalf("authorization request without openid scope: %s", loginURL)
+	}
+
+	code := hex.EncodeToString(randomBytes(t, 16))
+	idp.mu.Lock()
+	idp.authorizations[code] = stubAuthorization{
+		nonce:         query.Get("nonce"),
+		codeChallenge: query.Get("code_challenge"),
+		redirectURI:   query.Get("redirect_uri"),
+		claims:        claims,
+	}
+	idp.mu.Unlock()
+
+	return code, query.Get("state")
+}
+
+// samlEntityID is the entity ID of the stub identity provider
+func (idp *stubIdP) samlEntityID() string {
+	return idp.server.URL + "/saml"
+}
+
+// samlRequest is the part of an AuthnRequest the stub identity provider answers to
+type samlRequest struct {
+	id         string
+	acsURL     string
+	spEntityID string
+	relayState string
+}
+
+// readSAMLRequest decodes the AuthnRequest of an HTTP-Redirect binding URL
+func readSAMLRequest(t *testing.T, loginURL string) samlRequest {
+	t.Helper()
+
+	u, err := url.Parse(loginURL)
+	if err != nil {
+		t.Fatalf("invalid login URL: %v", err)
+	}
+	compressed, err := base64.StdEncoding.DecodeString(u.Query().Get("SAMLRequest"))
+	if err != nil {
+		t.Fatalf("invalid SAMLRequest encoding: %v", err)
+	}
+	data, err := io.ReadAll(flate.NewReader(bytes.NewReader(compressed)))
+	if err != nil {
+		t.Fatalf("invalid SAMLRequest compression: %v", err)
+	}
+	doc := etree.NewDocument()
+	if err := doc.ReadFromBytes(data); err != nil {
+		t.Fatalf("invalid SAMLRequest: %v", err)
+	}
+	request := doc.Root()
+	if request.NamespaceURI() != external.SAMLProtocolNamespace || request.Tag != "AuthnRequest" {
+		t.Fatalf("unexpected SAMLRequest: %s", data)
+	}
+	issuer := request.SelectElement("Issuer")
+	if issuer == nil || issuer.NamespaceURI() != external.SAMLAssertionNamespace {
+		t.Fatalf("SAMLRequest without issuer: %s", data)
+	}
+
+	return samlRequest{
+		id:         request.SelectAttrValue("ID", ""),
+		acsURL:     request.SelectAttrValue("AssertionConsumerServiceURL", ""),
+		spEntityID: issuer.Text(),
+		relayState: u.Query().Get("RelayState"),
+	}
+}
+
+// samlOptions changes the response of the stub identity provider
+type samlOptions struct {
+	nameID         string
+	nameIDFormat   string
+	groups         []string
+	audience       string
+	inResponseTo   string
+	signResponse   bool
+	signAssertion  bool
+	wrapAssertion  bool // hide the signed assertion in an unsigned one
+	wrapKeepID     bool // the unsigned assertion takes the ID of the signed one
+	tamperAfterSig func(string) string
+}
+
+// samlResponse answers an AuthnRequest and returns the base64 encoded response
+func (idp *stubIdP) samlResponse(t *testing.T, request samlRequest, opts samlOptions) string {
+	t.Helper()
+
+	now := time.Now().UTC()
+	instant := now.Format(time.RFC3339)
+	audience := request.spEntityID
+	if opts.audience != "" {
+		audience = opts.audience
+	}
+	inResponseTo := request.id
+	if opts.inResponseTo != "" {
+		inResponseTo = opts.inResponseTo
+	}
+	nameIDFormat := external.SAMLNameIDEmail
+	if opts.nameIDFormat != "" {
+		nameIDFormat = opts.nameIDFormat
+	}
+
+	var groups strings.Builder
+	for _, group := range opts.groups {
+		groups.WriteString(`<saml:AttributeValue xsi:type="xs:string">` + group + `</saml:AttributeValue>`)
+	}
+
+	assertionID := "_a" + hex.EncodeToString(randomBytes(t, 8))
+	assertion := fmt.Sprintf(
+		`<saml:Assertion xmlns:saml="%s" xmlns:xs="http://www.w3.org/2001/XMLSchema" xmlns:xsi="http://www.w3.org/2001/XMLSchema-instance" ID="%s" Version="2.0" IssueInstant="%s">`+
+			`<saml:Issuer>%s</saml:Issuer><!--signature:%s-->`+
+			`<saml:Subject><saml:NameID Format="%s">%s</saml:NameID>`+
+			`<saml:SubjectConfirmation Method="%s"><saml:SubjectConfirmationData InResponseTo="%s" NotOnOrAfter="%s" Recipient="%s"/></saml:SubjectConfirmation></saml:Subject>`+
+			`<saml:Conditions NotBefore="%s" NotOnOrAfter="%s"><saml:AudienceRestriction><saml:Audience>%s</saml:Audience></saml:AudienceRestriction></saml:Conditions>`+
+			`<saml:AuthnStatement AuthnInstant="%s"><saml:AuthnContext><saml:AuthnContextClassRef>urn:oasis:names:tc:SAML:2.0:ac:classes:PasswordProtectedTransport</saml:AuthnContextClassRef></saml:AuthnContext></saml:AuthnStatement>`+
+			`<saml:AttributeStatement>`+
+			`<saml:Attribute Name="email"><saml:AttributeValue xsi:type="xs:string">%s</saml:AttributeValue></saml:Attribute>`+
+			`<saml:Attribute Name="firstName"><saml:AttributeValue xsi:type="xs:string">Alice</saml:AttributeValue></saml:Attribute>`+
+			`<saml:Attribute Name="groups">%s</saml:Attribute>`+
+			`</saml:AttributeStatement></saml:Assertion>`,
+		external.SAMLAssertionNamespace, assertionID, instant,
+		idp.samlEntityID(), assertionID,
+		nameIDFormat, opts.nameID,
+		external.SAMLBearerMethod, inResponseTo, now.Add(5*time.Minute).Format(time.RFC3339), request.acsURL,
+		now.Add(-time.Minute).Format(time.RFC3339), now.Add(5*time.Minute).Format(time.RFC3339), audience,
+		instant,
+		opts.nameID,
+		groups.String(),
+	)
+	if opts.signAssertion {
+		assertion = idp.signSAML(t, assertion, assertionID)
+	}
+
+	if opts.wrapAssertion {
+		// The evil assertion carries the signed one as advice, so it is in the
+		// document but not where it is read from
+		evilID := "_evil"
+		if opts.wrapKeepID {
+			evilID = assertionID
+		}
+		assertion = fmt.Sprintf(
+			`<saml:Assertion xmlns:saml="%s" ID="%s" Version="2.0" IssueInstant="%s"><saml:Issuer>%s</saml:Issuer>`+
+				`<saml:Subject><saml:NameID Format="%s">mallory@example.com</saml:NameID></saml:Subject>`+
+				`<saml:Advice>%s</saml:Advice></saml:Assertion>`,
+			external.SAMLAssertionNamespace, evilID, instant, idp.samlEntityID(), external.SAMLNameIDEmail, assertion,
+		)
+	}
+
+	responseID := "_r" + hex.EncodeToString(randomBytes(t, 8))
+	response := fmt.Sprintf(
+		`<samlp:Response xmlns:samlp="%s" xmlns:saml="%s" ID="%s" Version="2.0" IssueInstant="%s" Destination="%s" InResponseTo="%s">`+
+			`<saml:Issuer>%s</saml:Issuer><!--signature:%s-->`+
+			`<samlp:Status><samlp:StatusCode Value="%s"/></samlp:Status>%s</samlp:Response>`,
+		external.SAMLProtocolNamespace, external.SAMLAssertionNamespace, responseID, instant, request.acsURL, inResponseTo,
+		idp.samlEntityID(), responseID,
+		external.SAMLStatusSuccess, assertion,
+	)
+	if opts.signResponse {
+		response = idp.signSAML(t, response, responseID)
+	}
+	if opts.tamperAfterSig != nil {
+		response = opts.tamperAfterSig(response)
+	}
+
+	return base64.StdEncoding.EncodeToString([]byte(response))
+}
+
+// signSAML puts an enveloped signature of the element with the given ID where
+// the document has its signature placeholder
+func (idp *stubIdP) signSAML(t *testing.T, document, id string) string {
+	t.Helper()
+
+	// Comments are not part of the canonical form, the placeholder doesn't count
+	doc := etree.NewDocument()
+	if err := doc.ReadFromString(document); err != nil {
+		t.Fatalf("invalid document: %v", err)
+	}
+	element := doc.FindElement("//[@ID='" + id + "']")
+	if element == nil {
+		t.Fatalf("element %s not found", id)
+	}
+
+	block, _ := pem.Decode([]byte(idp.certPEM))
+	signer, err := dsig.NewSigningContext(idp.key, [][]byte{block.Bytes})
+	if err != nil {
+		t.Fatalf("NewSigningContext failed: %v", err)
+	}
+	signer.Canonicalizer = dsig.MakeC14N10ExclusiveCanonicalizerWithPrefixList("")
+	signature, err := signer.ConstructSignature(element, true)
+	if err != nil {
+		t.Fatalf("failed to sign: %v", err)
+	}
+
+	signatureDoc := etree.NewDocument()
+	signatureDoc.SetRoot(signature)
+	signatureXML, err := signatureDoc.WriteToString()
+	if err != nil {
+		t.Fatalf("failed to serialize signature: %v", err)
+	}
+
+	return strings.Replace(document, "<!--signature:"+id+"-->", signatureXML, 1)
+}
+
+// samlSignaturePattern matches the enveloped signatures of a response
+var samlSignaturePattern = regexp.MustCompile(`<ds:Signature .*?</ds:Signature>`)
+
+// wrapSAMLResponse hides a signed response in an unsigned copy of it that
+// names another user
+func wrapSAMLResponse(response string) string {
+	evil := strings.ReplaceAll(samlSignaturePattern.ReplaceAllString(response, ""), "alice@example.com", "mallory@example.com")
+	return strings.Replace(evil, "</saml:Issuer>", "</saml:Issuer><samlp:Extensions>"+response+"</samlp:Extensions>", 1)
+}
+
+// ssoFixture is an Enterprise tenant with SSO against the stub identity provider
+type ssoFixture struct {
+	idp        *stubIdP
+	tenant     *models.Tenant
+	tenantRepo *fakeTenantRepository
+	userRepo   *fakeUserRepository
+	repo       *fakeSSORepository
+	service    *sso.Service
+}
+
+func newSSOFixture(t *testing.T, tier models.SubscriptionTier) *ssoFixture {
+	t.Helper()
+
+	tenant := &models.Tenant{ID: 1, Name: "Acme", Subdomain: "acme", Tier: tier, Status: models.TenantStatusActive}
+	f := &ssoFixture{
+		idp:        newStubIdP(t),
+		tenant:     tenant,
+		tenantRepo: newFakeTenantRepository(tenant),
+		userRepo:   newFakeUserRepository(),
+		repo:       newFakeSSORepository(),
+	}
+	f.service = sso.NewService(f.tenantRepo, f.userRepo, f.repo, entitlement.NewService(nil), external.NewOIDCClient(nil), "https://app.example.com/")
+	return f
+}
+
+func (f *ssoFixture) configure(t *testing.T, settings models.SSOSettings) {
+	t.Helper()
+
+	if err := settings.Validate(); err != nil {
+		t.Fatalf("invalid settings: %v", err)
+	}
+	if _, err := f.service.UpdateSettings(context.Background(), f.tenant, &settings); err != nil {
+		t.Fatalf("failed to update settings: %v", err)
+	}
+}
+
+func (f *ssoFixture) oidcSettings() models.SSOSettings {
+	return models.SSOSettings{
+		Enabled:         true,
+		Protocol:        models.SSOProtocolOIDC,
+		JITProvisioning: true,
+		RoleMapping: map[string]models.UserRole{
+			"gin-admins": models.RoleAdmin,
+			"tasters":    models.RoleViewer,
+		},
+		OIDC: &models.OIDCSettings{
+			Issuer:       f.idp.server.URL,
+			ClientID:     f.idp.clientID,
+			ClientSecret: f.idp.clientSecret,
+		},
+	}
+}
+
+func (f *ssoFixture) samlSettings() models.SSOSettings {
+	return models.SSOSettings{
+		Enabled:         true,
+		Protocol:        models.SSOProtocolSAML,
+		JITProvisioning: true,
+		RoleMapping:     map[string]models.UserRole{"gin-admins": models.RoleAdmin},
+		SAML: &models.SAMLSettings{
+			IdPEntityID:    f.idp.samlEntityID(),
+			IdPSSOURL:      f.idp.server.URL + "/saml/sso",
+			IdPCertificate: f.idp.certPEM,
+		},
+	}
+}
+
+// oidcLogin runs a complete OpenID Connect login and resolves the user
+func (f *ssoFixture) oidcLogin(t *testing.T, claims jwt.MapClaims) (*models.User, error) {
+	t.Helper()
+
+	ctx := context.Background()
+	loginURL, state, err := f.service.BeginLogin(ctx, "acme", "/")
+	if err != nil {
+		t.Fatalf("BeginLogin failed: %v", err)
+	}
+	code, returnedState := f.idp.authorize(t, loginURL, claims)
+	if returnedState != state {
+		t.Fatalf("state not passed to the identity provider")
+	}
+
+	tenant, identity, err := f.service.CompleteOIDC(ctx, "acme", returnedState, code)
+	if err != nil {
+		return nil, err
+	}
+	return f.service.ResolveUser(ctx, tenant, identity)
+}
+
+func TestSSOSettingsValidate(t *testing.T) {
+	tests := []struct {
+		name     string
+		settings models.SSOSettings
+		valid    bool
+	}{
+		{
+			name:     "disabled and empty",
+			settings: models.SSOSettings{},
+			valid:    true,
+		},
+		{
+			name:     "password login disabled without sso",
+			settings: models.SSOSettings{DisablePasswordLogin: true},
+		},
+		{
+			name: "owner can't be mapped",
+			settings: models.SSOSettings{
+				RoleMapping: map[string]models.UserRole{"founders": models.RoleOwner},
+			},
+		},
+		{
+			name: "oidc issuer over http",
+			settings: models.SSOSettings{
+				Enabled:  true,
+				Protocol: models.SSOProtocolOIDC,
+				OIDC:     &models.OIDCSettings{Issuer: "http://idp.example.com", ClientID: "app"},
+			},
+		},
+		{
+			name: "oidc",
+			settings: models.SSOSettings{
+				Enabled:  true,
+				Protocol: models.SSOProtocolOIDC,
+				OIDC:     &models.OIDCSettings{Issuer: "https://idp.example.com", ClientID: "app"},
+			},
+			valid: true,
+		},
+		{
+			name: "saml without certificate",
+			settings: models.SSOSettings{
+				Enabled:  true,
+				Protocol: models.SSOProtocolSAML,
+				SAML:     &models.SAMLSettings{IdPEntityID: "https://idp.example.com", IdPSSOURL: "https://idp.example.com/sso"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.settings.Validate()
+			if tt.valid && err != nil {
+				t.Errorf("expected valid settings, got %v", err)
+			}
+			if !tt.valid && err == nil {
+				t.Error("expected validation error")
+			}
+		})
+	}
+}
+
+func TestSSOSettingsMapRole(t *testing.T) {
+	settings := models.SSOSettings{
+		DefaultRole: models.RoleViewer,
+		RoleMapping: map[string]models.UserRole{
+			"gin-admins": models.RoleAdmin,
+			"staff":      models.RoleMember,
+		},
+	}
+
+	tests := []struct {
+		name     string
+		groups   []string
+		expected models.UserRole
+	}{
+		{"no groups", nil, models.RoleViewer},
+		{"unmapped group", []string{"sales"}, models.RoleViewer},
+		{"mapped group", []string{"staff"}, models.RoleMember},
+		{"highest role wins", []string{"staff", "gin-admins"}, models.RoleAdmin},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			role, managed := settings.MapRole(tt.groups)
+			if role != tt.expected || !managed {
+				t.Errorf("expected %s (managed), got %s (managed=%v)", tt.expected, role, managed)
+			}
+		})
+	}
+
+	unmapped := models.SSOSettings{}
+	if role, managed := unmapped.MapRole([]string{"gin-admins"}); role != models.RoleMember || managed {
+		t.Errorf("expected unmanaged member without role mapping, got %s (managed=%v)", role, managed)
+	}
+}
+
+func TestSSOSettingsRequireEnterprise(t *testing.T) {
+	f := newSSOFixture(t, models.TierPro)
+
+	settings := f.oidcSettings()
+	if _, err := f.service.UpdateSettings(context.Background(), f.tenant, &settings); !stdErrors.Is(err, errors.ErrFeatureNotAvailable) {
+		t.Errorf("expected ErrFeatureNotAvailable, got %v", err)
+	}
+	if _, _, err := f.service.BeginLogin(context.Background(), "acme", "/"); err != errors.ErrSSONotConfigured {
+		t.Errorf("expected ErrSSONotConfigured, got %v", err)
+	}
+
+	// Team management alone doesn't include single sign-on
+	limits := models.PlanLimitsMap[models.TierEnterprise]
+	limits.HasSSO = false
+	f.tenant.Tier = models.TierEnterprise
+	f.tenant.PlanLimits = &limits
+	if _, err := f.service.UpdateSettings(context.Background(), f.tenant, &settings); !stdErrors.Is(err, errors.ErrFeatureNotAvailable) {
+		t.Errorf("expected ErrFeatureNotAvailable without SSO in the plan, got %v", err)
+	}
+}
+
+func TestSSOUpdateSettingsKeepsSecretAndOtherSettings(t *testing.T) {
+	f := newSSOFixture(t, models.TierEnterprise)
+	f.tenantRepo.settings[f.tenant.ID] = json.RawMessage(`{"theme":"dark"}`)
+	f.configure(t, f.oidcSettings())
+
+	update := f.oidcSettings()
+	update.OIDC.ClientSecret = ""
+	f.configure(t, update)
+
+	settings, err := f.service.Settings(context.Background(), f.tenant.ID)
+	if err != nil {
+		t.Fatalf("Settings failed: %v", err)
+	}
+	if settings.OIDC.ClientSecret != f.idp.clientSecret {
+		t.Error("expected the stored client secret to be kept")
+	}
+	if len(settings.OIDC.Scopes) == 0 || settings.OIDC.Scopes[0] != "openid" {
+		t.Errorf("expected default scopes, got %v", settings.OIDC.Scopes)
+	}
+
+	var document map[string]json.RawMessage
+	if err := json.Unmarshal(f.tenantRepo.settings[f.tenant.ID], &document); err != nil {
+		t.Fatalf("invalid settings document: %v", err)
+	}
+	if string(document["theme"]) != `"dark"` {
+		t.Errorf("expected other settings to be kept, got %s", f.tenantRepo.settings[f.tenant.ID])
+	}
+}
+
+func TestOIDCLoginProvisionsUser(t *testing.T) {
+	f := newSSOFixture(t, models.TierEnterprise)
+	f.configure(t, f.oidcSettings())
+
+	ctx := context.Background()
+	loginURL, state, err := f.service.BeginLogin(ctx, "acme", "/gins?view=grid")
+	if err != nil {
+		t.Fatalf("BeginLogin failed: %v", err)
+	}
+	code, _ := f.idp.authorize(t, loginURL, jwt.MapClaims{
+		"sub":            "idp-alice",
+		"email":          "Alice@Example.com",
+		"email_verified": true,
+		"given_name":     "Alice",
+		"groups":         []string{"gin-admins"},
+	})
+
+	tenant, identity, err := f.service.CompleteOIDC(ctx, "acme", state, code)
+	if err != nil {
+		t.Fatalf("CompleteOIDC failed: %v", err)
+	}
+	if identity.RedirectPath != "/gins?view=grid" {
+		t.Errorf("expected redirect path to survive the login, got %q", identity.RedirectPath)
+	}
+
+	user, err := f.service.ResolveUser(ctx, tenant, identity)
+	if err != nil {
+		t.Fatalf("ResolveUser failed: %v", err)
+	}
+	if user.Email != "alice@example.com" || user.Role != models.RoleAdmin || !user.IsActive {
+		t.Errorf("unexpected provisioned user: %s %s active=%v", user.Email, user.Role, user.IsActive)
+	}
+	if user.FirstName == nil || *user.FirstName != "Alice" {
+		t.Error("expected first name from the ID token")
+	}
+
+	// The state is single use
+	if _, _, err := f.service.CompleteOIDC(ctx, "acme", state, code); err != errors.ErrSSOVerification {
+		t.Errorf("expected replayed state to fail, got %v", err)
+	}
+
+	// The next login finds the user by subject and follows the groups
+	again, err := f.oidcLogin(t, jwt.MapClaims{
+		"sub":    "idp-alice",
+		"email":  "alice.renamed@example.com",
+		"groups": []string{"tasters"},
+	})
+	if err != nil {
+		t.Fatalf("second login failed: %v", err)
+	}
+	if again.ID != user.ID || again.Role != models.RoleViewer {
+		t.Errorf("expected user %d with role viewer, got user %d with role %s", user.ID, again.ID, again.Role)
+	}
+}
+
+func TestOIDCLoginLinksExistingUsers(t *testing.T) {
+	f := newSSOFixture(t, models.TierEnterprise)
+	settings := f.oidcSettings()
+	settings.JITProvisioning = false
+	f.configure(t, settings)
+
+	ctx := context.Background()
+	owner := &models.User{TenantID: f.tenant.ID, Email: "owner@example.com", Role: models.RoleOwner, IsActive: true}
+	f.userRepo.Create(ctx, owner)
+
+	user, err := f.oidcLogin(t, jwt.MapClaims{"sub": "idp-owner", "email": "owner@example.com", "groups": []string{"tasters"}})
+	if err != nil {
+		t.Fatalf("login failed: %v", err)
+	}
+	if user.ID != owner.ID || user.Role != models.RoleOwner {
+		t.Errorf("expected the owner to be linked and keep the role, got user %d with role %s", user.ID, user.Role)
+	}
+	if _, err := f.repo.GetIdentity(ctx, f.tenant.ID, models.SSOProtocolOIDC, "idp-owner"); err != nil {
+		t.Errorf("expected identity link, got %v", err)
+	}
+
+	if _, err := f.oidcLogin(t, jwt.MapClaims{"sub": "idp-bob", "email": "bob@example.com"}); err != errors.ErrSSOUserNotProvisioned {
+		t.Errorf("expected ErrSSOUserNotProvisioned, got %v", err)
+	}
+}
+
+func TestOIDCLoginRejectsInvalidResponses(t *testing.T) {
+	f := newSSOFixture(t, models.TierEnterprise)
+	f.configure(t, f.oidcSettings())
+	ctx := context.Background()
+
+	t.Run("unverified email", func(t *testing.T) {
+		_, err := f.oidcLogin(t, jwt.MapClaims{"sub": "idp-eve", "email": "owner@example.com", "email_verified": false})
+		if err != errors.ErrSSOVerification {
+			t.Errorf("expected ErrSSOVerification, got %v", err)
+		}
+	})
+
+	t.Run("nonce mismatch", func(t *testing.T) {
+		f.idp.nonceOverride = "another-login"
+		defer func() { f.idp.nonceOverride = "" }()
+
+		_, err := f.oidcLogin(t, jwt.MapClaims{"sub": "idp-eve", "email": "eve@example.com"})
+		if err != errors.ErrSSOVerification {
+			t.Errorf("expected ErrSSOVerification, got %v", err)
+		}
+	})
+
+	t.Run("code of another login", func(t *testing.T) {
+		victimURL, _, err := f.service.BeginLogin(ctx, "acme", "/")
+		if err != nil {
+			t.Fatalf("BeginLogin failed: %v", err)
+		}
+		code, _ := f.idp.authorize(t, victimURL, jwt.MapClaims{"sub": "idp-victim", "email": "victim@example.com"})
+
+		_, attackerState, err := f.service.BeginLogin(ctx, "acme", "/")
+		if err != nil {
+			t.Fatalf("BeginLogin failed: %v", err)
+		}
+		if _, _, err := f.service.CompleteOIDC(ctx, "acme", attackerState, code); err != errors.ErrSSOVerification {
+			t.Errorf("expected PKCE to reject the code, got %v", err)
+		}
+	})
+
+	t.Run("unknown state", func(t *testing.T) {
+		if _, _, err := f.service.CompleteOIDC(ctx, "acme", "forged", "code"); err != errors.ErrSSOVerification {
+			t.Errorf("expected ErrSSOVerification, got %v", err)
+		}
+	})
+}
+
+func TestSAMLLogin(t *testing.T) {
+	f := newSSOFixture(t, models.TierEnterprise)
+	f.configure(t, f.samlSettings())
+
+	ctx := context.Background()
+	loginURL, state, err := f.service.BeginLogin(ctx, "acme", "/collection")
+	if err != nil {
+		t.Fatalf("BeginLogin failed: %v", err)
+	}
+	request := readSAMLRequest(t, loginURL)
+	if request.relayState != state {
+		t.Fatal("expected the state as RelayState")
+	}
+	sp := f.service.ServiceProvider("acme")
+	if request.acsURL != sp.SAMLACSURL || request.spEntityID != sp.SAMLEntityID {
+		t.Fatalf("unexpected service provider in request: %+v", request)
+	}
+
+	response := f.idp.samlResponse(t, request, samlOptions{
+		nameID:        "Alice@Example.com",
+		groups:        []string{"gin-admins"},
+		signAssertion: true,
+		signResponse:  true,
+	})
+	tenant, identity, err := f.service.CompleteSAML(ctx, "acme", response, request.relayState)
+	if err != nil {
+		t.Fatalf("CompleteSAML failed: %v", err)
+	}
+	if identity.RedirectPath != "/collection" || identity.FirstName != "Alice" {
+		t.Errorf("unexpected identity: %+v", identity)
+	}
+
+	user, err := f.service.ResolveUser(ctx, tenant, identity)
+	if err != nil {
+		t.Fatalf("ResolveUser failed: %v", err)
+	}
+	if user.Email != "alice@example.com" || user.Role != models.RoleAdmin {
+		t.Errorf("unexpected user: %s %s", user.Email, user.Role)
+	}
+
+	// The response can't be posted twice
+	if _, _, err := f.service.CompleteSAML(ctx, "acme", response, request.relayState); err != errors.ErrSSOVerification {
+		t.Errorf("expected replay to fail, got %v", err)
+	}
+}
+
+func TestSAMLResponseVerification(t *testing.T) {
+	idp := newStubIdP(t)
+	certs, err := models.ParseSAMLCertificates(idp.certPEM)
+	if err != nil {
+		t.Fatalf("ParseSAMLCertificates failed: %v", err)
+	}
+	sp := &external.SAMLServiceProvider{
+		EntityID:        "https://app.example.com/api/v1/auth/sso/acme/saml/metadata",
+		ACSURL:          "https://app.example.com/api/v1/auth/sso/acme/saml/acs",
+		IdPEntityID:     idp.samlEntityID(),
+		IdPSSOURL:       idp.server.URL + "/saml/sso",
+		IdPCertificates: certs,
+	}
+
+	newRequest := func(t *testing.T) samlRequest {
+		loginURL, _, err := sp.AuthnRequestURL("relay")
+		if err != nil {
+			t.Fatalf("AuthnRequestURL failed: %v", err)
+		}
+		return readSAMLRequest(t, loginURL)
+	}
+
+	tests := []struct {
+		name  string
+		opts  samlOptions
+		valid bool
+	}{
+		{
+			name:  "signed assertion",
+			opts:  samlOptions{signAssertion: true},
+			valid: true,
+		},
+		{
+			name:  "signed response",
+			opts:  samlOptions{signResponse: true},
+			valid: true,
+		},
+		{
+			name: "unsigned",
+			opts: samlOptions{},
+		},
+		{
+			name: "tampered name id",
+			opts: samlOptions{signAssertion: true, tamperAfterSig: func(response string) string {
+				return strings.Replace(response, ">alice@example.com</saml:NameID>", ">mallory@example.com</saml:NameID>", 1)
+			}},
+		},
+		{
+			name: "tampered response",
+			opts: samlOptions{signResponse: true, tamperAfterSig: func(response string) string {
+				return strings.Replace(response, `<saml:Attribute Name="groups">`, `<saml:Attribute Name="groups"><saml:AttributeValue>gin-admins</saml:AttributeValue>`, 1)
+			}},
+		},
+		{
+			name: "signature wrapping",
+			opts: samlOptions{signAssertion: true, wrapAssertion: true},
+		},
+		{
+			name: "signature wrapping with the signed ID",
+			opts: samlOptions{signAssertion: true, wrapAssertion: true, wrapKeepID: true},
+		},
+		{
+			name: "response signature wrapping",
+			opts: samlOptions{signResponse: true, tamperAfterSig: wrapSAMLResponse},
+		},
+		{
+			name: "wrong audience",
+			opts: samlOptions{signAssertion: true, audience: "https://other.example.com"},
+		},
+		{
+			name: "answer to another request",
+			opts: samlOptions{signAssertion: true, inResponseTo: "_another"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			request := newRequest(t)
+			tt.opts.nameID = "alice@example.com"
+			response := idp.samlResponse(t, request, tt.opts)
+
+			assertion, err := sp.ParseResponse(response, request.id)
+			if tt.valid {
+				if err != nil {
+					t.Fatalf("expected valid response, got %v", err)
+				}
+				if assertion.NameID != "alice@example.com" || assertion.Attribute("email") != "alice@example.com" {
+					t.Errorf("unexpected assertion: %+v", assertion)
+				}
+				return
+			}
+			if err == nil {
+				t.Error("expected the response to be rejected")
+			}
+		})
+	}
+
+	// Comments are not signed, a comment must not cut the signed NameID
+	// "alice@example.com.evil.test" down to "alice@example.com"
+	t.Run("comment in name id", func(t *testing.T) {
+		request := newRequest(t)
+		response := idp.samlResponse(t, request, samlOptions{
+			nameID:        "alice@example.com.evil.test",
+			signAssertion: true,
+			tamperAfterSig: func(response string) string {
+				return strings.ReplaceAll(response, ">alice@example.com.evil.test<", ">alice@example.com<!---->.evil.test<")
+			},
+		})
+
+		assertion, err := sp.ParseResponse(response, request.id)
+		if err != nil {
+			t.Fatalf("ParseResponse failed: %v", err)
+		}
+		if assertion.NameID != "alice@example.com.evil.test" || assertion.Attribute("email") != "alice@example.com.evil.test" {
+			t.Errorf("expected the whole signed values, got %s and %s", assertion.NameID, assertion.Attribute("email"))
+		}
+	})
+
+	t.Run("expired", func(t *testing.T) {
+		request := newRequest(t)
+		response := idp.samlResponse(t, request, samlOptions{nameID: "alice@example.com", signAssertion: true})
+
+		late := *sp
+		late.Now = func() time.Time { return time.Now().Add(10 * time.Minute) }
+		if _, err := late.ParseResponse(response, request.id); err == nil {
+			t.Error("expected an expired assertion to be rejected")
+		}
+	})
+
+	t.Run("untrusted certificate", func(t *testing.T) {
+		other := newStubIdP(t)
+		request := newRequest(t)
+		response := idp.samlResponse(t, request, samlOptions{nameID: "alice@example.com", signAssertion: true})
+
+		otherCerts, _ := models.ParseSAMLCertificates(other.certPEM)
+		untrusting := *sp
+		untrusting.IdPCertificates = otherCerts
+		if _, err := untrusting.ParseResponse(response, request.id); err == nil {
+			t.Error("expected a signature of another key to be rejected")
+		}
+	})
+}
+
+func TestPasswordLoginDisabledBySSO(t *testing.T) {
+	f := newSSOFixture(t, models.TierEnterprise)
+	settings := f.oidcSettings()
+	settings.DisablePasswordLogin = true
+	f.configure(t, settings)
+
+	ctx := context.Background()
+	password := "correct horse battery"
+	passwordHash, err := utils.HashPassword(password)
+	if err != nil {
+		t.Fatalf("HashPassword failed: %v", err)
+	}
+	f.userRepo.Create(ctx, &models.User{TenantID: f.tenant.ID, Email: "owner@example.com", PasswordHash: passwordHash, Role: models.RoleOwner, IsActive: true})
+	f.userRepo.Create(ctx, &models.User{TenantID: f.tenant.ID, Email: "member@example.com", PasswordHash: passwordHash, Role: models.RoleMember, IsActive: true})
+
+	authService := auth.NewService(f.userRepo, f.tenantRepo, "test-secret", time.Hour)
+	authService.SetSSOService(f.service)
+
+	if _, err := authService.Login(ctx, &models.LoginRequest{Email: "member@example.com", Password: password}, f.tenant.ID); err != errors.ErrPasswordLoginDisabled {
+		t.Errorf("expected ErrPasswordLoginDisabled for members, got %v", err)
+	}
+
+	// Owners keep password login in case the identity provider is unavailable
+	resp, err := authService.Login(ctx, &models.LoginRequest{Email: "owner@example.com", Password: password}, f.tenant.ID)
+	if err != nil || resp.Token == "" {
+		t.Errorf("expected owner login to succeed, got %v", err)
+	}
+
+	info, err := f.service.LoginInfo(ctx, "acme")
+	if err != nil {
+		t.Fatalf("LoginInfo failed: %v", err)
+	}
+	if !info.Enabled || info.PasswordLoginEnabled || info.LoginURL == "" {
+		t.Errorf("unexpected login info: %+v", info)
+	}
+}
@@ -0,0 +1,158 @@
+package unit
+
+import (
+	"context"
+	stdErrors "errors"
+	"testing"
+	"time"
+
+	"github.com/yourusername/gin-collection-saas/internal/domain/errors"
+	"github.com/yourusername/gin-collection-saas/internal/domain/models"
+	"github.com/yourusername/gin-collection-saas/internal/usecase/storagesync"
+)
+
+func TestPhotoBytesAccounting(t *testing.T) {
+	f := newPhotoUploadFixture()
+	ctx := context.Background()
+	size := int64(len(jpegBytes))
+
+	first, err := f.service.UploadPhoto(ctx, 1, 7, "bottle.jpg", jpegBytes, models.PhotoTypeBottle, nil)
+	if err != nil {
+		t.Fatalf("UploadPhoto failed: %v", err)
+	}
+	second, err := f.service.UploadPhoto(ctx, 1, 7, "label.jpg", jpegBytes[:1500], models.PhotoTypeLabel, nil)
+	if err != nil {
+		t.Fatalf("UploadPhoto failed: %v", err)
+	}
+
+	if *first.FileSizeBytes != size || *second.FileSizeBytes != 1500 || f.usage.bytesUsed != size+1500 {
+		t.Fatalf("expected %d bytes booked, got %d", size+1500, f.usage.bytesUsed)
+	}
+	if !first.IsPrimary || second.IsPrimary {
+		t.Errorf("expected only the first photo to be primary")
+	}
+
+	if err := f.service.DeletePhoto(ctx, 1, first.ID); err != nil {
+		t.Fatalf("DeletePhoto failed: %v", err)
+	}
+	if f.usage.bytesUsed != 1500 {
+		t.Errorf("expected the deleted photo's bytes released, %d used", f.usage.bytesUsed)
+	}
+	if !f.photos.photos[second.ID].IsPrimary {
+		t.Error("expected the remaining photo to become primary")
+	}
+	if len(f.storage.objects) != 1 {
+		t.Errorf("expected the deleted photo's object removed, %d objects left", len(f.storage.objects))
+	}
+}
+
+func TestUploadLimitsHoldForConcurrentUploads(t *testing.T) {
+	tests := []struct {
+		name       string
+		setup      func(f *photoUploadFixture)
+		concurrent func(f *photoUploadFixture)
+		want       error
+	}{
+		{
+			name: "storage limit",
+			setup: func(f *photoUploadFixture) {
+				f.usage.bytesUsed = 100*models.BytesPerMB - 3000
+			},
+			// Another upload was booked after the pre-check passed
+			concurrent: func(f *photoUploadFixture) {
+				f.photos.add(1, 7, "tenants/1/gins/7/existing.jpg", 2000)
+			},
+			want: errors.ErrStorageLimitReached,
+		},
+		{
+			name: "photo limit",
+			setup: func(f *photoUploadFixture) {
+				f.photos.add(1, 7, "tenants/1/gins/7/existing.jpg", 1024)
+				f.photos.add(1, 7, "tenants/1/gins/7/existing.jpg", 1024)
+			},
+			concurrent: func(f *photoUploadFixture) {
+				f.photos.add(1, 7, "tenants/1/gins/7/existing.jpg", 1024)
+			},
+			want: errors.ErrPhotoLimitReached,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newPhotoUploadFixture()
+			tt.setup(f)
+			f.photos.beforeCreate = func() {
+				f.photos.beforeCreate = nil
+				tt.concurrent(f)
+			}
+			photos, bytesUsed := len(f.photos.photos), f.usage.bytesUsed
+
+			_, err := f.service.UploadPhoto(context.Background(), 1, 7, "bottle.jpg", jpegBytes, models.PhotoTypeBottle, nil)
+			if !stdErrors.Is(err, tt.want) {
+				t.Fatalf("expected %v, got %v", tt.want, err)
+			}
+			var denial *models.EntitlementError
+			if !stdErrors.As(err, &denial) {
+				t.Errorf("expected a structured denial, got %T", err)
+			}
+
+			if len(f.photos.photos) != photos+1 || f.usage.bytesUsed <= bytesUsed {
+				t.Fatal("expected only the concurrent upload to be stored")
+			}
+			if len(f.storage.objects) != 0 {
+				t.Errorf("expected the rejected upload deleted, %d objects left", len(f.storage.objects))
+			}
+		})
+	}
+}
+
+func TestReconcileCorrectsSizesAndUsage(t *testing.T) {
+	f := newStorageSyncFixture()
+	usage := f.photos.usage
+	usage.photos = f.photos
+
+	// Recorded sizes differ from the stored objects
+	accurate := f.stored(f.s3, "s3", "tenants/1/gins/7/accurate.jpg")
+	drifted := f.stored(f.s3, "s3", "tenants/1/gins/7/drifted.jpg")
+	recorded := int64(100)
+	f.photos.photos[drifted.ID].FileSizeBytes = &recorded
+	usage.bytesUsed = 42
+
+	report, err := f.service.Reconcile(context.Background(), &storagesync.ReconcileOptions{Backend: "s3"})
+	if err != nil {
+		t.Fatalf("Reconcile failed: %v", err)
+	}
+
+	result := report.Tenants[0]
+	want := 2 * int64(len(jpegBytes))
+	if result.SizesCorrected != 1 || result.StorageBytes != want || usage.bytesUsed != want {
+		t.Fatalf("expected %d bytes after reconciliation, got %+v (usage %d)", want, result, usage.bytesUsed)
+	}
+	if *f.photos.photos[drifted.ID].FileSizeBytes != int64(len(jpegBytes)) || *f.photos.photos[accurate.ID].FileSizeBytes != int64(len(jpegBytes)) {
+		t.Error("expected the recorded sizes to match the stored objects")
+	}
+}
+
+func TestUsageReconciler(t *testing.T) {
+	f := newStorageSyncFixture()
+	usage := f.photos.usage
+	usage.photos = f.photos
+
+	f.stored(f.s3, "s3", "tenants/1/gins/7/bottle.jpg")
+	usage.bytesUsed = 0
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	usage.reconciled = make(chan int64, 1)
+	f.service.StartUsageReconciler(ctx, "s3", 10*time.Millisecond)
+
+	select {
+	case bytesUsed := <-usage.reconciled:
+		if bytesUsed != int64(len(jpegBytes)) {
+			t.Errorf("expected %d bytes after reconciliation, got %d", len(jpegBytes), bytesUsed)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("expected the reconciler to recompute the storage usage")
+	}
+}
@@ -0,0 +1,309 @@
+package unit
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/yourusername/gin-collection-saas/internal/domain/errors"
+	"github.com/yourusername/gin-collection-saas/internal/domain/models"
+	"github.com/yourusername/gin-collection-saas/internal/infrastructure/storage"
+	"github.com/yourusername/gin-collection-saas/internal/usecase/entitlement"
+	"github.com/yourusername/gin-collection-saas/internal/usecase/photo"
+	"github.com/yourusername/gin-collection-saas/internal/usecase/storagesync"
+)
+
+func (r *fakePhotoStore) UpdateStorageLocation(ctx context.Context, tenantID, id int64, backend, storageKey, photoURL string) error {
+	photo, ok := r.photos[id]
+	if !ok || photo.TenantID != tenantID {
+		return errors.ErrPhotoNotFound
+	}
+	photo.StorageBackend = backend
+	photo.StorageKey = &storageKey
+	photo.PhotoURL = photoURL
+	return nil
+}
+
+func (r *fakePhotoStore) UpdateFileSize(ctx context.Context, tenantID, id int64, sizeBytes int64) error {
+	photo, ok := r.photos[id]
+	if !ok || photo.TenantID != tenantID {
+		return errors.ErrPhotoNotFound
+	}
+	if photo.FileSizeBytes != nil {
+		r.usage.bytesUsed -= *photo.FileSizeBytes
+	}
+	photo.FileSizeBytes = &sizeBytes
+	r.usage.bytesUsed += sizeBytes
+	return nil
+}
+
+func (r *fakeStorageUsageRepository) ComputeFromPhotos(ctx context.Context, tenantID int64) (int64, int, error) {
+	if r.photos == nil {
+		return r.bytesUsed, 0, nil
+	}
+	var bytesUsed int64
+	photos, _ := r.photos.ListByTenant(ctx, tenantID)
+	for _, photo := range photos {
+		if photo.FileSizeBytes != nil {
+			bytesUsed += *photo.FileSizeBytes
+		}
+	}
+	return bytesUsed, len(photos), nil
+}
+
+func (r *fakeStorageUsageRepository) SetUsage(ctx context.Context, tenantID int64, bytesUsed int64, photoCount int) error {
+	if r.reconciled != nil {
+		select {
+		case r.reconciled <- bytesUsed:
+		default:
+		}
+		return nil
+	}
+	r.bytesUsed = bytesUsed
+	return nil
+}
+
+// corruptingStorage flips the first byte of every object written to it
+type corruptingStorage struct {
+	*fakeObjectStorage
+}
+
+func (s *corruptingStorage) PutObject(ctx context.Context, key string, data []byte, contentType string) (*storage.UploadResult, error) {
+	corrupted := append([]byte(nil), data...)
+	corrupted[0] ^= 0xFF
+	return s.fakeObjectStorage.PutObject(ctx, key, corrupted, contentType)
+}
+
+type storageSyncFixture struct {
+	service *storagesync.Service
+	local   *fakeObjectStorage
+	s3      *fakeObjectStorage
+	photos  *fakePhotoStore
+}
+
+// newStorageSyncFixture registers a local and an S3 backend, S3 is active
+func newStorageSyncFixture() *storageSyncFixture {
+	usage := &fakeStorageUsageRepository{}
+	f := &storageSyncFixture{
+		local:  newFakeObjectStorage("local"),
+		s3:     newFakeObjectStorage("s3"),
+		photos: newFakePhotoStore(usage),
+	}
+	tenants := newFakeTenantRepository(&models.Tenant{ID: 1, Name: "Gin Bar", Subdomain: "ginbar", Tier: models.TierPro, Status: models.TenantStatusActive})
+
+	f.service = storagesync.NewService(f.photos, tenants, usage)
+	f.service.SetActiveBackend("s3")
+	f.service.RegisterBackend("local", f.local)
+	f.service.RegisterBackend("s3", f.s3)
+	return f
+}
+
+// stored adds a photo whose object is held by a backend, backend "" is a
+// photo stored before backends were recorded
+func (f *storageSyncFixture) stored(on *fakeObjectStorage, backend, key string) *models.GinPhoto {
+	on.put(key, append([]byte(nil), jpegBytes...), "image/jpeg")
+	photo := f.photos.add(1, 7, key, int64(len(jpegBytes)))
+	photo.StorageBackend = backend
+	photo.PhotoURL = on.ObjectURL(key)
+	// Objects older than the orphan grace period
+	on.objects[key].modified = time.Now().Add(-2 * time.Hour)
+	return photo
+}
+
+func TestMigrateCopiesAndRewritesPhotos(t *testing.T) {
+	f := newStorageSyncFixture()
+	ctx := context.Background()
+
+	legacy := f.stored(f.local, "", "tenants/1/gins/7/legacy.jpg")
+	recorded := f.stored(f.local, "local", "tenants/1/gins/7/recorded.jpg")
+	migrated := f.stored(f.s3, "s3", "tenants/1/gins/7/migrated.jpg")
+
+	dryRun, err := f.service.Migrate(ctx, &storagesync.MigrateOptions{From: "local", To: "s3", DryRun: true})
+	if err != nil {
+		t.Fatalf("dry run failed: %v", err)
+	}
+	if dryRun.Copied != 2 || dryRun.Skipped != 1 || len(f.s3.objects) != 1 {
+		t.Fatalf("expected a dry run to copy nothing, got %+v", dryRun)
+	}
+
+	report, err := f.service.Migrate(ctx, &storagesync.MigrateOptions{From: "local", To: "s3", DeleteSource: true})
+	if err != nil {
+		t.Fatalf("Migrate failed: %v", err)
+	}
+	if report.Copied != 2 || report.Skipped != 1 || report.Failed != 0 || report.BytesCopied != 2*int64(len(jpegBytes)) {
+		t.Fatalf("unexpected report %+v", report)
+	}
+
+	for _, p := range []*models.GinPhoto{legacy, recorded, migrated} {
+		stored := f.photos.photos[p.ID]
+		key := *stored.StorageKey
+		if stored.StorageBackend != "s3" || stored.PhotoURL != "https://s3.example.com/"+key {
+			t.Errorf("expected photo %d on S3, got %s at %s", p.ID, stored.StorageBackend, stored.PhotoURL)
+		}
+		if object, ok := f.s3.objects[key]; !ok || !bytes.Equal(object.data, jpegBytes) {
+			t.Errorf("expected the object %s copied to S3", key)
+		}
+	}
+	if len(f.local.objects) != 0 {
+		t.Errorf("expected the source objects deleted, %d left", len(f.local.objects))
+	}
+}
+
+func TestMigrateVerifiesChecksum(t *testing.T) {
+	f := newStorageSyncFixture()
+	corrupting := &corruptingStorage{f.s3}
+	f.service.RegisterBackend("s3", corrupting)
+
+	photo := f.stored(f.local, "local", "tenants/1/gins/7/bottle.jpg")
+
+	report, err := f.service.Migrate(context.Background(), &storagesync.MigrateOptions{From: "local", To: "s3", DeleteSource: true})
+	if err != nil {
+		t.Fatalf("Migrate failed: %v", err)
+	}
+	if report.Failed != 1 || report.Copied != 0 || len(report.Errors) != 1 || report.Errors[0].PhotoID != photo.ID {
+		t.Fatalf("expected the corrupted copy to fail, got %+v", report)
+	}
+
+	stored := f.photos.photos[photo.ID]
+	if stored.StorageBackend != "local" || stored.PhotoURL != "https://local.example.com/tenants/1/gins/7/bottle.jpg" {
+		t.Errorf("expected the photo to stay on local storage, got %+v", stored)
+	}
+	if _, ok := f.local.objects["tenants/1/gins/7/bottle.jpg"]; !ok {
+		t.Error("expected the source object to be kept")
+	}
+}
+
+func TestMigrateSkipsIdenticalTargetObjects(t *testing.T) {
+	f := newStorageSyncFixture()
+
+	photo := f.stored(f.local, "local", "tenants/1/gins/7/bottle.jpg")
+	f.s3.put("tenants/1/gins/7/bottle.jpg", append([]byte(nil), jpegBytes...), "image/jpeg")
+
+	report, err := f.service.Migrate(context.Background(), &storagesync.MigrateOptions{From: "local", To: "s3"})
+	if err != nil {
+		t.Fatalf("Migrate failed: %v", err)
+	}
+	if report.Copied != 0 || report.Skipped != 1 {
+		t.Fatalf("expected the identical object not to be copied, got %+v", report)
+	}
+	if f.photos.photos[photo.ID].StorageBackend != "s3" {
+		t.Error("expected the photo to be rewritten to S3")
+	}
+}
+
+func TestMigrateRejectsInvalidBackends(t *testing.T) {
+	f := newStorageSyncFixture()
+
+	for _, opts := range []*storagesync.MigrateOptions{
+		{From: "local", To: "local"},
+		{From: "gcs", To: "s3"},
+		{From: "local", To: "gcs"},
+	} {
+		if _, err := f.service.StartMigration(opts); err == nil {
+			t.Errorf("expected migration %s -> %s to be rejected", opts.From, opts.To)
+		}
+	}
+}
+
+func TestMigrationJob(t *testing.T) {
+	f := newStorageSyncFixture()
+	photo := f.stored(f.local, "local", "tenants/1/gins/7/bottle.jpg")
+
+	job, err := f.service.StartMigration(&storagesync.MigrateOptions{TenantID: 1, From: "local", To: "s3"})
+	if err != nil {
+		t.Fatalf("StartMigration failed: %v", err)
+	}
+	if job.ID == "" || job.Status != storagesync.MigrationJobRunning {
+		t.Fatalf("expected a running job, got %+v", job)
+	}
+
+	deadline := time.Now().Add(2 * time.Second)
+	for job.Status == storagesync.MigrationJobRunning && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+		if job, err = f.service.GetMigrationJob(job.ID); err != nil {
+			t.Fatalf("GetMigrationJob failed: %v", err)
+		}
+	}
+	if job.Status != storagesync.MigrationJobCompleted || job.Report == nil || job.Report.Copied != 1 || job.FinishedAt == nil {
+		t.Fatalf("expected a completed job, got %+v", job)
+	}
+	if f.photos.photos[photo.ID].StorageBackend != "s3" {
+		t.Error("expected the photo migrated to S3")
+	}
+
+	if _, err := f.service.GetMigrationJob("unknown"); err != errors.ErrNotFound {
+		t.Errorf("expected ErrNotFound for an unknown job, got %v", err)
+	}
+}
+
+func TestReconcileChecksPhotosOnTheirBackend(t *testing.T) {
+	f := newStorageSyncFixture()
+	ctx := context.Background()
+
+	f.stored(f.local, "", "tenants/1/gins/7/legacy.jpg")
+	onLocal := f.stored(f.local, "local", "tenants/1/gins/7/local.jpg")
+	f.stored(f.s3, "s3", "tenants/1/gins/7/s3.jpg")
+	// Left behind by a migration without delete_source
+	f.local.put("tenants/1/gins/7/s3.jpg", jpegBytes, "image/jpeg")
+	f.local.objects["tenants/1/gins/7/s3.jpg"].modified = time.Now().Add(-2 * time.Hour)
+
+	report, err := f.service.Reconcile(ctx, &storagesync.ReconcileOptions{Backend: "local", DeleteOrphans: true})
+	if err != nil {
+		t.Fatalf("Reconcile failed: %v", err)
+	}
+	local := report.Tenants[0]
+	if local.Photos != 1 || len(local.Missing) != 0 {
+		t.Errorf("expected only the photo recorded on local storage, got %+v", local)
+	}
+	if len(local.Orphans) != 1 || local.Orphans[0].Key != "tenants/1/gins/7/s3.jpg" || local.OrphansDeleted != 1 {
+		t.Errorf("expected the migrated copy as the only orphan, got %+v", local.Orphans)
+	}
+	if _, ok := f.local.objects["tenants/1/gins/7/legacy.jpg"]; !ok {
+		t.Error("expected the object of a photo without recorded backend to be kept")
+	}
+
+	// The legacy photo counts on the active backend, where it is missing
+	report, err = f.service.Reconcile(ctx, &storagesync.ReconcileOptions{Backend: "s3"})
+	if err != nil {
+		t.Fatalf("Reconcile failed: %v", err)
+	}
+	s3 := report.Tenants[0]
+	if s3.Photos != 2 || len(s3.Missing) != 1 || s3.Missing[0].Key != "tenants/1/gins/7/legacy.jpg" || len(s3.Orphans) != 0 {
+		t.Errorf("unexpected S3 reconciliation %+v", s3)
+	}
+	if len(f.s3.objects) != 1 || f.photos.photos[onLocal.ID].StorageBackend != "local" {
+		t.Error("expected reconciliation not to move photos")
+	}
+}
+
+func TestDeletePhotoFromItsBackend(t *testing.T) {
+	f := newStorageSyncFixture()
+	usage := f.photos.usage
+
+	service := photo.NewService(f.photos, &fakeUploadGinRepository{}, usage, newFakeTenantRepository(), entitlement.NewService(usage), f.s3)
+	service.SetActiveBackend("s3")
+	service.RegisterBackend("local", f.local)
+
+	onLocal := f.stored(f.local, "local", "tenants/1/gins/7/local.jpg")
+	legacy := f.stored(f.s3, "", "tenants/1/gins/7/legacy.jpg")
+	// Same key on the active backend, must not be touched
+	f.s3.put("tenants/1/gins/7/local.jpg", jpegBytes, "image/jpeg")
+
+	if err := service.DeletePhoto(context.Background(), 1, onLocal.ID); err != nil {
+		t.Fatalf("DeletePhoto failed: %v", err)
+	}
+	if _, ok := f.local.objects["tenants/1/gins/7/local.jpg"]; ok {
+		t.Error("expected the object deleted from local storage")
+	}
+	if _, ok := f.s3.objects["tenants/1/gins/7/local.jpg"]; !ok {
+		t.Error("expected the S3 object with the same key to be kept")
+	}
+
+	if err := service.DeletePhoto(context.Background(), 1, legacy.ID); err != nil {
+		t.Fatalf("DeletePhoto failed: %v", err)
+	}
+	if _, ok := f.s3.objects["tenants/1/gins/7/legacy.jpg"]; ok {
+		t.Error("expected a photo without recorded backend deleted from the active backend")
+	}
+}
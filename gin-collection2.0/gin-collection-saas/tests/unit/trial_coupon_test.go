@@ -0,0 +1,716 @@
+package unit
+
+import (
+	"context"
+	stdErrors "errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/yourusername/gin-collection-saas/internal/domain/errors"
+	"github.com/yourusername/gin-collection-saas/internal/domain/models"
+	"github.com/yourusername/gin-collection-saas/internal/infrastructure/billing"
+	"github.com/yourusername/gin-collection-saas/internal/infrastructure/external"
+	"github.com/yourusername/gin-collection-saas/internal/usecase/subscription"
+)
+
+// fakeCouponRepository keeps coupons and their redemptions in memory,
+// redemptions are counted one at a time like with the coupon row locked
+type fakeCouponRepository struct {
+	mu          sync.Mutex
+	coupons     map[int64]*models.Coupon
+	redemptions []*models.CouponRedemption
+}
+
+func newFakeCouponRepository() *fakeCouponRepository {
+	return &fakeCouponRepository{coupons: make(map[int64]*models.Coupon)}
+}
+
+func (r *fakeCouponRepository) Create(ctx context.Context, coupon *models.Coupon) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, known := range r.coupons {
+		if known.Code == coupon.Code {
+			return errors.ErrConflict
+		}
+	}
+	coupon.ID = int64(len(r.coupons) + 1)
+	copied := *coupon
+	r.coupons[coupon.ID] = &copied
+	return nil
+}
+
+func (r *fakeCouponRepository) GetByID(ctx context.Context, id int64) (*models.Coupon, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	coupon, ok := r.coupons[id]
+	if !ok {
+		return nil, errors.ErrNotFound
+	}
+	copied := *coupon
+	return &copied, nil
+}
+
+func (r *fakeCouponRepository) GetByCode(ctx context.Context, code string) (*models.Coupon, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, coupon := range r.coupons {
+		if coupon.Code == code {
+			copied := *coupon
+			return &copied, nil
+		}
+	}
+	return nil, errors.ErrNotFound
+}
+
+func (r *fakeCouponRepository) List(ctx context.Context) ([]*models.Coupon, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	coupons := []*models.Coupon{}
+	for _, coupon := range r.coupons {
+		copied := *coupon
+		coupons = append(coupons, &copied)
+	}
+	return coupons, nil
+}
+
+func (r *fakeCouponRepository) Update(ctx context.Context, coupon *models.Coupon) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	copied := *coupon
+	r.coupons[coupon.ID] = &copied
+	return nil
+}
+
+func (r *fakeCouponRepository) Delete(ctx context.Context, id int64) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	delete(r.coupons, id)
+	return nil
+}
+
+func (r *fakeCouponRepository) GetRedemption(ctx context.Context, couponID, tenantID int64) (*models.CouponRedemption, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if redemption := r.find(couponID, tenantID); redemption != nil {
+		copied := *redemption
+		return &copied, nil
+	}
+	return nil, errors.ErrNotFound
+}
+
+func (r *fakeCouponRepository) Reserve(ctx context.Context, redemption *models.CouponRedemption) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.release(redemption.CouponID, func(pending *models.CouponRedemption) bool {
+		return !pending.ExpiresAt.After(time.Now()) || pending.TenantID == redemption.TenantID
+	})
+	if err := r.count(redemption.CouponID, redemption.TenantID); err != nil {
+		return err
+	}
+
+	redemption.ID = int64(len(r.redemptions) + 1)
+	redemption.Status = models.CouponRedemptionPending
+	copied := *redemption
+	r.redemptions = append(r.redemptions, &copied)
+	return nil
+}
+
+func (r *fakeCouponRepository) Release(ctx context.Context, couponID, tenantID int64) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.release(couponID, func(pending *models.CouponRedemption) bool { return pending.TenantID == tenantID })
+	return nil
+}
+
+func (r *fakeCouponRepository) ReleaseExpired(ctx context.Context) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	released := 0
+	for id := range r.coupons {
+		released += r.release(id, func(pending *models.CouponRedemption) bool { return !pending.ExpiresAt.After(time.Now()) })
+	}
+	return released, nil
+}
+
+func (r *fakeCouponRepository) Redeem(ctx context.Context, redemption *models.CouponRedemption) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if pending := r.find(redemption.CouponID, redemption.TenantID); pending != nil && pending.Status == models.CouponRedemptionPending {
+		pending.Status = models.CouponRedemptionRedeemed
+		pending.SubscriptionID = redemption.SubscriptionID
+		pending.ExpiresAt = nil
+		redemption.ID = pending.ID
+		return nil
+	}
+
+	if err := r.count(redemption.CouponID, redemption.TenantID); err != nil {
+		return err
+	}
+	redemption.ID = int64(len(r.redemptions) + 1)
+	redemption.Status = models.CouponRedemptionRedeemed
+	copied := *redemption
+	r.redemptions = append(r.redemptions, &copied)
+	return nil
+}
+
+func (r *fakeCouponRepository) ListRedemptions(ctx context.Context, couponID int64) ([]*models.CouponRedemption, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	redemptions := []*models.CouponRedemption{}
+	for _, redemption := range r.redemptions {
+		if redemption.CouponID == couponID {
+			copied := *redemption
+			redemptions = append(redemptions, &copied)
+		}
+	}
+	return redemptions, nil
+}
+
+// find returns the redemption of a coupon by a tenant, nil if there is none
+func (r *fakeCouponRepository) find(couponID, tenantID int64) *models.CouponRedemption {
+	for _, redemption := range r.redemptions {
+		if redemption.CouponID == couponID && redemption.TenantID == tenantID {
+			return redemption
+		}
+	}
+	return nil
+}
+
+// count counts a redemption of a tenant on a coupon
+func (r *fakeCouponRepository) count(couponID, tenantID int64) error {
+	coupon := r.coupons[couponID]
+	if coupon.LimitReached() {
+		return errors.ErrLimitReached
+	}
+	if r.find(couponID, tenantID) != nil {
+		return errors.ErrConflict
+	}
+	coupon.Redemptions++
+	return nil
+}
+
+// release deletes and uncounts the pending redemptions of a coupon that match
+func (r *fakeCouponRepository) release(couponID int64, match func(*models.CouponRedemption) bool) int {
+	kept := r.redemptions[:0]
+	released := 0
+	for _, redemption := range r.redemptions {
+		if redemption.CouponID == couponID && redemption.Status == models.CouponRedemptionPending && match(redemption) {
+			released++
+			continue
+		}
+		kept = append(kept, redemption)
+	}
+	r.redemptions = kept
+	r.coupons[couponID].Redemptions -= released
+	return released
+}
+
+// fakeLockRepository shares scheduler jobs with another instance. Jobs the
+// other instance holds can't be taken, a released job passes back to it.
+type fakeLockRepository struct {
+	mu       sync.Mutex
+	other    map[string]bool
+	released chan string
+}
+
+func newFakeLockRepository(otherJobs ...string) *fakeLockRepository {
+	r := &fakeLockRepository{other: make(map[string]bool), released: make(chan string, 1)}
+	for _, job := range otherJobs {
+		r.other[job] = true
+	}
+	return r
+}
+
+func (r *fakeLockRepository) TryLock(ctx context.Context, name string) (func(), bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.other[name] {
+		return nil, false, nil
+	}
+	release := func() {
+		r.mu.Lock()
+		r.other[name] = true
+		r.mu.Unlock()
+		r.released <- name
+	}
+	return release, true, nil
+}
+
+// runOnce lets the scheduler of a job run once and waits until it did
+func (r *fakeLockRepository) runOnce(t *testing.T, job string) {
+	r.mu.Lock()
+	r.other[job] = false
+	r.mu.Unlock()
+
+	select {
+	case released := <-r.released:
+		if released != job {
+			t.Fatalf("expected %s to run, got %s", job, released)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatalf("expected %s to run", job)
+	}
+}
+
+type trialCouponFixture struct {
+	service       *subscription.Service
+	stripe        *fakeStripe
+	contract      *providerContract
+	tenants       *fakeTenantRepository
+	subscriptions *fakeSubscriptionRepository
+	coupons       *fakeCouponRepository
+}
+
+func newTrialCouponFixture(t *testing.T) *trialCouponFixture {
+	contract, fake := newStripeContract(t)
+
+	f := &trialCouponFixture{
+		stripe:   fake,
+		contract: contract,
+		tenants: newFakeTenantRepository(
+			&models.Tenant{ID: 1, Name: "Gin Bar", Subdomain: "ginbar", Tier: models.TierFree, Status: models.TenantStatusActive},
+			&models.Tenant{ID: 2, Name: "Juniper Club", Subdomain: "juniper", Tier: models.TierFree, Status: models.TenantStatusActive},
+		),
+		subscriptions: newFakeSubscriptionRepository(),
+		coupons:       newFakeCouponRepository(),
+	}
+	f.useProvider(contract.provider)
+
+	return f
+}
+
+// useProvider sets up the service with a Stripe provider
+func (f *trialCouponFixture) useProvider(provider billing.BillingProvider) {
+	f.service = subscription.NewService(f.subscriptions, f.tenants, &fakeGinRepository{}, &fakePhotoRepository{},
+		&fakeStorageUsageRepository{}, billing.NewRegistry(models.BillingProviderStripe, provider), "https://app.example.com")
+	f.service.SetCouponRepo(f.coupons)
+	f.service.SetTrialDays(map[string]int{"PLAN_PRO_MONTHLY": 14})
+}
+
+// heldProvider holds the checkouts of a tenant at the provider until
+// proceed is closed, or fails them with err
+type heldProvider struct {
+	billing.BillingProvider
+	tenantID int64
+	started  chan struct{} // receives when a held checkout reached the provider, if set
+	proceed  chan struct{}
+	err      error
+}
+
+func (p *heldProvider) CreateSubscription(ctx context.Context, req *billing.CreateSubscriptionRequest) (*billing.Checkout, error) {
+	if req.TenantID != p.tenantID {
+		return p.BillingProvider.CreateSubscription(ctx, req)
+	}
+	if p.started != nil {
+		p.started <- struct{}{}
+		<-p.proceed
+	}
+	if p.err != nil {
+		return nil, p.err
+	}
+	return p.BillingProvider.CreateSubscription(ctx, req)
+}
+
+// createLimitedCoupon creates a coupon with a single redemption
+func (f *trialCouponFixture) createLimitedCoupon(t *testing.T, code string) *models.Coupon {
+	maxRedemptions := 1
+	coupon := &models.Coupon{
+		Code:           code,
+		DiscountType:   models.CouponDiscountPercent,
+		DiscountValue:  50,
+		Duration:       models.CouponDurationOnce,
+		MaxRedemptions: &maxRedemptions,
+		Active:         true,
+	}
+	if err := f.service.CreateCoupon(context.Background(), coupon); err != nil {
+		t.Fatalf("CreateCoupon failed: %v", err)
+	}
+	return coupon
+}
+
+// subscribe checks out a plan with Stripe and activates it
+func (f *trialCouponFixture) subscribe(t *testing.T, tenantID int64, planID, couponCode string) *models.Subscription {
+	ctx := context.Background()
+
+	upgrade, err := f.service.InitiateUpgrade(ctx, tenantID, planID, models.BillingCycleMonthly, "", couponCode)
+	if err != nil {
+		t.Fatalf("InitiateUpgrade failed: %v", err)
+	}
+	f.contract.approve(t, &billing.Checkout{CheckoutID: upgrade.CheckoutID})
+	activated, err := f.service.ActivateCheckout(ctx, tenantID, models.BillingProviderStripe, upgrade.CheckoutID)
+	if err != nil {
+		t.Fatalf("ActivateCheckout failed: %v", err)
+	}
+	return activated
+}
+
+func TestTrialFallsBackToFreeWithoutPayment(t *testing.T) {
+	f := newTrialCouponFixture(t)
+	ctx := context.Background()
+
+	if _, err := f.service.StartTrial(ctx, 1, "PLAN_BASIC_MONTHLY"); err != errors.ErrTrialNotAvailable {
+		t.Fatalf("expected ErrTrialNotAvailable for a plan without trial, got %v", err)
+	}
+
+	trial, err := f.service.StartTrial(ctx, 1, "PLAN_PRO_MONTHLY")
+	if err != nil {
+		t.Fatalf("StartTrial failed: %v", err)
+	}
+	if trial.Status != models.SubscriptionStatusTrialing || trial.TrialEndsAt == nil || f.tenants.tenants[1].Tier != models.TierPro {
+		t.Fatalf("expected a Pro trial, got %+v (tier %s)", trial, f.tenants.tenants[1].Tier)
+	}
+	if days := int(time.Until(*trial.TrialEndsAt).Hours()/24 + 0.5); days != 14 {
+		t.Fatalf("expected a 14 day trial, got %d days", days)
+	}
+
+	// Reminders go out within the reminder window, once
+	f.service.SetNotifications(newFakeUserRepository(), external.NewEmailClient(&external.EmailConfig{}))
+	f.service.SetTrialReminders(15 * 24 * time.Hour)
+	if ended, err := f.service.ProcessTrials(ctx); err != nil || ended != 0 {
+		t.Fatalf("expected no trial to end yet, got %d (%v)", ended, err)
+	}
+
+	// The trial ends without a subscription
+	past := time.Now().Add(-time.Minute)
+	f.subscriptions.subscriptions[trial.ID].TrialEndsAt = &past
+	ended, err := f.service.ProcessTrials(ctx)
+	if err != nil || ended != 1 {
+		t.Fatalf("expected the trial to end, got %d (%v)", ended, err)
+	}
+	if status := f.subscriptions.subscriptions[trial.ID].Status; status != models.SubscriptionStatusExpired {
+		t.Fatalf("expected the trial to expire, got %s", status)
+	}
+	if tier := f.tenants.tenants[1].Tier; tier != models.TierFree {
+		t.Fatalf("expected fall back to Free, got %s", tier)
+	}
+
+	// One trial per tenant
+	if _, err := f.service.StartTrial(ctx, 1, "PLAN_PRO_MONTHLY"); err != errors.ErrTrialNotAvailable {
+		t.Fatalf("expected ErrTrialNotAvailable for a second trial, got %v", err)
+	}
+}
+
+func TestTrialSchedulerRunsOnOneInstance(t *testing.T) {
+	f := newTrialCouponFixture(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	trial, err := f.service.StartTrial(ctx, 1, "PLAN_PRO_MONTHLY")
+	if err != nil {
+		t.Fatalf("StartTrial failed: %v", err)
+	}
+	past := time.Now().Add(-time.Minute)
+	f.subscriptions.subscriptions[trial.ID].TrialEndsAt = &past
+
+	// Another instance runs the job meanwhile
+	locks := newFakeLockRepository("subscription_trials")
+	f.service.SetLockRepo(locks)
+	f.service.StartTrialScheduler(ctx, 5*time.Millisecond)
+
+	time.Sleep(50 * time.Millisecond)
+	if status := f.subscriptions.subscriptions[trial.ID].Status; status != models.SubscriptionStatusTrialing {
+		t.Fatalf("expected the trial to be left to the other instance, got %s", status)
+	}
+
+	locks.runOnce(t, "subscription_trials")
+	if status := f.subscriptions.subscriptions[trial.ID].Status; status != models.SubscriptionStatusExpired {
+		t.Fatalf("expected the trial to end, got %s", status)
+	}
+}
+
+func TestTrialReminderIsSentOnce(t *testing.T) {
+	f := newTrialCouponFixture(t)
+	ctx := context.Background()
+
+	users := newFakeUserRepository()
+	users.Create(ctx, &models.User{TenantID: 1, Email: "owner@ginbar.test", Role: models.RoleOwner, IsActive: true})
+	f.service.SetNotifications(users, external.NewEmailClient(&external.EmailConfig{}))
+	f.service.SetTrialReminders(3 * 24 * time.Hour)
+
+	trial, err := f.service.StartTrial(ctx, 1, "PLAN_PRO_MONTHLY")
+	if err != nil {
+		t.Fatalf("StartTrial failed: %v", err)
+	}
+
+	if _, err := f.service.ProcessTrials(ctx); err != nil {
+		t.Fatalf("ProcessTrials failed: %v", err)
+	}
+	if f.subscriptions.subscriptions[trial.ID].TrialReminderSentAt != nil {
+		t.Fatal("expected no reminder 14 days before the end")
+	}
+
+	soon := time.Now().Add(48 * time.Hour)
+	f.subscriptions.subscriptions[trial.ID].TrialEndsAt = &soon
+	if _, err := f.service.ProcessTrials(ctx); err != nil {
+		t.Fatalf("ProcessTrials failed: %v", err)
+	}
+	sentAt := f.subscriptions.subscriptions[trial.ID].TrialReminderSentAt
+	if sentAt == nil {
+		t.Fatal("expected a reminder 2 days before the end")
+	}
+
+	if _, err := f.service.ProcessTrials(ctx); err != nil {
+		t.Fatalf("ProcessTrials failed: %v", err)
+	}
+	if again := f.subscriptions.subscriptions[trial.ID].TrialReminderSentAt; !again.Equal(*sentAt) {
+		t.Fatal("expected the reminder to be sent once")
+	}
+}
+
+func TestSubscribingEndsTrial(t *testing.T) {
+	f := newTrialCouponFixture(t)
+	ctx := context.Background()
+
+	trial, err := f.service.StartTrial(ctx, 1, "PLAN_PRO_MONTHLY")
+	if err != nil {
+		t.Fatalf("StartTrial failed: %v", err)
+	}
+
+	f.subscribe(t, 1, "PLAN_PRO_MONTHLY", "")
+	if status := f.subscriptions.subscriptions[trial.ID].Status; status != models.SubscriptionStatusCancelled {
+		t.Fatalf("expected the trial to end with the subscription, got %s", status)
+	}
+
+	if ended, err := f.service.ProcessTrials(ctx); err != nil || ended != 0 {
+		t.Fatalf("expected no trial to end, got %d (%v)", ended, err)
+	}
+	if tier := f.tenants.tenants[1].Tier; tier != models.TierPro {
+		t.Fatalf("expected the subscribed tier, got %s", tier)
+	}
+}
+
+func TestCouponAppliedAtUpgrade(t *testing.T) {
+	f := newTrialCouponFixture(t)
+	ctx := context.Background()
+
+	maxRedemptions := 1
+	coupon := &models.Coupon{
+		Code:           " launch20 ",
+		DiscountType:   models.CouponDiscountPercent,
+		DiscountValue:  20,
+		Duration:       models.CouponDurationOnce,
+		MaxRedemptions: &maxRedemptions,
+		PlanIDs:        []string{"PLAN_PRO_MONTHLY"},
+		Active:         true,
+	}
+	if err := f.service.CreateCoupon(ctx, coupon); err != nil {
+		t.Fatalf("CreateCoupon failed: %v", err)
+	}
+	if coupon.Code != "LAUNCH20" {
+		t.Fatalf("expected the code to be normalized, got %q", coupon.Code)
+	}
+
+	if _, err := f.service.PreviewCoupon(ctx, 1, "launch20", "PLAN_BASIC_MONTHLY", models.BillingCycleMonthly); err != errors.ErrCouponNotApplicable {
+		t.Fatalf("expected ErrCouponNotApplicable for another plan, got %v", err)
+	}
+	preview, err := f.service.PreviewCoupon(ctx, 1, "launch20", "PLAN_PRO_MONTHLY", models.BillingCycleMonthly)
+	if err != nil {
+		t.Fatalf("PreviewCoupon failed: %v", err)
+	}
+	if preview.Price != 9.99 || preview.Discount != 2 || preview.DiscountedPrice != 7.99 {
+		t.Fatalf("unexpected preview %+v", preview)
+	}
+
+	activated := f.subscribe(t, 1, "PLAN_PRO_MONTHLY", "launch20")
+	if activated.CouponID == nil || activated.Discount != 2 || activated.DiscountEndsAt == nil {
+		t.Fatalf("expected the discount on the subscription, got %+v", activated)
+	}
+
+	// The checkout carries a single-use Stripe coupon
+	if len(f.stripe.coupons) != 1 || f.stripe.sessionCoupon["cs_1"] != "co_1" {
+		t.Fatalf("expected the coupon on the checkout, got %v / %v", f.stripe.coupons, f.stripe.sessionCoupon)
+	}
+	if params := f.stripe.coupons[0]; params.Get("percent_off") != "20" || params.Get("duration") != "once" || params.Get("max_redemptions") != "1" {
+		t.Fatalf("unexpected Stripe coupon %v", params)
+	}
+
+	if redemptions := f.coupons.coupons[coupon.ID].Redemptions; redemptions != 1 {
+		t.Fatalf("expected one redemption, got %d", redemptions)
+	}
+
+	// The only redemption is used up
+	if _, err := f.service.InitiateUpgrade(ctx, 2, "PLAN_PRO_MONTHLY", models.BillingCycleMonthly, "", "LAUNCH20"); err != errors.ErrInvalidCoupon {
+		t.Fatalf("expected ErrInvalidCoupon for an exhausted coupon, got %v", err)
+	}
+}
+
+func TestCouponRestrictions(t *testing.T) {
+	f := newTrialCouponFixture(t)
+	ctx := context.Background()
+
+	expired := time.Now().Add(-time.Hour)
+	for _, coupon := range []*models.Coupon{
+		{Code: "OLD", DiscountType: models.CouponDiscountFixed, DiscountValue: 5, Duration: models.CouponDurationOnce, ExpiresAt: &expired, Active: true},
+		{Code: "PAUSED", DiscountType: models.CouponDiscountFixed, DiscountValue: 5, Duration: models.CouponDurationOnce},
+		{Code: "WELCOME", DiscountType: models.CouponDiscountFixed, DiscountValue: 5, Duration: models.CouponDurationForever, Active: true},
+	} {
+		if err := f.service.CreateCoupon(ctx, coupon); err != nil {
+			t.Fatalf("CreateCoupon %s failed: %v", coupon.Code, err)
+		}
+	}
+
+	for _, code := range []string{"OLD", "PAUSED", "UNKNOWN"} {
+		if _, err := f.service.PreviewCoupon(ctx, 1, code, "PLAN_PRO_MONTHLY", models.BillingCycleMonthly); err != errors.ErrInvalidCoupon {
+			t.Fatalf("expected ErrInvalidCoupon for %s, got %v", code, err)
+		}
+	}
+
+	// Each tenant redeems a coupon once
+	f.subscribe(t, 1, "PLAN_BASIC_MONTHLY", "welcome")
+	if _, err := f.service.PreviewCoupon(ctx, 1, "WELCOME", "PLAN_PRO_MONTHLY", models.BillingCycleMonthly); err != errors.ErrCouponRedeemed {
+		t.Fatalf("expected ErrCouponRedeemed, got %v", err)
+	}
+	if preview, err := f.service.PreviewCoupon(ctx, 2, "WELCOME", "PLAN_PRO_MONTHLY", models.BillingCycleMonthly); err != nil || preview.DiscountedPrice != 4.99 {
+		t.Fatalf("expected the coupon for another tenant, got %+v (%v)", preview, err)
+	}
+
+	if err := f.service.CreateCoupon(ctx, &models.Coupon{Code: "welcome", DiscountType: models.CouponDiscountPercent, DiscountValue: 10, Duration: models.CouponDurationOnce}); err != errors.ErrCouponCodeTaken {
+		t.Fatalf("expected ErrCouponCodeTaken, got %v", err)
+	}
+}
+
+func TestConcurrentCheckoutsShareCouponLimit(t *testing.T) {
+	f := newTrialCouponFixture(t)
+	ctx := context.Background()
+
+	held := &heldProvider{BillingProvider: f.contract.provider, tenantID: 1, started: make(chan struct{}), proceed: make(chan struct{})}
+	f.useProvider(held)
+	coupon := f.createLimitedCoupon(t, "LAST1")
+
+	first := make(chan error, 1)
+	var upgrade *subscription.UpgradeResponse
+	go func() {
+		var err error
+		upgrade, err = f.service.InitiateUpgrade(ctx, 1, "PLAN_PRO_MONTHLY", models.BillingCycleMonthly, "", "LAST1")
+		first <- err
+	}()
+	<-held.started
+
+	// The second checkout starts while the first one is at the provider
+	if _, err := f.service.InitiateUpgrade(ctx, 2, "PLAN_PRO_MONTHLY", models.BillingCycleMonthly, "", "LAST1"); err != errors.ErrInvalidCoupon {
+		t.Fatalf("expected ErrInvalidCoupon for the second checkout, got %v", err)
+	}
+
+	close(held.proceed)
+	if err := <-first; err != nil {
+		t.Fatalf("InitiateUpgrade failed: %v", err)
+	}
+	if len(f.stripe.coupons) != 1 {
+		t.Fatalf("expected a single checkout with the coupon, got %d", len(f.stripe.coupons))
+	}
+
+	f.contract.approve(t, &billing.Checkout{CheckoutID: upgrade.CheckoutID})
+	if _, err := f.service.ActivateCheckout(ctx, 1, models.BillingProviderStripe, upgrade.CheckoutID); err != nil {
+		t.Fatalf("ActivateCheckout failed: %v", err)
+	}
+
+	redemptions, _ := f.coupons.ListRedemptions(ctx, coupon.ID)
+	if f.coupons.coupons[coupon.ID].Redemptions != 1 || len(redemptions) != 1 ||
+		redemptions[0].TenantID != 1 || redemptions[0].Status != models.CouponRedemptionRedeemed {
+		t.Errorf("expected one redemption by the first tenant, got %d %+v", f.coupons.coupons[coupon.ID].Redemptions, redemptions)
+	}
+}
+
+func TestCouponReservationIsReleased(t *testing.T) {
+	f := newTrialCouponFixture(t)
+	ctx := context.Background()
+
+	f.useProvider(&heldProvider{BillingProvider: f.contract.provider, tenantID: 1, err: stdErrors.New("provider unavailable")})
+	coupon := f.createLimitedCoupon(t, "LAST1")
+
+	// A checkout that can't be started frees its redemption
+	if _, err := f.service.InitiateUpgrade(ctx, 1, "PLAN_PRO_MONTHLY", models.BillingCycleMonthly, "", "LAST1"); err == nil {
+		t.Fatal("expected the checkout to fail")
+	}
+	if redemptions := f.coupons.coupons[coupon.ID].Redemptions; redemptions != 0 {
+		t.Fatalf("expected the redemption to be released, got %d", redemptions)
+	}
+
+	// So does a checkout that isn't completed in time
+	abandoned, err := f.service.InitiateUpgrade(ctx, 2, "PLAN_PRO_MONTHLY", models.BillingCycleMonthly, "", "LAST1")
+	if err != nil {
+		t.Fatalf("InitiateUpgrade failed: %v", err)
+	}
+	if released, err := f.service.ReleaseExpiredCoupons(ctx); err != nil || released != 0 {
+		t.Fatalf("expected no release before the reservation expires, got %d (%v)", released, err)
+	}
+	expired := time.Now().Add(-time.Minute)
+	f.coupons.redemptions[0].ExpiresAt = &expired
+	if released, err := f.service.ReleaseExpiredCoupons(ctx); err != nil || released != 1 {
+		t.Fatalf("expected the expired reservation to be released, got %d (%v)", released, err)
+	}
+
+	f.useProvider(f.contract.provider)
+	f.subscribe(t, 1, "PLAN_PRO_MONTHLY", "LAST1")
+
+	// Completing the abandoned checkout late doesn't exceed the limit
+	f.contract.approve(t, &billing.Checkout{CheckoutID: abandoned.CheckoutID})
+	if _, err := f.service.ActivateCheckout(ctx, 2, models.BillingProviderStripe, abandoned.CheckoutID); err != nil {
+		t.Fatalf("ActivateCheckout failed: %v", err)
+	}
+	redemptions, _ := f.coupons.ListRedemptions(ctx, coupon.ID)
+	if f.coupons.coupons[coupon.ID].Redemptions != 1 || len(redemptions) != 1 || redemptions[0].TenantID != 1 {
+		t.Errorf("expected the only redemption by tenant 1, got %+v", redemptions)
+	}
+}
+
+func TestCouponValidation(t *testing.T) {
+	f := newTrialCouponFixture(t)
+	ctx := context.Background()
+
+	zero := 0
+	for name, coupon := range map[string]*models.Coupon{
+		"percent over 100":       {Code: "A", DiscountType: models.CouponDiscountPercent, DiscountValue: 120, Duration: models.CouponDurationOnce},
+		"negative fixed":         {Code: "B", DiscountType: models.CouponDiscountFixed, DiscountValue: -1, Duration: models.CouponDurationOnce},
+		"unknown type":           {Code: "C", DiscountType: "bogo", DiscountValue: 10, Duration: models.CouponDurationOnce},
+		"repeating without term": {Code: "D", DiscountType: models.CouponDiscountPercent, DiscountValue: 10, Duration: models.CouponDurationRepeating},
+		"no redemptions":         {Code: "E", DiscountType: models.CouponDiscountPercent, DiscountValue: 10, Duration: models.CouponDurationOnce, MaxRedemptions: &zero},
+		"unknown plan":           {Code: "F", DiscountType: models.CouponDiscountPercent, DiscountValue: 10, Duration: models.CouponDurationOnce, PlanIDs: []string{"PLAN_GOLD"}},
+		"empty code":             {Code: " ", DiscountType: models.CouponDiscountPercent, DiscountValue: 10, Duration: models.CouponDurationOnce},
+	} {
+		if err := f.service.CreateCoupon(ctx, coupon); !stdErrors.Is(err, errors.ErrInvalidInput) {
+			t.Errorf("%s: expected ErrInvalidInput, got %v", name, err)
+		}
+	}
+}
+
+func TestPayPalRejectsLimitedDiscounts(t *testing.T) {
+	contract := newPayPalContract(t)
+	ctx := context.Background()
+
+	_, err := contract.provider.CreateSubscription(ctx, &billing.CreateSubscriptionRequest{
+		TenantID:  1,
+		PlanID:    "PLAN_PRO_MONTHLY",
+		ReturnURL: "https://app.example.com/subscription/success",
+		CancelURL: "https://app.example.com/subscription/cancel",
+		Discount:  &billing.Discount{Code: "LAUNCH20", PercentOff: 20, Currency: "EUR", Duration: billing.DiscountOnce, Price: 7.99},
+	})
+	if err != billing.ErrDiscountNotSupported {
+		t.Fatalf("expected ErrDiscountNotSupported, got %v", err)
+	}
+
+	checkout, err := contract.provider.CreateSubscription(ctx, &billing.CreateSubscriptionRequest{
+		TenantID:  1,
+		PlanID:    "PLAN_PRO_MONTHLY",
+		ReturnURL: "https://app.example.com/subscription/success",
+		CancelURL: "https://app.example.com/subscription/cancel",
+		Discount:  &billing.Discount{Code: "WELCOME", AmountOff: 5, Currency: "EUR", Duration: billing.DiscountForever, Price: 4.99},
+	})
+	if err != nil || checkout.RedirectURL == "" {
+		t.Fatalf("expected a permanent discount to be accepted, got %+v (%v)", checkout, err)
+	}
+}
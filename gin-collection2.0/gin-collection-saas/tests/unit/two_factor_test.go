@@ -0,0 +1,289 @@
+package unit
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/yourusername/gin-collection-saas/internal/domain/errors"
+	"github.com/yourusername/gin-collection-saas/internal/domain/models"
+	"github.com/yourusername/gin-collection-saas/internal/usecase/twofactor"
+	"github.com/yourusername/gin-collection-saas/pkg/utils"
+)
+
+// fakeTwoFactorRepository keeps credentials, recovery codes and trusted devices in memory
+type fakeTwoFactorRepository struct {
+	nextID        int64
+	credentials   map[string]*models.TwoFactorCredential
+	recoveryCodes map[string]map[string]bool // subject key -> hash -> used
+	devices       []*models.TrustedDevice
+}
+
+func newFakeTwoFactorRepository() *fakeTwoFactorRepository {
+	return &fakeTwoFactorRepository{
+		credentials:   make(map[string]*models.TwoFactorCredential),
+		recoveryCodes: make(map[string]map[string]bool),
+	}
+}
+
+func subjectKey(subjectType string, subjectID int64) string {
+	return fmt.Sprintf("%s:%d", subjectType, subjectID)
+}
+
+func (r *fakeTwoFactorRepository) GetCredential(ctx context.Context, subjectType string, subjectID int64) (*models.TwoFactorCredential, error) {
+	credential, ok := r.credentials[subjectKey(subjectType, subjectID)]
+	if !ok {
+		return nil, errors.ErrNotFound
+	}
+	copied := *credential
+	return &copied, nil
+}
+
+func (r *fakeTwoFactorRepository) SaveCredential(ctx context.Context, credential *models.TwoFactorCredential) error {
+	r.nextID++
+	credential.ID = r.nextID
+	credential.ConfirmedAt = nil
+	credential.LastUsedStep = nil
+	r.credentials[subjectKey(credential.SubjectType, credential.SubjectID)] = credential
+	return nil
+}
+
+func (r *fakeTwoFactorRepository) byID(id int64) *models.TwoFactorCredential {
+	for _, credential := range r.credentials {
+		if credential.ID == id {
+			return credential
+		}
+	}
+	return nil
+}
+
+func (r *fakeTwoFactorRepository) ConfirmCredential(ctx context.Context, id int64) error {
+	now := time.Now()
+	r.byID(id).ConfirmedAt = &now
+	return nil
+}
+
+func (r *fakeTwoFactorRepository) UseStep(ctx context.Context, id int64, step int64) (bool, error) {
+	credential := r.byID(id)
+	if credential.LastUsedStep != nil && *credential.LastUsedStep >= step {
+		return false, nil
+	}
+	credential.LastUsedStep = &step
+	return true, nil
+}
+
+func (r *fakeTwoFactorRepository) Delete(ctx context.Context, subjectType string, subjectID int64) error {
+	key := subjectKey(subjectType, subjectID)
+	delete(r.credentials, key)
+	delete(r.recoveryCodes, key)
+	return r.DeleteTrustedDevices(ctx, subjectType, subjectID)
+}
+
+func (r *fakeTwoFactorRepository) ReplaceRecoveryCodes(ctx context.Context, subjectType string, subjectID int64, codeHashes []string) error {
+	codes := make(map[string]bool)
+	for _, hash := range codeHashes {
+		codes[hash] = false
+	}
+	r.recoveryCodes[subjectKey(subjectType, subjectID)] = codes
+	return nil
+}
+
+func (r *fakeTwoFactorRepository) UseRecoveryCode(ctx context.Context, subjectType string, subjectID int64, codeHash string) (bool, error) {
+	codes := r.recoveryCodes[subjectKey(subjectType, subjectID)]
+	used, ok := codes[codeHash]
+	if !ok || used {
+		return false, nil
+	}
+	codes[codeHash] = true
+	return true, nil
+}
+
+func (r *fakeTwoFactorRepository) CountRecoveryCodes(ctx context.Context, subjectType string, subjectID int64) (int, error) {
+	count := 0
+	for _, used := range r.recoveryCodes[subjectKey(subjectType, subjectID)] {
+		if !used {
+			count++
+		}
+	}
+	return count, nil
+}
+
+func (r *fakeTwoFactorRepository) CreateTrustedDevice(ctx context.Context, device *models.TrustedDevice) error {
+	r.devices = append(r.devices, device)
+	return nil
+}
+
+func (r *fakeTwoFactorRepository) IsTrustedDevice(ctx context.Context, subjectType string, subjectID int64, tokenHash string) (bool, error) {
+	for _, device := range r.devices {
+		if device.SubjectType == subjectType && device.SubjectID == subjectID &&
+			device.TokenHash == tokenHash && device.ExpiresAt.After(time.Now()) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (r *fakeTwoFactorRepository) DeleteTrustedDevices(ctx context.Context, subjectType string, subjectID int64) error {
+	kept := r.devices[:0]
+	for _, device := range r.devices {
+		if device.SubjectType != subjectType || device.SubjectID != subjectID {
+			kept = append(kept, device)
+		}
+	}
+	r.devices = kept
+	return nil
+}
+
+// currentCode computes the code an authenticator app would show right now
+func currentCode(t *testing.T, secret string, offset int64) string {
+	t.Helper()
+	code, err := utils.TOTPCode(secret, utils.TOTPStep(time.Now())+offset)
+	if err != nil {
+		t.Fatalf("failed to compute code: %v", err)
+	}
+	return code
+}
+
+// enroll runs the enrollment flow and returns secret and recovery codes
+func enroll(t *testing.T, service *twofactor.Service) (string, []string) {
+	t.Helper()
+	ctx := context.Background()
+
+	enrollment, err := service.BeginEnrollment(ctx, models.TwoFactorSubjectUser, 1, "jane@example.com")
+	if err != nil {
+		t.Fatalf("BeginEnrollment failed: %v", err)
+	}
+
+	codes, err := service.ConfirmEnrollment(ctx, models.TwoFactorSubjectUser, 1, currentCode(t, enrollment.Secret, -1))
+	if err != nil {
+		t.Fatalf("ConfirmEnrollment failed: %v", err)
+	}
+
+	return enrollment.Secret, codes
+}
+
+func TestTOTPCode_RFC6238Vectors(t *testing.T) {
+	// Secret "12345678901234567890", SHA1 vectors truncated to 6 digits
+	secret := "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+	tests := []struct {
+		unix int64
+		want string
+	}{
+		{59, "287082"},
+		{1111111109, "081804"},
+		{1234567890, "005924"},
+		{2000000000, "279037"},
+	}
+
+	for _, tt := range tests {
+		got, err := utils.TOTPCode(secret, utils.TOTPStep(time.Unix(tt.unix, 0)))
+		if err != nil {
+			t.Fatalf("TOTPCode(%d) failed: %v", tt.unix, err)
+		}
+		if got != tt.want {
+			t.Errorf("TOTPCode(%d) = %s, want %s", tt.unix, got, tt.want)
+		}
+	}
+}
+
+func TestTwoFactor_EnrollmentAndReplay(t *testing.T) {
+	ctx := context.Background()
+	service := twofactor.NewService(newFakeTwoFactorRepository(), "Gin Collection")
+
+	enrollment, err := service.BeginEnrollment(ctx, models.TwoFactorSubjectUser, 1, "jane@example.com")
+	if err != nil {
+		t.Fatalf("BeginEnrollment failed: %v", err)
+	}
+	if !strings.HasPrefix(enrollment.OTPAuthURI, "otpauth://totp/Gin%20Collection:jane@example.com?") {
+		t.Errorf("unexpected otpauth URI: %s", enrollment.OTPAuthURI)
+	}
+
+	if enabled, _ := service.IsEnabled(ctx, models.TwoFactorSubjectUser, 1); enabled {
+		t.Fatal("expected 2FA to stay disabled until confirmed")
+	}
+
+	if _, err := service.ConfirmEnrollment(ctx, models.TwoFactorSubjectUser, 1, "abcdef"); err != errors.ErrInvalidTwoFactorCode {
+		t.Errorf("expected ErrInvalidTwoFactorCode for a wrong code, got %v", err)
+	}
+
+	codes, err := service.ConfirmEnrollment(ctx, models.TwoFactorSubjectUser, 1, currentCode(t, enrollment.Secret, -1))
+	if err != nil {
+		t.Fatalf("ConfirmEnrollment failed: %v", err)
+	}
+	if len(codes) != models.RecoveryCodeCount {
+		t.Errorf("expected %d recovery codes, got %d", models.RecoveryCodeCount, len(codes))
+	}
+
+	if _, err := service.BeginEnrollment(ctx, models.TwoFactorSubjectUser, 1, "jane@example.com"); err != errors.ErrTwoFactorAlreadyEnabled {
+		t.Errorf("expected ErrTwoFactorAlreadyEnabled, got %v", err)
+	}
+
+	code := currentCode(t, enrollment.Secret, 0)
+	if err := service.Verify(ctx, models.TwoFactorSubjectUser, 1, code, ""); err != nil {
+		t.Fatalf("Verify failed: %v", err)
+	}
+	if err := service.Verify(ctx, models.TwoFactorSubjectUser, 1, code, ""); err != errors.ErrInvalidTwoFactorCode {
+		t.Errorf("expected replayed code to be rejected, got %v", err)
+	}
+}
+
+func TestTwoFactor_RecoveryCodesAreSingleUse(t *testing.T) {
+	ctx := context.Background()
+	service := twofactor.NewService(newFakeTwoFactorRepository(), "Gin Collection")
+	_, codes := enroll(t, service)
+
+	// Codes are accepted regardless of case and dashes
+	code := strings.ToUpper(strings.ReplaceAll(codes[0], "-", ""))
+	if err := service.Verify(ctx, models.TwoFactorSubjectUser, 1, "", code); err != nil {
+		t.Fatalf("Verify with recovery code failed: %v", err)
+	}
+	if err := service.Verify(ctx, models.TwoFactorSubjectUser, 1, "", codes[0]); err != errors.ErrInvalidTwoFactorCode {
+		t.Errorf("expected used recovery code to be rejected, got %v", err)
+	}
+
+	status, err := service.Status(ctx, models.TwoFactorSubjectUser, 1)
+	if err != nil {
+		t.Fatalf("Status failed: %v", err)
+	}
+	if !status.Enabled || status.RecoveryCodesRemaining != models.RecoveryCodeCount-1 {
+		t.Errorf("unexpected status: %+v", status)
+	}
+
+	if err := service.Disable(ctx, models.TwoFactorSubjectUser, 1, "", codes[1]); err != nil {
+		t.Fatalf("Disable failed: %v", err)
+	}
+	if err := service.Verify(ctx, models.TwoFactorSubjectUser, 1, "", codes[2]); err != errors.ErrTwoFactorNotEnabled {
+		t.Errorf("expected ErrTwoFactorNotEnabled after disable, got %v", err)
+	}
+}
+
+func TestTwoFactor_TrustedDevices(t *testing.T) {
+	ctx := context.Background()
+	service := twofactor.NewService(newFakeTwoFactorRepository(), "Gin Collection")
+	enroll(t, service)
+
+	token, err := service.TrustDevice(ctx, models.TwoFactorSubjectUser, 1, "test-agent")
+	if err != nil {
+		t.Fatalf("TrustDevice failed: %v", err)
+	}
+
+	if !service.IsTrustedDevice(ctx, models.TwoFactorSubjectUser, 1, token) {
+		t.Error("expected device to be trusted")
+	}
+	if service.IsTrustedDevice(ctx, models.TwoFactorSubjectUser, 2, token) {
+		t.Error("expected token to be bound to its subject")
+	}
+	if service.IsTrustedDevice(ctx, models.TwoFactorSubjectUser, 1, "") {
+		t.Error("expected empty token to be untrusted")
+	}
+
+	if err := service.ForgetDevices(ctx, models.TwoFactorSubjectUser, 1); err != nil {
+		t.Fatalf("ForgetDevices failed: %v", err)
+	}
+	if service.IsTrustedDevice(ctx, models.TwoFactorSubjectUser, 1, token) {
+		t.Error("expected device to be forgotten")
+	}
+}
@@ -0,0 +1,408 @@
+package unit
+
+import (
+	"context"
+	"encoding/json"
+	stdErrors "errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/yourusername/gin-collection-saas/internal/delivery/http/middleware"
+	"github.com/yourusername/gin-collection-saas/internal/domain/errors"
+	"github.com/yourusername/gin-collection-saas/internal/domain/models"
+	"github.com/yourusername/gin-collection-saas/internal/domain/repositories"
+	"github.com/yourusername/gin-collection-saas/internal/usecase/entitlement"
+	"github.com/yourusername/gin-collection-saas/internal/usecase/usage"
+)
+
+// fakeUsageMetricsRepository keeps metered usage in memory, by tenant, metric
+// and period start
+type fakeUsageMetricsRepository struct {
+	repositories.UsageMetricsRepository
+	metrics []*models.UsageMetric
+}
+
+func (r *fakeUsageMetricsRepository) find(tenantID int64, metricName string, periodStart time.Time) *models.UsageMetric {
+	for _, metric := range r.metrics {
+		if metric.TenantID == tenantID && metric.MetricName == metricName && metric.PeriodStart.Equal(periodStart) {
+			return metric
+		}
+	}
+	return nil
+}
+
+func (r *fakeUsageMetricsRepository) GetPeriodValue(ctx context.Context, tenantID int64, metricName string, periodStart time.Time) (int, error) {
+	if metric := r.find(tenantID, metricName, periodStart); metric != nil {
+		return metric.CurrentValue, nil
+	}
+	return 0, nil
+}
+
+func (r *fakeUsageMetricsRepository) Record(ctx context.Context, metric *models.UsageMetric, delta int) error {
+	existing := r.find(metric.TenantID, metric.MetricName, metric.PeriodStart)
+	if existing == nil {
+		existing = &models.UsageMetric{TenantID: metric.TenantID, MetricName: metric.MetricName, PeriodStart: metric.PeriodStart}
+		r.metrics = append(r.metrics, existing)
+	}
+	existing.CurrentValue += delta
+	existing.LimitValue = metric.LimitValue
+	existing.PeriodEnd = metric.PeriodEnd
+	return nil
+}
+
+func (r *fakeUsageMetricsRepository) RecordPeak(ctx context.Context, metric *models.UsageMetric) error {
+	existing := r.find(metric.TenantID, metric.MetricName, metric.PeriodStart)
+	if existing == nil {
+		copied := *metric
+		r.metrics = append(r.metrics, &copied)
+		return nil
+	}
+	if metric.CurrentValue > existing.CurrentValue {
+		existing.CurrentValue = metric.CurrentValue
+	}
+	existing.LimitValue = metric.LimitValue
+	existing.PeriodEnd = metric.PeriodEnd
+	return nil
+}
+
+func (r *fakeUsageMetricsRepository) ListSince(ctx context.Context, tenantID int64, since time.Time) ([]*models.UsageMetric, error) {
+	var metrics []*models.UsageMetric
+	// Newest period first, like the database
+	for i := len(r.metrics) - 1; i >= 0; i-- {
+		metric := r.metrics[i]
+		if metric.TenantID == tenantID && !metric.PeriodStart.Before(since) {
+			metrics = append(metrics, metric)
+		}
+	}
+	return metrics, nil
+}
+
+type usageFixture struct {
+	service       *usage.Service
+	usage         *fakeUsageMetricsRepository
+	tenants       *fakeTenantRepository
+	subscriptions *fakeSubscriptionRepository
+	audit         *fakeAuditLogRepository
+	tenant        *models.Tenant
+}
+
+// newUsageFixture creates a tenant of a tier without a subscription, so its
+// billing period is the calendar month
+func newUsageFixture(t *testing.T, tier models.SubscriptionTier) *usageFixture {
+	f := &usageFixture{
+		usage:         &fakeUsageMetricsRepository{},
+		subscriptions: newFakeSubscriptionRepository(),
+		audit:         &fakeAuditLogRepository{},
+		tenant:        &models.Tenant{ID: 1, Name: "Gin Club", Tier: tier},
+	}
+	f.tenants = newFakeTenantRepository(f.tenant)
+	f.service = usage.NewService(f.usage, f.tenants, f.subscriptions, &fakeStorageUsageRepository{bytesUsed: 3*models.BytesPerMB + 1})
+	f.service.SetAuditLogRepository(f.audit)
+	return f
+}
+
+func monthStart(t time.Time) time.Time {
+	t = t.UTC()
+	return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+}
+
+func TestLimitOverridesApplyOnTopOfPlan(t *testing.T) {
+	pro := &models.Tenant{ID: 1, Tier: models.TierPro, LimitOverrides: &models.LimitOverrides{MaxGins: intPtr(150)}}
+	limits := pro.GetLimits()
+	if limits.MaxGins == nil || *limits.MaxGins != 150 {
+		t.Fatalf("expected 150 gins for the Pro tenant, got %v", limits.MaxGins)
+	}
+	if limits.StorageLimitMB == nil || *limits.StorageLimitMB != *models.PlanLimitsMap[models.TierPro].StorageLimitMB {
+		t.Errorf("expected the plan's storage limit to be kept, got %v", limits.StorageLimitMB)
+	}
+
+	enterprise := &models.Tenant{ID: 2, Tier: models.TierEnterprise, LimitOverrides: &models.LimitOverrides{StorageLimitMB: intPtr(50 * 1024)}}
+	if limits := enterprise.GetLimits(); limits.StorageLimitMB == nil || *limits.StorageLimitMB != 50*1024 {
+		t.Errorf("expected 50GB storage for the Enterprise tenant, got %v", limits.StorageLimitMB)
+	}
+
+	// -1 lifts a limit of the plan
+	basic := &models.Tenant{ID: 3, Tier: models.TierBasic, LimitOverrides: &models.LimitOverrides{MaxGins: intPtr(-1)}}
+	if limits := basic.GetLimits(); limits.MaxGins != nil {
+		t.Errorf("expected unlimited gins, got %d", *limits.MaxGins)
+	}
+
+	// The overrides don't change the plan limits of other tenants
+	if *models.PlanLimitsMap[models.TierPro].MaxGins != 100 {
+		t.Errorf("expected the Pro plan to keep 100 gins, got %d", *models.PlanLimitsMap[models.TierPro].MaxGins)
+	}
+}
+
+func TestBillingRestrictedTenantIgnoresOverrides(t *testing.T) {
+	tenant := &models.Tenant{
+		ID:                1,
+		Tier:              models.TierPro,
+		BillingRestricted: true,
+		LimitOverrides:    &models.LimitOverrides{MaxGins: intPtr(150)},
+	}
+
+	limits := tenant.EnforcedLimits()
+	if limits.MaxGins == nil || *limits.MaxGins != *models.PlanLimitsMap[models.TierFree].MaxGins {
+		t.Errorf("expected the Free gin limit while restricted, got %v", limits.MaxGins)
+	}
+}
+
+func TestUsageLimitReachedInBillingPeriod(t *testing.T) {
+	f := newUsageFixture(t, models.TierPro)
+	f.tenant.LimitOverrides = &models.LimitOverrides{MaxAISuggestionsPerPeriod: intPtr(2)}
+	ctx := context.Background()
+
+	for i := 0; i < 2; i++ {
+		if _, err := f.service.Check(ctx, f.tenant, models.MetricAISuggestions); err != nil {
+			t.Fatalf("suggestion %d: expected to be within the limit, got %v", i+1, err)
+		}
+		if err := f.service.Record(ctx, f.tenant, models.MetricAISuggestions, 1); err != nil {
+			t.Fatalf("Record failed: %v", err)
+		}
+	}
+
+	meter, err := f.service.Check(ctx, f.tenant, models.MetricAISuggestions)
+	if err != errors.ErrUsageLimitReached {
+		t.Fatalf("expected ErrUsageLimitReached, got %v", err)
+	}
+	if meter.Current != 2 || *meter.Limit != 2 || meter.Percentage != 100 {
+		t.Errorf("unexpected meter: %+v", meter)
+	}
+
+	// The counter belongs to the current calendar month
+	recorded := f.usage.find(f.tenant.ID, models.MetricAISuggestions, monthStart(time.Now()))
+	if recorded == nil || recorded.LimitValue == nil || *recorded.LimitValue != 2 {
+		t.Fatalf("expected the usage to be recorded with its limit, got %+v", recorded)
+	}
+
+	// Without a limit nothing is checked
+	if meter, err := f.service.Check(ctx, f.tenant, models.MetricAPICalls); meter != nil || err != nil {
+		t.Errorf("expected unlimited API calls, got %+v, %v", meter, err)
+	}
+}
+
+func TestUsagePeriodFollowsSubscription(t *testing.T) {
+	f := newUsageFixture(t, models.TierPro)
+	ctx := context.Background()
+
+	start := time.Now().UTC().Add(-10 * 24 * time.Hour)
+	end := start.AddDate(0, 1, 0)
+	f.subscriptions.Create(ctx, &models.Subscription{
+		TenantID:           f.tenant.ID,
+		Status:             models.SubscriptionStatusActive,
+		CurrentPeriodStart: &start,
+		CurrentPeriodEnd:   &end,
+	})
+
+	periodStart, periodEnd, err := f.service.Period(ctx, f.tenant.ID)
+	if err != nil {
+		t.Fatalf("Period failed: %v", err)
+	}
+	wantStart := time.Date(start.Year(), start.Month(), start.Day(), 0, 0, 0, 0, time.UTC)
+	if !periodStart.Equal(wantStart) || !periodEnd.Equal(time.Date(end.Year(), end.Month(), end.Day(), 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("expected the subscription's period, got %s - %s", periodStart, periodEnd)
+	}
+
+	// A cancelled subscription falls back to the calendar month
+	subscription, _ := f.subscriptions.GetByTenantID(ctx, f.tenant.ID)
+	subscription.Status = models.SubscriptionStatusCancelled
+	f.subscriptions.Update(ctx, subscription)
+
+	periodStart, periodEnd, _ = f.service.Period(ctx, f.tenant.ID)
+	if !periodStart.Equal(monthStart(time.Now())) || !periodEnd.Equal(periodStart.AddDate(0, 1, 0)) {
+		t.Errorf("expected the calendar month, got %s - %s", periodStart, periodEnd)
+	}
+}
+
+func TestUsageReportShowsCurrentPeriodAndHistory(t *testing.T) {
+	f := newUsageFixture(t, models.TierPro)
+	f.tenant.LimitOverrides = &models.LimitOverrides{MaxAPICallsPerPeriod: intPtr(1000)}
+	ctx := context.Background()
+
+	current := monthStart(time.Now())
+	for i := 3; i >= 1; i-- {
+		start := current.AddDate(0, -i, 0)
+		f.usage.Record(ctx, &models.UsageMetric{
+			TenantID:    f.tenant.ID,
+			MetricName:  models.MetricAPICalls,
+			LimitValue:  intPtr(500),
+			PeriodStart: start,
+			PeriodEnd:   start.AddDate(0, 1, 0),
+		}, 100*i)
+	}
+	// Counters that aren't metered per period stay out of the report
+	f.usage.Record(ctx, &models.UsageMetric{TenantID: f.tenant.ID, MetricName: "gin_count", PeriodStart: current.AddDate(0, -1, 0)}, 42)
+	f.service.Record(ctx, f.tenant, models.MetricAPICalls, 250)
+
+	report, err := f.service.Report(ctx, f.tenant, 2)
+	if err != nil {
+		t.Fatalf("Report failed: %v", err)
+	}
+
+	meters := map[string]*models.UsageMeter{}
+	for _, meter := range report.Current.Meters {
+		meters[meter.Metric] = meter
+	}
+	if len(meters) != len(models.MeteredMetrics) {
+		t.Fatalf("expected a meter per metered metric, got %d", len(meters))
+	}
+	if api := meters[models.MetricAPICalls]; api.Current != 250 || *api.Limit != 1000 || api.Percentage != 25 || !api.Overridden {
+		t.Errorf("unexpected API call meter: %+v", api)
+	}
+	if storage := meters[models.MetricStorageMB]; storage.Current != 4 || storage.Overridden {
+		t.Errorf("expected 4MB storage (rounded up) against the plan limit, got %+v", storage)
+	}
+	if ai := meters[models.MetricAISuggestions]; ai.Limit != nil || ai.Reached {
+		t.Errorf("expected unlimited AI suggestions, got %+v", ai)
+	}
+
+	if len(report.History) != 2 {
+		t.Fatalf("expected 2 earlier periods, got %d", len(report.History))
+	}
+	last := report.History[0]
+	if !last.PeriodStart.Equal(current.AddDate(0, -1, 0)) || len(last.Meters) != 1 {
+		t.Fatalf("expected last month first with only metered usage, got %+v", last)
+	}
+	if meter := last.Meters[0]; meter.Current != 100 || *meter.Limit != 500 || meter.Percentage != 20 {
+		t.Errorf("expected the limit of the period to be kept, got %+v", meter)
+	}
+
+	// The dashboard records the storage peak of the period
+	if storage := f.usage.find(f.tenant.ID, models.MetricStorageMB, current); storage == nil || storage.CurrentValue != 4 {
+		t.Errorf("expected the storage peak to be recorded, got %+v", storage)
+	}
+}
+
+func TestSetLimitOverrides(t *testing.T) {
+	f := newUsageFixture(t, models.TierEnterprise)
+	ctx := context.Background()
+
+	for name, overrides := range map[string]*models.LimitOverrides{
+		"zero gins":           {MaxGins: intPtr(0)},
+		"negative storage":    {StorageLimitMB: intPtr(-5)},
+		"negative api calls":  {MaxAPICallsPerPeriod: intPtr(-2)},
+		"negative rate limit": {APIRateLimit: intPtr(-1)},
+	} {
+		if _, err := f.service.SetOverrides(ctx, f.tenant.ID, overrides, 7); !stdErrors.Is(err, errors.ErrInvalidInput) {
+			t.Errorf("%s: expected ErrInvalidInput, got %v", name, err)
+		}
+	}
+	if len(f.audit.logs) != 0 {
+		t.Fatalf("expected invalid overrides not to be audited, got %d entries", len(f.audit.logs))
+	}
+
+	tenant, err := f.service.SetOverrides(ctx, f.tenant.ID, &models.LimitOverrides{StorageLimitMB: intPtr(50 * 1024)}, 7)
+	if err != nil {
+		t.Fatalf("SetOverrides failed: %v", err)
+	}
+	if limits := tenant.GetLimits(); limits.StorageLimitMB == nil || *limits.StorageLimitMB != 50*1024 {
+		t.Errorf("expected 50GB storage, got %v", limits.StorageLimitMB)
+	}
+	if stored := f.tenants.tenants[f.tenant.ID].LimitOverrides; stored == nil || *stored.StorageLimitMB != 50*1024 {
+		t.Errorf("expected the overrides to be stored, got %+v", stored)
+	}
+
+	if len(f.audit.logs) != 1 || f.audit.logs[0].Action != string(models.AuditActionOverrideLimits) {
+		t.Fatalf("expected the change to be audited, got %+v", f.audit.logs)
+	}
+	var changes map[string]interface{}
+	json.Unmarshal([]byte(*f.audit.logs[0].Changes), &changes)
+	if changes["before"] != nil || changes["platform_admin_id"] != float64(7) {
+		t.Errorf("unexpected audited changes: %v", changes)
+	}
+
+	// No limits remove the overrides
+	if _, err := f.service.SetOverrides(ctx, f.tenant.ID, &models.LimitOverrides{}, 7); err != nil {
+		t.Fatalf("SetOverrides failed: %v", err)
+	}
+	if f.tenants.tenants[f.tenant.ID].LimitOverrides != nil {
+		t.Error("expected the overrides to be removed")
+	}
+
+	if _, err := f.service.SetOverrides(ctx, 99, nil, 7); err != errors.ErrTenantNotFound {
+		t.Errorf("expected ErrTenantNotFound, got %v", err)
+	}
+}
+
+func TestAPICallsMeteredForAPIKeys(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	f := newUsageFixture(t, models.TierPro)
+	f.tenant.LimitOverrides = &models.LimitOverrides{MaxAPICallsPerPeriod: intPtr(2)}
+
+	router := gin.New()
+	router.Use(func(c *gin.Context) {
+		c.Set("tenant", f.tenant)
+		c.Set("tenant_id", f.tenant.ID)
+		if c.GetHeader("X-API-Key") != "" {
+			c.Set("api_key", &models.APIKey{ID: 1, TenantID: f.tenant.ID})
+		}
+	}, middleware.NewUsageMeteringMiddleware(f.service, entitlement.NewService(nil)).MeterAPICalls())
+	router.GET("/api/v1/gins", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	request := func(apiKey bool) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/gins", nil)
+		if apiKey {
+			req.Header.Set("X-API-Key", "gck_test")
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	for i := 0; i < 2; i++ {
+		if w := request(true); w.Code != http.StatusOK {
+			t.Fatalf("call %d: expected 200, got %d", i+1, w.Code)
+		}
+	}
+
+	w := request(true)
+	if w.Code != http.StatusForbidden {
+		t.Fatalf("expected the third API call to be refused, got %d", w.Code)
+	}
+	var body map[string]interface{}
+	json.Unmarshal(w.Body.Bytes(), &body)
+	if body["feature"] != models.MetricAPICalls || body["reason"] != string(models.DenialLimitReached) ||
+		body["upgrade_required"] != true || body["limit"] != float64(2) || body["current_count"] != float64(2) {
+		t.Errorf("unexpected response: %v", body)
+	}
+
+	// Requests of the web app aren't metered
+	if w := request(false); w.Code != http.StatusOK {
+		t.Errorf("expected session requests to pass, got %d", w.Code)
+	}
+	if used, _ := f.usage.GetPeriodValue(context.Background(), f.tenant.ID, models.MetricAPICalls, monthStart(time.Now())); used != 2 {
+		t.Errorf("expected 2 metered API calls, got %d", used)
+	}
+}
+
+func TestAISuggestionsMeteredOnlyOnSuccess(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	f := newUsageFixture(t, models.TierPro)
+	status := http.StatusBadGateway
+
+	router := gin.New()
+	router.Use(func(c *gin.Context) {
+		c.Set("tenant", f.tenant)
+		c.Set("tenant_id", f.tenant.ID)
+	})
+	router.POST("/api/v1/ai/suggest-gin", middleware.NewUsageMeteringMiddleware(f.service, entitlement.NewService(nil)).Meter(models.MetricAISuggestions),
+		func(c *gin.Context) { c.Status(status) })
+
+	serve := func() {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/api/v1/ai/suggest-gin", nil))
+	}
+
+	serve()
+	status = http.StatusOK
+	serve()
+
+	if used, _ := f.usage.GetPeriodValue(context.Background(), f.tenant.ID, models.MetricAISuggestions, monthStart(time.Now())); used != 1 {
+		t.Errorf("expected only the successful suggestion to be metered, got %d", used)
+	}
+}
@@ -0,0 +1,543 @@
+package unit
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/json"
+	"testing"
+
+	"github.com/yourusername/gin-collection-saas/internal/domain/errors"
+	"github.com/yourusername/gin-collection-saas/internal/domain/models"
+	"github.com/yourusername/gin-collection-saas/internal/usecase/webauthn"
+	"github.com/yourusername/gin-collection-saas/pkg/utils"
+)
+
+const (
+	testRPID   = "gin.example.com"
+	testOrigin = "https://acme.gin.example.com"
+)
+
+// fakeWebAuthnRepository keeps passkeys and sessions in memory
+type fakeWebAuthnRepository struct {
+	nextID      int64
+	credentials []*models.WebAuthnCredential
+	sessions    map[string]*models.WebAuthnSession
+}
+
+func newFakeWebAuthnRepository() *fakeWebAuthnRepository {
+	return &fakeWebAuthnRepository{sessions: make(map[string]*models.WebAuthnSession)}
+}
+
+func (r *fakeWebAuthnRepository) CreateCredential(ctx context.Context, credential *models.WebAuthnCredential) error {
+	r.nextID++
+	credential.ID = r.nextID
+	r.credentials = append(r.credentials, credential)
+	return nil
+}
+
+func (r *fakeWebAuthnRepository) GetCredentialByCredentialID(ctx context.Context, credentialID string) (*models.WebAuthnCredential, error) {
+	for _, credential := range r.credentials {
+		if credential.CredentialID == credentialID {
+			copied := *credential
+			return &copied, nil
+		}
+	}
+	return nil, errors.ErrNotFound
+}
+
+func (r *fakeWebAuthnRepository) ListCredentials(ctx context.Context, subjectType string, subjectID int64) ([]*models.WebAuthnCredential, error) {
+	var result []*models.WebAuthnCredential
+	for _, credential := range r.credentials {
+		if credential.SubjectType == subjectType && credential.SubjectID == subjectID {
+			result = append(result, credential)
+		}
+	}
+	return result, nil
+}
+
+func (r *fakeWebAuthnRepository) UpdateCredentialUsage(ctx context.Context, id int64, signCount uint32, backedUp bool) error {
+	for _, credential := range r.credentials {
+		if credential.ID == id {
+			credential.SignCount = signCount
+			credential.BackedUp = backedUp
+		}
+	}
+	return nil
+}
+
+func (r *fakeWebAuthnRepository) DeleteCredential(ctx context.Context, subjectType string, subjectID int64, id int64) error {
+	for i, credential := range r.credentials {
+		if credential.ID == id && credential.SubjectType == subjectType && credential.SubjectID == subjectID {
+			r.credentials = append(r.credentials[:i], r.credentials[i+1:]...)
+			return nil
+		}
+	}
+	return errors.ErrNotFound
+}
+
+func (r *fakeWebAuthnRepository) CreateSession(ctx context.Context, session *models.WebAuthnSession) error {
+	r.sessions[session.ID] = session
+	return nil
+}
+
+func (r *fakeWebAuthnRepository) ConsumeSession(ctx context.Context, id string) (*models.WebAuthnSession, error) {
+	session, ok := r.sessions[id]
+	if !ok {
+		return nil, errors.ErrNotFound
+	}
+	delete(r.sessions, id)
+	return session, nil
+}
+
+func (r *fakeWebAuthnRepository) DeleteExpiredSessions(ctx context.Context) (int64, error) {
+	return 0, nil
+}
+
+// cborEntry is a map entry for the test CBOR encoder (keeps key order)
+type cborEntry struct {
+	key   interface{}
+	value interface{}
+}
+
+// cborEncode encodes the few CBOR types authenticators emit
+func cborEncode(value interface{}) []byte {
+	head := func(major byte, n uint64) []byte {
+		switch {
+		case n < 24:
+			return []byte{major<<5 | byte(n)}
+		case n < 256:
+			return []byte{major<<5 | 24, byte(n)}
+		case n < 65536:
+			return []byte{major<<5 | 25, byte(n >> 8), byte(n)}
+		}
+		b := []byte{major<<5 | 26, 0, 0, 0, 0}
+		binary.BigEndian.PutUint32(b[1:], uint32(n))
+		return b
+	}
+
+	switch v := value.(type) {
+	case int:
+		if v < 0 {
+			return head(1, uint64(-1-v))
+		}
+		return head(0, uint64(v))
+	case int64:
+		return cborEncode(int(v))
+	case string:
+		return append(head(3, uint64(len(v))), v...)
+	case []byte:
+		return append(head(2, uint64(len(v))), v...)
+	case []interface{}:
+		out := head(4, uint64(len(v)))
+		for _, item := range v {
+			out = append(out, cborEncode(item)...)
+		}
+		return out
+	case []cborEntry:
+		out := head(5, uint64(len(v)))
+		for _, entry := range v {
+			out = append(out, cborEncode(entry.key)...)
+			out = append(out, cborEncode(entry.value)...)
+		}
+		return out
+	}
+	panic("unsupported cbor type")
+}
+
+// softwareAuthenticator is a passkey authenticator implemented in software
+type softwareAuthenticator struct {
+	rpID         string
+	origin       string
+	credentialID []byte
+	ecKey        *ecdsa.PrivateKey
+	edKey        ed25519.PrivateKey
+	signCount    uint32
+	userVerified bool
+	packed       bool // packed self attestation instead of "none"
+	userHandle   []byte
+}
+
+func newES256Authenticator(t *testing.T) *softwareAuthenticator {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("failed to generate key: %v", err)
+	}
+	return &softwareAuthenticator{rpID: testRPID, origin: testOrigin, credentialID: randomBytes(t, 16), ecKey: key, userVerified: true}
+}
+
+func newEdDSAAuthenticator(t *testing.T) *softwareAuthenticator {
+	t.Helper()
+	_, key, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("failed to generate key: %v", err)
+	}
+	return &softwareAuthenticator{rpID: testRPID, origin: testOrigin, credentialID: randomBytes(t, 32), edKey: key, userVerified: true, packed: true}
+}
+
+func randomBytes(t *testing.T, n int) []byte {
+	t.Helper()
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		t.Fatalf("failed to read random bytes: %v", err)
+	}
+	return b
+}
+
+func (a *softwareAuthenticator) coseKey() []byte {
+	if a.edKey != nil {
+		return cborEncode([]cborEntry{
+			{1, 1}, {3, int(utils.COSEAlgEdDSA)}, {-1, 6}, {-2, []byte(a.edKey.Public().(ed25519.PublicKey))},
+		})
+	}
+	point := a.ecKey.PublicKey
+	x := make([]byte, 32)
+	y := make([]byte, 32)
+	point.X.FillBytes(x)
+	point.Y.FillBytes(y)
+	return cborEncode([]cborEntry{
+		{1, 2}, {3, int(utils.COSEAlgES256)}, {-1, 1}, {-2, x}, {-3, y},
+	})
+}
+
+func (a *softwareAuthenticator) alg() int {
+	if a.edKey != nil {
+		return int(utils.COSEAlgEdDSA)
+	}
+	return int(utils.COSEAlgES256)
+}
+
+func (a *softwareAuthenticator) sign(t *testing.T, data []byte) []byte {
+	t.Helper()
+	if a.edKey != nil {
+		return ed25519.Sign(a.edKey, data)
+	}
+	digest := sha256.Sum256(data)
+	sig, err := ecdsa.SignASN1(rand.Reader, a.ecKey, digest[:])
+	if err != nil {
+		t.Fatalf("failed to sign: %v", err)
+	}
+	return sig
+}
+
+func (a *softwareAuthenticator) authData(attested bool) []byte {
+	rpIDHash := sha256.Sum256([]byte(a.rpID))
+	flags := utils.AuthenticatorFlagUserPresent
+	if a.userVerified {
+		flags |= utils.AuthenticatorFlagUserVerified
+	}
+	if attested {
+		flags |= utils.AuthenticatorFlagAttestedData
+	}
+
+	data := append([]byte(nil), rpIDHash[:]...)
+	data = append(data, flags)
+	data = binary.BigEndian.AppendUint32(data, a.signCount)
+	if attested {
+		data = append(data, make([]byte, 16)...) // AAGUID
+		data = binary.BigEndian.AppendUint16(data, uint16(len(a.credentialID)))
+		data = append(data, a.credentialID...)
+		data = append(data, a.coseKey()...)
+	}
+	return data
+}
+
+func (a *softwareAuthenticator) clientData(ceremonyType, challenge string) []byte {
+	data, _ := json.Marshal(map[string]interface{}{
+		"type":      ceremonyType,
+		"challenge": challenge,
+		"origin":    a.origin,
+	})
+	return data
+}
+
+// create answers navigator.credentials.create()
+func (a *softwareAuthenticator) create(t *testing.T, begin *models.WebAuthnRegistrationBegin) *models.WebAuthnRegistrationRequest {
+	t.Helper()
+	a.userHandle, _ = utils.DecodeWebAuthnBase64(begin.PublicKey.User.ID)
+
+	clientData := a.clientData(utils.WebAuthnTypeCreate, begin.PublicKey.Challenge)
+	authData := a.authData(true)
+
+	statement := []cborEntry{}
+	format := utils.AttestationFormatNone
+	if a.packed {
+		format = utils.AttestationFormatPacked
+		clientDataHash := sha256.Sum256(clientData)
+		sig := a.sign(t, append(append([]byte(nil), authData...), clientDataHash[:]...))
+		statement = []cborEntry{{"alg", a.alg()}, {"sig", sig}}
+	}
+
+	attestation := cborEncode([]cborEntry{
+		{"fmt", format}, {"attStmt", statement}, {"authData", authData},
+	})
+
+	req := &models.WebAuthnRegistrationRequest{SessionID: begin.SessionID, Name: "Test key"}
+	req.Credential.ID = utils.EncodeWebAuthnBase64(a.credentialID)
+	req.Credential.RawID = req.Credential.ID
+	req.Credential.Type = "public-key"
+	req.Credential.Response.ClientDataJSON = utils.EncodeWebAuthnBase64(clientData)
+	req.Credential.Response.AttestationObject = utils.EncodeWebAuthnBase64(attestation)
+	req.Credential.Response.Transports = []string{"internal"}
+	return req
+}
+
+// get answers navigator.credentials.get()
+func (a *softwareAuthenticator) get(t *testing.T, begin *models.WebAuthnLoginBegin) *models.WebAuthnLoginRequest {
+	t.Helper()
+	a.signCount++
+
+	clientData := a.clientData(utils.WebAuthnTypeGet, begin.PublicKey.Challenge)
+	authData := a.authData(false)
+	clientDataHash := sha256.Sum256(clientData)
+	sig := a.sign(t, append(append([]byte(nil), authData...), clientDataHash[:]...))
+
+	req := &models.WebAuthnLoginRequest{SessionID: begin.SessionID}
+	req.Credential.ID = utils.EncodeWebAuthnBase64(a.credentialID)
+	req.Credential.RawID = req.Credential.ID
+	req.Credential.Type = "public-key"
+	req.Credential.Response.ClientDataJSON = utils.EncodeWebAuthnBase64(clientData)
+	req.Credential.Response.AuthenticatorData = utils.EncodeWebAuthnBase64(authData)
+	req.Credential.Response.Signature = utils.EncodeWebAuthnBase64(sig)
+	req.Credential.Response.UserHandle = utils.EncodeWebAuthnBase64(a.userHandle)
+	return req
+}
+
+func newWebAuthnService(repo *fakeWebAuthnRepository) *webauthn.Service {
+	return webauthn.NewService(repo, utils.WebAuthnRelyingParty{
+		ID:      testRPID,
+		Name:    "Gin Collection",
+		Origins: []string{"http://localhost:5173"},
+	})
+}
+
+// register runs a registration ceremony for user 1
+func register(t *testing.T, service *webauthn.Service, authenticator *softwareAuthenticator) *models.WebAuthnCredential {
+	t.Helper()
+	ctx := context.Background()
+
+	begin, err := service.BeginRegistration(ctx, webauthn.Account{
+		SubjectType: models.TwoFactorSubjectUser,
+		SubjectID:   1,
+		Name:        "jane@example.com",
+	})
+	if err != nil {
+		t.Fatalf("BeginRegistration failed: %v", err)
+	}
+
+	credential, err := service.FinishRegistration(ctx, models.TwoFactorSubjectUser, 1, authenticator.create(t, begin))
+	if err != nil {
+		t.Fatalf("FinishRegistration failed: %v", err)
+	}
+	return credential
+}
+
+func TestWebAuthn_RegisterAndLogin(t *testing.T) {
+	tests := []struct {
+		name          string
+		authenticator func(*testing.T) *softwareAuthenticator
+		format        string
+	}{
+		{"ES256 none attestation", newES256Authenticator, utils.AttestationFormatNone},
+		{"EdDSA packed self attestation", newEdDSAAuthenticator, utils.AttestationFormatPacked},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			service := newWebAuthnService(newFakeWebAuthnRepository())
+			authenticator := tt.authenticator(t)
+
+			credential := register(t, service, authenticator)
+			if credential.AttestationFormat != tt.format {
+				t.Errorf("expected attestation format %s, got %s", tt.format, credential.AttestationFormat)
+			}
+			if credential.Name != "Test key" {
+				t.Errorf("expected name %q, got %q", "Test key", credential.Name)
+			}
+
+			// Discoverable login without a known user
+			begin, err := service.BeginLogin(ctx, models.TwoFactorSubjectUser, nil)
+			if err != nil {
+				t.Fatalf("BeginLogin failed: %v", err)
+			}
+			assertion, err := service.FinishLogin(ctx, models.TwoFactorSubjectUser, authenticator.get(t, begin))
+			if err != nil {
+				t.Fatalf("FinishLogin failed: %v", err)
+			}
+			if assertion.Credential.SubjectID != 1 || !assertion.UserVerified {
+				t.Errorf("unexpected assertion: subject %d, user verified %v", assertion.Credential.SubjectID, assertion.UserVerified)
+			}
+		})
+	}
+}
+
+func TestWebAuthn_ExcludesRegisteredCredentials(t *testing.T) {
+	ctx := context.Background()
+	service := newWebAuthnService(newFakeWebAuthnRepository())
+	authenticator := newES256Authenticator(t)
+	register(t, service, authenticator)
+
+	begin, err := service.BeginRegistration(ctx, webauthn.Account{SubjectType: models.TwoFactorSubjectUser, SubjectID: 1, Name: "jane@example.com"})
+	if err != nil {
+		t.Fatalf("BeginRegistration failed: %v", err)
+	}
+	if len(begin.PublicKey.ExcludeCredentials) != 1 {
+		t.Fatalf("expected 1 excluded credential, got %d", len(begin.PublicKey.ExcludeCredentials))
+	}
+
+	// Registering the same authenticator again is a conflict
+	if _, err := service.FinishRegistration(ctx, models.TwoFactorSubjectUser, 1, authenticator.create(t, begin)); err != errors.ErrConflict {
+		t.Errorf("expected ErrConflict, got %v", err)
+	}
+}
+
+func TestWebAuthn_RejectsInvalidRegistrations(t *testing.T) {
+	tests := []struct {
+		name    string
+		tamper  func(*softwareAuthenticator)
+		subject int64
+		want    error
+	}{
+		{"foreign origin", func(a *softwareAuthenticator) { a.origin = "https://evil.example.net" }, 1, errors.ErrPasskeyVerification},
+		{"plain http subdomain", func(a *softwareAuthenticator) { a.origin = "http://acme.gin.example.com" }, 1, errors.ErrPasskeyVerification},
+		{"wrong RP ID", func(a *softwareAuthenticator) { a.rpID = "example.net" }, 1, errors.ErrPasskeyVerification},
+		{"session of another user", func(a *softwareAuthenticator) {}, 2, errors.ErrInvalidToken},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			service := newWebAuthnService(newFakeWebAuthnRepository())
+			authenticator := newES256Authenticator(t)
+			tt.tamper(authenticator)
+
+			begin, err := service.BeginRegistration(ctx, webauthn.Account{SubjectType: models.TwoFactorSubjectUser, SubjectID: 1, Name: "jane@example.com"})
+			if err != nil {
+				t.Fatalf("BeginRegistration failed: %v", err)
+			}
+
+			if _, err := service.FinishRegistration(ctx, models.TwoFactorSubjectUser, tt.subject, authenticator.create(t, begin)); err != tt.want {
+				t.Errorf("expected %v, got %v", tt.want, err)
+			}
+		})
+	}
+}
+
+func TestWebAuthn_LoginChecks(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("session is single use", func(t *testing.T) {
+		service := newWebAuthnService(newFakeWebAuthnRepository())
+		authenticator := newES256Authenticator(t)
+		register(t, service, authenticator)
+
+		begin, _ := service.BeginLogin(ctx, models.TwoFactorSubjectUser, nil)
+		req := authenticator.get(t, begin)
+		if _, err := service.FinishLogin(ctx, models.TwoFactorSubjectUser, req); err != nil {
+			t.Fatalf("FinishLogin failed: %v", err)
+		}
+		if _, err := service.FinishLogin(ctx, models.TwoFactorSubjectUser, req); err != errors.ErrInvalidToken {
+			t.Errorf("expected replay to fail with ErrInvalidToken, got %v", err)
+		}
+
+		// Replaying the signed assertion in a new session fails the challenge check
+		next, _ := service.BeginLogin(ctx, models.TwoFactorSubjectUser, nil)
+		req.SessionID = next.SessionID
+		if _, err := service.FinishLogin(ctx, models.TwoFactorSubjectUser, req); err != errors.ErrPasskeyVerification {
+			t.Errorf("expected ErrPasskeyVerification, got %v", err)
+		}
+	})
+
+	t.Run("sign count must increase", func(t *testing.T) {
+		service := newWebAuthnService(newFakeWebAuthnRepository())
+		authenticator := newES256Authenticator(t)
+		authenticator.signCount = 10
+		register(t, service, authenticator)
+
+		// A clone still at the registered counter
+		authenticator.signCount = 9
+		begin, _ := service.BeginLogin(ctx, models.TwoFactorSubjectUser, nil)
+		if _, err := service.FinishLogin(ctx, models.TwoFactorSubjectUser, authenticator.get(t, begin)); err != errors.ErrPasskeyVerification {
+			t.Errorf("expected ErrPasskeyVerification for a stale counter, got %v", err)
+		}
+
+		begin, _ = service.BeginLogin(ctx, models.TwoFactorSubjectUser, nil)
+		if _, err := service.FinishLogin(ctx, models.TwoFactorSubjectUser, authenticator.get(t, begin)); err != nil {
+			t.Errorf("expected increasing counter to pass, got %v", err)
+		}
+	})
+
+	t.Run("tampered signature", func(t *testing.T) {
+		service := newWebAuthnService(newFakeWebAuthnRepository())
+		authenticator := newEdDSAAuthenticator(t)
+		register(t, service, authenticator)
+
+		begin, _ := service.BeginLogin(ctx, models.TwoFactorSubjectUser, nil)
+		req := authenticator.get(t, begin)
+		sig, _ := utils.DecodeWebAuthnBase64(req.Credential.Response.Signature)
+		sig[0] ^= 0xff
+		req.Credential.Response.Signature = utils.EncodeWebAuthnBase64(sig)
+
+		if _, err := service.FinishLogin(ctx, models.TwoFactorSubjectUser, req); err != errors.ErrPasskeyVerification {
+			t.Errorf("expected ErrPasskeyVerification, got %v", err)
+		}
+	})
+
+	t.Run("passkey of another subject", func(t *testing.T) {
+		service := newWebAuthnService(newFakeWebAuthnRepository())
+		authenticator := newES256Authenticator(t)
+		register(t, service, authenticator)
+
+		other := int64(2)
+		begin, _ := service.BeginLogin(ctx, models.TwoFactorSubjectUser, &other)
+		if len(begin.PublicKey.AllowCredentials) != 0 {
+			t.Errorf("expected no allowed credentials for user 2")
+		}
+		if _, err := service.FinishLogin(ctx, models.TwoFactorSubjectUser, authenticator.get(t, begin)); err != errors.ErrPasskeyVerification {
+			t.Errorf("expected ErrPasskeyVerification, got %v", err)
+		}
+
+		// User passkeys can't sign in platform admins
+		begin, _ = service.BeginLogin(ctx, models.TwoFactorSubjectPlatformAdmin, nil)
+		if _, err := service.FinishLogin(ctx, models.TwoFactorSubjectPlatformAdmin, authenticator.get(t, begin)); err != errors.ErrPasskeyVerification {
+			t.Errorf("expected ErrPasskeyVerification, got %v", err)
+		}
+	})
+
+	t.Run("user presence without verification", func(t *testing.T) {
+		service := newWebAuthnService(newFakeWebAuthnRepository())
+		authenticator := newES256Authenticator(t)
+		register(t, service, authenticator)
+
+		authenticator.userVerified = false
+		begin, _ := service.BeginLogin(ctx, models.TwoFactorSubjectUser, nil)
+		assertion, err := service.FinishLogin(ctx, models.TwoFactorSubjectUser, authenticator.get(t, begin))
+		if err != nil {
+			t.Fatalf("FinishLogin failed: %v", err)
+		}
+		if assertion.UserVerified {
+			t.Error("expected assertion without user verification")
+		}
+	})
+}
+
+func TestDecodeCBOR_RejectsMalformedInput(t *testing.T) {
+	tests := map[string][]byte{
+		"truncated byte string": {0x45, 0x01, 0x02},
+		"indefinite length":     {0x5f, 0x41, 0x01, 0xff},
+		"duplicate map key":     {0xa2, 0x01, 0x01, 0x01, 0x02},
+		"huge array length":     {0x9b, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff},
+	}
+
+	for name, data := range tests {
+		if _, _, err := utils.DecodeCBOR(data); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
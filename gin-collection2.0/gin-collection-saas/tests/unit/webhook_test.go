@@ -0,0 +1,467 @@
+package unit
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/yourusername/gin-collection-saas/internal/domain/errors"
+	"github.com/yourusername/gin-collection-saas/internal/domain/models"
+	"github.com/yourusername/gin-collection-saas/internal/infrastructure/billing"
+	"github.com/yourusername/gin-collection-saas/internal/infrastructure/external"
+	"github.com/yourusername/gin-collection-saas/internal/usecase/subscription"
+)
+
+// fakeSubscriptionRepository keeps subscriptions in memory
+type fakeSubscriptionRepository struct {
+	subscriptions map[int64]*models.Subscription
+}
+
+func newFakeSubscriptionRepository(subscriptions ...*models.Subscription) *fakeSubscriptionRepository {
+	repo := &fakeSubscriptionRepository{subscriptions: make(map[int64]*models.Subscription)}
+	for _, s := range subscriptions {
+		repo.Create(context.Background(), s)
+	}
+	return repo
+}
+
+func (r *fakeSubscriptionRepository) Create(ctx context.Context, subscription *models.Subscription) error {
+	subscription.ID = int64(len(r.subscriptions) + 1)
+	copied := *subscription
+	r.subscriptions[subscription.ID] = &copied
+	return nil
+}
+
+func (r *fakeSubscriptionRepository) GetByID(ctx context.Context, id int64) (*models.Subscription, error) {
+	subscription, ok := r.subscriptions[id]
+	if !ok {
+		return nil, errors.ErrNotFound
+	}
+	copied := *subscription
+	return &copied, nil
+}
+
+func (r *fakeSubscriptionRepository) GetByTenantID(ctx context.Context, tenantID int64) (*models.Subscription, error) {
+	var latest *models.Subscription
+	for _, subscription := range r.subscriptions {
+		if subscription.TenantID == tenantID && (latest == nil || subscription.ID > latest.ID) {
+			latest = subscription
+		}
+	}
+	if latest == nil {
+		return nil, errors.ErrNotFound
+	}
+	copied := *latest
+	return &copied, nil
+}
+
+func (r *fakeSubscriptionRepository) GetByProviderSubscriptionID(ctx context.Context, provider, subscriptionID string) (*models.Subscription, error) {
+	for _, subscription := range r.subscriptions {
+		if subscription.Provider == provider && subscription.ProviderSubscriptionID != nil && *subscription.ProviderSubscriptionID == subscriptionID {
+			copied := *subscription
+			return &copied, nil
+		}
+	}
+	return nil, errors.ErrNotFound
+}
+
+func (r *fakeSubscriptionRepository) GetByProviderCheckoutID(ctx context.Context, provider, checkoutID string) (*models.Subscription, error) {
+	for _, subscription := range r.subscriptions {
+		if subscription.Provider == provider && subscription.ProviderCheckoutID != nil && *subscription.ProviderCheckoutID == checkoutID {
+			copied := *subscription
+			return &copied, nil
+		}
+	}
+	return nil, errors.ErrNotFound
+}
+
+func (r *fakeSubscriptionRepository) Update(ctx context.Context, subscription *models.Subscription) error {
+	if _, ok := r.subscriptions[subscription.ID]; !ok {
+		return errors.ErrNotFound
+	}
+	copied := *subscription
+	r.subscriptions[subscription.ID] = &copied
+	return nil
+}
+
+func (r *fakeSubscriptionRepository) UpdateStatus(ctx context.Context, id int64, status models.SubscriptionStatus) error {
+	subscription, ok := r.subscriptions[id]
+	if !ok {
+		return errors.ErrNotFound
+	}
+	subscription.Status = status
+	return nil
+}
+
+func (r *fakeSubscriptionRepository) List(ctx context.Context, tenantID int64) ([]*models.Subscription, error) {
+	var subscriptions []*models.Subscription
+	for _, subscription := range r.subscriptions {
+		if subscription.TenantID == tenantID {
+			copied := *subscription
+			subscriptions = append(subscriptions, &copied)
+		}
+	}
+	return subscriptions, nil
+}
+
+func (r *fakeSubscriptionRepository) GetActiveSubscription(ctx context.Context, tenantID int64) (*models.Subscription, error) {
+	for _, subscription := range r.subscriptions {
+		if subscription.TenantID == tenantID && subscription.Status == models.SubscriptionStatusActive {
+			copied := *subscription
+			return &copied, nil
+		}
+	}
+	return nil, errors.ErrNotFound
+}
+
+func (r *fakeSubscriptionRepository) ListScheduledChanges(ctx context.Context, dueBefore time.Time) ([]*models.Subscription, error) {
+	var subscriptions []*models.Subscription
+	for _, subscription := range r.subscriptions {
+		if subscription.ScheduledPlanID != nil && !subscription.ScheduledChangeAt.After(dueBefore) {
+			copied := *subscription
+			subscriptions = append(subscriptions, &copied)
+		}
+	}
+	return subscriptions, nil
+}
+
+func (r *fakeSubscriptionRepository) ListCancellationsDue(ctx context.Context, dueBefore time.Time) ([]*models.Subscription, error) {
+	var subscriptions []*models.Subscription
+	for _, subscription := range r.subscriptions {
+		periodEnd := subscription.CurrentPeriodEnd
+		if periodEnd == nil {
+			periodEnd = subscription.NextBillingDate
+		}
+		if subscription.Status == models.SubscriptionStatusActive && subscription.CancelAtPeriodEnd && periodEnd != nil && !periodEnd.After(dueBefore) {
+			copied := *subscription
+			subscriptions = append(subscriptions, &copied)
+		}
+	}
+	return subscriptions, nil
+}
+
+func (r *fakeSubscriptionRepository) ListTrialsEndingBefore(ctx context.Context, before time.Time) ([]*models.Subscription, error) {
+	var subscriptions []*models.Subscription
+	for _, subscription := range r.subscriptions {
+		if subscription.Status == models.SubscriptionStatusTrialing && !subscription.TrialEndsAt.After(before) {
+			copied := *subscription
+			subscriptions = append(subscriptions, &copied)
+		}
+	}
+	return subscriptions, nil
+}
+
+func (r *fakeSubscriptionRepository) ListInDunning(ctx context.Context) ([]*models.Subscription, error) {
+	var subscriptions []*models.Subscription
+	for _, subscription := range r.subscriptions {
+		if subscription.InDunning() {
+			copied := *subscription
+			subscriptions = append(subscriptions, &copied)
+		}
+	}
+	return subscriptions, nil
+}
+
+// fakeWebhookEventRepository keeps received webhook events in memory
+type fakeWebhookEventRepository struct {
+	events []*models.WebhookEvent
+}
+
+func (r *fakeWebhookEventRepository) Create(ctx context.Context, event *models.WebhookEvent) error {
+	for _, known := range r.events {
+		if known.Provider == event.Provider && known.EventID == event.EventID {
+			return errors.ErrConflict
+		}
+	}
+	event.ID = int64(len(r.events) + 1)
+	copied := *event
+	r.events = append(r.events, &copied)
+	return nil
+}
+
+func (r *fakeWebhookEventRepository) GetByID(ctx context.Context, id int64) (*models.WebhookEvent, error) {
+	for _, event := range r.events {
+		if event.ID == id {
+			copied := *event
+			return &copied, nil
+		}
+	}
+	return nil, errors.ErrNotFound
+}
+
+func (r *fakeWebhookEventRepository) GetByEventID(ctx context.Context, provider, eventID string) (*models.WebhookEvent, error) {
+	for _, event := range r.events {
+		if event.Provider == provider && event.EventID == eventID {
+			copied := *event
+			return &copied, nil
+		}
+	}
+	return nil, errors.ErrNotFound
+}
+
+func (r *fakeWebhookEventRepository) Update(ctx context.Context, event *models.WebhookEvent) error {
+	for _, known := range r.events {
+		if known.ID == event.ID {
+			known.Status = event.Status
+			known.Attempts = event.Attempts
+			known.Error = event.Error
+			known.ProcessedAt = event.ProcessedAt
+			return nil
+		}
+	}
+	return errors.ErrNotFound
+}
+
+func (r *fakeWebhookEventRepository) LatestResourceUpdate(ctx context.Context, provider, resourceID string) (*time.Time, error) {
+	var latest *time.Time
+	for _, event := range r.events {
+		if event.Provider != provider || event.ResourceID != resourceID || event.Status != models.WebhookEventStatusProcessed || event.ResourceUpdatedAt == nil {
+			continue
+		}
+		if latest == nil || event.ResourceUpdatedAt.After(*latest) {
+			latest = event.ResourceUpdatedAt
+		}
+	}
+	return latest, nil
+}
+
+func (r *fakeWebhookEventRepository) List(ctx context.Context, filter *models.WebhookEventFilter) ([]*models.WebhookEvent, int, error) {
+	var events []*models.WebhookEvent
+	for i := len(r.events) - 1; i >= 0; i-- {
+		event := r.events[i]
+		if filter.Status != "" && event.Status != filter.Status {
+			continue
+		}
+		copied := *event
+		copied.Payload = nil
+		events = append(events, &copied)
+	}
+	return events, len(events), nil
+}
+
+// stubSignedPayPalProvider parses PayPal events but accepts deliveries
+// carrying the expected signature instead of asking PayPal
+type stubSignedPayPalProvider struct {
+	*billing.PayPalProvider
+	signature string
+}
+
+func (p *stubSignedPayPalProvider) VerifyWebhook(ctx context.Context, headers http.Header, body []byte) error {
+	if headers.Get("PAYPAL-TRANSMISSION-SIG") != p.signature {
+		return billing.ErrInvalidSignature
+	}
+	return nil
+}
+
+const testWebhookSignature = "signed-by-paypal"
+
+type webhookFixture struct {
+	service       *subscription.Service
+	tenants       *fakeTenantRepository
+	subscriptions *fakeSubscriptionRepository
+	events        *fakeWebhookEventRepository
+	tenant        *models.Tenant
+}
+
+func newWebhookFixture(t *testing.T) *webhookFixture {
+	t.Helper()
+
+	paypalID := "I-SUBSCRIPTION1"
+	f := &webhookFixture{
+		tenant: &models.Tenant{ID: 1, Name: "Gin Bar", Subdomain: "ginbar", Tier: models.TierPro, Status: models.TenantStatusActive},
+		subscriptions: newFakeSubscriptionRepository(&models.Subscription{
+			TenantID:               1,
+			PlanID:                 "PLAN_PRO_MONTHLY",
+			Status:                 models.SubscriptionStatusActive,
+			BillingCycle:           models.BillingCycleMonthly,
+			Provider:               models.BillingProviderPayPal,
+			ProviderSubscriptionID: &paypalID,
+		}),
+		events: &fakeWebhookEventRepository{},
+	}
+	f.tenants = newFakeTenantRepository(f.tenant)
+	provider := &stubSignedPayPalProvider{
+		PayPalProvider: billing.NewPayPalProvider(nil, testPayPalPlans),
+		signature:      testWebhookSignature,
+	}
+	f.service = subscription.NewService(f.subscriptions, f.tenants, nil, nil, nil, billing.NewRegistry(models.BillingProviderPayPal, provider), "https://app.example.com")
+	f.service.SetWebhookEventRepo(f.events)
+	return f
+}
+
+func (f *webhookFixture) deliver(eventID, eventType, resourceID string, updatedAt time.Time) error {
+	body := fmt.Sprintf(`{"id":%q,"event_type":%q,"create_time":%q,"resource":{"id":%q,"status":"X","status_update_time":%q}}`,
+		eventID, eventType, time.Now().UTC().Format(time.RFC3339), resourceID, updatedAt.UTC().Format(time.RFC3339))
+	return f.service.ProcessWebhook(context.Background(), models.BillingProviderPayPal, paypalHeaders(eventID, testWebhookSignature), []byte(body))
+}
+
+// paypalHeaders returns the transmission headers of a PayPal delivery
+func paypalHeaders(transmissionID, signature string) http.Header {
+	headers := http.Header{}
+	headers.Set("PAYPAL-TRANSMISSION-ID", "tx-"+transmissionID)
+	headers.Set("PAYPAL-TRANSMISSION-SIG", signature)
+	return headers
+}
+
+func (f *webhookFixture) subscription(t *testing.T) *models.Subscription {
+	t.Helper()
+	s, err := f.subscriptions.GetByProviderSubscriptionID(context.Background(), models.BillingProviderPayPal, "I-SUBSCRIPTION1")
+	if err != nil {
+		t.Fatalf("subscription missing: %v", err)
+	}
+	return s
+}
+
+func TestWebhookRejectsInvalidSignature(t *testing.T) {
+	f := newWebhookFixture(t)
+
+	body := []byte(`{"id":"WH-1","event_type":"BILLING.SUBSCRIPTION.CANCELLED","resource":{"id":"I-SUBSCRIPTION1"}}`)
+	if err := f.service.ProcessWebhook(context.Background(), models.BillingProviderPayPal, paypalHeaders("1", "forged"), body); err != errors.ErrInvalidWebhookSignature {
+		t.Fatalf("expected ErrInvalidWebhookSignature, got %v", err)
+	}
+
+	if len(f.events.events) != 0 {
+		t.Errorf("expected rejected delivery not to be stored, got %d events", len(f.events.events))
+	}
+	if f.subscription(t).Status != models.SubscriptionStatusActive {
+		t.Errorf("expected subscription to be unchanged")
+	}
+}
+
+func TestWebhookEventIsProcessedOnce(t *testing.T) {
+	f := newWebhookFixture(t)
+	now := time.Now()
+
+	if err := f.deliver("WH-1", "BILLING.SUBSCRIPTION.CANCELLED", "I-SUBSCRIPTION1", now); err != nil {
+		t.Fatalf("ProcessWebhook failed: %v", err)
+	}
+	if f.tenants.tenants[1].Tier != models.TierFree {
+		t.Fatalf("expected tenant to be downgraded, got %s", f.tenants.tenants[1].Tier)
+	}
+
+	// A replayed delivery must not downgrade the tenant again
+	f.tenants.tenants[1].Tier = models.TierPro
+	if err := f.deliver("WH-1", "BILLING.SUBSCRIPTION.CANCELLED", "I-SUBSCRIPTION1", now); err != nil {
+		t.Fatalf("expected redelivery to be acknowledged, got %v", err)
+	}
+	if f.tenants.tenants[1].Tier != models.TierPro {
+		t.Errorf("expected redelivered event to be ignored")
+	}
+
+	if len(f.events.events) != 1 {
+		t.Fatalf("expected 1 stored event, got %d", len(f.events.events))
+	}
+	event := f.events.events[0]
+	if event.Status != models.WebhookEventStatusProcessed || event.Attempts != 1 || event.ProcessedAt == nil {
+		t.Errorf("unexpected stored event: status %s, attempts %d", event.Status, event.Attempts)
+	}
+}
+
+func TestWebhookSkipsOutdatedEvents(t *testing.T) {
+	f := newWebhookFixture(t)
+	now := time.Now()
+
+	if err := f.deliver("WH-2", "BILLING.SUBSCRIPTION.SUSPENDED", "I-SUBSCRIPTION1", now); err != nil {
+		t.Fatalf("ProcessWebhook failed: %v", err)
+	}
+
+	// The cancellation happened before the suspension but arrives later
+	if err := f.deliver("WH-1", "BILLING.SUBSCRIPTION.CANCELLED", "I-SUBSCRIPTION1", now.Add(-time.Hour)); err != nil {
+		t.Fatalf("expected outdated event to be acknowledged, got %v", err)
+	}
+	if status := f.subscription(t).Status; status != models.SubscriptionStatusSuspended {
+		t.Errorf("expected subscription to stay suspended, got %s", status)
+	}
+
+	skipped, _ := f.events.GetByEventID(context.Background(), models.BillingProviderPayPal, "WH-1")
+	if skipped.Status != models.WebhookEventStatusSkipped || skipped.Error == nil {
+		t.Errorf("expected outdated event to be skipped with a reason, got %s", skipped.Status)
+	}
+
+	// Newer events of the resource are processed
+	if err := f.deliver("WH-3", "BILLING.SUBSCRIPTION.CANCELLED", "I-SUBSCRIPTION1", now.Add(time.Minute)); err != nil {
+		t.Fatalf("ProcessWebhook failed: %v", err)
+	}
+	if status := f.subscription(t).Status; status != models.SubscriptionStatusCancelled {
+		t.Errorf("expected subscription to be cancelled, got %s", status)
+	}
+}
+
+func TestWebhookFailedEventIsRetried(t *testing.T) {
+	f := newWebhookFixture(t)
+	now := time.Now()
+
+	if err := f.deliver("WH-1", "BILLING.SUBSCRIPTION.SUSPENDED", "I-UNKNOWN", now); err == nil {
+		t.Fatal("expected event of unknown subscription to fail")
+	}
+	failed, _ := f.events.GetByEventID(context.Background(), models.BillingProviderPayPal, "WH-1")
+	if failed.Status != models.WebhookEventStatusFailed || failed.Error == nil {
+		t.Fatalf("expected failed event with error, got %s", failed.Status)
+	}
+
+	// PayPal redelivers until the event is processed
+	paypalID := "I-UNKNOWN"
+	f.subscriptions.Create(context.Background(), &models.Subscription{TenantID: 1, Status: models.SubscriptionStatusActive,
+		Provider: models.BillingProviderPayPal, ProviderSubscriptionID: &paypalID})
+	if err := f.deliver("WH-1", "BILLING.SUBSCRIPTION.SUSPENDED", "I-UNKNOWN", now); err != nil {
+		t.Fatalf("expected redelivery to succeed, got %v", err)
+	}
+
+	processed, _ := f.events.GetByEventID(context.Background(), models.BillingProviderPayPal, "WH-1")
+	if processed.Status != models.WebhookEventStatusProcessed || processed.Attempts != 2 || processed.Error != nil {
+		t.Errorf("expected processed event after 2 attempts, got %s after %d", processed.Status, processed.Attempts)
+	}
+}
+
+func TestAdminReplaysWebhookEvent(t *testing.T) {
+	f := newWebhookFixture(t)
+	ctx := context.Background()
+
+	if err := f.deliver("WH-1", "BILLING.SUBSCRIPTION.CANCELLED", "I-SUBSCRIPTION1", time.Now()); err != nil {
+		t.Fatalf("ProcessWebhook failed: %v", err)
+	}
+
+	events, total, err := f.service.ListWebhookEvents(ctx, &models.WebhookEventFilter{Limit: 50})
+	if err != nil || total != 1 {
+		t.Fatalf("expected 1 listed event, got %d (%v)", total, err)
+	}
+	if events[0].Payload != nil {
+		t.Errorf("expected list without payloads")
+	}
+
+	inspected, err := f.service.GetWebhookEvent(ctx, events[0].ID)
+	if err != nil || len(inspected.Payload) == 0 {
+		t.Fatalf("expected event with payload, got %v", err)
+	}
+
+	f.tenants.tenants[1].Tier = models.TierPro
+	replayed, err := f.service.ReplayWebhookEvent(ctx, events[0].ID)
+	if err != nil {
+		t.Fatalf("ReplayWebhookEvent failed: %v", err)
+	}
+	if replayed.Attempts != 2 || replayed.Status != models.WebhookEventStatusProcessed {
+		t.Errorf("expected second processed attempt, got %s after %d", replayed.Status, replayed.Attempts)
+	}
+	if f.tenants.tenants[1].Tier != models.TierFree {
+		t.Errorf("expected replay to apply the event")
+	}
+
+	if _, err := f.service.ReplayWebhookEvent(ctx, 999); err != errors.ErrNotFound {
+		t.Errorf("expected ErrNotFound for unknown event, got %v", err)
+	}
+}
+
+func TestPayPalRejectsUnsignedWebhook(t *testing.T) {
+	client := external.NewPayPalClient(&external.PayPalConfig{Mode: "sandbox", WebhookID: "WH-CONFIG"})
+	if err := client.VerifyWebhookSignature(&external.PayPalWebhookSignature{}, []byte(`{}`)); err != external.ErrWebhookSignature {
+		t.Errorf("expected ErrWebhookSignature without headers, got %v", err)
+	}
+
+	unconfigured := external.NewPayPalClient(&external.PayPalConfig{Mode: "sandbox"})
+	sig := &external.PayPalWebhookSignature{TransmissionID: "1", TransmissionTime: "t", TransmissionSig: "s", CertURL: "u", AuthAlgo: "a"}
+	if err := unconfigured.VerifyWebhookSignature(sig, []byte(`{}`)); err == nil {
+		t.Error("expected verification to fail without a webhook ID")
+	}
+}